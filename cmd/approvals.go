@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/application/commands"
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/grpc"
+	"go-clean-ddd-es-template/pkg/approval"
+)
+
+// newApprovalWorkflow creates the workflow requiring a second admin's approval to delete users
+// and purge the dead letter queue
+func newApprovalWorkflow(cfg *config.Config, userService *services.UserService, eventConsumer *consumers.EventConsumerWrapper, logger approval.Logger) *approval.Workflow {
+	workflow := approval.NewWorkflow(cfg.Approvals.TTL, cfg.Approvals.AuditSize, logger, nil)
+
+	workflow.Register(commands.DeleteUserCommandName, func(ctx context.Context, request approval.Request) error {
+		userID := request.Args["user_id"]
+		if userID == "" {
+			return fmt.Errorf("%s requires a user_id argument", request.Action)
+		}
+		_, err := userService.DeleteUser(ctx, dto.DeleteUserCommand{UserID: userID})
+		return err
+	})
+	workflow.Register(grpc.DLQPurgeAction, func(ctx context.Context, request approval.Request) error {
+		_, err := eventConsumer.PurgeFailedEvents(ctx)
+		return err
+	})
+
+	if cfg.Approvals.WebhookURL != "" {
+		workflow.AddNotifier(approval.NewWebhookNotifier(cfg.Approvals.WebhookURL, nil))
+	}
+	return workflow
+}
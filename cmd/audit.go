@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/approval"
+	"go-clean-ddd-es-template/pkg/audit"
+)
+
+// newAuditStore creates the store of the audit log, in the write database
+func newAuditStore(cfg *config.Config) (audit.Store, error) {
+	writeDB, err := databases.CreateDatabase(&cfg.WriteDatabase)
+	if err != nil {
+		return nil, err
+	}
+	return infraRepos.NewPostgresAuditStore(writeDB.GetDB()), nil
+}
+
+// newAuditEnforcer creates the enforcer of the retention of audit records, archiving them to
+// object storage before deletion when configured
+func newAuditEnforcer(cfg *config.Config, store audit.Store, logger audit.Logger) (*audit.Enforcer, error) {
+	enforcer := audit.NewEnforcer(store, cfg.AuditLog.Retention, cfg.AuditLog.BatchSize, logger, nil)
+	if cfg.AuditLog.ExportBeforeDelete {
+		objects, err := provideStorage(cfg)
+		if err != nil {
+			return nil, err
+		}
+		enforcer.AddExporter(audit.NewStorageExporter(objects, cfg.AuditLog.ExportPrefix))
+	}
+	return enforcer, nil
+}
+
+// recordApprovals records the requests, decisions and executions of sensitive actions as admin
+// actions. Their subject is the user acted on, or the approval request for actions on no user.
+func recordApprovals(workflow *approval.Workflow, log *audit.Log) {
+	workflow.AddNotifier(approval.NotifierFunc(func(ctx context.Context, event approval.Event) error {
+		request := event.Request
+		subject := request.Args["user_id"]
+		if subject == "" {
+			subject = request.ID
+		}
+		details := map[string]string{"approval_id": request.ID, "action": request.Action}
+		for name, value := range map[string]string{"reason": request.Reason, "comment": request.Comment, "error": request.Error} {
+			if value != "" {
+				details[name] = value
+			}
+		}
+		return log.Record(ctx, audit.Record{
+			Category:   audit.CategoryAdmin,
+			Action:     "approval." + event.Type,
+			Actor:      event.Actor,
+			Subject:    subject,
+			Details:    details,
+			OccurredAt: event.At,
+		})
+	}))
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"sort"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+)
+
+// projectedEventTypes are the event types the event consumer has handlers for
+var projectedEventTypes = []string{
+	"user.created", "user.updated", "user.deleted", "user.preferences_updated", "user.email_verified",
+	"user.avatar_updated",
+	"product.created", "product.updated", "product.deleted",
+}
+
+// registerEventHandlers registers the handlers enabled for this deployment and logs the active set
+func registerEventHandlers(eventConsumer *consumers.EventConsumerWrapper, components config.ComponentsConfig, handlers map[string]consumers.LegacyEventHandler, logger consumers.Logger) {
+	var active, disabled []string
+	for eventType, handler := range handlers {
+		if !components.HandlerEnabled(eventType) {
+			disabled = append(disabled, eventType)
+			continue
+		}
+		eventConsumer.RegisterEventHandler(eventType, handler)
+		active = append(active, eventType)
+	}
+	sort.Strings(active)
+	sort.Strings(disabled)
+
+	logger.Info("Active event handlers: %v", active)
+	if len(disabled) > 0 {
+		logger.Info("Disabled event handlers: %v", disabled)
+	}
+}
+
+// activeEventTypes returns the projected event types enabled for this deployment
+func activeEventTypes(cfg *config.Config) []string {
+	var active []string
+	for _, eventType := range projectedEventTypes {
+		if cfg.Components.HandlerEnabled(eventType) {
+			active = append(active, eventType)
+		}
+	}
+	return active
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+)
+
+// configOptions holds the flags of the config commands
+type configOptions struct {
+	format string
+}
+
+var configFlags configOptions
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Configuration tooling",
+}
+
+var configDocsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Print the inventory of configuration settings",
+	Long: `Print every environment variable read by the configuration with its type, default
+value and description, generated from the struct tags of the configuration. JSON output
+is meant for tooling, table output for humans. The effective configuration of a running
+instance is served, redacted, at GET /admin/config.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printConfigDocs(&configFlags); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	configDocsCmd.Flags().StringVar(&configFlags.format, "format", "json", "Output format: json or table")
+
+	configCmd.AddCommand(configDocsCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// printConfigDocs prints the settings inventory in the requested format
+func printConfigDocs(options *configOptions) error {
+	inventory := config.Inventory()
+
+	switch options.format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(inventory)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENV\tTYPE\tDEFAULT\tDESCRIPTION")
+		for _, setting := range inventory {
+			description := setting.Description
+			if setting.Sensitive {
+				description = "(sensitive) " + description
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", setting.Env, setting.Type, setting.Default, description)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown format %q, expected json or table", options.format)
+}
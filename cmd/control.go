@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/control"
+	"go-clean-ddd-es-template/pkg/featureflags"
+)
+
+// controlOptions holds the flags of the control commands
+type controlOptions struct {
+	url    string
+	token  string
+	issuer string
+}
+
+var controlFlags controlOptions
+
+var controlCmd = &cobra.Command{
+	Use:   "control",
+	Short: "Broadcast runtime commands to all consumer instances",
+}
+
+var controlSendCmd = &cobra.Command{
+	Use:   "send <type> [key=value...]",
+	Short: "Send a command on the control channel",
+	Long: `Send a signed command through the admin API of a running instance. The command
+is broadcast on the control topic and applied by every consumer instance:
+
+  pause_topic topic=<topic>     stop fetching messages of a topic
+  resume_topic topic=<topic>    resume a paused topic
+  flush_caches [tag=<tag>]      drop cached responses, all of them without a tag
+  reload_handlers [flag=bool]   reload feature flags from the configuration, with overrides
+  promote instance=<host>       resume the handlers of a standby instance
+  demote instance=<host>        put an instance on standby, pausing its handlers`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := sendControlCommand(&controlFlags, args[0], args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var controlAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the control commands received by an instance",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := showControlAudit(&controlFlags); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	cfg := config.Load()
+	issuer := os.Getenv("USER")
+	for _, c := range []*cobra.Command{controlSendCmd, controlAuditCmd} {
+		c.Flags().StringVar(&controlFlags.url, "url", "http://localhost:8080", "Base URL of the HTTP gateway")
+		c.Flags().StringVar(&controlFlags.token, "token", cfg.Admin.Token, "Admin API token")
+		controlCmd.AddCommand(c)
+	}
+	controlSendCmd.Flags().StringVar(&controlFlags.issuer, "issuer", issuer, "Issuer recorded in the audit trail")
+
+	rootCmd.AddCommand(controlCmd)
+}
+
+// sendControlCommand sends a command with key=value arguments to the admin API
+func sendControlCommand(options *controlOptions, commandType string, pairs []string) error {
+	args := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid argument %q, expected key=value", pair)
+		}
+		args[key] = value
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"type":   commandType,
+		"args":   args,
+		"issuer": options.issuer,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := adminRequest(options.url, options.token, http.MethodPost, "/admin/control/commands", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var command control.Command
+	if err := json.NewDecoder(resp.Body).Decode(&command); err != nil {
+		return fmt.Errorf("failed to decode command: %w", err)
+	}
+
+	fmt.Printf("Sent %s command %s\n", command.Type, command.ID)
+	return nil
+}
+
+// showControlAudit prints the control audit trail of an instance
+func showControlAudit(options *controlOptions) error {
+	resp, err := adminRequest(options.url, options.token, http.MethodGet, "/admin/control/audit", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var entries []control.AuditEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return fmt.Errorf("failed to decode audit trail: %w", err)
+	}
+
+	for _, entry := range entries {
+		line := fmt.Sprintf("%s  %-8s  %-15s  %s  by %s", entry.ReceivedAt.Format(time.RFC3339), entry.Outcome, entry.Type, entry.CommandID, entry.Issuer)
+		if len(entry.Args) > 0 {
+			line += fmt.Sprintf("  %v", entry.Args)
+		}
+		if entry.Error != "" {
+			line += "  error: " + entry.Error
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// startControlChannel subscribes the instance to the control topic and returns the producer
+// for issuing commands and the audit log of received commands
+func startControlChannel(cfg *config.Config, eventConsumer *consumers.EventConsumerWrapper, logger control.Logger) (*control.Producer, *control.AuditLog, error) {
+	broker, err := messagebroker.NewMessageBrokerFactory().CreateMessageBroker(&cfg.MessageBroker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer := control.NewSigner([]byte(cfg.Control.SigningKey))
+	audit := control.NewAuditLog(cfg.Control.AuditSize, logger)
+	channel := control.NewChannel(signer, cfg.Control.MaxAge, audit, nil)
+
+	channel.Register(control.CommandPauseTopic, func(ctx context.Context, command control.Command) error {
+		eventConsumer.PauseTopic(command.Args["topic"])
+		return nil
+	})
+	channel.Register(control.CommandResumeTopic, func(ctx context.Context, command control.Command) error {
+		eventConsumer.ResumeTopic(command.Args["topic"])
+		return nil
+	})
+	channel.Register(control.CommandFlushCaches, func(ctx context.Context, command control.Command) error {
+		responseCache := provideResponseCache(cfg)
+		if responseCache == nil {
+			return nil
+		}
+		if tag := command.Args["tag"]; tag != "" {
+			responseCache.InvalidateTag(tag)
+		} else {
+			responseCache.Clear()
+		}
+		return nil
+	})
+	// Promote or demote the instance a command names; commands naming other instances are ignored
+	instance, _ := os.Hostname()
+	channel.Register(control.CommandPromote, func(ctx context.Context, command control.Command) error {
+		if command.Args["instance"] == instance {
+			eventConsumer.SetStandby(false)
+		}
+		return nil
+	})
+	channel.Register(control.CommandDemote, func(ctx context.Context, command control.Command) error {
+		if command.Args["instance"] == instance {
+			eventConsumer.SetStandby(true)
+		}
+		return nil
+	})
+	channel.Register(control.CommandReloadHandlers, func(ctx context.Context, command control.Command) error {
+		flags := featureflags.New(config.Load().FeatureFlags)
+		for name, value := range command.Args {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q of flag %s", value, name)
+			}
+			flags.Set(name, enabled)
+		}
+		featureflags.SetGlobal(flags)
+		return nil
+	})
+
+	// Rejected and failed commands are recorded in the audit log by the channel
+	if err := broker.Subscribe(cfg.Control.Topic, func(message []byte) {
+		channel.Handle(context.Background(), message)
+	}); err != nil {
+		return nil, nil, fmt.Errorf("failed to subscribe to control topic: %w", err)
+	}
+
+	return control.NewProducer(broker, cfg.Control.Topic, signer, nil), audit, nil
+}
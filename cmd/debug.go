@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/pkg/debugconsole"
+	"go-clean-ddd-es-template/pkg/featureflags"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+var debugSocketPath string
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Debugging tools for a running instance",
+}
+
+var debugConsoleCmd = &cobra.Command{
+	Use:   "console [command]",
+	Short: "Connect to the debug console of a running process",
+	Long: `Connect to the debug console of a running process over its unix socket.
+Without arguments an interactive session is started; otherwise the given
+command is executed once and the output printed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runDebugConsole(args)
+	},
+}
+
+func init() {
+	debugConsoleCmd.Flags().StringVar(&debugSocketPath, "socket", config.Load().Debug.SocketPath, "Path of the debug console unix socket")
+	debugCmd.AddCommand(debugConsoleCmd)
+	rootCmd.AddCommand(debugCmd)
+}
+
+func runDebugConsole(args []string) {
+	client, err := debugconsole.Dial(debugSocketPath, 5*time.Second)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	// One-shot mode
+	if len(args) > 0 {
+		output, err := client.Send(strings.Join(args, " "))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(output)
+		return
+	}
+
+	// Interactive mode
+	fmt.Printf("Connected to %s. Type 'help' for commands, 'exit' to quit.\n", debugSocketPath)
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("debug> ")
+		if !scanner.Scan() {
+			return
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "exit", "quit":
+			return
+		}
+
+		output, err := client.Send(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return
+		}
+		fmt.Println(output)
+	}
+}
+
+// startDebugConsole serves the debug console for the running process
+func startDebugConsole(ctx context.Context, cfg *config.Config, eventConsumer *consumers.EventConsumerWrapper, logger debugconsole.Logger) error {
+	server := debugconsole.NewServer(cfg.Debug.SocketPath, logger)
+
+	server.Register("consumer-stats", "consumer-stats - show consumer worker pool metrics and lag", func(ctx context.Context, args []string) (interface{}, error) {
+		return map[string]interface{}{
+			"consumer_group": eventConsumer.ConsumerGroup(),
+			"metrics":        eventConsumer.GetMetrics(),
+			"lag":            eventConsumer.ConsumerLag(),
+			"queue_depth":    eventConsumer.QueueDepth(),
+			"deferred":       eventConsumer.DeferredEvents(),
+			"lanes":          eventConsumer.LaneStats(),
+		}, nil
+	})
+
+	server.Register("breakers", "breakers - dump circuit breaker states", func(ctx context.Context, args []string) (interface{}, error) {
+		snapshot := resilience.DefaultRegistry().Snapshot()
+		states := make(map[string]interface{}, len(snapshot))
+		for name, stats := range snapshot {
+			states[name] = map[string]interface{}{
+				"state":             stats.State.String(),
+				"failures":          stats.Failures,
+				"total_requests":    stats.TotalRequests,
+				"total_failures":    stats.TotalFailures,
+				"last_failure":      stats.LastFailure,
+				"last_state_change": stats.LastStateChange,
+			}
+		}
+		return states, nil
+	})
+
+	server.Register("dlq-peek", "dlq-peek [limit] - show the oldest dead letter queue entries", func(ctx context.Context, args []string) (interface{}, error) {
+		limit := 10
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid limit: %s", args[0])
+			}
+			limit = n
+		}
+		return eventConsumer.ListFailedEvents(ctx, limit, 0)
+	})
+
+	server.Register("flags", "flags - list feature flags", func(ctx context.Context, args []string) (interface{}, error) {
+		return featureflags.Global().All(), nil
+	})
+
+	server.Register("flag", "flag <name> [on|off] - toggle or set a feature flag", func(ctx context.Context, args []string) (interface{}, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("usage: flag <name> [on|off]")
+		}
+
+		name := args[0]
+		if len(args) == 1 {
+			return map[string]bool{name: featureflags.Global().Toggle(name)}, nil
+		}
+
+		switch args[1] {
+		case "on", "true":
+			featureflags.Global().Set(name, true)
+		case "off", "false":
+			featureflags.Global().Set(name, false)
+		default:
+			return nil, fmt.Errorf("invalid flag value: %s", args[1])
+		}
+		return map[string]bool{name: featureflags.Global().Enabled(name)}, nil
+	})
+
+	return server.Start(ctx)
+}
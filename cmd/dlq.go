@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/dataio"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// dlqOptions holds the flags of the dlq commands
+type dlqOptions struct {
+	url       string
+	token     string
+	file      string
+	format    string
+	eventType string
+	topic     string
+	since     string
+	until     string
+}
+
+var dlqFlags dlqOptions
+
+var dlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "Dead letter queue tools for a running instance",
+}
+
+var dlqExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export dead letter queue entries to CSV or JSONL",
+	Long: `Export dead letter queue entries of a running instance through the admin API.
+Entries are streamed to the file and can be filtered by --event-type, --topic and
+the RFC 3339 --since/--until window.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := exportDLQ(&dlqFlags); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var dlqImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import previously exported dead letter queue entries for reprocessing",
+	Long: `Import a CSV or JSONL dead letter queue export into a running instance through
+the admin API, e.g. to reprocess failed events in another environment. Imported
+entries get their attempts reset; entries already queued are skipped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := importDLQ(&dlqFlags); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	cfg := config.Load()
+	for _, c := range []*cobra.Command{dlqExportCmd, dlqImportCmd} {
+		c.Flags().StringVar(&dlqFlags.url, "url", "http://localhost:8080", "Base URL of the HTTP gateway")
+		c.Flags().StringVar(&dlqFlags.token, "token", cfg.Admin.Token, "Admin API token")
+		c.Flags().StringVarP(&dlqFlags.file, "file", "f", "", "Path of the CSV or JSONL file")
+		c.Flags().StringVar(&dlqFlags.format, "format", "", "File format: csv or jsonl (defaults to the file extension)")
+		c.MarkFlagRequired("file")
+		dlqCmd.AddCommand(c)
+	}
+
+	dlqExportCmd.Flags().StringVar(&dlqFlags.eventType, "event-type", "", "Only export entries of this event type")
+	dlqExportCmd.Flags().StringVar(&dlqFlags.topic, "topic", "", "Only export entries from this topic")
+	dlqExportCmd.Flags().StringVar(&dlqFlags.since, "since", "", "Only export entries that failed at or after this RFC 3339 time")
+	dlqExportCmd.Flags().StringVar(&dlqFlags.until, "until", "", "Only export entries that failed before this RFC 3339 time")
+
+	rootCmd.AddCommand(dlqCmd)
+}
+
+// exportDLQ streams the dead letter queue export of the admin API into a file
+func exportDLQ(options *dlqOptions) error {
+	format, err := dataio.ParseFormat(options.format, options.file)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"format": {string(format)}}
+	for name, value := range map[string]string{
+		"event_type": options.eventType,
+		"topic":      options.topic,
+		"since":      options.since,
+		"until":      options.until,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	resp, err := adminRequest(options.url, options.token, http.MethodGet, "/admin/dlq/export?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	file, err := os.Create(options.file)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	// Count records while copying to report how many entries were exported
+	reader, err := dataio.NewReader(io.TeeReader(resp.Body, file), format)
+	if err != nil {
+		return err
+	}
+	exported := 0
+	for {
+		if _, err := reader.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read export: %w", err)
+		}
+		exported++
+	}
+
+	fmt.Printf("Exported %d dead letter queue entries to %s\n", exported, options.file)
+	return nil
+}
+
+// importDLQ uploads a dead letter queue export to the admin API
+func importDLQ(options *dlqOptions) error {
+	format, err := dataio.ParseFormat(options.format, options.file)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(options.file)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer file.Close()
+
+	resp, err := adminRequest(options.url, options.token, http.MethodPost, "/admin/dlq/import?format="+string(format), file)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result resilience.DLQImportResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode import result: %w", err)
+	}
+
+	fmt.Printf("Imported %d dead letter queue entries, skipped %d already queued\n", result.Imported, result.Skipped)
+	return nil
+}
+
+// adminRequest sends an authenticated admin API request and turns error responses into errors
+func adminRequest(baseURL, token, method, path string, body io.Reader) (*http.Response, error) {
+	if token == "" {
+		return nil, fmt.Errorf("admin token is required (set ADMIN_API_TOKEN or --token)")
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(baseURL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("admin API request failed: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Message == "" {
+			return nil, fmt.Errorf("admin API returned %s", resp.Status)
+		}
+		return nil, fmt.Errorf("admin API returned %s: %s", resp.Status, apiErr.Message)
+	}
+
+	return resp, nil
+}
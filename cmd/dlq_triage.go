@@ -0,0 +1,598 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/grpc"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// dlqTriageOptions holds the flags of the dlq triage commands
+type dlqTriageOptions struct {
+	limit  int
+	offset int
+	all    bool
+	yes    bool
+	reason string
+}
+
+var dlqTriageFlags dlqTriageOptions
+
+var dlqListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List dead letter queue entries",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newDLQClient(&dlqFlags)
+		page, err := client.list(dlqFlags.filter(), dlqTriageFlags.limit, dlqTriageFlags.offset)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		printDLQPage(os.Stdout, page, dlqTriageFlags.offset)
+	},
+}
+
+var dlqShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a dead letter queue entry and its payload",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		event, err := newDLQClient(&dlqFlags).show(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		printDLQEvent(os.Stdout, event)
+	},
+}
+
+var dlqRetryCmd = &cobra.Command{
+	Use:   "retry [id...]",
+	Short: "Retry dead letter queue entries",
+	Long: `Retry dead letter queue entries by ID, or with --all every entry matching the
+--event-type, --topic and --since/--until filters. Retried entries leave the queue.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDLQBulk(os.Stdout, os.Stdin, "retry", args); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var dlqDeleteCmd = &cobra.Command{
+	Use:   "delete [id...]",
+	Short: "Delete dead letter queue entries",
+	Long: `Delete dead letter queue entries by ID, or with --all every entry matching the
+--event-type, --topic and --since/--until filters. In production it requires --yes and the
+environment name, typed or passed with --confirm-env.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDLQBulk(os.Stdout, os.Stdin, "delete", args); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var dlqPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Purge every dead letter queue entry",
+	Long: `Delete every dead letter queue entry. When purges need an approval, an approval
+request is created instead, requested by the admin whose access token is passed as
+--token, and the queue is purged once a second admin approves it. In production it
+requires --yes and the environment name, typed or passed with --confirm-env.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDLQPurge(os.Stdout, os.Stdin, newDLQClient(&dlqFlags)); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var dlqInteractiveCmd = &cobra.Command{
+	Use:     "interactive",
+	Aliases: []string{"tui"},
+	Short:   "Triage the dead letter queue interactively",
+	Long: `Start an interactive session to page through dead letter queue entries, filter
+them by event type or topic, inspect payloads and retry or delete entries one by
+one or in bulk. Type 'help' for commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		session := &dlqSession{client: newDLQClient(&dlqFlags), filter: dlqFlags.filter(), pageSize: dlqTriageFlags.limit, in: bufio.NewScanner(os.Stdin), out: os.Stdout}
+		session.run()
+	},
+}
+
+func init() {
+	cfg := config.Load()
+	for _, c := range []*cobra.Command{dlqListCmd, dlqShowCmd, dlqRetryCmd, dlqDeleteCmd, dlqPurgeCmd, dlqInteractiveCmd} {
+		c.Flags().StringVar(&dlqFlags.url, "url", "http://localhost:8080", "Base URL of the HTTP gateway")
+		c.Flags().StringVar(&dlqFlags.token, "token", cfg.Admin.Token, "Admin API token")
+		dlqCmd.AddCommand(c)
+	}
+
+	for _, c := range []*cobra.Command{dlqListCmd, dlqRetryCmd, dlqDeleteCmd, dlqInteractiveCmd} {
+		c.Flags().StringVar(&dlqFlags.eventType, "event-type", "", "Only entries of this event type")
+		c.Flags().StringVar(&dlqFlags.topic, "topic", "", "Only entries from this topic")
+		c.Flags().StringVar(&dlqFlags.since, "since", "", "Only entries that failed at or after this RFC 3339 time")
+		c.Flags().StringVar(&dlqFlags.until, "until", "", "Only entries that failed before this RFC 3339 time")
+	}
+	for _, c := range []*cobra.Command{dlqListCmd, dlqInteractiveCmd} {
+		c.Flags().IntVar(&dlqTriageFlags.limit, "limit", 20, "Entries per page")
+	}
+	dlqListCmd.Flags().IntVar(&dlqTriageFlags.offset, "offset", 0, "Entries to skip")
+	for _, c := range []*cobra.Command{dlqRetryCmd, dlqDeleteCmd} {
+		c.Flags().BoolVar(&dlqTriageFlags.all, "all", false, "Act on every entry matching the filters")
+		c.Flags().BoolVarP(&dlqTriageFlags.yes, "yes", "y", false, "Do not ask for confirmation")
+	}
+	dlqPurgeCmd.Flags().BoolVarP(&dlqTriageFlags.yes, "yes", "y", false, "Do not ask for confirmation")
+	dlqPurgeCmd.Flags().StringVar(&dlqTriageFlags.reason, "reason", "", "Why the queue is purged, shown to the approving admin")
+	guardProduction(dlqDeleteCmd, dlqPurgeCmd)
+}
+
+// dlqFilter is a dead letter queue filter as query parameters of the admin API
+type dlqFilter map[string]string
+
+// filter returns the filter of the dlq flags
+func (o *dlqOptions) filter() dlqFilter {
+	return dlqFilter{"event_type": o.eventType, "topic": o.topic, "since": o.since, "until": o.until}
+}
+
+// String describes the filter, "none" when it matches everything
+func (f dlqFilter) String() string {
+	var parts []string
+	for _, name := range []string{"event_type", "topic", "since", "until"} {
+		if f[name] != "" {
+			parts = append(parts, name+"="+f[name])
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
+
+// dlqClient triages the dead letter queue of a running instance through the admin API
+type dlqClient struct {
+	url   string
+	token string
+}
+
+func newDLQClient(options *dlqOptions) *dlqClient {
+	return &dlqClient{url: options.url, token: options.token}
+}
+
+// list returns a page of the entries matching filter
+func (c *dlqClient) list(filter dlqFilter, limit, offset int) (*grpc.DLQListResponse, error) {
+	query := url.Values{"limit": {strconv.Itoa(limit)}, "offset": {strconv.Itoa(offset)}}
+	for name, value := range filter {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	var page grpc.DLQListResponse
+	if err := c.do(http.MethodGet, "/admin/dlq/events?"+query.Encode(), &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// matching returns the IDs of every entry matching filter
+func (c *dlqClient) matching(filter dlqFilter) ([]string, error) {
+	const pageSize = 500
+
+	var ids []string
+	for offset := 0; ; offset += pageSize {
+		page, err := c.list(filter, pageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range page.Events {
+			ids = append(ids, event.ID)
+		}
+		if len(page.Events) < pageSize {
+			return ids, nil
+		}
+	}
+}
+
+// show returns an entry
+func (c *dlqClient) show(id string) (*resilience.FailedEvent, error) {
+	var event resilience.FailedEvent
+	if err := c.do(http.MethodGet, "/admin/dlq/events/"+url.PathEscape(id), &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// apply retries or deletes an entry
+func (c *dlqClient) apply(action, id string) error {
+	if action == "retry" {
+		return c.do(http.MethodPost, "/admin/dlq/events/"+url.PathEscape(id)+"/retry", nil)
+	}
+	return c.do(http.MethodDelete, "/admin/dlq/events/"+url.PathEscape(id), nil)
+}
+
+// dlqPurgeResult is the response of a purge: the purged count, or the approval request created
+// when purges need an approval
+type dlqPurgeResult struct {
+	Purged     int    `json:"purged"`
+	ApprovalID string `json:"id"`
+}
+
+// purge purges the queue, or requests an approval to
+func (c *dlqClient) purge(reason string) (*dlqPurgeResult, error) {
+	body, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := adminRequest(c.url, c.token, http.MethodPost, "/admin/dlq/purge", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result dlqPurgeResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode admin API response: %w", err)
+	}
+	return &result, nil
+}
+
+// do sends an admin API request and decodes the response into result, when not nil
+func (c *dlqClient) do(method, path string, result interface{}) error {
+	resp, err := adminRequest(c.url, c.token, method, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode admin API response: %w", err)
+	}
+	return nil
+}
+
+// runDLQBulk retries or deletes the entries of ids, or with --all every entry matching the filters
+func runDLQBulk(out io.Writer, in io.Reader, action string, ids []string) error {
+	client := newDLQClient(&dlqFlags)
+	if dlqTriageFlags.all {
+		if len(ids) > 0 {
+			return fmt.Errorf("pass entry IDs or --all, not both")
+		}
+		filter := dlqFlags.filter()
+		matching, err := client.matching(filter)
+		if err != nil {
+			return err
+		}
+		if !dlqTriageFlags.yes && !confirm(out, bufio.NewScanner(in), fmt.Sprintf("%s %d entries matching filter %s?", action, len(matching), filter)) {
+			return nil
+		}
+		ids = matching
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("no entries to %s: pass entry IDs or --all", action)
+	}
+
+	if failed := applyDLQAction(out, client, action, ids); failed > 0 {
+		return fmt.Errorf("failed to %s %d of %d entries", action, failed, len(ids))
+	}
+	return nil
+}
+
+// runDLQPurge purges the queue once confirmed, reporting the purged entries or the approval
+// request created
+func runDLQPurge(out io.Writer, in io.Reader, client *dlqClient) error {
+	if !dlqTriageFlags.yes && !confirm(out, bufio.NewScanner(in), "purge every dead letter queue entry?") {
+		return nil
+	}
+
+	result, err := client.purge(dlqTriageFlags.reason)
+	if err != nil {
+		return err
+	}
+	if result.ApprovalID != "" {
+		fmt.Fprintf(out, "Approval request %s created, the queue is purged once another admin approves it\n", result.ApprovalID)
+		return nil
+	}
+	fmt.Fprintf(out, "Purged %d entries\n", result.Purged)
+	return nil
+}
+
+// applyDLQAction retries or deletes entries, reporting each one, and returns how many failed
+func applyDLQAction(out io.Writer, client *dlqClient, action string, ids []string) int {
+	failed := 0
+	for _, id := range ids {
+		if err := client.apply(action, id); err != nil {
+			fmt.Fprintf(out, "%s %s: %v\n", action, id, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%s %s: ok\n", action, id)
+	}
+	fmt.Fprintf(out, "%d of %d entries done\n", len(ids)-failed, len(ids))
+	return failed
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(out io.Writer, in *bufio.Scanner, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	if !in.Scan() {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(in.Text()))
+	return answer == "y" || answer == "yes"
+}
+
+// printDLQPage prints a page of entries as a table, numbered from offset+1
+func printDLQPage(out io.Writer, page *grpc.DLQListResponse, offset int) {
+	if len(page.Events) == 0 {
+		fmt.Fprintf(out, "No entries (%d matching)\n", page.Total)
+		return
+	}
+
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tID\tEVENT TYPE\tTOPIC\tATTEMPTS\tFAILED AT\tERROR")
+	for i, event := range page.Events {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%d/%d\t%s\t%s\n", offset+i+1, event.ID, event.EventType, dlqTopic(event),
+			event.Attempts, event.MaxAttempts, event.Timestamp.Format(time.RFC3339), truncate(event.Error, 60))
+	}
+	table.Flush()
+	fmt.Fprintf(out, "Entries %d-%d of %d\n", offset+1, offset+len(page.Events), page.Total)
+}
+
+// printDLQEvent prints an entry with its metadata and its payload, indented when it is JSON
+func printDLQEvent(out io.Writer, event *resilience.FailedEvent) {
+	fmt.Fprintf(out, "ID:         %s\n", event.ID)
+	fmt.Fprintf(out, "Event type: %s\n", event.EventType)
+	fmt.Fprintf(out, "Topic:      %s\n", dlqTopic(event))
+	fmt.Fprintf(out, "Attempts:   %d/%d\n", event.Attempts, event.MaxAttempts)
+	fmt.Fprintf(out, "Failed at:  %s\n", event.Timestamp.Format(time.RFC3339))
+	fmt.Fprintf(out, "Error:      %s\n", event.Error)
+
+	if len(event.Metadata) > 0 {
+		fmt.Fprintln(out, "Metadata:")
+		table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		for _, key := range sortedKeys(event.Metadata) {
+			if key != "headers" {
+				fmt.Fprintf(table, "  %s\t%s\n", key, event.Metadata[key])
+			}
+		}
+		table.Flush()
+	}
+
+	fmt.Fprintln(out, "Payload:")
+	payload, _ := event.EventData["message"].(string)
+	if payload == "" {
+		encoded, _ := json.Marshal(event.EventData)
+		payload = string(encoded)
+	}
+	fmt.Fprintln(out, indentJSON(payload))
+}
+
+// dlqTopic returns the topic of an entry, recorded by consumers in its data
+func dlqTopic(event *resilience.FailedEvent) string {
+	if event.Topic != "" {
+		return event.Topic
+	}
+	topic, _ := event.EventData["topic"].(string)
+	return topic
+}
+
+// indentJSON indents a JSON payload, and decodes the base64 data of domain events so their
+// payload is readable; other payloads are returned as is
+func indentJSON(payload string) string {
+	var value map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &value); err != nil {
+		return payload
+	}
+	if data, ok := value["data"].(string); ok {
+		var decoded []byte
+		if err := json.Unmarshal([]byte(strconv.Quote(data)), &decoded); err == nil && json.Valid(decoded) {
+			value["data"] = json.RawMessage(decoded)
+		}
+	}
+	indented, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return payload
+	}
+	return string(indented)
+}
+
+// sortedKeys returns the keys of a map, sorted
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// dlqSession is an interactive dead letter queue triage session
+type dlqSession struct {
+	client   *dlqClient
+	filter   dlqFilter
+	pageSize int
+	offset   int
+	page     *grpc.DLQListResponse // Last listed page, its entries are addressed by number
+	in       *bufio.Scanner
+	out      io.Writer
+}
+
+const dlqSessionHelp = `Commands:
+  list | ls                          list the current page
+  next | prev                        list the next or previous page
+  filter [type=<event type>] [topic=<topic>] [since=<time>] [until=<time>]
+  filter clear                       match every entry
+  show <#|id>                        show an entry and its payload
+  retry <#|id>... | retry all        retry entries, or every entry matching the filter
+  delete <#|id>... | delete all      delete entries, or every entry matching the filter
+  help                               show this help
+  exit | quit                        leave the session`
+
+// run reads commands until the input ends or the user leaves
+func (s *dlqSession) run() {
+	if s.pageSize <= 0 {
+		s.pageSize = 20
+	}
+	fmt.Fprintf(s.out, "Dead letter queue of %s. Type 'help' for commands, 'exit' to quit.\n", s.client.url)
+	s.list()
+
+	for {
+		fmt.Fprint(s.out, "dlq> ")
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			return
+		}
+
+		fields := strings.Fields(s.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		command, args := fields[0], fields[1:]
+
+		switch command {
+		case "exit", "quit":
+			return
+		case "help":
+			fmt.Fprintln(s.out, dlqSessionHelp)
+		case "list", "ls":
+			s.list()
+		case "next":
+			if s.page != nil && s.offset+s.pageSize < s.page.Total {
+				s.offset += s.pageSize
+			}
+			s.list()
+		case "prev":
+			s.offset = max(s.offset-s.pageSize, 0)
+			s.list()
+		case "filter":
+			s.setFilter(args)
+		case "show":
+			s.show(args)
+		case "retry", "delete":
+			s.apply(command, args)
+		default:
+			fmt.Fprintf(s.out, "Unknown command %q, type 'help' for commands\n", command)
+		}
+	}
+}
+
+// list lists the current page
+func (s *dlqSession) list() {
+	page, err := s.client.list(s.filter, s.pageSize, s.offset)
+	if err != nil {
+		fmt.Fprintf(s.out, "%v\n", err)
+		return
+	}
+	s.page = page
+	fmt.Fprintf(s.out, "Filter: %s\n", s.filter)
+	printDLQPage(s.out, page, s.offset)
+}
+
+// setFilter sets the filter from key=value arguments and lists its first page
+func (s *dlqSession) setFilter(args []string) {
+	if len(args) == 1 && args[0] == "clear" {
+		s.filter = dlqFilter{}
+	} else {
+		filter := dlqFilter{}
+		for name, value := range s.filter {
+			filter[name] = value
+		}
+		for _, arg := range args {
+			name, value, ok := strings.Cut(arg, "=")
+			if name == "type" {
+				name = "event_type"
+			}
+			if _, known := map[string]bool{"event_type": true, "topic": true, "since": true, "until": true}[name]; !ok || !known {
+				fmt.Fprintf(s.out, "Invalid filter %q, expected type=, topic=, since= or until=\n", arg)
+				return
+			}
+			filter[name] = value
+		}
+		s.filter = filter
+	}
+	s.offset = 0
+	s.list()
+}
+
+// show shows an entry
+func (s *dlqSession) show(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(s.out, "Usage: show <#|id>")
+		return
+	}
+	event, err := s.client.show(s.resolve(args[0]))
+	if err != nil {
+		fmt.Fprintf(s.out, "%v\n", err)
+		return
+	}
+	printDLQEvent(s.out, event)
+}
+
+// apply retries or deletes entries, or every entry matching the filter after confirmation
+func (s *dlqSession) apply(action string, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(s.out, "Usage: %s <#|id>... | %s all\n", action, action)
+		return
+	}
+
+	var ids []string
+	if len(args) == 1 && args[0] == "all" {
+		matching, err := s.client.matching(s.filter)
+		if err != nil {
+			fmt.Fprintf(s.out, "%v\n", err)
+			return
+		}
+		if !confirm(s.out, s.in, fmt.Sprintf("%s %d entries matching filter %s?", action, len(matching), s.filter)) {
+			return
+		}
+		ids = matching
+	} else {
+		for _, arg := range args {
+			ids = append(ids, s.resolve(arg))
+		}
+	}
+
+	applyDLQAction(s.out, s.client, action, ids)
+	s.list()
+}
+
+// resolve returns the ID of an entry addressed by its number on the listed page, or by its ID
+func (s *dlqSession) resolve(ref string) string {
+	number, err := strconv.Atoi(ref)
+	if err != nil || s.page == nil {
+		return ref
+	}
+	if index := number - s.offset - 1; index >= 0 && index < len(s.page.Events) {
+		return s.page.Events[index].ID
+	}
+	return ref
+}
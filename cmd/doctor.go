@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/migrations"
+)
+
+// Limits of the doctor checks
+const (
+	doctorTimeout = 10 * time.Second // Per check
+	maxClockSkew  = 2 * time.Second  // Between the instance and the write database
+)
+
+// doctorCheck is an environment prerequisite verified by the doctor command
+type doctorCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+	hint string // How to fix the environment when the check fails
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Verify the environment prerequisites of the application",
+	Long: `Verify that the environment can run the application: the configuration is valid, the
+configured databases and message broker are reachable, migrations are applied, the configured
+topics exist, key files are readable and private, and the clock agrees with the write database.
+Each failed check is printed with a hint on how to fix it; the command exits with status 1 when
+any check fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if failed := runDoctor(config.Load()); failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d checks failed\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctor runs the doctor checks, printing the outcome of each, and returns the number failed
+func runDoctor(cfg *config.Config) int {
+	failed := 0
+	for _, check := range doctorChecks(cfg) {
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		message, err := check.run(ctx)
+		cancel()
+
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL  %-16s %v\n", check.name, err)
+			fmt.Printf("      %-16s hint: %s\n", "", check.hint)
+			continue
+		}
+		fmt.Printf("OK    %-16s %s\n", check.name, message)
+	}
+	return failed
+}
+
+// doctorChecks returns the checks of the dependencies configured for this deployment
+func doctorChecks(cfg *config.Config) []doctorCheck {
+	checks := []doctorCheck{
+		{
+			name: "config",
+			run: func(ctx context.Context) (string, error) {
+				if err := cfg.Validate(); err != nil {
+					return "", err
+				}
+				return "Configuration is valid", nil
+			},
+			hint: "fix the reported settings in the environment or .env; env.example documents every setting",
+		},
+	}
+
+	databases := []struct {
+		name   string
+		prefix string
+		cfg    *config.DatabaseConfig
+	}{
+		{"write_database", "WRITE_DB", &cfg.WriteDatabase},
+		{"read_database", "READ_DB", &cfg.ReadDatabase},
+		{"event_database", "EVENT_DB", &cfg.EventDatabase},
+	}
+	for _, db := range databases {
+		checks = append(checks, doctorCheck{
+			name: db.name,
+			run: func(ctx context.Context) (string, error) {
+				return pingDatabase(ctx, db.cfg)
+			},
+			hint: fmt.Sprintf("start the database (make all-up) or fix %s_HOST, %s_PORT and the credentials", db.prefix, db.prefix),
+		})
+	}
+	for _, shard := range cfg.ReadShards {
+		checks = append(checks, doctorCheck{
+			name: readShardCheckName(shard),
+			run: func(ctx context.Context) (string, error) {
+				return pingDatabase(ctx, &shard.Database)
+			},
+			hint: "start the read shard or fix its settings in READ_SHARDS",
+		})
+	}
+
+	checks = append(checks,
+		doctorCheck{
+			name: "migrations",
+			run: func(ctx context.Context) (string, error) {
+				return checkPendingMigrations(ctx, cfg)
+			},
+			hint: "run ./bin/app migrate up; after a failed migration, fix it and run ./bin/app migrate force <version>",
+		},
+		doctorCheck{
+			name: "clock_skew",
+			run: func(ctx context.Context) (string, error) {
+				return checkClockSkew(ctx, cfg)
+			},
+			hint: "synchronize the clock of this host with NTP, e.g. timedatectl set-ntp true",
+		},
+		doctorCheck{
+			name: "message_broker",
+			run: func(ctx context.Context) (string, error) {
+				return fetchBrokerMetadata(cfg)
+			},
+			hint: "start the broker (make all-up) or fix MESSAGE_BROKER_BROKERS",
+		},
+	)
+	if cfg.MessageBroker.Type == "kafka" {
+		checks = append(checks, doctorCheck{
+			name: "topics",
+			run: func(ctx context.Context) (string, error) {
+				return checkTopics(cfg)
+			},
+			hint: "create the missing topics, or enable auto.create.topics.enable on the brokers",
+		})
+	}
+
+	checks = append(checks,
+		doctorCheck{
+			name: "auth_keys",
+			run: func(ctx context.Context) (string, error) {
+				return checkAuthKeys(cfg)
+			},
+			hint: fmt.Sprintf("run ./bin/app generate-keys, or chmod 600 %s when it is readable by others", cfg.Auth.PrivateKeyPath),
+		},
+	)
+	if cfg.Encryption.Enabled {
+		checks = append(checks, doctorCheck{
+			name: "encryption_keys",
+			run: func(ctx context.Context) (string, error) {
+				return checkPrivatePath(cfg.Encryption.SecretsDir)
+			},
+			hint: fmt.Sprintf("mount the secrets volume at ENCRYPTION_SECRETS_DIR, then chmod 700 %s", cfg.Encryption.SecretsDir),
+		})
+	}
+	return checks
+}
+
+// checkPendingMigrations fails when migrations of the write or event database are dirty or not applied
+func checkPendingMigrations(ctx context.Context, cfg *config.Config) (string, error) {
+	writeDB, err := database.NewPostgresConnection(cfg.WriteDatabase)
+	if err != nil {
+		return "", err
+	}
+	defer writeDB.Close()
+
+	eventDB, err := connectEventDatabase(cfg, writeDB)
+	if err != nil {
+		return "", err
+	}
+	defer eventDB.Close()
+
+	migrationManager, err := migrations.NewMigrationManager(writeDB, eventDB, "./migrations/write", "./migrations/event")
+	if err != nil {
+		return "", err
+	}
+	defer migrationManager.Close()
+
+	databases := []struct {
+		name    string
+		dir     string
+		version func(ctx context.Context) (uint, bool, error)
+	}{
+		{"write", "./migrations/write", migrationManager.GetWriteDBVersion},
+		{"event", "./migrations/event", migrationManager.GetEventDBVersion},
+	}
+	var versions []string
+	for _, db := range databases {
+		version, dirty, err := db.version(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get %s database version: %w", db.name, err)
+		}
+		if dirty {
+			return "", fmt.Errorf("%s database migration %d is dirty", db.name, version)
+		}
+		files, err := migrations.ListMigrations(db.dir)
+		if err != nil {
+			return "", err
+		}
+		pending := 0
+		for _, file := range files {
+			if file.Version > version {
+				pending++
+			}
+		}
+		if pending > 0 {
+			return "", fmt.Errorf("%d migrations of the %s database are not applied (at version %d)", pending, db.name, version)
+		}
+		versions = append(versions, fmt.Sprintf("%s: %d", db.name, version))
+	}
+	return "Up to date (" + strings.Join(versions, ", ") + ")", nil
+}
+
+// checkClockSkew compares the clock of the instance with the clock of the write database,
+// allowing for the round trip of the query
+func checkClockSkew(ctx context.Context, cfg *config.Config) (string, error) {
+	db, err := database.NewDatabaseFactory().CreateDatabase(&cfg.WriteDatabase)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	sqlDB, ok := db.GetDB().(*sql.DB)
+	if !ok {
+		return "", fmt.Errorf("clock skew is checked against a sql write database, got %s", cfg.WriteDatabase.Type)
+	}
+	sent := time.Now()
+	var dbTime time.Time
+	if err := sqlDB.QueryRowContext(ctx, `SELECT CURRENT_TIMESTAMP`).Scan(&dbTime); err != nil {
+		return "", fmt.Errorf("failed to read database time: %w", err)
+	}
+	received := time.Now()
+
+	skew := dbTime.Sub(sent.Add(received.Sub(sent) / 2))
+	if skew.Abs() > maxClockSkew {
+		return "", fmt.Errorf("clock is %s off the write database, more than %s", skew.Round(time.Millisecond), maxClockSkew)
+	}
+	return fmt.Sprintf("Clock is %s off the write database", skew.Round(time.Millisecond)), nil
+}
+
+// checkTopics fails when topics the instance publishes to or consumes are missing
+func checkTopics(cfg *config.Config) (string, error) {
+	saramaConfig, err := messagebroker.NewSaramaConfig(&cfg.MessageBroker)
+	if err != nil {
+		return "", err
+	}
+	client, err := sarama.NewClient(cfg.MessageBroker.Brokers, saramaConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	defer client.Close()
+
+	existing, err := client.Topics()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch Kafka metadata: %w", err)
+	}
+	found := make(map[string]bool, len(existing))
+	for _, topic := range existing {
+		found[topic] = true
+	}
+
+	expected := make(map[string]bool)
+	for eventType, topic := range cfg.MessageBroker.Topics {
+		if cfg.Components.HandlerEnabled(eventType) {
+			expected[topic] = true
+		}
+	}
+	var topics []string
+	for topic := range expected {
+		topics = append(topics, topic)
+	}
+	topics = messagebroker.NewVersionedTopics(cfg.MessageBroker, nil, nil).ConsumerTopics(topics)
+	if cfg.Control.Enabled {
+		topics = append(topics, cfg.Control.Topic)
+	}
+
+	var missing []string
+	for _, topic := range topics {
+		if !found[topic] {
+			missing = append(missing, topic)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("missing topics: %s", strings.Join(missing, ", "))
+	}
+	return fmt.Sprintf("%d topics exist", len(topics)), nil
+}
+
+// checkAuthKeys loads the RSA key pair and checks that only the owner can read the private key
+func checkAuthKeys(cfg *config.Config) (string, error) {
+	if _, err := auth.NewJWTService(cfg.Auth.PrivateKeyPath, cfg.Auth.PublicKeyPath, time.Duration(cfg.Auth.TokenExpiry)*time.Hour); err != nil {
+		return "", err
+	}
+	if _, err := checkPrivatePath(cfg.Auth.PrivateKeyPath); err != nil {
+		return "", err
+	}
+	return "RSA key pair loaded, private key readable by its owner only", nil
+}
+
+// checkPrivatePath fails when a file or directory is missing or accessible by the group or others
+func checkPrivatePath(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if mode := info.Mode().Perm(); mode&0o077 != 0 {
+		return "", fmt.Errorf("%s has mode %04o, accessible by users other than its owner", path, mode)
+	}
+	return fmt.Sprintf("%s is accessible by its owner only", path), nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/eventstream"
+)
+
+// startEventGateway subscribes the instance to the topics of the events front-ends may subscribe
+// to and returns the hub fanning them out to the WebSocket clients of the instance. Every
+// instance consumes every event, like the control channel, so clients may connect to any.
+func startEventGateway(cfg *config.Config) (*eventstream.Hub, error) {
+	for _, pattern := range cfg.EventGateway.Topics {
+		if err := eventstream.ValidatePattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	broker, err := messagebroker.NewMessageBrokerFactory().CreateMessageBroker(&cfg.MessageBroker)
+	if err != nil {
+		return nil, err
+	}
+
+	hub := eventstream.NewHub(cfg.EventGateway.BufferSize)
+	for _, topic := range eventGatewayTopics(cfg) {
+		if err := broker.Subscribe(topic, func(message []byte) {
+			if event, ok := decodeGatewayEvent(message); ok {
+				hub.Publish(event)
+			}
+		}); err != nil {
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+	}
+	return hub, nil
+}
+
+// eventGatewayTopics returns the topics the event types matching the patterns of the gateway are
+// published to, as consumed under topic versioning and tenant routing
+func eventGatewayTopics(cfg *config.Config) []string {
+	topicSet := make(map[string]bool)
+	for eventType, topic := range cfg.MessageBroker.Topics {
+		if eventstream.MatchAny(cfg.EventGateway.Topics, eventType) {
+			topicSet[topic] = true
+		}
+	}
+	for _, event := range messagebroker.NewEventCatalog(cfg.MessageBroker).Events() {
+		if eventstream.MatchAny(cfg.EventGateway.Topics, event.Type) {
+			topicSet[event.Topic] = true
+		}
+	}
+
+	topics := make([]string, 0, len(topicSet))
+	for topic := range topicSet {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	topics = messagebroker.NewVersionedTopics(cfg.MessageBroker, nil, nil).ConsumerTopics(topics)
+	return messagebroker.NewTenantRouter(cfg.Tenancy).ConsumerTopics(topics)
+}
+
+// decodeGatewayEvent decodes a domain event consumed from the broker into the event pushed to
+// front-ends. Messages whose payload is not JSON, e.g. encrypted, are skipped.
+func decodeGatewayEvent(message []byte) (eventstream.Event, bool) {
+	var event domainEvent.Event
+	if err := json.Unmarshal(message, &event); err != nil || event.Type == "" || !json.Valid(event.Data) {
+		return eventstream.Event{}, false
+	}
+
+	var data struct {
+		UserID string `json:"user_id"`
+	}
+	_ = json.Unmarshal(event.Data, &data)
+
+	result := eventstream.Event{
+		ID:            event.ID.String(),
+		Type:          event.Type,
+		UserID:        data.UserID,
+		Data:          json.RawMessage(event.Data),
+		Timestamp:     event.Timestamp,
+		CorrelationID: event.CorrelationID,
+	}
+	if !event.TenantID.IsZero() {
+		result.TenantID = event.TenantID.String()
+	}
+	return result, true
+}
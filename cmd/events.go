@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+)
+
+// eventsOptions holds the flags of the events commands
+type eventsOptions struct {
+	format string
+	output string
+	check  bool
+}
+
+var eventsFlags eventsOptions
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Domain event tooling",
+}
+
+var eventsDocsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate the domain event catalog",
+	Long: `Generate the catalog of the domain events, with the description, payload schema,
+topic, producers and consumers declared for each event type in code, so the catalog
+never drifts from the implementation. Topics are the ones of the default configuration.
+Use --check in CI to fail when the catalog file is out of date. The catalog of a running
+instance, with its configured topics, is served at GET /admin/events.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := generateEventDocs(&eventsFlags); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	eventsDocsCmd.Flags().StringVar(&eventsFlags.format, "format", "markdown", "Output format: markdown or json")
+	eventsDocsCmd.Flags().StringVarP(&eventsFlags.output, "output", "o", "docs/events.md", "Path of the generated catalog, - for stdout")
+	eventsDocsCmd.Flags().BoolVar(&eventsFlags.check, "check", false, "Only verify that the catalog file is up to date")
+
+	eventsCmd.AddCommand(eventsDocsCmd)
+	rootCmd.AddCommand(eventsCmd)
+}
+
+// generateEventDocs writes the event catalog, or compares it with the file in check mode
+func generateEventDocs(options *eventsOptions) error {
+	catalog := messagebroker.NewEventCatalog(config.Defaults().MessageBroker)
+	if err := catalog.Validate(); err != nil {
+		return fmt.Errorf("invalid event catalog: %w", err)
+	}
+
+	var data []byte
+	switch options.format {
+	case "markdown":
+		data = catalog.Markdown()
+	case "json":
+		encoded, err := json.MarshalIndent(catalog.Events(), "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(encoded, '\n')
+	default:
+		return fmt.Errorf("unknown format %q, expected markdown or json", options.format)
+	}
+
+	if options.output == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if options.check {
+		existing, err := os.ReadFile(options.output)
+		if err != nil {
+			return fmt.Errorf("failed to read event catalog: %w", err)
+		}
+		if !bytes.Equal(existing, data) {
+			return fmt.Errorf("%s is out of date, run \"events docs\"", options.output)
+		}
+		fmt.Printf("%s is up to date\n", options.output)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(options.output), 0o755); err != nil {
+		return fmt.Errorf("failed to create catalog directory: %w", err)
+	}
+	if err := os.WriteFile(options.output, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write event catalog: %w", err)
+	}
+
+	fmt.Printf("Generated the catalog of %d events in %s\n", len(catalog.Events()), options.output)
+	return nil
+}
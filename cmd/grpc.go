@@ -2,9 +2,32 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
 	"go-clean-ddd-es-template/internal/infrastructure/grpc"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/approval"
+	"go-clean-ddd-es-template/pkg/audit"
+	"go-clean-ddd-es-template/pkg/autoscaling"
+	"go-clean-ddd-es-template/pkg/control"
+	"go-clean-ddd-es-template/pkg/debugconsole"
+	"go-clean-ddd-es-template/pkg/faultinjection"
+	"go-clean-ddd-es-template/pkg/featureflags"
+	"go-clean-ddd-es-template/pkg/health"
+	"go-clean-ddd-es-template/pkg/leader"
+	"go-clean-ddd-es-template/pkg/metrics"
+	"go-clean-ddd-es-template/pkg/mongoindex"
+	"go-clean-ddd-es-template/pkg/operation"
+	"go-clean-ddd-es-template/pkg/statshistory"
+	"go-clean-ddd-es-template/pkg/storage"
+	"go-clean-ddd-es-template/pkg/supervisor"
 
 	"github.com/spf13/cobra"
 )
@@ -18,6 +41,13 @@ var grpcCmd = &cobra.Command{
 	},
 }
 
+// strictStartup refuses to start when the startup self-check fails
+var strictStartup bool
+
+func init() {
+	grpcCmd.Flags().BoolVar(&strictStartup, "strict", false, "Refuse to start if the startup self-check fails")
+}
+
 func startGRPCServer() {
 	// Use flag port or default to 9091 for gRPC (9090 is used by Prometheus)
 	grpcPort := "9091"
@@ -27,6 +57,24 @@ func startGRPCServer() {
 		grpcPort = port
 	}
 
+	// Run startup self-check before wiring dependencies
+	cfg := provideConfig()
+	selfCheck := runStartupSelfCheck(cfg, strictStartup)
+
+	// Load feature flags
+	featureflags.SetGlobal(featureflags.New(cfg.FeatureFlags))
+
+	// Load the faults injected into event handlers, outside production only
+	if cfg.Faults.Enabled {
+		faults := make(map[string]faultinjection.Fault, len(cfg.Faults.Handlers))
+		for handler, spec := range cfg.Faults.Handlers {
+			if fault, err := faultinjection.ParseFault(spec); err == nil {
+				faults[handler] = fault
+			}
+		}
+		faultinjection.SetGlobal(faultinjection.New(faults))
+	}
+
 	// Initialize dependencies using Wire
 	grpcServer, err := InitializeGRPCServer()
 	if err != nil {
@@ -42,6 +90,11 @@ func startGRPCServer() {
 		os.Exit(1)
 	}
 
+	// Keep a warm standby paused until promoted, by the control channel or leader election
+	if cfg.Standby.Enabled {
+		eventConsumer.SetStandby(true)
+	}
+
 	// Get logger from the server
 	logger := grpcServer.GetLogger()
 	if logger == nil {
@@ -60,25 +113,511 @@ func startGRPCServer() {
 		os.Stdout.WriteString("Starting event consumer...\n")
 	}
 
-	// Start event consumer in background
-	ctx := context.Background()
-	go func() {
-		if err := eventConsumer.Start(ctx); err != nil {
+	// Push the pipeline stats to the OTLP collector as OpenTelemetry metrics
+	if cfg.OTelMetrics.Enabled {
+		if err := startOTelMetrics(cfg, eventConsumer); err != nil {
+			os.Stderr.WriteString("Failed to export OpenTelemetry metrics: " + err.Error() + "\n")
+		}
+	}
+
+	// Restart crashed components with backoff; components crashing too often report unhealthy
+	var supervisorLogger supervisor.Logger = &consumers.SimpleLogger{}
+	if logger != nil {
+		supervisorLogger = logger
+	}
+	// Background work runs until shutdown
+	ctx, stopBackground := context.WithCancel(context.Background())
+	components := supervisor.New(nil, supervisorLogger, metrics.NewMetrics())
+	restartPolicy := supervisor.RestartPolicy{
+		InitialBackoff: cfg.Supervisor.InitialBackoff,
+		MaxBackoff:     cfg.Supervisor.MaxBackoff,
+		MaxRestarts:    cfg.Supervisor.MaxRestarts,
+		Window:         cfg.Supervisor.Window,
+	}
+	componentHealth := health.NewHealthService()
+	componentHealth.AddCheck(components.HealthCheck())
+	httpServer.Handle("/health/components", componentHealth.HTTPHandler())
+
+	// Serve the liveness and readiness probes of Kubernetes over HTTP and the gRPC health checking
+	// protocol
+	probes := newProbes(cfg, components, eventConsumer)
+	httpServer.Handle("/healthz", probes.LivenessHandler())
+	httpServer.Handle("/readyz", probes.ReadinessHandler())
+	if err := grpcServer.RegisterHealthService(probes.GRPCServer()); err != nil {
+		os.Stderr.WriteString("Failed to serve the gRPC health service: " + err.Error() + "\n")
+	}
+	components.Go(ctx, supervisor.Component{
+		Name: "health-probes",
+		Run: func(ctx context.Context) error {
+			return probes.Run(ctx, cfg.Server.HealthCheckInterval)
+		},
+		Policy: restartPolicy,
+	})
+
+	// Expose the startup self-check report
+	httpServer.Handle("/startupz", selfCheck.HTTPHandler())
+
+	// Report the health of each read shard
+	if len(cfg.ReadShards) > 0 {
+		shardHealth := health.NewHealthService()
+		for _, shard := range cfg.ReadShards {
+			shardHealth.AddCheck(readShardCheck(shard))
+		}
+		httpServer.Handle("/health/read-shards", shardHealth.HTTPHandler())
+	}
+
+	// Serve avatar uploads and, for local storage, signed downloads
+	avatarHandler, err := InitializeAvatarHandler(grpcServer.GetAuthService())
+	if err != nil {
+		os.Stderr.WriteString("Failed to initialize avatar uploads: " + err.Error() + "\n")
+	} else {
+		httpServer.Handle(grpc.AvatarUploadPattern, avatarHandler)
+	}
+	if cfg.Storage.Provider == "local" {
+		if err := mountLocalStorage(httpServer, cfg.Storage.BasePath, cfg.Storage.BaseURL, cfg.Storage.SigningKey); err != nil {
+			os.Stderr.WriteString("Failed to serve local storage: " + err.Error() + "\n")
+		}
+	}
+
+	// Serve the user changefeed to external systems
+	if cfg.Changefeed.Enabled {
+		changefeedHandler, err := InitializeChangefeedHandler()
+		if err != nil {
+			os.Stderr.WriteString("Failed to initialize the changefeed: " + err.Error() + "\n")
+		} else {
+			httpServer.Handle(grpc.ChangefeedPattern, changefeedHandler)
+		}
+	}
+
+	// Push the events front-ends subscribe to over WebSocket
+	if cfg.EventGateway.Enabled {
+		hub, err := startEventGateway(cfg)
+		if err != nil {
+			os.Stderr.WriteString("Failed to initialize the event gateway: " + err.Error() + "\n")
+		} else {
+			var gatewayLogger grpc.EventGatewayLogger = &consumers.SimpleLogger{}
 			if logger != nil {
-				logger.Error("Failed to start event consumer: %v", err)
+				gatewayLogger = logger
+			}
+			gatewayHandler := grpc.NewEventGatewayHandler(hub, grpcServer.GetAuthService(), grpc.EventGatewayOptions{
+				Topics:          cfg.EventGateway.Topics,
+				PrivilegedRoles: cfg.EventGateway.PrivilegedRoles,
+				AllowedOrigins:  cfg.EventGateway.AllowedOrigins,
+				MaxConnections:  cfg.EventGateway.MaxConnections,
+				WriteTimeout:    cfg.EventGateway.WriteTimeout,
+			}, gatewayLogger)
+			httpServer.Handle(grpc.EventGatewayPattern, gatewayHandler)
+		}
+	}
+
+	// Require the approval of a second admin for sensitive commands
+	var approvals *approval.Workflow
+	if cfg.Approvals.Enabled {
+		var approvalLogger approval.Logger = &consumers.SimpleLogger{}
+		if logger != nil {
+			approvalLogger = logger
+		}
+		approvals = newApprovalWorkflow(cfg, grpcServer.GetUserService(), eventConsumer, approvalLogger)
+		grpcServer.SetApprovals(approvals)
+		components.Go(ctx, supervisor.Component{
+			Name: "approval-scheduler",
+			Run: func(ctx context.Context) error {
+				approvals.Run(ctx, time.Minute)
+				return nil
+			},
+			Policy: restartPolicy,
+		})
+
+		approvalHandler := grpc.NewApprovalHandler(approvals, grpcServer.GetAuthService())
+		httpServer.Handle(grpc.ApprovalListPattern, http.HandlerFunc(approvalHandler.List))
+		httpServer.Handle(grpc.ApprovalSubmitPattern, http.HandlerFunc(approvalHandler.Submit))
+		httpServer.Handle(grpc.ApprovalApprovePattern, http.HandlerFunc(approvalHandler.Approve))
+		httpServer.Handle(grpc.ApprovalRejectPattern, http.HandlerFunc(approvalHandler.Reject))
+		httpServer.Handle(grpc.ApprovalAuditPattern, http.HandlerFunc(approvalHandler.Audit))
+	}
+
+	// Record admin actions in the audit log, enforce the retention of its records and let
+	// operators search it and place legal holds
+	if cfg.AuditLog.Enabled {
+		auditStore, err := newAuditStore(cfg)
+		if err != nil {
+			os.Stderr.WriteString("Failed to initialize the audit log: " + err.Error() + "\n")
+		} else {
+			auditLog := audit.NewLog(auditStore, nil)
+			if approvals != nil {
+				recordApprovals(approvals, auditLog)
+			}
+
+			var auditLogger audit.Logger = &consumers.SimpleLogger{}
+			if logger != nil {
+				auditLogger = logger
+			}
+			enforcer, err := newAuditEnforcer(cfg, auditStore, auditLogger)
+			if err != nil {
+				os.Stderr.WriteString("Failed to initialize audit log retention: " + err.Error() + "\n")
 			} else {
-				os.Stderr.WriteString("Failed to start event consumer: " + err.Error() + "\n")
+				components.Go(ctx, supervisor.Component{
+					Name: "audit-retention",
+					Run: func(ctx context.Context) error {
+						return enforcer.Run(ctx, cfg.AuditLog.EnforceInterval)
+					},
+					Policy: restartPolicy,
+				})
+			}
+
+			if cfg.Admin.Token != "" {
+				auditHandler := grpc.NewAuditHandler(auditStore, auditLog, cfg.Admin.Token)
+				httpServer.Handle(grpc.AuditListPattern, http.HandlerFunc(auditHandler.List))
+				httpServer.Handle(grpc.AuditLegalHoldPattern, http.HandlerFunc(auditHandler.LegalHold))
+			}
+		}
+	}
+
+	// Serve dead letter queue triage, export/import and purge to operators, over HTTP and gRPC
+	if cfg.Admin.Token != "" {
+		dlqHandler := grpc.NewDLQHandler(eventConsumer, cfg.Admin.Token, cfg.Admin.MaxImportSize)
+		if approvals != nil {
+			dlqHandler.SetApprovals(approvals, grpcServer.GetAuthService())
+		}
+		httpServer.Handle(grpc.DLQExportPattern, http.HandlerFunc(dlqHandler.Export))
+		httpServer.Handle(grpc.DLQImportPattern, http.HandlerFunc(dlqHandler.Import))
+		httpServer.Handle(grpc.DLQPurgePattern, http.HandlerFunc(dlqHandler.Purge))
+		httpServer.Handle(grpc.DLQListPattern, http.HandlerFunc(dlqHandler.List))
+		httpServer.Handle(grpc.DLQShowPattern, http.HandlerFunc(dlqHandler.Show))
+		httpServer.Handle(grpc.DLQRetryPattern, http.HandlerFunc(dlqHandler.Retry))
+		httpServer.Handle(grpc.DLQDeletePattern, http.HandlerFunc(dlqHandler.Delete))
+
+		dlqAdminServer := grpc.NewDLQAdminServer(eventConsumer, cfg.Admin.Token)
+		if approvals != nil {
+			dlqAdminServer.SetApprovals(approvals)
+		}
+		if err := grpcServer.RegisterDLQAdminService(dlqAdminServer); err != nil {
+			os.Stderr.WriteString("Failed to serve the dead letter queue admin service: " + err.Error() + "\n")
+		}
+	}
+
+	// Run long-running admin operations in the background and let operators track and cancel them
+	if cfg.Admin.Token != "" {
+		var operationLogger operation.Logger = &consumers.SimpleLogger{}
+		if logger != nil {
+			operationLogger = logger
+		}
+		operations, err := newOperationManager(cfg, eventConsumer, operationLogger)
+		if err != nil {
+			os.Stderr.WriteString("Failed to initialize operations: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name: "operations",
+				Run: func(ctx context.Context) error {
+					return operations.Run(ctx, cfg.Operations.HeartbeatInterval)
+				},
+				Policy: restartPolicy,
+			})
+
+			operationsHandler := grpc.NewOperationsHandler(operations, cfg.Admin.Token)
+			if approvals != nil {
+				registerOperationApprovals(approvals, operations)
+				operationsHandler.SetApprovals(approvals)
+			}
+			httpServer.Handle(grpc.OperationStartPattern, http.HandlerFunc(operationsHandler.Start))
+			httpServer.Handle(grpc.OperationListPattern, http.HandlerFunc(operationsHandler.List))
+			httpServer.Handle(grpc.OperationGetPattern, http.HandlerFunc(operationsHandler.Get))
+			httpServer.Handle(grpc.OperationCancelPattern, http.HandlerFunc(operationsHandler.Cancel))
+		}
+	}
+
+	// Record snapshots of the pipeline stats and serve their history to the admin UI
+	if cfg.StatsHistory.Enabled {
+		var statsLogger statshistory.Logger = &consumers.SimpleLogger{}
+		if logger != nil {
+			statsLogger = logger
+		}
+		statsStore, recorder, err := newStatsHistory(cfg, eventConsumer, statsLogger)
+		if err != nil {
+			os.Stderr.WriteString("Failed to initialize the stats history: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name: "stats-history",
+				Run: func(ctx context.Context) error {
+					return recorder.Run(ctx, cfg.StatsHistory.Interval)
+				},
+				Policy: restartPolicy,
+			})
+			if cfg.Admin.Token != "" {
+				httpServer.Handle(grpc.StatsHistoryPattern, grpc.NewStatsHistoryHandler(statsStore, cfg.Admin.Token))
+			}
+		}
+	}
+
+	// Serve the effective, redacted configuration to operators
+	if cfg.Admin.Token != "" {
+		configHandler := grpc.NewConfigHandler(cfg, cfg.Admin.Token)
+		httpServer.Handle(grpc.ConfigPattern, http.HandlerFunc(configHandler.Get))
+	}
+
+	// Reload the authorization matrix when its file changes and serve it to auditors
+	if authorizer := grpcServer.GetAuthorizer(); authorizer != nil {
+		if cfg.Authorization.ReloadInterval > 0 {
+			components.Go(ctx, supervisor.Component{
+				Name: "authorization-reloader",
+				Run: func(ctx context.Context) error {
+					return authorizer.Run(ctx, cfg.Authorization.ReloadInterval)
+				},
+				Policy: restartPolicy,
+			})
+		}
+		if cfg.Admin.Token != "" {
+			authorizationHandler := grpc.NewAuthorizationHandler(authorizer, cfg.Admin.Token)
+			httpServer.Handle(grpc.AuthorizationPattern, http.HandlerFunc(authorizationHandler.Get))
+		}
+	}
+
+	// Serve the partitions the instance consumes to debug consumer group rebalances
+	if cfg.Admin.Token != "" {
+		instance, _ := os.Hostname()
+		assignmentsHandler := grpc.NewConsumerAssignmentsHandler(eventConsumer, instance, cfg.Admin.Token)
+		httpServer.Handle(grpc.ConsumerAssignmentsPattern, http.HandlerFunc(assignmentsHandler.Get))
+	}
+
+	// Serve the catalog of the domain events to operators and consuming teams
+	if cfg.Admin.Token != "" {
+		eventCatalogHandler := grpc.NewEventCatalogHandler(messagebroker.NewEventCatalog(cfg.MessageBroker), cfg.Admin.Token)
+		httpServer.Handle(grpc.EventCatalogListPattern, http.HandlerFunc(eventCatalogHandler.List))
+		httpServer.Handle(grpc.EventCatalogShowPattern, http.HandlerFunc(eventCatalogHandler.Show))
+	}
+
+	// Let operators change the injected faults at runtime
+	if cfg.Admin.Token != "" && cfg.Faults.Enabled {
+		faultHandler := grpc.NewFaultHandler(faultinjection.Global(), cfg.Admin.Token)
+		httpServer.Handle(grpc.FaultListPattern, http.HandlerFunc(faultHandler.List))
+		httpServer.Handle(grpc.FaultSetPattern, http.HandlerFunc(faultHandler.Set))
+		httpServer.Handle(grpc.FaultClearPattern, http.HandlerFunc(faultHandler.Clear))
+	}
+
+	// Subscribe to the control channel and let operators broadcast commands
+	if cfg.Control.Enabled {
+		var controlLogger control.Logger = &consumers.SimpleLogger{}
+		if logger != nil {
+			controlLogger = logger
+		}
+		producer, audit, err := startControlChannel(cfg, eventConsumer, controlLogger)
+		if err != nil {
+			os.Stderr.WriteString("Failed to start control channel: " + err.Error() + "\n")
+		} else if cfg.Admin.Token != "" {
+			controlHandler := grpc.NewControlHandler(producer, audit, cfg.Admin.Token)
+			httpServer.Handle(grpc.ControlCommandsPattern, http.HandlerFunc(controlHandler.Send))
+			httpServer.Handle(grpc.ControlAuditPattern, http.HandlerFunc(controlHandler.Audit))
+		}
+	}
+
+	// Reconcile read model indexes
+	if cfg.MongoIndexes.SyncOnStartup && cfg.ReadDatabase.Type == "mongodb" {
+		var indexLogger mongoindex.Logger
+		if logger != nil {
+			indexLogger = logger
+		}
+		if _, err := syncReadModelIndexes(ctx, cfg, indexLogger, false); err != nil {
+			os.Stderr.WriteString("Failed to sync read model indexes: " + err.Error() + "\n")
+		}
+	}
+
+	// Migrate outdated read model documents in the background
+	if cfg.ReadModel.MigrateOnStartup {
+		go func() {
+			report, err := migrateReadModel(ctx, cfg, cfg.ReadModel.MigrationBatchSize)
+			if err != nil {
+				os.Stderr.WriteString("Failed to migrate read model: " + err.Error() + "\n")
+				return
+			}
+			if logger != nil {
+				logger.Info("Read model migration finished: %d scanned, %d migrated, %d failed", report.Scanned, report.Migrated, report.Failed)
 			}
+		}()
+	}
+
+	// Export consumer lag and replica hints for KEDA/HPA
+	if cfg.Autoscaling.Enabled {
+		exporter := autoscaling.NewExporter(eventConsumer, eventConsumer.ConsumerGroup(), autoscaling.Policy{
+			LagPerReplica:        cfg.Autoscaling.LagPerReplica,
+			QueueDepthPerReplica: cfg.Autoscaling.QueueDepthPerReplica,
+			MinReplicas:          cfg.Autoscaling.MinReplicas,
+			MaxReplicas:          cfg.Autoscaling.MaxReplicas,
+			RefreshInterval:      cfg.Autoscaling.RefreshInterval,
+		}, nil)
+		httpServer.Handle("/autoscaling/replicas", exporter.HTTPHandler())
+		go exporter.Run(ctx)
+	}
+	// Serve the debug console over a unix socket
+	if cfg.Debug.ConsoleEnabled {
+		var consoleLogger debugconsole.Logger = &consumers.SimpleLogger{}
+		if logger != nil {
+			consoleLogger = logger
+		}
+		if err := startDebugConsole(ctx, cfg, eventConsumer, consoleLogger); err != nil {
+			os.Stderr.WriteString("Failed to start debug console: " + err.Error() + "\n")
 		}
-	}()
+	}
 
-	// Start HTTP server
-	if err := httpServer.Start(grpcPort, gatewayPort); err != nil {
+	// Publish the events commands appended to the outbox
+	if cfg.Outbox.Enabled {
+		if outboxRelay, err := InitializeOutboxRelay(); err != nil {
+			os.Stderr.WriteString("Failed to initialize outbox relay: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name:   "outbox-relay",
+				Run:    outboxRelay.Run,
+				Policy: restartPolicy,
+			})
+		}
+	}
+
+	// Publish the due messages delayed on brokers without native delays
+	if cfg.MessageBroker.DelayedStore == "postgres" {
+		if delayScheduler, err := InitializeDelayScheduler(); err != nil {
+			os.Stderr.WriteString("Failed to initialize delay scheduler: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name:   "delay-scheduler",
+				Run:    delayScheduler.Run,
+				Policy: restartPolicy,
+			})
+		}
+	}
+
+	// Rebuild the email index logins look users up in from the write database
+	if cfg.EmailIndex.Enabled {
+		if emailIndexRefresher, err := InitializeEmailIndexRefresher(); err != nil {
+			os.Stderr.WriteString("Failed to initialize email index refresher: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name:   "email-index-refresher",
+				Run:    emailIndexRefresher.Run,
+				Policy: restartPolicy,
+			})
+		}
+	}
+
+	// Reset the user counters ListUsers reads to the counts of the write database
+	if cfg.ReadModel.UserCounts {
+		if userCountReconciler, err := InitializeUserCountReconciler(); err != nil {
+			os.Stderr.WriteString("Failed to initialize user count reconciler: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name:   "user-count-reconciler",
+				Run:    userCountReconciler.Run,
+				Policy: restartPolicy,
+			})
+		}
+	}
+
+	// Move expired documents of archived read model collections to their archive
+	if len(cfg.ReadModel.Archive) > 0 {
+		if readModelArchiver, err := InitializeReadModelArchiver(); err != nil {
+			os.Stderr.WriteString("Failed to initialize read model archiver: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name:   "read-model-archiver",
+				Run:    readModelArchiver.Run,
+				Policy: restartPolicy,
+			})
+		}
+	}
+
+	// Keep the checkpointed projections up to date with the event store
+	if cfg.Projections.Enabled {
+		if projectionEngine, err := InitializeProjectionEngine(); err != nil {
+			os.Stderr.WriteString("Failed to initialize projection engine: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name:   "projections",
+				Run:    projectionEngine.Run,
+				Policy: restartPolicy,
+			})
+		}
+	}
+
+	// Rotate tenant data keys and re-encrypt tenant events on earlier keys
+	if cfg.Encryption.Enabled {
+		if keyRotationJob, err := InitializeKeyRotationJob(); err != nil {
+			os.Stderr.WriteString("Failed to initialize key rotation job: " + err.Error() + "\n")
+		} else {
+			components.Go(ctx, supervisor.Component{
+				Name:   "key-rotation",
+				Run:    keyRotationJob.Run,
+				Policy: restartPolicy,
+			})
+		}
+	}
+
+	// Promote the standby consumer while it holds the leader lock
+	if cfg.Standby.Enabled && cfg.Standby.LeaderElection {
+		var electionLogger leader.Logger = &consumers.SimpleLogger{}
 		if logger != nil {
-			logger.Error("Failed to start server: %v", err)
+			electionLogger = logger
+		}
+		if elector, err := newLeaderElector(cfg, eventConsumer, electionLogger); err != nil {
+			os.Stderr.WriteString("Failed to initialize leader election: " + err.Error() + "\n")
 		} else {
-			os.Stderr.WriteString("Failed to start server: " + err.Error() + "\n")
+			components.Go(ctx, supervisor.Component{
+				Name:   "leader-election",
+				Run:    elector.Run,
+				Policy: restartPolicy,
+			})
 		}
+	}
+
+	components.Go(ctx, supervisor.Component{
+		Name:   "event-consumer",
+		Run:    eventConsumer.Run,
+		Policy: restartPolicy,
+	})
+
+	// Serve until SIGTERM, then stop the components in dependency order
+	var lifecycleLogger shutdownLogger = &consumers.SimpleLogger{}
+	if logger != nil {
+		lifecycleLogger = logger
+	}
+	shutdown, err := newShutdownManager(httpServer, probes, eventConsumer, components, stopBackground, lifecycleLogger)
+	if err != nil {
+		os.Stderr.WriteString("Failed to register components for shutdown: " + err.Error() + "\n")
 		os.Exit(1)
 	}
+	os.Exit(serveUntilShutdown(httpServer, grpcPort, gatewayPort, shutdown, cfg.Server.ShutdownDrainTimeout, lifecycleLogger))
+}
+
+// startOTelMetrics installs the OTLP meter provider, exporting the publish and consume durations,
+// and observes the stats of the event consumer and its dead letter queue
+func startOTelMetrics(cfg *config.Config, eventConsumer *consumers.EventConsumerWrapper) error {
+	_, err := metrics.NewMeterProvider(metrics.OTLPConfig{
+		ServiceName:    cfg.Tracing.ServiceName,
+		ServiceVersion: "1.0.0",
+		Endpoint:       cfg.OTelMetrics.ExportEndpoint(cfg.Tracing),
+		Interval:       cfg.OTelMetrics.Interval,
+		Timeout:        cfg.OTelMetrics.Timeout,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = eventConsumer.RegisterMetrics(metrics.Meter())
+	return err
+}
+
+// mountLocalStorage serves signed local storage downloads at the path of baseURL
+func mountLocalStorage(httpServer *grpc.HTTPServer, basePath, baseURL, signingKey string) error {
+	local, err := storage.NewLocalStorage(basePath, baseURL, signingKey)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Path == "" || parsed.Path == "/" {
+		return fmt.Errorf("local storage base URL %q must include a path", baseURL)
+	}
+	prefix := strings.TrimSuffix(parsed.Path, "/") + "/"
+
+	httpServer.Handle(prefix, local.Handler(prefix))
+	return nil
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/pkg/health"
+	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/pkg/startup"
+	"go-clean-ddd-es-template/pkg/supervisor"
+)
+
+// newProbes builds the Kubernetes probes of the server. It is live while its supervised
+// components run, and ready while its databases and message broker respond; the event consumer
+// and circuit breakers only degrade readiness.
+func newProbes(cfg *config.Config, components *supervisor.Supervisor, eventConsumer *consumers.EventConsumerWrapper) *health.Probes {
+	liveness := health.NewHealthService()
+	liveness.AddCheck(health.SystemCheck())
+	liveness.AddCheck(components.HealthCheck())
+
+	readiness := health.NewHealthService()
+	readiness.AddCheck(databases.HealthCheck())
+	if cfg.MessageBroker.Type == "kafka" {
+		readiness.AddCheck(startup.ErrorCheck("message_broker", func(ctx context.Context) (string, error) {
+			return fetchBrokerMetadata(cfg)
+		}))
+	}
+	readiness.AddCheck(eventConsumer.HealthCheck())
+	readiness.AddCheck(resilience.DefaultRegistry().HealthCheck())
+
+	return health.NewProbes(liveness, readiness)
+}
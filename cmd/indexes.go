@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/mongoindex"
+)
+
+var indexesDryRun bool
+
+var indexesCmd = &cobra.Command{
+	Use:   "indexes",
+	Short: "Manage MongoDB read model indexes",
+}
+
+var indexesSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Create missing read model indexes and report undeclared ones",
+	Run: func(cmd *cobra.Command, args []string) {
+		runIndexesSync(indexesDryRun)
+	},
+}
+
+func init() {
+	indexesSyncCmd.Flags().BoolVar(&indexesDryRun, "dry-run", false, "Only report differences without creating indexes")
+	indexesCmd.AddCommand(indexesSyncCmd)
+	rootCmd.AddCommand(indexesCmd)
+}
+
+func runIndexesSync(dryRun bool) {
+	cfg := config.Load()
+
+	report, err := syncReadModelIndexes(context.Background(), cfg, nil, dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to sync indexes: %v\n", err)
+		os.Exit(1)
+	}
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+
+	if report.HasErrors() {
+		os.Exit(1)
+	}
+}
+
+// syncReadModelIndexes reconciles the declared read model indexes with MongoDB
+func syncReadModelIndexes(ctx context.Context, cfg *config.Config, logger mongoindex.Logger, dryRun bool) (*mongoindex.Report, error) {
+	if cfg.ReadDatabase.Type != "mongodb" {
+		return nil, fmt.Errorf("index management requires a mongodb read database, got %s", cfg.ReadDatabase.Type)
+	}
+
+	db, err := database.NewDatabaseFactory().CreateDatabase(&cfg.ReadDatabase)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	client, ok := db.GetDB().(*mongo.Client)
+	if !ok {
+		return nil, fmt.Errorf("read database is not a mongodb client")
+	}
+
+	policies, err := infraRepos.NewRetentionPolicies(cfg.ReadDatabase.Collection, cfg.ReadModel.Retention, cfg.ReadModel.Archive)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := mongoindex.NewRegistry()
+	infraRepos.RegisterUserReadModelIndexes(registry, cfg.ReadDatabase.Collection)
+	infraRepos.RegisterUserSummaryIndexes(registry)
+	infraRepos.RegisterUserChangeLogIndexes(registry)
+	infraRepos.RegisterRetentionIndexes(registry, policies)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	reconciler := mongoindex.NewReconciler(registry, mongoindex.NewMongoIndexManager(client.Database(cfg.ReadDatabase.DBName)), logger, nil)
+	if dryRun {
+		return reconciler.Plan(ctx), nil
+	}
+	return reconciler.Sync(ctx), nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/internal/infrastructure/grpc"
+	"go-clean-ddd-es-template/pkg/health"
+	"go-clean-ddd-es-template/pkg/lifecycle"
+	"go-clean-ddd-es-template/pkg/supervisor"
+)
+
+// databases opens the database pools of the server, closed once everything using them stopped
+var databases = database.NewDatabaseFactory()
+
+// newShutdownManager registers the components of the server so they stop in dependency order:
+// readiness fails first, then the HTTP gateway and gRPC server stop, so no new work comes in,
+// then the event consumer, then the background jobs relaying what its handlers appended and the
+// consumer worker pools with their DLQ scheduler, and the database pools last. stopBackground
+// cancels the background work not run by the supervisor.
+func newShutdownManager(httpServer *grpc.HTTPServer, probes *health.Probes, eventConsumer *consumers.EventConsumerWrapper, components *supervisor.Supervisor, stopBackground context.CancelFunc, logger lifecycle.Logger) (*lifecycle.Manager, error) {
+	manager := lifecycle.NewManager(logger)
+	shutdownComponents := []lifecycle.Component{
+		{
+			Name: "databases",
+			Stop: func(ctx context.Context) error {
+				return databases.CloseAll()
+			},
+		},
+		{
+			Name: "worker-pools",
+			Stop: func(ctx context.Context) error {
+				return waitContext(ctx, eventConsumer.StopWorkers)
+			},
+			DependsOn: []string{"databases"},
+		},
+		{
+			Name: "background-jobs",
+			Stop: func(ctx context.Context) error {
+				stopBackground()
+				return components.Stop(ctx)
+			},
+			DependsOn: []string{"databases"},
+		},
+		{
+			Name: "event-consumer",
+			Stop: func(ctx context.Context) error {
+				return components.Stop(ctx, "event-consumer", "leader-election")
+			},
+			DependsOn: []string{"worker-pools", "background-jobs"},
+		},
+		{
+			Name:      "http-server",
+			Stop:      httpServer.Stop,
+			DependsOn: []string{"event-consumer", "background-jobs", "databases"},
+		},
+		{
+			Name: "readiness",
+			Stop: func(ctx context.Context) error {
+				probes.Drain()
+				return nil
+			},
+			DependsOn: []string{"http-server"},
+		},
+	}
+	for _, component := range shutdownComponents {
+		if err := manager.Register(component); err != nil {
+			return nil, err
+		}
+	}
+	return manager, nil
+}
+
+// shutdownLogger logs the shutdown of the server
+type shutdownLogger interface {
+	lifecycle.Logger
+	Error(format string, v ...interface{})
+}
+
+// waitContext runs stop, waiting for it until ctx is done
+func waitContext(ctx context.Context, stop func()) error {
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serveUntilShutdown serves until the server fails or the process receives SIGINT or SIGTERM,
+// then stops the components of the server, giving them drainTimeout. It returns the exit code.
+func serveUntilShutdown(httpServer *grpc.HTTPServer, grpcPort, gatewayPort string, shutdown *lifecycle.Manager, drainTimeout time.Duration, logger shutdownLogger) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.Start(grpcPort, gatewayPort)
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-served:
+		logger.Error("Failed to start server: %v", err)
+		exitCode = 1
+	case sig := <-signals:
+		logger.Info("Received %s, shutting down within %s", sig, drainTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if err := shutdown.Shutdown(ctx); err != nil {
+		logger.Error("Shutdown incomplete: %v", err)
+		exitCode = 1
+	}
+	return exitCode
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 
 	"github.com/spf13/cobra"
@@ -15,6 +17,8 @@ import (
 	"go-clean-ddd-es-template/pkg/migrations"
 )
 
+var migrateAllowDestructive bool
+
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run database migrations",
@@ -24,6 +28,12 @@ var migrateCmd = &cobra.Command{
 var migrateUpCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Run all pending migrations",
+	Long: `Run all pending migrations of the write and event databases.
+
+Pending migrations are linted first: destructive statements (DROP TABLE, DROP COLUMN, TRUNCATE,
+DELETE without WHERE, type narrowing) are reported, and refused when MIGRATE_PRODUCTION is true
+unless --allow-destructive is passed. Applied migration files are compared with the checksums
+recorded when they were applied; changed files are refused when MIGRATE_VERIFY_CHECKSUMS is true.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		runMigrations("up")
 	},
@@ -32,6 +42,8 @@ var migrateUpCmd = &cobra.Command{
 var migrateDownCmd = &cobra.Command{
 	Use:   "down",
 	Short: "Rollback all migrations",
+	Long: `Rollback all migrations of the write and event databases. In production it requires --yes
+and the environment name, typed or passed with --confirm-env.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		runMigrations("down")
 	},
@@ -48,7 +60,10 @@ var migrateVersionCmd = &cobra.Command{
 var migrateForceCmd = &cobra.Command{
 	Use:   "force [version]",
 	Short: "Force migration version",
-	Args:  cobra.ExactArgs(1),
+	Long: `Record the migration version without running migrations, e.g. to recover from a failed
+migration. In production it requires --yes and the environment name, typed or passed with
+--confirm-env.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		version, err := strconv.Atoi(args[0])
 		if err != nil {
@@ -58,6 +73,15 @@ var migrateForceCmd = &cobra.Command{
 	},
 }
 
+var migrateLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report destructive statements of all migrations",
+	Long:  `Report destructive statements of all write and event database migrations without connecting to a database, exiting with status 1 when any is found`,
+	Run: func(cmd *cobra.Command, args []string) {
+		lintMigrations()
+	},
+}
+
 var migrateCreateCmd = &cobra.Command{
 	Use:   "create [name]",
 	Short: "Create a new migration file",
@@ -73,9 +97,21 @@ func init() {
 	migrateCmd.AddCommand(migrateVersionCmd)
 	migrateCmd.AddCommand(migrateForceCmd)
 	migrateCmd.AddCommand(migrateCreateCmd)
+	migrateCmd.AddCommand(migrateLintCmd)
+	migrateUpCmd.Flags().BoolVar(&migrateAllowDestructive, "allow-destructive", false, "Run destructive migrations in production")
+	guardProduction(migrateDownCmd, migrateForceCmd)
 	rootCmd.AddCommand(migrateCmd)
 }
 
+// connectEventDatabase connects to the event database, sharing the connection of writeDB when the
+// event database is the write database
+func connectEventDatabase(cfg *config.Config, writeDB *sql.DB) (*sql.DB, error) {
+	if cfg.EventDatabase.SameDatabase(cfg.WriteDatabase) {
+		return writeDB, nil
+	}
+	return database.NewPostgresConnection(cfg.EventDatabase)
+}
+
 func runMigrations(action string) {
 	// Load configuration
 	cfg := config.Load()
@@ -93,7 +129,7 @@ func runMigrations(action string) {
 	}
 	defer writeDB.Close()
 
-	eventDB, err := database.NewPostgresConnection(cfg.EventDatabase)
+	eventDB, err := connectEventDatabase(cfg, writeDB)
 	if err != nil {
 		logger.Fatal("Failed to connect to event database", zap.Error(err))
 	}
@@ -120,6 +156,10 @@ func runMigrations(action string) {
 
 	switch action {
 	case "up":
+		if err := preflightMigrations(ctx, migrationManager, cfg.Migrations, logger); err != nil {
+			logger.Fatal("Migration pre-flight failed: %v", err)
+		}
+
 		logger.Info("Running write database migrations...")
 		if err := migrationManager.RunWriteDBMigrations(ctx); err != nil {
 			logger.Fatal("Failed to run write database migrations", zap.Error(err))
@@ -132,6 +172,11 @@ func runMigrations(action string) {
 		}
 		logger.Info("Event database migrations completed")
 
+		// Record the checksums of the migrations just applied
+		if _, err := migrationManager.VerifyChecksums(ctx); err != nil {
+			logger.Fatal("Failed to record migration checksums: %v", err)
+		}
+
 	case "down":
 		logger.Info("Rolling back event database migrations...")
 		if err := migrationManager.EventDBMigrator.Down(ctx); err != nil {
@@ -147,6 +192,52 @@ func runMigrations(action string) {
 	}
 }
 
+// preflightMigrations refuses to migrate when applied migrations changed, or in production when
+// pending migrations are destructive and not explicitly allowed
+func preflightMigrations(ctx context.Context, manager *migrations.MigrationManager, cfg config.MigrationConfig, logger logger.Logger) error {
+	mismatches, err := manager.VerifyChecksums(ctx)
+	if err != nil {
+		return err
+	}
+	for _, mismatch := range mismatches {
+		logger.Error("Applied migration %s", mismatch)
+	}
+	if len(mismatches) > 0 && cfg.VerifyChecksums {
+		return fmt.Errorf("%d applied migrations changed since they were applied", len(mismatches))
+	}
+
+	findings, err := manager.Preflight(ctx)
+	if err != nil {
+		return err
+	}
+	for _, finding := range findings {
+		logger.Warn("Destructive migration %s", finding)
+	}
+	if len(findings) > 0 && cfg.Production && !migrateAllowDestructive {
+		return fmt.Errorf("%d destructive statements in pending migrations, pass --allow-destructive to run them in production", len(findings))
+	}
+	return nil
+}
+
+func lintMigrations() {
+	var findings []migrations.Finding
+	for _, dir := range []string{"./migrations/write", "./migrations/event"} {
+		dirFindings, err := migrations.LintDir(dir, 0)
+		if err != nil {
+			log.Fatalf("Failed to lint migrations: %v", err)
+		}
+		findings = append(findings, dirFindings...)
+	}
+
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+	if len(findings) > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("No destructive migrations")
+}
+
 func showMigrationVersion() {
 	// Load configuration
 	cfg := config.Load()
@@ -158,7 +249,7 @@ func showMigrationVersion() {
 	}
 	defer writeDB.Close()
 
-	eventDB, err := database.NewPostgresConnection(cfg.EventDatabase)
+	eventDB, err := connectEventDatabase(cfg, writeDB)
 	if err != nil {
 		log.Fatalf("Failed to connect to event database: %v", err)
 	}
@@ -205,7 +296,7 @@ func forceMigrationVersion(version int) {
 	}
 	defer writeDB.Close()
 
-	eventDB, err := database.NewPostgresConnection(cfg.EventDatabase)
+	eventDB, err := connectEventDatabase(cfg, writeDB)
 	if err != nil {
 		log.Fatalf("Failed to connect to event database: %v", err)
 	}
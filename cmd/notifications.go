@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/notification"
+	"go-clean-ddd-es-template/pkg/retry"
+)
+
+var notificationsCmd = &cobra.Command{
+	Use:   "notifications",
+	Short: "Deliver the notifications sent to users",
+}
+
+var notificationsWorkerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Deliver the notifications published to the notification topic",
+	Long: `Consume the notifications the service publishes to NOTIFICATION_TOPIC of the message
+broker with NOTIFICATION_PUBLISHER=topic, e.g. email verification links, and deliver them
+through the SMTP server, the webhook, or the log when neither is set. Failed deliveries are
+retried, then logged and dropped. Runs until SIGINT or SIGTERM.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runNotificationsWorker(config.Load()); err != nil {
+			fmt.Fprintf(os.Stderr, "Notifications worker failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	notificationsCmd.AddCommand(notificationsWorkerCmd)
+	rootCmd.AddCommand(notificationsCmd)
+}
+
+// runNotificationsWorker delivers the notifications of the notification topic until the process
+// receives SIGINT or SIGTERM
+func runNotificationsWorker(cfg *config.Config) error {
+	broker, err := messagebroker.NewMessageBrokerFactory().CreateMessageBroker(&cfg.MessageBroker)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the message broker: %w", err)
+	}
+	defer broker.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger := &consumers.SimpleLogger{}
+	worker := notification.NewWorker(newNotificationDelivery(cfg), retry.DefaultPolicy(), logger)
+	// Failures are logged by the worker, and dropped so they do not block the notifications after them
+	if err := broker.Subscribe(cfg.Notification.Topic, func(payload []byte) {
+		_ = worker.Handle(ctx, payload)
+	}); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", cfg.Notification.Topic, err)
+	}
+
+	logger.Info("Delivering the notifications of %s", cfg.Notification.Topic)
+	<-ctx.Done()
+	logger.Info("Notifications worker stopped")
+	return nil
+}
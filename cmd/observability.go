@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/grpc"
+	"go-clean-ddd-es-template/pkg/slo"
+)
+
+// observabilityOptions holds the flags of the observability commands
+type observabilityOptions struct {
+	output string
+	window time.Duration
+	check  bool
+}
+
+var observabilityFlags observabilityOptions
+
+var observabilityCmd = &cobra.Command{
+	Use:   "observability",
+	Short: "Observability tooling",
+}
+
+var observabilityGenCmd = &cobra.Command{
+	Use:   "gen",
+	Short: "Generate Prometheus SLO recording and alerting rules",
+	Long: `Generate Prometheus recording and alerting rules from the latency and error
+budgets declared for the gRPC services and HTTP handlers, so alerts stay in sync
+with the code. Use --check in CI to fail when the rule file is out of date.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := generateSLORules(&observabilityFlags); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	observabilityGenCmd.Flags().StringVarP(&observabilityFlags.output, "output", "o", "monitoring/rules/slo_rules.yml", "Path of the generated rule file")
+	observabilityGenCmd.Flags().DurationVar(&observabilityFlags.window, "window", slo.DefaultRuleWindow, "Rate window of the recording rules")
+	observabilityGenCmd.Flags().BoolVar(&observabilityFlags.check, "check", false, "Only verify that the rule file is up to date")
+
+	observabilityCmd.AddCommand(observabilityGenCmd)
+	rootCmd.AddCommand(observabilityCmd)
+}
+
+// generateSLORules writes the rules for the declared budgets, or compares them with the file in check mode
+func generateSLORules(options *observabilityOptions) error {
+	registry := slo.NewRegistry()
+	grpc.RegisterServiceBudgets(registry)
+
+	rules, err := slo.GenerateRules(registry.Budgets(), options.window)
+	if err != nil {
+		return err
+	}
+	data, err := rules.YAML()
+	if err != nil {
+		return err
+	}
+
+	if options.check {
+		existing, err := os.ReadFile(options.output)
+		if err != nil {
+			return fmt.Errorf("failed to read rule file: %w", err)
+		}
+		if !bytes.Equal(existing, data) {
+			return fmt.Errorf("%s is out of date, run \"observability gen\"", options.output)
+		}
+		fmt.Printf("%s is up to date\n", options.output)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(options.output), 0o755); err != nil {
+		return fmt.Errorf("failed to create rule directory: %w", err)
+	}
+	if err := os.WriteFile(options.output, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write rule file: %w", err)
+	}
+
+	fmt.Printf("Generated rules for %d budgets in %s\n", len(registry.Budgets()), options.output)
+	return nil
+}
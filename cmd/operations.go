@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/approval"
+	"go-clean-ddd-es-template/pkg/dataio"
+	"go-clean-ddd-es-template/pkg/id"
+	"go-clean-ddd-es-template/pkg/operation"
+	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/pkg/storage"
+)
+
+// Kinds of the long-running operations started through the admin operations API
+const (
+	projectionRebuildOperation = "projection.rebuild"
+	dlqExportOperation         = "dlq.export"
+	dlqRetryOperation          = "dlq.retry"
+)
+
+// dlqRetryPageSize is the number of failed events looked up per page before a bulk retry
+const dlqRetryPageSize = 500
+
+// newOperationManager creates the manager of the long-running admin operations: projection
+// rebuilds, exports of the dead letter queue to object storage and bulk retries of failed events
+func newOperationManager(cfg *config.Config, eventConsumer *consumers.EventConsumerWrapper, logger operation.Logger) (*operation.Manager, error) {
+	var store operation.Store = operation.NewMemoryStore()
+	if cfg.Operations.Store == "postgres" {
+		writeDB, err := databases.CreateDatabase(&cfg.WriteDatabase)
+		if err != nil {
+			return nil, err
+		}
+		store = infraRepos.NewPostgresOperationStore(writeDB.GetDB())
+	}
+	manager := operation.NewManager(store, cfg.Operations.Retention, logger, nil)
+
+	manager.Register(projectionRebuildOperation, func(args map[string]string) (operation.Task, error) {
+		batchSize, err := intArg(args, "batch_size", cfg.ReadModel.MigrationBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, report operation.Reporter) (interface{}, error) {
+			return rebuildProjectionsOperation(ctx, cfg, eventConsumer, batchSize, report)
+		}, nil
+	})
+
+	objects, err := provideStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	manager.Register(dlqExportOperation, func(args map[string]string) (operation.Task, error) {
+		name := args["format"]
+		if name == "" {
+			name = string(dataio.FormatJSONL)
+		}
+		format, err := dataio.ParseFormat(name, "")
+		if err != nil {
+			return nil, err
+		}
+		filter, err := dlqFilterArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, report operation.Reporter) (interface{}, error) {
+			return exportDLQOperation(ctx, eventConsumer, objects, cfg.Storage.SignedURLTTL, format, filter, report)
+		}, nil
+	})
+
+	manager.Register(dlqRetryOperation, func(args map[string]string) (operation.Task, error) {
+		filter, err := dlqFilterArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, report operation.Reporter) (interface{}, error) {
+			return retryDLQOperation(ctx, eventConsumer, filter, report)
+		}, nil
+	})
+	return manager, nil
+}
+
+// registerOperationApprovals makes projection rebuilds need the approval of a second admin,
+// starting the operation once approved
+func registerOperationApprovals(workflow *approval.Workflow, manager *operation.Manager) {
+	workflow.Register(projectionRebuildOperation, func(ctx context.Context, request approval.Request) error {
+		_, err := manager.Start(ctx, request.Action, request.Args, request.RequestedBy)
+		return err
+	})
+}
+
+// rebuildProjectionsOperation rebuilds the projections, keeping the event consumer of this
+// instance on standby meanwhile so it does not apply events to the read models being rebuilt.
+// Pause the consumers of the other instances first, e.g. through the control channel.
+func rebuildProjectionsOperation(ctx context.Context, cfg *config.Config, eventConsumer *consumers.EventConsumerWrapper, batchSize int, report operation.Reporter) (consumers.RebuildProgress, error) {
+	rebuild, err := openProjectionRebuild(cfg, batchSize)
+	if err != nil {
+		return consumers.RebuildProgress{}, err
+	}
+	defer rebuild.close()
+
+	if !eventConsumer.Standby() {
+		eventConsumer.SetStandby(true)
+		defer eventConsumer.SetStandby(false)
+	}
+	return rebuild.rebuilder.Rebuild(ctx, func(progress consumers.RebuildProgress) {
+		report(int64(progress.Replayed+progress.Skipped), int64(progress.Total))
+	})
+}
+
+// dlqExportResult is the result of a dead letter queue export
+type dlqExportResult struct {
+	Key      string `json:"key"`
+	Exported int    `json:"exported"`
+	URL      string `json:"url"` // Signed download URL, valid for STORAGE_SIGNED_URL_TTL
+}
+
+// exportDLQOperation streams the failed events matching filter to object storage, under
+// exports/dlq/<ULID>.<format>
+func exportDLQOperation(ctx context.Context, eventConsumer *consumers.EventConsumerWrapper, objects storage.Storage, urlTTL time.Duration, format dataio.Format, filter resilience.DLQFilter, report operation.Reporter) (dlqExportResult, error) {
+	_, total, err := eventConsumer.FindFailedEvents(ctx, filter, 1, 0)
+	if err != nil {
+		return dlqExportResult{}, fmt.Errorf("failed to count failed events: %w", err)
+	}
+	report(0, int64(total))
+
+	contentType := "application/x-ndjson"
+	if format == dataio.FormatCSV {
+		contentType = "text/csv"
+	}
+	result := dlqExportResult{Key: fmt.Sprintf("exports/dlq/%s.%s", id.NewGenerator(nil).ULID(), format)}
+
+	reader, pipe := io.Pipe()
+	exported := make(chan int, 1)
+	go func() {
+		writer, err := dataio.NewWriter(pipe, format, resilience.FailedEventFields, false)
+		count := 0
+		if err == nil {
+			count, err = eventConsumer.ExportFailedEvents(ctx, &reportingWriter{Writer: writer, total: int64(total), report: report}, filter)
+		}
+		pipe.CloseWithError(err)
+		exported <- count
+	}()
+	_, err = objects.Put(ctx, result.Key, reader, -1, storage.PutOptions{ContentType: contentType})
+	reader.CloseWithError(err)
+	result.Exported = <-exported
+	if err != nil {
+		// Do not keep a truncated export around
+		_ = objects.Delete(context.Background(), result.Key)
+		return dlqExportResult{}, fmt.Errorf("failed to store export: %w", err)
+	}
+
+	if result.URL, err = objects.SignedURL(ctx, result.Key, http.MethodGet, urlTTL); err != nil {
+		return dlqExportResult{}, fmt.Errorf("failed to sign export URL: %w", err)
+	}
+	return result, nil
+}
+
+// reportingWriter reports the records written as the progress of an operation
+type reportingWriter struct {
+	dataio.Writer
+	written int64
+	total   int64
+	report  operation.Reporter
+}
+
+// Write writes a record and reports it
+func (w *reportingWriter) Write(record dataio.Record) error {
+	if err := w.Writer.Write(record); err != nil {
+		return err
+	}
+	w.written++
+	w.report(w.written, w.total)
+	return nil
+}
+
+// dlqRetryResult is the result of a bulk retry of failed events
+type dlqRetryResult struct {
+	Retried int      `json:"retried"`
+	Failed  []string `json:"failed,omitempty"` // IDs of the events whose retry failed
+}
+
+// retryDLQOperation retries the failed events matching filter. The matching events are looked up
+// first, since retried events leave the dead letter queue.
+func retryDLQOperation(ctx context.Context, eventConsumer *consumers.EventConsumerWrapper, filter resilience.DLQFilter, report operation.Reporter) (dlqRetryResult, error) {
+	var ids []string
+	for offset := 0; ; offset += dlqRetryPageSize {
+		events, total, err := eventConsumer.FindFailedEvents(ctx, filter, dlqRetryPageSize, offset)
+		if err != nil {
+			return dlqRetryResult{}, fmt.Errorf("failed to list failed events: %w", err)
+		}
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		if len(events) == 0 || offset+len(events) >= total {
+			break
+		}
+	}
+
+	var result dlqRetryResult
+	report(0, int64(len(ids)))
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := eventConsumer.RetryFailedEvent(ctx, id); err != nil {
+			result.Failed = append(result.Failed, id)
+		} else {
+			result.Retried++
+		}
+		report(int64(i+1), int64(len(ids)))
+	}
+	return result, nil
+}
+
+// dlqFilterArgs parses the event_type, topic, since and until args of a dead letter queue operation
+func dlqFilterArgs(args map[string]string) (resilience.DLQFilter, error) {
+	filter := resilience.DLQFilter{
+		EventType: args["event_type"],
+		Topic:     args["topic"],
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if args[name] == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, args[name])
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 time: %w", name, err)
+		}
+		*t = parsed
+	}
+	return filter, nil
+}
+
+// intArg parses an optional positive integer arg of an operation
+func intArg(args map[string]string, name string, fallback int) (int, error) {
+	if args[name] == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(args[name])
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/projection"
+)
+
+// projectionOptions holds the flags of the projection commands
+type projectionOptions struct {
+	batchSize int
+	yes       bool
+}
+
+var projectionFlags projectionOptions
+
+var projectionCmd = &cobra.Command{
+	Use:   "projection",
+	Short: "Manage the projections of events into read models",
+}
+
+var projectionRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Rebuild the read models by replaying the whole event store",
+	Long: `Truncate the read models projected from events, then replay every event of the event
+store, in the order events were stored, through the projections enabled for this deployment,
+to recover read models after a projection bug. Stop the event consumers first: events they
+apply during the rebuild are lost or applied twice. The inbox is kept, so events consumed
+again after the rebuild are not applied twice. A failed rebuild stops at the failing event;
+run it again once fixed. In production it requires --yes and the environment name, typed or
+passed with --confirm-env.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runProjectionRebuild(&projectionFlags)
+	},
+}
+
+var eventsReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay the whole event store into the read models",
+	Long: `Replay every event of the event store into the read models, as "projection rebuild"
+does: the read models projected from events are truncated first, so stop the event
+consumers before. In production it requires --yes and the environment name, typed or
+passed with --confirm-env.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runProjectionRebuild(&projectionFlags)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{projectionRebuildCmd, eventsReplayCmd} {
+		c.Flags().IntVar(&projectionFlags.batchSize, "batch-size", 0, "Number of events read per batch (defaults to READ_MODEL_MIGRATION_BATCH_SIZE)")
+		c.Flags().BoolVarP(&projectionFlags.yes, "yes", "y", false, "Do not ask for confirmation")
+	}
+	guardProduction(projectionRebuildCmd, eventsReplayCmd)
+
+	projectionCmd.AddCommand(projectionRebuildCmd)
+	eventsCmd.AddCommand(eventsReplayCmd)
+	rootCmd.AddCommand(projectionCmd)
+}
+
+func runProjectionRebuild(options *projectionOptions) {
+	cfg := config.Load()
+	batchSize := options.batchSize
+	if batchSize <= 0 {
+		batchSize = cfg.ReadModel.MigrationBatchSize
+	}
+
+	progress, err := rebuildProjections(context.Background(), cfg, batchSize, options.yes)
+	fmt.Printf("Replayed %d of %d events, %d skipped\n", progress.Replayed, progress.Total, progress.Skipped)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to rebuild projections: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// rebuildProjections truncates the read models and replays the event store through the
+// projections, printing progress after each batch
+func rebuildProjections(ctx context.Context, cfg *config.Config, batchSize int, yes bool) (consumers.RebuildProgress, error) {
+	rebuild, err := openProjectionRebuild(cfg, batchSize)
+	if err != nil {
+		return consumers.RebuildProgress{}, err
+	}
+	defer rebuild.close()
+
+	question := fmt.Sprintf("Truncate %s and replay the event store?", strings.Join(rebuild.collections, ", "))
+	if !yes && !confirm(os.Stdout, bufio.NewScanner(os.Stdin), question) {
+		return consumers.RebuildProgress{}, fmt.Errorf("rebuild cancelled")
+	}
+
+	return rebuild.rebuilder.Rebuild(ctx, func(progress consumers.RebuildProgress) {
+		done := progress.Replayed + progress.Skipped
+		percent := 100.0
+		if progress.Total > 0 {
+			percent = float64(done) * 100 / float64(progress.Total)
+		}
+		fmt.Printf("Replayed %d/%d events (%.1f%%)\n", done, progress.Total, percent)
+	})
+}
+
+// projectionRebuild is a rebuilder of the projections with its own database connections
+type projectionRebuild struct {
+	rebuilder   *consumers.ProjectionRebuilder
+	collections []string // Read model collections truncated by the rebuild
+	close       func()
+}
+
+// openProjectionRebuild connects to the read and event databases to rebuild the projections
+// enabled for this deployment; close the rebuild once done
+func openProjectionRebuild(cfg *config.Config, batchSize int) (*projectionRebuild, error) {
+	databaseFactory := database.NewDatabaseFactory()
+	readDB, err := databaseFactory.CreateDatabase(&cfg.ReadDatabase)
+	if err != nil {
+		return nil, err
+	}
+	eventDB, err := databaseFactory.CreateDatabase(&cfg.EventDatabase)
+	if err != nil {
+		readDB.Close()
+		return nil, err
+	}
+	closeDatabases := func() {
+		eventDB.Close()
+		readDB.Close()
+	}
+
+	factory := infraRepos.NewRepositoryFactory(nil, readDB, eventDB, cfg)
+	reset, err := factory.CreateReadModelReset()
+	if err != nil {
+		closeDatabases()
+		return nil, err
+	}
+	eventLog, err := projectionEventLog(factory, cfg)
+	if err != nil {
+		closeDatabases()
+		return nil, err
+	}
+	handlers, err := projectionHandlers(factory, cfg)
+	if err != nil {
+		closeDatabases()
+		return nil, err
+	}
+
+	return &projectionRebuild{
+		rebuilder:   consumers.NewProjectionRebuilder(eventLog, reset, handlers, batchSize),
+		collections: reset.Collections(),
+		close:       closeDatabases,
+	}, nil
+}
+
+// projectionEventLog returns the event store read as a whole, decrypting tenant event data when
+// encryption is enabled
+func projectionEventLog(factory *infraRepos.RepositoryFactory, cfg *config.Config) (repositories.EventLog, error) {
+	eventStore, err := factory.CreateEventStore()
+	if err != nil {
+		return nil, err
+	}
+	if keyring := provideKeyring(cfg); keyring != nil {
+		eventStore = infraRepos.NewEncryptingEventStore(eventStore, keyring)
+	}
+	eventLog, ok := eventStore.(repositories.EventLog)
+	if !ok {
+		return nil, fmt.Errorf("event store %T does not support reading all events", eventStore)
+	}
+	return eventLog, nil
+}
+
+// projectionHandlers returns the projections enabled for this deployment, by event type. Events
+// are applied without the inbox and processed event checks of the consumers.
+func projectionHandlers(factory *infraRepos.RepositoryFactory, cfg *config.Config) (map[string]consumers.LegacyEventHandler, error) {
+	readRepository, err := factory.CreateUserReadRepository()
+	if err != nil {
+		return nil, err
+	}
+	var summaryRepository repositories.UserSummaryRepository
+	if cfg.ReadModel.UserSummaries {
+		if summaryRepository, err = factory.CreateUserSummaryRepository(); err != nil {
+			return nil, err
+		}
+	}
+	var countRepository repositories.UserCountRepository
+	if cfg.ReadModel.UserCounts {
+		if countRepository, err = factory.CreateUserCountRepository(); err != nil {
+			return nil, err
+		}
+	}
+	var changeLogRepository repositories.UserChangeLogRepository
+	if cfg.Changefeed.Enabled {
+		if changeLogRepository, err = factory.CreateUserChangeLogRepository(); err != nil {
+			return nil, err
+		}
+	}
+
+	userEventHandler := consumers.NewUserEventHandler(readRepository)
+	userHandler, loginHandler := userProjections(userEventHandler, summaryRepository, countRepository, changeLogRepository, clock.New())
+	productHandler := consumers.NewProductEventHandler()
+	handlers := map[string]consumers.LegacyEventHandler{
+		"user.created":             userHandler,
+		"user.updated":             userHandler,
+		"user.deleted":             userHandler,
+		"user.preferences_updated": userEventHandler, // Only kept in the read model
+		"user.email_verified":      userEventHandler, // Only kept in the read model
+		"user.avatar_updated":      userEventHandler, // Only kept in the read model
+		"product.created":          productHandler,
+		"product.updated":          productHandler,
+		"product.deleted":          productHandler,
+	}
+	if loginHandler != nil {
+		handlers["user.login"] = loginHandler
+	}
+	for eventType := range handlers {
+		if !cfg.Components.HandlerEnabled(eventType) {
+			delete(handlers, eventType)
+		}
+	}
+	return handlers, nil
+}
+
+// userProjections returns the handler applying user events to the user projections enabled, and
+// the handler of user logins, nil when no projection records them
+func userProjections(
+	userEventHandler *consumers.UserEventHandler,
+	userSummaryRepository repositories.UserSummaryRepository,
+	userCountRepository repositories.UserCountRepository,
+	userChangeLogRepository repositories.UserChangeLogRepository,
+	clk clock.Clock,
+) (userHandler, loginHandler consumers.LegacyEventHandler) {
+	// Project user events into user summaries before the read model: summary writes are idempotent,
+	// so they do not fail when an event is redelivered after the read model handler failed
+	userHandler = userEventHandler
+	var projections []consumers.LegacyEventHandler
+	if userSummaryRepository != nil {
+		summaryProjector := consumers.NewUserSummaryProjector(userSummaryRepository)
+		projections = append(projections, summaryProjector)
+		loginHandler = summaryProjector
+	}
+	// Record changes for the changefeed, keyed by event so redelivered events are recorded once
+	if userChangeLogRepository != nil {
+		projections = append(projections, consumers.NewUserChangeLogProjector(userChangeLogRepository, clk))
+	}
+	projections = append(projections, userEventHandler)
+	// Count created and deleted users once the other projections applied the event: increments are
+	// not idempotent, so events redelivered after another projection failed are counted once
+	if userCountRepository != nil {
+		projections = append(projections, consumers.NewUserCountProjector(userCountRepository))
+	}
+	if len(projections) > 1 {
+		userHandler = consumers.NewMultiEventHandler(projections...)
+	}
+	return userHandler, loginHandler
+}
+
+// checkpointedProjections returns the projections run by the projection engine, each resuming from
+// its checkpoint. Add read models here with projection.Define, giving the handler of each event
+// type they handle; a new projection catches up from the first event stored.
+func checkpointedProjections(factory *infraRepos.RepositoryFactory, cfg *config.Config) ([]projection.Projection, error) {
+	return nil, nil
+}
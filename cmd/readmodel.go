@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+)
+
+var readModelBatchSize int
+
+var readModelCmd = &cobra.Command{
+	Use:   "readmodel",
+	Short: "Manage read model documents",
+}
+
+var readModelMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate all outdated read model documents to the latest schema version",
+	Run: func(cmd *cobra.Command, args []string) {
+		runReadModelMigrate(readModelBatchSize)
+	},
+}
+
+var readModelSummariesCmd = &cobra.Command{
+	Use:   "summaries",
+	Short: "Backfill the user summary projection from the read model",
+	Long: `Create or refresh the user_summaries document of every user in the read model. Run it
+after enabling READ_MODEL_USER_SUMMARIES; the projector keeps summaries up to date from then on.
+Recorded last logins are kept.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runReadModelSummaries(readModelBatchSize)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{readModelMigrateCmd, readModelSummariesCmd} {
+		c.Flags().IntVar(&readModelBatchSize, "batch-size", 0, "Number of documents read per page (defaults to READ_MODEL_MIGRATION_BATCH_SIZE)")
+		readModelCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(readModelCmd)
+}
+
+func runReadModelMigrate(batchSize int) {
+	cfg := config.Load()
+	if batchSize <= 0 {
+		batchSize = cfg.ReadModel.MigrationBatchSize
+	}
+
+	report, err := migrateReadModel(context.Background(), cfg, batchSize)
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to migrate read model: %v\n", err)
+		os.Exit(1)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// migrateReadModel migrates every outdated read model document to the latest schema version
+func migrateReadModel(ctx context.Context, cfg *config.Config, batchSize int) (infraRepos.MigrationReport, error) {
+	db, err := database.NewDatabaseFactory().CreateDatabase(&cfg.ReadDatabase)
+	if err != nil {
+		return infraRepos.MigrationReport{}, err
+	}
+	defer db.Close()
+
+	migrator, err := infraRepos.NewRepositoryFactory(nil, db, nil, cfg).CreateUserReadModelMigrator()
+	if err != nil {
+		return infraRepos.MigrationReport{}, err
+	}
+	return migrator.MigrateAll(ctx, batchSize)
+}
+
+func runReadModelSummaries(batchSize int) {
+	cfg := config.Load()
+	if batchSize <= 0 {
+		batchSize = cfg.ReadModel.MigrationBatchSize
+	}
+
+	backfilled, err := backfillUserSummaries(context.Background(), cfg, batchSize)
+	fmt.Printf("Backfilled %d user summaries\n", backfilled)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to backfill user summaries: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// backfillUserSummaries saves the summary of every user in the read model, page by page
+func backfillUserSummaries(ctx context.Context, cfg *config.Config, batchSize int) (int, error) {
+	db, err := database.NewDatabaseFactory().CreateDatabase(&cfg.ReadDatabase)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	factory := infraRepos.NewRepositoryFactory(nil, db, nil, cfg)
+	readRepository, err := factory.CreateUserReadRepository()
+	if err != nil {
+		return 0, err
+	}
+	summaryRepository, err := factory.CreateUserSummaryRepository()
+	if err != nil {
+		return 0, err
+	}
+
+	backfilled := 0
+	for page := 1; ; page++ {
+		users, _, err := readRepository.ListUsers(ctx, page, batchSize)
+		if err != nil {
+			return backfilled, err
+		}
+		for _, user := range users {
+			if err := summaryRepository.SaveSummary(ctx, &entities.UserSummary{
+				UserID:    user.UserID,
+				Email:     user.Email,
+				Name:      user.Name,
+				Status:    entities.UserStatusActive,
+				CreatedAt: user.CreatedAt,
+			}); err != nil {
+				return backfilled, fmt.Errorf("failed to save summary of user %s: %w", user.UserID, err)
+			}
+			backfilled++
+		}
+		if len(users) < batchSize {
+			return backfilled, nil
+		}
+	}
+}
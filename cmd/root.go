@@ -3,6 +3,9 @@ package cmd
 import (
 	"os"
 
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+
 	"github.com/spf13/cobra"
 )
 
@@ -31,9 +34,17 @@ func Execute() {
 }
 
 func init() {
+	cobra.OnInitialize(configureDomainPolicies)
+
 	// Global persistent flags
 	rootCmd.PersistentFlags().StringVarP(&port, "port", "p", "8080", "Port to run the server on")
 
 	// Add commands
 	rootCmd.AddCommand(grpcCmd)
 }
+
+// configureDomainPolicies applies configuration to domain validation rules
+func configureDomainPolicies() {
+	cfg := config.Load()
+	entities.SetEmailPolicy(entities.EmailPolicy{AllowInternational: cfg.Email.AllowInternational})
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+)
+
+// guardProduction makes commands that destroy or rewrite data refuse to run in production unless
+// passed --yes, then the name of the environment is typed, or passed with --confirm-env when no
+// one is at the terminal. Outside production the commands run as before. Call it once the flags
+// of the commands are defined: commands without a --yes flag are given one.
+func guardProduction(commands ...*cobra.Command) {
+	for _, c := range commands {
+		if c.Flags().Lookup("yes") == nil {
+			c.Flags().BoolP("yes", "y", false, "Confirm the command in production")
+		}
+		c.Flags().String("confirm-env", "", "Name of the environment, confirming the command in production without typing it")
+		// Cobra runs PreRunE instead of PreRun when both are set, so the guard takes the place of
+		// whichever hook the command already has and runs it once confirmed
+		preRun, preRunE := c.PreRun, c.PreRunE
+		c.PreRun = nil
+		c.PreRunE = func(cmd *cobra.Command, args []string) error {
+			if err := confirmCommand(cmd); err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("refusing to run %s: %w", cmd.CommandPath(), err)
+			}
+			if preRunE != nil {
+				return preRunE(cmd, args)
+			}
+			if preRun != nil {
+				preRun(cmd, args)
+			}
+			return nil
+		}
+	}
+}
+
+// confirmCommand checks that cmd is confirmed for production by its flags, or the environment
+// name typed on its input
+func confirmCommand(cmd *cobra.Command) error {
+	yes, _ := cmd.Flags().GetBool("yes")
+	typed, _ := cmd.Flags().GetString("confirm-env")
+	return confirmProduction(config.Load(), yes, typed, cmd.OutOrStdout(), bufio.NewScanner(cmd.InOrStdin()))
+}
+
+// confirmProduction checks that a command is confirmed for the environment of cfg: in production
+// it must be passed --yes and the environment name, asked on in when typed is empty
+func confirmProduction(cfg *config.Config, yes bool, typed string, out io.Writer, in *bufio.Scanner) error {
+	if !cfg.IsProduction() {
+		return nil
+	}
+
+	environment := cfg.Environment.Name
+	if !yes {
+		return fmt.Errorf("environment %q is production, pass --yes to confirm", environment)
+	}
+	if typed == "" {
+		fmt.Fprintf(out, "Environment %q is production. Type its name to continue: ", environment)
+		if in.Scan() {
+			typed = strings.TrimSpace(in.Text())
+		}
+	}
+	if !cfg.IsEnvironment(typed) {
+		return fmt.Errorf("environment name %q does not match %q", typed, environment)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+)
+
+func productionConfig() *config.Config {
+	return &config.Config{Environment: config.EnvironmentConfig{Name: "Production", ProductionNames: []string{"production", "prod"}}}
+}
+
+func TestConfirmProduction(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *config.Config
+		yes     bool
+		typed   string
+		input   string
+		wantErr string
+	}{
+		{name: "outside production", cfg: &config.Config{Environment: config.EnvironmentConfig{Name: "staging", ProductionNames: []string{"production"}}}},
+		{name: "missing --yes", cfg: productionConfig(), typed: "Production", wantErr: "pass --yes"},
+		{name: "wrong name", cfg: productionConfig(), yes: true, typed: "staging", wantErr: "does not match"},
+		{name: "wrong name typed", cfg: productionConfig(), yes: true, input: "prod\n", wantErr: "does not match"},
+		{name: "nothing typed", cfg: productionConfig(), yes: true, wantErr: "does not match"},
+		{name: "correct name", cfg: productionConfig(), yes: true, typed: "Production"},
+		{name: "correct name in another case", cfg: productionConfig(), yes: true, typed: "production"},
+		{name: "correct name typed", cfg: productionConfig(), yes: true, input: " PRODUCTION \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := confirmProduction(tt.cfg, tt.yes, tt.typed, &out, bufio.NewScanner(strings.NewReader(tt.input)))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGuardProduction_KeepsPreRun(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("MIGRATE_PRODUCTION", "false")
+
+	var ran []string
+	withPreRun := &cobra.Command{Use: "pre-run", PreRun: func(cmd *cobra.Command, args []string) { ran = append(ran, "pre-run") }}
+	withPreRunE := &cobra.Command{Use: "pre-run-e", PreRunE: func(cmd *cobra.Command, args []string) error {
+		ran = append(ran, "pre-run-e")
+		return nil
+	}}
+	guardProduction(withPreRun, withPreRunE)
+
+	require.NoError(t, withPreRun.PreRunE(withPreRun, nil))
+	require.NoError(t, withPreRunE.PreRunE(withPreRunE, nil))
+	assert.Equal(t, []string{"pre-run", "pre-run-e"}, ran)
+}
+
+func TestGuardedCommands_RefuseUnconfirmedInProduction(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+
+	for _, path := range []string{"migrate down", "migrate force", "dlq delete", "dlq purge", "projection rebuild", "events replay"} {
+		t.Run(path, func(t *testing.T) {
+			cmd, _, err := rootCmd.Find(strings.Fields(path))
+			require.NoError(t, err)
+			require.Equal(t, path, strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" "))
+			require.NotNil(t, cmd.PreRunE, "the command is guarded")
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetIn(strings.NewReader("staging\n"))
+			t.Cleanup(func() {
+				cmd.SetOut(nil)
+				cmd.SetIn(nil)
+				require.NoError(t, cmd.Flags().Set("yes", "false"))
+				require.NoError(t, cmd.Flags().Set("confirm-env", ""))
+			})
+
+			assert.ErrorContains(t, cmd.PreRunE(cmd, nil), "pass --yes to confirm")
+
+			// Confirmed with --yes, the environment name must still be typed
+			require.NoError(t, cmd.Flags().Set("yes", "true"))
+			assert.ErrorContains(t, cmd.PreRunE(cmd, nil), `environment name "staging" does not match`)
+			assert.Contains(t, out.String(), "Type its name to continue")
+
+			require.NoError(t, cmd.Flags().Set("confirm-env", "production"))
+			assert.NoError(t, cmd.PreRunE(cmd, nil))
+		})
+	}
+}
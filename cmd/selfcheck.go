@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/internal/infrastructure/grpc"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/health"
+	"go-clean-ddd-es-template/pkg/i18n"
+	"go-clean-ddd-es-template/pkg/migrations"
+	"go-clean-ddd-es-template/pkg/startup"
+)
+
+// newStartupSelfCheck builds the startup self-check with all boot probes
+func newStartupSelfCheck(cfg *config.Config, strict bool) *startup.SelfCheck {
+	selfCheck := startup.NewSelfCheck(strict, 30*time.Second)
+
+	selfCheck.AddProbe("config", true, startup.ErrorCheck("config", func(ctx context.Context) (string, error) {
+		if err := cfg.Validate(); err != nil {
+			return "", err
+		}
+		return "Configuration is valid", nil
+	}))
+
+	// Only probe the dependencies of the services and handlers enabled for this deployment
+	servesCommands := cfg.Components.ServiceEnabled(grpc.UserServiceName) || cfg.Components.ServiceEnabled(grpc.AuthServiceName)
+	projectsEvents := len(activeEventTypes(cfg)) > 0
+
+	selfCheck.AddProbe("components", false, startup.ErrorCheck("components", func(ctx context.Context) (string, error) {
+		return fmt.Sprintf("Disabled services: %v, disabled event handlers: %v, projected events: %v",
+			cfg.Components.DisabledServices, cfg.Components.DisabledHandlers, activeEventTypes(cfg)), nil
+	}))
+
+	if servesCommands {
+		selfCheck.AddProbe("write_database", true, startup.ErrorCheck("write_database", func(ctx context.Context) (string, error) {
+			return pingDatabase(ctx, &cfg.WriteDatabase)
+		}))
+	}
+
+	if cfg.Components.ServiceEnabled(grpc.UserServiceName) || projectsEvents {
+		selfCheck.AddProbe("read_database", true, startup.ErrorCheck("read_database", func(ctx context.Context) (string, error) {
+			return pingDatabase(ctx, &cfg.ReadDatabase)
+		}))
+		for _, shard := range cfg.ReadShards {
+			selfCheck.AddProbe(readShardCheckName(shard), true, readShardCheck(shard))
+		}
+	}
+
+	if servesCommands {
+		selfCheck.AddProbe("event_database", true, startup.ErrorCheck("event_database", func(ctx context.Context) (string, error) {
+			return pingDatabase(ctx, &cfg.EventDatabase)
+		}))
+	}
+
+	if servesCommands || projectsEvents {
+		selfCheck.AddProbe("message_broker", true, startup.ErrorCheck("message_broker", func(ctx context.Context) (string, error) {
+			return fetchBrokerMetadata(cfg)
+		}))
+	}
+
+	selfCheck.AddProbe("migrations", false, startup.ErrorCheck("migrations", func(ctx context.Context) (string, error) {
+		return checkMigrationVersions(ctx, cfg)
+	}))
+
+	selfCheck.AddProbe("auth_keys", true, startup.ErrorCheck("auth_keys", func(ctx context.Context) (string, error) {
+		if _, err := auth.NewJWTService(cfg.Auth.PrivateKeyPath, cfg.Auth.PublicKeyPath, time.Duration(cfg.Auth.TokenExpiry)*time.Hour); err != nil {
+			return "", err
+		}
+		return "RSA key pair loaded", nil
+	}))
+
+	selfCheck.AddProbe("translations", false, startup.ErrorCheck("translations", func(ctx context.Context) (string, error) {
+		translator := i18n.NewTranslator(cfg.I18n.DefaultLocale)
+		if err := translator.LoadTranslations(cfg.I18n.TranslationsDir); err != nil {
+			return "", err
+		}
+		if !translator.IsLocaleSupported(cfg.I18n.DefaultLocale) {
+			return "", fmt.Errorf("default locale %s has no translation catalog", cfg.I18n.DefaultLocale)
+		}
+		return fmt.Sprintf("Loaded locales: %v", translator.GetSupportedLocales()), nil
+	}))
+
+	return selfCheck
+}
+
+// runStartupSelfCheck runs the self-check, prints the report and exits in strict mode on failure
+func runStartupSelfCheck(cfg *config.Config, strict bool) *startup.SelfCheck {
+	selfCheck := newStartupSelfCheck(cfg, strict)
+	report := selfCheck.Run(context.Background())
+
+	os.Stdout.WriteString("startup self-check: " + report.JSON() + "\n")
+
+	if selfCheck.ShouldRefuseStart() {
+		os.Stderr.WriteString(fmt.Sprintf("Refusing to start: startup self-check failed: %v\n", report.Failed()))
+		os.Exit(1)
+	}
+
+	return selfCheck
+}
+
+// pingDatabase connects to a database and verifies it responds
+func pingDatabase(ctx context.Context, cfg *config.DatabaseConfig) (string, error) {
+	db, err := database.NewDatabaseFactory().CreateDatabase(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	if err := database.Ping(ctx, db); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s database is reachable", cfg.Type), nil
+}
+
+// readShardCheckName returns the name of the health check of a read shard
+func readShardCheckName(shard config.ReadShardConfig) string {
+	return "read_shard_" + shard.Name
+}
+
+// readShardCheck creates a health check pinging a read shard
+func readShardCheck(shard config.ReadShardConfig) health.HealthChecker {
+	return startup.ErrorCheck(readShardCheckName(shard), func(ctx context.Context) (string, error) {
+		return pingDatabase(ctx, &shard.Database)
+	})
+}
+
+// fetchBrokerMetadata fetches cluster metadata from the message broker
+func fetchBrokerMetadata(cfg *config.Config) (string, error) {
+	if cfg.MessageBroker.Type != "kafka" {
+		return "", fmt.Errorf("metadata fetch not supported for broker type: %s", cfg.MessageBroker.Type)
+	}
+
+	saramaConfig, err := messagebroker.NewSaramaConfig(&cfg.MessageBroker)
+	if err != nil {
+		return "", err
+	}
+	client, err := sarama.NewClient(cfg.MessageBroker.Brokers, saramaConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	defer client.Close()
+
+	topics, err := client.Topics()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch Kafka metadata: %w", err)
+	}
+
+	return fmt.Sprintf("%d brokers, %d topics", len(client.Brokers()), len(topics)), nil
+}
+
+// checkMigrationVersions reports the migration version of the SQL databases
+func checkMigrationVersions(ctx context.Context, cfg *config.Config) (string, error) {
+	writeDB, err := database.NewPostgresConnection(cfg.WriteDatabase)
+	if err != nil {
+		return "", err
+	}
+	defer writeDB.Close()
+
+	eventDB, err := connectEventDatabase(cfg, writeDB)
+	if err != nil {
+		return "", err
+	}
+	defer eventDB.Close()
+
+	migrationManager, err := migrations.NewMigrationManager(writeDB, eventDB, "./migrations/write", "./migrations/event")
+	if err != nil {
+		return "", err
+	}
+	defer migrationManager.Close()
+
+	writeVersion, writeDirty, err := migrationManager.GetWriteDBVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get write database version: %w", err)
+	}
+	eventVersion, eventDirty, err := migrationManager.GetEventDBVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get event database version: %w", err)
+	}
+	if writeDirty || eventDirty {
+		return "", fmt.Errorf("dirty migration state (write: %d, event: %d)", writeVersion, eventVersion)
+	}
+
+	return fmt.Sprintf("write: %d, event: %d", writeVersion, eventVersion), nil
+}
@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/consistency"
+	"go-clean-ddd-es-template/pkg/smoke"
+)
+
+// smokeOptions holds the flags of the smoke command
+type smokeOptions struct {
+	url      string
+	token    string
+	password string
+	format   string
+	timeout  time.Duration
+	wait     time.Duration
+}
+
+var smokeFlags smokeOptions
+
+var smokeCmd = &cobra.Command{
+	Use:   "smoke",
+	Short: "Run an end-to-end smoke test against a running environment",
+	Long: `Run a scripted scenario against a running environment through the HTTP gateway:
+register a new user, log in, update the user, read the update back from the
+projection, delete the user, wait for the projection to drop it and check that
+no event was dead lettered meanwhile. The DLQ check needs the admin token and is
+skipped without one.
+
+A pass/fail report is printed as text or JSON; the command exits non-zero when a
+step fails, so it can gate post-deploy verification.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		passed, err := runSmoke(&smokeFlags, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if !passed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	cfg := config.Load()
+	smokeCmd.Flags().StringVar(&smokeFlags.url, "url", "http://localhost:8080", "Base URL of the HTTP gateway")
+	smokeCmd.Flags().StringVar(&smokeFlags.token, "token", cfg.Admin.Token, "Admin API token, for the dead letter queue check")
+	smokeCmd.Flags().StringVar(&smokeFlags.password, "password", "Smoke-test-1", "Password of the registered user")
+	smokeCmd.Flags().StringVar(&smokeFlags.format, "format", "text", "Report format: text or json")
+	smokeCmd.Flags().DurationVar(&smokeFlags.timeout, "timeout", 2*time.Minute, "Timeout of the whole scenario")
+	smokeCmd.Flags().DurationVar(&smokeFlags.wait, "wait", 10*time.Second, "How long to wait for the projection to catch up")
+	rootCmd.AddCommand(smokeCmd)
+}
+
+// runSmoke runs the smoke scenario and writes its report, returning whether it passed
+func runSmoke(options *smokeOptions, out io.Writer) (bool, error) {
+	if options.format != "text" && options.format != "json" {
+		return false, fmt.Errorf("unknown report format %q, use text or json", options.format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), options.timeout)
+	defer cancel()
+
+	client := &gatewayClient{
+		baseURL: strings.TrimSuffix(options.url, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	report := smoke.Run(ctx, nil, options.url, smokeScenario(client, options))
+
+	if options.format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return report.Passed, encoder.Encode(report)
+	}
+	return report.Passed, report.WriteText(out)
+}
+
+// smokeScenario returns the steps of the smoke scenario, which share the user they create
+func smokeScenario(client *gatewayClient, options *smokeOptions) []smoke.Step {
+	started := time.Now().UTC()
+	suffix := started.Format("20060102150405.000000000")
+	email := "smoke+" + strings.ReplaceAll(suffix, ".", "") + "@example.com"
+	name := "Smoke Test"
+	updatedName := "Smoke Test Updated"
+
+	var userID, consistencyToken string
+	return []smoke.Step{
+		{Name: "register", Run: func(ctx context.Context) error {
+			var resp struct {
+				UserID string `json:"userId"`
+			}
+			body := map[string]string{"email": email, "name": name, "password": options.password}
+			if _, err := client.do(ctx, http.MethodPost, "/v1/auth/register", body, nil, &resp); err != nil {
+				return err
+			}
+			if resp.UserID == "" {
+				return fmt.Errorf("register returned no user id")
+			}
+			userID = resp.UserID
+			return nil
+		}},
+		{Name: "login", Run: func(ctx context.Context) error {
+			var resp struct {
+				Token string `json:"token"`
+			}
+			body := map[string]string{"email": email, "password": options.password}
+			if _, err := client.do(ctx, http.MethodPost, "/v1/auth/login", body, nil, &resp); err != nil {
+				return err
+			}
+			if resp.Token == "" {
+				return fmt.Errorf("login returned no token")
+			}
+			client.token = resp.Token
+			return nil
+		}},
+		{Name: "update", Run: func(ctx context.Context) error {
+			body := map[string]string{"name": updatedName}
+			header, err := client.do(ctx, http.MethodPut, "/api/v2/users/"+url.PathEscape(userID), body, nil, nil)
+			if err != nil {
+				return err
+			}
+			consistencyToken = header.Get(consistency.Header)
+			return nil
+		}},
+		{Name: "verify projection", Run: func(ctx context.Context) error {
+			return client.poll(ctx, options.wait, func() (bool, error) {
+				var resp struct {
+					User struct {
+						Name string `json:"name"`
+					} `json:"user"`
+				}
+				header := http.Header{}
+				if consistencyToken != "" {
+					header.Set(consistency.Header, consistencyToken)
+				}
+				if _, err := client.do(ctx, http.MethodGet, "/api/v2/users/"+url.PathEscape(userID), nil, header, &resp); err != nil {
+					return false, err
+				}
+				if resp.User.Name != updatedName {
+					return false, fmt.Errorf("projection has name %q, want %q", resp.User.Name, updatedName)
+				}
+				return true, nil
+			})
+		}},
+		{Name: "delete", Run: func(ctx context.Context) error {
+			_, err := client.do(ctx, http.MethodDelete, "/api/v2/users/"+url.PathEscape(userID), nil, nil, nil)
+			return err
+		}},
+		{Name: "verify deletion", Run: func(ctx context.Context) error {
+			return client.poll(ctx, options.wait, func() (bool, error) {
+				_, err := client.do(ctx, http.MethodGet, "/api/v2/users/"+url.PathEscape(userID), nil, nil, nil)
+				if apiErr, ok := err.(*gatewayError); ok && apiErr.status == http.StatusNotFound {
+					return true, nil
+				}
+				if err != nil {
+					return false, err
+				}
+				return false, fmt.Errorf("user is still in the projection")
+			})
+		}},
+		{Name: "dead letter queue empty", Run: func(ctx context.Context) error {
+			if options.token == "" {
+				return fmt.Errorf("no admin token: %w", smoke.ErrSkipped)
+			}
+			// Only entries that failed during the run count, older ones are not this deployment's
+			query := url.Values{"format": {"jsonl"}, "since": {started.Format(time.RFC3339)}}
+			resp, err := adminRequest(client.baseURL, options.token, http.MethodGet, "/admin/dlq/export?"+query.Encode(), nil)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			entries := 0
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+					entries++
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("failed to read dead letter queue export: %w", err)
+			}
+			if entries > 0 {
+				return fmt.Errorf("%d events were dead lettered during the run", entries)
+			}
+			return nil
+		}},
+	}
+}
+
+// gatewayClient calls the HTTP gateway as an API client would
+type gatewayClient struct {
+	baseURL string
+	token   string // Bearer token of the logged in user
+	http    *http.Client
+}
+
+// gatewayError is a non 2xx gateway response
+type gatewayError struct {
+	status  int
+	message string
+}
+
+func (e *gatewayError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("gateway returned %d %s", e.status, http.StatusText(e.status))
+	}
+	return fmt.Sprintf("gateway returned %d %s: %s", e.status, http.StatusText(e.status), e.message)
+}
+
+// do sends a JSON request and decodes the response into out when not nil, returning the
+// response headers
+func (c *gatewayClient) do(ctx context.Context, method, path string, body interface{}, header http.Header, out interface{}) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return nil, &gatewayError{status: resp.StatusCode, message: apiErr.Message}
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// poll calls check until it succeeds or wait elapses, returning the last error
+func (c *gatewayClient) poll(ctx context.Context, wait time.Duration, check func() (bool, error)) error {
+	deadline := time.Now().Add(wait)
+	for {
+		ok, err := check()
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
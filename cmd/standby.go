@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/pkg/leader"
+)
+
+// newLeaderElector creates the election of the consumer instance resuming its handlers: the
+// instance holding the leader lock of the write database is promoted, the others stay on standby
+func newLeaderElector(cfg *config.Config, eventConsumer *consumers.EventConsumerWrapper, logger leader.Logger) (*leader.Elector, error) {
+	writeDB, err := databases.CreateDatabase(&cfg.WriteDatabase)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, ok := writeDB.GetDB().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("leader election requires a sql write database")
+	}
+
+	lock := database.NewAdvisoryLock(sqlDB, cfg.Standby.LockName)
+	return leader.NewElector(lock, cfg.Standby.ElectionInterval, nil, logger,
+		func() { eventConsumer.SetStandby(false) },
+		func() { eventConsumer.SetStandby(true) },
+	), nil
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/statshistory"
+)
+
+// newStatsHistory creates the store of the stats snapshots and the recorder of the stats of the
+// event consumer, its dead letter queue and the publishes of the instance
+func newStatsHistory(cfg *config.Config, eventConsumer *consumers.EventConsumerWrapper, logger statshistory.Logger) (statshistory.Store, *statshistory.Recorder, error) {
+	var store statshistory.Store = statshistory.NewMemoryStore()
+	if cfg.StatsHistory.Store == "postgres" {
+		writeDB, err := databases.CreateDatabase(&cfg.WriteDatabase)
+		if err != nil {
+			return nil, nil, err
+		}
+		store = infraRepos.NewPostgresStatsHistoryStore(writeDB.GetDB())
+	}
+
+	instance, _ := os.Hostname()
+	recorder := statshistory.NewRecorder(store, instance, cfg.StatsHistory.Retention, logger, nil)
+	recorder.AddSource(eventConsumer.Stats)
+	recorder.AddSource(infraRepos.PublisherStats)
+	return store, recorder, nil
+}
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/pkg/dataio"
+	"go-clean-ddd-es-template/pkg/validation"
+)
+
+// userExportFields are the user fields written by "users export"
+var userExportFields = []string{"user_id", "email", "name", "created_at"}
+
+// userTransferOptions holds the flags shared by users import and export
+type userTransferOptions struct {
+	file         string
+	format       string
+	mapping      string
+	dryRun       bool
+	progressFile string
+	errorReport  string
+	pageSize     int
+}
+
+var usersExportOptions userTransferOptions
+var usersImportOptions userTransferOptions
+
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Bulk user data tools",
+}
+
+var usersExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export users from the read model to CSV or JSONL",
+	Long: `Export users from the read model to CSV or JSONL.
+Use --map to rename output columns (e.g. "email=mail,name=full_name") and
+--progress to resume an interrupted export.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runUsersTransfer(exportUsers, &usersExportOptions)
+	},
+}
+
+var usersImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import users from CSV or JSONL through the create user command",
+	Long: `Import users from CSV or JSONL through the create user command.
+Use --map to rename input columns to email/name (e.g. "mail=email,full_name=name"),
+--dry-run to only validate, --errors to write rejected rows to a JSONL report and
+--progress to resume an interrupted import.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runUsersTransfer(importUsers, &usersImportOptions)
+	},
+}
+
+func init() {
+	for _, c := range []struct {
+		cmd     *cobra.Command
+		options *userTransferOptions
+	}{
+		{usersExportCmd, &usersExportOptions},
+		{usersImportCmd, &usersImportOptions},
+	} {
+		c.cmd.Flags().StringVarP(&c.options.file, "file", "f", "", "Path of the CSV or JSONL file")
+		c.cmd.Flags().StringVar(&c.options.format, "format", "", "File format: csv or jsonl (defaults to the file extension)")
+		c.cmd.Flags().StringVar(&c.options.mapping, "map", "", "Field mapping as source=target pairs separated by commas")
+		c.cmd.Flags().BoolVar(&c.options.dryRun, "dry-run", false, "Process the data without writing anything")
+		c.cmd.Flags().StringVar(&c.options.progressFile, "progress", "", "Checkpoint file used to resume an interrupted run")
+		c.cmd.MarkFlagRequired("file")
+		usersCmd.AddCommand(c.cmd)
+	}
+
+	usersExportCmd.Flags().IntVar(&usersExportOptions.pageSize, "page-size", 100, "Number of users read per page")
+	usersImportCmd.Flags().StringVar(&usersImportOptions.errorReport, "errors", "", "Path of the JSONL report of rejected rows")
+
+	rootCmd.AddCommand(usersCmd)
+}
+
+// runUsersTransfer initializes the user service and runs an import or export
+func runUsersTransfer(run func(context.Context, *services.UserService, *userTransferOptions) error, options *userTransferOptions) {
+	userService, err := InitializeUserService()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize dependencies: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(context.Background(), userService, options); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exportUsers streams users page by page from the read model into a file
+func exportUsers(ctx context.Context, userService *services.UserService, options *userTransferOptions) error {
+	format, err := dataio.ParseFormat(options.format, options.file)
+	if err != nil {
+		return err
+	}
+	mapping, err := dataio.ParseFieldMapping(options.mapping)
+	if err != nil {
+		return err
+	}
+	progress, err := dataio.LoadProgress(options.progressFile)
+	if err != nil {
+		return err
+	}
+	if options.pageSize <= 0 || options.pageSize > 100 {
+		return fmt.Errorf("page size must be between 1 and 100")
+	}
+
+	// Output columns are the mapped field names
+	columns := make([]string, len(userExportFields))
+	for i, field := range userExportFields {
+		columns[i] = field
+		if target, ok := mapping[field]; ok {
+			columns[i] = target
+		}
+	}
+
+	output := io.Discard
+	resuming := progress.Processed > 0
+	if !options.dryRun {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if resuming {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		file, err := os.OpenFile(options.file, flags, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file: %w", err)
+		}
+		defer file.Close()
+		output = file
+	}
+
+	writer, err := dataio.NewWriter(output, format, columns, resuming)
+	if err != nil {
+		return err
+	}
+
+	// Resume from the page containing the first unexported user
+	page := int(progress.Processed)/options.pageSize + 1
+	skip := int(progress.Processed) % options.pageSize
+
+	for {
+		response, err := userService.ListUsers(ctx, dto.ListUsersQuery{Page: page, PageSize: options.pageSize})
+		if err != nil {
+			return fmt.Errorf("failed to list users (page %d): %w", page, err)
+		}
+
+		if skip > len(response.Users) {
+			skip = len(response.Users)
+		}
+		for _, user := range response.Users[skip:] {
+			record := mapping.Apply(dataio.Record{
+				"user_id":    user.UserID,
+				"email":      user.Email,
+				"name":       user.Name,
+				"created_at": user.CreatedAt,
+			})
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write user %s: %w", user.UserID, err)
+			}
+			progress.Processed++
+			progress.Succeeded++
+		}
+		skip = 0
+
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("failed to flush output: %w", err)
+		}
+		if !options.dryRun {
+			if err := progress.Save(); err != nil {
+				return err
+			}
+		}
+
+		if len(response.Users) < options.pageSize || progress.Processed >= response.Total {
+			break
+		}
+		page++
+	}
+
+	if !options.dryRun {
+		if err := progress.Clear(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Exported %d users to %s (dry run: %v)\n", progress.Succeeded, options.file, options.dryRun)
+	return nil
+}
+
+// importUsers streams records from a file through the create user command
+func importUsers(ctx context.Context, userService *services.UserService, options *userTransferOptions) error {
+	format, err := dataio.ParseFormat(options.format, options.file)
+	if err != nil {
+		return err
+	}
+	mapping, err := dataio.ParseFieldMapping(options.mapping)
+	if err != nil {
+		return err
+	}
+	progress, err := dataio.LoadProgress(options.progressFile)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(options.file)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := dataio.NewReader(file, format)
+	if err != nil {
+		return err
+	}
+
+	report, err := dataio.NewErrorReport(options.errorReport, progress.Processed > 0)
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+
+	var index int64
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read input (line %d): %w", reader.Line(), err)
+		}
+
+		// Skip records handled by a previous run
+		index++
+		if index <= progress.Processed {
+			continue
+		}
+
+		record = mapping.Apply(record)
+		if err := importUser(ctx, userService, record, options.dryRun); err != nil {
+			progress.Failed++
+			if err := report.Add(reader.Line(), record, err); err != nil {
+				return fmt.Errorf("failed to write error report: %w", err)
+			}
+		} else {
+			progress.Succeeded++
+		}
+		progress.Processed++
+
+		if !options.dryRun && progress.Processed%100 == 0 {
+			if err := progress.Save(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !options.dryRun {
+		if err := progress.Clear(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Processed %d users: %d imported, %d rejected (dry run: %v)\n",
+		progress.Processed, progress.Succeeded, progress.Failed, options.dryRun)
+	if report.Count() > 0 && options.errorReport != "" {
+		fmt.Printf("Rejected rows written to %s\n", options.errorReport)
+	}
+	return nil
+}
+
+// importUser validates a record and creates the user unless running dry
+func importUser(ctx context.Context, userService *services.UserService, record dataio.Record, dryRun bool) error {
+	cmd := dto.CreateUserCommand{
+		Email: record["email"],
+		Name:  record["name"],
+	}
+
+	if err := validation.ValidateEmail(cmd.Email); err != nil {
+		return err
+	}
+	if err := validation.ValidateName(cmd.Name); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+
+	_, err := userService.CreateUser(ctx, cmd)
+	return err
+}
@@ -4,9 +4,14 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"go-clean-ddd-es-template/internal/application/commands"
+	"go-clean-ddd-es-template/internal/application/policies"
 	"go-clean-ddd-es-template/internal/application/queries"
 	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
 	"go-clean-ddd-es-template/internal/domain/repositories"
 	"go-clean-ddd-es-template/internal/infrastructure/config"
 	"go-clean-ddd-es-template/internal/infrastructure/consumers"
@@ -14,11 +19,25 @@ import (
 	"go-clean-ddd-es-template/internal/infrastructure/grpc"
 	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
 	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/audit"
 	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/authz"
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/faultinjection"
 	"go-clean-ddd-es-template/pkg/i18n"
 	"go-clean-ddd-es-template/pkg/logger"
+	"go-clean-ddd-es-template/pkg/metrics"
 	"go-clean-ddd-es-template/pkg/middleware"
+	"go-clean-ddd-es-template/pkg/notification"
+	"go-clean-ddd-es-template/pkg/projection"
+	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/pkg/retry"
+	"go-clean-ddd-es-template/pkg/secrets"
+	"go-clean-ddd-es-template/pkg/storage"
+	"go-clean-ddd-es-template/pkg/tenantkeys"
 	"go-clean-ddd-es-template/pkg/tracing"
+	"sync"
 	"time"
 
 	"github.com/google/wire"
@@ -41,12 +60,99 @@ func provideTracer(cfg *config.Config) (*tracing.Tracer, error) {
 	if !cfg.Tracing.Enabled {
 		return nil, nil
 	}
-	return tracing.NewTracer(cfg.Tracing.ServiceName, "1.0.0", cfg.Tracing.Endpoint)
+	return tracing.NewTracerFromConfig(tracing.Config{
+		ServiceName:    cfg.Tracing.ServiceName,
+		ServiceVersion: "1.0.0",
+		Endpoint:       cfg.Tracing.Endpoint,
+		Sampling: tracing.SamplingConfig{
+			Strategy:      cfg.Tracing.Sampler,
+			Ratio:         cfg.Tracing.SamplerRatio,
+			RatePerSecond: cfg.Tracing.SamplerRate,
+			ParentBased:   cfg.Tracing.SamplerParentBased,
+			SampleErrors:  cfg.Tracing.SampleErrors,
+			SlowThreshold: cfg.Tracing.SlowThreshold,
+		},
+		Export: tracing.ExportConfig{
+			Timeout:              cfg.Tracing.ExportTimeout,
+			RetryEnabled:         cfg.Tracing.ExportRetryEnabled,
+			RetryInitialInterval: cfg.Tracing.ExportRetryInitial,
+			RetryMaxInterval:     cfg.Tracing.ExportRetryMax,
+			RetryMaxElapsedTime:  cfg.Tracing.ExportRetryElapsed,
+			MaxQueueSize:         cfg.Tracing.ExportQueueSize,
+			MaxExportBatchSize:   cfg.Tracing.ExportBatchSize,
+			BatchTimeout:         cfg.Tracing.ExportBatchTimeout,
+		},
+	})
 }
 
 // provideLogger provides logger service
 func provideLogger(cfg *config.Config) (logger.Logger, error) {
-	return logger.NewLoggerFromConfig(cfg.Log.Level, cfg.Log.Format)
+	l, err := logger.NewLoggerFromConfig(cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return nil, err
+	}
+	// Log metric labels rejected to protect cardinality
+	metrics.NewMetrics().LabelGuard().SetLogger(l)
+	// Log and export the state changes of the circuit breakers
+	resilience.DefaultRegistry().OnStateChange(func(name string, from, to resilience.CircuitState) {
+		l.Warn("Circuit breaker %s changed from %s to %s", name, from, to)
+		metrics.NewMetrics().RecordCircuitBreakerStateChange(name, from.String(), to.String(), int(to))
+	})
+	return l, nil
+}
+
+// provideClock provides the system clock and installs it as the domain clock
+func provideClock() clock.Clock {
+	c := clock.New()
+	entities.SetClock(c)
+	events.SetClock(c)
+	return c
+}
+
+// Process-wide response cache shared by the gRPC server and the event consumer invalidating it
+var (
+	sharedResponseCache *cache.TaggedCache
+	responseCacheOnce   sync.Once
+)
+
+// provideResponseCache provides the response cache, or nil when response caching is disabled
+func provideResponseCache(cfg *config.Config) *cache.TaggedCache {
+	if !cfg.ResponseCache.Enabled {
+		return nil
+	}
+	responseCacheOnce.Do(func() {
+		sharedResponseCache = cache.NewTaggedCache(cfg.ResponseCache.MaxEntries, nil)
+	})
+	return sharedResponseCache
+}
+
+// Process-wide email index shared by the login handlers and the event consumer maintaining it
+var (
+	sharedEmailIndex *cache.KeyIndex
+	emailIndexOnce   sync.Once
+)
+
+// provideEmailIndex provides the index of user emails, or nil when logins read users by email
+func provideEmailIndex(cfg *config.Config) *cache.KeyIndex {
+	if !cfg.EmailIndex.Enabled {
+		return nil
+	}
+	emailIndexOnce.Do(func() {
+		sharedEmailIndex = cache.NewKeyIndex(cfg.EmailIndex.ExpectedUsers, cfg.EmailIndex.FalsePositiveRate, cfg.EmailIndex.MaxEntries)
+	})
+	return sharedEmailIndex
+}
+
+// provideEmailIndexRefresher provides the job rebuilding the email index from the write database
+func provideEmailIndexRefresher(writeRepo repositories.UserWriteRepository, emailIndex *cache.KeyIndex, cfg *config.Config, clk clock.Clock) (*infraRepos.EmailIndexRefresher, error) {
+	if emailIndex == nil {
+		return nil, fmt.Errorf("the email index is disabled")
+	}
+	source, ok := writeRepo.(infraRepos.UserEmailSource)
+	if !ok {
+		return nil, fmt.Errorf("the %s write database does not list user emails", cfg.WriteDatabase.Type)
+	}
+	return infraRepos.NewEmailIndexRefresher(source, emailIndex, cfg.EmailIndex.RefreshInterval, clk, &consumers.SimpleLogger{}), nil
 }
 
 // provideTranslator provides i18n translator
@@ -69,9 +175,9 @@ func provideErrorHandler(translator *i18n.Translator, logger logger.Logger) *mid
 	return middleware.NewErrorHandler(translator, logger)
 }
 
-// provideDatabaseFactory provides database factory
+// provideDatabaseFactory provides the database factory of the process, closing its pools on shutdown
 func provideDatabaseFactory() *database.DatabaseFactory {
-	return database.NewDatabaseFactory()
+	return databases
 }
 
 // provideWriteDatabase provides write database connection
@@ -87,7 +193,11 @@ func provideReadDatabase(factory *database.DatabaseFactory, cfg *config.Config)
 }
 
 // provideEventDatabase provides event database connection
-func provideEventDatabase(factory *database.DatabaseFactory, cfg *config.Config) (EventDatabase, error) {
+func provideEventDatabase(factory *database.DatabaseFactory, writeDB WriteDatabase, cfg *config.Config) (EventDatabase, error) {
+	// With the outbox events are appended in the command transaction on the write database
+	if cfg.Outbox.Enabled {
+		return EventDatabase(writeDB), nil
+	}
 	db, err := factory.CreateDatabase(&cfg.EventDatabase)
 	return EventDatabase(db), err
 }
@@ -102,6 +212,11 @@ func provideRepositoryFactory(
 	return infraRepos.NewRepositoryFactory(database.Database(writeDB), database.Database(readDB), database.Database(eventDB), cfg)
 }
 
+// provideCommandPolicy provides the business rules evaluated before commands run
+func provideCommandPolicy(cfg *config.Config) (commands.CommandPolicy, error) {
+	return policies.NewCommandPolicy(cfg.CommandRules.ApprovalDomains, cfg.CommandRules.File)
+}
+
 // provideMessageBrokerFactory provides message broker factory
 func provideMessageBrokerFactory() *messagebroker.MessageBrokerFactory {
 	return messagebroker.NewMessageBrokerFactory()
@@ -109,7 +224,31 @@ func provideMessageBrokerFactory() *messagebroker.MessageBrokerFactory {
 
 // provideMessageBroker provides message broker using factory
 func provideMessageBroker(factory *messagebroker.MessageBrokerFactory, cfg *config.Config) (messagebroker.MessageBroker, error) {
-	return factory.CreateMessageBroker(&cfg.MessageBroker)
+	broker, err := factory.CreateMessageBroker(&cfg.MessageBroker)
+	if err != nil || !cfg.Concurrency.Enabled {
+		return broker, err
+	}
+	return messagebroker.NewLimitedMessageBroker(broker, newAdaptiveLimiter(cfg, "message_broker")), nil
+}
+
+// newAdaptiveLimiter creates the adaptive concurrency limiter of a dependency, exporting its limit
+func newAdaptiveLimiter(cfg *config.Config, dependency string) *resilience.AdaptiveLimiter {
+	var algorithm resilience.LimitAlgorithm = resilience.NewAIMDLimit(0)
+	if cfg.Concurrency.Algorithm == "vegas" {
+		algorithm = resilience.NewVegasLimit(0)
+	}
+	m := metrics.NewMetrics()
+	m.RecordConcurrencyLimit(dependency, cfg.Concurrency.InitialLimit)
+	return resilience.NewAdaptiveLimiter(resilience.AdaptiveLimiterConfig{
+		InitialLimit:     cfg.Concurrency.InitialLimit,
+		MinLimit:         cfg.Concurrency.MinLimit,
+		MaxLimit:         cfg.Concurrency.MaxLimit,
+		Algorithm:        algorithm,
+		LatencyThreshold: cfg.Concurrency.LatencyThreshold,
+		OnLimitChange: func(limit int) {
+			m.RecordConcurrencyLimit(dependency, limit)
+		},
+	})
 }
 
 // provideUserEventHandler provides user event handler
@@ -122,19 +261,33 @@ func provideProductEventHandler() *consumers.ProductEventHandler {
 	return consumers.NewProductEventHandler()
 }
 
+// dlqLoadTimeout bounds the indexing of dead letter topics at startup
+const dlqLoadTimeout = 30 * time.Second
+
 // provideEventConsumer provides generic event consumer with multiple handlers
 func provideEventConsumer(
 	broker messagebroker.MessageBroker,
+	writeDB WriteDatabase,
 	userEventHandler *consumers.UserEventHandler,
+	userSummaryRepository repositories.UserSummaryRepository,
+	userCountRepository repositories.UserCountRepository,
+	userChangeLogRepository repositories.UserChangeLogRepository,
+	inboxRepository repositories.InboxRepository,
+	processedEventRepository repositories.ProcessedEventRepository,
 	productEventHandler *consumers.ProductEventHandler,
 	cfg *config.Config,
+	clk clock.Clock,
+	responseCache *cache.TaggedCache,
+	emailIndex *cache.KeyIndex,
 ) *consumers.EventConsumerWrapper {
 	consumer := broker.GetConsumer()
 
-	// Get unique topics from config mapping
+	// Get unique topics from config mapping, skipping topics whose events are all disabled
 	topicSet := make(map[string]bool)
-	for _, topic := range cfg.MessageBroker.Topics {
-		topicSet[topic] = true
+	for eventType, topic := range cfg.MessageBroker.Topics {
+		if cfg.Components.HandlerEnabled(eventType) {
+			topicSet[topic] = true
+		}
 	}
 
 	// Convert to slice
@@ -146,26 +299,124 @@ func provideEventConsumer(
 	// Add fallback topics for events not in config
 	fallbackTopics := []string{"product.created", "product.updated", "product.deleted"}
 	for _, topic := range fallbackTopics {
-		if !topicSet[topic] {
+		if !topicSet[topic] && cfg.Components.HandlerEnabled(topic) {
 			topics = append(topics, topic)
 		}
 	}
 
+	// Consume the latest, or the pinned, version of versioned topics
+	topicVersions := messagebroker.NewVersionedTopics(cfg.MessageBroker, metrics.NewMetrics(), clk)
+	topics = topicVersions.ConsumerTopics(topics)
+
+	// Consume per-tenant topics when events are routed by tenant
+	topics = messagebroker.NewTenantRouter(cfg.Tenancy).ConsumerTopics(topics)
+
+	// Consume the retry topics of failed messages when redelivery is enabled
+	retryRouter := messagebroker.NewRetryRouter(broker, cfg.MessageBroker)
+	retryRouter.SetDelayer(newDelayer(broker, writeDB, cfg))
+	topics = retryRouter.ConsumerTopics(topics)
+
 	// Create logger for event consumer
 	logger := &consumers.SimpleLogger{}
 
 	// Create event consumer with worker pool
-	eventConsumer := consumers.NewEventConsumerWrapperWithWorkerPool(consumer, cfg.MessageBroker.GroupID, topics, cfg, logger)
+	eventConsumer := consumers.NewEventConsumerWrapperWithWorkerPool(consumer, cfg.MessageBroker.GroupID, topics, cfg, logger, clk)
 
-	// Register user event handlers
-	eventConsumer.RegisterEventHandler("user.created", userEventHandler)
-	eventConsumer.RegisterEventHandler("user.updated", userEventHandler)
-	eventConsumer.RegisterEventHandler("user.deleted", userEventHandler)
+	// Apply user events to the user projections enabled
+	userHandler, loginHandler := userProjections(userEventHandler, userSummaryRepository, userCountRepository, userChangeLogRepository, clk)
+	// Preferences and email verifications are only kept in the read model, the other user
+	// projections do not record them
+	var readModelHandler consumers.LegacyEventHandler = userEventHandler
 
-	// Register product event handlers
-	eventConsumer.RegisterEventHandler("product.created", productEventHandler)
-	eventConsumer.RegisterEventHandler("product.updated", productEventHandler)
-	eventConsumer.RegisterEventHandler("product.deleted", productEventHandler)
+	// Apply each user event to the projections once, recording it in the inbox in the same transaction
+	if inboxRepository != nil {
+		userHandler = consumers.NewInboxHandler(userHandler, inboxRepository, cfg.MessageBroker.GroupID)
+		readModelHandler = consumers.NewInboxHandler(readModelHandler, inboxRepository, cfg.MessageBroker.GroupID)
+		if loginHandler != nil {
+			loginHandler = consumers.NewInboxHandler(loginHandler, inboxRepository, cfg.MessageBroker.GroupID)
+		}
+	}
+
+	// Index the emails of created and deleted users for logins
+	if emailIndex != nil {
+		userHandler = consumers.NewEmailIndexingHandler(userHandler, emailIndex)
+	}
+
+	// Invalidate cached user reads once the read model is updated
+	if responseCache != nil {
+		userHandler = consumers.NewCacheInvalidatingHandler(userHandler, responseCache, grpc.UsersCacheTag)
+		readModelHandler = consumers.NewCacheInvalidatingHandler(readModelHandler, responseCache, grpc.UsersCacheTag)
+		if loginHandler != nil {
+			loginHandler = consumers.NewCacheInvalidatingHandler(loginHandler, responseCache, grpc.UsersCacheTag)
+		}
+	}
+
+	// Register the user and product event handlers enabled for this deployment
+	handlers := map[string]consumers.LegacyEventHandler{
+		"user.created":             userHandler,
+		"user.updated":             userHandler,
+		"user.deleted":             userHandler,
+		"user.preferences_updated": readModelHandler,
+		"user.email_verified":      readModelHandler,
+		"user.avatar_updated":      readModelHandler,
+		"product.created":          productEventHandler,
+		"product.updated":          productEventHandler,
+		"product.deleted":          productEventHandler,
+	}
+	if loginHandler != nil {
+		handlers["user.login"] = loginHandler
+	}
+	if cfg.Faults.Enabled {
+		for eventType, handler := range handlers {
+			handlers[eventType] = consumers.NewFailureInjectingHandler(handler, faultinjection.Global())
+		}
+	}
+	// Skip events the consumer group already processed when Kafka redelivers or replays them
+	if processedEventRepository != nil {
+		for eventType, handler := range handlers {
+			handlers[eventType] = consumers.NewIdempotentHandler(handler, processedEventRepository, cfg.MessageBroker.GroupID, logger)
+		}
+	}
+	registerEventHandlers(eventConsumer, cfg.Components, handlers, logger)
+
+	if retryRouter.Enabled() {
+		eventConsumer.SetRedeliverer(retryRouter)
+	}
+
+	// Count messages consumed per topic version to follow the migration off deprecated versions
+	eventConsumer.SetTopicVersions(topicVersions)
+
+	// Route events exceeding their topic's age limit to expired topics instead of skipping them
+	if expiredRouter := messagebroker.NewExpiredRouter(broker, cfg.MessageBroker); expiredRouter.Enabled() {
+		eventConsumer.SetExpiredPublisher(expiredRouter)
+	}
+
+	// Quarantine events whose payload violates the schema of their event type and version
+	if cfg.MessageBroker.ValidateSchemas {
+		registry, err := messagebroker.NewEventSchemaRegistry(cfg.MessageBroker)
+		if err != nil {
+			logger.Error("Failed to load event schemas, consuming payloads unvalidated: %v", err)
+		} else {
+			eventConsumer.SetPayloadValidator(registry)
+		}
+	}
+
+	// Retry dead letter events by republishing them with their failure headers
+	eventConsumer.SetDLQRetryHandler(messagebroker.NewDLQRepublisher(broker))
+
+	// Keep dead letter events in dead letter topics, indexing those left by earlier runs
+	if cfg.MessageBroker.DLQStorage == "kafka" {
+		if storage, err := messagebroker.NewKafkaDLQStorage(&cfg.MessageBroker); err != nil {
+			logger.Error("Failed to create Kafka DLQ storage, keeping dead letters in memory: %v", err)
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), dlqLoadTimeout)
+			if err := storage.Load(ctx, topics); err != nil {
+				logger.Error("Failed to load dead letter topics: %v", err)
+			}
+			cancel()
+			eventConsumer.SetDLQStorage(storage)
+		}
+	}
 
 	return eventConsumer
 }
@@ -176,25 +427,221 @@ func provideUserWriteRepository(factory *infraRepos.RepositoryFactory) (reposito
 }
 
 // provideUserReadRepository provides user read repository
-func provideUserReadRepository(factory *infraRepos.RepositoryFactory) (repositories.UserReadRepository, error) {
-	return factory.CreateUserReadRepository()
+func provideUserReadRepository(factory *infraRepos.RepositoryFactory, cfg *config.Config) (repositories.UserReadRepository, error) {
+	repository, err := factory.CreateUserReadRepository()
+	if err != nil || !cfg.Concurrency.Enabled {
+		return repository, err
+	}
+	return infraRepos.NewLimitedUserReadRepository(repository, newAdaptiveLimiter(cfg, "user_read_repository")), nil
+}
+
+// provideUserSummaryRepository provides the user summary repository, or nil when lists read full read models
+func provideUserSummaryRepository(factory *infraRepos.RepositoryFactory, cfg *config.Config) (repositories.UserSummaryRepository, error) {
+	if !cfg.ReadModel.UserSummaries {
+		return nil, nil
+	}
+	return factory.CreateUserSummaryRepository()
+}
+
+// provideUserCountRepository provides the user counters, or nil when lists count users on every request
+func provideUserCountRepository(factory *infraRepos.RepositoryFactory, cfg *config.Config) (repositories.UserCountRepository, error) {
+	if !cfg.ReadModel.UserCounts {
+		return nil, nil
+	}
+	return factory.CreateUserCountRepository()
+}
+
+// provideUserCountReconciler provides the job resetting the user counters to the counts of the write database
+func provideUserCountReconciler(writeRepo repositories.UserWriteRepository, userCountRepository repositories.UserCountRepository, cfg *config.Config, clk clock.Clock) (*infraRepos.UserCountReconciler, error) {
+	if userCountRepository == nil {
+		return nil, fmt.Errorf("the user counts are disabled")
+	}
+	source, ok := writeRepo.(infraRepos.UserStatusSource)
+	if !ok {
+		return nil, fmt.Errorf("the %s write database does not count users", cfg.WriteDatabase.Type)
+	}
+	return infraRepos.NewUserCountReconciler(source, userCountRepository, cfg.ReadModel.UserCountReconcileInterval, clk, &consumers.SimpleLogger{}), nil
+}
+
+// provideUserChangeLogRepository provides the user change log repository, or nil when the changefeed is disabled
+func provideUserChangeLogRepository(factory *infraRepos.RepositoryFactory, cfg *config.Config) (repositories.UserChangeLogRepository, error) {
+	if !cfg.Changefeed.Enabled {
+		return nil, nil
+	}
+	return factory.CreateUserChangeLogRepository()
+}
+
+// provideInboxRepository provides the inbox of consumed events, or nil when events are applied without it
+func provideInboxRepository(factory *infraRepos.RepositoryFactory, cfg *config.Config) (repositories.InboxRepository, error) {
+	if !cfg.ReadModel.Inbox {
+		return nil, nil
+	}
+	return factory.CreateInboxRepository()
+}
+
+// provideProcessedEventRepository provides the record of events processed by the consumer group,
+// or nil when redelivered events are handled again
+func provideProcessedEventRepository(factory *infraRepos.RepositoryFactory, cfg *config.Config) (repositories.ProcessedEventRepository, error) {
+	if !cfg.MessageBroker.IdempotentConsumers {
+		return nil, nil
+	}
+	return factory.CreateProcessedEventRepository()
+}
+
+// provideChangefeedHandler provides the HTTP user changefeed handler
+func provideChangefeedHandler(userChangeLogRepository repositories.UserChangeLogRepository, cfg *config.Config, clk clock.Clock) *grpc.ChangefeedHandler {
+	changesHandler := queries.NewUserChangesQueryHandler(userChangeLogRepository, cfg.Changefeed.SettleDelay, cfg.Changefeed.PageSize, cfg.Changefeed.MaxPageSize, clk)
+	return grpc.NewChangefeedHandler(changesHandler, cfg.Changefeed.Token)
 }
 
 // provideUserRepository provides user repository (combines write and read)
-func provideUserRepository(writeRepo repositories.UserWriteRepository, readRepo repositories.UserReadRepository) repositories.UserRepository {
+func provideUserRepository(writeRepo repositories.UserWriteRepository, readRepo repositories.UserReadRepository, emailIndex *cache.KeyIndex) repositories.UserRepository {
 	// For now, we'll use writeRepo as the main repository since it has all the methods
 	// In a real implementation, you might want to create a composite repository
-	return writeRepo.(repositories.UserRepository)
+	userRepo := writeRepo.(repositories.UserRepository)
+	// Look logins up in the email index maintained by the event consumer
+	if emailIndex != nil {
+		return infraRepos.NewEmailIndexedUserRepository(userRepo, emailIndex)
+	}
+	return userRepo
 }
 
-// provideEventStore provides event store
-func provideEventStore(factory *infraRepos.RepositoryFactory) (repositories.EventStore, error) {
-	return factory.CreateEventStore()
+// provideKeyring provides the data keys of tenants, or nil when tenant data is not encrypted
+func provideKeyring(cfg *config.Config) *tenantkeys.Keyring {
+	if !cfg.Encryption.Enabled {
+		return nil
+	}
+	return tenantkeys.NewKeyring(secrets.NewFileProvider(cfg.Encryption.SecretsDir), nil)
+}
+
+// provideEventStore provides event store, encrypting tenant event data when a keyring is given
+// and streaming events to in-process subscribers when enabled
+func provideEventStore(factory *infraRepos.RepositoryFactory, keyring *tenantkeys.Keyring, cfg *config.Config) (repositories.EventStore, error) {
+	eventStore, err := factory.CreateEventStore()
+	if err != nil {
+		return nil, err
+	}
+	if keyring != nil {
+		eventStore = infraRepos.NewEncryptingEventStore(eventStore, keyring)
+	}
+	// Subscriptions read the whole event store past the concurrency limit, as projections do
+	eventLog, ok := eventStore.(repositories.EventLog)
+	if cfg.Subscriptions.Enabled && !ok {
+		return nil, fmt.Errorf("event store %T does not support reading all events", eventStore)
+	}
+	if cfg.Concurrency.Enabled {
+		eventStore = infraRepos.NewLimitedEventStore(eventStore, newAdaptiveLimiter(cfg, "event_store"))
+	}
+	if !cfg.Subscriptions.Enabled {
+		return eventStore, nil
+	}
+	return infraRepos.NewSubscribingEventStore(eventStore, eventLog, cfg.Subscriptions.BatchSize, cfg.Subscriptions.PollInterval, &consumers.SimpleLogger{}), nil
+}
+
+// provideEventSourcedUserRepository provides the users loaded from their event stream, which
+// commands append their events to at the version of the stream they decided on
+func provideEventSourcedUserRepository(eventStore repositories.EventStore) (*repositories.EventSourcedUserRepository, error) {
+	versioned, ok := eventStore.(repositories.VersionedEventStore)
+	if !ok {
+		return nil, fmt.Errorf("event store %T does not support appending at a version", eventStore)
+	}
+	return repositories.NewEventSourcedUserRepository(versioned), nil
+}
+
+// provideEventPublisher provides event publisher, appending events to the outbox when enabled and
+// recording sign ins in the audit log when enabled
+func provideEventPublisher(broker messagebroker.MessageBroker, writeDB WriteDatabase, cfg *config.Config) repositories.EventPublisher {
+	var publisher repositories.EventPublisher
+	if cfg.Outbox.Enabled {
+		publisher = infraRepos.NewOutboxEventPublisher(infraRepos.NewPostgresOutbox(writeDB))
+	} else {
+		brokerPublisher := infraRepos.NewMessageBrokerEventPublisher(broker, cfg)
+		brokerPublisher.SetDelayer(newDelayer(broker, writeDB, cfg))
+		publisher = brokerPublisher
+	}
+	if !cfg.AuditLog.Enabled {
+		return publisher
+	}
+	auditLog := audit.NewLog(infraRepos.NewPostgresAuditStore(writeDB.GetDB()), nil)
+	return infraRepos.NewAuditingEventPublisher(publisher, auditLog, &consumers.SimpleLogger{})
+}
+
+// delaySchedulerBatchSize bounds the delayed messages published per transaction
+const delaySchedulerBatchSize = 100
+
+// newDelayer creates the delayer of messages published after a delay, keeping them in the
+// delayed message store of the write database when configured
+func newDelayer(broker messagebroker.MessageBroker, writeDB WriteDatabase, cfg *config.Config) *messagebroker.Delayer {
+	var store messagebroker.DelayedMessageStore
+	if cfg.MessageBroker.DelayedStore == "postgres" {
+		store = messagebroker.NewPostgresDelayedMessageStore(writeDB)
+	}
+	return messagebroker.NewDelayer(broker, store, nil)
+}
+
+// provideDelayScheduler provides the scheduler publishing the due messages of the delayed message store
+func provideDelayScheduler(broker messagebroker.MessageBroker, writeDB WriteDatabase, cfg *config.Config) *messagebroker.DelayScheduler {
+	store := messagebroker.NewPostgresDelayedMessageStore(writeDB)
+	return messagebroker.NewDelayScheduler(broker, store, delaySchedulerBatchSize, cfg.MessageBroker.DelayedPollInterval, nil, &consumers.SimpleLogger{})
+}
+
+// provideOutboxRelay provides the relay publishing the events of the outbox to the message broker
+func provideOutboxRelay(broker messagebroker.MessageBroker, writeDB WriteDatabase, cfg *config.Config) *infraRepos.OutboxRelay {
+	publisher := infraRepos.NewMessageBrokerEventPublisher(broker, cfg)
+	return infraRepos.NewOutboxRelay(infraRepos.NewPostgresOutbox(writeDB), publisher, cfg.Outbox.BatchSize, cfg.Outbox.PollInterval, &consumers.SimpleLogger{})
 }
 
-// provideEventPublisher provides event publisher
-func provideEventPublisher(broker messagebroker.MessageBroker, cfg *config.Config) repositories.EventPublisher {
-	return infraRepos.NewMessageBrokerEventPublisher(broker, cfg)
+// provideKeyRotationJob provides the job rotating tenant data keys and re-encrypting tenant events
+func provideKeyRotationJob(eventDB EventDatabase, keyring *tenantkeys.Keyring, cfg *config.Config) *infraRepos.KeyRotationJob {
+	store := infraRepos.NewPostgresKeyRotationStore(eventDB.GetDB())
+	return infraRepos.NewKeyRotationJob(store, keyring, cfg.Tenancy.Tenants, cfg.Encryption.KeyMaxAge, cfg.Encryption.RotationBatchSize, cfg.Encryption.RotationInterval, nil, &consumers.SimpleLogger{})
+}
+
+// provideReadModelArchiver provides the job moving expired documents of archived read model collections
+func provideReadModelArchiver(factory *infraRepos.RepositoryFactory, cfg *config.Config) (*infraRepos.ReadModelArchiver, error) {
+	policies, err := infraRepos.NewRetentionPolicies(cfg.ReadDatabase.Collection, cfg.ReadModel.Retention, cfg.ReadModel.Archive)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := factory.CreateReadModelArchive()
+	if err != nil {
+		return nil, err
+	}
+	return infraRepos.NewReadModelArchiver(archive, policies, cfg.ReadModel.ArchiveBatchSize, cfg.ReadModel.ArchiveInterval, nil, &consumers.SimpleLogger{}), nil
+}
+
+// provideProjectionEngine provides the engine keeping the checkpointed projections up to date
+func provideProjectionEngine(factory *infraRepos.RepositoryFactory, eventDB EventDatabase, cfg *config.Config) (*projection.Engine, error) {
+	eventLog, err := projectionEventLog(factory, cfg)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := infraRepos.NewPostgresProjectionCheckpointStore(eventDB.GetDB())
+	engine := projection.NewEngine(infraRepos.NewEventLogSource(eventLog), checkpoints, cfg.Projections.BatchSize, cfg.Projections.PollInterval, nil, &consumers.SimpleLogger{})
+	projections, err := checkpointedProjections(factory, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.Register(projections...); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// provideTransactionManager provides the write database transaction manager commands append
+// their events to the outbox in, or nil when events are published directly
+func provideTransactionManager(writeDB WriteDatabase, cfg *config.Config) repositories.TransactionManager {
+	if !cfg.Outbox.Enabled {
+		return nil
+	}
+	transactions := infraRepos.NewPostgresTransactionManager(writeDB)
+	transactions.SetRetry(retry.Policy{
+		MaxAttempts: cfg.TransactionRetry.MaxAttempts,
+		BaseDelay:   cfg.TransactionRetry.BaseDelay,
+		MaxDelay:    cfg.TransactionRetry.MaxDelay,
+		Jitter:      1,
+	}, metrics.NewMetrics())
+	return transactions
 }
 
 // Command Handlers (Write Operations)
@@ -202,33 +649,76 @@ func provideUserCreateCommandHandler(
 	userWriteRepo repositories.UserWriteRepository,
 	eventStore repositories.EventStore,
 	eventPublisher repositories.EventPublisher,
+	policy commands.CommandPolicy,
+	transactions repositories.TransactionManager,
 ) *commands.UserCreateCommandHandler {
-	return commands.NewUserCreateCommandHandler(userWriteRepo, eventStore, eventPublisher)
+	handler := commands.NewUserCreateCommandHandler(userWriteRepo, eventStore, eventPublisher)
+	handler.SetPolicy(policy)
+	handler.SetTransactions(transactions)
+	return handler
 }
 
 func provideUserUpdateCommandHandler(
 	userWriteRepo repositories.UserWriteRepository,
 	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
 	eventPublisher repositories.EventPublisher,
+	policy commands.CommandPolicy,
+	transactions repositories.TransactionManager,
 ) *commands.UserUpdateCommandHandler {
-	return commands.NewUserUpdateCommandHandler(userWriteRepo, eventStore, eventPublisher)
+	handler := commands.NewUserUpdateCommandHandler(userWriteRepo, eventStore, users, eventPublisher)
+	handler.SetPolicy(policy)
+	handler.SetTransactions(transactions)
+	return handler
 }
 
 func provideUserDeleteCommandHandler(
 	userWriteRepo repositories.UserWriteRepository,
 	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
 	eventPublisher repositories.EventPublisher,
+	policy commands.CommandPolicy,
+	transactions repositories.TransactionManager,
 ) *commands.UserDeleteCommandHandler {
-	return commands.NewUserDeleteCommandHandler(userWriteRepo, eventStore, eventPublisher)
+	handler := commands.NewUserDeleteCommandHandler(userWriteRepo, eventStore, users, eventPublisher)
+	handler.SetPolicy(policy)
+	handler.SetTransactions(transactions)
+	return handler
 }
 
 // Query Handlers (Read Operations)
-func provideUserGetQueryHandler(userReadRepository repositories.UserReadRepository) *queries.UserGetQueryHandler {
-	return queries.NewUserGetQueryHandler(userReadRepository)
+func provideUserGetQueryHandler(userReadRepository repositories.UserReadRepository, userWriteRepository repositories.UserWriteRepository, cfg *config.Config) *queries.UserGetQueryHandler {
+	// Reads of degradable endpoints go through a circuit breaker so they fail fast while the read store is down
+	fallback := cfg.ReadModel.Fallbacks["GetUser"]
+	if fallback != queries.ReadFallbackNone {
+		userReadRepository = infraRepos.NewCircuitBreakerUserReadRepository(userReadRepository, resilience.CircuitBreakerConfig{
+			FailureThreshold:      cfg.ReadModel.FailureThreshold,
+			Timeout:               cfg.ReadModel.OpenTimeout,
+			SuccessThreshold:      1,
+			MaxHalfOpenCalls:      cfg.ReadModel.HalfOpenCalls,
+			WindowSize:            cfg.ReadModel.BreakerWindowSize,
+			MinimumCalls:          cfg.ReadModel.BreakerMinimumCalls,
+			FailureRateThreshold:  cfg.ReadModel.BreakerFailureRate,
+			SlowCallRateThreshold: cfg.ReadModel.BreakerSlowCallRate,
+			SlowCallDuration:      cfg.ReadModel.BreakerSlowCallDuration,
+		})
+	}
+
+	handler := queries.NewUserGetQueryHandler(userReadRepository)
+	// Reads carrying a consistency token fall back to the write database when the projection lags
+	handler.SetReadYourWrites(userWriteRepository, cfg.ReadModel.ConsistencyMaxWait, cfg.ReadModel.ConsistencyPollInterval, nil)
+	if fallback != queries.ReadFallbackNone {
+		handler.SetDegradation(fallback, userWriteRepository, cache.NewMemoryCache(), cfg.ReadModel.FallbackCacheTTL)
+	}
+	return handler
 }
 
-func provideUserListQueryHandler(userReadRepository repositories.UserReadRepository) *queries.UserListQueryHandler {
-	return queries.NewUserListQueryHandler(userReadRepository)
+func provideUserListQueryHandler(userReadRepository repositories.UserReadRepository, userSummaryRepository repositories.UserSummaryRepository) *queries.UserListQueryHandler {
+	handler := queries.NewUserListQueryHandler(userReadRepository)
+	if userSummaryRepository != nil {
+		handler.SetSummaries(userSummaryRepository)
+	}
+	return handler
 }
 
 func provideUserGetByEmailQueryHandler(userReadRepository repositories.UserReadRepository) *queries.UserGetByEmailQueryHandler {
@@ -239,6 +729,40 @@ func provideUserEventsQueryHandler(userReadRepository repositories.UserReadRepos
 	return queries.NewUserEventsQueryHandler(userReadRepository)
 }
 
+// preferenceDefaults returns the preferences of users who did not choose their own
+func preferenceDefaults(cfg *config.Config) entities.Preferences {
+	locale := cfg.Preferences.DefaultLocale
+	if locale == "" {
+		locale = cfg.I18n.DefaultLocale
+	}
+	return entities.Preferences{
+		Locale: locale,
+		Theme:  cfg.Preferences.DefaultTheme,
+		Notifications: entities.NotificationPreferences{
+			Email:  cfg.Preferences.EmailNotifications,
+			Push:   cfg.Preferences.PushNotifications,
+			Digest: cfg.Preferences.NotificationDigest,
+		},
+	}
+}
+
+func provideUserUpdatePreferencesCommandHandler(
+	userWriteRepo repositories.UserWriteRepository,
+	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
+	eventPublisher repositories.EventPublisher,
+	transactions repositories.TransactionManager,
+	cfg *config.Config,
+) *commands.UserUpdatePreferencesCommandHandler {
+	handler := commands.NewUserUpdatePreferencesCommandHandler(userWriteRepo, eventStore, users, eventPublisher, preferenceDefaults(cfg))
+	handler.SetTransactions(transactions)
+	return handler
+}
+
+func provideUserGetPreferencesQueryHandler(userReadRepository repositories.UserReadRepository, cfg *config.Config) *queries.UserGetPreferencesQueryHandler {
+	return queries.NewUserGetPreferencesQueryHandler(userReadRepository, preferenceDefaults(cfg))
+}
+
 // provideUserService provides user service
 func provideUserService(
 	createCommandHandler *commands.UserCreateCommandHandler,
@@ -248,8 +772,10 @@ func provideUserService(
 	listQueryHandler *queries.UserListQueryHandler,
 	getByEmailQueryHandler *queries.UserGetByEmailQueryHandler,
 	eventsQueryHandler *queries.UserEventsQueryHandler,
+	updatePreferencesCommandHandler *commands.UserUpdatePreferencesCommandHandler,
+	getPreferencesQueryHandler *queries.UserGetPreferencesQueryHandler,
 ) *services.UserService {
-	return services.NewUserService(
+	service := services.NewUserService(
 		createCommandHandler,
 		updateCommandHandler,
 		deleteCommandHandler,
@@ -258,11 +784,18 @@ func provideUserService(
 		getByEmailQueryHandler,
 		eventsQueryHandler,
 	)
+	service.SetPreferences(updatePreferencesCommandHandler, getPreferencesQueryHandler)
+	return service
 }
 
-// provideJWTService provides JWT service
-func provideJWTService(cfg *config.Config) (*auth.JWTService, error) {
-	return auth.NewJWTService(cfg.Auth.PrivateKeyPath, cfg.Auth.PublicKeyPath, time.Duration(cfg.Auth.TokenExpiry)*time.Hour)
+// provideJWTService provides JWT service issuing tokens at the time of clk
+func provideJWTService(cfg *config.Config, clk clock.Clock) (*auth.JWTService, error) {
+	jwtService, err := auth.NewJWTService(cfg.Auth.PrivateKeyPath, cfg.Auth.PublicKeyPath, time.Duration(cfg.Auth.TokenExpiry)*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	jwtService.SetClock(clk)
+	return jwtService, nil
 }
 
 // providePasswordService provides password service
@@ -277,26 +810,207 @@ func provideAuthRegisterCommandHandler(
 	eventPublisher repositories.EventPublisher,
 	passwordService *auth.PasswordService,
 	jwtService *auth.JWTService,
+	policy commands.CommandPolicy,
+	transactions repositories.TransactionManager,
 ) *commands.AuthRegisterCommandHandler {
-	return commands.NewAuthRegisterCommandHandler(userRepo, eventStore, eventPublisher, passwordService, jwtService)
+	handler := commands.NewAuthRegisterCommandHandler(userRepo, eventStore, eventPublisher, passwordService, jwtService)
+	handler.SetPolicy(policy)
+	handler.SetTransactions(transactions)
+	return handler
 }
 
-// provideAuthLoginCommandHandler provides auth login command handler
+// provideAuthLoginCommandHandler provides auth login command handler, locking out accounts and
+// client addresses failing to log in too often when enabled
 func provideAuthLoginCommandHandler(
+	factory *infraRepos.RepositoryFactory,
+	userRepo repositories.UserRepository,
+	passwordService *auth.PasswordService,
+	jwtService *auth.JWTService,
+	eventPublisher repositories.EventPublisher,
+	cfg *config.Config,
+) (*commands.AuthLoginCommandHandler, error) {
+	handler := commands.NewAuthLoginCommandHandler(userRepo, passwordService, jwtService)
+	// Logins are only published for the last login of user summaries and for auditing lockouts
+	if cfg.ReadModel.UserSummaries || cfg.LoginLockout.Enabled {
+		handler.SetEventPublisher(eventPublisher)
+	}
+	if !cfg.LoginLockout.Enabled {
+		return handler, nil
+	}
+
+	attempts, err := factory.CreateLoginAttemptRepository()
+	if err != nil {
+		return nil, err
+	}
+	handler.SetLockout(attempts, commands.LoginLockoutPolicy{
+		MaxFailures:   cfg.LoginLockout.MaxFailures,
+		IPMaxFailures: cfg.LoginLockout.IPMaxFailures,
+		Window:        cfg.LoginLockout.Window,
+		Duration:      cfg.LoginLockout.Duration,
+	})
+	return handler, nil
+}
+
+// provideNotificationSender provides the sender of notifications to users: published to a topic
+// of the message broker for the notifications worker, or delivered by the service itself
+func provideNotificationSender(broker messagebroker.MessageBroker, cfg *config.Config) notification.Sender {
+	if cfg.Notification.Publisher == "topic" {
+		return notification.NewTopicSender(broker, cfg.Notification.Topic)
+	}
+	return newNotificationDelivery(cfg)
+}
+
+// newNotificationDelivery returns the sender delivering notifications: mailed through the SMTP
+// server, posted to the webhook, or logged when neither is set
+func newNotificationDelivery(cfg *config.Config) notification.Sender {
+	switch {
+	case cfg.Notification.SMTPHost != "":
+		return notification.NewSMTPSender(notification.SMTPConfig{
+			Host:     cfg.Notification.SMTPHost,
+			Port:     cfg.Notification.SMTPPort,
+			Username: cfg.Notification.SMTPUsername,
+			Password: cfg.Notification.SMTPPassword,
+			From:     cfg.Notification.SMTPFrom,
+		}, nil)
+	case cfg.Notification.WebhookURL != "":
+		return notification.NewWebhookSender(cfg.Notification.WebhookURL, nil)
+	}
+	return notification.NewLogSender(&consumers.SimpleLogger{})
+}
+
+// provideAuthMagicLinkCommandHandler provides the magic link command handler, or nil when magic
+// links are disabled
+func provideAuthMagicLinkCommandHandler(
+	factory *infraRepos.RepositoryFactory,
+	userRepo repositories.UserRepository,
+	jwtService *auth.JWTService,
+	eventPublisher repositories.EventPublisher,
+	sender notification.Sender,
+	cfg *config.Config,
+) (*commands.AuthMagicLinkCommandHandler, error) {
+	if !cfg.MagicLink.Enabled {
+		return nil, nil
+	}
+	magicLinks, err := factory.CreateMagicLinkRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := resilience.NewKeyedLimiter(float64(cfg.MagicLink.RequestsPerHour)/3600, cfg.MagicLink.RequestsPerHour, nil)
+
+	handler := commands.NewAuthMagicLinkCommandHandler(userRepo, magicLinks, jwtService, sender, limiter, commands.MagicLinkOptions{
+		URL:        cfg.MagicLink.URL,
+		TTL:        cfg.MagicLink.TTL,
+		BindDevice: cfg.MagicLink.BindDevice,
+	})
+	handler.SetEventPublisher(eventPublisher)
+	return handler, nil
+}
+
+// provideAuthPasswordResetCommandHandler provides the password reset command handler, or nil when
+// password resets are disabled
+func provideAuthPasswordResetCommandHandler(
 	userRepo repositories.UserRepository,
+	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
+	refreshTokens repositories.RefreshTokenRepository,
+	eventPublisher repositories.EventPublisher,
 	passwordService *auth.PasswordService,
 	jwtService *auth.JWTService,
-) *commands.AuthLoginCommandHandler {
-	return commands.NewAuthLoginCommandHandler(userRepo, passwordService, jwtService)
+	sender notification.Sender,
+	transactions repositories.TransactionManager,
+	cfg *config.Config,
+) *commands.AuthPasswordResetCommandHandler {
+	if !cfg.PasswordReset.Enabled {
+		return nil
+	}
+
+	limiter := resilience.NewKeyedLimiter(float64(cfg.PasswordReset.RequestsPerHour)/3600, cfg.PasswordReset.RequestsPerHour, nil)
+
+	handler := commands.NewAuthPasswordResetCommandHandler(userRepo, eventStore, users, eventPublisher, passwordService, jwtService, sender, limiter, commands.PasswordResetOptions{
+		URL: cfg.PasswordReset.URL,
+		TTL: cfg.PasswordReset.TTL,
+	})
+	handler.SetTransactions(transactions)
+	if refreshTokens != nil {
+		handler.SetRefreshTokens(refreshTokens)
+	}
+	return handler
+}
+
+// provideAuthEmailVerificationCommandHandler provides the email verification command handler, or
+// nil when email verification is disabled
+func provideAuthEmailVerificationCommandHandler(
+	userRepo repositories.UserRepository,
+	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
+	eventPublisher repositories.EventPublisher,
+	jwtService *auth.JWTService,
+	sender notification.Sender,
+	transactions repositories.TransactionManager,
+	cfg *config.Config,
+) *commands.AuthEmailVerificationCommandHandler {
+	if !cfg.EmailVerification.Enabled {
+		return nil
+	}
+
+	limiter := resilience.NewKeyedLimiter(float64(cfg.EmailVerification.RequestsPerHour)/3600, cfg.EmailVerification.RequestsPerHour, nil)
+
+	handler := commands.NewAuthEmailVerificationCommandHandler(userRepo, eventStore, users, eventPublisher, jwtService, sender, limiter, commands.EmailVerificationOptions{
+		URL: cfg.EmailVerification.URL,
+		TTL: cfg.EmailVerification.TTL,
+	})
+	handler.SetTransactions(transactions)
+	return handler
+}
+
+// provideRefreshTokenRepository provides the refresh token store, or nil when refresh tokens are
+// disabled
+func provideRefreshTokenRepository(factory *infraRepos.RepositoryFactory, cfg *config.Config) (repositories.RefreshTokenRepository, error) {
+	if !cfg.RefreshTokens.Enabled {
+		return nil, nil
+	}
+	return factory.CreateRefreshTokenRepository()
+}
+
+// provideAuthRefreshTokenCommandHandler provides the refresh token command handler, or nil when
+// refresh tokens are disabled
+func provideAuthRefreshTokenCommandHandler(
+	userRepo repositories.UserRepository,
+	refreshTokens repositories.RefreshTokenRepository,
+	jwtService *auth.JWTService,
+	cfg *config.Config,
+) *commands.AuthRefreshTokenCommandHandler {
+	if refreshTokens == nil {
+		return nil
+	}
+	return commands.NewAuthRefreshTokenCommandHandler(userRepo, refreshTokens, jwtService, cfg.RefreshTokens.TTL)
 }
 
 // provideAuthService provides auth service
 func provideAuthService(
 	registerHandler *commands.AuthRegisterCommandHandler,
 	loginHandler *commands.AuthLoginCommandHandler,
+	magicLinkHandler *commands.AuthMagicLinkCommandHandler,
+	refreshTokenHandler *commands.AuthRefreshTokenCommandHandler,
+	passwordResetHandler *commands.AuthPasswordResetCommandHandler,
+	emailVerificationHandler *commands.AuthEmailVerificationCommandHandler,
 	jwtService *auth.JWTService,
 ) *services.AuthService {
-	return services.NewAuthService(registerHandler, loginHandler, jwtService)
+	authService := services.NewAuthService(registerHandler, loginHandler, jwtService)
+	if magicLinkHandler != nil {
+		authService.SetMagicLinkHandler(magicLinkHandler)
+	}
+	if refreshTokenHandler != nil {
+		authService.SetRefreshTokenHandler(refreshTokenHandler)
+	}
+	if passwordResetHandler != nil {
+		authService.SetPasswordResetHandler(passwordResetHandler)
+	}
+	if emailVerificationHandler != nil {
+		authService.SetEmailVerificationHandler(emailVerificationHandler)
+	}
+	return authService
 }
 
 // provideGRPCServer provides gRPC server
@@ -305,8 +1019,64 @@ func provideGRPCServer(
 	authService *services.AuthService,
 	tracer *tracing.Tracer,
 	logger logger.Logger,
-) *grpc.GRPCServer {
-	return grpc.NewGRPCServer(userService, authService, tracer, logger)
+	responseCache *cache.TaggedCache,
+	authorizer *authz.Authorizer,
+	cfg *config.Config,
+) (*grpc.GRPCServer, error) {
+	server := grpc.NewGRPCServer(userService, authService, tracer, logger, responseCache, cfg.ResponseCache, cfg.Components, cfg.APIVersions, cfg.RateLimit, cfg.Log, cfg.RequestCost, authorizer)
+	if err := server.BindAuthorization(); err != nil {
+		return nil, err
+	}
+	trustedProxies, err := cfg.Server.TrustedProxyNetworks()
+	if err != nil {
+		return nil, err
+	}
+	server.SetTrustedProxies(trustedProxies)
+	return server, nil
+}
+
+// provideAuthorizer provides the authorizer of gRPC calls, nil when no authorization matrix is
+// configured and calls are only authenticated
+func provideAuthorizer(cfg *config.Config, logger logger.Logger) (*authz.Authorizer, error) {
+	if cfg.Authorization.File == "" {
+		return nil, nil
+	}
+	return authz.NewAuthorizer(cfg.Authorization.File, nil, logger)
+}
+
+// provideStorage provides object storage
+func provideStorage(cfg *config.Config) (storage.Storage, error) {
+	return storage.New(storage.Config{
+		Provider:     cfg.Storage.Provider,
+		BasePath:     cfg.Storage.BasePath,
+		BaseURL:      cfg.Storage.BaseURL,
+		SigningKey:   cfg.Storage.SigningKey,
+		Bucket:       cfg.Storage.Bucket,
+		Region:       cfg.Storage.Region,
+		Endpoint:     cfg.Storage.Endpoint,
+		AccessKey:    cfg.Storage.AccessKey,
+		SecretKey:    cfg.Storage.SecretKey,
+		UsePathStyle: cfg.Storage.UsePathStyle,
+	})
+}
+
+// provideUserUploadAvatarCommandHandler provides user upload avatar command handler
+func provideUserUploadAvatarCommandHandler(
+	users *repositories.EventSourcedUserRepository,
+	eventPublisher repositories.EventPublisher,
+	transactions repositories.TransactionManager,
+	store storage.Storage,
+	cfg *config.Config,
+	clk clock.Clock,
+) *commands.UserUploadAvatarCommandHandler {
+	handler := commands.NewUserUploadAvatarCommandHandler(users, eventPublisher, store, cfg.Storage.MaxAvatarSize, cfg.Storage.SignedURLTTL, clk)
+	handler.SetTransactions(transactions)
+	return handler
+}
+
+// provideAvatarHandler provides the HTTP avatar upload handler, authenticating callers with tokens
+func provideAvatarHandler(uploadHandler *commands.UserUploadAvatarCommandHandler, tokens grpc.TokenValidator, cfg *config.Config) *grpc.AvatarHandler {
+	return grpc.NewAvatarHandler(uploadHandler, tokens, cfg.Storage.MaxAvatarSize)
 }
 
 // InitializeGRPCServer initializes gRPC server with all dependencies
@@ -324,10 +1094,15 @@ func InitializeGRPCServer() (*grpc.GRPCServer, error) {
 		provideRepositoryFactory,
 		provideUserWriteRepository,
 		provideUserReadRepository,
+		provideUserSummaryRepository,
 		provideUserRepository,
+		provideKeyring,
 		provideEventStore,
+		provideEventSourcedUserRepository,
 		provideEventPublisher,
+		provideTransactionManager,
 		// Command Handlers (Write Operations)
+		provideCommandPolicy,
 		provideUserCreateCommandHandler,
 		provideUserUpdateCommandHandler,
 		provideUserDeleteCommandHandler,
@@ -336,13 +1111,25 @@ func InitializeGRPCServer() (*grpc.GRPCServer, error) {
 		provideUserListQueryHandler,
 		provideUserGetByEmailQueryHandler,
 		provideUserEventsQueryHandler,
+		provideUserGetPreferencesQueryHandler,
+		provideUserUpdatePreferencesCommandHandler,
 		// Services
 		provideUserService,
+		provideClock,
 		provideJWTService,
 		providePasswordService,
 		provideAuthRegisterCommandHandler,
 		provideAuthLoginCommandHandler,
+		provideNotificationSender,
+		provideAuthMagicLinkCommandHandler,
+		provideAuthPasswordResetCommandHandler,
+		provideAuthEmailVerificationCommandHandler,
+		provideRefreshTokenRepository,
+		provideAuthRefreshTokenCommandHandler,
 		provideAuthService,
+		provideResponseCache,
+		provideEmailIndex,
+		provideAuthorizer,
 		provideGRPCServer,
 	)
 	return &grpc.GRPCServer{}, nil
@@ -360,9 +1147,193 @@ func InitializeEventConsumer() (*consumers.EventConsumerWrapper, error) {
 		provideMessageBroker,
 		provideRepositoryFactory,
 		provideUserReadRepository,
+		provideUserSummaryRepository,
+		provideUserCountRepository,
+		provideUserChangeLogRepository,
+		provideInboxRepository,
+		provideProcessedEventRepository,
 		provideUserEventHandler,
 		provideProductEventHandler,
+		provideClock,
+		provideResponseCache,
+		provideEmailIndex,
 		provideEventConsumer,
 	)
 	return &consumers.EventConsumer{}, nil
 }
+
+// InitializeOutboxRelay initializes the outbox relay with all dependencies
+func InitializeOutboxRelay() (*infraRepos.OutboxRelay, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideMessageBrokerFactory,
+		provideMessageBroker,
+		provideOutboxRelay,
+	)
+	return &infraRepos.OutboxRelay{}, nil
+}
+
+// InitializeKeyRotationJob initializes the tenant key rotation job with all dependencies
+func InitializeKeyRotationJob() (*infraRepos.KeyRotationJob, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideEventDatabase,
+		provideKeyring,
+		provideKeyRotationJob,
+	)
+	return &infraRepos.KeyRotationJob{}, nil
+}
+
+// InitializeDelayScheduler initializes the delayed message scheduler with all dependencies
+func InitializeDelayScheduler() (*messagebroker.DelayScheduler, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideMessageBrokerFactory,
+		provideMessageBroker,
+		provideDelayScheduler,
+	)
+	return &messagebroker.DelayScheduler{}, nil
+}
+
+// InitializeReadModelArchiver initializes the read model archiver with all dependencies
+func InitializeReadModelArchiver() (*infraRepos.ReadModelArchiver, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideReadDatabase,
+		provideEventDatabase,
+		provideRepositoryFactory,
+		provideReadModelArchiver,
+	)
+	return &infraRepos.ReadModelArchiver{}, nil
+}
+
+// InitializeEmailIndexRefresher initializes the job rebuilding the email index with all dependencies
+func InitializeEmailIndexRefresher() (*infraRepos.EmailIndexRefresher, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideReadDatabase,
+		provideEventDatabase,
+		provideRepositoryFactory,
+		provideUserWriteRepository,
+		provideEmailIndex,
+		provideClock,
+		provideEmailIndexRefresher,
+	)
+	return &infraRepos.EmailIndexRefresher{}, nil
+}
+
+// InitializeUserCountReconciler initializes the job reconciling the user counters with all dependencies
+func InitializeUserCountReconciler() (*infraRepos.UserCountReconciler, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideReadDatabase,
+		provideEventDatabase,
+		provideRepositoryFactory,
+		provideUserWriteRepository,
+		provideUserCountRepository,
+		provideClock,
+		provideUserCountReconciler,
+	)
+	return &infraRepos.UserCountReconciler{}, nil
+}
+
+// InitializeProjectionEngine initializes the projection engine with all dependencies
+func InitializeProjectionEngine() (*projection.Engine, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideReadDatabase,
+		provideEventDatabase,
+		provideRepositoryFactory,
+		provideProjectionEngine,
+	)
+	return &projection.Engine{}, nil
+}
+
+// InitializeUserService initializes user service with all dependencies
+func InitializeUserService() (*services.UserService, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideReadDatabase,
+		provideEventDatabase,
+		provideMessageBrokerFactory,
+		provideMessageBroker,
+		provideRepositoryFactory,
+		provideUserWriteRepository,
+		provideUserReadRepository,
+		provideUserSummaryRepository,
+		provideKeyring,
+		provideEventStore,
+		provideEventSourcedUserRepository,
+		provideEventPublisher,
+		provideTransactionManager,
+		provideCommandPolicy,
+		provideUserCreateCommandHandler,
+		provideUserUpdateCommandHandler,
+		provideUserDeleteCommandHandler,
+		provideUserGetQueryHandler,
+		provideUserListQueryHandler,
+		provideUserGetByEmailQueryHandler,
+		provideUserEventsQueryHandler,
+		provideUserGetPreferencesQueryHandler,
+		provideUserUpdatePreferencesCommandHandler,
+		provideUserService,
+	)
+	return &services.UserService{}, nil
+}
+
+// InitializeAvatarHandler initializes the avatar upload handler with all dependencies, authenticating
+// callers with tokens
+func InitializeAvatarHandler(tokens grpc.TokenValidator) (*grpc.AvatarHandler, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideReadDatabase,
+		provideEventDatabase,
+		provideMessageBrokerFactory,
+		provideMessageBroker,
+		provideRepositoryFactory,
+		provideKeyring,
+		provideEventStore,
+		provideEventSourcedUserRepository,
+		provideEventPublisher,
+		provideTransactionManager,
+		provideClock,
+		provideStorage,
+		provideUserUploadAvatarCommandHandler,
+		provideAvatarHandler,
+	)
+	return &grpc.AvatarHandler{}, nil
+}
+
+// InitializeChangefeedHandler initializes the user changefeed handler with all dependencies
+func InitializeChangefeedHandler() (*grpc.ChangefeedHandler, error) {
+	wire.Build(
+		provideConfig,
+		provideDatabaseFactory,
+		provideWriteDatabase,
+		provideReadDatabase,
+		provideEventDatabase,
+		provideRepositoryFactory,
+		provideUserChangeLogRepository,
+		provideClock,
+		provideChangefeedHandler,
+	)
+	return &grpc.ChangefeedHandler{}, nil
+}
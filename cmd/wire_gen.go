@@ -7,11 +7,17 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"go-clean-ddd-es-template/internal/application/commands"
+	"go-clean-ddd-es-template/internal/application/policies"
 	"go-clean-ddd-es-template/internal/application/queries"
 	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
 	repositories2 "go-clean-ddd-es-template/internal/domain/repositories"
 	"go-clean-ddd-es-template/internal/infrastructure/config"
 	"go-clean-ddd-es-template/internal/infrastructure/consumers"
@@ -19,10 +25,23 @@ import (
 	"go-clean-ddd-es-template/internal/infrastructure/grpc"
 	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
 	"go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/audit"
 	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/authz"
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/faultinjection"
 	"go-clean-ddd-es-template/pkg/i18n"
 	"go-clean-ddd-es-template/pkg/logger"
+	"go-clean-ddd-es-template/pkg/metrics"
 	"go-clean-ddd-es-template/pkg/middleware"
+	"go-clean-ddd-es-template/pkg/notification"
+	"go-clean-ddd-es-template/pkg/projection"
+	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/pkg/retry"
+	"go-clean-ddd-es-template/pkg/secrets"
+	"go-clean-ddd-es-template/pkg/storage"
+	"go-clean-ddd-es-template/pkg/tenantkeys"
 	"go-clean-ddd-es-template/pkg/tracing"
 )
 
@@ -40,7 +59,7 @@ func InitializeGRPCServer() (*grpc.GRPCServer, error) {
 	if err != nil {
 		return nil, err
 	}
-	eventDatabase, err := provideEventDatabase(databaseFactory, config)
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +68,12 @@ func InitializeGRPCServer() (*grpc.GRPCServer, error) {
 	if err != nil {
 		return nil, err
 	}
-	eventStore, err := provideEventStore(repositoryFactory)
+	keyring := provideKeyring(config)
+	eventStore, err := provideEventStore(repositoryFactory, keyring, config)
+	if err != nil {
+		return nil, err
+	}
+	eventSourcedUserRepository, err := provideEventSourcedUserRepository(eventStore)
 	if err != nil {
 		return nil, err
 	}
@@ -58,28 +82,56 @@ func InitializeGRPCServer() (*grpc.GRPCServer, error) {
 	if err != nil {
 		return nil, err
 	}
-	eventPublisher := provideEventPublisher(messageBroker, config)
-	userCreateCommandHandler := provideUserCreateCommandHandler(userWriteRepository, eventStore, eventPublisher)
-	userUpdateCommandHandler := provideUserUpdateCommandHandler(userWriteRepository, eventStore, eventPublisher)
-	userDeleteCommandHandler := provideUserDeleteCommandHandler(userWriteRepository, eventStore, eventPublisher)
-	userReadRepository, err := provideUserReadRepository(repositoryFactory)
+	eventPublisher := provideEventPublisher(messageBroker, writeDatabase, config)
+	transactionManager := provideTransactionManager(writeDatabase, config)
+	commandPolicy, err := provideCommandPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+	userCreateCommandHandler := provideUserCreateCommandHandler(userWriteRepository, eventStore, eventPublisher, commandPolicy, transactionManager)
+	userUpdateCommandHandler := provideUserUpdateCommandHandler(userWriteRepository, eventStore, eventSourcedUserRepository, eventPublisher, commandPolicy, transactionManager)
+	userDeleteCommandHandler := provideUserDeleteCommandHandler(userWriteRepository, eventStore, eventSourcedUserRepository, eventPublisher, commandPolicy, transactionManager)
+	userReadRepository, err := provideUserReadRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	userGetQueryHandler := provideUserGetQueryHandler(userReadRepository, userWriteRepository, config)
+	userSummaryRepository, err := provideUserSummaryRepository(repositoryFactory, config)
 	if err != nil {
 		return nil, err
 	}
-	userGetQueryHandler := provideUserGetQueryHandler(userReadRepository)
-	userListQueryHandler := provideUserListQueryHandler(userReadRepository)
+	userListQueryHandler := provideUserListQueryHandler(userReadRepository, userSummaryRepository)
 	userGetByEmailQueryHandler := provideUserGetByEmailQueryHandler(userReadRepository)
 	userEventsQueryHandler := provideUserEventsQueryHandler(userReadRepository)
-	userService := provideUserService(userCreateCommandHandler, userUpdateCommandHandler, userDeleteCommandHandler, userGetQueryHandler, userListQueryHandler, userGetByEmailQueryHandler, userEventsQueryHandler)
-	userRepository := provideUserRepository(userWriteRepository, userReadRepository)
+	userUpdatePreferencesCommandHandler := provideUserUpdatePreferencesCommandHandler(userWriteRepository, eventStore, eventSourcedUserRepository, eventPublisher, transactionManager, config)
+	userGetPreferencesQueryHandler := provideUserGetPreferencesQueryHandler(userReadRepository, config)
+	userService := provideUserService(userCreateCommandHandler, userUpdateCommandHandler, userDeleteCommandHandler, userGetQueryHandler, userListQueryHandler, userGetByEmailQueryHandler, userEventsQueryHandler, userUpdatePreferencesCommandHandler, userGetPreferencesQueryHandler)
+	keyIndex := provideEmailIndex(config)
+	userRepository := provideUserRepository(userWriteRepository, userReadRepository, keyIndex)
 	passwordService := providePasswordService()
-	jwtService, err := provideJWTService(config)
+	clockClock := provideClock()
+	jwtService, err := provideJWTService(config, clockClock)
+	if err != nil {
+		return nil, err
+	}
+	authRegisterCommandHandler := provideAuthRegisterCommandHandler(userRepository, eventStore, eventPublisher, passwordService, jwtService, commandPolicy, transactionManager)
+	authLoginCommandHandler, err := provideAuthLoginCommandHandler(repositoryFactory, userRepository, passwordService, jwtService, eventPublisher, config)
 	if err != nil {
 		return nil, err
 	}
-	authRegisterCommandHandler := provideAuthRegisterCommandHandler(userRepository, eventStore, eventPublisher, passwordService, jwtService)
-	authLoginCommandHandler := provideAuthLoginCommandHandler(userRepository, passwordService, jwtService)
-	authService := provideAuthService(authRegisterCommandHandler, authLoginCommandHandler, jwtService)
+	sender := provideNotificationSender(messageBroker, config)
+	authMagicLinkCommandHandler, err := provideAuthMagicLinkCommandHandler(repositoryFactory, userRepository, jwtService, eventPublisher, sender, config)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokenRepository, err := provideRefreshTokenRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	authRefreshTokenCommandHandler := provideAuthRefreshTokenCommandHandler(userRepository, refreshTokenRepository, jwtService, config)
+	authPasswordResetCommandHandler := provideAuthPasswordResetCommandHandler(userRepository, eventStore, eventSourcedUserRepository, refreshTokenRepository, eventPublisher, passwordService, jwtService, sender, transactionManager, config)
+	authEmailVerificationCommandHandler := provideAuthEmailVerificationCommandHandler(userRepository, eventStore, eventSourcedUserRepository, eventPublisher, jwtService, sender, transactionManager, config)
+	authService := provideAuthService(authRegisterCommandHandler, authLoginCommandHandler, authMagicLinkCommandHandler, authRefreshTokenCommandHandler, authPasswordResetCommandHandler, authEmailVerificationCommandHandler, jwtService)
 	tracer, err := provideTracer(config)
 	if err != nil {
 		return nil, err
@@ -88,7 +140,15 @@ func InitializeGRPCServer() (*grpc.GRPCServer, error) {
 	if err != nil {
 		return nil, err
 	}
-	grpcServer := provideGRPCServer(userService, authService, tracer, logger)
+	taggedCache := provideResponseCache(config)
+	authorizer, err := provideAuthorizer(config, logger)
+	if err != nil {
+		return nil, err
+	}
+	grpcServer, err := provideGRPCServer(userService, authService, tracer, logger, taggedCache, authorizer, config)
+	if err != nil {
+		return nil, err
+	}
 	return grpcServer, nil
 }
 
@@ -109,21 +169,338 @@ func InitializeEventConsumer() (*consumers.EventConsumerWrapper, error) {
 	if err != nil {
 		return nil, err
 	}
-	eventDatabase, err := provideEventDatabase(databaseFactory, config)
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
 	if err != nil {
 		return nil, err
 	}
 	repositoryFactory := provideRepositoryFactory(writeDatabase, readDatabase, eventDatabase, config)
-	userReadRepository, err := provideUserReadRepository(repositoryFactory)
+	userReadRepository, err := provideUserReadRepository(repositoryFactory, config)
 	if err != nil {
 		return nil, err
 	}
 	userEventHandler := provideUserEventHandler(userReadRepository)
+	userSummaryRepository, err := provideUserSummaryRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	userCountRepository, err := provideUserCountRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	userChangeLogRepository, err := provideUserChangeLogRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	inboxRepository, err := provideInboxRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	processedEventRepository, err := provideProcessedEventRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
 	productEventHandler := provideProductEventHandler()
-	eventConsumer := provideEventConsumer(messageBroker, userEventHandler, productEventHandler, config)
+	clockClock := provideClock()
+	taggedCache := provideResponseCache(config)
+	keyIndex := provideEmailIndex(config)
+	eventConsumer := provideEventConsumer(messageBroker, writeDatabase, userEventHandler, userSummaryRepository, userCountRepository, userChangeLogRepository, inboxRepository, processedEventRepository, productEventHandler, config, clockClock, taggedCache, keyIndex)
 	return eventConsumer, nil
 }
 
+// InitializeOutboxRelay initializes the outbox relay with all dependencies
+func InitializeOutboxRelay() (*repositories.OutboxRelay, error) {
+	config := provideConfig()
+	databaseFactory := provideDatabaseFactory()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	messageBrokerFactory := provideMessageBrokerFactory()
+	messageBroker, err := provideMessageBroker(messageBrokerFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	outboxRelay := provideOutboxRelay(messageBroker, writeDatabase, config)
+	return outboxRelay, nil
+}
+
+// InitializeKeyRotationJob initializes the tenant key rotation job with all dependencies
+func InitializeKeyRotationJob() (*repositories.KeyRotationJob, error) {
+	config := provideConfig()
+	databaseFactory := provideDatabaseFactory()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	keyring := provideKeyring(config)
+	keyRotationJob := provideKeyRotationJob(eventDatabase, keyring, config)
+	return keyRotationJob, nil
+}
+
+// InitializeDelayScheduler initializes the delayed message scheduler with all dependencies
+func InitializeDelayScheduler() (*messagebroker.DelayScheduler, error) {
+	config := provideConfig()
+	databaseFactory := provideDatabaseFactory()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	messageBrokerFactory := provideMessageBrokerFactory()
+	messageBroker, err := provideMessageBroker(messageBrokerFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	delayScheduler := provideDelayScheduler(messageBroker, writeDatabase, config)
+	return delayScheduler, nil
+}
+
+// InitializeReadModelArchiver initializes the read model archiver with all dependencies
+func InitializeReadModelArchiver() (*repositories.ReadModelArchiver, error) {
+	config := provideConfig()
+	databaseFactory := provideDatabaseFactory()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	readDatabase, err := provideReadDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	repositoryFactory := provideRepositoryFactory(writeDatabase, readDatabase, eventDatabase, config)
+	readModelArchiver, err := provideReadModelArchiver(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	return readModelArchiver, nil
+}
+
+// InitializeEmailIndexRefresher initializes the job rebuilding the email index with all dependencies
+func InitializeEmailIndexRefresher() (*repositories.EmailIndexRefresher, error) {
+	config := provideConfig()
+	databaseFactory := provideDatabaseFactory()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	readDatabase, err := provideReadDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	repositoryFactory := provideRepositoryFactory(writeDatabase, readDatabase, eventDatabase, config)
+	userWriteRepository, err := provideUserWriteRepository(repositoryFactory)
+	if err != nil {
+		return nil, err
+	}
+	keyIndex := provideEmailIndex(config)
+	clockClock := provideClock()
+	emailIndexRefresher, err := provideEmailIndexRefresher(userWriteRepository, keyIndex, config, clockClock)
+	if err != nil {
+		return nil, err
+	}
+	return emailIndexRefresher, nil
+}
+
+// InitializeUserCountReconciler initializes the job reconciling the user counters with all dependencies
+func InitializeUserCountReconciler() (*repositories.UserCountReconciler, error) {
+	config := provideConfig()
+	databaseFactory := provideDatabaseFactory()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	readDatabase, err := provideReadDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	repositoryFactory := provideRepositoryFactory(writeDatabase, readDatabase, eventDatabase, config)
+	userWriteRepository, err := provideUserWriteRepository(repositoryFactory)
+	if err != nil {
+		return nil, err
+	}
+	userCountRepository, err := provideUserCountRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	clockClock := provideClock()
+	userCountReconciler, err := provideUserCountReconciler(userWriteRepository, userCountRepository, config, clockClock)
+	if err != nil {
+		return nil, err
+	}
+	return userCountReconciler, nil
+}
+
+// InitializeProjectionEngine initializes the projection engine with all dependencies
+func InitializeProjectionEngine() (*projection.Engine, error) {
+	config := provideConfig()
+	databaseFactory := provideDatabaseFactory()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	readDatabase, err := provideReadDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	repositoryFactory := provideRepositoryFactory(writeDatabase, readDatabase, eventDatabase, config)
+	engine, err := provideProjectionEngine(repositoryFactory, eventDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// InitializeUserService initializes user service with all dependencies
+func InitializeUserService() (*services.UserService, error) {
+	databaseFactory := provideDatabaseFactory()
+	config := provideConfig()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	readDatabase, err := provideReadDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	repositoryFactory := provideRepositoryFactory(writeDatabase, readDatabase, eventDatabase, config)
+	userWriteRepository, err := provideUserWriteRepository(repositoryFactory)
+	if err != nil {
+		return nil, err
+	}
+	keyring := provideKeyring(config)
+	eventStore, err := provideEventStore(repositoryFactory, keyring, config)
+	if err != nil {
+		return nil, err
+	}
+	eventSourcedUserRepository, err := provideEventSourcedUserRepository(eventStore)
+	if err != nil {
+		return nil, err
+	}
+	messageBrokerFactory := provideMessageBrokerFactory()
+	messageBroker, err := provideMessageBroker(messageBrokerFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventPublisher := provideEventPublisher(messageBroker, writeDatabase, config)
+	transactionManager := provideTransactionManager(writeDatabase, config)
+	commandPolicy, err := provideCommandPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+	userCreateCommandHandler := provideUserCreateCommandHandler(userWriteRepository, eventStore, eventPublisher, commandPolicy, transactionManager)
+	userUpdateCommandHandler := provideUserUpdateCommandHandler(userWriteRepository, eventStore, eventSourcedUserRepository, eventPublisher, commandPolicy, transactionManager)
+	userDeleteCommandHandler := provideUserDeleteCommandHandler(userWriteRepository, eventStore, eventSourcedUserRepository, eventPublisher, commandPolicy, transactionManager)
+	userReadRepository, err := provideUserReadRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	userGetQueryHandler := provideUserGetQueryHandler(userReadRepository, userWriteRepository, config)
+	userSummaryRepository, err := provideUserSummaryRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	userListQueryHandler := provideUserListQueryHandler(userReadRepository, userSummaryRepository)
+	userGetByEmailQueryHandler := provideUserGetByEmailQueryHandler(userReadRepository)
+	userEventsQueryHandler := provideUserEventsQueryHandler(userReadRepository)
+	userUpdatePreferencesCommandHandler := provideUserUpdatePreferencesCommandHandler(userWriteRepository, eventStore, eventSourcedUserRepository, eventPublisher, transactionManager, config)
+	userGetPreferencesQueryHandler := provideUserGetPreferencesQueryHandler(userReadRepository, config)
+	userService := provideUserService(userCreateCommandHandler, userUpdateCommandHandler, userDeleteCommandHandler, userGetQueryHandler, userListQueryHandler, userGetByEmailQueryHandler, userEventsQueryHandler, userUpdatePreferencesCommandHandler, userGetPreferencesQueryHandler)
+	return userService, nil
+}
+
+// InitializeAvatarHandler initializes the avatar upload handler with all dependencies, authenticating
+// callers with tokens
+func InitializeAvatarHandler(tokens grpc.TokenValidator) (*grpc.AvatarHandler, error) {
+	databaseFactory := provideDatabaseFactory()
+	config := provideConfig()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	readDatabase, err := provideReadDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	repositoryFactory := provideRepositoryFactory(writeDatabase, readDatabase, eventDatabase, config)
+	keyring := provideKeyring(config)
+	eventStore, err := provideEventStore(repositoryFactory, keyring, config)
+	if err != nil {
+		return nil, err
+	}
+	eventSourcedUserRepository, err := provideEventSourcedUserRepository(eventStore)
+	if err != nil {
+		return nil, err
+	}
+	messageBrokerFactory := provideMessageBrokerFactory()
+	messageBroker, err := provideMessageBroker(messageBrokerFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventPublisher := provideEventPublisher(messageBroker, writeDatabase, config)
+	transactionManager := provideTransactionManager(writeDatabase, config)
+	storageStorage, err := provideStorage(config)
+	if err != nil {
+		return nil, err
+	}
+	clockClock := provideClock()
+	userUploadAvatarCommandHandler := provideUserUploadAvatarCommandHandler(eventSourcedUserRepository, eventPublisher, transactionManager, storageStorage, config, clockClock)
+	avatarHandler := provideAvatarHandler(userUploadAvatarCommandHandler, tokens, config)
+	return avatarHandler, nil
+}
+
+// InitializeChangefeedHandler initializes the user changefeed handler with all dependencies
+func InitializeChangefeedHandler() (*grpc.ChangefeedHandler, error) {
+	databaseFactory := provideDatabaseFactory()
+	config := provideConfig()
+	writeDatabase, err := provideWriteDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	readDatabase, err := provideReadDatabase(databaseFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	eventDatabase, err := provideEventDatabase(databaseFactory, writeDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	repositoryFactory := provideRepositoryFactory(writeDatabase, readDatabase, eventDatabase, config)
+	userChangeLogRepository, err := provideUserChangeLogRepository(repositoryFactory, config)
+	if err != nil {
+		return nil, err
+	}
+	clockClock := provideClock()
+	changefeedHandler := provideChangefeedHandler(userChangeLogRepository, config, clockClock)
+	return changefeedHandler, nil
+}
+
 // wire.go:
 
 // Type aliases to distinguish between different database types
@@ -143,12 +520,99 @@ func provideTracer(cfg *config.Config) (*tracing.Tracer, error) {
 	if !cfg.Tracing.Enabled {
 		return nil, nil
 	}
-	return tracing.NewTracer(cfg.Tracing.ServiceName, "1.0.0", cfg.Tracing.Endpoint)
+	return tracing.NewTracerFromConfig(tracing.Config{
+		ServiceName:    cfg.Tracing.ServiceName,
+		ServiceVersion: "1.0.0",
+		Endpoint:       cfg.Tracing.Endpoint,
+		Sampling: tracing.SamplingConfig{
+			Strategy:      cfg.Tracing.Sampler,
+			Ratio:         cfg.Tracing.SamplerRatio,
+			RatePerSecond: cfg.Tracing.SamplerRate,
+			ParentBased:   cfg.Tracing.SamplerParentBased,
+			SampleErrors:  cfg.Tracing.SampleErrors,
+			SlowThreshold: cfg.Tracing.SlowThreshold,
+		},
+		Export: tracing.ExportConfig{
+			Timeout:              cfg.Tracing.ExportTimeout,
+			RetryEnabled:         cfg.Tracing.ExportRetryEnabled,
+			RetryInitialInterval: cfg.Tracing.ExportRetryInitial,
+			RetryMaxInterval:     cfg.Tracing.ExportRetryMax,
+			RetryMaxElapsedTime:  cfg.Tracing.ExportRetryElapsed,
+			MaxQueueSize:         cfg.Tracing.ExportQueueSize,
+			MaxExportBatchSize:   cfg.Tracing.ExportBatchSize,
+			BatchTimeout:         cfg.Tracing.ExportBatchTimeout,
+		},
+	})
 }
 
 // provideLogger provides logger service
 func provideLogger(cfg *config.Config) (logger.Logger, error) {
-	return logger.NewLoggerFromConfig(cfg.Log.Level, cfg.Log.Format)
+	l, err := logger.NewLoggerFromConfig(cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return nil, err
+	}
+	// Log metric labels rejected to protect cardinality
+	metrics.NewMetrics().LabelGuard().SetLogger(l)
+	// Log and export the state changes of the circuit breakers
+	resilience.DefaultRegistry().OnStateChange(func(name string, from, to resilience.CircuitState) {
+		l.Warn("Circuit breaker %s changed from %s to %s", name, from, to)
+		metrics.NewMetrics().RecordCircuitBreakerStateChange(name, from.String(), to.String(), int(to))
+	})
+	return l, nil
+}
+
+// provideClock provides the system clock and installs it as the domain clock
+func provideClock() clock.Clock {
+	c := clock.New()
+	entities.SetClock(c)
+	events.SetClock(c)
+	return c
+}
+
+// Process-wide response cache shared by the gRPC server and the event consumer invalidating it
+var (
+	sharedResponseCache *cache.TaggedCache
+	responseCacheOnce   sync.Once
+)
+
+// provideResponseCache provides the response cache, or nil when response caching is disabled
+func provideResponseCache(cfg *config.Config) *cache.TaggedCache {
+	if !cfg.ResponseCache.Enabled {
+		return nil
+	}
+	responseCacheOnce.Do(func() {
+		sharedResponseCache = cache.NewTaggedCache(cfg.ResponseCache.MaxEntries, nil)
+	})
+	return sharedResponseCache
+}
+
+// Process-wide email index shared by the login handlers and the event consumer maintaining it
+var (
+	sharedEmailIndex *cache.KeyIndex
+	emailIndexOnce   sync.Once
+)
+
+// provideEmailIndex provides the index of user emails, or nil when logins read users by email
+func provideEmailIndex(cfg *config.Config) *cache.KeyIndex {
+	if !cfg.EmailIndex.Enabled {
+		return nil
+	}
+	emailIndexOnce.Do(func() {
+		sharedEmailIndex = cache.NewKeyIndex(cfg.EmailIndex.ExpectedUsers, cfg.EmailIndex.FalsePositiveRate, cfg.EmailIndex.MaxEntries)
+	})
+	return sharedEmailIndex
+}
+
+// provideEmailIndexRefresher provides the job rebuilding the email index from the write database
+func provideEmailIndexRefresher(writeRepo repositories2.UserWriteRepository, emailIndex *cache.KeyIndex, cfg *config.Config, clk clock.Clock) (*repositories.EmailIndexRefresher, error) {
+	if emailIndex == nil {
+		return nil, fmt.Errorf("the email index is disabled")
+	}
+	source, ok := writeRepo.(repositories.UserEmailSource)
+	if !ok {
+		return nil, fmt.Errorf("the %s write database does not list user emails", cfg.WriteDatabase.Type)
+	}
+	return repositories.NewEmailIndexRefresher(source, emailIndex, cfg.EmailIndex.RefreshInterval, clk, &consumers.SimpleLogger{}), nil
 }
 
 // provideTranslator provides i18n translator
@@ -168,9 +632,9 @@ func provideErrorHandler(translator *i18n.Translator, logger2 logger.Logger) *mi
 	return middleware.NewErrorHandler(translator, logger2)
 }
 
-// provideDatabaseFactory provides database factory
+// provideDatabaseFactory provides the database factory of the process, closing its pools on shutdown
 func provideDatabaseFactory() *database.DatabaseFactory {
-	return database.NewDatabaseFactory()
+	return databases
 }
 
 // provideWriteDatabase provides write database connection
@@ -186,7 +650,11 @@ func provideReadDatabase(factory *database.DatabaseFactory, cfg *config.Config)
 }
 
 // provideEventDatabase provides event database connection
-func provideEventDatabase(factory *database.DatabaseFactory, cfg *config.Config) (EventDatabase, error) {
+func provideEventDatabase(factory *database.DatabaseFactory, writeDB WriteDatabase, cfg *config.Config) (EventDatabase, error) {
+	// With the outbox events are appended in the command transaction on the write database
+	if cfg.Outbox.Enabled {
+		return EventDatabase(writeDB), nil
+	}
 	db, err := factory.CreateDatabase(&cfg.EventDatabase)
 	return EventDatabase(db), err
 }
@@ -201,6 +669,11 @@ func provideRepositoryFactory(
 	return repositories.NewRepositoryFactory(database.Database(writeDB), database.Database(readDB), database.Database(eventDB), cfg)
 }
 
+// provideCommandPolicy provides the business rules evaluated before commands run
+func provideCommandPolicy(cfg *config.Config) (commands.CommandPolicy, error) {
+	return policies.NewCommandPolicy(cfg.CommandRules.ApprovalDomains, cfg.CommandRules.File)
+}
+
 // provideMessageBrokerFactory provides message broker factory
 func provideMessageBrokerFactory() *messagebroker.MessageBrokerFactory {
 	return messagebroker.NewMessageBrokerFactory()
@@ -208,7 +681,31 @@ func provideMessageBrokerFactory() *messagebroker.MessageBrokerFactory {
 
 // provideMessageBroker provides message broker using factory
 func provideMessageBroker(factory *messagebroker.MessageBrokerFactory, cfg *config.Config) (messagebroker.MessageBroker, error) {
-	return factory.CreateMessageBroker(&cfg.MessageBroker)
+	broker, err := factory.CreateMessageBroker(&cfg.MessageBroker)
+	if err != nil || !cfg.Concurrency.Enabled {
+		return broker, err
+	}
+	return messagebroker.NewLimitedMessageBroker(broker, newAdaptiveLimiter(cfg, "message_broker")), nil
+}
+
+// newAdaptiveLimiter creates the adaptive concurrency limiter of a dependency, exporting its limit
+func newAdaptiveLimiter(cfg *config.Config, dependency string) *resilience.AdaptiveLimiter {
+	var algorithm resilience.LimitAlgorithm = resilience.NewAIMDLimit(0)
+	if cfg.Concurrency.Algorithm == "vegas" {
+		algorithm = resilience.NewVegasLimit(0)
+	}
+	m := metrics.NewMetrics()
+	m.RecordConcurrencyLimit(dependency, cfg.Concurrency.InitialLimit)
+	return resilience.NewAdaptiveLimiter(resilience.AdaptiveLimiterConfig{
+		InitialLimit:     cfg.Concurrency.InitialLimit,
+		MinLimit:         cfg.Concurrency.MinLimit,
+		MaxLimit:         cfg.Concurrency.MaxLimit,
+		Algorithm:        algorithm,
+		LatencyThreshold: cfg.Concurrency.LatencyThreshold,
+		OnLimitChange: func(limit int) {
+			m.RecordConcurrencyLimit(dependency, limit)
+		},
+	})
 }
 
 // provideUserEventHandler provides user event handler
@@ -221,18 +718,33 @@ func provideProductEventHandler() *consumers.ProductEventHandler {
 	return consumers.NewProductEventHandler()
 }
 
+// dlqLoadTimeout bounds the indexing of dead letter topics at startup
+const dlqLoadTimeout = 30 * time.Second
+
 // provideEventConsumer provides generic event consumer with multiple handlers
 func provideEventConsumer(
 	broker messagebroker.MessageBroker,
+	writeDB WriteDatabase,
 	userEventHandler *consumers.UserEventHandler,
+	userSummaryRepository repositories2.UserSummaryRepository,
+	userCountRepository repositories2.UserCountRepository,
+	userChangeLogRepository repositories2.UserChangeLogRepository,
+	inboxRepository repositories2.InboxRepository,
+	processedEventRepository repositories2.ProcessedEventRepository,
 	productEventHandler *consumers.ProductEventHandler,
 	cfg *config.Config,
+	clk clock.Clock,
+	responseCache *cache.TaggedCache,
+	emailIndex *cache.KeyIndex,
 ) *consumers.EventConsumerWrapper {
 	consumer := broker.GetConsumer()
 
+	// Get unique topics from config mapping, skipping topics whose events are all disabled
 	topicSet := make(map[string]bool)
-	for _, topic := range cfg.MessageBroker.Topics {
-		topicSet[topic] = true
+	for eventType, topic := range cfg.MessageBroker.Topics {
+		if cfg.Components.HandlerEnabled(eventType) {
+			topicSet[topic] = true
+		}
 	}
 
 	// Convert to slice
@@ -241,22 +753,127 @@ func provideEventConsumer(
 		topics = append(topics, topic)
 	}
 
+	// Add fallback topics for events not in config
 	fallbackTopics := []string{"product.created", "product.updated", "product.deleted"}
 	for _, topic := range fallbackTopics {
-		if !topicSet[topic] {
+		if !topicSet[topic] && cfg.Components.HandlerEnabled(topic) {
 			topics = append(topics, topic)
 		}
 	}
 
-	eventConsumer := consumers.NewEventConsumerWrapper(consumer, cfg.MessageBroker.GroupID, topics)
+	// Consume the latest, or the pinned, version of versioned topics
+	topicVersions := messagebroker.NewVersionedTopics(cfg.MessageBroker, metrics.NewMetrics(), clk)
+	topics = topicVersions.ConsumerTopics(topics)
+
+	// Consume per-tenant topics when events are routed by tenant
+	topics = messagebroker.NewTenantRouter(cfg.Tenancy).ConsumerTopics(topics)
+
+	// Consume the retry topics of failed messages when redelivery is enabled
+	retryRouter := messagebroker.NewRetryRouter(broker, cfg.MessageBroker)
+	retryRouter.SetDelayer(newDelayer(broker, writeDB, cfg))
+	topics = retryRouter.ConsumerTopics(topics)
+
+	// Create logger for event consumer
+	logger := &consumers.SimpleLogger{}
+
+	// Create event consumer with worker pool
+	eventConsumer := consumers.NewEventConsumerWrapperWithWorkerPool(consumer, cfg.MessageBroker.GroupID, topics, cfg, logger, clk)
+
+	// Apply user events to the user projections enabled
+	userHandler, loginHandler := userProjections(userEventHandler, userSummaryRepository, userCountRepository, userChangeLogRepository, clk)
+	// Preferences and email verifications are only kept in the read model, the other user
+	// projections do not record them
+	var readModelHandler consumers.LegacyEventHandler = userEventHandler
+
+	// Apply each user event to the projections once, recording it in the inbox in the same transaction
+	if inboxRepository != nil {
+		userHandler = consumers.NewInboxHandler(userHandler, inboxRepository, cfg.MessageBroker.GroupID)
+		readModelHandler = consumers.NewInboxHandler(readModelHandler, inboxRepository, cfg.MessageBroker.GroupID)
+		if loginHandler != nil {
+			loginHandler = consumers.NewInboxHandler(loginHandler, inboxRepository, cfg.MessageBroker.GroupID)
+		}
+	}
+
+	// Index the emails of created and deleted users for logins
+	if emailIndex != nil {
+		userHandler = consumers.NewEmailIndexingHandler(userHandler, emailIndex)
+	}
+
+	// Invalidate cached user reads once the read model is updated
+	if responseCache != nil {
+		userHandler = consumers.NewCacheInvalidatingHandler(userHandler, responseCache, grpc.UsersCacheTag)
+		readModelHandler = consumers.NewCacheInvalidatingHandler(readModelHandler, responseCache, grpc.UsersCacheTag)
+		if loginHandler != nil {
+			loginHandler = consumers.NewCacheInvalidatingHandler(loginHandler, responseCache, grpc.UsersCacheTag)
+		}
+	}
+
+	// Register the user and product event handlers enabled for this deployment
+	handlers := map[string]consumers.LegacyEventHandler{
+		"user.created":             userHandler,
+		"user.updated":             userHandler,
+		"user.deleted":             userHandler,
+		"user.preferences_updated": readModelHandler,
+		"user.email_verified":      readModelHandler,
+		"user.avatar_updated":      readModelHandler,
+		"product.created":          productEventHandler,
+		"product.updated":          productEventHandler,
+		"product.deleted":          productEventHandler,
+	}
+	if loginHandler != nil {
+		handlers["user.login"] = loginHandler
+	}
+	if cfg.Faults.Enabled {
+		for eventType, handler := range handlers {
+			handlers[eventType] = consumers.NewFailureInjectingHandler(handler, faultinjection.Global())
+		}
+	}
+	// Skip events the consumer group already processed when Kafka redelivers or replays them
+	if processedEventRepository != nil {
+		for eventType, handler := range handlers {
+			handlers[eventType] = consumers.NewIdempotentHandler(handler, processedEventRepository, cfg.MessageBroker.GroupID, logger)
+		}
+	}
+	registerEventHandlers(eventConsumer, cfg.Components, handlers, logger)
+
+	if retryRouter.Enabled() {
+		eventConsumer.SetRedeliverer(retryRouter)
+	}
+
+	// Count messages consumed per topic version to follow the migration off deprecated versions
+	eventConsumer.SetTopicVersions(topicVersions)
+
+	// Route events exceeding their topic's age limit to expired topics instead of skipping them
+	if expiredRouter := messagebroker.NewExpiredRouter(broker, cfg.MessageBroker); expiredRouter.Enabled() {
+		eventConsumer.SetExpiredPublisher(expiredRouter)
+	}
+
+	// Quarantine events whose payload violates the schema of their event type and version
+	if cfg.MessageBroker.ValidateSchemas {
+		registry, err := messagebroker.NewEventSchemaRegistry(cfg.MessageBroker)
+		if err != nil {
+			logger.Error("Failed to load event schemas, consuming payloads unvalidated: %v", err)
+		} else {
+			eventConsumer.SetPayloadValidator(registry)
+		}
+	}
 
-	eventConsumer.RegisterEventHandler("user.created", userEventHandler)
-	eventConsumer.RegisterEventHandler("user.updated", userEventHandler)
-	eventConsumer.RegisterEventHandler("user.deleted", userEventHandler)
+	// Retry dead letter events by republishing them with their failure headers
+	eventConsumer.SetDLQRetryHandler(messagebroker.NewDLQRepublisher(broker))
 
-	eventConsumer.RegisterEventHandler("product.created", productEventHandler)
-	eventConsumer.RegisterEventHandler("product.updated", productEventHandler)
-	eventConsumer.RegisterEventHandler("product.deleted", productEventHandler)
+	// Keep dead letter events in dead letter topics, indexing those left by earlier runs
+	if cfg.MessageBroker.DLQStorage == "kafka" {
+		if storage, err := messagebroker.NewKafkaDLQStorage(&cfg.MessageBroker); err != nil {
+			logger.Error("Failed to create Kafka DLQ storage, keeping dead letters in memory: %v", err)
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), dlqLoadTimeout)
+			if err := storage.Load(ctx, topics); err != nil {
+				logger.Error("Failed to load dead letter topics: %v", err)
+			}
+			cancel()
+			eventConsumer.SetDLQStorage(storage)
+		}
+	}
 
 	return eventConsumer
 }
@@ -267,23 +884,219 @@ func provideUserWriteRepository(factory *repositories.RepositoryFactory) (reposi
 }
 
 // provideUserReadRepository provides user read repository
-func provideUserReadRepository(factory *repositories.RepositoryFactory) (repositories2.UserReadRepository, error) {
-	return factory.CreateUserReadRepository()
+func provideUserReadRepository(factory *repositories.RepositoryFactory, cfg *config.Config) (repositories2.UserReadRepository, error) {
+	repository, err := factory.CreateUserReadRepository()
+	if err != nil || !cfg.Concurrency.Enabled {
+		return repository, err
+	}
+	return repositories.NewLimitedUserReadRepository(repository, newAdaptiveLimiter(cfg, "user_read_repository")), nil
+}
+
+// provideUserSummaryRepository provides the user summary repository, or nil when lists read full read models
+func provideUserSummaryRepository(factory *repositories.RepositoryFactory, cfg *config.Config) (repositories2.UserSummaryRepository, error) {
+	if !cfg.ReadModel.UserSummaries {
+		return nil, nil
+	}
+	return factory.CreateUserSummaryRepository()
+}
+
+// provideUserCountRepository provides the user counters, or nil when lists count users on every request
+func provideUserCountRepository(factory *repositories.RepositoryFactory, cfg *config.Config) (repositories2.UserCountRepository, error) {
+	if !cfg.ReadModel.UserCounts {
+		return nil, nil
+	}
+	return factory.CreateUserCountRepository()
+}
+
+// provideUserCountReconciler provides the job resetting the user counters to the counts of the write database
+func provideUserCountReconciler(writeRepo repositories2.UserWriteRepository, userCountRepository repositories2.UserCountRepository, cfg *config.Config, clk clock.Clock) (*repositories.UserCountReconciler, error) {
+	if userCountRepository == nil {
+		return nil, fmt.Errorf("the user counts are disabled")
+	}
+	source, ok := writeRepo.(repositories.UserStatusSource)
+	if !ok {
+		return nil, fmt.Errorf("the %s write database does not count users", cfg.WriteDatabase.Type)
+	}
+	return repositories.NewUserCountReconciler(source, userCountRepository, cfg.ReadModel.UserCountReconcileInterval, clk, &consumers.SimpleLogger{}), nil
+}
+
+// provideUserChangeLogRepository provides the user change log repository, or nil when the changefeed is disabled
+func provideUserChangeLogRepository(factory *repositories.RepositoryFactory, cfg *config.Config) (repositories2.UserChangeLogRepository, error) {
+	if !cfg.Changefeed.Enabled {
+		return nil, nil
+	}
+	return factory.CreateUserChangeLogRepository()
+}
+
+// provideInboxRepository provides the inbox of consumed events, or nil when events are applied without it
+func provideInboxRepository(factory *repositories.RepositoryFactory, cfg *config.Config) (repositories2.InboxRepository, error) {
+	if !cfg.ReadModel.Inbox {
+		return nil, nil
+	}
+	return factory.CreateInboxRepository()
+}
+
+// provideProcessedEventRepository provides the record of events processed by the consumer group,
+// or nil when redelivered events are handled again
+func provideProcessedEventRepository(factory *repositories.RepositoryFactory, cfg *config.Config) (repositories2.ProcessedEventRepository, error) {
+	if !cfg.MessageBroker.IdempotentConsumers {
+		return nil, nil
+	}
+	return factory.CreateProcessedEventRepository()
+}
+
+// provideChangefeedHandler provides the HTTP user changefeed handler
+func provideChangefeedHandler(userChangeLogRepository repositories2.UserChangeLogRepository, cfg *config.Config, clk clock.Clock) *grpc.ChangefeedHandler {
+	changesHandler := queries.NewUserChangesQueryHandler(userChangeLogRepository, cfg.Changefeed.SettleDelay, cfg.Changefeed.PageSize, cfg.Changefeed.MaxPageSize, clk)
+	return grpc.NewChangefeedHandler(changesHandler, cfg.Changefeed.Token)
 }
 
 // provideUserRepository provides user repository (combines write and read)
-func provideUserRepository(writeRepo repositories2.UserWriteRepository, readRepo repositories2.UserReadRepository) repositories2.UserRepository {
-	return writeRepo.(repositories2.UserRepository)
+func provideUserRepository(writeRepo repositories2.UserWriteRepository, readRepo repositories2.UserReadRepository, emailIndex *cache.KeyIndex) repositories2.UserRepository {
+	userRepo := writeRepo.(repositories2.UserRepository)
+	// Look logins up in the email index maintained by the event consumer
+	if emailIndex != nil {
+		return repositories.NewEmailIndexedUserRepository(userRepo, emailIndex)
+	}
+	return userRepo
+}
+
+// provideKeyring provides the data keys of tenants, or nil when tenant data is not encrypted
+func provideKeyring(cfg *config.Config) *tenantkeys.Keyring {
+	if !cfg.Encryption.Enabled {
+		return nil
+	}
+	return tenantkeys.NewKeyring(secrets.NewFileProvider(cfg.Encryption.SecretsDir), nil)
+}
+
+// provideEventStore provides event store, encrypting tenant event data when a keyring is given
+// and streaming events to in-process subscribers when enabled
+func provideEventStore(factory *repositories.RepositoryFactory, keyring *tenantkeys.Keyring, cfg *config.Config) (repositories2.EventStore, error) {
+	eventStore, err := factory.CreateEventStore()
+	if err != nil {
+		return nil, err
+	}
+	if keyring != nil {
+		eventStore = repositories.NewEncryptingEventStore(eventStore, keyring)
+	}
+	// Subscriptions read the whole event store past the concurrency limit, as projections do
+	eventLog, ok := eventStore.(repositories2.EventLog)
+	if cfg.Subscriptions.Enabled && !ok {
+		return nil, fmt.Errorf("event store %T does not support reading all events", eventStore)
+	}
+	if cfg.Concurrency.Enabled {
+		eventStore = repositories.NewLimitedEventStore(eventStore, newAdaptiveLimiter(cfg, "event_store"))
+	}
+	if !cfg.Subscriptions.Enabled {
+		return eventStore, nil
+	}
+	return repositories.NewSubscribingEventStore(eventStore, eventLog, cfg.Subscriptions.BatchSize, cfg.Subscriptions.PollInterval, &consumers.SimpleLogger{}), nil
+}
+
+// provideEventSourcedUserRepository provides the users loaded from their event stream, which
+// commands append their events to at the version of the stream they decided on
+func provideEventSourcedUserRepository(eventStore repositories2.EventStore) (*repositories2.EventSourcedUserRepository, error) {
+	versioned, ok := eventStore.(repositories2.VersionedEventStore)
+	if !ok {
+		return nil, fmt.Errorf("event store %T does not support appending at a version", eventStore)
+	}
+	return repositories2.NewEventSourcedUserRepository(versioned), nil
+}
+
+// provideEventPublisher provides event publisher, appending events to the outbox when enabled and
+// recording sign ins in the audit log when enabled
+func provideEventPublisher(broker messagebroker.MessageBroker, writeDB WriteDatabase, cfg *config.Config) repositories2.EventPublisher {
+	var publisher repositories2.EventPublisher
+	if cfg.Outbox.Enabled {
+		publisher = repositories.NewOutboxEventPublisher(repositories.NewPostgresOutbox(writeDB))
+	} else {
+		brokerPublisher := repositories.NewMessageBrokerEventPublisher(broker, cfg)
+		brokerPublisher.SetDelayer(newDelayer(broker, writeDB, cfg))
+		publisher = brokerPublisher
+	}
+	if !cfg.AuditLog.Enabled {
+		return publisher
+	}
+	auditLog := audit.NewLog(repositories.NewPostgresAuditStore(writeDB.GetDB()), nil)
+	return repositories.NewAuditingEventPublisher(publisher, auditLog, &consumers.SimpleLogger{})
+}
+
+// delaySchedulerBatchSize bounds the delayed messages published per transaction
+const delaySchedulerBatchSize = 100
+
+// newDelayer creates the delayer of messages published after a delay, keeping them in the
+// delayed message store of the write database when configured
+func newDelayer(broker messagebroker.MessageBroker, writeDB WriteDatabase, cfg *config.Config) *messagebroker.Delayer {
+	var store messagebroker.DelayedMessageStore
+	if cfg.MessageBroker.DelayedStore == "postgres" {
+		store = messagebroker.NewPostgresDelayedMessageStore(writeDB)
+	}
+	return messagebroker.NewDelayer(broker, store, nil)
+}
+
+// provideDelayScheduler provides the scheduler publishing the due messages of the delayed message store
+func provideDelayScheduler(broker messagebroker.MessageBroker, writeDB WriteDatabase, cfg *config.Config) *messagebroker.DelayScheduler {
+	store := messagebroker.NewPostgresDelayedMessageStore(writeDB)
+	return messagebroker.NewDelayScheduler(broker, store, delaySchedulerBatchSize, cfg.MessageBroker.DelayedPollInterval, nil, &consumers.SimpleLogger{})
 }
 
-// provideEventStore provides event store
-func provideEventStore(factory *repositories.RepositoryFactory) (repositories2.EventStore, error) {
-	return factory.CreateEventStore()
+// provideOutboxRelay provides the relay publishing the events of the outbox to the message broker
+func provideOutboxRelay(broker messagebroker.MessageBroker, writeDB WriteDatabase, cfg *config.Config) *repositories.OutboxRelay {
+	publisher := repositories.NewMessageBrokerEventPublisher(broker, cfg)
+	return repositories.NewOutboxRelay(repositories.NewPostgresOutbox(writeDB), publisher, cfg.Outbox.BatchSize, cfg.Outbox.PollInterval, &consumers.SimpleLogger{})
 }
 
-// provideEventPublisher provides event publisher
-func provideEventPublisher(broker messagebroker.MessageBroker, cfg *config.Config) repositories2.EventPublisher {
-	return repositories.NewMessageBrokerEventPublisher(broker, cfg)
+// provideKeyRotationJob provides the job rotating tenant data keys and re-encrypting tenant events
+func provideKeyRotationJob(eventDB EventDatabase, keyring *tenantkeys.Keyring, cfg *config.Config) *repositories.KeyRotationJob {
+	store := repositories.NewPostgresKeyRotationStore(eventDB.GetDB())
+	return repositories.NewKeyRotationJob(store, keyring, cfg.Tenancy.Tenants, cfg.Encryption.KeyMaxAge, cfg.Encryption.RotationBatchSize, cfg.Encryption.RotationInterval, nil, &consumers.SimpleLogger{})
+}
+
+// provideReadModelArchiver provides the job moving expired documents of archived read model collections
+func provideReadModelArchiver(factory *repositories.RepositoryFactory, cfg *config.Config) (*repositories.ReadModelArchiver, error) {
+	policies, err := repositories.NewRetentionPolicies(cfg.ReadDatabase.Collection, cfg.ReadModel.Retention, cfg.ReadModel.Archive)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := factory.CreateReadModelArchive()
+	if err != nil {
+		return nil, err
+	}
+	return repositories.NewReadModelArchiver(archive, policies, cfg.ReadModel.ArchiveBatchSize, cfg.ReadModel.ArchiveInterval, nil, &consumers.SimpleLogger{}), nil
+}
+
+// provideProjectionEngine provides the engine keeping the checkpointed projections up to date
+func provideProjectionEngine(factory *repositories.RepositoryFactory, eventDB EventDatabase, cfg *config.Config) (*projection.Engine, error) {
+	eventLog, err := projectionEventLog(factory, cfg)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := repositories.NewPostgresProjectionCheckpointStore(eventDB.GetDB())
+	engine := projection.NewEngine(repositories.NewEventLogSource(eventLog), checkpoints, cfg.Projections.BatchSize, cfg.Projections.PollInterval, nil, &consumers.SimpleLogger{})
+	projections, err := checkpointedProjections(factory, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.Register(projections...); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// provideTransactionManager provides the write database transaction manager commands append
+// their events to the outbox in, or nil when events are published directly
+func provideTransactionManager(writeDB WriteDatabase, cfg *config.Config) repositories2.TransactionManager {
+	if !cfg.Outbox.Enabled {
+		return nil
+	}
+	transactions := repositories.NewPostgresTransactionManager(writeDB)
+	transactions.SetRetry(retry.Policy{
+		MaxAttempts: cfg.TransactionRetry.MaxAttempts,
+		BaseDelay:   cfg.TransactionRetry.BaseDelay,
+		MaxDelay:    cfg.TransactionRetry.MaxDelay,
+		Jitter:      1,
+	}, metrics.NewMetrics())
+	return transactions
 }
 
 // Command Handlers (Write Operations)
@@ -291,33 +1104,76 @@ func provideUserCreateCommandHandler(
 	userWriteRepo repositories2.UserWriteRepository,
 	eventStore repositories2.EventStore,
 	eventPublisher repositories2.EventPublisher,
+	policy commands.CommandPolicy,
+	transactions repositories2.TransactionManager,
 ) *commands.UserCreateCommandHandler {
-	return commands.NewUserCreateCommandHandler(userWriteRepo, eventStore, eventPublisher)
+	handler := commands.NewUserCreateCommandHandler(userWriteRepo, eventStore, eventPublisher)
+	handler.SetPolicy(policy)
+	handler.SetTransactions(transactions)
+	return handler
 }
 
 func provideUserUpdateCommandHandler(
 	userWriteRepo repositories2.UserWriteRepository,
 	eventStore repositories2.EventStore,
+	users *repositories2.EventSourcedUserRepository,
 	eventPublisher repositories2.EventPublisher,
+	policy commands.CommandPolicy,
+	transactions repositories2.TransactionManager,
 ) *commands.UserUpdateCommandHandler {
-	return commands.NewUserUpdateCommandHandler(userWriteRepo, eventStore, eventPublisher)
+	handler := commands.NewUserUpdateCommandHandler(userWriteRepo, eventStore, users, eventPublisher)
+	handler.SetPolicy(policy)
+	handler.SetTransactions(transactions)
+	return handler
 }
 
 func provideUserDeleteCommandHandler(
 	userWriteRepo repositories2.UserWriteRepository,
 	eventStore repositories2.EventStore,
+	users *repositories2.EventSourcedUserRepository,
 	eventPublisher repositories2.EventPublisher,
+	policy commands.CommandPolicy,
+	transactions repositories2.TransactionManager,
 ) *commands.UserDeleteCommandHandler {
-	return commands.NewUserDeleteCommandHandler(userWriteRepo, eventStore, eventPublisher)
+	handler := commands.NewUserDeleteCommandHandler(userWriteRepo, eventStore, users, eventPublisher)
+	handler.SetPolicy(policy)
+	handler.SetTransactions(transactions)
+	return handler
 }
 
 // Query Handlers (Read Operations)
-func provideUserGetQueryHandler(userReadRepository repositories2.UserReadRepository) *queries.UserGetQueryHandler {
-	return queries.NewUserGetQueryHandler(userReadRepository)
+func provideUserGetQueryHandler(userReadRepository repositories2.UserReadRepository, userWriteRepository repositories2.UserWriteRepository, cfg *config.Config) *queries.UserGetQueryHandler {
+	// Reads of degradable endpoints go through a circuit breaker so they fail fast while the read store is down
+	fallback := cfg.ReadModel.Fallbacks["GetUser"]
+	if fallback != queries.ReadFallbackNone {
+		userReadRepository = repositories.NewCircuitBreakerUserReadRepository(userReadRepository, resilience.CircuitBreakerConfig{
+			FailureThreshold:      cfg.ReadModel.FailureThreshold,
+			Timeout:               cfg.ReadModel.OpenTimeout,
+			SuccessThreshold:      1,
+			MaxHalfOpenCalls:      cfg.ReadModel.HalfOpenCalls,
+			WindowSize:            cfg.ReadModel.BreakerWindowSize,
+			MinimumCalls:          cfg.ReadModel.BreakerMinimumCalls,
+			FailureRateThreshold:  cfg.ReadModel.BreakerFailureRate,
+			SlowCallRateThreshold: cfg.ReadModel.BreakerSlowCallRate,
+			SlowCallDuration:      cfg.ReadModel.BreakerSlowCallDuration,
+		})
+	}
+
+	handler := queries.NewUserGetQueryHandler(userReadRepository)
+	// Reads carrying a consistency token fall back to the write database when the projection lags
+	handler.SetReadYourWrites(userWriteRepository, cfg.ReadModel.ConsistencyMaxWait, cfg.ReadModel.ConsistencyPollInterval, nil)
+	if fallback != queries.ReadFallbackNone {
+		handler.SetDegradation(fallback, userWriteRepository, cache.NewMemoryCache(), cfg.ReadModel.FallbackCacheTTL)
+	}
+	return handler
 }
 
-func provideUserListQueryHandler(userReadRepository repositories2.UserReadRepository) *queries.UserListQueryHandler {
-	return queries.NewUserListQueryHandler(userReadRepository)
+func provideUserListQueryHandler(userReadRepository repositories2.UserReadRepository, userSummaryRepository repositories2.UserSummaryRepository) *queries.UserListQueryHandler {
+	handler := queries.NewUserListQueryHandler(userReadRepository)
+	if userSummaryRepository != nil {
+		handler.SetSummaries(userSummaryRepository)
+	}
+	return handler
 }
 
 func provideUserGetByEmailQueryHandler(userReadRepository repositories2.UserReadRepository) *queries.UserGetByEmailQueryHandler {
@@ -328,6 +1184,40 @@ func provideUserEventsQueryHandler(userReadRepository repositories2.UserReadRepo
 	return queries.NewUserEventsQueryHandler(userReadRepository)
 }
 
+// preferenceDefaults returns the preferences of users who did not choose their own
+func preferenceDefaults(cfg *config.Config) entities.Preferences {
+	locale := cfg.Preferences.DefaultLocale
+	if locale == "" {
+		locale = cfg.I18n.DefaultLocale
+	}
+	return entities.Preferences{
+		Locale: locale,
+		Theme:  cfg.Preferences.DefaultTheme,
+		Notifications: entities.NotificationPreferences{
+			Email:  cfg.Preferences.EmailNotifications,
+			Push:   cfg.Preferences.PushNotifications,
+			Digest: cfg.Preferences.NotificationDigest,
+		},
+	}
+}
+
+func provideUserUpdatePreferencesCommandHandler(
+	userWriteRepo repositories2.UserWriteRepository,
+	eventStore repositories2.EventStore,
+	users *repositories2.EventSourcedUserRepository,
+	eventPublisher repositories2.EventPublisher,
+	transactions repositories2.TransactionManager,
+	cfg *config.Config,
+) *commands.UserUpdatePreferencesCommandHandler {
+	handler := commands.NewUserUpdatePreferencesCommandHandler(userWriteRepo, eventStore, users, eventPublisher, preferenceDefaults(cfg))
+	handler.SetTransactions(transactions)
+	return handler
+}
+
+func provideUserGetPreferencesQueryHandler(userReadRepository repositories2.UserReadRepository, cfg *config.Config) *queries.UserGetPreferencesQueryHandler {
+	return queries.NewUserGetPreferencesQueryHandler(userReadRepository, preferenceDefaults(cfg))
+}
+
 // provideUserService provides user service
 func provideUserService(
 	createCommandHandler *commands.UserCreateCommandHandler,
@@ -337,8 +1227,10 @@ func provideUserService(
 	listQueryHandler *queries.UserListQueryHandler,
 	getByEmailQueryHandler *queries.UserGetByEmailQueryHandler,
 	eventsQueryHandler *queries.UserEventsQueryHandler,
+	updatePreferencesCommandHandler *commands.UserUpdatePreferencesCommandHandler,
+	getPreferencesQueryHandler *queries.UserGetPreferencesQueryHandler,
 ) *services.UserService {
-	return services.NewUserService(
+	service := services.NewUserService(
 		createCommandHandler,
 		updateCommandHandler,
 		deleteCommandHandler,
@@ -347,11 +1239,18 @@ func provideUserService(
 		getByEmailQueryHandler,
 		eventsQueryHandler,
 	)
+	service.SetPreferences(updatePreferencesCommandHandler, getPreferencesQueryHandler)
+	return service
 }
 
-// provideJWTService provides JWT service
-func provideJWTService(cfg *config.Config) (*auth.JWTService, error) {
-	return auth.NewJWTService(cfg.Auth.PrivateKeyPath, cfg.Auth.PublicKeyPath, time.Duration(cfg.Auth.TokenExpiry)*time.Hour)
+// provideJWTService provides JWT service issuing tokens at the time of clk
+func provideJWTService(cfg *config.Config, clk clock.Clock) (*auth.JWTService, error) {
+	jwtService, err := auth.NewJWTService(cfg.Auth.PrivateKeyPath, cfg.Auth.PublicKeyPath, time.Duration(cfg.Auth.TokenExpiry)*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	jwtService.SetClock(clk)
+	return jwtService, nil
 }
 
 // providePasswordService provides password service
@@ -366,26 +1265,242 @@ func provideAuthRegisterCommandHandler(
 	eventPublisher repositories2.EventPublisher,
 	passwordService *auth.PasswordService,
 	jwtService *auth.JWTService,
+	policy commands.CommandPolicy,
+	transactions repositories2.TransactionManager,
 ) *commands.AuthRegisterCommandHandler {
-	return commands.NewAuthRegisterCommandHandler(userRepo, eventStore, eventPublisher, passwordService, jwtService)
+	handler := commands.NewAuthRegisterCommandHandler(userRepo, eventStore, eventPublisher, passwordService, jwtService)
+	handler.SetPolicy(policy)
+	handler.SetTransactions(transactions)
+	return handler
 }
 
-// provideAuthLoginCommandHandler provides auth login command handler
+// provideAuthLoginCommandHandler provides auth login command handler, locking out accounts and
+// client addresses failing to log in too often when enabled
 func provideAuthLoginCommandHandler(
+	factory *repositories.RepositoryFactory,
 	userRepo repositories2.UserRepository,
 	passwordService *auth.PasswordService,
 	jwtService *auth.JWTService,
-) *commands.AuthLoginCommandHandler {
-	return commands.NewAuthLoginCommandHandler(userRepo, passwordService, jwtService)
+	eventPublisher repositories2.EventPublisher,
+	cfg *config.Config,
+) (*commands.AuthLoginCommandHandler, error) {
+	handler := commands.NewAuthLoginCommandHandler(userRepo, passwordService, jwtService)
+	// Logins are only published for the last login of user summaries and for auditing lockouts
+	if cfg.ReadModel.UserSummaries || cfg.LoginLockout.Enabled {
+		handler.SetEventPublisher(eventPublisher)
+	}
+	if !cfg.LoginLockout.Enabled {
+		return handler, nil
+	}
+
+	attempts, err := factory.CreateLoginAttemptRepository()
+	if err != nil {
+		return nil, err
+	}
+	handler.SetLockout(attempts, commands.LoginLockoutPolicy{
+		MaxFailures:   cfg.LoginLockout.MaxFailures,
+		IPMaxFailures: cfg.LoginLockout.IPMaxFailures,
+		Window:        cfg.LoginLockout.Window,
+		Duration:      cfg.LoginLockout.Duration,
+	})
+	return handler, nil
+}
+
+// provideNotificationSender provides the sender of notifications to users: published to a topic
+// of the message broker for the notifications worker, or delivered by the service itself
+func provideNotificationSender(broker messagebroker.MessageBroker, cfg *config.Config) notification.Sender {
+	if cfg.Notification.Publisher == "topic" {
+		return notification.NewTopicSender(broker, cfg.Notification.Topic)
+	}
+	return newNotificationDelivery(cfg)
+}
+
+// newNotificationDelivery returns the sender delivering notifications: mailed through the SMTP
+// server, posted to the webhook, or logged when neither is set
+func newNotificationDelivery(cfg *config.Config) notification.Sender {
+	switch {
+	case cfg.Notification.SMTPHost != "":
+		return notification.NewSMTPSender(notification.SMTPConfig{
+			Host:     cfg.Notification.SMTPHost,
+			Port:     cfg.Notification.SMTPPort,
+			Username: cfg.Notification.SMTPUsername,
+			Password: cfg.Notification.SMTPPassword,
+			From:     cfg.Notification.SMTPFrom,
+		}, nil)
+	case cfg.Notification.WebhookURL != "":
+		return notification.NewWebhookSender(cfg.Notification.WebhookURL, nil)
+	}
+	return notification.NewLogSender(&consumers.SimpleLogger{})
+}
+
+// provideAuthMagicLinkCommandHandler provides the magic link command handler, or nil when magic
+// links are disabled
+func provideAuthMagicLinkCommandHandler(
+	factory *repositories.RepositoryFactory,
+	userRepo repositories2.UserRepository,
+	jwtService *auth.JWTService,
+	eventPublisher repositories2.EventPublisher,
+	sender notification.Sender,
+	cfg *config.Config,
+) (*commands.AuthMagicLinkCommandHandler, error) {
+	if !cfg.MagicLink.Enabled {
+		return nil, nil
+	}
+	magicLinks, err := factory.CreateMagicLinkRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := resilience.NewKeyedLimiter(float64(cfg.MagicLink.RequestsPerHour)/3600, cfg.MagicLink.RequestsPerHour, nil)
+
+	handler := commands.NewAuthMagicLinkCommandHandler(userRepo, magicLinks, jwtService, sender, limiter, commands.MagicLinkOptions{
+		URL:        cfg.MagicLink.URL,
+		TTL:        cfg.MagicLink.TTL,
+		BindDevice: cfg.MagicLink.BindDevice,
+	})
+	handler.SetEventPublisher(eventPublisher)
+	return handler, nil
+}
+
+// provideAuthPasswordResetCommandHandler provides the password reset command handler, or nil when
+// password resets are disabled
+func provideAuthPasswordResetCommandHandler(
+	userRepo repositories2.UserRepository,
+	eventStore repositories2.EventStore,
+	users *repositories2.EventSourcedUserRepository,
+	refreshTokens repositories2.RefreshTokenRepository,
+	eventPublisher repositories2.EventPublisher,
+	passwordService *auth.PasswordService,
+	jwtService *auth.JWTService,
+	sender notification.Sender,
+	transactions repositories2.TransactionManager,
+	cfg *config.Config,
+) *commands.AuthPasswordResetCommandHandler {
+	if !cfg.PasswordReset.Enabled {
+		return nil
+	}
+
+	limiter := resilience.NewKeyedLimiter(float64(cfg.PasswordReset.RequestsPerHour)/3600, cfg.PasswordReset.RequestsPerHour, nil)
+
+	handler := commands.NewAuthPasswordResetCommandHandler(userRepo, eventStore, users, eventPublisher, passwordService, jwtService, sender, limiter, commands.PasswordResetOptions{
+		URL: cfg.PasswordReset.URL,
+		TTL: cfg.PasswordReset.TTL,
+	})
+	handler.SetTransactions(transactions)
+	if refreshTokens != nil {
+		handler.SetRefreshTokens(refreshTokens)
+	}
+	return handler
+}
+
+// provideAuthEmailVerificationCommandHandler provides the email verification command handler, or
+// nil when email verification is disabled
+func provideAuthEmailVerificationCommandHandler(
+	userRepo repositories2.UserRepository,
+	eventStore repositories2.EventStore,
+	users *repositories2.EventSourcedUserRepository,
+	eventPublisher repositories2.EventPublisher,
+	jwtService *auth.JWTService,
+	sender notification.Sender,
+	transactions repositories2.TransactionManager,
+	cfg *config.Config,
+) *commands.AuthEmailVerificationCommandHandler {
+	if !cfg.EmailVerification.Enabled {
+		return nil
+	}
+
+	limiter := resilience.NewKeyedLimiter(float64(cfg.EmailVerification.RequestsPerHour)/3600, cfg.EmailVerification.RequestsPerHour, nil)
+
+	handler := commands.NewAuthEmailVerificationCommandHandler(userRepo, eventStore, users, eventPublisher, jwtService, sender, limiter, commands.EmailVerificationOptions{
+		URL: cfg.EmailVerification.URL,
+		TTL: cfg.EmailVerification.TTL,
+	})
+	handler.SetTransactions(transactions)
+	return handler
+}
+
+// provideRefreshTokenRepository provides the refresh token store, or nil when refresh tokens are
+// disabled
+func provideRefreshTokenRepository(factory *repositories.RepositoryFactory, cfg *config.Config) (repositories2.RefreshTokenRepository, error) {
+	if !cfg.RefreshTokens.Enabled {
+		return nil, nil
+	}
+	return factory.CreateRefreshTokenRepository()
+}
+
+// provideAuthRefreshTokenCommandHandler provides the refresh token command handler, or nil when
+// refresh tokens are disabled
+func provideAuthRefreshTokenCommandHandler(
+	userRepo repositories2.UserRepository,
+	refreshTokens repositories2.RefreshTokenRepository,
+	jwtService *auth.JWTService,
+	cfg *config.Config,
+) *commands.AuthRefreshTokenCommandHandler {
+	if refreshTokens == nil {
+		return nil
+	}
+	return commands.NewAuthRefreshTokenCommandHandler(userRepo, refreshTokens, jwtService, cfg.RefreshTokens.TTL)
 }
 
 // provideAuthService provides auth service
 func provideAuthService(
 	registerHandler *commands.AuthRegisterCommandHandler,
 	loginHandler *commands.AuthLoginCommandHandler,
+	magicLinkHandler *commands.AuthMagicLinkCommandHandler,
+	refreshTokenHandler *commands.AuthRefreshTokenCommandHandler,
+	passwordResetHandler *commands.AuthPasswordResetCommandHandler,
+	emailVerificationHandler *commands.AuthEmailVerificationCommandHandler,
 	jwtService *auth.JWTService,
 ) *services.AuthService {
-	return services.NewAuthService(registerHandler, loginHandler, jwtService)
+	authService := services.NewAuthService(registerHandler, loginHandler, jwtService)
+	if magicLinkHandler != nil {
+		authService.SetMagicLinkHandler(magicLinkHandler)
+	}
+	if refreshTokenHandler != nil {
+		authService.SetRefreshTokenHandler(refreshTokenHandler)
+	}
+	if passwordResetHandler != nil {
+		authService.SetPasswordResetHandler(passwordResetHandler)
+	}
+	if emailVerificationHandler != nil {
+		authService.SetEmailVerificationHandler(emailVerificationHandler)
+	}
+	return authService
+}
+
+// provideStorage provides object storage
+func provideStorage(cfg *config.Config) (storage.Storage, error) {
+	return storage.New(storage.Config{
+		Provider:     cfg.Storage.Provider,
+		BasePath:     cfg.Storage.BasePath,
+		BaseURL:      cfg.Storage.BaseURL,
+		SigningKey:   cfg.Storage.SigningKey,
+		Bucket:       cfg.Storage.Bucket,
+		Region:       cfg.Storage.Region,
+		Endpoint:     cfg.Storage.Endpoint,
+		AccessKey:    cfg.Storage.AccessKey,
+		SecretKey:    cfg.Storage.SecretKey,
+		UsePathStyle: cfg.Storage.UsePathStyle,
+	})
+}
+
+// provideUserUploadAvatarCommandHandler provides user upload avatar command handler
+func provideUserUploadAvatarCommandHandler(
+	users *repositories2.EventSourcedUserRepository,
+	eventPublisher repositories2.EventPublisher,
+	transactions repositories2.TransactionManager,
+	store storage.Storage,
+	cfg *config.Config,
+	clk clock.Clock,
+) *commands.UserUploadAvatarCommandHandler {
+	handler := commands.NewUserUploadAvatarCommandHandler(users, eventPublisher, store, cfg.Storage.MaxAvatarSize, cfg.Storage.SignedURLTTL, clk)
+	handler.SetTransactions(transactions)
+	return handler
+}
+
+// provideAvatarHandler provides the HTTP avatar upload handler, authenticating callers with tokens
+func provideAvatarHandler(uploadHandler *commands.UserUploadAvatarCommandHandler, tokens grpc.TokenValidator, cfg *config.Config) *grpc.AvatarHandler {
+	return grpc.NewAvatarHandler(uploadHandler, tokens, cfg.Storage.MaxAvatarSize)
 }
 
 // provideGRPCServer provides gRPC server
@@ -393,6 +1508,27 @@ func provideGRPCServer(
 	userService *services.UserService,
 	authService *services.AuthService,
 	tracer *tracing.Tracer, logger2 logger.Logger,
-) *grpc.GRPCServer {
-	return grpc.NewGRPCServer(userService, authService, tracer, logger2)
+	responseCache *cache.TaggedCache,
+	authorizer *authz.Authorizer,
+	cfg *config.Config,
+) (*grpc.GRPCServer, error) {
+	server := grpc.NewGRPCServer(userService, authService, tracer, logger2, responseCache, cfg.ResponseCache, cfg.Components, cfg.APIVersions, cfg.RateLimit, cfg.Log, cfg.RequestCost, authorizer)
+	if err := server.BindAuthorization(); err != nil {
+		return nil, err
+	}
+	trustedProxies, err := cfg.Server.TrustedProxyNetworks()
+	if err != nil {
+		return nil, err
+	}
+	server.SetTrustedProxies(trustedProxies)
+	return server, nil
+}
+
+// provideAuthorizer provides the authorizer of gRPC calls, nil when no authorization matrix is
+// configured and calls are only authenticated
+func provideAuthorizer(cfg *config.Config, logger2 logger.Logger) (*authz.Authorizer, error) {
+	if cfg.Authorization.File == "" {
+		return nil, nil
+	}
+	return authz.NewAuthorizer(cfg.Authorization.File, nil, logger2)
 }
@@ -0,0 +1,142 @@
+package integration_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go-clean-ddd-es-template/internal/application/commands"
+	"go-clean-ddd-es-template/internal/application/queries"
+	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	grpcserver "go-clean-ddd-es-template/internal/infrastructure/grpc"
+	"go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/middleware"
+	"go-clean-ddd-es-template/pkg/tracing/tracingtest"
+	userv2 "go-clean-ddd-es-template/proto/user/v2"
+)
+
+// publishedMessage is a message handed to the capturing broker
+type publishedMessage struct {
+	topic   string
+	message []byte
+	headers kafka.Headers
+}
+
+// capturingBroker hands published messages to the test instead of a broker
+type capturingBroker struct {
+	published chan publishedMessage
+}
+
+func (b *capturingBroker) Connect() error                                     { return nil }
+func (b *capturingBroker) Close() error                                       { return nil }
+func (b *capturingBroker) Subscribe(topic string, handler func([]byte)) error { return nil }
+func (b *capturingBroker) GetConsumer() sarama.Consumer                       { return nil }
+
+func (b *capturingBroker) Publish(topic string, message []byte) error {
+	return b.PublishWithHeaders(topic, "", message, kafka.Headers{})
+}
+
+func (b *capturingBroker) PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error {
+	b.published <- publishedMessage{topic: topic, message: message, headers: headers.Clone()}
+	return nil
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Info(msg string, args ...interface{})  {}
+func (noopLogger) Error(msg string, args ...interface{}) {}
+func (noopLogger) Warn(msg string, args ...interface{})  {}
+
+// TestTracePropagation follows a create user request from the gRPC server through the command,
+// the publisher and the consumer to the projection, asserting that every step continues the
+// trace of the step before it. The broker is replaced by handing published messages with their
+// headers to the consumer, as Kafka would.
+func TestTracePropagation(t *testing.T) {
+	recorder := tracingtest.NewRecorder(t)
+	cfg := &config.Config{
+		MessageBroker: config.MessageBrokerConfig{PublisherWorkers: 1, ConsumerWorkers: 1, WorkerBufferSize: 10},
+	}
+
+	// Write side: the command handler publishes through the worker pool publisher
+	writeRepository := mocks.NewMockUserWriteRepository(t)
+	writeRepository.EXPECT().GetByEmail(mock.Anything, "trace@example.com").Return(nil, nil)
+	writeRepository.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+	eventStore := mocks.NewMockEventStore(t)
+	eventStore.EXPECT().SaveEvent(mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	broker := &capturingBroker{published: make(chan publishedMessage, 1)}
+	publisher := repositories.NewWorkerPoolEventPublisher(broker, cfg)
+	defer publisher.Stop()
+
+	readRepository := repositories.NewInMemoryUserReadRepository(nil)
+	userService := services.NewUserService(
+		commands.NewUserCreateCommandHandler(writeRepository, eventStore, publisher),
+		nil, nil,
+		queries.NewUserGetQueryHandler(readRepository),
+		nil, nil, nil,
+	)
+
+	// gRPC server with the tracing interceptor, served in memory
+	tracer := recorder.Tracer()
+	server := grpc.NewServer(grpc.UnaryInterceptor(middleware.GRPCTracingInterceptor(tracer)))
+	userv2.RegisterUserServiceServer(server, grpcserver.NewUserGRPCServer(userService, tracer))
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Read side: the worker pool consumer projects into the read model
+	consumer := consumers.NewWorkerPoolEventConsumer(cfg, nil, noopLogger{}, nil)
+	defer consumer.Stop()
+	consumer.RegisterHandler("user.created", consumers.NewEventHandlerAdapter(consumers.NewUserEventHandler(readRepository)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := userv2.NewUserServiceClient(conn).CreateUser(ctx, &userv2.CreateUserRequest{Email: "trace@example.com", Name: "Trace User"})
+	require.NoError(t, err)
+
+	var published publishedMessage
+	select {
+	case published = <-broker.published:
+	case <-ctx.Done():
+		t.Fatal("event not published")
+	}
+	assert.NotEmpty(t, published.headers.Get("traceparent"), "the trace context is carried by the message headers")
+
+	// Consume in a context of its own, as another process would
+	published.headers.Set(kafka.HeaderOriginalTopic, published.topic)
+	require.NoError(t, consumer.HandleMessage(kafka.ContextWithHeaders(context.Background(), published.headers), published.message))
+	recorder.WaitForSpan(t, "EventHandler.HandleEvent", 5*time.Second)
+
+	recorder.AssertChain(t,
+		userv2.UserService_CreateUser_FullMethodName,
+		"UserGRPCServer.CreateUser",
+		"UserService.CreateUser",
+		"WorkerPoolEventPublisher.PublishEvent",
+		"WorkerPoolEventConsumer.Consume",
+		"EventHandler.HandleEvent",
+	)
+
+	projected, err := readRepository.GetUserByID(context.Background(), resp.User.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "trace@example.com", projected.Email)
+}
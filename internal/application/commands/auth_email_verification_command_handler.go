@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/notification"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// EmailVerificationTemplate is the notification template of email verification links
+const EmailVerificationTemplate = "email_verification"
+
+// emailVerificationSentMessage is the response of every verification link requested, so it does
+// not tell whether the email belongs to a user
+const emailVerificationSentMessage = "If an account with this email is waiting for verification, a verification link was sent to it"
+
+// EmailVerificationOptions configures the email verification links sent to users
+type EmailVerificationOptions struct {
+	URL string        // Verification page of the link, with a {token} placeholder
+	TTL time.Duration // How long links can be used
+}
+
+// AuthEmailVerificationCommandHandler handles email verification: it sends users a signed link to
+// their email, then records the email verified once the link is used. Links only verify the
+// email they were sent to.
+type AuthEmailVerificationCommandHandler struct {
+	userRepo       repositories.UserRepository
+	eventStore     repositories.EventStore
+	users          *repositories.EventSourcedUserRepository
+	eventPublisher repositories.EventPublisher
+	jwtService     *auth.JWTService
+	sender         notification.Sender
+	limiter        *resilience.KeyedLimiter
+	options        EmailVerificationOptions
+	transactions   repositories.TransactionManager
+}
+
+// NewAuthEmailVerificationCommandHandler creates a new auth email verification command handler.
+// Links requested again are limited per email by limiter; a nil limiter does not limit them.
+// Verification events are appended to the user's stream loaded from users.
+func NewAuthEmailVerificationCommandHandler(
+	userRepo repositories.UserRepository,
+	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
+	eventPublisher repositories.EventPublisher,
+	jwtService *auth.JWTService,
+	sender notification.Sender,
+	limiter *resilience.KeyedLimiter,
+	options EmailVerificationOptions,
+) *AuthEmailVerificationCommandHandler {
+	return &AuthEmailVerificationCommandHandler{
+		userRepo:       userRepo,
+		eventStore:     eventStore,
+		users:          users,
+		eventPublisher: eventPublisher,
+		jwtService:     jwtService,
+		sender:         sender,
+		limiter:        limiter,
+		options:        options,
+	}
+}
+
+// SetTransactions makes the verification change the write database and append its event in a
+// single transaction
+func (h *AuthEmailVerificationCommandHandler) SetTransactions(transactions repositories.TransactionManager) {
+	h.transactions = transactions
+}
+
+// Send sends a verification link for email to the user, e.g. once registered
+func (h *AuthEmailVerificationCommandHandler) Send(ctx context.Context, userID, email, name string) error {
+	token, claims, err := h.jwtService.GenerateEmailVerificationToken(userID, email, h.options.TTL)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer, "failed to generate email verification link")
+	}
+
+	message := notification.Message{
+		Recipient: email,
+		Template:  EmailVerificationTemplate,
+		Data: map[string]string{
+			"name":       name,
+			"link":       strings.ReplaceAll(h.options.URL, "{token}", url.QueryEscape(token)),
+			"expires_at": claims.ExpiresAt.Time.UTC().Format(time.RFC3339),
+		},
+	}
+	if err := h.sender.Send(ctx, message); err != nil {
+		return errors.Wrap(err, errors.ErrServiceUnavailable, "failed to send email verification link")
+	}
+	return nil
+}
+
+// HandleRequest sends a verification link again to the user with the email of the command. The
+// response is the same when no user has this email or the user already verified it.
+func (h *AuthEmailVerificationCommandHandler) HandleRequest(ctx context.Context, cmd dto.RequestEmailVerificationCommand) (*dto.RequestEmailVerificationResponse, error) {
+	if h.limiter != nil && !h.limiter.Allow(strings.ToLower(strings.TrimSpace(cmd.Email))) {
+		return nil, errors.New(errors.ErrRateLimited, "too many verification links requested, try again later")
+	}
+
+	user, err := h.userRepo.GetByEmail(ctx, cmd.Email)
+	if err != nil || user == nil || user.IsEmailVerified() {
+		return &dto.RequestEmailVerificationResponse{Message: emailVerificationSentMessage}, nil
+	}
+
+	if err := h.Send(ctx, user.ID.Value(), user.Email.Value(), user.Name.Value()); err != nil {
+		return nil, err
+	}
+	return &dto.RequestEmailVerificationResponse{Message: emailVerificationSentMessage}, nil
+}
+
+// HandleVerify records the email of a verification link verified, appending a
+// "user.email_verified" event to the user's stream. Verifying an email again records nothing.
+func (h *AuthEmailVerificationCommandHandler) HandleVerify(ctx context.Context, cmd dto.VerifyEmailCommand) (*dto.VerifyEmailResponse, error) {
+	claims, err := h.jwtService.ValidateEmailVerificationToken(cmd.Token)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrUnauthorized, "invalid email verification link")
+	}
+
+	// The user may have been deleted since the link was sent
+	user, err := h.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || user == nil {
+		return nil, errors.New(errors.ErrUnauthorized, "invalid email verification link")
+	}
+	if err := auth.CheckEmailVerificationToken(claims, user.Email.Value()); err != nil {
+		return nil, errors.Wrap(err, errors.ErrUnauthorized, "email verification link is for another email")
+	}
+
+	response := &dto.VerifyEmailResponse{UserID: user.ID.Value(), Email: user.Email.Value(), Message: "Email verified successfully"}
+	if user.IsEmailVerified() {
+		return response, nil
+	}
+
+	// Load the user's stream: the event is appended at the version it is at now
+	stream, err := h.users.Load(ctx, user.ID.Value())
+	if err != nil {
+		return nil, err
+	}
+	user.VerifyEmail()
+	event, err := events.NewUserEmailVerifiedEvent(user.ID.Value(), user.Email.Value(), *user.EmailVerifiedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrEventStoreFailed, "failed to create event")
+	}
+	stream.RecordEvent(event)
+
+	err = inTransaction(ctx, h.transactions, VerifyEmailCommandName, func(ctx context.Context) error {
+		if err := h.userRepo.Update(ctx, user); err != nil {
+			return errors.Wrap(err, errors.ErrInternalServer, "failed to save email verification")
+		}
+		if err := h.users.Save(ctx, stream); err != nil {
+			return err
+		}
+		if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
+			return errors.Wrap(err, errors.ErrEventPublishFailed, "failed to publish event")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthEmailVerificationCommandHandler_SendAndVerify(t *testing.T) {
+	ctx := context.Background()
+	user, err := entities.NewUser("alice@example.com", "Alice")
+	require.NoError(t, err)
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByID(mock.Anything, user.ID.Value()).Return(user, nil)
+	userRepo.EXPECT().Update(mock.Anything, user).Return(nil).Once()
+
+	eventStore := newMemoryEventStore()
+	eventStore.create(t, user)
+	eventPublisher := mocks.NewMockEventPublisher(t)
+	eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.MatchedBy(func(event *events.Event) bool {
+		return event.Type == "user.email_verified" && event.Version == 2
+	})).Return(nil).Once()
+
+	var sent []notification.Message
+	sender := notification.SenderFunc(func(ctx context.Context, message notification.Message) error {
+		sent = append(sent, message)
+		return nil
+	})
+	handler := NewAuthEmailVerificationCommandHandler(userRepo, eventStore, repositories.NewEventSourcedUserRepository(eventStore), eventPublisher, newTestJWTService(t), sender, nil, EmailVerificationOptions{
+		URL: "https://app.example.com/verify?token={token}",
+		TTL: time.Hour,
+	})
+
+	require.NoError(t, handler.Send(ctx, user.ID.Value(), user.Email.Value(), user.Name.Value()))
+	require.Len(t, sent, 1)
+	assert.Equal(t, EmailVerificationTemplate, sent[0].Template)
+	assert.Equal(t, "alice@example.com", sent[0].Recipient)
+	token := tokenOf(t, sent[0])
+
+	resp, err := handler.HandleVerify(ctx, dto.VerifyEmailCommand{Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.Value(), resp.UserID)
+	assert.True(t, user.IsEmailVerified())
+
+	assert.Equal(t, []string{"user.created", "user.email_verified"}, eventStore.types(user.ID.Value()), "the verification is appended after the events of the user")
+
+	_, err = handler.HandleVerify(ctx, dto.VerifyEmailCommand{Token: token})
+	require.NoError(t, err, "verifying again records nothing")
+
+	require.NoError(t, user.UpdateEmail("alice@example.org"))
+	_, err = handler.HandleVerify(ctx, dto.VerifyEmailCommand{Token: token})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""), "links only verify the email they were sent to")
+}
+
+func TestAuthEmailVerificationCommandHandler_Request(t *testing.T) {
+	ctx := context.Background()
+	verified, err := entities.NewUser("bob@example.com", "Bob")
+	require.NoError(t, err)
+	verified.VerifyEmail()
+	unverified, err := entities.NewUser("carol@example.com", "Carol")
+	require.NoError(t, err)
+
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "nobody@example.com").Return(nil, errors.New(errors.ErrUserNotFound, "user not found"))
+	userRepo.EXPECT().GetByEmail(mock.Anything, "bob@example.com").Return(verified, nil)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "carol@example.com").Return(unverified, nil)
+
+	var recipients []string
+	sender := notification.SenderFunc(func(ctx context.Context, message notification.Message) error {
+		recipients = append(recipients, message.Recipient)
+		return nil
+	})
+	handler := NewAuthEmailVerificationCommandHandler(userRepo, nil, nil, nil, newTestJWTService(t), sender, nil, EmailVerificationOptions{URL: "{token}", TTL: time.Minute})
+
+	for _, email := range []string{"nobody@example.com", "bob@example.com", "carol@example.com"} {
+		resp, err := handler.HandleRequest(ctx, dto.RequestEmailVerificationCommand{Email: email})
+		require.NoError(t, err)
+		assert.Equal(t, emailVerificationSentMessage, resp.Message, "responses do not tell which emails wait for verification")
+	}
+	assert.Equal(t, []string{"carol@example.com"}, recipients)
+}
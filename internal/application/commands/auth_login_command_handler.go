@@ -2,18 +2,32 @@ package commands
 
 import (
 	"context"
+	"time"
 
 	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
 	"go-clean-ddd-es-template/internal/domain/repositories"
 	"go-clean-ddd-es-template/pkg/auth"
 	"go-clean-ddd-es-template/pkg/errors"
 )
 
+// LoginLockoutPolicy configures the lockout of accounts and client addresses failing to log in
+type LoginLockoutPolicy struct {
+	MaxFailures   int           // Failed logins within Window locking an account out
+	IPMaxFailures int           // Failed logins within Window throttling a client address, 0 for no limit
+	Window        time.Duration // Period failed logins are counted over, from the first one
+	Duration      time.Duration // How long accounts and client addresses are locked out
+}
+
 // AuthLoginCommandHandler handles user login
 type AuthLoginCommandHandler struct {
 	userRepo        repositories.UserRepository
 	passwordService *auth.PasswordService
 	jwtService      *auth.JWTService
+	eventPublisher  repositories.EventPublisher
+	attempts        repositories.LoginAttemptRepository
+	lockout         LoginLockoutPolicy
 }
 
 // NewAuthLoginCommandHandler creates a new auth login command handler
@@ -29,16 +43,44 @@ func NewAuthLoginCommandHandler(
 	}
 }
 
+// SetEventPublisher publishes a "user.login" event after every successful login, e.g. for the
+// last login of user summaries, and with a lockout an "auth.login_failed" event for every wrong
+// password and an "auth.account_locked" event for every account locked out
+func (h *AuthLoginCommandHandler) SetEventPublisher(eventPublisher repositories.EventPublisher) {
+	h.eventPublisher = eventPublisher
+}
+
+// SetLockout protects logins against brute force: failed logins are counted in attempts, per
+// account and per client address, and those failing too often are locked out for a while, even
+// with the right password
+func (h *AuthLoginCommandHandler) SetLockout(attempts repositories.LoginAttemptRepository, policy LoginLockoutPolicy) {
+	h.attempts = attempts
+	h.lockout = policy
+}
+
 // Handle handles the login command
 func (h *AuthLoginCommandHandler) Handle(ctx context.Context, cmd dto.LoginCommand) (*dto.LoginResponse, error) {
+	if err := h.checkLocked(ctx, clientLoginKey(cmd.ClientIP), errors.LoginThrottled); err != nil {
+		return nil, err
+	}
+
 	// Get user by email
 	user, err := h.userRepo.GetByEmail(ctx, cmd.Email)
 	if err != nil {
+		if err := h.recordClientFailure(ctx, cmd.ClientIP); err != nil {
+			return nil, err
+		}
 		return nil, errors.Wrap(err, errors.ErrUserNotFound, "user not found")
 	}
+	if err := h.checkLocked(ctx, userLoginKey(user.ID.Value()), errors.AccountLocked); err != nil {
+		return nil, err
+	}
 
 	// Check password
 	if !h.passwordService.CheckPassword(cmd.Password, user.GetPasswordHash()) {
+		if err := h.recordFailure(ctx, user, cmd.ClientIP); err != nil {
+			return nil, err
+		}
 		return nil, errors.New(errors.ErrUnauthorized, "invalid credentials")
 	}
 
@@ -48,11 +90,113 @@ func (h *AuthLoginCommandHandler) Handle(ctx context.Context, cmd dto.LoginComma
 		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate token")
 	}
 
-	return &dto.LoginResponse{
-		UserID: user.ID.Value(),
-		Email:  user.Email.Value(),
-		Name:   user.Name.Value(),
-		Roles:  []string{"user"}, // Default role
-		Token:  token,
-	}, nil
+	// The failures of the client address are kept: an attacker holding one account would
+	// otherwise reset them between guesses
+	if h.attempts != nil {
+		if err := h.attempts.Reset(ctx, userLoginKey(user.ID.Value())); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabaseQuery, "failed to reset failed logins")
+		}
+	}
+
+	if h.eventPublisher != nil {
+		h.publish(ctx, func() (*events.Event, error) {
+			return events.NewUserLoggedInEvent(user.ID.Value())
+		})
+	}
+
+	return loginResponse(user, token), nil
+}
+
+// checkLocked fails with the error of locked when a key is locked out
+func (h *AuthLoginCommandHandler) checkLocked(ctx context.Context, key string, locked func(until time.Time) *errors.AppError) error {
+	if h.attempts == nil || key == "" {
+		return nil
+	}
+	until, err := h.attempts.LockedUntil(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabaseQuery, "failed to check login lockout")
+	}
+	if !until.IsZero() {
+		return locked(until)
+	}
+	return nil
+}
+
+// recordFailure records a wrong password for a user, locking the user out once failing too
+// often. The failure is also recorded for the client address.
+func (h *AuthLoginCommandHandler) recordFailure(ctx context.Context, user *entities.User, clientIP string) error {
+	if h.attempts == nil {
+		return nil
+	}
+	if err := h.recordClientFailure(ctx, clientIP); err != nil {
+		return err
+	}
+
+	userID := user.ID.Value()
+	failures, err := h.attempts.RecordFailure(ctx, userLoginKey(userID), h.lockout.Window)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabaseQuery, "failed to record failed login")
+	}
+	if h.eventPublisher != nil {
+		h.publish(ctx, func() (*events.Event, error) {
+			return events.NewLoginFailedEvent(userID, clientIP, failures)
+		})
+	}
+	if failures < h.lockout.MaxFailures {
+		return nil
+	}
+
+	until := time.Now().Add(h.lockout.Duration)
+	if err := h.attempts.Lock(ctx, userLoginKey(userID), until); err != nil {
+		return errors.Wrap(err, errors.ErrDatabaseQuery, "failed to lock account")
+	}
+	if h.eventPublisher != nil {
+		h.publish(ctx, func() (*events.Event, error) {
+			return events.NewAccountLockedEvent(userID, failures, until)
+		})
+	}
+	return errors.AccountLocked(until)
+}
+
+// recordClientFailure records a failed login of a client address, throttling it once failing
+// too often. Addresses are not tracked when unknown or without a limit.
+func (h *AuthLoginCommandHandler) recordClientFailure(ctx context.Context, clientIP string) error {
+	if h.attempts == nil || clientIP == "" || h.lockout.IPMaxFailures <= 0 {
+		return nil
+	}
+	key := clientLoginKey(clientIP)
+	failures, err := h.attempts.RecordFailure(ctx, key, h.lockout.Window)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabaseQuery, "failed to record failed login")
+	}
+	if failures < h.lockout.IPMaxFailures {
+		return nil
+	}
+	if err := h.attempts.Lock(ctx, key, time.Now().Add(h.lockout.Duration)); err != nil {
+		return errors.Wrap(err, errors.ErrDatabaseQuery, "failed to throttle client")
+	}
+	return nil
+}
+
+// publish publishes an event of a login. Logins go on even when it cannot be published, the
+// events are informational.
+func (h *AuthLoginCommandHandler) publish(ctx context.Context, newEvent func() (*events.Event, error)) {
+	event, err := newEvent()
+	if err != nil {
+		return
+	}
+	_ = h.eventPublisher.PublishEvent(ctx, event)
+}
+
+// userLoginKey returns the key of the failed logins of a user
+func userLoginKey(userID string) string {
+	return "user:" + userID
+}
+
+// clientLoginKey returns the key of the failed logins of a client address, empty when unknown
+func clientLoginKey(clientIP string) string {
+	if clientIP == "" {
+		return ""
+	}
+	return "ip:" + clientIP
 }
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLoginAttempts counts failed logins in memory, within a window that never elapses
+type memoryLoginAttempts struct {
+	failures map[string]int
+	locked   map[string]time.Time
+}
+
+func newMemoryLoginAttempts() *memoryLoginAttempts {
+	return &memoryLoginAttempts{failures: map[string]int{}, locked: map[string]time.Time{}}
+}
+
+func (m *memoryLoginAttempts) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	m.failures[key]++
+	return m.failures[key], nil
+}
+
+func (m *memoryLoginAttempts) Lock(ctx context.Context, key string, until time.Time) error {
+	m.locked[key] = until
+	m.failures[key] = 0
+	return nil
+}
+
+func (m *memoryLoginAttempts) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	return m.locked[key], nil
+}
+
+func (m *memoryLoginAttempts) Reset(ctx context.Context, key string) error {
+	m.failures[key] = 0
+	return nil
+}
+
+// newLoginTestUser creates a user with a password
+func newLoginTestUser(t *testing.T, passwordService *auth.PasswordService, password string) *entities.User {
+	user, err := entities.NewUser("alice@example.com", "Alice")
+	require.NoError(t, err)
+	hash, err := passwordService.HashPassword(password)
+	require.NoError(t, err)
+	user.SetPasswordHash(hash)
+	return user
+}
+
+func TestAuthLoginCommandHandler_LocksAccountOut(t *testing.T) {
+	ctx := context.Background()
+	passwordService := auth.NewPasswordService(4)
+	user := newLoginTestUser(t, passwordService, "correct-password")
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "alice@example.com").Return(user, nil)
+
+	var published []string
+	eventPublisher := mocks.NewMockEventPublisher(t)
+	eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, event *events.Event) error {
+		published = append(published, event.Type)
+		return nil
+	})
+
+	attempts := newMemoryLoginAttempts()
+	handler := NewAuthLoginCommandHandler(userRepo, passwordService, newTestJWTService(t))
+	handler.SetEventPublisher(eventPublisher)
+	handler.SetLockout(attempts, LoginLockoutPolicy{MaxFailures: 3, Window: time.Minute, Duration: 15 * time.Minute})
+
+	wrong := dto.LoginCommand{Email: "alice@example.com", Password: "wrong-password"}
+	for i := 0; i < 2; i++ {
+		_, err := handler.Handle(ctx, wrong)
+		assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""))
+	}
+	_, err := handler.Handle(ctx, wrong)
+	assert.Equal(t, errors.ErrAccountLocked, errors.CodeOf(err, ""), "the failure reaching the limit locks the account out")
+
+	_, err = handler.Handle(ctx, dto.LoginCommand{Email: "alice@example.com", Password: "correct-password"})
+	assert.Equal(t, errors.ErrAccountLocked, errors.CodeOf(err, ""), "locked out accounts cannot log in, even with the right password")
+	assert.Equal(t, []string{"auth.login_failed", "auth.login_failed", "auth.login_failed", "auth.account_locked"}, published)
+
+	delete(attempts.locked, userLoginKey(user.ID.Value()))
+	resp, err := handler.Handle(ctx, dto.LoginCommand{Email: "alice@example.com", Password: "correct-password"})
+	require.NoError(t, err, "accounts log in again once the lockout ended")
+	assert.NotEmpty(t, resp.Token)
+}
+
+func TestAuthLoginCommandHandler_ResetsFailuresOnLogin(t *testing.T) {
+	ctx := context.Background()
+	passwordService := auth.NewPasswordService(4)
+	user := newLoginTestUser(t, passwordService, "correct-password")
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "alice@example.com").Return(user, nil)
+
+	handler := NewAuthLoginCommandHandler(userRepo, passwordService, newTestJWTService(t))
+	handler.SetLockout(newMemoryLoginAttempts(), LoginLockoutPolicy{MaxFailures: 2, Window: time.Minute, Duration: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		_, err := handler.Handle(ctx, dto.LoginCommand{Email: "alice@example.com", Password: "wrong-password"})
+		assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""))
+		_, err = handler.Handle(ctx, dto.LoginCommand{Email: "alice@example.com", Password: "correct-password"})
+		assert.NoError(t, err, "failures are counted since the last login")
+	}
+}
+
+func TestAuthLoginCommandHandler_ThrottlesClient(t *testing.T) {
+	ctx := context.Background()
+	passwordService := auth.NewPasswordService(4)
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, mock.Anything).Return(nil, errors.New(errors.ErrUserNotFound, "user not found"))
+
+	handler := NewAuthLoginCommandHandler(userRepo, passwordService, newTestJWTService(t))
+	handler.SetLockout(newMemoryLoginAttempts(), LoginLockoutPolicy{MaxFailures: 5, IPMaxFailures: 2, Window: time.Minute, Duration: time.Minute})
+
+	for _, email := range []string{"bob@example.com", "carol@example.com"} {
+		_, err := handler.Handle(ctx, dto.LoginCommand{Email: email, Password: "guess", ClientIP: "203.0.113.7"})
+		assert.Equal(t, errors.ErrUserNotFound, errors.CodeOf(err, ""))
+	}
+	_, err := handler.Handle(ctx, dto.LoginCommand{Email: "dave@example.com", Password: "guess", ClientIP: "203.0.113.7"})
+	assert.Equal(t, errors.ErrLoginThrottled, errors.CodeOf(err, ""), "clients are throttled whatever the accounts tried")
+
+	_, err = handler.Handle(ctx, dto.LoginCommand{Email: "dave@example.com", Password: "guess", ClientIP: "198.51.100.1"})
+	assert.Equal(t, errors.ErrUserNotFound, errors.CodeOf(err, ""), "other clients are not throttled")
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/notification"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// MagicLinkTemplate is the notification template of magic links
+const MagicLinkTemplate = "magic_link"
+
+// magicLinkSentMessage is the response of every magic link request, so it does not tell whether
+// the email belongs to a user
+const magicLinkSentMessage = "If an account exists for this email, a sign-in link was sent to it"
+
+// MagicLinkOptions configures the magic links sent to users
+type MagicLinkOptions struct {
+	URL        string        // Sign-in page of the link, with a {token} placeholder
+	TTL        time.Duration // How long links can be consumed
+	BindDevice bool          // Whether links are only consumed from the device requesting them
+}
+
+// AuthMagicLinkCommandHandler handles password-less sign in: it sends users a single-use signed
+// link, then exchanges the link for an access token
+type AuthMagicLinkCommandHandler struct {
+	userRepo       repositories.UserRepository
+	magicLinks     repositories.MagicLinkRepository
+	jwtService     *auth.JWTService
+	sender         notification.Sender
+	limiter        *resilience.KeyedLimiter
+	options        MagicLinkOptions
+	eventPublisher repositories.EventPublisher
+}
+
+// NewAuthMagicLinkCommandHandler creates a new auth magic link command handler. Requests are
+// limited per email by limiter; a nil limiter does not limit them.
+func NewAuthMagicLinkCommandHandler(
+	userRepo repositories.UserRepository,
+	magicLinks repositories.MagicLinkRepository,
+	jwtService *auth.JWTService,
+	sender notification.Sender,
+	limiter *resilience.KeyedLimiter,
+	options MagicLinkOptions,
+) *AuthMagicLinkCommandHandler {
+	return &AuthMagicLinkCommandHandler{
+		userRepo:   userRepo,
+		magicLinks: magicLinks,
+		jwtService: jwtService,
+		sender:     sender,
+		limiter:    limiter,
+		options:    options,
+	}
+}
+
+// SetEventPublisher publishes an "auth.magic_link_requested" event for every link sent and an
+// "auth.magic_link_consumed" event for every link consumed
+func (h *AuthMagicLinkCommandHandler) SetEventPublisher(eventPublisher repositories.EventPublisher) {
+	h.eventPublisher = eventPublisher
+}
+
+// HandleRequest sends a magic link to the user with the email of the command. The response is the
+// same when no user has this email.
+func (h *AuthMagicLinkCommandHandler) HandleRequest(ctx context.Context, cmd dto.RequestMagicLinkCommand) (*dto.RequestMagicLinkResponse, error) {
+	if h.options.BindDevice && cmd.DeviceID == "" {
+		return nil, errors.New(errors.ErrValidationFailed, "device_id is required to request a magic link")
+	}
+	if h.limiter != nil && !h.limiter.Allow(strings.ToLower(strings.TrimSpace(cmd.Email))) {
+		return nil, errors.New(errors.ErrRateLimited, "too many magic links requested, try again later")
+	}
+
+	user, err := h.userRepo.GetByEmail(ctx, cmd.Email)
+	if err != nil || user == nil {
+		return &dto.RequestMagicLinkResponse{Message: magicLinkSentMessage}, nil
+	}
+
+	device := ""
+	if h.options.BindDevice {
+		device = cmd.DeviceID
+	}
+	token, claims, err := h.jwtService.GenerateMagicLinkToken(user.ID.Value(), user.Email.Value(), device, h.options.TTL)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate magic link")
+	}
+
+	message := notification.Message{
+		Recipient: user.Email.Value(),
+		Template:  MagicLinkTemplate,
+		Data: map[string]string{
+			"name":       user.Name.Value(),
+			"link":       strings.ReplaceAll(h.options.URL, "{token}", url.QueryEscape(token)),
+			"expires_at": claims.ExpiresAt.Time.UTC().Format(time.RFC3339),
+		},
+	}
+	if err := h.sender.Send(ctx, message); err != nil {
+		return nil, errors.Wrap(err, errors.ErrServiceUnavailable, "failed to send magic link")
+	}
+
+	if h.eventPublisher != nil {
+		h.publish(ctx, func() (*events.Event, error) {
+			return events.NewMagicLinkRequestedEvent(user.ID.Value(), claims.ID, device != "", claims.ExpiresAt.Time)
+		})
+	}
+	return &dto.RequestMagicLinkResponse{Message: magicLinkSentMessage}, nil
+}
+
+// HandleConsume signs in the user of a magic link, which can only be consumed once
+func (h *AuthMagicLinkCommandHandler) HandleConsume(ctx context.Context, cmd dto.ConsumeMagicLinkCommand) (*dto.LoginResponse, error) {
+	claims, err := h.jwtService.ValidateMagicLinkToken(cmd.Token, cmd.DeviceID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrUnauthorized, "invalid magic link")
+	}
+
+	consumed, err := h.magicLinks.Consume(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseQuery, "failed to consume magic link")
+	}
+	if !consumed {
+		return nil, errors.New(errors.ErrUnauthorized, "magic link was already used")
+	}
+
+	// The user may have been deleted since the link was sent
+	user, err := h.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || user == nil {
+		return nil, errors.New(errors.ErrUnauthorized, "invalid magic link")
+	}
+
+	token, err := h.jwtService.GenerateToken(user.ID.Value(), user.Email.Value(), []string{"user"})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate token")
+	}
+
+	if h.eventPublisher != nil {
+		h.publish(ctx, func() (*events.Event, error) {
+			return events.NewMagicLinkConsumedEvent(user.ID.Value(), claims.ID)
+		})
+	}
+	return loginResponse(user, token), nil
+}
+
+// publish publishes an auth event. Magic links work even when it cannot be published, the events
+// are informational.
+func (h *AuthMagicLinkCommandHandler) publish(ctx context.Context, newEvent func() (*events.Event, error)) {
+	event, err := newEvent()
+	if err != nil {
+		return
+	}
+	_ = h.eventPublisher.PublishEvent(ctx, event)
+}
+
+// loginResponse returns the response of a user signing in with token
+func loginResponse(user *entities.User, token string) *dto.LoginResponse {
+	return &dto.LoginResponse{
+		UserID: user.ID.Value(),
+		Email:  user.Email.Value(),
+		Name:   user.Name.Value(),
+		Roles:  []string{"user"}, // Default role
+		Token:  token,
+	}
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/notification"
+	"go-clean-ddd-es-template/pkg/resilience"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryMagicLinks records consumed magic links in memory
+type memoryMagicLinks map[string]bool
+
+func (m memoryMagicLinks) Consume(ctx context.Context, linkID, userID string, expiresAt time.Time) (bool, error) {
+	if m[linkID] {
+		return false, nil
+	}
+	m[linkID] = true
+	return true, nil
+}
+
+// newTestJWTService creates a JWT service with a key pair generated for the test
+func newTestJWTService(t *testing.T) *auth.JWTService {
+	privateKey, publicKey, err := auth.GenerateRSAKeyPair(2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privatePath, []byte(auth.ExportPrivateKeyPEM(privateKey)), 0o600))
+	require.NoError(t, os.WriteFile(publicPath, []byte(auth.ExportPublicKeyPEM(publicKey)), 0o644))
+
+	jwtService, err := auth.NewJWTService(privatePath, publicPath, time.Hour)
+	require.NoError(t, err)
+	return jwtService
+}
+
+// tokenOf returns the token of the link of a magic link notification
+func tokenOf(t *testing.T, message notification.Message) string {
+	link, err := url.Parse(message.Data["link"])
+	require.NoError(t, err)
+	return link.Query().Get("token")
+}
+
+func TestAuthMagicLinkCommandHandler_RequestAndConsume(t *testing.T) {
+	ctx := context.Background()
+	user, err := entities.NewUser("alice@example.com", "Alice")
+	require.NoError(t, err)
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "alice@example.com").Return(user, nil)
+	userRepo.EXPECT().GetByID(mock.Anything, user.ID.Value()).Return(user, nil)
+
+	var sent []notification.Message
+	sender := notification.SenderFunc(func(ctx context.Context, message notification.Message) error {
+		sent = append(sent, message)
+		return nil
+	})
+	jwtService := newTestJWTService(t)
+	handler := NewAuthMagicLinkCommandHandler(userRepo, memoryMagicLinks{}, jwtService, sender, nil, MagicLinkOptions{
+		URL: "https://app.example.com/signin?token={token}",
+		TTL: 15 * time.Minute,
+	})
+
+	resp, err := handler.HandleRequest(ctx, dto.RequestMagicLinkCommand{Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Message)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "alice@example.com", sent[0].Recipient)
+	assert.Equal(t, MagicLinkTemplate, sent[0].Template)
+	assert.True(t, strings.HasPrefix(sent[0].Data["link"], "https://app.example.com/signin?token="))
+
+	login, err := handler.HandleConsume(ctx, dto.ConsumeMagicLinkCommand{Token: tokenOf(t, sent[0])})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.Value(), login.UserID)
+	claims, err := jwtService.ValidateToken(login.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.Value(), claims.UserID)
+
+	_, err = handler.HandleConsume(ctx, dto.ConsumeMagicLinkCommand{Token: tokenOf(t, sent[0])})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""), "links are single use")
+}
+
+func TestAuthMagicLinkCommandHandler_RequestUnknownEmail(t *testing.T) {
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "nobody@example.com").Return(nil, assert.AnError)
+	sender := notification.SenderFunc(func(ctx context.Context, message notification.Message) error {
+		t.Fatal("no link is sent to unknown emails")
+		return nil
+	})
+	handler := NewAuthMagicLinkCommandHandler(userRepo, memoryMagicLinks{}, nil, sender, nil, MagicLinkOptions{TTL: time.Minute})
+
+	resp, err := handler.HandleRequest(context.Background(), dto.RequestMagicLinkCommand{Email: "nobody@example.com"})
+	require.NoError(t, err, "unknown emails are not revealed")
+	assert.Equal(t, magicLinkSentMessage, resp.Message)
+}
+
+func TestAuthMagicLinkCommandHandler_RequestRateLimited(t *testing.T) {
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+	limiter := resilience.NewKeyedLimiter(1.0/3600, 2, nil)
+	handler := NewAuthMagicLinkCommandHandler(userRepo, memoryMagicLinks{}, nil, nil, limiter, MagicLinkOptions{TTL: time.Minute})
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := handler.HandleRequest(ctx, dto.RequestMagicLinkCommand{Email: "alice@example.com"})
+		require.NoError(t, err)
+	}
+	_, err := handler.HandleRequest(ctx, dto.RequestMagicLinkCommand{Email: "Alice@Example.com"})
+	assert.Equal(t, errors.ErrRateLimited, errors.CodeOf(err, ""), "requests are limited per email, regardless of case")
+}
+
+func TestAuthMagicLinkCommandHandler_DeviceBinding(t *testing.T) {
+	ctx := context.Background()
+	user, err := entities.NewUser("alice@example.com", "Alice")
+	require.NoError(t, err)
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "alice@example.com").Return(user, nil)
+	userRepo.EXPECT().GetByID(mock.Anything, user.ID.Value()).Return(user, nil)
+
+	var sent []notification.Message
+	sender := notification.SenderFunc(func(ctx context.Context, message notification.Message) error {
+		sent = append(sent, message)
+		return nil
+	})
+	handler := NewAuthMagicLinkCommandHandler(userRepo, memoryMagicLinks{}, newTestJWTService(t), sender, nil, MagicLinkOptions{
+		URL:        "https://app.example.com/signin?token={token}",
+		TTL:        15 * time.Minute,
+		BindDevice: true,
+	})
+
+	_, err = handler.HandleRequest(ctx, dto.RequestMagicLinkCommand{Email: "alice@example.com"})
+	assert.Equal(t, errors.ErrValidationFailed, errors.CodeOf(err, ""), "bound links require a device")
+
+	_, err = handler.HandleRequest(ctx, dto.RequestMagicLinkCommand{Email: "alice@example.com", DeviceID: "laptop"})
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+
+	_, err = handler.HandleConsume(ctx, dto.ConsumeMagicLinkCommand{Token: tokenOf(t, sent[0]), DeviceID: "phone"})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""))
+	_, err = handler.HandleConsume(ctx, dto.ConsumeMagicLinkCommand{Token: tokenOf(t, sent[0]), DeviceID: "laptop"})
+	assert.NoError(t, err, "links rejected from another device can still be consumed from the device requesting them")
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/notification"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// PasswordResetTemplate is the notification template of password reset links
+const PasswordResetTemplate = "password_reset"
+
+// passwordResetSentMessage is the response of every forgotten password, so it does not tell
+// whether the email belongs to a user
+const passwordResetSentMessage = "If an account exists for this email, a password reset link was sent to it"
+
+// PasswordResetOptions configures the password reset links sent to users
+type PasswordResetOptions struct {
+	URL string        // Reset page of the link, with a {token} placeholder
+	TTL time.Duration // How long links can be used
+}
+
+// AuthPasswordResetCommandHandler handles forgotten passwords: it sends users a signed link, then
+// sets the new password given with the link. Links are single use: they reset the password they
+// were sent for, so none works anymore once the password changed. Resetting a password revokes
+// the refresh tokens of the user, so a stolen session does not outlive it.
+type AuthPasswordResetCommandHandler struct {
+	userRepo        repositories.UserRepository
+	eventStore      repositories.EventStore
+	users           *repositories.EventSourcedUserRepository
+	refreshTokens   repositories.RefreshTokenRepository
+	eventPublisher  repositories.EventPublisher
+	passwordService *auth.PasswordService
+	jwtService      *auth.JWTService
+	sender          notification.Sender
+	limiter         *resilience.KeyedLimiter
+	options         PasswordResetOptions
+	transactions    repositories.TransactionManager
+}
+
+// NewAuthPasswordResetCommandHandler creates a new auth password reset command handler. Requests
+// are limited per email by limiter; a nil limiter does not limit them. Reset events are appended
+// to the user's stream loaded from users.
+func NewAuthPasswordResetCommandHandler(
+	userRepo repositories.UserRepository,
+	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
+	eventPublisher repositories.EventPublisher,
+	passwordService *auth.PasswordService,
+	jwtService *auth.JWTService,
+	sender notification.Sender,
+	limiter *resilience.KeyedLimiter,
+	options PasswordResetOptions,
+) *AuthPasswordResetCommandHandler {
+	return &AuthPasswordResetCommandHandler{
+		userRepo:        userRepo,
+		eventStore:      eventStore,
+		users:           users,
+		eventPublisher:  eventPublisher,
+		passwordService: passwordService,
+		jwtService:      jwtService,
+		sender:          sender,
+		limiter:         limiter,
+		options:         options,
+	}
+}
+
+// SetTransactions makes the reset change the write database and append its event in a single
+// transaction
+func (h *AuthPasswordResetCommandHandler) SetTransactions(transactions repositories.TransactionManager) {
+	h.transactions = transactions
+}
+
+// SetRefreshTokens makes resets revoke the refresh tokens of the user, in the transaction of the
+// reset
+func (h *AuthPasswordResetCommandHandler) SetRefreshTokens(refreshTokens repositories.RefreshTokenRepository) {
+	h.refreshTokens = refreshTokens
+}
+
+// HandleForgot sends a password reset link to the user with the email of the command, publishing
+// a "user.password_reset_requested" event. The response is the same when no user has this email.
+func (h *AuthPasswordResetCommandHandler) HandleForgot(ctx context.Context, cmd dto.ForgotPasswordCommand) (*dto.ForgotPasswordResponse, error) {
+	if h.limiter != nil && !h.limiter.Allow(strings.ToLower(strings.TrimSpace(cmd.Email))) {
+		return nil, errors.New(errors.ErrRateLimited, "too many password resets requested, try again later")
+	}
+
+	user, err := h.userRepo.GetByEmail(ctx, cmd.Email)
+	if err != nil || user == nil {
+		return &dto.ForgotPasswordResponse{Message: passwordResetSentMessage}, nil
+	}
+
+	token, claims, err := h.jwtService.GeneratePasswordResetToken(user.ID.Value(), user.Email.Value(), user.GetPasswordHash(), h.options.TTL)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate password reset link")
+	}
+
+	message := notification.Message{
+		Recipient: user.Email.Value(),
+		Template:  PasswordResetTemplate,
+		Data: map[string]string{
+			"name":       user.Name.Value(),
+			"link":       strings.ReplaceAll(h.options.URL, "{token}", url.QueryEscape(token)),
+			"expires_at": claims.ExpiresAt.Time.UTC().Format(time.RFC3339),
+		},
+	}
+	if err := h.sender.Send(ctx, message); err != nil {
+		return nil, errors.Wrap(err, errors.ErrServiceUnavailable, "failed to send password reset link")
+	}
+
+	// The link was sent, the event is informational
+	if event, err := events.NewPasswordResetRequestedEvent(user.ID.Value(), claims.ID, claims.ExpiresAt.Time); err == nil {
+		_ = h.eventPublisher.PublishEvent(ctx, event)
+	}
+	return &dto.ForgotPasswordResponse{Message: passwordResetSentMessage}, nil
+}
+
+// HandleReset sets the new password of the user of a password reset link, appending a
+// "user.password_reset" event to the user's stream and revoking the user's refresh tokens
+func (h *AuthPasswordResetCommandHandler) HandleReset(ctx context.Context, cmd dto.ResetPasswordCommand) (*dto.ResetPasswordResponse, error) {
+	claims, err := h.jwtService.ValidatePasswordResetToken(cmd.Token)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrUnauthorized, "invalid password reset link")
+	}
+	if err := h.passwordService.ValidatePassword(cmd.NewPassword); err != nil {
+		return nil, errors.Wrap(err, errors.ErrValidationFailed, "invalid password")
+	}
+
+	// The user may have been deleted since the link was sent
+	user, err := h.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || user == nil {
+		return nil, errors.New(errors.ErrUnauthorized, "invalid password reset link")
+	}
+	if err := auth.CheckPasswordResetToken(claims, user.GetPasswordHash()); err != nil {
+		return nil, errors.Wrap(err, errors.ErrUnauthorized, "password reset link was already used")
+	}
+
+	hash, err := h.passwordService.HashPassword(cmd.NewPassword)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to hash password")
+	}
+	user.SetPasswordHash(hash)
+
+	// Load the user's stream: the event is appended at the version it is at now
+	stream, err := h.users.Load(ctx, user.ID.Value())
+	if err != nil {
+		return nil, err
+	}
+	event, err := events.NewPasswordResetEvent(user.ID.Value(), claims.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrEventStoreFailed, "failed to create event")
+	}
+	stream.RecordEvent(event)
+
+	err = inTransaction(ctx, h.transactions, ResetPasswordCommandName, func(ctx context.Context) error {
+		if err := h.userRepo.Update(ctx, user); err != nil {
+			return errors.Wrap(err, errors.ErrInternalServer, "failed to save password")
+		}
+		if err := h.users.Save(ctx, stream); err != nil {
+			return err
+		}
+		if h.refreshTokens != nil {
+			if err := h.refreshTokens.RevokeUser(ctx, user.ID.Value()); err != nil {
+				return errors.Wrap(err, errors.ErrDatabaseQuery, "failed to revoke refresh tokens")
+			}
+		}
+		if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
+			return errors.Wrap(err, errors.ErrEventPublishFailed, "failed to publish event")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ResetPasswordResponse{Success: true, Message: "Password reset successfully"}, nil
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthPasswordResetCommandHandler_ForgotAndReset(t *testing.T) {
+	ctx := context.Background()
+	passwordService := auth.NewPasswordService(4)
+	user := newLoginTestUser(t, passwordService, "old-password1")
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "alice@example.com").Return(user, nil)
+	userRepo.EXPECT().GetByID(mock.Anything, user.ID.Value()).Return(user, nil)
+	userRepo.EXPECT().Update(mock.Anything, user).Return(nil).Once()
+
+	eventStore := newMemoryEventStore()
+	eventStore.create(t, user)
+	var published []string
+	eventPublisher := mocks.NewMockEventPublisher(t)
+	eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, event *events.Event) error {
+		published = append(published, event.Type)
+		return nil
+	})
+
+	var sent []notification.Message
+	sender := notification.SenderFunc(func(ctx context.Context, message notification.Message) error {
+		sent = append(sent, message)
+		return nil
+	})
+	jwtService := newTestJWTService(t)
+	handler := NewAuthPasswordResetCommandHandler(userRepo, eventStore, repositories.NewEventSourcedUserRepository(eventStore), eventPublisher, passwordService, jwtService, sender, nil, PasswordResetOptions{
+		URL: "https://app.example.com/reset?token={token}",
+		TTL: time.Hour,
+	})
+	refreshTokens := newMemoryRefreshTokens()
+	handler.SetRefreshTokens(refreshTokens)
+	refreshHandler := NewAuthRefreshTokenCommandHandler(userRepo, refreshTokens, jwtService, time.Hour)
+	session, err := refreshHandler.Issue(ctx, user.ID.Value(), user.Email.Value())
+	require.NoError(t, err)
+
+	forgot, err := handler.HandleForgot(ctx, dto.ForgotPasswordCommand{Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, passwordResetSentMessage, forgot.Message)
+	require.Len(t, sent, 1)
+	assert.Equal(t, PasswordResetTemplate, sent[0].Template)
+	token := tokenOf(t, sent[0])
+
+	_, err = handler.HandleReset(ctx, dto.ResetPasswordCommand{Token: token, NewPassword: "short"})
+	assert.Equal(t, errors.ErrValidationFailed, errors.CodeOf(err, ""))
+
+	reset, err := handler.HandleReset(ctx, dto.ResetPasswordCommand{Token: token, NewPassword: "New-password1"})
+	require.NoError(t, err)
+	assert.True(t, reset.Success)
+	assert.True(t, passwordService.CheckPassword("New-password1", user.GetPasswordHash()))
+	assert.Equal(t, []string{"user.password_reset_requested", "user.password_reset"}, published)
+	assert.Equal(t, []string{"user.created", "user.password_reset"}, eventStore.types(user.ID.Value()), "the reset is appended after the events of the user")
+	_, err = refreshHandler.Handle(ctx, dto.RefreshTokenCommand{RefreshToken: session})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""), "resetting the password revokes the sessions of the user")
+
+	_, err = handler.HandleReset(ctx, dto.ResetPasswordCommand{Token: token, NewPassword: "Other-password1"})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""), "links are single use")
+}
+
+func TestAuthPasswordResetCommandHandler_ForgotUnknownEmail(t *testing.T) {
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "nobody@example.com").Return(nil, errors.New(errors.ErrUserNotFound, "user not found"))
+	sender := notification.SenderFunc(func(ctx context.Context, message notification.Message) error {
+		t.Fatal("no link is sent to unknown emails")
+		return nil
+	})
+	handler := NewAuthPasswordResetCommandHandler(userRepo, nil, nil, nil, nil, nil, sender, nil, PasswordResetOptions{TTL: time.Minute})
+
+	resp, err := handler.HandleForgot(context.Background(), dto.ForgotPasswordCommand{Email: "nobody@example.com"})
+	require.NoError(t, err, "unknown emails are not revealed")
+	assert.Equal(t, passwordResetSentMessage, resp.Message)
+}
+
+func TestAuthPasswordResetCommandHandler_RejectsOtherTokens(t *testing.T) {
+	jwtService := newTestJWTService(t)
+	user, err := entities.NewUser("alice@example.com", "Alice")
+	require.NoError(t, err)
+	handler := NewAuthPasswordResetCommandHandler(mocks.NewMockUserRepository(t), nil, nil, nil, auth.NewPasswordService(4), jwtService, nil, nil, PasswordResetOptions{TTL: time.Minute})
+
+	accessToken, err := jwtService.GenerateToken(user.ID.Value(), user.Email.Value(), []string{"user"})
+	require.NoError(t, err)
+	_, err = handler.HandleReset(context.Background(), dto.ResetPasswordCommand{Token: accessToken, NewPassword: "New-password1"})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""), "access tokens do not reset passwords")
+}
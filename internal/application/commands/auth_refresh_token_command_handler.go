@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// AuthRefreshTokenCommandHandler issues a refresh token to users signing in and exchanges it for
+// an access token. Refresh tokens are rotated: each renews once, returning the token replacing
+// it, and a token used twice, e.g. stolen and used by both of its holders, revokes every token
+// rotated from the same sign in.
+type AuthRefreshTokenCommandHandler struct {
+	userRepo      repositories.UserRepository
+	refreshTokens repositories.RefreshTokenRepository
+	jwtService    *auth.JWTService
+	ttl           time.Duration
+}
+
+// NewAuthRefreshTokenCommandHandler creates a new auth refresh token command handler, issuing
+// refresh tokens valid for ttl
+func NewAuthRefreshTokenCommandHandler(
+	userRepo repositories.UserRepository,
+	refreshTokens repositories.RefreshTokenRepository,
+	jwtService *auth.JWTService,
+	ttl time.Duration,
+) *AuthRefreshTokenCommandHandler {
+	return &AuthRefreshTokenCommandHandler{
+		userRepo:      userRepo,
+		refreshTokens: refreshTokens,
+		jwtService:    jwtService,
+		ttl:           ttl,
+	}
+}
+
+// Issue issues the refresh token of a user signing in
+func (h *AuthRefreshTokenCommandHandler) Issue(ctx context.Context, userID, email string) (string, error) {
+	return h.issue(ctx, userID, email, "")
+}
+
+// issue issues a refresh token of a family, a new one when family is empty
+func (h *AuthRefreshTokenCommandHandler) issue(ctx context.Context, userID, email, family string) (string, error) {
+	token, claims, err := h.jwtService.GenerateRefreshToken(userID, email, family, h.ttl)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrInternalServer, "failed to generate refresh token")
+	}
+	if err := h.refreshTokens.Issue(ctx, claims.ID, claims.Family, userID, claims.ExpiresAt.Time); err != nil {
+		return "", errors.Wrap(err, errors.ErrDatabaseQuery, "failed to issue refresh token")
+	}
+	return token, nil
+}
+
+// Handle exchanges a refresh token for an access token and the refresh token replacing it
+func (h *AuthRefreshTokenCommandHandler) Handle(ctx context.Context, cmd dto.RefreshTokenCommand) (*dto.RefreshTokenResponse, error) {
+	claims, err := h.jwtService.ValidateRefreshToken(cmd.RefreshToken)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrUnauthorized, "invalid refresh token")
+	}
+
+	// The user may have been deleted since signing in. Looked up before rotating, so a token
+	// rejected for a failing lookup still renews once retried.
+	user, err := h.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || user == nil {
+		return nil, errors.New(errors.ErrUnauthorized, "invalid refresh token")
+	}
+
+	rotated, err := h.refreshTokens.Rotate(ctx, claims.ID, claims.Family)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseQuery, "failed to rotate refresh token")
+	}
+	if !rotated {
+		// Used twice, the token may have been stolen: neither of its holders renews anymore
+		if err := h.refreshTokens.RevokeFamily(ctx, claims.Family); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabaseQuery, "failed to revoke refresh tokens")
+		}
+		return nil, errors.New(errors.ErrUnauthorized, "refresh token was already used or revoked")
+	}
+
+	token, err := h.jwtService.GenerateToken(user.ID.Value(), user.Email.Value(), []string{"user"})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to generate token")
+	}
+	refreshToken, err := h.issue(ctx, user.ID.Value(), user.Email.Value(), claims.Family)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RefreshTokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// HandleRevoke signs out, revoking a refresh token and every token rotated from the same sign in
+func (h *AuthRefreshTokenCommandHandler) HandleRevoke(ctx context.Context, cmd dto.RevokeRefreshTokenCommand) error {
+	claims, err := h.jwtService.ValidateRefreshToken(cmd.RefreshToken)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrUnauthorized, "invalid refresh token")
+	}
+	if err := h.refreshTokens.RevokeFamily(ctx, claims.Family); err != nil {
+		return errors.Wrap(err, errors.ErrDatabaseQuery, "failed to revoke refresh tokens")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRefreshTokens records refresh tokens in memory
+type memoryRefreshTokens struct {
+	families map[string]string   // Family of each token issued
+	users    map[string][]string // Families of each user
+	rotated  map[string]bool
+	revoked  map[string]bool
+}
+
+func newMemoryRefreshTokens() *memoryRefreshTokens {
+	return &memoryRefreshTokens{families: map[string]string{}, users: map[string][]string{}, rotated: map[string]bool{}, revoked: map[string]bool{}}
+}
+
+func (m *memoryRefreshTokens) Issue(ctx context.Context, tokenID, familyID, userID string, expiresAt time.Time) error {
+	m.families[tokenID] = familyID
+	m.users[userID] = append(m.users[userID], familyID)
+	return nil
+}
+
+func (m *memoryRefreshTokens) Rotate(ctx context.Context, tokenID, familyID string) (bool, error) {
+	if m.families[tokenID] != familyID || m.rotated[tokenID] || m.revoked[familyID] {
+		return false, nil
+	}
+	m.rotated[tokenID] = true
+	return true, nil
+}
+
+func (m *memoryRefreshTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	m.revoked[familyID] = true
+	return nil
+}
+
+func (m *memoryRefreshTokens) RevokeUser(ctx context.Context, userID string) error {
+	for _, familyID := range m.users[userID] {
+		m.revoked[familyID] = true
+	}
+	return nil
+}
+
+func TestAuthRefreshTokenCommandHandler_Rotation(t *testing.T) {
+	ctx := context.Background()
+	user, err := entities.NewUser("alice@example.com", "Alice")
+	require.NoError(t, err)
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByID(mock.Anything, user.ID.Value()).Return(user, nil)
+
+	jwtService := newTestJWTService(t)
+	handler := NewAuthRefreshTokenCommandHandler(userRepo, newMemoryRefreshTokens(), jwtService, time.Hour)
+
+	first, err := handler.Issue(ctx, user.ID.Value(), user.Email.Value())
+	require.NoError(t, err)
+
+	resp, err := handler.Handle(ctx, dto.RefreshTokenCommand{RefreshToken: first})
+	require.NoError(t, err)
+	claims, err := jwtService.ValidateToken(resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.Value(), claims.UserID)
+	require.NotEmpty(t, resp.RefreshToken)
+	assert.NotEqual(t, first, resp.RefreshToken)
+
+	second, err := handler.Handle(ctx, dto.RefreshTokenCommand{RefreshToken: resp.RefreshToken})
+	require.NoError(t, err, "the token replacing a token renews in turn")
+
+	_, err = handler.Handle(ctx, dto.RefreshTokenCommand{RefreshToken: first})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""), "tokens renew once")
+	_, err = handler.Handle(ctx, dto.RefreshTokenCommand{RefreshToken: second.RefreshToken})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""), "reusing a token revokes the tokens rotated from the same sign in")
+}
+
+func TestAuthRefreshTokenCommandHandler_Revoke(t *testing.T) {
+	ctx := context.Background()
+	user, err := entities.NewUser("alice@example.com", "Alice")
+	require.NoError(t, err)
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.EXPECT().GetByID(mock.Anything, user.ID.Value()).Return(user, nil)
+
+	handler := NewAuthRefreshTokenCommandHandler(userRepo, newMemoryRefreshTokens(), newTestJWTService(t), time.Hour)
+	revoked, err := handler.Issue(ctx, user.ID.Value(), user.Email.Value())
+	require.NoError(t, err)
+	other, err := handler.Issue(ctx, user.ID.Value(), user.Email.Value())
+	require.NoError(t, err)
+
+	require.NoError(t, handler.HandleRevoke(ctx, dto.RevokeRefreshTokenCommand{RefreshToken: revoked}))
+	_, err = handler.Handle(ctx, dto.RefreshTokenCommand{RefreshToken: revoked})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""))
+	_, err = handler.Handle(ctx, dto.RefreshTokenCommand{RefreshToken: other})
+	assert.NoError(t, err, "revoking signs out of one sign in only")
+}
+
+func TestAuthRefreshTokenCommandHandler_RejectsAccessTokens(t *testing.T) {
+	jwtService := newTestJWTService(t)
+	handler := NewAuthRefreshTokenCommandHandler(mocks.NewMockUserRepository(t), newMemoryRefreshTokens(), jwtService, time.Hour)
+	token, err := jwtService.GenerateToken("user-1", "alice@example.com", []string{"user"})
+	require.NoError(t, err)
+
+	_, err = handler.Handle(context.Background(), dto.RefreshTokenCommand{RefreshToken: token})
+	assert.Equal(t, errors.ErrUnauthorized, errors.CodeOf(err, ""))
+}
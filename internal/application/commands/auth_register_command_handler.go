@@ -18,6 +18,8 @@ type AuthRegisterCommandHandler struct {
 	eventPublisher  repositories.EventPublisher
 	passwordService *auth.PasswordService
 	jwtService      *auth.JWTService
+	policy          CommandPolicy
+	transactions    repositories.TransactionManager
 }
 
 // NewAuthRegisterCommandHandler creates a new auth register command handler
@@ -37,8 +39,24 @@ func NewAuthRegisterCommandHandler(
 	}
 }
 
+// SetPolicy sets the business rules evaluated before the command runs
+func (h *AuthRegisterCommandHandler) SetPolicy(policy CommandPolicy) {
+	h.policy = policy
+}
+
+// SetTransactions makes the command change the write database and append its events in a
+// single transaction
+func (h *AuthRegisterCommandHandler) SetTransactions(transactions repositories.TransactionManager) {
+	h.transactions = transactions
+}
+
 // Handle handles the register command
 func (h *AuthRegisterCommandHandler) Handle(ctx context.Context, cmd dto.RegisterCommand) (*dto.RegisterResponse, error) {
+	// Reject commands handled before
+	if err := rejectReplay(ctx, h.eventStore); err != nil {
+		return nil, err
+	}
+
 	// Check if user already exists
 	existingUser, err := h.userRepo.GetByEmail(ctx, cmd.Email)
 	if err == nil && existingUser != nil {
@@ -62,6 +80,11 @@ func (h *AuthRegisterCommandHandler) Handle(ctx context.Context, cmd dto.Registe
 		return nil, errors.Wrap(err, errors.ErrValidationFailed, "failed to create user")
 	}
 
+	// Evaluate business rules
+	if err := evaluatePolicy(ctx, h.policy, RegisterCommandName, userFacts(user)); err != nil {
+		return nil, err
+	}
+
 	// Set password hash
 	user.SetPasswordHash(hashedPassword)
 
@@ -79,19 +102,28 @@ func (h *AuthRegisterCommandHandler) Handle(ctx context.Context, cmd dto.Registe
 		return nil, errors.Wrap(err, errors.ErrEventStoreFailed, "failed to create event")
 	}
 
-	// Save event to event store
-	if err := h.eventStore.SaveEvent(ctx, user.ID.Value(), event); err != nil {
-		return nil, errors.Wrap(err, errors.ErrEventStoreFailed, "failed to save event")
-	}
-
-	// Publish event
-	if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
-		return nil, errors.Wrap(err, errors.ErrEventPublishFailed, "failed to publish event")
-	}
-
-	// Save user to write database
-	if err := h.userRepo.Create(ctx, user); err != nil {
-		return nil, errors.Wrap(err, errors.ErrInternalServer, "failed to save user")
+	err = inTransaction(ctx, h.transactions, RegisterCommandName, func(ctx context.Context) error {
+		// Save event to event store
+		if err := h.eventStore.SaveEvent(ctx, user.ID.Value(), event); err != nil {
+			if replayedCommand(err) {
+				return err
+			}
+			return errors.Wrap(err, errors.ErrEventStoreFailed, "failed to save event")
+		}
+
+		// Publish event
+		if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
+			return errors.Wrap(err, errors.ErrEventPublishFailed, "failed to publish event")
+		}
+
+		// Save user to write database
+		if err := h.userRepo.Create(ctx, user); err != nil {
+			return errors.Wrap(err, errors.ErrInternalServer, "failed to save user")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate JWT token
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+)
+
+// Command names passed to command policies, and naming the transactions of commands
+const (
+	CreateUserCommandName        = "user.create"
+	UpdateUserCommandName        = "user.update"
+	DeleteUserCommandName        = "user.delete"
+	RegisterCommandName          = "auth.register"
+	UpdatePreferencesCommandName = "user.update_preferences"
+	ResetPasswordCommandName     = "auth.reset_password"
+	VerifyEmailCommandName       = "auth.verify_email"
+	UploadAvatarCommandName      = "user.upload_avatar"
+)
+
+// CommandPolicy evaluates business rules before a command changes state, e.g. that registrations
+// from a domain require approval. Evaluate returns nil to allow the command, or the structured
+// error denying it. See rules.Engine.
+type CommandPolicy interface {
+	Evaluate(ctx context.Context, command string, facts map[string]interface{}) error
+}
+
+// evaluatePolicy evaluates the policy of a command; a nil policy allows every command
+func evaluatePolicy(ctx context.Context, policy CommandPolicy, command string, facts map[string]interface{}) error {
+	if policy == nil {
+		return nil
+	}
+	return policy.Evaluate(ctx, command, facts)
+}
+
+// userFacts returns the facts of a user that rules can refer to
+func userFacts(user *entities.User) map[string]interface{} {
+	email := user.GetEmail()
+	domain := email[strings.LastIndex(email, "@")+1:]
+	return map[string]interface{}{
+		"user_id":      user.GetID(),
+		"email":        email,
+		"email_domain": domain,
+		"name":         user.GetName(),
+	}
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/consistency"
+)
+
+// AggregateVersioner is implemented by event stores that know the version of an aggregate,
+// the number of events stored for it. See repositories.PostgresEventStore.
+type AggregateVersioner interface {
+	GetLastEventVersion(ctx context.Context, aggregateID string) (int, error)
+}
+
+// consistencyToken returns the token of the version a command wrote, which queries accept to read
+// their own writes. The command already succeeded, so an event store that cannot tell the version
+// yields no token rather than an error.
+func consistencyToken(ctx context.Context, eventStore repositories.EventStore, aggregateID string) string {
+	versioner, ok := eventStore.(AggregateVersioner)
+	if !ok {
+		return ""
+	}
+	version, err := versioner.GetLastEventVersion(ctx, aggregateID)
+	if err != nil || version <= 0 {
+		return ""
+	}
+	return consistency.Token{AggregateID: aggregateID, Version: version}.String()
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// rejectReplay rejects a command re-submitted under the ID of a command handled before, whose
+// events, caused by the command ID, are in the event store. It complements idempotency keys for
+// commands delivered again by the broker or retried by clients after a lost response. Commands
+// without an ID and event stores unable to look events up by causation are not checked. The check
+// fails fast; the event store records the command ID when appending its events, so a command
+// submitted twice concurrently fails to append, see replayedCommand.
+func rejectReplay(ctx context.Context, eventStore repositories.EventStore) error {
+	commandID := events.MetadataFromContext(ctx).CommandID
+	if commandID == "" {
+		return nil
+	}
+	checker, ok := eventStore.(repositories.CausationChecker)
+	if !ok {
+		return nil
+	}
+
+	handled, err := checker.HasCausation(ctx, commandID)
+	if err != nil {
+		return errors.EventStoreError("check command replay", err)
+	}
+	if handled {
+		return errors.DuplicateCommand(commandID)
+	}
+	return nil
+}
+
+// replayedCommand reports whether err is the DUPLICATE_COMMAND error of saving the events of a
+// command appended concurrently under the same ID, returned as is rather than wrapped
+func replayedCommand(err error) bool {
+	return errors.CodeOf(err, "") == errors.ErrDuplicateCommand
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// causationEventStore is an event store looking events up by the causations it was given
+type causationEventStore struct {
+	*mocks.MockEventStore
+	causations map[string]bool
+}
+
+func (s *causationEventStore) HasCausation(ctx context.Context, causationID string) (bool, error) {
+	return s.causations[causationID], nil
+}
+
+func commandContext(commandID string) context.Context {
+	return events.ContextWithMetadata(context.Background(), events.Metadata{CausationID: commandID, CommandID: commandID})
+}
+
+func TestRejectReplay(t *testing.T) {
+	eventStore := &causationEventStore{MockEventStore: mocks.NewMockEventStore(t), causations: map[string]bool{"command-1": true}}
+
+	err := rejectReplay(commandContext("command-1"), eventStore)
+	assert.Equal(t, errors.ErrDuplicateCommand, errors.CodeOf(err, ""))
+	assert.NoError(t, rejectReplay(commandContext("command-2"), eventStore))
+
+	// Commands without an ID and event stores without causations are not checked
+	assert.NoError(t, rejectReplay(context.Background(), eventStore))
+	assert.NoError(t, rejectReplay(commandContext("command-1"), mocks.NewMockEventStore(t)))
+}
+
+func TestUserCreateCommandHandler_RejectsReplay(t *testing.T) {
+	userRepo := mocks.NewMockUserWriteRepository(t)
+	eventStore := &causationEventStore{MockEventStore: mocks.NewMockEventStore(t), causations: map[string]bool{"command-1": true}}
+	eventPublisher := mocks.NewMockEventPublisher(t)
+	handler := NewUserCreateCommandHandler(userRepo, eventStore, eventPublisher)
+
+	// Replayed commands never reach the repositories
+	result, err := handler.Handle(commandContext("command-1"), dto.CreateUserCommand{Email: "test@example.com", Name: "John Doe"})
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrDuplicateCommand, errors.CodeOf(err, ""))
+
+	userRepo.EXPECT().GetByEmail(mock.Anything, "test@example.com").Return(nil, errors.UserNotFound("test@example.com"))
+	userRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*entities.User")).Return(nil)
+	eventStore.EXPECT().SaveEvent(mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*events.Event")).Return(nil)
+	eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.AnythingOfType("*events.Event")).Return(nil)
+	result, err = handler.Handle(commandContext("command-2"), dto.CreateUserCommand{Email: "test@example.com", Name: "John Doe"})
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", result.Email)
+}
+
+func TestUserCreateCommandHandler_RejectsConcurrentReplay(t *testing.T) {
+	userRepo := mocks.NewMockUserWriteRepository(t)
+	eventStore := &causationEventStore{MockEventStore: mocks.NewMockEventStore(t), causations: map[string]bool{}}
+	handler := NewUserCreateCommandHandler(userRepo, eventStore, mocks.NewMockEventPublisher(t))
+
+	// The same command, handled concurrently, appended its events after the check
+	userRepo.EXPECT().GetByEmail(mock.Anything, "test@example.com").Return(nil, errors.UserNotFound("test@example.com"))
+	userRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*entities.User")).Return(nil)
+	eventStore.EXPECT().SaveEvent(mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*events.Event")).Return(repositories.NewDuplicateCommandError("command-1"))
+
+	result, err := handler.Handle(commandContext("command-1"), dto.CreateUserCommand{Email: "test@example.com", Name: "John Doe"})
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrDuplicateCommand, errors.CodeOf(err, ""))
+}
@@ -0,0 +1,17 @@
+package commands
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// inTransaction runs fn within a transaction of transactions named after the command, so the
+// aggregate change and the events appended to the outbox commit together, or directly when there
+// is no transaction manager
+func inTransaction(ctx context.Context, transactions repositories.TransactionManager, command string, fn func(ctx context.Context) error) error {
+	if transactions == nil {
+		return fn(ctx)
+	}
+	return transactions.WithinTransaction(ctx, command, fn)
+}
@@ -15,6 +15,8 @@ type UserCreateCommandHandler struct {
 	userWriteRepo  repositories.UserWriteRepository
 	eventStore     repositories.EventStore
 	eventPublisher repositories.EventPublisher
+	policy         CommandPolicy
+	transactions   repositories.TransactionManager
 }
 
 // NewUserCreateCommandHandler creates a new user create command handler
@@ -30,8 +32,24 @@ func NewUserCreateCommandHandler(
 	}
 }
 
+// SetPolicy sets the business rules evaluated before the command runs
+func (h *UserCreateCommandHandler) SetPolicy(policy CommandPolicy) {
+	h.policy = policy
+}
+
+// SetTransactions makes the command change the write database and append its events in a
+// single transaction
+func (h *UserCreateCommandHandler) SetTransactions(transactions repositories.TransactionManager) {
+	h.transactions = transactions
+}
+
 // Handle handles the create user command
 func (h *UserCreateCommandHandler) Handle(ctx context.Context, cmd dto.CreateUserCommand) (*dto.CreateUserCommandResponse, error) {
+	// Reject commands handled before
+	if err := rejectReplay(ctx, h.eventStore); err != nil {
+		return nil, err
+	}
+
 	// Create user entity with validation
 	user, err := entities.NewUser(cmd.Email, cmd.Name)
 	if err != nil {
@@ -39,6 +57,11 @@ func (h *UserCreateCommandHandler) Handle(ctx context.Context, cmd dto.CreateUse
 		return nil, errors.Wrap(err, errors.ErrValidationFailed, "Failed to create user")
 	}
 
+	// Evaluate business rules
+	if err := evaluatePolicy(ctx, h.policy, CreateUserCommandName, userFacts(user)); err != nil {
+		return nil, err
+	}
+
 	// Check if user already exists
 	existingUser, err := h.userWriteRepo.GetByEmail(ctx, cmd.Email)
 	if err != nil && !errors.IsAppError(err) {
@@ -48,33 +71,42 @@ func (h *UserCreateCommandHandler) Handle(ctx context.Context, cmd dto.CreateUse
 		return nil, errors.UserAlreadyExists(cmd.Email)
 	}
 
-	// Save to write database (PostgreSQL)
-	if err := h.userWriteRepo.Create(ctx, user); err != nil {
-		return nil, errors.DatabaseError("create user", err)
-	}
+	err = inTransaction(ctx, h.transactions, CreateUserCommandName, func(ctx context.Context) error {
+		// Save to write database (PostgreSQL)
+		if err := h.userWriteRepo.Create(ctx, user); err != nil {
+			return errors.DatabaseError("create user", err)
+		}
 
-	// Create domain event
-	userCreatedEvent := &events.UserCreatedEvent{
-		UserID:    user.GetID(),
-		Email:     user.GetEmail(),
-		Name:      user.GetName(),
-		CreatedAt: user.CreatedAt,
-	}
+		// Create domain event
+		userCreatedEvent := &events.UserCreatedEvent{
+			UserID:    user.GetID(),
+			Email:     user.GetEmail(),
+			Name:      user.GetName(),
+			CreatedAt: user.CreatedAt,
+		}
 
-	// Wrap in Event
-	event, err := events.NewEvent("user.created", userCreatedEvent, 1)
-	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrEventStoreFailed, "Failed to create event")
-	}
+		// Wrap in Event
+		event, err := events.NewEvent("user.created", userCreatedEvent, 1)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrEventStoreFailed, "Failed to create event")
+		}
 
-	// Save event to event store
-	if err := h.eventStore.SaveEvent(ctx, user.GetID(), event); err != nil {
-		return nil, errors.EventStoreError("save event", err)
-	}
+		// Save event to event store
+		if err := h.eventStore.SaveEvent(ctx, user.GetID(), event); err != nil {
+			if replayedCommand(err) {
+				return err
+			}
+			return errors.EventStoreError("save event", err)
+		}
 
-	// Publish event to Kafka
-	if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
-		return nil, errors.EventPublishError(err)
+		// Publish event to Kafka
+		if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
+			return errors.EventPublishError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Return response
@@ -82,8 +114,9 @@ func (h *UserCreateCommandHandler) Handle(ctx context.Context, cmd dto.CreateUse
 		UserID:    user.GetID(),
 		Email:     user.GetEmail(),
 		Name:      user.GetName(),
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedAt: dto.FormatTimestamp(ctx, user.CreatedAt),
 	}
+	response.ConsistencyToken = consistencyToken(ctx, h.eventStore, user.GetID())
 
 	return response, nil
 }
@@ -6,6 +6,8 @@ import (
 
 	"go-clean-ddd-es-template/internal/application/dto"
 	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/rules"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -91,3 +93,60 @@ func TestUserCreateCommandHandler_Handle(t *testing.T) {
 		})
 	}
 }
+
+func TestUserCreateCommandHandler_PolicyDenied(t *testing.T) {
+	userRepo := mocks.NewMockUserWriteRepository(t)
+	eventStore := mocks.NewMockEventStore(t)
+	eventPublisher := mocks.NewMockEventPublisher(t)
+
+	handler := NewUserCreateCommandHandler(userRepo, eventStore, eventPublisher)
+	handler.SetPolicy(rules.NewEngine(rules.Rule{
+		Name:     "blocked-domains",
+		Commands: []string{CreateUserCommandName},
+		Effect:   rules.EffectDeny,
+		Message:  "blocked domain",
+		When:     rules.MustCompile(`email_domain == "blocked.example"`),
+	}))
+
+	// Denied commands never reach the repositories
+	result, err := handler.Handle(context.Background(), dto.CreateUserCommand{Email: "test@blocked.example", Name: "John Doe"})
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrPolicyDenied, errors.CodeOf(err, ""))
+	assert.Equal(t, []rules.Violation{{Rule: "blocked-domains", Effect: rules.EffectDeny, Message: "blocked domain"}}, rules.Violations(err))
+}
+
+// recordingTransactions runs units of work directly, recording their operation and outcome
+type recordingTransactions struct {
+	runs      int
+	operation string
+	failed    error
+}
+
+func (r *recordingTransactions) WithinTransaction(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	r.runs++
+	r.operation = operation
+	r.failed = fn(ctx)
+	return r.failed
+}
+
+func TestUserCreateCommandHandler_Transaction(t *testing.T) {
+	userRepo := mocks.NewMockUserWriteRepository(t)
+	eventStore := mocks.NewMockEventStore(t)
+	eventPublisher := mocks.NewMockEventPublisher(t)
+	userRepo.EXPECT().GetByEmail(mock.Anything, "test@example.com").Return(nil, nil)
+	userRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*entities.User")).Return(nil)
+	eventStore.EXPECT().SaveEvent(mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*events.Event")).Return(nil)
+	eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.AnythingOfType("*events.Event")).Return(assert.AnError)
+
+	transactions := &recordingTransactions{}
+	handler := NewUserCreateCommandHandler(userRepo, eventStore, eventPublisher)
+	handler.SetTransactions(transactions)
+
+	// The user is created and the event appended in one transaction, rolled back together
+	result, err := handler.Handle(context.Background(), dto.CreateUserCommand{Email: "test@example.com", Name: "John Doe"})
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrEventPublishFailed, errors.CodeOf(err, ""))
+	assert.Equal(t, 1, transactions.runs)
+	assert.Equal(t, CreateUserCommandName, transactions.operation)
+	assert.Equal(t, err, transactions.failed)
+}
@@ -13,32 +13,60 @@ import (
 type UserDeleteCommandHandler struct {
 	userWriteRepo  repositories.UserWriteRepository
 	eventStore     repositories.EventStore
+	users          *repositories.EventSourcedUserRepository
 	eventPublisher repositories.EventPublisher
+	policy         CommandPolicy
+	transactions   repositories.TransactionManager
 }
 
-// NewUserDeleteCommandHandler creates a new user delete command handler
+// NewUserDeleteCommandHandler creates a new user delete command handler. Its event is appended to the
+// user's stream loaded from users, at the version the command was decided on.
 func NewUserDeleteCommandHandler(
 	userWriteRepo repositories.UserWriteRepository,
 	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
 	eventPublisher repositories.EventPublisher,
 ) *UserDeleteCommandHandler {
 	return &UserDeleteCommandHandler{
 		userWriteRepo:  userWriteRepo,
 		eventStore:     eventStore,
+		users:          users,
 		eventPublisher: eventPublisher,
 	}
 }
 
+// SetPolicy sets the business rules evaluated before the command runs
+func (h *UserDeleteCommandHandler) SetPolicy(policy CommandPolicy) {
+	h.policy = policy
+}
+
+// SetTransactions makes the command change the write database and append its events in a
+// single transaction
+func (h *UserDeleteCommandHandler) SetTransactions(transactions repositories.TransactionManager) {
+	h.transactions = transactions
+}
+
 // Handle handles the delete user command
 func (h *UserDeleteCommandHandler) Handle(ctx context.Context, cmd dto.DeleteUserCommand) (*dto.DeleteUserCommandResponse, error) {
+	// Reject commands handled before
+	if err := rejectReplay(ctx, h.eventStore); err != nil {
+		return nil, err
+	}
+
 	// Get existing user from write database
 	user, err := h.userWriteRepo.GetByID(ctx, cmd.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Delete from write database (PostgreSQL)
-	if err := h.userWriteRepo.Delete(ctx, cmd.UserID); err != nil {
+	// Load the user's stream: the event is appended at the version it is at now
+	stream, err := h.users.Load(ctx, user.GetID())
+	if err != nil {
+		return nil, err
+	}
+
+	// Evaluate business rules
+	if err := evaluatePolicy(ctx, h.policy, DeleteUserCommandName, userFacts(user)); err != nil {
 		return nil, err
 	}
 
@@ -48,28 +76,41 @@ func (h *UserDeleteCommandHandler) Handle(ctx context.Context, cmd dto.DeleteUse
 		DeletedAt: time.Now(),
 	}
 
-	// Wrap in Event
-	event, err := events.NewEvent("user.deleted", userDeletedEvent, 1)
+	// Wrap in Event, numbered after the events of the user's stream
+	event, err := events.NewEvent("user.deleted", userDeletedEvent, 0)
 	if err != nil {
 		return nil, err
 	}
+	stream.RecordEvent(event)
 
-	// Save event to event store
-	if err := h.eventStore.SaveEvent(ctx, user.GetID(), event); err != nil {
-		return nil, err
-	}
+	err = inTransaction(ctx, h.transactions, DeleteUserCommandName, func(ctx context.Context) error {
+		// Delete from write database (PostgreSQL)
+		if err := h.userWriteRepo.Delete(ctx, cmd.UserID); err != nil {
+			return err
+		}
+
+		// Append event to the user's stream, failing when another command appended first
+		if err := h.users.Save(ctx, stream); err != nil {
+			return err
+		}
 
-	// Publish event to Kafka
-	if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
+		// Publish event to Kafka
+		if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Return response
 	response := &dto.DeleteUserCommandResponse{
 		UserID:    user.GetID(),
-		DeletedAt: userDeletedEvent.DeletedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DeletedAt: dto.FormatTimestamp(ctx, userDeletedEvent.DeletedAt),
 		Success:   true,
 	}
+	response.ConsistencyToken = consistencyToken(ctx, h.eventStore, user.GetID())
 
 	return response, nil
 }
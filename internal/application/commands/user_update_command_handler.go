@@ -12,37 +12,68 @@ import (
 type UserUpdateCommandHandler struct {
 	userWriteRepo  repositories.UserWriteRepository
 	eventStore     repositories.EventStore
+	users          *repositories.EventSourcedUserRepository
 	eventPublisher repositories.EventPublisher
+	policy         CommandPolicy
+	transactions   repositories.TransactionManager
 }
 
-// NewUserUpdateCommandHandler creates a new user update command handler
+// NewUserUpdateCommandHandler creates a new user update command handler. Its event is appended to the
+// user's stream loaded from users, at the version the command was decided on.
 func NewUserUpdateCommandHandler(
 	userWriteRepo repositories.UserWriteRepository,
 	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
 	eventPublisher repositories.EventPublisher,
 ) *UserUpdateCommandHandler {
 	return &UserUpdateCommandHandler{
 		userWriteRepo:  userWriteRepo,
 		eventStore:     eventStore,
+		users:          users,
 		eventPublisher: eventPublisher,
 	}
 }
 
+// SetPolicy sets the business rules evaluated before the command runs
+func (h *UserUpdateCommandHandler) SetPolicy(policy CommandPolicy) {
+	h.policy = policy
+}
+
+// SetTransactions makes the command change the write database and append its events in a
+// single transaction
+func (h *UserUpdateCommandHandler) SetTransactions(transactions repositories.TransactionManager) {
+	h.transactions = transactions
+}
+
 // Handle handles the update user command
 func (h *UserUpdateCommandHandler) Handle(ctx context.Context, cmd dto.UpdateUserCommand) (*dto.UpdateUserCommandResponse, error) {
+	// Reject commands handled before
+	if err := rejectReplay(ctx, h.eventStore); err != nil {
+		return nil, err
+	}
+
 	// Get existing user from write database
 	user, err := h.userWriteRepo.GetByID(ctx, cmd.UserID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Load the user's stream: the event is appended at the version it is at now
+	stream, err := h.users.Load(ctx, user.GetID())
+	if err != nil {
+		return nil, err
+	}
+
 	// Update user with validation
+	currentName := user.GetName()
 	if err := user.UpdateName(cmd.Name); err != nil {
 		return nil, err
 	}
 
-	// Save to write database (PostgreSQL)
-	if err := h.userWriteRepo.Update(ctx, user); err != nil {
+	// Evaluate business rules
+	facts := userFacts(user)
+	facts["current_name"] = currentName
+	if err := evaluatePolicy(ctx, h.policy, UpdateUserCommandName, facts); err != nil {
 		return nil, err
 	}
 
@@ -53,19 +84,31 @@ func (h *UserUpdateCommandHandler) Handle(ctx context.Context, cmd dto.UpdateUse
 		UpdatedAt: user.UpdatedAt,
 	}
 
-	// Wrap in Event
-	event, err := events.NewEvent("user.updated", userUpdatedEvent, 1)
+	// Wrap in Event, numbered after the events of the user's stream
+	event, err := events.NewEvent("user.updated", userUpdatedEvent, 0)
 	if err != nil {
 		return nil, err
 	}
+	stream.RecordEvent(event)
 
-	// Save event to event store
-	if err := h.eventStore.SaveEvent(ctx, user.GetID(), event); err != nil {
-		return nil, err
-	}
+	err = inTransaction(ctx, h.transactions, UpdateUserCommandName, func(ctx context.Context) error {
+		// Save to write database (PostgreSQL)
+		if err := h.userWriteRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Append event to the user's stream, failing when another command appended first
+		if err := h.users.Save(ctx, stream); err != nil {
+			return err
+		}
 
-	// Publish event to Kafka
-	if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
+		// Publish event to Kafka
+		if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -73,8 +116,9 @@ func (h *UserUpdateCommandHandler) Handle(ctx context.Context, cmd dto.UpdateUse
 	response := &dto.UpdateUserCommandResponse{
 		UserID:    user.GetID(),
 		Name:      user.GetName(),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: dto.FormatTimestamp(ctx, user.UpdatedAt),
 	}
+	response.ConsistencyToken = consistencyToken(ctx, h.eventStore, user.GetID())
 
 	return response, nil
 }
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryEventStore is an in-memory event store of streams numbered from 1, appending with
+// optimistic concurrency as the PostgreSQL event store does
+type memoryEventStore struct {
+	repositories.EventStore
+	streams map[string][]*events.Event
+}
+
+func newMemoryEventStore() *memoryEventStore {
+	return &memoryEventStore{streams: make(map[string][]*events.Event)}
+}
+
+// create stores the user.created event of a user, the first event of the user's stream
+func (s *memoryEventStore) create(t *testing.T, user *entities.User) {
+	created, err := events.NewEvent("user.created", events.UserCreatedEvent{UserID: user.GetID(), Email: user.GetEmail(), Name: user.GetName(), CreatedAt: user.CreatedAt}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveEvent(context.Background(), user.GetID(), created))
+}
+
+// types returns the types of the events of a stream, in version order
+func (s *memoryEventStore) types(aggregateID string) []string {
+	var types []string
+	for _, event := range s.streams[aggregateID] {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func (s *memoryEventStore) SaveEvent(ctx context.Context, aggregateID string, event *events.Event) error {
+	for _, stored := range s.streams[aggregateID] {
+		if stored.Version == event.Version {
+			return repositories.NewConcurrencyError(aggregateID, event.Version-1, event.Version)
+		}
+	}
+	s.streams[aggregateID] = append(s.streams[aggregateID], event)
+	return nil
+}
+
+func (s *memoryEventStore) GetEvents(ctx context.Context, aggregateID string) ([]*events.Event, error) {
+	return s.streams[aggregateID], nil
+}
+
+func (s *memoryEventStore) AppendEvents(ctx context.Context, aggregateID string, expectedVersion int, appended ...*events.Event) error {
+	if current := len(s.streams[aggregateID]); current != expectedVersion {
+		return repositories.NewConcurrencyError(aggregateID, expectedVersion, current)
+	}
+	for i, event := range appended {
+		stored := *event
+		stored.Version = expectedVersion + i + 1
+		s.streams[aggregateID] = append(s.streams[aggregateID], &stored)
+	}
+	return nil
+}
+
+func TestUserUpdateCommandHandler_AppendsToStream(t *testing.T) {
+	user, err := entities.NewUser("test@example.com", "John Doe")
+	require.NoError(t, err)
+	eventStore := newMemoryEventStore()
+	eventStore.create(t, user)
+
+	userRepo := mocks.NewMockUserWriteRepository(t)
+	userRepo.EXPECT().GetByID(mock.Anything, user.GetID()).Return(user, nil)
+	userRepo.EXPECT().Update(mock.Anything, user).Return(nil)
+	var published []int
+	eventPublisher := mocks.NewMockEventPublisher(t)
+	eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.AnythingOfType("*events.Event")).RunAndReturn(func(ctx context.Context, event *events.Event) error {
+		published = append(published, event.Version)
+		return nil
+	})
+
+	handler := NewUserUpdateCommandHandler(userRepo, eventStore, repositories.NewEventSourcedUserRepository(eventStore), eventPublisher)
+	for _, name := range []string{"Jane Doe", "Janet Doe"} {
+		result, err := handler.Handle(context.Background(), dto.UpdateUserCommand{UserID: user.GetID(), Name: name})
+		require.NoError(t, err)
+		assert.Equal(t, name, result.Name)
+	}
+
+	assert.Equal(t, []string{"user.created", "user.updated", "user.updated"}, eventStore.types(user.GetID()))
+	assert.Equal(t, []int{2, 3}, published, "events are published with the version they were appended at")
+}
+
+func TestUserDeleteCommandHandler_ConflictingAppend(t *testing.T) {
+	user, err := entities.NewUser("test@example.com", "John Doe")
+	require.NoError(t, err)
+	eventStore := newMemoryEventStore()
+	eventStore.create(t, user)
+	users := repositories.NewEventSourcedUserRepository(eventStore)
+
+	userRepo := mocks.NewMockUserWriteRepository(t)
+	userRepo.EXPECT().GetByID(mock.Anything, user.GetID()).Return(user, nil)
+	// Another command appends to the user's stream while the user is deleted
+	userRepo.EXPECT().Delete(mock.Anything, user.GetID()).RunAndReturn(func(ctx context.Context, userID string) error {
+		updated, err := events.NewEvent("user.updated", events.UserUpdatedEvent{UserID: userID, Name: "Jane Doe"}, 2)
+		require.NoError(t, err)
+		return eventStore.SaveEvent(ctx, userID, updated)
+	})
+
+	handler := NewUserDeleteCommandHandler(userRepo, eventStore, users, mocks.NewMockEventPublisher(t))
+	_, err = handler.Handle(context.Background(), dto.DeleteUserCommand{UserID: user.GetID()})
+	assert.Equal(t, errors.ErrConcurrencyConflict, errors.CodeOf(err, ""))
+	assert.Equal(t, []string{"user.created", "user.updated"}, eventStore.types(user.GetID()))
+}
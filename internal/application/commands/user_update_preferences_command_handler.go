@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// UserUpdatePreferencesCommandHandler handles the update user preferences command (write operation).
+// The preferences are rebuilt from the events of the user's stream, the only place they are written.
+type UserUpdatePreferencesCommandHandler struct {
+	userWriteRepo  repositories.UserWriteRepository
+	eventStore     repositories.EventStore
+	users          *repositories.EventSourcedUserRepository
+	eventPublisher repositories.EventPublisher
+	defaults       entities.Preferences
+	transactions   repositories.TransactionManager
+}
+
+// NewUserUpdatePreferencesCommandHandler creates a new user update preferences command handler,
+// responding with defaults for the settings users did not choose. The preferences are loaded with
+// the user's stream from users, so changes are appended at the version they were decided on.
+func NewUserUpdatePreferencesCommandHandler(
+	userWriteRepo repositories.UserWriteRepository,
+	eventStore repositories.EventStore,
+	users *repositories.EventSourcedUserRepository,
+	eventPublisher repositories.EventPublisher,
+	defaults entities.Preferences,
+) *UserUpdatePreferencesCommandHandler {
+	return &UserUpdatePreferencesCommandHandler{
+		userWriteRepo:  userWriteRepo,
+		eventStore:     eventStore,
+		users:          users,
+		eventPublisher: eventPublisher,
+		defaults:       defaults,
+	}
+}
+
+// SetTransactions makes the command append and publish its event in a single transaction
+func (h *UserUpdatePreferencesCommandHandler) SetTransactions(transactions repositories.TransactionManager) {
+	h.transactions = transactions
+}
+
+// Handle handles the update user preferences command
+func (h *UserUpdatePreferencesCommandHandler) Handle(ctx context.Context, cmd dto.UpdateUserPreferencesCommand) (*dto.UpdateUserPreferencesCommandResponse, error) {
+	// Reject commands handled before
+	if err := rejectReplay(ctx, h.eventStore); err != nil {
+		return nil, err
+	}
+
+	// Only existing users have preferences
+	user, err := h.userWriteRepo.GetByID(ctx, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := h.users.Load(ctx, user.GetID())
+	if err != nil {
+		return nil, err
+	}
+	preferences := &stream.Preferences
+
+	changes := entities.PreferenceSettings{
+		Locale: cmd.Locale,
+		Theme:  cmd.Theme,
+	}
+	if cmd.EmailNotifications != nil || cmd.PushNotifications != nil || cmd.NotificationDigest != nil {
+		changes.Notifications = &entities.NotificationSettings{
+			Email:  cmd.EmailNotifications,
+			Push:   cmd.PushNotifications,
+			Digest: cmd.NotificationDigest,
+		}
+	}
+	event, err := preferences.Update(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Changes leaving the preferences as they were record no event
+	if event != nil {
+		stream.RecordEvent(event)
+		err = inTransaction(ctx, h.transactions, UpdatePreferencesCommandName, func(ctx context.Context) error {
+			// Fails when another command appended to the user's stream since it was loaded
+			if err := h.users.Save(ctx, stream); err != nil {
+				return err
+			}
+			return h.eventPublisher.PublishEvent(ctx, event)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := &dto.UpdateUserPreferencesCommandResponse{
+		UserPreferences: dto.NewUserPreferences(ctx, user.GetID(), preferences.Settings.Resolve(h.defaults), preferences.UpdatedAt),
+	}
+	response.ConsistencyToken = consistencyToken(ctx, h.eventStore, user.GetID())
+
+	return response, nil
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testPreferenceDefaults = entities.Preferences{
+	Locale:        "en",
+	Theme:         entities.ThemeSystem,
+	Notifications: entities.NotificationPreferences{Email: true, Digest: entities.DigestWeekly},
+}
+
+func TestUserUpdatePreferencesCommandHandler_Handle(t *testing.T) {
+	user, err := entities.NewUser("test@example.com", "John Doe")
+	require.NoError(t, err)
+	dark, light := entities.ThemeDark, entities.ThemeLight
+	previous, err := events.NewUserPreferencesUpdatedEvent(user.GetID(), entities.PreferenceSettings{Theme: &dark})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		command       dto.UpdateUserPreferencesCommand
+		history       []*events.Event
+		recorded      bool
+		expectedTheme string
+		expectedError bool
+	}{
+		{
+			name:          "first change",
+			command:       dto.UpdateUserPreferencesCommand{UserID: user.GetID(), Theme: &dark},
+			recorded:      true,
+			expectedTheme: entities.ThemeDark,
+		},
+		{
+			name:          "change over earlier preferences",
+			command:       dto.UpdateUserPreferencesCommand{UserID: user.GetID(), Theme: &light},
+			history:       []*events.Event{previous},
+			recorded:      true,
+			expectedTheme: entities.ThemeLight,
+		},
+		{
+			name:          "unchanged preferences record no event",
+			command:       dto.UpdateUserPreferencesCommand{UserID: user.GetID(), Theme: &dark},
+			history:       []*events.Event{previous},
+			expectedTheme: entities.ThemeDark,
+		},
+		{
+			name:          "invalid locale",
+			command:       dto.UpdateUserPreferencesCommand{UserID: user.GetID(), Locale: new(string)},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewMockUserWriteRepository(t)
+			eventStore := newMemoryEventStore()
+			eventPublisher := mocks.NewMockEventPublisher(t)
+
+			userRepo.EXPECT().GetByID(mock.Anything, user.GetID()).Return(user, nil)
+			eventStore.create(t, user)
+			for _, event := range tt.history {
+				stored := *event
+				stored.Version = len(eventStore.streams[user.GetID()]) + 1
+				require.NoError(t, eventStore.SaveEvent(context.Background(), user.GetID(), &stored))
+			}
+			if tt.recorded {
+				eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.MatchedBy(func(event *events.Event) bool {
+					return event.Type == "user.preferences_updated" && event.Version == len(tt.history)+2
+				})).Return(nil)
+			}
+
+			handler := NewUserUpdatePreferencesCommandHandler(userRepo, eventStore, repositories.NewEventSourcedUserRepository(eventStore), eventPublisher, testPreferenceDefaults)
+			result, err := handler.Handle(context.Background(), tt.command)
+
+			if tt.expectedError {
+				assert.Equal(t, errors.ErrValidationFailed, errors.CodeOf(err, ""))
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTheme, result.Theme)
+			assert.Equal(t, "en", result.Locale, "settings the user did not choose are the defaults")
+			assert.True(t, result.Notifications.Email)
+			assert.NotEmpty(t, result.UpdatedAt)
+		})
+	}
+}
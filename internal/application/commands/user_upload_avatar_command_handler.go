@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/storage"
+)
+
+// allowedAvatarTypes maps accepted avatar content types to file extensions
+var allowedAvatarTypes = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// UserUploadAvatarCommandHandler handles the upload avatar command.
+// The image is stored in object storage and its metadata appended to the user's stream as a
+// "user.avatar_updated" event, projected to the user read model.
+type UserUploadAvatarCommandHandler struct {
+	users          *repositories.EventSourcedUserRepository
+	eventPublisher repositories.EventPublisher
+	storage        storage.Storage
+	maxSize        int64
+	urlTTL         time.Duration
+	clock          clock.Clock
+	transactions   repositories.TransactionManager
+}
+
+// NewUserUploadAvatarCommandHandler creates a new user upload avatar command handler. Avatars are
+// stored under keys and stamped with the time of clk; a nil clock uses the system clock.
+func NewUserUploadAvatarCommandHandler(
+	users *repositories.EventSourcedUserRepository,
+	eventPublisher repositories.EventPublisher,
+	store storage.Storage,
+	maxSize int64,
+	urlTTL time.Duration,
+	clk clock.Clock,
+) *UserUploadAvatarCommandHandler {
+	return &UserUploadAvatarCommandHandler{
+		users:          users,
+		eventPublisher: eventPublisher,
+		storage:        store,
+		maxSize:        maxSize,
+		urlTTL:         urlTTL,
+		clock:          clock.OrDefault(clk),
+	}
+}
+
+// SetTransactions makes the command append and publish its event in a single transaction
+func (h *UserUploadAvatarCommandHandler) SetTransactions(transactions repositories.TransactionManager) {
+	h.transactions = transactions
+}
+
+// Handle handles the upload avatar command
+func (h *UserUploadAvatarCommandHandler) Handle(ctx context.Context, cmd dto.UploadAvatarCommand) (*dto.UploadAvatarCommandResponse, error) {
+	if cmd.UserID == "" {
+		return nil, errors.InvalidUserID(cmd.UserID)
+	}
+	if cmd.Content == nil {
+		return nil, errors.ValidationFailed("avatar", "file is required")
+	}
+
+	// Load the user's stream: the event is appended at the version it is at now
+	stream, err := h.users.Load(ctx, cmd.UserID)
+	if err != nil {
+		if errors.CodeOf(err, "") == errors.ErrNotFound {
+			return nil, errors.UserNotFound(cmd.UserID)
+		}
+		return nil, err
+	}
+	if stream.IsDeleted() {
+		return nil, errors.UserDeleted(cmd.UserID)
+	}
+
+	// Detect the content type from the first bytes, client supplied types are not trusted
+	content := bufio.NewReaderSize(cmd.Content, 512)
+	head, err := content.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, errors.ValidationFailed("avatar", "failed to read file")
+	}
+	contentType := http.DetectContentType(head)
+	extension, ok := allowedAvatarTypes[contentType]
+	if !ok {
+		return nil, errors.ValidationFailed("avatar", fmt.Sprintf("unsupported content type %s", contentType))
+	}
+
+	// Store under a new key so cached URLs of the previous avatar stay valid until they expire
+	uploadedAt := h.clock.Now().UTC()
+	key := fmt.Sprintf("avatars/%s/%d.%s", cmd.UserID, uploadedAt.UnixNano(), extension)
+
+	object, err := storage.UploadLimited(ctx, h.storage, key, content, h.maxSize, contentType)
+	if err == storage.ErrTooLarge {
+		return nil, errors.ValidationFailed("avatar", fmt.Sprintf("file exceeds %d bytes", h.maxSize))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "Failed to store avatar")
+	}
+
+	previous := stream.Avatar
+	event, err := stream.ChangeAvatar(entities.AvatarMetadata{
+		Key:         object.Key,
+		ContentType: contentType,
+		Size:        object.Size,
+		UploadedAt:  uploadedAt,
+	})
+	if err != nil {
+		_ = h.storage.Delete(ctx, key)
+		return nil, err
+	}
+	err = inTransaction(ctx, h.transactions, UploadAvatarCommandName, func(ctx context.Context) error {
+		// Fails when another command appended to the user's stream since it was loaded
+		if err := h.users.Save(ctx, stream); err != nil {
+			return err
+		}
+		return h.eventPublisher.PublishEvent(ctx, event)
+	})
+	if err != nil {
+		_ = h.storage.Delete(ctx, key)
+		return nil, err
+	}
+
+	// The previous avatar is no longer referenced
+	if previous != nil && previous.Key != key {
+		_ = h.storage.Delete(ctx, previous.Key)
+	}
+
+	url, err := h.storage.SignedURL(ctx, key, http.MethodGet, h.urlTTL)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "Failed to sign avatar URL")
+	}
+
+	return &dto.UploadAvatarCommandResponse{
+		UserID:      cmd.UserID,
+		Key:         key,
+		URL:         url,
+		ContentType: contentType,
+		Size:        object.Size,
+		SizeDisplay: dto.DisplayInteger(ctx, object.Size),
+		UploadedAt:  dto.FormatTimestamp(ctx, uploadedAt),
+	}, nil
+}
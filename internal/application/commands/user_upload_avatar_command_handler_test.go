@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testPNG = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+
+func TestUserUploadAvatarCommandHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       []byte
+		existingUser  bool
+		expectedError bool
+	}{
+		{
+			name:          "successful avatar upload",
+			content:       testPNG,
+			existingUser:  true,
+			expectedError: false,
+		},
+		{
+			name:          "user not found",
+			content:       testPNG,
+			existingUser:  false,
+			expectedError: true,
+		},
+		{
+			name:          "unsupported content type",
+			content:       []byte("<html><body>not an image</body></html>"),
+			existingUser:  true,
+			expectedError: true,
+		},
+		{
+			name:          "file too large",
+			content:       append(append([]byte(nil), testPNG...), make([]byte, 2048)...),
+			existingUser:  true,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := entities.NewUser("test@example.com", "John Doe")
+			require.NoError(t, err)
+			eventStore := newMemoryEventStore()
+			if tt.existingUser {
+				eventStore.create(t, user)
+			}
+
+			eventPublisher := mocks.NewMockEventPublisher(t)
+			if !tt.expectedError {
+				eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.AnythingOfType("*events.Event")).Return(nil)
+			}
+
+			store, err := storage.NewLocalStorage(t.TempDir(), "/files", "secret")
+			require.NoError(t, err)
+
+			handler := NewUserUploadAvatarCommandHandler(repositories.NewEventSourcedUserRepository(eventStore), eventPublisher, store, 1024, time.Minute, nil)
+
+			result, err := handler.Handle(context.Background(), dto.UploadAvatarCommand{
+				UserID:  user.GetID(),
+				Content: bytes.NewReader(tt.content),
+			})
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				if tt.existingUser {
+					assert.Equal(t, []string{"user.created"}, eventStore.types(user.GetID()))
+				}
+			} else {
+				assert.NoError(t, err)
+				require.NotNil(t, result)
+				assert.Equal(t, "image/png", result.ContentType)
+				assert.True(t, strings.HasPrefix(result.URL, "/files/avatars/"+user.GetID()+"/"))
+
+				_, err := store.Stat(context.Background(), result.Key)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserUploadAvatarCommandHandler_RecordsAvatarUpdatedEvents(t *testing.T) {
+	user, err := entities.NewUser("test@example.com", "John Doe")
+	require.NoError(t, err)
+	eventStore := newMemoryEventStore()
+	eventStore.create(t, user)
+
+	var published []*events.Event
+	eventPublisher := mocks.NewMockEventPublisher(t)
+	eventPublisher.EXPECT().PublishEvent(mock.Anything, mock.AnythingOfType("*events.Event")).RunAndReturn(func(ctx context.Context, event *events.Event) error {
+		published = append(published, event)
+		return nil
+	})
+
+	store, err := storage.NewLocalStorage(t.TempDir(), "/files", "secret")
+	require.NoError(t, err)
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	handler := NewUserUploadAvatarCommandHandler(repositories.NewEventSourcedUserRepository(eventStore), eventPublisher, store, 1024, time.Minute, clk)
+	first, err := handler.Handle(context.Background(), dto.UploadAvatarCommand{UserID: user.GetID(), Content: bytes.NewReader(testPNG)})
+	require.NoError(t, err)
+	clk.Advance(time.Hour)
+	second, err := handler.Handle(context.Background(), dto.UploadAvatarCommand{UserID: user.GetID(), Content: bytes.NewReader(testPNG)})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"user.created", "user.avatar_updated", "user.avatar_updated"}, eventStore.types(user.GetID()))
+	require.Len(t, published, 2)
+	assert.Equal(t, []int{2, 3}, []int{published[0].Version, published[1].Version})
+
+	var data events.UserAvatarUpdatedEvent
+	require.NoError(t, json.Unmarshal(published[1].Data, &data))
+	assert.Equal(t, second.Key, data.Avatar.Key)
+	assert.True(t, start.Add(time.Hour).Equal(data.Avatar.UploadedAt), "the avatar is stamped with the time of the clock")
+	assert.Equal(t, fmt.Sprintf("avatars/%s/%d.png", user.GetID(), start.Add(time.Hour).UnixNano()), second.Key, "the avatar is keyed by the time of the clock")
+
+	// The replaced avatar is removed from storage
+	_, err = store.Stat(context.Background(), first.Key)
+	assert.Error(t, err)
+}
+
+func TestUserUploadAvatarCommandHandler_DeletedUser(t *testing.T) {
+	user, err := entities.NewUser("test@example.com", "John Doe")
+	require.NoError(t, err)
+	eventStore := newMemoryEventStore()
+	eventStore.create(t, user)
+	deleted, err := events.NewEvent("user.deleted", events.UserDeletedEvent{UserID: user.GetID(), DeletedAt: time.Now()}, 2)
+	require.NoError(t, err)
+	require.NoError(t, eventStore.SaveEvent(context.Background(), user.GetID(), deleted))
+
+	store, err := storage.NewLocalStorage(t.TempDir(), "/files", "secret")
+	require.NoError(t, err)
+
+	handler := NewUserUploadAvatarCommandHandler(repositories.NewEventSourcedUserRepository(eventStore), mocks.NewMockEventPublisher(t), store, 1024, time.Minute, nil)
+	_, err = handler.Handle(context.Background(), dto.UploadAvatarCommand{UserID: user.GetID(), Content: bytes.NewReader(testPNG)})
+	assert.Equal(t, errors.ErrUserDeleted, errors.CodeOf(err, ""))
+	assert.Equal(t, []string{"user.created", "user.deleted"}, eventStore.types(user.GetID()))
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "io"
+
 // ==================== COMMANDS ====================
 
 // CreateUserCommand represents a command to create a user
@@ -14,6 +16,8 @@ type CreateUserCommandResponse struct {
 	Email     string `json:"email"`
 	Name      string `json:"name"`
 	CreatedAt string `json:"created_at"`
+
+	ConsistencyToken string `json:"consistency_token,omitempty"` // Read your write by passing it to queries
 }
 
 // UpdateUserCommand represents a command to update a user
@@ -27,6 +31,8 @@ type UpdateUserCommandResponse struct {
 	UserID    string `json:"user_id"`
 	Name      string `json:"name"`
 	UpdatedAt string `json:"updated_at"`
+
+	ConsistencyToken string `json:"consistency_token,omitempty"` // Read your write by passing it to queries
 }
 
 // DeleteUserCommand represents a command to delete a user
@@ -39,6 +45,43 @@ type DeleteUserCommandResponse struct {
 	UserID    string `json:"user_id"`
 	DeletedAt string `json:"deleted_at"`
 	Success   bool   `json:"success"`
+
+	ConsistencyToken string `json:"consistency_token,omitempty"` // Read your write by passing it to queries
+}
+
+// UploadAvatarCommand represents a command to upload a user avatar
+type UploadAvatarCommand struct {
+	UserID  string    `json:"user_id" validate:"required"`
+	Content io.Reader `json:"-"`
+}
+
+// UploadAvatarCommandResponse represents the response of uploading a user avatar
+type UploadAvatarCommandResponse struct {
+	UserID      string `json:"user_id"`
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SizeDisplay string `json:"size_display,omitempty"` // Localized size, only when display values are requested
+	UploadedAt  string `json:"uploaded_at"`
+}
+
+// UpdateUserPreferencesCommand represents a command to change the preferences of a user; nil
+// settings are left as they are
+type UpdateUserPreferencesCommand struct {
+	UserID             string  `json:"user_id" validate:"required"`
+	Locale             *string `json:"locale,omitempty"`
+	Theme              *string `json:"theme,omitempty" validate:"omitempty,oneof=light dark system"`
+	EmailNotifications *bool   `json:"email_notifications,omitempty"`
+	PushNotifications  *bool   `json:"push_notifications,omitempty"`
+	NotificationDigest *string `json:"notification_digest,omitempty" validate:"omitempty,oneof=never daily weekly"`
+}
+
+// UpdateUserPreferencesCommandResponse represents the response of updating user preferences command
+type UpdateUserPreferencesCommandResponse struct {
+	UserPreferences
+
+	ConsistencyToken string `json:"consistency_token,omitempty"` // Read your write by passing it to queries
 }
 
 // ==================== AUTH COMMANDS ====================
@@ -47,37 +90,105 @@ type DeleteUserCommandResponse struct {
 type RegisterCommand struct {
 	Email    string `json:"email" validate:"required,email"`
 	Name     string `json:"name" validate:"required,min=2,max=100"`
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required,min=8" sensitive:"true"`
 }
 
 // RegisterResponse represents the response of register command
 type RegisterResponse struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
-	Token  string `json:"token"`
+	UserID       string `json:"user_id"`
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	Token        string `json:"token" sensitive:"log"`
+	RefreshToken string `json:"refresh_token,omitempty" sensitive:"log"` // Empty while refresh tokens are disabled
 }
 
 // LoginCommand represents a command to login a user
 type LoginCommand struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Password string `json:"password" validate:"required" sensitive:"true"`
+	ClientIP string `json:"-"` // Address of the client, set by the transport for login lockout
 }
 
 // LoginResponse represents the response of login command
 type LoginResponse struct {
-	UserID string   `json:"user_id"`
-	Email  string   `json:"email"`
-	Name   string   `json:"name"`
-	Roles  []string `json:"roles"`
-	Token  string   `json:"token"`
+	UserID       string   `json:"user_id"`
+	Email        string   `json:"email"`
+	Name         string   `json:"name"`
+	Roles        []string `json:"roles"`
+	Token        string   `json:"token" sensitive:"log"`
+	RefreshToken string   `json:"refresh_token,omitempty" sensitive:"log"` // Empty while refresh tokens are disabled
+}
+
+// RequestMagicLinkCommand represents a command to send a sign-in link to a user
+type RequestMagicLinkCommand struct {
+	Email    string `json:"email" validate:"required,email"`
+	DeviceID string `json:"device_id" sensitive:"log"` // Binds the link to the requesting device when enabled
+}
+
+// RequestMagicLinkResponse represents the response of request magic link command. It is the same
+// whether or not the email belongs to a user.
+type RequestMagicLinkResponse struct {
+	Message string `json:"message"`
+}
+
+// ConsumeMagicLinkCommand represents a command to sign in with a magic link
+type ConsumeMagicLinkCommand struct {
+	Token    string `json:"token" validate:"required" sensitive:"true"`
+	DeviceID string `json:"device_id" sensitive:"log"`
+}
+
+// ForgotPasswordCommand represents a command to send a password reset link to a user
+type ForgotPasswordCommand struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPasswordResponse represents the response of forgot password command. It is the same
+// whether or not the email belongs to a user.
+type ForgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// ResetPasswordCommand represents a command to set a new password with a password reset link
+type ResetPasswordCommand struct {
+	Token       string `json:"token" validate:"required" sensitive:"true"`
+	NewPassword string `json:"new_password" validate:"required,min=8" sensitive:"true"`
+}
+
+// ResetPasswordResponse represents the response of reset password command
+type ResetPasswordResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// RequestEmailVerificationCommand represents a command to send an email verification link to a
+// user again
+type RequestEmailVerificationCommand struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// RequestEmailVerificationResponse represents the response of request email verification command.
+// It is the same whether or not the email belongs to a user waiting for verification.
+type RequestEmailVerificationResponse struct {
+	Message string `json:"message"`
+}
+
+// VerifyEmailCommand represents a command to verify the email of a user with a verification link
+type VerifyEmailCommand struct {
+	Token string `json:"token" validate:"required" sensitive:"true"`
+}
+
+// VerifyEmailResponse represents the response of verify email command
+type VerifyEmailResponse struct {
+	UserID  string `json:"user_id"`
+	Email   string `json:"email"`
+	Message string `json:"message"`
 }
 
 // ChangePasswordCommand represents a command to change password
 type ChangePasswordCommand struct {
 	UserID          string `json:"user_id" validate:"required"`
-	CurrentPassword string `json:"current_password" validate:"required"`
-	NewPassword     string `json:"new_password" validate:"required,min=8"`
+	CurrentPassword string `json:"current_password" validate:"required" sensitive:"true"`
+	NewPassword     string `json:"new_password" validate:"required,min=8" sensitive:"true"`
 }
 
 // ChangePasswordResponse represents the response of change password command
@@ -91,9 +202,22 @@ type ValidateTokenResponse struct {
 	UserID string   `json:"user_id"`
 	Email  string   `json:"email"`
 	Roles  []string `json:"roles"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// RefreshTokenCommand represents a command to renew an access token with a refresh token
+type RefreshTokenCommand struct {
+	RefreshToken string `json:"refresh_token" validate:"required" sensitive:"true"`
 }
 
 // RefreshTokenResponse represents the response of refresh token command
 type RefreshTokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token" sensitive:"log"`
+	RefreshToken string `json:"refresh_token,omitempty" sensitive:"log"` // Replaces the refresh token of the command
+}
+
+// RevokeRefreshTokenCommand represents a command to sign out, revoking a refresh token and every
+// token rotated from the same sign in
+type RevokeRefreshTokenCommand struct {
+	RefreshToken string `json:"refresh_token" validate:"required" sensitive:"true"`
 }
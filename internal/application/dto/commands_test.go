@@ -0,0 +1,28 @@
+package dto_test
+
+import (
+	"testing"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/pkg/redact"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommands_SensitiveFieldsAreNotLogged(t *testing.T) {
+	data, err := redact.MarshalForLog(dto.LoginCommand{Email: "test@example.com", Password: "secret-password"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "test@example.com")
+	assert.NotContains(t, string(data), "secret-password")
+
+	data, err = redact.MarshalForLog(dto.LoginResponse{Token: "access-token"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "access-token")
+}
+
+func TestCommands_TokensAreReturnedInResponses(t *testing.T) {
+	data, err := redact.MarshalForResponse(dto.LoginResponse{Token: "access-token"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "access-token")
+}
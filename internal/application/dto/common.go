@@ -2,6 +2,8 @@ package dto
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -32,19 +34,62 @@ type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
 	Value   string `json:"value"`
+	Rule    string `json:"rule,omitempty"`  // Failed validation tag, e.g. "required" or "min"
+	Param   string `json:"param,omitempty"` // Parameter of the rule, e.g. "2" for min=2
+	Length  bool   `json:"-"`               // Whether Param bounds a length rather than a value
 }
 
-// ValidateRequest validates a request struct using validator
+// ValidationErrors is the error of a request failing validation, one entry per invalid field
+type ValidationErrors []ValidationError
+
+// Error joins the messages of the violations
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, violation := range e {
+		messages[i] = violation.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// validate checks requests, naming fields after their JSON name
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// ValidateRequest validates a request struct using validator. Invalid requests fail with
+// ValidationErrors listing the violated rule of each field.
 func ValidateRequest(req interface{}) error {
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		var validationErrors []string
-		for _, err := range err.(validator.ValidationErrors) {
-			validationErrors = append(validationErrors, err.Error())
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	violations := make(ValidationErrors, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		kind := fieldError.Kind()
+		violations[i] = ValidationError{
+			Field:   fieldError.Field(),
+			Message: fieldError.Error(),
+			Rule:    fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Length:  kind == reflect.String || kind == reflect.Slice || kind == reflect.Map,
 		}
-		return errors.New("validation failed: " + strings.Join(validationErrors, "; "))
 	}
-	return nil
+	return violations
 }
 
 // NewErrorResponse creates a new error response
@@ -164,3 +164,18 @@ func TestValidateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRequest_FieldViolations(t *testing.T) {
+	err := dto.ValidateRequest(dto.CreateUserCommand{Email: "not-an-email", Name: "A"})
+
+	var violations dto.ValidationErrors
+	assert.ErrorAs(t, err, &violations)
+	assert.Len(t, violations, 2)
+	assert.Equal(t, "email", violations[0].Field)
+	assert.Equal(t, "email", violations[0].Rule)
+	assert.Equal(t, "name", violations[1].Field)
+	assert.Equal(t, "min", violations[1].Rule)
+	assert.Equal(t, "2", violations[1].Param)
+	assert.True(t, violations[1].Length)
+	assert.Contains(t, err.Error(), "validation failed: ")
+}
@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/i18n"
+)
+
+// FormatTimestamp formats a response timestamp as RFC 3339, or as a localized display value
+// when the request asked for one
+func FormatTimestamp(ctx context.Context, t time.Time) string {
+	if formatter := i18n.FormatterFromContext(ctx); formatter != nil {
+		return formatter.FormatDateTime(t)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// DisplayInteger formats a response number for display, or returns an empty string when the
+// request did not ask for display values
+func DisplayInteger(ctx context.Context, value int64) string {
+	if formatter := i18n.FormatterFromContext(ctx); formatter != nil {
+		return formatter.FormatInteger(value)
+	}
+	return ""
+}
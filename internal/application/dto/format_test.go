@@ -0,0 +1,23 @@
+package dto_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/pkg/i18n"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTimestamp(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 14, 7, 0, 0, time.UTC)
+
+	assert.Equal(t, "2024-03-05T14:07:00Z", dto.FormatTimestamp(context.Background(), ts))
+	assert.Equal(t, "", dto.DisplayInteger(context.Background(), 2048))
+
+	ctx := i18n.WithFormatter(context.Background(), i18n.NewFormatter("vi", nil))
+	assert.Equal(t, "14:07 05/03/2024", dto.FormatTimestamp(ctx, ts))
+	assert.Equal(t, "2.048", dto.DisplayInteger(ctx, 2048))
+}
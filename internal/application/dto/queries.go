@@ -5,6 +5,9 @@ package dto
 // GetUserQuery represents a query to get a user by ID
 type GetUserQuery struct {
 	UserID string `json:"user_id" validate:"required"`
+
+	// ConsistencyToken returned by a command makes the query wait for the read model to catch up
+	ConsistencyToken string `json:"consistency_token,omitempty"`
 }
 
 // GetUserQueryResponse represents the response of getting a user query
@@ -14,6 +17,32 @@ type GetUserQueryResponse struct {
 	Name      string `json:"name"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
+
+	// Stale is set when the read store was unavailable and the user was served from StaleSource
+	Stale       bool   `json:"stale,omitempty"`
+	StaleSource string `json:"stale_source,omitempty"`
+}
+
+// GetUserPreferencesQuery represents a query to get the preferences of a user
+type GetUserPreferencesQuery struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+// UserPreferences represents the effective preferences of a user, the defaults for the settings
+// the user did not choose
+type UserPreferences struct {
+	UserID        string                  `json:"user_id"`
+	Locale        string                  `json:"locale"`
+	Theme         string                  `json:"theme"`
+	Notifications NotificationPreferences `json:"notifications"`
+	UpdatedAt     string                  `json:"updated_at,omitempty"` // Empty until the user changes a setting
+}
+
+// NotificationPreferences represents the effective notification settings of a user
+type NotificationPreferences struct {
+	Email  bool   `json:"email"`
+	Push   bool   `json:"push"`
+	Digest string `json:"digest"`
 }
 
 // ListUsersQuery represents a query to list users with pagination
@@ -36,6 +65,33 @@ type UserSummary struct {
 	Email     string `json:"email"`
 	Name      string `json:"name"`
 	CreatedAt string `json:"created_at"`
+
+	// Only set when listed from the user summary projection
+	Status      string `json:"status,omitempty"`
+	LastLoginAt string `json:"last_login_at,omitempty"`
+}
+
+// ListUserChangesQuery represents a query to list the user changes recorded after a cursor
+type ListUserChangesQuery struct {
+	Cursor string `json:"cursor"` // Cursor returned by the previous query, empty to read from the first change
+	Limit  int    `json:"limit"`  // Maximum number of changes returned, 0 for the default
+}
+
+// ListUserChangesQueryResponse represents the response of listing user changes query
+type ListUserChangesQueryResponse struct {
+	Changes    []UserChange `json:"changes"`
+	NextCursor string       `json:"next_cursor"` // Cursor of the next query, returned even when no change was read
+	HasMore    bool         `json:"has_more"`    // Whether more changes can be read right away
+}
+
+// UserChange represents an entry of the user change log
+type UserChange struct {
+	Sequence   int64  `json:"sequence"`
+	UserID     string `json:"user_id"`
+	Operation  string `json:"operation"`
+	Email      string `json:"email,omitempty"`
+	Name       string `json:"name,omitempty"`
+	OccurredAt string `json:"occurred_at"`
 }
 
 // GetUserByEmailQuery represents a query to get a user by email
@@ -1,6 +1,9 @@
 package dto
 
 import (
+	"context"
+	"time"
+
 	"go-clean-ddd-es-template/internal/domain/entities"
 )
 
@@ -69,3 +72,21 @@ type GetUserEventsRequest struct {
 type GetUserEventsResponse struct {
 	Events []interface{} `json:"events"`
 }
+
+// NewUserPreferences converts the effective preferences of a user to the response DTO
+func NewUserPreferences(ctx context.Context, userID string, preferences entities.Preferences, updatedAt time.Time) UserPreferences {
+	response := UserPreferences{
+		UserID: userID,
+		Locale: preferences.Locale,
+		Theme:  preferences.Theme,
+		Notifications: NotificationPreferences{
+			Email:  preferences.Notifications.Email,
+			Push:   preferences.Notifications.Push,
+			Digest: preferences.Notifications.Digest,
+		},
+	}
+	if !updatedAt.IsZero() {
+		response.UpdatedAt = FormatTimestamp(ctx, updatedAt)
+	}
+	return response
+}
@@ -0,0 +1,52 @@
+package policies
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go-clean-ddd-es-template/internal/application/commands"
+	"go-clean-ddd-es-template/pkg/rules"
+)
+
+// signupCommands are the commands adding a user
+var signupCommands = []string{commands.RegisterCommandName, commands.CreateUserCommandName}
+
+// ApprovalRequiredDomains requires approval for users signing up with an email of one of domains
+func ApprovalRequiredDomains(domains []string) rules.Rule {
+	normalized := make([]string, len(domains))
+	for i, domain := range domains {
+		normalized[i] = strings.ToLower(strings.TrimSpace(domain))
+	}
+
+	return rules.Rule{
+		Name:     "approval-required-domains",
+		Commands: signupCommands,
+		Effect:   rules.EffectRequireApproval,
+		Message:  fmt.Sprintf("sign ups from %s require approval", strings.Join(normalized, ", ")),
+		When: func(facts map[string]interface{}) (bool, error) {
+			domain, _ := facts["email_domain"].(string)
+			return slices.Contains(normalized, strings.ToLower(domain)), nil
+		},
+	}
+}
+
+// NewCommandPolicy creates the rule engine of the command pipeline from the rules defined in Go
+// and the rules of the optional rules file
+func NewCommandPolicy(approvalDomains []string, rulesFile string) (*rules.Engine, error) {
+	engine := rules.NewEngine()
+	if len(approvalDomains) > 0 {
+		engine.Add(ApprovalRequiredDomains(approvalDomains))
+	}
+
+	if rulesFile != "" {
+		fileRules, err := rules.LoadFile(rulesFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range fileRules {
+			engine.Add(rule)
+		}
+	}
+	return engine, nil
+}
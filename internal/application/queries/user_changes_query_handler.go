@@ -0,0 +1,116 @@
+package queries
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// changeCursorPrefix prefixes the sequence encoded in change cursors
+const changeCursorPrefix = "seq:"
+
+// UserChangesQueryHandler handles the list user changes query, the changefeed external systems
+// sync users from. Clients pass the cursor of each response to the next query.
+type UserChangesQueryHandler struct {
+	changeLogRepository repositories.UserChangeLogRepository
+	settleDelay         time.Duration
+	defaultLimit        int
+	maxLimit            int
+	clock               clock.Clock
+}
+
+// NewUserChangesQueryHandler creates a new user changes query handler. Changes recorded less than
+// settleDelay ago are not returned yet: sequences are taken before changes are stored, so a
+// recent change may still be missing a lower sequence being stored concurrently, which a cursor
+// past it would skip. A nil clock uses the system clock.
+func NewUserChangesQueryHandler(changeLogRepository repositories.UserChangeLogRepository, settleDelay time.Duration, defaultLimit, maxLimit int, clk clock.Clock) *UserChangesQueryHandler {
+	return &UserChangesQueryHandler{
+		changeLogRepository: changeLogRepository,
+		settleDelay:         settleDelay,
+		defaultLimit:        defaultLimit,
+		maxLimit:            maxLimit,
+		clock:               clock.OrDefault(clk),
+	}
+}
+
+// Handle handles the list user changes query
+func (h *UserChangesQueryHandler) Handle(ctx context.Context, query dto.ListUserChangesQuery) (*dto.ListUserChangesQueryResponse, error) {
+	after, err := parseChangeCursor(query.Cursor)
+	if err != nil {
+		return nil, errors.ValidationFailed("cursor", err.Error())
+	}
+
+	limit := query.Limit
+	switch {
+	case limit < 0:
+		return nil, errors.ValidationFailed("limit", "must not be negative")
+	case limit == 0:
+		limit = h.defaultLimit
+	case limit > h.maxLimit:
+		limit = h.maxLimit
+	}
+
+	// Read one more change to know whether more are ready
+	changes, err := h.changeLogRepository.ListChanges(ctx, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user changes: %w", err)
+	}
+
+	// Stop at the first change still settling, later sequences may have been stored before it
+	settled := h.clock.Now().Add(-h.settleDelay)
+	for i, change := range changes {
+		if !change.RecordedAt.Before(settled) {
+			changes = changes[:i]
+			break
+		}
+	}
+	hasMore := len(changes) > limit
+	changes = changes[:min(len(changes), limit)]
+
+	response := &dto.ListUserChangesQueryResponse{
+		Changes:    make([]dto.UserChange, len(changes)),
+		NextCursor: encodeChangeCursor(after),
+		HasMore:    hasMore,
+	}
+	for i, change := range changes {
+		response.Changes[i] = dto.UserChange{
+			Sequence:   change.Sequence,
+			UserID:     change.UserID,
+			Operation:  change.Operation,
+			Email:      change.Email,
+			Name:       change.Name,
+			OccurredAt: dto.FormatTimestamp(ctx, change.OccurredAt),
+		}
+		response.NextCursor = encodeChangeCursor(change.Sequence)
+	}
+	return response, nil
+}
+
+// encodeChangeCursor encodes the sequence of the last change read, clients treat cursors as opaque
+func encodeChangeCursor(sequence int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(changeCursorPrefix + strconv.FormatInt(sequence, 10)))
+}
+
+// parseChangeCursor decodes a cursor of encodeChangeCursor, an empty cursor reading from the start
+func parseChangeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(data), changeCursorPrefix) {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	sequence, err := strconv.ParseInt(strings.TrimPrefix(string(data), changeCursorPrefix), 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return sequence, nil
+}
@@ -0,0 +1,87 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubUserChangeLogRepository lists fixed changes
+type stubUserChangeLogRepository struct {
+	changes []*entities.UserChange
+}
+
+func (r *stubUserChangeLogRepository) AppendChange(ctx context.Context, change *entities.UserChange) error {
+	return nil
+}
+
+func (r *stubUserChangeLogRepository) ListChanges(ctx context.Context, after int64, limit int) ([]*entities.UserChange, error) {
+	var changes []*entities.UserChange
+	for _, change := range r.changes {
+		if change.Sequence > after && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func TestUserChangesQueryHandler_Handle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	change := func(sequence int64, recordedAgo time.Duration) *entities.UserChange {
+		return &entities.UserChange{
+			Sequence:   sequence,
+			UserID:     "user-123",
+			Operation:  entities.UserChangeUpdated,
+			Name:       "John Doe",
+			OccurredAt: now.Add(-time.Hour),
+			RecordedAt: now.Add(-recordedAgo),
+		}
+	}
+	repo := &stubUserChangeLogRepository{changes: []*entities.UserChange{
+		change(1, time.Minute), change(2, time.Minute), change(4, time.Minute),
+		// Still settling, the change after it must not be read past it
+		change(5, time.Second), change(6, time.Minute),
+	}}
+	handler := NewUserChangesQueryHandler(repo, 5*time.Second, 2, 3, clock.NewFake(now))
+
+	// Reads from the start with the default limit
+	response, err := handler.Handle(ctx, dto.ListUserChangesQuery{})
+	require.NoError(t, err)
+	require.Len(t, response.Changes, 2)
+	assert.Equal(t, int64(1), response.Changes[0].Sequence)
+	assert.Equal(t, "2024-01-01T11:00:00Z", response.Changes[0].OccurredAt)
+	assert.True(t, response.HasMore)
+
+	// Resumes after the cursor, stopping before the settling change
+	response, err = handler.Handle(ctx, dto.ListUserChangesQuery{Cursor: response.NextCursor, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, response.Changes, 1)
+	assert.Equal(t, int64(4), response.Changes[0].Sequence)
+	assert.False(t, response.HasMore)
+
+	// Without new changes the cursor is returned as is
+	cursor := response.NextCursor
+	response, err = handler.Handle(ctx, dto.ListUserChangesQuery{Cursor: cursor})
+	require.NoError(t, err)
+	assert.Empty(t, response.Changes)
+	assert.Equal(t, cursor, response.NextCursor)
+}
+
+func TestUserChangesQueryHandler_InvalidQuery(t *testing.T) {
+	handler := NewUserChangesQueryHandler(&stubUserChangeLogRepository{}, 0, 10, 100, nil)
+
+	_, err := handler.Handle(context.Background(), dto.ListUserChangesQuery{Cursor: "not a cursor"})
+	assert.Equal(t, errors.ErrValidationFailed, errors.CodeOf(err, errors.ErrInternalServer))
+
+	_, err = handler.Handle(context.Background(), dto.ListUserChangesQuery{Limit: -1})
+	assert.Equal(t, errors.ErrValidationFailed, errors.CodeOf(err, errors.ErrInternalServer))
+}
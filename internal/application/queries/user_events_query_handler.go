@@ -42,7 +42,7 @@ func (h *UserEventsQueryHandler) Handle(ctx context.Context, query dto.GetUserEv
 		eventRecords[i] = dto.EventRecord{
 			EventType: event.EventType,
 			Data:      string(eventDataJSON),
-			Timestamp: event.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+			Timestamp: dto.FormatTimestamp(ctx, event.Timestamp),
 			Version:   event.Version,
 		}
 	}
@@ -34,8 +34,8 @@ func (h *UserGetByEmailQueryHandler) Handle(ctx context.Context, query dto.GetUs
 		UserID:    user.UserID,
 		Email:     user.Email,
 		Name:      user.Name,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedAt: dto.FormatTimestamp(ctx, user.CreatedAt),
+		UpdatedAt: dto.FormatTimestamp(ctx, user.UpdatedAt),
 	}
 
 	return response, nil
@@ -0,0 +1,50 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// UserGetPreferencesQueryHandler handles the get user preferences query (read operation).
+// The settings users chose are read from the preferences document of the user read model and
+// merged over the defaults.
+type UserGetPreferencesQueryHandler struct {
+	userReadRepository repositories.UserReadRepository
+	defaults           entities.Preferences
+}
+
+// NewUserGetPreferencesQueryHandler creates a new user get preferences query handler, responding
+// with defaults for the settings users did not choose
+func NewUserGetPreferencesQueryHandler(userReadRepository repositories.UserReadRepository, defaults entities.Preferences) *UserGetPreferencesQueryHandler {
+	return &UserGetPreferencesQueryHandler{
+		userReadRepository: userReadRepository,
+		defaults:           defaults,
+	}
+}
+
+// Handle handles the get user preferences query
+func (h *UserGetPreferencesQueryHandler) Handle(ctx context.Context, query dto.GetUserPreferencesQuery) (*dto.UserPreferences, error) {
+	user, err := h.userReadRepository.GetUserByID(ctx, query.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user preferences: %w", err)
+	}
+	if user.DeletedAt != nil {
+		return nil, errors.UserDeleted(query.UserID)
+	}
+
+	var settings entities.PreferenceSettings
+	var updatedAt time.Time
+	if user.Preferences != nil {
+		settings = user.Preferences.PreferenceSettings
+		updatedAt = user.Preferences.UpdatedAt
+	}
+
+	response := dto.NewUserPreferences(ctx, user.UserID, settings.Resolve(h.defaults), updatedAt)
+	return &response, nil
+}
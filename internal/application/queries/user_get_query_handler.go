@@ -2,16 +2,43 @@ package queries
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"time"
 
 	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
 	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/consistency"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// Fallbacks of reads while the read store circuit breaker is open
+const (
+	ReadFallbackNone    = ""         // Fail the read
+	ReadFallbackWriteDB = "write_db" // Read the write database
+	ReadFallbackCache   = "cache"    // Serve the last successful read of the user
 )
 
 // UserGetQueryHandler handles the get user query (read operation)
 // Uses MongoDB read repository for optimized read performance
 type UserGetQueryHandler struct {
 	userReadRepository repositories.UserReadRepository
+
+	// Read your writes, see SetReadYourWrites
+	userWriteRepository repositories.UserWriteRepository
+	maxWait             time.Duration
+	pollInterval        time.Duration
+	clock               clock.Clock
+
+	// Graceful degradation, see SetDegradation
+	fallback           string
+	fallbackRepository repositories.UserWriteRepository
+	lastReads          cache.Cache
+	lastReadTTL        time.Duration
 }
 
 // NewUserGetQueryHandler creates a new user get query handler
@@ -21,22 +48,137 @@ func NewUserGetQueryHandler(userReadRepository repositories.UserReadRepository)
 	}
 }
 
+// SetReadYourWrites makes queries carrying a consistency token wait up to maxWait, polling every
+// pollInterval, for the read model to reach the version of the token, and read the user from the
+// write database when it does not. A nil clock uses the system clock.
+func (h *UserGetQueryHandler) SetReadYourWrites(userWriteRepository repositories.UserWriteRepository, maxWait, pollInterval time.Duration, clk clock.Clock) {
+	h.userWriteRepository = userWriteRepository
+	h.maxWait = maxWait
+	h.pollInterval = pollInterval
+	h.clock = clock.OrDefault(clk)
+}
+
+// SetDegradation serves reads failing because the read store circuit breaker is open from a
+// fallback instead of failing them: ReadFallbackWriteDB reads userWriteRepository, ReadFallbackCache
+// serves the last successful read of the user, kept in lastReads for ttl. Degraded responses are
+// flagged stale.
+func (h *UserGetQueryHandler) SetDegradation(fallback string, userWriteRepository repositories.UserWriteRepository, lastReads cache.Cache, ttl time.Duration) {
+	h.fallback = fallback
+	h.fallbackRepository = userWriteRepository
+	h.lastReads = lastReads
+	h.lastReadTTL = ttl
+}
+
 // Handle handles the get user query
 func (h *UserGetQueryHandler) Handle(ctx context.Context, query dto.GetUserQuery) (*dto.GetUserQueryResponse, error) {
+	if query.ConsistencyToken != "" && h.userWriteRepository != nil {
+		return h.handleConsistent(ctx, query)
+	}
+
 	// Get user from MongoDB read model (optimized for queries)
 	user, err := h.userReadRepository.GetUserByID(ctx, query.UserID)
 	if err != nil {
+		if stderrors.Is(err, resilience.ErrCircuitOpen) && h.fallback != ReadFallbackNone {
+			return h.handleDegraded(ctx, query, err)
+		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Convert to response DTO
-	response := &dto.GetUserQueryResponse{
+	if h.fallback == ReadFallbackCache && h.lastReads != nil {
+		h.lastReads.Set(lastReadKey(user.UserID), user, h.lastReadTTL)
+	}
+	return readModelResponse(ctx, user), nil
+}
+
+// handleDegraded serves a read from the configured fallback while the read store is unavailable
+func (h *UserGetQueryHandler) handleDegraded(ctx context.Context, query dto.GetUserQuery, readErr error) (*dto.GetUserQueryResponse, error) {
+	var response *dto.GetUserQueryResponse
+	switch h.fallback {
+	case ReadFallbackWriteDB:
+		if h.fallbackRepository == nil {
+			return nil, fmt.Errorf("failed to get user: %w", readErr)
+		}
+		user, err := h.fallbackRepository.GetByID(ctx, query.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		response = writeModelResponse(ctx, user)
+	case ReadFallbackCache:
+		if h.lastReads == nil {
+			return nil, fmt.Errorf("failed to get user: %w", readErr)
+		}
+		cached, ok := h.lastReads.Get(lastReadKey(query.UserID))
+		if !ok {
+			return nil, fmt.Errorf("failed to get user: %w", readErr)
+		}
+		response = readModelResponse(ctx, cached.(*entities.UserReadModel))
+	default:
+		return nil, fmt.Errorf("failed to get user: %w", readErr)
+	}
+
+	response.Stale = true
+	response.StaleSource = h.fallback
+	return response, nil
+}
+
+// lastReadKey is the key of a user's last successful read
+func lastReadKey(userID string) string {
+	return "user:" + userID
+}
+
+// handleConsistent reads the user at the version of the query's consistency token or later
+func (h *UserGetQueryHandler) handleConsistent(ctx context.Context, query dto.GetUserQuery) (*dto.GetUserQueryResponse, error) {
+	token, err := consistency.Parse(query.ConsistencyToken)
+	if err != nil {
+		return nil, errors.ValidationFailed("consistency_token", err.Error())
+	}
+	if token.AggregateID != query.UserID {
+		return nil, errors.ValidationFailed("consistency_token", "token belongs to another user")
+	}
+
+	// The projection may not have the user yet, so read errors only mean it has not caught up
+	var user *entities.UserReadModel
+	reached, err := consistency.Wait(ctx, h.clock, h.maxWait, h.pollInterval, func(ctx context.Context) (bool, error) {
+		current, readErr := h.userReadRepository.GetUserByID(ctx, query.UserID)
+		if readErr != nil {
+			return false, nil
+		}
+		user = current
+		return current.Version >= token.Version, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if reached {
+		return readModelResponse(ctx, user), nil
+	}
+
+	// Serve the write side, which is consistent with every successful command
+	written, err := h.userWriteRepository.GetByID(ctx, query.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return writeModelResponse(ctx, written), nil
+}
+
+// writeModelResponse converts a user of the write database to the response DTO
+func writeModelResponse(ctx context.Context, user *entities.User) *dto.GetUserQueryResponse {
+	return &dto.GetUserQueryResponse{
+		UserID:    user.GetID(),
+		Email:     user.GetEmail(),
+		Name:      user.GetName(),
+		CreatedAt: dto.FormatTimestamp(ctx, user.CreatedAt),
+		UpdatedAt: dto.FormatTimestamp(ctx, user.UpdatedAt),
+	}
+}
+
+// readModelResponse converts a read model to the response DTO
+func readModelResponse(ctx context.Context, user *entities.UserReadModel) *dto.GetUserQueryResponse {
+	return &dto.GetUserQueryResponse{
 		UserID:    user.UserID,
 		Email:     user.Email,
 		Name:      user.Name,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedAt: dto.FormatTimestamp(ctx, user.CreatedAt),
+		UpdatedAt: dto.FormatTimestamp(ctx, user.UpdatedAt),
 	}
-
-	return response, nil
 }
@@ -2,12 +2,16 @@ package queries
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"go-clean-ddd-es-template/internal/application/dto"
 	"go-clean-ddd-es-template/internal/domain/entities"
 	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/consistency"
+	"go-clean-ddd-es-template/pkg/resilience"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -89,3 +93,111 @@ func TestUserGetQueryHandler_Handle(t *testing.T) {
 		})
 	}
 }
+
+func TestUserGetQueryHandler_ReadYourWrites(t *testing.T) {
+	token := consistency.Token{AggregateID: "user-123", Version: 2}.String()
+	readModel := func(version int) *entities.UserReadModel {
+		return &entities.UserReadModel{UserID: "user-123", Email: "test@example.com", Name: "Old Name", Version: version}
+	}
+
+	t.Run("waits for the projection to catch up", func(t *testing.T) {
+		readRepo := mocks.NewMockUserReadRepository(t)
+		writeRepo := mocks.NewMockUserWriteRepository(t)
+		readRepo.EXPECT().GetUserByID(mock.Anything, "user-123").Return(readModel(1), nil).Once()
+		readRepo.EXPECT().GetUserByID(mock.Anything, "user-123").Return(&entities.UserReadModel{UserID: "user-123", Name: "New Name", Version: 2}, nil).Once()
+
+		handler := NewUserGetQueryHandler(readRepo)
+		handler.SetReadYourWrites(writeRepo, time.Second, time.Millisecond, nil)
+
+		result, err := handler.Handle(context.Background(), dto.GetUserQuery{UserID: "user-123", ConsistencyToken: token})
+		assert.NoError(t, err)
+		assert.Equal(t, "New Name", result.Name)
+	})
+
+	t.Run("reads the write side when the projection lags", func(t *testing.T) {
+		readRepo := mocks.NewMockUserReadRepository(t)
+		writeRepo := mocks.NewMockUserWriteRepository(t)
+		readRepo.EXPECT().GetUserByID(mock.Anything, "user-123").Return(readModel(1), nil)
+		user, err := entities.NewUser("test@example.com", "New Name")
+		assert.NoError(t, err)
+		writeRepo.EXPECT().GetByID(mock.Anything, "user-123").Return(user, nil)
+
+		handler := NewUserGetQueryHandler(readRepo)
+		handler.SetReadYourWrites(writeRepo, 0, time.Millisecond, nil)
+
+		result, err := handler.Handle(context.Background(), dto.GetUserQuery{UserID: "user-123", ConsistencyToken: token})
+		assert.NoError(t, err)
+		assert.Equal(t, "New Name", result.Name)
+	})
+
+	t.Run("rejects a token of another user", func(t *testing.T) {
+		handler := NewUserGetQueryHandler(mocks.NewMockUserReadRepository(t))
+		handler.SetReadYourWrites(mocks.NewMockUserWriteRepository(t), time.Second, time.Millisecond, nil)
+
+		_, err := handler.Handle(context.Background(), dto.GetUserQuery{UserID: "user-456", ConsistencyToken: token})
+		assert.Error(t, err)
+	})
+}
+
+func TestUserGetQueryHandler_Degradation(t *testing.T) {
+	circuitOpen := fmt.Errorf("circuit breaker is OPEN: %w", resilience.ErrCircuitOpen)
+
+	t.Run("reads the write database while the read store is down", func(t *testing.T) {
+		readRepo := mocks.NewMockUserReadRepository(t)
+		writeRepo := mocks.NewMockUserWriteRepository(t)
+		readRepo.EXPECT().GetUserByID(mock.Anything, "user-123").Return(nil, circuitOpen)
+		user, err := entities.NewUser("test@example.com", "John Doe")
+		assert.NoError(t, err)
+		writeRepo.EXPECT().GetByID(mock.Anything, "user-123").Return(user, nil)
+
+		handler := NewUserGetQueryHandler(readRepo)
+		handler.SetDegradation(ReadFallbackWriteDB, writeRepo, nil, 0)
+
+		result, err := handler.Handle(context.Background(), dto.GetUserQuery{UserID: "user-123"})
+		assert.NoError(t, err)
+		assert.Equal(t, "John Doe", result.Name)
+		assert.True(t, result.Stale)
+		assert.Equal(t, ReadFallbackWriteDB, result.StaleSource)
+	})
+
+	t.Run("serves the last read while the read store is down", func(t *testing.T) {
+		readRepo := mocks.NewMockUserReadRepository(t)
+		readRepo.EXPECT().GetUserByID(mock.Anything, "user-123").Return(&entities.UserReadModel{UserID: "user-123", Name: "John Doe"}, nil).Once()
+		readRepo.EXPECT().GetUserByID(mock.Anything, "user-123").Return(nil, circuitOpen).Once()
+
+		handler := NewUserGetQueryHandler(readRepo)
+		handler.SetDegradation(ReadFallbackCache, nil, cache.NewMemoryCache(), time.Minute)
+
+		fresh, err := handler.Handle(context.Background(), dto.GetUserQuery{UserID: "user-123"})
+		assert.NoError(t, err)
+		assert.False(t, fresh.Stale)
+
+		result, err := handler.Handle(context.Background(), dto.GetUserQuery{UserID: "user-123"})
+		assert.NoError(t, err)
+		assert.Equal(t, "John Doe", result.Name)
+		assert.True(t, result.Stale)
+		assert.Equal(t, ReadFallbackCache, result.StaleSource)
+	})
+
+	t.Run("fails without a cached read", func(t *testing.T) {
+		readRepo := mocks.NewMockUserReadRepository(t)
+		readRepo.EXPECT().GetUserByID(mock.Anything, "user-123").Return(nil, circuitOpen)
+
+		handler := NewUserGetQueryHandler(readRepo)
+		handler.SetDegradation(ReadFallbackCache, nil, cache.NewMemoryCache(), time.Minute)
+
+		_, err := handler.Handle(context.Background(), dto.GetUserQuery{UserID: "user-123"})
+		assert.ErrorIs(t, err, resilience.ErrCircuitOpen)
+	})
+
+	t.Run("does not degrade other read errors", func(t *testing.T) {
+		readRepo := mocks.NewMockUserReadRepository(t)
+		readRepo.EXPECT().GetUserByID(mock.Anything, "user-123").Return(nil, assert.AnError)
+
+		handler := NewUserGetQueryHandler(readRepo)
+		handler.SetDegradation(ReadFallbackWriteDB, mocks.NewMockUserWriteRepository(t), nil, 0)
+
+		_, err := handler.Handle(context.Background(), dto.GetUserQuery{UserID: "user-123"})
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
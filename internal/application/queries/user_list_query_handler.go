@@ -11,7 +11,8 @@ import (
 // UserListQueryHandler handles the list users query (read operation)
 // Uses MongoDB read repository for optimized read performance
 type UserListQueryHandler struct {
-	userReadRepository repositories.UserReadRepository
+	userReadRepository    repositories.UserReadRepository
+	userSummaryRepository repositories.UserSummaryRepository
 }
 
 // NewUserListQueryHandler creates a new user list query handler
@@ -21,8 +22,17 @@ func NewUserListQueryHandler(userReadRepository repositories.UserReadRepository)
 	}
 }
 
+// SetSummaries lists users from the compact user summary projection instead of full read models
+func (h *UserListQueryHandler) SetSummaries(userSummaryRepository repositories.UserSummaryRepository) {
+	h.userSummaryRepository = userSummaryRepository
+}
+
 // Handle handles the list users query
 func (h *UserListQueryHandler) Handle(ctx context.Context, query dto.ListUsersQuery) (*dto.ListUsersQueryResponse, error) {
+	if h.userSummaryRepository != nil {
+		return h.handleSummaries(ctx, query)
+	}
+
 	// Get users from MongoDB read model (optimized for queries)
 	users, total, err := h.userReadRepository.ListUsers(ctx, query.Page, query.PageSize)
 	if err != nil {
@@ -36,7 +46,7 @@ func (h *UserListQueryHandler) Handle(ctx context.Context, query dto.ListUsersQu
 			UserID:    user.UserID,
 			Email:     user.Email,
 			Name:      user.Name,
-			CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			CreatedAt: dto.FormatTimestamp(ctx, user.CreatedAt),
 		}
 	}
 
@@ -49,3 +59,32 @@ func (h *UserListQueryHandler) Handle(ctx context.Context, query dto.ListUsersQu
 
 	return response, nil
 }
+
+// handleSummaries lists users from the user summary projection
+func (h *UserListQueryHandler) handleSummaries(ctx context.Context, query dto.ListUsersQuery) (*dto.ListUsersQueryResponse, error) {
+	summaries, total, err := h.userSummaryRepository.ListSummaries(ctx, query.Page, query.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	userSummaries := make([]dto.UserSummary, len(summaries))
+	for i, summary := range summaries {
+		userSummaries[i] = dto.UserSummary{
+			UserID:    summary.UserID,
+			Email:     summary.Email,
+			Name:      summary.Name,
+			CreatedAt: dto.FormatTimestamp(ctx, summary.CreatedAt),
+			Status:    summary.Status,
+		}
+		if summary.LastLoginAt != nil {
+			userSummaries[i].LastLoginAt = dto.FormatTimestamp(ctx, *summary.LastLoginAt)
+		}
+	}
+
+	return &dto.ListUsersQueryResponse{
+		Users:    userSummaries,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
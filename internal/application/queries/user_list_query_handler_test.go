@@ -0,0 +1,63 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubUserSummaryRepository returns fixed summaries
+type stubUserSummaryRepository struct {
+	summaries []*entities.UserSummary
+}
+
+func (r *stubUserSummaryRepository) SaveSummary(ctx context.Context, summary *entities.UserSummary) error {
+	return nil
+}
+
+func (r *stubUserSummaryRepository) UpdateName(ctx context.Context, userID, name string) error {
+	return nil
+}
+
+func (r *stubUserSummaryRepository) MarkDeleted(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (r *stubUserSummaryRepository) RecordLogin(ctx context.Context, userID string, at time.Time) error {
+	return nil
+}
+
+func (r *stubUserSummaryRepository) ListSummaries(ctx context.Context, page, pageSize int) ([]*entities.UserSummary, int64, error) {
+	return r.summaries, int64(len(r.summaries)), nil
+}
+
+func TestUserListQueryHandler_Summaries(t *testing.T) {
+	lastLogin := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	summaries := &stubUserSummaryRepository{summaries: []*entities.UserSummary{{
+		UserID:      "user-123",
+		Email:       "test@example.com",
+		Name:        "John Doe",
+		Status:      entities.UserStatusActive,
+		CreatedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		LastLoginAt: &lastLogin,
+	}}}
+
+	// The full read model is not read when summaries are set
+	handler := NewUserListQueryHandler(mocks.NewMockUserReadRepository(t))
+	handler.SetSummaries(summaries)
+
+	result, err := handler.Handle(context.Background(), dto.ListUsersQuery{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	require.Len(t, result.Users, 1)
+	assert.Equal(t, "John Doe", result.Users[0].Name)
+	assert.Equal(t, entities.UserStatusActive, result.Users[0].Status)
+	assert.NotEmpty(t, result.Users[0].LastLoginAt)
+}
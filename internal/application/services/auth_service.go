@@ -7,6 +7,7 @@ import (
 	"go-clean-ddd-es-template/internal/application/commands"
 	"go-clean-ddd-es-template/internal/application/dto"
 	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/errors"
 )
 
 // AuthService handles authentication and authorization
@@ -14,6 +15,12 @@ type AuthService struct {
 	registerHandler *commands.AuthRegisterCommandHandler
 	loginHandler    *commands.AuthLoginCommandHandler
 	jwtService      *auth.JWTService
+
+	magicLinkHandler     *commands.AuthMagicLinkCommandHandler     // Nil when magic links are disabled
+	refreshTokenHandler  *commands.AuthRefreshTokenCommandHandler  // Nil when refresh tokens are disabled
+	passwordResetHandler *commands.AuthPasswordResetCommandHandler // Nil when password resets are disabled
+
+	emailVerificationHandler *commands.AuthEmailVerificationCommandHandler // Nil when email verification is disabled
 }
 
 // NewAuthService creates a new auth service
@@ -29,14 +36,121 @@ func NewAuthService(
 	}
 }
 
+// SetMagicLinkHandler enables password-less sign in with magic links
+func (s *AuthService) SetMagicLinkHandler(handler *commands.AuthMagicLinkCommandHandler) {
+	s.magicLinkHandler = handler
+}
+
+// SetRefreshTokenHandler enables refresh tokens, issued on sign in and rotated on refresh
+func (s *AuthService) SetRefreshTokenHandler(handler *commands.AuthRefreshTokenCommandHandler) {
+	s.refreshTokenHandler = handler
+}
+
+// SetPasswordResetHandler enables resetting forgotten passwords with links sent by email
+func (s *AuthService) SetPasswordResetHandler(handler *commands.AuthPasswordResetCommandHandler) {
+	s.passwordResetHandler = handler
+}
+
+// SetEmailVerificationHandler enables email verification: registered users are sent a link
+// verifying their email
+func (s *AuthService) SetEmailVerificationHandler(handler *commands.AuthEmailVerificationCommandHandler) {
+	s.emailVerificationHandler = handler
+}
+
 // Register registers a new user
 func (s *AuthService) Register(ctx context.Context, req dto.RegisterCommand) (*dto.RegisterResponse, error) {
-	return s.registerHandler.Handle(ctx, req)
+	resp, err := s.registerHandler.Handle(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// The user is registered, those the link did not reach request it again
+	if s.emailVerificationHandler != nil {
+		_ = s.emailVerificationHandler.Send(ctx, resp.UserID, resp.Email, resp.Name)
+	}
+	if resp.RefreshToken, err = s.issueRefreshToken(ctx, resp.UserID, resp.Email); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 // Login logs in a user
 func (s *AuthService) Login(ctx context.Context, req dto.LoginCommand) (*dto.LoginResponse, error) {
-	return s.loginHandler.Handle(ctx, req)
+	resp, err := s.loginHandler.Handle(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.withRefreshToken(ctx, resp)
+}
+
+// RequestMagicLink sends a single-use sign-in link to a user
+func (s *AuthService) RequestMagicLink(ctx context.Context, req dto.RequestMagicLinkCommand) (*dto.RequestMagicLinkResponse, error) {
+	if s.magicLinkHandler == nil {
+		return nil, errors.New(errors.ErrNotFound, "magic link sign in is not enabled")
+	}
+	return s.magicLinkHandler.HandleRequest(ctx, req)
+}
+
+// ConsumeMagicLink signs in a user with a magic link
+func (s *AuthService) ConsumeMagicLink(ctx context.Context, req dto.ConsumeMagicLinkCommand) (*dto.LoginResponse, error) {
+	if s.magicLinkHandler == nil {
+		return nil, errors.New(errors.ErrNotFound, "magic link sign in is not enabled")
+	}
+	resp, err := s.magicLinkHandler.HandleConsume(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.withRefreshToken(ctx, resp)
+}
+
+// ForgotPassword sends a password reset link to a user
+func (s *AuthService) ForgotPassword(ctx context.Context, req dto.ForgotPasswordCommand) (*dto.ForgotPasswordResponse, error) {
+	if s.passwordResetHandler == nil {
+		return nil, errors.New(errors.ErrNotFound, "password reset is not enabled")
+	}
+	return s.passwordResetHandler.HandleForgot(ctx, req)
+}
+
+// ResetPassword sets a new password with a password reset link
+func (s *AuthService) ResetPassword(ctx context.Context, req dto.ResetPasswordCommand) (*dto.ResetPasswordResponse, error) {
+	if s.passwordResetHandler == nil {
+		return nil, errors.New(errors.ErrNotFound, "password reset is not enabled")
+	}
+	return s.passwordResetHandler.HandleReset(ctx, req)
+}
+
+// RequestEmailVerification sends an email verification link to a user again
+func (s *AuthService) RequestEmailVerification(ctx context.Context, req dto.RequestEmailVerificationCommand) (*dto.RequestEmailVerificationResponse, error) {
+	if s.emailVerificationHandler == nil {
+		return nil, errors.New(errors.ErrNotFound, "email verification is not enabled")
+	}
+	return s.emailVerificationHandler.HandleRequest(ctx, req)
+}
+
+// VerifyEmail verifies the email of a user with an email verification link
+func (s *AuthService) VerifyEmail(ctx context.Context, req dto.VerifyEmailCommand) (*dto.VerifyEmailResponse, error) {
+	if s.emailVerificationHandler == nil {
+		return nil, errors.New(errors.ErrNotFound, "email verification is not enabled")
+	}
+	return s.emailVerificationHandler.HandleVerify(ctx, req)
+}
+
+// withRefreshToken attaches a refresh token to the response of a sign in
+func (s *AuthService) withRefreshToken(ctx context.Context, resp *dto.LoginResponse) (*dto.LoginResponse, error) {
+	refreshToken, err := s.issueRefreshToken(ctx, resp.UserID, resp.Email)
+	if err != nil {
+		return nil, err
+	}
+	resp.RefreshToken = refreshToken
+	return resp, nil
+}
+
+// issueRefreshToken issues the refresh token of a user signing in, none while refresh tokens are
+// disabled
+func (s *AuthService) issueRefreshToken(ctx context.Context, userID, email string) (string, error) {
+	if s.refreshTokenHandler == nil {
+		return "", nil
+	}
+	return s.refreshTokenHandler.Issue(ctx, userID, email)
 }
 
 // ValidateToken validates a JWT token
@@ -50,14 +164,21 @@ func (s *AuthService) ValidateToken(ctx context.Context, token string) (*dto.Val
 		UserID: claims.UserID,
 		Email:  claims.Email,
 		Roles:  claims.Roles,
+		Scopes: claims.Scopes,
 	}, nil
 }
 
-// RefreshToken refreshes a JWT token
+// RefreshToken renews a session. With refresh tokens enabled, token is a refresh token, exchanged
+// for an access token and the refresh token replacing it; otherwise it is an unexpired access
+// token, renewed.
 func (s *AuthService) RefreshToken(ctx context.Context, token string) (*dto.RefreshTokenResponse, error) {
+	if s.refreshTokenHandler != nil {
+		return s.refreshTokenHandler.Handle(ctx, dto.RefreshTokenCommand{RefreshToken: token})
+	}
+
 	claims, err := s.jwtService.ValidateToken(token)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, errors.ErrUnauthorized, "invalid token")
 	}
 
 	// Generate new token
@@ -71,6 +192,15 @@ func (s *AuthService) RefreshToken(ctx context.Context, token string) (*dto.Refr
 	}, nil
 }
 
+// RevokeRefreshToken signs out, revoking a refresh token and every token rotated from the same
+// sign in
+func (s *AuthService) RevokeRefreshToken(ctx context.Context, req dto.RevokeRefreshTokenCommand) error {
+	if s.refreshTokenHandler == nil {
+		return errors.New(errors.ErrNotFound, "refresh tokens are not enabled")
+	}
+	return s.refreshTokenHandler.HandleRevoke(ctx, req)
+}
+
 // ChangePassword changes a user's password
 func (s *AuthService) ChangePassword(ctx context.Context, req dto.ChangePasswordCommand) (*dto.ChangePasswordResponse, error) {
 	// TODO: Implement change password handler
@@ -6,6 +6,7 @@ import (
 	"go-clean-ddd-es-template/internal/application/commands"
 	"go-clean-ddd-es-template/internal/application/dto"
 	"go-clean-ddd-es-template/internal/application/queries"
+	"go-clean-ddd-es-template/pkg/tracing"
 )
 
 // UserService combines all command and query handlers for user operations
@@ -17,6 +18,10 @@ type UserService struct {
 	listQueryHandler       *queries.UserListQueryHandler
 	getByEmailQueryHandler *queries.UserGetByEmailQueryHandler
 	eventsQueryHandler     *queries.UserEventsQueryHandler
+
+	// Preferences, see SetPreferences
+	updatePreferencesCommandHandler *commands.UserUpdatePreferencesCommandHandler
+	getPreferencesQueryHandler      *queries.UserGetPreferencesQueryHandler
 }
 
 // NewUserService creates a new user service
@@ -40,23 +45,46 @@ func NewUserService(
 	}
 }
 
+// SetPreferences sets the handlers of the user preference command and query
+func (s *UserService) SetPreferences(updateCommandHandler *commands.UserUpdatePreferencesCommandHandler, getQueryHandler *queries.UserGetPreferencesQueryHandler) {
+	s.updatePreferencesCommandHandler = updateCommandHandler
+	s.getPreferencesQueryHandler = getQueryHandler
+}
+
 // ==================== COMMANDS ====================
 
 // CreateUser executes the create user command
-func (s *UserService) CreateUser(ctx context.Context, cmd dto.CreateUserCommand) (*dto.CreateUserCommandResponse, error) {
+func (s *UserService) CreateUser(ctx context.Context, cmd dto.CreateUserCommand) (response *dto.CreateUserCommandResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer func() { tracing.End(span, err) }()
+
 	return s.createCommandHandler.Handle(ctx, cmd)
 }
 
 // UpdateUser executes the update user command
-func (s *UserService) UpdateUser(ctx context.Context, cmd dto.UpdateUserCommand) (*dto.UpdateUserCommandResponse, error) {
+func (s *UserService) UpdateUser(ctx context.Context, cmd dto.UpdateUserCommand) (response *dto.UpdateUserCommandResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer func() { tracing.End(span, err) }()
+
 	return s.updateCommandHandler.Handle(ctx, cmd)
 }
 
 // DeleteUser executes the delete user command
-func (s *UserService) DeleteUser(ctx context.Context, cmd dto.DeleteUserCommand) (*dto.DeleteUserCommandResponse, error) {
+func (s *UserService) DeleteUser(ctx context.Context, cmd dto.DeleteUserCommand) (response *dto.DeleteUserCommandResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer func() { tracing.End(span, err) }()
+
 	return s.deleteCommandHandler.Handle(ctx, cmd)
 }
 
+// UpdateUserPreferences executes the update user preferences command
+func (s *UserService) UpdateUserPreferences(ctx context.Context, cmd dto.UpdateUserPreferencesCommand) (response *dto.UpdateUserPreferencesCommandResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUserPreferences")
+	defer func() { tracing.End(span, err) }()
+
+	return s.updatePreferencesCommandHandler.Handle(ctx, cmd)
+}
+
 // ==================== QUERIES ====================
 
 // GetUser executes the get user query
@@ -78,3 +106,8 @@ func (s *UserService) GetUserByEmail(ctx context.Context, query dto.GetUserByEma
 func (s *UserService) GetUserEvents(ctx context.Context, query dto.GetUserEventsQuery) (*dto.GetUserEventsQueryResponse, error) {
 	return s.eventsQueryHandler.Handle(ctx, query)
 }
+
+// GetUserPreferences executes the get user preferences query
+func (s *UserService) GetUserPreferences(ctx context.Context, query dto.GetUserPreferencesQuery) (*dto.UserPreferences, error) {
+	return s.getPreferencesQueryHandler.Handle(ctx, query)
+}
@@ -61,8 +61,8 @@ func TestUserService_CreateUser(t *testing.T) {
 
 			// Create command and query handlers
 			createHandler := commands.NewUserCreateCommandHandler(userWriteRepo, eventStore, eventPublisher)
-			updateHandler := commands.NewUserUpdateCommandHandler(userWriteRepo, eventStore, eventPublisher)
-			deleteHandler := commands.NewUserDeleteCommandHandler(userWriteRepo, eventStore, eventPublisher)
+			updateHandler := commands.NewUserUpdateCommandHandler(userWriteRepo, eventStore, nil, eventPublisher)
+			deleteHandler := commands.NewUserDeleteCommandHandler(userWriteRepo, eventStore, nil, eventPublisher)
 			getHandler := queries.NewUserGetQueryHandler(userReadRepo)
 			listHandler := queries.NewUserListQueryHandler(userReadRepo)
 			getByEmailHandler := queries.NewUserGetByEmailQueryHandler(userReadRepo)
@@ -142,8 +142,8 @@ func TestUserService_GetUser(t *testing.T) {
 
 			// Create command and query handlers
 			createHandler := commands.NewUserCreateCommandHandler(userWriteRepo, eventStore, eventPublisher)
-			updateHandler := commands.NewUserUpdateCommandHandler(userWriteRepo, eventStore, eventPublisher)
-			deleteHandler := commands.NewUserDeleteCommandHandler(userWriteRepo, eventStore, eventPublisher)
+			updateHandler := commands.NewUserUpdateCommandHandler(userWriteRepo, eventStore, nil, eventPublisher)
+			deleteHandler := commands.NewUserDeleteCommandHandler(userWriteRepo, eventStore, nil, eventPublisher)
 			getHandler := queries.NewUserGetQueryHandler(userReadRepo)
 			listHandler := queries.NewUserListQueryHandler(userReadRepo)
 			getByEmailHandler := queries.NewUserGetByEmailQueryHandler(userReadRepo)
@@ -0,0 +1,59 @@
+package aggregates
+
+import "go-clean-ddd-es-template/internal/domain/events"
+
+// Aggregate defines the interface of aggregates rebuilt from their event stream. Embedding
+// AggregateRoot provides all but AggregateID and Apply.
+type Aggregate interface {
+	// AggregateID returns the ID of the aggregate's event stream
+	AggregateID() string
+
+	// Apply applies an event of the aggregate's stream to its state
+	Apply(event *events.Event) error
+
+	// AggregateVersion returns the version of the last event stored for the aggregate
+	AggregateVersion() int
+
+	// ReplayedEvent records that an event of the aggregate's stream was applied to it
+	ReplayedEvent(event *events.Event)
+
+	// UncommittedEvents returns the events recorded since the aggregate was loaded or last saved
+	UncommittedEvents() []*events.Event
+
+	// MarkCommitted records that the uncommitted events were stored
+	MarkCommitted()
+}
+
+// AggregateRoot tracks the version of an event sourced aggregate and the events recorded on it
+// since it was loaded. Aggregates embed it and apply their events themselves.
+type AggregateRoot struct {
+	version int
+	changes []*events.Event
+}
+
+// AggregateVersion returns the version of the last event stored for the aggregate, 0 when none is
+func (a *AggregateRoot) AggregateVersion() int {
+	return a.version
+}
+
+// ReplayedEvent records that an event of the aggregate's stream was applied to it
+func (a *AggregateRoot) ReplayedEvent(event *events.Event) {
+	a.version = event.Version
+}
+
+// RecordEvent records a new event of the aggregate, numbering it after the events recorded so far
+func (a *AggregateRoot) RecordEvent(event *events.Event) {
+	event.Version = a.version + len(a.changes) + 1
+	a.changes = append(a.changes, event)
+}
+
+// UncommittedEvents returns the events recorded since the aggregate was loaded or last saved
+func (a *AggregateRoot) UncommittedEvents() []*events.Event {
+	return a.changes
+}
+
+// MarkCommitted records that the uncommitted events were stored
+func (a *AggregateRoot) MarkCommitted() {
+	a.version += len(a.changes)
+	a.changes = nil
+}
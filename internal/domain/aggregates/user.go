@@ -0,0 +1,122 @@
+package aggregates
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// User is the event sourced aggregate of a user, rebuilt from its user.* events
+type User struct {
+	AggregateRoot
+	*entities.User
+	Preferences UserPreferences
+	Avatar      *entities.AvatarMetadata // Nil until the user uploads an avatar
+
+	deleted bool
+}
+
+// NewUser creates an empty user aggregate to apply events to
+func NewUser() *User {
+	return &User{User: &entities.User{}}
+}
+
+// AggregateID returns the ID of the user's event stream
+func (u *User) AggregateID() string {
+	return u.ID.String()
+}
+
+// Apply applies an event of the user's stream to the user
+func (u *User) Apply(event *events.Event) error {
+	switch event.Type {
+	case "user.created":
+		var data events.UserCreatedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal %s event: %w", event.Type, err)
+		}
+		user, err := entities.NewUser(data.Email, data.Name)
+		if err != nil {
+			return err
+		}
+		if user.ID, err = entities.NewUserIDFromString(data.UserID); err != nil {
+			return err
+		}
+		user.CreatedAt = data.CreatedAt
+		user.UpdatedAt = data.CreatedAt
+		u.User = user
+		u.Preferences.UserID = data.UserID
+	case "user.updated":
+		var data events.UserUpdatedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal %s event: %w", event.Type, err)
+		}
+		if err := u.UpdateName(data.Name); err != nil {
+			return err
+		}
+		u.UpdatedAt = data.UpdatedAt
+	case "user.deleted":
+		var data events.UserDeletedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal %s event: %w", event.Type, err)
+		}
+		u.deleted = true
+		u.UpdatedAt = data.DeletedAt
+	case "user.preferences_updated":
+		return u.Preferences.Apply(event)
+	case "user.email_verified":
+		var data events.UserEmailVerifiedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal %s event: %w", event.Type, err)
+		}
+		// Only the current email is verified, the user may have changed it since
+		if data.Email == u.GetEmail() {
+			verifiedAt := data.VerifiedAt
+			u.EmailVerifiedAt = &verifiedAt
+		}
+		u.UpdatedAt = data.VerifiedAt
+	case "user.avatar_updated":
+		var data events.UserAvatarUpdatedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal %s event: %w", event.Type, err)
+		}
+		avatar := data.Avatar
+		u.Avatar = &avatar
+		u.UpdatedAt = data.Avatar.UploadedAt
+	case "user.password_reset":
+		// The password hash is not part of the user's events
+		var data events.PasswordResetEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal %s event: %w", event.Type, err)
+		}
+		u.UpdatedAt = data.ResetAt
+	default:
+		return fmt.Errorf("unknown user event type: %s", event.Type)
+	}
+	return nil
+}
+
+// ChangeAvatar records the avatar of the user changed to avatar, returning the event recorded.
+// Deleted users cannot change their avatar.
+func (u *User) ChangeAvatar(avatar entities.AvatarMetadata) (*events.Event, error) {
+	if u.deleted {
+		return nil, errors.UserDeleted(u.AggregateID())
+	}
+
+	event, err := events.NewUserAvatarUpdatedEvent(u.AggregateID(), avatar)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.Apply(event); err != nil {
+		return nil, err
+	}
+	u.RecordEvent(event)
+	return event, nil
+}
+
+// IsDeleted checks if the user was deleted
+func (u *User) IsDeleted() bool {
+	return u.deleted
+}
@@ -0,0 +1,66 @@
+package aggregates
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+)
+
+// UserPreferences is the preferences sub-aggregate of a user: the settings the user chose, rebuilt
+// from the user.preferences_updated events of the user's stream
+type UserPreferences struct {
+	UserID    string
+	Settings  entities.PreferenceSettings
+	UpdatedAt time.Time // Zero until the user changes a setting
+}
+
+// LoadUserPreferences rebuilds the preferences of a user from the events of the user's stream,
+// skipping the events of the other parts of the user
+func LoadUserPreferences(userID string, history []*events.Event) (*UserPreferences, error) {
+	preferences := &UserPreferences{UserID: userID}
+	for _, event := range history {
+		if event.Type != "user.preferences_updated" {
+			continue
+		}
+		if err := preferences.Apply(event); err != nil {
+			return nil, err
+		}
+	}
+	return preferences, nil
+}
+
+// Apply applies a user.preferences_updated event to the preferences
+func (p *UserPreferences) Apply(event *events.Event) error {
+	var data events.UserPreferencesUpdatedEvent
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("failed to unmarshal %s event: %w", event.Type, err)
+	}
+	p.Settings = data.Preferences
+	p.UpdatedAt = data.UpdatedAt
+	return nil
+}
+
+// Update validates changes to the settings and applies them, returning the event recording the
+// settings after the change; the event is nil when the changes leave the settings as they were
+func (p *UserPreferences) Update(changes entities.PreferenceSettings) (*events.Event, error) {
+	if err := changes.Validate(); err != nil {
+		return nil, err
+	}
+	settings := p.Settings.Merge(changes)
+	if reflect.DeepEqual(settings, p.Settings) {
+		return nil, nil
+	}
+
+	event, err := events.NewUserPreferencesUpdatedEvent(p.UserID, settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Apply(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -3,10 +3,16 @@ package entities
 import (
 	"net/mail"
 	"strings"
+	"sync/atomic"
 	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
 
 	"go-clean-ddd-es-template/pkg/errors"
 	"go-clean-ddd-es-template/pkg/i18n"
+	"go-clean-ddd-es-template/pkg/security"
 )
 
 // Email represents an email address value object
@@ -14,12 +20,33 @@ type Email struct {
 	value string
 }
 
-// NewEmail creates a new Email value object with validation
+// EmailPolicy controls which email addresses are accepted
+type EmailPolicy struct {
+	// AllowInternational accepts internationalized addresses (RFC 6531) with unicode
+	// local parts and IDN domains. Domains are normalized to punycode.
+	AllowInternational bool
+}
+
+// allowInternationalEmails holds the policy used by NewEmail
+var allowInternationalEmails atomic.Bool
+
+// SetEmailPolicy sets the policy used by NewEmail
+func SetEmailPolicy(policy EmailPolicy) {
+	allowInternationalEmails.Store(policy.AllowInternational)
+}
+
+// NewEmail creates a new Email value object with validation using the configured policy
 func NewEmail(email string) (Email, error) {
-	if err := validateEmail(email); err != nil {
+	return NewEmailWithPolicy(email, EmailPolicy{AllowInternational: allowInternationalEmails.Load()})
+}
+
+// NewEmailWithPolicy creates a new Email value object with validation using policy
+func NewEmailWithPolicy(email string, policy EmailPolicy) (Email, error) {
+	normalized, err := validateEmail(email, policy)
+	if err != nil {
 		return Email{}, err
 	}
-	return Email{value: strings.ToLower(strings.TrimSpace(email))}, nil
+	return Email{value: normalized}, nil
 }
 
 // String returns the email as a string
@@ -32,68 +59,103 @@ func (e Email) Value() string {
 	return e.value
 }
 
+// Display returns the email with its domain in unicode form for presentation
+func (e Email) Display() string {
+	at := strings.LastIndex(e.value, "@")
+	if at < 0 {
+		return e.value
+	}
+	domain, err := idna.Display.ToUnicode(e.value[at+1:])
+	if err != nil {
+		return e.value
+	}
+	return e.value[:at+1] + domain
+}
+
 // Equals checks if two emails are equal
 func (e Email) Equals(other Email) bool {
 	return e.value == other.value
 }
 
-// validateEmail validates email format with enhanced security
-func validateEmail(email string) error {
+// validateEmail validates email format with enhanced security and returns its normalized form
+func validateEmail(email string, policy EmailPolicy) (string, error) {
 	if email == "" {
-		return errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_REQUIRED", "en"))
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_REQUIRED", "en"))
 	}
 
 	// Trim whitespace
 	email = strings.TrimSpace(email)
 	if email == "" {
-		return errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_REQUIRED", "en"))
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_REQUIRED", "en"))
+	}
+
+	// Only internationalized addresses may contain non ASCII characters
+	if policy.AllowInternational {
+		email = norm.NFC.String(email)
+	} else if !isASCII(email) {
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_NON_ASCII", "en"))
 	}
 
 	// Check length limits (RFC 5321)
 	if len(email) > 254 {
-		return errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_TOO_LONG", "en"))
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_TOO_LONG", "en"))
 	}
 
 	// Check for minimum length
 	if len(email) < 5 { // a@b.c
-		return errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_TOO_SHORT", "en"))
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_TOO_SHORT", "en"))
 	}
 
 	// Check for basic format
 	if !strings.Contains(email, "@") {
-		return errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_MISSING_AT", "en"))
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_MISSING_AT", "en"))
 	}
 
 	// Split email into local and domain parts
 	parts := strings.Split(email, "@")
 	if len(parts) != 2 {
-		return errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_INVALID_FORMAT", "en"))
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_INVALID_FORMAT", "en"))
 	}
 
-	localPart := parts[0]
+	localPart := strings.ToLower(parts[0])
 	domainPart := parts[1]
 
 	// Validate local part
 	if err := validateLocalPart(localPart); err != nil {
-		return err
+		return "", err
+	}
+
+	// Convert IDN domains to punycode so equal domains compare equal
+	if policy.AllowInternational {
+		ascii, err := idna.Lookup.ToASCII(domainPart)
+		if err != nil {
+			return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_DOMAIN_INVALID_IDN", "en"))
+		}
+		domainPart = ascii
 	}
+	domainPart = strings.ToLower(domainPart)
 
 	// Validate domain part
 	if err := validateDomainPart(domainPart); err != nil {
-		return err
+		return "", err
+	}
+
+	normalized := localPart + "@" + domainPart
+	if len(normalized) > 254 {
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_TOO_LONG", "en"))
 	}
 
 	// Use Go's built-in email validation as additional check
-	if _, err := mail.ParseAddress(email); err != nil {
-		return errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_INVALID_FORMAT", "en"))
+	if _, err := mail.ParseAddress(normalized); err != nil {
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_INVALID_FORMAT", "en"))
 	}
 
 	// Check for suspicious patterns (security)
-	if containsSuspiciousPatterns(email) {
-		return errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_SUSPICIOUS_PATTERN", "en"))
+	if security.ContainsInjectionPattern(normalized) {
+		return "", errors.New(errors.ErrInvalidEmail, i18n.T("EMAIL_SUSPICIOUS_PATTERN", "en"))
 	}
 
-	return nil
+	return normalized, nil
 }
 
 // validateLocalPart validates the local part of email
@@ -169,7 +231,7 @@ func validateDomainPart(domainPart string) error {
 
 // isValidLocalPartChar checks if character is valid in local part
 func isValidLocalPartChar(char rune) bool {
-	return unicode.IsLetter(char) || unicode.IsDigit(char) ||
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || unicode.IsMark(char) ||
 		char == '!' || char == '#' || char == '$' || char == '%' ||
 		char == '&' || char == '\'' || char == '*' || char == '+' ||
 		char == '-' || char == '/' || char == '=' || char == '?' ||
@@ -182,22 +244,14 @@ func isValidDomainChar(char rune) bool {
 	return unicode.IsLetter(char) || unicode.IsDigit(char) || char == '-' || char == '.'
 }
 
-// containsSuspiciousPatterns checks for potentially malicious patterns
-func containsSuspiciousPatterns(email string) bool {
-	suspiciousPatterns := []string{
-		"<script", "javascript:", "vbscript:", "onload=", "onerror=",
-		"<iframe", "<object", "<embed", "data:text/html",
-		"../../", "..\\", "file://", "ftp://", "gopher://",
-	}
-
-	emailLower := strings.ToLower(email)
-	for _, pattern := range suspiciousPatterns {
-		if strings.Contains(emailLower, pattern) {
-			return true
+// isASCII checks if a string only contains ASCII characters
+func isASCII(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] >= utf8.RuneSelf {
+			return false
 		}
 	}
-
-	return false
+	return true
 }
 
 // MustNewEmail creates a new Email value object and panics if validation fails
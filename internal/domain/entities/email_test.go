@@ -106,3 +106,87 @@ func TestEmail_Value(t *testing.T) {
 	email, _ := NewEmail("test@example.com")
 	assert.Equal(t, "test@example.com", email.Value())
 }
+
+func TestNewEmailWithPolicy(t *testing.T) {
+	strict := EmailPolicy{}
+	international := EmailPolicy{AllowInternational: true}
+
+	tests := []struct {
+		name          string
+		email         string
+		policy        EmailPolicy
+		expected      string
+		expectedError bool
+	}{
+		// ASCII addresses are accepted by both policies
+		{name: "ascii strict", email: "user@example.com", policy: strict, expected: "user@example.com"},
+		{name: "ascii international", email: "user@example.com", policy: international, expected: "user@example.com"},
+		{name: "uppercase is lowered", email: "User@Example.COM", policy: strict, expected: "user@example.com"},
+		{name: "surrounding spaces are trimmed", email: "  user@example.com ", policy: strict, expected: "user@example.com"},
+		{name: "punycode domain strict", email: "user@xn--bcher-kva.de", policy: strict, expected: "user@xn--bcher-kva.de"},
+
+		// Internationalized addresses require the opt-in policy
+		{name: "idn domain strict", email: "user@bücher.de", policy: strict, expectedError: true},
+		{name: "unicode local part strict", email: "josé@example.com", policy: strict, expectedError: true},
+		{name: "idn domain international", email: "user@bücher.de", policy: international, expected: "user@xn--bcher-kva.de"},
+		{name: "uppercase idn domain international", email: "user@BÜCHER.de", policy: international, expected: "user@xn--bcher-kva.de"},
+		{name: "unicode local part international", email: "josé@example.com", policy: international, expected: "josé@example.com"},
+		{name: "decomposed local part is composed", email: "jose\u0301@example.com", policy: international, expected: "josé@example.com"},
+		{name: "chinese address international", email: "用户@例子.广告", policy: international, expected: "用户@xn--fsqu00a.xn--4rr70v"},
+		{name: "greek address international", email: "δοκιμή@παράδειγμα.δοκιμή", policy: international, expected: "δοκιμή@xn--hxajbheg2az3al.xn--jxalpdlp"},
+		{name: "invalid idn label international", email: "user@xn--a.de", policy: international, expectedError: true},
+		{name: "domain with symbol international", email: "user@exa☃mple..com", policy: international, expectedError: true},
+
+		// Security and format checks apply to both policies
+		{name: "script pattern strict", email: "<script>@example.com", policy: strict, expectedError: true},
+		{name: "script pattern international", email: "<script>@example.com", policy: international, expectedError: true},
+		{name: "path traversal international", email: "a../../@example.com", policy: international, expectedError: true},
+		{name: "consecutive dots international", email: "a..b@bücher.de", policy: international, expectedError: true},
+		{name: "two at signs international", email: "a@b@bücher.de", policy: international, expectedError: true},
+		{name: "missing tld international", email: "user@bücher", policy: international, expectedError: true},
+		{name: "control character international", email: "us\u0000er@bücher.de", policy: international, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := NewEmailWithPolicy(tt.email, tt.policy)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Equal(t, Email{}, email)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, email.Value())
+			}
+		})
+	}
+}
+
+func TestNewEmail_UsesConfiguredPolicy(t *testing.T) {
+	defer SetEmailPolicy(EmailPolicy{})
+
+	_, err := NewEmail("user@bücher.de")
+	assert.Error(t, err)
+
+	SetEmailPolicy(EmailPolicy{AllowInternational: true})
+	email, err := NewEmail("user@bücher.de")
+	assert.NoError(t, err)
+	assert.Equal(t, "user@xn--bcher-kva.de", email.Value())
+}
+
+func TestEmail_Display(t *testing.T) {
+	email, err := NewEmailWithPolicy("user@bücher.de", EmailPolicy{AllowInternational: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "user@bücher.de", email.Display())
+
+	ascii, _ := NewEmail("test@example.com")
+	assert.Equal(t, "test@example.com", ascii.Display())
+}
+
+func TestEmail_EqualsAcrossForms(t *testing.T) {
+	policy := EmailPolicy{AllowInternational: true}
+	unicode, _ := NewEmailWithPolicy("user@bücher.de", policy)
+	punycode, _ := NewEmailWithPolicy("user@xn--bcher-kva.de", policy)
+
+	assert.True(t, unicode.Equals(punycode))
+}
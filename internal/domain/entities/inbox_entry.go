@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+// Inbox entry outcomes
+const (
+	InboxProcessed = "processed"
+	InboxFailed    = "failed"
+)
+
+// InboxEntry records the outcome of a consumer handling an event, kept in the "inbox" collection
+// and keyed by consumer and event ID. Events processed by a consumer are not applied again when
+// redelivered, e.g. after the consumer crashed before committing its offset.
+type InboxEntry struct {
+	Consumer  string    `bson:"consumer" json:"consumer"`
+	EventID   string    `bson:"event_id" json:"event_id"`
+	EventType string    `bson:"event_type" json:"event_type"`
+	Outcome   string    `bson:"outcome" json:"outcome"`
+	Error     string    `bson:"error,omitempty" json:"error,omitempty"` // Error of the latest failure
+	Attempts  int       `bson:"attempts" json:"attempts"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -6,6 +6,7 @@ import (
 
 	"go-clean-ddd-es-template/pkg/errors"
 	"go-clean-ddd-es-template/pkg/i18n"
+	"go-clean-ddd-es-template/pkg/security"
 )
 
 // Name represents a person's name value object
@@ -71,7 +72,7 @@ func validateName(name string) error {
 	}
 
 	// Check for suspicious patterns (security)
-	if containsSuspiciousNamePatterns(trimmedName) {
+	if security.ContainsInjectionPattern(trimmedName) {
 		return errors.New(errors.ErrInvalidName, i18n.T("NAME_SUSPICIOUS_PATTERN", "en"))
 	}
 
@@ -133,26 +134,6 @@ func isValidNameChar(char rune) bool {
 	return false
 }
 
-// containsSuspiciousNamePatterns checks for potentially malicious patterns in names
-func containsSuspiciousNamePatterns(name string) bool {
-	suspiciousPatterns := []string{
-		"<script", "javascript:", "vbscript:", "onload=", "onerror=",
-		"<iframe", "<object", "<embed", "data:text/html",
-		"../../", "..\\", "file://", "ftp://", "gopher://",
-		"SELECT", "INSERT", "UPDATE", "DELETE", "DROP", "CREATE",
-		"UNION", "OR", "AND", "WHERE", "FROM", "JOIN",
-	}
-
-	nameLower := strings.ToLower(name)
-	for _, pattern := range suspiciousPatterns {
-		if strings.Contains(nameLower, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
 // containsControlCharacters checks for control characters
 func containsControlCharacters(name string) bool {
 	for _, char := range name {
@@ -0,0 +1,128 @@
+package entities
+
+import (
+	"slices"
+
+	"golang.org/x/text/language"
+
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// User interface themes
+const (
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+	ThemeSystem = "system" // Follows the operating system of the device
+)
+
+// Notification digest frequencies
+const (
+	DigestNever  = "never"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Themes and NotificationDigests are the accepted preference values
+var (
+	Themes              = []string{ThemeLight, ThemeDark, ThemeSystem}
+	NotificationDigests = []string{DigestNever, DigestDaily, DigestWeekly}
+)
+
+// Preferences are the effective settings of a user, those the user chose merged over the defaults
+type Preferences struct {
+	Locale        string                  `json:"locale"`
+	Theme         string                  `json:"theme"`
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+// NotificationPreferences are the effective notification settings of a user
+type NotificationPreferences struct {
+	Email  bool   `json:"email"`
+	Push   bool   `json:"push"`
+	Digest string `json:"digest"`
+}
+
+// PreferenceSettings are the settings a user chose, nil for those left to the defaults. They are
+// kept as a nested document of the user read model.
+type PreferenceSettings struct {
+	Locale        *string               `bson:"locale,omitempty" json:"locale,omitempty"`
+	Theme         *string               `bson:"theme,omitempty" json:"theme,omitempty"`
+	Notifications *NotificationSettings `bson:"notifications,omitempty" json:"notifications,omitempty"`
+}
+
+// NotificationSettings are the notification settings a user chose, nil for those left to the defaults
+type NotificationSettings struct {
+	Email  *bool   `bson:"email,omitempty" json:"email,omitempty"`
+	Push   *bool   `bson:"push,omitempty" json:"push,omitempty"`
+	Digest *string `bson:"digest,omitempty" json:"digest,omitempty"`
+}
+
+// Validate checks the settings chosen and normalizes the locale to its canonical BCP 47 form
+func (s *PreferenceSettings) Validate() error {
+	if s.Locale != nil {
+		tag, err := language.Parse(*s.Locale)
+		if err != nil {
+			return errors.ValidationFailed("locale", "must be a BCP 47 language tag, e.g. en-US")
+		}
+		locale := tag.String()
+		s.Locale = &locale
+	}
+	if s.Theme != nil && !slices.Contains(Themes, *s.Theme) {
+		return errors.ValidationFailed("theme", "must be one of light, dark or system")
+	}
+	if s.Notifications != nil && s.Notifications.Digest != nil && !slices.Contains(NotificationDigests, *s.Notifications.Digest) {
+		return errors.ValidationFailed("notification_digest", "must be one of never, daily or weekly")
+	}
+	return nil
+}
+
+// Merge returns the settings with the changes applied, settings the changes leave nil unchanged
+func (s PreferenceSettings) Merge(changes PreferenceSettings) PreferenceSettings {
+	merged := s
+	if changes.Locale != nil {
+		merged.Locale = changes.Locale
+	}
+	if changes.Theme != nil {
+		merged.Theme = changes.Theme
+	}
+	if changes.Notifications != nil {
+		notifications := NotificationSettings{}
+		if s.Notifications != nil {
+			notifications = *s.Notifications
+		}
+		if changes.Notifications.Email != nil {
+			notifications.Email = changes.Notifications.Email
+		}
+		if changes.Notifications.Push != nil {
+			notifications.Push = changes.Notifications.Push
+		}
+		if changes.Notifications.Digest != nil {
+			notifications.Digest = changes.Notifications.Digest
+		}
+		merged.Notifications = &notifications
+	}
+	return merged
+}
+
+// Resolve returns the effective preferences: the settings chosen, the defaults for the others
+func (s PreferenceSettings) Resolve(defaults Preferences) Preferences {
+	preferences := defaults
+	if s.Locale != nil {
+		preferences.Locale = *s.Locale
+	}
+	if s.Theme != nil {
+		preferences.Theme = *s.Theme
+	}
+	if s.Notifications != nil {
+		if s.Notifications.Email != nil {
+			preferences.Notifications.Email = *s.Notifications.Email
+		}
+		if s.Notifications.Push != nil {
+			preferences.Notifications.Push = *s.Notifications.Push
+		}
+		if s.Notifications.Digest != nil {
+			preferences.Notifications.Digest = *s.Notifications.Digest
+		}
+	}
+	return preferences
+}
@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestPreferenceSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings PreferenceSettings
+		wantErr  bool
+	}{
+		{name: "no settings", settings: PreferenceSettings{}},
+		{name: "valid settings", settings: PreferenceSettings{Locale: ptr("vi-VN"), Theme: ptr(ThemeDark), Notifications: &NotificationSettings{Digest: ptr(DigestDaily)}}},
+		{name: "invalid locale", settings: PreferenceSettings{Locale: ptr("not a locale")}, wantErr: true},
+		{name: "invalid theme", settings: PreferenceSettings{Theme: ptr("neon")}, wantErr: true},
+		{name: "invalid digest", settings: PreferenceSettings{Notifications: &NotificationSettings{Digest: ptr("hourly")}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPreferenceSettings_ValidateCanonicalizesLocale(t *testing.T) {
+	settings := PreferenceSettings{Locale: ptr("EN-us")}
+	require.NoError(t, settings.Validate())
+	assert.Equal(t, "en-US", *settings.Locale)
+}
+
+func TestPreferenceSettings_MergeAndResolve(t *testing.T) {
+	defaults := Preferences{
+		Locale:        "en",
+		Theme:         ThemeSystem,
+		Notifications: NotificationPreferences{Email: true, Push: false, Digest: DigestWeekly},
+	}
+	settings := PreferenceSettings{Theme: ptr(ThemeDark), Notifications: &NotificationSettings{Email: ptr(false)}}
+
+	merged := settings.Merge(PreferenceSettings{Locale: ptr("vi"), Notifications: &NotificationSettings{Push: ptr(true)}})
+	assert.Equal(t, Preferences{
+		Locale:        "vi",
+		Theme:         ThemeDark,
+		Notifications: NotificationPreferences{Email: false, Push: true, Digest: DigestWeekly},
+	}, merged.Resolve(defaults), "settings left out of the changes are kept and unset ones come from the defaults")
+	assert.Nil(t, settings.Notifications.Push, "merging does not change the original settings")
+	assert.Equal(t, defaults, PreferenceSettings{}.Resolve(defaults))
+}
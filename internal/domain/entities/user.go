@@ -9,9 +9,11 @@ type User struct {
 	ID           UserID    `json:"id"`
 	Email        Email     `json:"email"`
 	Name         Name      `json:"name"`
-	PasswordHash string    `json:"-"` // Never expose password hash in JSON
+	PasswordHash string    `json:"-" sensitive:"true"` // Never expose password hash in JSON
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // Nil until the user verifies their email
 }
 
 // NewUser creates a new User entity with validation
@@ -28,13 +30,13 @@ func NewUser(email, name string) (*User, error) {
 		return nil, err
 	}
 
-	now := time.Now()
+	createdAt := now()
 	return &User{
 		ID:        NewUserID(),
 		Email:     emailVO,
 		Name:      nameVO,
-		CreatedAt: now,
-		UpdatedAt: now,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
 	}, nil
 }
 
@@ -45,21 +47,36 @@ func (u *User) UpdateName(name string) error {
 		return err
 	}
 	u.Name = nameVO
-	u.UpdatedAt = time.Now()
+	u.UpdatedAt = now()
 	return nil
 }
 
-// UpdateEmail updates the user's email with validation
+// UpdateEmail updates the user's email with validation. A new email is not verified.
 func (u *User) UpdateEmail(email string) error {
 	emailVO, err := NewEmail(email)
 	if err != nil {
 		return err
 	}
+	if !u.Email.Equals(emailVO) {
+		u.EmailVerifiedAt = nil
+	}
 	u.Email = emailVO
-	u.UpdatedAt = time.Now()
+	u.UpdatedAt = now()
 	return nil
 }
 
+// VerifyEmail records that the user verified their current email
+func (u *User) VerifyEmail() {
+	verifiedAt := now()
+	u.EmailVerifiedAt = &verifiedAt
+	u.UpdatedAt = verifiedAt
+}
+
+// IsEmailVerified checks if the user verified their current email
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 // GetEmail returns the email as a string
 func (u *User) GetEmail() string {
 	return u.Email.String()
@@ -88,7 +105,7 @@ func (u *User) Equals(other *User) bool {
 // SetPasswordHash sets the password hash
 func (u *User) SetPasswordHash(hash string) {
 	u.PasswordHash = hash
-	u.UpdatedAt = time.Now()
+	u.UpdatedAt = now()
 }
 
 // GetPasswordHash returns the password hash
@@ -12,9 +12,9 @@ type UserID struct {
 	value string
 }
 
-// NewUserID creates a new UserID value object
+// NewUserID creates a new UserID value object backed by a time ordered UUIDv7
 func NewUserID() UserID {
-	return UserID{value: uuid.New().String()}
+	return UserID{value: ids().UUIDv7()}
 }
 
 // NewUserIDFromString creates a UserID from a string with validation
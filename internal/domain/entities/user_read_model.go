@@ -6,20 +6,43 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// UserReadModelSchemaVersion is the latest schema version of UserReadModel documents. Bump it
+// together with a new migration when the document layout changes.
+const UserReadModelSchemaVersion = 1
+
 // UserReadModel represents the read model for user stored in MongoDB
 type UserReadModel struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    string             `bson:"user_id" json:"user_id"`
-	Email     string             `bson:"email" json:"email"`
-	Name      string             `bson:"name" json:"name"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
-	DeletedAt *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
-	Version   int                `bson:"version" json:"version"`
+	ID              primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID          string               `bson:"user_id" json:"user_id"`
+	Email           string               `bson:"email" json:"email"`
+	Name            string               `bson:"name" json:"name"`
+	CreatedAt       time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time            `bson:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time           `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	Avatar          *AvatarMetadata      `bson:"avatar,omitempty" json:"avatar,omitempty"`
+	Preferences     *PreferencesDocument `bson:"preferences,omitempty" json:"preferences,omitempty"`             // Nil until the user changes a setting
+	EmailVerifiedAt *time.Time           `bson:"email_verified_at,omitempty" json:"email_verified_at,omitempty"` // Nil until the user verifies their email
+	Version         int                  `bson:"version" json:"version"`
+	SchemaVersion   int                  `bson:"schema_version" json:"schema_version"` // Zero for documents written before versioning
+}
+
+// AvatarMetadata describes the avatar image of a user stored in object storage
+type AvatarMetadata struct {
+	Key         string    `bson:"key" json:"key"`
+	ContentType string    `bson:"content_type" json:"content_type"`
+	Size        int64     `bson:"size" json:"size"`
+	UploadedAt  time.Time `bson:"uploaded_at" json:"uploaded_at"`
+}
+
+// PreferencesDocument is the nested document of the settings a user chose in the user read model
+type PreferencesDocument struct {
+	PreferenceSettings `bson:",inline"`
+	UpdatedAt          time.Time `bson:"updated_at" json:"updated_at"`
 }
 
 // UserEvent represents a user event for serialization (without MongoDB ObjectID)
 type UserEvent struct {
+	EventID   string                 `json:"event_id,omitempty" bson:"event_id,omitempty"` // ID of the consumed domain event, empty for stored events
 	UserID    string                 `json:"user_id"`
 	EventType string                 `json:"event_type"`
 	EventData map[string]interface{} `json:"event_data"`
@@ -27,10 +50,41 @@ type UserEvent struct {
 	Version   int                    `json:"version"`
 }
 
-// UserSummary represents a summary of user for listing
+// User summary statuses
+const (
+	UserStatusActive  = "active"
+	UserStatusDeleted = "deleted"
+)
+
+// UserSummary is the compact projection of a user read by list queries, so lists do not load
+// full read model documents. It is kept in the "user_summaries" collection.
 type UserSummary struct {
-	UserID    string    `bson:"user_id" json:"user_id"`
-	Email     string    `bson:"email" json:"email"`
-	Name      string    `bson:"name" json:"name"`
-	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UserID      string     `bson:"user_id" json:"user_id"`
+	Email       string     `bson:"email" json:"email"`
+	Name        string     `bson:"name" json:"name"`
+	Status      string     `bson:"status" json:"status"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	LastLoginAt *time.Time `bson:"last_login_at,omitempty" json:"last_login_at,omitempty"`
+}
+
+// User change operations
+const (
+	UserChangeCreated = "created"
+	UserChangeUpdated = "updated"
+	UserChangeDeleted = "deleted"
+)
+
+// UserChange is an entry of the append-only user change log, kept in the "user_changes"
+// collection so external systems can sync users incrementally. Sequences increase in the order
+// changes are recorded; Key identifies the event recorded, so redelivered events are not
+// recorded twice.
+type UserChange struct {
+	Sequence   int64     `bson:"sequence" json:"sequence"`
+	Key        string    `bson:"key" json:"-"`
+	UserID     string    `bson:"user_id" json:"user_id"`
+	Operation  string    `bson:"operation" json:"operation"`
+	Email      string    `bson:"email,omitempty" json:"email,omitempty"` // Only set by changes carrying it
+	Name       string    `bson:"name,omitempty" json:"name,omitempty"`   // Only set by changes carrying it
+	OccurredAt time.Time `bson:"occurred_at" json:"occurred_at"`
+	RecordedAt time.Time `bson:"recorded_at" json:"recorded_at"`
 }
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/pkg/clock"
 )
 
 func TestNewUser(t *testing.T) {
@@ -111,6 +113,21 @@ func TestUser_UpdateEmail(t *testing.T) {
 	assert.Equal(t, "new@example.com", user.GetEmail()) // Email should remain unchanged
 }
 
+func TestUser_VerifyEmail(t *testing.T) {
+	user, err := NewUser("test@example.com", "John Doe")
+	assert.NoError(t, err)
+	assert.False(t, user.IsEmailVerified())
+
+	user.VerifyEmail()
+	assert.True(t, user.IsEmailVerified())
+	assert.Equal(t, user.UpdatedAt, *user.EmailVerifiedAt)
+
+	assert.NoError(t, user.UpdateEmail("test@example.com"))
+	assert.True(t, user.IsEmailVerified(), "the same email stays verified")
+	assert.NoError(t, user.UpdateEmail("new@example.com"))
+	assert.False(t, user.IsEmailVerified(), "a new email is not verified")
+}
+
 func TestUser_GetEmail(t *testing.T) {
 	user, err := NewUser("test@example.com", "John Doe")
 	assert.NoError(t, err)
@@ -216,3 +233,19 @@ func TestUser_IsValid(t *testing.T) {
 	user.ID = UserID{}
 	assert.False(t, user.IsValid())
 }
+
+func TestUser_UsesDomainClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	SetClock(fake)
+	defer SetClock(nil)
+
+	user, err := NewUser("test@example.com", "Test User")
+	assert.NoError(t, err)
+	assert.Equal(t, fake.Now(), user.CreatedAt)
+	assert.Equal(t, fake.Now(), user.UpdatedAt)
+
+	fake.Advance(time.Hour)
+	assert.NoError(t, user.UpdateName("New Name"))
+	assert.Equal(t, fake.Now(), user.UpdatedAt)
+	assert.Equal(t, time.Hour, user.UpdatedAt.Sub(user.CreatedAt))
+}
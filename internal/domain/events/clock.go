@@ -0,0 +1,37 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
+)
+
+var (
+	clockMu     sync.RWMutex
+	domainClock = clock.New()
+	domainIDs   = id.NewGenerator(domainClock)
+)
+
+// SetClock sets the clock used for event IDs and timestamps; nil restores the system clock
+func SetClock(c clock.Clock) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	domainClock = clock.OrDefault(c)
+	domainIDs = id.NewGenerator(domainClock)
+}
+
+// now returns the current time of the domain clock
+func now() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return domainClock.Now()
+}
+
+// ids returns the ID generator of the domain clock
+func ids() *id.Generator {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return domainIDs
+}
@@ -3,15 +3,23 @@ package events
 import (
 	"encoding/json"
 	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/valueobjects"
 )
 
-// Event represents a domain event
+// Event represents a domain event. Its envelope, the correlation, causation, tenant and trace
+// context, is stamped from the Metadata of the context it is published within.
 type Event struct {
-	ID        string    `json:"id"`
-	Type      string    `json:"type"`
-	Data      []byte    `json:"data"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   int       `json:"version"`
+	ID            valueobjects.EventID  `json:"id"`
+	TenantID      valueobjects.TenantID `json:"tenant_id,omitzero"` // Empty for single tenant deployments
+	Type          string                `json:"type"`
+	Data          []byte                `json:"data"`
+	Timestamp     time.Time             `json:"timestamp"`
+	Version       int                   `json:"version"`
+	CorrelationID string                `json:"correlation_id,omitempty"` // Request or workflow the event is part of
+	CausationID   string                `json:"causation_id,omitempty"`   // ID of the request or event that caused the event
+	TraceParent   string                `json:"traceparent,omitempty"`    // W3C trace context of the span that published the event
 }
 
 // NewEvent creates a new domain event
@@ -22,10 +30,10 @@ func NewEvent(eventType string, data interface{}, version int) (*Event, error) {
 	}
 
 	return &Event{
-		ID:        generateEventID(),
+		ID:        newEventID(),
 		Type:      eventType,
 		Data:      jsonData,
-		Timestamp: time.Now(),
+		Timestamp: now(),
 		Version:   version,
 	}, nil
 }
@@ -51,8 +59,172 @@ type UserDeletedEvent struct {
 	DeletedAt time.Time `json:"deleted_at"`
 }
 
+// UserPreferencesUpdatedEvent represents a user changing their preferences. It carries every
+// setting the user chose after the change, so applying it again is harmless.
+type UserPreferencesUpdatedEvent struct {
+	UserID      string                      `json:"user_id"`
+	Preferences entities.PreferenceSettings `json:"preferences"`
+	UpdatedAt   time.Time                   `json:"updated_at"`
+}
+
+// NewUserPreferencesUpdatedEvent creates the "user.preferences_updated" event of the settings a
+// user chose, changed now. It is numbered once recorded on the user's stream.
+func NewUserPreferencesUpdatedEvent(userID string, preferences entities.PreferenceSettings) (*Event, error) {
+	return NewEvent("user.preferences_updated", UserPreferencesUpdatedEvent{
+		UserID:      userID,
+		Preferences: preferences,
+		UpdatedAt:   now(),
+	}, 0)
+}
+
+// UserLoggedInEvent represents a successful login. It is published for projections only and not
+// stored with the user's events.
+type UserLoggedInEvent struct {
+	UserID     string    `json:"user_id"`
+	LoggedInAt time.Time `json:"logged_in_at"`
+}
+
+// NewUserLoggedInEvent creates the "user.login" event of a user logging in now
+func NewUserLoggedInEvent(userID string) (*Event, error) {
+	return NewEvent("user.login", UserLoggedInEvent{UserID: userID, LoggedInAt: now()}, 0)
+}
+
+// MagicLinkRequestedEvent represents a magic link sent to a user. Like logins, it is published
+// for projections and auditing only.
+type MagicLinkRequestedEvent struct {
+	UserID      string    `json:"user_id"`
+	LinkID      string    `json:"link_id"`
+	DeviceBound bool      `json:"device_bound"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// NewMagicLinkRequestedEvent creates the "auth.magic_link_requested" event of a magic link sent now
+func NewMagicLinkRequestedEvent(userID, linkID string, deviceBound bool, expiresAt time.Time) (*Event, error) {
+	return NewEvent("auth.magic_link_requested", MagicLinkRequestedEvent{
+		UserID:      userID,
+		LinkID:      linkID,
+		DeviceBound: deviceBound,
+		ExpiresAt:   expiresAt,
+		RequestedAt: now(),
+	}, 0)
+}
+
+// MagicLinkConsumedEvent represents a user signing in with a magic link
+type MagicLinkConsumedEvent struct {
+	UserID     string    `json:"user_id"`
+	LinkID     string    `json:"link_id"`
+	ConsumedAt time.Time `json:"consumed_at"`
+}
+
+// NewMagicLinkConsumedEvent creates the "auth.magic_link_consumed" event of a magic link consumed now
+func NewMagicLinkConsumedEvent(userID, linkID string) (*Event, error) {
+	return NewEvent("auth.magic_link_consumed", MagicLinkConsumedEvent{UserID: userID, LinkID: linkID, ConsumedAt: now()}, 0)
+}
+
+// LoginFailedEvent represents a password login failing for an existing user. Like logins, it is
+// published for projections and auditing only.
+type LoginFailedEvent struct {
+	UserID   string    `json:"user_id"`
+	ClientIP string    `json:"client_ip,omitempty"`
+	Failures int       `json:"failures"` // Failures of the user within the lockout window, this one included
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// NewLoginFailedEvent creates the "auth.login_failed" event of a login of a user failing now
+func NewLoginFailedEvent(userID, clientIP string, failures int) (*Event, error) {
+	return NewEvent("auth.login_failed", LoginFailedEvent{UserID: userID, ClientIP: clientIP, Failures: failures, FailedAt: now()}, 0)
+}
+
+// AccountLockedEvent represents a user locked out after too many failed logins
+type AccountLockedEvent struct {
+	UserID      string    `json:"user_id"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+	LockedAt    time.Time `json:"locked_at"`
+}
+
+// NewAccountLockedEvent creates the "auth.account_locked" event of a user locked out now
+func NewAccountLockedEvent(userID string, failures int, lockedUntil time.Time) (*Event, error) {
+	return NewEvent("auth.account_locked", AccountLockedEvent{
+		UserID:      userID,
+		Failures:    failures,
+		LockedUntil: lockedUntil,
+		LockedAt:    now(),
+	}, 0)
+}
+
+// PasswordResetRequestedEvent represents a password reset link sent to a user. Like magic links,
+// it is published for auditing only.
+type PasswordResetRequestedEvent struct {
+	UserID      string    `json:"user_id"`
+	ResetID     string    `json:"reset_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// NewPasswordResetRequestedEvent creates the "user.password_reset_requested" event of a password
+// reset link sent now
+func NewPasswordResetRequestedEvent(userID, resetID string, expiresAt time.Time) (*Event, error) {
+	return NewEvent("user.password_reset_requested", PasswordResetRequestedEvent{
+		UserID:      userID,
+		ResetID:     resetID,
+		ExpiresAt:   expiresAt,
+		RequestedAt: now(),
+	}, 0)
+}
+
+// PasswordResetEvent represents a user setting a new password with a password reset link. It is
+// stored with the user's events; the password hash is not part of it.
+type PasswordResetEvent struct {
+	UserID  string    `json:"user_id"`
+	ResetID string    `json:"reset_id"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// NewPasswordResetEvent creates the "user.password_reset" event of a password reset now. It is
+// numbered once recorded on the user's stream.
+func NewPasswordResetEvent(userID, resetID string) (*Event, error) {
+	return NewEvent("user.password_reset", PasswordResetEvent{UserID: userID, ResetID: resetID, ResetAt: now()}, 0)
+}
+
+// UserEmailVerifiedEvent represents a user verifying their email with a verification link. It is
+// stored with the user's events.
+type UserEmailVerifiedEvent struct {
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// NewUserEmailVerifiedEvent creates the "user.email_verified" event of a user who verified email
+// at verifiedAt. It is numbered once recorded on the user's stream.
+func NewUserEmailVerifiedEvent(userID, email string, verifiedAt time.Time) (*Event, error) {
+	return NewEvent("user.email_verified", UserEmailVerifiedEvent{UserID: userID, Email: email, VerifiedAt: verifiedAt}, 0)
+}
+
+// UserAvatarUpdatedEvent represents a user uploading a new avatar image. It is stored with the
+// user's events, so projections rebuilt from the events keep the avatar.
+type UserAvatarUpdatedEvent struct {
+	UserID string                  `json:"user_id"`
+	Avatar entities.AvatarMetadata `json:"avatar"`
+}
+
+// NewUserAvatarUpdatedEvent creates the "user.avatar_updated" event of the avatar a user uploaded.
+// It is numbered once recorded on the user's stream.
+func NewUserAvatarUpdatedEvent(userID string, avatar entities.AvatarMetadata) (*Event, error) {
+	return NewEvent("user.avatar_updated", UserAvatarUpdatedEvent{UserID: userID, Avatar: avatar}, 0)
+}
+
+// newEventID creates the ID of a new event
+func newEventID() valueobjects.EventID {
+	eventID, err := valueobjects.ParseEventID(generateEventID())
+	if err != nil {
+		return valueobjects.NewEventID()
+	}
+	return eventID
+}
+
+// generateEventID generates a ULID, so event IDs are unique and sort in creation order
 func generateEventID() string {
-	// This would typically use a UUID generator
-	// For now, using a simple timestamp-based ID
-	return time.Now().Format("20060102150405")
+	return ids().ULID()
 }
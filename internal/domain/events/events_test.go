@@ -6,6 +6,10 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/internal/domain/valueobjects"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
 )
 
 func TestNewEvent(t *testing.T) {
@@ -158,15 +162,15 @@ func TestGenerateEventID(t *testing.T) {
 	assert.NotEmpty(t, id1)
 	assert.NotEmpty(t, id2)
 
-	// Test format - should be timestamp format
-	assert.Len(t, id1, 14) // YYYYMMDDHHMMSS format
-	assert.Len(t, id2, 14)
+	// Test format - should be ULID format
+	assert.Len(t, id1, 26)
+	assert.Len(t, id2, 26)
+
+	// Test that IDs are unique and ordered by creation
+	assert.Less(t, id1, id2)
 
-	// Test that IDs are valid timestamp strings
-	_, err1 := time.Parse("20060102150405", id1)
-	_, err2 := time.Parse("20060102150405", id2)
-	assert.NoError(t, err1)
-	assert.NoError(t, err2)
+	_, err := id.ULIDTime(id1)
+	assert.NoError(t, err)
 }
 
 func TestUserCreatedEvent_JSONSerialization(t *testing.T) {
@@ -219,3 +223,34 @@ func TestUserDeletedEvent_JSONSerialization(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, event.UserID, unmarshaledEvent.UserID)
 }
+
+func TestNewEvent_UsesDomainClock(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	SetClock(clock.NewFake(fixed))
+	defer SetClock(nil)
+
+	event, err := NewEvent("user.created", map[string]string{"user_id": "123"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, fixed, event.Timestamp)
+
+	created, err := id.ULIDTime(event.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, fixed, created)
+}
+
+func TestEvent_StampAndConsequences(t *testing.T) {
+	tenantID := valueobjects.NewTenantID()
+	event, err := NewEvent("user.created", map[string]string{"user_id": "123"}, 1)
+	assert.NoError(t, err)
+	event.CorrelationID = "request-1"
+
+	event.Stamp(Metadata{CorrelationID: "request-2", CausationID: "request-2", TenantID: tenantID})
+	assert.Equal(t, "request-1", event.CorrelationID, "existing envelope fields are kept")
+	assert.Equal(t, "request-2", event.CausationID)
+	assert.Equal(t, tenantID, event.TenantID)
+
+	assert.Equal(t, Metadata{CorrelationID: "request-1", CausationID: event.ID.String(), TenantID: tenantID}, event.Consequences())
+
+	event.CorrelationID = ""
+	assert.Equal(t, event.ID.String(), event.Consequences().CorrelationID, "events without a correlation start one")
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/domain/valueobjects"
+)
+
+// Metadata is the envelope shared by the events published while serving a request or handling
+// an event, so a chain of events can be followed across asynchronous boundaries
+type Metadata struct {
+	CorrelationID string                // Request or workflow the events are part of
+	CausationID   string                // ID of the request or event causing the events
+	TenantID      valueobjects.TenantID // Empty for single tenant deployments
+	TraceParent   string                // W3C trace context, for events published without a live span
+	CommandID     string                // ID the caller gave the command causing the events, empty for none
+}
+
+// metadataKey is the context key of the metadata of the events published within a context
+type metadataKey struct{}
+
+// ContextWithMetadata returns a context whose published events are stamped with metadata
+func ContextWithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, metadata)
+}
+
+// MetadataFromContext returns the metadata of the events published within ctx, empty when none
+// was set
+func MetadataFromContext(ctx context.Context) Metadata {
+	metadata, _ := ctx.Value(metadataKey{}).(Metadata)
+	return metadata
+}
+
+// Stamp sets the envelope fields of the event left empty from metadata
+func (e *Event) Stamp(metadata Metadata) {
+	if e.CorrelationID == "" {
+		e.CorrelationID = metadata.CorrelationID
+	}
+	if e.CausationID == "" {
+		e.CausationID = metadata.CausationID
+	}
+	if e.TenantID.IsZero() {
+		e.TenantID = metadata.TenantID
+	}
+	if e.TraceParent == "" {
+		e.TraceParent = metadata.TraceParent
+	}
+}
+
+// Consequences returns the metadata of the events caused by handling the event: they share its
+// correlation ID, its own ID for events without one, and are caused by it
+func (e *Event) Consequences() Metadata {
+	correlationID := e.CorrelationID
+	if correlationID == "" {
+		correlationID = e.ID.String()
+	}
+	return Metadata{
+		CorrelationID: correlationID,
+		CausationID:   e.ID.String(),
+		TenantID:      e.TenantID,
+		TraceParent:   e.TraceParent,
+	}
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/domain/aggregates"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// EventSourcedRepository loads aggregates by replaying their event stream and saves them by
+// appending the events recorded on them, failing when another writer appended first
+type EventSourcedRepository[T aggregates.Aggregate] struct {
+	eventStore   VersionedEventStore
+	newAggregate func() T
+}
+
+// NewEventSourcedRepository creates a new event sourced repository of the aggregates newAggregate
+// creates empty
+func NewEventSourcedRepository[T aggregates.Aggregate](eventStore VersionedEventStore, newAggregate func() T) *EventSourcedRepository[T] {
+	return &EventSourcedRepository[T]{
+		eventStore:   eventStore,
+		newAggregate: newAggregate,
+	}
+}
+
+// EventSourcedUserRepository loads users from their event stream, so commands append their events
+// at the version of the stream they decided on
+type EventSourcedUserRepository = EventSourcedRepository[*aggregates.User]
+
+// NewEventSourcedUserRepository creates a new event sourced repository of users
+func NewEventSourcedUserRepository(eventStore VersionedEventStore) *EventSourcedUserRepository {
+	return NewEventSourcedRepository(eventStore, aggregates.NewUser)
+}
+
+// Load rebuilds an aggregate from its events; it fails with a NOT_FOUND error when there are none
+func (r *EventSourcedRepository[T]) Load(ctx context.Context, aggregateID string) (T, error) {
+	var zero T
+	history, err := r.eventStore.GetEvents(ctx, aggregateID)
+	if err != nil {
+		return zero, errors.Wrapf(err, errors.ErrEventStoreFailed, "failed to load events of aggregate %s", aggregateID)
+	}
+	if len(history) == 0 {
+		return zero, errors.Newf(errors.ErrNotFound, "aggregate %s not found", aggregateID)
+	}
+
+	aggregate := r.newAggregate()
+	for _, event := range history {
+		if err := aggregate.Apply(event); err != nil {
+			return zero, fmt.Errorf("failed to apply event %d of aggregate %s: %w", event.Version, aggregateID, err)
+		}
+		aggregate.ReplayedEvent(event)
+	}
+	return aggregate, nil
+}
+
+// Save appends the events recorded on an aggregate to its stream, expecting the stream to still
+// be at the version the aggregate was loaded at. On a ConcurrencyError the aggregate should be
+// loaded again and the command retried.
+func (r *EventSourcedRepository[T]) Save(ctx context.Context, aggregate T) error {
+	changes := aggregate.UncommittedEvents()
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := r.eventStore.AppendEvents(ctx, aggregate.AggregateID(), aggregate.AggregateVersion(), changes...); err != nil {
+		return err
+	}
+	aggregate.MarkCommitted()
+	return nil
+}
@@ -0,0 +1,102 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSourcedRepository_LoadAndSave(t *testing.T) {
+	ctx := context.Background()
+	store := &streamEventStore{streams: make(map[string][]*events.Event)}
+	repo := repositories.NewEventSourcedUserRepository(store)
+	userID := "0190b5a0-8c3e-7c3a-9b1e-2f4d6a8b0c1d"
+
+	_, err := repo.Load(ctx, userID)
+	assert.Equal(t, errors.ErrNotFound, errors.CodeOf(err, ""))
+
+	created, err := events.NewEvent("user.created", events.UserCreatedEvent{UserID: userID, Email: "john@example.com", Name: "John Doe", CreatedAt: time.Now()}, 0)
+	require.NoError(t, err)
+	require.NoError(t, store.AppendEvents(ctx, userID, 0, created))
+
+	user, err := repo.Load(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", user.GetName())
+	assert.Equal(t, "john@example.com", user.GetEmail())
+	assert.Equal(t, 1, user.AggregateVersion())
+
+	stale, err := repo.Load(ctx, userID)
+	require.NoError(t, err)
+
+	require.NoError(t, user.UpdateName("Jane Doe"))
+	updated, err := events.NewEvent("user.updated", events.UserUpdatedEvent{UserID: userID, Name: "Jane Doe", UpdatedAt: time.Now()}, 0)
+	require.NoError(t, err)
+	user.RecordEvent(updated)
+	assert.Equal(t, 2, updated.Version)
+	require.NoError(t, repo.Save(ctx, user))
+	assert.Equal(t, 2, user.AggregateVersion())
+	assert.Empty(t, user.UncommittedEvents())
+
+	deleted, err := events.NewEvent("user.deleted", events.UserDeletedEvent{UserID: userID, DeletedAt: time.Now()}, 0)
+	require.NoError(t, err)
+	stale.RecordEvent(deleted)
+	err = repo.Save(ctx, stale)
+	assert.Equal(t, errors.ErrConcurrencyConflict, errors.CodeOf(err, ""), "saving an aggregate loaded before the last save conflicts")
+	var conflict *repositories.ConcurrencyError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, repositories.ConcurrencyError{AggregateID: userID, ExpectedVersion: 1, ActualVersion: 2}, *conflict)
+
+	reloaded, err := repo.Load(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", reloaded.GetName())
+	assert.False(t, reloaded.IsDeleted())
+	assert.False(t, reloaded.IsEmailVerified())
+
+	verified, err := events.NewUserEmailVerifiedEvent(userID, "john@example.com", time.Now())
+	require.NoError(t, err)
+	reset, err := events.NewPasswordResetEvent(userID, "reset-1")
+	require.NoError(t, err)
+	reloaded.RecordEvent(verified)
+	reloaded.RecordEvent(reset)
+	require.NoError(t, repo.Save(ctx, reloaded))
+
+	reloaded, err = repo.Load(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, reloaded.IsEmailVerified())
+	reloaded.RecordEvent(deleted)
+	require.NoError(t, repo.Save(ctx, reloaded))
+
+	reloaded, err = repo.Load(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, reloaded.IsDeleted())
+	assert.Equal(t, 5, reloaded.AggregateVersion())
+}
+
+// streamEventStore is an in-memory event store appending with optimistic concurrency
+type streamEventStore struct {
+	mockEventStore
+	streams map[string][]*events.Event
+}
+
+func (s *streamEventStore) GetEvents(ctx context.Context, aggregateID string) ([]*events.Event, error) {
+	return s.streams[aggregateID], nil
+}
+
+func (s *streamEventStore) AppendEvents(ctx context.Context, aggregateID string, expectedVersion int, appended ...*events.Event) error {
+	if len(s.streams[aggregateID]) != expectedVersion {
+		return repositories.NewConcurrencyError(aggregateID, expectedVersion, len(s.streams[aggregateID]))
+	}
+	for i, event := range appended {
+		stored := *event
+		stored.Version = expectedVersion + i + 1
+		s.streams[aggregateID] = append(s.streams[aggregateID], &stored)
+	}
+	return nil
+}
@@ -2,9 +2,11 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/pkg/errors"
 )
 
 // EventStore defines the interface for event storage
@@ -30,3 +32,106 @@ type EventPublisher interface {
 	// PublishEvents publishes multiple domain events
 	PublishEvents(ctx context.Context, events []*events.Event) error
 }
+
+// DelayedEventPublisher defines the interface for publishing events after a delay, e.g. saga
+// timeouts, whatever the message broker
+type DelayedEventPublisher interface {
+	// PublishEventAfter publishes a domain event once delay elapsed
+	PublishEventAfter(ctx context.Context, event *events.Event, delay time.Duration) error
+}
+
+// VersionedEventStore defines the interface for event stores appending to the stream of an
+// aggregate with optimistic concurrency
+type VersionedEventStore interface {
+	EventStore
+
+	// AppendEvents appends events to the stream of an aggregate, numbered from expectedVersion+1.
+	// It fails with a ConcurrencyError when the stream is no longer at expectedVersion.
+	AppendEvents(ctx context.Context, aggregateID string, expectedVersion int, events ...*events.Event) error
+}
+
+// CausationChecker defines the interface for event stores looking events up by their causation,
+// to detect commands handled before. Event stores implementing it also record the command ID of
+// the context appending events, failing appends of a command handled concurrently under the same
+// ID with NewDuplicateCommandError.
+type CausationChecker interface {
+	// HasCausation reports whether events caused by causationID were stored
+	HasCausation(ctx context.Context, causationID string) (bool, error)
+}
+
+// EventPosition is the position of an event in the whole event store, which is read in the order
+// of global positions. Events are numbered in the order they are committed, so reading after the
+// global position of the last event read resumes exactly where it stopped. Positions saved before
+// events were numbered have no global position and are read after in the order events were
+// created, then by aggregate and version.
+type EventPosition struct {
+	GlobalPosition int64 // 0 when unknown
+	StoredAt       time.Time
+	AggregateID    string
+	Version        int
+}
+
+// StoredEvent is an event read from the whole event store, with the aggregate it belongs to
+type StoredEvent struct {
+	ID             string // ID of the stored event, stable across reads
+	GlobalPosition int64  // Position of the event in the whole event store, from 1
+	AggregateID    string
+	Event          *events.Event
+}
+
+// Position returns the position of the event in the event store
+func (e *StoredEvent) Position() EventPosition {
+	return EventPosition{GlobalPosition: e.GlobalPosition, StoredAt: e.Event.Timestamp, AggregateID: e.AggregateID, Version: e.Event.Version}
+}
+
+// EventLog defines the interface for event stores read as a whole, e.g. to rebuild projections
+type EventLog interface {
+	// CountEvents returns the number of events stored
+	CountEvents(ctx context.Context) (int, error)
+
+	// ReadEvents returns up to limit events stored after a position, from the first event when
+	// after is nil
+	ReadEvents(ctx context.Context, after *EventPosition, limit int) ([]*StoredEvent, error)
+}
+
+// EventSubscriber defines the interface for event stores streaming events as they are stored, so
+// projections and sagas running in the same process react to them without the message broker.
+// Streams are closed once ctx is done.
+type EventSubscriber interface {
+	// Subscribe streams the events of an aggregate stored after afterVersion, in version order
+	Subscribe(ctx context.Context, aggregateID string, afterVersion int) (<-chan *events.Event, error)
+
+	// SubscribeAll streams the events stored after a position, from the first event when after is
+	// nil, in the order of ReadEvents
+	SubscribeAll(ctx context.Context, after *EventPosition) (<-chan *StoredEvent, error)
+}
+
+// ConcurrencyError is the error of appending to the stream of an aggregate at an expected version
+// once another writer advanced it. Event stores return it wrapped in a CONCURRENCY_CONFLICT
+// AppError, see NewConcurrencyError.
+type ConcurrencyError struct {
+	AggregateID     string
+	ExpectedVersion int
+	ActualVersion   int // Version found, a lower bound when a concurrent append raced the check
+}
+
+// NewConcurrencyError returns the CONCURRENCY_CONFLICT error of appending to the stream of an
+// aggregate at expectedVersion when it is at actualVersion
+func NewConcurrencyError(aggregateID string, expectedVersion, actualVersion int) error {
+	return errors.ConcurrencyConflict(aggregateID, &ConcurrencyError{
+		AggregateID:     aggregateID,
+		ExpectedVersion: expectedVersion,
+		ActualVersion:   actualVersion,
+	})
+}
+
+// NewDuplicateCommandError returns the DUPLICATE_COMMAND error of appending the events of a
+// command whose ID was recorded by another append
+func NewDuplicateCommandError(commandID string) error {
+	return errors.DuplicateCommand(commandID)
+}
+
+// Error implements the error interface
+func (e *ConcurrencyError) Error() string {
+	return fmt.Sprintf("aggregate %s is at version %d, expected version %d", e.AggregateID, e.ActualVersion, e.ExpectedVersion)
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+)
+
+// InboxRepository defines the interface of the inbox of consumed events, applying each event to
+// the projections of a consumer exactly once
+type InboxRepository interface {
+	// Apply runs apply unless the consumer already processed the event, and records the event as
+	// processed in the same transaction as the writes apply makes with the context it is given.
+	// It reports whether apply ran. Failures are recorded with their error.
+	Apply(ctx context.Context, consumer, eventID, eventType string, apply func(ctx context.Context) error) (bool, error)
+	// GetEntry returns the entry of an event consumed by a consumer, nil when there is none
+	GetEntry(ctx context.Context, consumer, eventID string) (*entities.InboxEntry, error)
+}
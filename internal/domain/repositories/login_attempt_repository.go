@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttemptRepository defines the interface of the record of failed logins, by key, e.g. of a
+// user or a client address, so keys failing too often are locked out
+type LoginAttemptRepository interface {
+	// RecordFailure records a failed login of a key, returning its failures within window: the
+	// count starts over once window elapsed since the first failure counted
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+
+	// Lock locks a key out until a time, and starts its count of failures over
+	Lock(ctx context.Context, key string, until time.Time) error
+
+	// LockedUntil returns the end of the lockout of a key, the zero time when it is not locked out
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+
+	// Reset forgets the failures of a key, e.g. once it logged in
+	Reset(ctx context.Context, key string) error
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// MagicLinkRepository defines the interface of the record of magic links consumed, so each link
+// signs in once
+type MagicLinkRepository interface {
+	// Consume records that a link was consumed, returning false when it already was. Links may
+	// be forgotten once expired, their token is rejected then.
+	Consume(ctx context.Context, linkID, userID string, expiresAt time.Time) (bool, error)
+}
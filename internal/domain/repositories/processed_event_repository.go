@@ -0,0 +1,13 @@
+package repositories
+
+import "context"
+
+// ProcessedEventRepository defines the interface of the record of events processed by each
+// consumer group, used to skip events redelivered or replayed by the message broker
+type ProcessedEventRepository interface {
+	// IsProcessed reports whether the consumer group already processed the event
+	IsProcessed(ctx context.Context, consumerGroup, eventID string) (bool, error)
+	// MarkProcessed records that the consumer group processed the event. Marking an event twice
+	// is not an error.
+	MarkProcessed(ctx context.Context, consumerGroup, eventID, eventType string) error
+}
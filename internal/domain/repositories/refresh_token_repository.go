@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshTokenRepository defines the interface of the record of refresh tokens issued, so each
+// token is rotated once and a family of tokens can be revoked
+type RefreshTokenRepository interface {
+	// Issue records a refresh token of a family. Tokens may be forgotten once expired, their
+	// token is rejected then.
+	Issue(ctx context.Context, tokenID, familyID, userID string, expiresAt time.Time) error
+
+	// Rotate records that a token of a family was exchanged, returning false when it already was,
+	// its family is revoked or it was never issued
+	Rotate(ctx context.Context, tokenID, familyID string) (bool, error)
+
+	// RevokeFamily revokes every token of a family, issued or to be issued
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// RevokeUser revokes every family of a user, e.g. once their password was reset, so no
+	// session signed in before renews anymore
+	RevokeUser(ctx context.Context, userID string) error
+}
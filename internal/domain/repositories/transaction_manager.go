@@ -0,0 +1,13 @@
+package repositories
+
+import "context"
+
+// TransactionManager runs units of work atomically on the write database
+type TransactionManager interface {
+	// WithinTransaction runs fn in a transaction, committed when fn succeeds and rolled back
+	// otherwise. Repositories called with the context fn is given join the transaction. operation
+	// names the unit of work, e.g. in metrics. fn may be run again in a new transaction when the
+	// transaction conflicts with a concurrent one, so it must not have effects outside of it that
+	// cannot be repeated.
+	WithinTransaction(ctx context.Context, operation string, fn func(ctx context.Context) error) error
+}
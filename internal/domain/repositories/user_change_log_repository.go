@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+)
+
+// UserChangeLogRepository defines the interface of the append-only user change log external
+// systems sync users from
+type UserChangeLogRepository interface {
+	// AppendChange records a change with the next sequence, ignoring changes whose key is
+	// already recorded
+	AppendChange(ctx context.Context, change *entities.UserChange) error
+	// ListChanges returns up to limit changes with a sequence after the given one, in sequence order
+	ListChanges(ctx context.Context, after int64, limit int) ([]*entities.UserChange, error)
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import "context"
+
+// UserCountRepository defines the interface of the user counters: the number of users by status,
+// maintained by the projections so list queries do not count documents on every request
+type UserCountRepository interface {
+	// Increment adds delta to the number of users with a status
+	Increment(ctx context.Context, status string, delta int64) error
+	// Counts returns the number of users by status; statuses never counted are missing
+	Counts(ctx context.Context) (map[string]int64, error)
+	// Reset replaces the number of users of the given statuses, e.g. once reconciled with the
+	// write database
+	Reset(ctx context.Context, counts map[string]int64) error
+}
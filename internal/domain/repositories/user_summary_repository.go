@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+)
+
+// UserSummaryRepository defines the interface of the user summary projection, the compact
+// documents list queries read instead of full read models
+type UserSummaryRepository interface {
+	// SaveSummary creates or replaces the summary of a user, keeping its last login
+	SaveSummary(ctx context.Context, summary *entities.UserSummary) error
+	// UpdateName sets the name of a user's summary
+	UpdateName(ctx context.Context, userID, name string) error
+	// MarkDeleted sets the status of a user's summary to deleted, hiding it from lists
+	MarkDeleted(ctx context.Context, userID string) error
+	// RecordLogin sets the last login of a user's summary
+	RecordLogin(ctx context.Context, userID string, at time.Time) error
+	// ListSummaries returns a page of active users, newest first, and the number of active users
+	ListSummaries(ctx context.Context, page, pageSize int) ([]*entities.UserSummary, int64, error)
+}
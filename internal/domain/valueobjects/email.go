@@ -1,20 +1,16 @@
 package valueobjects
 
-import (
-	"errors"
-	"regexp"
-
-	"go-clean-ddd-es-template/pkg/utils"
-)
+import "go-clean-ddd-es-template/internal/domain/entities"
 
 // Email represents an email address value object
 type Email struct {
 	value string
 }
 
-// NewEmail creates a new Email value object with validation
+// NewEmail creates a new Email value object with validation.
+// Validation rules are shared with the Email entity; the address is kept as given.
 func NewEmail(email string) (Email, error) {
-	if err := validateEmail(email); err != nil {
+	if _, err := entities.NewEmail(email); err != nil {
 		return Email{}, err
 	}
 	return Email{value: email}, nil
@@ -35,30 +31,6 @@ func (e Email) Equals(other Email) bool {
 	return e.value == other.value
 }
 
-// validateEmail validates email format
-func validateEmail(email string) error {
-	if email == "" {
-		return errors.New("email is required")
-	}
-
-	if !utils.IsValidEmail(email) {
-		return errors.New("invalid email format")
-	}
-
-	// Additional domain-specific validation
-	if len(email) > 254 {
-		return errors.New("email is too long")
-	}
-
-	// Check for valid characters
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegex.MatchString(email) {
-		return errors.New("email contains invalid characters")
-	}
-
-	return nil
-}
-
 // MustNewEmail creates a new Email value object and panics if validation fails
 // Use only in tests or when you're certain the email is valid
 func MustNewEmail(email string) Email {
@@ -0,0 +1,189 @@
+package valueobjects
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"go-clean-ddd-es-template/pkg/id"
+)
+
+// idPattern matches opaque identifiers: UUIDs, ULIDs, slugs and timestamp based IDs
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+
+// IDKind identifies the kind of a TypedID. Each aggregate declares its own kind so
+// identifiers of different aggregates cannot be mixed up at compile time.
+type IDKind interface {
+	// Name returns the kind name used in error messages, e.g. "product"
+	Name() string
+}
+
+// TypedID is an identifier value object of kind K
+type TypedID[K IDKind] struct {
+	value string
+}
+
+// NewTypedID creates a new time ordered identifier of kind K
+func NewTypedID[K IDKind]() TypedID[K] {
+	return TypedID[K]{value: id.NewUUIDv7()}
+}
+
+// ParseTypedID creates an identifier of kind K from a string with validation
+func ParseTypedID[K IDKind](id string) (TypedID[K], error) {
+	if id == "" {
+		return TypedID[K]{}, fmt.Errorf("%s ID is required", kindName[K]())
+	}
+	if !idPattern.MatchString(id) {
+		return TypedID[K]{}, fmt.Errorf("invalid %s ID format: %q", kindName[K](), id)
+	}
+	return TypedID[K]{value: id}, nil
+}
+
+// MustParseTypedID creates an identifier of kind K and panics if validation fails
+// Use only in tests or when you're certain the ID is valid
+func MustParseTypedID[K IDKind](id string) TypedID[K] {
+	typed, err := ParseTypedID[K](id)
+	if err != nil {
+		panic(err)
+	}
+	return typed
+}
+
+// String returns the identifier as a string
+func (id TypedID[K]) String() string {
+	return id.value
+}
+
+// Equals checks if two identifiers are equal
+func (id TypedID[K]) Equals(other TypedID[K]) bool {
+	return id.value == other.value
+}
+
+// IsZero checks if the identifier is zero value
+func (id TypedID[K]) IsZero() bool {
+	return id.value == ""
+}
+
+// MarshalJSON encodes the identifier as a JSON string
+func (id TypedID[K]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(id.value)
+}
+
+// UnmarshalJSON decodes and validates a JSON string; an empty string or null yields the zero value
+func (id *TypedID[K]) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%s ID must be a string: %w", kindName[K](), err)
+	}
+	return id.set(value)
+}
+
+// MarshalText encodes the identifier as text
+func (id TypedID[K]) MarshalText() ([]byte, error) {
+	return []byte(id.value), nil
+}
+
+// UnmarshalText decodes and validates text
+func (id *TypedID[K]) UnmarshalText(text []byte) error {
+	value := string(text)
+	return id.set(&value)
+}
+
+// Value implements driver.Valuer; the zero value is stored as NULL
+func (id TypedID[K]) Value() (driver.Value, error) {
+	if id.IsZero() {
+		return nil, nil
+	}
+	return id.value, nil
+}
+
+// Scan implements sql.Scanner
+func (id *TypedID[K]) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*id = TypedID[K]{}
+		return nil
+	case string:
+		return id.set(&v)
+	case []byte:
+		value := string(v)
+		return id.set(&value)
+	default:
+		return fmt.Errorf("cannot scan %T into %s ID", src, kindName[K]())
+	}
+}
+
+// set validates and assigns a raw value; nil and empty values reset the identifier
+func (id *TypedID[K]) set(value *string) error {
+	if value == nil || *value == "" {
+		*id = TypedID[K]{}
+		return nil
+	}
+	parsed, err := ParseTypedID[K](*value)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// kindName returns the name of kind K
+func kindName[K IDKind]() string {
+	var kind K
+	return kind.Name()
+}
+
+// eventKind is the IDKind of domain events
+type eventKind struct{}
+
+func (eventKind) Name() string { return "event" }
+
+// productKind is the IDKind of products
+type productKind struct{}
+
+func (productKind) Name() string { return "product" }
+
+// tenantKind is the IDKind of tenants
+type tenantKind struct{}
+
+func (tenantKind) Name() string { return "tenant" }
+
+// EventID identifies a domain event
+type EventID = TypedID[eventKind]
+
+// ProductID identifies a product aggregate
+type ProductID = TypedID[productKind]
+
+// TenantID identifies a tenant
+type TenantID = TypedID[tenantKind]
+
+// NewEventID creates a new time ordered event ID
+func NewEventID() EventID {
+	return NewTypedID[eventKind]()
+}
+
+// ParseEventID creates an event ID from a string with validation
+func ParseEventID(id string) (EventID, error) {
+	return ParseTypedID[eventKind](id)
+}
+
+// NewProductID creates a new time ordered product ID
+func NewProductID() ProductID {
+	return NewTypedID[productKind]()
+}
+
+// ParseProductID creates a product ID from a string with validation
+func ParseProductID(id string) (ProductID, error) {
+	return ParseTypedID[productKind](id)
+}
+
+// NewTenantID creates a new time ordered tenant ID
+func NewTenantID() TenantID {
+	return NewTypedID[tenantKind]()
+}
+
+// ParseTenantID creates a tenant ID from a string with validation
+func ParseTenantID(id string) (TenantID, error) {
+	return ParseTypedID[tenantKind](id)
+}
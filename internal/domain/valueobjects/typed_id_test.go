@@ -0,0 +1,112 @@
+package valueobjects
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTypedID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{name: "uuid", id: "3f2504e0-4f89-41d3-9a0c-0305e82c3301"},
+		{name: "slug", id: "product-123"},
+		{name: "timestamp", id: "20240102150405"},
+		{name: "namespaced", id: "sku:ABC.42"},
+		{name: "empty", id: "", wantErr: true},
+		{name: "whitespace", id: "product 123", wantErr: true},
+		{name: "leading separator", id: "-product", wantErr: true},
+		{name: "injection", id: "1;DROP TABLE", wantErr: true},
+		{name: "too long", id: string(make([]byte, 129)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := ParseProductID(tt.id)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.True(t, id.IsZero())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.id, id.String())
+		})
+	}
+}
+
+func TestTypedID_ErrorMentionsKind(t *testing.T) {
+	_, err := ParseTenantID("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenant")
+}
+
+func TestTypedID_NewAndEquals(t *testing.T) {
+	id1 := NewProductID()
+	id2 := NewProductID()
+
+	assert.False(t, id1.IsZero())
+	assert.False(t, id1.Equals(id2))
+	assert.True(t, id1.Equals(MustParseTypedID[productKind](id1.String())))
+}
+
+func TestTypedID_JSON(t *testing.T) {
+	type payload struct {
+		ProductID ProductID `json:"product_id"`
+		TenantID  TenantID  `json:"tenant_id,omitzero"`
+	}
+
+	id := MustParseTypedID[productKind]("product-123")
+	data, err := json.Marshal(payload{ProductID: id})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"product_id":"product-123"}`, string(data))
+
+	var decoded payload
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, id.Equals(decoded.ProductID))
+	assert.True(t, decoded.TenantID.IsZero())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"product_id":"bad id"}`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"product_id":42}`), &decoded))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"product_id":null}`), &decoded))
+	assert.True(t, decoded.ProductID.IsZero())
+}
+
+func TestTypedID_SQL(t *testing.T) {
+	id := MustParseTypedID[eventKind]("20240102150405")
+
+	value, err := id.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "20240102150405", value)
+
+	value, err = EventID{}.Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	var scanned EventID
+	require.NoError(t, scanned.Scan([]byte("20240102150405")))
+	assert.True(t, id.Equals(scanned))
+
+	require.NoError(t, scanned.Scan(nil))
+	assert.True(t, scanned.IsZero())
+
+	assert.Error(t, scanned.Scan(42))
+	assert.Error(t, scanned.Scan("bad id"))
+}
+
+func TestTypedID_Text(t *testing.T) {
+	ids := map[TenantID]int{MustParseTypedID[tenantKind]("acme"): 1}
+
+	data, err := json.Marshal(ids)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"acme":1}`, string(data))
+
+	var decoded map[TenantID]int
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, ids, decoded)
+}
@@ -1,102 +1,766 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Config struct {
-	Server        ServerConfig
-	WriteDatabase DatabaseConfig
-	ReadDatabase  DatabaseConfig
-	EventDatabase DatabaseConfig
-	MessageBroker MessageBrokerConfig
-	Tracing       TracingConfig
-	Log           LogConfig
-	I18n          I18nConfig
-	Auth          AuthConfig
+	Server            ServerConfig
+	Environment       EnvironmentConfig
+	WriteDatabase     DatabaseConfig    `envPrefix:"WRITE_DB_"`
+	ReadDatabase      DatabaseConfig    `envPrefix:"READ_DB_"`
+	ReadShards        []ReadShardConfig `env:"READ_SHARDS" sensitive:"true"`
+	EventDatabase     DatabaseConfig    `envPrefix:"EVENT_DB_"`
+	MessageBroker     MessageBrokerConfig
+	Tenancy           TenancyConfig
+	Tracing           TracingConfig
+	OTelMetrics       OTelMetricsConfig
+	Log               LogConfig
+	I18n              I18nConfig
+	Auth              AuthConfig
+	Authorization     AuthorizationConfig
+	Autoscaling       AutoscalingConfig
+	Debug             DebugConfig
+	Admin             AdminConfig
+	QueryExplain      QueryExplainConfig
+	MongoIndexes      MongoIndexConfig
+	ReadModel         ReadModelConfig
+	CommandRules      CommandRulesConfig
+	Storage           StorageConfig
+	Email             EmailConfig
+	ResponseCache     ResponseCacheConfig
+	EmailIndex        EmailIndexConfig
+	RequestCost       RequestCostConfig
+	Control           ControlConfig
+	Approvals         ApprovalConfig
+	Replay            ReplayConfig
+	Components        ComponentsConfig
+	APIVersions       APIVersionsConfig
+	RateLimit         RateLimitConfig
+	Migrations        MigrationConfig
+	Changefeed        ChangefeedConfig
+	EventGateway      EventGatewayConfig
+	Supervisor        SupervisorConfig
+	Concurrency       ConcurrencyLimitConfig
+	Faults            FailureInjectionConfig
+	Outbox            OutboxConfig
+	TransactionRetry  TransactionRetryConfig
+	Encryption        EncryptionConfig
+	Standby           StandbyConfig
+	Projections       ProjectionsConfig
+	Subscriptions     EventSubscriptionConfig
+	Operations        OperationsConfig
+	AuditLog          AuditLogConfig
+	StatsHistory      StatsHistoryConfig
+	MagicLink         MagicLinkConfig
+	PasswordReset     PasswordResetConfig
+	EmailVerification EmailVerificationConfig
+	RefreshTokens     RefreshTokenConfig
+	LoginLockout      LoginLockoutConfig
+	Notification      NotificationConfig
+	Preferences       PreferencesConfig
+	FeatureFlags      map[string]bool `env:"FEATURE_FLAGS"`
 }
 
 type ServerConfig struct {
-	Port string
+	Port                 string        `env:"PORT"`
+	ShutdownDrainTimeout time.Duration `env:"SHUTDOWN_DRAIN_TIMEOUT" desc:"Time given to the server, consumers, jobs and database pools to stop on SIGTERM"`
+	HealthCheckInterval  time.Duration `env:"HEALTH_CHECK_INTERVAL" desc:"How often the gRPC health status is refreshed from the liveness and readiness checks"`
+	TrustedProxies       []string      `env:"TRUSTED_PROXIES" desc:"Addresses or CIDR ranges of the proxies whose x-forwarded-for gives the client address; the HTTP gateway of the service is always trusted"`
+}
+
+// TrustedProxyNetworks parses the trusted proxies, single addresses being networks of one address
+func (c ServerConfig) TrustedProxyNetworks() ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		cidr := proxy
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// EnvironmentConfig names the deployment the service runs in
+type EnvironmentConfig struct {
+	Name            string   `env:"APP_ENV" desc:"Name of the deployment, e.g. 'staging', typed to confirm dangerous commands in production"`
+	ProductionNames []string `env:"APP_PRODUCTION_ENVS" desc:"Deployment names treated as production, where dangerous commands require --yes and the name typed"`
+}
+
+// IsEnvironment tells whether name is the name of the environment, ignoring case
+func (c *Config) IsEnvironment(name string) bool {
+	return strings.EqualFold(name, c.Environment.Name)
+}
+
+// IsProduction tells whether the deployment is production: its name is one of the production
+// names, or MIGRATE_PRODUCTION is true
+func (c *Config) IsProduction() bool {
+	if c.Migrations.Production {
+		return true
+	}
+	for _, name := range c.Environment.ProductionNames {
+		if c.IsEnvironment(name) {
+			return true
+		}
+	}
+	return false
 }
 
 type DatabaseConfig struct {
-	Type     string // "postgres", "mysql", "mongodb"
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
+	Type     string `env:"TYPE" desc:"'postgres', 'mysql', 'mongodb'"`
+	Host     string `env:"HOST"`
+	Port     string `env:"PORT"`
+	User     string `env:"USER"`
+	Password string `env:"PASSWORD" sensitive:"true"`
+	DBName   string `env:"NAME"`
 	// MongoDB specific
-	URI        string
-	Collection string
+	URI        string `env:"URI" sensitive:"true"`
+	Collection string `env:"COLLECTION"`
 	// MySQL specific
-	Charset   string
-	ParseTime bool
-	Loc       string
+	Charset   string `env:"CHARSET"`
+	ParseTime bool   `env:"PARSE_TIME"`
+	Loc       string `env:"LOC"`
 	// Connection Pool Configuration
-	MaxOpenConns    int           // Maximum number of open connections to the database
-	MaxIdleConns    int           // Maximum number of idle connections in the pool
-	ConnMaxLifetime time.Duration // Maximum amount of time a connection may be reused
-	ConnMaxIdleTime time.Duration // Maximum amount of time a connection can be idle
+	MaxOpenConns    int           `env:"MAX_OPEN_CONNS" desc:"Maximum number of open connections to the database"`
+	MaxIdleConns    int           `env:"MAX_IDLE_CONNS" desc:"Maximum number of idle connections in the pool"`
+	ConnMaxLifetime time.Duration `env:"CONN_MAX_LIFETIME" desc:"Maximum amount of time a connection may be reused"`
+	ConnMaxIdleTime time.Duration `env:"CONN_MAX_IDLE_TIME" desc:"Maximum amount of time a connection can be idle"`
+}
+
+// SameDatabase reports whether c and other name the same database on the same server
+func (c DatabaseConfig) SameDatabase(other DatabaseConfig) bool {
+	return c.Type == other.Type && c.Host == other.Host && c.Port == other.Port && c.DBName == other.DBName && c.URI == other.URI
 }
 
 type MessageBrokerConfig struct {
-	Type    string // "kafka", "rabbitmq", "redis", "nats"
-	Brokers []string
+	Type    string   `env:"MESSAGE_BROKER_TYPE" desc:"'kafka', 'rabbitmq', 'redis', 'nats'"`
+	Brokers []string `env:"MESSAGE_BROKER_BROKERS"`
 	Topics  map[string]string
 	// Kafka specific
-	GroupID string
+	GroupID  string `env:"MESSAGE_BROKER_GROUP_ID"`
+	ClientID string `env:"MESSAGE_BROKER_CLIENT_ID" desc:"Client identity reported to the Kafka brokers, e.g. for quotas and ACL audit logs"`
+	// Kafka authentication and encryption
+	SASLMechanism         string `env:"MESSAGE_BROKER_SASL_MECHANISM" desc:"'PLAIN', 'SCRAM-SHA-256' or 'SCRAM-SHA-512'; empty connects without SASL"`
+	SASLUsername          string `env:"MESSAGE_BROKER_SASL_USERNAME"`
+	SASLPassword          string `env:"MESSAGE_BROKER_SASL_PASSWORD" sensitive:"true"`
+	TLSEnabled            bool   `env:"MESSAGE_BROKER_TLS_ENABLED" desc:"Whether connections to the Kafka brokers are encrypted"`
+	TLSCAFile             string `env:"MESSAGE_BROKER_TLS_CA_FILE" desc:"PEM CA certificates the brokers are verified against; empty uses the system pool"`
+	TLSCertFile           string `env:"MESSAGE_BROKER_TLS_CERT_FILE" desc:"PEM client certificate for mutual TLS, set with MESSAGE_BROKER_TLS_KEY_FILE"`
+	TLSKeyFile            string `env:"MESSAGE_BROKER_TLS_KEY_FILE" desc:"PEM private key of the client certificate"`
+	TLSServerName         string `env:"MESSAGE_BROKER_TLS_SERVER_NAME" desc:"Name the broker certificates are verified against; empty uses the broker host"`
+	TLSInsecureSkipVerify bool   `env:"MESSAGE_BROKER_TLS_INSECURE_SKIP_VERIFY" desc:"Whether broker certificates are accepted unverified, for development only"`
 	// RabbitMQ specific
-	Exchange string
-	Queue    string
-	// Redis specific
-	Channel string
+	Exchange string `env:"MESSAGE_BROKER_EXCHANGE"`
+	Queue    string `env:"MESSAGE_BROKER_QUEUE"`
+	// Redis specific, streams are consumed by the consumer group GroupID
+	StreamMaxLen int `env:"MESSAGE_BROKER_STREAM_MAX_LEN" desc:"Approximate number of entries kept per Redis stream, 0 keeps every entry"`
 	// NATS specific
-	Subject string
+	Subject string `env:"MESSAGE_BROKER_SUBJECT"`
 	// Worker Pool Configuration
-	PublisherWorkers int // Number of workers for publishing events
-	ConsumerWorkers  int // Number of workers for consuming events
-	WorkerBufferSize int // Buffer size for worker channels
+	PublisherWorkers int `env:"MESSAGE_BROKER_PUBLISHER_WORKERS" desc:"Number of workers of WorkerPoolEventPublisher publishing events"`
+	ConsumerWorkers  int `env:"MESSAGE_BROKER_CONSUMER_WORKERS" desc:"Number of workers of WorkerPoolEventConsumer handling events"`
+	WorkerBufferSize int `env:"MESSAGE_BROKER_WORKER_BUFFER_SIZE" desc:"Jobs queued for the publisher and consumer workers before callers block"`
+	// Handler retries
+	RetryAttempts  int                          `env:"MESSAGE_BROKER_RETRY_ATTEMPTS" desc:"Attempts at handling a consumed event, including the first, before it is redelivered or dead lettered"`
+	RetryBaseDelay time.Duration                `env:"MESSAGE_BROKER_RETRY_BASE_DELAY" desc:"Wait after the first failed attempt at handling an event, doubled after every further one"`
+	RetryMaxDelay  time.Duration                `env:"MESSAGE_BROKER_RETRY_MAX_DELAY" desc:"Longest wait between attempts at handling an event"`
+	RetryJitter    float64                      `env:"MESSAGE_BROKER_RETRY_JITTER" desc:"Fraction of each wait between attempts that is random, from 0 to 1"`
+	RetryPolicies  map[string]RetryPolicyConfig `env:"MESSAGE_BROKER_RETRY_POLICIES" desc:"Retry policy per topic or event type as attempts:base delay:max delay:jitter; omitted parts are the defaults"`
+	// Retry topics
+	MaxRedeliveries  int    `env:"MESSAGE_BROKER_MAX_REDELIVERIES" desc:"Redeliveries of a failed message through its retry topic; 0 sends failures straight to the dead letter queue"`
+	RetryTopicFormat string `env:"MESSAGE_BROKER_RETRY_TOPIC_FORMAT" desc:"Retry topic name, {topic} is replaced with the original topic"`
+	// Dead letter queue storage
+	DLQStorage     string `env:"MESSAGE_BROKER_DLQ_STORAGE" desc:"Where dead letter events are kept: 'memory' or 'kafka' (dead letter topics)"`
+	DLQTopicFormat string `env:"MESSAGE_BROKER_DLQ_TOPIC_FORMAT" desc:"Dead letter topic name with kafka DLQ storage, {topic} is replaced with the original topic"`
+	// Background retries of dead letter events
+	DLQAutoRetry     bool          `env:"MESSAGE_BROKER_DLQ_AUTO_RETRY" desc:"Whether dead letter events are retried in the background instead of only by operators"`
+	DLQMaxAttempts   int           `env:"MESSAGE_BROKER_DLQ_MAX_ATTEMPTS" desc:"Retries of a dead letter event before it is left to operators"`
+	DLQRetryDelay    time.Duration `env:"MESSAGE_BROKER_DLQ_RETRY_DELAY" desc:"Wait before the first background retry of a dead letter event, doubled for every further retry"`
+	DLQMaxRetryDelay time.Duration `env:"MESSAGE_BROKER_DLQ_MAX_RETRY_DELAY" desc:"Longest wait between background retries of a dead letter event"`
+	DLQScanInterval  time.Duration `env:"MESSAGE_BROKER_DLQ_SCAN_INTERVAL" desc:"How often dead letter events due for a retry are looked for"`
+	// Message age limits
+	MaxMessageAge        map[string]time.Duration `env:"MESSAGE_BROKER_MAX_MESSAGE_AGE" desc:"Age limit per topic after which events are expired instead of processed"`
+	DefaultMaxMessageAge time.Duration            `env:"MESSAGE_BROKER_DEFAULT_MAX_MESSAGE_AGE" desc:"Age limit of topics without their own, 0 for none"`
+	ExpiredTopicFormat   string                   `env:"MESSAGE_BROKER_EXPIRED_TOPIC_FORMAT" desc:"Topic expired events are routed to, {topic} is replaced; empty skips them"`
+	// Versioned topics
+	TopicVersions         map[string]int       `env:"MESSAGE_BROKER_TOPIC_VERSIONS" desc:"Current schema version per topic, published to its versioned topic"`
+	VersionedTopicFormat  string               `env:"MESSAGE_BROKER_VERSIONED_TOPIC_FORMAT" desc:"Versioned topic name, {topic} and {version} are replaced"`
+	DualPublishUntil      map[string]time.Time `env:"MESSAGE_BROKER_DUAL_PUBLISH_UNTIL" desc:"End of the deprecation window per topic, until which its previous version is still published"`
+	ConsumerTopicVersions map[string]int       `env:"MESSAGE_BROKER_CONSUMER_TOPIC_VERSIONS" desc:"Versions consumers not migrated yet keep consuming instead of the latest"`
+	// Payload schemas
+	ValidateSchemas bool   `env:"MESSAGE_BROKER_VALIDATE_SCHEMAS" desc:"Whether consumed payloads are validated against the schema of their event type and version before handlers run"`
+	SchemaDir       string `env:"MESSAGE_BROKER_SCHEMA_DIR" desc:"Directory of JSON schemas named <event type>[.v<version>].json, added to the built-in schemas"`
+	StrictSchemas   bool   `env:"MESSAGE_BROKER_STRICT_SCHEMAS" desc:"Whether events without a schema are quarantined too instead of handled unvalidated"`
+	// Idempotent consumers
+	IdempotentConsumers bool `env:"MESSAGE_BROKER_IDEMPOTENT_CONSUMERS" desc:"Whether events processed by the consumer group are recorded in the event database and skipped when redelivered or replayed"`
+	// Delayed delivery
+	RetryDelay          time.Duration `env:"MESSAGE_BROKER_RETRY_DELAY" desc:"Delay of the first redelivery of a failed message through its retry topic, doubled for every further one; 0 redelivers right away"`
+	DelayedStore        string        `env:"MESSAGE_BROKER_DELAYED_STORE" desc:"Where messages delayed on brokers without native delays, e.g. Kafka, wait: 'memory' or 'postgres' (write database)"`
+	DelayedPollInterval time.Duration `env:"MESSAGE_BROKER_DELAYED_POLL_INTERVAL" desc:"How often the delay scheduler publishes the due messages of the postgres store"`
+}
+
+// RetryPolicyConfig is how often the handler of an event is attempted and how long to wait
+// between attempts
+type RetryPolicyConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64
+}
+
+// String formats the policy like MESSAGE_BROKER_RETRY_POLICIES entries, attempts:base delay:max delay:jitter
+func (p RetryPolicyConfig) String() string {
+	return fmt.Sprintf("%d:%s:%s:%s", p.MaxAttempts, p.BaseDelay, p.MaxDelay, strconv.FormatFloat(p.Jitter, 'g', -1, 64))
+}
+
+// validate checks that the policy makes at least one attempt and its delays and jitter are in range
+func (p RetryPolicyConfig) validate() error {
+	switch {
+	case p.MaxAttempts < 1:
+		return fmt.Errorf("attempts must be positive")
+	case p.BaseDelay < 0 || p.MaxDelay < p.BaseDelay:
+		return fmt.Errorf("delays must not be negative and the max delay not shorter than the base delay")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// RetryPolicyOf returns the retry policy of the handlers of events of a type consumed from
+// topic: the policy of the event type, else the policy of the topic, else the default one
+func (c MessageBrokerConfig) RetryPolicyOf(topic, eventType string) RetryPolicyConfig {
+	if policy, ok := c.RetryPolicies[eventType]; ok {
+		return policy
+	}
+	if policy, ok := c.RetryPolicies[topic]; ok {
+		return policy
+	}
+	return c.defaultRetryPolicy()
+}
+
+// defaultRetryPolicy returns the retry policy of topics and event types without their own
+func (c MessageBrokerConfig) defaultRetryPolicy() RetryPolicyConfig {
+	return RetryPolicyConfig{
+		MaxAttempts: c.RetryAttempts,
+		BaseDelay:   c.RetryBaseDelay,
+		MaxDelay:    c.RetryMaxDelay,
+		Jitter:      c.RetryJitter,
+	}
+}
+
+// MaxMessageAgeOf returns the age limit of events consumed from topic, 0 for none
+func (c MessageBrokerConfig) MaxMessageAgeOf(topic string) time.Duration {
+	if maxAge, ok := c.MaxMessageAge[topic]; ok {
+		return maxAge
+	}
+	return c.DefaultMaxMessageAge
+}
+
+type TenancyConfig struct {
+	Routing              string   `env:"TENANT_ROUTING" desc:"'none', 'topic' (per-tenant topics) or 'key' (tenant as message key)"`
+	TopicFormat          string   `env:"TENANT_TOPIC_FORMAT" desc:"Per-tenant topic name, {topic} and {tenant} are replaced"`
+	Tenants              []string `env:"TENANTS" desc:"Tenants whose topics are consumed in topic routing mode"`
+	ConsumerRate         int      `env:"TENANT_CONSUMER_RATE" desc:"Events per second consumed per tenant; 0 disables throttling"`
+	ConsumerBurst        int      `env:"TENANT_CONSUMER_BURST" desc:"Events a tenant may consume at once above its rate"`
+	MaxDeferredPerTenant int      `env:"TENANT_MAX_DEFERRED" desc:"Throttled events held per tenant before consumption blocks"`
 }
 
 type TracingConfig struct {
-	Enabled     bool
-	ServiceName string
-	Endpoint    string
+	Enabled     bool   `env:"TRACING_ENABLED"`
+	ServiceName string `env:"TRACING_SERVICE_NAME"`
+	Endpoint    string `env:"TRACING_ENDPOINT"`
+
+	Sampler            string        `env:"TRACING_SAMPLER" desc:"Head sampling strategy: always_on, always_off, ratio or rate_limited"`
+	SamplerRatio       float64       `env:"TRACING_SAMPLER_RATIO" desc:"Ratio of traces sampled by the ratio strategy"`
+	SamplerRate        float64       `env:"TRACING_SAMPLER_RATE" desc:"Traces per second sampled by the rate_limited strategy"`
+	SamplerParentBased bool          `env:"TRACING_SAMPLER_PARENT_BASED" desc:"Whether spans follow the sampling decision of their parent"`
+	SampleErrors       bool          `env:"TRACING_SAMPLE_ERRORS" desc:"Whether failed spans are exported whatever the head sampler decided"`
+	SlowThreshold      time.Duration `env:"TRACING_SLOW_THRESHOLD" desc:"Spans lasting at least this long are exported whatever the head sampler decided, 0 to disable"`
+
+	ExportTimeout      time.Duration `env:"TRACING_EXPORT_TIMEOUT" desc:"Timeout of an OTLP export request"`
+	ExportRetryEnabled bool          `env:"TRACING_EXPORT_RETRY_ENABLED" desc:"Whether failed exports are retried with exponential backoff"`
+	ExportRetryInitial time.Duration `env:"TRACING_EXPORT_RETRY_INITIAL_INTERVAL" desc:"Wait before the first retry"`
+	ExportRetryMax     time.Duration `env:"TRACING_EXPORT_RETRY_MAX_INTERVAL" desc:"Maximum wait between retries"`
+	ExportRetryElapsed time.Duration `env:"TRACING_EXPORT_RETRY_MAX_ELAPSED_TIME" desc:"Time after which a batch that keeps failing is dropped"`
+	ExportQueueSize    int           `env:"TRACING_EXPORT_QUEUE_SIZE" desc:"Spans queued for export before spans are dropped"`
+	ExportBatchSize    int           `env:"TRACING_EXPORT_BATCH_SIZE" desc:"Spans exported per request"`
+	ExportBatchTimeout time.Duration `env:"TRACING_EXPORT_BATCH_TIMEOUT" desc:"Maximum delay before queued spans are exported"`
+}
+
+// OTelMetricsConfig holds the export of the consumer, publisher and dead letter queue stats as
+// OpenTelemetry metrics, for deployments collecting them over OTLP rather than scraping
+type OTelMetricsConfig struct {
+	Enabled  bool          `env:"OTEL_METRICS_ENABLED" desc:"Whether pipeline stats are exported to an OTLP collector"`
+	Endpoint string        `env:"OTEL_METRICS_ENDPOINT" desc:"OTLP HTTP endpoint, host:port; the tracing endpoint when empty"`
+	Interval time.Duration `env:"OTEL_METRICS_INTERVAL" desc:"Interval between exports"`
+	Timeout  time.Duration `env:"OTEL_METRICS_EXPORT_TIMEOUT" desc:"Timeout of an OTLP export request"`
+}
+
+// ExportEndpoint returns the OTLP endpoint metrics are exported to
+func (c OTelMetricsConfig) ExportEndpoint(tracing TracingConfig) string {
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+	return tracing.Endpoint
 }
 
 type LogConfig struct {
-	Level      string `json:"level" yaml:"level"`             // "debug", "info", "warn", "error", "fatal"
-	Format     string `json:"format" yaml:"format"`           // "json", "text", "console"
-	Output     string `json:"output" yaml:"output"`           // "stdout", "stderr", "file"
-	FilePath   string `json:"file_path" yaml:"file_path"`     // Path to log file when output is "file"
-	MaxSize    int    `json:"max_size" yaml:"max_size"`       // Max size in MB for log rotation
-	MaxBackups int    `json:"max_backups" yaml:"max_backups"` // Max number of backup files
-	MaxAge     int    `json:"max_age" yaml:"max_age"`         // Max age in days for log files
-	Compress   bool   `json:"compress" yaml:"compress"`       // Whether to compress rotated log files
-	Caller     bool   `json:"caller" yaml:"caller"`           // Whether to include caller information
-	Stacktrace bool   `json:"stacktrace" yaml:"stacktrace"`   // Whether to include stack trace for errors
+	Level      string `json:"level" yaml:"level" env:"LOG_LEVEL" desc:"'debug', 'info', 'warn', 'error', 'fatal'"`
+	Format     string `json:"format" yaml:"format" env:"LOG_FORMAT" desc:"'json', 'text', 'console'"`
+	Output     string `json:"output" yaml:"output" env:"LOG_OUTPUT" desc:"'stdout', 'stderr', 'file'"`
+	FilePath   string `json:"file_path" yaml:"file_path" env:"LOG_FILE_PATH" desc:"Path to log file when output is 'file'"`
+	MaxSize    int    `json:"max_size" yaml:"max_size" env:"LOG_MAX_SIZE" desc:"Max size in MB for log rotation"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" env:"LOG_MAX_BACKUPS" desc:"Max number of backup files"`
+	MaxAge     int    `json:"max_age" yaml:"max_age" env:"LOG_MAX_AGE" desc:"Max age in days for log files"`
+	Compress   bool   `json:"compress" yaml:"compress" env:"LOG_COMPRESS" desc:"Whether to compress rotated log files"`
+	Caller     bool   `json:"caller" yaml:"caller" env:"LOG_CALLER" desc:"Whether to include caller information"`
+	Stacktrace bool   `json:"stacktrace" yaml:"stacktrace" env:"LOG_STACKTRACE" desc:"Whether to include stack trace for errors"`
+	Requests   bool   `json:"requests" yaml:"requests" env:"LOG_REQUESTS" desc:"Whether to log every gRPC request, stripped of sensitive fields"`
 }
 
 type I18nConfig struct {
-	DefaultLocale   string
-	TranslationsDir string
+	DefaultLocale   string `env:"I18N_DEFAULT_LOCALE"`
+	TranslationsDir string `env:"I18N_TRANSLATIONS_DIR"`
 }
 
 type AuthConfig struct {
-	PrivateKeyPath string
-	PublicKeyPath  string
-	TokenExpiry    int // in hours
+	PrivateKeyPath string `env:"AUTH_PRIVATE_KEY_PATH"`
+	PublicKeyPath  string `env:"AUTH_PUBLIC_KEY_PATH"`
+	TokenExpiry    int    `env:"AUTH_TOKEN_EXPIRY" desc:"Token lifetime in hours"`
+}
+
+// AuthorizationConfig holds the roles and scopes each gRPC method requires
+type AuthorizationConfig struct {
+	File           string        `env:"AUTHORIZATION_FILE" desc:"YAML matrix of the policy of every gRPC method, see docs/authorization.example.yaml; empty only authenticates calls"`
+	ReloadInterval time.Duration `env:"AUTHORIZATION_RELOAD_INTERVAL" desc:"How often the authorization matrix file is reloaded when changed, 0 disables hot reload"`
+}
+
+// MagicLinkConfig holds password-less sign in with single-use links
+type MagicLinkConfig struct {
+	Enabled         bool          `env:"MAGIC_LINK_ENABLED" desc:"Whether users can sign in with a link sent to their email"`
+	URL             string        `env:"MAGIC_LINK_URL" desc:"Sign-in page of the links, where {token} is replaced by the link token"`
+	TTL             time.Duration `env:"MAGIC_LINK_TTL" desc:"How long links can be consumed"`
+	RequestsPerHour int           `env:"MAGIC_LINK_REQUESTS_PER_HOUR" desc:"Links sent per email and hour at most"`
+	BindDevice      bool          `env:"MAGIC_LINK_BIND_DEVICE" desc:"Whether links are only consumed from the device requesting them, identified by its device_id"`
+}
+
+// PasswordResetConfig holds the links resetting forgotten passwords
+type PasswordResetConfig struct {
+	Enabled         bool          `env:"PASSWORD_RESET_ENABLED" desc:"Whether users can reset a forgotten password with a link sent to their email"`
+	URL             string        `env:"PASSWORD_RESET_URL" desc:"Reset page of the links, where {token} is replaced by the link token"`
+	TTL             time.Duration `env:"PASSWORD_RESET_TTL" desc:"How long links can be used"`
+	RequestsPerHour int           `env:"PASSWORD_RESET_REQUESTS_PER_HOUR" desc:"Links sent per email and hour at most"`
+}
+
+// EmailVerificationConfig holds the links verifying the emails of users
+type EmailVerificationConfig struct {
+	Enabled         bool          `env:"EMAIL_VERIFICATION_ENABLED" desc:"Whether users are sent a link verifying their email once registered"`
+	URL             string        `env:"EMAIL_VERIFICATION_URL" desc:"Verification page of the links, where {token} is replaced by the link token"`
+	TTL             time.Duration `env:"EMAIL_VERIFICATION_TTL" desc:"How long links can be used"`
+	RequestsPerHour int           `env:"EMAIL_VERIFICATION_REQUESTS_PER_HOUR" desc:"Links sent again per email and hour at most"`
+}
+
+// RefreshTokenConfig holds the refresh tokens renewing the access tokens of signed in users
+type RefreshTokenConfig struct {
+	Enabled  bool          `env:"REFRESH_TOKEN_ENABLED" desc:"Whether sign ins also return a refresh token, rotated whenever it renews the access token"`
+	TTL      time.Duration `env:"REFRESH_TOKEN_TTL" desc:"How long refresh tokens renew access tokens; users sign in again afterwards"`
+	Store    string        `env:"REFRESH_TOKEN_STORE" desc:"Record of the tokens issued, rotated and revoked: 'postgres' (write database) or 'redis'"`
+	RedisURL string        `env:"REFRESH_TOKEN_REDIS_URL" desc:"Redis server of the redis store, a redis:// URL or a host:port address" sensitive:"true"`
+}
+
+// LoginLockoutConfig holds the brute-force protection of password logins: accounts and client
+// addresses failing too often are locked out for a while
+type LoginLockoutConfig struct {
+	Enabled       bool          `env:"LOGIN_LOCKOUT_ENABLED" desc:"Whether failed logins are counted, locking out accounts and client addresses failing too often"`
+	Store         string        `env:"LOGIN_LOCKOUT_STORE" desc:"Record of the failed logins: 'memory' (per instance) or 'postgres' (write database, shared between instances)"`
+	MaxFailures   int           `env:"LOGIN_LOCKOUT_MAX_FAILURES" desc:"Failed logins within the window locking an account out"`
+	IPMaxFailures int           `env:"LOGIN_LOCKOUT_IP_MAX_FAILURES" desc:"Failed logins within the window throttling a client address, whatever the accounts tried; 0 to only lock accounts out"`
+	Window        time.Duration `env:"LOGIN_LOCKOUT_WINDOW" desc:"Period failed logins are counted over, from the first one"`
+	Duration      time.Duration `env:"LOGIN_LOCKOUT_DURATION" desc:"How long accounts and client addresses are locked out"`
+}
+
+// PreferencesConfig holds the defaults of the settings users did not choose themselves
+type PreferencesConfig struct {
+	DefaultLocale      string `env:"PREFERENCES_DEFAULT_LOCALE" desc:"Locale of users who did not choose one; empty for the i18n default locale"`
+	DefaultTheme       string `env:"PREFERENCES_DEFAULT_THEME" desc:"Theme of users who did not choose one: 'light', 'dark' or 'system'"`
+	EmailNotifications bool   `env:"PREFERENCES_EMAIL_NOTIFICATIONS" desc:"Whether users are notified by email unless they opt out"`
+	PushNotifications  bool   `env:"PREFERENCES_PUSH_NOTIFICATIONS" desc:"Whether users get push notifications unless they opt out"`
+	NotificationDigest string `env:"PREFERENCES_NOTIFICATION_DIGEST" desc:"Notification digest of users who did not choose one: 'never', 'daily' or 'weekly'"`
+}
+
+// NotificationConfig holds the delivery of notifications to users: delivered by the service
+// itself, or published to a topic of the message broker for the notifications worker to deliver
+type NotificationConfig struct {
+	Publisher    string `env:"NOTIFICATION_PUBLISHER" desc:"How notifications leave the service: 'direct' (delivered by the service) or 'topic' (published to the message broker for the notifications worker)"`
+	Topic        string `env:"NOTIFICATION_TOPIC" desc:"Topic of the message broker notifications are published to and consumed from by the worker"`
+	WebhookURL   string `env:"NOTIFICATION_WEBHOOK_URL" desc:"URL notifications are posted to as JSON for delivery; empty logs them instead" sensitive:"true"`
+	SMTPHost     string `env:"NOTIFICATION_SMTP_HOST" desc:"SMTP server notifications are mailed through; empty for the webhook or the log"`
+	SMTPPort     int    `env:"NOTIFICATION_SMTP_PORT" desc:"Port of the SMTP server"`
+	SMTPUsername string `env:"NOTIFICATION_SMTP_USERNAME" desc:"User authenticating to the SMTP server; empty for no authentication"`
+	SMTPPassword string `env:"NOTIFICATION_SMTP_PASSWORD" desc:"Password authenticating to the SMTP server" sensitive:"true"`
+	SMTPFrom     string `env:"NOTIFICATION_SMTP_FROM" desc:"Sender address of the mails"`
+}
+
+type AutoscalingConfig struct {
+	Enabled              bool          `env:"AUTOSCALING_ENABLED"`
+	LagPerReplica        int64         `env:"AUTOSCALING_LAG_PER_REPLICA" desc:"Consumer lag a single replica is expected to absorb"`
+	QueueDepthPerReplica int           `env:"AUTOSCALING_QUEUE_DEPTH_PER_REPLICA" desc:"Worker queue depth a single replica is expected to absorb"`
+	MinReplicas          int           `env:"AUTOSCALING_MIN_REPLICAS" desc:"Lower bound for replica hints"`
+	MaxReplicas          int           `env:"AUTOSCALING_MAX_REPLICAS" desc:"Upper bound for replica hints"`
+	RefreshInterval      time.Duration `env:"AUTOSCALING_REFRESH_INTERVAL" desc:"How often lag gauges are refreshed"`
+}
+
+type DebugConfig struct {
+	ConsoleEnabled bool   `env:"DEBUG_CONSOLE_ENABLED" desc:"Whether the debug console unix socket is served"`
+	SocketPath     string `env:"DEBUG_CONSOLE_SOCKET" desc:"Path of the debug console unix socket"`
+}
+
+type AdminConfig struct {
+	Token         string `env:"ADMIN_API_TOKEN" desc:"Bearer token for the admin API; empty disables it" sensitive:"true"`
+	MaxImportSize int64  `env:"ADMIN_MAX_IMPORT_SIZE" desc:"Maximum size of an uploaded dead letter queue import in bytes"`
+}
+
+type QueryExplainConfig struct {
+	Enabled       bool          `env:"QUERY_EXPLAIN_ENABLED" desc:"Whether slow read queries are explained (development only)"`
+	SlowThreshold time.Duration `env:"QUERY_EXPLAIN_SLOW_THRESHOLD" desc:"Query duration above which the plan is logged"`
+}
+
+type MongoIndexConfig struct {
+	SyncOnStartup bool `env:"MONGO_INDEX_SYNC_ON_STARTUP" desc:"Whether declared read model indexes are reconciled at startup"`
+}
+
+type ReadModelConfig struct {
+	LazyMigration      bool `env:"READ_MODEL_LAZY_MIGRATION" desc:"Whether outdated read model documents are migrated when read"`
+	MigrateOnStartup   bool `env:"READ_MODEL_MIGRATE_ON_STARTUP" desc:"Whether all outdated documents are migrated in the background at startup"`
+	MigrationBatchSize int  `env:"READ_MODEL_MIGRATION_BATCH_SIZE" desc:"Number of documents read per page by the full migration"`
+	UserSummaries      bool `env:"READ_MODEL_USER_SUMMARIES" desc:"Whether ListUsers reads the compact user_summaries projection, see 'readmodel summaries'"`
+	UserCounts         bool `env:"READ_MODEL_USER_COUNTS" desc:"Whether ListUsers reads the number of users from the user_counts counters maintained by the projections instead of counting documents"`
+	Inbox              bool `env:"READ_MODEL_INBOX" desc:"Whether consumed events are recorded in the inbox collection in the same transaction as the projections, applying each once; requires a replica set"`
+
+	UserCountReconcileInterval time.Duration `env:"READ_MODEL_USER_COUNT_RECONCILE_INTERVAL" desc:"How often the user counters are reset to the counts of the write database"`
+
+	ConsistencyMaxWait      time.Duration `env:"READ_MODEL_CONSISTENCY_MAX_WAIT" desc:"How long reads with a consistency token wait for the projection before reading the write side"`
+	ConsistencyPollInterval time.Duration `env:"READ_MODEL_CONSISTENCY_POLL_INTERVAL" desc:"How often waiting reads check the projection"`
+
+	// Graceful degradation while the read store is down
+	Fallbacks        map[string]string `env:"READ_MODEL_FALLBACKS" desc:"Query endpoint -> fallback served while the read store circuit breaker is open: 'write_db' or 'cache'"`
+	FailureThreshold int               `env:"READ_MODEL_FAILURE_THRESHOLD" desc:"Consecutive read store failures opening the circuit breaker"`
+	OpenTimeout      time.Duration     `env:"READ_MODEL_OPEN_TIMEOUT" desc:"How long the circuit breaker stays open before probing the read store again"`
+	HalfOpenCalls    int               `env:"READ_MODEL_HALF_OPEN_CALLS" desc:"Trial read store calls let through at once while probing, the others failing fast; 0 for unlimited"`
+	FallbackCacheTTL time.Duration     `env:"READ_MODEL_FALLBACK_CACHE_TTL" desc:"How long successful reads are kept for the 'cache' fallback"`
+
+	// Sliding window of the read store circuit breaker, replacing the consecutive failure threshold when set
+	BreakerWindowSize       int           `env:"READ_MODEL_BREAKER_WINDOW_SIZE" desc:"Recent read store calls the circuit breaker computes its failure and slow call rates over, 0 counts consecutive failures"`
+	BreakerMinimumCalls     int           `env:"READ_MODEL_BREAKER_MINIMUM_CALLS" desc:"Calls in the window before the rates are evaluated, 0 for the window size"`
+	BreakerFailureRate      float64       `env:"READ_MODEL_BREAKER_FAILURE_RATE" desc:"Percentage of failed calls in the window opening the circuit breaker, 0 disables"`
+	BreakerSlowCallRate     float64       `env:"READ_MODEL_BREAKER_SLOW_CALL_RATE" desc:"Percentage of slow calls in the window opening the circuit breaker, 0 disables"`
+	BreakerSlowCallDuration time.Duration `env:"READ_MODEL_BREAKER_SLOW_CALL_DURATION" desc:"Duration from which a read store call is slow"`
+
+	// Retention of read model collections, enforced by TTL indexes or the archiver
+	Retention        map[string]time.Duration `env:"READ_MODEL_RETENTION" desc:"Collection -> how long its documents are kept, e.g. users_events=2160h; unlisted collections are kept forever"`
+	Archive          map[string]bool          `env:"READ_MODEL_ARCHIVE" desc:"Collections whose expired documents are moved to <collection>_archive by the archiver rather than deleted by a TTL index"`
+	ArchiveInterval  time.Duration            `env:"READ_MODEL_ARCHIVE_INTERVAL" desc:"How often the archiver moves expired documents"`
+	ArchiveBatchSize int                      `env:"READ_MODEL_ARCHIVE_BATCH_SIZE" desc:"Number of documents the archiver moves at a time"`
+}
+
+type CommandRulesConfig struct {
+	File            string   `env:"COMMAND_RULES_FILE" desc:"YAML file of business rules evaluated before commands, empty for none"`
+	ApprovalDomains []string `env:"COMMAND_RULES_APPROVAL_DOMAINS" desc:"Email domains whose sign ups require approval"`
+}
+
+type StorageConfig struct {
+	Provider      string        `env:"STORAGE_PROVIDER" desc:"'local', 's3', 'minio' or 'gcs'"`
+	BasePath      string        `env:"STORAGE_LOCAL_PATH" desc:"Local disk directory"`
+	BaseURL       string        `env:"STORAGE_LOCAL_BASE_URL" desc:"Public URL of the local download handler"`
+	SigningKey    string        `env:"STORAGE_SIGNING_KEY" desc:"Secret used to sign local download URLs" sensitive:"true"`
+	Bucket        string        `env:"STORAGE_BUCKET"`
+	Region        string        `env:"STORAGE_REGION"`
+	Endpoint      string        `env:"STORAGE_ENDPOINT"`
+	AccessKey     string        `env:"STORAGE_ACCESS_KEY" sensitive:"true"`
+	SecretKey     string        `env:"STORAGE_SECRET_KEY" sensitive:"true"`
+	UsePathStyle  bool          `env:"STORAGE_USE_PATH_STYLE"`
+	SignedURLTTL  time.Duration `env:"STORAGE_SIGNED_URL_TTL" desc:"Validity of generated download URLs"`
+	MaxAvatarSize int64         `env:"STORAGE_MAX_AVATAR_SIZE" desc:"Maximum avatar upload size in bytes"`
+}
+
+type EmailConfig struct {
+	AllowInternational bool `env:"EMAIL_ALLOW_INTERNATIONAL" desc:"Accept internationalized (unicode/IDN) email addresses"`
+}
+
+type ResponseCacheConfig struct {
+	Enabled     bool          `env:"RESPONSE_CACHE_ENABLED" desc:"Whether idempotent gateway and gRPC reads are cached"`
+	TTL         time.Duration `env:"RESPONSE_CACHE_TTL" desc:"Lifetime of cached reads without an explicit max-age"`
+	MaxEntries  int           `env:"RESPONSE_CACHE_MAX_ENTRIES" desc:"Maximum number of cached responses"`
+	MaxBodySize int64         `env:"RESPONSE_CACHE_MAX_BODY_SIZE" desc:"Larger gateway responses are not cached"`
+}
+
+// EmailIndexConfig holds the settings of the index of user emails logins look users up in
+type EmailIndexConfig struct {
+	Enabled           bool          `env:"EMAIL_INDEX_ENABLED" desc:"Whether logins look users up in the email index maintained by the event consumer"`
+	ExpectedUsers     int           `env:"EMAIL_INDEX_EXPECTED_USERS" desc:"Number of users the bloom filter of indexed emails is sized for"`
+	FalsePositiveRate float64       `env:"EMAIL_INDEX_FALSE_POSITIVE_RATE" desc:"Share of unknown emails the bloom filter lets through to the database"`
+	MaxEntries        int           `env:"EMAIL_INDEX_MAX_ENTRIES" desc:"Maximum number of emails mapped to user IDs (0 for unbounded)"`
+	RefreshInterval   time.Duration `env:"EMAIL_INDEX_REFRESH_INTERVAL" desc:"Interval between rebuilds of the index from the write database"`
+}
+
+// RequestCostConfig holds the settings of the accounting of the database queries, broker
+// publishes and cache lookups of each gRPC and gateway request
+type RequestCostConfig struct {
+	Enabled        bool          `env:"REQUEST_COST_ENABLED" desc:"Whether the cost of requests is accounted and logged per method"`
+	Headers        bool          `env:"REQUEST_COST_HEADERS" desc:"Whether responses carry their cost in the Server-Timing header"`
+	LogInterval    time.Duration `env:"REQUEST_COST_LOG_INTERVAL" desc:"Interval between logs of the cost of each method"`
+	QueryThreshold int           `env:"REQUEST_COST_QUERY_THRESHOLD" desc:"Requests running this many database queries are logged as likely N+1 (0 to disable)"`
+}
+
+type ControlConfig struct {
+	Enabled    bool          `env:"CONTROL_ENABLED" desc:"Whether consumers follow commands broadcast on the control topic"`
+	Topic      string        `env:"CONTROL_TOPIC" desc:"Control topic every instance consumes"`
+	SigningKey string        `env:"CONTROL_SIGNING_KEY" desc:"HMAC key authenticating command producers" sensitive:"true"`
+	MaxAge     time.Duration `env:"CONTROL_MAX_AGE" desc:"Commands issued longer ago are rejected"`
+	AuditSize  int           `env:"CONTROL_AUDIT_SIZE" desc:"Received commands kept in the audit trail of an instance"`
+}
+
+type ApprovalConfig struct {
+	Enabled    bool          `env:"APPROVALS_ENABLED" desc:"Whether sensitive admin commands need the approval of a second admin"`
+	TTL        time.Duration `env:"APPROVALS_TTL" desc:"Pending approval requests expire after this long"`
+	AuditSize  int           `env:"APPROVALS_AUDIT_SIZE" desc:"Approval audit events kept in memory"`
+	WebhookURL string        `env:"APPROVALS_WEBHOOK_URL" desc:"URL notified of every approval event; empty disables notifications" sensitive:"true"`
+}
+
+type ReplayConfig struct {
+	OnStart    bool `env:"REPLAY_ON_START" desc:"Whether consumers replay topics from the oldest offset next to live consumption"`
+	Rate       int  `env:"REPLAY_RATE" desc:"Replayed messages per second, 0 for unthrottled"`
+	Burst      int  `env:"REPLAY_BURST" desc:"Replayed messages allowed in a burst"`
+	BufferSize int  `env:"REPLAY_BUFFER_SIZE" desc:"Messages queued per lane before reading pauses"`
+}
+
+type ComponentsConfig struct {
+	DisabledHandlers []string `env:"DISABLED_EVENT_HANDLERS" desc:"Event types, or domains such as 'product', whose handlers are not registered"`
+	DisabledServices []string `env:"DISABLED_SERVICES" desc:"gRPC services ('user', 'auth') that are not served"`
+}
+
+// grpcServices are the gRPC services a deployment can disable
+var grpcServices = []string{"user", "auth"}
+
+// HandlerEnabled reports whether events of eventType are handled. Disabling a domain disables
+// all of its event types.
+func (c ComponentsConfig) HandlerEnabled(eventType string) bool {
+	for _, disabled := range c.DisabledHandlers {
+		if eventType == disabled || strings.HasPrefix(eventType, disabled+".") {
+			return false
+		}
+	}
+	return true
+}
+
+// ServiceEnabled reports whether a gRPC service is served
+func (c ComponentsConfig) ServiceEnabled(service string) bool {
+	return !slices.Contains(c.DisabledServices, service)
+}
+
+// ReadShardConfig is a read database holding part of the users. Users are routed to shards by
+// the hash of their ID and the shard order, so shards must not be reordered without resharding.
+type ReadShardConfig struct {
+	Name     string
+	Database DatabaseConfig
+}
+
+type APIVersionsConfig struct {
+	DefaultVersion     string   `env:"API_DEFAULT_VERSION" desc:"Version served for unversioned /api/ paths without an X-API-Version header"`
+	DeprecatedVersions []string `env:"API_DEPRECATED_VERSIONS" desc:"Versions whose methods return deprecation metadata"`
+	Sunset             string   `env:"API_SUNSET" desc:"RFC 3339 time deprecated versions are removed, empty when not scheduled"`
+}
+
+type RateLimitConfig struct {
+	Requests    int           `env:"RATE_LIMIT_REQUESTS" desc:"Hard limit of gRPC requests per client and window, past which calls are rejected"`
+	Window      time.Duration `env:"RATE_LIMIT_WINDOW" desc:"Rate limit window"`
+	SoftPercent int           `env:"RATE_LIMIT_SOFT_PERCENT" desc:"Share of the hard limit in percent past which responses carry a warning, 0 to never warn"`
+}
+
+type MigrationConfig struct {
+	Production      bool `env:"MIGRATE_PRODUCTION" desc:"Whether 'migrate up' refuses destructive migrations unless --allow-destructive is passed"`
+	VerifyChecksums bool `env:"MIGRATE_VERIFY_CHECKSUMS" desc:"Whether 'migrate up' refuses to run when applied migration files changed"`
+}
+
+type ChangefeedConfig struct {
+	Enabled     bool          `env:"CHANGEFEED_ENABLED" desc:"Whether projections record user changes and GET /api/v1/users/changes serves them"`
+	Token       string        `env:"CHANGEFEED_TOKEN" desc:"Bearer token of changefeed clients" sensitive:"true"`
+	PageSize    int           `env:"CHANGEFEED_PAGE_SIZE" desc:"Changes returned when clients pass no limit"`
+	MaxPageSize int           `env:"CHANGEFEED_MAX_PAGE_SIZE" desc:"Most changes returned by a request"`
+	SettleDelay time.Duration `env:"CHANGEFEED_SETTLE_DELAY" desc:"How long recorded changes wait before being served, so concurrent writes are not skipped"`
+}
+
+// EventGatewayConfig holds the WebSocket gateway pushing events to front-ends
+type EventGatewayConfig struct {
+	Enabled         bool          `env:"EVENT_GATEWAY_ENABLED" desc:"Whether front-ends subscribe to events over WebSocket at GET /api/v1/events/ws"`
+	Topics          []string      `env:"EVENT_GATEWAY_TOPICS" desc:"Event type patterns front-ends may subscribe to, e.g. user.*"`
+	PrivilegedRoles []string      `env:"EVENT_GATEWAY_PRIVILEGED_ROLES" desc:"Roles receiving the events about every user; other users only receive their own"`
+	AllowedOrigins  []string      `env:"EVENT_GATEWAY_ALLOWED_ORIGINS" desc:"Origins of the pages allowed to connect, empty allowing any"`
+	MaxConnections  int           `env:"EVENT_GATEWAY_MAX_CONNECTIONS" desc:"Subscriptions served at once by an instance, 0 for no limit"`
+	BufferSize      int           `env:"EVENT_GATEWAY_BUFFER_SIZE" desc:"Events buffered per subscription before a slow client is disconnected"`
+	WriteTimeout    time.Duration `env:"EVENT_GATEWAY_WRITE_TIMEOUT" desc:"Time allowed to push an event to a client"`
+}
+
+type SupervisorConfig struct {
+	InitialBackoff time.Duration `env:"SUPERVISOR_INITIAL_BACKOFF" desc:"Wait before restarting a crashed component, doubled for every further restart"`
+	MaxBackoff     time.Duration `env:"SUPERVISOR_MAX_BACKOFF" desc:"Longest wait before restarting a crashed component"`
+	MaxRestarts    int           `env:"SUPERVISOR_MAX_RESTARTS" desc:"Restarts allowed within the window before a component fails and the service turns unhealthy, 0 for no limit"`
+	Window         time.Duration `env:"SUPERVISOR_WINDOW" desc:"Restarts older than this are forgotten"`
 }
 
+type ConcurrencyLimitConfig struct {
+	Enabled          bool          `env:"CONCURRENCY_LIMIT_ENABLED" desc:"Whether read store, event store and broker publish calls in flight are bounded by adaptive limits"`
+	Algorithm        string        `env:"CONCURRENCY_LIMIT_ALGORITHM" desc:"'aimd' or 'vegas'"`
+	InitialLimit     int           `env:"CONCURRENCY_LIMIT_INITIAL" desc:"Calls in flight allowed to each dependency before latencies are observed"`
+	MinLimit         int           `env:"CONCURRENCY_LIMIT_MIN" desc:"Lowest limit of calls in flight"`
+	MaxLimit         int           `env:"CONCURRENCY_LIMIT_MAX" desc:"Highest limit of calls in flight"`
+	LatencyThreshold time.Duration `env:"CONCURRENCY_LIMIT_LATENCY_THRESHOLD" desc:"Latency from which a call signals an overloaded dependency and decreases the limit, 0 for timeouts only"`
+}
+
+type FailureInjectionConfig struct {
+	Enabled  bool              `env:"FAILURE_INJECTION_ENABLED" desc:"Whether faults can be injected into event handlers, refused in production"`
+	Handlers map[string]string `env:"FAILURE_INJECTION_FAULTS" desc:"Faults per event type handler, a failure rate from 0 to 1 optionally followed by :error message"`
+}
+
+type OutboxConfig struct {
+	Enabled      bool          `env:"OUTBOX_ENABLED" desc:"Whether commands append events to the outbox table of the write database in their transaction, published by the outbox relay"`
+	BatchSize    int           `env:"OUTBOX_BATCH_SIZE" desc:"Events the relay publishes per transaction"`
+	PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" desc:"How often the relay checks the drained outbox for new events"`
+}
+
+// TransactionRetryConfig holds the retry of write database transactions of commands aborted by a
+// conflict with a concurrent transaction
+type TransactionRetryConfig struct {
+	MaxAttempts int           `env:"TRANSACTION_RETRY_MAX_ATTEMPTS" desc:"Attempts of a transaction aborted by a serialization failure or deadlock, including the first; 1 never retries"`
+	BaseDelay   time.Duration `env:"TRANSACTION_RETRY_BASE_DELAY" desc:"Longest wait before the second attempt, doubled for every further attempt; waits are random up to it"`
+	MaxDelay    time.Duration `env:"TRANSACTION_RETRY_MAX_DELAY" desc:"Longest wait between attempts"`
+}
+
+// EncryptionConfig holds per-tenant encryption of event data
+type EncryptionConfig struct {
+	Enabled           bool          `env:"ENCRYPTION_ENABLED" desc:"Whether the data of tenant events is encrypted in the event store with a data key per tenant"`
+	SecretsDir        string        `env:"ENCRYPTION_SECRETS_DIR" desc:"Directory of the secrets provider keeping tenant data keys, e.g. a mounted secrets volume"`
+	KeyMaxAge         time.Duration `env:"ENCRYPTION_KEY_MAX_AGE" desc:"Age after which the rotation job rotates the data key of a tenant, 0 never rotates"`
+	RotationInterval  time.Duration `env:"ENCRYPTION_ROTATION_INTERVAL" desc:"How often the rotation job checks tenant keys and re-encrypts events on earlier key versions"`
+	RotationBatchSize int           `env:"ENCRYPTION_ROTATION_BATCH_SIZE" desc:"Events re-encrypted between two rotation checkpoints"`
+}
+
+// StandbyConfig holds warm standby of event consumers
+type StandbyConfig struct {
+	Enabled          bool          `env:"STANDBY_ENABLED" desc:"Whether the event consumer starts as a warm standby, consuming its partitions with handlers paused until promoted"`
+	LeaderElection   bool          `env:"STANDBY_LEADER_ELECTION" desc:"Whether the standby is promoted while it holds the leader lock, an advisory lock of the write database"`
+	LockName         string        `env:"STANDBY_LOCK_NAME" desc:"Name of the leader lock the instances of a consumer group compete for"`
+	ElectionInterval time.Duration `env:"STANDBY_ELECTION_INTERVAL" desc:"How often instances try to acquire, or check they still hold, the leader lock"`
+}
+
+// ProjectionsConfig holds the projections kept up to date from the event store with checkpoints
+type ProjectionsConfig struct {
+	Enabled      bool          `env:"PROJECTIONS_ENABLED" desc:"Whether the instance runs the checkpointed projections, reading the event database"`
+	BatchSize    int           `env:"PROJECTIONS_BATCH_SIZE" desc:"Events read per batch; the checkpoint of a projection is saved after each batch"`
+	PollInterval time.Duration `env:"PROJECTIONS_POLL_INTERVAL" desc:"How often projections that caught up poll the event store for new events"`
+}
+
+// EventSubscriptionConfig holds the event store subscriptions of projections and sagas running
+// in the instance
+type EventSubscriptionConfig struct {
+	Enabled      bool          `env:"EVENT_SUBSCRIPTIONS_ENABLED" desc:"Whether the event store streams events to in-process subscribers, reading the event database"`
+	BatchSize    int           `env:"EVENT_SUBSCRIPTIONS_BATCH_SIZE" desc:"Events read per batch by subscriptions to the whole event store"`
+	PollInterval time.Duration `env:"EVENT_SUBSCRIPTIONS_POLL_INTERVAL" desc:"How often subscriptions poll for events stored by other instances; events stored by the instance are streamed at once"`
+}
+
+// OperationsConfig holds the tracking of long-running admin operations, e.g. projection rebuilds
+// and DLQ exports, polled and cancelled through the admin operations API
+type OperationsConfig struct {
+	Store             string        `env:"OPERATIONS_STORE" desc:"'memory' or 'postgres', sharing operations between instances through the write database"`
+	HeartbeatInterval time.Duration `env:"OPERATIONS_HEARTBEAT_INTERVAL" desc:"How often running operations are refreshed; those missing 3 heartbeats are marked failed"`
+	Retention         time.Duration `env:"OPERATIONS_RETENTION" desc:"How long finished operations are kept, 0 to keep them forever"`
+}
+
+// AuditLogConfig holds the audit log of sign ins and admin actions, and the retention enforced on
+// its records by category
+type AuditLogConfig struct {
+	Enabled            bool                     `env:"AUDIT_LOG_ENABLED" desc:"Whether sign ins and admin actions are recorded in the audit_log table of the write database"`
+	Retention          map[string]time.Duration `env:"AUDIT_LOG_RETENTION" desc:"Category (auth, admin) -> how long its records are kept; unlisted categories are kept forever"`
+	EnforceInterval    time.Duration            `env:"AUDIT_LOG_ENFORCE_INTERVAL" desc:"How often records past their retention are deleted"`
+	BatchSize          int                      `env:"AUDIT_LOG_ENFORCE_BATCH_SIZE" desc:"Records exported and deleted at a time"`
+	ExportBeforeDelete bool                     `env:"AUDIT_LOG_EXPORT_BEFORE_DELETE" desc:"Whether expired records are archived to object storage before they are deleted"`
+	ExportPrefix       string                   `env:"AUDIT_LOG_EXPORT_PREFIX" desc:"Object storage prefix of the archived records"`
+}
+
+// StatsHistoryConfig holds the periodic snapshots of the consumer, publisher and DLQ stats
+type StatsHistoryConfig struct {
+	Enabled   bool          `env:"STATS_HISTORY_ENABLED" desc:"Whether snapshots of the pipeline stats are recorded and served at GET /admin/stats/history"`
+	Store     string        `env:"STATS_HISTORY_STORE" desc:"Store of the snapshots: memory, or postgres to keep them across restarts"`
+	Interval  time.Duration `env:"STATS_HISTORY_INTERVAL" desc:"How often a snapshot is recorded"`
+	Retention time.Duration `env:"STATS_HISTORY_RETENTION" desc:"How long snapshots are kept, 0 to keep them forever"`
+}
+
+// SupportedAPIVersions are the versions of the public API
+var SupportedAPIVersions = []string{"v1", "v2"}
+
+// DegradableReadEndpoints are the query endpoints that can be served from a fallback while the
+// read store is down
+var DegradableReadEndpoints = []string{"GetUser"}
+
+// SunsetAt returns when deprecated versions are removed, or the zero time when not scheduled
+func (c APIVersionsConfig) SunsetAt() time.Time {
+	sunset, err := time.Parse(time.RFC3339, c.Sunset)
+	if err != nil {
+		return time.Time{}
+	}
+	return sunset
+}
+
+// Load loads the configuration from the environment
 func Load() *Config {
-	return &Config{
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	return load()
+}
+
+// Defaults returns the configuration of an empty environment
+func Defaults() *Config {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	getenv = func(string) string { return "" }
+	defer func() { getenv = os.Getenv }()
+	return load()
+}
+
+var (
+	loadMu sync.Mutex  // Serializes loads, Defaults swaps getenv
+	getenv = os.Getenv // Environment lookup of the getEnv helpers
+)
+
+// load builds the configuration from the environment variables read by getenv
+func load() *Config {
+	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
+			Port:                 getEnv("PORT", "8080"),
+			ShutdownDrainTimeout: getEnvAsDuration("SHUTDOWN_DRAIN_TIMEOUT", 30*time.Second),
+			HealthCheckInterval:  getEnvAsDuration("HEALTH_CHECK_INTERVAL", 10*time.Second),
+			TrustedProxies:       getEnvAsList("TRUSTED_PROXIES"),
+		},
+		Environment: EnvironmentConfig{
+			Name:            getEnv("APP_ENV", "development"),
+			ProductionNames: parseList(getEnv("APP_PRODUCTION_ENVS", "production,prod")),
 		},
 		WriteDatabase: DatabaseConfig{
 			Type:            getEnv("WRITE_DB_TYPE", "postgres"),
@@ -105,6 +769,7 @@ func Load() *Config {
 			User:            getEnv("WRITE_DB_USER", "postgres"),
 			Password:        getEnv("WRITE_DB_PASSWORD", "password"),
 			DBName:          getEnv("WRITE_DB_NAME", "clean_ddd_write_db"),
+			URI:             getEnv("WRITE_DB_URI", ""),
 			Collection:      getEnv("WRITE_DB_COLLECTION", "users"),
 			Charset:         getEnv("WRITE_DB_CHARSET", "utf8mb4"),
 			ParseTime:       getEnv("WRITE_DB_PARSE_TIME", "true") == "true",
@@ -138,6 +803,7 @@ func Load() *Config {
 			User:            getEnv("EVENT_DB_USER", "postgres"),
 			Password:        getEnv("EVENT_DB_PASSWORD", "password"),
 			DBName:          getEnv("EVENT_DB_NAME", "clean_ddd_event_db"),
+			URI:             getEnv("EVENT_DB_URI", ""),
 			Collection:      getEnv("EVENT_DB_COLLECTION", "events"),
 			Charset:         getEnv("EVENT_DB_CHARSET", "utf8mb4"),
 			ParseTime:       getEnv("EVENT_DB_PARSE_TIME", "true") == "true",
@@ -157,39 +823,112 @@ func Load() *Config {
 				"product.view": "product.view",
 
 				// Medium-volume events: Domain-grouped topics
-				"user.created":    "user-events",
-				"user.updated":    "user-events",
-				"user.deleted":    "user-events",
-				"order.created":   "order-events",
-				"order.updated":   "order-events",
-				"order.cancelled": "order-events",
-				"product.created": "product-events",
-				"product.updated": "product-events",
-				"product.deleted": "product-events",
+				"user.created":             "user-events",
+				"user.updated":             "user-events",
+				"user.deleted":             "user-events",
+				"user.preferences_updated": "user-events",
+				"user.email_verified":      "user-events",
+				"user.avatar_updated":      "user-events",
+				"order.created":            "order-events",
+				"order.updated":            "order-events",
+				"order.cancelled":          "order-events",
+				"product.created":          "product-events",
+				"product.updated":          "product-events",
+				"product.deleted":          "product-events",
 
 				// Low-volume events: Bounded-context grouped topics
-				"admin.login":        "admin-events",
-				"admin.logout":       "admin-events",
-				"admin.create_user":  "admin-events",
-				"admin.delete_user":  "admin-events",
-				"system.backup":      "system-events",
-				"system.maintenance": "system-events",
-				"audit.log":          "audit-events",
-				"security.event":     "audit-events",
+				"admin.login":                   "admin-events",
+				"admin.logout":                  "admin-events",
+				"admin.create_user":             "admin-events",
+				"admin.delete_user":             "admin-events",
+				"auth.magic_link_requested":     "auth-events",
+				"auth.magic_link_consumed":      "auth-events",
+				"auth.login_failed":             "auth-events",
+				"auth.account_locked":           "auth-events",
+				"user.password_reset_requested": "auth-events",
+				"user.password_reset":           "auth-events",
+				"system.backup":                 "system-events",
+				"system.maintenance":            "system-events",
+				"audit.log":                     "audit-events",
+				"security.event":                "audit-events",
 			},
-			GroupID:          getEnv("MESSAGE_BROKER_GROUP_ID", "user-service"),
-			Exchange:         getEnv("MESSAGE_BROKER_EXCHANGE", "user-events"),
-			Queue:            getEnv("MESSAGE_BROKER_QUEUE", "user-events"),
-			Channel:          getEnv("MESSAGE_BROKER_CHANNEL", "user-events"),
-			Subject:          getEnv("MESSAGE_BROKER_SUBJECT", "user.events"),
-			PublisherWorkers: getEnvAsInt("MESSAGE_BROKER_PUBLISHER_WORKERS", 5),
-			ConsumerWorkers:  getEnvAsInt("MESSAGE_BROKER_CONSUMER_WORKERS", 10),
-			WorkerBufferSize: getEnvAsInt("MESSAGE_BROKER_WORKER_BUFFER_SIZE", 100),
+			GroupID:               getEnv("MESSAGE_BROKER_GROUP_ID", "user-service"),
+			ClientID:              getEnv("MESSAGE_BROKER_CLIENT_ID", ""),
+			SASLMechanism:         getEnv("MESSAGE_BROKER_SASL_MECHANISM", ""),
+			SASLUsername:          getEnv("MESSAGE_BROKER_SASL_USERNAME", ""),
+			SASLPassword:          getEnv("MESSAGE_BROKER_SASL_PASSWORD", ""),
+			TLSEnabled:            getEnv("MESSAGE_BROKER_TLS_ENABLED", "false") == "true",
+			TLSCAFile:             getEnv("MESSAGE_BROKER_TLS_CA_FILE", ""),
+			TLSCertFile:           getEnv("MESSAGE_BROKER_TLS_CERT_FILE", ""),
+			TLSKeyFile:            getEnv("MESSAGE_BROKER_TLS_KEY_FILE", ""),
+			TLSServerName:         getEnv("MESSAGE_BROKER_TLS_SERVER_NAME", ""),
+			TLSInsecureSkipVerify: getEnv("MESSAGE_BROKER_TLS_INSECURE_SKIP_VERIFY", "false") == "true",
+			Exchange:              getEnv("MESSAGE_BROKER_EXCHANGE", "user-events"),
+			Queue:                 getEnv("MESSAGE_BROKER_QUEUE", "user-events"),
+			StreamMaxLen:          getEnvAsInt("MESSAGE_BROKER_STREAM_MAX_LEN", 100000),
+			Subject:               getEnv("MESSAGE_BROKER_SUBJECT", "user.events"),
+			PublisherWorkers:      getEnvAsInt("MESSAGE_BROKER_PUBLISHER_WORKERS", 5),
+			ConsumerWorkers:       getEnvAsInt("MESSAGE_BROKER_CONSUMER_WORKERS", 10),
+			WorkerBufferSize:      getEnvAsInt("MESSAGE_BROKER_WORKER_BUFFER_SIZE", 100),
+			RetryAttempts:         getEnvAsInt("MESSAGE_BROKER_RETRY_ATTEMPTS", 3),
+			RetryBaseDelay:        getEnvAsDuration("MESSAGE_BROKER_RETRY_BASE_DELAY", time.Second),
+			RetryMaxDelay:         getEnvAsDuration("MESSAGE_BROKER_RETRY_MAX_DELAY", 30*time.Second),
+			RetryJitter:           getEnvAsFloat("MESSAGE_BROKER_RETRY_JITTER", 0),
+			MaxRedeliveries:       getEnvAsInt("MESSAGE_BROKER_MAX_REDELIVERIES", 0),
+			RetryTopicFormat:      getEnv("MESSAGE_BROKER_RETRY_TOPIC_FORMAT", "{topic}.retry"),
+			DLQStorage:            getEnv("MESSAGE_BROKER_DLQ_STORAGE", "memory"),
+			DLQTopicFormat:        getEnv("MESSAGE_BROKER_DLQ_TOPIC_FORMAT", "{topic}.dlq"),
+			DLQAutoRetry:          getEnv("MESSAGE_BROKER_DLQ_AUTO_RETRY", "false") == "true",
+			DLQMaxAttempts:        getEnvAsInt("MESSAGE_BROKER_DLQ_MAX_ATTEMPTS", 3),
+			DLQRetryDelay:         getEnvAsDuration("MESSAGE_BROKER_DLQ_RETRY_DELAY", 5*time.Minute),
+			DLQMaxRetryDelay:      getEnvAsDuration("MESSAGE_BROKER_DLQ_MAX_RETRY_DELAY", time.Hour),
+			DLQScanInterval:       getEnvAsDuration("MESSAGE_BROKER_DLQ_SCAN_INTERVAL", time.Minute),
+
+			MaxMessageAge:        getEnvAsDurationMap("MESSAGE_BROKER_MAX_MESSAGE_AGE"),
+			DefaultMaxMessageAge: getEnvAsDuration("MESSAGE_BROKER_DEFAULT_MAX_MESSAGE_AGE", 0),
+			ExpiredTopicFormat:   getEnv("MESSAGE_BROKER_EXPIRED_TOPIC_FORMAT", ""),
+
+			TopicVersions:         getEnvAsIntMap("MESSAGE_BROKER_TOPIC_VERSIONS"),
+			VersionedTopicFormat:  getEnv("MESSAGE_BROKER_VERSIONED_TOPIC_FORMAT", "{topic}.v{version}"),
+			DualPublishUntil:      getEnvAsTimeMap("MESSAGE_BROKER_DUAL_PUBLISH_UNTIL"),
+			ConsumerTopicVersions: getEnvAsIntMap("MESSAGE_BROKER_CONSUMER_TOPIC_VERSIONS"),
+
+			ValidateSchemas: getEnv("MESSAGE_BROKER_VALIDATE_SCHEMAS", "true") == "true",
+			SchemaDir:       getEnv("MESSAGE_BROKER_SCHEMA_DIR", ""),
+			StrictSchemas:   getEnv("MESSAGE_BROKER_STRICT_SCHEMAS", "false") == "true",
+
+			IdempotentConsumers: getEnv("MESSAGE_BROKER_IDEMPOTENT_CONSUMERS", "false") == "true",
+
+			RetryDelay:          getEnvAsDuration("MESSAGE_BROKER_RETRY_DELAY", 0),
+			DelayedStore:        getEnv("MESSAGE_BROKER_DELAYED_STORE", "memory"),
+			DelayedPollInterval: getEnvAsDuration("MESSAGE_BROKER_DELAYED_POLL_INTERVAL", time.Second),
 		},
 		Tracing: TracingConfig{
 			Enabled:     getEnv("TRACING_ENABLED", "true") == "true",
 			ServiceName: getEnv("TRACING_SERVICE_NAME", "go-clean-ddd-es-template"),
 			Endpoint:    getEnv("TRACING_ENDPOINT", "localhost:4318"),
+
+			Sampler:            getEnv("TRACING_SAMPLER", "always_on"),
+			SamplerRatio:       getEnvAsFloat("TRACING_SAMPLER_RATIO", 1),
+			SamplerRate:        getEnvAsFloat("TRACING_SAMPLER_RATE", 100),
+			SamplerParentBased: getEnv("TRACING_SAMPLER_PARENT_BASED", "true") == "true",
+			SampleErrors:       getEnv("TRACING_SAMPLE_ERRORS", "true") == "true",
+			SlowThreshold:      getEnvAsDuration("TRACING_SLOW_THRESHOLD", time.Second),
+
+			ExportTimeout:      getEnvAsDuration("TRACING_EXPORT_TIMEOUT", 10*time.Second),
+			ExportRetryEnabled: getEnv("TRACING_EXPORT_RETRY_ENABLED", "true") == "true",
+			ExportRetryInitial: getEnvAsDuration("TRACING_EXPORT_RETRY_INITIAL_INTERVAL", 5*time.Second),
+			ExportRetryMax:     getEnvAsDuration("TRACING_EXPORT_RETRY_MAX_INTERVAL", 30*time.Second),
+			ExportRetryElapsed: getEnvAsDuration("TRACING_EXPORT_RETRY_MAX_ELAPSED_TIME", time.Minute),
+			ExportQueueSize:    getEnvAsInt("TRACING_EXPORT_QUEUE_SIZE", 2048),
+			ExportBatchSize:    getEnvAsInt("TRACING_EXPORT_BATCH_SIZE", 512),
+			ExportBatchTimeout: getEnvAsDuration("TRACING_EXPORT_BATCH_TIMEOUT", 5*time.Second),
+		},
+		OTelMetrics: OTelMetricsConfig{
+			Enabled:  getEnv("OTEL_METRICS_ENABLED", "false") == "true",
+			Endpoint: getEnv("OTEL_METRICS_ENDPOINT", ""),
+			Interval: getEnvAsDuration("OTEL_METRICS_INTERVAL", time.Minute),
+			Timeout:  getEnvAsDuration("OTEL_METRICS_EXPORT_TIMEOUT", 30*time.Second),
 		},
 		Log: LogConfig{
 			Level:      getEnv("LOG_LEVEL", "info"),
@@ -202,6 +941,7 @@ func Load() *Config {
 			Compress:   getEnv("LOG_COMPRESS", "true") == "true",
 			Caller:     getEnv("LOG_CALLER", "true") == "true",
 			Stacktrace: getEnv("LOG_STACKTRACE", "true") == "true",
+			Requests:   getEnv("LOG_REQUESTS", "false") == "true",
 		},
 		I18n: I18nConfig{
 			DefaultLocale:   getEnv("I18N_DEFAULT_LOCALE", "en"),
@@ -212,18 +952,907 @@ func Load() *Config {
 			PublicKeyPath:  getEnv("AUTH_PUBLIC_KEY_PATH", "./keys/public.pem"),
 			TokenExpiry:    getEnvAsInt("AUTH_TOKEN_EXPIRY", 24), // 24 hours
 		},
+		Authorization: AuthorizationConfig{
+			File:           getEnv("AUTHORIZATION_FILE", ""),
+			ReloadInterval: getEnvAsDuration("AUTHORIZATION_RELOAD_INTERVAL", 30*time.Second),
+		},
+		MagicLink: MagicLinkConfig{
+			Enabled:         getEnv("MAGIC_LINK_ENABLED", "false") == "true",
+			URL:             getEnv("MAGIC_LINK_URL", "http://localhost:3000/auth/magic-link?token={token}"),
+			TTL:             getEnvAsDuration("MAGIC_LINK_TTL", 15*time.Minute),
+			RequestsPerHour: getEnvAsInt("MAGIC_LINK_REQUESTS_PER_HOUR", 5),
+			BindDevice:      getEnv("MAGIC_LINK_BIND_DEVICE", "false") == "true",
+		},
+		PasswordReset: PasswordResetConfig{
+			Enabled:         getEnv("PASSWORD_RESET_ENABLED", "false") == "true",
+			URL:             getEnv("PASSWORD_RESET_URL", "http://localhost:3000/auth/reset-password?token={token}"),
+			TTL:             getEnvAsDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+			RequestsPerHour: getEnvAsInt("PASSWORD_RESET_REQUESTS_PER_HOUR", 5),
+		},
+		EmailVerification: EmailVerificationConfig{
+			Enabled:         getEnv("EMAIL_VERIFICATION_ENABLED", "false") == "true",
+			URL:             getEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/auth/verify-email?token={token}"),
+			TTL:             getEnvAsDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			RequestsPerHour: getEnvAsInt("EMAIL_VERIFICATION_REQUESTS_PER_HOUR", 5),
+		},
+		RefreshTokens: RefreshTokenConfig{
+			Enabled:  getEnv("REFRESH_TOKEN_ENABLED", "false") == "true",
+			TTL:      getEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+			Store:    getEnv("REFRESH_TOKEN_STORE", "postgres"),
+			RedisURL: getEnv("REFRESH_TOKEN_REDIS_URL", "localhost:6379"),
+		},
+		LoginLockout: LoginLockoutConfig{
+			Enabled:       getEnv("LOGIN_LOCKOUT_ENABLED", "false") == "true",
+			Store:         getEnv("LOGIN_LOCKOUT_STORE", "memory"),
+			MaxFailures:   getEnvAsInt("LOGIN_LOCKOUT_MAX_FAILURES", 5),
+			IPMaxFailures: getEnvAsInt("LOGIN_LOCKOUT_IP_MAX_FAILURES", 20),
+			Window:        getEnvAsDuration("LOGIN_LOCKOUT_WINDOW", 15*time.Minute),
+			Duration:      getEnvAsDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		},
+		Notification: NotificationConfig{
+			Publisher:    getEnv("NOTIFICATION_PUBLISHER", "direct"),
+			Topic:        getEnv("NOTIFICATION_TOPIC", "notifications"),
+			WebhookURL:   getEnv("NOTIFICATION_WEBHOOK_URL", ""),
+			SMTPHost:     getEnv("NOTIFICATION_SMTP_HOST", ""),
+			SMTPPort:     getEnvAsInt("NOTIFICATION_SMTP_PORT", 587),
+			SMTPUsername: getEnv("NOTIFICATION_SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("NOTIFICATION_SMTP_PASSWORD", ""),
+			SMTPFrom:     getEnv("NOTIFICATION_SMTP_FROM", ""),
+		},
+		Preferences: PreferencesConfig{
+			DefaultLocale:      getEnv("PREFERENCES_DEFAULT_LOCALE", ""),
+			DefaultTheme:       getEnv("PREFERENCES_DEFAULT_THEME", "system"),
+			EmailNotifications: getEnv("PREFERENCES_EMAIL_NOTIFICATIONS", "true") == "true",
+			PushNotifications:  getEnv("PREFERENCES_PUSH_NOTIFICATIONS", "false") == "true",
+			NotificationDigest: getEnv("PREFERENCES_NOTIFICATION_DIGEST", "weekly"),
+		},
+		Autoscaling: AutoscalingConfig{
+			Enabled:              getEnv("AUTOSCALING_ENABLED", "true") == "true",
+			LagPerReplica:        int64(getEnvAsInt("AUTOSCALING_LAG_PER_REPLICA", 1000)),
+			QueueDepthPerReplica: getEnvAsInt("AUTOSCALING_QUEUE_DEPTH_PER_REPLICA", 100),
+			MinReplicas:          getEnvAsInt("AUTOSCALING_MIN_REPLICAS", 1),
+			MaxReplicas:          getEnvAsInt("AUTOSCALING_MAX_REPLICAS", 10),
+			RefreshInterval:      getEnvAsDuration("AUTOSCALING_REFRESH_INTERVAL", 15*time.Second),
+		},
+		Debug: DebugConfig{
+			ConsoleEnabled: getEnv("DEBUG_CONSOLE_ENABLED", "true") == "true",
+			SocketPath:     getEnv("DEBUG_CONSOLE_SOCKET", "/tmp/go-clean-ddd-es-template.sock"),
+		},
+		Admin: AdminConfig{
+			Token:         getEnv("ADMIN_API_TOKEN", ""),
+			MaxImportSize: int64(getEnvAsInt("ADMIN_MAX_IMPORT_SIZE", 32*1024*1024)),
+		},
+		QueryExplain: QueryExplainConfig{
+			Enabled:       getEnv("QUERY_EXPLAIN_ENABLED", "false") == "true",
+			SlowThreshold: getEnvAsDuration("QUERY_EXPLAIN_SLOW_THRESHOLD", 100*time.Millisecond),
+		},
+		MongoIndexes: MongoIndexConfig{
+			SyncOnStartup: getEnv("MONGO_INDEX_SYNC_ON_STARTUP", "true") == "true",
+		},
+		ReadModel: ReadModelConfig{
+			LazyMigration:      getEnv("READ_MODEL_LAZY_MIGRATION", "true") == "true",
+			MigrateOnStartup:   getEnv("READ_MODEL_MIGRATE_ON_STARTUP", "false") == "true",
+			MigrationBatchSize: getEnvAsInt("READ_MODEL_MIGRATION_BATCH_SIZE", 500),
+			UserSummaries:      getEnv("READ_MODEL_USER_SUMMARIES", "false") == "true",
+			UserCounts:         getEnv("READ_MODEL_USER_COUNTS", "false") == "true",
+			Inbox:              getEnv("READ_MODEL_INBOX", "false") == "true",
+
+			UserCountReconcileInterval: getEnvAsDuration("READ_MODEL_USER_COUNT_RECONCILE_INTERVAL", time.Hour),
+
+			ConsistencyMaxWait:      getEnvAsDuration("READ_MODEL_CONSISTENCY_MAX_WAIT", 500*time.Millisecond),
+			ConsistencyPollInterval: getEnvAsDuration("READ_MODEL_CONSISTENCY_POLL_INTERVAL", 25*time.Millisecond),
+
+			Fallbacks:        getEnvAsStringMap("READ_MODEL_FALLBACKS"),
+			FailureThreshold: getEnvAsInt("READ_MODEL_FAILURE_THRESHOLD", 5),
+			OpenTimeout:      getEnvAsDuration("READ_MODEL_OPEN_TIMEOUT", 30*time.Second),
+			HalfOpenCalls:    getEnvAsInt("READ_MODEL_HALF_OPEN_CALLS", 1),
+			FallbackCacheTTL: getEnvAsDuration("READ_MODEL_FALLBACK_CACHE_TTL", 10*time.Minute),
+
+			BreakerWindowSize:       getEnvAsInt("READ_MODEL_BREAKER_WINDOW_SIZE", 0),
+			BreakerMinimumCalls:     getEnvAsInt("READ_MODEL_BREAKER_MINIMUM_CALLS", 0),
+			BreakerFailureRate:      getEnvAsFloat("READ_MODEL_BREAKER_FAILURE_RATE", 50),
+			BreakerSlowCallRate:     getEnvAsFloat("READ_MODEL_BREAKER_SLOW_CALL_RATE", 0),
+			BreakerSlowCallDuration: getEnvAsDuration("READ_MODEL_BREAKER_SLOW_CALL_DURATION", time.Second),
+
+			Retention:        getEnvAsDurationMap("READ_MODEL_RETENTION"),
+			Archive:          getEnvAsBoolMap("READ_MODEL_ARCHIVE"),
+			ArchiveInterval:  getEnvAsDuration("READ_MODEL_ARCHIVE_INTERVAL", time.Hour),
+			ArchiveBatchSize: getEnvAsInt("READ_MODEL_ARCHIVE_BATCH_SIZE", 500),
+		},
+		CommandRules: CommandRulesConfig{
+			File:            getEnv("COMMAND_RULES_FILE", ""),
+			ApprovalDomains: getEnvAsList("COMMAND_RULES_APPROVAL_DOMAINS"),
+		},
+		Storage: StorageConfig{
+			Provider:      getEnv("STORAGE_PROVIDER", "local"),
+			BasePath:      getEnv("STORAGE_LOCAL_PATH", "./data/uploads"),
+			BaseURL:       getEnv("STORAGE_LOCAL_BASE_URL", "http://localhost:8080/files"),
+			SigningKey:    getEnv("STORAGE_SIGNING_KEY", "change-me"),
+			Bucket:        getEnv("STORAGE_BUCKET", ""),
+			Region:        getEnv("STORAGE_REGION", ""),
+			Endpoint:      getEnv("STORAGE_ENDPOINT", ""),
+			AccessKey:     getEnv("STORAGE_ACCESS_KEY", ""),
+			SecretKey:     getEnv("STORAGE_SECRET_KEY", ""),
+			UsePathStyle:  getEnv("STORAGE_USE_PATH_STYLE", "false") == "true",
+			SignedURLTTL:  getEnvAsDuration("STORAGE_SIGNED_URL_TTL", 15*time.Minute),
+			MaxAvatarSize: int64(getEnvAsInt("STORAGE_MAX_AVATAR_SIZE", 2*1024*1024)),
+		},
+		Tenancy: TenancyConfig{
+			Routing:              getEnv("TENANT_ROUTING", "none"),
+			TopicFormat:          getEnv("TENANT_TOPIC_FORMAT", "{topic}.{tenant}"),
+			Tenants:              getEnvAsList("TENANTS"),
+			ConsumerRate:         getEnvAsInt("TENANT_CONSUMER_RATE", 0),
+			ConsumerBurst:        getEnvAsInt("TENANT_CONSUMER_BURST", 50),
+			MaxDeferredPerTenant: getEnvAsInt("TENANT_MAX_DEFERRED", 1000),
+		},
+		Email: EmailConfig{
+			AllowInternational: getEnv("EMAIL_ALLOW_INTERNATIONAL", "false") == "true",
+		},
+		ResponseCache: ResponseCacheConfig{
+			Enabled:     getEnv("RESPONSE_CACHE_ENABLED", "false") == "true",
+			TTL:         getEnvAsDuration("RESPONSE_CACHE_TTL", 30*time.Second),
+			MaxEntries:  getEnvAsInt("RESPONSE_CACHE_MAX_ENTRIES", 10000),
+			MaxBodySize: int64(getEnvAsInt("RESPONSE_CACHE_MAX_BODY_SIZE", 1024*1024)),
+		},
+		EmailIndex: EmailIndexConfig{
+			Enabled:           getEnv("EMAIL_INDEX_ENABLED", "false") == "true",
+			ExpectedUsers:     getEnvAsInt("EMAIL_INDEX_EXPECTED_USERS", 1000000),
+			FalsePositiveRate: getEnvAsFloat("EMAIL_INDEX_FALSE_POSITIVE_RATE", 0.01),
+			MaxEntries:        getEnvAsInt("EMAIL_INDEX_MAX_ENTRIES", 100000),
+			RefreshInterval:   getEnvAsDuration("EMAIL_INDEX_REFRESH_INTERVAL", time.Minute),
+		},
+		RequestCost: RequestCostConfig{
+			Enabled:        getEnv("REQUEST_COST_ENABLED", "false") == "true",
+			Headers:        getEnv("REQUEST_COST_HEADERS", "true") == "true",
+			LogInterval:    getEnvAsDuration("REQUEST_COST_LOG_INTERVAL", time.Minute),
+			QueryThreshold: getEnvAsInt("REQUEST_COST_QUERY_THRESHOLD", 20),
+		},
+		Control: ControlConfig{
+			Enabled:    getEnv("CONTROL_ENABLED", "false") == "true",
+			Topic:      getEnv("CONTROL_TOPIC", "consumer-control"),
+			SigningKey: getEnv("CONTROL_SIGNING_KEY", ""),
+			MaxAge:     getEnvAsDuration("CONTROL_MAX_AGE", 5*time.Minute),
+			AuditSize:  getEnvAsInt("CONTROL_AUDIT_SIZE", 1000),
+		},
+		Approvals: ApprovalConfig{
+			Enabled:    getEnv("APPROVALS_ENABLED", "false") == "true",
+			TTL:        getEnvAsDuration("APPROVALS_TTL", 24*time.Hour),
+			AuditSize:  getEnvAsInt("APPROVALS_AUDIT_SIZE", 1000),
+			WebhookURL: getEnv("APPROVALS_WEBHOOK_URL", ""),
+		},
+		Components: ComponentsConfig{
+			DisabledHandlers: getEnvAsList("DISABLED_EVENT_HANDLERS"),
+			DisabledServices: getEnvAsList("DISABLED_SERVICES"),
+		},
+		APIVersions: APIVersionsConfig{
+			DefaultVersion:     getEnv("API_DEFAULT_VERSION", "v2"),
+			DeprecatedVersions: getEnvAsList("API_DEPRECATED_VERSIONS"),
+			Sunset:             getEnv("API_SUNSET", ""),
+		},
+		RateLimit: RateLimitConfig{
+			Requests:    getEnvAsInt("RATE_LIMIT_REQUESTS", 1000),
+			Window:      getEnvAsDuration("RATE_LIMIT_WINDOW", time.Hour),
+			SoftPercent: getEnvAsInt("RATE_LIMIT_SOFT_PERCENT", 80),
+		},
+		Changefeed: ChangefeedConfig{
+			Enabled:     getEnv("CHANGEFEED_ENABLED", "false") == "true",
+			Token:       getEnv("CHANGEFEED_TOKEN", ""),
+			PageSize:    getEnvAsInt("CHANGEFEED_PAGE_SIZE", 100),
+			MaxPageSize: getEnvAsInt("CHANGEFEED_MAX_PAGE_SIZE", 1000),
+			SettleDelay: getEnvAsDuration("CHANGEFEED_SETTLE_DELAY", 2*time.Second),
+		},
+		EventGateway: EventGatewayConfig{
+			Enabled:         getEnv("EVENT_GATEWAY_ENABLED", "false") == "true",
+			Topics:          parseList(getEnv("EVENT_GATEWAY_TOPICS", "user.*,product.*")),
+			PrivilegedRoles: parseList(getEnv("EVENT_GATEWAY_PRIVILEGED_ROLES", "admin")),
+			AllowedOrigins:  getEnvAsList("EVENT_GATEWAY_ALLOWED_ORIGINS"),
+			MaxConnections:  getEnvAsInt("EVENT_GATEWAY_MAX_CONNECTIONS", 1000),
+			BufferSize:      getEnvAsInt("EVENT_GATEWAY_BUFFER_SIZE", 64),
+			WriteTimeout:    getEnvAsDuration("EVENT_GATEWAY_WRITE_TIMEOUT", 10*time.Second),
+		},
+		Supervisor: SupervisorConfig{
+			InitialBackoff: getEnvAsDuration("SUPERVISOR_INITIAL_BACKOFF", time.Second),
+			MaxBackoff:     getEnvAsDuration("SUPERVISOR_MAX_BACKOFF", time.Minute),
+			MaxRestarts:    getEnvAsInt("SUPERVISOR_MAX_RESTARTS", 5),
+			Window:         getEnvAsDuration("SUPERVISOR_WINDOW", 10*time.Minute),
+		},
+		Concurrency: ConcurrencyLimitConfig{
+			Enabled:          getEnv("CONCURRENCY_LIMIT_ENABLED", "false") == "true",
+			Algorithm:        getEnv("CONCURRENCY_LIMIT_ALGORITHM", "aimd"),
+			InitialLimit:     getEnvAsInt("CONCURRENCY_LIMIT_INITIAL", 20),
+			MinLimit:         getEnvAsInt("CONCURRENCY_LIMIT_MIN", 1),
+			MaxLimit:         getEnvAsInt("CONCURRENCY_LIMIT_MAX", 200),
+			LatencyThreshold: getEnvAsDuration("CONCURRENCY_LIMIT_LATENCY_THRESHOLD", time.Second),
+		},
+		Faults: FailureInjectionConfig{
+			Enabled:  getEnv("FAILURE_INJECTION_ENABLED", "false") == "true",
+			Handlers: getEnvAsStringMap("FAILURE_INJECTION_FAULTS"),
+		},
+		Outbox: OutboxConfig{
+			Enabled:      getEnv("OUTBOX_ENABLED", "false") == "true",
+			BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			PollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		},
+		TransactionRetry: TransactionRetryConfig{
+			MaxAttempts: getEnvAsInt("TRANSACTION_RETRY_MAX_ATTEMPTS", 3),
+			BaseDelay:   getEnvAsDuration("TRANSACTION_RETRY_BASE_DELAY", 20*time.Millisecond),
+			MaxDelay:    getEnvAsDuration("TRANSACTION_RETRY_MAX_DELAY", 500*time.Millisecond),
+		},
+		Encryption: EncryptionConfig{
+			Enabled:           getEnv("ENCRYPTION_ENABLED", "false") == "true",
+			SecretsDir:        getEnv("ENCRYPTION_SECRETS_DIR", "./secrets"),
+			KeyMaxAge:         getEnvAsDuration("ENCRYPTION_KEY_MAX_AGE", 90*24*time.Hour),
+			RotationInterval:  getEnvAsDuration("ENCRYPTION_ROTATION_INTERVAL", time.Hour),
+			RotationBatchSize: getEnvAsInt("ENCRYPTION_ROTATION_BATCH_SIZE", 500),
+		},
+		Standby: StandbyConfig{
+			Enabled:          getEnv("STANDBY_ENABLED", "false") == "true",
+			LeaderElection:   getEnv("STANDBY_LEADER_ELECTION", "false") == "true",
+			LockName:         getEnv("STANDBY_LOCK_NAME", "projection-workers"),
+			ElectionInterval: getEnvAsDuration("STANDBY_ELECTION_INTERVAL", 5*time.Second),
+		},
+		Projections: ProjectionsConfig{
+			Enabled:      getEnv("PROJECTIONS_ENABLED", "false") == "true",
+			BatchSize:    getEnvAsInt("PROJECTIONS_BATCH_SIZE", 500),
+			PollInterval: getEnvAsDuration("PROJECTIONS_POLL_INTERVAL", time.Second),
+		},
+		Subscriptions: EventSubscriptionConfig{
+			Enabled:      getEnv("EVENT_SUBSCRIPTIONS_ENABLED", "false") == "true",
+			BatchSize:    getEnvAsInt("EVENT_SUBSCRIPTIONS_BATCH_SIZE", 100),
+			PollInterval: getEnvAsDuration("EVENT_SUBSCRIPTIONS_POLL_INTERVAL", time.Second),
+		},
+		Operations: OperationsConfig{
+			Store:             getEnv("OPERATIONS_STORE", "memory"),
+			HeartbeatInterval: getEnvAsDuration("OPERATIONS_HEARTBEAT_INTERVAL", 10*time.Second),
+			Retention:         getEnvAsDuration("OPERATIONS_RETENTION", 7*24*time.Hour),
+		},
+		AuditLog: AuditLogConfig{
+			Enabled:            getEnv("AUDIT_LOG_ENABLED", "false") == "true",
+			Retention:          parseDurationMap(getEnv("AUDIT_LOG_RETENTION", "auth=17520h,admin=61320h")),
+			EnforceInterval:    getEnvAsDuration("AUDIT_LOG_ENFORCE_INTERVAL", 24*time.Hour),
+			BatchSize:          getEnvAsInt("AUDIT_LOG_ENFORCE_BATCH_SIZE", 500),
+			ExportBeforeDelete: getEnv("AUDIT_LOG_EXPORT_BEFORE_DELETE", "true") == "true",
+			ExportPrefix:       getEnv("AUDIT_LOG_EXPORT_PREFIX", "audit"),
+		},
+		StatsHistory: StatsHistoryConfig{
+			Enabled:   getEnv("STATS_HISTORY_ENABLED", "false") == "true",
+			Store:     getEnv("STATS_HISTORY_STORE", "postgres"),
+			Interval:  getEnvAsDuration("STATS_HISTORY_INTERVAL", time.Minute),
+			Retention: getEnvAsDuration("STATS_HISTORY_RETENTION", 30*24*time.Hour),
+		},
+		Migrations: MigrationConfig{
+			Production:      getEnv("MIGRATE_PRODUCTION", "false") == "true",
+			VerifyChecksums: getEnv("MIGRATE_VERIFY_CHECKSUMS", "true") == "true",
+		},
+		Replay: ReplayConfig{
+			OnStart:    getEnv("REPLAY_ON_START", "false") == "true",
+			Rate:       getEnvAsInt("REPLAY_RATE", 200),
+			Burst:      getEnvAsInt("REPLAY_BURST", 50),
+			BufferSize: getEnvAsInt("REPLAY_BUFFER_SIZE", 1000),
+		},
+		FeatureFlags: getEnvAsBoolMap("FEATURE_FLAGS"),
+	}
+
+	cfg.ReadShards = getEnvAsReadShards("READ_SHARDS", cfg.ReadDatabase)
+	cfg.MessageBroker.RetryPolicies = getEnvAsRetryPolicies("MESSAGE_BROKER_RETRY_POLICIES", cfg.MessageBroker.defaultRetryPolicy())
+	return cfg
+}
+
+// Validate checks that the configuration is usable before the application starts
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.Server.ShutdownDrainTimeout <= 0 {
+		errs = append(errs, "shutdown drain timeout must be positive")
+	}
+	if c.Server.HealthCheckInterval <= 0 {
+		errs = append(errs, "health check interval must be positive")
+	}
+	if _, err := c.Server.TrustedProxyNetworks(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if strings.TrimSpace(c.Environment.Name) == "" {
+		errs = append(errs, "environment name (APP_ENV) must not be empty")
+	}
+
+	databases := []struct {
+		name string
+		cfg  DatabaseConfig
+	}{
+		{"write database", c.WriteDatabase},
+		{"read database", c.ReadDatabase},
+		{"event database", c.EventDatabase},
+	}
+
+	for _, database := range databases {
+		name, db := database.name, database.cfg
+		if db.Type == "" {
+			errs = append(errs, fmt.Sprintf("%s type is required", name))
+		}
+		if db.Type == "mongodb" && db.URI == "" && db.Host == "" {
+			errs = append(errs, fmt.Sprintf("%s requires a URI or host", name))
+		}
+		if db.Type != "mongodb" && db.Host == "" {
+			errs = append(errs, fmt.Sprintf("%s host is required", name))
+		}
+		if db.MaxIdleConns > db.MaxOpenConns && db.MaxOpenConns > 0 {
+			errs = append(errs, fmt.Sprintf("%s max idle connections exceed max open connections", name))
+		}
+	}
+
+	shardNames := make(map[string]bool)
+	for _, shard := range c.ReadShards {
+		if shard.Name == "" {
+			errs = append(errs, "read shard name is required")
+		} else if shardNames[shard.Name] {
+			errs = append(errs, fmt.Sprintf("duplicate read shard: %s", shard.Name))
+		}
+		shardNames[shard.Name] = true
+		if shard.Database.URI == "" && shard.Database.Host == "" {
+			errs = append(errs, fmt.Sprintf("read shard %s requires a URI or host", shard.Name))
+		}
+	}
+
+	if c.MessageBroker.Type == "" {
+		errs = append(errs, "message broker type is required")
+	}
+	if len(c.MessageBroker.Brokers) == 0 || c.MessageBroker.Brokers[0] == "" {
+		errs = append(errs, "at least one message broker address is required")
+	}
+	if c.MessageBroker.GroupID == "" {
+		errs = append(errs, "message broker group ID is required")
+	}
+	switch c.MessageBroker.SASLMechanism {
+	case "":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if c.MessageBroker.SASLUsername == "" || c.MessageBroker.SASLPassword == "" {
+			errs = append(errs, fmt.Sprintf("message broker SASL mechanism %s requires a username and password", c.MessageBroker.SASLMechanism))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("message broker SASL mechanism must be PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, got %q", c.MessageBroker.SASLMechanism))
+	}
+	if (c.MessageBroker.TLSCertFile == "") != (c.MessageBroker.TLSKeyFile == "") {
+		errs = append(errs, "message broker TLS certificate and key files must be set together")
+	}
+	if !c.MessageBroker.TLSEnabled && (c.MessageBroker.TLSCAFile != "" || c.MessageBroker.TLSCertFile != "" || c.MessageBroker.TLSServerName != "" || c.MessageBroker.TLSInsecureSkipVerify) {
+		errs = append(errs, "message broker TLS files and settings require MESSAGE_BROKER_TLS_ENABLED")
+	}
+	if c.MessageBroker.PublisherWorkers <= 0 {
+		errs = append(errs, "message broker publisher workers must be positive")
+	}
+	if c.MessageBroker.ConsumerWorkers <= 0 {
+		errs = append(errs, "message broker consumer workers must be positive")
+	}
+	if c.MessageBroker.WorkerBufferSize <= 0 {
+		errs = append(errs, "message broker worker buffer size must be positive")
+	}
+	if err := c.MessageBroker.defaultRetryPolicy().validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("message broker retry policy: %v", err))
+	}
+	for name, policy := range c.MessageBroker.RetryPolicies {
+		if err := policy.validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("message broker retry policy of %s: %v", name, err))
+		}
+	}
+	switch c.MessageBroker.DLQStorage {
+	case "memory":
+	case "kafka":
+		if c.MessageBroker.Type != "kafka" {
+			errs = append(errs, "kafka DLQ storage requires the kafka message broker")
+		}
+		if !strings.Contains(c.MessageBroker.DLQTopicFormat, "{topic}") {
+			errs = append(errs, "message broker DLQ topic format must contain {topic}")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("message broker DLQ storage must be memory or kafka, got %q", c.MessageBroker.DLQStorage))
+	}
+	if c.MessageBroker.DLQMaxAttempts <= 0 {
+		errs = append(errs, "message broker DLQ max attempts must be positive")
+	}
+	if c.MessageBroker.DLQAutoRetry {
+		if c.MessageBroker.DLQRetryDelay <= 0 || c.MessageBroker.DLQMaxRetryDelay < c.MessageBroker.DLQRetryDelay {
+			errs = append(errs, "message broker DLQ retry delay must be positive and at most the max retry delay")
+		}
+		if c.MessageBroker.DLQScanInterval <= 0 {
+			errs = append(errs, "message broker DLQ scan interval must be positive")
+		}
+	}
+
+	if len(c.MessageBroker.TopicVersions) > 0 {
+		format := c.MessageBroker.VersionedTopicFormat
+		if !strings.Contains(format, "{topic}") || !strings.Contains(format, "{version}") {
+			errs = append(errs, "versioned topic format must contain {topic} and {version}")
+		}
+	}
+	for topic, version := range c.MessageBroker.TopicVersions {
+		if version <= 0 {
+			errs = append(errs, fmt.Sprintf("version of topic %s must be positive", topic))
+		}
+	}
+	for topic, version := range c.MessageBroker.ConsumerTopicVersions {
+		if latest := c.MessageBroker.TopicVersions[topic]; version <= 0 || version > latest {
+			errs = append(errs, fmt.Sprintf("consumed version %d of topic %s is not published", version, topic))
+		}
+	}
+
+	switch c.Tenancy.Routing {
+	case "", "none", "key":
+	case "topic":
+		if !strings.Contains(c.Tenancy.TopicFormat, "{tenant}") {
+			errs = append(errs, "tenant topic format must contain {tenant}")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported tenant routing: %s", c.Tenancy.Routing))
+	}
+
+	for _, service := range c.Components.DisabledServices {
+		if !slices.Contains(grpcServices, service) {
+			errs = append(errs, fmt.Sprintf("unknown service to disable: %s", service))
+		}
+	}
+
+	if !slices.Contains(SupportedAPIVersions, c.APIVersions.DefaultVersion) {
+		errs = append(errs, fmt.Sprintf("unknown default API version: %s", c.APIVersions.DefaultVersion))
+	}
+	for _, version := range c.APIVersions.DeprecatedVersions {
+		if !slices.Contains(SupportedAPIVersions, version) {
+			errs = append(errs, fmt.Sprintf("unknown deprecated API version: %s", version))
+		}
+	}
+	if c.APIVersions.Sunset != "" && c.APIVersions.SunsetAt().IsZero() {
+		errs = append(errs, fmt.Sprintf("invalid API sunset time: %s", c.APIVersions.Sunset))
+	}
+
+	if c.ReadModel.MigrationBatchSize <= 0 {
+		errs = append(errs, "read model migration batch size must be positive")
+	}
+	if c.Tracing.Enabled {
+		switch c.Tracing.Sampler {
+		case "always_on", "always_off", "ratio", "rate_limited":
+		default:
+			errs = append(errs, fmt.Sprintf("unknown tracing sampler: %s", c.Tracing.Sampler))
+		}
+		if c.Tracing.SamplerRatio < 0 || c.Tracing.SamplerRatio > 1 {
+			errs = append(errs, "tracing sampler ratio must be between 0 and 1")
+		}
+		if c.Tracing.Sampler == "rate_limited" && c.Tracing.SamplerRate <= 0 {
+			errs = append(errs, "tracing sampler rate must be positive")
+		}
+	}
+
+	if c.OTelMetrics.Enabled {
+		if c.OTelMetrics.ExportEndpoint(c.Tracing) == "" {
+			errs = append(errs, "OpenTelemetry metrics require an OTLP endpoint")
+		}
+		if c.OTelMetrics.Interval <= 0 || c.OTelMetrics.Timeout <= 0 {
+			errs = append(errs, "OpenTelemetry metrics interval and export timeout must be positive")
+		}
+	}
+
+	if c.RateLimit.Requests <= 0 || c.RateLimit.Window <= 0 {
+		errs = append(errs, "rate limit requests and window must be positive")
+	}
+	if c.RateLimit.SoftPercent < 0 || c.RateLimit.SoftPercent > 100 {
+		errs = append(errs, "rate limit soft percent must be between 0 and 100")
+	}
+
+	if c.ReadModel.ConsistencyMaxWait < 0 {
+		errs = append(errs, "read model consistency max wait must not be negative")
+	}
+	if c.ReadModel.ConsistencyPollInterval <= 0 {
+		errs = append(errs, "read model consistency poll interval must be positive")
+	}
+	if c.ReadModel.UserSummaries && c.ReadDatabase.Type != "mongodb" {
+		errs = append(errs, "read model user summaries require a mongodb read database")
+	}
+	if c.ReadModel.UserCounts {
+		if c.ReadDatabase.Type != "mongodb" {
+			errs = append(errs, "read model user counts require a mongodb read database")
+		}
+		if c.ReadModel.UserCountReconcileInterval <= 0 {
+			errs = append(errs, "read model user count reconcile interval must be positive")
+		}
+	}
+	if c.MessageBroker.IdempotentConsumers && c.EventDatabase.Type != "postgres" {
+		errs = append(errs, "idempotent consumers require a postgres event database")
+	}
+	if c.MessageBroker.RetryDelay < 0 {
+		errs = append(errs, "message broker retry delay must not be negative")
+	}
+	switch c.MessageBroker.DelayedStore {
+	case "memory":
+	case "postgres":
+		if c.WriteDatabase.Type != "postgres" {
+			errs = append(errs, "the postgres delayed message store requires a postgres write database")
+		}
+		if c.MessageBroker.DelayedPollInterval <= 0 {
+			errs = append(errs, "message broker delayed poll interval must be positive")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("message broker delayed store must be memory or postgres, got %q", c.MessageBroker.DelayedStore))
+	}
+	if c.ReadModel.Inbox && (c.ReadDatabase.Type != "mongodb" || len(c.ReadShards) > 0) {
+		errs = append(errs, "the read model inbox requires a single mongodb read database")
+	}
+	if c.Changefeed.Enabled {
+		if c.ReadDatabase.Type != "mongodb" {
+			errs = append(errs, "the changefeed requires a mongodb read database")
+		}
+		if c.Changefeed.Token == "" {
+			errs = append(errs, "the changefeed requires a token")
+		}
+		if c.Changefeed.PageSize <= 0 || c.Changefeed.MaxPageSize < c.Changefeed.PageSize {
+			errs = append(errs, "changefeed page size must be positive and at most the max page size")
+		}
+		if c.Changefeed.SettleDelay < 0 {
+			errs = append(errs, "changefeed settle delay must not be negative")
+		}
+	}
+	if c.EventGateway.Enabled {
+		if len(c.EventGateway.Topics) == 0 {
+			errs = append(errs, "the event gateway requires at least one topic")
+		}
+		if c.EventGateway.MaxConnections < 0 || c.EventGateway.BufferSize <= 0 || c.EventGateway.WriteTimeout <= 0 {
+			errs = append(errs, "event gateway buffer size and write timeout must be positive, max connections not negative")
+		}
+	}
+	if c.Supervisor.InitialBackoff <= 0 || c.Supervisor.MaxBackoff < c.Supervisor.InitialBackoff {
+		errs = append(errs, "supervisor initial backoff must be positive and at most the max backoff")
+	}
+	if c.Supervisor.MaxRestarts < 0 || c.Supervisor.Window < 0 {
+		errs = append(errs, "supervisor max restarts and window must not be negative")
+	}
+	if c.Concurrency.Enabled {
+		if c.Concurrency.Algorithm != "aimd" && c.Concurrency.Algorithm != "vegas" {
+			errs = append(errs, fmt.Sprintf("concurrency limit algorithm must be aimd or vegas, got %q", c.Concurrency.Algorithm))
+		}
+		if c.Concurrency.MinLimit <= 0 || c.Concurrency.MaxLimit < c.Concurrency.MinLimit {
+			errs = append(errs, "concurrency min limit must be positive and at most the max limit")
+		}
+		if c.Concurrency.InitialLimit < c.Concurrency.MinLimit || c.Concurrency.InitialLimit > c.Concurrency.MaxLimit {
+			errs = append(errs, "concurrency initial limit must be between the min and max limits")
+		}
+		if c.Concurrency.LatencyThreshold < 0 {
+			errs = append(errs, "concurrency limit latency threshold must not be negative")
+		}
+	}
+	if c.Outbox.Enabled {
+		if c.WriteDatabase.Type != "postgres" {
+			errs = append(errs, "the outbox requires a postgres write database")
+		}
+		// Events must be appended in the command transaction: a transaction retried after
+		// appending to a separate event database would find its own events and conflict
+		if !c.EventDatabase.SameDatabase(c.WriteDatabase) {
+			errs = append(errs, "the outbox requires the event database to be the write database")
+		}
+		if c.Outbox.BatchSize <= 0 {
+			errs = append(errs, "outbox batch size must be positive")
+		}
+		if c.Outbox.PollInterval <= 0 {
+			errs = append(errs, "outbox poll interval must be positive")
+		}
+	}
+	if c.TransactionRetry.MaxAttempts < 1 {
+		errs = append(errs, "transaction retry max attempts must be at least 1")
+	}
+	if c.TransactionRetry.BaseDelay < 0 || c.TransactionRetry.MaxDelay < c.TransactionRetry.BaseDelay {
+		errs = append(errs, "transaction retry delays must not be negative and the max delay not shorter than the base delay")
+	}
+	if c.Encryption.Enabled {
+		if c.EventDatabase.Type != "postgres" {
+			errs = append(errs, "tenant encryption requires a postgres event database")
+		}
+		if c.Encryption.SecretsDir == "" {
+			errs = append(errs, "tenant encryption requires a secrets directory")
+		}
+		if c.Encryption.KeyMaxAge < 0 {
+			errs = append(errs, "encryption key max age must not be negative")
+		}
+		if c.Encryption.RotationInterval <= 0 {
+			errs = append(errs, "encryption rotation interval must be positive")
+		}
+		if c.Encryption.RotationBatchSize <= 0 {
+			errs = append(errs, "encryption rotation batch size must be positive")
+		}
+	}
+	if c.Standby.Enabled {
+		if !c.Standby.LeaderElection && !c.Control.Enabled {
+			errs = append(errs, "a standby consumer requires leader election or the control channel to be promoted")
+		}
+		if c.Standby.LeaderElection {
+			if c.WriteDatabase.Type != "postgres" {
+				errs = append(errs, "standby leader election requires a postgres write database")
+			}
+			if c.Standby.LockName == "" {
+				errs = append(errs, "standby leader election requires a lock name")
+			}
+			if c.Standby.ElectionInterval <= 0 {
+				errs = append(errs, "standby election interval must be positive")
+			}
+		}
+	}
+	if c.Projections.Enabled {
+		if c.EventDatabase.Type != "postgres" {
+			errs = append(errs, "projections require a postgres event database")
+		}
+		if c.Projections.BatchSize <= 0 {
+			errs = append(errs, "projections batch size must be positive")
+		}
+		if c.Projections.PollInterval <= 0 {
+			errs = append(errs, "projections poll interval must be positive")
+		}
+	}
+	if c.Subscriptions.Enabled {
+		if c.EventDatabase.Type != "postgres" {
+			errs = append(errs, "event subscriptions require a postgres event database")
+		}
+		if c.Subscriptions.BatchSize <= 0 {
+			errs = append(errs, "event subscriptions batch size must be positive")
+		}
+		if c.Subscriptions.PollInterval <= 0 {
+			errs = append(errs, "event subscriptions poll interval must be positive")
+		}
+	}
+	switch c.Operations.Store {
+	case "memory":
+	case "postgres":
+		if c.WriteDatabase.Type != "postgres" {
+			errs = append(errs, "the postgres operation store requires a postgres write database")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("operation store must be memory or postgres, got %q", c.Operations.Store))
+	}
+	if c.Operations.HeartbeatInterval <= 0 {
+		errs = append(errs, "operations heartbeat interval must be positive")
 	}
+	if c.Operations.Retention < 0 {
+		errs = append(errs, "operations retention must not be negative")
+	}
+	if c.AuditLog.Enabled {
+		if c.WriteDatabase.Type != "postgres" {
+			errs = append(errs, "the audit log requires a postgres write database")
+		}
+		if c.AuditLog.EnforceInterval <= 0 || c.AuditLog.BatchSize <= 0 {
+			errs = append(errs, "audit log enforce interval and batch size must be positive")
+		}
+		if c.AuditLog.ExportBeforeDelete && c.AuditLog.ExportPrefix == "" {
+			errs = append(errs, "audit log export prefix is required to export records before deletion")
+		}
+	}
+	if c.StatsHistory.Enabled {
+		switch c.StatsHistory.Store {
+		case "memory":
+		case "postgres":
+			if c.WriteDatabase.Type != "postgres" {
+				errs = append(errs, "the postgres stats history store requires a postgres write database")
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("stats history store must be memory or postgres, got %q", c.StatsHistory.Store))
+		}
+		if c.StatsHistory.Interval <= 0 {
+			errs = append(errs, "stats history interval must be positive")
+		}
+		if c.StatsHistory.Retention < 0 {
+			errs = append(errs, "stats history retention must not be negative")
+		}
+	}
+	for category, retention := range c.AuditLog.Retention {
+		if retention <= 0 {
+			errs = append(errs, fmt.Sprintf("audit log retention of %s must be positive", category))
+		}
+	}
+	if c.Authorization.ReloadInterval < 0 {
+		errs = append(errs, "authorization reload interval must not be negative")
+	}
+	if c.MagicLink.Enabled {
+		if c.WriteDatabase.Type != "postgres" {
+			errs = append(errs, "magic links require a postgres write database")
+		}
+		if !strings.Contains(c.MagicLink.URL, "{token}") {
+			errs = append(errs, "magic link URL must contain a {token} placeholder")
+		}
+		if c.MagicLink.TTL <= 0 {
+			errs = append(errs, "magic link TTL must be positive")
+		}
+		if c.MagicLink.RequestsPerHour <= 0 {
+			errs = append(errs, "magic link requests per hour must be positive")
+		}
+	}
+	if c.PasswordReset.Enabled {
+		if !strings.Contains(c.PasswordReset.URL, "{token}") {
+			errs = append(errs, "password reset URL must contain a {token} placeholder")
+		}
+		if c.PasswordReset.TTL <= 0 {
+			errs = append(errs, "password reset TTL must be positive")
+		}
+		if c.PasswordReset.RequestsPerHour <= 0 {
+			errs = append(errs, "password reset requests per hour must be positive")
+		}
+	}
+	if c.EmailVerification.Enabled {
+		if !strings.Contains(c.EmailVerification.URL, "{token}") {
+			errs = append(errs, "email verification URL must contain a {token} placeholder")
+		}
+		if c.EmailVerification.TTL <= 0 {
+			errs = append(errs, "email verification TTL must be positive")
+		}
+		if c.EmailVerification.RequestsPerHour <= 0 {
+			errs = append(errs, "email verification requests per hour must be positive")
+		}
+	}
+	switch c.Notification.Publisher {
+	case "direct":
+	case "topic":
+		if c.Notification.Topic == "" {
+			errs = append(errs, "notification topic is required with the topic publisher")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("notification publisher must be 'direct' or 'topic', got %q", c.Notification.Publisher))
+	}
+	if c.Notification.SMTPHost != "" {
+		if c.Notification.SMTPPort <= 0 || c.Notification.SMTPPort > 65535 {
+			errs = append(errs, fmt.Sprintf("notification SMTP port must be between 1 and 65535, got %d", c.Notification.SMTPPort))
+		}
+		if c.Notification.SMTPFrom == "" {
+			errs = append(errs, "notification SMTP sender address is required with an SMTP host")
+		}
+	}
+	if c.RefreshTokens.Enabled {
+		switch c.RefreshTokens.Store {
+		case "postgres":
+			if c.WriteDatabase.Type != "postgres" {
+				errs = append(errs, "the postgres refresh token store requires a postgres write database")
+			}
+		case "redis":
+			if c.RefreshTokens.RedisURL == "" {
+				errs = append(errs, "the redis refresh token store requires a Redis URL")
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("unsupported refresh token store: %s", c.RefreshTokens.Store))
+		}
+		if c.RefreshTokens.TTL <= 0 {
+			errs = append(errs, "refresh token TTL must be positive")
+		}
+	}
+	if c.LoginLockout.Enabled {
+		switch c.LoginLockout.Store {
+		case "memory":
+		case "postgres":
+			if c.WriteDatabase.Type != "postgres" {
+				errs = append(errs, "the postgres login lockout store requires a postgres write database")
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("unsupported login lockout store: %s", c.LoginLockout.Store))
+		}
+		if c.LoginLockout.MaxFailures <= 0 {
+			errs = append(errs, "login lockout max failures must be positive")
+		}
+		if c.LoginLockout.IPMaxFailures < 0 {
+			errs = append(errs, "login lockout IP max failures must not be negative")
+		}
+		if c.LoginLockout.Window <= 0 || c.LoginLockout.Duration <= 0 {
+			errs = append(errs, "login lockout window and duration must be positive")
+		}
+	}
+	if c.EmailIndex.Enabled {
+		if c.WriteDatabase.Type != "postgres" {
+			errs = append(errs, "the email index requires a postgres write database")
+		}
+		if c.EmailIndex.ExpectedUsers <= 0 {
+			errs = append(errs, "email index expected users must be positive")
+		}
+		if c.EmailIndex.FalsePositiveRate <= 0 || c.EmailIndex.FalsePositiveRate >= 1 {
+			errs = append(errs, fmt.Sprintf("email index false positive rate must be between 0 and 1 exclusive, got %v", c.EmailIndex.FalsePositiveRate))
+		}
+		if c.EmailIndex.MaxEntries < 0 {
+			errs = append(errs, "email index max entries must not be negative")
+		}
+		if c.EmailIndex.RefreshInterval <= 0 {
+			errs = append(errs, "email index refresh interval must be positive")
+		}
+	}
+	if c.RequestCost.Enabled {
+		if c.RequestCost.LogInterval <= 0 {
+			errs = append(errs, "request cost log interval must be positive")
+		}
+		if c.RequestCost.QueryThreshold < 0 {
+			errs = append(errs, "request cost query threshold must not be negative")
+		}
+	}
+	if !slices.Contains([]string{"light", "dark", "system"}, c.Preferences.DefaultTheme) {
+		errs = append(errs, "default theme must be 'light', 'dark' or 'system'")
+	}
+	if !slices.Contains([]string{"never", "daily", "weekly"}, c.Preferences.NotificationDigest) {
+		errs = append(errs, "default notification digest must be 'never', 'daily' or 'weekly'")
+	}
+	if c.Faults.Enabled && c.Migrations.Production {
+		errs = append(errs, "failure injection must not be enabled in production (MIGRATE_PRODUCTION=true)")
+	}
+	for handler, fault := range c.Faults.Handlers {
+		rate, _, _ := strings.Cut(fault, ":")
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(rate), 64); err != nil || parsed < 0 || parsed > 1 {
+			errs = append(errs, fmt.Sprintf("failure injection rate of %s must be between 0 and 1, got %q", handler, rate))
+		}
+	}
+	for endpoint, fallback := range c.ReadModel.Fallbacks {
+		if !slices.Contains(DegradableReadEndpoints, endpoint) {
+			errs = append(errs, fmt.Sprintf("read model fallback endpoint %q is not one of %v", endpoint, DegradableReadEndpoints))
+		}
+		if fallback != "write_db" && fallback != "cache" {
+			errs = append(errs, fmt.Sprintf("read model fallback of %s must be write_db or cache, got %q", endpoint, fallback))
+		}
+	}
+	if len(c.ReadModel.Fallbacks) > 0 {
+		if c.ReadModel.FailureThreshold <= 0 || c.ReadModel.OpenTimeout <= 0 {
+			errs = append(errs, "read model failure threshold and open timeout must be positive")
+		}
+		if c.ReadModel.FallbackCacheTTL <= 0 {
+			errs = append(errs, "read model fallback cache TTL must be positive")
+		}
+	}
+	if c.ReadModel.HalfOpenCalls < 0 || c.ReadModel.BreakerWindowSize < 0 || c.ReadModel.BreakerMinimumCalls < 0 {
+		errs = append(errs, "read model half-open calls, breaker window size and minimum calls must not be negative")
+	}
+	if c.ReadModel.BreakerFailureRate < 0 || c.ReadModel.BreakerFailureRate > 100 ||
+		c.ReadModel.BreakerSlowCallRate < 0 || c.ReadModel.BreakerSlowCallRate > 100 {
+		errs = append(errs, "read model breaker failure and slow call rates must be percentages between 0 and 100")
+	}
+	if c.ReadModel.BreakerSlowCallRate > 0 && c.ReadModel.BreakerSlowCallDuration <= 0 {
+		errs = append(errs, "read model breaker slow call duration must be positive when the slow call rate is set")
+	}
+	if len(c.ReadModel.Retention) > 0 && c.ReadDatabase.Type != "mongodb" {
+		errs = append(errs, "read model retention requires a mongodb read database")
+	}
+	for collection, maxAge := range c.ReadModel.Retention {
+		if maxAge <= 0 {
+			errs = append(errs, fmt.Sprintf("read model retention of %s must be positive", collection))
+		}
+	}
+	for collection, archived := range c.ReadModel.Archive {
+		if _, ok := c.ReadModel.Retention[collection]; archived && !ok {
+			errs = append(errs, fmt.Sprintf("archived read model collection %s has no retention", collection))
+		}
+	}
+	if c.ReadModel.ArchiveInterval <= 0 || c.ReadModel.ArchiveBatchSize <= 0 {
+		errs = append(errs, "read model archive interval and batch size must be positive")
+	}
+
+	if c.Approvals.Enabled {
+		if c.Approvals.TTL <= 0 {
+			errs = append(errs, "approval TTL must be positive")
+		}
+		if c.Admin.Token == "" {
+			errs = append(errs, "approvals require an admin API token")
+		}
+	}
+
+	if c.Auth.PrivateKeyPath == "" || c.Auth.PublicKeyPath == "" {
+		errs = append(errs, "auth key paths are required")
+	}
+	if c.Auth.TokenExpiry <= 0 {
+		errs = append(errs, "auth token expiry must be positive")
+	}
+
+	if c.I18n.DefaultLocale == "" {
+		errs = append(errs, "i18n default locale is required")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
@@ -231,11 +1860,183 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
 	}
 	return defaultValue
 }
+
+// getEnvAsList parses a comma separated list, skipping empty items
+func getEnvAsList(key string) []string {
+	return parseList(getenv(key))
+}
+
+// parseList parses a comma separated list, skipping empty items
+func parseList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvAsBoolMap parses "name=true,other=false" into a map; bare names are enabled
+func getEnvAsBoolMap(key string) map[string]bool {
+	result := make(map[string]bool)
+	for _, item := range strings.Split(getenv(key), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, found := strings.Cut(item, "=")
+		if !found {
+			result[name] = true
+			continue
+		}
+		if enabled, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			result[strings.TrimSpace(name)] = enabled
+		}
+	}
+	return result
+}
+
+// getEnvAsStringMap parses "name=value,other=value" into a map; items without a value are ignored
+func getEnvAsStringMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range strings.Split(getenv(key), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			result[strings.TrimSpace(name)] = value
+		}
+	}
+	return result
+}
+
+// getEnvAsDurationMap parses "name=1h,other=30m" into a map; invalid durations are ignored
+func getEnvAsDurationMap(key string) map[string]time.Duration {
+	return parseDurationMap(getenv(key))
+}
+
+// parseDurationMap parses "name=1h,other=30m" into a map; invalid durations are ignored
+func parseDurationMap(value string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for _, item := range strings.Split(value, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		if duration, err := time.ParseDuration(strings.TrimSpace(value)); err == nil {
+			result[strings.TrimSpace(name)] = duration
+		}
+	}
+	return result
+}
+
+// getEnvAsIntMap parses "name=2,other=3" into a map; invalid numbers are ignored
+func getEnvAsIntMap(key string) map[string]int {
+	result := make(map[string]int)
+	for _, item := range strings.Split(getenv(key), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		if number, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			result[strings.TrimSpace(name)] = number
+		}
+	}
+	return result
+}
+
+// getEnvAsTimeMap parses "name=2025-01-31T00:00:00Z,other=..." into a map; invalid RFC 3339 times are ignored
+func getEnvAsTimeMap(key string) map[string]time.Time {
+	result := make(map[string]time.Time)
+	for _, item := range strings.Split(getenv(key), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(value)); err == nil {
+			result[strings.TrimSpace(name)] = t
+		}
+	}
+	return result
+}
+
+// getEnvAsRetryPolicies parses "name=5:500ms:10s:0.2,other=1" into retry policies; omitted or
+// empty parts are those of base and items with an invalid part are ignored
+func getEnvAsRetryPolicies(key string, base RetryPolicyConfig) map[string]RetryPolicyConfig {
+	result := make(map[string]RetryPolicyConfig)
+	for _, item := range strings.Split(getenv(key), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		policy := base
+		var err error
+		for i, part := range strings.Split(value, ":") {
+			if part = strings.TrimSpace(part); part == "" || err != nil {
+				continue
+			}
+			switch i {
+			case 0:
+				policy.MaxAttempts, err = strconv.Atoi(part)
+			case 1:
+				policy.BaseDelay, err = time.ParseDuration(part)
+			case 2:
+				policy.MaxDelay, err = time.ParseDuration(part)
+			case 3:
+				policy.Jitter, err = strconv.ParseFloat(part, 64)
+			default:
+				err = fmt.Errorf("unexpected part %q", part)
+			}
+		}
+		if err == nil {
+			result[strings.TrimSpace(name)] = policy
+		}
+	}
+	return result
+}
+
+// getEnvAsReadShards parses "name=target,other=target" into read shards in the listed order. Each
+// shard inherits the read database settings; a target with a scheme replaces the URI, otherwise it
+// is a "host" or "host:port".
+func getEnvAsReadShards(key string, base DatabaseConfig) []ReadShardConfig {
+	var shards []ReadShardConfig
+	for _, item := range strings.Split(getenv(key), ",") {
+		name, target, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		shard := ReadShardConfig{Name: strings.TrimSpace(name), Database: base}
+		target = strings.TrimSpace(target)
+		if strings.Contains(target, "://") {
+			shard.Database.URI = target
+		} else {
+			host, port, hasPort := strings.Cut(target, ":")
+			shard.Database.Host = host
+			shard.Database.URI = ""
+			if hasPort {
+				shard.Database.Port = port
+			}
+		}
+		shards = append(shards, shard)
+	}
+	return shards
+}
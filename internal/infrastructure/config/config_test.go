@@ -3,10 +3,12 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"go-clean-ddd-es-template/internal/infrastructure/config"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoad(t *testing.T) {
@@ -101,11 +103,11 @@ func TestMessageBrokerConfig_Fields(t *testing.T) {
 		Topics: map[string]string{
 			"user.created": "user-events",
 		},
-		GroupID:  "user-service",
-		Exchange: "user-events",
-		Queue:    "user-events",
-		Channel:  "user-events",
-		Subject:  "user.events",
+		GroupID:      "user-service",
+		Exchange:     "user-events",
+		Queue:        "user-events",
+		StreamMaxLen: 1000,
+		Subject:      "user.events",
 	}
 
 	assert.Equal(t, "kafka", mbConfig.Type)
@@ -114,7 +116,7 @@ func TestMessageBrokerConfig_Fields(t *testing.T) {
 	assert.Equal(t, "user-service", mbConfig.GroupID)
 	assert.Equal(t, "user-events", mbConfig.Exchange)
 	assert.Equal(t, "user-events", mbConfig.Queue)
-	assert.Equal(t, "user-events", mbConfig.Channel)
+	assert.Equal(t, 1000, mbConfig.StreamMaxLen)
 	assert.Equal(t, "user.events", mbConfig.Subject)
 }
 
@@ -137,3 +139,214 @@ func TestServerConfig_Fields(t *testing.T) {
 
 	assert.Equal(t, "8080", serverConfig.Port)
 }
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := config.Load()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.MessageBroker.GroupID = ""
+	cfg.Auth.TokenExpiry = 0
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "group ID is required")
+	assert.Contains(t, err.Error(), "token expiry must be positive")
+}
+
+func TestServerConfig_TrustedProxyNetworks(t *testing.T) {
+	os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.10,2001:db8::1")
+	defer os.Unsetenv("TRUSTED_PROXIES")
+
+	cfg := config.Load()
+	networks, err := cfg.Server.TrustedProxyNetworks()
+	require.NoError(t, err)
+	var cidrs []string
+	for _, network := range networks {
+		cidrs = append(cidrs, network.String())
+	}
+	assert.Equal(t, []string{"10.0.0.0/8", "192.0.2.10/32", "2001:db8::1/128"}, cidrs)
+
+	cfg.Server.TrustedProxies = []string{"gateway"}
+	assert.ErrorContains(t, cfg.Validate(), `invalid trusted proxy "gateway"`)
+}
+
+func TestLoad_FeatureFlags(t *testing.T) {
+	os.Setenv("FEATURE_FLAGS", "alpha=true, beta=false,gamma")
+	defer os.Unsetenv("FEATURE_FLAGS")
+
+	cfg := config.Load()
+	assert.Equal(t, map[string]bool{"alpha": true, "beta": false, "gamma": true}, cfg.FeatureFlags)
+}
+
+func TestComponentsConfig(t *testing.T) {
+	os.Setenv("DISABLED_EVENT_HANDLERS", "product, user.deleted")
+	os.Setenv("DISABLED_SERVICES", "auth")
+	defer os.Unsetenv("DISABLED_EVENT_HANDLERS")
+	defer os.Unsetenv("DISABLED_SERVICES")
+
+	cfg := config.Load()
+	assert.True(t, cfg.Components.HandlerEnabled("user.created"))
+	assert.False(t, cfg.Components.HandlerEnabled("user.deleted"))
+	assert.False(t, cfg.Components.HandlerEnabled("product.created"))
+	assert.True(t, cfg.Components.HandlerEnabled("productivity.created"))
+	assert.True(t, cfg.Components.ServiceEnabled("user"))
+	assert.False(t, cfg.Components.ServiceEnabled("auth"))
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Components.DisabledServices = []string{"orders"}
+	assert.ErrorContains(t, cfg.Validate(), "unknown service to disable: orders")
+}
+
+func TestMessageBrokerConfig_MaxMessageAge(t *testing.T) {
+	os.Setenv("MESSAGE_BROKER_MAX_MESSAGE_AGE", "user-events=1h, audit-events=0s,broken=soon")
+	os.Setenv("MESSAGE_BROKER_DEFAULT_MAX_MESSAGE_AGE", "24h")
+	defer os.Unsetenv("MESSAGE_BROKER_MAX_MESSAGE_AGE")
+	defer os.Unsetenv("MESSAGE_BROKER_DEFAULT_MAX_MESSAGE_AGE")
+
+	cfg := config.Load()
+	assert.Equal(t, time.Hour, cfg.MessageBroker.MaxMessageAgeOf("user-events"))
+	assert.Equal(t, time.Duration(0), cfg.MessageBroker.MaxMessageAgeOf("audit-events"))
+	assert.Equal(t, 24*time.Hour, cfg.MessageBroker.MaxMessageAgeOf("broken"))
+}
+
+func TestMessageBrokerConfig_WorkerPools(t *testing.T) {
+	os.Setenv("MESSAGE_BROKER_PUBLISHER_WORKERS", "3")
+	os.Setenv("MESSAGE_BROKER_CONSUMER_WORKERS", "7")
+	os.Setenv("MESSAGE_BROKER_WORKER_BUFFER_SIZE", "0")
+	defer os.Unsetenv("MESSAGE_BROKER_PUBLISHER_WORKERS")
+	defer os.Unsetenv("MESSAGE_BROKER_CONSUMER_WORKERS")
+	defer os.Unsetenv("MESSAGE_BROKER_WORKER_BUFFER_SIZE")
+
+	cfg := config.Load()
+	assert.Equal(t, 3, cfg.MessageBroker.PublisherWorkers)
+	assert.Equal(t, 7, cfg.MessageBroker.ConsumerWorkers)
+	assert.ErrorContains(t, cfg.Validate(), "worker buffer size must be positive")
+}
+
+func TestMessageBrokerConfig_RetryPolicies(t *testing.T) {
+	os.Setenv("MESSAGE_BROKER_RETRY_POLICIES", "user-events=5:500ms, user.deleted=1,audit-events=2::1m:0.2,broken=often")
+	os.Setenv("MESSAGE_BROKER_RETRY_JITTER", "0.1")
+	defer os.Unsetenv("MESSAGE_BROKER_RETRY_POLICIES")
+	defer os.Unsetenv("MESSAGE_BROKER_RETRY_JITTER")
+
+	cfg := config.Load()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, config.RetryPolicyConfig{MaxAttempts: 5, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second, Jitter: 0.1},
+		cfg.MessageBroker.RetryPolicyOf("user-events", "user.created"), "omitted parts are the defaults")
+	assert.Equal(t, 1, cfg.MessageBroker.RetryPolicyOf("user-events", "user.deleted").MaxAttempts, "event types take precedence over topics")
+	assert.Equal(t, config.RetryPolicyConfig{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2},
+		cfg.MessageBroker.RetryPolicyOf("audit-events", "audit.log"))
+	assert.Equal(t, config.RetryPolicyConfig{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 30 * time.Second, Jitter: 0.1},
+		cfg.MessageBroker.RetryPolicyOf("broken", "broken.event"), "invalid policies are ignored")
+
+	cfg.MessageBroker.RetryPolicies["user-events"] = config.RetryPolicyConfig{MaxAttempts: 0}
+	assert.ErrorContains(t, cfg.Validate(), "retry policy of user-events")
+}
+
+func TestMessageBrokerConfig_Security(t *testing.T) {
+	os.Setenv("MESSAGE_BROKER_SASL_MECHANISM", "SCRAM-SHA-512")
+	os.Setenv("MESSAGE_BROKER_SASL_USERNAME", "user-service")
+	os.Setenv("MESSAGE_BROKER_TLS_CERT_FILE", "/etc/kafka/client.pem")
+	defer os.Unsetenv("MESSAGE_BROKER_SASL_MECHANISM")
+	defer os.Unsetenv("MESSAGE_BROKER_SASL_USERNAME")
+	defer os.Unsetenv("MESSAGE_BROKER_TLS_CERT_FILE")
+
+	cfg := config.Load()
+	assert.Equal(t, "SCRAM-SHA-512", cfg.MessageBroker.SASLMechanism)
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "SASL mechanism SCRAM-SHA-512 requires a username and password")
+	assert.ErrorContains(t, err, "TLS certificate and key files must be set together")
+	assert.ErrorContains(t, err, "require MESSAGE_BROKER_TLS_ENABLED")
+
+	cfg.MessageBroker.SASLPassword = "secret"
+	cfg.MessageBroker.TLSEnabled = true
+	cfg.MessageBroker.TLSKeyFile = "/etc/kafka/client.key"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestAPIVersionsConfig(t *testing.T) {
+	os.Setenv("API_DEPRECATED_VERSIONS", "v1")
+	os.Setenv("API_SUNSET", "2027-01-01T00:00:00Z")
+	defer os.Unsetenv("API_DEPRECATED_VERSIONS")
+	defer os.Unsetenv("API_SUNSET")
+
+	cfg := config.Load()
+	assert.Equal(t, "v2", cfg.APIVersions.DefaultVersion)
+	assert.Equal(t, []string{"v1"}, cfg.APIVersions.DeprecatedVersions)
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), cfg.APIVersions.SunsetAt())
+	assert.NoError(t, cfg.Validate())
+
+	cfg.APIVersions.DefaultVersion = "v3"
+	cfg.APIVersions.Sunset = "next year"
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "unknown default API version: v3")
+	assert.ErrorContains(t, err, "invalid API sunset time: next year")
+}
+
+func TestReadShardsConfig(t *testing.T) {
+	os.Setenv("READ_SHARDS", "a=mongodb://shard-a:27017, b=shard-b:27018,c=shard-c")
+	defer os.Unsetenv("READ_SHARDS")
+
+	cfg := config.Load()
+	require.Len(t, cfg.ReadShards, 3)
+	assert.Equal(t, "a", cfg.ReadShards[0].Name)
+	assert.Equal(t, "mongodb://shard-a:27017", cfg.ReadShards[0].Database.URI)
+	assert.Equal(t, cfg.ReadDatabase.DBName, cfg.ReadShards[0].Database.DBName)
+	assert.Equal(t, "", cfg.ReadShards[1].Database.URI)
+	assert.Equal(t, "shard-b", cfg.ReadShards[1].Database.Host)
+	assert.Equal(t, "27018", cfg.ReadShards[1].Database.Port)
+	assert.Equal(t, cfg.ReadDatabase.Port, cfg.ReadShards[2].Database.Port)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.ReadShards[2].Name = "a"
+	assert.ErrorContains(t, cfg.Validate(), "duplicate read shard: a")
+}
+
+func TestFailureInjectionConfig(t *testing.T) {
+	os.Setenv("FAILURE_INJECTION_ENABLED", "true")
+	os.Setenv("FAILURE_INJECTION_FAULTS", "user.created=0.1,user.deleted=1:read model unavailable")
+	defer os.Unsetenv("FAILURE_INJECTION_ENABLED")
+	defer os.Unsetenv("FAILURE_INJECTION_FAULTS")
+
+	cfg := config.Load()
+	assert.True(t, cfg.Faults.Enabled)
+	assert.Equal(t, "1:read model unavailable", cfg.Faults.Handlers["user.deleted"])
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Faults.Handlers["user.updated"] = "2"
+	cfg.Migrations.Production = true
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "failure injection must not be enabled in production")
+	assert.ErrorContains(t, err, "failure injection rate of user.updated must be between 0 and 1")
+}
+
+func TestConfig_IsProduction(t *testing.T) {
+	os.Setenv("APP_ENV", "Production")
+	defer os.Unsetenv("APP_ENV")
+
+	cfg := config.Load()
+	assert.True(t, cfg.IsProduction())
+
+	cfg.Environment.Name = "staging"
+	assert.False(t, cfg.IsProduction())
+	cfg.Migrations.Production = true
+	assert.True(t, cfg.IsProduction(), "MIGRATE_PRODUCTION marks any environment production")
+
+	cfg.Environment.Name = " "
+	assert.ErrorContains(t, cfg.Validate(), "environment name (APP_ENV) must not be empty")
+}
+
+func TestConfig_ValidateOutboxEventDatabase(t *testing.T) {
+	cfg := config.Load()
+	cfg.WriteDatabase.Type = "postgres"
+	cfg.EventDatabase.Type = "postgres"
+	cfg.Outbox.Enabled = true
+	assert.ErrorContains(t, cfg.Validate(), "the outbox requires the event database to be the write database")
+
+	// Retried command transactions append their events again, so they must be rolled back with them
+	cfg.EventDatabase = cfg.WriteDatabase
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Outbox.Enabled = false
+	cfg.EventDatabase.DBName = "events"
+	assert.NoError(t, cfg.Validate(), "without the outbox commands do not run in transactions")
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Struct tags describing the settings of Config fields
+const (
+	envTag       = "env"       // Environment variable of a field, relative to the prefixes of its parents
+	envPrefixTag = "envPrefix" // Prefix of the environment variables of a nested struct
+	descTag      = "desc"      // Description of a setting
+	sensitiveTag = "sensitive" // Secret settings whose values are redacted, see pkg/redact
+)
+
+// RedactedValue replaces the values of sensitive settings that are set
+const RedactedValue = "[REDACTED]"
+
+// Setting is a configuration setting read from an environment variable
+type Setting struct {
+	Env         string `json:"env"`
+	Field       string `json:"field"` // Path of the Config field, e.g. MessageBroker.GroupID
+	Type        string `json:"type"`
+	Default     string `json:"default"` // Value of an empty environment, in the format of the variable
+	Description string `json:"description,omitempty"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+}
+
+// SettingValue is a setting with its effective value
+type SettingValue struct {
+	Setting
+	Value string `json:"value"` // Effective value, redacted for sensitive settings
+	Set   bool   `json:"set"`   // Whether the environment variable is set
+}
+
+// Inventory lists the settings of the configuration in declaration order
+func Inventory() []Setting {
+	var settings []Setting
+	walkSettings(reflect.ValueOf(Defaults()).Elem(), "", "", func(setting Setting, _ reflect.Value) {
+		settings = append(settings, setting)
+	})
+	return settings
+}
+
+// Settings lists the settings of the configuration in declaration order with their values.
+// Sensitive values are redacted unless empty.
+func (c *Config) Settings() []SettingValue {
+	defaults := make(map[string]string)
+	for _, setting := range Inventory() {
+		defaults[setting.Field] = setting.Default
+	}
+
+	var values []SettingValue
+	walkSettings(reflect.ValueOf(c).Elem(), "", "", func(setting Setting, value reflect.Value) {
+		setting.Default = defaults[setting.Field]
+		effective := SettingValue{Setting: setting, Value: formatSetting(value), Set: os.Getenv(setting.Env) != ""}
+		if setting.Sensitive && effective.Value != "" {
+			effective.Value = RedactedValue
+		}
+		values = append(values, effective)
+	})
+	return values
+}
+
+// walkSettings calls visit with every field of v read from an environment variable, its setting
+// defaulting to the value of the field
+func walkSettings(v reflect.Value, path, prefix string, visit func(Setting, reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		if env, ok := field.Tag.Lookup(envTag); ok {
+			visit(Setting{
+				Env:         prefix + env,
+				Field:       fieldPath,
+				Type:        settingType(field.Type),
+				Default:     formatSetting(v.Field(i)),
+				Description: field.Tag.Get(descTag),
+				Sensitive:   field.Tag.Get(sensitiveTag) == "true",
+			}, v.Field(i))
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeFor[time.Time]() {
+			walkSettings(v.Field(i), fieldPath, prefix+field.Tag.Get(envPrefixTag), visit)
+		}
+	}
+}
+
+// settingType names the type of a setting
+func settingType(t reflect.Type) string {
+	switch t {
+	case reflect.TypeFor[time.Duration]():
+		return "duration"
+	case reflect.TypeFor[[]ReadShardConfig]():
+		return "[]string" // name=target items
+	}
+	return strings.ReplaceAll(t.String(), "time.Duration", "duration")
+}
+
+// formatSetting formats a value like its environment variable: lists and maps are comma separated
+func formatSetting(v reflect.Value) string {
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return value.String()
+	case time.Time:
+		return value.Format(time.RFC3339)
+	case []ReadShardConfig:
+		shards := make([]string, len(value))
+		for i, shard := range value {
+			target := shard.Database.URI
+			if target == "" {
+				target = shard.Database.Host
+				if shard.Database.Port != "" {
+					target += ":" + shard.Database.Port
+				}
+			}
+			shards[i] = shard.Name + "=" + target
+		}
+		return strings.Join(shards, ",")
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatSetting(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries = append(entries, fmt.Sprint(iter.Key().Interface())+"="+formatSetting(iter.Value()))
+		}
+		sort.Strings(entries)
+		return strings.Join(entries, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,84 @@
+package config_test
+
+import (
+	"testing"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probeValues are values of each setting type differing from any default
+var probeValues = map[string]string{
+	"string":                              "probe",
+	"int":                                 "7",
+	"int64":                               "7",
+	"float64":                             "0.5",
+	"duration":                            "7s",
+	"[]string":                            "a=probe:1",
+	"map[string]bool":                     "a=false",
+	"map[string]string":                   "a=b",
+	"map[string]int":                      "a=7",
+	"map[string]duration":                 "a=7s",
+	"map[string]time.Time":                "a=2025-01-31T00:00:00Z",
+	"map[string]config.RetryPolicyConfig": "a=7:7s:7s:0.5",
+}
+
+func TestInventory_MatchesLoad(t *testing.T) {
+	inventory := config.Inventory()
+	require.NotEmpty(t, inventory)
+
+	seen := make(map[string]bool)
+	for _, setting := range inventory {
+		assert.False(t, seen[setting.Env], "%s is read by several fields", setting.Env)
+		seen[setting.Env] = true
+
+		probe, ok := probeValues[setting.Type]
+		if setting.Type == "bool" {
+			probe, ok = "true", true
+			if setting.Default == "true" {
+				probe = "false"
+			}
+		}
+		require.True(t, ok, "no probe value for %s of type %s", setting.Env, setting.Type)
+		t.Setenv(setting.Env, probe)
+	}
+
+	for _, setting := range config.Load().Settings() {
+		assert.True(t, setting.Set, setting.Env)
+		if setting.Sensitive {
+			assert.Equal(t, config.RedactedValue, setting.Value, "%s is redacted", setting.Env)
+			continue
+		}
+		expected := probeValues[setting.Type]
+		if setting.Type == "bool" {
+			expected = map[string]string{"true": "false", "false": "true"}[setting.Default]
+		}
+		assert.Equal(t, expected, setting.Value, "%s is read into %s", setting.Env, setting.Field)
+	}
+}
+
+func TestDefaults_IgnoresEnvironment(t *testing.T) {
+	t.Setenv("PORT", "9999")
+	t.Setenv("WRITE_DB_PASSWORD", "secret")
+
+	defaults := config.Defaults()
+	assert.Equal(t, "8080", defaults.Server.Port)
+	assert.Equal(t, "9999", config.Load().Server.Port)
+
+	for _, setting := range config.Inventory() {
+		switch setting.Env {
+		case "PORT":
+			assert.Equal(t, "8080", setting.Default)
+		case "WRITE_DB_PASSWORD":
+			assert.True(t, setting.Sensitive)
+			assert.Equal(t, "password", setting.Default)
+		case "WRITE_DB_MAX_OPEN_CONNS":
+			assert.Equal(t, "int", setting.Type)
+		case "WRITE_DB_CONN_MAX_LIFETIME":
+			assert.Equal(t, "duration", setting.Type)
+			assert.Equal(t, "5m0s", setting.Default)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package consumers
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/pkg/cache"
+)
+
+// CacheInvalidatingHandler invalidates cached reads after the wrapped handler has updated the read model
+type CacheInvalidatingHandler struct {
+	next        LegacyEventHandler
+	invalidator cache.Invalidator
+	tags        []string
+}
+
+// NewCacheInvalidatingHandler creates a handler that invalidates tags after next handled an event.
+// A nil invalidator disables invalidation.
+func NewCacheInvalidatingHandler(next LegacyEventHandler, invalidator cache.Invalidator, tags ...string) *CacheInvalidatingHandler {
+	return &CacheInvalidatingHandler{
+		next:        next,
+		invalidator: invalidator,
+		tags:        tags,
+	}
+}
+
+// HandleEvent handles the event and invalidates the cached reads it made stale
+func (h *CacheInvalidatingHandler) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	if err := h.next.HandleEvent(ctx, eventType, eventData); err != nil {
+		return err
+	}
+
+	if h.invalidator != nil {
+		for _, tag := range h.tags {
+			h.invalidator.InvalidateTag(tag)
+		}
+	}
+	return nil
+}
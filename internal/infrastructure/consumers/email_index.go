@@ -0,0 +1,45 @@
+package consumers
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/pkg/cache"
+)
+
+// EmailIndexingHandler keeps the email index of users up to date after the wrapped handler has
+// handled user events, so logins read users by id instead of by email
+type EmailIndexingHandler struct {
+	next  LegacyEventHandler
+	index cache.Indexer
+}
+
+// NewEmailIndexingHandler creates a handler indexing the emails of the users whose events next
+// handled
+func NewEmailIndexingHandler(next LegacyEventHandler, index cache.Indexer) *EmailIndexingHandler {
+	return &EmailIndexingHandler{
+		next:  next,
+		index: index,
+	}
+}
+
+// HandleEvent handles the event and indexes the email it created, changed or removed
+func (h *EmailIndexingHandler) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	if err := h.next.HandleEvent(ctx, eventType, eventData); err != nil {
+		return err
+	}
+
+	userID, _ := eventData["user_id"].(string)
+	if userID == "" {
+		return nil
+	}
+	switch eventType {
+	case "user.created", "user.updated":
+		// Emails cannot be changed yet, user.updated events only carry one once they can
+		if email, _ := eventData["email"].(string); email != "" {
+			h.index.Put(userID, email)
+		}
+	case "user.deleted":
+		h.index.Remove(userID)
+	}
+	return nil
+}
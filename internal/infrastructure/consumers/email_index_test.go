@@ -0,0 +1,41 @@
+package consumers_test
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/pkg/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailIndexingHandler_HandleEvent(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	next := &recordingHandler{calls: &calls, name: "read-model"}
+	index := cache.NewKeyIndex(100, 0.01, 0)
+	handler := consumers.NewEmailIndexingHandler(next, index)
+
+	next.err = assert.AnError
+	created := map[string]interface{}{"user_id": "user-1", "email": "alice@example.com"}
+	assert.ErrorIs(t, handler.HandleEvent(ctx, "user.created", created), assert.AnError)
+	_, ok := index.Lookup("alice@example.com")
+	assert.False(t, ok, "emails of events failing to project are not indexed")
+
+	next.err = nil
+	require.NoError(t, handler.HandleEvent(ctx, "user.created", created))
+	id, ok := index.Lookup("alice@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", id)
+
+	require.NoError(t, handler.HandleEvent(ctx, "user.updated", map[string]interface{}{"user_id": "user-1", "name": "Alice"}))
+	_, ok = index.Lookup("alice@example.com")
+	assert.True(t, ok, "updates without an email keep the indexed one")
+
+	require.NoError(t, handler.HandleEvent(ctx, "user.deleted", map[string]interface{}{"user_id": "user-1"}))
+	_, ok = index.Lookup("alice@example.com")
+	assert.False(t, ok)
+	assert.Len(t, calls, 4)
+}
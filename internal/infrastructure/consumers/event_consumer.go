@@ -8,7 +8,9 @@ import (
 
 	"go-clean-ddd-es-template/internal/domain/entities"
 	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/pkg/kafka"
 	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/pkg/retry"
 )
 
 // EventConsumer handles event consumption with dead letter queue
@@ -67,9 +69,11 @@ func (ec *EventConsumer) HandleMessage(ctx context.Context, message []byte) erro
 		ec.logger.Error("Failed to unmarshal event: %v", err)
 		return fmt.Errorf("failed to unmarshal event: %w", err)
 	}
+	ctx = eventContext(ctx, &event, kafka.HeadersFromContext(ctx))
 
 	// Convert to UserEvent format for processing
 	userEvent := &entities.UserEvent{
+		EventID:   event.ID.String(),
 		UserID:    "", // Will be extracted from event data
 		EventType: event.Type,
 		EventData: make(map[string]interface{}),
@@ -129,30 +133,20 @@ func (ec *EventConsumer) processEvent(ctx context.Context, event *entities.UserE
 
 	// Execute handler with retry logic
 	return ec.executeWithRetry(ctx, func() error {
-		return handler.HandleEvent(ctx, event)
+		return handleEvent(ctx, handler, event)
 	})
 }
 
-// executeWithRetry executes a function with retry logic
+// executeWithRetry executes a function with retry logic, backing off exponentially until ctx is done
 func (ec *EventConsumer) executeWithRetry(ctx context.Context, fn func() error) error {
-	maxAttempts := 3
-	delay := time.Second
-
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if err := fn(); err == nil {
-			return nil
-		} else {
-			lastErr = err
-			if attempt < maxAttempts {
-				ec.logger.Warn("Attempt %d failed, retrying in %v: %v", attempt, delay, err)
-				time.Sleep(delay)
-				delay *= 2 // Exponential backoff
-			}
-		}
-	}
-
-	return fmt.Errorf("failed after %d attempts: %w", maxAttempts, lastErr)
+	return retry.Retrier{
+		Policy: retry.DefaultPolicy(),
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			ec.logger.Warn("Attempt %d failed, retrying in %v: %v", attempt, delay, err)
+		},
+	}.Do(ctx, func(attempt int) error {
+		return fn()
+	})
 }
 
 // GetDLQStats returns dead letter queue statistics
@@ -2,13 +2,22 @@ package consumers
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"slices"
+	"sort"
 	"sync"
 
 	"go-clean-ddd-es-template/internal/domain/entities"
 	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/dataio"
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/replay"
+	"go-clean-ddd-es-template/pkg/resilience"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
 )
 
 // LegacyEventHandler represents the old event handler interface
@@ -36,7 +45,7 @@ func (a *EventHandlerAdapter) HandleEvent(ctx context.Context, event *entities.U
 		eventData = event.EventData
 	}
 
-	return a.legacyHandler.HandleEvent(ctx, event.EventType, eventData)
+	return a.legacyHandler.HandleEvent(ContextWithEventID(ctx, event.EventID), event.EventType, eventData)
 }
 
 // EventConsumerInterface defines the common interface for event consumers
@@ -53,6 +62,23 @@ type EventConsumerWrapper struct {
 	topics        []string
 	stopChan      chan struct{}
 	wg            sync.WaitGroup
+
+	lagMu sync.RWMutex
+	lag   map[string]map[int32]int64 // topic -> partition -> lag
+
+	pauseMu            sync.Mutex
+	paused             map[string]bool
+	standby            bool                                  // Every topic paused until promoted
+	partitionConsumers map[string][]sarama.PartitionConsumer // topic -> active partition consumers
+	assigned           map[string][]int32                    // topic -> partitions consumed by the instance
+
+	// Cold-start replay, nil merger when disabled
+	merger     *replay.Merger
+	boundaryMu sync.Mutex
+	boundaries map[string]map[int32]*partitionBoundary // topic -> partition -> lane boundary
+
+	// Records consumption of versioned topics, nil when not set
+	topicVersions ConsumedTopicRecorder
 }
 
 // NewEventConsumerWrapper creates a new event consumer wrapper
@@ -70,21 +96,31 @@ func NewEventConsumerWrapper(consumer sarama.Consumer, consumerGroup string, top
 		consumerGroup: consumerGroup,
 		topics:        topics,
 		stopChan:      make(chan struct{}),
+		lag:           make(map[string]map[int32]int64),
 	}
 }
 
 // NewEventConsumerWrapperWithWorkerPool creates a new event consumer wrapper with worker pool
-func NewEventConsumerWrapperWithWorkerPool(consumer sarama.Consumer, consumerGroup string, topics []string, config *config.Config, logger Logger) *EventConsumerWrapper {
+func NewEventConsumerWrapperWithWorkerPool(consumer sarama.Consumer, consumerGroup string, topics []string, config *config.Config, logger Logger, clk clock.Clock) *EventConsumerWrapper {
 	// Create worker pool event consumer
-	eventConsumer := NewWorkerPoolEventConsumer(config, consumer, logger)
+	eventConsumer := NewWorkerPoolEventConsumer(config, consumer, logger, clk)
 
-	return &EventConsumerWrapper{
+	wrapper := &EventConsumerWrapper{
 		consumer:      consumer,
 		eventConsumer: eventConsumer,
 		consumerGroup: consumerGroup,
 		topics:        topics,
 		stopChan:      make(chan struct{}),
+		lag:           make(map[string]map[int32]int64),
 	}
+
+	// Replay topic history next to live consumption, live messages first
+	if config.Replay.OnStart {
+		wrapper.merger = replay.NewMerger(config.Replay.BufferSize, float64(config.Replay.Rate), config.Replay.Burst, clk)
+		wrapper.boundaries = make(map[string]map[int32]*partitionBoundary)
+	}
+
+	return wrapper
 }
 
 // RegisterEventHandler registers an event handler (compatibility method)
@@ -104,10 +140,63 @@ func (w *EventConsumerWrapper) Start(ctx context.Context) error {
 		go w.consumeTopic(ctx, topic)
 	}
 
+	w.startReplay(ctx)
+
 	log.Printf("Event consumer started successfully")
 	return nil
 }
 
+// Run consumes like Start, but blocks until ctx is done. It returns an error as soon as the
+// consumer of a topic exits or panics, stopping the others, so a supervisor restarts the whole
+// consumer instead of it silently running without some topics.
+func (w *EventConsumerWrapper) Run(ctx context.Context) error {
+	log.Printf("Running event consumer for topics: %v", w.topics)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	exited := make(chan error, len(w.topics))
+	for _, topic := range w.topics {
+		w.wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					exited <- fmt.Errorf("consumer of topic %s panicked: %v", topic, r)
+				}
+			}()
+			w.consumeTopic(runCtx, topic)
+			exited <- fmt.Errorf("consumer of topic %s exited", topic)
+		}()
+	}
+	w.startReplay(runCtx)
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-exited:
+	}
+	cancel()
+	w.wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// startReplay replays the history of each topic through the rate limited replay lane
+func (w *EventConsumerWrapper) startReplay(ctx context.Context) {
+	if w.merger == nil {
+		return
+	}
+	w.wg.Add(1)
+	go w.dispatchLanes(ctx)
+	for _, topic := range w.topics {
+		w.wg.Add(1)
+		go w.replayTopic(ctx, topic)
+	}
+}
+
 // consumeTopic consumes messages from a specific topic
 func (w *EventConsumerWrapper) consumeTopic(ctx context.Context, topic string) {
 	defer w.wg.Done()
@@ -127,6 +216,9 @@ func (w *EventConsumerWrapper) consumeTopic(ctx context.Context, topic string) {
 			continue
 		}
 		defer partitionConsumer.Close()
+		w.trackPartitionConsumer(topic, partitionConsumer)
+		w.assign(topic, partition)
+		defer w.release(topic, partition)
 
 		// Consume messages
 		for {
@@ -140,9 +232,23 @@ func (w *EventConsumerWrapper) consumeTopic(ctx context.Context, topic string) {
 			case msg := <-partitionConsumer.Messages():
 				if msg != nil {
 					log.Printf("[INFO] Received message from topic %s partition %d offset %d", topic, partition, msg.Offset)
+					if w.topicVersions != nil {
+						w.topicVersions.RecordConsumed(topic)
+					}
+					w.recordLag(topic, partition, partitionConsumer.HighWaterMarkOffset()-msg.Offset-1)
+
+					// Queue the message in the live lane while replaying
+					if w.merger != nil {
+						if w.acceptMessage(replay.LaneLive, msg) {
+							if err := w.merger.Push(ctx, replay.LaneLive, orderingKey(msg), msg); err != nil {
+								log.Printf("[ERROR] Failed to queue message from topic %s: %v", topic, err)
+							}
+						}
+						continue
+					}
 
-					// Handle the message
-					if err := w.eventConsumer.HandleMessage(ctx, msg.Value); err != nil {
+					// Handle the message with its headers in context
+					if err := w.eventConsumer.HandleMessage(messageContext(ctx, topic, msg), msg.Value); err != nil {
 						log.Printf("[ERROR] Failed to handle message from topic %s: %v", topic, err)
 					}
 				}
@@ -155,6 +261,332 @@ func (w *EventConsumerWrapper) consumeTopic(ctx context.Context, topic string) {
 	}
 }
 
+// messageContext returns the context of handling msg consumed from topic. It carries the message
+// headers, with the original topic set on first delivery, and the trace context of the producer.
+func messageContext(ctx context.Context, topic string, msg *sarama.ConsumerMessage) context.Context {
+	headers := kafka.HeadersFromRecords(msg.Headers)
+	if headers.OriginalTopic() == "" {
+		headers.Set(kafka.HeaderOriginalTopic, topic)
+	}
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headers)
+	return kafka.ContextWithHeaders(ctx, headers)
+}
+
+// trackPartitionConsumer registers a partition consumer for pausing, pausing it right away
+// when its topic is paused or the instance is on standby
+func (w *EventConsumerWrapper) trackPartitionConsumer(topic string, partitionConsumer sarama.PartitionConsumer) {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	if w.partitionConsumers == nil {
+		w.partitionConsumers = make(map[string][]sarama.PartitionConsumer)
+	}
+	w.partitionConsumers[topic] = append(w.partitionConsumers[topic], partitionConsumer)
+	if w.standby || w.paused[topic] {
+		partitionConsumer.Pause()
+	}
+}
+
+// assign records that the instance consumes a partition
+func (w *EventConsumerWrapper) assign(topic string, partition int32) {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	if w.assigned == nil {
+		w.assigned = make(map[string][]int32)
+	}
+	w.assigned[topic] = append(w.assigned[topic], partition)
+	log.Printf("[INFO] Consumer group %s assigned partition %d of topic %s", w.consumerGroup, partition, topic)
+}
+
+// release records that the instance stopped consuming a partition
+func (w *EventConsumerWrapper) release(topic string, partition int32) {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	w.assigned[topic] = slices.DeleteFunc(w.assigned[topic], func(p int32) bool { return p == partition })
+	if len(w.assigned[topic]) == 0 {
+		delete(w.assigned, topic)
+	}
+	log.Printf("[INFO] Consumer group %s released partition %d of topic %s", w.consumerGroup, partition, topic)
+}
+
+// Assignments returns the partitions of each topic the instance consumes, sorted
+func (w *EventConsumerWrapper) Assignments() map[string][]int32 {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	result := make(map[string][]int32, len(w.assigned))
+	for topic, partitions := range w.assigned {
+		result[topic] = slices.Sorted(slices.Values(partitions))
+	}
+	return result
+}
+
+// PauseTopic stops fetching messages of a topic until it is resumed. Messages already
+// fetched are still handled.
+func (w *EventConsumerWrapper) PauseTopic(topic string) {
+	w.setPaused(topic, true)
+	log.Printf("[INFO] Paused consumption of topic %s", topic)
+}
+
+// ResumeTopic resumes fetching messages of a paused topic
+func (w *EventConsumerWrapper) ResumeTopic(topic string) {
+	w.setPaused(topic, false)
+	log.Printf("[INFO] Resumed consumption of topic %s", topic)
+}
+
+// PausedTopics returns the paused topics
+func (w *EventConsumerWrapper) PausedTopics() []string {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	topics := make([]string, 0, len(w.paused))
+	for topic := range w.paused {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// setPaused pauses or resumes the partition consumers of a topic
+func (w *EventConsumerWrapper) setPaused(topic string, paused bool) {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	if w.paused == nil {
+		w.paused = make(map[string]bool)
+	}
+	if paused {
+		w.paused[topic] = true
+	} else {
+		delete(w.paused, topic)
+	}
+	w.applyPause(topic)
+}
+
+// SetStandby keeps the instance in the consumer group with every topic paused until it is
+// promoted, so a warm standby takes over without cold caches or rejoining the group. Topics
+// paused with PauseTopic stay paused once promoted.
+func (w *EventConsumerWrapper) SetStandby(standby bool) {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	if w.standby == standby {
+		return
+	}
+	w.standby = standby
+	for topic := range w.partitionConsumers {
+		w.applyPause(topic)
+	}
+	if standby {
+		log.Printf("[INFO] Consumer group %s is on standby", w.consumerGroup)
+	} else {
+		log.Printf("[INFO] Consumer group %s promoted from standby", w.consumerGroup)
+	}
+}
+
+// Standby reports whether the instance is on standby
+func (w *EventConsumerWrapper) Standby() bool {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+	return w.standby
+}
+
+// applyPause pauses the partition consumers of a topic when it is paused or the instance is on
+// standby, and resumes them otherwise. pauseMu must be held.
+func (w *EventConsumerWrapper) applyPause(topic string) {
+	paused := w.standby || w.paused[topic]
+	for _, partitionConsumer := range w.partitionConsumers[topic] {
+		if paused {
+			partitionConsumer.Pause()
+		} else {
+			partitionConsumer.Resume()
+		}
+	}
+}
+
+// recordLag stores the latest lag observed for a topic partition
+func (w *EventConsumerWrapper) recordLag(topic string, partition int32, lag int64) {
+	if lag < 0 {
+		lag = 0
+	}
+
+	w.lagMu.Lock()
+	defer w.lagMu.Unlock()
+
+	if w.lag[topic] == nil {
+		w.lag[topic] = make(map[int32]int64)
+	}
+	w.lag[topic][partition] = lag
+}
+
+// ConsumerLag returns the outstanding message count per topic
+func (w *EventConsumerWrapper) ConsumerLag() map[string]int64 {
+	w.lagMu.RLock()
+	defer w.lagMu.RUnlock()
+
+	result := make(map[string]int64, len(w.lag))
+	for topic, partitions := range w.lag {
+		var total int64
+		for _, lag := range partitions {
+			total += lag
+		}
+		result[topic] = total
+	}
+	return result
+}
+
+// QueueDepth returns the number of messages buffered by the underlying consumer
+func (w *EventConsumerWrapper) QueueDepth() int {
+	if depth, ok := w.eventConsumer.(interface{ QueueDepth() int }); ok {
+		return depth.QueueDepth()
+	}
+	return 0
+}
+
+// DeferredEvents returns the number of throttled events waiting per tenant
+func (w *EventConsumerWrapper) DeferredEvents() map[string]int {
+	if deferred, ok := w.eventConsumer.(interface{ DeferredEvents() map[string]int }); ok {
+		return deferred.DeferredEvents()
+	}
+	return map[string]int{}
+}
+
+// SetRedeliverer makes the consumer redeliver messages that failed processing through retry topics
+func (w *EventConsumerWrapper) SetRedeliverer(redeliverer Redeliverer) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		workerPool.SetRedeliverer(redeliverer)
+	}
+}
+
+// SetExpiredPublisher makes the consumer route expired events to an expired topic instead of skipping them
+func (w *EventConsumerWrapper) SetExpiredPublisher(publisher ExpiredPublisher) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		workerPool.SetExpiredPublisher(publisher)
+	}
+}
+
+// SetPayloadValidator makes the consumer quarantine events whose payload violates its schema
+func (w *EventConsumerWrapper) SetPayloadValidator(validator PayloadValidator) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		workerPool.SetPayloadValidator(validator)
+	}
+}
+
+// ConsumedTopicRecorder records the topics messages are consumed from
+type ConsumedTopicRecorder interface {
+	RecordConsumed(topic string)
+}
+
+// SetTopicVersions records every consumed message with the versions of versioned topics
+func (w *EventConsumerWrapper) SetTopicVersions(recorder ConsumedTopicRecorder) {
+	w.topicVersions = recorder
+}
+
+// SetDLQRetryHandler sets the handler retrying events from the dead letter queue
+func (w *EventConsumerWrapper) SetDLQRetryHandler(handler resilience.RetryHandler) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		workerPool.SetRetryHandler(handler)
+	}
+}
+
+// SetDLQStorage keeps the events of the dead letter queue in a persistent storage instead of memory
+func (w *EventConsumerWrapper) SetDLQStorage(storage resilience.DLQStorage) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		workerPool.SetDLQStorage(storage)
+	}
+}
+
+// GetMetrics returns worker pool metrics, or nil if the consumer has no worker pool
+func (w *EventConsumerWrapper) GetMetrics() *ConsumerMetrics {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.GetMetrics()
+	}
+	return nil
+}
+
+// ListFailedEvents lists failed events from the consumer's dead letter queue
+func (w *EventConsumerWrapper) ListFailedEvents(ctx context.Context, limit, offset int) ([]*resilience.FailedEvent, error) {
+	if dlq, ok := w.eventConsumer.(interface {
+		ListFailedEvents(ctx context.Context, limit, offset int) ([]*resilience.FailedEvent, error)
+	}); ok {
+		return dlq.ListFailedEvents(ctx, limit, offset)
+	}
+	return []*resilience.FailedEvent{}, nil
+}
+
+// FindFailedEvents returns a page of the failed events matching filter from the consumer's dead
+// letter queue, and how many events match
+func (w *EventConsumerWrapper) FindFailedEvents(ctx context.Context, filter resilience.DLQFilter, limit, offset int) ([]*resilience.FailedEvent, int, error) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.FindFailedEvents(ctx, filter, limit, offset)
+	}
+	return []*resilience.FailedEvent{}, 0, nil
+}
+
+// GetFailedEvent gets a failed event from the consumer's dead letter queue
+func (w *EventConsumerWrapper) GetFailedEvent(ctx context.Context, eventID string) (*resilience.FailedEvent, error) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.GetFailedEvent(ctx, eventID)
+	}
+	return nil, fmt.Errorf("event consumer has no dead letter queue")
+}
+
+// RetryFailedEvent retries a failed event from the consumer's dead letter queue
+func (w *EventConsumerWrapper) RetryFailedEvent(ctx context.Context, eventID string) error {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.RetryFailedEvent(ctx, eventID)
+	}
+	return fmt.Errorf("event consumer has no dead letter queue")
+}
+
+// DeleteFailedEvent deletes a failed event from the consumer's dead letter queue
+func (w *EventConsumerWrapper) DeleteFailedEvent(ctx context.Context, eventID string) error {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.DeleteFailedEvent(ctx, eventID)
+	}
+	return fmt.Errorf("event consumer has no dead letter queue")
+}
+
+// ExportFailedEvents streams failed events matching filter from the consumer's dead letter queue
+func (w *EventConsumerWrapper) ExportFailedEvents(ctx context.Context, writer dataio.Writer, filter resilience.DLQFilter) (int, error) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.ExportFailedEvents(ctx, writer, filter)
+	}
+	return 0, writer.Flush()
+}
+
+// ImportFailedEvents adds previously exported failed events to the consumer's dead letter queue
+func (w *EventConsumerWrapper) ImportFailedEvents(ctx context.Context, reader dataio.Reader) (resilience.DLQImportResult, error) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.ImportFailedEvents(ctx, reader)
+	}
+	return resilience.DLQImportResult{}, fmt.Errorf("event consumer has no dead letter queue")
+}
+
+// PurgeFailedEvents removes all failed events from the consumer's dead letter queue
+func (w *EventConsumerWrapper) PurgeFailedEvents(ctx context.Context) (int, error) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.PurgeFailedEvents(ctx)
+	}
+	return 0, fmt.Errorf("event consumer has no dead letter queue")
+}
+
+// GetDLQStats returns statistics of the consumer's dead letter queue
+func (w *EventConsumerWrapper) GetDLQStats(ctx context.Context) (resilience.DLQStats, error) {
+	if workerPool, ok := w.eventConsumer.(*WorkerPoolEventConsumer); ok {
+		return workerPool.GetDLQStats(ctx)
+	}
+	return resilience.DLQStats{}, fmt.Errorf("event consumer has no dead letter queue")
+}
+
+// ConsumerGroup returns the consumer group name
+func (w *EventConsumerWrapper) ConsumerGroup() string {
+	return w.consumerGroup
+}
+
 // Stop stops the event consumer
 func (w *EventConsumerWrapper) Stop() {
 	log.Printf("[INFO] Stopping event consumer...")
@@ -163,6 +595,14 @@ func (w *EventConsumerWrapper) Stop() {
 	log.Printf("[INFO] Event consumer stopped")
 }
 
+// StopWorkers stops the worker pool handling the consumed messages and its dead letter queue.
+// Call it once consuming stopped.
+func (w *EventConsumerWrapper) StopWorkers() {
+	if pool, ok := w.eventConsumer.(interface{ Stop() }); ok {
+		pool.Stop()
+	}
+}
+
 // SimpleLogger implements the Logger interface
 type SimpleLogger struct{}
 
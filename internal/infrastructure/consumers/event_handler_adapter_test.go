@@ -0,0 +1,87 @@
+package consumers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/pkg/health"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventConsumerWrapper_RunStopsWithContext(t *testing.T) {
+	consumer := mocks.NewConsumer(t, nil)
+	consumer.SetTopicMetadata(map[string][]int32{"user.events": {0}})
+	consumer.ExpectConsumePartition("user.events", 0, sarama.OffsetNewest)
+
+	wrapper := consumers.NewEventConsumerWrapper(consumer, "group", []string{"user.events"})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- wrapper.Run(ctx) }()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+}
+
+func TestEventConsumerWrapper_RunFailsWhenATopicStops(t *testing.T) {
+	// Without partitions the consumer of the topic exits at once
+	consumer := mocks.NewConsumer(t, nil)
+	consumer.SetTopicMetadata(map[string][]int32{"user.events": {}})
+
+	wrapper := consumers.NewEventConsumerWrapper(consumer, "group", []string{"user.events"})
+	err := wrapper.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "consumer of topic user.events exited")
+}
+
+func TestEventConsumerWrapper_Standby(t *testing.T) {
+	consumer := mocks.NewConsumer(t, nil)
+	consumer.SetTopicMetadata(map[string][]int32{"user.events": {0}})
+	partitionConsumer := consumer.ExpectConsumePartition("user.events", 0, sarama.OffsetNewest)
+
+	wrapper := consumers.NewEventConsumerWrapper(consumer, "group", []string{"user.events"})
+	wrapper.SetStandby(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go wrapper.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		return len(wrapper.Assignments()["user.events"]) == 1
+	}, 5*time.Second, 10*time.Millisecond, "standby instances still consume their partitions")
+	assert.True(t, wrapper.Standby())
+	assert.True(t, partitionConsumer.IsPaused(), "standby instances start paused")
+
+	wrapper.SetStandby(false)
+	assert.False(t, partitionConsumer.IsPaused())
+
+	wrapper.PauseTopic("user.events")
+	wrapper.SetStandby(true)
+	wrapper.SetStandby(false)
+	assert.True(t, partitionConsumer.IsPaused(), "paused topics stay paused once promoted")
+}
+
+func TestEventConsumerWrapper_HealthCheck(t *testing.T) {
+	consumer := mocks.NewConsumer(t, nil)
+	wrapper := consumers.NewEventConsumerWrapper(consumer, "group", []string{"user.events"})
+
+	wrapper.SetStandby(true)
+	check := wrapper.HealthCheck()(context.Background())
+	assert.Equal(t, health.StatusHealthy, check.Status, "standby instances are healthy")
+	assert.Equal(t, true, check.Details["standby"])
+
+	wrapper.SetStandby(false)
+	wrapper.PauseTopic("user.events")
+	check = wrapper.HealthCheck()(context.Background())
+	assert.Equal(t, health.StatusDegraded, check.Status)
+	assert.Equal(t, []string{"user.events"}, check.Details["paused_topics"])
+}
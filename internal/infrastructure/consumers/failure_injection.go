@@ -0,0 +1,31 @@
+package consumers
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/pkg/faultinjection"
+)
+
+// FailureInjectingHandler fails events before the wrapped handler sees them, at the rate of the
+// fault configured for their event type, so that staging can exercise retries, the dead letter
+// queue and alerting without code changes
+type FailureInjectingHandler struct {
+	next     LegacyEventHandler
+	injector *faultinjection.Injector
+}
+
+// NewFailureInjectingHandler creates a handler failing the events picked by injector
+func NewFailureInjectingHandler(next LegacyEventHandler, injector *faultinjection.Injector) *FailureInjectingHandler {
+	return &FailureInjectingHandler{
+		next:     next,
+		injector: injector,
+	}
+}
+
+// HandleEvent fails the event when a fault is injected, and handles it otherwise
+func (h *FailureInjectingHandler) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	if err := h.injector.Inject(eventType); err != nil {
+		return err
+	}
+	return h.next.HandleEvent(ctx, eventType, eventData)
+}
@@ -0,0 +1,46 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+
+	"go-clean-ddd-es-template/pkg/health"
+)
+
+// HealthCheck reports the event consumer: degraded while topics are paused by operators, healthy
+// otherwise, standby instances included. Crashes of the consumer are reported by its supervisor.
+func (w *EventConsumerWrapper) HealthCheck() health.HealthChecker {
+	return func(ctx context.Context) health.Check {
+		assigned := 0
+		for _, partitions := range w.Assignments() {
+			assigned += len(partitions)
+		}
+		var lag int64
+		for _, topicLag := range w.ConsumerLag() {
+			lag += topicLag
+		}
+		paused := w.PausedTopics()
+
+		check := health.Check{
+			Name:    "event_consumer",
+			Status:  health.StatusHealthy,
+			Message: "Event consumer is consuming",
+			Details: map[string]interface{}{
+				"consumer_group":      w.consumerGroup,
+				"standby":             w.Standby(),
+				"assigned_partitions": assigned,
+				"paused_topics":       paused,
+				"lag":                 lag,
+				"queue_depth":         w.QueueDepth(),
+			},
+		}
+		switch {
+		case len(paused) > 0:
+			check.Status = health.StatusDegraded
+			check.Message = fmt.Sprintf("topics paused: %v", paused)
+		case w.Standby():
+			check.Message = "Event consumer is on standby"
+		}
+		return check
+	}
+}
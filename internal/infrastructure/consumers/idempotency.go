@@ -0,0 +1,56 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// IdempotentHandler skips events its consumer group already processed, so redeliveries and
+// replays from the message broker are not applied to the read models again. Events are marked
+// processed once the wrapped handler succeeded; deliveries of the same event handled concurrently
+// may both be applied. Events without an ID are always handled.
+type IdempotentHandler struct {
+	next          LegacyEventHandler
+	processed     repositories.ProcessedEventRepository
+	consumerGroup string
+	logger        Logger
+}
+
+// NewIdempotentHandler creates a handler skipping events consumerGroup already processed
+func NewIdempotentHandler(next LegacyEventHandler, processed repositories.ProcessedEventRepository, consumerGroup string, logger Logger) *IdempotentHandler {
+	return &IdempotentHandler{
+		next:          next,
+		processed:     processed,
+		consumerGroup: consumerGroup,
+		logger:        logger,
+	}
+}
+
+// HandleEvent handles the event unless the consumer group already processed it
+func (h *IdempotentHandler) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	eventID := EventIDFromContext(ctx)
+	if eventID == "" {
+		return h.next.HandleEvent(ctx, eventType, eventData)
+	}
+
+	processed, err := h.processed.IsProcessed(ctx, h.consumerGroup, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to check whether event %s was processed: %w", eventID, err)
+	}
+	if processed {
+		h.logger.Info("Skipping %s event %s already processed by %s", eventType, eventID, h.consumerGroup)
+		return nil
+	}
+
+	if err := h.next.HandleEvent(ctx, eventType, eventData); err != nil {
+		return err
+	}
+
+	// The event is applied: failing it now would only have it applied again when redelivered
+	if err := h.processed.MarkProcessed(ctx, h.consumerGroup, eventID, eventType); err != nil {
+		h.logger.Error("Failed to mark %s event %s processed by %s: %v", eventType, eventID, h.consumerGroup, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package consumers_test
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentHandler_HandleEvent(t *testing.T) {
+	ctx := context.Background()
+	processed := infraRepos.NewInMemoryProcessedEventRepository()
+	var calls []string
+	next := &recordingHandler{calls: &calls, name: "read-model"}
+	adapter := consumers.NewEventHandlerAdapter(consumers.NewIdempotentHandler(next, processed, "user-service", &consumers.SimpleLogger{}))
+	event := &entities.UserEvent{EventID: "01J0000000000000000000000B", EventType: "user.updated"}
+
+	next.err = assert.AnError
+	assert.ErrorIs(t, adapter.HandleEvent(ctx, event), assert.AnError)
+	done, err := processed.IsProcessed(ctx, "user-service", event.EventID)
+	require.NoError(t, err)
+	assert.False(t, done, "failed events are not marked processed")
+
+	next.err = nil
+	require.NoError(t, adapter.HandleEvent(ctx, event))
+	require.NoError(t, adapter.HandleEvent(ctx, event))
+	assert.Len(t, calls, 2, "redelivered events are skipped once processed")
+
+	other := consumers.NewEventHandlerAdapter(consumers.NewIdempotentHandler(next, processed, "audit-service", &consumers.SimpleLogger{}))
+	require.NoError(t, other.HandleEvent(ctx, event))
+	assert.Len(t, calls, 3, "consumer groups track processed events of their own")
+
+	event.EventID = ""
+	require.NoError(t, adapter.HandleEvent(ctx, event))
+	require.NoError(t, adapter.HandleEvent(ctx, event))
+	assert.Len(t, calls, 5, "events without an ID are always handled")
+}
@@ -0,0 +1,52 @@
+package consumers
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// eventIDKey is the context key of the ID of the event being handled
+type eventIDKey struct{}
+
+// ContextWithEventID returns a context carrying the ID of the event being handled
+func ContextWithEventID(ctx context.Context, eventID string) context.Context {
+	return context.WithValue(ctx, eventIDKey{}, eventID)
+}
+
+// EventIDFromContext returns the ID of the event being handled, empty when unknown
+func EventIDFromContext(ctx context.Context) string {
+	eventID, _ := ctx.Value(eventIDKey{}).(string)
+	return eventID
+}
+
+// InboxHandler applies each event to the wrapped handler once per consumer, recording it in the
+// inbox in the same transaction as the projection writes. Events redelivered after a crash, a
+// rebalance or a retry are skipped once processed. Events without an ID are always handled.
+type InboxHandler struct {
+	next     LegacyEventHandler
+	inbox    repositories.InboxRepository
+	consumer string
+}
+
+// NewInboxHandler creates a handler applying events once for consumer, e.g. its consumer group
+func NewInboxHandler(next LegacyEventHandler, inbox repositories.InboxRepository, consumer string) *InboxHandler {
+	return &InboxHandler{
+		next:     next,
+		inbox:    inbox,
+		consumer: consumer,
+	}
+}
+
+// HandleEvent handles the event unless the consumer already processed it
+func (h *InboxHandler) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	eventID := EventIDFromContext(ctx)
+	if eventID == "" {
+		return h.next.HandleEvent(ctx, eventType, eventData)
+	}
+
+	_, err := h.inbox.Apply(ctx, h.consumer, eventID, eventType, func(ctx context.Context) error {
+		return h.next.HandleEvent(ctx, eventType, eventData)
+	})
+	return err
+}
@@ -0,0 +1,35 @@
+package consumers_test
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInboxHandler_HandleEvent(t *testing.T) {
+	inbox := infraRepos.NewInMemoryInboxRepository()
+	var calls []string
+	next := recordingHandler{calls: &calls, name: "projection"}
+	adapter := consumers.NewEventHandlerAdapter(consumers.NewInboxHandler(next, inbox, "user-projections"))
+	event := &entities.UserEvent{EventID: "01J0000000000000000000000A", EventType: "user.created"}
+
+	require.NoError(t, adapter.HandleEvent(context.Background(), event))
+	require.NoError(t, adapter.HandleEvent(context.Background(), event))
+	assert.Len(t, calls, 1, "redelivered events are applied once")
+
+	entry, err := inbox.GetEntry(context.Background(), "user-projections", event.EventID)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, entities.InboxProcessed, entry.Outcome)
+
+	event.EventID = ""
+	require.NoError(t, adapter.HandleEvent(context.Background(), event))
+	require.NoError(t, adapter.HandleEvent(context.Background(), event))
+	assert.Len(t, calls, 3, "events without an ID are always handled")
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"go-clean-ddd-es-template/internal/domain/valueobjects"
 )
 
 // ProductEventHandler handles product-specific events
@@ -35,7 +37,11 @@ func (h *ProductEventHandler) HandleEvent(ctx context.Context, eventType string,
 
 // handleProductCreated handles product.created event
 func (h *ProductEventHandler) handleProductCreated(ctx context.Context, data map[string]interface{}) error {
-	productID, _ := data["product_id"].(string)
+	productID, err := parseProductID(data)
+	if err != nil {
+		log.Printf("Skipping product event: %v", err)
+		return nil
+	}
 	name, _ := data["name"].(string)
 	price, _ := data["price"].(float64)
 	createdAtStr, _ := data["created_at"].(string)
@@ -58,7 +64,11 @@ func (h *ProductEventHandler) handleProductCreated(ctx context.Context, data map
 
 // handleProductUpdated handles product.updated event
 func (h *ProductEventHandler) handleProductUpdated(ctx context.Context, data map[string]interface{}) error {
-	productID, _ := data["product_id"].(string)
+	productID, err := parseProductID(data)
+	if err != nil {
+		log.Printf("Skipping product event: %v", err)
+		return nil
+	}
 	name, _ := data["name"].(string)
 	price, _ := data["price"].(float64)
 	updatedAtStr, _ := data["updated_at"].(string)
@@ -82,7 +92,11 @@ func (h *ProductEventHandler) handleProductUpdated(ctx context.Context, data map
 
 // handleProductDeleted handles product.deleted event
 func (h *ProductEventHandler) handleProductDeleted(ctx context.Context, data map[string]interface{}) error {
-	productID, _ := data["product_id"].(string)
+	productID, err := parseProductID(data)
+	if err != nil {
+		log.Printf("Skipping product event: %v", err)
+		return nil
+	}
 	deletedAtStr, _ := data["deleted_at"].(string)
 
 	deletedAt, err := time.Parse(time.RFC3339, deletedAtStr)
@@ -101,3 +115,9 @@ func (h *ProductEventHandler) handleProductDeleted(ctx context.Context, data map
 
 	return nil
 }
+
+// parseProductID extracts and validates the product ID of an event
+func parseProductID(data map[string]interface{}) (valueobjects.ProductID, error) {
+	raw, _ := data["product_id"].(string)
+	return valueobjects.ParseProductID(raw)
+}
@@ -0,0 +1,103 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// ReadModelReset empties the read models projections write to
+type ReadModelReset interface {
+	Truncate(ctx context.Context) error
+}
+
+// RebuildProgress is the progress of a projection rebuild
+type RebuildProgress struct {
+	Total    int `json:"total"`    // Events stored when the rebuild started
+	Replayed int `json:"replayed"` // Events applied to the projections
+	Skipped  int `json:"skipped"`  // Events of types no projection handles
+}
+
+// ProjectionRebuilder recovers read models by truncating them and replaying the whole event store
+// through the projections, e.g. after a projection bug corrupted them
+type ProjectionRebuilder struct {
+	events     repositories.EventLog
+	readModels ReadModelReset
+	handlers   map[string]LegacyEventHandler // By event type
+	batchSize  int
+}
+
+// NewProjectionRebuilder creates a rebuilder replaying events through the projections handling
+// their type, reading batchSize events at a time
+func NewProjectionRebuilder(events repositories.EventLog, readModels ReadModelReset, handlers map[string]LegacyEventHandler, batchSize int) *ProjectionRebuilder {
+	return &ProjectionRebuilder{
+		events:     events,
+		readModels: readModels,
+		handlers:   handlers,
+		batchSize:  batchSize,
+	}
+}
+
+// Rebuild truncates the read models, then replays every event in the order it was stored,
+// calling report after each batch. It stops at the first event a projection fails to apply;
+// running it again starts over.
+func (r *ProjectionRebuilder) Rebuild(ctx context.Context, report func(RebuildProgress)) (RebuildProgress, error) {
+	var progress RebuildProgress
+	total, err := r.events.CountEvents(ctx)
+	if err != nil {
+		return progress, err
+	}
+	progress.Total = total
+
+	if err := r.readModels.Truncate(ctx); err != nil {
+		return progress, err
+	}
+
+	var after *repositories.EventPosition
+	for {
+		batch, err := r.events.ReadEvents(ctx, after, r.batchSize)
+		if err != nil {
+			return progress, err
+		}
+		for _, stored := range batch {
+			handled, err := r.replay(ctx, stored)
+			if err != nil {
+				return progress, err
+			}
+			if handled {
+				progress.Replayed++
+			} else {
+				progress.Skipped++
+			}
+		}
+		if len(batch) > 0 {
+			report(progress)
+		}
+		if len(batch) < r.batchSize {
+			return progress, nil
+		}
+		position := batch[len(batch)-1].Position()
+		after = &position
+	}
+}
+
+// replay applies a stored event to the projection handling its type, reporting whether there is one
+func (r *ProjectionRebuilder) replay(ctx context.Context, stored *repositories.StoredEvent) (bool, error) {
+	handler, ok := r.handlers[stored.Event.Type]
+	if !ok {
+		return false, nil
+	}
+
+	eventData := make(map[string]interface{})
+	if len(stored.Event.Data) > 0 {
+		if err := json.Unmarshal(stored.Event.Data, &eventData); err != nil {
+			return false, fmt.Errorf("failed to unmarshal data of event %s: %w", stored.ID, err)
+		}
+	}
+	if err := handler.HandleEvent(ContextWithEventID(ctx, stored.ID), stored.Event.Type, eventData); err != nil {
+		return false, fmt.Errorf("failed to replay %s event %s of aggregate %s: %w", stored.Event.Type, stored.ID, stored.AggregateID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,150 @@
+package consumers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryEventLog is an EventLog over events kept in the order they were stored
+type memoryEventLog struct {
+	stored []*repositories.StoredEvent
+	reads  int
+}
+
+func (l *memoryEventLog) append(aggregateID, eventType, data string) {
+	version := 1
+	for _, stored := range l.stored {
+		if stored.AggregateID == aggregateID {
+			version++
+		}
+	}
+	l.stored = append(l.stored, &repositories.StoredEvent{
+		ID:          fmt.Sprintf("event-%d", len(l.stored)+1),
+		AggregateID: aggregateID,
+		Event: &events.Event{
+			Type:      eventType,
+			Data:      []byte(data),
+			Version:   version,
+			Timestamp: time.Date(2024, 1, 1, 0, 0, len(l.stored), 0, time.UTC),
+		},
+	})
+}
+
+func (l *memoryEventLog) CountEvents(ctx context.Context) (int, error) {
+	return len(l.stored), nil
+}
+
+func (l *memoryEventLog) ReadEvents(ctx context.Context, after *repositories.EventPosition, limit int) ([]*repositories.StoredEvent, error) {
+	l.reads++
+	start := 0
+	if after != nil {
+		for start < len(l.stored) && !l.stored[start].Event.Timestamp.After(after.StoredAt) {
+			start++
+		}
+	}
+	end := start + limit
+	if end > len(l.stored) {
+		end = len(l.stored)
+	}
+	return l.stored[start:end], nil
+}
+
+type readModelResetFunc func(ctx context.Context) error
+
+func (f readModelResetFunc) Truncate(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestProjectionRebuilder_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	log := &memoryEventLog{}
+	log.append("user-1", "user.created", `{"user_id":"user-1","email":"one@example.com","name":"One","created_at":"2024-01-01T00:00:00Z"}`)
+	log.append("user-2", "user.created", `{"user_id":"user-2","email":"two@example.com","name":"Two","created_at":"2024-01-01T00:00:00Z"}`)
+	log.append("user-1", "user.updated", `{"user_id":"user-1","name":"One Updated"}`)
+	log.append("product-1", "product.created", `{"product_id":"product-1"}`)
+	log.append("user-2", "user.deleted", `{"user_id":"user-2"}`)
+
+	summaries := infraRepos.NewInMemoryUserSummaryRepository()
+	require.NoError(t, summaries.SaveSummary(ctx, &entities.UserSummary{UserID: "stale", Name: "Stale"}))
+	projector := consumers.NewUserSummaryProjector(summaries)
+	var eventIDs []string
+	recorder := consumers.NewMultiEventHandler(eventIDRecorder{&eventIDs}, projector)
+	handlers := map[string]consumers.LegacyEventHandler{
+		"user.created": recorder,
+		"user.updated": recorder,
+		"user.deleted": recorder,
+	}
+	reset := readModelResetFunc(func(ctx context.Context) error {
+		return summaries.MarkDeleted(ctx, "stale")
+	})
+
+	var reports []consumers.RebuildProgress
+	rebuilder := consumers.NewProjectionRebuilder(log, reset, handlers, 2)
+	progress, err := rebuilder.Rebuild(ctx, func(p consumers.RebuildProgress) {
+		reports = append(reports, p)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, consumers.RebuildProgress{Total: 5, Replayed: 4, Skipped: 1}, progress)
+	assert.Equal(t, []consumers.RebuildProgress{
+		{Total: 5, Replayed: 2},
+		{Total: 5, Replayed: 3, Skipped: 1},
+		{Total: 5, Replayed: 4, Skipped: 1},
+	}, reports)
+	assert.Equal(t, 3, log.reads)
+	assert.Equal(t, []string{"event-1", "event-2", "event-3", "event-5"}, eventIDs, "events are replayed in order with their stored IDs")
+
+	listed, _, err := summaries.ListSummaries(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "One Updated", listed[0].Name)
+}
+
+func TestProjectionRebuilder_StopsAtFailedEvent(t *testing.T) {
+	ctx := context.Background()
+	log := &memoryEventLog{}
+	log.append("user-1", "user.created", `{"user_id":"user-1"}`)
+	log.append("user-1", "user.updated", `{"user_id":"user-1"}`)
+
+	failure := errors.New("read model unavailable")
+	var calls []string
+	handlers := map[string]consumers.LegacyEventHandler{
+		"user.created": recordingHandler{calls: &calls, name: "created", err: failure},
+		"user.updated": recordingHandler{calls: &calls, name: "updated"},
+	}
+	reset := readModelResetFunc(func(ctx context.Context) error { return nil })
+
+	progress, err := consumers.NewProjectionRebuilder(log, reset, handlers, 10).Rebuild(ctx, func(consumers.RebuildProgress) {})
+	assert.ErrorIs(t, err, failure)
+	assert.Contains(t, err.Error(), "event-1")
+	assert.Equal(t, []string{"created"}, calls)
+	assert.Equal(t, consumers.RebuildProgress{Total: 2}, progress)
+
+	truncateFailure := errors.New("truncate failed")
+	reset = readModelResetFunc(func(ctx context.Context) error { return truncateFailure })
+	_, err = consumers.NewProjectionRebuilder(log, reset, handlers, 10).Rebuild(ctx, func(consumers.RebuildProgress) {})
+	assert.ErrorIs(t, err, truncateFailure)
+	assert.Len(t, calls, 1, "nothing is replayed when the read models cannot be truncated")
+}
+
+// eventIDRecorder records the IDs of the events it handles
+type eventIDRecorder struct {
+	ids *[]string
+}
+
+func (r eventIDRecorder) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	*r.ids = append(*r.ids, consumers.EventIDFromContext(ctx))
+	return nil
+}
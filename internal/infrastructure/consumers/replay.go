@@ -0,0 +1,181 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"go-clean-ddd-es-template/pkg/replay"
+
+	"github.com/IBM/sarama"
+)
+
+// partitionBoundary splits a partition between the replay and live lanes. The live lane starts
+// at the newest offset and the replay lane at the oldest; replay stops where live began, and
+// live skips offsets replay already read.
+type partitionBoundary struct {
+	replayed  int64 // Highest offset read by the replay lane, -1 before the first
+	liveStart int64 // First offset read by the live lane, -1 before the first
+}
+
+// newPartitionBoundary creates the boundary of a partition nothing was read from yet
+func newPartitionBoundary() *partitionBoundary {
+	return &partitionBoundary{replayed: -1, liveStart: -1}
+}
+
+// acceptReplay reports whether the replay lane handles offset, false once it reached the live lane
+func (b *partitionBoundary) acceptReplay(offset int64) bool {
+	if b.liveStart >= 0 && offset >= b.liveStart {
+		return false
+	}
+	if offset > b.replayed {
+		b.replayed = offset
+	}
+	return true
+}
+
+// acceptLive reports whether the live lane handles offset, false when the replay lane already read it
+func (b *partitionBoundary) acceptLive(offset int64) bool {
+	if offset <= b.replayed {
+		return false
+	}
+	if b.liveStart < 0 {
+		b.liveStart = offset
+	}
+	return true
+}
+
+// acceptMessage checks a message read by a lane against the boundary of its partition
+func (w *EventConsumerWrapper) acceptMessage(lane replay.Lane, msg *sarama.ConsumerMessage) bool {
+	w.boundaryMu.Lock()
+	defer w.boundaryMu.Unlock()
+
+	if w.boundaries[msg.Topic] == nil {
+		w.boundaries[msg.Topic] = make(map[int32]*partitionBoundary)
+	}
+	boundary, ok := w.boundaries[msg.Topic][msg.Partition]
+	if !ok {
+		boundary = newPartitionBoundary()
+		w.boundaries[msg.Topic][msg.Partition] = boundary
+	}
+
+	if lane == replay.LaneReplay {
+		return boundary.acceptReplay(msg.Offset)
+	}
+	return boundary.acceptLive(msg.Offset)
+}
+
+// replayTopic reads the history of every partition of a topic into the replay lane
+func (w *EventConsumerWrapper) replayTopic(ctx context.Context, topic string) {
+	defer w.wg.Done()
+
+	partitions, err := w.consumer.Partitions(topic)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get partitions for replay of topic %s: %v", topic, err)
+		return
+	}
+
+	for _, partition := range partitions {
+		w.wg.Add(1)
+		go w.replayPartition(ctx, topic, partition)
+	}
+}
+
+// replayPartition reads a partition from the oldest offset until it reaches the live lane or
+// the high water mark the partition had when it was read
+func (w *EventConsumerWrapper) replayPartition(ctx context.Context, topic string, partition int32) {
+	defer w.wg.Done()
+
+	partitionConsumer, err := w.consumer.ConsumePartition(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create replay consumer for topic %s partition %d: %v", topic, partition, err)
+		return
+	}
+	defer partitionConsumer.Close()
+
+	log.Printf("[INFO] Replaying topic %s partition %d", topic, partition)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stopChan:
+			return
+		case msg := <-partitionConsumer.Messages():
+			if msg == nil {
+				continue
+			}
+			if !w.acceptMessage(replay.LaneReplay, msg) {
+				log.Printf("[INFO] Replay of topic %s partition %d reached live consumption at offset %d", topic, partition, msg.Offset)
+				return
+			}
+			if err := w.merger.Push(ctx, replay.LaneReplay, orderingKey(msg), msg); err != nil {
+				return
+			}
+			if msg.Offset >= partitionConsumer.HighWaterMarkOffset()-1 {
+				log.Printf("[INFO] Replay of topic %s partition %d caught up at offset %d", topic, partition, msg.Offset)
+				return
+			}
+		case err := <-partitionConsumer.Errors():
+			if err != nil {
+				log.Printf("[ERROR] Error replaying topic %s partition %d: %v", topic, partition, err)
+			}
+		}
+	}
+}
+
+// dispatchLanes hands merged live and replay messages to the event consumer
+func (w *EventConsumerWrapper) dispatchLanes(ctx context.Context) {
+	defer w.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		item, err := w.merger.Next(ctx)
+		if err != nil {
+			return
+		}
+
+		msg := item.Value.(*sarama.ConsumerMessage)
+		if err := w.eventConsumer.HandleMessage(messageContext(ctx, msg.Topic, msg), msg.Value); err != nil {
+			log.Printf("[ERROR] Failed to handle %s message from topic %s: %v", item.Lane, msg.Topic, err)
+		}
+	}
+}
+
+// orderingKey returns the key whose messages must be handled in order: the user or product
+// the event is about, or else the message key
+func orderingKey(msg *sarama.ConsumerMessage) string {
+	var envelope struct {
+		Data []byte `json:"data"`
+	}
+	var data struct {
+		UserID    string `json:"user_id"`
+		ProductID string `json:"product_id"`
+	}
+	if json.Unmarshal(msg.Value, &envelope) == nil && json.Unmarshal(envelope.Data, &data) == nil {
+		if data.UserID != "" {
+			return "user:" + data.UserID
+		}
+		if data.ProductID != "" {
+			return "product:" + data.ProductID
+		}
+	}
+	return string(msg.Key)
+}
+
+// LaneStats returns the live and replay lane statistics, or nil when replay is disabled
+func (w *EventConsumerWrapper) LaneStats() *replay.Stats {
+	if w.merger == nil {
+		return nil
+	}
+	stats := w.merger.Stats()
+	return &stats
+}
@@ -0,0 +1,173 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Outcomes of consumed jobs, the outcome attribute of the consumer instruments
+const (
+	outcomeProcessed   = "processed"
+	outcomeFailed      = "failed"
+	outcomeRedelivered = "redelivered"
+)
+
+// jobDuration records how long consumed jobs take, from the first attempt until they are
+// processed, redelivered or given up to the dead letter queue
+var jobDuration, _ = metrics.Meter().Float64Histogram("consumer.job.duration",
+	metric.WithDescription("Duration of consumed jobs, retries included"),
+	metric.WithUnit("s"))
+
+// recordJob records the duration of a job consumed from topic since start
+func recordJob(ctx context.Context, topic, outcome string, start time.Time) {
+	jobDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String("topic", topic),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// consumerInstruments are the observable instruments of the consumer stats
+type consumerInstruments struct {
+	events     metric.Int64ObservableCounter
+	workerJobs metric.Int64ObservableCounter
+	queueDepth metric.Int64ObservableGauge
+	deferred   metric.Int64ObservableGauge
+	lag        metric.Int64ObservableGauge
+	laneQueued metric.Int64ObservableGauge
+	laneItems  metric.Int64ObservableCounter
+	dlqEvents  metric.Int64ObservableGauge
+	dlqUsage   metric.Float64ObservableGauge
+}
+
+// newConsumerInstruments creates the observable instruments of the consumer stats on meter
+func newConsumerInstruments(meter metric.Meter) (*consumerInstruments, error) {
+	var i consumerInstruments
+	var err, errs error
+	i.events, err = meter.Int64ObservableCounter("consumer.events",
+		metric.WithDescription("Consumed events by outcome"))
+	errs = errors.Join(errs, err)
+	i.workerJobs, err = meter.Int64ObservableCounter("consumer.worker.jobs",
+		metric.WithDescription("Jobs started and failed by each consumer worker"))
+	errs = errors.Join(errs, err)
+	i.queueDepth, err = meter.Int64ObservableGauge("consumer.queue.depth",
+		metric.WithDescription("Jobs waiting for a consumer worker"))
+	errs = errors.Join(errs, err)
+	i.deferred, err = meter.Int64ObservableGauge("consumer.deferred",
+		metric.WithDescription("Throttled events waiting per tenant"))
+	errs = errors.Join(errs, err)
+	i.lag, err = meter.Int64ObservableGauge("consumer.lag",
+		metric.WithDescription("Messages behind the newest offset per topic"))
+	errs = errors.Join(errs, err)
+	i.laneQueued, err = meter.Int64ObservableGauge("consumer.lane.queued",
+		metric.WithDescription("Messages queued in the live and replay lanes"))
+	errs = errors.Join(errs, err)
+	i.laneItems, err = meter.Int64ObservableCounter("consumer.lane.items",
+		metric.WithDescription("Messages emitted by the live and replay lanes"))
+	errs = errors.Join(errs, err)
+	i.dlqEvents, err = meter.Int64ObservableGauge("dlq.events",
+		metric.WithDescription("Events in the dead letter queue"))
+	errs = errors.Join(errs, err)
+	i.dlqUsage, err = meter.Float64ObservableGauge("dlq.utilization",
+		metric.WithDescription("Share of the dead letter queue capacity in use"),
+		metric.WithUnit("%"))
+	errs = errors.Join(errs, err)
+	if errs != nil {
+		return nil, errs
+	}
+	return &i, nil
+}
+
+// observables returns the instruments observed by the callback
+func (i *consumerInstruments) observables() []metric.Observable {
+	return []metric.Observable{i.events, i.workerJobs, i.queueDepth, i.deferred, i.lag, i.laneQueued, i.laneItems, i.dlqEvents, i.dlqUsage}
+}
+
+// RegisterMetrics exports the stats of the consumer, its worker pool, lanes and dead letter queue
+// as OpenTelemetry instruments of meter, observed at each collection. Unregister the returned
+// registration to stop observing the consumer.
+func (w *EventConsumerWrapper) RegisterMetrics(meter metric.Meter) (metric.Registration, error) {
+	instruments, err := newConsumerInstruments(meter)
+	if err != nil {
+		return nil, err
+	}
+	return meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		w.observe(ctx, o, instruments)
+		return nil
+	}, instruments.observables()...)
+}
+
+// observe observes the current stats of the consumer
+func (w *EventConsumerWrapper) observe(ctx context.Context, o metric.Observer, i *consumerInstruments) {
+	if m := w.GetMetrics(); m != nil {
+		for outcome, count := range map[string]int64{
+			outcomeProcessed: m.ProcessedEvents,
+			outcomeFailed:    m.FailedEvents,
+			"retried":        m.RetryEvents,
+			"throttled":      m.ThrottledEvents,
+			"expired":        m.ExpiredEvents,
+			"expired_routed": m.RoutedExpired,
+			"poison":         m.PoisonEvents,
+		} {
+			o.ObserveInt64(i.events, count, metric.WithAttributes(attribute.String("outcome", outcome)))
+		}
+		for id, stats := range m.WorkerStats {
+			worker := attribute.String("worker", strconv.Itoa(id))
+			o.ObserveInt64(i.workerJobs, stats.JobsProcessed, metric.WithAttributes(worker, attribute.String("outcome", "started")))
+			o.ObserveInt64(i.workerJobs, stats.JobsFailed, metric.WithAttributes(worker, attribute.String("outcome", outcomeFailed)))
+		}
+	}
+
+	o.ObserveInt64(i.queueDepth, int64(w.QueueDepth()))
+	for tenant, count := range w.DeferredEvents() {
+		o.ObserveInt64(i.deferred, int64(count), metric.WithAttributes(attribute.String("tenant", tenant)))
+	}
+	for topic, lag := range w.ConsumerLag() {
+		o.ObserveInt64(i.lag, lag, metric.WithAttributes(attribute.String("topic", topic)))
+	}
+
+	if lanes := w.LaneStats(); lanes != nil {
+		live, replayed := attribute.String("lane", "live"), attribute.String("lane", "replay")
+		o.ObserveInt64(i.laneQueued, int64(lanes.LiveQueued), metric.WithAttributes(live))
+		o.ObserveInt64(i.laneQueued, int64(lanes.ReplayQueued), metric.WithAttributes(replayed))
+		o.ObserveInt64(i.laneItems, lanes.Live, metric.WithAttributes(live))
+		o.ObserveInt64(i.laneItems, lanes.Replayed, metric.WithAttributes(replayed))
+	}
+
+	// Consumers without a dead letter queue, or whose storage is unreachable, report no DLQ stats
+	if stats, err := w.GetDLQStats(ctx); err == nil {
+		o.ObserveInt64(i.dlqEvents, int64(stats.TotalEvents))
+		o.ObserveFloat64(i.dlqUsage, stats.Utilization)
+	}
+}
+
+// Stats returns the current stats of the consumer and its dead letter queue, named like their
+// instruments with the attribute value appended, e.g. consumer.lag.user-events
+func (w *EventConsumerWrapper) Stats(ctx context.Context) map[string]float64 {
+	stats := map[string]float64{"consumer.queue.depth": float64(w.QueueDepth())}
+	if m := w.GetMetrics(); m != nil {
+		stats["consumer.events."+outcomeProcessed] = float64(m.ProcessedEvents)
+		stats["consumer.events."+outcomeFailed] = float64(m.FailedEvents)
+		stats["consumer.events.retried"] = float64(m.RetryEvents)
+		stats["consumer.events.throttled"] = float64(m.ThrottledEvents)
+		stats["consumer.events.expired"] = float64(m.ExpiredEvents)
+		stats["consumer.events.poison"] = float64(m.PoisonEvents)
+	}
+	for tenant, count := range w.DeferredEvents() {
+		stats["consumer.deferred."+tenant] = float64(count)
+	}
+	for topic, lag := range w.ConsumerLag() {
+		stats["consumer.lag."+topic] = float64(lag)
+	}
+	if dlq, err := w.GetDLQStats(ctx); err == nil {
+		stats["dlq.events"] = float64(dlq.TotalEvents)
+		stats["dlq.utilization"] = dlq.Utilization
+	}
+	return stats
+}
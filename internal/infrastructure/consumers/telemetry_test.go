@@ -0,0 +1,84 @@
+package consumers_test
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collect collects the metrics of reader by name
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+// valueOf returns the value of the data point of an int64 sum or gauge with attrs
+func valueOf(t *testing.T, data metricdata.Aggregation, attrs ...attribute.KeyValue) int64 {
+	var points []metricdata.DataPoint[int64]
+	switch data := data.(type) {
+	case metricdata.Sum[int64]:
+		points = data.DataPoints
+	case metricdata.Gauge[int64]:
+		points = data.DataPoints
+	default:
+		t.Fatalf("unexpected aggregation %T", data)
+	}
+	want := attribute.NewSet(attrs...)
+	for _, point := range points {
+		if point.Attributes.Equals(&want) {
+			return point.Value
+		}
+	}
+	t.Fatalf("no data point with attributes %v", attrs)
+	return 0
+}
+
+func TestEventConsumerWrapper_RegisterMetrics(t *testing.T) {
+	cfg := &config.Config{MessageBroker: config.MessageBrokerConfig{ConsumerWorkers: 2, WorkerBufferSize: 10}}
+	wrapper := consumers.NewEventConsumerWrapperWithWorkerPool(mocks.NewConsumer(t, nil), "group", []string{"user.events"}, cfg, noopLogger{}, nil)
+	defer wrapper.Stop()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	registration, err := wrapper.RegisterMetrics(provider.Meter("test"))
+	require.NoError(t, err)
+
+	metrics := collect(t, reader)
+	assert.Equal(t, int64(0), valueOf(t, metrics["consumer.events"], attribute.String("outcome", "processed")))
+	assert.Equal(t, int64(0), valueOf(t, metrics["consumer.worker.jobs"], attribute.String("worker", "2"), attribute.String("outcome", "started")))
+	assert.Equal(t, int64(0), valueOf(t, metrics["consumer.queue.depth"]))
+	assert.Equal(t, int64(0), valueOf(t, metrics["dlq.events"]))
+	assert.Contains(t, metrics, "dlq.utilization")
+	assert.NotContains(t, metrics, "consumer.lane.queued", "lanes are only observed when replay is enabled")
+
+	require.NoError(t, registration.Unregister())
+	assert.Empty(t, collect(t, reader))
+}
+
+func TestEventConsumerWrapper_Stats(t *testing.T) {
+	cfg := &config.Config{MessageBroker: config.MessageBrokerConfig{ConsumerWorkers: 2, WorkerBufferSize: 10}}
+	wrapper := consumers.NewEventConsumerWrapperWithWorkerPool(mocks.NewConsumer(t, nil), "group", []string{"user.events"}, cfg, noopLogger{}, nil)
+	defer wrapper.Stop()
+
+	stats := wrapper.Stats(context.Background())
+	assert.Equal(t, float64(0), stats["consumer.events.processed"])
+	assert.Equal(t, float64(0), stats["consumer.queue.depth"])
+	assert.Contains(t, stats, "dlq.events")
+	assert.Contains(t, stats, "dlq.utilization")
+}
@@ -0,0 +1,70 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// UserChangeLogEventTypes are the events recorded in the user change log
+var UserChangeLogEventTypes = []string{"user.created", "user.updated", "user.deleted"}
+
+// userChangeOperations map recorded events to change operations, and to the data field of the
+// time they occurred at
+var userChangeOperations = map[string]struct {
+	operation string
+	timeField string
+}{
+	"user.created": {entities.UserChangeCreated, "created_at"},
+	"user.updated": {entities.UserChangeUpdated, "updated_at"},
+	"user.deleted": {entities.UserChangeDeleted, "deleted_at"},
+}
+
+// UserChangeLogProjector appends user events to the user change log external systems sync from
+type UserChangeLogProjector struct {
+	changeLogRepository repositories.UserChangeLogRepository
+	clock               clock.Clock
+}
+
+// NewUserChangeLogProjector creates a new user change log projector. A nil clock uses the system clock.
+func NewUserChangeLogProjector(changeLogRepository repositories.UserChangeLogRepository, clk clock.Clock) *UserChangeLogProjector {
+	return &UserChangeLogProjector{
+		changeLogRepository: changeLogRepository,
+		clock:               clock.OrDefault(clk),
+	}
+}
+
+// HandleEvent appends a user event to the change log. Changes are keyed by user, event type and
+// the time the event occurred at, so redelivered events are recorded once; events without that
+// time are recorded every time they are delivered.
+func (p *UserChangeLogProjector) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	userID, _ := eventData["user_id"].(string)
+	if userID == "" {
+		return fmt.Errorf("%s event without user_id", eventType)
+	}
+	mapping, ok := userChangeOperations[eventType]
+	if !ok {
+		return fmt.Errorf("unknown user change event type: %s", eventType)
+	}
+
+	recordedAt := p.clock.Now().UTC().Truncate(time.Millisecond)
+	occurredAt, _ := eventData[mapping.timeField].(string)
+	if occurredAt == "" {
+		occurredAt = "recorded:" + recordedAt.Format(time.RFC3339Nano)
+	}
+	email, _ := eventData["email"].(string)
+	name, _ := eventData["name"].(string)
+	return p.changeLogRepository.AppendChange(ctx, &entities.UserChange{
+		Key:        userID + "/" + eventType + "/" + occurredAt,
+		UserID:     userID,
+		Operation:  mapping.operation,
+		Email:      email,
+		Name:       name,
+		OccurredAt: eventTime(eventData, mapping.timeField),
+		RecordedAt: recordedAt,
+	})
+}
@@ -0,0 +1,57 @@
+package consumers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserChangeLogProjector_HandleEvent(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	repo := infraRepos.NewInMemoryUserChangeLogRepository()
+	projector := consumers.NewUserChangeLogProjector(repo, clk)
+
+	created := map[string]interface{}{
+		"user_id":    "user-123",
+		"email":      "test@example.com",
+		"name":       "John Doe",
+		"created_at": "2024-01-01T00:00:00Z",
+	}
+	require.NoError(t, projector.HandleEvent(ctx, "user.created", created))
+	// Redelivered events are recorded once
+	require.NoError(t, projector.HandleEvent(ctx, "user.created", created))
+	require.NoError(t, projector.HandleEvent(ctx, "user.updated", map[string]interface{}{
+		"user_id":    "user-123",
+		"name":       "John Updated",
+		"updated_at": "2024-01-02T00:00:00Z",
+	}))
+	require.NoError(t, projector.HandleEvent(ctx, "user.deleted", map[string]interface{}{
+		"user_id":    "user-123",
+		"deleted_at": "2024-01-02T12:00:00Z",
+	}))
+
+	changes, err := repo.ListChanges(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, []int64{1, 2, 3}, []int64{changes[0].Sequence, changes[1].Sequence, changes[2].Sequence})
+	assert.Equal(t, entities.UserChangeCreated, changes[0].Operation)
+	assert.Equal(t, "test@example.com", changes[0].Email)
+	assert.Equal(t, entities.UserChangeUpdated, changes[1].Operation)
+	assert.Equal(t, "John Updated", changes[1].Name)
+	assert.Empty(t, changes[1].Email)
+	assert.Equal(t, entities.UserChangeDeleted, changes[2].Operation)
+	assert.Equal(t, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), changes[2].OccurredAt)
+	assert.Equal(t, clk.Now(), changes[2].RecordedAt)
+
+	assert.Error(t, projector.HandleEvent(ctx, "user.created", map[string]interface{}{}))
+	assert.Error(t, projector.HandleEvent(ctx, "user.login", map[string]interface{}{"user_id": "user-123"}))
+}
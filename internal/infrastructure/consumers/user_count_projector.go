@@ -0,0 +1,37 @@
+package consumers
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// UserCountProjector maintains the user counters read by list queries: created users are counted
+// active, deleted ones move from active to deleted. Other events are ignored, so the projector
+// can handle every user event next to the other user projections.
+type UserCountProjector struct {
+	countRepository repositories.UserCountRepository
+}
+
+// NewUserCountProjector creates a new user count projector
+func NewUserCountProjector(countRepository repositories.UserCountRepository) *UserCountProjector {
+	return &UserCountProjector{
+		countRepository: countRepository,
+	}
+}
+
+// HandleEvent counts a created or deleted user
+func (p *UserCountProjector) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	switch eventType {
+	case "user.created":
+		return p.countRepository.Increment(ctx, entities.UserStatusActive, 1)
+	case "user.deleted":
+		if err := p.countRepository.Increment(ctx, entities.UserStatusActive, -1); err != nil {
+			return err
+		}
+		return p.countRepository.Increment(ctx, entities.UserStatusDeleted, 1)
+	default:
+		return nil
+	}
+}
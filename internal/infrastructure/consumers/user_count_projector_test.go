@@ -0,0 +1,29 @@
+package consumers_test
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCountProjector_HandleEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := infraRepos.NewInMemoryUserCountRepository()
+	projector := consumers.NewUserCountProjector(repo)
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		require.NoError(t, projector.HandleEvent(ctx, "user.created", map[string]interface{}{"user_id": userID}))
+	}
+	require.NoError(t, projector.HandleEvent(ctx, "user.updated", map[string]interface{}{"user_id": "user-1"}))
+	require.NoError(t, projector.HandleEvent(ctx, "user.deleted", map[string]interface{}{"user_id": "user-2"}))
+
+	counts, err := repo.Counts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{entities.UserStatusActive: 1, entities.UserStatusDeleted: 1}, counts)
+}
@@ -2,6 +2,7 @@ package consumers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -30,6 +31,12 @@ func (h *UserEventHandler) HandleEvent(ctx context.Context, eventType string, ev
 		return h.handleUserUpdated(ctx, eventData)
 	case "user.deleted":
 		return h.handleUserDeleted(ctx, eventData)
+	case "user.preferences_updated":
+		return h.handleUserPreferencesUpdated(ctx, eventData)
+	case "user.email_verified":
+		return h.handleUserEmailVerified(ctx, eventData)
+	case "user.avatar_updated":
+		return h.handleUserAvatarUpdated(ctx, eventData)
 	default:
 		return fmt.Errorf("unknown user event type: %s", eventType)
 	}
@@ -181,3 +188,141 @@ func (h *UserEventHandler) handleUserDeleted(ctx context.Context, data map[strin
 
 	return nil
 }
+
+// handleUserPreferencesUpdated handles user.preferences_updated event. The event carries every
+// setting the user chose, so it replaces the preferences document of the user.
+func (h *UserEventHandler) handleUserPreferencesUpdated(ctx context.Context, data map[string]interface{}) error {
+	userID, _ := data["user_id"].(string)
+	updatedAtStr, _ := data["updated_at"].(string)
+
+	updatedAt, err := time.Parse(time.RFC3339, updatedAtStr)
+	if err != nil {
+		updatedAt = time.Now()
+	}
+
+	// The settings are a nested document, decode them back from the event data
+	var settings entities.PreferenceSettings
+	if raw, ok := data["preferences"]; ok {
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("invalid preferences of user.preferences_updated event: %w", err)
+		}
+		if err := json.Unmarshal(encoded, &settings); err != nil {
+			return fmt.Errorf("invalid preferences of user.preferences_updated event: %w", err)
+		}
+	}
+
+	// Get existing user from MongoDB
+	existingUser, err := h.readRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Replace preferences
+	existingUser.Preferences = &entities.PreferencesDocument{
+		PreferenceSettings: settings,
+		UpdatedAt:          updatedAt,
+	}
+	existingUser.Version++
+
+	// Save to MongoDB
+	if err := h.readRepository.UpdateUser(ctx, existingUser); err != nil {
+		return err
+	}
+
+	// Save event to MongoDB
+	userEvent := &entities.UserEvent{
+		UserID:    userID,
+		EventType: "user.preferences_updated",
+		EventData: data,
+		Timestamp: time.Now(),
+		Version:   existingUser.Version,
+	}
+
+	if err := h.readRepository.SaveEvent(ctx, userEvent); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// handleUserEmailVerified handles user.email_verified event
+func (h *UserEventHandler) handleUserEmailVerified(ctx context.Context, data map[string]interface{}) error {
+	userID, _ := data["user_id"].(string)
+	verifiedAtStr, _ := data["verified_at"].(string)
+
+	verifiedAt, err := time.Parse(time.RFC3339, verifiedAtStr)
+	if err != nil {
+		verifiedAt = time.Now()
+	}
+
+	// Get existing user from MongoDB
+	existingUser, err := h.readRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Mark the email verified
+	existingUser.EmailVerifiedAt = &verifiedAt
+	existingUser.UpdatedAt = verifiedAt
+	existingUser.Version++
+
+	// Save to MongoDB
+	if err := h.readRepository.UpdateUser(ctx, existingUser); err != nil {
+		return err
+	}
+
+	// Save event to MongoDB
+	userEvent := &entities.UserEvent{
+		UserID:    userID,
+		EventType: "user.email_verified",
+		EventData: data,
+		Timestamp: time.Now(),
+		Version:   existingUser.Version,
+	}
+
+	return h.readRepository.SaveEvent(ctx, userEvent)
+}
+
+// handleUserAvatarUpdated handles user.avatar_updated event, replacing the avatar metadata of the
+// user
+func (h *UserEventHandler) handleUserAvatarUpdated(ctx context.Context, data map[string]interface{}) error {
+	userID, _ := data["user_id"].(string)
+
+	// The metadata is a nested document, decode it back from the event data
+	var avatar entities.AvatarMetadata
+	encoded, err := json.Marshal(data["avatar"])
+	if err != nil {
+		return fmt.Errorf("invalid avatar of user.avatar_updated event: %w", err)
+	}
+	if err := json.Unmarshal(encoded, &avatar); err != nil {
+		return fmt.Errorf("invalid avatar of user.avatar_updated event: %w", err)
+	}
+
+	// Get existing user from MongoDB
+	existingUser, err := h.readRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Replace the avatar
+	existingUser.Avatar = &avatar
+	existingUser.UpdatedAt = avatar.UploadedAt
+	existingUser.Version++
+
+	// Save to MongoDB
+	if err := h.readRepository.UpdateUser(ctx, existingUser); err != nil {
+		return err
+	}
+
+	// Save event to MongoDB
+	userEvent := &entities.UserEvent{
+		UserID:    userID,
+		EventType: "user.avatar_updated",
+		EventData: data,
+		Timestamp: time.Now(),
+		Version:   existingUser.Version,
+	}
+
+	return h.readRepository.SaveEvent(ctx, userEvent)
+}
@@ -0,0 +1,46 @@
+package consumers_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserEventHandler_HandleAvatarUpdated(t *testing.T) {
+	ctx := context.Background()
+	readRepository := repositories.NewInMemoryUserReadRepository(nil)
+	require.NoError(t, readRepository.SaveUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: "a@example.com", Name: "Alice", Version: 1}))
+	handler := consumers.NewUserEventHandler(readRepository)
+
+	uploadedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	event, err := events.NewUserAvatarUpdatedEvent("user-1", entities.AvatarMetadata{
+		Key:         "avatars/user-1/1.png",
+		ContentType: "image/png",
+		Size:        128,
+		UploadedAt:  uploadedAt,
+	})
+	require.NoError(t, err)
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+
+	require.NoError(t, handler.HandleEvent(ctx, "user.avatar_updated", data))
+
+	user, err := readRepository.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, user.Avatar)
+	assert.Equal(t, "avatars/user-1/1.png", user.Avatar.Key)
+	assert.Equal(t, "image/png", user.Avatar.ContentType)
+	assert.Equal(t, int64(128), user.Avatar.Size)
+	assert.True(t, uploadedAt.Equal(user.Avatar.UploadedAt))
+	assert.Equal(t, 2, user.Version)
+	assert.Equal(t, "Alice", user.Name, "the rest of the user is kept")
+}
@@ -0,0 +1,38 @@
+package consumers_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserEventHandler_HandleEmailVerified(t *testing.T) {
+	ctx := context.Background()
+	readRepository := repositories.NewInMemoryUserReadRepository(nil)
+	require.NoError(t, readRepository.SaveUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: "a@example.com", Name: "Alice", Version: 1}))
+	handler := consumers.NewUserEventHandler(readRepository)
+
+	verifiedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	event, err := events.NewUserEmailVerifiedEvent("user-1", "a@example.com", verifiedAt)
+	require.NoError(t, err)
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+
+	require.NoError(t, handler.HandleEvent(ctx, "user.email_verified", data))
+
+	user, err := readRepository.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, user.EmailVerifiedAt)
+	assert.True(t, verifiedAt.Equal(*user.EmailVerifiedAt))
+	assert.Equal(t, 2, user.Version)
+	assert.Equal(t, "Alice", user.Name, "the rest of the user is kept")
+}
@@ -0,0 +1,43 @@
+package consumers_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserEventHandler_HandlePreferencesUpdated(t *testing.T) {
+	ctx := context.Background()
+	readRepository := repositories.NewInMemoryUserReadRepository(nil)
+	require.NoError(t, readRepository.SaveUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: "a@example.com", Name: "Alice", Version: 1}))
+	handler := consumers.NewUserEventHandler(readRepository)
+
+	theme, push := entities.ThemeDark, true
+	event, err := events.NewUserPreferencesUpdatedEvent("user-1", entities.PreferenceSettings{
+		Theme:         &theme,
+		Notifications: &entities.NotificationSettings{Push: &push},
+	})
+	require.NoError(t, err)
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+
+	require.NoError(t, handler.HandleEvent(ctx, "user.preferences_updated", data))
+
+	user, err := readRepository.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, user.Preferences)
+	assert.Equal(t, 2, user.Version)
+	assert.Equal(t, entities.ThemeDark, *user.Preferences.Theme)
+	assert.True(t, *user.Preferences.Notifications.Push)
+	assert.Nil(t, user.Preferences.Locale, "settings the user did not choose stay unset")
+	assert.False(t, user.Preferences.UpdatedAt.IsZero())
+	assert.Equal(t, "Alice", user.Name, "the rest of the user is kept")
+}
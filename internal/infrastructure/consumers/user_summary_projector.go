@@ -0,0 +1,86 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// UserSummaryEventTypes are the events projected into user summaries
+var UserSummaryEventTypes = []string{"user.created", "user.updated", "user.deleted", "user.login"}
+
+// UserSummaryProjector maintains the user summary projection read by list queries
+type UserSummaryProjector struct {
+	summaryRepository repositories.UserSummaryRepository
+}
+
+// NewUserSummaryProjector creates a new user summary projector
+func NewUserSummaryProjector(summaryRepository repositories.UserSummaryRepository) *UserSummaryProjector {
+	return &UserSummaryProjector{
+		summaryRepository: summaryRepository,
+	}
+}
+
+// HandleEvent projects a user event into the user's summary
+func (p *UserSummaryProjector) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	userID, _ := eventData["user_id"].(string)
+	if userID == "" {
+		return fmt.Errorf("%s event without user_id", eventType)
+	}
+
+	switch eventType {
+	case "user.created":
+		email, _ := eventData["email"].(string)
+		name, _ := eventData["name"].(string)
+		return p.summaryRepository.SaveSummary(ctx, &entities.UserSummary{
+			UserID:    userID,
+			Email:     email,
+			Name:      name,
+			Status:    entities.UserStatusActive,
+			CreatedAt: eventTime(eventData, "created_at"),
+		})
+	case "user.updated":
+		name, _ := eventData["name"].(string)
+		return p.summaryRepository.UpdateName(ctx, userID, name)
+	case "user.deleted":
+		return p.summaryRepository.MarkDeleted(ctx, userID)
+	case "user.login":
+		return p.summaryRepository.RecordLogin(ctx, userID, eventTime(eventData, "logged_in_at"))
+	default:
+		return fmt.Errorf("unknown user summary event type: %s", eventType)
+	}
+}
+
+// eventTime parses an RFC 3339 time of event data, defaulting to now like UserEventHandler
+func eventTime(eventData map[string]interface{}, key string) time.Time {
+	value, _ := eventData[key].(string)
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// MultiEventHandler passes events to several handlers in order, stopping at the first error, so
+// projections of the same events can be registered together
+type MultiEventHandler struct {
+	handlers []LegacyEventHandler
+}
+
+// NewMultiEventHandler creates a handler passing events to handlers
+func NewMultiEventHandler(handlers ...LegacyEventHandler) *MultiEventHandler {
+	return &MultiEventHandler{handlers: handlers}
+}
+
+// HandleEvent passes the event to every handler
+func (h *MultiEventHandler) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	for _, handler := range h.handlers {
+		if err := handler.HandleEvent(ctx, eventType, eventData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
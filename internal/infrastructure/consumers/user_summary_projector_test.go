@@ -0,0 +1,70 @@
+package consumers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSummaryProjector_HandleEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := infraRepos.NewInMemoryUserSummaryRepository()
+	projector := consumers.NewUserSummaryProjector(repo)
+
+	require.NoError(t, projector.HandleEvent(ctx, "user.created", map[string]interface{}{
+		"user_id":    "user-123",
+		"email":      "test@example.com",
+		"name":       "John Doe",
+		"created_at": "2024-01-01T00:00:00Z",
+	}))
+	require.NoError(t, projector.HandleEvent(ctx, "user.updated", map[string]interface{}{"user_id": "user-123", "name": "John Updated"}))
+	require.NoError(t, projector.HandleEvent(ctx, "user.login", map[string]interface{}{"user_id": "user-123", "logged_in_at": "2024-01-02T00:00:00Z"}))
+
+	summaries, _, err := repo.ListSummaries(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "John Updated", summaries[0].Name)
+	assert.Equal(t, entities.UserStatusActive, summaries[0].Status)
+	require.NotNil(t, summaries[0].LastLoginAt)
+	assert.Equal(t, "2024-01-02T00:00:00Z", summaries[0].LastLoginAt.Format("2006-01-02T15:04:05Z07:00"))
+
+	require.NoError(t, projector.HandleEvent(ctx, "user.deleted", map[string]interface{}{"user_id": "user-123"}))
+	_, total, err := repo.ListSummaries(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	assert.Error(t, projector.HandleEvent(ctx, "user.created", map[string]interface{}{}))
+	assert.Error(t, projector.HandleEvent(ctx, "user.unknown", map[string]interface{}{"user_id": "user-123"}))
+}
+
+type recordingHandler struct {
+	calls *[]string
+	name  string
+	err   error
+}
+
+func (h recordingHandler) HandleEvent(ctx context.Context, eventType string, eventData map[string]interface{}) error {
+	*h.calls = append(*h.calls, h.name)
+	return h.err
+}
+
+func TestMultiEventHandler_StopsAtFirstError(t *testing.T) {
+	var calls []string
+	failure := errors.New("boom")
+	handler := consumers.NewMultiEventHandler(
+		recordingHandler{calls: &calls, name: "first"},
+		recordingHandler{calls: &calls, name: "second", err: failure},
+		recordingHandler{calls: &calls, name: "third"},
+	)
+
+	err := handler.HandleEvent(context.Background(), "user.created", nil)
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
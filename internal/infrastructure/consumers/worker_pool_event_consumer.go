@@ -10,9 +10,19 @@ import (
 	"go-clean-ddd-es-template/internal/domain/entities"
 	"go-clean-ddd-es-template/internal/domain/events"
 	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/dataio"
+	apperrors "go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/featureflags"
+	"go-clean-ddd-es-template/pkg/kafka"
 	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/pkg/retry"
+	"go-clean-ddd-es-template/pkg/tracing"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WorkerPoolEventConsumer handles event consumption with worker pool
@@ -27,8 +37,37 @@ type WorkerPoolEventConsumer struct {
 	stopChan        chan struct{}
 	wg              sync.WaitGroup
 	metrics         *ConsumerMetrics
+	clock           clock.Clock
+
+	// Per-tenant throttling, nil when disabled
+	tenantLimiter *resilience.KeyedLimiter
+	maxDeferred   int
+	deferredMu    sync.Mutex
+	deferred      map[string]int // tenant -> throttled jobs waiting to be queued
+
+	expiredPublisher ExpiredPublisher // nil skips expired events
+	payloadValidator PayloadValidator // nil handles payloads unvalidated
+}
+
+// Redeliverer republishes a message that failed processing for another attempt. It returns
+// false when the message exhausted its redeliveries.
+type Redeliverer interface {
+	Redeliver(message []byte, headers kafka.Headers) (bool, error)
+}
+
+// ExpiredPublisher routes events that exceeded the age limit of their topic to an expired topic
+type ExpiredPublisher interface {
+	PublishExpired(message []byte, headers kafka.Headers) error
 }
 
+// PayloadValidator validates event payloads against the schema of their event type and version
+type PayloadValidator interface {
+	Validate(eventType string, version int, payload []byte) error
+}
+
+// ProcessExpiredEventsFlag is the feature flag that lifts message age limits, e.g. for full replays
+const ProcessExpiredEventsFlag = "process_expired_events"
+
 // ConsumerWorker represents a worker in the consumer pool
 type ConsumerWorker struct {
 	id       int
@@ -39,16 +78,21 @@ type ConsumerWorker struct {
 	stopChan <-chan struct{}
 	wg       *sync.WaitGroup
 	metrics  *ConsumerMetrics
+	clock    clock.Clock
+	group    string                     // Consumer group stamped on messages that failed processing
+	retries  config.MessageBrokerConfig // Retry policies of the topics and event types
+
+	redeliverer Redeliverer // nil sends failed messages straight to the dead letter queue
 }
 
 // ConsumeJob represents a job to consume an event
 type ConsumeJob struct {
 	Message    []byte
+	Headers    kafka.Headers
 	Topic      string
 	Partition  int32
 	Offset     int64
-	RetryCount int
-	MaxRetries int
+	RetryCount int // Attempt the job starts at
 }
 
 // ConsumerMetrics holds metrics for the consumer
@@ -57,6 +101,10 @@ type ConsumerMetrics struct {
 	ProcessedEvents int64
 	FailedEvents    int64
 	RetryEvents     int64
+	ThrottledEvents int64
+	ExpiredEvents   int64 // Events older than the age limit of their topic, skipped or routed
+	RoutedExpired   int64 // Expired events routed to an expired topic
+	PoisonEvents    int64 // Events whose payload violates its schema, quarantined without being handled
 	WorkerStats     map[int]*ConsumerWorkerStats
 }
 
@@ -68,9 +116,18 @@ type ConsumerWorkerStats struct {
 }
 
 // NewWorkerPoolEventConsumer creates a new worker pool event consumer
-func NewWorkerPoolEventConsumer(config *config.Config, consumer sarama.Consumer, logger Logger) *WorkerPoolEventConsumer {
+func NewWorkerPoolEventConsumer(config *config.Config, consumer sarama.Consumer, logger Logger, clk clock.Clock) *WorkerPoolEventConsumer {
 	// Create dead letter queue with in-memory storage
 	dlqConfig := resilience.DefaultDeadLetterQueueConfig()
+	dlqConfig.Clock = clk
+	if config.MessageBroker.DLQMaxAttempts > 0 {
+		dlqConfig.MaxAttempts = config.MessageBroker.DLQMaxAttempts
+	}
+	if config.MessageBroker.DLQAutoRetry {
+		dlqConfig.RetryDelay = config.MessageBroker.DLQRetryDelay
+		dlqConfig.MaxRetryDelay = config.MessageBroker.DLQMaxRetryDelay
+		dlqConfig.ScanInterval = config.MessageBroker.DLQScanInterval
+	}
 	dlq := resilience.NewDeadLetterQueue(dlqConfig, nil, nil)
 
 	eventConsumer := &WorkerPoolEventConsumer{
@@ -82,11 +139,26 @@ func NewWorkerPoolEventConsumer(config *config.Config, consumer sarama.Consumer,
 		jobQueue:        make(chan *ConsumeJob, config.MessageBroker.WorkerBufferSize),
 		stopChan:        make(chan struct{}),
 		metrics:         &ConsumerMetrics{WorkerStats: make(map[int]*ConsumerWorkerStats)},
+		clock:           clock.OrDefault(clk),
+		maxDeferred:     config.Tenancy.MaxDeferredPerTenant,
+		deferred:        make(map[string]int),
+	}
+
+	// Throttle consumption per tenant so one tenant cannot starve the projections of others
+	if config.Tenancy.ConsumerRate > 0 {
+		eventConsumer.tenantLimiter = resilience.NewKeyedLimiter(float64(config.Tenancy.ConsumerRate), config.Tenancy.ConsumerBurst, eventConsumer.clock)
 	}
 
 	// Create worker pool
 	eventConsumer.createWorkerPool()
 
+	// Retry dead letter events in the background with the retry handler set once wired
+	if config.MessageBroker.DLQAutoRetry {
+		if err := dlq.Start(context.Background()); err != nil {
+			logger.Error("Failed to start dead letter queue retries: %v", err)
+		}
+	}
+
 	return eventConsumer
 }
 
@@ -109,6 +181,9 @@ func (ec *WorkerPoolEventConsumer) createWorkerPool() {
 			stopChan: ec.stopChan,
 			wg:       &ec.wg,
 			metrics:  ec.metrics,
+			clock:    ec.clock,
+			group:    ec.config.MessageBroker.GroupID,
+			retries:  ec.config.MessageBroker,
 		}
 
 		ec.workerPool[i] = worker
@@ -130,6 +205,10 @@ func (ec *WorkerPoolEventConsumer) createWorkerPool() {
 func (w *ConsumerWorker) start() {
 	defer w.wg.Done()
 
+	// Retries stop waiting once the worker is stopped
+	stop, cancel := retry.UntilClosed(w.stopChan)
+	defer cancel()
+
 	w.logger.Info("Consumer worker %d started", w.id)
 
 	for {
@@ -142,13 +221,13 @@ func (w *ConsumerWorker) start() {
 				continue
 			}
 
-			w.processJob(job)
+			w.processJob(stop, job)
 		}
 	}
 }
 
-// processJob processes a consume job with retry logic
-func (w *ConsumerWorker) processJob(job *ConsumeJob) {
+// processJob processes a consume job with retry logic, retrying until stop is done
+func (w *ConsumerWorker) processJob(stop context.Context, job *ConsumeJob) {
 	startTime := time.Now()
 
 	// Update worker stats
@@ -158,15 +237,29 @@ func (w *ConsumerWorker) processJob(job *ConsumeJob) {
 	stats.LastJobTime = startTime
 	w.metrics.mu.Unlock()
 
+	// Continue the trace of the publisher from the message headers
+	ctx, span := startConsumeSpan(kafka.ContextWithHeaders(context.Background(), job.Headers), job)
+	var failure error
+	outcome := outcomeFailed
+	defer func() {
+		tracing.End(span, failure)
+		recordJob(ctx, job.Topic, outcome, startTime)
+	}()
+
 	// Parse event from message
 	var event events.Event
 	if err := json.Unmarshal(job.Message, &event); err != nil {
-		w.handleJobError(job, fmt.Errorf("failed to unmarshal event: %w", err))
+		failure = fmt.Errorf("failed to unmarshal event: %w", err)
+		w.handleJobError(job, failure)
 		return
 	}
 
+	// Publish the events caused by handling the event within its correlation
+	ctx = eventContext(ctx, &event, job.Headers)
+
 	// Convert to UserEvent format for processing
 	userEvent := &entities.UserEvent{
+		EventID:   event.ID.String(),
 		UserID:    "", // Will be extracted from event data
 		EventType: event.Type,
 		EventData: make(map[string]interface{}),
@@ -177,7 +270,8 @@ func (w *ConsumerWorker) processJob(job *ConsumeJob) {
 	// Parse event data
 	if len(event.Data) > 0 {
 		if err := json.Unmarshal(event.Data, &userEvent.EventData); err != nil {
-			w.handleJobError(job, fmt.Errorf("failed to unmarshal event data: %w", err))
+			failure = fmt.Errorf("failed to unmarshal event data: %w", err)
+			w.handleJobError(job, failure)
 			return
 		}
 	}
@@ -187,36 +281,96 @@ func (w *ConsumerWorker) processJob(job *ConsumeJob) {
 		userEvent.UserID = userID
 	}
 
-	// Process the event with retry logic
-	var lastErr error
-	for attempt := job.RetryCount; attempt <= job.MaxRetries; attempt++ {
-		if err := w.processEvent(userEvent); err == nil {
-			// Success
-			w.metrics.mu.Lock()
-			w.metrics.ProcessedEvents++
-			w.metrics.mu.Unlock()
-
-			w.logger.Info("Worker %d: Successfully processed event %s from topic %s partition %d offset %d (attempt %d)",
-				w.id, userEvent.EventType, job.Topic, job.Partition, job.Offset, attempt)
-			return
-		} else {
-			lastErr = err
-			if attempt < job.MaxRetries {
-				// Exponential backoff
-				backoff := time.Duration(attempt) * time.Second
-				w.logger.Warn("Worker %d: Failed to process event %s (attempt %d), retrying in %v: %v",
-					w.id, userEvent.EventType, attempt, backoff, err)
-				time.Sleep(backoff)
+	// Process the event with the retry policy of its topic and type, waiting between attempts
+	// only until the worker is stopped
+	err := retry.Retrier{
+		Policy:       retryPolicy(w.retries.RetryPolicyOf(job.Topic, userEvent.EventType)),
+		FirstAttempt: job.RetryCount,
+		Clock:        w.clock,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			w.logger.Warn("Worker %d: Failed to process event %s (attempt %d), retrying in %v: %v",
+				w.id, userEvent.EventType, attempt, delay, err)
+		},
+	}.Do(stop, func(attempt int) error {
+		if err := w.processEvent(ctx, userEvent); err != nil {
+			if apperrors.CodeOf(err, "") != apperrors.ErrDuplicateCommand {
+				return err
 			}
+			// The command the message delivers was handled by an earlier delivery
+			w.logger.Info("Worker %d: Skipping event %s from topic %s, its command %s was already handled",
+				w.id, userEvent.EventType, job.Topic, job.Headers.CommandID())
+			return nil
 		}
+		w.metrics.mu.Lock()
+		w.metrics.ProcessedEvents++
+		w.metrics.mu.Unlock()
+
+		w.logger.Info("Worker %d: Successfully processed event %s from topic %s partition %d offset %d (attempt %d)",
+			w.id, userEvent.EventType, job.Topic, job.Partition, job.Offset, attempt)
+		return nil
+	})
+	if err == nil {
+		outcome = outcomeProcessed
+		return
+	}
+
+	// All attempts failed, redeliver through the retry topic or add to dead letter queue
+	failure = err
+	if w.redeliver(job, err) {
+		outcome = outcomeRedelivered
+		return
+	}
+	w.handleJobError(job, err)
+}
+
+// retryPolicy converts a configured retry policy; a policy without attempts, from a config not
+// loaded from the environment, is the default one
+func retryPolicy(policy config.RetryPolicyConfig) retry.Policy {
+	if policy.MaxAttempts == 0 {
+		return retry.DefaultPolicy()
 	}
+	return retry.Policy{
+		MaxAttempts: policy.MaxAttempts,
+		BaseDelay:   policy.BaseDelay,
+		MaxDelay:    policy.MaxDelay,
+		Jitter:      policy.Jitter,
+	}
+}
+
+// redeliver hands a failed job to the redeliverer and reports whether it was redelivered
+func (w *ConsumerWorker) redeliver(job *ConsumeJob, err error) bool {
+	if w.redeliverer == nil {
+		return false
+	}
+
+	redelivered, redeliverErr := w.redeliverer.Redeliver(job.Message, w.failureHeaders(job, err))
+	if redeliverErr != nil {
+		w.logger.Error("Worker %d: Failed to redeliver message from topic %s: %v", w.id, job.Topic, redeliverErr)
+		return false
+	}
+	if !redelivered {
+		return false
+	}
+
+	w.metrics.mu.Lock()
+	w.metrics.RetryEvents++
+	w.metrics.mu.Unlock()
 
-	// All attempts failed, add to dead letter queue
-	w.handleJobError(job, lastErr)
+	w.logger.Warn("Worker %d: Redelivered message from topic %s (redelivery %d): %v",
+		w.id, job.Topic, job.Headers.RetryCount()+1, err)
+	return true
+}
+
+// failureHeaders returns the headers of a failed job stamped with the error code, the consumer
+// group and, on its first failure, the time it failed
+func (w *ConsumerWorker) failureHeaders(job *ConsumeJob, err error) kafka.Headers {
+	headers := job.Headers.Clone()
+	headers.RecordFailure(string(apperrors.CodeOf(err, apperrors.ErrEventHandlingFailed)), w.group, w.clock.Now())
+	return headers
 }
 
 // processEvent processes a single event
-func (w *ConsumerWorker) processEvent(event *entities.UserEvent) error {
+func (w *ConsumerWorker) processEvent(ctx context.Context, event *entities.UserEvent) error {
 	// Find and execute handler
 	handler, exists := w.handlers[event.EventType]
 	if !exists {
@@ -224,7 +378,33 @@ func (w *ConsumerWorker) processEvent(event *entities.UserEvent) error {
 	}
 
 	// Execute handler
-	return handler.HandleEvent(context.Background(), event)
+	return handleEvent(ctx, handler, event)
+}
+
+// startConsumeSpan starts the span of consuming a job, a child of the span that published the
+// message when its headers carry a trace context
+func startConsumeSpan(ctx context.Context, job *ConsumeJob) (context.Context, trace.Span) {
+	carrier := job.Headers
+	if carrier.TraceParent() == "" {
+		// Events published without headers carry the trace context in their envelope
+		if traceParent := traceParentOf(job.Message); traceParent != "" {
+			carrier = carrier.Clone()
+			carrier.Set(kafka.HeaderTraceParent, traceParent)
+		}
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	return tracing.Start(ctx, "WorkerPoolEventConsumer.Consume",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", job.Topic)),
+	)
+}
+
+// handleEvent executes a handler, e.g. a projection, in a span of its own
+func handleEvent(ctx context.Context, handler EventHandler, event *entities.UserEvent) (err error) {
+	ctx, span := tracing.Start(ctx, "EventHandler.HandleEvent", trace.WithAttributes(attribute.String("event.type", event.EventType)))
+	defer func() { tracing.End(span, err) }()
+
+	return handler.HandleEvent(ctx, event)
 }
 
 // handleJobError handles job processing errors
@@ -242,10 +422,19 @@ func (w *ConsumerWorker) handleJobError(job *ConsumeJob, err error) {
 		"message":   string(job.Message),
 	}
 
+	headers := w.failureHeaders(job, err)
 	metadata := map[string]string{
-		"source": "worker_pool_consumer",
-		"worker": fmt.Sprintf("%d", w.id),
-		"error":  err.Error(),
+		"source":         "worker_pool_consumer",
+		"worker":         fmt.Sprintf("%d", w.id),
+		"error":          err.Error(),
+		"retry_count":    fmt.Sprintf("%d", headers.RetryCount()),
+		"first_failure":  headers.Get(kafka.HeaderFirstFailure),
+		"last_error":     headers.LastError(),
+		"consumer_group": headers.ConsumerGroup(),
+	}
+	// Keep the headers so retries from the dead letter queue republish them
+	if encoded, encodeErr := json.Marshal(headers); encodeErr == nil {
+		metadata["headers"] = string(encoded)
 	}
 
 	if dlqErr := w.dlq.AddEvent(context.Background(), "failed_event", eventData, err, metadata); dlqErr != nil {
@@ -265,19 +454,167 @@ func (ec *WorkerPoolEventConsumer) RegisterHandler(eventType string, handler Eve
 	}
 }
 
-// HandleMessage processes a message using the worker pool
+// SetRedeliverer makes workers redeliver messages that failed processing instead of adding
+// them to the dead letter queue, until the redeliverer reports them exhausted
+func (ec *WorkerPoolEventConsumer) SetRedeliverer(redeliverer Redeliverer) {
+	for _, worker := range ec.workerPool {
+		worker.redeliverer = redeliverer
+	}
+}
+
+// SetRetryHandler sets the handler retrying events from the dead letter queue
+func (ec *WorkerPoolEventConsumer) SetRetryHandler(handler resilience.RetryHandler) {
+	ec.deadLetterQueue.SetRetryHandler(handler)
+}
+
+// SetDLQStorage keeps the events of the dead letter queue in a persistent storage instead of memory
+func (ec *WorkerPoolEventConsumer) SetDLQStorage(storage resilience.DLQStorage) {
+	ec.deadLetterQueue.SetStorage(storage)
+}
+
+// SetExpiredPublisher routes expired events to an expired topic instead of skipping them
+func (ec *WorkerPoolEventConsumer) SetExpiredPublisher(publisher ExpiredPublisher) {
+	ec.expiredPublisher = publisher
+}
+
+// SetPayloadValidator validates event payloads before handlers run. Events with an invalid
+// payload are poison: they are quarantined in the dead letter queue without retries.
+func (ec *WorkerPoolEventConsumer) SetPayloadValidator(validator PayloadValidator) {
+	ec.payloadValidator = validator
+}
+
+// HandleMessage processes a message using the worker pool. Headers of the message are taken from ctx.
 func (ec *WorkerPoolEventConsumer) HandleMessage(ctx context.Context, message []byte) error {
+	headers := kafka.HeadersFromContext(ctx)
+	topic := headers.OriginalTopic()
+	if topic == "" {
+		topic = "unknown"
+	}
+
 	// Create job
 	job := &ConsumeJob{
 		Message:    message,
-		Topic:      "unknown", // Will be set by the caller
+		Headers:    headers,
+		Topic:      topic,
 		Partition:  0,
 		Offset:     0,
 		RetryCount: 1,
-		MaxRetries: 3,
 	}
 
-	// Send job to worker pool
+	if ec.expired(topic, message) {
+		return ec.expire(job)
+	}
+
+	if err := ec.validatePayload(message); err != nil {
+		return ec.quarantine(ctx, job, err)
+	}
+
+	if ec.tenantLimiter != nil {
+		tenant := headers.TenantID()
+		if tenant == "" {
+			tenant = tenantOf(message)
+		}
+		if tenant != "" {
+			if delay := ec.tenantLimiter.Reserve(tenant); delay > 0 {
+				return ec.deferJob(ctx, tenant, job, delay)
+			}
+		}
+	}
+
+	return ec.enqueue(ctx, job)
+}
+
+// expired reports whether an event is older than the age limit of the topic it was consumed from
+func (ec *WorkerPoolEventConsumer) expired(topic string, message []byte) bool {
+	maxAge := ec.config.MessageBroker.MaxMessageAgeOf(topic)
+	if maxAge <= 0 || featureflags.Global().Enabled(ProcessExpiredEventsFlag) {
+		return false
+	}
+
+	var envelope struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil || envelope.Timestamp.IsZero() {
+		return false
+	}
+	return ec.clock.Since(envelope.Timestamp) > maxAge
+}
+
+// expire skips an expired job or routes it to the expired topic
+func (ec *WorkerPoolEventConsumer) expire(job *ConsumeJob) error {
+	ec.metrics.mu.Lock()
+	ec.metrics.ExpiredEvents++
+	ec.metrics.mu.Unlock()
+
+	if ec.expiredPublisher == nil {
+		ec.logger.Info("Skipped expired event from topic %s", job.Topic)
+		return nil
+	}
+
+	if err := ec.expiredPublisher.PublishExpired(job.Message, job.Headers); err != nil {
+		return err
+	}
+
+	ec.metrics.mu.Lock()
+	ec.metrics.RoutedExpired++
+	ec.metrics.mu.Unlock()
+
+	ec.logger.Info("Routed expired event from topic %s", job.Topic)
+	return nil
+}
+
+// validatePayload validates the payload of an event message. Messages that are not events are
+// left to the workers, which dead letter them.
+func (ec *WorkerPoolEventConsumer) validatePayload(message []byte) error {
+	if ec.payloadValidator == nil {
+		return nil
+	}
+
+	var event events.Event
+	if err := json.Unmarshal(message, &event); err != nil {
+		return nil
+	}
+	return ec.payloadValidator.Validate(event.Type, event.Version, event.Data)
+}
+
+// quarantine adds a poison job to the dead letter queue with its validation error, without
+// handling or redelivering it
+func (ec *WorkerPoolEventConsumer) quarantine(ctx context.Context, job *ConsumeJob, err error) error {
+	ec.metrics.mu.Lock()
+	ec.metrics.PoisonEvents++
+	ec.metrics.mu.Unlock()
+
+	eventData := map[string]interface{}{
+		"topic":     job.Topic,
+		"partition": job.Partition,
+		"offset":    job.Offset,
+		"message":   string(job.Message),
+	}
+
+	headers := job.Headers.Clone()
+	headers.RecordFailure(string(apperrors.ErrInvalidEventPayload), ec.config.MessageBroker.GroupID, ec.clock.Now())
+	metadata := map[string]string{
+		"source":           "worker_pool_consumer",
+		"poison":           "true",
+		"validation_error": err.Error(),
+		"retry_count":      fmt.Sprintf("%d", headers.RetryCount()),
+		"first_failure":    headers.Get(kafka.HeaderFirstFailure),
+		"last_error":       headers.LastError(),
+		"consumer_group":   headers.ConsumerGroup(),
+	}
+	if encoded, encodeErr := json.Marshal(headers); encodeErr == nil {
+		metadata["headers"] = string(encoded)
+	}
+
+	if dlqErr := ec.deadLetterQueue.AddEvent(ctx, "poison_event", eventData, err, metadata); dlqErr != nil {
+		return fmt.Errorf("failed to quarantine poison event from topic %s: %w", job.Topic, dlqErr)
+	}
+	ec.logger.Warn("Quarantined poison event from topic %s: %v", job.Topic, err)
+	return nil
+}
+
+// enqueue sends a job to the worker pool
+func (ec *WorkerPoolEventConsumer) enqueue(ctx context.Context, job *ConsumeJob) error {
 	select {
 	case ec.jobQueue <- job:
 		return nil
@@ -285,20 +622,128 @@ func (ec *WorkerPoolEventConsumer) HandleMessage(ctx context.Context, message []
 		return ctx.Err()
 	default:
 		// Queue is full, try to process directly
-		return ec.processDirectly(ctx, message)
+		start := time.Now()
+		ctx, span := startConsumeSpan(ctx, job)
+		err := ec.processDirectly(ctx, job.Topic, job.Message)
+		tracing.End(span, err)
+		outcome := outcomeProcessed
+		if err != nil {
+			outcome = outcomeFailed
+		}
+		recordJob(ctx, job.Topic, outcome, start)
+		return err
+	}
+}
+
+// deferJob queues a throttled job once its tenant has budget again. Up to maxDeferred jobs
+// per tenant wait in the background so other tenants keep flowing; beyond that the caller
+// blocks, which applies backpressure to the tenant's partition.
+func (ec *WorkerPoolEventConsumer) deferJob(ctx context.Context, tenant string, job *ConsumeJob, delay time.Duration) error {
+	ec.metrics.mu.Lock()
+	ec.metrics.ThrottledEvents++
+	ec.metrics.mu.Unlock()
+
+	ec.deferredMu.Lock()
+	if ec.deferred[tenant] >= ec.maxDeferred {
+		ec.deferredMu.Unlock()
+
+		select {
+		case <-ec.clock.After(delay):
+			return ec.enqueue(ctx, job)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	ec.deferred[tenant]++
+	ec.deferredMu.Unlock()
+
+	go func() {
+		defer func() {
+			ec.deferredMu.Lock()
+			if ec.deferred[tenant]--; ec.deferred[tenant] <= 0 {
+				delete(ec.deferred, tenant)
+			}
+			ec.deferredMu.Unlock()
+		}()
+
+		select {
+		case <-ec.clock.After(delay):
+		case <-ec.stopChan:
+			return
+		}
+
+		if err := ec.enqueue(context.Background(), job); err != nil {
+			ec.logger.Error("Failed to handle throttled message of tenant %s: %v", tenant, err)
+		}
+	}()
+
+	return nil
+}
+
+// tenantOf returns the tenant of an event message, or "" for single tenant events
+func tenantOf(message []byte) string {
+	var envelope struct {
+		TenantID string `json:"tenant_id"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return ""
+	}
+	return envelope.TenantID
+}
+
+// traceParentOf returns the trace context of the envelope of an event message, "" when it has none
+func traceParentOf(message []byte) string {
+	var envelope struct {
+		TraceParent string `json:"traceparent"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return ""
+	}
+	return envelope.TraceParent
+}
+
+// eventContext returns the context of handling an event consumed with headers. Events its
+// handlers publish are caused by it and share its correlation ID and tenant; envelopes of events
+// published before they had one are completed from the headers. Events of messages delivering a
+// command are caused by the command instead, so commands delivered again are detected.
+func eventContext(ctx context.Context, event *events.Event, headers kafka.Headers) context.Context {
+	event.Stamp(events.Metadata{
+		CorrelationID: headers.CorrelationID(),
+		CausationID:   headers.CausationID(),
+		TraceParent:   headers.TraceParent(),
+	})
+	consequences := event.Consequences()
+	if commandID := headers.CommandID(); commandID != "" {
+		consequences.CausationID = commandID
+		consequences.CommandID = commandID
+	}
+	return events.ContextWithMetadata(ctx, consequences)
+}
+
+// DeferredEvents returns the number of throttled events waiting per tenant
+func (ec *WorkerPoolEventConsumer) DeferredEvents() map[string]int {
+	ec.deferredMu.Lock()
+	defer ec.deferredMu.Unlock()
+
+	result := make(map[string]int, len(ec.deferred))
+	for tenant, count := range ec.deferred {
+		result[tenant] = count
 	}
+	return result
 }
 
-// processDirectly processes a message directly when worker pool is full
-func (ec *WorkerPoolEventConsumer) processDirectly(ctx context.Context, message []byte) error {
+// processDirectly processes a message consumed from topic directly when worker pool is full
+func (ec *WorkerPoolEventConsumer) processDirectly(ctx context.Context, topic string, message []byte) error {
 	// Parse event from message
 	var event events.Event
 	if err := json.Unmarshal(message, &event); err != nil {
 		return fmt.Errorf("failed to unmarshal event: %w", err)
 	}
+	ctx = eventContext(ctx, &event, kafka.HeadersFromContext(ctx))
 
 	// Convert to UserEvent format for processing
 	userEvent := &entities.UserEvent{
+		EventID:   event.ID.String(),
 		UserID:    "",
 		EventType: event.Type,
 		EventData: make(map[string]interface{}),
@@ -319,43 +764,35 @@ func (ec *WorkerPoolEventConsumer) processDirectly(ctx context.Context, message
 	}
 
 	// Process the event
-	return ec.processEvent(ctx, userEvent)
+	return ec.processEvent(ctx, topic, userEvent)
 }
 
-// processEvent processes a single event
-func (ec *WorkerPoolEventConsumer) processEvent(ctx context.Context, event *entities.UserEvent) error {
+// processEvent processes a single event consumed from topic
+func (ec *WorkerPoolEventConsumer) processEvent(ctx context.Context, topic string, event *entities.UserEvent) error {
 	// Find and execute handler
 	handler, exists := ec.eventHandlers[event.EventType]
 	if !exists {
 		return fmt.Errorf("no handler registered for event type: %s", event.EventType)
 	}
 
-	// Execute handler with retry logic
-	return ec.executeWithRetry(ctx, func() error {
-		return handler.HandleEvent(ctx, event)
+	// Execute handler with the retry policy of its topic and type
+	policy := retryPolicy(ec.config.MessageBroker.RetryPolicyOf(topic, event.EventType))
+	return ec.executeWithRetry(ctx, policy, func() error {
+		return handleEvent(ctx, handler, event)
 	})
 }
 
 // executeWithRetry executes a function with retry logic
-func (ec *WorkerPoolEventConsumer) executeWithRetry(ctx context.Context, fn func() error) error {
-	maxAttempts := 3
-	delay := time.Second
-
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if err := fn(); err == nil {
-			return nil
-		} else {
-			lastErr = err
-			if attempt < maxAttempts {
-				ec.logger.Warn("Attempt %d failed, retrying in %v: %v", attempt, delay, err)
-				time.Sleep(delay)
-				delay *= 2 // Exponential backoff
-			}
-		}
-	}
-
-	return fmt.Errorf("failed after %d attempts: %w", maxAttempts, lastErr)
+func (ec *WorkerPoolEventConsumer) executeWithRetry(ctx context.Context, policy retry.Policy, fn func() error) error {
+	return retry.Retrier{
+		Policy: policy,
+		Clock:  ec.clock,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			ec.logger.Warn("Attempt %d failed, retrying in %v: %v", attempt, delay, err)
+		},
+	}.Do(ctx, func(attempt int) error {
+		return fn()
+	})
 }
 
 // GetMetrics returns consumer metrics
@@ -368,6 +805,10 @@ func (ec *WorkerPoolEventConsumer) GetMetrics() *ConsumerMetrics {
 		ProcessedEvents: ec.metrics.ProcessedEvents,
 		FailedEvents:    ec.metrics.FailedEvents,
 		RetryEvents:     ec.metrics.RetryEvents,
+		ThrottledEvents: ec.metrics.ThrottledEvents,
+		ExpiredEvents:   ec.metrics.ExpiredEvents,
+		RoutedExpired:   ec.metrics.RoutedExpired,
+		PoisonEvents:    ec.metrics.PoisonEvents,
 		WorkerStats:     make(map[int]*ConsumerWorkerStats),
 	}
 
@@ -382,6 +823,11 @@ func (ec *WorkerPoolEventConsumer) GetMetrics() *ConsumerMetrics {
 	return metrics
 }
 
+// QueueDepth returns the number of jobs waiting for a worker
+func (ec *WorkerPoolEventConsumer) QueueDepth() int {
+	return len(ec.jobQueue)
+}
+
 // GetDLQStats returns dead letter queue statistics
 func (ec *WorkerPoolEventConsumer) GetDLQStats(ctx context.Context) (resilience.DLQStats, error) {
 	return ec.deadLetterQueue.GetStats(ctx)
@@ -397,6 +843,34 @@ func (ec *WorkerPoolEventConsumer) ListFailedEvents(ctx context.Context, limit,
 	return ec.deadLetterQueue.ListEvents(ctx, limit, offset)
 }
 
+// FindFailedEvents returns a page of the failed events matching filter from dead letter queue,
+// and how many events match
+func (ec *WorkerPoolEventConsumer) FindFailedEvents(ctx context.Context, filter resilience.DLQFilter, limit, offset int) ([]*resilience.FailedEvent, int, error) {
+	return ec.deadLetterQueue.FindEvents(ctx, filter, limit, offset)
+}
+
+// ExportFailedEvents streams failed events matching filter from dead letter queue
+func (ec *WorkerPoolEventConsumer) ExportFailedEvents(ctx context.Context, w dataio.Writer, filter resilience.DLQFilter) (int, error) {
+	return ec.deadLetterQueue.Export(ctx, w, filter)
+}
+
+// ImportFailedEvents adds previously exported failed events to dead letter queue
+func (ec *WorkerPoolEventConsumer) ImportFailedEvents(ctx context.Context, r dataio.Reader) (resilience.DLQImportResult, error) {
+	return ec.deadLetterQueue.Import(ctx, r)
+}
+
+// PurgeFailedEvents removes all failed events from dead letter queue and returns how many there were
+func (ec *WorkerPoolEventConsumer) PurgeFailedEvents(ctx context.Context) (int, error) {
+	stats, err := ec.deadLetterQueue.GetStats(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := ec.deadLetterQueue.Clear(ctx); err != nil {
+		return 0, err
+	}
+	return stats.TotalEvents, nil
+}
+
 // GetFailedEvent gets a specific failed event from dead letter queue
 func (ec *WorkerPoolEventConsumer) GetFailedEvent(ctx context.Context, eventID string) (*resilience.FailedEvent, error) {
 	return ec.deadLetterQueue.GetEvent(ctx, eventID)
@@ -410,6 +884,7 @@ func (ec *WorkerPoolEventConsumer) DeleteFailedEvent(ctx context.Context, eventI
 // Stop stops the worker pool
 func (ec *WorkerPoolEventConsumer) Stop() {
 	ec.logger.Info("Stopping consumer worker pool...")
+	ec.deadLetterQueue.Stop()
 	close(ec.stopChan)
 	ec.wg.Wait()
 	ec.logger.Info("Consumer worker pool stopped")
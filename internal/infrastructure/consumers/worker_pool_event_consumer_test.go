@@ -0,0 +1,394 @@
+package consumers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/valueobjects"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/consumers"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/featureflags"
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/pkg/schema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Info(msg string, args ...interface{})  {}
+func (noopLogger) Error(msg string, args ...interface{}) {}
+func (noopLogger) Warn(msg string, args ...interface{})  {}
+
+// countingHandler counts handled events per user ID
+type countingHandler struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (h *countingHandler) HandleEvent(ctx context.Context, event *entities.UserEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[event.UserID]++
+	return nil
+}
+
+func (h *countingHandler) count(userID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.counts[userID]
+}
+
+func tenantMessage(t *testing.T, tenant string) []byte {
+	t.Helper()
+
+	event, err := events.NewEvent("user.created", map[string]string{"user_id": tenant}, 1)
+	require.NoError(t, err)
+	event.TenantID, err = valueobjects.ParseTenantID(tenant)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	return data
+}
+
+func TestWorkerPoolEventConsumer_TenantThrottling(t *testing.T) {
+	cfg := &config.Config{
+		MessageBroker: config.MessageBrokerConfig{ConsumerWorkers: 2, WorkerBufferSize: 10},
+		Tenancy:       config.TenancyConfig{ConsumerRate: 1, ConsumerBurst: 1, MaxDeferredPerTenant: 10},
+	}
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+
+	consumer := consumers.NewWorkerPoolEventConsumer(cfg, nil, noopLogger{}, fake)
+	defer consumer.Stop()
+
+	handler := &countingHandler{counts: make(map[string]int)}
+	consumer.RegisterHandler("user.created", handler)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, consumer.HandleMessage(ctx, tenantMessage(t, "acme")))
+	}
+	require.NoError(t, consumer.HandleMessage(ctx, tenantMessage(t, "globex")))
+
+	// The noisy tenant is throttled without holding back the other tenant
+	require.Eventually(t, func() bool {
+		return handler.count("acme") == 1 && handler.count("globex") == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, map[string]int{"acme": 2}, consumer.DeferredEvents())
+	assert.Equal(t, int64(2), consumer.GetMetrics().ThrottledEvents)
+
+	require.Eventually(t, func() bool { return fake.Waiters() == 2 }, time.Second, time.Millisecond)
+	fake.Advance(time.Second)
+	require.Eventually(t, func() bool { return handler.count("acme") == 2 }, time.Second, time.Millisecond)
+
+	fake.Advance(time.Second)
+	require.Eventually(t, func() bool { return handler.count("acme") == 3 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return len(consumer.DeferredEvents()) == 0 }, time.Second, time.Millisecond)
+}
+
+// headerHandler records the headers events are handled with
+type headerHandler struct {
+	headers chan kafka.Headers
+}
+
+func (h *headerHandler) HandleEvent(ctx context.Context, event *entities.UserEvent) error {
+	h.headers <- kafka.HeadersFromContext(ctx)
+	return nil
+}
+
+func TestWorkerPoolEventConsumer_Headers(t *testing.T) {
+	cfg := &config.Config{MessageBroker: config.MessageBrokerConfig{ConsumerWorkers: 1, WorkerBufferSize: 10}}
+
+	consumer := consumers.NewWorkerPoolEventConsumer(cfg, nil, noopLogger{}, nil)
+	defer consumer.Stop()
+
+	handler := &headerHandler{headers: make(chan kafka.Headers, 1)}
+	consumer.RegisterHandler("user.created", handler)
+
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderOriginalTopic, "user-events")
+	headers.Set(kafka.HeaderSchema, "user.created")
+	ctx := kafka.ContextWithHeaders(context.Background(), headers)
+	require.NoError(t, consumer.HandleMessage(ctx, tenantMessage(t, "acme")))
+
+	select {
+	case handled := <-handler.headers:
+		assert.Equal(t, "user-events", handled.OriginalTopic())
+		assert.Equal(t, "user.created", handled.Schema())
+	case <-time.After(time.Second):
+		t.Fatal("event was not handled")
+	}
+}
+
+// metadataHandler records the metadata of the events published while handling events
+type metadataHandler struct {
+	metadata chan events.Metadata
+}
+
+func (h *metadataHandler) HandleEvent(ctx context.Context, event *entities.UserEvent) error {
+	h.metadata <- events.MetadataFromContext(ctx)
+	return nil
+}
+
+func TestWorkerPoolEventConsumer_EventMetadata(t *testing.T) {
+	cfg := &config.Config{MessageBroker: config.MessageBrokerConfig{ConsumerWorkers: 1, WorkerBufferSize: 10}}
+
+	consumer := consumers.NewWorkerPoolEventConsumer(cfg, nil, noopLogger{}, nil)
+	defer consumer.Stop()
+
+	handler := &metadataHandler{metadata: make(chan events.Metadata, 3)}
+	consumer.RegisterHandler("user.created", handler)
+
+	// The envelope of the event takes precedence over its headers
+	event, err := events.NewEvent("user.created", map[string]string{"user_id": "u-1"}, 1)
+	require.NoError(t, err)
+	event.CorrelationID = "request-1"
+	message, err := json.Marshal(event)
+	require.NoError(t, err)
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderCorrelationID, "request-2")
+	require.NoError(t, consumer.HandleMessage(kafka.ContextWithHeaders(context.Background(), headers), message))
+
+	// Events published before they had an envelope start a correlation of their own
+	legacy, err := events.NewEvent("user.created", map[string]string{"user_id": "u-2"}, 1)
+	require.NoError(t, err)
+	legacyMessage, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, consumer.HandleMessage(context.Background(), legacyMessage))
+
+	// Events of a message delivering a command are caused by the command
+	command, err := events.NewEvent("user.created", map[string]string{"user_id": "u-3"}, 1)
+	require.NoError(t, err)
+	commandMessage, err := json.Marshal(command)
+	require.NoError(t, err)
+	commandHeaders := kafka.Headers{}
+	commandHeaders.Set(kafka.HeaderCommandID, "command-1")
+	require.NoError(t, consumer.HandleMessage(kafka.ContextWithHeaders(context.Background(), commandHeaders), commandMessage))
+
+	for _, want := range []events.Metadata{
+		{CorrelationID: "request-1", CausationID: event.ID.String()},
+		{CorrelationID: legacy.ID.String(), CausationID: legacy.ID.String()},
+		{CorrelationID: command.ID.String(), CausationID: "command-1", CommandID: "command-1"},
+	} {
+		select {
+		case metadata := <-handler.metadata:
+			assert.Equal(t, want, metadata)
+		case <-time.After(time.Second):
+			t.Fatal("event was not handled")
+		}
+	}
+}
+
+// recordingExpiredPublisher records routed expired messages
+type recordingExpiredPublisher struct {
+	mu     sync.Mutex
+	routed []kafka.Headers
+}
+
+func (p *recordingExpiredPublisher) PublishExpired(message []byte, headers kafka.Headers) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.routed = append(p.routed, headers)
+	return nil
+}
+
+func agedMessage(t *testing.T, userID string, timestamp time.Time) []byte {
+	t.Helper()
+
+	event, err := events.NewEvent("user.created", map[string]string{"user_id": userID}, 1)
+	require.NoError(t, err)
+	event.Timestamp = timestamp
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	return data
+}
+
+func TestWorkerPoolEventConsumer_ExpiredEvents(t *testing.T) {
+	cfg := &config.Config{MessageBroker: config.MessageBrokerConfig{
+		ConsumerWorkers:      1,
+		WorkerBufferSize:     10,
+		MaxMessageAge:        map[string]time.Duration{"audit-events": 0},
+		DefaultMaxMessageAge: time.Hour,
+	}}
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	consumer := consumers.NewWorkerPoolEventConsumer(cfg, nil, noopLogger{}, clock.NewFake(now))
+	defer consumer.Stop()
+
+	handler := &countingHandler{counts: make(map[string]int)}
+	consumer.RegisterHandler("user.created", handler)
+
+	consumed := func(topic string) context.Context {
+		headers := kafka.Headers{}
+		headers.Set(kafka.HeaderOriginalTopic, topic)
+		return kafka.ContextWithHeaders(context.Background(), headers)
+	}
+
+	// Expired events are skipped, fresh ones and topics without a limit are processed
+	require.NoError(t, consumer.HandleMessage(consumed("user-events"), agedMessage(t, "stale", now.Add(-2*time.Hour))))
+	require.NoError(t, consumer.HandleMessage(consumed("user-events"), agedMessage(t, "fresh", now.Add(-time.Minute))))
+	require.NoError(t, consumer.HandleMessage(consumed("audit-events"), agedMessage(t, "unlimited", now.Add(-2*time.Hour))))
+	require.Eventually(t, func() bool {
+		return handler.count("fresh") == 1 && handler.count("unlimited") == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 0, handler.count("stale"))
+	assert.Equal(t, int64(1), consumer.GetMetrics().ExpiredEvents)
+
+	// With a publisher expired events are routed instead
+	publisher := &recordingExpiredPublisher{}
+	consumer.SetExpiredPublisher(publisher)
+	require.NoError(t, consumer.HandleMessage(consumed("user-events"), agedMessage(t, "stale", now.Add(-2*time.Hour))))
+	require.Len(t, publisher.routed, 1)
+	assert.Equal(t, "user-events", publisher.routed[0].OriginalTopic())
+	assert.Equal(t, int64(2), consumer.GetMetrics().ExpiredEvents)
+	assert.Equal(t, int64(1), consumer.GetMetrics().RoutedExpired)
+
+	// The override flag processes expired events, e.g. for full replays
+	defer featureflags.SetGlobal(featureflags.Global())
+	featureflags.SetGlobal(featureflags.New(map[string]bool{consumers.ProcessExpiredEventsFlag: true}))
+	require.NoError(t, consumer.HandleMessage(consumed("user-events"), agedMessage(t, "stale", now.Add(-2*time.Hour))))
+	require.Eventually(t, func() bool { return handler.count("stale") == 1 }, time.Second, time.Millisecond)
+}
+
+// recordingRetryHandler records events retried from the dead letter queue
+type recordingRetryHandler struct {
+	retried []*resilience.FailedEvent
+}
+
+func (h *recordingRetryHandler) HandleRetry(ctx context.Context, event *resilience.FailedEvent) error {
+	h.retried = append(h.retried, event)
+	return nil
+}
+
+func TestWorkerPoolEventConsumer_FailureAttribution(t *testing.T) {
+	cfg := &config.Config{MessageBroker: config.MessageBrokerConfig{ConsumerWorkers: 1, WorkerBufferSize: 10, GroupID: "projections"}}
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	consumer := consumers.NewWorkerPoolEventConsumer(cfg, nil, noopLogger{}, clock.NewFake(now))
+	defer consumer.Stop()
+
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderOriginalTopic, "user-events")
+	headers.SetRetryCount(2)
+	ctx := kafka.ContextWithHeaders(context.Background(), headers)
+	require.NoError(t, consumer.HandleMessage(ctx, []byte(`not json`)))
+
+	var failed []*resilience.FailedEvent
+	require.Eventually(t, func() bool {
+		failed, _ = consumer.ListFailedEvents(context.Background(), 10, 0)
+		return len(failed) == 1
+	}, time.Second, time.Millisecond)
+
+	// The failure is recorded in the headers kept for retries from the dead letter queue
+	metadata := failed[0].Metadata
+	assert.Equal(t, "2", metadata["retry_count"])
+	assert.Equal(t, "EVENT_HANDLING_FAILED", metadata["last_error"])
+	assert.Equal(t, "projections", metadata["consumer_group"])
+
+	var stored kafka.Headers
+	require.NoError(t, json.Unmarshal([]byte(metadata["headers"]), &stored))
+	assert.Equal(t, "user-events", stored.OriginalTopic())
+	assert.Equal(t, "EVENT_HANDLING_FAILED", stored.LastError())
+	assert.Equal(t, "projections", stored.ConsumerGroup())
+	assert.True(t, now.Equal(stored.FirstFailure()))
+
+	handler := &recordingRetryHandler{}
+	consumer.SetRetryHandler(handler)
+	require.NoError(t, consumer.RetryFailedEvent(context.Background(), failed[0].ID))
+	require.Len(t, handler.retried, 1)
+	assert.Equal(t, failed[0].ID, handler.retried[0].ID)
+}
+
+func TestWorkerPoolEventConsumer_PoisonEvents(t *testing.T) {
+	cfg := &config.Config{MessageBroker: config.MessageBrokerConfig{ConsumerWorkers: 1, WorkerBufferSize: 10, GroupID: "projections"}}
+	consumer := consumers.NewWorkerPoolEventConsumer(cfg, nil, noopLogger{}, clock.NewFake(time.Now()))
+	defer consumer.Stop()
+
+	handler := &countingHandler{counts: make(map[string]int)}
+	consumer.RegisterHandler("user.created", handler)
+
+	registry := schema.NewRegistry(false)
+	registry.Register("user.created", 1, schema.MustParseJSONSchema(`{"type": "object", "required": ["user_id", "email"]}`))
+	consumer.SetPayloadValidator(registry)
+
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderOriginalTopic, "user-events")
+	ctx := kafka.ContextWithHeaders(context.Background(), headers)
+
+	message := func(data map[string]string) []byte {
+		event, err := events.NewEvent("user.created", data, 1)
+		require.NoError(t, err)
+		encoded, err := json.Marshal(event)
+		require.NoError(t, err)
+		return encoded
+	}
+
+	// Valid payloads are handled, poison ones quarantined without being handled
+	require.NoError(t, consumer.HandleMessage(ctx, message(map[string]string{"user_id": "valid", "email": "a@example.com"})))
+	require.NoError(t, consumer.HandleMessage(ctx, message(map[string]string{"user_id": "poison"})))
+	require.Eventually(t, func() bool { return handler.count("valid") == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, handler.count("poison"))
+	assert.Equal(t, int64(1), consumer.GetMetrics().PoisonEvents)
+
+	failed, err := consumer.ListFailedEvents(context.Background(), 10, 0)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "poison_event", failed[0].EventType)
+	assert.Equal(t, "true", failed[0].Metadata["poison"])
+	assert.Contains(t, failed[0].Metadata["validation_error"], `$: missing required property "email"`)
+	assert.Equal(t, "INVALID_EVENT_PAYLOAD", failed[0].Metadata["last_error"])
+	assert.Equal(t, "projections", failed[0].Metadata["consumer_group"])
+}
+
+// failingHandler fails every event, counting attempts
+type failingHandler struct {
+	mu       sync.Mutex
+	attempts int
+}
+
+func (h *failingHandler) HandleEvent(ctx context.Context, event *entities.UserEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.attempts++
+	return errors.New("read model unavailable")
+}
+
+func (h *failingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.attempts
+}
+
+func TestWorkerPoolEventConsumer_RetryPolicyOfEventType(t *testing.T) {
+	cfg := &config.Config{MessageBroker: config.MessageBrokerConfig{
+		ConsumerWorkers:  1,
+		WorkerBufferSize: 10,
+		RetryAttempts:    5,
+		RetryBaseDelay:   time.Hour,
+		RetryMaxDelay:    time.Hour,
+		RetryPolicies: map[string]config.RetryPolicyConfig{
+			"user.created": {MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		},
+	}}
+
+	consumer := consumers.NewWorkerPoolEventConsumer(cfg, nil, noopLogger{}, nil)
+	defer consumer.Stop()
+
+	handler := &failingHandler{}
+	consumer.RegisterHandler("user.created", handler)
+	require.NoError(t, consumer.HandleMessage(context.Background(), tenantMessage(t, "acme")))
+
+	require.Eventually(t, func() bool { return consumer.GetMetrics().FailedEvents == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, handler.count(), "the policy of the event type replaces the default one")
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// AdvisoryLock is a PostgreSQL session advisory lock, held on a connection of its own for as long
+// as that connection lives. It implements leader.Lock.
+type AdvisoryLock struct {
+	db  *sql.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn // Connection holding the lock, nil when not held
+}
+
+// NewAdvisoryLock creates the advisory lock of a name
+func NewAdvisoryLock(db *sql.DB, name string) *AdvisoryLock {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return &AdvisoryLock{
+		db:  db,
+		key: int64(hash.Sum64()),
+	}
+}
+
+// TryLock acquires the lock unless another session holds it. When held, it checks that the
+// connection holding it is still alive; a lost connection released the lock.
+func (l *AdvisoryLock) TryLock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err != nil {
+			discard(l.conn)
+			l.conn = nil
+			return false, fmt.Errorf("lost the connection holding the advisory lock: %w", err)
+		}
+		return true, nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to open advisory lock connection: %w", err)
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Unlock releases the lock if held
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+	conn := l.conn
+	l.conn = nil
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		discard(conn)
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return conn.Close()
+}
+
+// discard closes the underlying connection of conn instead of returning it to the pool, ending
+// its session and any lock it holds
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	conn.Close()
+}
@@ -2,10 +2,14 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/health"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -18,8 +22,18 @@ type Database interface {
 	GetDB() interface{} // Returns the underlying database connection
 }
 
-// DatabaseFactory creates database instances based on configuration
-type DatabaseFactory struct{}
+// DatabaseFactory creates database instances based on configuration, keeping track of them so
+// their pools can be closed on shutdown
+type DatabaseFactory struct {
+	mu     sync.Mutex
+	opened []openedDatabase
+}
+
+// openedDatabase is a database the factory opened, named after its type and database name
+type openedDatabase struct {
+	name string
+	db   Database
+}
 
 // NewDatabaseFactory creates a new database factory
 func NewDatabaseFactory() *DatabaseFactory {
@@ -28,21 +42,100 @@ func NewDatabaseFactory() *DatabaseFactory {
 
 // CreateDatabase creates a database instance based on configuration
 func (f *DatabaseFactory) CreateDatabase(cfg *config.DatabaseConfig) (Database, error) {
+	var db Database
+	var err error
 	switch cfg.Type {
 	case "postgres":
-		return NewPostgresDB(cfg)
+		db, err = NewPostgresDB(cfg)
 	case "mysql":
-		return NewMySQLDB(cfg)
+		db, err = NewMySQLDB(cfg)
 	case "mongodb":
-		return NewMongoDB(cfg)
+		db, err = NewMongoDB(cfg)
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", cfg.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+	f.track(cfg, db)
+	return db, nil
 }
 
 // CreateMongoDB creates a MongoDB instance based on configuration
 func (f *DatabaseFactory) CreateMongoDB(cfg *config.DatabaseConfig) (*MongoDB, error) {
-	return NewMongoDB(cfg)
+	db, err := NewMongoDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	f.track(cfg, db)
+	return db, nil
+}
+
+// track records an opened database
+func (f *DatabaseFactory) track(cfg *config.DatabaseConfig, db Database) {
+	name := cfg.DBName
+	if name == "" {
+		name = cfg.Host
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.opened = append(f.opened, openedDatabase{name: cfg.Type + "/" + name, db: db})
+}
+
+// HealthCheck pings the databases the factory opened: unhealthy when one does not respond,
+// healthy otherwise
+func (f *DatabaseFactory) HealthCheck() health.HealthChecker {
+	return func(ctx context.Context) health.Check {
+		f.mu.Lock()
+		opened := append([]openedDatabase(nil), f.opened...)
+		f.mu.Unlock()
+
+		start := time.Now()
+		check := health.Check{Name: "databases", Status: health.StatusHealthy, Details: make(map[string]interface{})}
+		failed := make(map[string]bool)
+		for _, database := range opened {
+			if failed[database.name] {
+				continue // Opened more than once and already failed
+			}
+			if err := Ping(ctx, database.db); err != nil {
+				failed[database.name] = true
+				check.Status = health.StatusUnhealthy
+				check.Message = fmt.Sprintf("database %s is unreachable: %v", database.name, err)
+				check.Details[database.name] = err.Error()
+				continue
+			}
+			check.Details[database.name] = "reachable"
+		}
+		check.Duration = time.Since(start)
+		return check
+	}
+}
+
+// Ping verifies an opened database responds
+func Ping(ctx context.Context, db Database) error {
+	switch conn := db.GetDB().(type) {
+	case *sql.DB:
+		return conn.PingContext(ctx)
+	case *mongo.Client:
+		return conn.Ping(ctx, nil)
+	}
+	return nil
+}
+
+// CloseAll closes every database the factory opened, most recent first
+func (f *DatabaseFactory) CloseAll() error {
+	f.mu.Lock()
+	opened := f.opened
+	f.opened = nil
+	f.mu.Unlock()
+
+	var errs []error
+	for i := len(opened) - 1; i >= 0; i-- {
+		if err := opened[i].db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // MySQLDB stub implementation
@@ -120,6 +213,9 @@ func (m *MongoDB) Connect() error {
 		fmt.Printf("Set MongoDB MaxConnIdleTime to %v\n", m.config.ConnMaxIdleTime)
 	}
 
+	// Record the commands of accounted requests in their cost
+	clientOptions.SetMonitor(costMonitor())
+
 	// Connect to MongoDB
 	client, err := mongo.Connect(context.Background(), clientOptions)
 	if err != nil {
@@ -1,10 +1,12 @@
 package database_test
 
 import (
+	"context"
 	"testing"
 
 	"go-clean-ddd-es-template/internal/infrastructure/config"
 	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/pkg/health"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -82,6 +84,18 @@ func TestDatabaseFactory_NewDatabaseFactory(t *testing.T) {
 	assert.NotNil(t, factory)
 }
 
+func TestDatabaseFactory_HealthCheck(t *testing.T) {
+	factory := database.NewDatabaseFactory()
+	_, err := factory.CreateDatabase(&config.DatabaseConfig{Type: "mysql", DBName: "testdb"})
+	assert.Error(t, err)
+
+	// Databases failing to open are not tracked
+	check := factory.HealthCheck()(context.Background())
+	assert.Equal(t, "databases", check.Name)
+	assert.Equal(t, health.StatusHealthy, check.Status)
+	assert.Empty(t, check.Details)
+}
+
 func TestPostgresDB_NewPostgresDB(t *testing.T) {
 	// This test would require a real PostgreSQL connection
 	// For now, we'll test the structure
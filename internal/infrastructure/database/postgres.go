@@ -6,8 +6,6 @@ import (
 	"log"
 
 	"go-clean-ddd-es-template/internal/infrastructure/config"
-
-	_ "github.com/lib/pq"
 )
 
 // PostgresDB represents PostgreSQL database connection
@@ -34,7 +32,7 @@ func NewPostgresConnection(cfg config.DatabaseConfig) (*sql.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName)
 
-	db, err := sql.Open("postgres", dsn)
+	db, err := openPostgres(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open PostgreSQL connection: %w", err)
 	}
@@ -55,7 +53,7 @@ func (p *PostgresDB) Connect() error {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		p.config.Host, p.config.Port, p.config.User, p.config.Password, p.config.DBName)
 
-	db, err := sql.Open("postgres", dsn)
+	db, err := openPostgres(dsn)
 	if err != nil {
 		return fmt.Errorf("failed to open PostgreSQL connection: %w", err)
 	}
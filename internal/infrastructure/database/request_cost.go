@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/requestcost"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// openPostgres opens a PostgreSQL connection pool recording the queries of accounted requests
+func openPostgres(dsn string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(costConnector{connector}), nil
+}
+
+// costConnector opens connections recording the queries of accounted requests in their cost
+type costConnector struct {
+	driver.Connector
+}
+
+// Connect opens a connection
+func (c costConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &costConn{Conn: conn}, nil
+}
+
+// costConn records the statements run on a connection, inside transactions or not, in the cost of
+// the request they are run for. Statements are timed until their first row.
+type costConn struct {
+	driver.Conn
+}
+
+// QueryContext runs a query
+func (c *costConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	requestcost.RecordQuery(ctx, time.Since(start))
+	return rows, err
+}
+
+// ExecContext runs a statement
+func (c *costConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	requestcost.RecordQuery(ctx, time.Since(start))
+	return result, err
+}
+
+// PrepareContext prepares a statement
+func (c *costConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx starts a transaction
+func (c *costConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// Ping checks the connection is alive
+func (c *costConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession resets the connection before it is reused
+func (c *costConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid reports whether the connection can be reused
+func (c *costConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// costMonitor records the MongoDB commands of accounted requests in their cost
+func costMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			requestcost.RecordQuery(ctx, e.Duration)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			requestcost.RecordQuery(ctx, e.Duration)
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/requestcost"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnector opens connections answering every query with no rows
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                            { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"id"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestCostConn_RecordsQueriesOfAccountedRequests(t *testing.T) {
+	db := sql.OpenDB(costConnector{fakeConnector{}})
+	defer db.Close()
+
+	ctx, cost := requestcost.WithCost(context.Background())
+	_, err := db.ExecContext(ctx, "UPDATE users SET name = $1", "Alice")
+	require.NoError(t, err)
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	err = WithTransaction(ctx, db, func(ctx context.Context) error {
+		_, err := ExecutorFrom(ctx, db).ExecContext(ctx, "DELETE FROM users")
+		return err
+	})
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(context.Background(), "DELETE FROM users")
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, cost.Snapshot().Queries, "statements in transactions count, those outside of requests do not")
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-clean-ddd-es-template/pkg/retry"
+
+	"github.com/lib/pq"
+)
+
+// Reasons of transactions aborted by a conflict with a concurrent transaction, see
+// TransactionConflict
+const (
+	ConflictSerializationFailure = "serialization_failure"
+	ConflictDeadlock             = "deadlock"
+)
+
+// PostgreSQL error codes of transactions aborted by a conflict
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// Executor runs statements on a database connection or within a transaction
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey is the context key of the transaction in progress
+type txKey struct{}
+
+// contextTx is a transaction in progress on a database
+type contextTx struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// WithTransaction runs fn in a transaction on db, committed when fn succeeds and rolled back
+// otherwise. Repositories join it through ExecutorFrom with the context fn is given. Calls nested
+// in a transaction on the same database run in that transaction.
+func WithTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if InTransaction(ctx, db) {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, contextTx{db: db, tx: tx})); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RetryTransaction runs fn in a transaction on db like WithTransaction, and runs it again in a new
+// transaction while it is aborted by a conflict with a concurrent transaction, up to
+// policy.MaxAttempts attempts waiting policy.Delay between them. onConflict, when not nil, is
+// called for every conflict with whether the transaction is retried. Nested in a transaction on
+// db, fn runs once in that transaction: only the outermost transaction can be run again.
+func RetryTransaction(ctx context.Context, db *sql.DB, policy retry.Policy, onConflict func(reason string, retried bool), fn func(ctx context.Context) error) error {
+	if InTransaction(ctx, db) {
+		return fn(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := WithTransaction(ctx, db, fn)
+		reason := TransactionConflict(err)
+		if reason == "" {
+			return err
+		}
+		retried := attempt < policy.MaxAttempts && ctx.Err() == nil
+		if onConflict != nil {
+			onConflict(reason, retried)
+		}
+		if !retried {
+			return err
+		}
+		if waitErr := retry.Sleep(ctx, nil, policy.Delay(attempt)); waitErr != nil {
+			return fmt.Errorf("stopped retrying transaction after %d attempts, %w: %w", attempt, waitErr, err)
+		}
+	}
+}
+
+// TransactionConflict returns why err aborted a transaction, ConflictSerializationFailure or
+// ConflictDeadlock, empty when it is not a conflict. Transactions aborted by a conflict may
+// succeed when run again.
+func TransactionConflict(err error) string {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return ""
+	}
+	switch pqErr.Code {
+	case serializationFailure:
+		return ConflictSerializationFailure
+	case deadlockDetected:
+		return ConflictDeadlock
+	}
+	return ""
+}
+
+// InTransaction reports whether ctx carries a transaction on db
+func InTransaction(ctx context.Context, db *sql.DB) bool {
+	current, ok := ctx.Value(txKey{}).(contextTx)
+	return ok && current.db == db
+}
+
+// ExecutorFrom returns the transaction ctx carries on db, or db itself outside of transactions
+func ExecutorFrom(ctx context.Context, db *sql.DB) Executor {
+	if current, ok := ctx.Value(txKey{}).(contextTx); ok && current.db == db {
+		return current.tx
+	}
+	return db
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/retry"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionConflict(t *testing.T) {
+	assert.Equal(t, ConflictSerializationFailure, TransactionConflict(&pq.Error{Code: "40001"}))
+	assert.Equal(t, ConflictDeadlock, TransactionConflict(fmt.Errorf("failed to insert: %w", &pq.Error{Code: "40P01"})))
+	assert.Empty(t, TransactionConflict(&pq.Error{Code: "23505"}))
+	assert.Empty(t, TransactionConflict(assert.AnError))
+	assert.Empty(t, TransactionConflict(nil))
+}
+
+func TestRetryTransaction(t *testing.T) {
+	db := sql.OpenDB(fakeConnector{})
+	defer db.Close()
+	ctx := context.Background()
+	policy := retry.Policy{MaxAttempts: 3}
+	deadlock := &pq.Error{Code: "40P01"}
+
+	var attempts int
+	var conflicts []bool
+	err := RetryTransaction(ctx, db, policy, func(reason string, retried bool) {
+		assert.Equal(t, ConflictDeadlock, reason)
+		conflicts = append(conflicts, retried)
+	}, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return deadlock
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []bool{true, true}, conflicts)
+
+	attempts, conflicts = 0, nil
+	err = RetryTransaction(ctx, db, policy, func(reason string, retried bool) {
+		conflicts = append(conflicts, retried)
+	}, func(ctx context.Context) error {
+		attempts++
+		return deadlock
+	})
+	assert.ErrorIs(t, err, deadlock)
+	assert.Equal(t, 3, attempts, "retries are bounded")
+	assert.Equal(t, []bool{true, true, false}, conflicts)
+
+	attempts = 0
+	err = RetryTransaction(ctx, db, policy, nil, func(ctx context.Context) error {
+		attempts++
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, attempts, "other failures are not retried")
+
+	attempts = 0
+	err = WithTransaction(ctx, db, func(ctx context.Context) error {
+		return RetryTransaction(ctx, db, policy, nil, func(ctx context.Context) error {
+			attempts++
+			return deadlock
+		})
+	})
+	assert.ErrorIs(t, err, deadlock)
+	assert.Equal(t, 1, attempts, "nested transactions cannot be run again on their own")
+}
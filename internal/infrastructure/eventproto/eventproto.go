@@ -0,0 +1,168 @@
+package eventproto
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/valueobjects"
+	"go-clean-ddd-es-template/proto/user"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Event types with a protobuf payload message
+const (
+	UserCreated = "user.created"
+	UserUpdated = "user.updated"
+	UserDeleted = "user.deleted"
+)
+
+// ToProto converts a domain event to its protobuf envelope. User events carry their payload
+// message; events of other types keep their JSON encoded data.
+func ToProto(event *events.Event) (*user.DomainEvent, error) {
+	msg := &user.DomainEvent{
+		Id:        event.ID.String(),
+		TenantId:  event.TenantID.String(),
+		Type:      event.Type,
+		Timestamp: timestamppb.New(event.Timestamp),
+		Version:   int32(event.Version),
+	}
+
+	switch event.Type {
+	case UserCreated:
+		var data events.UserCreatedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode %s event data: %w", event.Type, err)
+		}
+		msg.Payload = &user.DomainEvent_UserCreated{UserCreated: UserCreatedToProto(&data)}
+	case UserUpdated:
+		var data events.UserUpdatedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode %s event data: %w", event.Type, err)
+		}
+		msg.Payload = &user.DomainEvent_UserUpdated{UserUpdated: UserUpdatedToProto(&data)}
+	case UserDeleted:
+		var data events.UserDeletedEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode %s event data: %w", event.Type, err)
+		}
+		msg.Payload = &user.DomainEvent_UserDeleted{UserDeleted: UserDeletedToProto(&data)}
+	default:
+		msg.Payload = &user.DomainEvent_Data{Data: event.Data}
+	}
+
+	return msg, nil
+}
+
+// FromProto converts a protobuf envelope to a domain event with JSON encoded data
+func FromProto(msg *user.DomainEvent) (*events.Event, error) {
+	eventID, err := valueobjects.ParseEventID(msg.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("invalid event ID: %w", err)
+	}
+
+	var tenantID valueobjects.TenantID
+	if msg.GetTenantId() != "" {
+		if tenantID, err = valueobjects.ParseTenantID(msg.GetTenantId()); err != nil {
+			return nil, fmt.Errorf("invalid tenant ID: %w", err)
+		}
+	}
+
+	var data []byte
+	switch payload := msg.GetPayload().(type) {
+	case *user.DomainEvent_UserCreated:
+		data, err = json.Marshal(UserCreatedFromProto(payload.UserCreated))
+	case *user.DomainEvent_UserUpdated:
+		data, err = json.Marshal(UserUpdatedFromProto(payload.UserUpdated))
+	case *user.DomainEvent_UserDeleted:
+		data, err = json.Marshal(UserDeletedFromProto(payload.UserDeleted))
+	case *user.DomainEvent_Data:
+		data = payload.Data
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event data: %w", msg.GetType(), err)
+	}
+
+	return &events.Event{
+		ID:        eventID,
+		TenantID:  tenantID,
+		Type:      msg.GetType(),
+		Data:      data,
+		Timestamp: msg.GetTimestamp().AsTime(),
+		Version:   int(msg.GetVersion()),
+	}, nil
+}
+
+// Marshal encodes a domain event in the protobuf wire format
+func Marshal(event *events.Event) ([]byte, error) {
+	msg, err := ToProto(event)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+// Unmarshal decodes a domain event encoded by Marshal
+func Unmarshal(data []byte) (*events.Event, error) {
+	var msg user.DomainEvent
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode domain event: %w", err)
+	}
+	return FromProto(&msg)
+}
+
+// UserCreatedToProto converts a user created event to its protobuf message
+func UserCreatedToProto(event *events.UserCreatedEvent) *user.UserCreatedEvent {
+	return &user.UserCreatedEvent{
+		UserId:    event.UserID,
+		Email:     event.Email,
+		Name:      event.Name,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}
+}
+
+// UserCreatedFromProto converts a protobuf message to a user created event
+func UserCreatedFromProto(msg *user.UserCreatedEvent) *events.UserCreatedEvent {
+	return &events.UserCreatedEvent{
+		UserID:    msg.GetUserId(),
+		Email:     msg.GetEmail(),
+		Name:      msg.GetName(),
+		CreatedAt: msg.GetCreatedAt().AsTime(),
+	}
+}
+
+// UserUpdatedToProto converts a user updated event to its protobuf message
+func UserUpdatedToProto(event *events.UserUpdatedEvent) *user.UserUpdatedEvent {
+	return &user.UserUpdatedEvent{
+		UserId:    event.UserID,
+		Name:      event.Name,
+		UpdatedAt: timestamppb.New(event.UpdatedAt),
+	}
+}
+
+// UserUpdatedFromProto converts a protobuf message to a user updated event
+func UserUpdatedFromProto(msg *user.UserUpdatedEvent) *events.UserUpdatedEvent {
+	return &events.UserUpdatedEvent{
+		UserID:    msg.GetUserId(),
+		Name:      msg.GetName(),
+		UpdatedAt: msg.GetUpdatedAt().AsTime(),
+	}
+}
+
+// UserDeletedToProto converts a user deleted event to its protobuf message
+func UserDeletedToProto(event *events.UserDeletedEvent) *user.UserDeletedEvent {
+	return &user.UserDeletedEvent{
+		UserId:    event.UserID,
+		DeletedAt: timestamppb.New(event.DeletedAt),
+	}
+}
+
+// UserDeletedFromProto converts a protobuf message to a user deleted event
+func UserDeletedFromProto(msg *user.UserDeletedEvent) *events.UserDeletedEvent {
+	return &events.UserDeletedEvent{
+		UserID:    msg.GetUserId(),
+		DeletedAt: msg.GetDeletedAt().AsTime(),
+	}
+}
@@ -0,0 +1,118 @@
+package eventproto_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/valueobjects"
+	"go-clean-ddd-es-template/internal/infrastructure/eventproto"
+	"go-clean-ddd-es-template/proto/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventProto_RoundTrip(t *testing.T) {
+	at := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		eventType string
+		data      interface{}
+		check     func(t *testing.T, msg *user.DomainEvent)
+	}{
+		{
+			name:      "user created",
+			eventType: eventproto.UserCreated,
+			data:      &events.UserCreatedEvent{UserID: "user-1", Email: "a@example.com", Name: "Alice", CreatedAt: at},
+			check: func(t *testing.T, msg *user.DomainEvent) {
+				assert.Equal(t, "a@example.com", msg.GetUserCreated().GetEmail())
+				assert.Equal(t, at, msg.GetUserCreated().GetCreatedAt().AsTime())
+			},
+		},
+		{
+			name:      "user updated",
+			eventType: eventproto.UserUpdated,
+			data:      &events.UserUpdatedEvent{UserID: "user-1", Name: "Alicia", UpdatedAt: at},
+			check: func(t *testing.T, msg *user.DomainEvent) {
+				assert.Equal(t, "Alicia", msg.GetUserUpdated().GetName())
+			},
+		},
+		{
+			name:      "user deleted",
+			eventType: eventproto.UserDeleted,
+			data:      &events.UserDeletedEvent{UserID: "user-1", DeletedAt: at},
+			check: func(t *testing.T, msg *user.DomainEvent) {
+				assert.Equal(t, "user-1", msg.GetUserDeleted().GetUserId())
+			},
+		},
+		{
+			name:      "event without message",
+			eventType: "product.created",
+			data:      map[string]string{"product_id": "product-1"},
+			check: func(t *testing.T, msg *user.DomainEvent) {
+				assert.JSONEq(t, `{"product_id":"product-1"}`, string(msg.GetData()))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := events.NewEvent(tt.eventType, tt.data, 3)
+			require.NoError(t, err)
+			event.TenantID = valueobjects.NewTenantID()
+
+			msg, err := eventproto.ToProto(event)
+			require.NoError(t, err)
+			assert.Equal(t, event.ID.String(), msg.GetId())
+			assert.Equal(t, event.TenantID.String(), msg.GetTenantId())
+			assert.Equal(t, int32(3), msg.GetVersion())
+			tt.check(t, msg)
+
+			encoded, err := eventproto.Marshal(event)
+			require.NoError(t, err)
+			decoded, err := eventproto.Unmarshal(encoded)
+			require.NoError(t, err)
+
+			assert.Equal(t, event.ID, decoded.ID)
+			assert.Equal(t, event.TenantID, decoded.TenantID)
+			assert.Equal(t, event.Type, decoded.Type)
+			assert.True(t, event.Timestamp.Equal(decoded.Timestamp))
+			assert.Equal(t, event.Version, decoded.Version)
+			assert.JSONEq(t, string(event.Data), string(decoded.Data))
+		})
+	}
+}
+
+func TestEventProto_Errors(t *testing.T) {
+	event, err := events.NewEvent(eventproto.UserCreated, nil, 1)
+	require.NoError(t, err)
+	event.Data = []byte(`not json`)
+	_, err = eventproto.ToProto(event)
+	assert.Error(t, err)
+
+	_, err = eventproto.FromProto(&user.DomainEvent{Id: "invalid id"})
+	assert.Error(t, err)
+
+	_, err = eventproto.Unmarshal([]byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestEventProto_SingleTenant(t *testing.T) {
+	event, err := events.NewEvent("product.deleted", map[string]string{"product_id": "product-1"}, 1)
+	require.NoError(t, err)
+
+	msg, err := eventproto.ToProto(event)
+	require.NoError(t, err)
+	assert.Empty(t, msg.GetTenantId())
+
+	decoded, err := eventproto.FromProto(msg)
+	require.NoError(t, err)
+	assert.True(t, decoded.TenantID.IsZero())
+
+	var data map[string]string
+	require.NoError(t, json.Unmarshal(decoded.Data, &data))
+	assert.Equal(t, "product-1", data["product_id"])
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"slices"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/middleware"
+)
+
+// versionSuccessors maps the gRPC methods of each API version to the gateway path of the
+// method replacing them in the next version
+var versionSuccessors = map[string]map[string]string{
+	"v1": {
+		"/user.UserService/CreateUser": "/api/v2/users",
+		"/user.UserService/GetUser":    "/api/v2/users/{id}",
+		"/user.UserService/UpdateUser": "/api/v2/users/{id}",
+		"/user.UserService/DeleteUser": "/api/v2/users/{id}",
+		"/user.UserService/ListUsers":  "/api/v2/users",
+	},
+}
+
+// APIDeprecations returns the deprecations of the methods of the deprecated API versions
+func APIDeprecations(cfg config.APIVersionsConfig) map[string]middleware.Deprecation {
+	deprecations := make(map[string]middleware.Deprecation)
+	for version, successors := range versionSuccessors {
+		if !slices.Contains(cfg.DeprecatedVersions, version) {
+			continue
+		}
+		for method, successor := range successors {
+			deprecations[method] = middleware.Deprecation{Sunset: cfg.SunsetAt(), Successor: successor}
+		}
+	}
+	return deprecations
+}
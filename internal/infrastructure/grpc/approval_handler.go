@@ -0,0 +1,176 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/pkg/approval"
+	"go-clean-ddd-es-template/pkg/authz"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/redact"
+)
+
+// Routes the approval admin handlers are mounted at
+const (
+	ApprovalListPattern    = "GET /admin/approvals"
+	ApprovalSubmitPattern  = "POST /admin/approvals"
+	ApprovalApprovePattern = "POST /admin/approvals/{id}/approve"
+	ApprovalRejectPattern  = "POST /admin/approvals/{id}/reject"
+	ApprovalAuditPattern   = "GET /admin/approvals/audit"
+)
+
+// maxApprovalRequestSize limits the size of approval request bodies
+const maxApprovalRequestSize = 64 << 10
+
+// ApprovalWorkflow creates approval requests for sensitive commands. See approval.Workflow.
+type ApprovalWorkflow interface {
+	Requires(action string) bool
+	Submit(ctx context.Context, action string, args map[string]string, requestedBy, reason string) (approval.Request, error)
+}
+
+// ApprovalHandler lets admins submit sensitive commands and approve or reject the commands
+// submitted by other admins. Every request must carry the access token of an admin as a bearer
+// token; the admin it was issued to is the requester or approver.
+type ApprovalHandler struct {
+	workflow *approval.Workflow
+	tokens   TokenValidator
+}
+
+// NewApprovalHandler creates a new approval admin handler, authenticating admins with tokens
+func NewApprovalHandler(workflow *approval.Workflow, tokens TokenValidator) *ApprovalHandler {
+	return &ApprovalHandler{
+		workflow: workflow,
+		tokens:   tokens,
+	}
+}
+
+// submitApprovalRequest is the body of a submit request
+type submitApprovalRequest struct {
+	Action string            `json:"action"`
+	Args   map[string]string `json:"args"`
+	Reason string            `json:"reason"`
+}
+
+// decideApprovalRequest is the body of an approve or reject request
+type decideApprovalRequest struct {
+	Reason string `json:"reason"`
+}
+
+// List handles GET /admin/approvals?status=pending, listing approval requests oldest first
+func (h *ApprovalHandler) List(w http.ResponseWriter, r *http.Request) {
+	if authenticateAdmin(w, r, h.tokens) == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.workflow.List(r.URL.Query().Get("status")))
+}
+
+// Submit handles POST /admin/approvals with an {"action", "args", "reason"} body, requested by
+// the calling admin. The pending request is returned with 202 Accepted.
+func (h *ApprovalHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	claims := authenticateAdmin(w, r, h.tokens)
+	if claims == nil {
+		return
+	}
+
+	var req submitApprovalRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApprovalRequestSize)).Decode(&req); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("body", err.Error()), "Failed to submit approval request")
+		return
+	}
+
+	request, err := h.workflow.Submit(r.Context(), req.Action, req.Args, claims.UserID, req.Reason)
+	if err != nil {
+		writeHTTPError(w, errors.ValidationFailed("action", err.Error()), "Failed to submit approval request")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, request)
+}
+
+// Approve handles POST /admin/approvals/{id}/approve, approved by the calling admin. The command
+// runs before the response, which is the executed or failed request.
+func (h *ApprovalHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, func(ctx context.Context, approver string, req decideApprovalRequest) (approval.Request, error) {
+		return h.workflow.Approve(ctx, r.PathValue("id"), approver)
+	})
+}
+
+// Reject handles POST /admin/approvals/{id}/reject with an optional {"reason"} body, rejected by
+// the calling admin
+func (h *ApprovalHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, func(ctx context.Context, approver string, req decideApprovalRequest) (approval.Request, error) {
+		return h.workflow.Reject(ctx, r.PathValue("id"), approver, req.Reason)
+	})
+}
+
+// Audit handles GET /admin/approvals/audit, listing the audit events of this instance, oldest first
+func (h *ApprovalHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	if authenticateAdmin(w, r, h.tokens) == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.workflow.Audit())
+}
+
+// decide decodes a decision of the calling admin and writes the decided request
+func (h *ApprovalHandler) decide(w http.ResponseWriter, r *http.Request, decision func(ctx context.Context, approver string, req decideApprovalRequest) (approval.Request, error)) {
+	claims := authenticateAdmin(w, r, h.tokens)
+	if claims == nil {
+		return
+	}
+
+	var req decideApprovalRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApprovalRequestSize)).Decode(&req); err != nil && err != io.EOF {
+		writeHTTPError(w, errors.ValidationFailed("body", err.Error()), "Failed to decide approval request")
+		return
+	}
+
+	request, err := decision(r.Context(), claims.UserID, req)
+	if err != nil {
+		writeHTTPError(w, approvalError(err), "Failed to decide approval request")
+		return
+	}
+	writeJSON(w, http.StatusOK, request)
+}
+
+// authenticateAdmin checks the bearer access token of a request and the admin role of its user,
+// writing an error response and returning nil when either is missing
+func authenticateAdmin(w http.ResponseWriter, r *http.Request, tokens TokenValidator) *dto.ValidateTokenResponse {
+	claims := authenticateUser(w, r, tokens)
+	if claims == nil {
+		return nil
+	}
+	if claims.UserID == "" || !authz.AdminPolicy.Allows(claims.Roles, claims.Scopes) {
+		writeHTTPError(w, errors.New(errors.ErrForbidden, "an admin access token is required"), "Forbidden")
+		return nil
+	}
+	return claims
+}
+
+// approvalError converts a rejected decision to an application error
+func approvalError(err error) error {
+	switch {
+	case stderrors.Is(err, approval.ErrNotFound):
+		return errors.New(errors.ErrNotFound, err.Error())
+	case stderrors.Is(err, approval.ErrSelfApproval):
+		return errors.New(errors.ErrForbidden, err.Error())
+	default:
+		return errors.New(errors.ErrBadRequest, err.Error())
+	}
+}
+
+// writeJSON writes a JSON response, stripped of fields tagged sensitive
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	data, err := redact.MarshalForResponse(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/approval"
+	"go-clean-ddd-es-template/pkg/authz"
+)
+
+// serveApproval serves a request to the approval routes with token as bearer token when set,
+// returning the response
+func serveApproval(handler *ApprovalHandler, method, target, token, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ApprovalListPattern, handler.List)
+	mux.HandleFunc(ApprovalSubmitPattern, handler.Submit)
+	mux.HandleFunc(ApprovalApprovePattern, handler.Approve)
+	mux.HandleFunc(ApprovalRejectPattern, handler.Reject)
+	mux.HandleFunc(ApprovalAuditPattern, handler.Audit)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestApprovalHandler_DecidedByAnotherAdmin(t *testing.T) {
+	jwtService := newTestJWTService(t)
+	workflow := approval.NewWorkflow(time.Hour, 10, nil, nil)
+	var executed []approval.Request
+	workflow.Register("user.delete", func(ctx context.Context, request approval.Request) error {
+		executed = append(executed, request)
+		return nil
+	})
+	handler := NewApprovalHandler(workflow, jwtTokens{jwtService})
+
+	alice, err := jwtService.GenerateToken("admin-alice", "alice@example.com", []string{authz.AdminRole})
+	require.NoError(t, err)
+	bob, err := jwtService.GenerateToken("admin-bob", "bob@example.com", []string{authz.AdminRole})
+	require.NoError(t, err)
+	user, err := jwtService.GenerateToken("user-1", "carol@example.com", []string{"user"})
+	require.NoError(t, err)
+
+	// Only admins reach the approval routes
+	assert.Equal(t, http.StatusUnauthorized, serveApproval(handler, http.MethodGet, "/admin/approvals", "", "").Code)
+	assert.Equal(t, http.StatusForbidden, serveApproval(handler, http.MethodGet, "/admin/approvals", user, "").Code)
+
+	// The requester is the admin the token was issued to, whoever the body names
+	rec := serveApproval(handler, http.MethodPost, "/admin/approvals", alice, `{"action": "user.delete", "args": {"user_id": "42"}, "requested_by": "admin-bob"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var request approval.Request
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &request))
+	assert.Equal(t, "admin-alice", request.RequestedBy)
+
+	// The requester cannot approve their own request, even naming another approver
+	rec = serveApproval(handler, http.MethodPost, "/admin/approvals/"+request.ID+"/approve", alice, `{"approver": "admin-bob"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	assert.Empty(t, executed)
+
+	// Nor can a user who is not an admin
+	rec = serveApproval(handler, http.MethodPost, "/admin/approvals/"+request.ID+"/approve", user, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	assert.Empty(t, executed)
+
+	rec = serveApproval(handler, http.MethodPost, "/admin/approvals/"+request.ID+"/approve", bob, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &request))
+	assert.Equal(t, approval.StatusExecuted, request.Status)
+	assert.Equal(t, "admin-bob", request.DecidedBy)
+	require.Len(t, executed, 1)
+	assert.Equal(t, "42", executed[0].Args["user_id"])
+}
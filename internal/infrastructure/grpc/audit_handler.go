@@ -0,0 +1,134 @@
+package grpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go-clean-ddd-es-template/pkg/audit"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// Routes the audit log admin handlers are mounted at
+const (
+	AuditListPattern      = "GET /admin/audit"
+	AuditLegalHoldPattern = "POST /admin/audit/legal-hold"
+)
+
+// Page sizes of audit log listings
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+// AuditHandler lets operators search the audit log and place or lift legal holds exempting
+// records from retention. Every request must carry the admin token as a bearer token.
+type AuditHandler struct {
+	store audit.Store
+	log   *audit.Log
+	token string
+}
+
+// NewAuditHandler creates a new audit log admin handler, recording legal hold changes in log
+func NewAuditHandler(store audit.Store, log *audit.Log, token string) *AuditHandler {
+	return &AuditHandler{
+		store: store,
+		log:   log,
+		token: token,
+	}
+}
+
+// List handles GET /admin/audit?category=&actor=&subject=&limit=, listing records newest first
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := parseIntParam(query.Get("limit"), defaultAuditPageSize)
+	if err != nil || limit <= 0 || limit > maxAuditPageSize {
+		writeHTTPError(w, errors.ValidationFailed("limit", "limit must be between 1 and "+strconv.Itoa(maxAuditPageSize)), "Failed to list audit records")
+		return
+	}
+
+	records, err := h.store.List(r.Context(), audit.Filter{
+		Category: query.Get("category"),
+		Actor:    query.Get("actor"),
+		Subject:  query.Get("subject"),
+		Limit:    limit,
+	})
+	if err != nil {
+		writeHTTPError(w, err, "Failed to list audit records")
+		return
+	}
+	if records == nil {
+		records = []audit.Record{}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// legalHoldRequest is the body of a legal hold request
+type legalHoldRequest struct {
+	Category    string `json:"category"`
+	Actor       string `json:"actor"`
+	Subject     string `json:"subject"`
+	Hold        bool   `json:"hold"`
+	RequestedBy string `json:"requested_by"`
+	Reason      string `json:"reason"`
+}
+
+// LegalHold handles POST /admin/audit/legal-hold with a {"category", "actor", "subject", "hold",
+// "requested_by", "reason"} body, placing or lifting the legal hold of the matching records. The
+// change is itself recorded as an admin action.
+func (h *AuditHandler) LegalHold(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	var req legalHoldRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApprovalRequestSize)).Decode(&req); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("body", err.Error()), "Failed to set legal hold")
+		return
+	}
+	if req.RequestedBy == "" {
+		writeHTTPError(w, errors.ValidationFailed("requested_by", "the admin setting the legal hold is required"), "Failed to set legal hold")
+		return
+	}
+	if req.Actor == "" && req.Subject == "" {
+		writeHTTPError(w, errors.ValidationFailed("subject", "an actor or a subject is required"), "Failed to set legal hold")
+		return
+	}
+
+	filter := audit.Filter{Category: req.Category, Actor: req.Actor, Subject: req.Subject}
+	updated, err := h.store.SetLegalHold(r.Context(), filter, req.Hold)
+	if err != nil {
+		writeHTTPError(w, err, "Failed to set legal hold")
+		return
+	}
+
+	action := "audit.legal_hold_placed"
+	if !req.Hold {
+		action = "audit.legal_hold_lifted"
+	}
+	details := map[string]string{"updated": strconv.Itoa(updated)}
+	for name, value := range map[string]string{"category": req.Category, "actor": req.Actor, "reason": req.Reason} {
+		if value != "" {
+			details[name] = value
+		}
+	}
+	err = h.log.Record(r.Context(), audit.Record{
+		Category: audit.CategoryAdmin,
+		Action:   action,
+		Actor:    req.RequestedBy,
+		Subject:  req.Subject,
+		Details:  details,
+		// The change is kept as long as the records it holds
+		LegalHold: req.Hold,
+	})
+	if err != nil {
+		writeHTTPError(w, err, "Failed to record legal hold")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"updated": updated, "hold": req.Hold})
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"net"
 	"time"
 
 	"google.golang.org/grpc/codes"
@@ -10,15 +11,18 @@ import (
 
 	"go-clean-ddd-es-template/internal/application/dto"
 	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/pkg/errors"
 	"go-clean-ddd-es-template/pkg/logger"
+	"go-clean-ddd-es-template/pkg/middleware"
 	"go-clean-ddd-es-template/proto/auth"
 )
 
 // AuthHandler handles gRPC auth requests
 type AuthHandler struct {
 	auth.UnimplementedAuthServiceServer
-	authService *services.AuthService
-	logger      logger.Logger
+	authService    *services.AuthService
+	logger         logger.Logger
+	trustedProxies []*net.IPNet
 }
 
 // NewAuthHandler creates a new auth handler
@@ -29,6 +33,12 @@ func NewAuthHandler(authService *services.AuthService, logger logger.Logger) *Au
 	}
 }
 
+// SetTrustedProxies sets the proxies, besides the HTTP gateway, whose x-forwarded-for gives the
+// address of the client of a call
+func (h *AuthHandler) SetTrustedProxies(proxies []*net.IPNet) {
+	h.trustedProxies = proxies
+}
+
 // Register handles user registration
 func (h *AuthHandler) Register(ctx context.Context, req *auth.RegisterRequest) (*auth.RegisterResponse, error) {
 	h.logger.Info("Handling register request for email: %s, name: %s", req.Email, req.Name)
@@ -44,15 +54,16 @@ func (h *AuthHandler) Register(ctx context.Context, req *auth.RegisterRequest) (
 	resp, err := h.authService.Register(ctx, serviceReq)
 	if err != nil {
 		h.logger.Error("Failed to register user: %v, email: %s", err, req.Email)
-		return nil, status.Errorf(codes.Internal, "failed to register user: %v", err)
+		return nil, commandError(err, "failed to register user")
 	}
 
 	// Convert service response to gRPC response
 	return &auth.RegisterResponse{
-		UserId: resp.UserID,
-		Email:  resp.Email,
-		Name:   resp.Name,
-		Token:  resp.Token,
+		UserId:       resp.UserID,
+		Email:        resp.Email,
+		Name:         resp.Name,
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
 	}, nil
 }
 
@@ -64,25 +75,172 @@ func (h *AuthHandler) Login(ctx context.Context, req *auth.LoginRequest) (*auth.
 	serviceReq := dto.LoginCommand{
 		Email:    req.Email,
 		Password: req.Password,
+		ClientIP: middleware.GRPCClientIP(ctx, h.trustedProxies),
 	}
 
 	// Call auth service
 	resp, err := h.authService.Login(ctx, serviceReq)
 	if err != nil {
 		h.logger.Error("Failed to login user: %v, email: %s", err, req.Email)
-		return nil, status.Errorf(codes.Unauthenticated, "invalid credentials: %v", err)
+		return nil, loginError(err)
 	}
 
 	// Convert service response to gRPC response
 	return &auth.LoginResponse{
-		UserId: resp.UserID,
-		Email:  resp.Email,
-		Name:   resp.Name,
-		Roles:  resp.Roles,
-		Token:  resp.Token,
+		UserId:       resp.UserID,
+		Email:        resp.Email,
+		Name:         resp.Name,
+		Roles:        resp.Roles,
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
+	}, nil
+}
+
+// RequestMagicLink sends a magic link to the user of an email
+func (h *AuthHandler) RequestMagicLink(ctx context.Context, req *auth.RequestMagicLinkRequest) (*auth.RequestMagicLinkResponse, error) {
+	h.logger.Info("Handling request magic link request")
+
+	resp, err := h.authService.RequestMagicLink(ctx, dto.RequestMagicLinkCommand{
+		Email:    req.Email,
+		DeviceID: req.DeviceId,
+	})
+	if err != nil {
+		h.logger.Error("Failed to request magic link: %v", err)
+		return nil, authError(err, "failed to request magic link")
+	}
+
+	return &auth.RequestMagicLinkResponse{
+		Message: resp.Message,
+	}, nil
+}
+
+// ConsumeMagicLink signs in the user of a magic link
+func (h *AuthHandler) ConsumeMagicLink(ctx context.Context, req *auth.ConsumeMagicLinkRequest) (*auth.LoginResponse, error) {
+	h.logger.Info("Handling consume magic link request")
+
+	resp, err := h.authService.ConsumeMagicLink(ctx, dto.ConsumeMagicLinkCommand{
+		Token:    req.Token,
+		DeviceID: req.DeviceId,
+	})
+	if err != nil {
+		h.logger.Error("Failed to consume magic link: %v", err)
+		return nil, authError(err, "failed to consume magic link")
+	}
+
+	return &auth.LoginResponse{
+		UserId:       resp.UserID,
+		Email:        resp.Email,
+		Name:         resp.Name,
+		Roles:        resp.Roles,
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
+	}, nil
+}
+
+// ForgotPassword sends a password reset link to the user of an email
+func (h *AuthHandler) ForgotPassword(ctx context.Context, req *auth.ForgotPasswordRequest) (*auth.ForgotPasswordResponse, error) {
+	h.logger.Info("Handling forgot password request")
+
+	resp, err := h.authService.ForgotPassword(ctx, dto.ForgotPasswordCommand{
+		Email: req.Email,
+	})
+	if err != nil {
+		h.logger.Error("Failed to send password reset link: %v", err)
+		return nil, authError(err, "failed to send password reset link")
+	}
+
+	return &auth.ForgotPasswordResponse{
+		Message: resp.Message,
+	}, nil
+}
+
+// ResetPassword sets the new password of the user of a password reset link
+func (h *AuthHandler) ResetPassword(ctx context.Context, req *auth.ResetPasswordRequest) (*auth.ResetPasswordResponse, error) {
+	h.logger.Info("Handling reset password request")
+
+	resp, err := h.authService.ResetPassword(ctx, dto.ResetPasswordCommand{
+		Token:       req.Token,
+		NewPassword: req.NewPassword,
+	})
+	if err != nil {
+		h.logger.Error("Failed to reset password: %v", err)
+		return nil, authError(err, "failed to reset password")
+	}
+
+	return &auth.ResetPasswordResponse{
+		Success: resp.Success,
+		Message: resp.Message,
+	}, nil
+}
+
+// RequestEmailVerification sends a verification link again to the user of an email
+func (h *AuthHandler) RequestEmailVerification(ctx context.Context, req *auth.RequestEmailVerificationRequest) (*auth.RequestEmailVerificationResponse, error) {
+	h.logger.Info("Handling request email verification request")
+
+	resp, err := h.authService.RequestEmailVerification(ctx, dto.RequestEmailVerificationCommand{
+		Email: req.Email,
+	})
+	if err != nil {
+		h.logger.Error("Failed to send email verification link: %v", err)
+		return nil, authError(err, "failed to send email verification link")
+	}
+
+	return &auth.RequestEmailVerificationResponse{
+		Message: resp.Message,
+	}, nil
+}
+
+// VerifyEmail verifies the email of an email verification link
+func (h *AuthHandler) VerifyEmail(ctx context.Context, req *auth.VerifyEmailRequest) (*auth.VerifyEmailResponse, error) {
+	h.logger.Info("Handling verify email request")
+
+	resp, err := h.authService.VerifyEmail(ctx, dto.VerifyEmailCommand{
+		Token: req.Token,
+	})
+	if err != nil {
+		h.logger.Error("Failed to verify email: %v", err)
+		return nil, authError(err, "failed to verify email")
+	}
+
+	return &auth.VerifyEmailResponse{
+		UserId:  resp.UserID,
+		Email:   resp.Email,
+		Message: resp.Message,
 	}, nil
 }
 
+// loginError returns the status of a failed login: invalid credentials but for lockouts and
+// failures of the server
+func loginError(err error) error {
+	switch errors.CodeOf(err, "") {
+	case errors.ErrAccountLocked:
+		return status.Errorf(codes.PermissionDenied, "account locked: %v", err)
+	case errors.ErrLoginThrottled:
+		return status.Errorf(codes.ResourceExhausted, "too many failed logins: %v", err)
+	case errors.ErrDatabaseQuery, errors.ErrInternalServer:
+		return status.Errorf(codes.Internal, "failed to login: %v", err)
+	}
+	return status.Errorf(codes.Unauthenticated, "invalid credentials: %v", err)
+}
+
+// authError returns the status of a failed magic link or refresh token request
+func authError(err error, message string) error {
+	code := codes.Internal
+	switch errors.CodeOf(err, "") {
+	case errors.ErrValidationFailed:
+		code = codes.InvalidArgument
+	case errors.ErrUnauthorized:
+		code = codes.Unauthenticated
+	case errors.ErrRateLimited:
+		code = codes.ResourceExhausted
+	case errors.ErrNotFound:
+		code = codes.Unimplemented
+	case errors.ErrServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Errorf(code, "%s: %v", message, err)
+}
+
 // ValidateToken validates JWT token
 func (h *AuthHandler) ValidateToken(ctx context.Context, req *auth.ValidateTokenRequest) (*auth.ValidateTokenResponse, error) {
 	h.logger.Info("Handling validate token request")
@@ -106,7 +264,8 @@ func (h *AuthHandler) ValidateToken(ctx context.Context, req *auth.ValidateToken
 	}, nil
 }
 
-// RefreshToken refreshes JWT token
+// RefreshToken renews a session with a refresh token, or renews an access token while refresh
+// tokens are disabled
 func (h *AuthHandler) RefreshToken(ctx context.Context, req *auth.RefreshTokenRequest) (*auth.RefreshTokenResponse, error) {
 	h.logger.Info("Handling refresh token request")
 
@@ -114,7 +273,7 @@ func (h *AuthHandler) RefreshToken(ctx context.Context, req *auth.RefreshTokenRe
 	resp, err := h.authService.RefreshToken(ctx, req.Token)
 	if err != nil {
 		h.logger.Error("Failed to refresh token: %v", err)
-		return nil, status.Errorf(codes.Unauthenticated, "failed to refresh token: %v", err)
+		return nil, authError(err, "failed to refresh token")
 	}
 
 	// Get new token expiration
@@ -122,11 +281,24 @@ func (h *AuthHandler) RefreshToken(ctx context.Context, req *auth.RefreshTokenRe
 
 	// Convert service response to gRPC response
 	return &auth.RefreshTokenResponse{
-		Token:     resp.Token,
-		ExpiresAt: timestamppb.New(time.Now().Add(expiration)),
+		Token:        resp.Token,
+		ExpiresAt:    timestamppb.New(time.Now().Add(expiration)),
+		RefreshToken: resp.RefreshToken,
 	}, nil
 }
 
+// RevokeRefreshToken signs out, revoking a refresh token
+func (h *AuthHandler) RevokeRefreshToken(ctx context.Context, req *auth.RevokeRefreshTokenRequest) (*auth.RevokeRefreshTokenResponse, error) {
+	h.logger.Info("Handling revoke refresh token request")
+
+	if err := h.authService.RevokeRefreshToken(ctx, dto.RevokeRefreshTokenCommand{RefreshToken: req.RefreshToken}); err != nil {
+		h.logger.Error("Failed to revoke refresh token: %v", err)
+		return nil, authError(err, "failed to revoke refresh token")
+	}
+
+	return &auth.RevokeRefreshTokenResponse{}, nil
+}
+
 // ChangePassword changes user password
 func (h *AuthHandler) ChangePassword(ctx context.Context, req *auth.ChangePasswordRequest) (*auth.ChangePasswordResponse, error) {
 	h.logger.Info("Handling change password request")
@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"net/http"
+
+	"go-clean-ddd-es-template/pkg/authz"
+)
+
+// AuthorizationPattern is the route the effective authorization matrix is served at
+const AuthorizationPattern = "GET /admin/authorization"
+
+// AuthorizationHandler serves the roles and scopes each gRPC method requires, as currently
+// enforced, for access audits. Every request must carry the admin token as a bearer token.
+type AuthorizationHandler struct {
+	authorizer *authz.Authorizer
+	token      string
+}
+
+// NewAuthorizationHandler creates a new authorization matrix admin handler
+func NewAuthorizationHandler(authorizer *authz.Authorizer, token string) *AuthorizationHandler {
+	return &AuthorizationHandler{
+		authorizer: authorizer,
+		token:      token,
+	}
+}
+
+// Get handles GET /admin/authorization, returning the policy of every served method with the
+// file and time it was loaded from
+func (h *AuthorizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.authorizer.Snapshot())
+}
@@ -0,0 +1,129 @@
+package grpc
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"go-clean-ddd-es-template/internal/application/commands"
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/pkg/authz"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// AvatarUploadPattern is the route the avatar upload handler is mounted at
+const AvatarUploadPattern = "POST /api/v1/users/{id}/avatar"
+
+// avatarFormField is the multipart form field holding the image
+const avatarFormField = "avatar"
+
+// AvatarHandler handles multipart avatar uploads over HTTP.
+// File uploads are served outside the gRPC gateway so the body is streamed, not buffered, and
+// their callers are authenticated here rather than by the auth interceptor: users change their own
+// avatar, admins any.
+type AvatarHandler struct {
+	uploadHandler *commands.UserUploadAvatarCommandHandler
+	tokens        TokenValidator
+	maxSize       int64
+}
+
+// NewAvatarHandler creates a new avatar upload handler authenticating callers with tokens
+func NewAvatarHandler(uploadHandler *commands.UserUploadAvatarCommandHandler, tokens TokenValidator, maxSize int64) *AvatarHandler {
+	return &AvatarHandler{
+		uploadHandler: uploadHandler,
+		tokens:        tokens,
+		maxSize:       maxSize,
+	}
+}
+
+// ServeHTTP handles POST /api/v1/users/{id}/avatar with a multipart "avatar" file field
+func (h *AvatarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	claims := authenticateUser(w, r, h.tokens)
+	if claims == nil {
+		return
+	}
+	if claims.UserID != r.PathValue("id") && !authz.AdminPolicy.Allows(claims.Roles, claims.Scopes) {
+		writeHTTPError(w, errors.New(errors.ErrForbidden, "users can only change their own avatar"), "Forbidden")
+		return
+	}
+
+	// Allow some room for multipart boundaries and headers
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+64*1024)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeHTTPError(w, errors.ValidationFailed("avatar", "multipart/form-data body is required"), "Failed to upload avatar")
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			writeHTTPError(w, errors.ValidationFailed("avatar", "missing avatar file field"), "Failed to upload avatar")
+			return
+		}
+		if part.FormName() != avatarFormField {
+			part.Close()
+			continue
+		}
+
+		// The content type is sniffed from the content, client supplied headers are not trusted
+		response, err := h.uploadHandler.Handle(r.Context(), dto.UploadAvatarCommand{
+			UserID:  r.PathValue("id"),
+			Content: part,
+		})
+		part.Close()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if stderrors.As(err, &maxBytesErr) {
+				err = errors.ValidationFailed("avatar", "file is too large")
+			}
+			writeHTTPError(w, err, "Failed to upload avatar")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+}
+
+// authenticateUser validates the bearer access token of r, returning its claims, or nil once it
+// wrote the error response of a missing or invalid token
+func authenticateUser(w http.ResponseWriter, r *http.Request, tokens TokenValidator) *dto.ValidateTokenResponse {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ok && token != "" {
+		if claims, err := tokens.ValidateToken(r.Context(), token); err == nil {
+			return claims
+		}
+	}
+
+	writeHTTPError(w, errors.New(errors.ErrUnauthorized, "a valid access token is required"), "Unauthorized")
+	return nil
+}
+
+// writeHTTPError writes an error as JSON using the application error status.
+// Messages of server errors are replaced by fallbackMessage so internals are not leaked.
+func writeHTTPError(w http.ResponseWriter, err error, fallbackMessage string) {
+	status := http.StatusInternalServerError
+	code := string(errors.ErrInternalServer)
+	message := fallbackMessage
+
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		status = appErr.HTTPStatus
+		code = string(appErr.Code)
+		if status < http.StatusInternalServerError {
+			message = appErr.Message
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"code":    code,
+		"message": message,
+	})
+}
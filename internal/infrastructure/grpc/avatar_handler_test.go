@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/authz"
+)
+
+// jwtTokens validates access tokens with a JWT service, as services.AuthService does
+type jwtTokens struct {
+	jwt *auth.JWTService
+}
+
+func (t jwtTokens) ValidateToken(ctx context.Context, token string) (*dto.ValidateTokenResponse, error) {
+	claims, err := t.jwt.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ValidateTokenResponse{UserID: claims.UserID, Email: claims.Email, Roles: claims.Roles, Scopes: claims.Scopes}, nil
+}
+
+// newTestJWTService creates a JWT service with a key pair generated for the test
+func newTestJWTService(t *testing.T) *auth.JWTService {
+	privateKey, publicKey, err := auth.GenerateRSAKeyPair(2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privatePath, []byte(auth.ExportPrivateKeyPEM(privateKey)), 0o600))
+	require.NoError(t, os.WriteFile(publicPath, []byte(auth.ExportPublicKeyPEM(publicKey)), 0o644))
+
+	jwtService, err := auth.NewJWTService(privatePath, publicPath, time.Hour)
+	require.NoError(t, err)
+	return jwtService
+}
+
+// uploadAvatar serves an avatar upload of the user with a body that is not multipart, with
+// authorization as its Authorization header when set, returning the response status
+func uploadAvatar(handler http.Handler, userID, authorization string) int {
+	mux := http.NewServeMux()
+	mux.Handle(AvatarUploadPattern, handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/"+userID+"/avatar", strings.NewReader("not multipart"))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAvatarHandler_Authorization(t *testing.T) {
+	jwtService := newTestJWTService(t)
+	handler := NewAvatarHandler(nil, jwtTokens{jwtService}, 1024)
+
+	userToken, err := jwtService.GenerateToken("user-1", "alice@example.com", []string{"user"})
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateToken("admin-1", "admin@example.com", []string{"user", authz.AdminRole})
+	require.NoError(t, err)
+	refreshToken, _, err := jwtService.GenerateRefreshToken("user-1", "alice@example.com", "", time.Hour)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, uploadAvatar(handler, "user-1", ""), "missing token")
+	assert.Equal(t, http.StatusUnauthorized, uploadAvatar(handler, "user-1", userToken), "missing bearer scheme")
+	assert.Equal(t, http.StatusUnauthorized, uploadAvatar(handler, "user-1", "Bearer "+refreshToken), "refresh tokens are not access tokens")
+	assert.Equal(t, http.StatusForbidden, uploadAvatar(handler, "user-2", "Bearer "+userToken), "users cannot change the avatar of others")
+
+	// Authorized uploads go on to read the body, rejected as it is not multipart
+	assert.Equal(t, http.StatusBadRequest, uploadAvatar(handler, "user-1", "Bearer "+userToken))
+	assert.Equal(t, http.StatusBadRequest, uploadAvatar(handler, "user-2", "Bearer "+adminToken), "admins can change any avatar")
+}
@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/middleware"
+)
+
+// UsersCacheTag tags cached reads of the user read model; user events invalidate it
+const UsersCacheTag = "users"
+
+// ReadCachePolicies returns the cache policies of the idempotent gRPC read methods
+func ReadCachePolicies(ttl time.Duration) map[string]middleware.ReadCachePolicy {
+	users := middleware.ReadCachePolicy{TTL: ttl, Tags: []string{UsersCacheTag}}
+	return map[string]middleware.ReadCachePolicy{
+		"/user.UserService/GetUser":               users,
+		"/user.UserService/ListUsers":             users,
+		"/user.v2.UserService/GetUser":            users,
+		"/user.v2.UserService/ListUsers":          users,
+		"/user.v2.UserService/GetUserPreferences": users,
+	}
+}
+
+// gatewayCacheTags returns the invalidation tags of a cached gateway response
+func gatewayCacheTags(r *http.Request) []string {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/users") || strings.HasPrefix(r.URL.Path, "/api/v2/users") {
+		return []string{UsersCacheTag}
+	}
+	return nil
+}
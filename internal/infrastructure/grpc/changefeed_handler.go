@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/internal/application/queries"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// ChangefeedPattern is the route the user changefeed is mounted at
+const ChangefeedPattern = "GET /api/v1/users/changes"
+
+// ChangefeedHandler serves the user change log to external systems syncing users without
+// consuming Kafka. Every request must carry the changefeed token as a bearer token.
+type ChangefeedHandler struct {
+	changesHandler *queries.UserChangesQueryHandler
+	token          string
+}
+
+// NewChangefeedHandler creates a new changefeed handler
+func NewChangefeedHandler(changesHandler *queries.UserChangesQueryHandler, token string) *ChangefeedHandler {
+	return &ChangefeedHandler{
+		changesHandler: changesHandler,
+		token:          token,
+	}
+}
+
+// ServeHTTP handles GET /api/v1/users/changes?cursor=&limit=. Clients start without a cursor
+// and pass the next_cursor of each response to the following request, polling once has_more
+// is false.
+func (h *ChangefeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorizeBearer(w, r, h.token, "a valid changefeed token is required") {
+		return
+	}
+
+	query := dto.ListUserChangesQuery{Cursor: r.URL.Query().Get("cursor")}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		var err error
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			writeHTTPError(w, errors.ValidationFailed("limit", "must be a number"), "Failed to list user changes")
+			return
+		}
+	}
+
+	response, err := h.changesHandler.Handle(r.Context(), query)
+	if err != nil {
+		writeHTTPError(w, err, "Failed to list user changes")
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/approval"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/rules"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the domain of gRPC error info details
+const errorDomain = "go-clean-ddd-es-template"
+
+// commandError converts the error of a command to a gRPC status error. Business rule denials are
+// PermissionDenied, or FailedPrecondition when the command only requires approval, with the
+// violated rules as error details; concurrency conflicts are Aborted, so clients retry the
+// command; commands handled before under the same command ID are AlreadyExists, so clients stop
+// retrying; other errors are Internal and prefixed with message.
+func commandError(err error, message string) error {
+	switch errors.CodeOf(err, "") {
+	case errors.ErrConcurrencyConflict:
+		return status.Errorf(codes.Aborted, "%s: %v", message, err)
+	case errors.ErrDuplicateCommand:
+		return status.Errorf(codes.AlreadyExists, "%s: %v", message, err)
+	}
+
+	violations := rules.Violations(err)
+	if len(violations) == 0 {
+		return status.Errorf(codes.Internal, "%s: %v", message, err)
+	}
+
+	reason := errors.CodeOf(err, errors.ErrPolicyDenied)
+	code := codes.PermissionDenied
+	if reason == errors.ErrApprovalRequired {
+		code = codes.FailedPrecondition
+	}
+
+	names := make([]string, len(violations))
+	failure := &errdetails.PreconditionFailure{}
+	for i, violation := range violations {
+		names[i] = violation.Rule
+		failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+			Type:        string(violation.Effect),
+			Subject:     violation.Rule,
+			Description: violation.Message,
+		})
+	}
+	info := &errdetails.ErrorInfo{
+		Reason:   string(reason),
+		Domain:   errorDomain,
+		Metadata: map[string]string{"rules": strings.Join(names, ",")},
+	}
+
+	st, detailErr := status.New(code, fmt.Sprintf("%s: %v", message, err)).WithDetails(info, failure)
+	if detailErr != nil {
+		return status.Errorf(code, "%s: %v", message, err)
+	}
+	return st.Err()
+}
+
+// approvalRequiredError is the FailedPrecondition status of a command waiting for approval,
+// carrying the ID of the approval request in its error info
+func approvalRequiredError(request approval.Request) error {
+	st := status.Newf(codes.FailedPrecondition, "%s requires the approval of a second admin, approval request %s expires at %s",
+		request.Action, request.ID, request.ExpiresAt.Format(time.RFC3339))
+	info := &errdetails.ErrorInfo{
+		Reason:   string(errors.ErrApprovalRequired),
+		Domain:   errorDomain,
+		Metadata: map[string]string{"approval_id": request.ID, "action": request.Action},
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
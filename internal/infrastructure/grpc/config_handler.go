@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"net/http"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+)
+
+// ConfigPattern is the route the effective configuration is served at
+const ConfigPattern = "GET /admin/config"
+
+// ConfigHandler serves the effective configuration of the instance for debugging, with
+// sensitive values redacted. Every request must carry the admin token as a bearer token.
+type ConfigHandler struct {
+	config *config.Config
+	token  string
+}
+
+// NewConfigHandler creates a new configuration admin handler
+func NewConfigHandler(cfg *config.Config, token string) *ConfigHandler {
+	return &ConfigHandler{
+		config: cfg,
+		token:  token,
+	}
+}
+
+// configResponse is the body of a configuration response
+type configResponse struct {
+	Settings []config.SettingValue `json:"settings"`
+}
+
+// Get handles GET /admin/config, returning every setting with its default and effective value
+func (h *ConfigHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, configResponse{Settings: h.config.Settings()})
+}
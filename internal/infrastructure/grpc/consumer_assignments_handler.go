@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"net/http"
+	"sort"
+)
+
+// ConsumerAssignmentsPattern is the route the partition assignments of the instance are served at
+const ConsumerAssignmentsPattern = "GET /admin/consumer/assignments"
+
+// AssignmentSource reports the partitions an instance consumes
+type AssignmentSource interface {
+	ConsumerGroup() string
+	Assignments() map[string][]int32
+	Standby() bool
+}
+
+// ConsumerAssignmentsHandler serves the partitions of each topic the instance consumes, so
+// dashboards polling every instance show which one owns which partitions while debugging
+// rebalances. Every request must carry the admin token as a bearer token.
+type ConsumerAssignmentsHandler struct {
+	source   AssignmentSource
+	instance string
+	token    string
+}
+
+// NewConsumerAssignmentsHandler creates a new consumer assignments admin handler for the
+// instance named instance, e.g. its hostname
+func NewConsumerAssignmentsHandler(source AssignmentSource, instance, token string) *ConsumerAssignmentsHandler {
+	return &ConsumerAssignmentsHandler{
+		source:   source,
+		instance: instance,
+		token:    token,
+	}
+}
+
+// topicAssignment is the partitions of a topic consumed by the instance
+type topicAssignment struct {
+	Topic      string  `json:"topic"`
+	Partitions []int32 `json:"partitions"`
+}
+
+// consumerAssignmentsResponse is the body of a consumer assignments response
+type consumerAssignmentsResponse struct {
+	Instance      string            `json:"instance"`
+	ConsumerGroup string            `json:"consumer_group"`
+	Standby       bool              `json:"standby"` // Partitions consumed with handlers paused
+	Assignments   []topicAssignment `json:"assignments"`
+}
+
+// Get handles GET /admin/consumer/assignments, returning the partitions consumed by topic
+func (h *ConsumerAssignmentsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	assignments := []topicAssignment{}
+	for topic, partitions := range h.source.Assignments() {
+		assignments = append(assignments, topicAssignment{Topic: topic, Partitions: partitions})
+	}
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].Topic < assignments[j].Topic
+	})
+
+	writeJSON(w, http.StatusOK, consumerAssignmentsResponse{
+		Instance:      h.instance,
+		ConsumerGroup: h.source.ConsumerGroup(),
+		Standby:       h.source.Standby(),
+		Assignments:   assignments,
+	})
+}
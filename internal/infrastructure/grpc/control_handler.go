@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go-clean-ddd-es-template/pkg/control"
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// Routes the control channel admin handlers are mounted at
+const (
+	ControlCommandsPattern = "POST /admin/control/commands"
+	ControlAuditPattern    = "GET /admin/control/audit"
+)
+
+// maxControlCommandSize limits the size of command request bodies
+const maxControlCommandSize = 64 << 10
+
+// ControlHandler issues control commands to every consumer instance and serves the audit
+// trail of the commands this instance received. Every request must carry the admin token
+// as a bearer token.
+type ControlHandler struct {
+	producer *control.Producer
+	audit    *control.AuditLog
+	token    string
+}
+
+// NewControlHandler creates a new control channel admin handler
+func NewControlHandler(producer *control.Producer, audit *control.AuditLog, token string) *ControlHandler {
+	return &ControlHandler{
+		producer: producer,
+		audit:    audit,
+		token:    token,
+	}
+}
+
+// sendCommandRequest is the body of a command request
+type sendCommandRequest struct {
+	Type   string            `json:"type"`
+	Args   map[string]string `json:"args"`
+	Issuer string            `json:"issuer"`
+}
+
+// Send handles POST /admin/control/commands with a {"type", "args", "issuer"} body.
+// The signed command is broadcast to all instances and returned with 202 Accepted.
+func (h *ControlHandler) Send(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	var req sendCommandRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxControlCommandSize)).Decode(&req); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("body", err.Error()), "Failed to send control command")
+		return
+	}
+
+	candidate := control.Command{Type: req.Type, Args: req.Args, Issuer: req.Issuer}
+	if err := candidate.Validate(); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("command", err.Error()), "Failed to send control command")
+		return
+	}
+
+	command, err := h.producer.Send(req.Type, req.Args, req.Issuer)
+	if err != nil {
+		writeHTTPError(w, err, "Failed to send control command")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(command)
+}
+
+// Audit handles GET /admin/control/audit, listing the commands received by this instance, oldest first
+func (h *ControlHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.audit.Entries())
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/pkg/id"
+)
+
+// Headers identifying the request a call is part of. Callers may set either; the correlation ID
+// of a call is returned in CorrelationIDHeader. Callers retrying a command set CommandIDHeader to
+// the same ID on every attempt, so a command handled before is rejected instead of handled again.
+const (
+	CorrelationIDHeader = "X-Correlation-Id"
+	RequestIDHeader     = "X-Request-Id"
+	CommandIDHeader     = "X-Command-Id"
+)
+
+// Metadata keys of the correlation headers
+const (
+	correlationIDMetadataKey = "x-correlation-id"
+	requestIDMetadataKey     = "x-request-id"
+	commandIDMetadataKey     = "x-command-id"
+)
+
+// correlationContext returns the context of a call whose published events are correlated with
+// it: by the correlation or request ID of the caller, or a new one. The events it causes directly
+// are caused by the call itself, identified by the command ID of the caller when it has one.
+func correlationContext(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	correlationID := firstMetadataValue(md, correlationIDMetadataKey)
+	if correlationID == "" {
+		correlationID = firstMetadataValue(md, requestIDMetadataKey)
+	}
+	if correlationID == "" {
+		correlationID = id.NewULID()
+	}
+	causationID := correlationID
+	commandID := firstMetadataValue(md, commandIDMetadataKey)
+	if commandID != "" {
+		causationID = commandID
+	}
+	return events.ContextWithMetadata(ctx, events.Metadata{
+		CorrelationID: correlationID,
+		CausationID:   causationID,
+		CommandID:     commandID,
+	}), correlationID
+}
+
+// firstMetadataValue returns the first value of key in md, "" when absent
+func firstMetadataValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// correlationInterceptor correlates the events published by unary calls with them
+func correlationInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, correlationID := correlationContext(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(correlationIDMetadataKey, correlationID))
+		return handler(ctx, req)
+	}
+}
+
+// correlationStreamInterceptor correlates the events published by streaming calls with them
+func correlationStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, correlationID := correlationContext(stream.Context())
+		_ = stream.SetHeader(metadata.Pairs(correlationIDMetadataKey, correlationID))
+		return handler(srv, &correlatedServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// correlatedServerStream is a server stream with the context of its correlation
+type correlatedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the correlation
+func (s *correlatedServerStream) Context() context.Context {
+	return s.ctx
+}
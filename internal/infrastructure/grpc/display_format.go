@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+
+	"go-clean-ddd-es-template/pkg/consistency"
+	"go-clean-ddd-es-template/pkg/middleware"
+	"go-clean-ddd-es-template/pkg/requestcost"
+)
+
+// DisplayFormatEndpoints lists the endpoints that return localized timestamps and numbers
+// when the client sends "X-Display-Format: localized"
+var DisplayFormatEndpoints = middleware.DisplayFormatEndpoints{
+	"/user.UserService/CreateUser": true,
+	"/user.UserService/GetUser":    true,
+	"/user.UserService/UpdateUser": true,
+	"/user.UserService/DeleteUser": true,
+	"/user.UserService/ListUsers":  true,
+
+	"/user.v2.UserService/CreateUser":            true,
+	"/user.v2.UserService/GetUser":               true,
+	"/user.v2.UserService/UpdateUser":            true,
+	"/user.v2.UserService/DeleteUser":            true,
+	"/user.v2.UserService/ListUsers":             true,
+	"/user.v2.UserService/GetUserPreferences":    true,
+	"/user.v2.UserService/UpdateUserPreferences": true,
+
+	AvatarUploadPattern: true,
+}
+
+// gatewayHeaderMatcher forwards the display format, consistency token, correlation and command ID
+// headers to gRPC next to the gateway defaults
+func gatewayHeaderMatcher(key string) (string, bool) {
+	switch http.CanonicalHeaderKey(key) {
+	case middleware.DisplayFormatHeader, middleware.TimezoneHeader, consistency.Header, CorrelationIDHeader, RequestIDHeader, CommandIDHeader:
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeaderMatcher returns deprecation and rate limit metadata, consistency tokens,
+// stale flags, request costs and correlation IDs as plain HTTP headers and other metadata with the gateway's default prefix
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	if middleware.IsDeprecationMetadata(key) || middleware.IsRateLimitMetadata(key) || key == consistency.MetadataKey || key == staleMetadataKey || key == requestcost.MetadataKey || key == correlationIDMetadataKey {
+		return http.CanonicalHeaderKey(key), true
+	}
+	return fmt.Sprintf("%s%s", runtime.MetadataHeaderPrefix, key), true
+}
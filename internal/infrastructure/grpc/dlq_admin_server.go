@@ -0,0 +1,209 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/proto/admin"
+)
+
+// DeadLetterQueueService is the dead letter queue served by the admin gRPC service
+type DeadLetterQueueService interface {
+	DeadLetterQueueTriage
+	GetDLQStats(ctx context.Context) (resilience.DLQStats, error)
+	PurgeFailedEvents(ctx context.Context) (int, error)
+}
+
+// DLQAdminServer implements the gRPC DeadLetterQueueService server, letting operators list,
+// inspect, retry, delete and clear failed events. Every call must carry the admin token as a
+// bearer token; the user authentication of the other services does not apply.
+type DLQAdminServer struct {
+	admin.UnimplementedDeadLetterQueueServiceServer
+	dlq       DeadLetterQueueService
+	token     string
+	approvals ApprovalWorkflow
+}
+
+// NewDLQAdminServer creates a new dead letter queue admin gRPC server
+func NewDLQAdminServer(dlq DeadLetterQueueService, token string) *DLQAdminServer {
+	return &DLQAdminServer{
+		dlq:   dlq,
+		token: token,
+	}
+}
+
+// SetApprovals makes clearing the queue create an approval request instead of running
+func (s *DLQAdminServer) SetApprovals(approvals ApprovalWorkflow) {
+	s.approvals = approvals
+}
+
+// ListFailedEvents implements admin.DeadLetterQueueServiceServer.ListFailedEvents
+func (s *DLQAdminServer) ListFailedEvents(ctx context.Context, req *admin.ListFailedEventsRequest) (*admin.ListFailedEventsResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultDLQPageSize
+	}
+	if limit < 0 || limit > maxDLQPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", maxDLQPageSize)
+	}
+	if req.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset must not be negative")
+	}
+	filter := resilience.DLQFilter{EventType: req.EventType, Topic: req.Topic}
+	var err error
+	if filter.Since, err = parseTimeParam(req.Since); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "since must be an RFC 3339 time: %v", err)
+	}
+	if filter.Until, err = parseTimeParam(req.Until); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "until must be an RFC 3339 time: %v", err)
+	}
+
+	events, total, err := s.dlq.FindFailedEvents(ctx, filter, limit, int(req.Offset))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list dead letter queue: %v", err)
+	}
+
+	response := &admin.ListFailedEventsResponse{Total: int32(total)}
+	for _, event := range events {
+		response.Events = append(response.Events, toProtoFailedEvent(event))
+	}
+	return response, nil
+}
+
+// GetFailedEvent implements admin.DeadLetterQueueServiceServer.GetFailedEvent
+func (s *DLQAdminServer) GetFailedEvent(ctx context.Context, req *admin.GetFailedEventRequest) (*admin.GetFailedEventResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	event, err := s.find(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &admin.GetFailedEventResponse{Event: toProtoFailedEvent(event)}, nil
+}
+
+// RetryFailedEvent implements admin.DeadLetterQueueServiceServer.RetryFailedEvent. Retried
+// events leave the queue.
+func (s *DLQAdminServer) RetryFailedEvent(ctx context.Context, req *admin.RetryFailedEventRequest) (*admin.RetryFailedEventResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.find(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	if err := s.dlq.RetryFailedEvent(ctx, req.Id); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to retry dead letter queue entry: %v", err)
+	}
+	return &admin.RetryFailedEventResponse{Success: true, Message: "event retried"}, nil
+}
+
+// DeleteFailedEvent implements admin.DeadLetterQueueServiceServer.DeleteFailedEvent
+func (s *DLQAdminServer) DeleteFailedEvent(ctx context.Context, req *admin.DeleteFailedEventRequest) (*admin.DeleteFailedEventResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.find(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	if err := s.dlq.DeleteFailedEvent(ctx, req.Id); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete dead letter queue entry: %v", err)
+	}
+	return &admin.DeleteFailedEventResponse{Success: true, Message: "event deleted"}, nil
+}
+
+// GetStats implements admin.DeadLetterQueueServiceServer.GetStats
+func (s *DLQAdminServer) GetStats(ctx context.Context, req *admin.GetStatsRequest) (*admin.GetStatsResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.dlq.GetDLQStats(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get dead letter queue statistics: %v", err)
+	}
+	return &admin.GetStatsResponse{
+		TotalEvents:        int32(stats.TotalEvents),
+		MaxSize:            int32(stats.MaxSize),
+		MaxAttempts:        int32(stats.MaxAttempts),
+		RetryDelay:         stats.RetryDelay.String(),
+		UtilizationPercent: stats.Utilization,
+	}, nil
+}
+
+// ClearFailedEvents implements admin.DeadLetterQueueServiceServer.ClearFailedEvents. When
+// purges need an approval the ID of the pending approval request is returned and the queue is
+// cleared once it is approved.
+func (s *DLQAdminServer) ClearFailedEvents(ctx context.Context, req *admin.ClearFailedEventsRequest) (*admin.ClearFailedEventsResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.approvals != nil && s.approvals.Requires(DLQPurgeAction) {
+		request, err := s.approvals.Submit(ctx, DLQPurgeAction, nil, req.RequestedBy, req.Reason)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to request approval: %v", err)
+		}
+		return &admin.ClearFailedEventsResponse{ApprovalId: request.ID}, nil
+	}
+
+	cleared, err := s.dlq.PurgeFailedEvents(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to clear dead letter queue: %v", err)
+	}
+	return &admin.ClearFailedEventsResponse{Cleared: int32(cleared)}, nil
+}
+
+// authorize checks the admin bearer token of a call
+func (s *DLQAdminServer) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if s.token != "" && ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "a valid admin token is required")
+}
+
+// find returns a failed event, or a NotFound status
+func (s *DLQAdminServer) find(ctx context.Context, id string) (*resilience.FailedEvent, error) {
+	event, err := s.dlq.GetFailedEvent(ctx, id)
+	if err != nil || event == nil {
+		return nil, status.Errorf(codes.NotFound, "dead letter queue entry %s not found", id)
+	}
+	return event, nil
+}
+
+// toProtoFailedEvent converts a failed event to its protobuf message. Event data that cannot be
+// represented as a protobuf Struct is left out.
+func toProtoFailedEvent(event *resilience.FailedEvent) *admin.FailedEvent {
+	data, _ := structpb.NewStruct(event.EventData)
+	return &admin.FailedEvent{
+		Id:          event.ID,
+		EventType:   event.EventType,
+		EventData:   data,
+		Error:       event.Error,
+		Timestamp:   event.Timestamp.Format(time.RFC3339Nano),
+		Attempts:    int32(event.Attempts),
+		MaxAttempts: int32(event.MaxAttempts),
+		Topic:       event.Topic,
+		Partition:   event.Partition,
+		Offset:      event.Offset,
+		Metadata:    event.Metadata,
+	}
+}
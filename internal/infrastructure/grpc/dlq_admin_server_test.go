@@ -0,0 +1,230 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"go-clean-ddd-es-template/pkg/approval"
+	"go-clean-ddd-es-template/pkg/resilience"
+	"go-clean-ddd-es-template/proto/admin"
+)
+
+const testAdminToken = "admin-secret"
+
+// memoryDLQ is a dead letter queue in memory, recording the pages listed and the events retried
+type memoryDLQ struct {
+	events   map[string]*resilience.FailedEvent
+	pages    [][2]int // Limit and offset of the pages listed
+	filters  []resilience.DLQFilter
+	retried  []string
+	retryErr error
+	purged   bool
+}
+
+func newMemoryDLQ(events ...*resilience.FailedEvent) *memoryDLQ {
+	dlq := &memoryDLQ{events: make(map[string]*resilience.FailedEvent)}
+	for _, event := range events {
+		dlq.events[event.ID] = event
+	}
+	return dlq
+}
+
+func (d *memoryDLQ) FindFailedEvents(ctx context.Context, filter resilience.DLQFilter, limit, offset int) ([]*resilience.FailedEvent, int, error) {
+	d.pages = append(d.pages, [2]int{limit, offset})
+	d.filters = append(d.filters, filter)
+	var events []*resilience.FailedEvent
+	for _, event := range d.events {
+		events = append(events, event)
+	}
+	return events, len(events), nil
+}
+
+func (d *memoryDLQ) GetFailedEvent(ctx context.Context, eventID string) (*resilience.FailedEvent, error) {
+	if event, ok := d.events[eventID]; ok {
+		return event, nil
+	}
+	return nil, fmt.Errorf("event %s not found", eventID)
+}
+
+func (d *memoryDLQ) RetryFailedEvent(ctx context.Context, eventID string) error {
+	if d.retryErr != nil {
+		return d.retryErr
+	}
+	d.retried = append(d.retried, eventID)
+	delete(d.events, eventID)
+	return nil
+}
+
+func (d *memoryDLQ) DeleteFailedEvent(ctx context.Context, eventID string) error {
+	delete(d.events, eventID)
+	return nil
+}
+
+func (d *memoryDLQ) GetDLQStats(ctx context.Context) (resilience.DLQStats, error) {
+	return resilience.DLQStats{TotalEvents: len(d.events), MaxSize: 100}, nil
+}
+
+func (d *memoryDLQ) PurgeFailedEvents(ctx context.Context) (int, error) {
+	cleared := len(d.events)
+	d.events = make(map[string]*resilience.FailedEvent)
+	d.purged = true
+	return cleared, nil
+}
+
+// recordingApprovals requires approvals for its actions and records the requests submitted
+type recordingApprovals struct {
+	actions   []string
+	submitted []approval.Request
+}
+
+func (a *recordingApprovals) Requires(action string) bool {
+	for _, required := range a.actions {
+		if required == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *recordingApprovals) Submit(ctx context.Context, action string, args map[string]string, requestedBy, reason string) (approval.Request, error) {
+	if requestedBy == "" {
+		return approval.Request{}, fmt.Errorf("requested_by is required")
+	}
+	request := approval.Request{ID: fmt.Sprintf("approval-%d", len(a.submitted)+1), Action: action, RequestedBy: requestedBy, Reason: reason}
+	a.submitted = append(a.submitted, request)
+	return request, nil
+}
+
+// adminContext returns the context of a call with authorization as its authorization metadata
+func adminContext(authorization string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+}
+
+func failedEvent(id string) *resilience.FailedEvent {
+	return &resilience.FailedEvent{ID: id, EventType: "user.created", Timestamp: time.Now(), Attempts: 3, MaxAttempts: 3, Topic: "user-events"}
+}
+
+func TestDLQAdminServer_Authorization(t *testing.T) {
+	server := NewDLQAdminServer(newMemoryDLQ(failedEvent("event-1")), testAdminToken)
+	calls := map[string]func(ctx context.Context) error{
+		"ListFailedEvents": func(ctx context.Context) error {
+			_, err := server.ListFailedEvents(ctx, &admin.ListFailedEventsRequest{})
+			return err
+		},
+		"GetFailedEvent": func(ctx context.Context) error {
+			_, err := server.GetFailedEvent(ctx, &admin.GetFailedEventRequest{Id: "event-1"})
+			return err
+		},
+		"RetryFailedEvent": func(ctx context.Context) error {
+			_, err := server.RetryFailedEvent(ctx, &admin.RetryFailedEventRequest{Id: "missing"})
+			return err
+		},
+		"DeleteFailedEvent": func(ctx context.Context) error {
+			_, err := server.DeleteFailedEvent(ctx, &admin.DeleteFailedEventRequest{Id: "missing"})
+			return err
+		},
+		"GetStats": func(ctx context.Context) error {
+			_, err := server.GetStats(ctx, &admin.GetStatsRequest{})
+			return err
+		},
+		"ClearFailedEvents": func(ctx context.Context) error {
+			_, err := server.ClearFailedEvents(ctx, &admin.ClearFailedEventsRequest{})
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		assert.Equal(t, codes.Unauthenticated, status.Code(call(context.Background())), "%s without a token", name)
+		assert.Equal(t, codes.Unauthenticated, status.Code(call(adminContext("Bearer wrong"))), "%s with another token", name)
+		assert.Equal(t, codes.Unauthenticated, status.Code(call(adminContext(testAdminToken))), "%s without the bearer scheme", name)
+	}
+	assert.NoError(t, calls["GetStats"](adminContext("Bearer "+testAdminToken)))
+
+	// Without an admin token configured, no call is authorized
+	unconfigured := NewDLQAdminServer(newMemoryDLQ(), "")
+	_, err := unconfigured.GetStats(adminContext("Bearer "), &admin.GetStatsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestDLQAdminServer_ListFailedEventsValidation(t *testing.T) {
+	dlq := newMemoryDLQ(failedEvent("event-1"))
+	server := NewDLQAdminServer(dlq, testAdminToken)
+	ctx := adminContext("Bearer " + testAdminToken)
+
+	for _, req := range []*admin.ListFailedEventsRequest{
+		{Limit: -1},
+		{Limit: maxDLQPageSize + 1},
+		{Offset: -1},
+		{Since: "yesterday"},
+		{Until: "2024-13-01T00:00:00Z"},
+	} {
+		_, err := server.ListFailedEvents(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "%v", req)
+	}
+	assert.Empty(t, dlq.pages, "invalid pages are not listed")
+
+	resp, err := server.ListFailedEvents(ctx, &admin.ListFailedEventsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), resp.Total)
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, "event-1", resp.Events[0].Id)
+
+	_, err = server.ListFailedEvents(ctx, &admin.ListFailedEventsRequest{Limit: maxDLQPageSize, Offset: 20, EventType: "user.created", Since: "2024-01-01T00:00:00Z"})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{defaultDLQPageSize, 0}, {maxDLQPageSize, 20}}, dlq.pages)
+	assert.Equal(t, "user.created", dlq.filters[1].EventType)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), dlq.filters[1].Since)
+}
+
+func TestDLQAdminServer_RetryFailedEvent(t *testing.T) {
+	dlq := newMemoryDLQ(failedEvent("event-1"), failedEvent("event-2"))
+	server := NewDLQAdminServer(dlq, testAdminToken)
+	ctx := adminContext("Bearer " + testAdminToken)
+
+	resp, err := server.RetryFailedEvent(ctx, &admin.RetryFailedEventRequest{Id: "event-1"})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+	assert.Equal(t, []string{"event-1"}, dlq.retried)
+
+	_, err = server.RetryFailedEvent(ctx, &admin.RetryFailedEventRequest{Id: "event-1"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "retried events leave the queue")
+
+	dlq.retryErr = fmt.Errorf("broker unavailable")
+	_, err = server.RetryFailedEvent(ctx, &admin.RetryFailedEventRequest{Id: "event-2"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestDLQAdminServer_ClearFailedEventsApproval(t *testing.T) {
+	dlq := newMemoryDLQ(failedEvent("event-1"), failedEvent("event-2"))
+	approvals := &recordingApprovals{actions: []string{DLQPurgeAction}}
+	server := NewDLQAdminServer(dlq, testAdminToken)
+	server.SetApprovals(approvals)
+	ctx := adminContext("Bearer " + testAdminToken)
+
+	_, err := server.ClearFailedEvents(ctx, &admin.ClearFailedEventsRequest{Reason: "replayed upstream"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "approval requests name their requester")
+
+	resp, err := server.ClearFailedEvents(ctx, &admin.ClearFailedEventsRequest{RequestedBy: "ops@example.com", Reason: "replayed upstream"})
+	require.NoError(t, err)
+	assert.Equal(t, "approval-1", resp.ApprovalId)
+	assert.Zero(t, resp.Cleared)
+	assert.False(t, dlq.purged, "the queue is cleared once the request is approved")
+	require.Len(t, approvals.submitted, 1)
+	assert.Equal(t, DLQPurgeAction, approvals.submitted[0].Action)
+
+	// Without approvals required, the queue is cleared at once
+	approvals.actions = nil
+	resp, err = server.ClearFailedEvents(ctx, &admin.ClearFailedEventsRequest{RequestedBy: "ops@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Cleared)
+	assert.Empty(t, resp.ApprovalId)
+	assert.True(t, dlq.purged)
+}
@@ -0,0 +1,362 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/dataio"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// Routes the dead letter queue admin handlers are mounted at
+const (
+	DLQExportPattern = "GET /admin/dlq/export"
+	DLQImportPattern = "POST /admin/dlq/import"
+	DLQPurgePattern  = "POST /admin/dlq/purge"
+	DLQListPattern   = "GET /admin/dlq/events"
+	DLQShowPattern   = "GET /admin/dlq/events/{id}"
+	DLQRetryPattern  = "POST /admin/dlq/events/{id}/retry"
+	DLQDeletePattern = "DELETE /admin/dlq/events/{id}"
+)
+
+// Page sizes of dead letter queue listings
+const (
+	defaultDLQPageSize = 50
+	maxDLQPageSize     = 500
+)
+
+// DLQPurgeAction is the sensitive action purging the dead letter queue
+const DLQPurgeAction = "dlq.purge"
+
+// DeadLetterQueueTransfer exports and imports dead letter queue contents
+type DeadLetterQueueTransfer interface {
+	ExportFailedEvents(ctx context.Context, w dataio.Writer, filter resilience.DLQFilter) (int, error)
+	ImportFailedEvents(ctx context.Context, r dataio.Reader) (resilience.DLQImportResult, error)
+	PurgeFailedEvents(ctx context.Context) (int, error)
+}
+
+// DeadLetterQueueTriage lists, inspects, retries and deletes dead letter queue entries
+type DeadLetterQueueTriage interface {
+	FindFailedEvents(ctx context.Context, filter resilience.DLQFilter, limit, offset int) ([]*resilience.FailedEvent, int, error)
+	GetFailedEvent(ctx context.Context, eventID string) (*resilience.FailedEvent, error)
+	RetryFailedEvent(ctx context.Context, eventID string) error
+	DeleteFailedEvent(ctx context.Context, eventID string) error
+}
+
+// DeadLetterQueueAdmin is the dead letter queue served to operators
+type DeadLetterQueueAdmin interface {
+	DeadLetterQueueTransfer
+	DeadLetterQueueTriage
+}
+
+// DLQListResponse is a page of dead letter queue entries
+type DLQListResponse struct {
+	Events []*resilience.FailedEvent `json:"events"`
+	Total  int                       `json:"total"` // Entries matching the filter
+}
+
+// DLQHandler serves dead letter queue triage, export and import for operators.
+// Every request must carry the admin token as a bearer token.
+type DLQHandler struct {
+	dlq           DeadLetterQueueAdmin
+	token         string
+	maxImportSize int64
+	approvals     ApprovalWorkflow
+	tokens        TokenValidator
+}
+
+// NewDLQHandler creates a new dead letter queue admin handler
+func NewDLQHandler(dlq DeadLetterQueueAdmin, token string, maxImportSize int64) *DLQHandler {
+	return &DLQHandler{
+		dlq:           dlq,
+		token:         token,
+		maxImportSize: maxImportSize,
+	}
+}
+
+// SetApprovals makes purges create an approval request instead of running. Purges needing an
+// approval are requested by an admin authenticated with an access token validated by tokens.
+func (h *DLQHandler) SetApprovals(approvals ApprovalWorkflow, tokens TokenValidator) {
+	h.approvals = approvals
+	h.tokens = tokens
+}
+
+// Export handles GET /admin/dlq/export?format=csv|jsonl&event_type=&topic=&since=&until=
+// Events are streamed as they are read, times are RFC 3339.
+func (h *DLQHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	format, err := dataio.ParseFormat(defaultFormat(query.Get("format")), "")
+	if err != nil {
+		writeHTTPError(w, errors.ValidationFailed("format", err.Error()), "Failed to export dead letter queue")
+		return
+	}
+
+	filter, err := parseDLQFilter(query)
+	if err != nil {
+		writeHTTPError(w, err, "Failed to export dead letter queue")
+		return
+	}
+
+	contentType := "application/x-ndjson"
+	if format == dataio.FormatCSV {
+		contentType = "text/csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="dlq-export.`+string(format)+`"`)
+
+	writer, err := dataio.NewWriter(w, format, resilience.FailedEventFields, false)
+	if err != nil {
+		writeHTTPError(w, err, "Failed to export dead letter queue")
+		return
+	}
+
+	// The status is already sent once streaming starts, a failure truncates the output
+	h.dlq.ExportFailedEvents(r.Context(), writer, filter)
+}
+
+// Import handles POST /admin/dlq/import?format=csv|jsonl with a previously exported file as body
+func (h *DLQHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	format, err := dataio.ParseFormat(defaultFormat(r.URL.Query().Get("format")), "")
+	if err != nil {
+		writeHTTPError(w, errors.ValidationFailed("format", err.Error()), "Failed to import dead letter queue")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxImportSize)
+	reader, err := dataio.NewReader(r.Body, format)
+	if err != nil {
+		writeHTTPError(w, errors.ValidationFailed("body", err.Error()), "Failed to import dead letter queue")
+		return
+	}
+
+	result, err := h.dlq.ImportFailedEvents(r.Context(), reader)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			err = errors.ValidationFailed("body", "import is too large")
+		} else {
+			err = errors.ValidationFailed("body", err.Error())
+		}
+		writeHTTPError(w, err, "Failed to import dead letter queue")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// List handles GET /admin/dlq/events?event_type=&topic=&since=&until=&limit=&offset=
+func (h *DLQHandler) List(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := parseDLQFilter(query)
+	if err != nil {
+		writeHTTPError(w, err, "Failed to list dead letter queue")
+		return
+	}
+	limit, err := parseIntParam(query.Get("limit"), defaultDLQPageSize)
+	if err != nil || limit <= 0 || limit > maxDLQPageSize {
+		writeHTTPError(w, errors.ValidationFailed("limit", "limit must be between 1 and "+strconv.Itoa(maxDLQPageSize)), "Failed to list dead letter queue")
+		return
+	}
+	offset, err := parseIntParam(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		writeHTTPError(w, errors.ValidationFailed("offset", "offset must not be negative"), "Failed to list dead letter queue")
+		return
+	}
+
+	events, total, err := h.dlq.FindFailedEvents(r.Context(), filter, limit, offset)
+	if err != nil {
+		writeHTTPError(w, err, "Failed to list dead letter queue")
+		return
+	}
+	writeJSON(w, http.StatusOK, DLQListResponse{Events: events, Total: total})
+}
+
+// Show handles GET /admin/dlq/events/{id}
+func (h *DLQHandler) Show(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	event, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, event)
+}
+
+// Retry handles POST /admin/dlq/events/{id}/retry. Retried entries leave the queue.
+func (h *DLQHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	event, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+	if err := h.dlq.RetryFailedEvent(r.Context(), event.ID); err != nil {
+		writeHTTPError(w, errors.New(errors.ErrBadRequest, err.Error()), "Failed to retry dead letter queue entry")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"retried": event.ID})
+}
+
+// Delete handles DELETE /admin/dlq/events/{id}
+func (h *DLQHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	event, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+	if err := h.dlq.DeleteFailedEvent(r.Context(), event.ID); err != nil {
+		writeHTTPError(w, err, "Failed to delete dead letter queue entry")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": event.ID})
+}
+
+// find returns the entry of the request's id path value, writing a not found response when it
+// is not queued
+func (h *DLQHandler) find(w http.ResponseWriter, r *http.Request) (*resilience.FailedEvent, bool) {
+	id := r.PathValue("id")
+	event, err := h.dlq.GetFailedEvent(r.Context(), id)
+	if err != nil || event == nil {
+		writeHTTPError(w, errors.Newf(errors.ErrNotFound, "dead letter queue entry %s not found", id), "Not found")
+		return nil, false
+	}
+	return event, true
+}
+
+// purgeRequest is the body of a purge request
+type purgeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Purge handles POST /admin/dlq/purge with an optional {"reason"} body. When purges need an
+// approval the calling admin, authenticated by their access token instead of the admin token,
+// requests it and the pending approval request is returned with 202 Accepted. Otherwise the
+// queue is purged right away.
+func (h *DLQHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	requiresApproval := h.approvals != nil && h.approvals.Requires(DLQPurgeAction)
+	var requestedBy string
+	if requiresApproval {
+		claims := authenticateAdmin(w, r, h.tokens)
+		if claims == nil {
+			return
+		}
+		requestedBy = claims.UserID
+	} else if !h.authorize(w, r) {
+		return
+	}
+
+	var req purgeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApprovalRequestSize)).Decode(&req); err != nil && err != io.EOF {
+		writeHTTPError(w, errors.ValidationFailed("body", err.Error()), "Failed to purge dead letter queue")
+		return
+	}
+
+	if requiresApproval {
+		request, err := h.approvals.Submit(r.Context(), DLQPurgeAction, nil, requestedBy, req.Reason)
+		if err != nil {
+			writeHTTPError(w, errors.ValidationFailed("action", err.Error()), "Failed to purge dead letter queue")
+			return
+		}
+		writeJSON(w, http.StatusAccepted, request)
+		return
+	}
+
+	purged, err := h.dlq.PurgeFailedEvents(r.Context())
+	if err != nil {
+		writeHTTPError(w, err, "Failed to purge dead letter queue")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// authorize checks the bearer token and writes an error response when it does not match
+func (h *DLQHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	return authorizeAdmin(w, r, h.token)
+}
+
+// authorizeAdmin checks the admin bearer token and writes an error response when it does not match
+func authorizeAdmin(w http.ResponseWriter, r *http.Request, adminToken string) bool {
+	return authorizeBearer(w, r, adminToken, "a valid admin token is required")
+}
+
+// authorizeBearer checks a bearer token and writes an error response with message when it does
+// not match. An empty expected token matches no request.
+func authorizeBearer(w http.ResponseWriter, r *http.Request, expected, message string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if expected != "" && ok && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+		return true
+	}
+
+	writeHTTPError(w, errors.New(errors.ErrUnauthorized, message), "Unauthorized")
+	return false
+}
+
+// defaultFormat returns the requested format or jsonl
+func defaultFormat(format string) string {
+	if format == "" {
+		return string(dataio.FormatJSONL)
+	}
+	return format
+}
+
+// parseDLQFilter parses the event_type, topic, since and until query parameters
+func parseDLQFilter(query url.Values) (resilience.DLQFilter, error) {
+	filter := resilience.DLQFilter{
+		EventType: query.Get("event_type"),
+		Topic:     query.Get("topic"),
+	}
+
+	var err error
+	if filter.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		return filter, errors.ValidationFailed("since", err.Error())
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		return filter, errors.ValidationFailed("until", err.Error())
+	}
+	return filter, nil
+}
+
+// parseIntParam parses an optional integer query parameter
+func parseIntParam(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// parseTimeParam parses an optional RFC 3339 query parameter
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
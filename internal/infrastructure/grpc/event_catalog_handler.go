@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	"net/http"
+
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/eventcatalog"
+)
+
+// Routes the event catalog admin handlers are mounted at
+const (
+	EventCatalogListPattern = "GET /admin/events"
+	EventCatalogShowPattern = "GET /admin/events/{type}"
+)
+
+// EventCatalogHandler serves the catalog of the domain events: their description, payload
+// schema, topic, producers and consumers. Every request must carry the admin token as a bearer
+// token.
+type EventCatalogHandler struct {
+	catalog *eventcatalog.Catalog
+	token   string
+}
+
+// NewEventCatalogHandler creates a new event catalog admin handler
+func NewEventCatalogHandler(catalog *eventcatalog.Catalog, token string) *EventCatalogHandler {
+	return &EventCatalogHandler{
+		catalog: catalog,
+		token:   token,
+	}
+}
+
+// eventCatalogResponse is the body of an event catalog response
+type eventCatalogResponse struct {
+	Events []eventcatalog.Event `json:"events"`
+}
+
+// List handles GET /admin/events, returning every documented event sorted by type
+func (h *EventCatalogHandler) List(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, eventCatalogResponse{Events: h.catalog.Events()})
+}
+
+// Show handles GET /admin/events/{type}
+func (h *EventCatalogHandler) Show(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	eventType := r.PathValue("type")
+	event, ok := h.catalog.Get(eventType)
+	if !ok {
+		writeHTTPError(w, errors.Newf(errors.ErrNotFound, "event type %s not found", eventType), "Not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, event)
+}
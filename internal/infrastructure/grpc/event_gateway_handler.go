@@ -0,0 +1,185 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/eventstream"
+)
+
+// EventGatewayPattern is the route front-ends open WebSocket event subscriptions at
+const EventGatewayPattern = "GET /api/v1/events/ws"
+
+// maxClientMessageSize bounds the messages read from clients, which are ignored
+const maxClientMessageSize = 4 << 10
+
+// TokenValidator validates the access tokens of users. See services.AuthService.
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*dto.ValidateTokenResponse, error)
+}
+
+// EventGatewayOptions configure the event gateway
+type EventGatewayOptions struct {
+	Topics          []string      // Event type patterns clients may subscribe to
+	PrivilegedRoles []string      // Roles receiving the events about every user, not only their own
+	AllowedOrigins  []string      // Origins of the pages allowed to connect, empty allowing any
+	MaxConnections  int           // Connections served at once, 0 for no limit
+	WriteTimeout    time.Duration // Time allowed to push an event to a client
+}
+
+// EventGatewayLogger logs the subscriptions of the event gateway
+type EventGatewayLogger interface {
+	Info(format string, v ...interface{})
+}
+
+// EventGatewayHandler pushes the events of the topics front-ends subscribe to as JSON messages
+// over WebSocket. Clients authenticate with the access token of a user, as a bearer token or an
+// access_token query parameter since browsers cannot set headers on WebSocket requests. Events
+// about a user are only pushed to that user, unless the token has a privileged role.
+type EventGatewayHandler struct {
+	hub     *eventstream.Hub
+	tokens  TokenValidator
+	options EventGatewayOptions
+	logger  EventGatewayLogger
+}
+
+// NewEventGatewayHandler creates a new event gateway handler subscribing clients to hub
+func NewEventGatewayHandler(hub *eventstream.Hub, tokens TokenValidator, options EventGatewayOptions, logger EventGatewayLogger) *EventGatewayHandler {
+	return &EventGatewayHandler{
+		hub:     hub,
+		tokens:  tokens,
+		options: options,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP handles GET /api/v1/events/ws?topics=user.*,product.*, upgrading the connection to
+// WebSocket once the token and topics are accepted
+func (h *EventGatewayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		writeHTTPError(w, errors.New(errors.ErrUnauthorized, "a valid access token is required"), "Unauthorized")
+		return
+	}
+	claims, err := h.tokens.ValidateToken(r.Context(), token)
+	if err != nil {
+		writeHTTPError(w, errors.New(errors.ErrUnauthorized, "a valid access token is required"), "Unauthorized")
+		return
+	}
+
+	topics, err := h.parseTopics(r.URL.Query().Get("topics"))
+	if err != nil {
+		writeHTTPError(w, err, "Failed to subscribe to events")
+		return
+	}
+	if h.options.MaxConnections > 0 && h.hub.Len() >= h.options.MaxConnections {
+		writeHTTPError(w, errors.New(errors.ErrServiceUnavailable, "too many event subscriptions, retry later"), "Failed to subscribe to events")
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(conn *websocket.Conn) {
+			h.stream(conn, claims, topics)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// parseTopics parses the comma separated event type patterns of a subscription, each covered
+// by a pattern clients may subscribe to
+func (h *EventGatewayHandler) parseTopics(value string) ([]string, error) {
+	var topics []string
+	for _, topic := range strings.Split(value, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	if len(topics) == 0 {
+		return nil, errors.ValidationFailed("topics", "at least one event type pattern is required, e.g. user.*")
+	}
+
+	for _, topic := range topics {
+		if err := eventstream.ValidatePattern(topic); err != nil {
+			return nil, errors.ValidationFailed("topics", err.Error())
+		}
+		allowed := slices.ContainsFunc(h.options.Topics, func(pattern string) bool {
+			return eventstream.Covers(pattern, topic)
+		})
+		if !allowed {
+			return nil, errors.New(errors.ErrForbidden, fmt.Sprintf("subscribing to %s is not allowed", topic))
+		}
+	}
+	return topics, nil
+}
+
+// checkOrigin accepts the origins allowed, or any origin when none are configured
+func (h *EventGatewayHandler) checkOrigin(config *websocket.Config, r *http.Request) error {
+	if len(h.options.AllowedOrigins) == 0 {
+		return nil
+	}
+	origin := r.Header.Get("Origin")
+	if !slices.Contains(h.options.AllowedOrigins, origin) {
+		return fmt.Errorf("origin %q is not allowed", origin)
+	}
+	return nil
+}
+
+// stream pushes the events of a subscription to a client until either side ends it
+func (h *EventGatewayHandler) stream(conn *websocket.Conn, claims *dto.ValidateTokenResponse, topics []string) {
+	defer conn.Close()
+
+	privileged := slices.ContainsFunc(claims.Roles, func(role string) bool {
+		return slices.Contains(h.options.PrivilegedRoles, role)
+	})
+	subscription, err := h.hub.Subscribe(topics, func(event eventstream.Event) bool {
+		return privileged || event.UserID == "" || event.UserID == claims.UserID
+	})
+	if err != nil {
+		return
+	}
+	defer subscription.Close()
+	if h.logger != nil {
+		h.logger.Info("User %s subscribed to events %v", claims.UserID, topics)
+	}
+
+	// Reading detects the client closing the connection; what clients send is ignored
+	conn.MaxPayloadBytes = maxClientMessageSize
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var message []byte
+		for websocket.Message.Receive(conn, &message) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-subscription.Done():
+			if err := subscription.Err(); err != nil && h.logger != nil {
+				h.logger.Info("Ended event subscription of user %s: %v", claims.UserID, err)
+			}
+			return
+		case event := <-subscription.Events():
+			if h.options.WriteTimeout > 0 {
+				_ = conn.SetWriteDeadline(time.Now().Add(h.options.WriteTimeout))
+			}
+			if err := websocket.JSON.Send(conn, event); err != nil {
+				return
+			}
+		}
+	}
+}
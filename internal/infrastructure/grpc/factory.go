@@ -3,27 +3,42 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
 	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/authz"
+	"go-clean-ddd-es-template/pkg/cache"
 	"go-clean-ddd-es-template/pkg/logger"
+	"go-clean-ddd-es-template/pkg/metrics"
 	"go-clean-ddd-es-template/pkg/middleware"
+	"go-clean-ddd-es-template/pkg/requestcost"
 	"go-clean-ddd-es-template/pkg/tracing"
+	"go-clean-ddd-es-template/proto/admin"
 	"go-clean-ddd-es-template/proto/auth"
 	"go-clean-ddd-es-template/proto/user"
+	userv2 "go-clean-ddd-es-template/proto/user/v2"
 )
 
 // GRPCServer represents the gRPC server with gateway
 type GRPCServer struct {
 	grpcServer  *grpc.Server
 	gatewayMux  *runtime.ServeMux
+	gateway     http.Handler
 	userService *services.UserService
 	authService *services.AuthService
+	userServer  *UserGRPCServer
+	authServer  *AuthHandler
+	validation  *middleware.ValidationMiddleware
+	authorizer  *authz.Authorizer
 	tracer      *tracing.Tracer
 	logger      logger.Logger
 }
@@ -43,37 +58,157 @@ func (s *GRPCServer) GetLogger() logger.Logger {
 	return s.logger
 }
 
+// GetUserService returns the user service
+func (s *GRPCServer) GetUserService() *services.UserService {
+	return s.userService
+}
+
+// GetAuthService returns the auth service
+func (s *GRPCServer) GetAuthService() *services.AuthService {
+	return s.authService
+}
+
+// GetAuthorizer returns the authorizer of the calls, nil when calls are only authenticated
+func (s *GRPCServer) GetAuthorizer() *authz.Authorizer {
+	return s.authorizer
+}
+
+// Methods returns the full names of the methods the gRPC server serves
+func (s *GRPCServer) Methods() []string {
+	var methods []string
+	for service, info := range s.grpcServer.GetServiceInfo() {
+		for _, method := range info.Methods {
+			methods = append(methods, "/"+service+"/"+method.Name)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// BindAuthorization requires the authorization matrix to have a policy for every served method,
+// failing when one has none. It does nothing when calls are only authenticated.
+func (s *GRPCServer) BindAuthorization() error {
+	if s.authorizer == nil {
+		return nil
+	}
+	if err := s.authorizer.Bind(s.Methods()); err != nil {
+		return fmt.Errorf("invalid authorization matrix: %w", err)
+	}
+	return nil
+}
+
+// SetApprovals makes sensitive user commands wait for the approval of a second admin
+func (s *GRPCServer) SetApprovals(approvals ApprovalWorkflow) {
+	s.userServer.SetApprovals(approvals)
+}
+
+// SetTrustedProxies sets the proxies, besides the HTTP gateway, whose x-forwarded-for gives the
+// address of the client of auth calls and of rate limits
+func (s *GRPCServer) SetTrustedProxies(proxies []*net.IPNet) {
+	s.authServer.SetTrustedProxies(proxies)
+	s.validation.SetTrustedProxies(proxies)
+}
+
+// RegisterDLQAdminService serves the dead letter queue admin service over gRPC and through the
+// gateway. It must be called before the server starts.
+func (s *GRPCServer) RegisterDLQAdminService(server *DLQAdminServer) error {
+	admin.RegisterDeadLetterQueueServiceServer(s.grpcServer, server)
+	if err := s.BindAuthorization(); err != nil {
+		return err
+	}
+
+	gatewayOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if err := admin.RegisterDeadLetterQueueServiceHandlerFromEndpoint(
+		context.Background(),
+		s.gatewayMux,
+		"localhost:9091", // gRPC server address
+		gatewayOpts,
+	); err != nil {
+		return fmt.Errorf("failed to register dead letter queue admin gateway: %w", err)
+	}
+	return nil
+}
+
+// RegisterHealthService serves the gRPC health checking protocol, for Kubernetes gRPC probes and
+// load balancers. It must be called before the server starts.
+func (s *GRPCServer) RegisterHealthService(server healthpb.HealthServer) error {
+	healthpb.RegisterHealthServer(s.grpcServer, server)
+	return s.BindAuthorization()
+}
+
 // ServeHTTP implements http.Handler for the gateway
 func (s *GRPCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.gatewayMux.ServeHTTP(w, r)
+	s.gateway.ServeHTTP(w, r)
 }
 
-// NewGRPCServer creates a new gRPC server with gateway
-func NewGRPCServer(userService *services.UserService, authService *services.AuthService, tracer *tracing.Tracer, logger logger.Logger) *GRPCServer {
+// Names of the gRPC services a deployment can disable
+const (
+	UserServiceName = "user"
+	AuthServiceName = "auth"
+)
+
+// NewGRPCServer creates a new gRPC server with gateway.
+// A non-nil responseCache caches idempotent reads of the gateway and the gRPC services.
+// Services disabled in components are neither served over gRPC nor through the gateway.
+// Every version of a service is served; methods of deprecated versions return deprecation metadata.
+// Calls return rate limit metadata, warning clients past the soft limit before they are rejected.
+// Requests are logged, stripped of sensitive fields, when logConfig enables it.
+// The database queries, broker publishes and cache lookups of requests are accounted when requestCost enables it.
+// A non-nil authorizer authorizes calls against its matrix, see BindAuthorization.
+// Events published while serving a call carry its correlation ID, see correlationContext.
+func NewGRPCServer(userService *services.UserService, authService *services.AuthService, tracer *tracing.Tracer, logger logger.Logger, responseCache *cache.TaggedCache, cacheConfig config.ResponseCacheConfig, components config.ComponentsConfig, apiVersions config.APIVersionsConfig, rateLimit config.RateLimitConfig, logConfig config.LogConfig, requestCost config.RequestCostConfig, authorizer *authz.Authorizer) *GRPCServer {
 	// Create validation middleware
 	validationConfig := middleware.DefaultValidationConfig()
 	// Adjust config for gRPC (higher limits, different rate limiting)
 	validationConfig.MaxRequestSize = 50 * 1024 * 1024 // 50MB for gRPC
 	validationConfig.MaxHeaderSize = 5 * 1024 * 1024   // 5MB for gRPC headers
-	validationConfig.RateLimitRequests = 1000          // Higher rate limit for gRPC
-	validationConfig.RateLimitWindow = 60 * 60         // 1 hour window
+	validationConfig.RateLimitRequests = rateLimit.Requests
+	validationConfig.RateLimitWindow = rateLimit.Window
+	validationConfig.RateLimitSoftPct = rateLimit.SoftPercent
+	validationConfig.Routes = middleware.DefaultRouteRegistry()
 
 	validationMiddleware := middleware.NewValidationMiddleware(validationConfig, logger)
 
 	// Create auth interceptor
 	authInterceptor := middleware.NewAuthInterceptor(authService, logger)
+	if authorizer != nil {
+		authInterceptor.SetAuthorizer(authorizer)
+	}
 
 	// Create gRPC server with interceptors
 	var opts []grpc.ServerOption
 	var unaryInterceptors []grpc.UnaryServerInterceptor
 	var streamInterceptors []grpc.StreamServerInterceptor
 
+	// Add metrics interceptor first so rejected requests count against latency and error budgets
+	unaryInterceptors = append(unaryInterceptors, middleware.GRPCMetricsInterceptor(metrics.NewMetrics()))
+
+	// Correlate the events published while serving calls with them
+	unaryInterceptors = append(unaryInterceptors, correlationInterceptor())
+	streamInterceptors = append(streamInterceptors, correlationStreamInterceptor())
+
+	// Log requests, including rejected ones
+	if logConfig.Requests {
+		unaryInterceptors = append(unaryInterceptors, middleware.GRPCRequestLoggingInterceptor(logger))
+	}
+
+	// Account the cost of requests, including those answered from the read cache
+	if requestCost.Enabled {
+		aggregator := requestcost.NewAggregator(requestCost.LogInterval, requestCost.QueryThreshold, nil, logger)
+		unaryInterceptors = append(unaryInterceptors, middleware.GRPCRequestCostInterceptor(aggregator, requestCost.Headers))
+	}
+
 	// Add tracing interceptors
 	if tracer != nil {
 		unaryInterceptors = append(unaryInterceptors, middleware.GRPCTracingInterceptor(tracer))
 		streamInterceptors = append(streamInterceptors, middleware.GRPCStreamTracingInterceptor(tracer))
 	}
 
+	// Flag calls of deprecated API versions, including rejected ones
+	if deprecations := APIDeprecations(apiVersions); len(deprecations) > 0 {
+		unaryInterceptors = append(unaryInterceptors, middleware.GRPCDeprecationInterceptor(deprecations))
+	}
+
 	// Add validation interceptors
 	unaryInterceptors = append(unaryInterceptors, middleware.GRPCValidationInterceptor(validationMiddleware))
 	streamInterceptors = append(streamInterceptors, middleware.GRPCStreamValidationInterceptor(validationMiddleware))
@@ -86,6 +221,14 @@ func NewGRPCServer(userService *services.UserService, authService *services.Auth
 	unaryInterceptors = append(unaryInterceptors, authInterceptor.UnaryAuthInterceptor())
 	streamInterceptors = append(streamInterceptors, authInterceptor.StreamAuthInterceptor())
 
+	// Add display format interceptor so responses can carry localized values
+	unaryInterceptors = append(unaryInterceptors, middleware.GRPCDisplayFormatInterceptor(DisplayFormatEndpoints))
+
+	// Add read cache interceptor last so cached reads are still authenticated and rate limited
+	if responseCache != nil {
+		unaryInterceptors = append(unaryInterceptors, middleware.GRPCReadCacheInterceptor(responseCache, ReadCachePolicies(cacheConfig.TTL)))
+	}
+
 	// Chain all interceptors
 	if len(unaryInterceptors) > 0 {
 		opts = append(opts, grpc.ChainUnaryInterceptor(unaryInterceptors...))
@@ -96,50 +239,98 @@ func NewGRPCServer(userService *services.UserService, authService *services.Auth
 
 	grpcServer := grpc.NewServer(opts...)
 
-	// Create user gRPC server
+	// Create user gRPC server, serving v1 through a shim over v2
 	userGRPCServer := NewUserGRPCServer(userService, tracer)
+	userV1Shim := NewUserV1Shim(userGRPCServer)
 
 	// Create auth gRPC server
 	authGRPCServer := NewAuthHandler(authService, logger)
 
-	// Register services
-	user.RegisterUserServiceServer(grpcServer, userGRPCServer)
-	auth.RegisterAuthServiceServer(grpcServer, authGRPCServer)
+	// Register the services enabled for this deployment
+	var active []string
+	if components.ServiceEnabled(UserServiceName) {
+		user.RegisterUserServiceServer(grpcServer, userV1Shim)
+		userv2.RegisterUserServiceServer(grpcServer, userGRPCServer)
+		active = append(active, UserServiceName)
+	}
+	if components.ServiceEnabled(AuthServiceName) {
+		auth.RegisterAuthServiceServer(grpcServer, authGRPCServer)
+		active = append(active, AuthServiceName)
+	}
+	if logger != nil {
+		logger.Info("Active gRPC services: %v", active)
+		if len(components.DisabledServices) > 0 {
+			logger.Info("Disabled gRPC services: %v", components.DisabledServices)
+		}
+	}
 
 	// Register reflection service on gRPC server
 	reflection.Register(grpcServer)
 
 	// Create gRPC Gateway mux with validation middleware
-	gatewayMux := runtime.NewServeMux()
+	gatewayMux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+	)
 
 	// Register gRPC Gateway handlers
 	gatewayOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
 
 	// Register user service gateway
-	if err := user.RegisterUserServiceHandlerFromEndpoint(
-		context.Background(),
-		gatewayMux,
-		"localhost:9091", // gRPC server address
-		gatewayOpts,
-	); err != nil {
-		panic(fmt.Sprintf("failed to register user gateway: %v", err))
+	if components.ServiceEnabled(UserServiceName) {
+		if err := user.RegisterUserServiceHandlerFromEndpoint(
+			context.Background(),
+			gatewayMux,
+			"localhost:9091", // gRPC server address
+			gatewayOpts,
+		); err != nil {
+			panic(fmt.Sprintf("failed to register user gateway: %v", err))
+		}
+		if err := userv2.RegisterUserServiceHandlerFromEndpoint(
+			context.Background(),
+			gatewayMux,
+			"localhost:9091", // gRPC server address
+			gatewayOpts,
+		); err != nil {
+			panic(fmt.Sprintf("failed to register user v2 gateway: %v", err))
+		}
 	}
 
 	// Register auth service gateway
-	if err := auth.RegisterAuthServiceHandlerFromEndpoint(
-		context.Background(),
-		gatewayMux,
-		"localhost:9091", // gRPC server address
-		gatewayOpts,
-	); err != nil {
-		panic(fmt.Sprintf("failed to register auth gateway: %v", err))
+	if components.ServiceEnabled(AuthServiceName) {
+		if err := auth.RegisterAuthServiceHandlerFromEndpoint(
+			context.Background(),
+			gatewayMux,
+			"localhost:9091", // gRPC server address
+			gatewayOpts,
+		); err != nil {
+			panic(fmt.Sprintf("failed to register auth gateway: %v", err))
+		}
 	}
 
+	// Cache idempotent gateway reads
+	var gateway http.Handler = gatewayMux
+	if responseCache != nil {
+		gateway = middleware.NewResponseCache(responseCache, middleware.ResponseCacheConfig{
+			DefaultTTL:  cacheConfig.TTL,
+			MaxBodySize: cacheConfig.MaxBodySize,
+			Tags:        gatewayCacheTags,
+		}).Wrap(gatewayMux)
+	}
+
+	// Route unversioned /api/ paths to the requested version
+	gateway = middleware.NewAPIVersionRouter(apiVersions.DefaultVersion, config.SupportedAPIVersions).Wrap(gateway)
+
 	return &GRPCServer{
 		grpcServer:  grpcServer,
 		gatewayMux:  gatewayMux,
+		gateway:     gateway,
 		userService: userService,
 		authService: authService,
+		userServer:  userGRPCServer,
+		authServer:  authGRPCServer,
+		validation:  validationMiddleware,
+		authorizer:  authorizer,
 		tracer:      tracer,
 		logger:      logger,
 	}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/faultinjection"
+)
+
+// Routes the failure injection admin handlers are mounted at
+const (
+	FaultListPattern  = "GET /admin/faults"
+	FaultSetPattern   = "PUT /admin/faults/{handler}"
+	FaultClearPattern = "DELETE /admin/faults/{handler}"
+)
+
+// maxFaultRequestSize limits the size of fault request bodies
+const maxFaultRequestSize = 4 << 10
+
+// FaultHandler lets operators change the faults injected into the event handlers at runtime, by
+// event type. Every request must carry the admin token as a bearer token.
+type FaultHandler struct {
+	injector *faultinjection.Injector
+	token    string
+}
+
+// NewFaultHandler creates a new failure injection admin handler
+func NewFaultHandler(injector *faultinjection.Injector, token string) *FaultHandler {
+	return &FaultHandler{
+		injector: injector,
+		token:    token,
+	}
+}
+
+// List handles GET /admin/faults, returning the injected faults by event type
+func (h *FaultHandler) List(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.injector.Faults())
+}
+
+// Set handles PUT /admin/faults/{handler} with a {"rate", "error"} body, failing that share of
+// the events of the type with the error
+func (h *FaultHandler) Set(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	var fault faultinjection.Fault
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFaultRequestSize)).Decode(&fault); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("body", err.Error()), "Failed to set fault")
+		return
+	}
+	handler := r.PathValue("handler")
+	if err := h.injector.Set(handler, fault); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("rate", err.Error()), "Failed to set fault")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fault)
+}
+
+// Clear handles DELETE /admin/faults/{handler}, handling the events of the type normally again
+func (h *FaultHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	handler := r.PathValue("handler")
+	if _, ok := h.injector.Faults()[handler]; !ok {
+		writeHTTPError(w, errors.Newf(errors.ErrNotFound, "no fault injected into %s", handler), "Not found")
+		return
+	}
+	h.injector.Clear(handler)
+
+	writeJSON(w, http.StatusOK, map[string]string{"cleared": handler})
+}
@@ -0,0 +1,171 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/operation"
+)
+
+// Routes the operations admin handlers are mounted at
+const (
+	OperationStartPattern  = "POST /admin/operations"
+	OperationListPattern   = "GET /admin/operations"
+	OperationGetPattern    = "GET /admin/operations/{id}"
+	OperationCancelPattern = "POST /admin/operations/{id}/cancel"
+)
+
+// Page sizes of operation listings
+const (
+	defaultOperationPageSize = 50
+	maxOperationPageSize     = 500
+)
+
+// OperationManager starts and tracks long-running operations. See operation.Manager.
+type OperationManager interface {
+	Start(ctx context.Context, kind string, args map[string]string, requestedBy string) (operation.Operation, error)
+	Get(ctx context.Context, id string) (operation.Operation, error)
+	List(ctx context.Context, filter operation.Filter) ([]operation.Operation, error)
+	Cancel(ctx context.Context, id string) (operation.Operation, error)
+}
+
+// OperationsHandler lets operators start long-running operations, e.g. projection rebuilds, then
+// poll their progress and cancel them. Every request must carry the admin token as a bearer token.
+type OperationsHandler struct {
+	operations OperationManager
+	token      string
+	approvals  ApprovalWorkflow
+}
+
+// NewOperationsHandler creates a new operations admin handler
+func NewOperationsHandler(operations OperationManager, token string) *OperationsHandler {
+	return &OperationsHandler{
+		operations: operations,
+		token:      token,
+	}
+}
+
+// SetApprovals makes the kinds of operations needing an approval create an approval request
+// instead of starting
+func (h *OperationsHandler) SetApprovals(approvals ApprovalWorkflow) {
+	h.approvals = approvals
+}
+
+// startOperationRequest is the body of a start request
+type startOperationRequest struct {
+	Kind        string            `json:"kind"`
+	Args        map[string]string `json:"args"`
+	RequestedBy string            `json:"requested_by"`
+	Reason      string            `json:"reason"`
+}
+
+// Start handles POST /admin/operations with a {"kind", "args", "requested_by", "reason"} body.
+// The running operation, or the pending approval request when its kind needs an approval, is
+// returned with 202 Accepted.
+func (h *OperationsHandler) Start(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	var req startOperationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApprovalRequestSize)).Decode(&req); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("body", err.Error()), "Failed to start operation")
+		return
+	}
+	if req.RequestedBy == "" {
+		writeHTTPError(w, errors.ValidationFailed("requested_by", "the admin starting the operation is required"), "Failed to start operation")
+		return
+	}
+
+	if h.approvals != nil && h.approvals.Requires(req.Kind) {
+		request, err := h.approvals.Submit(r.Context(), req.Kind, req.Args, req.RequestedBy, req.Reason)
+		if err != nil {
+			writeHTTPError(w, errors.ValidationFailed("requested_by", err.Error()), "Failed to start operation")
+			return
+		}
+		writeJSON(w, http.StatusAccepted, request)
+		return
+	}
+
+	op, err := h.operations.Start(r.Context(), req.Kind, req.Args, req.RequestedBy)
+	if err != nil {
+		writeHTTPError(w, operationError(err), "Failed to start operation")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, op)
+}
+
+// List handles GET /admin/operations?kind=&status=&limit=, listing operations newest first
+func (h *OperationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := parseIntParam(query.Get("limit"), defaultOperationPageSize)
+	if err != nil || limit <= 0 || limit > maxOperationPageSize {
+		writeHTTPError(w, errors.ValidationFailed("limit", "limit must be between 1 and "+strconv.Itoa(maxOperationPageSize)), "Failed to list operations")
+		return
+	}
+
+	operations, err := h.operations.List(r.Context(), operation.Filter{
+		Kind:   query.Get("kind"),
+		Status: query.Get("status"),
+		Limit:  limit,
+	})
+	if err != nil {
+		writeHTTPError(w, err, "Failed to list operations")
+		return
+	}
+	if operations == nil {
+		operations = []operation.Operation{}
+	}
+	writeJSON(w, http.StatusOK, operations)
+}
+
+// Get handles GET /admin/operations/{id}, returning the status and progress of an operation
+func (h *OperationsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	op, err := h.operations.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeHTTPError(w, operationError(err), "Failed to get operation")
+		return
+	}
+	writeJSON(w, http.StatusOK, op)
+}
+
+// Cancel handles POST /admin/operations/{id}/cancel. Operations running on another instance are
+// returned with cancel_requested set until that instance stops them.
+func (h *OperationsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	op, err := h.operations.Cancel(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeHTTPError(w, operationError(err), "Failed to cancel operation")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, op)
+}
+
+// operationError converts a rejected operation request to an application error
+func operationError(err error) error {
+	switch {
+	case stderrors.Is(err, operation.ErrNotFound):
+		return errors.New(errors.ErrNotFound, err.Error())
+	case stderrors.Is(err, operation.ErrInvalidArgs):
+		return errors.ValidationFailed("args", err.Error())
+	case stderrors.Is(err, operation.ErrUnknownKind), stderrors.Is(err, operation.ErrFinished):
+		return errors.New(errors.ErrBadRequest, err.Error())
+	default:
+		return err
+	}
+}
@@ -2,16 +2,27 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"net"
 	"net/http"
+	"sync"
 
 	"go-clean-ddd-es-template/pkg/logger"
+	"go-clean-ddd-es-template/pkg/metrics"
+	"go-clean-ddd-es-template/pkg/middleware"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // HTTPServer represents the HTTP server that serves both gRPC and HTTP gateway
 type HTTPServer struct {
 	grpcServer *GRPCServer
 	logger     logger.Logger
+	handlers   map[string]http.Handler
+	metrics    *middleware.MetricsMiddleware
+
+	mu     sync.Mutex
+	server *http.Server // The HTTP gateway, once started
 }
 
 // NewHTTPServer creates a new HTTP server instance
@@ -19,10 +30,18 @@ func NewHTTPServer(grpcServer *GRPCServer, logger logger.Logger) *HTTPServer {
 	return &HTTPServer{
 		grpcServer: grpcServer,
 		logger:     logger,
+		handlers:   make(map[string]http.Handler),
+		metrics:    middleware.NewMetricsMiddleware(metrics.NewMetrics()),
 	}
 }
 
-// Start starts the gRPC server and HTTP gateway
+// Handle registers an additional HTTP handler served alongside the gateway.
+// Requests are recorded in the HTTP metrics labelled with pattern.
+func (s *HTTPServer) Handle(pattern string, handler http.Handler) {
+	s.handlers[pattern] = s.metrics.Wrap(DisplayFormatEndpoints.Wrap(handler))
+}
+
+// Start starts the gRPC server and HTTP gateway, blocking until the gateway fails or is stopped
 func (s *HTTPServer) Start(grpcPort, gatewayPort string) error {
 	// Start gRPC server in background
 	go func() {
@@ -48,6 +67,14 @@ func (s *HTTPServer) Start(grpcPort, gatewayPort string) error {
 	mux.HandleFunc("/swagger/", swaggerHandler.ServeSwaggerUI)
 	mux.HandleFunc("/swagger.json", swaggerHandler.ServeSwaggerJSON)
 
+	// Expose Prometheus metrics
+	mux.Handle("/metrics", promhttp.Handler())
+
+	// Add additionally registered handlers
+	for pattern, handler := range s.handlers {
+		mux.Handle(pattern, handler)
+	}
+
 	// Add gRPC gateway handler
 	mux.Handle("/", s.grpcServer)
 
@@ -55,16 +82,48 @@ func (s *HTTPServer) Start(grpcPort, gatewayPort string) error {
 		Addr:    ":" + gatewayPort,
 		Handler: mux,
 	}
+	s.mu.Lock()
+	s.server = server
+	s.mu.Unlock()
 
-	return server.ListenAndServe()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
-// Stop gracefully stops the server
+// Stop gracefully stops the server: the HTTP gateway and the gRPC server stop accepting requests
+// and wait for those in flight until ctx is done, when the ones left are closed
 func (s *HTTPServer) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping HTTP server...")
 
+	// Graceful shutdown of the HTTP gateway
+	s.mu.Lock()
+	server := s.server
+	s.mu.Unlock()
+	var err error
+	if server != nil {
+		if err = server.Shutdown(ctx); err != nil {
+			server.Close()
+		}
+	}
+
 	// Graceful shutdown of gRPC server
-	s.grpcServer.GetGRPCServer().GracefulStop()
+	grpcServer := s.grpcServer.GetGRPCServer()
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+		err = errors.Join(err, ctx.Err())
+	}
+	if err != nil {
+		return err
+	}
 
 	s.logger.Info("HTTP server stopped successfully")
 	return nil
@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"time"
+
+	"go-clean-ddd-es-template/pkg/slo"
+)
+
+// RegisterServiceBudgets declares the latency and error budgets of the gRPC services and HTTP handlers.
+// Alert rules are generated from them with "observability gen".
+func RegisterServiceBudgets(registry *slo.Registry) {
+	// User service
+	registry.Register(
+		slo.Budget{Name: "user_create", Kind: slo.KindRPC, Target: "/user.UserService/CreateUser", Latency: 300 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "user_get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser", Latency: 100 * time.Millisecond, ErrorRate: 0.005},
+		slo.Budget{Name: "user_update", Kind: slo.KindRPC, Target: "/user.UserService/UpdateUser", Latency: 300 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "user_delete", Kind: slo.KindRPC, Target: "/user.UserService/DeleteUser", Latency: 300 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "user_list", Kind: slo.KindRPC, Target: "/user.UserService/ListUsers", Latency: 500 * time.Millisecond, ErrorRate: 0.01},
+	)
+
+	// Auth service: password hashing makes login and registration slower by design,
+	// token validation sits on the path of every authenticated request
+	registry.Register(
+		slo.Budget{Name: "auth_register", Kind: slo.KindRPC, Target: "/auth.AuthService/Register", Latency: 800 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_login", Kind: slo.KindRPC, Target: "/auth.AuthService/Login", Latency: 500 * time.Millisecond, ErrorRate: 0.01, Severity: "critical"},
+		slo.Budget{Name: "auth_validate_token", Kind: slo.KindRPC, Target: "/auth.AuthService/ValidateToken", Latency: 50 * time.Millisecond, ErrorRate: 0.001, Severity: "critical"},
+		slo.Budget{Name: "auth_refresh_token", Kind: slo.KindRPC, Target: "/auth.AuthService/RefreshToken", Latency: 200 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_revoke_refresh_token", Kind: slo.KindRPC, Target: "/auth.AuthService/RevokeRefreshToken", Latency: 200 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_request_magic_link", Kind: slo.KindRPC, Target: "/auth.AuthService/RequestMagicLink", Latency: 800 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_consume_magic_link", Kind: slo.KindRPC, Target: "/auth.AuthService/ConsumeMagicLink", Latency: 500 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_forgot_password", Kind: slo.KindRPC, Target: "/auth.AuthService/ForgotPassword", Latency: 800 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_reset_password", Kind: slo.KindRPC, Target: "/auth.AuthService/ResetPassword", Latency: 800 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_request_email_verification", Kind: slo.KindRPC, Target: "/auth.AuthService/RequestEmailVerification", Latency: 800 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_verify_email", Kind: slo.KindRPC, Target: "/auth.AuthService/VerifyEmail", Latency: 500 * time.Millisecond, ErrorRate: 0.01},
+		slo.Budget{Name: "auth_change_password", Kind: slo.KindRPC, Target: "/auth.AuthService/ChangePassword", Latency: 800 * time.Millisecond, ErrorRate: 0.01},
+	)
+
+	// HTTP handlers
+	registry.Register(
+		slo.Budget{Name: "avatar_upload", Kind: slo.KindHTTP, Target: AvatarUploadPattern, Latency: 2 * time.Second, Percentile: 0.95, ErrorRate: 0.01},
+		slo.Budget{Name: "dlq_export", Kind: slo.KindHTTP, Target: DLQExportPattern, ErrorRate: 0.05, For: 15 * time.Minute},
+		slo.Budget{Name: "dlq_import", Kind: slo.KindHTTP, Target: DLQImportPattern, ErrorRate: 0.05, For: 15 * time.Minute},
+	)
+}
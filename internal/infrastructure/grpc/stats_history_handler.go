@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/statshistory"
+)
+
+// StatsHistoryPattern is the route the history of the pipeline stats is served at
+const StatsHistoryPattern = "GET /admin/stats/history"
+
+// Page sizes of stats history listings
+const (
+	defaultStatsHistoryPageSize = 60
+	maxStatsHistoryPageSize     = 10000
+)
+
+// StatsHistoryHandler serves the snapshots of the consumer, publisher and DLQ stats to the admin
+// UI. Every request must carry the admin token as a bearer token.
+type StatsHistoryHandler struct {
+	store statshistory.Store
+	token string
+}
+
+// NewStatsHistoryHandler creates a new stats history admin handler
+func NewStatsHistoryHandler(store statshistory.Store, token string) *StatsHistoryHandler {
+	return &StatsHistoryHandler{
+		store: store,
+		token: token,
+	}
+}
+
+// ServeHTTP handles GET /admin/stats/history?prefix=&instance=&since=&until=&limit=, returning
+// the most recent snapshots selected, oldest first, with the stats named by prefix only, e.g.
+// prefix=consumer.lag
+func (h *StatsHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.token) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := parseIntParam(query.Get("limit"), defaultStatsHistoryPageSize)
+	if err != nil || limit <= 0 || limit > maxStatsHistoryPageSize {
+		writeHTTPError(w, errors.ValidationFailed("limit", "limit must be between 1 and "+strconv.Itoa(maxStatsHistoryPageSize)), "Failed to list stats history")
+		return
+	}
+	selection := statshistory.Query{
+		Prefix:   query.Get("prefix"),
+		Instance: query.Get("instance"),
+		Limit:    limit,
+	}
+	if selection.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("since", err.Error()), "Failed to list stats history")
+		return
+	}
+	if selection.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		writeHTTPError(w, errors.ValidationFailed("until", err.Error()), "Failed to list stats history")
+		return
+	}
+
+	snapshots, err := h.store.List(r.Context(), selection)
+	if err != nil {
+		writeHTTPError(w, err, "Failed to list stats history")
+		return
+	}
+	if snapshots == nil {
+		snapshots = []statshistory.Snapshot{}
+	}
+	writeJSON(w, http.StatusOK, snapshots)
+}
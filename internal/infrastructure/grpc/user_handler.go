@@ -3,20 +3,27 @@ package grpc
 import (
 	"context"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"go-clean-ddd-es-template/internal/application/commands"
 	"go-clean-ddd-es-template/internal/application/dto"
 	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/pkg/consistency"
+	"go-clean-ddd-es-template/pkg/errors"
 	"go-clean-ddd-es-template/pkg/tracing"
-	"go-clean-ddd-es-template/proto/user"
+	userv2 "go-clean-ddd-es-template/proto/user/v2"
 )
 
-// UserGRPCServer implements the gRPC UserService server
+// UserGRPCServer implements the gRPC UserService server of the latest API version. Earlier
+// versions are served by shims over it.
 type UserGRPCServer struct {
-	user.UnimplementedUserServiceServer
+	userv2.UnimplementedUserServiceServer
 	userService *services.UserService
 	tracer      *tracing.Tracer
+	approvals   ApprovalWorkflow
 }
 
 // NewUserGRPCServer creates a new user gRPC server
@@ -27,8 +34,13 @@ func NewUserGRPCServer(userService *services.UserService, tracer *tracing.Tracer
 	}
 }
 
-// CreateUser implements user.UserServiceServer.CreateUser
-func (s *UserGRPCServer) CreateUser(ctx context.Context, req *user.CreateUserRequest) (*user.CreateUserResponse, error) {
+// SetApprovals makes sensitive commands create an approval request instead of running
+func (s *UserGRPCServer) SetApprovals(approvals ApprovalWorkflow) {
+	s.approvals = approvals
+}
+
+// CreateUser implements userv2.UserServiceServer.CreateUser
+func (s *UserGRPCServer) CreateUser(ctx context.Context, req *userv2.CreateUserRequest) (*userv2.CreateUserResponse, error) {
 	ctx, span := s.tracer.StartSpan(ctx, "UserGRPCServer.CreateUser")
 	defer span.End()
 
@@ -38,16 +50,17 @@ func (s *UserGRPCServer) CreateUser(ctx context.Context, req *user.CreateUserReq
 	}
 
 	if err := dto.ValidateRequest(cmd); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+		return nil, validationError(ctx, err)
 	}
 
 	response, err := s.userService.CreateUser(ctx, cmd)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
+		return nil, commandError(err, "failed to create user")
 	}
+	setConsistencyToken(ctx, response.ConsistencyToken)
 
-	return &user.CreateUserResponse{
-		User: &user.User{
+	return &userv2.CreateUserResponse{
+		User: &userv2.User{
 			Id:        response.UserID,
 			Email:     response.Email,
 			Name:      response.Name,
@@ -57,26 +70,35 @@ func (s *UserGRPCServer) CreateUser(ctx context.Context, req *user.CreateUserReq
 	}, nil
 }
 
-// GetUser implements user.UserServiceServer.GetUser
-func (s *UserGRPCServer) GetUser(ctx context.Context, req *user.GetUserRequest) (*user.GetUserResponse, error) {
+// GetUser implements userv2.UserServiceServer.GetUser
+func (s *UserGRPCServer) GetUser(ctx context.Context, req *userv2.GetUserRequest) (*userv2.GetUserResponse, error) {
 	ctx, span := s.tracer.StartSpan(ctx, "UserGRPCServer.GetUser")
 	defer span.End()
 
 	query := dto.GetUserQuery{
-		UserID: req.Id,
+		UserID:           req.Id,
+		ConsistencyToken: incomingConsistencyToken(ctx),
+	}
+	if query.ConsistencyToken != "" {
+		if _, err := consistency.Parse(query.ConsistencyToken); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s: %v", consistency.Header, err)
+		}
 	}
 
 	if err := dto.ValidateRequest(query); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+		return nil, validationError(ctx, err)
 	}
 
 	response, err := s.userService.GetUser(ctx, query)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
 	}
+	if response.Stale {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(staleMetadataKey, response.StaleSource))
+	}
 
-	return &user.GetUserResponse{
-		User: &user.User{
+	return &userv2.GetUserResponse{
+		User: &userv2.User{
 			Id:        response.UserID,
 			Email:     response.Email,
 			Name:      response.Name,
@@ -86,18 +108,24 @@ func (s *UserGRPCServer) GetUser(ctx context.Context, req *user.GetUserRequest)
 	}, nil
 }
 
-// ListUsers implements user.UserServiceServer.ListUsers
-func (s *UserGRPCServer) ListUsers(ctx context.Context, req *user.ListUsersRequest) (*user.ListUsersResponse, error) {
+// ListUsers implements userv2.UserServiceServer.ListUsers
+func (s *UserGRPCServer) ListUsers(ctx context.Context, req *userv2.ListUsersRequest) (*userv2.ListUsersResponse, error) {
 	ctx, span := s.tracer.StartSpan(ctx, "UserGRPCServer.ListUsers")
 	defer span.End()
 
 	query := dto.ListUsersQuery{
-		Page:     1,
-		PageSize: 10,
+		Page:     int(req.Page),
+		PageSize: int(req.PageSize),
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 10
 	}
 
 	if err := dto.ValidateRequest(query); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+		return nil, validationError(ctx, err)
 	}
 
 	response, err := s.userService.ListUsers(ctx, query)
@@ -105,9 +133,9 @@ func (s *UserGRPCServer) ListUsers(ctx context.Context, req *user.ListUsersReque
 		return nil, status.Errorf(codes.Internal, "failed to list users: %v", err)
 	}
 
-	users := make([]*user.User, len(response.Users))
+	users := make([]*userv2.User, len(response.Users))
 	for i, u := range response.Users {
-		users[i] = &user.User{
+		users[i] = &userv2.User{
 			Id:        u.UserID,
 			Email:     u.Email,
 			Name:      u.Name,
@@ -116,13 +144,16 @@ func (s *UserGRPCServer) ListUsers(ctx context.Context, req *user.ListUsersReque
 		}
 	}
 
-	return &user.ListUsersResponse{
-		Users: users,
+	return &userv2.ListUsersResponse{
+		Users:    users,
+		Total:    response.Total,
+		Page:     int32(response.Page),
+		PageSize: int32(response.PageSize),
 	}, nil
 }
 
-// UpdateUser implements user.UserServiceServer.UpdateUser
-func (s *UserGRPCServer) UpdateUser(ctx context.Context, req *user.UpdateUserRequest) (*user.UpdateUserResponse, error) {
+// UpdateUser implements userv2.UserServiceServer.UpdateUser
+func (s *UserGRPCServer) UpdateUser(ctx context.Context, req *userv2.UpdateUserRequest) (*userv2.UpdateUserResponse, error) {
 	ctx, span := s.tracer.StartSpan(ctx, "UserGRPCServer.UpdateUser")
 	defer span.End()
 
@@ -132,16 +163,17 @@ func (s *UserGRPCServer) UpdateUser(ctx context.Context, req *user.UpdateUserReq
 	}
 
 	if err := dto.ValidateRequest(cmd); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+		return nil, validationError(ctx, err)
 	}
 
 	response, err := s.userService.UpdateUser(ctx, cmd)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
+		return nil, commandError(err, "failed to update user")
 	}
+	setConsistencyToken(ctx, response.ConsistencyToken)
 
-	return &user.UpdateUserResponse{
-		User: &user.User{
+	return &userv2.UpdateUserResponse{
+		User: &userv2.User{
 			Id:        response.UserID,
 			Name:      response.Name,
 			UpdatedAt: response.UpdatedAt,
@@ -149,8 +181,8 @@ func (s *UserGRPCServer) UpdateUser(ctx context.Context, req *user.UpdateUserReq
 	}, nil
 }
 
-// DeleteUser implements user.UserServiceServer.DeleteUser
-func (s *UserGRPCServer) DeleteUser(ctx context.Context, req *user.DeleteUserRequest) (*user.DeleteUserResponse, error) {
+// DeleteUser implements userv2.UserServiceServer.DeleteUser
+func (s *UserGRPCServer) DeleteUser(ctx context.Context, req *userv2.DeleteUserRequest) (*userv2.DeleteUserResponse, error) {
 	ctx, span := s.tracer.StartSpan(ctx, "UserGRPCServer.DeleteUser")
 	defer span.End()
 
@@ -159,15 +191,124 @@ func (s *UserGRPCServer) DeleteUser(ctx context.Context, req *user.DeleteUserReq
 	}
 
 	if err := dto.ValidateRequest(cmd); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+		return nil, validationError(ctx, err)
+	}
+
+	// Deleting a user needs the approval of a second admin
+	if s.approvals != nil && s.approvals.Requires(commands.DeleteUserCommandName) {
+		requestedBy, _ := ctx.Value("user_id").(string)
+		request, err := s.approvals.Submit(ctx, commands.DeleteUserCommandName, map[string]string{"user_id": req.Id}, requestedBy, "")
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to request approval: %v", err)
+		}
+		return nil, approvalRequiredError(request)
 	}
 
 	response, err := s.userService.DeleteUser(ctx, cmd)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to delete user: %v", err)
+		return nil, commandError(err, "failed to delete user")
 	}
+	setConsistencyToken(ctx, response.ConsistencyToken)
 
-	return &user.DeleteUserResponse{
+	return &userv2.DeleteUserResponse{
 		Success: response.Success,
 	}, nil
 }
+
+// GetUserPreferences implements userv2.UserServiceServer.GetUserPreferences
+func (s *UserGRPCServer) GetUserPreferences(ctx context.Context, req *userv2.GetUserPreferencesRequest) (*userv2.GetUserPreferencesResponse, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "UserGRPCServer.GetUserPreferences")
+	defer span.End()
+
+	query := dto.GetUserPreferencesQuery{
+		UserID: req.Id,
+	}
+
+	if err := dto.ValidateRequest(query); err != nil {
+		return nil, validationError(ctx, err)
+	}
+
+	response, err := s.userService.GetUserPreferences(ctx, query)
+	if err != nil {
+		if code := errors.CodeOf(err, ""); code == errors.ErrUserNotFound || code == errors.ErrUserDeleted {
+			return nil, status.Errorf(codes.NotFound, "failed to get user preferences: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user preferences: %v", err)
+	}
+
+	return &userv2.GetUserPreferencesResponse{
+		Preferences: preferencesMessage(*response),
+	}, nil
+}
+
+// UpdateUserPreferences implements userv2.UserServiceServer.UpdateUserPreferences
+func (s *UserGRPCServer) UpdateUserPreferences(ctx context.Context, req *userv2.UpdateUserPreferencesRequest) (*userv2.UpdateUserPreferencesResponse, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "UserGRPCServer.UpdateUserPreferences")
+	defer span.End()
+
+	cmd := dto.UpdateUserPreferencesCommand{
+		UserID:             req.Id,
+		Locale:             req.Locale,
+		Theme:              req.Theme,
+		EmailNotifications: req.EmailNotifications,
+		PushNotifications:  req.PushNotifications,
+		NotificationDigest: req.NotificationDigest,
+	}
+
+	if err := dto.ValidateRequest(cmd); err != nil {
+		return nil, validationError(ctx, err)
+	}
+
+	response, err := s.userService.UpdateUserPreferences(ctx, cmd)
+	if err != nil {
+		// The locale is only known to be valid once the domain parsed it
+		if errors.CodeOf(err, "") == errors.ErrValidationFailed {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to update user preferences: %v", err)
+		}
+		return nil, commandError(err, "failed to update user preferences")
+	}
+	setConsistencyToken(ctx, response.ConsistencyToken)
+
+	return &userv2.UpdateUserPreferencesResponse{
+		Preferences: preferencesMessage(response.UserPreferences),
+	}, nil
+}
+
+// preferencesMessage converts the preferences DTO to its message
+func preferencesMessage(preferences dto.UserPreferences) *userv2.Preferences {
+	return &userv2.Preferences{
+		Locale: preferences.Locale,
+		Theme:  preferences.Theme,
+		Notifications: &userv2.NotificationPreferences{
+			Email:  preferences.Notifications.Email,
+			Push:   preferences.Notifications.Push,
+			Digest: preferences.Notifications.Digest,
+		},
+		UpdatedAt: preferences.UpdatedAt,
+	}
+}
+
+// StaleHeader flags responses served from a fallback while the read store is down; its value is
+// the fallback, "write_db" or "cache"
+const StaleHeader = "X-Stale-Data"
+
+// staleMetadataKey is the gRPC metadata key of StaleHeader
+const staleMetadataKey = "x-stale-data"
+
+// setConsistencyToken returns the consistency token of a command as response header metadata,
+// which clients pass back to queries to read their own writes
+func setConsistencyToken(ctx context.Context, token string) {
+	if token == "" {
+		return
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(consistency.MetadataKey, token))
+}
+
+// incomingConsistencyToken returns the consistency token sent with a query, if any
+func incomingConsistencyToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(consistency.MetadataKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/proto/user"
+	userv2 "go-clean-ddd-es-template/proto/user/v2"
+)
+
+// userV1PageSize is the fixed page size of v1 ListUsers, which has no pagination
+const userV1PageSize = 10
+
+// UserV1Shim serves the v1 UserService by translating its requests and responses to the v2
+// server, so v1 clients keep working while the API evolves in v2
+type UserV1Shim struct {
+	user.UnimplementedUserServiceServer
+	v2 userv2.UserServiceServer
+}
+
+// NewUserV1Shim creates a v1 UserService server delegating to a v2 server
+func NewUserV1Shim(v2 userv2.UserServiceServer) *UserV1Shim {
+	return &UserV1Shim{v2: v2}
+}
+
+// CreateUser implements user.UserServiceServer.CreateUser
+func (s *UserV1Shim) CreateUser(ctx context.Context, req *user.CreateUserRequest) (*user.CreateUserResponse, error) {
+	resp, err := s.v2.CreateUser(ctx, &userv2.CreateUserRequest{Email: req.Email, Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+	return &user.CreateUserResponse{User: userToV1(resp.User)}, nil
+}
+
+// GetUser implements user.UserServiceServer.GetUser
+func (s *UserV1Shim) GetUser(ctx context.Context, req *user.GetUserRequest) (*user.GetUserResponse, error) {
+	resp, err := s.v2.GetUser(ctx, &userv2.GetUserRequest{Id: req.Id})
+	if err != nil {
+		return nil, err
+	}
+	return &user.GetUserResponse{User: userToV1(resp.User)}, nil
+}
+
+// ListUsers implements user.UserServiceServer.ListUsers. v1 returns the first page only.
+func (s *UserV1Shim) ListUsers(ctx context.Context, req *user.ListUsersRequest) (*user.ListUsersResponse, error) {
+	resp, err := s.v2.ListUsers(ctx, &userv2.ListUsersRequest{Page: 1, PageSize: userV1PageSize})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*user.User, len(resp.Users))
+	for i, u := range resp.Users {
+		users[i] = userToV1(u)
+	}
+	return &user.ListUsersResponse{Users: users}, nil
+}
+
+// UpdateUser implements user.UserServiceServer.UpdateUser. The email of v1 requests was never
+// updatable and is ignored.
+func (s *UserV1Shim) UpdateUser(ctx context.Context, req *user.UpdateUserRequest) (*user.UpdateUserResponse, error) {
+	resp, err := s.v2.UpdateUser(ctx, &userv2.UpdateUserRequest{Id: req.Id, Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+	return &user.UpdateUserResponse{User: userToV1(resp.User)}, nil
+}
+
+// DeleteUser implements user.UserServiceServer.DeleteUser
+func (s *UserV1Shim) DeleteUser(ctx context.Context, req *user.DeleteUserRequest) (*user.DeleteUserResponse, error) {
+	resp, err := s.v2.DeleteUser(ctx, &userv2.DeleteUserRequest{Id: req.Id})
+	if err != nil {
+		return nil, err
+	}
+	return &user.DeleteUserResponse{Success: resp.Success}, nil
+}
+
+// userToV1 converts a v2 user to its v1 message
+func userToV1(u *userv2.User) *user.User {
+	if u == nil {
+		return nil
+	}
+	return &user.User{
+		Id:        u.Id,
+		Email:     u.Email,
+		Name:      u.Name,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+
+	"go-clean-ddd-es-template/internal/application/dto"
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/i18n"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// validationError converts a failed request validation to an InvalidArgument status. Field
+// violations are returned as google.rpc.BadRequest details, translated into the locale of the
+// caller's Accept-Language metadata, next to the localized message of the whole error.
+func validationError(ctx context.Context, err error) error {
+	var violations dto.ValidationErrors
+	if !stderrors.As(err, &violations) {
+		return status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
+	}
+
+	translator := i18n.GetGlobalTranslator()
+	locale := requestLocale(ctx, translator)
+
+	badRequest := &errdetails.BadRequest{}
+	descriptions := make([]string, len(violations))
+	for i, violation := range violations {
+		descriptions[i] = translateViolation(translator, violation, locale)
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: descriptions[i],
+		})
+	}
+	info := &errdetails.ErrorInfo{
+		Reason: string(errors.ErrValidationFailed),
+		Domain: errorDomain,
+	}
+	message := &errdetails.LocalizedMessage{
+		Locale:  locale,
+		Message: strings.Join(descriptions, "; "),
+	}
+
+	st, detailErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(info, badRequest, message)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
+
+// translateViolation describes a field violation in locale, e.g. VALIDATION_MIN_LENGTH for a
+// string failing min=2. Rules without a translation use the generic VALIDATION_FAILED message,
+// or the validator's message when no catalog is loaded.
+func translateViolation(translator *i18n.Translator, violation dto.ValidationError, locale string) string {
+	key := "VALIDATION_" + strings.ToUpper(violation.Rule)
+	if violation.Length && (violation.Rule == "min" || violation.Rule == "max") {
+		key += "_LENGTH"
+	}
+	if description := translator.Translate(key, locale, violation.Field, violation.Param); description != key {
+		return description
+	}
+	if description := translator.Translate(string(errors.ErrValidationFailed), locale, violation.Field, violation.Rule); description != string(errors.ErrValidationFailed) {
+		return description
+	}
+	return violation.Message
+}
+
+// requestLocale returns the locale of the caller's Accept-Language metadata, which the gateway
+// forwards with a prefix, or the translator's default locale
+func requestLocale(ctx context.Context, translator *i18n.Translator) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range []string{"accept-language", "grpcgateway-accept-language"} {
+		if values := md.Get(key); len(values) > 0 {
+			if locale := i18n.MatchLocale(values[0]); locale != "" && translator.IsLocaleSupported(locale) {
+				return locale
+			}
+		}
+	}
+	return translator.DefaultLocale()
+}
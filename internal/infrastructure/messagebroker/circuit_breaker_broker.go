@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/kafka"
 	"go-clean-ddd-es-template/pkg/resilience"
 
 	"github.com/IBM/sarama"
@@ -20,9 +21,12 @@ type CircuitBreakerMessageBroker struct {
 
 // NewCircuitBreakerMessageBroker creates a new circuit breaker message broker
 func NewCircuitBreakerMessageBroker(broker MessageBroker, config *config.MessageBrokerConfig, cbConfig resilience.CircuitBreakerConfig) *CircuitBreakerMessageBroker {
+	circuitBreaker := resilience.NewCircuitBreaker(cbConfig)
+	resilience.DefaultRegistry().Register("message_broker", circuitBreaker)
+
 	return &CircuitBreakerMessageBroker{
 		broker:         broker,
-		circuitBreaker: resilience.NewCircuitBreaker(cbConfig),
+		circuitBreaker: circuitBreaker,
 		config:         config,
 	}
 }
@@ -51,6 +55,22 @@ func (cb *CircuitBreakerMessageBroker) Publish(topic string, message []byte) err
 	return err
 }
 
+// PublishWithKey wraps a keyed publish with circuit breaker; brokers without key support publish without it
+func (cb *CircuitBreakerMessageBroker) PublishWithKey(topic, key string, message []byte) error {
+	_, err := cb.circuitBreaker.ExecuteWithResult(context.Background(), func() (interface{}, error) {
+		return nil, PublishKeyed(cb.broker, topic, key, message)
+	})
+	return err
+}
+
+// PublishWithHeaders wraps a publish with headers with circuit breaker; brokers without header support publish without them
+func (cb *CircuitBreakerMessageBroker) PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error {
+	_, err := cb.circuitBreaker.ExecuteWithResult(context.Background(), func() (interface{}, error) {
+		return nil, PublishWithHeaders(cb.broker, topic, key, message, headers)
+	})
+	return err
+}
+
 // Subscribe wraps broker.Subscribe with circuit breaker
 func (cb *CircuitBreakerMessageBroker) Subscribe(topic string, handler func([]byte)) error {
 	_, err := cb.circuitBreaker.ExecuteWithResult(context.Background(), func() (interface{}, error) {
@@ -0,0 +1,147 @@
+package messagebroker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
+	"go-clean-ddd-es-template/pkg/kafka"
+)
+
+// DelayedPublisher is implemented by brokers delaying deliveries natively
+type DelayedPublisher interface {
+	PublishDelayed(topic, key string, message []byte, headers kafka.Headers, delay time.Duration) error
+}
+
+// DelayedMessage is a message scheduled for publishing at DueAt
+type DelayedMessage struct {
+	ID      string
+	Topic   string
+	Key     string
+	Payload []byte
+	Headers kafka.Headers
+	DueAt   time.Time
+}
+
+// DelayedMessageStore keeps the messages scheduled by a Delayer until the DelayScheduler
+// publishes them. See PostgresDelayedMessageStore.
+type DelayedMessageStore interface {
+	// Schedule stores a message until it is due
+	Schedule(ctx context.Context, message *DelayedMessage) error
+	// PublishDue hands up to limit messages due at now to publish, oldest first, removing those
+	// published. It returns the number of messages published.
+	PublishDue(ctx context.Context, now time.Time, limit int, publish func(message *DelayedMessage) error) (int, error)
+}
+
+// Delayer publishes messages after a delay whatever the broker: brokers delaying deliveries
+// natively, RabbitMQ and Redis, are handed the delay; with other brokers such as Kafka the
+// message is kept in the store until the DelayScheduler republishes it. Without a store, messages
+// are held in memory and lost when the service stops before they are due.
+type Delayer struct {
+	broker MessageBroker
+	store  DelayedMessageStore
+	clock  clock.Clock
+}
+
+// NewDelayer creates a delayer publishing to broker; store may be nil
+func NewDelayer(broker MessageBroker, store DelayedMessageStore, clk clock.Clock) *Delayer {
+	return &Delayer{
+		broker: broker,
+		store:  store,
+		clock:  clock.OrDefault(clk),
+	}
+}
+
+// Deliver publishes a message to topic once delay elapsed, right away when it is not positive
+func (d *Delayer) Deliver(ctx context.Context, topic, key string, message []byte, headers kafka.Headers, delay time.Duration) error {
+	if delay <= 0 {
+		return PublishWithHeaders(d.broker, topic, key, message, headers)
+	}
+	if publisher, ok := d.broker.(DelayedPublisher); ok {
+		return publisher.PublishDelayed(topic, key, message, headers, delay)
+	}
+
+	dueAt := d.clock.Now().Add(delay)
+	if d.store != nil {
+		err := d.store.Schedule(ctx, &DelayedMessage{
+			ID:      id.NewUUIDv7(),
+			Topic:   topic,
+			Key:     key,
+			Payload: message,
+			Headers: headers,
+			DueAt:   dueAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to schedule message to topic %s: %w", topic, err)
+		}
+		return nil
+	}
+
+	due := d.clock.After(delay)
+	go func() {
+		<-due
+		if err := PublishWithHeaders(d.broker, topic, key, message, headers); err != nil {
+			log.Printf("[ERROR] Failed to publish message delayed until %s to topic %s: %v", dueAt.Format(time.RFC3339), topic, err)
+		}
+	}()
+	return nil
+}
+
+// DelaySchedulerLogger logs the failures of the delay scheduler
+type DelaySchedulerLogger interface {
+	Error(msg string, args ...interface{})
+}
+
+// DelayScheduler publishes the messages of a DelayedMessageStore once due, for brokers without
+// native delays
+type DelayScheduler struct {
+	broker    MessageBroker
+	store     DelayedMessageStore
+	batchSize int
+	interval  time.Duration
+	clock     clock.Clock
+	logger    DelaySchedulerLogger
+}
+
+// NewDelayScheduler creates a scheduler publishing up to batchSize due messages at a time, looking
+// for due messages every interval
+func NewDelayScheduler(broker MessageBroker, store DelayedMessageStore, batchSize int, interval time.Duration, clk clock.Clock, logger DelaySchedulerLogger) *DelayScheduler {
+	return &DelayScheduler{
+		broker:    broker,
+		store:     store,
+		batchSize: batchSize,
+		interval:  interval,
+		clock:     clock.OrDefault(clk),
+		logger:    logger,
+	}
+}
+
+// Run publishes due messages until ctx is done. Batches are published back to back while
+// messages are due, and failed batches are retried on the next poll.
+func (s *DelayScheduler) Run(ctx context.Context) error {
+	for {
+		published, err := s.PublishDue(ctx)
+		if err != nil && s.logger != nil {
+			s.logger.Error("Failed to publish delayed messages: %v", err)
+		}
+		if err == nil && published == s.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.clock.After(s.interval):
+		}
+	}
+}
+
+// PublishDue publishes a batch of due messages, returning the number published
+func (s *DelayScheduler) PublishDue(ctx context.Context) (int, error) {
+	return s.store.PublishDue(ctx, s.clock.Now(), s.batchSize, func(message *DelayedMessage) error {
+		return PublishWithHeaders(s.broker, message.Topic, message.Key, message.Payload, message.Headers)
+	})
+}
@@ -0,0 +1,116 @@
+package messagebroker_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker/mocks"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/kafka"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publishingBroker records the topics of publishes with headers
+type publishingBroker struct {
+	*mocks.MockMessageBroker
+	mu     sync.Mutex
+	topics []string
+}
+
+func (b *publishingBroker) PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topics = append(b.topics, topic)
+	return nil
+}
+
+func (b *publishingBroker) published() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.topics...)
+}
+
+// delayingBroker delays deliveries natively
+type delayingBroker struct {
+	publishingBroker
+	delays []time.Duration
+}
+
+func (b *delayingBroker) PublishDelayed(topic, key string, message []byte, headers kafka.Headers, delay time.Duration) error {
+	b.delays = append(b.delays, delay)
+	return nil
+}
+
+// memoryDelayedStore publishes its due messages in order, like PostgresDelayedMessageStore
+type memoryDelayedStore struct {
+	messages []*messagebroker.DelayedMessage
+}
+
+func (s *memoryDelayedStore) Schedule(ctx context.Context, message *messagebroker.DelayedMessage) error {
+	s.messages = append(s.messages, message)
+	sort.SliceStable(s.messages, func(i, j int) bool { return s.messages[i].DueAt.Before(s.messages[j].DueAt) })
+	return nil
+}
+
+func (s *memoryDelayedStore) PublishDue(ctx context.Context, now time.Time, limit int, publish func(message *messagebroker.DelayedMessage) error) (int, error) {
+	published := 0
+	for len(s.messages) > 0 && published < limit && !s.messages[0].DueAt.After(now) {
+		if err := publish(s.messages[0]); err != nil {
+			return published, err
+		}
+		s.messages = s.messages[1:]
+		published++
+	}
+	return published, nil
+}
+
+func TestDelayer_SchedulesOnBrokersWithoutNativeDelays(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	broker := &publishingBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}
+	store := &memoryDelayedStore{}
+	delayer := messagebroker.NewDelayer(broker, store, clk)
+	scheduler := messagebroker.NewDelayScheduler(broker, store, 10, time.Second, clk, nil)
+
+	require.NoError(t, delayer.Deliver(ctx, "saga.timeouts", "", []byte(`{}`), nil, 10*time.Minute))
+	require.NoError(t, delayer.Deliver(ctx, "user-events.retry", "", []byte(`{}`), nil, time.Minute))
+	require.NoError(t, delayer.Deliver(ctx, "user-events", "", []byte(`{}`), nil, 0))
+	assert.Equal(t, []string{"user-events"}, broker.published(), "messages without delay are published right away")
+
+	clk.Advance(time.Minute)
+	published, err := scheduler.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	clk.Advance(9 * time.Minute)
+	_, err = scheduler.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-events", "user-events.retry", "saga.timeouts"}, broker.published())
+}
+
+func TestDelayer_UsesNativeDelays(t *testing.T) {
+	broker := &delayingBroker{publishingBroker: publishingBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}}
+	delayer := messagebroker.NewDelayer(broker, &memoryDelayedStore{}, nil)
+
+	require.NoError(t, delayer.Deliver(context.Background(), "saga.timeouts", "", []byte(`{}`), nil, 10*time.Minute))
+	assert.Equal(t, []time.Duration{10 * time.Minute}, broker.delays)
+	assert.Empty(t, broker.published())
+}
+
+func TestDelayer_HoldsMessagesInMemoryWithoutStore(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	broker := &publishingBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}
+	delayer := messagebroker.NewDelayer(broker, nil, clk)
+
+	require.NoError(t, delayer.Deliver(context.Background(), "saga.timeouts", "", []byte(`{}`), nil, time.Minute))
+	assert.Empty(t, broker.published())
+
+	clk.Advance(time.Minute)
+	assert.Eventually(t, func() bool { return len(broker.published()) == 1 }, time.Second, time.Millisecond)
+}
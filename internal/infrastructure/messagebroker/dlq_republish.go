@@ -0,0 +1,73 @@
+package messagebroker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// DLQRepublisher retries events from the dead letter queue by republishing them to the topic
+// they were consumed from. The republished message keeps the headers it failed with and is
+// stamped with its retry count, so consumers can tell how often and why it failed before.
+type DLQRepublisher struct {
+	broker MessageBroker
+}
+
+// NewDLQRepublisher creates a dead letter queue republisher publishing to broker
+func NewDLQRepublisher(broker MessageBroker) *DLQRepublisher {
+	return &DLQRepublisher{broker: broker}
+}
+
+// HandleRetry republishes a failed event added by the worker pool consumer
+func (r *DLQRepublisher) HandleRetry(ctx context.Context, event *resilience.FailedEvent) error {
+	message, _ := event.EventData["message"].(string)
+	if message == "" {
+		return fmt.Errorf("dead letter event %s has no message to republish", event.ID)
+	}
+
+	headers := RepublishHeaders(event)
+	topic := headers.OriginalTopic()
+	if topic == "" {
+		return fmt.Errorf("dead letter event %s has no topic to republish to", event.ID)
+	}
+
+	if err := PublishWithHeaders(r.broker, topic, headers.TenantID(), []byte(message), headers); err != nil {
+		return fmt.Errorf("failed to republish dead letter event %s to %s: %w", event.ID, topic, err)
+	}
+	return nil
+}
+
+// RepublishHeaders returns the headers a dead letter event is republished with: the headers it
+// failed with, counting each retry from the dead letter queue as a redelivery. Events stored
+// without headers get them from their metadata.
+func RepublishHeaders(event *resilience.FailedEvent) kafka.Headers {
+	headers := kafka.Headers{}
+	if encoded := event.Metadata["headers"]; encoded != "" {
+		_ = json.Unmarshal([]byte(encoded), &headers)
+	}
+
+	if headers.OriginalTopic() == "" {
+		topic, _ := event.EventData["topic"].(string)
+		if topic == "" {
+			topic = event.Topic
+		}
+		headers.Set(kafka.HeaderOriginalTopic, topic)
+	}
+	if headers.LastError() == "" {
+		firstFailure, err := time.Parse(time.RFC3339Nano, event.Metadata["first_failure"])
+		if err != nil {
+			firstFailure = event.Timestamp
+		}
+		headers.RecordFailure(event.Metadata["last_error"], event.Metadata["consumer_group"], firstFailure)
+	}
+
+	if _, ok := headers[kafka.HeaderRetryCount]; !ok {
+		headers.Set(kafka.HeaderRetryCount, event.Metadata["retry_count"])
+	}
+	headers.SetRetryCount(headers.RetryCount() + event.Attempts)
+	return headers
+}
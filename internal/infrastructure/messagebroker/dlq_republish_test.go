@@ -0,0 +1,58 @@
+package messagebroker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker/mocks"
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/resilience"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDLQRepublisher_HandleRetry(t *testing.T) {
+	broker := &headerBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}
+	republisher := messagebroker.NewDLQRepublisher(broker)
+
+	event := &resilience.FailedEvent{
+		ID:        "dlq-1",
+		EventData: map[string]interface{}{"topic": "user-events", "message": `{}`},
+		Attempts:  1,
+		Metadata: map[string]string{
+			"headers": `{"original-topic":"user-events","tenant-id":"acme","retry-count":"3",` +
+				`"first-failure":"2024-01-02T15:04:05Z","last-error":"DATABASE_ERROR","consumer-group":"projections"}`,
+		},
+	}
+
+	// The message is republished to its topic with the headers it failed with
+	require.NoError(t, republisher.HandleRetry(context.Background(), event))
+	assert.Equal(t, "user-events", broker.topic)
+	assert.Equal(t, "acme", broker.key)
+	assert.Equal(t, 4, broker.headers.RetryCount())
+	assert.Equal(t, "DATABASE_ERROR", broker.headers.LastError())
+	assert.Equal(t, "projections", broker.headers.ConsumerGroup())
+	assert.Equal(t, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), broker.headers.FirstFailure())
+
+	assert.Error(t, republisher.HandleRetry(context.Background(), &resilience.FailedEvent{ID: "dlq-2"}))
+}
+
+func TestRepublishHeaders_FromMetadata(t *testing.T) {
+	failedAt := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	headers := messagebroker.RepublishHeaders(&resilience.FailedEvent{
+		EventData: map[string]interface{}{"topic": "user-events"},
+		Timestamp: failedAt,
+		Attempts:  2,
+		Metadata:  map[string]string{"retry_count": "1", "last_error": "TIMEOUT", "consumer_group": "projections"},
+	})
+
+	assert.Equal(t, "user-events", headers.OriginalTopic())
+	assert.Equal(t, 3, headers.RetryCount())
+	assert.Equal(t, "TIMEOUT", headers.LastError())
+	assert.Equal(t, "projections", headers.ConsumerGroup())
+	assert.Equal(t, failedAt, headers.FirstFailure())
+	assert.Empty(t, headers.Get(kafka.HeaderTenantID))
+}
@@ -0,0 +1,35 @@
+package messagebroker
+
+import (
+	"encoding/json"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/eventcatalog"
+)
+
+// NewEventCatalog creates the catalog of the domain events, documenting each with the topic the
+// publishers route it to under the configuration and the schema its payloads are validated with
+func NewEventCatalog(cfg config.MessageBrokerConfig) *eventcatalog.Catalog {
+	catalog := eventcatalog.NewCatalog()
+	for _, event := range domainEvents {
+		catalog.Register(eventcatalog.Event{
+			Type:        event.eventType,
+			Version:     event.version,
+			Description: event.description,
+			Topic:       EventTopic(cfg, event.eventType),
+			Schema:      json.RawMessage(event.schema),
+			Producers:   event.producers,
+			Consumers:   event.consumers,
+		})
+	}
+	return catalog
+}
+
+// EventTopic returns the topic events of a type are published to: the topic mapped to the type,
+// or the type itself
+func EventTopic(cfg config.MessageBrokerConfig, eventType string) string {
+	if topic, ok := cfg.Topics[eventType]; ok {
+		return topic
+	}
+	return eventType
+}
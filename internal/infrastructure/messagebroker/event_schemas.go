@@ -0,0 +1,202 @@
+package messagebroker
+
+import (
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/schema"
+)
+
+// userProjections are the consumers of the user events, projecting them into the read model
+var userProjections = []string{"consumers.UserEventHandler", "consumers.UserSummaryProjector", "consumers.UserChangeLogProjector"}
+
+// countedUserProjections are the consumers of the events of created and deleted users, also counted
+// by the user counters
+var countedUserProjections = []string{"consumers.UserEventHandler", "consumers.UserSummaryProjector", "consumers.UserCountProjector", "consumers.UserChangeLogProjector"}
+
+// domainEvents declare the domain events: the built-in schemas of their payloads by event type
+// and version, and their documentation in the event catalog
+var domainEvents = []struct {
+	eventType   string
+	version     int
+	schema      string
+	description string
+	producers   []string
+	consumers   []string
+}{
+	{"user.created", 1, `{
+		"type": "object",
+		"required": ["user_id", "email", "name", "created_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"email": {"type": "string", "format": "email"},
+			"name": {"type": "string", "minLength": 1},
+			"created_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A user was created, by an admin or by signing up",
+		[]string{"commands.UserCreateCommandHandler", "commands.AuthRegisterCommandHandler"}, countedUserProjections},
+	{"user.updated", 1, `{
+		"type": "object",
+		"required": ["user_id", "name", "updated_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"name": {"type": "string", "minLength": 1},
+			"updated_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "The profile of a user was updated",
+		[]string{"commands.UserUpdateCommandHandler"}, userProjections},
+	{"user.deleted", 1, `{
+		"type": "object",
+		"required": ["user_id", "deleted_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"deleted_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A user was deleted",
+		[]string{"commands.UserDeleteCommandHandler"}, countedUserProjections},
+	{"user.preferences_updated", 1, `{
+		"type": "object",
+		"required": ["user_id", "preferences", "updated_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"preferences": {
+				"type": "object",
+				"properties": {
+					"locale": {"type": "string", "minLength": 1},
+					"theme": {"type": "string", "enum": ["light", "dark", "system"]},
+					"notifications": {
+						"type": "object",
+						"properties": {
+							"email": {"type": "boolean"},
+							"push": {"type": "boolean"},
+							"digest": {"type": "string", "enum": ["never", "daily", "weekly"]}
+						}
+					}
+				}
+			},
+			"updated_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A user changed their preferences; carries every setting the user chose, unset ones are left to the defaults",
+		[]string{"commands.UserUpdatePreferencesCommandHandler"}, []string{"consumers.UserEventHandler"}},
+	{"user.email_verified", 1, `{
+		"type": "object",
+		"required": ["user_id", "email", "verified_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"email": {"type": "string", "format": "email"},
+			"verified_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A user verified their email with a verification link",
+		[]string{"commands.AuthEmailVerificationCommandHandler"}, []string{"consumers.UserEventHandler"}},
+	{"user.avatar_updated", 1, `{
+		"type": "object",
+		"required": ["user_id", "avatar"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"avatar": {
+				"type": "object",
+				"required": ["key", "content_type", "size", "uploaded_at"],
+				"properties": {
+					"key": {"type": "string", "minLength": 1},
+					"content_type": {"type": "string", "enum": ["image/png", "image/jpeg", "image/gif", "image/webp"]},
+					"size": {"type": "integer", "minimum": 0},
+					"uploaded_at": {"type": "string", "format": "date-time"}
+				}
+			}
+		}
+	}`, "A user uploaded a new avatar image to object storage",
+		[]string{"commands.UserUploadAvatarCommandHandler"}, []string{"consumers.UserEventHandler"}},
+	{"user.login", 0, `{
+		"type": "object",
+		"required": ["user_id", "logged_in_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"logged_in_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A user logged in; published for projections only, not stored with the user's events",
+		[]string{"commands.AuthLoginCommandHandler"}, []string{"consumers.UserSummaryProjector"}},
+	{"auth.magic_link_requested", 0, `{
+		"type": "object",
+		"required": ["user_id", "link_id", "device_bound", "expires_at", "requested_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"link_id": {"type": "string", "minLength": 1},
+			"device_bound": {"type": "boolean"},
+			"expires_at": {"type": "string", "format": "date-time"},
+			"requested_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A magic link was sent to a user; published for auditing only, not stored with the user's events",
+		[]string{"commands.AuthMagicLinkCommandHandler"}, nil},
+	{"auth.magic_link_consumed", 0, `{
+		"type": "object",
+		"required": ["user_id", "link_id", "consumed_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"link_id": {"type": "string", "minLength": 1},
+			"consumed_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A user signed in with a magic link; published for auditing only, not stored with the user's events",
+		[]string{"commands.AuthMagicLinkCommandHandler"}, nil},
+	{"auth.login_failed", 0, `{
+		"type": "object",
+		"required": ["user_id", "failures", "failed_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"client_ip": {"type": "string"},
+			"failures": {"type": "integer", "minimum": 1},
+			"failed_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A password login of a user failed while login lockout is enabled; published for auditing only, not stored with the user's events",
+		[]string{"commands.AuthLoginCommandHandler"}, nil},
+	{"auth.account_locked", 0, `{
+		"type": "object",
+		"required": ["user_id", "failures", "locked_until", "locked_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"failures": {"type": "integer", "minimum": 1},
+			"locked_until": {"type": "string", "format": "date-time"},
+			"locked_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A user was locked out after too many failed logins; published for auditing only, not stored with the user's events",
+		[]string{"commands.AuthLoginCommandHandler"}, nil},
+	{"user.password_reset_requested", 0, `{
+		"type": "object",
+		"required": ["user_id", "reset_id", "expires_at", "requested_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"reset_id": {"type": "string", "minLength": 1},
+			"expires_at": {"type": "string", "format": "date-time"},
+			"requested_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A password reset link was sent to a user; published for auditing only, not stored with the user's events",
+		[]string{"commands.AuthPasswordResetCommandHandler"}, nil},
+	{"user.password_reset", 1, `{
+		"type": "object",
+		"required": ["user_id", "reset_id", "reset_at"],
+		"properties": {
+			"user_id": {"type": "string", "minLength": 1},
+			"reset_id": {"type": "string", "minLength": 1},
+			"reset_at": {"type": "string", "format": "date-time"}
+		}
+	}`, "A user set a new password with a password reset link",
+		[]string{"commands.AuthPasswordResetCommandHandler"}, nil},
+}
+
+// NewEventSchemaRegistry creates the registry of event payload schemas: the built-in schemas of
+// domain events, extended or replaced by the schemas of the configured directory
+func NewEventSchemaRegistry(cfg config.MessageBrokerConfig) (*schema.Registry, error) {
+	registry := schema.NewRegistry(cfg.StrictSchemas)
+	for _, builtin := range domainEvents {
+		validator := schema.MustParseJSONSchema(builtin.schema)
+		registry.Register(builtin.eventType, builtin.version, validator)
+		// Events stored with the user's events carry the version of the user's stream
+		if builtin.version > 0 {
+			registry.Register(builtin.eventType, schema.AnyVersion, validator)
+		}
+	}
+
+	if cfg.SchemaDir != "" {
+		if _, err := registry.LoadDir(cfg.SchemaDir); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
@@ -0,0 +1,84 @@
+package messagebroker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEventSchemaRegistry(t *testing.T) {
+	registry, err := messagebroker.NewEventSchemaRegistry(config.MessageBrokerConfig{})
+	require.NoError(t, err)
+
+	// Events published by the application conform to the built-in schemas
+	now := time.Now()
+	published := []struct {
+		eventType string
+		data      interface{}
+		version   int
+	}{
+		{"user.created", events.UserCreatedEvent{UserID: "u1", Email: "a@example.com", Name: "Ann", CreatedAt: now}, 1},
+		{"user.updated", events.UserUpdatedEvent{UserID: "u1", Name: "Ann", UpdatedAt: now}, 2},
+		{"user.deleted", events.UserDeletedEvent{UserID: "u1", DeletedAt: now}, 3},
+	}
+	for _, p := range published {
+		event, err := events.NewEvent(p.eventType, p.data, p.version)
+		require.NoError(t, err)
+		assert.NoError(t, registry.Validate(event.Type, event.Version, event.Data), p.eventType)
+	}
+	login, err := events.NewUserLoggedInEvent("u1")
+	require.NoError(t, err)
+	assert.NoError(t, registry.Validate(login.Type, login.Version, login.Data))
+
+	strict, err := messagebroker.NewEventSchemaRegistry(config.MessageBrokerConfig{StrictSchemas: true})
+	require.NoError(t, err)
+	assert.NoError(t, strict.Validate("user.deleted", 7, []byte(`{"user_id": "u1", "deleted_at": "2024-01-01T00:00:00Z"}`)),
+		"events stored with the user's events are validated at any version of the user's stream")
+
+	err = registry.Validate("user.created", 1, []byte(`{"user_id": "", "email": "not an email", "name": "Ann"}`))
+	assert.ErrorContains(t, err, `missing required property "created_at"`)
+	assert.ErrorContains(t, err, "$.email: must be a valid email")
+	assert.ErrorContains(t, err, "$.user_id: must be at least 1 characters long")
+}
+
+func TestNewEventSchemaRegistry_SchemaDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "product.created.json"), []byte(`{"type": "object", "required": ["product_id"]}`), 0o644))
+
+	registry, err := messagebroker.NewEventSchemaRegistry(config.MessageBrokerConfig{SchemaDir: dir, StrictSchemas: true})
+	require.NoError(t, err)
+	assert.Error(t, registry.Validate("product.created", 1, []byte(`{}`)))
+	assert.Error(t, registry.Validate("product.deleted", 1, []byte(`{}`)), "strict registries reject events without a schema")
+
+	_, err = messagebroker.NewEventSchemaRegistry(config.MessageBrokerConfig{SchemaDir: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestNewEventCatalog(t *testing.T) {
+	cfg := config.MessageBrokerConfig{Topics: map[string]string{"user.created": "user-events"}}
+	catalog := messagebroker.NewEventCatalog(cfg)
+	require.NoError(t, catalog.Validate(), "every domain event is fully documented")
+
+	registry, err := messagebroker.NewEventSchemaRegistry(cfg)
+	require.NoError(t, err)
+	for _, event := range catalog.Events() {
+		_, registered := registry.Lookup(event.Type, event.Version)
+		assert.True(t, registered, "%s is documented with the schema it is validated with", event.Type)
+		assert.Equal(t, messagebroker.EventTopic(cfg, event.Type), event.Topic)
+	}
+
+	created, ok := catalog.Get("user.created")
+	require.True(t, ok)
+	assert.Equal(t, "user-events", created.Topic, "mapped events are documented with their mapped topic")
+	login, ok := catalog.Get("user.login")
+	require.True(t, ok)
+	assert.Equal(t, "user.login", login.Topic, "unmapped events are published to their type")
+}
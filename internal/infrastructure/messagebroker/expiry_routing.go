@@ -0,0 +1,38 @@
+package messagebroker
+
+import (
+	"fmt"
+	"strings"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/kafka"
+)
+
+// ExpiredRouter routes events that exceeded the age limit of their topic to an expired topic,
+// where they can be inspected or reprocessed instead of being dropped
+type ExpiredRouter struct {
+	broker      MessageBroker
+	topicFormat string
+}
+
+// NewExpiredRouter creates an expired event router publishing to broker
+func NewExpiredRouter(broker MessageBroker, cfg config.MessageBrokerConfig) *ExpiredRouter {
+	return &ExpiredRouter{
+		broker:      broker,
+		topicFormat: cfg.ExpiredTopicFormat,
+	}
+}
+
+// Enabled reports whether expired events are routed rather than skipped
+func (r *ExpiredRouter) Enabled() bool {
+	return r.topicFormat != ""
+}
+
+// PublishExpired publishes an expired message, consumed with headers, to the expired topic of its original topic
+func (r *ExpiredRouter) PublishExpired(message []byte, headers kafka.Headers) error {
+	topic := strings.ReplaceAll(r.topicFormat, "{topic}", headers.OriginalTopic())
+	if err := PublishWithHeaders(r.broker, topic, headers.TenantID(), message, headers); err != nil {
+		return fmt.Errorf("failed to route expired message to %s: %w", topic, err)
+	}
+	return nil
+}
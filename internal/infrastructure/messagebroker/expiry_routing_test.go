@@ -0,0 +1,32 @@
+package messagebroker_test
+
+import (
+	"testing"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker/mocks"
+	"go-clean-ddd-es-template/pkg/kafka"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiredRouter_PublishExpired(t *testing.T) {
+	assert.False(t, messagebroker.NewExpiredRouter(nil, config.MessageBrokerConfig{}).Enabled())
+
+	broker := &headerBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}
+	router := messagebroker.NewExpiredRouter(broker, config.MessageBrokerConfig{ExpiredTopicFormat: "{topic}.expired"})
+	assert.True(t, router.Enabled())
+
+	// Expired redeliveries go to the expired topic of the original topic
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderOriginalTopic, "user-events")
+	headers.Set(kafka.HeaderTenantID, "acme")
+	headers.SetRetryCount(1)
+
+	require.NoError(t, router.PublishExpired([]byte(`{}`), headers))
+	assert.Equal(t, "user-events.expired", broker.topic)
+	assert.Equal(t, "acme", broker.key)
+	assert.Equal(t, headers, broker.headers)
+}
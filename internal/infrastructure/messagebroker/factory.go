@@ -52,9 +52,38 @@ type KafkaBroker struct {
 	metrics  *metrics.Metrics
 }
 
+// KafkaSecurity returns the client identity, SASL and TLS settings of the Kafka clients
+func KafkaSecurity(cfg *config.MessageBrokerConfig) kafka.SecurityConfig {
+	return kafka.SecurityConfig{
+		ClientID:              cfg.ClientID,
+		SASLMechanism:         cfg.SASLMechanism,
+		SASLUsername:          cfg.SASLUsername,
+		SASLPassword:          cfg.SASLPassword,
+		TLSEnabled:            cfg.TLSEnabled,
+		TLSCAFile:             cfg.TLSCAFile,
+		TLSCertFile:           cfg.TLSCertFile,
+		TLSKeyFile:            cfg.TLSKeyFile,
+		TLSServerName:         cfg.TLSServerName,
+		TLSInsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}
+}
+
+// NewSaramaConfig returns the Sarama configuration of the clients connecting to the configured
+// Kafka brokers, authenticated and encrypted as configured
+func NewSaramaConfig(cfg *config.MessageBrokerConfig) (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	if err := KafkaSecurity(cfg).Apply(saramaConfig); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security configuration: %w", err)
+	}
+	return saramaConfig, nil
+}
+
 func NewKafkaBroker(cfg *config.MessageBrokerConfig) (*KafkaBroker, error) {
 	// Create Sarama config
-	saramaConfig := sarama.NewConfig()
+	saramaConfig, err := NewSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
 	saramaConfig.Producer.Return.Successes = true
 	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
 	saramaConfig.Producer.Retry.Max = 5
@@ -66,7 +95,12 @@ func NewKafkaBroker(cfg *config.MessageBrokerConfig) (*KafkaBroker, error) {
 	}
 
 	// Create Sarama consumer
-	saramaConsumer, err := sarama.NewConsumer(cfg.Brokers, nil)
+	consumerConfig, err := NewSaramaConfig(cfg)
+	if err != nil {
+		saramaProducer.Close()
+		return nil, err
+	}
+	saramaConsumer, err := sarama.NewConsumer(cfg.Brokers, consumerConfig)
 	if err != nil {
 		saramaProducer.Close()
 		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
@@ -126,6 +160,43 @@ func (k *KafkaBroker) Publish(topic string, message []byte) error {
 	return nil
 }
 
+// PublishWithKey publishes a message keyed for partitioning, e.g. by tenant
+func (k *KafkaBroker) PublishWithKey(topic, key string, message []byte) error {
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(key),
+		Value: sarama.ByteEncoder(message),
+	}
+
+	_, _, err := k.producer.SendMessage(msg)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to topic %s: %w", topic, err)
+	}
+
+	log.Printf("Message published to topic: %s with key: %s", topic, key)
+	return nil
+}
+
+// PublishWithHeaders publishes a message with a partition key and headers; an empty key publishes unkeyed
+func (k *KafkaBroker) PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error {
+	msg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(message),
+		Headers: headers.Records(),
+	}
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+
+	_, _, err := k.producer.SendMessage(msg)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to topic %s: %w", topic, err)
+	}
+
+	log.Printf("Message published to topic: %s", topic)
+	return nil
+}
+
 func (k *KafkaBroker) Subscribe(topic string, handler func([]byte)) error {
 	// Get partitions for the topic
 	partitions, err := k.consumer.Partitions(topic)
@@ -157,80 +228,6 @@ func (k *KafkaBroker) GetConsumer() sarama.Consumer {
 	return k.consumer.GetConsumer()
 }
 
-// RabbitMQBroker stub implementation
-type RabbitMQBroker struct {
-	config *config.MessageBrokerConfig
-}
-
-func NewRabbitMQBroker(cfg *config.MessageBrokerConfig) (*RabbitMQBroker, error) {
-	broker := &RabbitMQBroker{
-		config: cfg,
-	}
-
-	if err := broker.Connect(); err != nil {
-		return nil, err
-	}
-
-	return broker, nil
-}
-
-func (r *RabbitMQBroker) Connect() error {
-	return fmt.Errorf("RabbitMQ implementation not available - use Kafka instead")
-}
-
-func (r *RabbitMQBroker) Close() error {
-	return nil
-}
-
-func (r *RabbitMQBroker) Publish(topic string, message []byte) error {
-	return fmt.Errorf("RabbitMQ implementation not available")
-}
-
-func (r *RabbitMQBroker) Subscribe(topic string, handler func([]byte)) error {
-	return fmt.Errorf("RabbitMQ implementation not available")
-}
-
-func (r *RabbitMQBroker) GetConsumer() sarama.Consumer {
-	return nil
-}
-
-// RedisBroker stub implementation
-type RedisBroker struct {
-	config *config.MessageBrokerConfig
-}
-
-func NewRedisBroker(cfg *config.MessageBrokerConfig) (*RedisBroker, error) {
-	broker := &RedisBroker{
-		config: cfg,
-	}
-
-	if err := broker.Connect(); err != nil {
-		return nil, err
-	}
-
-	return broker, nil
-}
-
-func (r *RedisBroker) Connect() error {
-	return fmt.Errorf("Redis implementation not available - use Kafka instead")
-}
-
-func (r *RedisBroker) Close() error {
-	return nil
-}
-
-func (r *RedisBroker) Publish(topic string, message []byte) error {
-	return fmt.Errorf("Redis implementation not available")
-}
-
-func (r *RedisBroker) Subscribe(topic string, handler func([]byte)) error {
-	return fmt.Errorf("Redis implementation not available")
-}
-
-func (r *RedisBroker) GetConsumer() sarama.Consumer {
-	return nil
-}
-
 // NATSBroker stub implementation
 type NATSBroker struct {
 	config *config.MessageBrokerConfig
@@ -40,7 +40,7 @@ func TestMessageBrokerFactory_CreateMessageBroker(t *testing.T) {
 				Exchange: "user-events",
 				Queue:    "user-events",
 			},
-			expectError: true, // RabbitMQ is stub implementation
+			expectError: true, // No RabbitMQ server, or RabbitMQ support not compiled in
 		},
 		{
 			name: "create redis broker",
@@ -50,9 +50,9 @@ func TestMessageBrokerFactory_CreateMessageBroker(t *testing.T) {
 				Topics: map[string]string{
 					"user.created": "user-events",
 				},
-				Channel: "user-events",
+				GroupID: "user-service",
 			},
-			expectError: true, // Redis is stub implementation
+			expectError: true, // No Redis server, or Redis support not compiled in
 		},
 		{
 			name: "create nats broker",
@@ -142,11 +142,11 @@ func TestRedisBroker_NewRedisBroker(t *testing.T) {
 		Topics: map[string]string{
 			"user.created": "user-events",
 		},
-		Channel: "user-events",
+		GroupID: "user-service",
 	}
 
 	broker, err := messagebroker.NewRedisBroker(config)
-	// This will fail because Redis is stub implementation
+	// This will fail without a Redis server, or because Redis support is not compiled in
 	assert.Error(t, err)
 	assert.Nil(t, broker)
 }
@@ -0,0 +1,314 @@
+package messagebroker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/resilience"
+
+	"github.com/IBM/sarama"
+)
+
+// KafkaDLQStorage stores the events of the dead letter queue in Kafka dead letter topics, one per
+// topic the events were consumed from, so they can be inspected and replayed with Kafka tooling.
+// Events are keyed by ID: storing an event again supersedes it and deleting it writes a
+// tombstone, so dead letter topics are meant to be compacted. Queries are served from an index
+// of the live events, built by Load from the dead letter topics and kept up to date on writes.
+type KafkaDLQStorage struct {
+	producer    sarama.SyncProducer
+	consumer    sarama.Consumer
+	offsets     func(topic string, partition int32, time int64) (int64, error)
+	client      sarama.Client // Closed with the storage, nil when not owned
+	topicFormat string
+
+	mu     sync.RWMutex
+	events map[string]*resilience.FailedEvent // Live events by ID
+}
+
+// NewKafkaDLQStorage creates a Kafka dead letter storage connected to the configured brokers,
+// writing to the dead letter topics named by the DLQ topic format
+func NewKafkaDLQStorage(cfg *config.MessageBrokerConfig) (*KafkaDLQStorage, error) {
+	saramaConfig, err := NewSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Retry.Max = 5
+
+	client, err := sarama.NewClient(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		producer.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+
+	storage := newKafkaDLQStorage(producer, consumer, client.GetOffset, cfg.DLQTopicFormat)
+	storage.client = client
+	return storage, nil
+}
+
+// newKafkaDLQStorage creates a Kafka dead letter storage on a producer and a consumer, offsets
+// returning the oldest or newest offset of a partition like sarama.Client.GetOffset
+func newKafkaDLQStorage(producer sarama.SyncProducer, consumer sarama.Consumer, offsets func(topic string, partition int32, time int64) (int64, error), topicFormat string) *KafkaDLQStorage {
+	return &KafkaDLQStorage{
+		producer:    producer,
+		consumer:    consumer,
+		offsets:     offsets,
+		topicFormat: topicFormat,
+		events:      make(map[string]*resilience.FailedEvent),
+	}
+}
+
+// DLQTopic returns the dead letter topic of the events consumed from topic
+func (s *KafkaDLQStorage) DLQTopic(topic string) string {
+	return strings.ReplaceAll(s.topicFormat, "{topic}", topic)
+}
+
+// eventTopic returns the dead letter topic of an event: the one of the topic it was consumed
+// from, or of its type when it was not consumed from a topic
+func (s *KafkaDLQStorage) eventTopic(event *resilience.FailedEvent) string {
+	topic := event.Topic
+	if topic == "" {
+		topic, _ = event.EventData["topic"].(string)
+	}
+	if topic == "" {
+		topic = event.EventType
+	}
+	return s.DLQTopic(topic)
+}
+
+// Load indexes the live events of the dead letter topics of the given topics, reading each
+// partition up to its newest offset. Topics without a dead letter topic yet are skipped.
+func (s *KafkaDLQStorage) Load(ctx context.Context, topics []string) error {
+	for _, topic := range topics {
+		dlqTopic := s.DLQTopic(topic)
+		partitions, err := s.consumer.Partitions(dlqTopic)
+		if err != nil {
+			continue // Created on the first dead letter
+		}
+		for _, partition := range partitions {
+			if err := s.loadPartition(ctx, dlqTopic, partition); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadPartition indexes the events of a dead letter topic partition
+func (s *KafkaDLQStorage) loadPartition(ctx context.Context, topic string, partition int32) error {
+	oldest, err := s.offsets(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return fmt.Errorf("failed to get oldest offset of %s/%d: %w", topic, partition, err)
+	}
+	newest, err := s.offsets(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return fmt.Errorf("failed to get newest offset of %s/%d: %w", topic, partition, err)
+	}
+	if newest <= oldest {
+		return nil
+	}
+
+	partitionConsumer, err := s.consumer.ConsumePartition(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s/%d: %w", topic, partition, err)
+	}
+	defer partitionConsumer.Close()
+
+	for {
+		select {
+		case msg, ok := <-partitionConsumer.Messages():
+			if !ok {
+				return fmt.Errorf("consumer of %s/%d closed before offset %d", topic, partition, newest)
+			}
+			if err := s.apply(msg); err != nil {
+				return err
+			}
+			if msg.Offset >= newest-1 {
+				return nil
+			}
+		case consumerErr := <-partitionConsumer.Errors():
+			if consumerErr != nil {
+				return fmt.Errorf("failed to read %s/%d: %w", topic, partition, consumerErr.Err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// apply indexes a dead letter record: an event, or a tombstone deleting the event of its key
+func (s *KafkaDLQStorage) apply(msg *sarama.ConsumerMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg.Value == nil {
+		delete(s.events, string(msg.Key))
+		return nil
+	}
+
+	var event resilience.FailedEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return fmt.Errorf("failed to decode dead letter %s/%d/%d: %w", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+	s.events[event.ID] = &event
+	return nil
+}
+
+// Store writes an event to its dead letter topic, superseding any earlier version of it
+func (s *KafkaDLQStorage) Store(ctx context.Context, event *resilience.FailedEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter event %s: %w", event.ID, err)
+	}
+
+	topic := s.eventTopic(event)
+	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(event.ID),
+		Value: sarama.ByteEncoder(value),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write dead letter event %s to %s: %w", event.ID, topic, err)
+	}
+
+	stored := *event
+	s.mu.Lock()
+	s.events[event.ID] = &stored
+	s.mu.Unlock()
+	return nil
+}
+
+// Get returns a copy of a live event
+func (s *KafkaDLQStorage) Get(ctx context.Context, id string) (*resilience.FailedEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	event, ok := s.events[id]
+	if !ok {
+		return nil, fmt.Errorf("event not found: %s", id)
+	}
+	copied := *event
+	return &copied, nil
+}
+
+// List returns a page of the live events, oldest first
+func (s *KafkaDLQStorage) List(ctx context.Context, limit, offset int) ([]*resilience.FailedEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// IDs are time ordered
+	ids := make([]string, 0, len(s.events))
+	for id := range s.events {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if offset >= len(ids) {
+		return []*resilience.FailedEvent{}, nil
+	}
+	end := min(offset+limit, len(ids))
+
+	events := make([]*resilience.FailedEvent, 0, end-offset)
+	for _, id := range ids[offset:end] {
+		copied := *s.events[id]
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// Delete writes a tombstone for an event to its dead letter topic
+func (s *KafkaDLQStorage) Delete(ctx context.Context, id string) error {
+	s.mu.RLock()
+	event, ok := s.events[id]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("event not found: %s", id)
+	}
+
+	topic := s.eventTopic(event)
+	_, _, err := s.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(id),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter event %s from %s: %w", id, topic, err)
+	}
+
+	s.mu.Lock()
+	delete(s.events, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// Count returns the number of live events
+func (s *KafkaDLQStorage) Count(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.events), nil
+}
+
+// Replay retries every live event with handler, e.g. a DLQRepublisher publishing them back to
+// the topics they were consumed from, and deletes the replayed events. It returns how many
+// events were replayed and stops at the first failure.
+func (s *KafkaDLQStorage) Replay(ctx context.Context, handler resilience.RetryHandler) (int, error) {
+	count, err := s.Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+	events, err := s.List(ctx, count, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+		if err := handler.HandleRetry(ctx, event); err != nil {
+			return replayed, fmt.Errorf("failed to replay dead letter event %s: %w", event.ID, err)
+		}
+		if err := s.Delete(ctx, event.ID); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// Close closes the producer, the consumer and the client of the storage
+func (s *KafkaDLQStorage) Close() error {
+	var errs []error
+	if err := s.producer.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close producer: %w", err))
+	}
+	if err := s.consumer.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close consumer: %w", err))
+	}
+	if s.client != nil {
+		if err := s.client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close client: %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("errors closing Kafka dead letter storage: %v", errs)
+	}
+	return nil
+}
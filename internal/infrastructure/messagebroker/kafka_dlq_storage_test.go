@@ -0,0 +1,108 @@
+package messagebroker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/resilience"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRetryHandler records the events it retries
+type recordingRetryHandler struct {
+	retried []string
+	err     error
+}
+
+func (h *recordingRetryHandler) HandleRetry(ctx context.Context, event *resilience.FailedEvent) error {
+	if h.err != nil {
+		return h.err
+	}
+	h.retried = append(h.retried, event.ID)
+	return nil
+}
+
+func TestKafkaDLQStorage_WritesDeadLetterTopics(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	var records []*sarama.ProducerMessage
+	record := func(msg *sarama.ProducerMessage) error {
+		records = append(records, msg)
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(record)
+	}
+	storage := newKafkaDLQStorage(producer, mocks.NewConsumer(t, nil), nil, "{topic}.dlq")
+
+	ctx := context.Background()
+	require.NoError(t, storage.Store(ctx, &resilience.FailedEvent{ID: "dlq_01", EventData: map[string]interface{}{"topic": "user.created"}}))
+	require.NoError(t, storage.Store(ctx, &resilience.FailedEvent{ID: "dlq_02", EventType: "poison_event"}))
+	require.NoError(t, storage.Delete(ctx, "dlq_01"))
+
+	require.Len(t, records, 3)
+	assert.Equal(t, "user.created.dlq", records[0].Topic, "events go to the dead letter topic of the topic they were consumed from")
+	assert.Equal(t, "poison_event.dlq", records[1].Topic)
+	assert.Equal(t, "user.created.dlq", records[2].Topic)
+	key, _ := records[2].Key.Encode()
+	assert.Equal(t, "dlq_01", string(key))
+	assert.Nil(t, records[2].Value, "deleting writes a tombstone")
+
+	count, err := storage.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	_, err = storage.Get(ctx, "dlq_01")
+	assert.Error(t, err)
+	assert.Error(t, storage.Delete(ctx, "dlq_01"))
+}
+
+func TestKafkaDLQStorage_LoadsAndReplaysDeadLetters(t *testing.T) {
+	consumer := mocks.NewConsumer(t, nil)
+	consumer.SetTopicMetadata(map[string][]int32{"user.created.dlq": {0}})
+	partition := consumer.ExpectConsumePartition("user.created.dlq", 0, sarama.OffsetOldest)
+	partition.YieldMessage(&sarama.ConsumerMessage{Key: []byte("dlq_02"), Value: []byte(`{"id":"dlq_02","attempts":1,"topic":"user.created"}`)})
+	partition.YieldMessage(&sarama.ConsumerMessage{Key: []byte("dlq_01"), Value: []byte(`{"id":"dlq_01","topic":"user.created"}`)})
+	partition.YieldMessage(&sarama.ConsumerMessage{Key: []byte("dlq_03"), Value: []byte(`{"id":"dlq_03","topic":"user.created"}`)})
+	partition.YieldMessage(&sarama.ConsumerMessage{Key: []byte("dlq_03")})
+	partition.YieldMessage(&sarama.ConsumerMessage{Key: []byte("dlq_02"), Value: []byte(`{"id":"dlq_02","attempts":2,"topic":"user.created"}`)})
+
+	offsets := func(topic string, partition int32, time int64) (int64, error) {
+		if time == sarama.OffsetNewest {
+			return 5, nil
+		}
+		return 0, nil
+	}
+	producer := mocks.NewSyncProducer(t, nil)
+	storage := newKafkaDLQStorage(producer, consumer, offsets, "{topic}.dlq")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, storage.Load(ctx, []string{"user.created", "user.deleted"}), "topics without dead letters are skipped")
+
+	events, err := storage.List(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2, "tombstoned events are not loaded")
+	assert.Equal(t, "dlq_01", events[0].ID, "events are listed oldest first")
+	assert.Equal(t, 2, events[1].Attempts, "the latest version of an event wins")
+
+	// Replaying stops at the first failure and keeps the events not replayed
+	handler := &recordingRetryHandler{err: errors.New("broker down")}
+	replayed, err := storage.Replay(ctx, handler)
+	assert.Error(t, err)
+	assert.Equal(t, 0, replayed)
+
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+	handler.err = nil
+	replayed, err = storage.Replay(ctx, handler)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Equal(t, []string{"dlq_01", "dlq_02"}, handler.retried)
+	count, _ := storage.Count(ctx)
+	assert.Zero(t, count, "replayed events are deleted")
+}
@@ -0,0 +1,70 @@
+package messagebroker
+
+import (
+	"context"
+
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/resilience"
+
+	"github.com/IBM/sarama"
+)
+
+// LimitedMessageBroker wraps the publishes of a MessageBroker with an adaptive concurrency limit
+type LimitedMessageBroker struct {
+	broker  MessageBroker
+	limiter *resilience.AdaptiveLimiter
+}
+
+// NewLimitedMessageBroker creates a new concurrency limited message broker
+func NewLimitedMessageBroker(broker MessageBroker, limiter *resilience.AdaptiveLimiter) *LimitedMessageBroker {
+	return &LimitedMessageBroker{
+		broker:  broker,
+		limiter: limiter,
+	}
+}
+
+// Connect connects the wrapped broker
+func (l *LimitedMessageBroker) Connect() error {
+	return l.broker.Connect()
+}
+
+// Close closes the wrapped broker
+func (l *LimitedMessageBroker) Close() error {
+	return l.broker.Close()
+}
+
+// Publish wraps broker.Publish with the concurrency limit
+func (l *LimitedMessageBroker) Publish(topic string, message []byte) error {
+	return l.limiter.Execute(context.Background(), func() error {
+		return l.broker.Publish(topic, message)
+	})
+}
+
+// PublishWithKey wraps a keyed publish with the concurrency limit; brokers without key support publish without it
+func (l *LimitedMessageBroker) PublishWithKey(topic, key string, message []byte) error {
+	return l.limiter.Execute(context.Background(), func() error {
+		return PublishKeyed(l.broker, topic, key, message)
+	})
+}
+
+// PublishWithHeaders wraps a publish with headers with the concurrency limit; brokers without header support publish without them
+func (l *LimitedMessageBroker) PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error {
+	return l.limiter.Execute(context.Background(), func() error {
+		return PublishWithHeaders(l.broker, topic, key, message, headers)
+	})
+}
+
+// Subscribe subscribes to the wrapped broker, consumption is not limited
+func (l *LimitedMessageBroker) Subscribe(topic string, handler func([]byte)) error {
+	return l.broker.Subscribe(topic, handler)
+}
+
+// GetConsumer returns the consumer of the wrapped broker
+func (l *LimitedMessageBroker) GetConsumer() sarama.Consumer {
+	return l.broker.GetConsumer()
+}
+
+// GetStats returns adaptive limiter statistics
+func (l *LimitedMessageBroker) GetStats() resilience.AdaptiveLimiterStats {
+	return l.limiter.GetStats()
+}
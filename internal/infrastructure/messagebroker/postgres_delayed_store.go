@@ -0,0 +1,123 @@
+package messagebroker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+)
+
+// PostgresDelayedMessageStore keeps delayed messages in the delayed_messages table of the write
+// database. Due messages are locked while they are published, so replicas running the delay
+// scheduler publish each message once.
+type PostgresDelayedMessageStore struct {
+	db database.Database
+}
+
+// NewPostgresDelayedMessageStore creates a new PostgreSQL delayed message store
+func NewPostgresDelayedMessageStore(db database.Database) *PostgresDelayedMessageStore {
+	return &PostgresDelayedMessageStore{
+		db: db,
+	}
+}
+
+// Schedule stores a message until it is due
+func (s *PostgresDelayedMessageStore) Schedule(ctx context.Context, message *DelayedMessage) error {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return err
+	}
+	headers, err := json.Marshal(message.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message headers: %w", err)
+	}
+
+	query := `
+		INSERT INTO delayed_messages (id, topic, message_key, payload, headers, due_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = database.ExecutorFrom(ctx, sqlDB).ExecContext(ctx, query, message.ID, message.Topic, message.Key, message.Payload, headers, message.DueAt.UTC())
+	return err
+}
+
+// PublishDue locks up to limit messages due at now, oldest first, and hands each to publish.
+// Published messages are deleted; the batch stops at the first failure, leaving the failed
+// message and later ones for the next call. Messages locked by a concurrent scheduler are skipped.
+func (s *PostgresDelayedMessageStore) PublishDue(ctx context.Context, now time.Time, limit int, publish func(message *DelayedMessage) error) (int, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	err = database.WithTransaction(ctx, sqlDB, func(ctx context.Context) error {
+		messages, err := s.lockDue(ctx, sqlDB, now, limit)
+		if err != nil {
+			return err
+		}
+
+		tx := database.ExecutorFrom(ctx, sqlDB)
+		for _, message := range messages {
+			if publishErr = publish(message); publishErr != nil {
+				return nil
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM delayed_messages WHERE id = $1`, message.ID); err != nil {
+				return fmt.Errorf("failed to delete delayed message %s: %w", message.ID, err)
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if publishErr != nil {
+		return published, fmt.Errorf("failed to publish delayed message: %w", publishErr)
+	}
+	return published, nil
+}
+
+// lockDue locks up to limit messages due at now, oldest first, skipping locked ones
+func (s *PostgresDelayedMessageStore) lockDue(ctx context.Context, sqlDB *sql.DB, now time.Time, limit int) ([]*DelayedMessage, error) {
+	query := `
+		SELECT id::text, topic, message_key, payload, headers, due_at
+		FROM delayed_messages
+		WHERE due_at <= $1
+		ORDER BY due_at, id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := database.ExecutorFrom(ctx, sqlDB).QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query delayed messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*DelayedMessage
+	for rows.Next() {
+		var message DelayedMessage
+		var headers []byte
+		if err := rows.Scan(&message.ID, &message.Topic, &message.Key, &message.Payload, &headers, &message.DueAt); err != nil {
+			return nil, fmt.Errorf("failed to scan delayed message: %w", err)
+		}
+		if err := json.Unmarshal(headers, &message.Headers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal headers of delayed message %s: %w", message.ID, err)
+		}
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
+
+// sqlDB returns the connection of the write database
+func (s *PostgresDelayedMessageStore) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
@@ -0,0 +1,311 @@
+//go:build rabbitmq
+
+package messagebroker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/kafka"
+
+	"github.com/IBM/sarama"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// rabbitMQConfirmTimeout bounds the wait for the broker to confirm a published message
+const rabbitMQConfirmTimeout = 10 * time.Second
+
+// RabbitMQBroker implements MessageBroker interface using RabbitMQ. Topics are routing keys of
+// a durable topic exchange; every subscribed topic gets a durable queue, named after the
+// configured queue and the topic, bound to the exchange with the topic as binding key.
+// Published messages are persistent and confirmed by the broker before Publish returns.
+type RabbitMQBroker struct {
+	config *config.MessageBrokerConfig
+
+	mu        sync.Mutex
+	conn      *amqp.Connection
+	publisher *amqp.Channel   // Channel in confirm mode shared by publishes
+	consumers []*amqp.Channel // One channel per subscribed topic
+}
+
+func NewRabbitMQBroker(cfg *config.MessageBrokerConfig) (*RabbitMQBroker, error) {
+	broker := &RabbitMQBroker{
+		config: cfg,
+	}
+
+	if err := broker.Connect(); err != nil {
+		return nil, err
+	}
+
+	return broker, nil
+}
+
+// rabbitMQURL returns the AMQP URL of the first broker, a host:port address connecting as the
+// default guest user
+func rabbitMQURL(cfg *config.MessageBrokerConfig) (string, error) {
+	if len(cfg.Brokers) == 0 || cfg.Brokers[0] == "" {
+		return "", fmt.Errorf("no RabbitMQ broker configured")
+	}
+	address := cfg.Brokers[0]
+	if strings.HasPrefix(address, "amqp://") || strings.HasPrefix(address, "amqps://") {
+		return address, nil
+	}
+	return "amqp://guest:guest@" + address + "/", nil
+}
+
+func (r *RabbitMQBroker) Connect() error {
+	url, err := rabbitMQURL(r.config)
+	if err != nil {
+		return err
+	}
+
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	publisher, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+	if err := publisher.Confirm(false); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to enable RabbitMQ publisher confirms: %w", err)
+	}
+	if err := publisher.ExchangeDeclare(r.config.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to declare RabbitMQ exchange %s: %w", r.config.Exchange, err)
+	}
+
+	// Surface connection losses, the broker does not reconnect on its own
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		if err, ok := <-closed; ok && err != nil {
+			log.Printf("[ERROR] RabbitMQ connection closed: %v", err)
+		}
+	}()
+
+	r.mu.Lock()
+	r.conn = conn
+	r.publisher = publisher
+	r.mu.Unlock()
+
+	log.Printf("Connected to RabbitMQ exchange: %s", r.config.Exchange)
+	return nil
+}
+
+func (r *RabbitMQBroker) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, channel := range r.consumers {
+		if err := channel.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close consumer channel: %w", err))
+		}
+	}
+	if err := r.publisher.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close publisher channel: %w", err))
+	}
+	if err := r.conn.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close connection: %w", err))
+	}
+	r.conn, r.publisher, r.consumers = nil, nil, nil
+
+	if len(errs) > 0 {
+		return fmt.Errorf("errors closing RabbitMQ broker: %v", errs)
+	}
+
+	return nil
+}
+
+func (r *RabbitMQBroker) Publish(topic string, message []byte) error {
+	return r.publish(topic, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         message,
+	})
+}
+
+// PublishWithHeaders publishes a message with headers. RabbitMQ has no partitions, so the key
+// is only kept as a header for consumers.
+func (r *RabbitMQBroker) PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error {
+	table := make(amqp.Table, len(headers)+1)
+	for name, value := range headers {
+		table[name] = value
+	}
+	if key != "" {
+		table["key"] = []byte(key)
+	}
+
+	return r.publish(topic, amqp.Publishing{
+		Headers:      table,
+		ContentType:  headers.ContentType(),
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         message,
+	})
+}
+
+// PublishDelayed publishes a message delivered to topic once delay elapsed. The message waits in
+// a queue per topic and delay, whose messages expire after the delay into the exchange with the
+// topic as routing key; queues of delays no longer used are deleted once idle.
+func (r *RabbitMQBroker) PublishDelayed(topic, key string, message []byte, headers kafka.Headers, delay time.Duration) error {
+	r.mu.Lock()
+	publisher := r.publisher
+	r.mu.Unlock()
+	if publisher == nil {
+		return fmt.Errorf("RabbitMQ broker is not connected")
+	}
+
+	ttl := delay.Milliseconds()
+	queue := fmt.Sprintf("%s.delayed.%s.%dms", r.config.Queue, topic, ttl)
+	_, err := publisher.QueueDeclare(queue, true, false, false, false, amqp.Table{
+		"x-message-ttl":             ttl,
+		"x-dead-letter-exchange":    r.config.Exchange,
+		"x-dead-letter-routing-key": topic,
+		"x-expires":                 2*ttl + time.Minute.Milliseconds(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare RabbitMQ delay queue %s: %w", queue, err)
+	}
+
+	table := make(amqp.Table, len(headers)+1)
+	for name, value := range headers {
+		table[name] = value
+	}
+	if key != "" {
+		table["key"] = []byte(key)
+	}
+
+	// Published through the default exchange, which routes to the queue named by the routing key
+	return r.publishTo("", queue, amqp.Publishing{
+		Headers:      table,
+		ContentType:  headers.ContentType(),
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         message,
+	})
+}
+
+// publish publishes a message to the exchange with the topic as routing key, waiting for the
+// broker to confirm it
+func (r *RabbitMQBroker) publish(topic string, msg amqp.Publishing) error {
+	return r.publishTo(r.config.Exchange, topic, msg)
+}
+
+// publishTo publishes a message to an exchange, waiting for the broker to confirm it
+func (r *RabbitMQBroker) publishTo(exchange, topic string, msg amqp.Publishing) error {
+	r.mu.Lock()
+	publisher := r.publisher
+	r.mu.Unlock()
+	if publisher == nil {
+		return fmt.Errorf("RabbitMQ broker is not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rabbitMQConfirmTimeout)
+	defer cancel()
+
+	confirmation, err := publisher.PublishWithDeferredConfirmWithContext(ctx, exchange, topic, false, false, msg)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to topic %s: %w", topic, err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to confirm message published to topic %s: %w", topic, err)
+	}
+	if !acked {
+		return fmt.Errorf("RabbitMQ rejected message published to topic %s", topic)
+	}
+
+	log.Printf("Message published to topic: %s", topic)
+	return nil
+}
+
+// Subscribe consumes the queue of a topic, declaring and binding it first. Messages are
+// acknowledged once handled; a handler panicking rejects its message without requeueing it, so
+// it goes to the dead letter exchange of the queue when one is set up.
+func (r *RabbitMQBroker) Subscribe(topic string, handler func([]byte)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return fmt.Errorf("RabbitMQ broker is not connected")
+	}
+
+	channel, err := r.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open RabbitMQ channel for topic %s: %w", topic, err)
+	}
+
+	queue := r.config.Queue + "." + topic
+	if err := r.declareQueue(channel, queue, topic); err != nil {
+		channel.Close()
+		return err
+	}
+	deliveries, err := channel.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		channel.Close()
+		return fmt.Errorf("failed to consume RabbitMQ queue %s: %w", queue, err)
+	}
+	r.consumers = append(r.consumers, channel)
+
+	go func() {
+		for delivery := range deliveries {
+			handleDelivery(delivery, handler)
+		}
+	}()
+
+	log.Printf("Subscribed to topic: %s", topic)
+	return nil
+}
+
+// declareQueue declares the durable queue of a topic, bound to the exchange, and limits the
+// unacknowledged messages delivered at once to the consumer workers
+func (r *RabbitMQBroker) declareQueue(channel *amqp.Channel, queue, topic string) error {
+	prefetch := r.config.ConsumerWorkers
+	if prefetch <= 0 {
+		prefetch = 1
+	}
+	if err := channel.Qos(prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set RabbitMQ prefetch: %w", err)
+	}
+	if _, err := channel.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare RabbitMQ queue %s: %w", queue, err)
+	}
+	if err := channel.QueueBind(queue, topic, r.config.Exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind RabbitMQ queue %s to topic %s: %w", queue, topic, err)
+	}
+	return nil
+}
+
+// handleDelivery hands a delivery to the handler and acknowledges it
+func handleDelivery(delivery amqp.Delivery, handler func([]byte)) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("[ERROR] Handler panicked on message from topic %s: %v", delivery.RoutingKey, recovered)
+			if err := delivery.Nack(false, false); err != nil {
+				log.Printf("[ERROR] Failed to reject RabbitMQ message: %v", err)
+			}
+		}
+	}()
+
+	handler(delivery.Body)
+	if err := delivery.Ack(false); err != nil {
+		log.Printf("[ERROR] Failed to acknowledge RabbitMQ message: %v", err)
+	}
+}
+
+// GetConsumer returns nil, RabbitMQ has no Kafka consumer
+func (r *RabbitMQBroker) GetConsumer() sarama.Consumer {
+	return nil
+}
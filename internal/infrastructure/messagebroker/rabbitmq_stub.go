@@ -0,0 +1,49 @@
+//go:build !rabbitmq
+
+package messagebroker
+
+import (
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+
+	"github.com/IBM/sarama"
+)
+
+// RabbitMQBroker stands in for the RabbitMQ broker of rabbitmq_broker.go, compiled in with the
+// rabbitmq build tag
+type RabbitMQBroker struct {
+	config *config.MessageBrokerConfig
+}
+
+func NewRabbitMQBroker(cfg *config.MessageBrokerConfig) (*RabbitMQBroker, error) {
+	broker := &RabbitMQBroker{
+		config: cfg,
+	}
+
+	if err := broker.Connect(); err != nil {
+		return nil, err
+	}
+
+	return broker, nil
+}
+
+func (r *RabbitMQBroker) Connect() error {
+	return fmt.Errorf("RabbitMQ support is not compiled in - build with -tags rabbitmq or use Kafka instead")
+}
+
+func (r *RabbitMQBroker) Close() error {
+	return nil
+}
+
+func (r *RabbitMQBroker) Publish(topic string, message []byte) error {
+	return fmt.Errorf("RabbitMQ support is not compiled in")
+}
+
+func (r *RabbitMQBroker) Subscribe(topic string, handler func([]byte)) error {
+	return fmt.Errorf("RabbitMQ support is not compiled in")
+}
+
+func (r *RabbitMQBroker) GetConsumer() sarama.Consumer {
+	return nil
+}
@@ -0,0 +1,400 @@
+//go:build redis
+
+package messagebroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/kafka"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Fields of the stream entry of a message
+const (
+	redisPayloadField = "payload"
+	redisKeyField     = "key"
+	redisHeaderPrefix = "header:" // Prefix of the fields of message headers
+	redisTopicField   = "topic"   // Field of delayed messages only, the stream they are appended to
+)
+
+// Keys of delayed messages
+const (
+	redisDelayedKey    = "delayed-messages" // Sorted set of delayed message IDs scored by due time in milliseconds
+	redisDelayedPrefix = "delayed-message:" // Prefix of the hash of the fields of a delayed message
+)
+
+const (
+	redisDialTimeout  = 5 * time.Second
+	redisBlockTimeout = 5 * time.Second // Wait for new entries before looking for stale ones
+	redisClaimIdle    = time.Minute     // Idle time after which pending entries are claimed from their consumer
+	redisRetryDelay   = time.Second     // Wait before reading again after a failed read
+	redisDelayPoll    = time.Second     // How often due delayed messages are looked for
+	redisDelayLease   = time.Minute     // Time a delayed message being appended is hidden from other instances
+)
+
+// redisLeaseDue leases the due delayed messages, KEYS[1], due at ARGV[1], until ARGV[2], at most
+// ARGV[3] of them, returning their IDs
+var redisLeaseDue = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, id in ipairs(due) do
+	redis.call('ZADD', KEYS[1], ARGV[2], id)
+end
+return due
+`)
+
+// RedisBroker implements MessageBroker interface using Redis Streams. Topics are streams,
+// appended to with XADD and trimmed to about StreamMaxLen entries; subscribers read them with
+// XREADGROUP as consumers of the group GroupID and acknowledge handled entries with XACK.
+// Entries left pending by a stopped instance or a panicking handler are claimed again once
+// idle for a minute, so every entry is delivered at least once. Delayed messages wait in a
+// sorted set until the poller of an instance appends them to their stream once due.
+type RedisBroker struct {
+	config   *config.MessageBrokerConfig
+	consumer string // Name of the instance in the consumer groups
+
+	mu     sync.Mutex
+	client *redis.Client
+	ctx    context.Context // Context of the subscriptions, cancelled on Close
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRedisBroker(cfg *config.MessageBrokerConfig) (*RedisBroker, error) {
+	hostname, _ := os.Hostname()
+	broker := &RedisBroker{
+		config:   cfg,
+		consumer: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+
+	if err := broker.Connect(); err != nil {
+		return nil, err
+	}
+
+	return broker, nil
+}
+
+// redisOptions returns the client options of the first broker, a redis:// URL or a host:port
+// address
+func redisOptions(cfg *config.MessageBrokerConfig) (*redis.Options, error) {
+	if len(cfg.Brokers) == 0 || cfg.Brokers[0] == "" {
+		return nil, fmt.Errorf("no Redis server configured")
+	}
+	address := cfg.Brokers[0]
+	if strings.HasPrefix(address, "redis://") || strings.HasPrefix(address, "rediss://") {
+		return redis.ParseURL(address)
+	}
+	return &redis.Options{Addr: address}, nil
+}
+
+func (r *RedisBroker) Connect() error {
+	options, err := redisOptions(r.config)
+	if err != nil {
+		return err
+	}
+
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), redisDialTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	r.mu.Lock()
+	r.client = client
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.wg.Add(1)
+	go r.appendDelayed(r.ctx, client)
+	r.mu.Unlock()
+
+	log.Printf("Connected to Redis: %s", options.Addr)
+	return nil
+}
+
+func (r *RedisBroker) Close() error {
+	r.mu.Lock()
+	client, cancel := r.client, r.cancel
+	r.client, r.cancel = nil, nil
+	r.mu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+
+	// Stop the subscriptions before closing their client
+	cancel()
+	r.wg.Wait()
+
+	if err := client.Close(); err != nil {
+		return fmt.Errorf("errors closing Redis broker: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisBroker) Publish(topic string, message []byte) error {
+	return r.publish(topic, map[string]interface{}{redisPayloadField: message})
+}
+
+// PublishWithHeaders publishes a message with headers, stored as fields of the stream entry.
+// Streams have no partitions, so the key is only kept for consumers.
+func (r *RedisBroker) PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error {
+	values := make(map[string]interface{}, len(headers)+2)
+	values[redisPayloadField] = message
+	if key != "" {
+		values[redisKeyField] = key
+	}
+	for name, value := range headers {
+		values[redisHeaderPrefix+name] = value
+	}
+
+	return r.publish(topic, values)
+}
+
+// PublishDelayed publishes a message appended to the stream of topic once delay elapsed. The
+// message is kept in a hash and its ID in a sorted set scored by due time; a due message is
+// leased by one instance, appended and removed, and appended again by any instance if the lease
+// expires first.
+func (r *RedisBroker) PublishDelayed(topic, key string, message []byte, headers kafka.Headers, delay time.Duration) error {
+	client, ctx := r.connection()
+	if client == nil {
+		return fmt.Errorf("Redis broker is not connected")
+	}
+
+	values := make(map[string]interface{}, len(headers)+3)
+	values[redisTopicField] = topic
+	values[redisPayloadField] = message
+	if key != "" {
+		values[redisKeyField] = key
+	}
+	for name, value := range headers {
+		values[redisHeaderPrefix+name] = value
+	}
+
+	id := uuid.New().String()
+	dueAt := time.Now().Add(delay)
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisDelayedPrefix+id, values)
+		pipe.ZAdd(ctx, redisDelayedKey, redis.Z{Score: float64(dueAt.UnixMilli()), Member: id})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delay message to topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+// appendDelayed appends due delayed messages to their streams until the broker is closed
+func (r *RedisBroker) appendDelayed(ctx context.Context, client *redis.Client) {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(redisDelayPoll):
+		}
+
+		now := time.Now()
+		ids, err := redisLeaseDue.Run(ctx, client, []string{redisDelayedKey}, now.UnixMilli(), now.Add(redisDelayLease).UnixMilli(), r.batchSize()).StringSlice()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[ERROR] Failed to lease due delayed messages: %v", err)
+			}
+			continue
+		}
+		for _, id := range ids {
+			if err := r.appendDelayedMessage(ctx, client, id); err != nil && ctx.Err() == nil {
+				log.Printf("[ERROR] Failed to append delayed message %s: %v", id, err)
+			}
+		}
+	}
+}
+
+// appendDelayedMessage appends a leased delayed message to its stream and removes it
+func (r *RedisBroker) appendDelayedMessage(ctx context.Context, client *redis.Client, id string) error {
+	fields, err := client.HGetAll(ctx, redisDelayedPrefix+id).Result()
+	if err != nil {
+		return err
+	}
+	if topic := fields[redisTopicField]; topic != "" {
+		values := make(map[string]interface{}, len(fields))
+		for name, value := range fields {
+			if name != redisTopicField {
+				values[name] = value
+			}
+		}
+		if err := r.publish(topic, values); err != nil {
+			return err
+		}
+	}
+
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, redisDelayedPrefix+id)
+		pipe.ZRem(ctx, redisDelayedKey, id)
+		return nil
+	})
+	return err
+}
+
+// publish appends an entry to the stream of the topic, trimming the stream when it is bounded
+func (r *RedisBroker) publish(topic string, values map[string]interface{}) error {
+	client, ctx := r.connection()
+	if client == nil {
+		return fmt.Errorf("Redis broker is not connected")
+	}
+
+	args := &redis.XAddArgs{
+		Stream: topic,
+		Values: values,
+	}
+	if r.config.StreamMaxLen > 0 {
+		args.MaxLen = int64(r.config.StreamMaxLen)
+		args.Approx = true
+	}
+	if err := client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to publish message to topic %s: %w", topic, err)
+	}
+
+	log.Printf("Message published to topic: %s", topic)
+	return nil
+}
+
+// Subscribe consumes the stream of a topic as a member of the consumer group, creating the
+// group, which starts at new entries, first. Entries are acknowledged once handled.
+func (r *RedisBroker) Subscribe(topic string, handler func([]byte)) error {
+	client, ctx := r.connection()
+	if client == nil {
+		return fmt.Errorf("Redis broker is not connected")
+	}
+
+	err := client.XGroupCreateMkStream(ctx, topic, r.config.GroupID, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create Redis consumer group %s for topic %s: %w", r.config.GroupID, topic, err)
+	}
+
+	r.wg.Add(1)
+	go r.consume(ctx, client, topic, handler)
+
+	log.Printf("Subscribed to topic: %s", topic)
+	return nil
+}
+
+// connection returns the client and the context of the subscriptions, a nil client when closed
+func (r *RedisBroker) connection() (*redis.Client, context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.client, r.ctx
+}
+
+// consume reads the stream of a topic until the broker is closed. The entries the consumer
+// left pending before a restart are handled first, then new ones; whenever no entry arrives
+// for a while, stale entries of other consumers are claimed.
+func (r *RedisBroker) consume(ctx context.Context, client *redis.Client, topic string, handler func([]byte)) {
+	defer r.wg.Done()
+
+	start := "0" // Pending entries after this ID, ">" for new entries
+	for ctx.Err() == nil {
+		streams, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    r.config.GroupID,
+			Consumer: r.consumer,
+			Streams:  []string{topic, start},
+			Count:    r.batchSize(),
+			Block:    redisBlockTimeout,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			r.claimStale(ctx, client, topic, handler)
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[ERROR] Failed to read Redis stream %s: %v", topic, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(redisRetryDelay):
+			}
+			continue
+		}
+
+		read := 0
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				r.handle(ctx, client, topic, message, handler)
+				read++
+				if start != ">" {
+					// Continue after the entry, which stays pending if its handler panicked
+					start = message.ID
+				}
+			}
+		}
+		if read == 0 {
+			start = ">"
+		}
+	}
+}
+
+// claimStale claims the entries pending for longer than redisClaimIdle, e.g. from a consumer
+// that stopped, and handles them
+func (r *RedisBroker) claimStale(ctx context.Context, client *redis.Client, topic string, handler func([]byte)) {
+	messages, _, err := client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   topic,
+		Group:    r.config.GroupID,
+		Consumer: r.consumer,
+		MinIdle:  redisClaimIdle,
+		Start:    "0-0",
+		Count:    r.batchSize(),
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[ERROR] Failed to claim stale entries of Redis stream %s: %v", topic, err)
+		}
+		return
+	}
+
+	for _, message := range messages {
+		r.handle(ctx, client, topic, message, handler)
+	}
+}
+
+// handle hands an entry to the handler and acknowledges it. An entry whose handler panics stays
+// pending until it is claimed again.
+func (r *RedisBroker) handle(ctx context.Context, client *redis.Client, topic string, message redis.XMessage, handler func([]byte)) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("[ERROR] Handler panicked on message %s from topic %s: %v", message.ID, topic, recovered)
+		}
+	}()
+
+	payload, _ := message.Values[redisPayloadField].(string)
+	handler([]byte(payload))
+
+	if err := client.XAck(ctx, topic, r.config.GroupID, message.ID).Err(); err != nil {
+		log.Printf("[ERROR] Failed to acknowledge Redis message %s: %v", message.ID, err)
+	}
+}
+
+// batchSize returns the number of entries read at once, the consumer workers
+func (r *RedisBroker) batchSize() int64 {
+	if r.config.ConsumerWorkers <= 0 {
+		return 1
+	}
+	return int64(r.config.ConsumerWorkers)
+}
+
+// GetConsumer returns nil, Redis has no Kafka consumer
+func (r *RedisBroker) GetConsumer() sarama.Consumer {
+	return nil
+}
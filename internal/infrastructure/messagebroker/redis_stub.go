@@ -0,0 +1,49 @@
+//go:build !redis
+
+package messagebroker
+
+import (
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+
+	"github.com/IBM/sarama"
+)
+
+// RedisBroker stands in for the Redis Streams broker of redis_broker.go, compiled in with the
+// redis build tag once github.com/redis/go-redis/v9 is added to the module
+type RedisBroker struct {
+	config *config.MessageBrokerConfig
+}
+
+func NewRedisBroker(cfg *config.MessageBrokerConfig) (*RedisBroker, error) {
+	broker := &RedisBroker{
+		config: cfg,
+	}
+
+	if err := broker.Connect(); err != nil {
+		return nil, err
+	}
+
+	return broker, nil
+}
+
+func (r *RedisBroker) Connect() error {
+	return fmt.Errorf("Redis support is not compiled in - build with -tags redis or use Kafka instead")
+}
+
+func (r *RedisBroker) Close() error {
+	return nil
+}
+
+func (r *RedisBroker) Publish(topic string, message []byte) error {
+	return fmt.Errorf("Redis support is not compiled in")
+}
+
+func (r *RedisBroker) Subscribe(topic string, handler func([]byte)) error {
+	return fmt.Errorf("Redis support is not compiled in")
+}
+
+func (r *RedisBroker) GetConsumer() sarama.Consumer {
+	return nil
+}
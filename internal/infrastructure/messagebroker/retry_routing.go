@@ -0,0 +1,103 @@
+package messagebroker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/kafka"
+)
+
+// RetryRouter redelivers messages that failed processing through retry topics. The retry-count
+// header tracks the redeliveries of a message; once it reaches the maximum the message is not
+// redelivered anymore and belongs in the dead letter queue. With a delayer and a retry delay,
+// redeliveries wait the retry delay, doubled for every redelivery already made.
+type RetryRouter struct {
+	broker          MessageBroker
+	topicFormat     string
+	maxRedeliveries int
+	retryDelay      time.Duration
+	delayer         *Delayer
+}
+
+// NewRetryRouter creates a retry router publishing redeliveries to broker
+func NewRetryRouter(broker MessageBroker, cfg config.MessageBrokerConfig) *RetryRouter {
+	return &RetryRouter{
+		broker:          broker,
+		topicFormat:     cfg.RetryTopicFormat,
+		maxRedeliveries: cfg.MaxRedeliveries,
+		retryDelay:      cfg.RetryDelay,
+	}
+}
+
+// SetDelayer sets the delayer delaying redeliveries by the retry delay
+func (r *RetryRouter) SetDelayer(delayer *Delayer) {
+	r.delayer = delayer
+}
+
+// Delay returns the wait before the redelivery of a message consumed with headers
+func (r *RetryRouter) Delay(headers kafka.Headers) time.Duration {
+	if r.delayer == nil {
+		return 0
+	}
+	return r.retryDelay << headers.RetryCount()
+}
+
+// Enabled reports whether failed messages are redelivered
+func (r *RetryRouter) Enabled() bool {
+	return r.maxRedeliveries > 0 && r.topicFormat != ""
+}
+
+// Route returns the retry topic and headers of the next redelivery of a message consumed with
+// headers, or false when the message exhausted its redeliveries
+func (r *RetryRouter) Route(headers kafka.Headers) (string, kafka.Headers, bool) {
+	retryCount := headers.RetryCount()
+	if !r.Enabled() || retryCount >= r.maxRedeliveries || headers.OriginalTopic() == "" {
+		return "", nil, false
+	}
+
+	next := headers.Clone()
+	next.SetRetryCount(retryCount + 1)
+	return r.retryTopic(headers.OriginalTopic()), next, true
+}
+
+// Redeliver publishes a failed message to its retry topic. It returns false without publishing
+// when the message exhausted its redeliveries.
+func (r *RetryRouter) Redeliver(message []byte, headers kafka.Headers) (bool, error) {
+	topic, next, ok := r.Route(headers)
+	if !ok {
+		return false, nil
+	}
+
+	if delay := r.Delay(headers); delay > 0 {
+		if err := r.delayer.Deliver(context.Background(), topic, next.TenantID(), message, next, delay); err != nil {
+			return false, fmt.Errorf("failed to redeliver message to %s: %w", topic, err)
+		}
+		return true, nil
+	}
+	if err := PublishWithHeaders(r.broker, topic, next.TenantID(), message, next); err != nil {
+		return false, fmt.Errorf("failed to redeliver message to %s: %w", topic, err)
+	}
+	return true, nil
+}
+
+// ConsumerTopics returns the topics to consume, adding the retry topic of each topic when
+// redelivery is enabled
+func (r *RetryRouter) ConsumerTopics(topics []string) []string {
+	if !r.Enabled() {
+		return topics
+	}
+
+	result := make([]string, 0, len(topics)*2)
+	for _, topic := range topics {
+		result = append(result, topic, r.retryTopic(topic))
+	}
+	return result
+}
+
+// retryTopic formats the retry topic name of a topic
+func (r *RetryRouter) retryTopic(topic string) string {
+	return strings.ReplaceAll(r.topicFormat, "{topic}", topic)
+}
@@ -0,0 +1,99 @@
+package messagebroker_test
+
+import (
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker/mocks"
+	"go-clean-ddd-es-template/pkg/kafka"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerBroker records publishes with headers
+type headerBroker struct {
+	*mocks.MockMessageBroker
+	topic, key string
+	headers    kafka.Headers
+}
+
+func (b *headerBroker) PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error {
+	b.topic, b.key, b.headers = topic, key, headers
+	return nil
+}
+
+func TestRetryRouter_Redeliver(t *testing.T) {
+	broker := &headerBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}
+	router := messagebroker.NewRetryRouter(broker, config.MessageBrokerConfig{MaxRedeliveries: 2, RetryTopicFormat: "{topic}.retry"})
+	message := []byte(`{}`)
+
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderOriginalTopic, "user-events")
+	headers.Set(kafka.HeaderTenantID, "acme")
+
+	redelivered, err := router.Redeliver(message, headers)
+	require.NoError(t, err)
+	assert.True(t, redelivered)
+	assert.Equal(t, "user-events.retry", broker.topic)
+	assert.Equal(t, "acme", broker.key)
+	assert.Equal(t, 1, broker.headers.RetryCount())
+	assert.Equal(t, 0, headers.RetryCount(), "consumed headers are not modified")
+
+	// Redeliveries from the retry topic keep routing to the retry topic of the original topic
+	redelivered, err = router.Redeliver(message, broker.headers)
+	require.NoError(t, err)
+	assert.True(t, redelivered)
+	assert.Equal(t, "user-events.retry", broker.topic)
+	assert.Equal(t, 2, broker.headers.RetryCount())
+
+	// Exhausted messages are left to the dead letter queue
+	redelivered, err = router.Redeliver(message, broker.headers)
+	require.NoError(t, err)
+	assert.False(t, redelivered)
+}
+
+func TestRetryRouter_DelaysRedeliveries(t *testing.T) {
+	broker := &delayingBroker{publishingBroker: publishingBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}}
+	router := messagebroker.NewRetryRouter(broker, config.MessageBrokerConfig{MaxRedeliveries: 3, RetryTopicFormat: "{topic}.retry", RetryDelay: time.Second})
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderOriginalTopic, "user-events")
+	assert.Zero(t, router.Delay(headers), "redeliveries are not delayed without a delayer")
+
+	router.SetDelayer(messagebroker.NewDelayer(broker, nil, nil))
+	for retryCount := 0; retryCount < 3; retryCount++ {
+		headers.SetRetryCount(retryCount)
+		redelivered, err := router.Redeliver([]byte(`{}`), headers)
+		require.NoError(t, err)
+		assert.True(t, redelivered)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, broker.delays)
+}
+
+func TestRetryRouter_ConsumerTopics(t *testing.T) {
+	cfg := config.MessageBrokerConfig{MaxRedeliveries: 3, RetryTopicFormat: "{topic}-retry"}
+	router := messagebroker.NewRetryRouter(nil, cfg)
+	assert.True(t, router.Enabled())
+	assert.Equal(t, []string{"user-events", "user-events-retry"}, router.ConsumerTopics([]string{"user-events"}))
+
+	cfg.MaxRedeliveries = 0
+	router = messagebroker.NewRetryRouter(nil, cfg)
+	assert.False(t, router.Enabled())
+	assert.Equal(t, []string{"user-events"}, router.ConsumerTopics([]string{"user-events"}))
+}
+
+func TestPublishWithHeaders(t *testing.T) {
+	message := []byte(`{}`)
+	headers := kafka.Headers{kafka.HeaderContentType: []byte(kafka.ContentTypeJSON)}
+
+	// Brokers without header support publish without the headers
+	broker := mocks.NewMockMessageBroker(t)
+	broker.EXPECT().Publish("user-events", message).Return(nil)
+	assert.NoError(t, messagebroker.PublishWithHeaders(broker, "user-events", "", message, headers))
+
+	withHeaders := &headerBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}
+	assert.NoError(t, messagebroker.PublishWithHeaders(withHeaders, "user-events", "acme", message, headers))
+	assert.Equal(t, kafka.ContentTypeJSON, withHeaders.headers.ContentType())
+}
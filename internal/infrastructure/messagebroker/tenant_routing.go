@@ -0,0 +1,102 @@
+package messagebroker
+
+import (
+	"strings"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/kafka"
+)
+
+// Tenant routing modes
+const (
+	TenantRoutingNone  = "none"
+	TenantRoutingTopic = "topic"
+	TenantRoutingKey   = "key"
+)
+
+// KeyedPublisher is implemented by brokers that can publish with a partition key.
+// Messages with the same key keep their order and land on the same partition.
+type KeyedPublisher interface {
+	PublishWithKey(topic, key string, message []byte) error
+}
+
+// PublishKeyed publishes with a key when the broker supports it, otherwise without
+func PublishKeyed(broker MessageBroker, topic, key string, message []byte) error {
+	if keyed, ok := broker.(KeyedPublisher); ok && key != "" {
+		return keyed.PublishWithKey(topic, key, message)
+	}
+	return broker.Publish(topic, message)
+}
+
+// HeaderPublisher is implemented by brokers that can publish messages with headers
+type HeaderPublisher interface {
+	PublishWithHeaders(topic, key string, message []byte, headers kafka.Headers) error
+}
+
+// PublishWithHeaders publishes with headers when the broker supports them, otherwise keyed without headers
+func PublishWithHeaders(broker MessageBroker, topic, key string, message []byte, headers kafka.Headers) error {
+	if publisher, ok := broker.(HeaderPublisher); ok {
+		return publisher.PublishWithHeaders(topic, key, message, headers)
+	}
+	return PublishKeyed(broker, topic, key, message)
+}
+
+// TenantRouter decides where events of a tenant are published and which topics are consumed
+type TenantRouter struct {
+	routing     string
+	topicFormat string
+	tenants     []string
+}
+
+// NewTenantRouter creates a tenant router from configuration
+func NewTenantRouter(cfg config.TenancyConfig) *TenantRouter {
+	routing := cfg.Routing
+	if routing == "" {
+		routing = TenantRoutingNone
+	}
+
+	return &TenantRouter{
+		routing:     routing,
+		topicFormat: cfg.TopicFormat,
+		tenants:     cfg.Tenants,
+	}
+}
+
+// Route returns the topic and message key for an event of tenant published to topic.
+// Events without a tenant are published to topic without a key.
+func (r *TenantRouter) Route(topic, tenant string) (string, string) {
+	if tenant == "" {
+		return topic, ""
+	}
+
+	switch r.routing {
+	case TenantRoutingTopic:
+		return r.tenantTopic(topic, tenant), ""
+	case TenantRoutingKey:
+		return topic, tenant
+	default:
+		return topic, ""
+	}
+}
+
+// ConsumerTopics returns the topics to consume. In topic routing mode the per-tenant
+// topics of the configured tenants are consumed next to the shared topics.
+func (r *TenantRouter) ConsumerTopics(topics []string) []string {
+	if r.routing != TenantRoutingTopic {
+		return topics
+	}
+
+	result := make([]string, 0, len(topics)*(len(r.tenants)+1))
+	for _, topic := range topics {
+		result = append(result, topic)
+		for _, tenant := range r.tenants {
+			result = append(result, r.tenantTopic(topic, tenant))
+		}
+	}
+	return result
+}
+
+// tenantTopic formats the per-tenant topic name
+func (r *TenantRouter) tenantTopic(topic, tenant string) string {
+	return strings.NewReplacer("{topic}", topic, "{tenant}", tenant).Replace(r.topicFormat)
+}
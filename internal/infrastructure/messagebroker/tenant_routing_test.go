@@ -0,0 +1,84 @@
+package messagebroker_test
+
+import (
+	"testing"
+
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantRouter_Route(t *testing.T) {
+	tests := []struct {
+		name          string
+		routing       string
+		tenant        string
+		expectedTopic string
+		expectedKey   string
+	}{
+		{name: "none", routing: "none", tenant: "acme", expectedTopic: "user-events"},
+		{name: "default", routing: "", tenant: "acme", expectedTopic: "user-events"},
+		{name: "topic", routing: "topic", tenant: "acme", expectedTopic: "user-events.acme"},
+		{name: "key", routing: "key", tenant: "acme", expectedTopic: "user-events", expectedKey: "acme"},
+		{name: "topic without tenant", routing: "topic", expectedTopic: "user-events"},
+		{name: "key without tenant", routing: "key", expectedTopic: "user-events"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := messagebroker.NewTenantRouter(config.TenancyConfig{
+				Routing:     tt.routing,
+				TopicFormat: "{topic}.{tenant}",
+			})
+
+			topic, key := router.Route("user-events", tt.tenant)
+			assert.Equal(t, tt.expectedTopic, topic)
+			assert.Equal(t, tt.expectedKey, key)
+		})
+	}
+}
+
+func TestTenantRouter_ConsumerTopics(t *testing.T) {
+	cfg := config.TenancyConfig{
+		Routing:     "topic",
+		TopicFormat: "tenant-{tenant}-{topic}",
+		Tenants:     []string{"acme", "globex"},
+	}
+
+	topics := messagebroker.NewTenantRouter(cfg).ConsumerTopics([]string{"user-events"})
+	assert.Equal(t, []string{"user-events", "tenant-acme-user-events", "tenant-globex-user-events"}, topics)
+
+	cfg.Routing = "key"
+	assert.Equal(t, []string{"user-events"}, messagebroker.NewTenantRouter(cfg).ConsumerTopics([]string{"user-events"}))
+}
+
+// keyedBroker records keyed publishes
+type keyedBroker struct {
+	*mocks.MockMessageBroker
+	topic, key string
+}
+
+func (b *keyedBroker) PublishWithKey(topic, key string, message []byte) error {
+	b.topic, b.key = topic, key
+	return nil
+}
+
+func TestPublishKeyed(t *testing.T) {
+	message := []byte(`{}`)
+
+	// Brokers without key support publish without the key
+	broker := mocks.NewMockMessageBroker(t)
+	broker.EXPECT().Publish("user-events", message).Return(nil)
+	assert.NoError(t, messagebroker.PublishKeyed(broker, "user-events", "acme", message))
+
+	keyed := &keyedBroker{MockMessageBroker: mocks.NewMockMessageBroker(t)}
+	assert.NoError(t, messagebroker.PublishKeyed(keyed, "user-events", "acme", message))
+	assert.Equal(t, "user-events", keyed.topic)
+	assert.Equal(t, "acme", keyed.key)
+
+	// Unkeyed messages use the plain publish
+	keyed.EXPECT().Publish("user-events", message).Return(nil)
+	assert.NoError(t, messagebroker.PublishKeyed(keyed, "user-events", "", message))
+}
@@ -0,0 +1,169 @@
+package messagebroker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// DefaultVersionedTopicFormat names the versioned topics of a topic
+const DefaultVersionedTopicFormat = "{topic}.v{version}"
+
+// VersionedTopic is a schema version of a topic
+type VersionedTopic struct {
+	Name    string // Topic name, e.g. user-events.v2
+	Topic   string // Unversioned topic name, e.g. user-events
+	Version int
+}
+
+// EventEncoder encodes an event in the schema of a topic version, e.g. converting it back to the
+// previous schema for consumers that are not migrated yet
+type EventEncoder func(event *events.Event) ([]byte, error)
+
+// TopicVersionRecorder records messages consumed from versioned topics
+type TopicVersionRecorder interface {
+	RecordTopicVersionConsumed(topic string, version int, outdated bool)
+}
+
+// VersionedTopics publishes breaking schema changes of events to a new version of their topic.
+// Topics with a configured version N are published as {topic}.vN; during the deprecation window
+// of a topic its previous version is published as well, encoded by the encoder registered for
+// that version. Topics without a version are published unversioned.
+type VersionedTopics struct {
+	format           string
+	versions         map[string]int
+	dualPublishUntil map[string]time.Time
+	consumerVersions map[string]int
+	encoders         map[string]EventEncoder // "<event type>@<version>" -> encoder
+	topics           map[string]VersionedTopic
+	recorder         TopicVersionRecorder
+	clock            clock.Clock
+}
+
+// NewVersionedTopics creates versioned topics from configuration. A nil recorder records nothing,
+// a nil clock uses the system clock.
+func NewVersionedTopics(cfg config.MessageBrokerConfig, recorder TopicVersionRecorder, clk clock.Clock) *VersionedTopics {
+	format := cfg.VersionedTopicFormat
+	if format == "" {
+		format = DefaultVersionedTopicFormat
+	}
+
+	v := &VersionedTopics{
+		format:           format,
+		versions:         cfg.TopicVersions,
+		dualPublishUntil: cfg.DualPublishUntil,
+		consumerVersions: cfg.ConsumerTopicVersions,
+		encoders:         make(map[string]EventEncoder),
+		topics:           make(map[string]VersionedTopic),
+		recorder:         recorder,
+		clock:            clock.OrDefault(clk),
+	}
+	for topic, latest := range cfg.TopicVersions {
+		for version := 1; version <= latest; version++ {
+			versioned := v.Topic(topic, version)
+			v.topics[versioned.Name] = versioned
+		}
+	}
+	return v
+}
+
+// Latest returns the current version of a topic, 0 for unversioned topics
+func (v *VersionedTopics) Latest(topic string) int {
+	return v.versions[topic]
+}
+
+// Topic returns a version of a topic
+func (v *VersionedTopics) Topic(topic string, version int) VersionedTopic {
+	name := strings.NewReplacer("{topic}", topic, "{version}", strconv.Itoa(version)).Replace(v.format)
+	return VersionedTopic{Name: name, Topic: topic, Version: version}
+}
+
+// Lookup returns the topic version of a versioned topic name
+func (v *VersionedTopics) Lookup(name string) (VersionedTopic, bool) {
+	versioned, ok := v.topics[name]
+	return versioned, ok
+}
+
+// RegisterEncoder registers the encoder of an event type for a topic version.
+// Versions without an encoder receive the event as JSON.
+func (v *VersionedTopics) RegisterEncoder(eventType string, version int, encoder EventEncoder) {
+	v.encoders[encoderKey(eventType, version)] = encoder
+}
+
+// PublishTopics returns the versions an event published to topic goes to, latest first: the latest
+// version, and the previous one until the end of the topic's deprecation window. An unversioned
+// topic is returned as is, with version 0.
+func (v *VersionedTopics) PublishTopics(topic string) []VersionedTopic {
+	latest := v.versions[topic]
+	if latest <= 0 {
+		return []VersionedTopic{{Name: topic, Topic: topic}}
+	}
+
+	result := []VersionedTopic{v.Topic(topic, latest)}
+	if until, ok := v.dualPublishUntil[topic]; ok && latest > 1 && v.clock.Now().Before(until) {
+		result = append(result, v.Topic(topic, latest-1))
+	}
+	return result
+}
+
+// Encode encodes an event for a topic version
+func (v *VersionedTopics) Encode(event *events.Event, version int) ([]byte, error) {
+	if encoder, ok := v.encoders[encoderKey(event.Type, version)]; ok {
+		return encoder(event)
+	}
+	return json.Marshal(event)
+}
+
+// Publish publishes an event of topic to every version being published: each version is encoded
+// and handed to send, which routes and publishes it. Publication stops at the first error.
+func (v *VersionedTopics) Publish(event *events.Event, topic string, send func(topic VersionedTopic, message []byte) error) error {
+	for _, versioned := range v.PublishTopics(topic) {
+		message, err := v.Encode(event, versioned.Version)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s for %s: %w", event.Type, versioned.Name, err)
+		}
+		if err := send(versioned, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConsumerTopics returns the topics to consume, preferring the latest version of each versioned
+// topic. A consumer that is not migrated yet pins the version it consumes in configuration.
+func (v *VersionedTopics) ConsumerTopics(topics []string) []string {
+	result := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		latest := v.versions[topic]
+		if latest <= 0 {
+			result = append(result, topic)
+			continue
+		}
+		version := latest
+		if pinned, ok := v.consumerVersions[topic]; ok && pinned > 0 && pinned < latest {
+			version = pinned
+		}
+		result = append(result, v.Topic(topic, version).Name)
+	}
+	return result
+}
+
+// RecordConsumed records a message consumed from a topic when it is a versioned topic, flagging
+// versions older than the latest one
+func (v *VersionedTopics) RecordConsumed(name string) {
+	versioned, ok := v.topics[name]
+	if !ok || v.recorder == nil {
+		return
+	}
+	v.recorder.RecordTopicVersionConsumed(versioned.Topic, versioned.Version, versioned.Version < v.versions[versioned.Topic])
+}
+
+func encoderKey(eventType string, version int) string {
+	return eventType + "@" + strconv.Itoa(version)
+}
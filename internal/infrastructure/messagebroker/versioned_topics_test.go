@@ -0,0 +1,84 @@
+package messagebroker_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/infrastructure/config"
+	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionRecorder records consumed topic versions
+type versionRecorder struct {
+	consumed []string
+}
+
+func (r *versionRecorder) RecordTopicVersionConsumed(topic string, version int, outdated bool) {
+	r.consumed = append(r.consumed, fmt.Sprintf("%s@%d outdated=%t", topic, version, outdated))
+}
+
+func newVersionedTopics(fake *clock.Fake, recorder messagebroker.TopicVersionRecorder) *messagebroker.VersionedTopics {
+	return messagebroker.NewVersionedTopics(config.MessageBrokerConfig{
+		TopicVersions:         map[string]int{"user-events": 2},
+		DualPublishUntil:      map[string]time.Time{"user-events": fake.Now().Add(24 * time.Hour)},
+		ConsumerTopicVersions: map[string]int{"user-events": 1},
+	}, recorder, fake)
+}
+
+func TestVersionedTopics_Publish(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	versions := newVersionedTopics(fake, nil)
+	versions.RegisterEncoder("user.created", 1, func(event *events.Event) ([]byte, error) {
+		return []byte(`{"legacy":true}`), nil
+	})
+
+	event := &events.Event{Type: "user.created"}
+	published := map[string]string{}
+	send := func(topic messagebroker.VersionedTopic, message []byte) error {
+		published[topic.Name] = string(message)
+		return nil
+	}
+
+	// Both versions are published during the deprecation window, each in its own schema
+	require.NoError(t, versions.Publish(event, "user-events", send))
+	require.Len(t, published, 2)
+	assert.JSONEq(t, `{"legacy":true}`, published["user-events.v1"])
+	var latest events.Event
+	require.NoError(t, json.Unmarshal([]byte(published["user-events.v2"]), &latest))
+	assert.Equal(t, "user.created", latest.Type)
+
+	// Once the window ends only the latest version is published
+	fake.Advance(24 * time.Hour)
+	assert.Equal(t, []messagebroker.VersionedTopic{{Name: "user-events.v2", Topic: "user-events", Version: 2}}, versions.PublishTopics("user-events"))
+
+	// Unversioned topics are published as is
+	assert.Equal(t, []messagebroker.VersionedTopic{{Name: "order-events", Topic: "order-events"}}, versions.PublishTopics("order-events"))
+}
+
+func TestVersionedTopics_Consumers(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	recorder := &versionRecorder{}
+
+	// Consumers prefer the latest version unless they pin an older one
+	latest := messagebroker.NewVersionedTopics(config.MessageBrokerConfig{TopicVersions: map[string]int{"user-events": 2}}, nil, fake)
+	assert.Equal(t, []string{"user-events.v2", "order-events"}, latest.ConsumerTopics([]string{"user-events", "order-events"}))
+
+	pinned := newVersionedTopics(fake, recorder)
+	assert.Equal(t, []string{"user-events.v1", "order-events"}, pinned.ConsumerTopics([]string{"user-events", "order-events"}))
+
+	pinned.RecordConsumed("user-events.v1")
+	pinned.RecordConsumed("user-events.v2")
+	pinned.RecordConsumed("order-events")
+	assert.Equal(t, []string{"user-events@1 outdated=true", "user-events@2 outdated=false"}, recorder.consumed)
+
+	versioned, ok := pinned.Lookup("user-events.v1")
+	require.True(t, ok)
+	assert.Equal(t, 1, versioned.Version)
+}
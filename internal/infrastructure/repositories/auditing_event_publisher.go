@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/audit"
+)
+
+// AuditedEventTypes maps the types of the events recorded in the audit log to their category
+var AuditedEventTypes = map[string]string{
+	"user.login":                    audit.CategoryAuth,
+	"auth.magic_link_requested":     audit.CategoryAuth,
+	"auth.magic_link_consumed":      audit.CategoryAuth,
+	"auth.login_failed":             audit.CategoryAuth,
+	"auth.account_locked":           audit.CategoryAuth,
+	"user.password_reset_requested": audit.CategoryAuth,
+	"user.password_reset":           audit.CategoryAuth,
+	"user.email_verified":           audit.CategoryAuth,
+}
+
+// AuditLogger logs the events that could not be recorded in the audit log
+type AuditLogger interface {
+	Error(msg string, args ...interface{})
+}
+
+// AuditingEventPublisher records the audited events in the audit log once published. Failing to
+// record an event is logged, not returned, so signing in does not depend on the audit log.
+type AuditingEventPublisher struct {
+	publisher repositories.EventPublisher
+	log       *audit.Log
+	logger    AuditLogger
+}
+
+// NewAuditingEventPublisher creates a publisher recording the audited events published through publisher
+func NewAuditingEventPublisher(publisher repositories.EventPublisher, log *audit.Log, logger AuditLogger) *AuditingEventPublisher {
+	return &AuditingEventPublisher{
+		publisher: publisher,
+		log:       log,
+		logger:    logger,
+	}
+}
+
+// PublishEvent publishes an event, then records it when audited
+func (p *AuditingEventPublisher) PublishEvent(ctx context.Context, event *domainEvent.Event) error {
+	if err := p.publisher.PublishEvent(ctx, event); err != nil {
+		return err
+	}
+	p.record(ctx, event)
+	return nil
+}
+
+// PublishEvents publishes multiple events, then records the audited ones
+func (p *AuditingEventPublisher) PublishEvents(ctx context.Context, events []*domainEvent.Event) error {
+	if err := p.publisher.PublishEvents(ctx, events); err != nil {
+		return err
+	}
+	for _, event := range events {
+		p.record(ctx, event)
+	}
+	return nil
+}
+
+// record appends an audited event to the audit log, the user it is about as actor and subject
+func (p *AuditingEventPublisher) record(ctx context.Context, event *domainEvent.Event) {
+	category, ok := AuditedEventTypes[event.Type]
+	if !ok {
+		return
+	}
+
+	var data struct {
+		UserID string `json:"user_id"`
+		LinkID string `json:"link_id"`
+	}
+	_ = json.Unmarshal(event.Data, &data)
+	details := map[string]string{"event_id": event.ID.String()}
+	if event.CorrelationID != "" {
+		details["correlation_id"] = event.CorrelationID
+	}
+	if !event.TenantID.IsZero() {
+		details["tenant_id"] = event.TenantID.String()
+	}
+	if data.LinkID != "" {
+		details["link_id"] = data.LinkID
+	}
+
+	err := p.log.Record(ctx, audit.Record{
+		Category:   category,
+		Action:     event.Type,
+		Actor:      data.UserID,
+		Subject:    data.UserID,
+		Details:    details,
+		OccurredAt: event.Timestamp,
+	})
+	if err != nil && p.logger != nil {
+		p.logger.Error("Failed to record %s event %s in the audit log: %v", event.Type, event.ID.String(), err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"go-clean-ddd-es-template/internal/domain/events"
 	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
 	"go-clean-ddd-es-template/pkg/resilience"
 )
 
@@ -13,13 +14,18 @@ import (
 type CircuitBreakerEventPublisher struct {
 	publisher      repositories.EventPublisher
 	circuitBreaker *resilience.CircuitBreaker
+	clock          clock.Clock
 }
 
 // NewCircuitBreakerEventPublisher creates a new circuit breaker event publisher
 func NewCircuitBreakerEventPublisher(publisher repositories.EventPublisher, config resilience.CircuitBreakerConfig) *CircuitBreakerEventPublisher {
+	circuitBreaker := resilience.NewCircuitBreaker(config)
+	resilience.DefaultRegistry().Register("event_publisher", circuitBreaker)
+
 	return &CircuitBreakerEventPublisher{
 		publisher:      publisher,
-		circuitBreaker: resilience.NewCircuitBreaker(config),
+		circuitBreaker: circuitBreaker,
+		clock:          clock.OrDefault(config.Clock),
 	}
 }
 
@@ -54,7 +60,7 @@ func (cb *CircuitBreakerEventPublisher) PublishEventWithRetry(ctx context.Contex
 					select {
 					case <-ctx.Done():
 						return ctx.Err()
-					case <-time.After(backoff):
+					case <-cb.clock.After(backoff):
 						continue
 					}
 				}
@@ -79,7 +85,7 @@ func (cb *CircuitBreakerEventPublisher) PublishEventsWithRetry(ctx context.Conte
 					select {
 					case <-ctx.Done():
 						return ctx.Err()
-					case <-time.After(backoff):
+					case <-cb.clock.After(backoff):
 						continue
 					}
 				}
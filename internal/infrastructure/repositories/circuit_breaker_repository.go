@@ -16,9 +16,12 @@ type CircuitBreakerUserWriteRepository struct {
 
 // NewCircuitBreakerUserWriteRepository creates a new circuit breaker repository
 func NewCircuitBreakerUserWriteRepository(repository repositories.UserWriteRepository, config resilience.CircuitBreakerConfig) *CircuitBreakerUserWriteRepository {
+	circuitBreaker := resilience.NewCircuitBreaker(config)
+	resilience.DefaultRegistry().Register("user_write_repository", circuitBreaker)
+
 	return &CircuitBreakerUserWriteRepository{
 		repository:     repository,
-		circuitBreaker: resilience.NewCircuitBreaker(config),
+		circuitBreaker: circuitBreaker,
 	}
 }
 
@@ -91,12 +94,78 @@ type CircuitBreakerUserReadRepository struct {
 
 // NewCircuitBreakerUserReadRepository creates a new circuit breaker read repository
 func NewCircuitBreakerUserReadRepository(repository repositories.UserReadRepository, config resilience.CircuitBreakerConfig) *CircuitBreakerUserReadRepository {
+	circuitBreaker := resilience.NewCircuitBreaker(config)
+	resilience.DefaultRegistry().Register("user_read_repository", circuitBreaker)
+
 	return &CircuitBreakerUserReadRepository{
 		repository:     repository,
-		circuitBreaker: resilience.NewCircuitBreaker(config),
+		circuitBreaker: circuitBreaker,
 	}
 }
 
+// SaveUser wraps repository.SaveUser with circuit breaker
+func (r *CircuitBreakerUserReadRepository) SaveUser(ctx context.Context, user *entities.UserReadModel) error {
+	return r.circuitBreaker.Execute(ctx, func() error {
+		return r.repository.SaveUser(ctx, user)
+	})
+}
+
+// GetUserByID wraps repository.GetUserByID with circuit breaker
+func (r *CircuitBreakerUserReadRepository) GetUserByID(ctx context.Context, userID string) (*entities.UserReadModel, error) {
+	return r.GetByID(ctx, userID)
+}
+
+// GetUserByEmail wraps repository.GetUserByEmail with circuit breaker
+func (r *CircuitBreakerUserReadRepository) GetUserByEmail(ctx context.Context, email string) (*entities.UserReadModel, error) {
+	return r.GetByEmail(ctx, email)
+}
+
+// ListUsers wraps repository.ListUsers with circuit breaker
+func (r *CircuitBreakerUserReadRepository) ListUsers(ctx context.Context, page, pageSize int) ([]*entities.UserReadModel, int64, error) {
+	var total int64
+	result, err := r.circuitBreaker.ExecuteWithResult(ctx, func() (interface{}, error) {
+		users, count, err := r.repository.ListUsers(ctx, page, pageSize)
+		total = count
+		return users, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return result.([]*entities.UserReadModel), total, nil
+}
+
+// UpdateUser wraps repository.UpdateUser with circuit breaker
+func (r *CircuitBreakerUserReadRepository) UpdateUser(ctx context.Context, user *entities.UserReadModel) error {
+	return r.circuitBreaker.Execute(ctx, func() error {
+		return r.repository.UpdateUser(ctx, user)
+	})
+}
+
+// DeleteUser wraps repository.DeleteUser with circuit breaker
+func (r *CircuitBreakerUserReadRepository) DeleteUser(ctx context.Context, userID string) error {
+	return r.circuitBreaker.Execute(ctx, func() error {
+		return r.repository.DeleteUser(ctx, userID)
+	})
+}
+
+// SaveEvent wraps repository.SaveEvent with circuit breaker
+func (r *CircuitBreakerUserReadRepository) SaveEvent(ctx context.Context, event *entities.UserEvent) error {
+	return r.circuitBreaker.Execute(ctx, func() error {
+		return r.repository.SaveEvent(ctx, event)
+	})
+}
+
+// GetUserEvents wraps repository.GetUserEvents with circuit breaker
+func (r *CircuitBreakerUserReadRepository) GetUserEvents(ctx context.Context, userID string) ([]*entities.UserEvent, error) {
+	result, err := r.circuitBreaker.ExecuteWithResult(ctx, func() (interface{}, error) {
+		return r.repository.GetUserEvents(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*entities.UserEvent), nil
+}
+
 // GetByID wraps repository.GetByID with circuit breaker
 func (r *CircuitBreakerUserReadRepository) GetByID(ctx context.Context, userID string) (*entities.UserReadModel, error) {
 	result, err := r.circuitBreaker.ExecuteWithResult(ctx, func() (interface{}, error) {
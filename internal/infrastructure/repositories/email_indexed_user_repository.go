@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/requestcost"
+)
+
+// EmailIndexedUserRepository wraps UserRepository with an index of user emails, kept up to date
+// by the event consumer, so users are read by id instead of by email and emails no user holds,
+// e.g. logins with mistyped or guessed emails, are rejected without a query
+type EmailIndexedUserRepository struct {
+	repository repositories.UserRepository
+	index      *cache.KeyIndex
+}
+
+// NewEmailIndexedUserRepository creates a repository looking emails up in index
+func NewEmailIndexedUserRepository(repository repositories.UserRepository, index *cache.KeyIndex) *EmailIndexedUserRepository {
+	return &EmailIndexedUserRepository{
+		repository: repository,
+		index:      index,
+	}
+}
+
+// Create creates the user and indexes its email, so it can log in before its event is consumed
+func (r *EmailIndexedUserRepository) Create(ctx context.Context, user *entities.User) error {
+	if err := r.repository.Create(ctx, user); err != nil {
+		return err
+	}
+	r.index.Put(user.GetID(), user.GetEmail())
+	return nil
+}
+
+// GetByID reads the user by id
+func (r *EmailIndexedUserRepository) GetByID(ctx context.Context, id string) (*entities.User, error) {
+	return r.repository.GetByID(ctx, id)
+}
+
+// GetByEmail reads the user holding an indexed email by id, and by email otherwise. Emails ruled
+// out by the index are not found without reading the repository.
+func (r *EmailIndexedUserRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	if !r.index.MayExist(email) {
+		requestcost.RecordCacheHit(ctx)
+		return nil, fmt.Errorf("user not found")
+	}
+
+	if id, ok := r.index.Lookup(email); ok {
+		user, err := r.repository.GetByID(ctx, id)
+		if err == nil && user.GetEmail() == email {
+			requestcost.RecordCacheHit(ctx)
+			return user, nil
+		}
+		// The index is behind the repository, e.g. the user was deleted
+		r.index.Remove(id)
+	}
+	requestcost.RecordCacheMiss(ctx)
+
+	user, err := r.repository.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	r.index.Put(user.GetID(), user.GetEmail())
+	return user, nil
+}
+
+// Update updates the user and indexes its email
+func (r *EmailIndexedUserRepository) Update(ctx context.Context, user *entities.User) error {
+	if err := r.repository.Update(ctx, user); err != nil {
+		return err
+	}
+	r.index.Put(user.GetID(), user.GetEmail())
+	return nil
+}
+
+// Delete deletes the user and removes its email from the index
+func (r *EmailIndexedUserRepository) Delete(ctx context.Context, id string) error {
+	if err := r.repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.index.Remove(id)
+	return nil
+}
+
+// List lists the users
+func (r *EmailIndexedUserRepository) List(ctx context.Context) ([]*entities.User, error) {
+	return r.repository.List(ctx)
+}
+
+// UserEmailSource lists the emails of the users of the write database
+type UserEmailSource interface {
+	// ListEmails calls fn with the ID and email of every user
+	ListEmails(ctx context.Context, fn func(userID, email string)) error
+}
+
+// EmailIndexRefresher rebuilds the email index of users from the write database at start and
+// every interval. Between rebuilds the index follows the events the instance consumes; rebuilds
+// catch up with the users of the partitions other instances consume and drop deleted emails from
+// the bloom filter.
+type EmailIndexRefresher struct {
+	source   UserEmailSource
+	index    *cache.KeyIndex
+	interval time.Duration
+	clock    clock.Clock
+	logger   ArchiverLogger
+}
+
+// NewEmailIndexRefresher creates a refresher rebuilding index from the emails of source every
+// interval
+func NewEmailIndexRefresher(source UserEmailSource, index *cache.KeyIndex, interval time.Duration, clk clock.Clock, logger ArchiverLogger) *EmailIndexRefresher {
+	return &EmailIndexRefresher{
+		source:   source,
+		index:    index,
+		interval: interval,
+		clock:    clock.OrDefault(clk),
+		logger:   logger,
+	}
+}
+
+// Run rebuilds the index every interval until ctx is done
+func (r *EmailIndexRefresher) Run(ctx context.Context) error {
+	for {
+		if err := r.RefreshOnce(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("Failed to rebuild the email index: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.clock.After(r.interval):
+		}
+	}
+}
+
+// RefreshOnce rebuilds the index from the emails of the source
+func (r *EmailIndexRefresher) RefreshOnce(ctx context.Context) error {
+	return r.index.Rebuild(func(put func(id, key string)) error {
+		return r.source.ListEmails(ctx, put)
+	})
+}
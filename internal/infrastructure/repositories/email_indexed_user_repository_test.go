@@ -0,0 +1,85 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories/mocks"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// emailSource lists the emails of fixed users
+type emailSource map[string]string
+
+func (s emailSource) ListEmails(ctx context.Context, fn func(userID, email string)) error {
+	for id, email := range s {
+		fn(id, email)
+	}
+	return nil
+}
+
+func TestEmailIndexedUserRepository_GetByEmail(t *testing.T) {
+	ctx := context.Background()
+	alice, err := entities.NewUser("alice@example.com", "Alice")
+	require.NoError(t, err)
+	index := cache.NewKeyIndex(100, 0.01, 0)
+	refresher := infraRepos.NewEmailIndexRefresher(emailSource{alice.GetID(): "alice@example.com"}, index, 0, nil, nil)
+	require.NoError(t, refresher.RefreshOnce(ctx))
+
+	userRepo := mocks.NewMockUserRepository(t)
+	repository := infraRepos.NewEmailIndexedUserRepository(userRepo, index)
+
+	userRepo.EXPECT().GetByID(mock.Anything, alice.GetID()).Return(alice, nil).Once()
+	user, err := repository.GetByEmail(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Same(t, alice, user, "indexed emails are read by id")
+
+	_, err = repository.GetByEmail(ctx, "nobody@example.com")
+	assert.Error(t, err, "emails ruled out by the bloom filter are not read")
+}
+
+func TestEmailIndexedUserRepository_IndexesMisses(t *testing.T) {
+	ctx := context.Background()
+	bob, err := entities.NewUser("bob@example.com", "Bob")
+	require.NoError(t, err)
+	index := cache.NewKeyIndex(100, 0.01, 0)
+	userRepo := mocks.NewMockUserRepository(t)
+	repository := infraRepos.NewEmailIndexedUserRepository(userRepo, index)
+
+	userRepo.EXPECT().GetByEmail(mock.Anything, "bob@example.com").Return(bob, nil).Once()
+	user, err := repository.GetByEmail(ctx, "bob@example.com")
+	require.NoError(t, err)
+	assert.Same(t, bob, user, "a cold index reads by email")
+	id, ok := index.Lookup("bob@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, bob.GetID(), id)
+
+	// The user was deleted before its event was consumed
+	userRepo.EXPECT().GetByID(mock.Anything, bob.GetID()).Return(nil, errors.New("user not found")).Once()
+	userRepo.EXPECT().GetByEmail(mock.Anything, "bob@example.com").Return(nil, errors.New("user not found")).Once()
+	_, err = repository.GetByEmail(ctx, "bob@example.com")
+	assert.Error(t, err)
+	_, ok = index.Lookup("bob@example.com")
+	assert.False(t, ok, "stale entries are removed")
+}
+
+func TestEmailIndexedUserRepository_CreateIndexesEmail(t *testing.T) {
+	ctx := context.Background()
+	index := cache.NewKeyIndex(100, 0.01, 0)
+	require.NoError(t, index.Rebuild(func(put func(id, key string)) error { return nil }))
+	userRepo := mocks.NewMockUserRepository(t)
+	repository := infraRepos.NewEmailIndexedUserRepository(userRepo, index)
+
+	carol, err := entities.NewUser("carol@example.com", "Carol")
+	require.NoError(t, err)
+	userRepo.EXPECT().Create(mock.Anything, carol).Return(nil).Once()
+	require.NoError(t, repository.Create(ctx, carol))
+	assert.True(t, index.MayExist("carol@example.com"), "users created by the instance log in before their event is consumed")
+}
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/tenantkeys"
+)
+
+// encryptedEventData is the data of a tenant event as stored, encrypted with the tenant's data key
+type encryptedEventData struct {
+	TenantID   string `json:"tenant_id"`
+	KeyVersion int    `json:"key_version"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptingEventStore wraps EventStore, encrypting the data of tenant events, which holds their
+// PII, with the data key of their tenant. Events without a tenant are stored as is.
+type EncryptingEventStore struct {
+	eventStore repositories.EventStore
+	keyring    *tenantkeys.Keyring
+}
+
+// NewEncryptingEventStore creates a new event store encrypting tenant event data
+func NewEncryptingEventStore(eventStore repositories.EventStore, keyring *tenantkeys.Keyring) *EncryptingEventStore {
+	return &EncryptingEventStore{
+		eventStore: eventStore,
+		keyring:    keyring,
+	}
+}
+
+// SaveEvent saves a copy of the event with its data encrypted; the event itself is left as is
+// for publishing
+func (s *EncryptingEventStore) SaveEvent(ctx context.Context, aggregateID string, event *events.Event) error {
+	if event.TenantID.IsZero() {
+		return s.eventStore.SaveEvent(ctx, aggregateID, event)
+	}
+
+	data, err := encryptEventData(ctx, s.keyring, event.TenantID.String(), event.Data)
+	if err != nil {
+		return err
+	}
+	encrypted := *event
+	encrypted.Data = data
+	return s.eventStore.SaveEvent(ctx, aggregateID, &encrypted)
+}
+
+// AppendEvents appends copies of the events with their data encrypted; the events themselves
+// are left as is for publishing
+func (s *EncryptingEventStore) AppendEvents(ctx context.Context, aggregateID string, expectedVersion int, stream ...*events.Event) error {
+	appender, err := versionedEventStore(s.eventStore)
+	if err != nil {
+		return err
+	}
+
+	encrypted := make([]*events.Event, len(stream))
+	for i, event := range stream {
+		encrypted[i] = event
+		if event.TenantID.IsZero() {
+			continue
+		}
+		data, err := encryptEventData(ctx, s.keyring, event.TenantID.String(), event.Data)
+		if err != nil {
+			return err
+		}
+		copied := *event
+		copied.Data = data
+		encrypted[i] = &copied
+	}
+	return appender.AppendEvents(ctx, aggregateID, expectedVersion, encrypted...)
+}
+
+// GetEvents wraps eventStore.GetEvents, decrypting event data
+func (s *EncryptingEventStore) GetEvents(ctx context.Context, aggregateID string) ([]*events.Event, error) {
+	stored, err := s.eventStore.GetEvents(ctx, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decryptEvents(ctx, stored)
+}
+
+// GetEventsByType wraps eventStore.GetEventsByType, decrypting event data
+func (s *EncryptingEventStore) GetEventsByType(ctx context.Context, eventType string) ([]*events.Event, error) {
+	stored, err := s.eventStore.GetEventsByType(ctx, eventType)
+	if err != nil {
+		return nil, err
+	}
+	return s.decryptEvents(ctx, stored)
+}
+
+// GetEventsSince wraps eventStore.GetEventsSince, decrypting event data
+func (s *EncryptingEventStore) GetEventsSince(ctx context.Context, since time.Time) ([]*events.Event, error) {
+	stored, err := s.eventStore.GetEventsSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	return s.decryptEvents(ctx, stored)
+}
+
+// GetLastEventVersion wraps eventStore.GetLastEventVersion; event stores that cannot tell the
+// version of an aggregate return 0
+func (s *EncryptingEventStore) GetLastEventVersion(ctx context.Context, aggregateID string) (int, error) {
+	versioner, ok := s.eventStore.(interface {
+		GetLastEventVersion(ctx context.Context, aggregateID string) (int, error)
+	})
+	if !ok {
+		return 0, nil
+	}
+	return versioner.GetLastEventVersion(ctx, aggregateID)
+}
+
+// HasCausation wraps eventStore.HasCausation; event stores that cannot look events up by
+// causation report none
+func (s *EncryptingEventStore) HasCausation(ctx context.Context, causationID string) (bool, error) {
+	checker, ok := s.eventStore.(repositories.CausationChecker)
+	if !ok {
+		return false, nil
+	}
+	return checker.HasCausation(ctx, causationID)
+}
+
+// versionedEventStore returns eventStore as a VersionedEventStore, failing for event stores that
+// cannot append with optimistic concurrency
+func versionedEventStore(eventStore repositories.EventStore) (repositories.VersionedEventStore, error) {
+	versioned, ok := eventStore.(repositories.VersionedEventStore)
+	if !ok {
+		return nil, fmt.Errorf("event store %T does not support appending with an expected version", eventStore)
+	}
+	return versioned, nil
+}
+
+// CountEvents wraps the CountEvents of eventStore
+func (s *EncryptingEventStore) CountEvents(ctx context.Context) (int, error) {
+	log, err := eventLog(s.eventStore)
+	if err != nil {
+		return 0, err
+	}
+	return log.CountEvents(ctx)
+}
+
+// ReadEvents wraps the ReadEvents of eventStore, decrypting event data
+func (s *EncryptingEventStore) ReadEvents(ctx context.Context, after *repositories.EventPosition, limit int) ([]*repositories.StoredEvent, error) {
+	log, err := eventLog(s.eventStore)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := log.ReadEvents(ctx, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range stored {
+		data, err := decryptEventData(ctx, s.keyring, record.Event.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt event %s: %w", record.ID, err)
+		}
+		record.Event.Data = data
+	}
+	return stored, nil
+}
+
+// eventLog returns eventStore as an EventLog, failing when it cannot be read as a whole
+func eventLog(eventStore repositories.EventStore) (repositories.EventLog, error) {
+	log, ok := eventStore.(repositories.EventLog)
+	if !ok {
+		return nil, fmt.Errorf("event store %T does not support reading all events", eventStore)
+	}
+	return log, nil
+}
+
+// decryptEvents decrypts the data of events read from the event store
+func (s *EncryptingEventStore) decryptEvents(ctx context.Context, stored []*events.Event) ([]*events.Event, error) {
+	for _, event := range stored {
+		data, err := decryptEventData(ctx, s.keyring, event.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt event %s: %w", event.ID, err)
+		}
+		event.Data = data
+	}
+	return stored, nil
+}
+
+// encryptEventData encrypts event data with the current data key of a tenant
+func encryptEventData(ctx context.Context, keyring *tenantkeys.Keyring, tenant string, data []byte) ([]byte, error) {
+	ciphertext, err := keyring.Encrypt(ctx, tenant, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt event data: %w", err)
+	}
+	return json.Marshal(encryptedEventData{
+		TenantID:   tenant,
+		KeyVersion: ciphertext.KeyVersion,
+		Ciphertext: ciphertext.Data,
+	})
+}
+
+// decryptEventData decrypts stored event data, returned as is when it is not encrypted
+func decryptEventData(ctx context.Context, keyring *tenantkeys.Keyring, data []byte) ([]byte, error) {
+	encrypted, ok := parseEncryptedEventData(data)
+	if !ok {
+		return data, nil
+	}
+	return keyring.Decrypt(ctx, encrypted.TenantID, &tenantkeys.Ciphertext{
+		KeyVersion: encrypted.KeyVersion,
+		Data:       encrypted.Ciphertext,
+	})
+}
+
+// parseEncryptedEventData decodes stored event data encrypted with a tenant data key
+func parseEncryptedEventData(data []byte) (*encryptedEventData, bool) {
+	var encrypted encryptedEventData
+	if err := json.Unmarshal(data, &encrypted); err != nil || encrypted.TenantID == "" || encrypted.KeyVersion == 0 || encrypted.Ciphertext == nil {
+		return nil, false
+	}
+	return &encrypted, true
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/projection"
+)
+
+// EventLogSource reads the whole event store as the source of projections. Positions are the
+// global positions of events, checkpoints saved before events were numbered being read as
+// "<stored at>/<version>/<aggregate ID>".
+type EventLogSource struct {
+	events repositories.EventLog
+}
+
+// NewEventLogSource creates a projection source reading events
+func NewEventLogSource(events repositories.EventLog) *EventLogSource {
+	return &EventLogSource{events: events}
+}
+
+// Read returns up to limit events stored after position
+func (s *EventLogSource) Read(ctx context.Context, position string, limit int) ([]projection.Event, error) {
+	after, err := parseEventPosition(position)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.events.ReadEvents(ctx, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := make([]projection.Event, 0, len(stored))
+	for _, event := range stored {
+		batch = append(batch, projection.Event{
+			ID:          event.ID,
+			AggregateID: event.AggregateID,
+			Type:        event.Event.Type,
+			Data:        event.Event.Data,
+			Version:     event.Event.Version,
+			Timestamp:   event.Event.Timestamp,
+			Position:    formatEventPosition(event.Position()),
+		})
+	}
+	return batch, nil
+}
+
+// formatEventPosition encodes an event position as a projection position
+func formatEventPosition(position repositories.EventPosition) string {
+	if position.GlobalPosition == 0 {
+		return position.StoredAt.UTC().Format(time.RFC3339Nano) + "/" + strconv.Itoa(position.Version) + "/" + position.AggregateID
+	}
+	return strconv.FormatInt(position.GlobalPosition, 10)
+}
+
+// parseEventPosition decodes a projection position, nil for the start of the event store
+func parseEventPosition(position string) (*repositories.EventPosition, error) {
+	if position == "" {
+		return nil, nil
+	}
+	if !strings.Contains(position, "/") {
+		globalPosition, err := strconv.ParseInt(position, 10, 64)
+		if err != nil || globalPosition <= 0 {
+			return nil, fmt.Errorf("invalid event position %q", position)
+		}
+		return &repositories.EventPosition{GlobalPosition: globalPosition}, nil
+	}
+	parts := strings.SplitN(position, "/", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid event position %q", position)
+	}
+	storedAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid event position %q: %w", position, err)
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid event position %q: %w", position, err)
+	}
+	return &repositories.EventPosition{StoredAt: storedAt, AggregateID: parts[2], Version: version}, nil
+}
@@ -0,0 +1,65 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// positionRecordingLog returns its events once, recording the positions it is read after
+type positionRecordingLog struct {
+	events []*repositories.StoredEvent
+	after  []*repositories.EventPosition
+}
+
+func (l *positionRecordingLog) CountEvents(ctx context.Context) (int, error) {
+	return len(l.events), nil
+}
+
+func (l *positionRecordingLog) ReadEvents(ctx context.Context, after *repositories.EventPosition, limit int) ([]*repositories.StoredEvent, error) {
+	l.after = append(l.after, after)
+	events := l.events
+	l.events = nil
+	return events, nil
+}
+
+func TestEventLogSource_PositionRoundTrip(t *testing.T) {
+	storedAt := time.Date(2026, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	log := &positionRecordingLog{events: []*repositories.StoredEvent{{
+		ID:             "event-1",
+		GlobalPosition: 42,
+		AggregateID:    "user/1",
+		Event:          &domainEvent.Event{Type: "user.created", Data: []byte(`{}`), Version: 3, Timestamp: storedAt},
+	}}}
+	source := infraRepos.NewEventLogSource(log)
+	ctx := context.Background()
+
+	batch, err := source.Read(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, "event-1", batch[0].ID)
+	assert.Equal(t, "user.created", batch[0].Type)
+
+	_, err = source.Read(ctx, batch[0].Position, 10)
+	require.NoError(t, err)
+	require.Len(t, log.after, 2)
+	assert.Nil(t, log.after[0], "an empty position reads from the start")
+	assert.Equal(t, "42", batch[0].Position)
+	assert.Equal(t, &repositories.EventPosition{GlobalPosition: 42}, log.after[1])
+
+	_, err = source.Read(ctx, "2026-03-01T12:30:00.123456789Z/3/user/1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, &repositories.EventPosition{StoredAt: storedAt, AggregateID: "user/1", Version: 3}, log.after[2], "checkpoints saved before events were numbered still resume")
+
+	for _, position := range []string{"not a position", "0", "-1"} {
+		_, err = source.Read(ctx, position, 10)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/queryplan"
+)
+
+// ExplainableUserReadRepository is a read repository that can describe the queries it runs
+type ExplainableUserReadRepository interface {
+	repositories.UserReadRepository
+	DescribeGetUserByID(userID string) queryplan.Query
+	DescribeGetUserByEmail(email string) queryplan.Query
+	DescribeListUsers(page, pageSize int) queryplan.Query
+	DescribeGetUserEvents(userID string) queryplan.Query
+	DescribeGetEventsByType(eventType string) queryplan.Query
+}
+
+// ExplainUserReadRepository wraps UserReadRepository and logs query plans of slow reads.
+// It is intended for development to help tune read model indexes.
+type ExplainUserReadRepository struct {
+	repository ExplainableUserReadRepository
+	analyzer   *queryplan.Analyzer
+}
+
+// NewExplainUserReadRepository creates a new query plan explaining read repository
+func NewExplainUserReadRepository(repository ExplainableUserReadRepository, analyzer *queryplan.Analyzer) *ExplainUserReadRepository {
+	return &ExplainUserReadRepository{
+		repository: repository,
+		analyzer:   analyzer,
+	}
+}
+
+// SaveUser saves a user
+func (r *ExplainUserReadRepository) SaveUser(ctx context.Context, user *entities.UserReadModel) error {
+	return r.repository.SaveUser(ctx, user)
+}
+
+// GetUserByID retrieves a user by ID and explains the query when it is slow
+func (r *ExplainUserReadRepository) GetUserByID(ctx context.Context, userID string) (*entities.UserReadModel, error) {
+	defer r.observe(r.repository.DescribeGetUserByID(userID), time.Now())
+	return r.repository.GetUserByID(ctx, userID)
+}
+
+// GetUserByEmail retrieves a user by email and explains the query when it is slow
+func (r *ExplainUserReadRepository) GetUserByEmail(ctx context.Context, email string) (*entities.UserReadModel, error) {
+	defer r.observe(r.repository.DescribeGetUserByEmail(email), time.Now())
+	return r.repository.GetUserByEmail(ctx, email)
+}
+
+// ListUsers lists users and explains the query when it is slow
+func (r *ExplainUserReadRepository) ListUsers(ctx context.Context, page, pageSize int) ([]*entities.UserReadModel, int64, error) {
+	defer r.observe(r.repository.DescribeListUsers(page, pageSize), time.Now())
+	return r.repository.ListUsers(ctx, page, pageSize)
+}
+
+// UpdateUser updates a user
+func (r *ExplainUserReadRepository) UpdateUser(ctx context.Context, user *entities.UserReadModel) error {
+	return r.repository.UpdateUser(ctx, user)
+}
+
+// UpgradeUser stores a migrated user, see UserReadModelUpgrader
+func (r *ExplainUserReadRepository) UpgradeUser(ctx context.Context, user *entities.UserReadModel, fromVersion int) error {
+	return upgradeUser(ctx, r.repository, user, fromVersion)
+}
+
+// DeleteUser deletes a user
+func (r *ExplainUserReadRepository) DeleteUser(ctx context.Context, userID string) error {
+	return r.repository.DeleteUser(ctx, userID)
+}
+
+// SaveEvent saves a user event
+func (r *ExplainUserReadRepository) SaveEvent(ctx context.Context, event *entities.UserEvent) error {
+	return r.repository.SaveEvent(ctx, event)
+}
+
+// GetUserEvents retrieves events for a user and explains the query when it is slow
+func (r *ExplainUserReadRepository) GetUserEvents(ctx context.Context, userID string) ([]*entities.UserEvent, error) {
+	defer r.observe(r.repository.DescribeGetUserEvents(userID), time.Now())
+	return r.repository.GetUserEvents(ctx, userID)
+}
+
+// GetEventsByType retrieves events by type and explains the query when it is slow
+func (r *ExplainUserReadRepository) GetEventsByType(ctx context.Context, eventType string) ([]*entities.UserEvent, error) {
+	defer r.observe(r.repository.DescribeGetEventsByType(eventType), time.Now())
+	return r.repository.GetEventsByType(ctx, eventType)
+}
+
+func (r *ExplainUserReadRepository) observe(query queryplan.Query, start time.Time) {
+	r.analyzer.Observe(query, time.Since(start))
+}
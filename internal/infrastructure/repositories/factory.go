@@ -1,12 +1,16 @@
 package repositories
 
 import (
+	"database/sql"
 	"fmt"
 
 	"go-clean-ddd-es-template/internal/domain/repositories"
 	"go-clean-ddd-es-template/internal/infrastructure/config"
 	"go-clean-ddd-es-template/internal/infrastructure/database"
 	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/logger"
+	"go-clean-ddd-es-template/pkg/metrics"
+	"go-clean-ddd-es-template/pkg/queryplan"
 
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -41,14 +45,221 @@ func (f *RepositoryFactory) CreateUserWriteRepository() (repositories.UserWriteR
 
 // CreateUserReadRepository creates user read repository based on config
 func (f *RepositoryFactory) CreateUserReadRepository() (repositories.UserReadRepository, error) {
+	if f.config.ReadModel.LazyMigration {
+		return f.CreateUserReadModelMigrator()
+	}
+	return f.createUserReadRepository()
+}
+
+// CreateUserReadModelMigrator creates a user read repository migrating outdated documents, used
+// by the full read model migration job
+func (f *RepositoryFactory) CreateUserReadModelMigrator() (*MigratingUserReadRepository, error) {
+	repository, err := f.createUserReadRepository()
+	if err != nil {
+		return nil, err
+	}
+	return NewMigratingUserReadRepository(repository, metrics.NewMetrics()), nil
+}
+
+// createUserReadRepository creates the user read repository of the configured database, or of
+// the configured read shards
+func (f *RepositoryFactory) createUserReadRepository() (repositories.UserReadRepository, error) {
+	if len(f.config.ReadShards) == 0 {
+		return f.createReadStoreRepository(f.readDB, &f.config.ReadDatabase)
+	}
+
+	databaseFactory := database.NewDatabaseFactory()
+	shards := make([]ReadShard, 0, len(f.config.ReadShards))
+	for i := range f.config.ReadShards {
+		shardConfig := &f.config.ReadShards[i]
+		db, err := databaseFactory.CreateDatabase(&shardConfig.Database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to read shard %s: %w", shardConfig.Name, err)
+		}
+		repository, err := f.createReadStoreRepository(db, &shardConfig.Database)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, ReadShard{Name: shardConfig.Name, Repository: repository})
+	}
+	return NewShardedUserReadRepository(shards, NewHashShardRouter()), nil
+}
+
+// createReadStoreRepository creates the user read repository of a single read database
+func (f *RepositoryFactory) createReadStoreRepository(db database.Database, dbConfig *config.DatabaseConfig) (repositories.UserReadRepository, error) {
+	var repository ExplainableUserReadRepository
+	var explainer queryplan.Explainer
+
+	switch dbConfig.Type {
+	case "mongodb":
+		client := db.GetDB().(*mongo.Client)
+		mongoRepository := NewMongoUserReadRepository(client, dbConfig.DBName, dbConfig.Collection)
+		// The counters count the users of every shard, so they only replace the count of a single database
+		if f.config.ReadModel.UserCounts && len(f.config.ReadShards) == 0 {
+			mongoRepository.SetCounts(NewMongoUserCountRepository(client, dbConfig.DBName))
+		}
+		repository = mongoRepository
+		explainer = queryplan.NewMongoExplainer(client, dbConfig.DBName)
+	case "postgres":
+		repository = NewPostgresUserReadRepository(db)
+		if sqlDB, ok := db.GetDB().(*sql.DB); ok {
+			explainer = queryplan.NewPostgresExplainer(sqlDB)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported read database type: %s", dbConfig.Type)
+	}
+
+	if !f.config.QueryExplain.Enabled || explainer == nil {
+		return repository, nil
+	}
+
+	analyzer := queryplan.NewAnalyzer(explainer, f.config.QueryExplain.SlowThreshold, logger.NewLogger(logger.LevelInfo))
+	return NewExplainUserReadRepository(repository, analyzer), nil
+}
+
+// CreateUserSummaryRepository creates the user summary repository, kept in the read database
+// next to the read models, or in the main read database when reads are sharded
+func (f *RepositoryFactory) CreateUserSummaryRepository() (repositories.UserSummaryRepository, error) {
+	switch f.config.ReadDatabase.Type {
+	case "mongodb":
+		client := f.readDB.GetDB().(*mongo.Client)
+		repository := NewMongoUserSummaryRepository(client, f.config.ReadDatabase.DBName)
+		if f.config.ReadModel.UserCounts {
+			repository.SetCounts(NewMongoUserCountRepository(client, f.config.ReadDatabase.DBName))
+		}
+		return repository, nil
+	default:
+		return nil, fmt.Errorf("user summaries require a mongodb read database, got %s", f.config.ReadDatabase.Type)
+	}
+}
+
+// CreateUserCountRepository creates the user counters, kept in the read database next to the read
+// models, or in the main read database when reads are sharded
+func (f *RepositoryFactory) CreateUserCountRepository() (repositories.UserCountRepository, error) {
+	switch f.config.ReadDatabase.Type {
+	case "mongodb":
+		client := f.readDB.GetDB().(*mongo.Client)
+		return NewMongoUserCountRepository(client, f.config.ReadDatabase.DBName), nil
+	default:
+		return nil, fmt.Errorf("user counts require a mongodb read database, got %s", f.config.ReadDatabase.Type)
+	}
+}
+
+// CreateUserChangeLogRepository creates the user change log repository, kept in the read database
+// next to the read models, or in the main read database when reads are sharded
+func (f *RepositoryFactory) CreateUserChangeLogRepository() (repositories.UserChangeLogRepository, error) {
+	switch f.config.ReadDatabase.Type {
+	case "mongodb":
+		client := f.readDB.GetDB().(*mongo.Client)
+		return NewMongoUserChangeLogRepository(client, f.config.ReadDatabase.DBName), nil
+	default:
+		return nil, fmt.Errorf("the user change log requires a mongodb read database, got %s", f.config.ReadDatabase.Type)
+	}
+}
+
+// CreateReadModelArchive creates the archive of expired read model documents
+func (f *RepositoryFactory) CreateReadModelArchive() (ReadModelArchive, error) {
+	switch f.config.ReadDatabase.Type {
+	case "mongodb":
+		client := f.readDB.GetDB().(*mongo.Client)
+		return NewMongoReadModelArchive(client, f.config.ReadDatabase.DBName), nil
+	default:
+		return nil, fmt.Errorf("the read model archive requires a mongodb read database, got %s", f.config.ReadDatabase.Type)
+	}
+}
+
+// CreateReadModelReset creates the reset of the read models projected from events: users and their
+// events, and the user summaries, counts and changes when those projections are enabled. The inbox is
+// kept, so events already applied are not applied again on top of the rebuilt read models, and so
+// is the change sequence counter, so changefeed cursors stay valid.
+func (f *RepositoryFactory) CreateReadModelReset() (*MongoReadModelReset, error) {
+	if f.config.ReadDatabase.Type != "mongodb" {
+		return nil, fmt.Errorf("resetting read models requires a mongodb read database, got %s", f.config.ReadDatabase.Type)
+	}
+	if len(f.config.ReadShards) > 0 {
+		return nil, fmt.Errorf("resetting sharded read models is not supported")
+	}
+
+	collections := []string{f.config.ReadDatabase.Collection, f.config.ReadDatabase.Collection + "_events"}
+	if f.config.ReadModel.UserSummaries {
+		collections = append(collections, UserSummaryCollection)
+	}
+	if f.config.ReadModel.UserCounts {
+		collections = append(collections, UserCountCollection)
+	}
+	if f.config.Changefeed.Enabled {
+		collections = append(collections, UserChangeCollection)
+	}
+	client := f.readDB.GetDB().(*mongo.Client)
+	return NewMongoReadModelReset(client, f.config.ReadDatabase.DBName, collections), nil
+}
+
+// CreateInboxRepository creates the inbox of consumed events, kept in the read database so events
+// are recorded in the same transaction as the projection writes
+func (f *RepositoryFactory) CreateInboxRepository() (repositories.InboxRepository, error) {
 	switch f.config.ReadDatabase.Type {
 	case "mongodb":
 		client := f.readDB.GetDB().(*mongo.Client)
-		return NewMongoUserReadRepository(client, f.config.ReadDatabase.DBName, f.config.ReadDatabase.Collection), nil
+		return NewMongoInboxRepository(client, f.config.ReadDatabase.DBName), nil
+	default:
+		return nil, fmt.Errorf("the inbox requires a mongodb read database, got %s", f.config.ReadDatabase.Type)
+	}
+}
+
+// CreateProcessedEventRepository creates the record of events processed by each consumer group,
+// kept in the event database
+func (f *RepositoryFactory) CreateProcessedEventRepository() (repositories.ProcessedEventRepository, error) {
+	switch f.config.EventDatabase.Type {
+	case "postgres":
+		return NewPostgresProcessedEventRepository(f.eventDB.GetDB()), nil
+	default:
+		return nil, fmt.Errorf("processed events require a postgres event database, got %s", f.config.EventDatabase.Type)
+	}
+}
+
+// CreateMagicLinkRepository creates the record of magic links consumed, kept in the write database
+func (f *RepositoryFactory) CreateMagicLinkRepository() (repositories.MagicLinkRepository, error) {
+	switch f.config.WriteDatabase.Type {
+	case "postgres":
+		return NewPostgresMagicLinkRepository(f.writeDB.GetDB()), nil
+	default:
+		return nil, fmt.Errorf("magic links require a postgres write database, got %s", f.config.WriteDatabase.Type)
+	}
+}
+
+// CreateRefreshTokenRepository creates the record of refresh tokens issued, kept in the write
+// database or Redis
+func (f *RepositoryFactory) CreateRefreshTokenRepository() (repositories.RefreshTokenRepository, error) {
+	switch f.config.RefreshTokens.Store {
+	case "postgres":
+		if f.config.WriteDatabase.Type != "postgres" {
+			return nil, fmt.Errorf("the postgres refresh token store requires a postgres write database, got %s", f.config.WriteDatabase.Type)
+		}
+		return NewPostgresRefreshTokenRepository(f.writeDB.GetDB()), nil
+	case "redis":
+		repository, err := NewRedisRefreshTokenRepository(f.config.RefreshTokens.RedisURL, f.config.RefreshTokens.TTL)
+		if err != nil {
+			return nil, err
+		}
+		return repository, nil
+	default:
+		return nil, fmt.Errorf("unsupported refresh token store: %s", f.config.RefreshTokens.Store)
+	}
+}
+
+// CreateLoginAttemptRepository creates the record of failed logins, kept in memory or in the
+// write database
+func (f *RepositoryFactory) CreateLoginAttemptRepository() (repositories.LoginAttemptRepository, error) {
+	switch f.config.LoginLockout.Store {
+	case "memory":
+		return NewInMemoryLoginAttemptRepository(nil), nil
 	case "postgres":
-		return NewPostgresUserReadRepository(f.readDB), nil
+		if f.config.WriteDatabase.Type != "postgres" {
+			return nil, fmt.Errorf("the postgres login lockout store requires a postgres write database, got %s", f.config.WriteDatabase.Type)
+		}
+		return NewPostgresLoginAttemptRepository(f.writeDB.GetDB()), nil
 	default:
-		return nil, fmt.Errorf("unsupported read database type: %s", f.config.ReadDatabase.Type)
+		return nil, fmt.Errorf("unsupported login lockout store: %s", f.config.LoginLockout.Store)
 	}
 }
 
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/tenantkeys"
+)
+
+// StoredEventData is the stored data of an event, encrypted with a tenant data key
+type StoredEventData struct {
+	ID   string
+	Data []byte
+}
+
+// KeyRotationCheckpoint is the progress of re-encrypting the events of a tenant with a version of
+// its data key. LastEventID is empty when no pass is in progress.
+type KeyRotationCheckpoint struct {
+	TenantID    string
+	KeyVersion  int
+	LastEventID string
+	Reencrypted int64 // Events re-encrypted with KeyVersion
+	UpdatedAt   time.Time
+}
+
+// KeyRotationStore reads and rewrites encrypted events for the key rotation job. See
+// PostgresKeyRotationStore.
+type KeyRotationStore interface {
+	StaleEvents(ctx context.Context, tenant string, keyVersion int, afterID string, limit int) ([]*StoredEventData, error)
+	UpdateEventData(ctx context.Context, id string, data []byte) error
+	LoadCheckpoint(ctx context.Context, tenant string) (*KeyRotationCheckpoint, error)
+	SaveCheckpoint(ctx context.Context, checkpoint *KeyRotationCheckpoint) error
+}
+
+// KeyRotationLogger logs the progress and failures of the key rotation job
+type KeyRotationLogger interface {
+	Info(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// KeyRotationJob rotates the data keys of tenants once they reach their maximum age, and
+// re-encrypts the events of each tenant still encrypted with an earlier key version. Events are
+// re-encrypted a batch at a time, checkpointing after each batch, so a job stopped midway resumes
+// where it stopped. Events encrypted with a stale version after a pass ended, e.g. by a replica
+// that had not seen the rotation yet, are re-encrypted by the next pass.
+type KeyRotationJob struct {
+	store     KeyRotationStore
+	keyring   *tenantkeys.Keyring
+	tenants   []string
+	maxAge    time.Duration
+	batchSize int
+	interval  time.Duration
+	clock     clock.Clock
+	logger    KeyRotationLogger
+}
+
+// NewKeyRotationJob creates a job rotating the keys of tenants older than maxAge, 0 for never,
+// and re-encrypting up to batchSize events at a time every interval
+func NewKeyRotationJob(store KeyRotationStore, keyring *tenantkeys.Keyring, tenants []string, maxAge time.Duration, batchSize int, interval time.Duration, clk clock.Clock, logger KeyRotationLogger) *KeyRotationJob {
+	return &KeyRotationJob{
+		store:     store,
+		keyring:   keyring,
+		tenants:   tenants,
+		maxAge:    maxAge,
+		batchSize: batchSize,
+		interval:  interval,
+		clock:     clock.OrDefault(clk),
+		logger:    logger,
+	}
+}
+
+// Run runs a pass for every tenant every interval until ctx is done
+func (j *KeyRotationJob) Run(ctx context.Context) error {
+	for {
+		for _, tenant := range j.tenants {
+			if err := j.RunOnce(ctx, tenant); err != nil && ctx.Err() == nil {
+				j.logger.Error("Failed to rotate the data key of tenant %s: %v", tenant, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-j.clock.After(j.interval):
+		}
+	}
+}
+
+// RunOnce rotates the data key of a tenant if it is due, then re-encrypts the tenant's events
+// encrypted with earlier key versions
+func (j *KeyRotationJob) RunOnce(ctx context.Context, tenant string) error {
+	key, err := j.keyring.Current(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	if j.maxAge > 0 && j.clock.Since(key.CreatedAt) >= j.maxAge {
+		if key, err = j.keyring.Rotate(ctx, tenant); err != nil {
+			return err
+		}
+		j.logger.Info("Rotated the data key of tenant %s to version %d", tenant, key.Version)
+	}
+
+	checkpoint, err := j.store.LoadCheckpoint(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil || checkpoint.KeyVersion != key.Version {
+		checkpoint = &KeyRotationCheckpoint{TenantID: tenant, KeyVersion: key.Version}
+	}
+
+	for ctx.Err() == nil {
+		stale, err := j.store.StaleEvents(ctx, tenant, key.Version, checkpoint.LastEventID, j.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, event := range stale {
+			if err := j.reencrypt(ctx, tenant, event); err != nil {
+				// Skipped until the next pass, so one unreadable event does not stall the rotation
+				j.logger.Error("Failed to re-encrypt event %s of tenant %s: %v", event.ID, tenant, err)
+			} else {
+				checkpoint.Reencrypted++
+			}
+			checkpoint.LastEventID = event.ID
+		}
+
+		// Start the next pass from the first event once this one is done
+		done := len(stale) < j.batchSize
+		if done {
+			checkpoint.LastEventID = ""
+		}
+		checkpoint.UpdatedAt = j.clock.Now().UTC()
+		if err := j.store.SaveCheckpoint(ctx, checkpoint); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// reencrypt re-encrypts the data of an event with the current data key of its tenant
+func (j *KeyRotationJob) reencrypt(ctx context.Context, tenant string, event *StoredEventData) error {
+	data, err := decryptEventData(ctx, j.keyring, event.Data)
+	if err != nil {
+		return err
+	}
+	data, err = encryptEventData(ctx, j.keyring, tenant, data)
+	if err != nil {
+		return err
+	}
+	return j.store.UpdateEventData(ctx, event.ID, data)
+}
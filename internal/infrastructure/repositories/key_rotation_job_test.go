@@ -0,0 +1,182 @@
+package repositories_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/valueobjects"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/secrets"
+	"go-clean-ddd-es-template/pkg/tenantkeys"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryEventStore stores events in memory and serves them to the key rotation job like
+// PostgresEventStore and PostgresKeyRotationStore
+type memoryEventStore struct {
+	events      map[string]*domainEvent.Event // By ID
+	checkpoints map[string]*infraRepos.KeyRotationCheckpoint
+	saves       int // Checkpoints saved
+}
+
+func newMemoryEventStore() *memoryEventStore {
+	return &memoryEventStore{
+		events:      make(map[string]*domainEvent.Event),
+		checkpoints: make(map[string]*infraRepos.KeyRotationCheckpoint),
+	}
+}
+
+func (s *memoryEventStore) SaveEvent(ctx context.Context, aggregateID string, event *domainEvent.Event) error {
+	stored := *event
+	s.events[event.ID.String()] = &stored
+	return nil
+}
+
+func (s *memoryEventStore) GetEvents(ctx context.Context, aggregateID string) ([]*domainEvent.Event, error) {
+	var all []*domainEvent.Event
+	for _, event := range s.events {
+		copied := *event
+		all = append(all, &copied)
+	}
+	return all, nil
+}
+
+func (s *memoryEventStore) GetEventsByType(ctx context.Context, eventType string) ([]*domainEvent.Event, error) {
+	return nil, nil
+}
+
+func (s *memoryEventStore) GetEventsSince(ctx context.Context, since time.Time) ([]*domainEvent.Event, error) {
+	return nil, nil
+}
+
+func (s *memoryEventStore) StaleEvents(ctx context.Context, tenant string, keyVersion int, afterID string, limit int) ([]*infraRepos.StoredEventData, error) {
+	var stale []*infraRepos.StoredEventData
+	for id, event := range s.events {
+		var data struct {
+			TenantID   string `json:"tenant_id"`
+			KeyVersion int    `json:"key_version"`
+		}
+		if json.Unmarshal(event.Data, &data) == nil && data.TenantID == tenant && data.KeyVersion < keyVersion && id > afterID {
+			stale = append(stale, &infraRepos.StoredEventData{ID: id, Data: event.Data})
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].ID < stale[j].ID })
+	if len(stale) > limit {
+		stale = stale[:limit]
+	}
+	return stale, nil
+}
+
+func (s *memoryEventStore) UpdateEventData(ctx context.Context, id string, data []byte) error {
+	s.events[id].Data = data
+	return nil
+}
+
+func (s *memoryEventStore) LoadCheckpoint(ctx context.Context, tenant string) (*infraRepos.KeyRotationCheckpoint, error) {
+	checkpoint, ok := s.checkpoints[tenant]
+	if !ok {
+		return nil, nil
+	}
+	copied := *checkpoint
+	return &copied, nil
+}
+
+func (s *memoryEventStore) SaveCheckpoint(ctx context.Context, checkpoint *infraRepos.KeyRotationCheckpoint) error {
+	copied := *checkpoint
+	s.checkpoints[checkpoint.TenantID] = &copied
+	s.saves++
+	return nil
+}
+
+// discardLogger drops what the key rotation job logs
+type discardLogger struct{}
+
+func (discardLogger) Info(msg string, args ...interface{})  {}
+func (discardLogger) Error(msg string, args ...interface{}) {}
+
+func acmeTenant(t *testing.T) valueobjects.TenantID {
+	tenant, err := valueobjects.ParseTenantID("acme")
+	require.NoError(t, err)
+	return tenant
+}
+
+func keyVersionOf(t *testing.T, event *domainEvent.Event) int {
+	var data struct {
+		KeyVersion int `json:"key_version"`
+	}
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+	return data.KeyVersion
+}
+
+func TestEncryptingEventStore_EncryptsTenantEvents(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryEventStore()
+	eventStore := infraRepos.NewEncryptingEventStore(store, tenantkeys.NewKeyring(secrets.NewMemoryProvider(), nil))
+
+	tenantEvent, err := domainEvent.NewEvent("user.created", map[string]string{"email": "jane@acme.io"}, 1)
+	require.NoError(t, err)
+	tenantEvent.TenantID = acmeTenant(t)
+	require.NoError(t, eventStore.SaveEvent(ctx, "user-1", tenantEvent))
+	assert.Contains(t, string(tenantEvent.Data), "jane@acme.io", "the saved event is left as is for publishing")
+	assert.NotContains(t, string(store.events[tenantEvent.ID.String()].Data), "jane@acme.io")
+
+	plainEvent, err := domainEvent.NewEvent("user.created", map[string]string{"email": "john@example.com"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, eventStore.SaveEvent(ctx, "user-2", plainEvent))
+	assert.Equal(t, plainEvent.Data, store.events[plainEvent.ID.String()].Data)
+
+	events, err := eventStore.GetEvents(ctx, "user-1")
+	require.NoError(t, err)
+	for _, event := range events {
+		assert.JSONEq(t, map[string]string{
+			tenantEvent.ID.String(): `{"email":"jane@acme.io"}`,
+			plainEvent.ID.String():  `{"email":"john@example.com"}`,
+		}[event.ID.String()], string(event.Data))
+	}
+}
+
+func TestKeyRotationJob_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	keyring := tenantkeys.NewKeyring(secrets.NewMemoryProvider(), clk)
+	store := newMemoryEventStore()
+	eventStore := infraRepos.NewEncryptingEventStore(store, keyring)
+	for i := 0; i < 5; i++ {
+		event, err := domainEvent.NewEvent("user.created", map[string]string{"name": fmt.Sprintf("user-%d", i)}, 1)
+		require.NoError(t, err)
+		event.TenantID = acmeTenant(t)
+		require.NoError(t, eventStore.SaveEvent(ctx, "user", event))
+	}
+
+	job := infraRepos.NewKeyRotationJob(store, keyring, []string{"acme"}, 30*24*time.Hour, 2, time.Hour, clk, discardLogger{})
+	require.NoError(t, job.RunOnce(ctx, "acme"))
+	assert.Equal(t, 1, store.checkpoints["acme"].KeyVersion)
+	assert.Zero(t, store.checkpoints["acme"].Reencrypted, "events on the current key are left as is")
+
+	clk.Advance(31 * 24 * time.Hour)
+	require.NoError(t, job.RunOnce(ctx, "acme"))
+	current, err := keyring.Current(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.Version, "keys are rotated once they reach their max age")
+
+	checkpoint := store.checkpoints["acme"]
+	assert.Equal(t, 2, checkpoint.KeyVersion)
+	assert.Equal(t, int64(5), checkpoint.Reencrypted)
+	assert.Empty(t, checkpoint.LastEventID, "finished passes start over from the first event")
+	assert.Equal(t, 1+3, store.saves, "progress is checkpointed after each batch")
+
+	events, err := eventStore.GetEvents(ctx, "user")
+	require.NoError(t, err)
+	for _, event := range events {
+		assert.Equal(t, 2, keyVersionOf(t, store.events[event.ID.String()]))
+		assert.Contains(t, string(event.Data), `"name":"user-`)
+	}
+}
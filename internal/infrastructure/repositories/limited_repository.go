@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// LimitedUserReadRepository wraps UserReadRepository with an adaptive concurrency limit
+type LimitedUserReadRepository struct {
+	repository repositories.UserReadRepository
+	limiter    *resilience.AdaptiveLimiter
+}
+
+// NewLimitedUserReadRepository creates a new concurrency limited read repository
+func NewLimitedUserReadRepository(repository repositories.UserReadRepository, limiter *resilience.AdaptiveLimiter) *LimitedUserReadRepository {
+	return &LimitedUserReadRepository{
+		repository: repository,
+		limiter:    limiter,
+	}
+}
+
+// SaveUser wraps repository.SaveUser with the concurrency limit
+func (r *LimitedUserReadRepository) SaveUser(ctx context.Context, user *entities.UserReadModel) error {
+	return r.limiter.Execute(ctx, func() error {
+		return r.repository.SaveUser(ctx, user)
+	})
+}
+
+// GetUserByID wraps repository.GetUserByID with the concurrency limit
+func (r *LimitedUserReadRepository) GetUserByID(ctx context.Context, userID string) (*entities.UserReadModel, error) {
+	result, err := r.limiter.ExecuteWithResult(ctx, func() (interface{}, error) {
+		return r.repository.GetUserByID(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*entities.UserReadModel), nil
+}
+
+// GetUserByEmail wraps repository.GetUserByEmail with the concurrency limit
+func (r *LimitedUserReadRepository) GetUserByEmail(ctx context.Context, email string) (*entities.UserReadModel, error) {
+	result, err := r.limiter.ExecuteWithResult(ctx, func() (interface{}, error) {
+		return r.repository.GetUserByEmail(ctx, email)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*entities.UserReadModel), nil
+}
+
+// ListUsers wraps repository.ListUsers with the concurrency limit
+func (r *LimitedUserReadRepository) ListUsers(ctx context.Context, page, pageSize int) ([]*entities.UserReadModel, int64, error) {
+	var total int64
+	result, err := r.limiter.ExecuteWithResult(ctx, func() (interface{}, error) {
+		users, count, err := r.repository.ListUsers(ctx, page, pageSize)
+		total = count
+		return users, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return result.([]*entities.UserReadModel), total, nil
+}
+
+// UpdateUser wraps repository.UpdateUser with the concurrency limit
+func (r *LimitedUserReadRepository) UpdateUser(ctx context.Context, user *entities.UserReadModel) error {
+	return r.limiter.Execute(ctx, func() error {
+		return r.repository.UpdateUser(ctx, user)
+	})
+}
+
+// DeleteUser wraps repository.DeleteUser with the concurrency limit
+func (r *LimitedUserReadRepository) DeleteUser(ctx context.Context, userID string) error {
+	return r.limiter.Execute(ctx, func() error {
+		return r.repository.DeleteUser(ctx, userID)
+	})
+}
+
+// SaveEvent wraps repository.SaveEvent with the concurrency limit
+func (r *LimitedUserReadRepository) SaveEvent(ctx context.Context, event *entities.UserEvent) error {
+	return r.limiter.Execute(ctx, func() error {
+		return r.repository.SaveEvent(ctx, event)
+	})
+}
+
+// GetUserEvents wraps repository.GetUserEvents with the concurrency limit
+func (r *LimitedUserReadRepository) GetUserEvents(ctx context.Context, userID string) ([]*entities.UserEvent, error) {
+	result, err := r.limiter.ExecuteWithResult(ctx, func() (interface{}, error) {
+		return r.repository.GetUserEvents(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*entities.UserEvent), nil
+}
+
+// GetEventsByType wraps repository.GetEventsByType with the concurrency limit
+func (r *LimitedUserReadRepository) GetEventsByType(ctx context.Context, eventType string) ([]*entities.UserEvent, error) {
+	result, err := r.limiter.ExecuteWithResult(ctx, func() (interface{}, error) {
+		return r.repository.GetEventsByType(ctx, eventType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*entities.UserEvent), nil
+}
+
+// GetStats returns adaptive limiter statistics
+func (r *LimitedUserReadRepository) GetStats() resilience.AdaptiveLimiterStats {
+	return r.limiter.GetStats()
+}
+
+// LimitedEventStore wraps EventStore with an adaptive concurrency limit
+type LimitedEventStore struct {
+	eventStore repositories.EventStore
+	limiter    *resilience.AdaptiveLimiter
+}
+
+// NewLimitedEventStore creates a new concurrency limited event store
+func NewLimitedEventStore(eventStore repositories.EventStore, limiter *resilience.AdaptiveLimiter) *LimitedEventStore {
+	return &LimitedEventStore{
+		eventStore: eventStore,
+		limiter:    limiter,
+	}
+}
+
+// SaveEvent wraps eventStore.SaveEvent with the concurrency limit
+func (s *LimitedEventStore) SaveEvent(ctx context.Context, aggregateID string, event *events.Event) error {
+	return s.limiter.Execute(ctx, func() error {
+		return s.eventStore.SaveEvent(ctx, aggregateID, event)
+	})
+}
+
+// GetEvents wraps eventStore.GetEvents with the concurrency limit
+func (s *LimitedEventStore) GetEvents(ctx context.Context, aggregateID string) ([]*events.Event, error) {
+	return s.getEvents(ctx, func() ([]*events.Event, error) {
+		return s.eventStore.GetEvents(ctx, aggregateID)
+	})
+}
+
+// GetEventsByType wraps eventStore.GetEventsByType with the concurrency limit
+func (s *LimitedEventStore) GetEventsByType(ctx context.Context, eventType string) ([]*events.Event, error) {
+	return s.getEvents(ctx, func() ([]*events.Event, error) {
+		return s.eventStore.GetEventsByType(ctx, eventType)
+	})
+}
+
+// GetEventsSince wraps eventStore.GetEventsSince with the concurrency limit
+func (s *LimitedEventStore) GetEventsSince(ctx context.Context, since time.Time) ([]*events.Event, error) {
+	return s.getEvents(ctx, func() ([]*events.Event, error) {
+		return s.eventStore.GetEventsSince(ctx, since)
+	})
+}
+
+// AppendEvents wraps eventStore.AppendEvents with the concurrency limit
+func (s *LimitedEventStore) AppendEvents(ctx context.Context, aggregateID string, expectedVersion int, events ...*events.Event) error {
+	appender, err := versionedEventStore(s.eventStore)
+	if err != nil {
+		return err
+	}
+	return s.limiter.Execute(ctx, func() error {
+		return appender.AppendEvents(ctx, aggregateID, expectedVersion, events...)
+	})
+}
+
+// GetLastEventVersion wraps eventStore.GetLastEventVersion with the concurrency limit; event
+// stores that cannot tell the version of an aggregate return 0
+func (s *LimitedEventStore) GetLastEventVersion(ctx context.Context, aggregateID string) (int, error) {
+	versioner, ok := s.eventStore.(interface {
+		GetLastEventVersion(ctx context.Context, aggregateID string) (int, error)
+	})
+	if !ok {
+		return 0, nil
+	}
+
+	result, err := s.limiter.ExecuteWithResult(ctx, func() (interface{}, error) {
+		return versioner.GetLastEventVersion(ctx, aggregateID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int), nil
+}
+
+// HasCausation wraps eventStore.HasCausation with the concurrency limit; event stores that cannot
+// look events up by causation report none
+func (s *LimitedEventStore) HasCausation(ctx context.Context, causationID string) (bool, error) {
+	checker, ok := s.eventStore.(repositories.CausationChecker)
+	if !ok {
+		return false, nil
+	}
+
+	result, err := s.limiter.ExecuteWithResult(ctx, func() (interface{}, error) {
+		return checker.HasCausation(ctx, causationID)
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+// getEvents runs an event query with the concurrency limit
+func (s *LimitedEventStore) getEvents(ctx context.Context, query func() ([]*events.Event, error)) ([]*events.Event, error) {
+	result, err := s.limiter.ExecuteWithResult(ctx, func() (interface{}, error) {
+		return query()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*events.Event), nil
+}
+
+// GetStats returns adaptive limiter statistics
+func (s *LimitedEventStore) GetStats() resilience.AdaptiveLimiterStats {
+	return s.limiter.GetStats()
+}
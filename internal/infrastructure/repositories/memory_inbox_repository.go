@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+)
+
+// InMemoryInboxRepository implements InboxRepository in memory for tests and demos, following
+// the contract of MongoInboxRepository. Events are applied one at a time; writes apply makes are
+// not rolled back when it fails.
+type InMemoryInboxRepository struct {
+	mu      sync.Mutex
+	entries map[string]*entities.InboxEntry // By consumer and event ID
+}
+
+// NewInMemoryInboxRepository creates a new in-memory inbox repository
+func NewInMemoryInboxRepository() *InMemoryInboxRepository {
+	return &InMemoryInboxRepository{
+		entries: make(map[string]*entities.InboxEntry),
+	}
+}
+
+// Apply runs apply unless the consumer already processed the event, recording its outcome
+func (r *InMemoryInboxRepository) Apply(ctx context.Context, consumer, eventID, eventType string, apply func(ctx context.Context) error) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := inboxEntryID(consumer, eventID)
+	entry, ok := r.entries[id]
+	if ok && entry.Outcome == entities.InboxProcessed {
+		return false, nil
+	}
+	if !ok {
+		entry = &entities.InboxEntry{Consumer: consumer, EventID: eventID}
+		r.entries[id] = entry
+	}
+
+	err := apply(ctx)
+	entry.EventType = eventType
+	entry.Attempts++
+	entry.UpdatedAt = storedTime(time.Now())
+	if err != nil {
+		entry.Outcome = entities.InboxFailed
+		entry.Error = err.Error()
+		return false, err
+	}
+	entry.Outcome = entities.InboxProcessed
+	entry.Error = ""
+	return true, nil
+}
+
+// GetEntry returns a copy of the entry of an event consumed by a consumer, nil when there is none
+func (r *InMemoryInboxRepository) GetEntry(ctx context.Context, consumer, eventID string) (*entities.InboxEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[inboxEntryID(consumer, eventID)]
+	if !ok {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
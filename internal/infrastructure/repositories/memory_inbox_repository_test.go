@@ -0,0 +1,60 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryInboxRepository_Apply(t *testing.T) {
+	ctx := context.Background()
+	var repo repositories.InboxRepository = infraRepos.NewInMemoryInboxRepository()
+	calls := 0
+	fail := errors.New("read model unavailable")
+
+	applied, err := repo.Apply(ctx, "projections", "event-1", "user.created", func(context.Context) error {
+		calls++
+		return fail
+	})
+	assert.ErrorIs(t, err, fail)
+	assert.False(t, applied)
+	entry, err := repo.GetEntry(ctx, "projections", "event-1")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, entities.InboxFailed, entry.Outcome)
+	assert.Equal(t, "read model unavailable", entry.Error)
+
+	apply := func(context.Context) error {
+		calls++
+		return nil
+	}
+	applied, err = repo.Apply(ctx, "projections", "event-1", "user.created", apply)
+	require.NoError(t, err)
+	assert.True(t, applied, "failed events are applied when redelivered")
+
+	applied, err = repo.Apply(ctx, "projections", "event-1", "user.created", apply)
+	require.NoError(t, err)
+	assert.False(t, applied, "processed events are not applied again")
+	assert.Equal(t, 2, calls)
+
+	entry, err = repo.GetEntry(ctx, "projections", "event-1")
+	require.NoError(t, err)
+	assert.Equal(t, entities.InboxProcessed, entry.Outcome)
+	assert.Empty(t, entry.Error)
+	assert.Equal(t, 2, entry.Attempts)
+
+	applied, err = repo.Apply(ctx, "audit", "event-1", "user.created", apply)
+	require.NoError(t, err)
+	assert.True(t, applied, "consumers have inboxes of their own")
+
+	entry, err = repo.GetEntry(ctx, "projections", "event-2")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// loginAttemptSweepInterval is the number of failures recorded between removals of the keys no
+// longer counted
+const loginAttemptSweepInterval = 1024
+
+// loginAttempts holds the failed logins of a key
+type loginAttempts struct {
+	failures    int
+	firstFailed time.Time
+	lockedUntil time.Time
+}
+
+// InMemoryLoginAttemptRepository implements LoginAttemptRepository in memory, for single instance
+// deployments: failures are counted per instance and forgotten on restart
+type InMemoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	clock    clock.Clock
+	attempts map[string]*loginAttempts
+	recorded int
+}
+
+// NewInMemoryLoginAttemptRepository creates a new in-memory login attempt repository. A nil
+// clock uses the system clock.
+func NewInMemoryLoginAttemptRepository(clk clock.Clock) *InMemoryLoginAttemptRepository {
+	return &InMemoryLoginAttemptRepository{
+		clock:    clock.OrDefault(clk),
+		attempts: make(map[string]*loginAttempts),
+	}
+}
+
+// RecordFailure records a failed login of a key, returning its failures within window
+func (r *InMemoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	if r.recorded++; r.recorded%loginAttemptSweepInterval == 0 {
+		r.forgetExpired(now, window)
+	}
+	attempts, ok := r.attempts[key]
+	if !ok {
+		attempts = &loginAttempts{}
+		r.attempts[key] = attempts
+	}
+	if attempts.failures == 0 || now.Sub(attempts.firstFailed) >= window {
+		attempts.failures, attempts.firstFailed = 0, now
+	}
+	attempts.failures++
+	return attempts.failures, nil
+}
+
+// Lock locks a key out until a time, and starts its count of failures over
+func (r *InMemoryLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts[key] = &loginAttempts{lockedUntil: until}
+	return nil
+}
+
+// LockedUntil returns the end of the lockout of a key, the zero time when it is not locked out
+func (r *InMemoryLoginAttemptRepository) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts, ok := r.attempts[key]
+	if !ok || !attempts.lockedUntil.After(r.clock.Now()) {
+		return time.Time{}, nil
+	}
+	return attempts.lockedUntil, nil
+}
+
+// Reset forgets the failures of a key
+func (r *InMemoryLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempts, ok := r.attempts[key]; ok {
+		attempts.failures = 0
+	}
+	return nil
+}
+
+// forgetExpired forgets the keys neither locked out nor failing within window, so keys tried
+// once do not pile up
+func (r *InMemoryLoginAttemptRepository) forgetExpired(now time.Time, window time.Duration) {
+	for key, attempts := range r.attempts {
+		if !attempts.lockedUntil.After(now) && (attempts.failures == 0 || now.Sub(attempts.firstFailed) >= window) {
+			delete(r.attempts, key)
+		}
+	}
+}
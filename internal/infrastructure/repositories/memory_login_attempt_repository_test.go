@@ -0,0 +1,53 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryLoginAttemptRepository(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	var repo repositories.LoginAttemptRepository = infraRepos.NewInMemoryLoginAttemptRepository(clk)
+	window := 10 * time.Minute
+
+	for want := 1; want <= 2; want++ {
+		failures, err := repo.RecordFailure(ctx, "user:1", window)
+		require.NoError(t, err)
+		assert.Equal(t, want, failures)
+	}
+	clk.Advance(window)
+	failures, err := repo.RecordFailure(ctx, "user:1", window)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures, "failures are counted over the window from the first one")
+
+	require.NoError(t, repo.Reset(ctx, "user:1"))
+	failures, err = repo.RecordFailure(ctx, "user:1", window)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures)
+
+	until := clk.Now().Add(time.Minute)
+	require.NoError(t, repo.Lock(ctx, "user:1", until))
+	lockedUntil, err := repo.LockedUntil(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, until, lockedUntil)
+	lockedUntil, err = repo.LockedUntil(ctx, "user:2")
+	require.NoError(t, err)
+	assert.True(t, lockedUntil.IsZero())
+
+	clk.Advance(time.Minute)
+	lockedUntil, err = repo.LockedUntil(ctx, "user:1")
+	require.NoError(t, err)
+	assert.True(t, lockedUntil.IsZero(), "lockouts end")
+	failures, err = repo.RecordFailure(ctx, "user:1", window)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures, "locking out starts the count over")
+}
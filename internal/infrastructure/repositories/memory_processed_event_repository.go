@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryProcessedEventRepository implements ProcessedEventRepository in memory for tests and demos
+type InMemoryProcessedEventRepository struct {
+	mu        sync.RWMutex
+	processed map[string]string // Event type by consumer group and event ID
+}
+
+// NewInMemoryProcessedEventRepository creates a new in-memory processed event repository
+func NewInMemoryProcessedEventRepository() *InMemoryProcessedEventRepository {
+	return &InMemoryProcessedEventRepository{
+		processed: make(map[string]string),
+	}
+}
+
+// IsProcessed reports whether the consumer group already processed the event
+func (r *InMemoryProcessedEventRepository) IsProcessed(ctx context.Context, consumerGroup, eventID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.processed[inboxEntryID(consumerGroup, eventID)]
+	return ok, nil
+}
+
+// MarkProcessed records that the consumer group processed the event
+func (r *InMemoryProcessedEventRepository) MarkProcessed(ctx context.Context, consumerGroup, eventID, eventType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.processed[inboxEntryID(consumerGroup, eventID)] = eventType
+	return nil
+}
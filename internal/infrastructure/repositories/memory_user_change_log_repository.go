@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+)
+
+// InMemoryUserChangeLogRepository implements UserChangeLogRepository in memory for tests and
+// demos, following the contract of MongoUserChangeLogRepository. Reads return copies.
+type InMemoryUserChangeLogRepository struct {
+	mu       sync.RWMutex
+	changes  []*entities.UserChange // Sequence order
+	keys     map[string]bool
+	sequence int64
+}
+
+// NewInMemoryUserChangeLogRepository creates a new in-memory user change log repository
+func NewInMemoryUserChangeLogRepository() *InMemoryUserChangeLogRepository {
+	return &InMemoryUserChangeLogRepository{keys: make(map[string]bool)}
+}
+
+// AppendChange records a change with the next sequence, ignoring changes whose key is recorded
+func (r *InMemoryUserChangeLogRepository) AppendChange(ctx context.Context, change *entities.UserChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys[change.Key] {
+		return nil
+	}
+	r.sequence++
+	change.Sequence = r.sequence
+
+	stored := *change
+	stored.OccurredAt = storedTime(change.OccurredAt)
+	stored.RecordedAt = storedTime(change.RecordedAt)
+	r.changes = append(r.changes, &stored)
+	r.keys[change.Key] = true
+	return nil
+}
+
+// ListChanges returns changes after a sequence in sequence order
+func (r *InMemoryUserChangeLogRepository) ListChanges(ctx context.Context, after int64, limit int) ([]*entities.UserChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changes []*entities.UserChange
+	for _, change := range r.changes {
+		if len(changes) == limit {
+			break
+		}
+		if change.Sequence > after {
+			copied := *change
+			changes = append(changes, &copied)
+		}
+	}
+	return changes, nil
+}
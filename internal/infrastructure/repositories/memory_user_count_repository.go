@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// InMemoryUserCountRepository implements UserCountRepository in memory for tests and demos,
+// following the contract of MongoUserCountRepository
+type InMemoryUserCountRepository struct {
+	mu     sync.RWMutex
+	counts map[string]int64
+}
+
+// NewInMemoryUserCountRepository creates a new in-memory user count repository
+func NewInMemoryUserCountRepository() *InMemoryUserCountRepository {
+	return &InMemoryUserCountRepository{counts: make(map[string]int64)}
+}
+
+// Increment adds delta to the counter of a status, creating it when missing
+func (r *InMemoryUserCountRepository) Increment(ctx context.Context, status string, delta int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counts[status] += delta
+	return nil
+}
+
+// Counts returns a copy of the counter of every status
+func (r *InMemoryUserCountRepository) Counts(ctx context.Context) (map[string]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return maps.Clone(r.counts), nil
+}
+
+// Reset sets the counters of the given statuses
+func (r *InMemoryUserCountRepository) Reset(ctx context.Context, counts map[string]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	maps.Copy(r.counts, counts)
+	return nil
+}
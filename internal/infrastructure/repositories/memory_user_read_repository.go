@@ -0,0 +1,285 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// duplicateKeyCode is the MongoDB error code of unique index violations
+const duplicateKeyCode = 11000
+
+// InMemoryUserReadRepository implements UserReadRepository in memory for tests and demos.
+// It follows the query contract of MongoUserReadRepository: soft deleted users are hidden from
+// reads, user IDs are unique, lists are sorted newest first with ties kept in insertion order,
+// timestamps are stored with millisecond precision and reads return copies, so callers never
+// share state with the store.
+type InMemoryUserReadRepository struct {
+	mu     sync.RWMutex
+	users  []*entities.UserReadModel // Insertion order
+	events []*entities.UserEvent     // Insertion order
+	clock  clock.Clock
+}
+
+// NewInMemoryUserReadRepository creates a new in-memory user read repository.
+// A nil clock uses the system clock.
+func NewInMemoryUserReadRepository(clk clock.Clock) *InMemoryUserReadRepository {
+	return &InMemoryUserReadRepository{
+		clock: clock.OrDefault(clk),
+	}
+}
+
+// SaveUser saves a user, failing with a duplicate key error when the user ID already exists
+func (r *InMemoryUserReadRepository) SaveUser(ctx context.Context, user *entities.UserReadModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.UserID == user.UserID {
+			return mongo.WriteException{WriteErrors: []mongo.WriteError{{
+				Code:    duplicateKeyCode,
+				Message: fmt.Sprintf("E11000 duplicate key error dup key: { user_id: %q }", user.UserID),
+			}}}
+		}
+	}
+
+	// Set timestamp if not set
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = r.clock.Now()
+	}
+	user.UpdatedAt = r.clock.Now()
+
+	stored := copyUserReadModel(user)
+	if stored.ID.IsZero() {
+		stored.ID = primitive.NewObjectID()
+	}
+	r.users = append(r.users, stored)
+	return nil
+}
+
+// GetUserByID retrieves a user by ID
+func (r *InMemoryUserReadRepository) GetUserByID(ctx context.Context, userID string) (*entities.UserReadModel, error) {
+	return r.findUser(func(user *entities.UserReadModel) bool { return user.UserID == userID })
+}
+
+// GetUserByEmail retrieves the first saved user with an email
+func (r *InMemoryUserReadRepository) GetUserByEmail(ctx context.Context, email string) (*entities.UserReadModel, error) {
+	return r.findUser(func(user *entities.UserReadModel) bool { return user.Email == email })
+}
+
+// ListUsers retrieves a page of users, newest first. A zero page size returns all users after the skipped ones.
+func (r *InMemoryUserReadRepository) ListUsers(ctx context.Context, page, pageSize int) ([]*entities.UserReadModel, int64, error) {
+	skip := (page - 1) * pageSize
+	if skip < 0 {
+		return nil, 0, fmt.Errorf("invalid page %d with page size %d: skip must be non-negative", page, pageSize)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var active []*entities.UserReadModel
+	for _, user := range r.users {
+		if user.DeletedAt == nil {
+			active = append(active, user)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].CreatedAt.After(active[j].CreatedAt)
+	})
+
+	total := int64(len(active))
+	if skip > len(active) {
+		skip = len(active)
+	}
+	end := len(active)
+	// Like MongoDB, a negative limit returns at most its absolute value
+	limit := pageSize
+	if limit < 0 {
+		limit = -limit
+	}
+	if limit > 0 && skip+limit < end {
+		end = skip + limit
+	}
+
+	users := make([]*entities.UserReadModel, 0, end-skip)
+	for _, user := range active[skip:end] {
+		users = append(users, copyUserReadModel(user))
+	}
+	return users, total, nil
+}
+
+// UpdateUser updates a user. Like a MongoDB $set of the model, nil deletion, avatar and preferences
+// fields keep their stored values, and updating an unknown user is not an error.
+func (r *InMemoryUserReadRepository) UpdateUser(ctx context.Context, user *entities.UserReadModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.UpdatedAt = r.clock.Now()
+
+	for i, existing := range r.users {
+		if existing.UserID != user.UserID {
+			continue
+		}
+		updated := copyUserReadModel(user)
+		updated.ID = existing.ID
+		if updated.DeletedAt == nil {
+			updated.DeletedAt = existing.DeletedAt
+		}
+		if updated.Avatar == nil {
+			updated.Avatar = existing.Avatar
+		}
+		if updated.Preferences == nil {
+			updated.Preferences = existing.Preferences
+		}
+		if updated.EmailVerifiedAt == nil {
+			updated.EmailVerifiedAt = existing.EmailVerifiedAt
+		}
+		r.users[i] = updated
+		return nil
+	}
+	return nil
+}
+
+// UpgradeUser stores a user migrated from fromVersion, keeping its update time. Like
+// MongoUserReadRepository, it only applies while the stored user is still at fromVersion.
+func (r *InMemoryUserReadRepository) UpgradeUser(ctx context.Context, user *entities.UserReadModel, fromVersion int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.users {
+		if existing.UserID != user.UserID || existing.SchemaVersion != fromVersion {
+			continue
+		}
+		upgraded := copyUserReadModel(user)
+		upgraded.ID = existing.ID
+		r.users[i] = upgraded
+		return nil
+	}
+	return nil
+}
+
+// DeleteUser soft deletes a user
+func (r *InMemoryUserReadRepository) DeleteUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := storedTime(r.clock.Now())
+	for _, user := range r.users {
+		if user.UserID == userID {
+			user.DeletedAt = &now
+			user.UpdatedAt = now
+			return nil
+		}
+	}
+	return nil
+}
+
+// SaveEvent saves a user event
+func (r *InMemoryUserReadRepository) SaveEvent(ctx context.Context, event *entities.UserEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Set timestamp if not set
+	if event.Timestamp.IsZero() {
+		event.Timestamp = r.clock.Now()
+	}
+
+	r.events = append(r.events, copyUserEvent(event))
+	return nil
+}
+
+// GetUserEvents retrieves events for a user, oldest first
+func (r *InMemoryUserReadRepository) GetUserEvents(ctx context.Context, userID string) ([]*entities.UserEvent, error) {
+	return r.findEvents(func(event *entities.UserEvent) bool { return event.UserID == userID }), nil
+}
+
+// GetEventsByType retrieves events by type, oldest first
+func (r *InMemoryUserReadRepository) GetEventsByType(ctx context.Context, eventType string) ([]*entities.UserEvent, error) {
+	return r.findEvents(func(event *entities.UserEvent) bool { return event.EventType == eventType }), nil
+}
+
+// findUser returns a copy of the first saved user that is not deleted and matches
+func (r *InMemoryUserReadRepository) findUser(match func(*entities.UserReadModel) bool) (*entities.UserReadModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.DeletedAt == nil && match(user) {
+			return copyUserReadModel(user), nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// findEvents returns copies of the matching events sorted by timestamp, ties in insertion order
+func (r *InMemoryUserReadRepository) findEvents(match func(*entities.UserEvent) bool) []*entities.UserEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]*entities.UserEvent, 0)
+	for _, event := range r.events {
+		if match(event) {
+			events = append(events, copyUserEvent(event))
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events
+}
+
+// storedTime converts a timestamp to the millisecond UTC precision MongoDB stores
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+// copyUserReadModel returns a deep copy of user as MongoDB would store it
+func copyUserReadModel(user *entities.UserReadModel) *entities.UserReadModel {
+	copied := *user
+	copied.CreatedAt = storedTime(user.CreatedAt)
+	copied.UpdatedAt = storedTime(user.UpdatedAt)
+	if user.DeletedAt != nil {
+		deletedAt := storedTime(*user.DeletedAt)
+		copied.DeletedAt = &deletedAt
+	}
+	if user.Avatar != nil {
+		avatar := *user.Avatar
+		avatar.UploadedAt = storedTime(avatar.UploadedAt)
+		copied.Avatar = &avatar
+	}
+	if user.Preferences != nil {
+		preferences := *user.Preferences
+		preferences.UpdatedAt = storedTime(preferences.UpdatedAt)
+		if preferences.Notifications != nil {
+			notifications := *preferences.Notifications
+			preferences.Notifications = &notifications
+		}
+		copied.Preferences = &preferences
+	}
+	if user.EmailVerifiedAt != nil {
+		emailVerifiedAt := storedTime(*user.EmailVerifiedAt)
+		copied.EmailVerifiedAt = &emailVerifiedAt
+	}
+	return &copied
+}
+
+// copyUserEvent returns a copy of event as MongoDB would store it
+func copyUserEvent(event *entities.UserEvent) *entities.UserEvent {
+	copied := *event
+	copied.Timestamp = storedTime(event.Timestamp)
+	if event.EventData != nil {
+		copied.EventData = make(map[string]interface{}, len(event.EventData))
+		for key, value := range event.EventData {
+			copied.EventData[key] = value
+		}
+	}
+	return &copied
+}
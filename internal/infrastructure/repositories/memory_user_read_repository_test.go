@@ -0,0 +1,147 @@
+package repositories_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestInMemoryUserReadRepository_Users(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var repo repositories.UserReadRepository = infraRepos.NewInMemoryUserReadRepository(fake)
+
+	user := &entities.UserReadModel{UserID: "user-1", Email: "one@example.com", Name: "One"}
+	require.NoError(t, repo.SaveUser(ctx, user))
+	assert.Equal(t, fake.Now(), user.CreatedAt)
+
+	err := repo.SaveUser(ctx, &entities.UserReadModel{UserID: "user-1"})
+	assert.True(t, mongo.IsDuplicateKeyError(err))
+
+	found, err := repo.GetUserByEmail(ctx, "one@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "One", found.Name)
+	assert.False(t, found.ID.IsZero())
+
+	// Returned models are copies
+	found.Name = "Changed"
+	found, err = repo.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "One", found.Name)
+
+	// Nil avatar keeps the stored one, as with a MongoDB $set
+	require.NoError(t, repo.UpdateUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: "one@example.com", Name: "Avatar",
+		Avatar: &entities.AvatarMetadata{Key: "avatars/user-1"}}))
+	require.NoError(t, repo.UpdateUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: "one@example.com", Name: "Renamed"}))
+	found, err = repo.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", found.Name)
+	require.NotNil(t, found.Avatar)
+	assert.Equal(t, "avatars/user-1", found.Avatar.Key)
+
+	require.NoError(t, repo.DeleteUser(ctx, "user-1"))
+	_, err = repo.GetUserByID(ctx, "user-1")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	_, err = repo.GetUserByEmail(ctx, "one@example.com")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func TestInMemoryUserReadRepository_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	repo := infraRepos.NewInMemoryUserReadRepository(fake)
+
+	// user-1 and user-2 share a creation time and keep insertion order
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, repo.SaveUser(ctx, &entities.UserReadModel{UserID: fmt.Sprintf("user-%d", i)}))
+		if i != 1 {
+			fake.Advance(time.Minute)
+		}
+	}
+	require.NoError(t, repo.DeleteUser(ctx, "user-4"))
+
+	ids := func(users []*entities.UserReadModel) []string {
+		result := make([]string, 0, len(users))
+		for _, user := range users {
+			result = append(result, user.UserID)
+		}
+		return result
+	}
+
+	users, total, err := repo.ListUsers(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, []string{"user-5", "user-3"}, ids(users))
+
+	users, _, err = repo.ListUsers(ctx, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2"}, ids(users))
+
+	users, _, err = repo.ListUsers(ctx, 3, 2)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	_, _, err = repo.ListUsers(ctx, 0, 2)
+	assert.Error(t, err)
+}
+
+func TestInMemoryUserReadRepository_Events(t *testing.T) {
+	ctx := context.Background()
+	repo := infraRepos.NewInMemoryUserReadRepository(nil)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.SaveEvent(ctx, &entities.UserEvent{UserID: "user-1", EventType: "user.updated", Timestamp: base.Add(time.Second), Version: 2}))
+	require.NoError(t, repo.SaveEvent(ctx, &entities.UserEvent{UserID: "user-1", EventType: "user.created", Timestamp: base, Version: 1}))
+	require.NoError(t, repo.SaveEvent(ctx, &entities.UserEvent{UserID: "user-2", EventType: "user.created", Timestamp: base, Version: 1}))
+
+	events, err := repo.GetUserEvents(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, 1, events[0].Version)
+	assert.Equal(t, 2, events[1].Version)
+
+	events, err = repo.GetEventsByType(ctx, "user.created")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "user-1", events[0].UserID)
+	assert.Equal(t, "user-2", events[1].UserID)
+
+	events, err = repo.GetUserEvents(ctx, "unknown")
+	require.NoError(t, err)
+	assert.NotNil(t, events)
+	assert.Empty(t, events)
+}
+
+func TestInMemoryUserReadRepository_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := infraRepos.NewInMemoryUserReadRepository(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userID := fmt.Sprintf("user-%d", i)
+			assert.NoError(t, repo.SaveUser(ctx, &entities.UserReadModel{UserID: userID}))
+			assert.NoError(t, repo.UpdateUser(ctx, &entities.UserReadModel{UserID: userID, Name: "updated"}))
+			_, _, err := repo.ListUsers(ctx, 1, 10)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	_, total, err := repo.ListUsers(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), total)
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// InMemoryUserSummaryRepository implements UserSummaryRepository in memory for tests and demos,
+// following the contract of MongoUserSummaryRepository. Reads return copies.
+type InMemoryUserSummaryRepository struct {
+	mu        sync.RWMutex
+	summaries []*entities.UserSummary // Insertion order
+	counts    repositories.UserCountRepository
+}
+
+// NewInMemoryUserSummaryRepository creates a new in-memory user summary repository
+func NewInMemoryUserSummaryRepository() *InMemoryUserSummaryRepository {
+	return &InMemoryUserSummaryRepository{}
+}
+
+// SetCounts makes ListSummaries read the number of active users from the user counters
+func (r *InMemoryUserSummaryRepository) SetCounts(counts repositories.UserCountRepository) {
+	r.counts = counts
+}
+
+// SaveSummary creates or replaces the summary of a user, keeping its last login when the new
+// summary has none
+func (r *InMemoryUserSummaryRepository) SaveSummary(ctx context.Context, summary *entities.UserSummary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := copyUserSummary(summary)
+	if existing := r.find(summary.UserID); existing != nil {
+		if stored.LastLoginAt == nil {
+			stored.LastLoginAt = existing.LastLoginAt
+		}
+		*existing = *stored
+		return nil
+	}
+	r.summaries = append(r.summaries, stored)
+	return nil
+}
+
+// UpdateName sets the name of a user's summary
+func (r *InMemoryUserSummaryRepository) UpdateName(ctx context.Context, userID, name string) error {
+	return r.update(userID, func(summary *entities.UserSummary) { summary.Name = name })
+}
+
+// MarkDeleted sets the status of a user's summary to deleted
+func (r *InMemoryUserSummaryRepository) MarkDeleted(ctx context.Context, userID string) error {
+	return r.update(userID, func(summary *entities.UserSummary) { summary.Status = entities.UserStatusDeleted })
+}
+
+// RecordLogin sets the last login of a user's summary, ignoring logins older than the recorded one
+func (r *InMemoryUserSummaryRepository) RecordLogin(ctx context.Context, userID string, at time.Time) error {
+	at = storedTime(at)
+	return r.update(userID, func(summary *entities.UserSummary) {
+		if summary.LastLoginAt == nil || at.After(*summary.LastLoginAt) {
+			summary.LastLoginAt = &at
+		}
+	})
+}
+
+// ListSummaries returns a page of active users, newest first with ties in insertion order
+func (r *InMemoryUserSummaryRepository) ListSummaries(ctx context.Context, page, pageSize int) ([]*entities.UserSummary, int64, error) {
+	skip := (page - 1) * pageSize
+	if skip < 0 {
+		return nil, 0, fmt.Errorf("invalid page %d with page size %d: skip must be non-negative", page, pageSize)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var active []*entities.UserSummary
+	for _, summary := range r.summaries {
+		if summary.Status == entities.UserStatusActive {
+			active = append(active, summary)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].CreatedAt.After(active[j].CreatedAt)
+	})
+
+	total, _ := activeUserTotal(ctx, r.counts, func() (int64, error) {
+		return int64(len(active)), nil
+	})
+	skip = min(skip, len(active))
+	end := len(active)
+	if pageSize > 0 {
+		end = min(skip+pageSize, end)
+	}
+
+	summaries := make([]*entities.UserSummary, 0, end-skip)
+	for _, summary := range active[skip:end] {
+		summaries = append(summaries, copyUserSummary(summary))
+	}
+	return summaries, total, nil
+}
+
+// update applies change to a user's summary; updating an unknown user is not an error
+func (r *InMemoryUserSummaryRepository) update(userID string, change func(*entities.UserSummary)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if summary := r.find(userID); summary != nil {
+		change(summary)
+	}
+	return nil
+}
+
+// find returns the stored summary of a user, or nil. Requires r.mu.
+func (r *InMemoryUserSummaryRepository) find(userID string) *entities.UserSummary {
+	for _, summary := range r.summaries {
+		if summary.UserID == userID {
+			return summary
+		}
+	}
+	return nil
+}
+
+// copyUserSummary returns a copy of summary as MongoDB would store it
+func copyUserSummary(summary *entities.UserSummary) *entities.UserSummary {
+	copied := *summary
+	copied.CreatedAt = storedTime(summary.CreatedAt)
+	if summary.LastLoginAt != nil {
+		lastLogin := storedTime(*summary.LastLoginAt)
+		copied.LastLoginAt = &lastLogin
+	}
+	return &copied
+}
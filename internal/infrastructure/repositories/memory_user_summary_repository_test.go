@@ -0,0 +1,53 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryUserSummaryRepository(t *testing.T) {
+	ctx := context.Background()
+	var repo repositories.UserSummaryRepository = infraRepos.NewInMemoryUserSummaryRepository()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"user-1", "user-2", "user-3"} {
+		require.NoError(t, repo.SaveSummary(ctx, &entities.UserSummary{
+			UserID:    id,
+			Email:     id + "@example.com",
+			Name:      id,
+			Status:    entities.UserStatusActive,
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+
+	require.NoError(t, repo.UpdateName(ctx, "user-1", "Renamed"))
+	require.NoError(t, repo.MarkDeleted(ctx, "user-2"))
+	require.NoError(t, repo.RecordLogin(ctx, "user-3", start.Add(2*time.Hour)))
+	require.NoError(t, repo.RecordLogin(ctx, "user-3", start.Add(time.Hour)))
+	require.NoError(t, repo.RecordLogin(ctx, "unknown", start))
+
+	summaries, total, err := repo.ListSummaries(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "user-3", summaries[0].UserID)
+	require.NotNil(t, summaries[0].LastLoginAt)
+	assert.Equal(t, start.Add(2*time.Hour), *summaries[0].LastLoginAt)
+	assert.Equal(t, "Renamed", summaries[1].Name)
+
+	// Saving a summary again keeps its last login
+	require.NoError(t, repo.SaveSummary(ctx, &entities.UserSummary{UserID: "user-3", Name: "Backfilled", Status: entities.UserStatusActive, CreatedAt: start}))
+	summaries, _, err = repo.ListSummaries(ctx, 2, 1)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "Backfilled", summaries[0].Name)
+	assert.NotNil(t, summaries[0].LastLoginAt)
+}
@@ -2,49 +2,97 @@ package repositories
 
 import (
 	"context"
-	"encoding/json"
+	"time"
 
 	"go-clean-ddd-es-template/internal/domain/events"
 	"go-clean-ddd-es-template/internal/infrastructure/config"
 	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/requestcost"
 )
 
 // MessageBrokerEventPublisher implements EventPublisher using message broker
 type MessageBrokerEventPublisher struct {
-	broker messagebroker.MessageBroker
-	config *config.Config
+	broker   messagebroker.MessageBroker
+	config   *config.Config
+	router   *messagebroker.TenantRouter
+	versions *messagebroker.VersionedTopics
+	delayer  *messagebroker.Delayer
 }
 
 // NewMessageBrokerEventPublisher creates a new message broker event publisher
 func NewMessageBrokerEventPublisher(broker messagebroker.MessageBroker, config *config.Config) *MessageBrokerEventPublisher {
 	return &MessageBrokerEventPublisher{
-		broker: broker,
-		config: config,
+		broker:   broker,
+		config:   config,
+		router:   messagebroker.NewTenantRouter(config.Tenancy),
+		versions: messagebroker.NewVersionedTopics(config.MessageBroker, nil, nil),
+		delayer:  messagebroker.NewDelayer(broker, nil, nil),
 	}
 }
 
+// SetDelayer sets the delayer of events published after a delay, by default holding them in
+// memory on brokers without native delays
+func (p *MessageBrokerEventPublisher) SetDelayer(delayer *messagebroker.Delayer) {
+	p.delayer = delayer
+}
+
+// TopicVersions returns the versioned topics events are published to, e.g. to register the
+// encoders of deprecated versions
+func (p *MessageBrokerEventPublisher) TopicVersions() *messagebroker.VersionedTopics {
+	return p.versions
+}
+
 // PublishEvent publishes an event to the message broker
 func (p *MessageBrokerEventPublisher) PublishEvent(ctx context.Context, event *events.Event) error {
-	// Serialize event to JSON
-	eventData, err := json.Marshal(event)
-	if err != nil {
+	// Get topic from config mapping, fallback to event type, then publish each of its versions
+	// routed by tenant
+	stampEvent(ctx, event)
+	tenant := event.TenantID.String()
+	return p.versions.Publish(event, p.getTopicForEvent(event.Type), func(versioned messagebroker.VersionedTopic, message []byte) error {
+		topic, key := p.router.Route(versioned.Name, tenant)
+		start := time.Now()
+		err := messagebroker.PublishKeyed(p.broker, topic, key, message)
+		requestcost.RecordPublish(ctx, time.Since(start))
+		recordPublish(ctx, topic, start, err)
 		return err
-	}
+	})
+}
 
-	// Get topic from config mapping, fallback to event type
-	topic := p.getTopicForEvent(event.Type)
-	return p.broker.Publish(topic, eventData)
+// PublishEventWithHeaders publishes an event like PublishEvent, with headers when the broker
+// supports them
+func (p *MessageBrokerEventPublisher) PublishEventWithHeaders(ctx context.Context, event *events.Event, headers kafka.Headers) error {
+	stampEvent(ctx, event)
+	tenant := event.TenantID.String()
+	return p.versions.Publish(event, p.getTopicForEvent(event.Type), func(versioned messagebroker.VersionedTopic, message []byte) error {
+		topic, key := p.router.Route(versioned.Name, tenant)
+		start := time.Now()
+		err := messagebroker.PublishWithHeaders(p.broker, topic, key, message, headers)
+		requestcost.RecordPublish(ctx, time.Since(start))
+		recordPublish(ctx, topic, start, err)
+		return err
+	})
+}
+
+// PublishEventAfter publishes an event like PublishEventWithHeaders once delay elapsed, e.g. the
+// timeout of a saga step
+func (p *MessageBrokerEventPublisher) PublishEventAfter(ctx context.Context, event *events.Event, delay time.Duration) error {
+	tenant := event.TenantID.String()
+	headers := eventHeaders(ctx, event)
+	return p.versions.Publish(event, p.getTopicForEvent(event.Type), func(versioned messagebroker.VersionedTopic, message []byte) error {
+		topic, key := p.router.Route(versioned.Name, tenant)
+		start := time.Now()
+		err := p.delayer.Deliver(ctx, topic, key, message, headers, delay)
+		requestcost.RecordPublish(ctx, time.Since(start))
+		recordPublish(ctx, topic, start, err)
+		return err
+	})
 }
 
 // getTopicForEvent returns the appropriate topic for an event type
 func (p *MessageBrokerEventPublisher) getTopicForEvent(eventType string) string {
-	// Check if there's a mapping in config
-	if mappedTopic, exists := p.config.MessageBroker.Topics[eventType]; exists {
-		return mappedTopic
-	}
-
-	// Fallback to event type as topic name
-	return eventType
+	// Shared with the event catalog, so documented topics match the published ones
+	return messagebroker.EventTopic(p.config.MessageBroker, eventType)
 }
 
 // PublishEvents publishes multiple events to the message broker
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// userReadModelName labels user read model migrations in metrics
+const userReadModelName = "user"
+
+// Triggers of read model migrations
+const (
+	MigrationTriggerRead       = "read"       // A read found an outdated document
+	MigrationTriggerBackground = "background" // The full migration job
+)
+
+// Statuses of read model migrations
+const (
+	MigrationStatusMigrated = "migrated" // The upgraded document was stored
+	MigrationStatusFailed   = "failed"   // Storing the upgraded document failed
+)
+
+// UserReadModelMigration upgrades a user document from the previous schema version to ToVersion
+type UserReadModelMigration struct {
+	ToVersion   int
+	Description string
+	Migrate     func(user *entities.UserReadModel)
+}
+
+// UserReadModelMigrations are the user read model migrations in schema version order. The last
+// one migrates to entities.UserReadModelSchemaVersion.
+var UserReadModelMigrations = []UserReadModelMigration{
+	{
+		ToVersion:   1,
+		Description: "normalize emails stored before email normalization",
+		Migrate: func(user *entities.UserReadModel) {
+			user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+		},
+	},
+}
+
+// MigrateUserReadModel upgrades a user to the latest schema version in place and reports whether
+// it changed. Documents of a newer schema, written by a newer release, are left untouched.
+func MigrateUserReadModel(user *entities.UserReadModel) bool {
+	if user.SchemaVersion >= entities.UserReadModelSchemaVersion {
+		return false
+	}
+	for _, migration := range UserReadModelMigrations {
+		if migration.ToVersion > user.SchemaVersion {
+			migration.Migrate(user)
+			user.SchemaVersion = migration.ToVersion
+		}
+	}
+	return true
+}
+
+// UserReadModelUpgrader is implemented by read repositories that can store a migrated user
+// without touching its update time. The upgrade only applies while the stored document is still
+// at fromVersion, so it never overwrites a concurrent write.
+type UserReadModelUpgrader interface {
+	UpgradeUser(ctx context.Context, user *entities.UserReadModel, fromVersion int) error
+}
+
+// MigrationRecorder records read model migrations, see metrics.Metrics
+type MigrationRecorder interface {
+	RecordReadModelMigration(model string, fromVersion, toVersion int, trigger, status string)
+}
+
+// MigrationReport summarizes a full read model migration
+type MigrationReport struct {
+	Scanned  int `json:"scanned"`
+	Migrated int `json:"migrated"`
+	Failed   int `json:"failed"`
+}
+
+// MigratingUserReadRepository wraps UserReadRepository and lazily migrates outdated user documents
+// to the latest schema version when they are read, persisting the upgrade. Writes are stamped with
+// the latest schema version.
+type MigratingUserReadRepository struct {
+	repository repositories.UserReadRepository
+	recorder   MigrationRecorder
+}
+
+// NewMigratingUserReadRepository creates a new lazily migrating read repository.
+// A nil recorder records nothing.
+func NewMigratingUserReadRepository(repository repositories.UserReadRepository, recorder MigrationRecorder) *MigratingUserReadRepository {
+	return &MigratingUserReadRepository{
+		repository: repository,
+		recorder:   recorder,
+	}
+}
+
+// SaveUser saves a user at the latest schema version
+func (r *MigratingUserReadRepository) SaveUser(ctx context.Context, user *entities.UserReadModel) error {
+	user.SchemaVersion = entities.UserReadModelSchemaVersion
+	return r.repository.SaveUser(ctx, user)
+}
+
+// GetUserByID retrieves a user by ID, migrating it when outdated
+func (r *MigratingUserReadRepository) GetUserByID(ctx context.Context, userID string) (*entities.UserReadModel, error) {
+	user, err := r.repository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	r.migrate(ctx, user, MigrationTriggerRead)
+	return user, nil
+}
+
+// GetUserByEmail retrieves a user by email, migrating it when outdated
+func (r *MigratingUserReadRepository) GetUserByEmail(ctx context.Context, email string) (*entities.UserReadModel, error) {
+	user, err := r.repository.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	r.migrate(ctx, user, MigrationTriggerRead)
+	return user, nil
+}
+
+// ListUsers lists users, migrating the outdated ones
+func (r *MigratingUserReadRepository) ListUsers(ctx context.Context, page, pageSize int) ([]*entities.UserReadModel, int64, error) {
+	users, total, err := r.repository.ListUsers(ctx, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, user := range users {
+		r.migrate(ctx, user, MigrationTriggerRead)
+	}
+	return users, total, nil
+}
+
+// UpdateUser updates a user at the latest schema version
+func (r *MigratingUserReadRepository) UpdateUser(ctx context.Context, user *entities.UserReadModel) error {
+	user.SchemaVersion = entities.UserReadModelSchemaVersion
+	return r.repository.UpdateUser(ctx, user)
+}
+
+// DeleteUser deletes a user
+func (r *MigratingUserReadRepository) DeleteUser(ctx context.Context, userID string) error {
+	return r.repository.DeleteUser(ctx, userID)
+}
+
+// SaveEvent saves a user event
+func (r *MigratingUserReadRepository) SaveEvent(ctx context.Context, event *entities.UserEvent) error {
+	return r.repository.SaveEvent(ctx, event)
+}
+
+// GetUserEvents retrieves events for a user
+func (r *MigratingUserReadRepository) GetUserEvents(ctx context.Context, userID string) ([]*entities.UserEvent, error) {
+	return r.repository.GetUserEvents(ctx, userID)
+}
+
+// GetEventsByType retrieves events by type
+func (r *MigratingUserReadRepository) GetEventsByType(ctx context.Context, eventType string) ([]*entities.UserEvent, error) {
+	return r.repository.GetEventsByType(ctx, eventType)
+}
+
+// UpgradeUser stores a migrated user, see UserReadModelUpgrader
+func (r *MigratingUserReadRepository) UpgradeUser(ctx context.Context, user *entities.UserReadModel, fromVersion int) error {
+	return upgradeUser(ctx, r.repository, user, fromVersion)
+}
+
+// MigrateAll migrates every outdated user, reading batchSize users per page. Soft deleted users
+// are not listed and migrate when they are restored. Failures are counted and do not stop the
+// migration; only failing reads do.
+func (r *MigratingUserReadRepository) MigrateAll(ctx context.Context, batchSize int) (MigrationReport, error) {
+	var report MigrationReport
+	if batchSize <= 0 {
+		return report, fmt.Errorf("batch size must be positive, got %d", batchSize)
+	}
+
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		users, total, err := r.repository.ListUsers(ctx, page, batchSize)
+		if err != nil {
+			return report, fmt.Errorf("failed to list users page %d: %w", page, err)
+		}
+		for _, user := range users {
+			report.Scanned++
+			switch r.migrate(ctx, user, MigrationTriggerBackground) {
+			case MigrationStatusMigrated:
+				report.Migrated++
+			case MigrationStatusFailed:
+				report.Failed++
+			}
+		}
+
+		if len(users) < batchSize || int64(page*batchSize) >= total {
+			return report, nil
+		}
+	}
+}
+
+// migrate upgrades an outdated user and stores it, returning the migration status or an empty
+// status when the user is up to date. Reads return the upgraded user even when storing fails,
+// the next read retries.
+func (r *MigratingUserReadRepository) migrate(ctx context.Context, user *entities.UserReadModel, trigger string) string {
+	fromVersion := user.SchemaVersion
+	if !MigrateUserReadModel(user) {
+		return ""
+	}
+
+	status := MigrationStatusMigrated
+	if err := upgradeUser(ctx, r.repository, user, fromVersion); err != nil {
+		status = MigrationStatusFailed
+	}
+	if r.recorder != nil {
+		r.recorder.RecordReadModelMigration(userReadModelName, fromVersion, user.SchemaVersion, trigger, status)
+	}
+	return status
+}
+
+// upgradeUser stores a migrated user with the repository upgrader, falling back to an update
+func upgradeUser(ctx context.Context, repository repositories.UserReadRepository, user *entities.UserReadModel, fromVersion int) error {
+	if upgrader, ok := repository.(UserReadModelUpgrader); ok {
+		return upgrader.UpgradeUser(ctx, user, fromVersion)
+	}
+	return repository.UpdateUser(ctx, user)
+}
@@ -0,0 +1,111 @@
+package repositories_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedMigration struct {
+	from, to        int
+	trigger, status string
+}
+
+type fakeMigrationRecorder struct {
+	migrations []recordedMigration
+}
+
+func (r *fakeMigrationRecorder) RecordReadModelMigration(model string, fromVersion, toVersion int, trigger, status string) {
+	r.migrations = append(r.migrations, recordedMigration{fromVersion, toVersion, trigger, status})
+}
+
+func TestMigratingUserReadRepository_LazyMigration(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := infraRepos.NewInMemoryUserReadRepository(fake)
+	recorder := &fakeMigrationRecorder{}
+	repo := infraRepos.NewMigratingUserReadRepository(store, recorder)
+
+	// A document written before versioning
+	require.NoError(t, store.SaveUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: " One@Example.COM ", Name: "One"}))
+	fake.Advance(time.Hour)
+
+	user, err := repo.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "one@example.com", user.Email)
+	assert.Equal(t, entities.UserReadModelSchemaVersion, user.SchemaVersion)
+
+	// The upgrade is persisted without touching the update time
+	stored, err := store.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "one@example.com", stored.Email)
+	assert.Equal(t, entities.UserReadModelSchemaVersion, stored.SchemaVersion)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), stored.UpdatedAt)
+
+	// Up to date documents are not migrated again
+	_, err = repo.GetUserByEmail(ctx, "one@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []recordedMigration{{0, 1, infraRepos.MigrationTriggerRead, infraRepos.MigrationStatusMigrated}}, recorder.migrations)
+
+	// Writes are stamped with the latest schema version
+	require.NoError(t, repo.SaveUser(ctx, &entities.UserReadModel{UserID: "user-2", Email: "two@example.com"}))
+	stored, err = store.GetUserByID(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, entities.UserReadModelSchemaVersion, stored.SchemaVersion)
+}
+
+func TestMigratingUserReadRepository_UpgradeKeepsConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	store := infraRepos.NewInMemoryUserReadRepository(nil)
+	require.NoError(t, store.SaveUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: "One@example.com", Name: "One"}))
+
+	outdated, err := store.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: "one@example.com", Name: "Renamed",
+		SchemaVersion: entities.UserReadModelSchemaVersion}))
+
+	// The upgrade of the outdated read does not overwrite the newer write
+	infraRepos.MigrateUserReadModel(outdated)
+	require.NoError(t, store.UpgradeUser(ctx, outdated, 0))
+
+	stored, err := store.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", stored.Name)
+}
+
+func TestMigratingUserReadRepository_MigrateAll(t *testing.T) {
+	ctx := context.Background()
+	store := infraRepos.NewInMemoryUserReadRepository(nil)
+	for i := 0; i < 5; i++ {
+		user := &entities.UserReadModel{UserID: fmt.Sprintf("user-%d", i), Email: fmt.Sprintf("User%d@Example.com", i)}
+		if i%2 == 0 {
+			user.SchemaVersion = entities.UserReadModelSchemaVersion
+		}
+		require.NoError(t, store.SaveUser(ctx, user))
+	}
+	recorder := &fakeMigrationRecorder{}
+	repo := infraRepos.NewMigratingUserReadRepository(store, recorder)
+
+	report, err := repo.MigrateAll(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, infraRepos.MigrationReport{Scanned: 5, Migrated: 2}, report)
+	require.Len(t, recorder.migrations, 2)
+	assert.Equal(t, infraRepos.MigrationTriggerBackground, recorder.migrations[0].trigger)
+
+	users, _, err := store.ListUsers(ctx, 1, 0)
+	require.NoError(t, err)
+	for _, user := range users {
+		assert.Equal(t, entities.UserReadModelSchemaVersion, user.SchemaVersion)
+	}
+
+	_, err = repo.MigrateAll(ctx, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+)
+
+// InboxCollection is the collection of the inbox, next to the read models it guards
+const InboxCollection = "inbox"
+
+// MongoInboxRepository implements InboxRepository using MongoDB. Events are applied in a
+// multi-document transaction, which requires the read database to be a replica set.
+type MongoInboxRepository struct {
+	client   *mongo.Client
+	database string
+}
+
+// NewMongoInboxRepository creates a new MongoDB inbox repository
+func NewMongoInboxRepository(client *mongo.Client, database string) *MongoInboxRepository {
+	return &MongoInboxRepository{
+		client:   client,
+		database: database,
+	}
+}
+
+// Apply runs apply in a transaction recording the event as processed, unless it already is.
+// Writes apply makes with the session context it is given commit or abort with the entry; when
+// the transaction fails the failure is recorded outside of it.
+func (r *MongoInboxRepository) Apply(ctx context.Context, consumer, eventID, eventType string, apply func(ctx context.Context) error) (bool, error) {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return false, err
+	}
+	defer session.EndSession(ctx)
+
+	id := inboxEntryID(consumer, eventID)
+	applied, err := session.WithTransaction(ctx, func(txCtx mongo.SessionContext) (interface{}, error) {
+		err := r.collection().FindOne(txCtx, bson.M{"_id": id, "outcome": entities.InboxProcessed}).Err()
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return false, err
+		}
+
+		if err := apply(txCtx); err != nil {
+			return false, err
+		}
+		_, err = r.collection().UpdateOne(txCtx,
+			bson.M{"_id": id},
+			bson.M{
+				"$set":   inboxEntryFields(consumer, eventID, eventType, entities.InboxProcessed),
+				"$unset": bson.M{"error": ""},
+				"$inc":   bson.M{"attempts": 1},
+			},
+			options.Update().SetUpsert(true),
+		)
+		return true, err
+	})
+	if err != nil {
+		r.recordFailure(ctx, id, consumer, eventID, eventType, err)
+		return false, err
+	}
+	return applied.(bool), nil
+}
+
+// GetEntry returns the entry of an event consumed by a consumer, nil when there is none
+func (r *MongoInboxRepository) GetEntry(ctx context.Context, consumer, eventID string) (*entities.InboxEntry, error) {
+	var entry entities.InboxEntry
+	err := r.collection().FindOne(ctx, bson.M{"_id": inboxEntryID(consumer, eventID)}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// recordFailure records a failed attempt at applying an event, unless a concurrent delivery
+// processed it. Recording is best effort: the event is redelivered either way.
+func (r *MongoInboxRepository) recordFailure(ctx context.Context, id, consumer, eventID, eventType string, cause error) {
+	fields := inboxEntryFields(consumer, eventID, eventType, entities.InboxFailed)
+	fields["error"] = cause.Error()
+	_, _ = r.collection().UpdateOne(ctx,
+		bson.M{"_id": id, "outcome": bson.M{"$ne": entities.InboxProcessed}},
+		bson.M{"$set": fields, "$inc": bson.M{"attempts": 1}},
+		options.Update().SetUpsert(true),
+	)
+}
+
+func (r *MongoInboxRepository) collection() *mongo.Collection {
+	return r.client.Database(r.database).Collection(InboxCollection)
+}
+
+// inboxEntryID returns the _id of the inbox entry of an event consumed by a consumer
+func inboxEntryID(consumer, eventID string) string {
+	return consumer + "/" + eventID
+}
+
+// inboxEntryFields returns the fields set on an inbox entry when recording an outcome
+func inboxEntryFields(consumer, eventID, eventType, outcome string) bson.M {
+	return bson.M{
+		"consumer":   consumer,
+		"event_id":   eventID,
+		"event_type": eventType,
+		"outcome":    outcome,
+		"updated_at": time.Now().UTC(),
+	}
+}
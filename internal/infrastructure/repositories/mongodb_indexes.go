@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"go-clean-ddd-es-template/pkg/mongoindex"
+)
+
+// RegisterUserReadModelIndexes declares the indexes used by MongoUserReadRepository
+func RegisterUserReadModelIndexes(registry *mongoindex.Registry, collection string) {
+	registry.Register(collection,
+		mongoindex.Definition{Keys: bson.D{{Key: "user_id", Value: 1}}, Unique: true},
+		mongoindex.Definition{Keys: bson.D{{Key: "email", Value: 1}}},
+		mongoindex.Definition{Keys: bson.D{{Key: "deleted_at", Value: 1}, {Key: "created_at", Value: -1}}},
+	)
+
+	registry.Register(collection+"_events",
+		mongoindex.Definition{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: 1}}},
+		mongoindex.Definition{Keys: bson.D{{Key: "event_type", Value: 1}, {Key: "timestamp", Value: 1}}},
+	)
+}
+
+// RegisterUserSummaryIndexes declares the indexes used by MongoUserSummaryRepository
+func RegisterUserSummaryIndexes(registry *mongoindex.Registry) {
+	registry.Register(UserSummaryCollection,
+		mongoindex.Definition{Keys: bson.D{{Key: "user_id", Value: 1}}, Unique: true},
+		mongoindex.Definition{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+	)
+}
+
+// RegisterUserChangeLogIndexes declares the indexes used by MongoUserChangeLogRepository
+func RegisterUserChangeLogIndexes(registry *mongoindex.Registry) {
+	registry.Register(UserChangeCollection,
+		mongoindex.Definition{Keys: bson.D{{Key: "sequence", Value: 1}}, Unique: true},
+		mongoindex.Definition{Keys: bson.D{{Key: "key", Value: 1}}, Unique: true},
+	)
+}
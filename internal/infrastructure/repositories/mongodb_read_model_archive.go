@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoReadModelArchive implements ReadModelArchive using MongoDB. Documents are copied to the
+// archive collection before they are deleted, so a document is never lost; one copied by a run
+// that failed before deleting it is deleted by the next.
+type MongoReadModelArchive struct {
+	client   *mongo.Client
+	database string
+}
+
+// NewMongoReadModelArchive creates a new MongoDB read model archive
+func NewMongoReadModelArchive(client *mongo.Client, database string) *MongoReadModelArchive {
+	return &MongoReadModelArchive{
+		client:   client,
+		database: database,
+	}
+}
+
+// ArchiveBefore moves up to limit documents of collection whose field is before cutoff to its
+// archive collection, oldest first
+func (a *MongoReadModelArchive) ArchiveBefore(ctx context.Context, collection, field string, cutoff time.Time, limit int) (int, error) {
+	db := a.client.Database(a.database)
+	cursor, err := db.Collection(collection).Find(ctx,
+		bson.M{field: bson.M{"$lt": cutoff}},
+		options.Find().SetSort(bson.D{{Key: field, Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired documents of %s: %w", collection, err)
+	}
+	var documents []bson.M
+	if err := cursor.All(ctx, &documents); err != nil {
+		return 0, fmt.Errorf("failed to read expired documents of %s: %w", collection, err)
+	}
+	if len(documents) == 0 {
+		return 0, nil
+	}
+
+	archived := make([]interface{}, len(documents))
+	ids := make(bson.A, len(documents))
+	for i, document := range documents {
+		archived[i] = document
+		ids[i] = document["_id"]
+	}
+	// Documents archived by an earlier run that failed to delete them are already there
+	_, err = db.Collection(ArchiveCollection(collection)).InsertMany(ctx, archived, options.InsertMany().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return 0, fmt.Errorf("failed to archive expired documents of %s: %w", collection, err)
+	}
+
+	result, err := db.Collection(collection).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete archived documents of %s: %w", collection, err)
+	}
+	return int(result.DeletedCount), nil
+}
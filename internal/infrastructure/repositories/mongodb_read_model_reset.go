@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoReadModelReset empties the collections of the read models projected from events, so they
+// can be rebuilt by replaying the event store. Collections keep their indexes.
+type MongoReadModelReset struct {
+	client      *mongo.Client
+	database    string
+	collections []string
+}
+
+// NewMongoReadModelReset creates a reset of the given read model collections
+func NewMongoReadModelReset(client *mongo.Client, database string, collections []string) *MongoReadModelReset {
+	return &MongoReadModelReset{
+		client:      client,
+		database:    database,
+		collections: collections,
+	}
+}
+
+// Truncate deletes every document of the read model collections
+func (r *MongoReadModelReset) Truncate(ctx context.Context) error {
+	for _, collection := range r.collections {
+		if _, err := r.client.Database(r.database).Collection(collection).DeleteMany(ctx, bson.M{}); err != nil {
+			return fmt.Errorf("failed to truncate %s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+// Collections returns the read model collections truncated
+func (r *MongoReadModelReset) Collections() []string {
+	return r.collections
+}
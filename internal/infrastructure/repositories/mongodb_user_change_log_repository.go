@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+)
+
+// Collections of the user change log
+const (
+	UserChangeCollection = "user_changes" // Changes
+	CounterCollection    = "counters"     // Sequence counters, by _id
+)
+
+// userChangeCounter is the counter of user change sequences
+const userChangeCounter = "user_changes"
+
+// MongoUserChangeLogRepository implements UserChangeLogRepository using MongoDB
+type MongoUserChangeLogRepository struct {
+	client   *mongo.Client
+	database string
+}
+
+// NewMongoUserChangeLogRepository creates a new MongoDB user change log repository
+func NewMongoUserChangeLogRepository(client *mongo.Client, database string) *MongoUserChangeLogRepository {
+	return &MongoUserChangeLogRepository{
+		client:   client,
+		database: database,
+	}
+}
+
+// AppendChange records a change with the next sequence of the counter. Changes whose key is
+// already recorded are ignored; the sequence they took is skipped, so sequences may have gaps.
+func (r *MongoUserChangeLogRepository) AppendChange(ctx context.Context, change *entities.UserChange) error {
+	err := r.collection().FindOne(ctx, bson.M{"key": change.Key}).Err()
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	var counter struct {
+		Value int64 `bson:"value"`
+	}
+	err = r.client.Database(r.database).Collection(CounterCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": userChangeCounter},
+		bson.M{"$inc": bson.M{"value": int64(1)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return err
+	}
+
+	stored := *change
+	stored.Sequence = counter.Value
+	if _, err := r.collection().InsertOne(ctx, &stored); err != nil {
+		// The same event was recorded concurrently
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return err
+	}
+	change.Sequence = stored.Sequence
+	return nil
+}
+
+// ListChanges returns changes after a sequence in sequence order
+func (r *MongoUserChangeLogRepository) ListChanges(ctx context.Context, after int64, limit int) ([]*entities.UserChange, error) {
+	filter := bson.M{"sequence": bson.M{"$gt": after}}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "sequence", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection().Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []*entities.UserChange
+	if err = cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *MongoUserChangeLogRepository) collection() *mongo.Collection {
+	return r.client.Database(r.database).Collection(UserChangeCollection)
+}
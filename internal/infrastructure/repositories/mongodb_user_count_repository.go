@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// UserCountCollection is the collection of the user counters, one document per status
+const UserCountCollection = "user_counts"
+
+// MongoUserCountRepository implements UserCountRepository using MongoDB
+type MongoUserCountRepository struct {
+	client   *mongo.Client
+	database string
+}
+
+// NewMongoUserCountRepository creates a new MongoDB user count repository
+func NewMongoUserCountRepository(client *mongo.Client, database string) *MongoUserCountRepository {
+	return &MongoUserCountRepository{
+		client:   client,
+		database: database,
+	}
+}
+
+// Increment adds delta to the counter of a status, creating it when missing
+func (r *MongoUserCountRepository) Increment(ctx context.Context, status string, delta int64) error {
+	_, err := r.collection().UpdateOne(ctx,
+		bson.M{"_id": status},
+		bson.M{"$inc": bson.M{"count": delta}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Counts returns the counter of every status
+func (r *MongoUserCountRepository) Counts(ctx context.Context) (map[string]int64, error) {
+	cursor, err := r.collection().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []struct {
+		Status string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(documents))
+	for _, document := range documents {
+		counts[document.Status] = document.Count
+	}
+	return counts, nil
+}
+
+// Reset sets the counters of the given statuses, recording when they were reconciled
+func (r *MongoUserCountRepository) Reset(ctx context.Context, counts map[string]int64) error {
+	now := time.Now()
+	for status, count := range counts {
+		_, err := r.collection().UpdateOne(ctx,
+			bson.M{"_id": status},
+			bson.M{"$set": bson.M{"count": count, "reconciled_at": now}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MongoUserCountRepository) collection() *mongo.Collection {
+	return r.client.Database(r.database).Collection(UserCountCollection)
+}
+
+// activeUserTotal returns the number of active users of counts, or calls count when no counters
+// are set, they cannot be read, or they have not counted active users yet
+func activeUserTotal(ctx context.Context, counts repositories.UserCountRepository, count func() (int64, error)) (int64, error) {
+	if counts != nil {
+		if recorded, err := counts.Counts(ctx); err == nil {
+			if total, ok := recorded[entities.UserStatusActive]; ok {
+				return max(total, 0), nil
+			}
+		}
+	}
+	return count()
+}
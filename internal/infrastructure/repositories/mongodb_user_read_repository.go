@@ -9,6 +9,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/queryplan"
 )
 
 // MongoUserReadRepository implements UserReadRepository using MongoDB
@@ -16,6 +18,7 @@ type MongoUserReadRepository struct {
 	client     *mongo.Client
 	database   string
 	collection string
+	counts     repositories.UserCountRepository
 }
 
 // NewMongoUserReadRepository creates a new MongoDB user read repository
@@ -27,6 +30,12 @@ func NewMongoUserReadRepository(client *mongo.Client, database, collection strin
 	}
 }
 
+// SetCounts makes ListUsers read the number of users from the user counters instead of counting
+// documents on every request
+func (r *MongoUserReadRepository) SetCounts(counts repositories.UserCountRepository) {
+	r.counts = counts
+}
+
 // SaveUser saves a user to MongoDB
 func (r *MongoUserReadRepository) SaveUser(ctx context.Context, user *entities.UserReadModel) error {
 	collection := r.client.Database(r.database).Collection(r.collection)
@@ -45,10 +54,10 @@ func (r *MongoUserReadRepository) SaveUser(ctx context.Context, user *entities.U
 func (r *MongoUserReadRepository) GetUserByID(ctx context.Context, userID string) (*entities.UserReadModel, error) {
 	collection := r.client.Database(r.database).Collection(r.collection)
 
-	filter := bson.M{"user_id": userID, "deleted_at": bson.M{"$exists": false}}
+	query := r.DescribeGetUserByID(userID)
 
 	var user entities.UserReadModel
-	err := collection.FindOne(ctx, filter).Decode(&user)
+	err := collection.FindOne(ctx, query.Filter).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -60,10 +69,10 @@ func (r *MongoUserReadRepository) GetUserByID(ctx context.Context, userID string
 func (r *MongoUserReadRepository) GetUserByEmail(ctx context.Context, email string) (*entities.UserReadModel, error) {
 	collection := r.client.Database(r.database).Collection(r.collection)
 
-	filter := bson.M{"email": email, "deleted_at": bson.M{"$exists": false}}
+	query := r.DescribeGetUserByEmail(email)
 
 	var user entities.UserReadModel
-	err := collection.FindOne(ctx, filter).Decode(&user)
+	err := collection.FindOne(ctx, query.Filter).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -76,25 +85,24 @@ func (r *MongoUserReadRepository) ListUsers(ctx context.Context, page, pageSize
 	collection := r.client.Database(r.database).Collection(r.collection)
 
 	// Filter out deleted users
-	filter := bson.M{"deleted_at": bson.M{"$exists": false}}
+	query := r.DescribeListUsers(page, pageSize)
 
-	// Count total documents
-	total, err := collection.CountDocuments(ctx, filter)
+	// Count total documents, unless the user counters count them
+	total, err := activeUserTotal(ctx, r.counts, func() (int64, error) {
+		return collection.CountDocuments(ctx, query.Filter)
+	})
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Calculate skip
-	skip := int64((page - 1) * pageSize)
-
 	// Find options
 	findOptions := options.Find().
-		SetSkip(skip).
-		SetLimit(int64(pageSize)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
+		SetSkip(query.Skip).
+		SetLimit(query.Limit).
+		SetSort(query.Sort)
 
 	// Execute query
-	cursor, err := collection.Find(ctx, filter, findOptions)
+	cursor, err := collection.Find(ctx, query.Filter, findOptions)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -122,6 +130,21 @@ func (r *MongoUserReadRepository) UpdateUser(ctx context.Context, user *entities
 	return err
 }
 
+// UpgradeUser stores a user migrated from fromVersion, keeping its update time. Documents written
+// before versioning have no schema_version field and match version zero.
+func (r *MongoUserReadRepository) UpgradeUser(ctx context.Context, user *entities.UserReadModel, fromVersion int) error {
+	collection := r.client.Database(r.database).Collection(r.collection)
+
+	filter := bson.M{"user_id": user.UserID, "schema_version": fromVersion}
+	if fromVersion == 0 {
+		filter["schema_version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	update := bson.M{"$set": user}
+
+	_, err := collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
 // DeleteUser soft deletes a user in MongoDB
 func (r *MongoUserReadRepository) DeleteUser(ctx context.Context, userID string) error {
 	collection := r.client.Database(r.database).Collection(r.collection)
@@ -151,10 +174,10 @@ func (r *MongoUserReadRepository) SaveEvent(ctx context.Context, event *entities
 func (r *MongoUserReadRepository) GetUserEvents(ctx context.Context, userID string) ([]*entities.UserEvent, error) {
 	eventsCollection := r.client.Database(r.database).Collection(r.collection + "_events")
 
-	filter := bson.M{"user_id": userID}
-	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	query := r.DescribeGetUserEvents(userID)
+	findOptions := options.Find().SetSort(query.Sort)
 
-	cursor, err := eventsCollection.Find(ctx, filter, findOptions)
+	cursor, err := eventsCollection.Find(ctx, query.Filter, findOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -172,10 +195,10 @@ func (r *MongoUserReadRepository) GetUserEvents(ctx context.Context, userID stri
 func (r *MongoUserReadRepository) GetEventsByType(ctx context.Context, eventType string) ([]*entities.UserEvent, error) {
 	eventsCollection := r.client.Database(r.database).Collection(r.collection + "_events")
 
-	filter := bson.M{"event_type": eventType}
-	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	query := r.DescribeGetEventsByType(eventType)
+	findOptions := options.Find().SetSort(query.Sort)
 
-	cursor, err := eventsCollection.Find(ctx, filter, findOptions)
+	cursor, err := eventsCollection.Find(ctx, query.Filter, findOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -188,3 +211,51 @@ func (r *MongoUserReadRepository) GetEventsByType(ctx context.Context, eventType
 
 	return events, nil
 }
+
+// DescribeGetUserByID describes the query issued by GetUserByID
+func (r *MongoUserReadRepository) DescribeGetUserByID(userID string) queryplan.Query {
+	return r.usersQuery("GetUserByID", bson.M{"user_id": userID, "deleted_at": bson.M{"$exists": false}})
+}
+
+// DescribeGetUserByEmail describes the query issued by GetUserByEmail
+func (r *MongoUserReadRepository) DescribeGetUserByEmail(email string) queryplan.Query {
+	return r.usersQuery("GetUserByEmail", bson.M{"email": email, "deleted_at": bson.M{"$exists": false}})
+}
+
+// DescribeListUsers describes the query issued by ListUsers
+func (r *MongoUserReadRepository) DescribeListUsers(page, pageSize int) queryplan.Query {
+	query := r.usersQuery("ListUsers", bson.M{"deleted_at": bson.M{"$exists": false}})
+	query.Sort = bson.D{{Key: "created_at", Value: -1}}
+	query.Skip = int64((page - 1) * pageSize)
+	query.Limit = int64(pageSize)
+	return query
+}
+
+// DescribeGetUserEvents describes the query issued by GetUserEvents
+func (r *MongoUserReadRepository) DescribeGetUserEvents(userID string) queryplan.Query {
+	return r.eventsQuery("GetUserEvents", bson.M{"user_id": userID})
+}
+
+// DescribeGetEventsByType describes the query issued by GetEventsByType
+func (r *MongoUserReadRepository) DescribeGetEventsByType(eventType string) queryplan.Query {
+	return r.eventsQuery("GetEventsByType", bson.M{"event_type": eventType})
+}
+
+func (r *MongoUserReadRepository) usersQuery(operation string, filter bson.M) queryplan.Query {
+	return queryplan.Query{
+		Backend:    queryplan.BackendMongo,
+		Operation:  operation,
+		Collection: r.collection,
+		Filter:     filter,
+	}
+}
+
+func (r *MongoUserReadRepository) eventsQuery(operation string, filter bson.M) queryplan.Query {
+	return queryplan.Query{
+		Backend:    queryplan.BackendMongo,
+		Operation:  operation,
+		Collection: r.collection + "_events",
+		Filter:     filter,
+		Sort:       bson.D{{Key: "timestamp", Value: 1}},
+	}
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// UserSummaryCollection is the collection of the user summary projection
+const UserSummaryCollection = "user_summaries"
+
+// MongoUserSummaryRepository implements UserSummaryRepository using MongoDB
+type MongoUserSummaryRepository struct {
+	client   *mongo.Client
+	database string
+	counts   repositories.UserCountRepository
+}
+
+// NewMongoUserSummaryRepository creates a new MongoDB user summary repository
+func NewMongoUserSummaryRepository(client *mongo.Client, database string) *MongoUserSummaryRepository {
+	return &MongoUserSummaryRepository{
+		client:   client,
+		database: database,
+	}
+}
+
+// SetCounts makes ListSummaries read the number of active users from the user counters instead of
+// counting documents on every request
+func (r *MongoUserSummaryRepository) SetCounts(counts repositories.UserCountRepository) {
+	r.counts = counts
+}
+
+// SaveSummary upserts the summary of a user. The last login is only set when the summary has
+// one, so recreating a summary keeps the recorded login.
+func (r *MongoUserSummaryRepository) SaveSummary(ctx context.Context, summary *entities.UserSummary) error {
+	set := bson.M{
+		"email":      summary.Email,
+		"name":       summary.Name,
+		"status":     summary.Status,
+		"created_at": summary.CreatedAt,
+	}
+	if summary.LastLoginAt != nil {
+		set["last_login_at"] = *summary.LastLoginAt
+	}
+
+	_, err := r.collection().UpdateOne(ctx,
+		bson.M{"user_id": summary.UserID},
+		bson.M{"$set": set},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// UpdateName sets the name of a user's summary
+func (r *MongoUserSummaryRepository) UpdateName(ctx context.Context, userID, name string) error {
+	_, err := r.collection().UpdateOne(ctx, bson.M{"user_id": userID}, bson.M{"$set": bson.M{"name": name}})
+	return err
+}
+
+// MarkDeleted sets the status of a user's summary to deleted
+func (r *MongoUserSummaryRepository) MarkDeleted(ctx context.Context, userID string) error {
+	_, err := r.collection().UpdateOne(ctx, bson.M{"user_id": userID}, bson.M{"$set": bson.M{"status": entities.UserStatusDeleted}})
+	return err
+}
+
+// RecordLogin sets the last login of a user's summary, ignoring logins older than the recorded one
+func (r *MongoUserSummaryRepository) RecordLogin(ctx context.Context, userID string, at time.Time) error {
+	_, err := r.collection().UpdateOne(ctx, bson.M{"user_id": userID}, bson.M{"$max": bson.M{"last_login_at": at}})
+	return err
+}
+
+// ListSummaries returns a page of active users, newest first
+func (r *MongoUserSummaryRepository) ListSummaries(ctx context.Context, page, pageSize int) ([]*entities.UserSummary, int64, error) {
+	collection := r.collection()
+	filter := bson.M{"status": entities.UserStatusActive}
+
+	total, err := activeUserTotal(ctx, r.counts, func() (int64, error) {
+		return collection.CountDocuments(ctx, filter)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var summaries []*entities.UserSummary
+	if err = cursor.All(ctx, &summaries); err != nil {
+		return nil, 0, err
+	}
+	return summaries, total, nil
+}
+
+func (r *MongoUserSummaryRepository) collection() *mongo.Collection {
+	return r.client.Database(r.database).Collection(UserSummaryCollection)
+}
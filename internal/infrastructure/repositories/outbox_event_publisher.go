@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+)
+
+// OutboxEventPublisher implements EventPublisher by appending events to the outbox, in the
+// transaction of the aggregate change when ctx carries one. The outbox relay publishes them to
+// the message broker, so events are not lost when publishing fails after the change committed.
+type OutboxEventPublisher struct {
+	outbox *PostgresOutbox
+}
+
+// NewOutboxEventPublisher creates a new outbox event publisher
+func NewOutboxEventPublisher(outbox *PostgresOutbox) *OutboxEventPublisher {
+	return &OutboxEventPublisher{
+		outbox: outbox,
+	}
+}
+
+// PublishEvent appends an event to the outbox with the headers of publishing it within ctx
+func (p *OutboxEventPublisher) PublishEvent(ctx context.Context, event *domainEvent.Event) error {
+	return p.outbox.Append(ctx, event, eventHeaders(ctx, event))
+}
+
+// PublishEvents appends multiple events to the outbox
+func (p *OutboxEventPublisher) PublishEvents(ctx context.Context, events []*domainEvent.Event) error {
+	for _, event := range events {
+		if err := p.PublishEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/pkg/kafka"
+)
+
+// OutboxStore hands unpublished outbox events to the relay. See PostgresOutbox.RelayBatch.
+type OutboxStore interface {
+	RelayBatch(ctx context.Context, limit int, publish func(ctx context.Context, message *OutboxMessage) error) (int, error)
+}
+
+// OutboxPublisher publishes relayed events to the message broker. See
+// MessageBrokerEventPublisher.PublishEventWithHeaders.
+type OutboxPublisher interface {
+	PublishEventWithHeaders(ctx context.Context, event *domainEvent.Event, headers kafka.Headers) error
+}
+
+// OutboxLogger logs relay failures
+type OutboxLogger interface {
+	Error(msg string, args ...interface{})
+}
+
+// OutboxRelay publishes the events of the outbox to the message broker in the order they were
+// appended. Every message carries the event ID as its idempotency key: an event published again
+// after the relay failed to mark it, e.g. when it crashed, is recognized by consumers.
+type OutboxRelay struct {
+	store     OutboxStore
+	publisher OutboxPublisher
+	batchSize int
+	interval  time.Duration
+	logger    OutboxLogger
+}
+
+// NewOutboxRelay creates a relay publishing up to batchSize events at a time, polling the outbox
+// every interval once it is drained
+func NewOutboxRelay(store OutboxStore, publisher OutboxPublisher, batchSize int, interval time.Duration, logger OutboxLogger) *OutboxRelay {
+	return &OutboxRelay{
+		store:     store,
+		publisher: publisher,
+		batchSize: batchSize,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Run relays events until ctx is done. Batches are relayed back to back while the outbox is
+// full, and failed batches are retried on the next poll.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := r.RelayOnce(ctx)
+		if err != nil && r.logger != nil {
+			r.logger.Error("Failed to relay outbox events: %v", err)
+		}
+		if err == nil && published == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes a batch of events, returning the number published
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	return r.store.RelayBatch(ctx, r.batchSize, r.publish)
+}
+
+// publish publishes an outbox event with its idempotency key
+func (r *OutboxRelay) publish(ctx context.Context, message *OutboxMessage) error {
+	var event domainEvent.Event
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal outbox event %s: %w", message.EventID, err)
+	}
+
+	headers := message.Headers.Clone()
+	headers.Set(kafka.HeaderIdempotency, message.EventID)
+	return r.publisher.PublishEventWithHeaders(ctx, &event, headers)
+}
@@ -0,0 +1,83 @@
+package repositories_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/kafka"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOutbox relays its messages in order, stopping at the first failure like PostgresOutbox
+type memoryOutbox struct {
+	pending []*infraRepos.OutboxMessage
+}
+
+func (o *memoryOutbox) RelayBatch(ctx context.Context, limit int, publish func(ctx context.Context, message *infraRepos.OutboxMessage) error) (int, error) {
+	published := 0
+	for len(o.pending) > 0 && published < limit {
+		if err := publish(ctx, o.pending[0]); err != nil {
+			return published, err
+		}
+		o.pending = o.pending[1:]
+		published++
+	}
+	return published, nil
+}
+
+// recordingPublisher records the events it publishes, failing with err when set
+type recordingPublisher struct {
+	events  []*domainEvent.Event
+	headers []kafka.Headers
+	err     error
+}
+
+func (p *recordingPublisher) PublishEventWithHeaders(ctx context.Context, event *domainEvent.Event, headers kafka.Headers) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	p.headers = append(p.headers, headers)
+	return nil
+}
+
+func outboxMessage(t *testing.T, eventType string) *infraRepos.OutboxMessage {
+	event, err := domainEvent.NewEvent(eventType, map[string]string{"user_id": "user-1"}, 1)
+	require.NoError(t, err)
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderSchema, eventType)
+	return &infraRepos.OutboxMessage{EventID: event.ID.String(), EventType: eventType, Payload: payload, Headers: headers}
+}
+
+func TestOutboxRelay_RelayOnce(t *testing.T) {
+	created, updated := outboxMessage(t, "user.created"), outboxMessage(t, "user.updated")
+	store := &memoryOutbox{pending: []*infraRepos.OutboxMessage{created, updated}}
+	publisher := &recordingPublisher{err: errors.New("broker unavailable")}
+	relay := infraRepos.NewOutboxRelay(store, publisher, 10, time.Second, nil)
+
+	published, err := relay.RelayOnce(context.Background())
+	assert.Error(t, err)
+	assert.Zero(t, published)
+	assert.Len(t, store.pending, 2, "failed events stay in the outbox")
+
+	publisher.err = nil
+	published, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	require.Len(t, publisher.events, 2)
+	assert.Equal(t, "user.created", publisher.events[0].Type)
+	assert.Equal(t, created.EventID, publisher.events[0].ID.String())
+	assert.Equal(t, created.EventID, publisher.headers[0].IdempotencyKey())
+	assert.Equal(t, "user.created", publisher.headers[0].Schema(), "stored headers are kept")
+	assert.Equal(t, updated.EventID, publisher.headers[1].IdempotencyKey())
+	assert.Empty(t, created.Headers.IdempotencyKey(), "stored headers are not modified")
+}
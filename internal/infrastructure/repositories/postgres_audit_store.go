@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/pkg/audit"
+)
+
+// PostgresAuditStore implements audit.Store on the audit_log table of the write database
+type PostgresAuditStore struct {
+	db database.Database
+}
+
+// NewPostgresAuditStore creates a new PostgreSQL audit store
+func NewPostgresAuditStore(db interface{}) *PostgresAuditStore {
+	return &PostgresAuditStore{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// auditColumns are the columns scanned by scanAuditRecord
+const auditColumns = `id, category, action, actor, subject, details, occurred_at, legal_hold`
+
+// Append adds a record
+func (s *PostgresAuditStore) Append(ctx context.Context, record audit.Record) error {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	details, err := json.Marshal(record.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record details: %w", err)
+	}
+	query := `INSERT INTO audit_log (` + auditColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = sqlDB.ExecContext(ctx, query, record.ID, record.Category, record.Action, record.Actor, record.Subject,
+		details, record.OccurredAt.UTC(), record.LegalHold)
+	if err != nil {
+		return fmt.Errorf("failed to append audit record: %w", err)
+	}
+	return nil
+}
+
+// List returns the records matching filter, newest first
+func (s *PostgresAuditStore) List(ctx context.Context, filter audit.Filter) ([]audit.Record, error) {
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_log
+		WHERE ($1 = '' OR category = $1) AND ($2 = '' OR actor = $2) AND ($3 = '' OR subject = $3)
+		ORDER BY id DESC
+	`
+	args := []interface{}{filter.Category, filter.Actor, filter.Subject}
+	if filter.Limit > 0 {
+		query += ` LIMIT $4`
+		args = append(args, filter.Limit)
+	}
+	return s.query(ctx, query, args...)
+}
+
+// Expired returns up to limit records of category that occurred before t and are not under legal hold
+func (s *PostgresAuditStore) Expired(ctx context.Context, category string, before time.Time, limit int) ([]audit.Record, error) {
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_log
+		WHERE category = $1 AND occurred_at < $2 AND NOT legal_hold
+		ORDER BY occurred_at, id
+		LIMIT $3
+	`
+	return s.query(ctx, query, category, before.UTC(), limit)
+}
+
+// Delete deletes the records not under legal hold
+func (s *PostgresAuditStore) Delete(ctx context.Context, ids []string) (int, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := sqlDB.ExecContext(ctx, `DELETE FROM audit_log WHERE id = ANY($1) AND NOT legal_hold`, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit records: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit records: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// SetLegalHold places or lifts the legal hold of the records matching filter
+func (s *PostgresAuditStore) SetLegalHold(ctx context.Context, filter audit.Filter, hold bool) (int, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE audit_log SET legal_hold = $4
+		WHERE ($1 = '' OR category = $1) AND ($2 = '' OR actor = $2) AND ($3 = '' OR subject = $3)
+			AND legal_hold <> $4
+	`
+	result, err := sqlDB.ExecContext(ctx, query, filter.Category, filter.Actor, filter.Subject, hold)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set the legal hold of audit records: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to set the legal hold of audit records: %w", err)
+	}
+	return int(updated), nil
+}
+
+// query returns the records selected by query
+func (s *PostgresAuditStore) query(ctx context.Context, query string, args ...interface{}) ([]audit.Record, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sqlDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []audit.Record
+	for rows.Next() {
+		var record audit.Record
+		var details []byte
+		if err := rows.Scan(&record.ID, &record.Category, &record.Action, &record.Actor, &record.Subject,
+			&details, &record.OccurredAt, &record.LegalHold); err != nil {
+			return nil, fmt.Errorf("failed to scan audit record: %w", err)
+		}
+		if err := json.Unmarshal(details, &record.Details); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal details of audit record %s: %w", record.ID, err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// sqlDB returns the connection of the write database
+func (s *PostgresAuditStore) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
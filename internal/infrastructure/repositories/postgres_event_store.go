@@ -3,10 +3,14 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+
 	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
 	"go-clean-ddd-es-template/internal/infrastructure/database"
 )
 
@@ -22,6 +26,23 @@ func NewPostgresEventStore(db interface{}) *PostgresEventStore {
 	}
 }
 
+// uniqueViolation is the PostgreSQL error code of unique constraint violations
+const uniqueViolation = "23505"
+
+// eventPositionLock is the key of the advisory lock serializing appends, so events are numbered
+// with global positions in the order they are committed
+const eventPositionLock = 7_143_650_117
+
+// lockEventPositions takes the advisory lock of global positions until the transaction of ctx
+// ends. A position taken by a transaction committed later than the next one would otherwise be
+// skipped by readers already past it.
+func lockEventPositions(ctx context.Context, sqlDB *sql.DB) error {
+	if _, err := database.ExecutorFrom(ctx, sqlDB).ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, eventPositionLock); err != nil {
+		return fmt.Errorf("failed to lock event positions: %w", err)
+	}
+	return nil
+}
+
 // databaseWrapper wraps the database connection to implement Database interface
 type databaseWrapper struct {
 	db interface{}
@@ -39,7 +60,9 @@ func (d *databaseWrapper) GetDB() interface{} {
 	return d.db
 }
 
-// SaveEvent saves an event to the event store
+// SaveEvent saves an event to the event store, in a transaction numbering it with the next global
+// position. It fails with a ConcurrencyError when the aggregate already has an event of the same
+// version.
 func (s *PostgresEventStore) SaveEvent(ctx context.Context, aggregateID string, event *domainEvent.Event) error {
 	// Get underlying database connection
 	dbConn := s.db.GetDB()
@@ -55,36 +78,239 @@ func (s *PostgresEventStore) SaveEvent(ctx context.Context, aggregateID string,
 
 	// Insert event into events table
 	query := `
-		INSERT INTO events (aggregate_id, aggregate_type, event_type, event_data, version, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO events (aggregate_id, aggregate_type, event_type, event_data, version, created_at, causation_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	_, err := sqlDB.ExecContext(ctx, query,
-		aggregateID,
-		"user", // aggregate type
-		event.Type,
-		event.Data,
-		event.Version,
-		event.Timestamp,
-	)
+	return database.WithTransaction(ctx, sqlDB, func(ctx context.Context) error {
+		if err := lockEventPositions(ctx, sqlDB); err != nil {
+			return err
+		}
+		if err := recordCommand(ctx, database.ExecutorFrom(ctx, sqlDB), aggregateID); err != nil {
+			return err
+		}
+		_, err := database.ExecutorFrom(ctx, sqlDB).ExecContext(ctx, query,
+			aggregateID,
+			"user", // aggregate type
+			event.Type,
+			event.Data,
+			event.Version,
+			event.Timestamp,
+			causationOf(ctx, event),
+		)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return repositories.NewConcurrencyError(aggregateID, event.Version-1, event.Version)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert event: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetEvents retrieves all events for an aggregate, in version order
+func (s *PostgresEventStore) GetEvents(ctx context.Context, aggregateID string) ([]*domainEvent.Event, error) {
+	// Get underlying database connection
+	dbConn := s.db.GetDB()
+	if dbConn == nil {
+		return nil, fmt.Errorf("database connection not available")
+	}
+
+	// Type assertion to get *sql.DB
+	sqlDB, ok := dbConn.(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("database connection is not *sql.DB")
+	}
+
+	query := `
+		SELECT event_type, event_data, version, created_at
+		FROM events
+		WHERE aggregate_id = $1
+		ORDER BY version
+	`
+	rows, err := database.ExecutorFrom(ctx, sqlDB).QueryContext(ctx, query, aggregateID)
 	if err != nil {
-		return fmt.Errorf("failed to insert event: %w", err)
+		return nil, fmt.Errorf("failed to query events: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var stored []*domainEvent.Event
+	for rows.Next() {
+		var event domainEvent.Event
+		if err := rows.Scan(&event.Type, &event.Data, &event.Version, &event.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		stored = append(stored, &event)
+	}
+	return stored, rows.Err()
 }
 
-// GetEvents retrieves all events for an aggregate
-func (s *PostgresEventStore) GetEvents(ctx context.Context, aggregateID string) ([]*domainEvent.Event, error) {
+// AppendEvents appends events to the stream of an aggregate, numbered from expectedVersion+1, in
+// a transaction numbering them with the next global positions. Writers appending concurrently at
+// the same version conflict on the unique index of aggregate versions.
+func (s *PostgresEventStore) AppendEvents(ctx context.Context, aggregateID string, expectedVersion int, events ...*domainEvent.Event) error {
 	// Get underlying database connection
 	dbConn := s.db.GetDB()
 	if dbConn == nil {
-		return nil, fmt.Errorf("database connection not available")
+		return fmt.Errorf("database connection not available")
 	}
 
-	// For now, we'll return an error indicating the implementation is not available
-	// In a real implementation, you'd have type assertions to handle different database types
-	return nil, fmt.Errorf("event store implementation not available - use PostgreSQL")
+	// Type assertion to get *sql.DB
+	sqlDB, ok := dbConn.(*sql.DB)
+	if !ok {
+		return fmt.Errorf("database connection is not *sql.DB")
+	}
+
+	return database.WithTransaction(ctx, sqlDB, func(ctx context.Context) error {
+		if err := lockEventPositions(ctx, sqlDB); err != nil {
+			return err
+		}
+		tx := database.ExecutorFrom(ctx, sqlDB)
+
+		var current int
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM events WHERE aggregate_id = $1`, aggregateID).Scan(&current); err != nil {
+			return fmt.Errorf("failed to get last event version: %w", err)
+		}
+		if current != expectedVersion {
+			return repositories.NewConcurrencyError(aggregateID, expectedVersion, current)
+		}
+		if err := recordCommand(ctx, tx, aggregateID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO events (aggregate_id, aggregate_type, event_type, event_data, version, created_at, causation_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`
+		for i, event := range events {
+			version := expectedVersion + i + 1
+			_, err := tx.ExecContext(ctx, query, aggregateID, "user", event.Type, event.Data, version, event.Timestamp, causationOf(ctx, event))
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+				return repositories.NewConcurrencyError(aggregateID, expectedVersion, version)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to insert event: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// HasCausation reports whether events caused by causationID were stored
+func (s *PostgresEventStore) HasCausation(ctx context.Context, causationID string) (bool, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return false, fmt.Errorf("database connection is not *sql.DB")
+	}
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM events WHERE causation_id = $1)`
+	if err := database.ExecutorFrom(ctx, sqlDB).QueryRowContext(ctx, query, causationID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to look up events by causation: %w", err)
+	}
+	return exists, nil
+}
+
+// recordCommand records the command of ctx handled, in the transaction appending its events to
+// the stream of aggregateID. The command ID is the key of handled_commands, so of two appends of
+// a command submitted twice concurrently, the second conflicts once the first committed and fails
+// with DUPLICATE_COMMAND, appending nothing.
+func recordCommand(ctx context.Context, tx database.Executor, aggregateID string) error {
+	commandID := domainEvent.MetadataFromContext(ctx).CommandID
+	if commandID == "" {
+		return nil
+	}
+
+	query := `INSERT INTO handled_commands (command_id, aggregate_id, handled_at) VALUES ($1, $2, $3)`
+	_, err := tx.ExecContext(ctx, query, commandID, aggregateID, time.Now().UTC())
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return repositories.NewDuplicateCommandError(commandID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record command: %w", err)
+	}
+	return nil
+}
+
+// causationOf returns the causation of an event, the one of the events published within ctx when
+// it has none, NULL when neither has one
+func causationOf(ctx context.Context, event *domainEvent.Event) sql.NullString {
+	causationID := event.CausationID
+	if causationID == "" {
+		causationID = domainEvent.MetadataFromContext(ctx).CausationID
+	}
+	return sql.NullString{String: causationID, Valid: causationID != ""}
+}
+
+// CountEvents returns the number of events stored
+func (s *PostgresEventStore) CountEvents(ctx context.Context) (int, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return 0, fmt.Errorf("database connection is not *sql.DB")
+	}
+
+	var count int
+	if err := sqlDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM events`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count events: %w", err)
+	}
+	return count, nil
+}
+
+// ReadEvents returns up to limit events stored after a position, in the order of their global
+// positions. Positions without a global position are read after by creation time, then aggregate
+// and version, as they were before events were numbered.
+func (s *PostgresEventStore) ReadEvents(ctx context.Context, after *repositories.EventPosition, limit int) ([]*repositories.StoredEvent, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("database connection is not *sql.DB")
+	}
+
+	query := `
+		SELECT id, position, aggregate_id, event_type, event_data, version, created_at
+		FROM events
+		ORDER BY position
+		LIMIT $1
+	`
+	args := []interface{}{limit}
+	switch {
+	case after != nil && after.GlobalPosition > 0:
+		query = `
+			SELECT id, position, aggregate_id, event_type, event_data, version, created_at
+			FROM events
+			WHERE position > $2
+			ORDER BY position
+			LIMIT $1
+		`
+		args = append(args, after.GlobalPosition)
+	case after != nil:
+		query = `
+			SELECT id, position, aggregate_id, event_type, event_data, version, created_at
+			FROM events
+			WHERE (created_at, aggregate_id, version) > ($2, $3, $4)
+			ORDER BY position
+			LIMIT $1
+		`
+		args = append(args, after.StoredAt, after.AggregateID, after.Version)
+	}
+	rows, err := database.ExecutorFrom(ctx, sqlDB).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var stored []*repositories.StoredEvent
+	for rows.Next() {
+		var event domainEvent.Event
+		record := repositories.StoredEvent{Event: &event}
+		if err := rows.Scan(&record.ID, &record.GlobalPosition, &record.AggregateID, &event.Type, &event.Data, &event.Version, &event.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		stored = append(stored, &record)
+	}
+	return stored, rows.Err()
 }
 
 // GetEventsByType retrieves events by type
@@ -113,7 +339,8 @@ func (s *PostgresEventStore) GetEventsSince(ctx context.Context, since time.Time
 	return nil, fmt.Errorf("event store implementation not available - use PostgreSQL")
 }
 
-// GetLastEventVersion gets the last event version for an aggregate
+// GetLastEventVersion gets the last event version for an aggregate, the number of events stored for it.
+// Returns 0 for an aggregate without events.
 func (s *PostgresEventStore) GetLastEventVersion(ctx context.Context, aggregateID string) (int, error) {
 	// Get underlying database connection
 	dbConn := s.db.GetDB()
@@ -121,9 +348,19 @@ func (s *PostgresEventStore) GetLastEventVersion(ctx context.Context, aggregateI
 		return 0, fmt.Errorf("database connection not available")
 	}
 
-	// For now, we'll return an error indicating the implementation is not available
-	// In a real implementation, you'd have type assertions to handle different database types
-	return 0, fmt.Errorf("event store implementation not available - use PostgreSQL")
+	// Type assertion to get *sql.DB
+	sqlDB, ok := dbConn.(*sql.DB)
+	if !ok {
+		return 0, fmt.Errorf("database connection is not *sql.DB")
+	}
+
+	var version int
+	query := `SELECT COUNT(*) FROM events WHERE aggregate_id = $1`
+	if err := sqlDB.QueryRowContext(ctx, query, aggregateID).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get last event version: %w", err)
+	}
+
+	return version, nil
 }
 
 // Close closes the database connection
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+)
+
+// PostgresKeyRotationStore implements KeyRotationStore on the events and key_rotation_checkpoints
+// tables of the event database
+type PostgresKeyRotationStore struct {
+	db database.Database
+}
+
+// NewPostgresKeyRotationStore creates a new PostgreSQL key rotation store
+func NewPostgresKeyRotationStore(db interface{}) *PostgresKeyRotationStore {
+	return &PostgresKeyRotationStore{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// StaleEvents returns up to limit events of a tenant encrypted with a key version older than
+// keyVersion, ordered by ID after afterID
+func (s *PostgresKeyRotationStore) StaleEvents(ctx context.Context, tenant string, keyVersion int, afterID string, limit int) ([]*StoredEventData, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id::text, event_data
+		FROM events
+		WHERE event_data ? 'ciphertext'
+			AND event_data->>'tenant_id' = $1
+			AND (event_data->>'key_version')::int < $2
+			AND ($3 = '' OR id > $3::uuid)
+		ORDER BY id
+		LIMIT $4
+	`
+	rows, err := sqlDB.QueryContext(ctx, query, tenant, keyVersion, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events to re-encrypt: %w", err)
+	}
+	defer rows.Close()
+
+	var stale []*StoredEventData
+	for rows.Next() {
+		var event StoredEventData
+		if err := rows.Scan(&event.ID, &event.Data); err != nil {
+			return nil, fmt.Errorf("failed to scan event to re-encrypt: %w", err)
+		}
+		stale = append(stale, &event)
+	}
+	return stale, rows.Err()
+}
+
+// UpdateEventData replaces the data of an event
+func (s *PostgresKeyRotationStore) UpdateEventData(ctx context.Context, id string, data []byte) error {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return err
+	}
+	if _, err := sqlDB.ExecContext(ctx, `UPDATE events SET event_data = $2 WHERE id = $1`, id, data); err != nil {
+		return fmt.Errorf("failed to update event %s: %w", id, err)
+	}
+	return nil
+}
+
+// LoadCheckpoint returns the re-encryption checkpoint of a tenant, nil when there is none
+func (s *PostgresKeyRotationStore) LoadCheckpoint(ctx context.Context, tenant string) (*KeyRotationCheckpoint, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := KeyRotationCheckpoint{TenantID: tenant}
+	var lastEventID sql.NullString
+	query := `SELECT key_version, last_event_id::text, reencrypted, updated_at FROM key_rotation_checkpoints WHERE tenant_id = $1`
+	err = sqlDB.QueryRowContext(ctx, query, tenant).Scan(&checkpoint.KeyVersion, &lastEventID, &checkpoint.Reencrypted, &checkpoint.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key rotation checkpoint of tenant %s: %w", tenant, err)
+	}
+	checkpoint.LastEventID = lastEventID.String
+	return &checkpoint, nil
+}
+
+// SaveCheckpoint creates or replaces the re-encryption checkpoint of a tenant
+func (s *PostgresKeyRotationStore) SaveCheckpoint(ctx context.Context, checkpoint *KeyRotationCheckpoint) error {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO key_rotation_checkpoints (tenant_id, key_version, last_event_id, reencrypted, updated_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			key_version = EXCLUDED.key_version,
+			last_event_id = EXCLUDED.last_event_id,
+			reencrypted = EXCLUDED.reencrypted,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err = sqlDB.ExecContext(ctx, query, checkpoint.TenantID, checkpoint.KeyVersion, checkpoint.LastEventID, checkpoint.Reencrypted, checkpoint.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save key rotation checkpoint of tenant %s: %w", checkpoint.TenantID, err)
+	}
+	return nil
+}
+
+// sqlDB returns the connection of the event database
+func (s *PostgresKeyRotationStore) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+)
+
+// PostgresLoginAttemptRepository implements LoginAttemptRepository using the login_attempts table
+// of the write database, sharing the failures counted between instances
+type PostgresLoginAttemptRepository struct {
+	db database.Database
+}
+
+// NewPostgresLoginAttemptRepository creates a new PostgreSQL login attempt repository
+func NewPostgresLoginAttemptRepository(db interface{}) *PostgresLoginAttemptRepository {
+	return &PostgresLoginAttemptRepository{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// RecordFailure records a failed login of a key, returning its failures within window. Keys
+// neither counted nor locked out anymore are deleted along the way.
+func (r *PostgresLoginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	windowStart := now.Add(-window)
+	query := `
+		DELETE FROM login_attempts
+		WHERE (first_failed_at IS NULL OR first_failed_at <= $1) AND (locked_until IS NULL OR locked_until <= $2)
+	`
+	if _, err := sqlDB.ExecContext(ctx, query, windowStart, now); err != nil {
+		return 0, fmt.Errorf("failed to delete expired login attempts: %w", err)
+	}
+
+	query = `
+		INSERT INTO login_attempts (attempt_key, failures, first_failed_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (attempt_key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.failures = 0 OR login_attempts.first_failed_at <= $3 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			first_failed_at = CASE
+				WHEN login_attempts.failures = 0 OR login_attempts.first_failed_at <= $3 THEN $2
+				ELSE login_attempts.first_failed_at
+			END
+		RETURNING failures
+	`
+	var failures int
+	if err := sqlDB.QueryRowContext(ctx, query, key, now, windowStart).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return failures, nil
+}
+
+// Lock locks a key out until a time, and starts its count of failures over
+func (r *PostgresLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO login_attempts (attempt_key, failures, locked_until)
+		VALUES ($1, 0, $2)
+		ON CONFLICT (attempt_key) DO UPDATE SET failures = 0, first_failed_at = NULL, locked_until = $2
+	`
+	if _, err := sqlDB.ExecContext(ctx, query, key, until.UTC()); err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+	return nil
+}
+
+// LockedUntil returns the end of the lockout of a key, the zero time when it is not locked out
+func (r *PostgresLoginAttemptRepository) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	query := `SELECT locked_until FROM login_attempts WHERE attempt_key = $1 AND locked_until > $2`
+	var lockedUntil time.Time
+	err = sqlDB.QueryRowContext(ctx, query, key, time.Now().UTC()).Scan(&lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get login lockout: %w", err)
+	}
+	return lockedUntil, nil
+}
+
+// Reset forgets the failures of a key
+func (r *PostgresLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE login_attempts SET failures = 0, first_failed_at = NULL WHERE attempt_key = $1`
+	if _, err := sqlDB.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+// sqlDB returns the connection of the write database
+func (r *PostgresLoginAttemptRepository) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := r.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
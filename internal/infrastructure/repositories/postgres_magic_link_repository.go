@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+)
+
+// PostgresMagicLinkRepository implements MagicLinkRepository using the consumed_magic_links table
+// of the write database
+type PostgresMagicLinkRepository struct {
+	db database.Database
+}
+
+// NewPostgresMagicLinkRepository creates a new PostgreSQL magic link repository
+func NewPostgresMagicLinkRepository(db interface{}) *PostgresMagicLinkRepository {
+	return &PostgresMagicLinkRepository{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// Consume records that a link was consumed, returning false when it already was. Links expired
+// are deleted along the way.
+func (r *PostgresMagicLinkRepository) Consume(ctx context.Context, linkID, userID string, expiresAt time.Time) (bool, error) {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := sqlDB.ExecContext(ctx, `DELETE FROM consumed_magic_links WHERE expires_at < $1`, time.Now().UTC()); err != nil {
+		return false, fmt.Errorf("failed to delete expired magic links: %w", err)
+	}
+
+	query := `
+		INSERT INTO consumed_magic_links (link_id, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (link_id) DO NOTHING
+	`
+	result, err := sqlDB.ExecContext(ctx, query, linkID, userID, expiresAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to consume magic link: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume magic link: %w", err)
+	}
+	return inserted == 1, nil
+}
+
+// sqlDB returns the connection of the write database
+func (r *PostgresMagicLinkRepository) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := r.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/pkg/operation"
+)
+
+// PostgresOperationStore implements operation.Store on the operations table of the write database,
+// sharing the operations between instances
+type PostgresOperationStore struct {
+	db database.Database
+}
+
+// NewPostgresOperationStore creates a new PostgreSQL operation store
+func NewPostgresOperationStore(db interface{}) *PostgresOperationStore {
+	return &PostgresOperationStore{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// operationColumns are the columns scanned by scanOperation
+const operationColumns = `id, kind, args, status, done, total, result, error, requested_by, cancel_requested, created_at, updated_at, finished_at`
+
+// Save creates or updates an operation. Updates of finished operations are rejected and
+// cancellation requests are kept.
+func (s *PostgresOperationStore) Save(ctx context.Context, op operation.Operation) (operation.Operation, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return operation.Operation{}, err
+	}
+
+	args, err := json.Marshal(op.Args)
+	if err != nil {
+		return operation.Operation{}, fmt.Errorf("failed to marshal operation args: %w", err)
+	}
+	var result interface{}
+	if len(op.Result) > 0 {
+		result = []byte(op.Result)
+	}
+
+	query := `
+		INSERT INTO operations (` + operationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			done = EXCLUDED.done,
+			total = EXCLUDED.total,
+			result = EXCLUDED.result,
+			error = EXCLUDED.error,
+			cancel_requested = operations.cancel_requested OR EXCLUDED.cancel_requested,
+			updated_at = EXCLUDED.updated_at,
+			finished_at = EXCLUDED.finished_at
+		WHERE operations.status = $14
+		RETURNING cancel_requested
+	`
+	err = sqlDB.QueryRowContext(ctx, query, op.ID, op.Kind, args, op.Status, op.Done, op.Total, result, op.Error,
+		op.RequestedBy, op.CancelRequested, op.CreatedAt.UTC(), op.UpdatedAt.UTC(), nullTime(op.FinishedAt),
+		operation.StatusRunning).Scan(&op.CancelRequested)
+	if errors.Is(err, sql.ErrNoRows) {
+		stored, err := s.Get(ctx, op.ID)
+		if err != nil {
+			return operation.Operation{}, err
+		}
+		return stored, operation.ErrFinished
+	}
+	if err != nil {
+		return operation.Operation{}, fmt.Errorf("failed to save operation %s: %w", op.ID, err)
+	}
+	return op, nil
+}
+
+// Get returns an operation
+func (s *PostgresOperationStore) Get(ctx context.Context, id string) (operation.Operation, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return operation.Operation{}, err
+	}
+
+	row := sqlDB.QueryRowContext(ctx, `SELECT `+operationColumns+` FROM operations WHERE id = $1`, id)
+	op, err := scanOperation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return operation.Operation{}, operation.ErrNotFound
+	}
+	if err != nil {
+		return operation.Operation{}, fmt.Errorf("failed to get operation %s: %w", id, err)
+	}
+	return op, nil
+}
+
+// List returns the operations matching filter, newest first
+func (s *PostgresOperationStore) List(ctx context.Context, filter operation.Filter) ([]operation.Operation, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + operationColumns + `
+		FROM operations
+		WHERE ($1 = '' OR kind = $1) AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+	`
+	queryArgs := []interface{}{filter.Kind, filter.Status}
+	if filter.Limit > 0 {
+		query += ` LIMIT $3`
+		queryArgs = append(queryArgs, filter.Limit)
+	}
+	rows, err := sqlDB.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operations: %w", err)
+	}
+	defer rows.Close()
+
+	var operations []operation.Operation
+	for rows.Next() {
+		op, err := scanOperation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan operation: %w", err)
+		}
+		operations = append(operations, op)
+	}
+	return operations, rows.Err()
+}
+
+// DeleteFinishedBefore deletes the operations finished before t
+func (s *PostgresOperationStore) DeleteFinishedBefore(ctx context.Context, t time.Time) (int, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := sqlDB.ExecContext(ctx, `DELETE FROM operations WHERE finished_at < $1`, t.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished operations: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished operations: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// operationRow is a row of operations, read by QueryRow or Query
+type operationRow interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOperation scans the operationColumns of a row
+func scanOperation(row operationRow) (operation.Operation, error) {
+	var op operation.Operation
+	var args, result []byte
+	var finishedAt sql.NullTime
+	err := row.Scan(&op.ID, &op.Kind, &args, &op.Status, &op.Done, &op.Total, &result, &op.Error,
+		&op.RequestedBy, &op.CancelRequested, &op.CreatedAt, &op.UpdatedAt, &finishedAt)
+	if err != nil {
+		return operation.Operation{}, err
+	}
+	if err := json.Unmarshal(args, &op.Args); err != nil {
+		return operation.Operation{}, fmt.Errorf("failed to unmarshal args of operation %s: %w", op.ID, err)
+	}
+	if len(result) > 0 {
+		op.Result = result
+	}
+	if finishedAt.Valid {
+		op.FinishedAt = &finishedAt.Time
+	}
+	op.SetProgress(op.Done, op.Total)
+	return op, nil
+}
+
+// nullTime converts an optional time for a nullable timestamp column
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// sqlDB returns the connection of the write database
+func (s *PostgresOperationStore) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
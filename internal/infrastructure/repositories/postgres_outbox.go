@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/pkg/kafka"
+)
+
+// OutboxMessage is an event of the outbox waiting to be published
+type OutboxMessage struct {
+	ID        int64
+	EventID   string
+	EventType string
+	Payload   []byte // JSON encoded event
+	Headers   kafka.Headers
+	Attempts  int
+}
+
+// PostgresOutbox keeps domain events in the outbox table of the write database until the outbox
+// relay publishes them
+type PostgresOutbox struct {
+	db database.Database
+}
+
+// NewPostgresOutbox creates a new PostgreSQL outbox
+func NewPostgresOutbox(db database.Database) *PostgresOutbox {
+	return &PostgresOutbox{
+		db: db,
+	}
+}
+
+// Append adds an event to the outbox, within the transaction ctx carries if any. Events already
+// in the outbox are ignored.
+func (o *PostgresOutbox) Append(ctx context.Context, event *domainEvent.Event, headers kafka.Headers) error {
+	sqlDB, err := o.sqlDB()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event headers: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox (event_id, event_type, payload, headers)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id) DO NOTHING
+	`
+	if _, err := database.ExecutorFrom(ctx, sqlDB).ExecContext(ctx, query, event.ID.String(), event.Type, payload, encodedHeaders); err != nil {
+		return fmt.Errorf("failed to append event to outbox: %w", err)
+	}
+	return nil
+}
+
+// RelayBatch locks up to limit unpublished events, oldest first, and hands each to publish.
+// Published events are marked so; at the first failure its error is recorded and the batch stops,
+// so later events are not published before it. Events locked by a concurrent relay are skipped.
+// It returns the number of events published.
+func (o *PostgresOutbox) RelayBatch(ctx context.Context, limit int, publish func(ctx context.Context, message *OutboxMessage) error) (int, error) {
+	sqlDB, err := o.sqlDB()
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	err = database.WithTransaction(ctx, sqlDB, func(ctx context.Context) error {
+		messages, err := o.lockPending(ctx, sqlDB, limit)
+		if err != nil {
+			return err
+		}
+
+		tx := database.ExecutorFrom(ctx, sqlDB)
+		for _, message := range messages {
+			if publishErr = publish(ctx, message); publishErr != nil {
+				_, err := tx.ExecContext(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`, message.ID, publishErr.Error())
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = NULL, published_at = NOW() WHERE id = $1`, message.ID); err != nil {
+				return fmt.Errorf("failed to mark outbox event %s published: %w", message.EventID, err)
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if publishErr != nil {
+		return published, fmt.Errorf("failed to publish outbox event: %w", publishErr)
+	}
+	return published, nil
+}
+
+// lockPending locks up to limit unpublished events, oldest first, skipping locked ones
+func (o *PostgresOutbox) lockPending(ctx context.Context, sqlDB *sql.DB, limit int) ([]*OutboxMessage, error) {
+	query := `
+		SELECT id, event_id, event_type, payload, headers, attempts
+		FROM outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := database.ExecutorFrom(ctx, sqlDB).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*OutboxMessage
+	for rows.Next() {
+		var message OutboxMessage
+		var headers []byte
+		if err := rows.Scan(&message.ID, &message.EventID, &message.EventType, &message.Payload, &headers, &message.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		if err := json.Unmarshal(headers, &message.Headers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal headers of outbox event %s: %w", message.EventID, err)
+		}
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
+
+// sqlDB returns the connection of the write database
+func (o *PostgresOutbox) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := o.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+)
+
+// PostgresProcessedEventRepository implements ProcessedEventRepository using the
+// processed_events table of the event database
+type PostgresProcessedEventRepository struct {
+	db database.Database
+}
+
+// NewPostgresProcessedEventRepository creates a new PostgreSQL processed event repository
+func NewPostgresProcessedEventRepository(db interface{}) *PostgresProcessedEventRepository {
+	return &PostgresProcessedEventRepository{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// IsProcessed reports whether the consumer group already processed the event
+func (r *PostgresProcessedEventRepository) IsProcessed(ctx context.Context, consumerGroup, eventID string) (bool, error) {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return false, err
+	}
+
+	var processed bool
+	query := `SELECT EXISTS (SELECT 1 FROM processed_events WHERE consumer_group = $1 AND event_id = $2)`
+	if err := sqlDB.QueryRowContext(ctx, query, consumerGroup, eventID).Scan(&processed); err != nil {
+		return false, fmt.Errorf("failed to look up processed event: %w", err)
+	}
+	return processed, nil
+}
+
+// MarkProcessed records that the consumer group processed the event
+func (r *PostgresProcessedEventRepository) MarkProcessed(ctx context.Context, consumerGroup, eventID, eventType string) error {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO processed_events (consumer_group, event_id, event_type)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (consumer_group, event_id) DO NOTHING
+	`
+	if _, err := sqlDB.ExecContext(ctx, query, consumerGroup, eventID, eventType); err != nil {
+		return fmt.Errorf("failed to mark event processed: %w", err)
+	}
+	return nil
+}
+
+// sqlDB returns the connection of the event database
+func (r *PostgresProcessedEventRepository) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := r.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+)
+
+// PostgresProjectionCheckpointStore implements projection.CheckpointStore on the
+// projection_checkpoints table of the event database
+type PostgresProjectionCheckpointStore struct {
+	db database.Database
+}
+
+// NewPostgresProjectionCheckpointStore creates a new PostgreSQL projection checkpoint store
+func NewPostgresProjectionCheckpointStore(db interface{}) *PostgresProjectionCheckpointStore {
+	return &PostgresProjectionCheckpointStore{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// Load returns the position of a projection, empty when it has no checkpoint
+func (s *PostgresProjectionCheckpointStore) Load(ctx context.Context, projection string) (string, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return "", err
+	}
+
+	var position string
+	err = sqlDB.QueryRowContext(ctx, `SELECT position FROM projection_checkpoints WHERE name = $1`, projection).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load checkpoint of projection %s: %w", projection, err)
+	}
+	return position, nil
+}
+
+// Save creates or replaces the checkpoint of a projection
+func (s *PostgresProjectionCheckpointStore) Save(ctx context.Context, projection, position string) error {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO projection_checkpoints (name, position, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (name) DO UPDATE SET
+			position = EXCLUDED.position,
+			updated_at = EXCLUDED.updated_at
+	`
+	if _, err := sqlDB.ExecContext(ctx, query, projection, position); err != nil {
+		return fmt.Errorf("failed to save checkpoint of projection %s: %w", projection, err)
+	}
+	return nil
+}
+
+// sqlDB returns the connection of the event database
+func (s *PostgresProjectionCheckpointStore) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+)
+
+// PostgresRefreshTokenRepository implements RefreshTokenRepository using the refresh_tokens table
+// of the write database
+type PostgresRefreshTokenRepository struct {
+	db database.Database
+}
+
+// NewPostgresRefreshTokenRepository creates a new PostgreSQL refresh token repository
+func NewPostgresRefreshTokenRepository(db interface{}) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// Issue records a refresh token of a family, revoked already when its family is. Tokens expired
+// are deleted along the way.
+func (r *PostgresRefreshTokenRepository) Issue(ctx context.Context, tokenID, familyID, userID string, expiresAt time.Time) error {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	if _, err := sqlDB.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < $1`, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+
+	query := `
+		INSERT INTO refresh_tokens (token_id, family_id, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4, (SELECT MAX(revoked_at) FROM refresh_tokens WHERE family_id = $2))
+	`
+	if _, err := sqlDB.ExecContext(ctx, query, tokenID, familyID, userID, expiresAt.UTC()); err != nil {
+		return fmt.Errorf("failed to issue refresh token: %w", err)
+	}
+	return nil
+}
+
+// Rotate records that a token of a family was exchanged, returning false when it already was, its
+// family is revoked or it is unknown
+func (r *PostgresRefreshTokenRepository) Rotate(ctx context.Context, tokenID, familyID string) (bool, error) {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE refresh_tokens SET rotated_at = $3
+		WHERE token_id = $1 AND family_id = $2 AND rotated_at IS NULL AND revoked_at IS NULL
+	`
+	result, err := sqlDB.ExecContext(ctx, query, tokenID, familyID, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	rotated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	return rotated == 1, nil
+}
+
+// RevokeFamily revokes every token of a family
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+	if _, err := sqlDB.ExecContext(ctx, query, familyID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser revokes every family of a user, within the transaction of ctx when there is one
+func (r *PostgresRefreshTokenRepository) RevokeUser(ctx context.Context, userID string) error {
+	sqlDB, err := r.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := database.ExecutorFrom(ctx, sqlDB).ExecContext(ctx, query, userID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens of user: %w", err)
+	}
+	return nil
+}
+
+// sqlDB returns the connection of the write database
+func (r *PostgresRefreshTokenRepository) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := r.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
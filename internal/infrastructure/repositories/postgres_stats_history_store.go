@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/pkg/statshistory"
+)
+
+// PostgresStatsHistoryStore implements statshistory.Store on the pipeline_stats table of the
+// write database, one row per stat of a snapshot
+type PostgresStatsHistoryStore struct {
+	db database.Database
+}
+
+// NewPostgresStatsHistoryStore creates a new PostgreSQL stats history store
+func NewPostgresStatsHistoryStore(db interface{}) *PostgresStatsHistoryStore {
+	return &PostgresStatsHistoryStore{
+		db: &databaseWrapper{db: db},
+	}
+}
+
+// Save inserts the stats of a snapshot
+func (s *PostgresStatsHistoryStore) Save(ctx context.Context, snapshot statshistory.Snapshot) error {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(snapshot.Stats))
+	values := make([]float64, 0, len(snapshot.Stats))
+	for name, value := range snapshot.Stats {
+		names = append(names, name)
+		values = append(values, value)
+	}
+	query := `
+		INSERT INTO pipeline_stats (taken_at, instance, name, value)
+		SELECT $1, $2, stat.name, stat.value FROM unnest($3::text[], $4::float8[]) AS stat(name, value)
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := sqlDB.ExecContext(ctx, query, snapshot.TakenAt.UTC(), snapshot.Instance, pq.Array(names), pq.Array(values)); err != nil {
+		return fmt.Errorf("failed to save stats snapshot: %w", err)
+	}
+	return nil
+}
+
+// List returns the snapshots selected by query, oldest first
+func (s *PostgresStatsHistoryStore) List(ctx context.Context, query statshistory.Query) ([]statshistory.Snapshot, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return nil, err
+	}
+
+	// A NULL limit selects every snapshot
+	var limit sql.NullInt64
+	if query.Limit > 0 {
+		limit = sql.NullInt64{Int64: int64(query.Limit), Valid: true}
+	}
+	var since, until *time.Time
+	if !query.Since.IsZero() {
+		since = &query.Since
+	}
+	if !query.Until.IsZero() {
+		until = &query.Until
+	}
+	statement := `
+		WITH selected AS (
+			SELECT taken_at, instance, name, value
+			FROM pipeline_stats
+			WHERE ($1 = '' OR name = $1 OR name LIKE $2 ESCAPE '\')
+				AND ($3 = '' OR instance = $3)
+				AND ($4::timestamp IS NULL OR taken_at >= $4)
+				AND ($5::timestamp IS NULL OR taken_at < $5)
+		), snapshots AS (
+			SELECT DISTINCT taken_at, instance FROM selected ORDER BY taken_at DESC LIMIT $6
+		)
+		SELECT selected.taken_at, selected.instance, selected.name, selected.value
+		FROM selected JOIN snapshots USING (taken_at, instance)
+		ORDER BY selected.taken_at, selected.instance
+	`
+	rows, err := sqlDB.QueryContext(ctx, statement, query.Prefix, escapeLike(query.Prefix)+".%", query.Instance,
+		nullTime(since), nullTime(until), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stats snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []statshistory.Snapshot
+	for rows.Next() {
+		var takenAt time.Time
+		var instance, name string
+		var value float64
+		if err := rows.Scan(&takenAt, &instance, &name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan stats snapshot: %w", err)
+		}
+		if n := len(snapshots); n == 0 || !snapshots[n-1].TakenAt.Equal(takenAt) || snapshots[n-1].Instance != instance {
+			snapshots = append(snapshots, statshistory.Snapshot{TakenAt: takenAt, Instance: instance, Stats: make(map[string]float64)})
+		}
+		snapshots[len(snapshots)-1].Stats[name] = value
+	}
+	return snapshots, rows.Err()
+}
+
+// DeleteBefore deletes the snapshots taken before t
+func (s *PostgresStatsHistoryStore) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	sqlDB, err := s.sqlDB()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := sqlDB.ExecContext(ctx, `DELETE FROM pipeline_stats WHERE taken_at < $1`, t.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stats snapshots: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stats snapshots: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// sqlDB returns the connection of the write database
+func (s *PostgresStatsHistoryStore) sqlDB() (*sql.DB, error) {
+	sqlDB, ok := s.db.GetDB().(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+	return sqlDB, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
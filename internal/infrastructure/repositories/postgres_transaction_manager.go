@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/pkg/retry"
+)
+
+// TransactionRecorder records the outcome of transactions and their conflicts, see metrics.Metrics
+type TransactionRecorder interface {
+	RecordTransaction(operation, status string)
+	RecordTransactionConflict(operation, reason, outcome string)
+}
+
+// PostgresTransactionManager implements TransactionManager on the PostgreSQL write database
+type PostgresTransactionManager struct {
+	db       database.Database
+	retry    retry.Policy
+	recorder TransactionRecorder
+}
+
+// NewPostgresTransactionManager creates a new PostgreSQL transaction manager
+func NewPostgresTransactionManager(db database.Database) *PostgresTransactionManager {
+	return &PostgresTransactionManager{
+		db: db,
+	}
+}
+
+// SetRetry runs transactions aborted by a serialization failure or a deadlock again, as often as
+// policy allows, and records transactions and their conflicts per operation in recorder
+func (m *PostgresTransactionManager) SetRetry(policy retry.Policy, recorder TransactionRecorder) {
+	m.retry = policy
+	m.recorder = recorder
+}
+
+// WithinTransaction runs fn in a transaction on the write database. Units of work nested in a
+// transaction join it and are neither retried nor recorded on their own.
+func (m *PostgresTransactionManager) WithinTransaction(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	sqlDB, ok := m.db.GetDB().(*sql.DB)
+	if !ok {
+		return errors.New("invalid database connection type - expected sql.DB")
+	}
+	if database.InTransaction(ctx, sqlDB) {
+		return fn(ctx)
+	}
+
+	err := database.RetryTransaction(ctx, sqlDB, m.retry, func(reason string, retried bool) {
+		if m.recorder == nil {
+			return
+		}
+		outcome := "exhausted"
+		if retried {
+			outcome = "retried"
+		}
+		m.recorder.RecordTransactionConflict(operation, reason, outcome)
+	}, fn)
+	if m.recorder != nil {
+		status := "committed"
+		if err != nil {
+			status = "failed"
+		}
+		m.recorder.RecordTransaction(operation, status)
+	}
+	return err
+}
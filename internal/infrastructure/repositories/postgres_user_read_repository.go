@@ -7,6 +7,7 @@ import (
 
 	"go-clean-ddd-es-template/internal/domain/entities"
 	"go-clean-ddd-es-template/internal/infrastructure/database"
+	"go-clean-ddd-es-template/pkg/queryplan"
 )
 
 // PostgresUserReadRepository implements UserReadRepository using PostgreSQL
@@ -137,3 +138,61 @@ func (r *PostgresUserReadRepository) GetEventsByType(ctx context.Context, eventT
 	// For now, return a placeholder error
 	return nil, fmt.Errorf("PostgreSQL read repository implementation not available - use a real database driver")
 }
+
+// DescribeGetUserByID describes the query GetUserByID runs against the read model
+func (r *PostgresUserReadRepository) DescribeGetUserByID(userID string) queryplan.Query {
+	return postgresQuery("GetUserByID", `
+		SELECT id, user_id, email, name, created_at, updated_at, version
+		FROM user_read_models
+		WHERE user_id = $1 AND deleted_at IS NULL
+	`, userID)
+}
+
+// DescribeGetUserByEmail describes the query GetUserByEmail runs against the read model
+func (r *PostgresUserReadRepository) DescribeGetUserByEmail(email string) queryplan.Query {
+	return postgresQuery("GetUserByEmail", `
+		SELECT id, user_id, email, name, created_at, updated_at, version
+		FROM user_read_models
+		WHERE email = $1 AND deleted_at IS NULL
+	`, email)
+}
+
+// DescribeListUsers describes the query ListUsers runs against the read model
+func (r *PostgresUserReadRepository) DescribeListUsers(page, pageSize int) queryplan.Query {
+	return postgresQuery("ListUsers", `
+		SELECT id, user_id, email, name, created_at, updated_at, version
+		FROM user_read_models
+		WHERE deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`, pageSize, (page-1)*pageSize)
+}
+
+// DescribeGetUserEvents describes the query GetUserEvents runs against the events table
+func (r *PostgresUserReadRepository) DescribeGetUserEvents(userID string) queryplan.Query {
+	return postgresQuery("GetUserEvents", `
+		SELECT aggregate_id, event_type, event_data, version, created_at
+		FROM events
+		WHERE aggregate_id = $1
+		ORDER BY created_at ASC
+	`, userID)
+}
+
+// DescribeGetEventsByType describes the query GetEventsByType runs against the events table
+func (r *PostgresUserReadRepository) DescribeGetEventsByType(eventType string) queryplan.Query {
+	return postgresQuery("GetEventsByType", `
+		SELECT aggregate_id, event_type, event_data, version, created_at
+		FROM events
+		WHERE event_type = $1
+		ORDER BY created_at ASC
+	`, eventType)
+}
+
+func postgresQuery(operation, statement string, args ...interface{}) queryplan.Query {
+	return queryplan.Query{
+		Backend:   queryplan.BackendPostgres,
+		Operation: operation,
+		Statement: statement,
+		Args:      args,
+	}
+}
@@ -50,17 +50,18 @@ func (r *PostgresUserWriteRepository) Create(ctx context.Context, user *entities
 
 	// Insert user using raw SQL
 	query := `
-		INSERT INTO users (id, email, name, password_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO users (id, email, name, password_hash, created_at, updated_at, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	_, err := sqlDB.ExecContext(ctx, query,
+	_, err := database.ExecutorFrom(ctx, sqlDB).ExecContext(ctx, query,
 		user.GetID(),
 		user.GetEmail(),
 		user.GetName(),
 		user.GetPasswordHash(),
 		user.CreatedAt,
 		user.UpdatedAt,
+		user.EmailVerifiedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -84,16 +85,17 @@ func (r *PostgresUserWriteRepository) GetByID(ctx context.Context, userID string
 	}
 
 	query := `
-		SELECT id, email, name, password_hash, created_at, updated_at
+		SELECT id, email, name, password_hash, created_at, updated_at, email_verified_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var id, email, name, passwordHash string
 	var createdAt, updatedAt time.Time
+	var emailVerifiedAt sql.NullTime
 
-	err := sqlDB.QueryRowContext(ctx, query, userID).Scan(
-		&id, &email, &name, &passwordHash, &createdAt, &updatedAt,
+	err := database.ExecutorFrom(ctx, sqlDB).QueryRowContext(ctx, query, userID).Scan(
+		&id, &email, &name, &passwordHash, &createdAt, &updatedAt, &emailVerifiedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -109,9 +111,16 @@ func (r *PostgresUserWriteRepository) GetByID(ctx context.Context, userID string
 	}
 
 	// Set additional fields
+	user.ID, err = entities.NewUserIDFromString(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID %s: %w", id, err)
+	}
 	user.SetPasswordHash(passwordHash)
 	user.CreatedAt = createdAt
 	user.UpdatedAt = updatedAt
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
 
 	return user, nil
 }
@@ -131,16 +140,17 @@ func (r *PostgresUserWriteRepository) GetByEmail(ctx context.Context, email stri
 	}
 
 	query := `
-		SELECT id, email, name, password_hash, created_at, updated_at
+		SELECT id, email, name, password_hash, created_at, updated_at, email_verified_at
 		FROM users
 		WHERE email = $1 AND deleted_at IS NULL
 	`
 
 	var id, userEmail, name, passwordHash string
 	var createdAt, updatedAt time.Time
+	var emailVerifiedAt sql.NullTime
 
-	err := sqlDB.QueryRowContext(ctx, query, email).Scan(
-		&id, &userEmail, &name, &passwordHash, &createdAt, &updatedAt,
+	err := database.ExecutorFrom(ctx, sqlDB).QueryRowContext(ctx, query, email).Scan(
+		&id, &userEmail, &name, &passwordHash, &createdAt, &updatedAt, &emailVerifiedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -156,9 +166,16 @@ func (r *PostgresUserWriteRepository) GetByEmail(ctx context.Context, email stri
 	}
 
 	// Set additional fields
+	user.ID, err = entities.NewUserIDFromString(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID %s: %w", id, err)
+	}
 	user.SetPasswordHash(passwordHash)
 	user.CreatedAt = createdAt
 	user.UpdatedAt = updatedAt
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
 
 	return user, nil
 }
@@ -182,15 +199,16 @@ func (r *PostgresUserWriteRepository) Update(ctx context.Context, user *entities
 
 	query := `
 		UPDATE users
-		SET email = $1, name = $2, password_hash = $3, updated_at = $4
-		WHERE id = $5 AND deleted_at IS NULL
+		SET email = $1, name = $2, password_hash = $3, updated_at = $4, email_verified_at = $5
+		WHERE id = $6 AND deleted_at IS NULL
 	`
 
-	result, err := sqlDB.ExecContext(ctx, query,
+	result, err := database.ExecutorFrom(ctx, sqlDB).ExecContext(ctx, query,
 		user.GetEmail(),
 		user.GetName(),
 		user.GetPasswordHash(),
 		user.UpdatedAt,
+		user.EmailVerifiedAt,
 		user.GetID(),
 	)
 	if err != nil {
@@ -229,7 +247,7 @@ func (r *PostgresUserWriteRepository) Delete(ctx context.Context, userID string)
 		WHERE id = $2 AND deleted_at IS NULL
 	`
 
-	result, err := sqlDB.ExecContext(ctx, query, time.Now(), userID)
+	result, err := database.ExecutorFrom(ctx, sqlDB).ExecContext(ctx, query, time.Now(), userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -258,3 +276,70 @@ func (r *PostgresUserWriteRepository) List(ctx context.Context) ([]*entities.Use
 	// For now, return a placeholder error
 	return nil, fmt.Errorf("PostgreSQL implementation not available - use a real database driver")
 }
+
+// ListEmails calls fn with the ID and email of every user, streaming them from PostgreSQL
+func (r *PostgresUserWriteRepository) ListEmails(ctx context.Context, fn func(userID, email string)) error {
+	// Get underlying database connection
+	dbConn := r.db.GetDB()
+	if dbConn == nil {
+		return errors.New("database connection not available")
+	}
+
+	// Cast to sql.DB
+	sqlDB, ok := dbConn.(*sql.DB)
+	if !ok {
+		return errors.New("invalid database connection type - expected sql.DB")
+	}
+
+	query := `
+		SELECT id, email
+		FROM users
+		WHERE deleted_at IS NULL
+	`
+
+	rows, err := database.ExecutorFrom(ctx, sqlDB).QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to list user emails: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return fmt.Errorf("failed to scan user email: %w", err)
+		}
+		fn(id, email)
+	}
+	return rows.Err()
+}
+
+// CountUsersByStatus returns the number of active and deleted users of PostgreSQL
+func (r *PostgresUserWriteRepository) CountUsersByStatus(ctx context.Context) (map[string]int64, error) {
+	// Get underlying database connection
+	dbConn := r.db.GetDB()
+	if dbConn == nil {
+		return nil, errors.New("database connection not available")
+	}
+
+	// Cast to sql.DB
+	sqlDB, ok := dbConn.(*sql.DB)
+	if !ok {
+		return nil, errors.New("invalid database connection type - expected sql.DB")
+	}
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE deleted_at IS NULL),
+			COUNT(*) FILTER (WHERE deleted_at IS NOT NULL)
+		FROM users
+	`
+
+	var active, deleted int64
+	if err := database.ExecutorFrom(ctx, sqlDB).QueryRowContext(ctx, query).Scan(&active, &deleted); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	return map[string]int64{
+		entities.UserStatusActive:  active,
+		entities.UserStatusDeleted: deleted,
+	}, nil
+}
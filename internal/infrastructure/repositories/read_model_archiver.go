@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// ReadModelArchive moves expired read model documents to their archive collection. See
+// MongoReadModelArchive.
+type ReadModelArchive interface {
+	// ArchiveBefore moves up to limit documents of collection whose field is before cutoff,
+	// oldest first, returning the number moved
+	ArchiveBefore(ctx context.Context, collection, field string, cutoff time.Time, limit int) (int, error)
+}
+
+// ArchiverLogger logs the progress and failures of the read model archiver
+type ArchiverLogger interface {
+	Info(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// ReadModelArchiver enforces the retention of archived read model collections, moving their
+// expired documents a batch at a time to the archive collection. Collections that are not
+// archived expire through TTL indexes and are left to MongoDB.
+type ReadModelArchiver struct {
+	archive   ReadModelArchive
+	policies  []RetentionPolicy
+	batchSize int
+	interval  time.Duration
+	clock     clock.Clock
+	logger    ArchiverLogger
+}
+
+// NewReadModelArchiver creates an archiver moving up to batchSize expired documents at a time of
+// the archived collections of policies every interval
+func NewReadModelArchiver(archive ReadModelArchive, policies []RetentionPolicy, batchSize int, interval time.Duration, clk clock.Clock, logger ArchiverLogger) *ReadModelArchiver {
+	return &ReadModelArchiver{
+		archive:   archive,
+		policies:  policies,
+		batchSize: batchSize,
+		interval:  interval,
+		clock:     clock.OrDefault(clk),
+		logger:    logger,
+	}
+}
+
+// Run archives expired documents every interval until ctx is done
+func (a *ReadModelArchiver) Run(ctx context.Context) error {
+	for {
+		for _, policy := range a.policies {
+			if !policy.Archive {
+				continue
+			}
+			if _, err := a.ArchiveOnce(ctx, policy); err != nil && ctx.Err() == nil {
+				a.logger.Error("Failed to archive expired documents of %s: %v", policy.Collection, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-a.clock.After(a.interval):
+		}
+	}
+}
+
+// ArchiveOnce archives the documents of a collection expired now, returning the number archived
+func (a *ReadModelArchiver) ArchiveOnce(ctx context.Context, policy RetentionPolicy) (int, error) {
+	cutoff := a.clock.Now().Add(-policy.MaxAge)
+	archived := 0
+	for ctx.Err() == nil {
+		moved, err := a.archive.ArchiveBefore(ctx, policy.Collection, policy.Field, cutoff, a.batchSize)
+		archived += moved
+		if err != nil {
+			return archived, err
+		}
+		if moved < a.batchSize {
+			if archived > 0 {
+				a.logger.Info("Archived %d expired documents of %s", archived, policy.Collection)
+			}
+			return archived, nil
+		}
+	}
+	return archived, ctx.Err()
+}
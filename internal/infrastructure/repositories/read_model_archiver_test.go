@@ -0,0 +1,71 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/mongoindex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetentionPolicies(t *testing.T) {
+	policies, err := infraRepos.NewRetentionPolicies("users",
+		map[string]time.Duration{"users_events": 90 * 24 * time.Hour, "user_changes": time.Hour},
+		map[string]bool{"user_changes": true},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []infraRepos.RetentionPolicy{
+		{Collection: "user_changes", Field: "recorded_at", MaxAge: time.Hour, Archive: true},
+		{Collection: "users_events", Field: "timestamp", MaxAge: 90 * 24 * time.Hour},
+	}, policies)
+
+	registry := mongoindex.NewRegistry()
+	infraRepos.RegisterRetentionIndexes(registry, policies)
+	ttl := registry.Definitions("users_events")
+	require.Len(t, ttl, 1)
+	assert.Equal(t, "timestamp_ttl", ttl[0].IndexName())
+	require.NotNil(t, ttl[0].ExpireAfterSeconds)
+	assert.Equal(t, int32(90*24*60*60), *ttl[0].ExpireAfterSeconds)
+	archived := registry.Definitions("user_changes")
+	require.Len(t, archived, 1)
+	assert.Nil(t, archived[0].ExpireAfterSeconds, "archived collections are not expired by MongoDB")
+
+	_, err = infraRepos.NewRetentionPolicies("users", map[string]time.Duration{"user_summaries": time.Hour}, nil)
+	assert.Error(t, err, "summaries are kept forever")
+	_, err = infraRepos.NewRetentionPolicies("users", nil, map[string]bool{"users_events": true})
+	assert.Error(t, err)
+}
+
+func TestReadModelArchiver_ArchiveOnce(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	archive := &batchArchive{expired: 5}
+	policy := infraRepos.RetentionPolicy{Collection: "user_changes", Field: "recorded_at", MaxAge: time.Hour, Archive: true}
+	archiver := infraRepos.NewReadModelArchiver(archive, []infraRepos.RetentionPolicy{policy}, 2, time.Minute, clock.NewFake(now), discardLogger{})
+
+	archived, err := archiver.ArchiveOnce(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, 5, archived)
+	assert.Equal(t, 0, archive.expired)
+	assert.Equal(t, 3, archive.calls, "batches are moved until one is not full")
+	assert.Equal(t, now.Add(-time.Hour), archive.cutoff)
+}
+
+// batchArchive is a read model archive holding a number of expired documents
+type batchArchive struct {
+	expired int
+	calls   int
+	cutoff  time.Time
+}
+
+func (a *batchArchive) ArchiveBefore(ctx context.Context, collection, field string, cutoff time.Time, limit int) (int, error) {
+	a.calls++
+	a.cutoff = cutoff
+	moved := min(limit, a.expired)
+	a.expired -= moved
+	return moved, nil
+}
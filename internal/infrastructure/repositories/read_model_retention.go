@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"go-clean-ddd-es-template/pkg/mongoindex"
+)
+
+// RetentionPolicy declares how long the documents of a read model collection are kept. Documents
+// expire MaxAge after the date in Field; documents without it are kept forever.
+type RetentionPolicy struct {
+	Collection string
+	Field      string
+	MaxAge     time.Duration
+	Archive    bool // Moved to the archive collection by ReadModelArchiver rather than deleted by a TTL index
+}
+
+// ArchiveCollection returns the collection the expired documents of a collection are archived to
+func ArchiveCollection(collection string) string {
+	return collection + "_archive"
+}
+
+// retentionFields returns the date field documents of each read model collection supporting
+// retention age from. Collections not listed, e.g. user summaries, are kept forever.
+func retentionFields(usersCollection string) map[string]string {
+	return map[string]string{
+		usersCollection:             "deleted_at", // Only deleted users expire
+		usersCollection + "_events": "timestamp",
+		UserChangeCollection:        "recorded_at",
+		InboxCollection:             "updated_at",
+	}
+}
+
+// NewRetentionPolicies creates the retention policies of the read model collections given a
+// maximum age, sorted by collection. Collections in archive are archived rather than deleted.
+func NewRetentionPolicies(usersCollection string, retention map[string]time.Duration, archive map[string]bool) ([]RetentionPolicy, error) {
+	fields := retentionFields(usersCollection)
+	for collection, enabled := range archive {
+		if _, ok := retention[collection]; enabled && !ok {
+			return nil, fmt.Errorf("archived collection %s has no retention", collection)
+		}
+	}
+
+	policies := make([]RetentionPolicy, 0, len(retention))
+	for collection, maxAge := range retention {
+		field, ok := fields[collection]
+		if !ok {
+			return nil, fmt.Errorf("collection %s does not support retention", collection)
+		}
+		if maxAge <= 0 {
+			return nil, fmt.Errorf("retention of collection %s must be positive", collection)
+		}
+		policies = append(policies, RetentionPolicy{
+			Collection: collection,
+			Field:      field,
+			MaxAge:     maxAge,
+			Archive:    archive[collection],
+		})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Collection < policies[j].Collection
+	})
+	return policies, nil
+}
+
+// RegisterRetentionIndexes declares the TTL indexes expiring documents of collections that are not
+// archived, and the indexes ReadModelArchiver finds expired documents of the others with. The
+// indexes are matched by name only, so drop a TTL index to change its retention.
+func RegisterRetentionIndexes(registry *mongoindex.Registry, policies []RetentionPolicy) {
+	for _, policy := range policies {
+		keys := bson.D{{Key: policy.Field, Value: 1}}
+		if policy.Archive {
+			registry.Register(policy.Collection, mongoindex.Definition{Keys: keys})
+			continue
+		}
+		expireAfter := int32(policy.MaxAge / time.Second)
+		registry.Register(policy.Collection, mongoindex.Definition{
+			Name:               policy.Field + "_ttl",
+			Keys:               keys,
+			ExpireAfterSeconds: &expireAfter,
+		})
+	}
+}
@@ -0,0 +1,124 @@
+//go:build redis
+
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Keys of the refresh tokens, of the revoked families and of the families of each user
+const (
+	redisRefreshTokenPrefix  = "refresh_token:"
+	redisRevokedFamilyPrefix = "refresh_token_family_revoked:"
+	redisUserFamiliesPrefix  = "refresh_token_user_families:"
+)
+
+// rotateRefreshToken marks an issued token as rotated unless it already was or its family is
+// revoked, returning 1 when it did
+var rotateRefreshToken = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+if redis.call('HGET', KEYS[1], 'family') ~= ARGV[1] or redis.call('HGET', KEYS[1], 'state') ~= 'issued' then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'state', 'rotated')
+return 1
+`)
+
+// RedisRefreshTokenRepository implements RefreshTokenRepository with a hash per token expiring
+// with it, and a key per revoked family expiring once its tokens did
+type RedisRefreshTokenRepository struct {
+	client *redis.Client
+	ttl    time.Duration // Lifetime of the refresh tokens, and of the revocation of their families
+}
+
+// NewRedisRefreshTokenRepository connects to the Redis server at address, a redis:// URL or a
+// host:port address, keeping the revocation of families for ttl, the lifetime of refresh tokens
+func NewRedisRefreshTokenRepository(address string, ttl time.Duration) (*RedisRefreshTokenRepository, error) {
+	var options *redis.Options
+	if strings.HasPrefix(address, "redis://") || strings.HasPrefix(address, "rediss://") {
+		parsed, err := redis.ParseURL(address)
+		if err != nil {
+			return nil, err
+		}
+		options = parsed
+	} else {
+		options = &redis.Options{Addr: address}
+	}
+
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return &RedisRefreshTokenRepository{client: client, ttl: ttl}, nil
+}
+
+// Issue records a refresh token of a family, until it expires, and the family among the user's
+func (r *RedisRefreshTokenRepository) Issue(ctx context.Context, tokenID, familyID, userID string, expiresAt time.Time) error {
+	key := redisRefreshTokenPrefix + tokenID
+	families := redisUserFamiliesPrefix + userID
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "family", familyID, "user", userID, "state", "issued")
+		pipe.ExpireAt(ctx, key, expiresAt)
+		pipe.SAdd(ctx, families, familyID)
+		pipe.Expire(ctx, families, r.ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to issue refresh token: %w", err)
+	}
+	return nil
+}
+
+// Rotate records that a token of a family was exchanged, returning false when it already was, its
+// family is revoked or it is unknown
+func (r *RedisRefreshTokenRepository) Rotate(ctx context.Context, tokenID, familyID string) (bool, error) {
+	keys := []string{redisRefreshTokenPrefix + tokenID, redisRevokedFamilyPrefix + familyID}
+	rotated, err := rotateRefreshToken.Run(ctx, r.client, keys, familyID).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	return rotated == 1, nil
+}
+
+// RevokeFamily revokes every token of a family for the lifetime of refresh tokens, after which
+// all of them expired
+func (r *RedisRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := r.client.Set(ctx, redisRevokedFamilyPrefix+familyID, time.Now().UTC().Format(time.RFC3339), r.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser revokes every family of a user issued a token within the lifetime of refresh tokens
+func (r *RedisRefreshTokenRepository) RevokeUser(ctx context.Context, userID string) error {
+	families, err := r.client.SMembers(ctx, redisUserFamiliesPrefix+userID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens of user: %w", err)
+	}
+	revokedAt := time.Now().UTC().Format(time.RFC3339)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, familyID := range families {
+			pipe.Set(ctx, redisRevokedFamilyPrefix+familyID, revokedAt, r.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens of user: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection to Redis
+func (r *RedisRefreshTokenRepository) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,38 @@
+//go:build !redis
+
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RedisRefreshTokenRepository stands in for the Redis refresh token store of
+// redis_refresh_token_repository.go, compiled in with the redis build tag once
+// github.com/redis/go-redis/v9 is added to the module
+type RedisRefreshTokenRepository struct{}
+
+func NewRedisRefreshTokenRepository(address string, ttl time.Duration) (*RedisRefreshTokenRepository, error) {
+	return nil, fmt.Errorf("Redis support is not compiled in - build with -tags redis or use the postgres refresh token store instead")
+}
+
+func (r *RedisRefreshTokenRepository) Issue(ctx context.Context, tokenID, familyID, userID string, expiresAt time.Time) error {
+	return fmt.Errorf("Redis support is not compiled in")
+}
+
+func (r *RedisRefreshTokenRepository) Rotate(ctx context.Context, tokenID, familyID string) (bool, error) {
+	return false, fmt.Errorf("Redis support is not compiled in")
+}
+
+func (r *RedisRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return fmt.Errorf("Redis support is not compiled in")
+}
+
+func (r *RedisRefreshTokenRepository) RevokeUser(ctx context.Context, userID string) error {
+	return fmt.Errorf("Redis support is not compiled in")
+}
+
+func (r *RedisRefreshTokenRepository) Close() error {
+	return nil
+}
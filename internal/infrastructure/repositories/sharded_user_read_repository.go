@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// ShardRouter picks the shard owning a user
+type ShardRouter interface {
+	// Route returns the index of the shard owning userID among shards shards
+	Route(userID string, shards int) int
+}
+
+// HashShardRouter routes users by the FNV-1a hash of their ID modulo the shard count. Adding or
+// reordering shards moves users, so the shard list must only change with a resharding.
+type HashShardRouter struct{}
+
+// NewHashShardRouter creates a new hash shard router
+func NewHashShardRouter() *HashShardRouter {
+	return &HashShardRouter{}
+}
+
+// Route returns the shard of userID
+func (r *HashShardRouter) Route(userID string, shards int) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(userID))
+	return int(hash.Sum32() % uint32(shards))
+}
+
+// ReadShard is a read store holding part of the users
+type ReadShard struct {
+	Name       string
+	Repository repositories.UserReadRepository
+}
+
+// ShardedUserReadRepository implements UserReadRepository across horizontally split read stores.
+// Users and their events live on the shard their ID routes to; lookups by email, lists and
+// event type queries are scattered to all shards and their results gathered.
+type ShardedUserReadRepository struct {
+	shards []ReadShard
+	router ShardRouter
+}
+
+// NewShardedUserReadRepository creates a new sharded read repository. A nil router routes by hash.
+func NewShardedUserReadRepository(shards []ReadShard, router ShardRouter) *ShardedUserReadRepository {
+	if router == nil {
+		router = NewHashShardRouter()
+	}
+	return &ShardedUserReadRepository{
+		shards: shards,
+		router: router,
+	}
+}
+
+// SaveUser saves a user to its shard
+func (r *ShardedUserReadRepository) SaveUser(ctx context.Context, user *entities.UserReadModel) error {
+	return r.shard(user.UserID).SaveUser(ctx, user)
+}
+
+// GetUserByID retrieves a user by ID from its shard
+func (r *ShardedUserReadRepository) GetUserByID(ctx context.Context, userID string) (*entities.UserReadModel, error) {
+	return r.shard(userID).GetUserByID(ctx, userID)
+}
+
+// GetUserByEmail retrieves a user by email from any shard, preferring earlier shards
+func (r *ShardedUserReadRepository) GetUserByEmail(ctx context.Context, email string) (*entities.UserReadModel, error) {
+	found := make([]*entities.UserReadModel, len(r.shards))
+	err := r.scatter(func(i int, shard ReadShard) error {
+		user, err := shard.Repository.GetUserByEmail(ctx, email)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		found[i] = user
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range found {
+		if user != nil {
+			return user, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// ListUsers retrieves a page of users across all shards, newest first. Each shard returns the
+// users up to the end of the page, which are merged before the page is cut, so deep pages read
+// more from every shard. A zero page size returns all users after the skipped ones.
+func (r *ShardedUserReadRepository) ListUsers(ctx context.Context, page, pageSize int) ([]*entities.UserReadModel, int64, error) {
+	skip := (page - 1) * pageSize
+	if skip < 0 {
+		return nil, 0, fmt.Errorf("invalid page %d with page size %d: skip must be non-negative", page, pageSize)
+	}
+	// Like MongoDB, a negative limit returns at most its absolute value
+	limit := pageSize
+	if limit < 0 {
+		limit = -limit
+	}
+	fetch := 0
+	if limit > 0 {
+		fetch = skip + limit
+	}
+
+	results := make([][]*entities.UserReadModel, len(r.shards))
+	totals := make([]int64, len(r.shards))
+	err := r.scatter(func(i int, shard ReadShard) error {
+		var err error
+		results[i], totals[i], err = shard.Repository.ListUsers(ctx, 1, fetch)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var users []*entities.UserReadModel
+	var total int64
+	for i := range r.shards {
+		users = append(users, results[i]...)
+		total += totals[i]
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		return users[i].CreatedAt.After(users[j].CreatedAt)
+	})
+
+	if skip > len(users) {
+		skip = len(users)
+	}
+	end := len(users)
+	if limit > 0 && skip+limit < end {
+		end = skip + limit
+	}
+	return users[skip:end], total, nil
+}
+
+// UpdateUser updates a user on its shard
+func (r *ShardedUserReadRepository) UpdateUser(ctx context.Context, user *entities.UserReadModel) error {
+	return r.shard(user.UserID).UpdateUser(ctx, user)
+}
+
+// UpgradeUser stores a migrated user on its shard, see UserReadModelUpgrader
+func (r *ShardedUserReadRepository) UpgradeUser(ctx context.Context, user *entities.UserReadModel, fromVersion int) error {
+	return upgradeUser(ctx, r.shard(user.UserID), user, fromVersion)
+}
+
+// DeleteUser deletes a user on its shard
+func (r *ShardedUserReadRepository) DeleteUser(ctx context.Context, userID string) error {
+	return r.shard(userID).DeleteUser(ctx, userID)
+}
+
+// SaveEvent saves a user event to the shard of its user
+func (r *ShardedUserReadRepository) SaveEvent(ctx context.Context, event *entities.UserEvent) error {
+	return r.shard(event.UserID).SaveEvent(ctx, event)
+}
+
+// GetUserEvents retrieves events for a user from its shard
+func (r *ShardedUserReadRepository) GetUserEvents(ctx context.Context, userID string) ([]*entities.UserEvent, error) {
+	return r.shard(userID).GetUserEvents(ctx, userID)
+}
+
+// GetEventsByType retrieves events by type from all shards, oldest first
+func (r *ShardedUserReadRepository) GetEventsByType(ctx context.Context, eventType string) ([]*entities.UserEvent, error) {
+	results := make([][]*entities.UserEvent, len(r.shards))
+	err := r.scatter(func(i int, shard ReadShard) error {
+		var err error
+		results[i], err = shard.Repository.GetEventsByType(ctx, eventType)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*entities.UserEvent
+	for _, shardEvents := range results {
+		events = append(events, shardEvents...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// shard returns the repository of the shard owning userID
+func (r *ShardedUserReadRepository) shard(userID string) repositories.UserReadRepository {
+	return r.shards[r.router.Route(userID, len(r.shards))].Repository
+}
+
+// scatter runs query on all shards concurrently and returns the error of the first failing shard
+func (r *ShardedUserReadRepository) scatter(query func(i int, shard ReadShard) error) error {
+	errs := make([]error, len(r.shards))
+	var wg sync.WaitGroup
+	for i, shard := range r.shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = query(i, shard)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("read shard %s: %w", r.shards[i].Name, err)
+		}
+	}
+	return nil
+}
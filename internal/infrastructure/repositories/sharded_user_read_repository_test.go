@@ -0,0 +1,126 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// prefixRouter routes users by the digit after their "user-" prefix
+type prefixRouter struct{}
+
+func (prefixRouter) Route(userID string, shards int) int {
+	return int(userID[len("user-")]-'0') % shards
+}
+
+func newShardedRepository(t *testing.T) (*infraRepos.ShardedUserReadRepository, []*infraRepos.InMemoryUserReadRepository, *clock.Fake) {
+	t.Helper()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	stores := []*infraRepos.InMemoryUserReadRepository{
+		infraRepos.NewInMemoryUserReadRepository(fake),
+		infraRepos.NewInMemoryUserReadRepository(fake),
+	}
+	shards := []infraRepos.ReadShard{{Name: "a", Repository: stores[0]}, {Name: "b", Repository: stores[1]}}
+	return infraRepos.NewShardedUserReadRepository(shards, prefixRouter{}), stores, fake
+}
+
+func TestShardedUserReadRepository_RoutesByUserID(t *testing.T) {
+	ctx := context.Background()
+	repo, stores, _ := newShardedRepository(t)
+
+	require.NoError(t, repo.SaveUser(ctx, &entities.UserReadModel{UserID: "user-0", Email: "zero@example.com"}))
+	require.NoError(t, repo.SaveUser(ctx, &entities.UserReadModel{UserID: "user-1", Email: "one@example.com"}))
+	require.NoError(t, repo.SaveEvent(ctx, &entities.UserEvent{UserID: "user-1", EventType: "user.created"}))
+
+	_, err := stores[0].GetUserByID(ctx, "user-0")
+	assert.NoError(t, err)
+	_, err = stores[1].GetUserByID(ctx, "user-0")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	user, err := repo.GetUserByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "one@example.com", user.Email)
+
+	events, err := stores[1].GetUserEvents(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// Lookups by email scatter to all shards
+	user, err = repo.GetUserByEmail(ctx, "one@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", user.UserID)
+	_, err = repo.GetUserByEmail(ctx, "missing@example.com")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func TestShardedUserReadRepository_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	repo, _, fake := newShardedRepository(t)
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, repo.SaveUser(ctx, &entities.UserReadModel{UserID: fmt.Sprintf("user-%d", i)}))
+		fake.Advance(time.Minute)
+	}
+
+	var ids []string
+	for page := 1; page <= 3; page++ {
+		users, total, err := repo.ListUsers(ctx, page, 3)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), total)
+		for _, user := range users {
+			ids = append(ids, user.UserID)
+		}
+	}
+	assert.Equal(t, []string{"user-6", "user-5", "user-4", "user-3", "user-2", "user-1", "user-0"}, ids)
+
+	all, _, err := repo.ListUsers(ctx, 1, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 7)
+
+	_, _, err = repo.ListUsers(ctx, 0, 3)
+	assert.Error(t, err)
+}
+
+// failingReadRepository fails every list query
+type failingReadRepository struct {
+	repositories.UserReadRepository
+}
+
+func (failingReadRepository) ListUsers(ctx context.Context, page, pageSize int) ([]*entities.UserReadModel, int64, error) {
+	return nil, 0, errors.New("connection refused")
+}
+
+func TestShardedUserReadRepository_ShardFailure(t *testing.T) {
+	shards := []infraRepos.ReadShard{
+		{Name: "a", Repository: infraRepos.NewInMemoryUserReadRepository(nil)},
+		{Name: "b", Repository: failingReadRepository{}},
+	}
+	repo := infraRepos.NewShardedUserReadRepository(shards, nil)
+
+	_, _, err := repo.ListUsers(context.Background(), 1, 10)
+	assert.EqualError(t, err, "read shard b: connection refused")
+}
+
+func TestHashShardRouter(t *testing.T) {
+	router := infraRepos.NewHashShardRouter()
+	counts := make([]int, 4)
+	for i := 0; i < 1000; i++ {
+		shard := router.Route(fmt.Sprintf("user-%d", i), len(counts))
+		require.Equal(t, shard, router.Route(fmt.Sprintf("user-%d", i), len(counts)))
+		counts[shard]++
+	}
+	for _, count := range counts {
+		assert.Greater(t, count, 150)
+	}
+}
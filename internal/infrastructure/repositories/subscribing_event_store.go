@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+)
+
+// EventSubscriptionLogger logs failures of event subscriptions
+type EventSubscriptionLogger interface {
+	Error(msg string, args ...interface{})
+}
+
+// SubscribingEventStore wraps EventStore, streaming events to subscribers as they are stored.
+// Subscriptions poll the event store every pollInterval, catching the events other instances
+// store, and are woken up as soon as events are stored through this store. Reads that fail are
+// logged and retried on the next poll.
+//
+// SubscribeAll follows the global positions of ReadEvents, so catching up after the position of
+// the last event streamed misses none.
+type SubscribingEventStore struct {
+	eventStore   repositories.EventStore
+	events       repositories.EventLog
+	batchSize    int
+	pollInterval time.Duration
+	logger       EventSubscriptionLogger
+
+	mu     sync.Mutex
+	stored chan struct{} // Closed, and replaced, once events are stored
+}
+
+// NewSubscribingEventStore creates a new event store streaming events to subscribers, reading the
+// whole event store from events up to batchSize events at a time
+func NewSubscribingEventStore(eventStore repositories.EventStore, events repositories.EventLog, batchSize int, pollInterval time.Duration, logger EventSubscriptionLogger) *SubscribingEventStore {
+	return &SubscribingEventStore{
+		eventStore:   eventStore,
+		events:       events,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+		logger:       logger,
+		stored:       make(chan struct{}),
+	}
+}
+
+// SaveEvent wraps eventStore.SaveEvent, waking subscribers up once saved
+func (s *SubscribingEventStore) SaveEvent(ctx context.Context, aggregateID string, event *events.Event) error {
+	if err := s.eventStore.SaveEvent(ctx, aggregateID, event); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// AppendEvents wraps eventStore.AppendEvents, waking subscribers up once appended
+func (s *SubscribingEventStore) AppendEvents(ctx context.Context, aggregateID string, expectedVersion int, stream ...*events.Event) error {
+	appender, err := versionedEventStore(s.eventStore)
+	if err != nil {
+		return err
+	}
+	if err := appender.AppendEvents(ctx, aggregateID, expectedVersion, stream...); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// GetEvents wraps eventStore.GetEvents
+func (s *SubscribingEventStore) GetEvents(ctx context.Context, aggregateID string) ([]*events.Event, error) {
+	return s.eventStore.GetEvents(ctx, aggregateID)
+}
+
+// GetEventsByType wraps eventStore.GetEventsByType
+func (s *SubscribingEventStore) GetEventsByType(ctx context.Context, eventType string) ([]*events.Event, error) {
+	return s.eventStore.GetEventsByType(ctx, eventType)
+}
+
+// GetEventsSince wraps eventStore.GetEventsSince
+func (s *SubscribingEventStore) GetEventsSince(ctx context.Context, since time.Time) ([]*events.Event, error) {
+	return s.eventStore.GetEventsSince(ctx, since)
+}
+
+// GetLastEventVersion wraps eventStore.GetLastEventVersion; event stores that cannot tell the
+// version of an aggregate return 0
+func (s *SubscribingEventStore) GetLastEventVersion(ctx context.Context, aggregateID string) (int, error) {
+	versioner, ok := s.eventStore.(interface {
+		GetLastEventVersion(ctx context.Context, aggregateID string) (int, error)
+	})
+	if !ok {
+		return 0, nil
+	}
+	return versioner.GetLastEventVersion(ctx, aggregateID)
+}
+
+// HasCausation wraps eventStore.HasCausation; event stores that cannot look events up by
+// causation report none
+func (s *SubscribingEventStore) HasCausation(ctx context.Context, causationID string) (bool, error) {
+	checker, ok := s.eventStore.(repositories.CausationChecker)
+	if !ok {
+		return false, nil
+	}
+	return checker.HasCausation(ctx, causationID)
+}
+
+// Subscribe streams the events of an aggregate stored after afterVersion. The stream of the
+// aggregate is read whole on each poll, which suits aggregates of a few hundred events.
+func (s *SubscribingEventStore) Subscribe(ctx context.Context, aggregateID string, afterVersion int) (<-chan *events.Event, error) {
+	stream := make(chan *events.Event)
+	go func() {
+		defer close(stream)
+		last := afterVersion
+		s.poll(ctx, func() (bool, error) {
+			stored, err := s.eventStore.GetEvents(ctx, aggregateID)
+			if err != nil {
+				return false, err
+			}
+			for _, event := range stored {
+				if event.Version <= last {
+					continue
+				}
+				select {
+				case stream <- event:
+					last = event.Version
+				case <-ctx.Done():
+					return false, nil
+				}
+			}
+			return false, nil
+		})
+	}()
+	return stream, nil
+}
+
+// SubscribeAll streams the events stored after a position, from the first event when after is nil
+func (s *SubscribingEventStore) SubscribeAll(ctx context.Context, after *repositories.EventPosition) (<-chan *repositories.StoredEvent, error) {
+	stream := make(chan *repositories.StoredEvent)
+	go func() {
+		defer close(stream)
+		position := after
+		s.poll(ctx, func() (bool, error) {
+			stored, err := s.events.ReadEvents(ctx, position, s.batchSize)
+			if err != nil {
+				return false, err
+			}
+			for _, event := range stored {
+				select {
+				case stream <- event:
+					streamed := event.Position()
+					position = &streamed
+				case <-ctx.Done():
+					return false, nil
+				}
+			}
+			return len(stored) == s.batchSize, nil
+		})
+	}()
+	return stream, nil
+}
+
+// poll runs read until ctx is done: back to back while it reports more events to read, otherwise
+// once events are stored through this store or pollInterval elapsed
+func (s *SubscribingEventStore) poll(ctx context.Context, read func() (bool, error)) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		// Taken before reading so events stored while reading wake the subscription up again
+		stored := s.storedSignal()
+		more, err := read()
+		if err != nil && s.logger != nil {
+			s.logger.Error("Failed to read subscribed events: %v", err)
+		}
+		if err == nil && more {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-stored:
+		case <-ticker.C:
+		}
+	}
+}
+
+// storedSignal returns the channel closed once events are next stored
+func (s *SubscribingEventStore) storedSignal() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stored
+}
+
+// notify wakes up the subscriptions waiting for events to be stored
+func (s *SubscribingEventStore) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.stored)
+	s.stored = make(chan struct{})
+}
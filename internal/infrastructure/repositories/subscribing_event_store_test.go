@@ -0,0 +1,161 @@
+package repositories_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	domainEvent "go-clean-ddd-es-template/internal/domain/events"
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryEventLog stores events in memory, read as a whole in the order they were saved
+type memoryEventLog struct {
+	mu     sync.Mutex
+	stored []*repositories.StoredEvent
+}
+
+func (s *memoryEventLog) SaveEvent(ctx context.Context, aggregateID string, event *domainEvent.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stored = append(s.stored, &repositories.StoredEvent{ID: event.ID.String(), GlobalPosition: int64(len(s.stored) + 1), AggregateID: aggregateID, Event: event})
+	return nil
+}
+
+func (s *memoryEventLog) AppendEvents(ctx context.Context, aggregateID string, expectedVersion int, events ...*domainEvent.Event) error {
+	for i, event := range events {
+		event.Version = expectedVersion + i + 1
+		if err := s.SaveEvent(ctx, aggregateID, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryEventLog) GetEvents(ctx context.Context, aggregateID string) ([]*domainEvent.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []*domainEvent.Event
+	for _, stored := range s.stored {
+		if stored.AggregateID == aggregateID {
+			events = append(events, stored.Event)
+		}
+	}
+	return events, nil
+}
+
+func (s *memoryEventLog) GetEventsByType(ctx context.Context, eventType string) ([]*domainEvent.Event, error) {
+	return nil, nil
+}
+
+func (s *memoryEventLog) GetEventsSince(ctx context.Context, since time.Time) ([]*domainEvent.Event, error) {
+	return nil, nil
+}
+
+func (s *memoryEventLog) CountEvents(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.stored), nil
+}
+
+// ReadEvents reads events in the order they were saved, numbered from 1
+func (s *memoryEventLog) ReadEvents(ctx context.Context, after *repositories.EventPosition, limit int) ([]*repositories.StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start := 0
+	if after != nil {
+		start = min(int(after.GlobalPosition), len(s.stored))
+	}
+	end := min(start+limit, len(s.stored))
+	return append([]*repositories.StoredEvent{}, s.stored[start:end]...), nil
+}
+
+func newSubscribedEvent(t *testing.T, eventType string, version int) *domainEvent.Event {
+	event, err := domainEvent.NewEvent(eventType, map[string]string{}, version)
+	require.NoError(t, err)
+	event.Timestamp = time.Date(2026, 3, 1, 12, 0, version, 0, time.UTC)
+	return event
+}
+
+// receive returns the next event of a stream, failing when none is streamed in time
+func receive[T any](t *testing.T, stream <-chan T) T {
+	t.Helper()
+	select {
+	case event, ok := <-stream:
+		require.True(t, ok, "stream closed")
+		return event
+	case <-time.After(time.Second):
+		require.FailNow(t, "no event streamed")
+	}
+	var zero T
+	return zero
+}
+
+func TestSubscribingEventStore_Subscribe(t *testing.T) {
+	inner := &memoryEventLog{}
+	// Polled once an hour: events are streamed as they are stored through the store
+	store := infraRepos.NewSubscribingEventStore(inner, inner, 10, time.Hour, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, store.SaveEvent(ctx, "user-1", newSubscribedEvent(t, "user.created", 1)))
+	require.NoError(t, store.SaveEvent(ctx, "user-1", newSubscribedEvent(t, "user.updated", 2)))
+	require.NoError(t, store.SaveEvent(ctx, "user-2", newSubscribedEvent(t, "user.created", 1)))
+
+	stream, err := store.Subscribe(ctx, "user-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, receive(t, stream).Version, "events up to afterVersion are skipped")
+
+	require.NoError(t, store.SaveEvent(ctx, "user-2", newSubscribedEvent(t, "user.updated", 2)))
+	require.NoError(t, store.AppendEvents(ctx, "user-1", 2, newSubscribedEvent(t, "user.deleted", 0)))
+	event := receive(t, stream)
+	assert.Equal(t, "user.deleted", event.Type, "events of other aggregates are not streamed")
+	assert.Equal(t, 3, event.Version)
+
+	cancel()
+	for range stream {
+	}
+}
+
+func TestSubscribingEventStore_SubscribeAll(t *testing.T) {
+	inner := &memoryEventLog{}
+	store := infraRepos.NewSubscribingEventStore(inner, inner, 2, time.Hour, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for version := 1; version <= 3; version++ {
+		require.NoError(t, store.SaveEvent(ctx, "user-1", newSubscribedEvent(t, "user.updated", version)))
+	}
+	first := inner.stored[0].Position()
+
+	stream, err := store.SubscribeAll(ctx, &first)
+	require.NoError(t, err)
+	assert.Equal(t, 2, receive(t, stream).Event.Version)
+	assert.Equal(t, 3, receive(t, stream).Event.Version, "full batches are followed by the next one")
+
+	require.NoError(t, store.SaveEvent(ctx, "user-2", newSubscribedEvent(t, "user.created", 1)))
+	assert.Equal(t, "user-2", receive(t, stream).AggregateID)
+
+	cancel()
+	for range stream {
+	}
+}
+
+func TestSubscribingEventStore_PollsEventsStoredElsewhere(t *testing.T) {
+	inner := &memoryEventLog{}
+	store := infraRepos.NewSubscribingEventStore(inner, inner, 10, 10*time.Millisecond, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := store.SubscribeAll(ctx, nil)
+	require.NoError(t, err)
+
+	// Stored by another instance, past the subscribing store
+	require.NoError(t, inner.SaveEvent(ctx, "user-1", newSubscribedEvent(t, "user.created", 1)))
+	assert.Equal(t, "user-1", receive(t, stream).AggregateID)
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// publishDuration records how long publishing an event to a topic takes
+var publishDuration, _ = metrics.Meter().Float64Histogram("publisher.publish.duration",
+	metric.WithDescription("Duration of event publishes by outcome"),
+	metric.WithUnit("s"))
+
+// Publishes of the instance by outcome, since it started
+var publishedEvents, failedPublishes atomic.Int64
+
+// recordPublish records the duration of a publish to topic since start, failed when err is not nil
+func recordPublish(ctx context.Context, topic string, start time.Time, err error) {
+	outcome := "published"
+	if err != nil {
+		outcome = "failed"
+		failedPublishes.Add(1)
+	} else {
+		publishedEvents.Add(1)
+	}
+	publishDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String("topic", topic),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// PublisherStats returns the events published to the message broker by the instance since it
+// started, by outcome
+func PublisherStats(ctx context.Context) map[string]float64 {
+	return map[string]float64{
+		"publisher.events.published": float64(publishedEvents.Load()),
+		"publisher.events.failed":    float64(failedPublishes.Load()),
+	}
+}
+
+// RegisterMetrics exports the stats of the publisher and its worker pool as OpenTelemetry
+// instruments of meter, observed at each collection. Unregister the returned registration to stop
+// observing the publisher.
+func (p *WorkerPoolEventPublisher) RegisterMetrics(meter metric.Meter) (metric.Registration, error) {
+	events, err := meter.Int64ObservableCounter("publisher.events",
+		metric.WithDescription("Published events by outcome"))
+	errs := err
+	workerJobs, err := meter.Int64ObservableCounter("publisher.worker.jobs",
+		metric.WithDescription("Jobs started and failed by each publisher worker"))
+	errs = errors.Join(errs, err)
+	queueDepth, err := meter.Int64ObservableGauge("publisher.queue.depth",
+		metric.WithDescription("Jobs waiting for a publisher worker"))
+	errs = errors.Join(errs, err)
+	if errs != nil {
+		return nil, errs
+	}
+
+	return meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		m := p.GetMetrics()
+		for outcome, count := range map[string]int64{
+			"published": m.PublishedEvents,
+			"failed":    m.FailedEvents,
+			"retried":   m.RetryEvents,
+		} {
+			o.ObserveInt64(events, count, metric.WithAttributes(attribute.String("outcome", outcome)))
+		}
+		for id, stats := range m.WorkerStats {
+			worker := attribute.String("worker", strconv.Itoa(id))
+			o.ObserveInt64(workerJobs, stats.JobsProcessed, metric.WithAttributes(worker, attribute.String("outcome", "started")))
+			o.ObserveInt64(workerJobs, stats.JobsFailed, metric.WithAttributes(worker, attribute.String("outcome", "failed")))
+		}
+		o.ObserveInt64(queueDepth, int64(len(p.jobQueue)))
+		return nil
+	}, events, workerJobs, queueDepth)
+}
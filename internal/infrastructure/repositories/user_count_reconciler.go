@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/repositories"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// UserStatusSource counts the users of the write database by status
+type UserStatusSource interface {
+	// CountUsersByStatus returns the number of users of every status
+	CountUsersByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+// UserCountReconciler resets the user counters to the counts of the write database at start and
+// every interval. Between reconciliations the counters follow the events the projections apply;
+// reconciliations correct the drift of events applied twice or not at all, and count the users
+// created before the counters were.
+type UserCountReconciler struct {
+	source   UserStatusSource
+	counts   repositories.UserCountRepository
+	interval time.Duration
+	clock    clock.Clock
+	logger   ArchiverLogger
+}
+
+// NewUserCountReconciler creates a reconciler resetting counts to the counts of source every
+// interval
+func NewUserCountReconciler(source UserStatusSource, counts repositories.UserCountRepository, interval time.Duration, clk clock.Clock, logger ArchiverLogger) *UserCountReconciler {
+	return &UserCountReconciler{
+		source:   source,
+		counts:   counts,
+		interval: interval,
+		clock:    clock.OrDefault(clk),
+		logger:   logger,
+	}
+}
+
+// Run reconciles the counters every interval until ctx is done
+func (r *UserCountReconciler) Run(ctx context.Context) error {
+	for {
+		if err := r.ReconcileOnce(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("Failed to reconcile the user counts: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.clock.After(r.interval):
+		}
+	}
+}
+
+// ReconcileOnce resets the counters to the counts of the source, logging the counters that drifted
+func (r *UserCountReconciler) ReconcileOnce(ctx context.Context) error {
+	actual, err := r.source.CountUsersByStatus(ctx)
+	if err != nil {
+		return err
+	}
+	recorded, err := r.counts.Counts(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.counts.Reset(ctx, actual); err != nil {
+		return err
+	}
+
+	for status, count := range actual {
+		if previous, ok := recorded[status]; ok && previous != count {
+			r.logger.Info("Reconciled the %s user count: %d, counted %d", status, count, previous)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/internal/domain/entities"
+	infraRepos "go-clean-ddd-es-template/internal/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedUserStatusSource counts fixed users by status
+type fixedUserStatusSource map[string]int64
+
+func (s fixedUserStatusSource) CountUsersByStatus(ctx context.Context) (map[string]int64, error) {
+	return s, nil
+}
+
+func TestUserCountReconciler_ReconcileOnce(t *testing.T) {
+	ctx := context.Background()
+	counts := infraRepos.NewInMemoryUserCountRepository()
+	require.NoError(t, counts.Increment(ctx, entities.UserStatusActive, 3))
+	require.NoError(t, counts.Increment(ctx, "suspended", 1))
+
+	source := fixedUserStatusSource{entities.UserStatusActive: 5, entities.UserStatusDeleted: 2}
+	reconciler := infraRepos.NewUserCountReconciler(source, counts, time.Hour, nil, discardLogger{})
+	require.NoError(t, reconciler.ReconcileOnce(ctx))
+
+	recorded, err := counts.Counts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{entities.UserStatusActive: 5, entities.UserStatusDeleted: 2, "suspended": 1}, recorded,
+		"statuses the source does not count are left as they are")
+}
+
+func TestInMemoryUserSummaryRepository_Counts(t *testing.T) {
+	ctx := context.Background()
+	repo := infraRepos.NewInMemoryUserSummaryRepository()
+	require.NoError(t, repo.SaveSummary(ctx, &entities.UserSummary{UserID: "user-1", Status: entities.UserStatusActive, CreatedAt: time.Now()}))
+
+	counts := infraRepos.NewInMemoryUserCountRepository()
+	repo.SetCounts(counts)
+	_, total, err := repo.ListSummaries(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total, "users are counted until the counters count active users")
+
+	require.NoError(t, counts.Reset(ctx, map[string]int64{entities.UserStatusActive: 42}))
+	summaries, total, err := repo.ListSummaries(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), total)
+	assert.Len(t, summaries, 1)
+
+	require.NoError(t, counts.Increment(ctx, entities.UserStatusActive, -50))
+	_, total, err = repo.ListSummaries(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total, "counters drifting below zero do not make negative totals")
+}
@@ -2,7 +2,6 @@ package repositories
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"sync"
@@ -11,12 +10,21 @@ import (
 	"go-clean-ddd-es-template/internal/domain/events"
 	"go-clean-ddd-es-template/internal/infrastructure/config"
 	"go-clean-ddd-es-template/internal/infrastructure/messagebroker"
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/retry"
+	"go-clean-ddd-es-template/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WorkerPoolEventPublisher implements EventPublisher using worker pool for concurrent publishing
 type WorkerPoolEventPublisher struct {
 	broker     messagebroker.MessageBroker
 	config     *config.Config
+	router     *messagebroker.TenantRouter
+	versions   *messagebroker.VersionedTopics
 	workerPool []*PublisherWorker
 	jobQueue   chan *PublishJob
 	stopChan   chan struct{}
@@ -30,6 +38,7 @@ type PublisherWorker struct {
 	jobQueue <-chan *PublishJob
 	broker   messagebroker.MessageBroker
 	config   *config.Config
+	versions *messagebroker.VersionedTopics
 	stopChan <-chan struct{}
 	wg       *sync.WaitGroup
 	metrics  *PublisherMetrics
@@ -39,6 +48,9 @@ type PublisherWorker struct {
 type PublishJob struct {
 	Event      *events.Event
 	Topic      string
+	Key        string // Partition key, empty for unkeyed messages
+	Version    int    // Topic version the event is encoded for, 0 for unversioned topics
+	Headers    kafka.Headers
 	RetryCount int
 	MaxRetries int
 }
@@ -64,6 +76,8 @@ func NewWorkerPoolEventPublisher(broker messagebroker.MessageBroker, config *con
 	publisher := &WorkerPoolEventPublisher{
 		broker:   broker,
 		config:   config,
+		router:   messagebroker.NewTenantRouter(config.Tenancy),
+		versions: messagebroker.NewVersionedTopics(config.MessageBroker, nil, nil),
 		jobQueue: make(chan *PublishJob, config.MessageBroker.WorkerBufferSize),
 		stopChan: make(chan struct{}),
 		metrics:  &PublisherMetrics{WorkerStats: make(map[int]*WorkerStats)},
@@ -90,6 +104,7 @@ func (p *WorkerPoolEventPublisher) createWorkerPool() {
 			jobQueue: p.jobQueue,
 			broker:   p.broker,
 			config:   p.config,
+			versions: p.versions,
 			stopChan: p.stopChan,
 			wg:       &p.wg,
 			metrics:  p.metrics,
@@ -116,6 +131,10 @@ func (w *PublisherWorker) start() {
 
 	log.Printf("Publisher worker %d started", w.id)
 
+	// Retries stop waiting once the pool is stopped
+	stop, cancel := retry.UntilClosed(w.stopChan)
+	defer cancel()
+
 	for {
 		select {
 		case <-w.stopChan:
@@ -126,13 +145,13 @@ func (w *PublisherWorker) start() {
 				continue
 			}
 
-			w.processJob(job)
+			w.processJob(stop, job)
 		}
 	}
 }
 
-// processJob processes a publish job with retry logic
-func (w *PublisherWorker) processJob(job *PublishJob) {
+// processJob processes a publish job with retry logic, retrying until stop is done
+func (w *PublisherWorker) processJob(stop context.Context, job *PublishJob) {
 	startTime := time.Now()
 
 	// Update worker stats
@@ -142,39 +161,40 @@ func (w *PublisherWorker) processJob(job *PublishJob) {
 	stats.LastJobTime = startTime
 	w.metrics.mu.Unlock()
 
-	// Serialize event to JSON
-	eventData, err := json.Marshal(job.Event)
+	// Encode event for its topic version
+	eventData, err := w.versions.Encode(job.Event, job.Version)
 	if err != nil {
 		w.handleJobError(job, fmt.Errorf("failed to marshal event: %w", err))
 		return
 	}
 
-	// Publish with retry logic
-	var lastErr error
-	for attempt := job.RetryCount; attempt <= job.MaxRetries; attempt++ {
-		if err := w.broker.Publish(job.Topic, eventData); err == nil {
-			// Success
-			w.metrics.mu.Lock()
-			w.metrics.PublishedEvents++
-			w.metrics.mu.Unlock()
-
-			log.Printf("Worker %d: Successfully published event %s to topic %s (attempt %d)",
-				w.id, job.Event.Type, job.Topic, attempt)
-			return
-		} else {
-			lastErr = err
-			if attempt < job.MaxRetries {
-				// Exponential backoff
-				backoff := time.Duration(attempt) * time.Second
-				log.Printf("Worker %d: Failed to publish event %s (attempt %d), retrying in %v: %v",
-					w.id, job.Event.Type, attempt, backoff, err)
-				time.Sleep(backoff)
-			}
+	// Publish with retry logic, backing off exponentially
+	err = retry.Retrier{
+		Policy:       retry.Policy{MaxAttempts: job.MaxRetries, BaseDelay: time.Second, MaxDelay: 30 * time.Second},
+		FirstAttempt: job.RetryCount,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			log.Printf("Worker %d: Failed to publish event %s (attempt %d), retrying in %v: %v",
+				w.id, job.Event.Type, attempt, delay, err)
+		},
+	}.Do(stop, func(attempt int) error {
+		start := time.Now()
+		err := messagebroker.PublishWithHeaders(w.broker, job.Topic, job.Key, eventData, job.Headers)
+		recordPublish(stop, job.Topic, start, err)
+		if err != nil {
+			return err
 		}
-	}
+		w.metrics.mu.Lock()
+		w.metrics.PublishedEvents++
+		w.metrics.mu.Unlock()
 
-	// All attempts failed
-	w.handleJobError(job, lastErr)
+		log.Printf("Worker %d: Successfully published event %s to topic %s (attempt %d)",
+			w.id, job.Event.Type, job.Topic, attempt)
+		return nil
+	})
+	if err != nil {
+		// All attempts failed
+		w.handleJobError(job, err)
+	}
 }
 
 // handleJobError handles job processing errors
@@ -184,43 +204,98 @@ func (w *PublisherWorker) handleJobError(job *PublishJob, err error) {
 	w.metrics.WorkerStats[w.id].JobsFailed++
 	w.metrics.mu.Unlock()
 
-	log.Printf("Worker %d: Failed to publish event %s to topic %s after %d attempts: %v",
-		w.id, job.Event.Type, job.Topic, job.MaxRetries, err)
+	log.Printf("Worker %d: Failed to publish event %s to topic %s: %v",
+		w.id, job.Event.Type, job.Topic, err)
 }
 
-// PublishEvent publishes an event using the worker pool
-func (p *WorkerPoolEventPublisher) PublishEvent(ctx context.Context, event *events.Event) error {
-	// Get topic from config mapping
-	topic := p.getTopicForEvent(event.Type)
-
-	// Create job
-	job := &PublishJob{
-		Event:      event,
-		Topic:      topic,
-		RetryCount: 1,
-		MaxRetries: 3,
-	}
+// PublishEvent publishes an event using the worker pool. The trace context of its span is
+// carried by the message headers, so that consuming the event continues the trace.
+func (p *WorkerPoolEventPublisher) PublishEvent(ctx context.Context, event *events.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "WorkerPoolEventPublisher.PublishEvent",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("event.type", event.Type)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	headers := eventHeaders(ctx, event)
+
+	// Get topic from config mapping, then publish each of its versions routed by tenant
+	for _, versioned := range p.versions.PublishTopics(p.getTopicForEvent(event.Type)) {
+		topic, key := p.router.Route(versioned.Name, event.TenantID.String())
+
+		// Create job
+		job := &PublishJob{
+			Event:      event,
+			Topic:      topic,
+			Key:        key,
+			Version:    versioned.Version,
+			Headers:    headers,
+			RetryCount: 1,
+			MaxRetries: 3,
+		}
 
-	// Send job to worker pool
-	select {
-	case p.jobQueue <- job:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-		// Queue is full, try to publish directly
-		return p.publishDirectly(ctx, event, topic)
+		// Send job to worker pool
+		select {
+		case p.jobQueue <- job:
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			// Queue is full, try to publish directly
+			if err := p.publishDirectly(ctx, job); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
 }
 
-// publishDirectly publishes an event directly when worker pool is full
-func (p *WorkerPoolEventPublisher) publishDirectly(ctx context.Context, event *events.Event, topic string) error {
-	eventData, err := json.Marshal(event)
+// TopicVersions returns the versioned topics events are published to, e.g. to register the
+// encoders of deprecated versions
+func (p *WorkerPoolEventPublisher) TopicVersions() *messagebroker.VersionedTopics {
+	return p.versions
+}
+
+// publishDirectly publishes a job directly when worker pool is full
+func (p *WorkerPoolEventPublisher) publishDirectly(ctx context.Context, job *PublishJob) error {
+	eventData, err := p.versions.Encode(job.Event, job.Version)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	return p.broker.Publish(topic, eventData)
+	start := time.Now()
+	err = messagebroker.PublishWithHeaders(p.broker, job.Topic, job.Key, eventData, job.Headers)
+	recordPublish(ctx, job.Topic, start, err)
+	return err
+}
+
+// eventHeaders stamps an event published within ctx with its envelope and returns its standard
+// headers
+func eventHeaders(ctx context.Context, event *events.Event) kafka.Headers {
+	stampEvent(ctx, event)
+
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderContentType, kafka.ContentTypeJSON)
+	headers.Set(kafka.HeaderSchema, event.Type)
+	headers.Set(kafka.HeaderTenantID, event.TenantID.String())
+	headers.Set(kafka.HeaderCorrelationID, event.CorrelationID)
+	headers.Set(kafka.HeaderCausationID, event.CausationID)
+	otel.GetTextMapPropagator().Inject(ctx, headers)
+	if headers.TraceParent() == "" {
+		headers.Set(kafka.HeaderTraceParent, event.TraceParent)
+	}
+	return headers
+}
+
+// stampEvent sets the envelope an event published within ctx does not have yet, e.g. when first
+// published rather than relayed: the metadata of ctx and the trace context of its span
+func stampEvent(ctx context.Context, event *events.Event) {
+	metadata := events.MetadataFromContext(ctx)
+	carrier := kafka.Headers{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if traceParent := carrier.TraceParent(); traceParent != "" {
+		metadata.TraceParent = traceParent
+	}
+	event.Stamp(metadata)
 }
 
 // PublishEvents publishes multiple events using the worker pool
@@ -235,13 +310,8 @@ func (p *WorkerPoolEventPublisher) PublishEvents(ctx context.Context, events []*
 
 // getTopicForEvent returns the appropriate topic for an event type
 func (p *WorkerPoolEventPublisher) getTopicForEvent(eventType string) string {
-	// Check if there's a mapping in config
-	if mappedTopic, exists := p.config.MessageBroker.Topics[eventType]; exists {
-		return mappedTopic
-	}
-
-	// Fallback to event type as topic name
-	return eventType
+	// Shared with the event catalog, so documented topics match the published ones
+	return messagebroker.EventTopic(p.config.MessageBroker, eventType)
 }
 
 // GetMetrics returns publisher metrics
@@ -0,0 +1,5 @@
+-- Migration: 000006_create_processed_events_table
+-- Description: Rollback processed events table
+
+DROP INDEX IF EXISTS idx_processed_events_processed_at;
+DROP TABLE IF EXISTS processed_events;
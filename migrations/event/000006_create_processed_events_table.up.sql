@@ -0,0 +1,14 @@
+-- Migration: 000006_create_processed_events_table
+-- Description: Create the record of events processed by each consumer group, so events
+-- redelivered or replayed by the message broker are skipped
+
+CREATE TABLE IF NOT EXISTS processed_events (
+    consumer_group VARCHAR(255) NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    processed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consumer_group, event_id)
+);
+
+-- Processed events by age, for pruning
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at ON processed_events(processed_at);
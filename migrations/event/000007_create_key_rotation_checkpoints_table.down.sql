@@ -0,0 +1,5 @@
+-- Migration: 000007_create_key_rotation_checkpoints_table
+-- Description: Rollback key rotation checkpoints table
+
+DROP INDEX IF EXISTS idx_events_tenant_key_version;
+DROP TABLE IF EXISTS key_rotation_checkpoints;
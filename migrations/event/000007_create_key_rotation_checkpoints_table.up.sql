@@ -0,0 +1,16 @@
+-- Migration: 000007_create_key_rotation_checkpoints_table
+-- Description: Track the progress of re-encrypting tenant events with the current tenant data
+-- key, so rotation jobs resume where they stopped
+
+CREATE TABLE IF NOT EXISTS key_rotation_checkpoints (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    key_version INTEGER NOT NULL,
+    last_event_id UUID NULL,
+    reencrypted BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Encrypted events by tenant and key version, looked up by rotation jobs
+CREATE INDEX IF NOT EXISTS idx_events_tenant_key_version
+    ON events((event_data->>'tenant_id'), ((event_data->>'key_version')::int), id)
+    WHERE event_data ? 'ciphertext';
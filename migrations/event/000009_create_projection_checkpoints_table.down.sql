@@ -0,0 +1,4 @@
+-- Migration: 000009_create_projection_checkpoints_table
+-- Description: Rollback projection checkpoints table
+
+DROP TABLE IF EXISTS projection_checkpoints;
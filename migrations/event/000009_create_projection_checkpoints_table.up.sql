@@ -0,0 +1,9 @@
+-- Migration: 000009_create_projection_checkpoints_table
+-- Description: Track the position each projection reached in the event store, so projections
+-- resume where they stopped after a restart
+
+CREATE TABLE IF NOT EXISTS projection_checkpoints (
+    name VARCHAR(255) PRIMARY KEY,
+    position TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
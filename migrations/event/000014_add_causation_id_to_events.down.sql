@@ -0,0 +1,5 @@
+-- Migration: 000014_add_causation_id_to_events
+-- Description: Rollback causation ID of events
+
+DROP INDEX IF EXISTS idx_events_causation_id;
+ALTER TABLE events DROP COLUMN IF EXISTS causation_id;
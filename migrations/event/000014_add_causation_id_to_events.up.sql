@@ -0,0 +1,7 @@
+-- Migration: 000014_add_causation_id_to_events
+-- Description: Record the request, command or event that caused each event, so commands
+-- re-submitted under the ID of a command handled before are detected
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS causation_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_events_causation_id ON events(causation_id) WHERE causation_id IS NOT NULL;
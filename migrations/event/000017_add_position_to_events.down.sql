@@ -0,0 +1,6 @@
+-- Migration: 000017_add_position_to_events
+-- Description: Rollback global position of events
+
+DROP INDEX IF EXISTS idx_events_position;
+ALTER TABLE events DROP COLUMN IF EXISTS position;
+DROP SEQUENCE IF EXISTS events_position_seq;
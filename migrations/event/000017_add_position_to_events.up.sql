@@ -0,0 +1,22 @@
+-- Migration: 000017_add_position_to_events
+-- Description: Number events with a global position, in the order they are committed, so the
+-- event store is read after a position without missing events committed late
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS position BIGINT;
+
+CREATE SEQUENCE IF NOT EXISTS events_position_seq OWNED BY events.position;
+
+-- Events stored so far are numbered in the order they were read until now
+UPDATE events SET position = numbered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, aggregate_id, version) AS position
+    FROM events
+) numbered
+WHERE events.id = numbered.id AND events.position IS NULL;
+
+SELECT setval('events_position_seq', COALESCE((SELECT MAX(position) FROM events), 0) + 1, false);
+
+ALTER TABLE events ALTER COLUMN position SET DEFAULT nextval('events_position_seq');
+ALTER TABLE events ALTER COLUMN position SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_position ON events(position);
@@ -0,0 +1,4 @@
+-- Migration: 000020_create_handled_commands_table
+-- Description: Rollback handled commands table
+
+DROP TABLE IF EXISTS handled_commands;
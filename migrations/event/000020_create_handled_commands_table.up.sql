@@ -0,0 +1,9 @@
+-- Migration: 000020_create_handled_commands_table
+-- Description: Record the ID of each command whose events were appended, in the transaction
+-- appending them, so a command submitted twice concurrently appends its events once
+
+CREATE TABLE IF NOT EXISTS handled_commands (
+    command_id VARCHAR(255) PRIMARY KEY,
+    aggregate_id VARCHAR(255) NOT NULL,
+    handled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+-- Migration: 000005_create_outbox_table
+-- Description: Rollback outbox table
+
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+DROP TABLE IF EXISTS outbox;
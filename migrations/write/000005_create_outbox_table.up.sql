@@ -0,0 +1,18 @@
+-- Migration: 000005_create_outbox_table
+-- Description: Create the outbox of domain events, written in the same transaction as the
+-- aggregate changes and published to the message broker by the outbox relay
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NULL
+);
+
+-- Unpublished events, relayed oldest first
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(id) WHERE published_at IS NULL;
@@ -0,0 +1,5 @@
+-- Migration: 000008_create_delayed_messages_table
+-- Description: Rollback delayed messages table
+
+DROP INDEX IF EXISTS idx_delayed_messages_due_at;
+DROP TABLE IF EXISTS delayed_messages;
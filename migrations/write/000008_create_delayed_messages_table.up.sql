@@ -0,0 +1,16 @@
+-- Migration: 000008_create_delayed_messages_table
+-- Description: Create the store of messages scheduled for delayed delivery on brokers without
+-- native delays, published by the delay scheduler once due
+
+CREATE TABLE IF NOT EXISTS delayed_messages (
+    id UUID PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    message_key VARCHAR(255) NOT NULL DEFAULT '',
+    payload BYTEA NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    due_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Messages by due time, published oldest first
+CREATE INDEX IF NOT EXISTS idx_delayed_messages_due_at ON delayed_messages(due_at, id);
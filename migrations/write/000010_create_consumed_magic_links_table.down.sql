@@ -0,0 +1,5 @@
+-- Migration: 000010_create_consumed_magic_links_table
+-- Description: Rollback consumed magic links table
+
+DROP INDEX IF EXISTS idx_consumed_magic_links_expires_at;
+DROP TABLE IF EXISTS consumed_magic_links;
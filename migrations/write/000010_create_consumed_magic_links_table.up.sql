@@ -0,0 +1,12 @@
+-- Migration: 000010_create_consumed_magic_links_table
+-- Description: Record the magic links consumed, so each signs in once; rows are deleted once the
+-- link expired
+
+CREATE TABLE IF NOT EXISTS consumed_magic_links (
+    link_id VARCHAR(64) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    consumed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_consumed_magic_links_expires_at ON consumed_magic_links(expires_at);
@@ -0,0 +1,6 @@
+-- Migration: 000011_create_operations_table
+-- Description: Rollback operations table
+
+DROP INDEX IF EXISTS idx_operations_finished_at;
+DROP INDEX IF EXISTS idx_operations_status_updated_at;
+DROP TABLE IF EXISTS operations;
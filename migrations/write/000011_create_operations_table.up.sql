@@ -0,0 +1,22 @@
+-- Migration: 000011_create_operations_table
+-- Description: Track long-running operations, e.g. projection rebuilds and DLQ exports, so their
+-- progress can be polled and cancelled from any instance
+
+CREATE TABLE IF NOT EXISTS operations (
+    id VARCHAR(64) PRIMARY KEY,
+    kind VARCHAR(100) NOT NULL,
+    args JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL,
+    done BIGINT NOT NULL DEFAULT 0,
+    total BIGINT NOT NULL DEFAULT 0,
+    result JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    requested_by VARCHAR(255) NOT NULL DEFAULT '',
+    cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_operations_status_updated_at ON operations(status, updated_at);
+CREATE INDEX IF NOT EXISTS idx_operations_finished_at ON operations(finished_at);
@@ -0,0 +1,7 @@
+-- Migration: 000012_create_audit_log_table
+-- Description: Rollback audit log table
+
+DROP INDEX IF EXISTS idx_audit_log_actor;
+DROP INDEX IF EXISTS idx_audit_log_subject;
+DROP INDEX IF EXISTS idx_audit_log_category_occurred_at;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,18 @@
+-- Migration: 000012_create_audit_log_table
+-- Description: Keep the audit log of sign ins and admin actions; records past the retention of
+-- their category are deleted unless under legal hold
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id VARCHAR(64) PRIMARY KEY,
+    category VARCHAR(50) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    details JSONB NOT NULL DEFAULT '{}',
+    occurred_at TIMESTAMP NOT NULL,
+    legal_hold BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_category_occurred_at ON audit_log(category, occurred_at) WHERE NOT legal_hold;
+CREATE INDEX IF NOT EXISTS idx_audit_log_subject ON audit_log(subject);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
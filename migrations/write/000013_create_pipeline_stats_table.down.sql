@@ -0,0 +1,6 @@
+-- Migration: 000013_create_pipeline_stats_table
+-- Description: Rollback pipeline stats table
+
+DROP INDEX IF EXISTS idx_pipeline_stats_name_taken_at;
+DROP INDEX IF EXISTS idx_pipeline_stats_taken_at;
+DROP TABLE IF EXISTS pipeline_stats;
@@ -0,0 +1,14 @@
+-- Migration: 000013_create_pipeline_stats_table
+-- Description: Keep periodic snapshots of consumer, publisher and DLQ stats, one row per stat, so
+-- their history survives restarts; snapshots past the retention are deleted
+
+CREATE TABLE IF NOT EXISTS pipeline_stats (
+    taken_at TIMESTAMP NOT NULL,
+    instance VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (instance, taken_at, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pipeline_stats_taken_at ON pipeline_stats(taken_at);
+CREATE INDEX IF NOT EXISTS idx_pipeline_stats_name_taken_at ON pipeline_stats(name, taken_at);
@@ -0,0 +1,6 @@
+-- Migration: 000015_create_refresh_tokens_table
+-- Description: Rollback refresh tokens table
+
+DROP INDEX IF EXISTS idx_refresh_tokens_expires_at;
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,16 @@
+-- Migration: 000015_create_refresh_tokens_table
+-- Description: Record the refresh tokens issued, so each is rotated once and the family of a token
+-- used twice is revoked; rows are deleted once the token expired
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_id VARCHAR(64) PRIMARY KEY,
+    family_id VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    issued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    rotated_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
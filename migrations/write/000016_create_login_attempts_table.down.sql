@@ -0,0 +1,5 @@
+-- Migration: 000016_create_login_attempts_table
+-- Description: Rollback login attempts table
+
+DROP INDEX IF EXISTS idx_login_attempts_first_failed_at;
+DROP TABLE IF EXISTS login_attempts;
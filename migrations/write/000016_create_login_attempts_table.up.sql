@@ -0,0 +1,12 @@
+-- Migration: 000016_create_login_attempts_table
+-- Description: Count the failed logins of users and client addresses, locking out those failing
+-- too often; rows are deleted once neither counted nor locked out
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key VARCHAR(255) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    first_failed_at TIMESTAMP,
+    locked_until TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_first_failed_at ON login_attempts(first_failed_at);
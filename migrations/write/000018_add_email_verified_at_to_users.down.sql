@@ -0,0 +1,4 @@
+-- Migration: 000018_add_email_verified_at_to_users
+-- Description: Rollback email verification of users
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
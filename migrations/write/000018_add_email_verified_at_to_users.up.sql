@@ -0,0 +1,4 @@
+-- Migration: 000018_add_email_verified_at_to_users
+-- Description: Record when users verified their email; existing users are not verified
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP NULL;
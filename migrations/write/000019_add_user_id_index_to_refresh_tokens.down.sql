@@ -0,0 +1,4 @@
+-- Migration: 000019_add_user_id_index_to_refresh_tokens
+-- Description: Rollback lookup of refresh tokens by user
+
+DROP INDEX IF EXISTS idx_refresh_tokens_user_id;
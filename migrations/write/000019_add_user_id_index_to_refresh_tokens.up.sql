@@ -0,0 +1,4 @@
+-- Migration: 000019_add_user_id_index_to_refresh_tokens
+-- Description: Look refresh tokens up by user, so resetting a password revokes every session of the user
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
@@ -0,0 +1,366 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
+)
+
+// Statuses of approval requests
+const (
+	StatusPending  = "pending"  // Waiting for a second admin
+	StatusApproved = "approved" // Approved, the action is running
+	StatusExecuted = "executed" // Approved and the action succeeded
+	StatusFailed   = "failed"   // Approved but the action failed
+	StatusRejected = "rejected"
+	StatusExpired  = "expired"
+)
+
+// Types of audit events
+const (
+	EventRequested = "requested"
+	EventApproved  = "approved"
+	EventExecuted  = "executed"
+	EventFailed    = "failed"
+	EventRejected  = "rejected"
+	EventExpired   = "expired"
+)
+
+// Errors of rejected decisions
+var (
+	ErrNotFound      = errors.New("approval request not found")
+	ErrNotPending    = errors.New("approval request is not pending")
+	ErrExpired       = errors.New("approval request is expired")
+	ErrSelfApproval  = errors.New("approval request must be decided by another admin than its requester")
+	ErrUnknownAction = errors.New("unknown sensitive action")
+)
+
+// Request is a sensitive action waiting for, or decided by, a second admin
+type Request struct {
+	ID          string            `json:"id"`
+	Action      string            `json:"action"`
+	Args        map[string]string `json:"args,omitempty"`
+	RequestedBy string            `json:"requested_by"`
+	Reason      string            `json:"reason,omitempty"`
+	Status      string            `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+	DecidedBy   string            `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time        `json:"decided_at,omitempty"`
+	Comment     string            `json:"comment,omitempty"` // Reason of a rejection
+	Error       string            `json:"error,omitempty"`   // Error of a failed action
+}
+
+// Event is an audit event of an approval request
+type Event struct {
+	Type    string    `json:"type"`
+	Actor   string    `json:"actor,omitempty"`
+	At      time.Time `json:"at"`
+	Request Request   `json:"request"`
+}
+
+// Executor runs an approved action
+type Executor func(ctx context.Context, request Request) error
+
+// Notifier is told about every audit event, e.g. to ask admins for an approval
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// NotifierFunc adapts a function to a Notifier
+type NotifierFunc func(ctx context.Context, event Event) error
+
+// Notify calls f
+func (f NotifierFunc) Notify(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Logger writes audit events to the application log
+type Logger interface {
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+}
+
+// Workflow enforces the two-person rule on sensitive actions: an admin submits a request, which
+// runs only once another admin approves it before it expires. Requests are kept in memory, so
+// pending requests are local to an instance and lost on restart.
+type Workflow struct {
+	ttl       time.Duration
+	auditSize int
+	logger    Logger
+	clock     clock.Clock
+	ids       *id.Generator
+
+	mu        sync.Mutex
+	executors map[string]Executor
+	requests  map[string]*Request
+	notifiers []Notifier
+	audit     []Event
+}
+
+// NewWorkflow creates a workflow whose requests expire after ttl, keeping the last auditSize audit
+// events. A nil logger only keeps events in memory, a nil clock uses the system clock.
+func NewWorkflow(ttl time.Duration, auditSize int, logger Logger, clk clock.Clock) *Workflow {
+	clk = clock.OrDefault(clk)
+	return &Workflow{
+		ttl:       ttl,
+		auditSize: auditSize,
+		logger:    logger,
+		clock:     clk,
+		ids:       id.NewGenerator(clk),
+		executors: make(map[string]Executor),
+		requests:  make(map[string]*Request),
+	}
+}
+
+// Register makes an action sensitive, running executor once a request for it is approved
+func (w *Workflow) Register(action string, executor Executor) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.executors[action] = executor
+}
+
+// Requires reports whether action needs an approval
+func (w *Workflow) Requires(action string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, ok := w.executors[action]
+	return ok
+}
+
+// AddNotifier registers a notification hook called with every audit event
+func (w *Workflow) AddNotifier(notifier Notifier) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.notifiers = append(w.notifiers, notifier)
+}
+
+// Submit creates a pending request for action on behalf of requestedBy
+func (w *Workflow) Submit(ctx context.Context, action string, args map[string]string, requestedBy, reason string) (Request, error) {
+	if requestedBy == "" {
+		return Request{}, errors.New("requester is required")
+	}
+
+	w.mu.Lock()
+	if _, ok := w.executors[action]; !ok {
+		w.mu.Unlock()
+		return Request{}, fmt.Errorf("%w: %s", ErrUnknownAction, action)
+	}
+	now := w.clock.Now().UTC()
+	request := &Request{
+		ID:          w.ids.ULID(),
+		Action:      action,
+		Args:        args,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+		Status:      StatusPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(w.ttl),
+	}
+	w.requests[request.ID] = request
+	event := w.record(EventRequested, requestedBy, request)
+	w.mu.Unlock()
+
+	w.notify(ctx, event)
+	return *request, nil
+}
+
+// Approve approves a pending request on behalf of approver and runs its action.
+// The returned request is executed, or failed with the error of the action.
+func (w *Workflow) Approve(ctx context.Context, requestID, approver string) (Request, error) {
+	w.mu.Lock()
+	request, expired, err := w.decide(requestID, approver)
+	if err != nil {
+		w.mu.Unlock()
+		if expired != nil {
+			w.notify(ctx, *expired)
+		}
+		return Request{}, err
+	}
+	request.Status = StatusApproved
+	executor := w.executors[request.Action]
+	approved := w.record(EventApproved, approver, request)
+	snapshot := *request
+	w.mu.Unlock()
+
+	w.notify(ctx, approved)
+
+	// The action runs outside the lock, the approved status keeps others from deciding meanwhile
+	runErr := executor(ctx, snapshot)
+
+	w.mu.Lock()
+	eventType := EventExecuted
+	request.Status = StatusExecuted
+	if runErr != nil {
+		eventType = EventFailed
+		request.Status = StatusFailed
+		request.Error = runErr.Error()
+	}
+	done := w.record(eventType, approver, request)
+	snapshot = *request
+	w.mu.Unlock()
+
+	w.notify(ctx, done)
+	return snapshot, nil
+}
+
+// Reject rejects a pending request on behalf of approver
+func (w *Workflow) Reject(ctx context.Context, requestID, approver, reason string) (Request, error) {
+	w.mu.Lock()
+	request, expired, err := w.decide(requestID, approver)
+	if err != nil {
+		w.mu.Unlock()
+		if expired != nil {
+			w.notify(ctx, *expired)
+		}
+		return Request{}, err
+	}
+	request.Status = StatusRejected
+	request.Comment = reason
+	event := w.record(EventRejected, approver, request)
+	snapshot := *request
+	w.mu.Unlock()
+
+	w.notify(ctx, event)
+	return snapshot, nil
+}
+
+// decide checks that approver may decide a request and stamps the decision.
+// A request found expired is marked so and its audit event returned. Requires w.mu.
+func (w *Workflow) decide(requestID, approver string) (*Request, *Event, error) {
+	if approver == "" {
+		return nil, nil, errors.New("approver is required")
+	}
+	request, ok := w.requests[requestID]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	if request.Status != StatusPending {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotPending, request.Status)
+	}
+	now := w.clock.Now().UTC()
+	if !now.Before(request.ExpiresAt) {
+		event := w.expire(request)
+		return nil, &event, ErrExpired
+	}
+	if approver == request.RequestedBy {
+		return nil, nil, ErrSelfApproval
+	}
+	request.DecidedBy = approver
+	request.DecidedAt = &now
+	return request, nil, nil
+}
+
+// expire marks a pending request as expired. Requires w.mu.
+func (w *Workflow) expire(request *Request) Event {
+	request.Status = StatusExpired
+	return w.record(EventExpired, "", request)
+}
+
+// Expire marks the pending requests past their expiry as expired and returns how many were
+func (w *Workflow) Expire(ctx context.Context) int {
+	w.mu.Lock()
+	now := w.clock.Now()
+	var events []Event
+	for _, request := range w.requests {
+		if request.Status == StatusPending && !now.Before(request.ExpiresAt) {
+			events = append(events, w.expire(request))
+		}
+	}
+	w.mu.Unlock()
+
+	for _, event := range events {
+		w.notify(ctx, event)
+	}
+	return len(events)
+}
+
+// Run expires pending requests every interval until ctx is done
+func (w *Workflow) Run(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.clock.After(interval):
+			w.Expire(ctx)
+		}
+	}
+}
+
+// Get returns a request
+func (w *Workflow) Get(requestID string) (Request, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	request, ok := w.requests[requestID]
+	if !ok {
+		return Request{}, ErrNotFound
+	}
+	return *request, nil
+}
+
+// List returns the requests with a status, or all requests for an empty status, oldest first
+func (w *Workflow) List(status string) []Request {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	requests := make([]Request, 0, len(w.requests))
+	for _, request := range w.requests {
+		if status == "" || request.Status == status {
+			requests = append(requests, *request)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID < requests[j].ID })
+	return requests
+}
+
+// Audit returns the recorded audit events, oldest first
+func (w *Workflow) Audit() []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]Event(nil), w.audit...)
+}
+
+// record adds an audit event of request, dropping the oldest one when the audit is full. Requires w.mu.
+func (w *Workflow) record(eventType, actor string, request *Request) Event {
+	event := Event{Type: eventType, Actor: actor, At: w.clock.Now().UTC(), Request: *request}
+	w.audit = append(w.audit, event)
+	if w.auditSize > 0 && len(w.audit) > w.auditSize {
+		w.audit = append([]Event(nil), w.audit[len(w.audit)-w.auditSize:]...)
+	}
+
+	if w.logger != nil {
+		switch eventType {
+		case EventFailed:
+			w.logger.Warn("Approval request %s %s %s by %s: %s", request.ID, request.Action, eventType, actor, request.Error)
+		case EventExpired:
+			w.logger.Warn("Approval request %s %s from %s expired", request.ID, request.Action, request.RequestedBy)
+		default:
+			w.logger.Info("Approval request %s %s %s by %s (args: %v)", request.ID, request.Action, eventType, actor, request.Args)
+		}
+	}
+	return event
+}
+
+// notify calls the notification hooks, which must not block decisions on failure
+func (w *Workflow) notify(ctx context.Context, event Event) {
+	w.mu.Lock()
+	notifiers := append([]Notifier(nil), w.notifiers...)
+	w.mu.Unlock()
+
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(ctx, event); err != nil && w.logger != nil {
+			w.logger.Warn("Failed to notify approval event %s of request %s: %v", event.Type, event.Request.ID, err)
+		}
+	}
+}
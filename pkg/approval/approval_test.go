@@ -0,0 +1,140 @@
+package approval_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/approval"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+func newTestWorkflow(fake *clock.Fake) (*approval.Workflow, *[]approval.Request, *[]approval.Event) {
+	workflow := approval.NewWorkflow(time.Hour, 10, nil, fake)
+	var executed []approval.Request
+	workflow.Register("user.delete", func(ctx context.Context, request approval.Request) error {
+		executed = append(executed, request)
+		return nil
+	})
+	workflow.Register("dlq.purge", func(ctx context.Context, request approval.Request) error {
+		return errors.New("queue unavailable")
+	})
+	var notified []approval.Event
+	workflow.AddNotifier(approval.NotifierFunc(func(ctx context.Context, event approval.Event) error {
+		notified = append(notified, event)
+		return nil
+	}))
+	return workflow, &executed, &notified
+}
+
+func TestWorkflow_ApproveRunsAction(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	workflow, executed, notified := newTestWorkflow(fake)
+	ctx := context.Background()
+
+	request, err := workflow.Submit(ctx, "user.delete", map[string]string{"user_id": "42"}, "alice", "GDPR request")
+	require.NoError(t, err)
+	assert.Equal(t, approval.StatusPending, request.Status)
+	assert.Equal(t, fake.Now().Add(time.Hour), request.ExpiresAt)
+	assert.Empty(t, *executed)
+
+	// The requester cannot approve their own request
+	_, err = workflow.Approve(ctx, request.ID, "alice")
+	assert.ErrorIs(t, err, approval.ErrSelfApproval)
+
+	approved, err := workflow.Approve(ctx, request.ID, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, approval.StatusExecuted, approved.Status)
+	assert.Equal(t, "bob", approved.DecidedBy)
+	require.Len(t, *executed, 1)
+	assert.Equal(t, "42", (*executed)[0].Args["user_id"])
+
+	// A decided request cannot be approved again
+	_, err = workflow.Approve(ctx, request.ID, "carol")
+	assert.ErrorIs(t, err, approval.ErrNotPending)
+	assert.Len(t, *executed, 1)
+
+	var types []string
+	for _, event := range workflow.Audit() {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{approval.EventRequested, approval.EventApproved, approval.EventExecuted}, types)
+	assert.Len(t, *notified, 3)
+}
+
+func TestWorkflow_FailedAction(t *testing.T) {
+	workflow, _, _ := newTestWorkflow(clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)))
+	ctx := context.Background()
+
+	request, err := workflow.Submit(ctx, "dlq.purge", nil, "alice", "")
+	require.NoError(t, err)
+
+	failed, err := workflow.Approve(ctx, request.ID, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, approval.StatusFailed, failed.Status)
+	assert.Equal(t, "queue unavailable", failed.Error)
+}
+
+func TestWorkflow_RejectAndExpire(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	workflow, executed, _ := newTestWorkflow(fake)
+	ctx := context.Background()
+
+	_, err := workflow.Submit(ctx, "user.drop", nil, "alice", "")
+	assert.ErrorIs(t, err, approval.ErrUnknownAction)
+
+	rejected, err := workflow.Submit(ctx, "user.delete", map[string]string{"user_id": "1"}, "alice", "")
+	require.NoError(t, err)
+	rejected, err = workflow.Reject(ctx, rejected.ID, "bob", "wrong user")
+	require.NoError(t, err)
+	assert.Equal(t, approval.StatusRejected, rejected.Status)
+	assert.Equal(t, "wrong user", rejected.Comment)
+
+	late, err := workflow.Submit(ctx, "user.delete", map[string]string{"user_id": "2"}, "alice", "")
+	require.NoError(t, err)
+	swept, err := workflow.Submit(ctx, "user.delete", map[string]string{"user_id": "3"}, "alice", "")
+	require.NoError(t, err)
+	fake.Advance(time.Hour)
+
+	_, err = workflow.Approve(ctx, late.ID, "bob")
+	assert.ErrorIs(t, err, approval.ErrExpired)
+	assert.Equal(t, 1, workflow.Expire(ctx))
+	assert.Empty(t, *executed)
+
+	expired := workflow.List(approval.StatusExpired)
+	require.Len(t, expired, 2)
+	assert.Equal(t, late.ID, expired[0].ID)
+	assert.Equal(t, swept.ID, expired[1].ID)
+	assert.Empty(t, workflow.List(approval.StatusPending))
+	assert.Len(t, workflow.List(""), 3)
+
+	_, err = workflow.Get("missing")
+	assert.ErrorIs(t, err, approval.ErrNotFound)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received approval.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		if received.Type == approval.EventFailed {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	notifier := approval.NewWebhookNotifier(server.URL, server.Client())
+	event := approval.Event{Type: approval.EventRequested, Actor: "alice", Request: approval.Request{ID: "1", Action: "dlq.purge"}}
+	require.NoError(t, notifier.Notify(context.Background(), event))
+	assert.Equal(t, "alice", received.Actor)
+	assert.Equal(t, "dlq.purge", received.Request.Action)
+
+	event.Type = approval.EventFailed
+	assert.Error(t, notifier.Notify(context.Background(), event))
+}
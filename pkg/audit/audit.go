@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
+)
+
+// Categories of audit records, each with its own retention
+const (
+	CategoryAuth  = "auth"  // Sign ins and magic links
+	CategoryAdmin = "admin" // Actions of admins, e.g. approvals
+)
+
+// Record is an entry of the audit log
+type Record struct {
+	ID         string            `json:"id"` // ULID, ordered by time
+	Category   string            `json:"category"`
+	Action     string            `json:"action"`
+	Actor      string            `json:"actor,omitempty"`   // Who acted, e.g. an admin or a user signing in
+	Subject    string            `json:"subject,omitempty"` // What was acted on, e.g. a user
+	Details    map[string]string `json:"details,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	LegalHold  bool              `json:"legal_hold"` // Exempts the record from retention
+}
+
+// Filter selects audit records; empty fields match every record
+type Filter struct {
+	Category string
+	Actor    string
+	Subject  string
+	Limit    int // 0 selects every record
+}
+
+// matches reports whether a record matches the filter
+func (f Filter) matches(r Record) bool {
+	return (f.Category == "" || r.Category == f.Category) &&
+		(f.Actor == "" || r.Actor == f.Actor) &&
+		(f.Subject == "" || r.Subject == f.Subject)
+}
+
+// Store persists audit records
+type Store interface {
+	Append(ctx context.Context, record Record) error
+	// List returns the records matching filter, newest first
+	List(ctx context.Context, filter Filter) ([]Record, error)
+	// Expired returns up to limit records of category that occurred before t and are not under
+	// legal hold, oldest first
+	Expired(ctx context.Context, category string, before time.Time, limit int) ([]Record, error)
+	// Delete deletes records, returning how many were; records under legal hold are kept
+	Delete(ctx context.Context, ids []string) (int, error)
+	// SetLegalHold places or lifts the legal hold of the records matching filter, whatever its
+	// limit, returning how many records were updated
+	SetLegalHold(ctx context.Context, filter Filter, hold bool) (int, error)
+}
+
+// Log records audit records in a store, stamping their ID and time
+type Log struct {
+	store Store
+	clock clock.Clock
+	ids   *id.Generator
+}
+
+// NewLog creates a log appending to store. A nil clock uses the system clock.
+func NewLog(store Store, clk clock.Clock) *Log {
+	clk = clock.OrDefault(clk)
+	return &Log{store: store, clock: clk, ids: id.NewGenerator(clk)}
+}
+
+// Record appends a record, setting its ID and OccurredAt when empty
+func (l *Log) Record(ctx context.Context, record Record) error {
+	if record.OccurredAt.IsZero() {
+		record.OccurredAt = l.clock.Now().UTC()
+	}
+	if record.ID == "" {
+		record.ID = l.ids.ULID()
+	}
+	return l.store.Append(ctx, record)
+}
+
+// MemoryStore keeps audit records in memory, for tests and development
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Append adds a record
+func (s *MemoryStore) Append(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.ID] = record
+	return nil
+}
+
+// List returns the records matching filter, newest first
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]Record, error) {
+	records := s.sorted(filter.matches)
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, nil
+}
+
+// Expired returns up to limit records of category that occurred before t and are not under legal hold
+func (s *MemoryStore) Expired(ctx context.Context, category string, before time.Time, limit int) ([]Record, error) {
+	records := s.sorted(func(r Record) bool {
+		return r.Category == category && r.OccurredAt.Before(before) && !r.LegalHold
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// Delete deletes the records not under legal hold
+func (s *MemoryStore) Delete(ctx context.Context, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for _, recordID := range ids {
+		if record, ok := s.records[recordID]; ok && !record.LegalHold {
+			delete(s.records, recordID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// SetLegalHold places or lifts the legal hold of the records matching filter
+func (s *MemoryStore) SetLegalHold(ctx context.Context, filter Filter, hold bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated := 0
+	for recordID, record := range s.records {
+		if filter.matches(record) && record.LegalHold != hold {
+			record.LegalHold = hold
+			s.records[recordID] = record
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// sorted returns the records matching match, oldest first
+func (s *MemoryStore) sorted(match func(Record) bool) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		if match(record) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records
+}
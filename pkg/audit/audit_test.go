@@ -0,0 +1,124 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/audit"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// record appends a record of category that occurred age ago
+func record(t *testing.T, log *audit.Log, fake *clock.Fake, category, subject string, age time.Duration) {
+	err := log.Record(context.Background(), audit.Record{
+		Category:   category,
+		Action:     "test",
+		Subject:    subject,
+		OccurredAt: fake.Now().Add(-age),
+	})
+	require.NoError(t, err)
+}
+
+// subjects returns the subjects of the stored records of category, newest first
+func subjects(t *testing.T, store audit.Store, category string) []string {
+	records, err := store.List(context.Background(), audit.Filter{Category: category})
+	require.NoError(t, err)
+	var result []string
+	for _, r := range records {
+		result = append(result, r.Subject)
+	}
+	return result
+}
+
+func TestEnforcer_Enforce(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := audit.NewMemoryStore()
+	log := audit.NewLog(store, fake)
+	year := 365 * 24 * time.Hour
+
+	record(t, log, fake, audit.CategoryAuth, "old-login", 3*year)
+	record(t, log, fake, audit.CategoryAuth, "held-login", 3*year)
+	record(t, log, fake, audit.CategoryAuth, "recent-login", year)
+	record(t, log, fake, audit.CategoryAdmin, "old-action", 3*year)
+	record(t, log, fake, audit.CategoryAdmin, "ancient-action", 8*year)
+	record(t, log, fake, "other", "unlimited", 10*year)
+
+	held, err := store.SetLegalHold(ctx, audit.Filter{Subject: "held-login"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, held)
+
+	var exported []string
+	enforcer := audit.NewEnforcer(store, map[string]time.Duration{
+		audit.CategoryAuth:  2 * year,
+		audit.CategoryAdmin: 7 * year,
+	}, 1, nil, fake)
+	enforcer.AddExporter(audit.ExporterFunc(func(ctx context.Context, category string, records []audit.Record) error {
+		for _, r := range records {
+			exported = append(exported, category+"/"+r.Subject)
+		}
+		return nil
+	}))
+
+	deleted, err := enforcer.Enforce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{audit.CategoryAuth: 1, audit.CategoryAdmin: 1}, deleted)
+	assert.Equal(t, []string{"admin/ancient-action", "auth/old-login"}, exported)
+	assert.Equal(t, []string{"recent-login", "held-login"}, subjects(t, store, audit.CategoryAuth))
+	assert.Equal(t, []string{"old-action"}, subjects(t, store, audit.CategoryAdmin))
+	assert.Equal(t, []string{"unlimited"}, subjects(t, store, "other"))
+
+	// Lifting the hold lets the record expire
+	_, err = store.SetLegalHold(ctx, audit.Filter{Subject: "held-login"}, false)
+	require.NoError(t, err)
+	deleted, err = enforcer.Enforce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted[audit.CategoryAuth])
+	assert.Equal(t, []string{"recent-login"}, subjects(t, store, audit.CategoryAuth))
+}
+
+func TestEnforcer_EnforceKeepsRecordsOnFailedExport(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := audit.NewMemoryStore()
+	log := audit.NewLog(store, fake)
+	record(t, log, fake, audit.CategoryAuth, "old-login", 48*time.Hour)
+
+	enforcer := audit.NewEnforcer(store, map[string]time.Duration{audit.CategoryAuth: time.Hour}, 10, nil, fake)
+	enforcer.AddExporter(audit.ExporterFunc(func(ctx context.Context, category string, records []audit.Record) error {
+		return errors.New("storage unavailable")
+	}))
+
+	_, err := enforcer.Enforce(ctx)
+	assert.ErrorContains(t, err, "storage unavailable")
+	assert.Equal(t, []string{"old-login"}, subjects(t, store, audit.CategoryAuth))
+}
+
+func TestMemoryStore_List(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := audit.NewMemoryStore()
+	log := audit.NewLog(store, fake)
+
+	for _, subject := range []string{"a", "b", "c"} {
+		require.NoError(t, log.Record(ctx, audit.Record{Category: audit.CategoryAdmin, Action: "test", Actor: "alice", Subject: subject}))
+		fake.Advance(time.Second)
+	}
+
+	records, err := store.List(ctx, audit.Filter{Actor: "alice", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "c", records[0].Subject)
+	assert.Equal(t, "b", records[1].Subject)
+	assert.NotEmpty(t, records[0].ID)
+	assert.Equal(t, fake.Now().Add(-time.Second), records[0].OccurredAt)
+
+	records, err = store.List(ctx, audit.Filter{Actor: "bob"})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"go-clean-ddd-es-template/pkg/storage"
+)
+
+// StorageExporter archives expired audit records to object storage as JSON lines, one object per
+// batch under <prefix>/<category>/<year>/<ID of the first record>.jsonl. Keys are derived from the
+// records, so a batch exported again after its deletion failed replaces its previous export.
+type StorageExporter struct {
+	objects storage.Storage
+	prefix  string
+}
+
+// NewStorageExporter creates an exporter archiving audit records under prefix
+func NewStorageExporter(objects storage.Storage, prefix string) *StorageExporter {
+	return &StorageExporter{objects: objects, prefix: prefix}
+}
+
+// Export writes a batch of records of category to object storage
+func (e *StorageExporter) Export(ctx context.Context, category string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode audit record %s: %w", record.ID, err)
+		}
+	}
+
+	first := records[0]
+	key := path.Join(e.prefix, category, first.OccurredAt.UTC().Format("2006"), first.ID+".jsonl")
+	if _, err := e.objects.Put(ctx, key, &buf, int64(buf.Len()), storage.PutOptions{ContentType: "application/x-ndjson"}); err != nil {
+		return fmt.Errorf("failed to store audit export %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Exporter is told about expired records before they are deleted, e.g. to archive them; a failed
+// export keeps the records until the next enforcement
+type Exporter interface {
+	Export(ctx context.Context, category string, records []Record) error
+}
+
+// ExporterFunc adapts a function to an Exporter
+type ExporterFunc func(ctx context.Context, category string, records []Record) error
+
+// Export calls f
+func (f ExporterFunc) Export(ctx context.Context, category string, records []Record) error {
+	return f(ctx, category, records)
+}
+
+// Logger logs retention enforcements
+type Logger interface {
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+}
+
+// Enforcer deletes the audit records past the retention of their category, exporting them first.
+// Records under legal hold and of categories without retention are kept.
+type Enforcer struct {
+	store     Store
+	retention map[string]time.Duration
+	batchSize int
+	exporters []Exporter
+	logger    Logger
+	clock     clock.Clock
+}
+
+// NewEnforcer creates an enforcer of retention, by category, deleting batchSize records at a time.
+// A nil logger logs nothing, a nil clock uses the system clock.
+func NewEnforcer(store Store, retention map[string]time.Duration, batchSize int, logger Logger, clk clock.Clock) *Enforcer {
+	return &Enforcer{
+		store:     store,
+		retention: retention,
+		batchSize: batchSize,
+		logger:    logger,
+		clock:     clock.OrDefault(clk),
+	}
+}
+
+// AddExporter registers an exporter called with every batch of expired records before deletion
+func (e *Enforcer) AddExporter(exporter Exporter) {
+	e.exporters = append(e.exporters, exporter)
+}
+
+// Enforce deletes the expired records of every category, returning how many were deleted by
+// category. A failing category stops the enforcement, keeping the records deleted so far deleted.
+func (e *Enforcer) Enforce(ctx context.Context) (map[string]int, error) {
+	categories := make([]string, 0, len(e.retention))
+	for category, retention := range e.retention {
+		if retention > 0 {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+
+	deleted := make(map[string]int, len(categories))
+	now := e.clock.Now()
+	for _, category := range categories {
+		count, err := e.enforce(ctx, category, now.Add(-e.retention[category]))
+		deleted[category] = count
+		if err != nil {
+			return deleted, fmt.Errorf("failed to enforce the retention of %s audit records: %w", category, err)
+		}
+		if count > 0 && e.logger != nil {
+			e.logger.Info("Deleted %d %s audit records past their %s retention", count, category, e.retention[category])
+		}
+	}
+	return deleted, nil
+}
+
+// enforce exports then deletes the records of category that occurred before cutoff, a batch at a time
+func (e *Enforcer) enforce(ctx context.Context, category string, cutoff time.Time) (int, error) {
+	deleted := 0
+	for {
+		records, err := e.store.Expired(ctx, category, cutoff, e.batchSize)
+		if err != nil || len(records) == 0 {
+			return deleted, err
+		}
+		for _, exporter := range e.exporters {
+			if err := exporter.Export(ctx, category, records); err != nil {
+				return deleted, fmt.Errorf("failed to export audit records: %w", err)
+			}
+		}
+
+		ids := make([]string, len(records))
+		for i, record := range records {
+			ids[i] = record.ID
+		}
+		count, err := e.store.Delete(ctx, ids)
+		deleted += count
+		if err != nil {
+			return deleted, err
+		}
+		// Records held meanwhile are kept and would be selected again
+		if count < len(records) || len(records) < e.batchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// Run enforces the retention every interval until ctx is done
+func (e *Enforcer) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if _, err := e.Enforce(ctx); err != nil && e.logger != nil {
+			e.logger.Warn("%v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-e.clock.After(interval):
+		}
+	}
+}
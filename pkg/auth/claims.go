@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenIssuer is the issuer of every token of the service
+const tokenIssuer = "go-clean-ddd-es-template"
+
+// newRegisteredClaims returns the registered claims of a token for audience issued now, by the
+// clock of the service, to a user, valid for ttl and identified by a random ID
+func (j *JWTService) newRegisteredClaims(userID, audience string, ttl time.Duration) (jwt.RegisteredClaims, error) {
+	id, err := randomID()
+	if err != nil {
+		return jwt.RegisteredClaims{}, err
+	}
+
+	now := j.clock.Now()
+	return jwt.RegisteredClaims{
+		ID:        id,
+		Audience:  jwt.ClaimStrings{audience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    tokenIssuer,
+		Subject:   userID,
+	}, nil
+}
+
+// signClaims signs claims with the private key of the service
+func (j *JWTService) signClaims(claims jwt.Claims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(j.privateKey)
+}
+
+// parseClaims validates a token signed by the service for audience, parsing its claims into
+// claims. Tokens for other audiences are rejected, so a token is only accepted for its purpose,
+// and expiry is checked against the clock of the service.
+func (j *JWTService) parseClaims(tokenString, audience string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return j.publicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithAudience(audience), jwt.WithTimeFunc(j.clock.Now))
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return ErrInvalidToken
+	}
+	return nil
+}
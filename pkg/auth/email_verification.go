@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// EmailVerificationAudience is the audience of email verification tokens, which verify the email
+// of a user and are not accepted as access tokens
+const EmailVerificationAudience = "email-verification"
+
+// ErrEmailChanged is the error of an email verification token issued for another email than the
+// user's, e.g. before the user changed it
+var ErrEmailChanged = errors.New("email changed since the verification was requested")
+
+// EmailVerificationClaims represents the claims in an email verification token
+type EmailVerificationClaims struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// GenerateEmailVerificationToken generates a signed token valid for ttl, verifying email as the
+// email of the user
+func (j *JWTService) GenerateEmailVerificationToken(userID, email string, ttl time.Duration) (string, *EmailVerificationClaims, error) {
+	registered, err := j.newRegisteredClaims(userID, EmailVerificationAudience, ttl)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate email verification ID: %w", err)
+	}
+	claims := &EmailVerificationClaims{UserID: userID, Email: email, RegisteredClaims: registered}
+
+	token, err := j.signClaims(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// ValidateEmailVerificationToken validates an email verification token and returns its claims.
+// It does not check that the email is still the user's, see CheckEmailVerificationToken.
+func (j *JWTService) ValidateEmailVerificationToken(tokenString string) (*EmailVerificationClaims, error) {
+	claims := &EmailVerificationClaims{}
+	if err := j.parseClaims(tokenString, EmailVerificationAudience, claims); err != nil {
+		return nil, fmt.Errorf("failed to parse email verification token: %w", err)
+	}
+	if claims.ID == "" || claims.Email == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// CheckEmailVerificationToken checks that claims verify email, failing with ErrEmailChanged
+// otherwise
+func CheckEmailVerificationToken(claims *EmailVerificationClaims, email string) error {
+	if !strings.EqualFold(claims.Email, email) {
+		return ErrEmailChanged
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTService_EmailVerificationToken(t *testing.T) {
+	jwtService := newJWTService(t)
+
+	token, issued, err := jwtService.GenerateEmailVerificationToken("user-1", "alice@example.com", time.Minute)
+	require.NoError(t, err)
+	assert.NotEmpty(t, issued.ID)
+
+	claims, err := jwtService.ValidateEmailVerificationToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.NoError(t, auth.CheckEmailVerificationToken(claims, "Alice@Example.com"))
+	assert.ErrorIs(t, auth.CheckEmailVerificationToken(claims, "alice@example.org"), auth.ErrEmailChanged, "tokens only verify the email they were sent to")
+
+	_, err = jwtService.ValidateToken(token)
+	assert.Error(t, err, "email verification tokens are not access tokens")
+
+	resetToken, _, err := jwtService.GeneratePasswordResetToken("user-1", "alice@example.com", "hash-1", time.Minute)
+	require.NoError(t, err)
+	_, err = jwtService.ValidateEmailVerificationToken(resetToken)
+	assert.Error(t, err, "password reset tokens do not verify emails")
+
+	expired, _, err := jwtService.GenerateEmailVerificationToken("user-1", "alice@example.com", -time.Minute)
+	require.NoError(t, err)
+	_, err = jwtService.ValidateEmailVerificationToken(expired)
+	assert.Error(t, err)
+}
@@ -10,13 +10,19 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"go-clean-ddd-es-template/pkg/clock"
 )
 
+// AccessTokenAudience is the audience of access tokens, the only tokens authenticating requests
+const AccessTokenAudience = "access"
+
 // JWTClaims represents the claims in a JWT token
 type JWTClaims struct {
 	UserID string   `json:"user_id"`
 	Email  string   `json:"email"`
 	Roles  []string `json:"roles"`
+	Scopes []string `json:"scopes,omitempty"` // Granted to tokens issued for services rather than users
 	jwt.RegisteredClaims
 }
 
@@ -25,6 +31,7 @@ type JWTService struct {
 	privateKey    *rsa.PrivateKey
 	publicKey     *rsa.PublicKey
 	tokenDuration time.Duration
+	clock         clock.Clock
 }
 
 // NewJWTService creates a new JWT service with RSA keys from file paths
@@ -81,9 +88,15 @@ func NewJWTService(privateKeyPath, publicKeyPath string, tokenDuration time.Dura
 		privateKey:    privateKey,
 		publicKey:     rsaPublicKey,
 		tokenDuration: tokenDuration,
+		clock:         clock.New(),
 	}, nil
 }
 
+// SetClock issues tokens and checks their expiry at the time of clk, nil for the system clock
+func (j *JWTService) SetClock(clk clock.Clock) {
+	j.clock = clock.OrDefault(clk)
+}
+
 // GenerateRSAKeyPair generates a new RSA key pair
 func GenerateRSAKeyPair(bits int) (*rsa.PrivateKey, *rsa.PublicKey, error) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
@@ -124,42 +137,21 @@ func (j *JWTService) GetExpiration() time.Duration {
 
 // GenerateToken generates a new JWT token for a user using RSA
 func (j *JWTService) GenerateToken(userID, email string, roles []string) (string, error) {
-	now := time.Now()
-	claims := &JWTClaims{
-		UserID: userID,
-		Email:  email,
-		Roles:  roles,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(j.tokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(now),
-			NotBefore: jwt.NewNumericDate(now),
-			Issuer:    "go-clean-ddd-es-template",
-			Subject:   userID,
-		},
+	registered, err := j.newRegisteredClaims(userID, AccessTokenAudience, j.tokenDuration)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	return token.SignedString(j.privateKey)
+	return j.signClaims(&JWTClaims{UserID: userID, Email: email, Roles: roles, RegisteredClaims: registered})
 }
 
-// ValidateToken validates a JWT token and returns the claims using RSA
+// ValidateToken validates an access token and returns the claims using RSA. Tokens of any other
+// audience, e.g. magic link or refresh tokens, do not authenticate requests.
 func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.publicKey, nil
-	})
-	if err != nil {
+	claims := &JWTClaims{}
+	if err := j.parseClaims(tokenString, AccessTokenAudience, claims); err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
-
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, fmt.Errorf("invalid token")
+	return claims, nil
 }
 
 // RefreshToken generates a new token with extended expiration
@@ -190,5 +182,5 @@ func (j *JWTService) IsTokenExpired(tokenString string) (bool, error) {
 		return true, err
 	}
 
-	return time.Now().After(expiration), nil
+	return j.clock.Now().After(expiration), nil
 }
@@ -0,0 +1,67 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/auth"
+	"go-clean-ddd-es-template/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTService_ValidateTokenAcceptsAccessTokensOnly(t *testing.T) {
+	jwtService := newJWTService(t)
+
+	accessToken, err := jwtService.GenerateToken("user-1", "alice@example.com", []string{"user"})
+	require.NoError(t, err)
+	claims, err := jwtService.ValidateToken(accessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Equal(t, []string{auth.AccessTokenAudience}, []string(claims.Audience))
+
+	magicLink, _, err := jwtService.GenerateMagicLinkToken("user-1", "alice@example.com", "", time.Minute)
+	require.NoError(t, err)
+	refreshToken, _, err := jwtService.GenerateRefreshToken("user-1", "alice@example.com", "", time.Minute)
+	require.NoError(t, err)
+	passwordReset, _, err := jwtService.GeneratePasswordResetToken("user-1", "alice@example.com", "hash", time.Minute)
+	require.NoError(t, err)
+	emailVerification, _, err := jwtService.GenerateEmailVerificationToken("user-1", "alice@example.com", time.Minute)
+	require.NoError(t, err)
+	for name, token := range map[string]string{
+		"magic link":         magicLink,
+		"refresh":            refreshToken,
+		"password reset":     passwordReset,
+		"email verification": emailVerification,
+	} {
+		_, err := jwtService.ValidateToken(token)
+		assert.Error(t, err, "%s tokens are not access tokens", name)
+	}
+}
+
+func TestJWTService_IssuesAndExpiresTokensByItsClock(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(issuedAt)
+	jwtService := newJWTService(t)
+	jwtService.SetClock(clk)
+
+	token, err := jwtService.GenerateToken("user-1", "alice@example.com", []string{"user"})
+	require.NoError(t, err)
+	claims, err := jwtService.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, issuedAt, claims.IssuedAt.Time.UTC())
+	assert.Equal(t, issuedAt.Add(time.Hour), claims.ExpiresAt.Time.UTC())
+
+	refreshToken, refreshClaims, err := jwtService.GenerateRefreshToken("user-1", "alice@example.com", "", 2*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, issuedAt.Add(2*time.Hour), refreshClaims.ExpiresAt.Time.UTC())
+
+	clk.Advance(time.Hour + time.Second)
+	_, err = jwtService.ValidateToken(token)
+	assert.Error(t, err, "access tokens expire by the clock of the service")
+	expired, _ := jwtService.IsTokenExpired(token)
+	assert.True(t, expired)
+	_, err = jwtService.ValidateRefreshToken(refreshToken)
+	assert.NoError(t, err)
+}
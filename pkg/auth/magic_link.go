@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MagicLinkAudience is the audience of magic link tokens, which sign in once and are not accepted
+// as access tokens
+const MagicLinkAudience = "magic-link"
+
+// ErrDeviceMismatch is the error of a magic link bound to a device consumed from another device
+var ErrDeviceMismatch = errors.New("magic link was requested from another device")
+
+// MagicLinkClaims represents the claims in a magic link token
+type MagicLinkClaims struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Device string `json:"device,omitempty"` // SHA-256 of the device the link is bound to
+	jwt.RegisteredClaims
+}
+
+// GenerateMagicLinkToken generates a signed magic link token valid for ttl, identified by a random
+// ID so it can be consumed once. A non-empty device binds the token to the device requesting it.
+func (j *JWTService) GenerateMagicLinkToken(userID, email, device string, ttl time.Duration) (string, *MagicLinkClaims, error) {
+	registered, err := j.newRegisteredClaims(userID, MagicLinkAudience, ttl)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate magic link ID: %w", err)
+	}
+	claims := &MagicLinkClaims{UserID: userID, Email: email, RegisteredClaims: registered}
+	if device != "" {
+		claims.Device = deviceHash(device)
+	}
+
+	token, err := j.signClaims(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// ValidateMagicLinkToken validates a magic link token consumed from device and returns its claims.
+// It does not check that the token was not consumed already.
+func (j *JWTService) ValidateMagicLinkToken(tokenString, device string) (*MagicLinkClaims, error) {
+	claims := &MagicLinkClaims{}
+	if err := j.parseClaims(tokenString, MagicLinkAudience, claims); err != nil {
+		return nil, fmt.Errorf("failed to parse magic link token: %w", err)
+	}
+	if claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	if claims.Device != "" && subtle.ConstantTimeCompare([]byte(claims.Device), []byte(deviceHash(device))) != 1 {
+		return nil, ErrDeviceMismatch
+	}
+	return claims, nil
+}
+
+// deviceHash returns the hash of a device ID stored in magic link tokens
+func deviceHash(device string) string {
+	sum := sha256.Sum256([]byte(device))
+	return hex.EncodeToString(sum[:])
+}
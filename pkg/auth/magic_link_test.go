@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJWTService creates a JWT service with a key pair generated for the test
+func newJWTService(t *testing.T) *auth.JWTService {
+	privateKey, publicKey, err := auth.GenerateRSAKeyPair(2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privatePath, []byte(auth.ExportPrivateKeyPEM(privateKey)), 0o600))
+	require.NoError(t, os.WriteFile(publicPath, []byte(auth.ExportPublicKeyPEM(publicKey)), 0o644))
+
+	jwtService, err := auth.NewJWTService(privatePath, publicPath, time.Hour)
+	require.NoError(t, err)
+	return jwtService
+}
+
+func TestJWTService_MagicLinkToken(t *testing.T) {
+	jwtService := newJWTService(t)
+
+	token, issued, err := jwtService.GenerateMagicLinkToken("user-1", "alice@example.com", "", time.Minute)
+	require.NoError(t, err)
+	assert.NotEmpty(t, issued.ID)
+
+	claims, err := jwtService.ValidateMagicLinkToken(token, "any device")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Equal(t, issued.ID, claims.ID)
+
+	_, err = jwtService.ValidateToken(token)
+	assert.Error(t, err, "magic link tokens are not access tokens")
+
+	accessToken, err := jwtService.GenerateToken("user-1", "alice@example.com", []string{"user"})
+	require.NoError(t, err)
+	_, err = jwtService.ValidateMagicLinkToken(accessToken, "")
+	assert.Error(t, err, "access tokens are not magic link tokens")
+
+	expired, _, err := jwtService.GenerateMagicLinkToken("user-1", "alice@example.com", "", -time.Minute)
+	require.NoError(t, err)
+	_, err = jwtService.ValidateMagicLinkToken(expired, "")
+	assert.Error(t, err)
+}
+
+func TestJWTService_MagicLinkTokenBoundToDevice(t *testing.T) {
+	jwtService := newJWTService(t)
+
+	token, _, err := jwtService.GenerateMagicLinkToken("user-1", "alice@example.com", "laptop", time.Minute)
+	require.NoError(t, err)
+
+	_, err = jwtService.ValidateMagicLinkToken(token, "laptop")
+	assert.NoError(t, err)
+	_, err = jwtService.ValidateMagicLinkToken(token, "phone")
+	assert.ErrorIs(t, err, auth.ErrDeviceMismatch)
+	_, err = jwtService.ValidateMagicLinkToken(token, "")
+	assert.ErrorIs(t, err, auth.ErrDeviceMismatch)
+}
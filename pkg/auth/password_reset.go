@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PasswordResetAudience is the audience of password reset tokens, which reset a password once and
+// are not accepted as access tokens
+const PasswordResetAudience = "password-reset"
+
+// ErrPasswordChanged is the error of a password reset token issued before the password changed,
+// e.g. by using the token or another one
+var ErrPasswordChanged = errors.New("password changed since the reset was requested")
+
+// PasswordResetClaims represents the claims in a password reset token. Tokens carry a fingerprint
+// of the password hash they reset, so they stop working once the password changed.
+type PasswordResetClaims struct {
+	UserID      string `json:"user_id"`
+	Email       string `json:"email"`
+	Fingerprint string `json:"pwd"`
+	jwt.RegisteredClaims
+}
+
+// GeneratePasswordResetToken generates a signed password reset token valid for ttl, resetting the
+// password whose hash is passwordHash
+func (j *JWTService) GeneratePasswordResetToken(userID, email, passwordHash string, ttl time.Duration) (string, *PasswordResetClaims, error) {
+	registered, err := j.newRegisteredClaims(userID, PasswordResetAudience, ttl)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate password reset ID: %w", err)
+	}
+	claims := &PasswordResetClaims{
+		UserID:           userID,
+		Email:            email,
+		Fingerprint:      passwordFingerprint(passwordHash),
+		RegisteredClaims: registered,
+	}
+
+	token, err := j.signClaims(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// ValidatePasswordResetToken validates a password reset token and returns its claims. It does not
+// check that the password is still the one the token resets, see CheckPasswordResetToken.
+func (j *JWTService) ValidatePasswordResetToken(tokenString string) (*PasswordResetClaims, error) {
+	claims := &PasswordResetClaims{}
+	if err := j.parseClaims(tokenString, PasswordResetAudience, claims); err != nil {
+		return nil, fmt.Errorf("failed to parse password reset token: %w", err)
+	}
+	if claims.ID == "" || claims.Fingerprint == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// CheckPasswordResetToken checks that claims reset the password whose hash is passwordHash, failing
+// with ErrPasswordChanged otherwise
+func CheckPasswordResetToken(claims *PasswordResetClaims, passwordHash string) error {
+	if subtle.ConstantTimeCompare([]byte(claims.Fingerprint), []byte(passwordFingerprint(passwordHash))) != 1 {
+		return ErrPasswordChanged
+	}
+	return nil
+}
+
+// passwordFingerprint returns the fingerprint of a password hash stored in password reset tokens,
+// which does not reveal the hash
+func passwordFingerprint(passwordHash string) string {
+	sum := sha256.Sum256([]byte("password-reset:" + passwordHash))
+	return hex.EncodeToString(sum[:16])
+}
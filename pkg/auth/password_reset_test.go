@@ -0,0 +1,39 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTService_PasswordResetToken(t *testing.T) {
+	jwtService := newJWTService(t)
+
+	token, issued, err := jwtService.GeneratePasswordResetToken("user-1", "alice@example.com", "hash-1", time.Minute)
+	require.NoError(t, err)
+	assert.NotEmpty(t, issued.ID)
+	assert.NotContains(t, issued.Fingerprint, "hash-1")
+
+	claims, err := jwtService.ValidatePasswordResetToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.NoError(t, auth.CheckPasswordResetToken(claims, "hash-1"))
+	assert.ErrorIs(t, auth.CheckPasswordResetToken(claims, "hash-2"), auth.ErrPasswordChanged, "tokens stop working once the password changed")
+
+	_, err = jwtService.ValidateToken(token)
+	assert.Error(t, err, "password reset tokens are not access tokens")
+
+	accessToken, err := jwtService.GenerateToken("user-1", "alice@example.com", []string{"user"})
+	require.NoError(t, err)
+	_, err = jwtService.ValidatePasswordResetToken(accessToken)
+	assert.Error(t, err, "access tokens are not password reset tokens")
+
+	expired, _, err := jwtService.GeneratePasswordResetToken("user-1", "alice@example.com", "hash-1", -time.Minute)
+	require.NoError(t, err)
+	_, err = jwtService.ValidatePasswordResetToken(expired)
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RefreshTokenAudience is the audience of refresh tokens, which renew access tokens and are not
+// accepted as access tokens
+const RefreshTokenAudience = "refresh"
+
+// RefreshTokenClaims represents the claims in a refresh token. Tokens rotated from one another
+// share their family, so all of them are revoked when one is used twice.
+type RefreshTokenClaims struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Family string `json:"family"`
+	jwt.RegisteredClaims
+}
+
+// GenerateRefreshToken generates a signed refresh token valid for ttl, identified by a random ID so
+// it can be rotated once. An empty family starts a new one, signing in again.
+func (j *JWTService) GenerateRefreshToken(userID, email, family string, ttl time.Duration) (string, *RefreshTokenClaims, error) {
+	registered, err := j.newRegisteredClaims(userID, RefreshTokenAudience, ttl)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token ID: %w", err)
+	}
+	if family == "" {
+		if family, err = randomID(); err != nil {
+			return "", nil, fmt.Errorf("failed to generate refresh token family: %w", err)
+		}
+	}
+	claims := &RefreshTokenClaims{UserID: userID, Email: email, Family: family, RegisteredClaims: registered}
+
+	token, err := j.signClaims(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// ValidateRefreshToken validates a refresh token and returns its claims. It does not check that the
+// token was not rotated or revoked already.
+func (j *JWTService) ValidateRefreshToken(tokenString string) (*RefreshTokenClaims, error) {
+	claims := &RefreshTokenClaims{}
+	if err := j.parseClaims(tokenString, RefreshTokenAudience, claims); err != nil {
+		return nil, fmt.Errorf("failed to parse refresh token: %w", err)
+	}
+	if claims.ID == "" || claims.Family == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// randomID returns a random 128-bit hex ID
+func randomID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,51 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTService_RefreshToken(t *testing.T) {
+	jwtService := newJWTService(t)
+
+	token, issued, err := jwtService.GenerateRefreshToken("user-1", "alice@example.com", "", time.Hour)
+	require.NoError(t, err)
+	assert.NotEmpty(t, issued.ID)
+	assert.NotEmpty(t, issued.Family, "signing in starts a family")
+
+	claims, err := jwtService.ValidateRefreshToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Equal(t, issued.Family, claims.Family)
+
+	rotated, rotatedClaims, err := jwtService.GenerateRefreshToken("user-1", "alice@example.com", issued.Family, time.Hour)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, rotated)
+	assert.NotEqual(t, issued.ID, rotatedClaims.ID)
+	assert.Equal(t, issued.Family, rotatedClaims.Family, "rotated tokens stay in their family")
+
+	_, err = jwtService.ValidateToken(token)
+	assert.Error(t, err, "refresh tokens are not access tokens")
+
+	accessToken, err := jwtService.GenerateToken("user-1", "alice@example.com", []string{"user"})
+	require.NoError(t, err)
+	_, err = jwtService.ValidateRefreshToken(accessToken)
+	assert.Error(t, err, "access tokens are not refresh tokens")
+
+	magicLink, _, err := jwtService.GenerateMagicLinkToken("user-1", "alice@example.com", "", time.Minute)
+	require.NoError(t, err)
+	_, err = jwtService.ValidateRefreshToken(magicLink)
+	assert.Error(t, err, "magic link tokens are not refresh tokens")
+}
+
+func TestJWTService_RefreshTokenExpired(t *testing.T) {
+	jwtService := newJWTService(t)
+
+	token, _, err := jwtService.GenerateRefreshToken("user-1", "alice@example.com", "", -time.Minute)
+	require.NoError(t, err)
+	_, err = jwtService.ValidateRefreshToken(token)
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Errors of denied calls
+var (
+	ErrNoPolicy = errors.New("no authorization policy for method")
+	ErrDenied   = errors.New("caller lacks the roles or scopes the method requires")
+)
+
+// Logger logs the reloads of the authorization matrix
+type Logger interface {
+	Info(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Authorizer authorizes gRPC calls against the authorization matrix of a file, reloaded when the
+// file changes. A reloaded matrix replaces the current one only when it covers every method the
+// authorizer is bound to, so a bad edit never opens or locks out methods.
+type Authorizer struct {
+	path   string
+	clock  clock.Clock
+	logger Logger
+
+	mu       sync.RWMutex
+	matrix   *Matrix
+	data     []byte
+	methods  []string
+	loadedAt time.Time
+}
+
+// NewAuthorizer creates an authorizer loading the matrix of the YAML file at path. A nil clock
+// uses the system clock.
+func NewAuthorizer(path string, clk clock.Clock, logger Logger) (*Authorizer, error) {
+	a := &Authorizer{
+		path:   path,
+		clock:  clock.OrDefault(clk),
+		logger: logger,
+	}
+	data, matrix, err := a.read()
+	if err != nil {
+		return nil, err
+	}
+	a.matrix, a.data, a.loadedAt = matrix, data, a.clock.Now()
+	return a, nil
+}
+
+// read reads and parses the matrix file
+func (a *Authorizer) read() ([]byte, *Matrix, error) {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read authorization matrix: %w", err)
+	}
+	matrix, err := Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, matrix, nil
+}
+
+// Bind requires the matrix, and every reloaded one, to have a policy for each of methods, the
+// methods the server serves
+func (a *Authorizer) Bind(methods []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.matrix.Validate(methods); err != nil {
+		return err
+	}
+	a.methods = append([]string(nil), methods...)
+	return nil
+}
+
+// Policy returns the policy of a full gRPC method
+func (a *Authorizer) Policy(method string) (Policy, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	policy, _, ok := a.matrix.PolicyOf(method)
+	return policy, ok
+}
+
+// Authorize fails with ErrNoPolicy or ErrDenied unless a caller with roles and scopes may call
+// method
+func (a *Authorizer) Authorize(method string, roles, scopes []string) error {
+	policy, ok := a.Policy(method)
+	if !ok {
+		return fmt.Errorf("%w %s", ErrNoPolicy, method)
+	}
+	if !policy.Allows(roles, scopes) {
+		return ErrDenied
+	}
+	return nil
+}
+
+// Reload reloads the matrix file when it changed, keeping the current matrix when the file is
+// invalid or misses the policy of a bound method
+func (a *Authorizer) Reload() error {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to read authorization matrix: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if bytes.Equal(data, a.data) {
+		return nil
+	}
+	matrix, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	if err := matrix.Validate(a.methods); err != nil {
+		return err
+	}
+	a.matrix, a.data, a.loadedAt = matrix, data, a.clock.Now()
+	if a.logger != nil {
+		a.logger.Info("Reloaded the authorization matrix of %s", a.path)
+	}
+	return nil
+}
+
+// Run reloads the matrix file every interval until ctx is done
+func (a *Authorizer) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-a.clock.After(interval):
+		}
+
+		if err := a.Reload(); err != nil && a.logger != nil {
+			a.logger.Error("Kept the current authorization matrix, failed to reload %s: %v", a.path, err)
+		}
+	}
+}
+
+// MethodPolicy is the effective policy of a method and the rule it comes from
+type MethodPolicy struct {
+	Method string `json:"method"`
+	Rule   string `json:"rule,omitempty"`
+	Policy
+}
+
+// Snapshot is the effective authorization matrix, for audits
+type Snapshot struct {
+	Source   string         `json:"source"`
+	LoadedAt time.Time      `json:"loaded_at"`
+	Methods  []MethodPolicy `json:"methods"`
+}
+
+// Snapshot returns the policy of every bound method, sorted by method
+func (a *Authorizer) Snapshot() Snapshot {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	methods := make([]MethodPolicy, 0, len(a.methods))
+	for _, method := range a.methods {
+		policy, rule, _ := a.matrix.PolicyOf(method)
+		methods = append(methods, MethodPolicy{Method: method, Rule: rule, Policy: policy})
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Method < methods[j].Method })
+	return Snapshot{Source: a.path, LoadedAt: a.loadedAt, Methods: methods}
+}
@@ -0,0 +1,198 @@
+package authz_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/authz"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+const matrixYAML = `
+methods:
+  /auth.AuthService/Login:
+    public: true
+  /auth.AuthService/*:
+    authenticated: true
+  /user.v2.UserService/*:
+    roles: [user, admin]
+  /user.v2.UserService/DeleteUser:
+    roles: [admin]
+    scopes: [users:delete]
+`
+
+var servedMethods = []string{
+	"/auth.AuthService/Login",
+	"/auth.AuthService/RefreshToken",
+	"/user.v2.UserService/GetUser",
+	"/user.v2.UserService/DeleteUser",
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "empty", yaml: "methods: {}", wantErr: "declares no methods"},
+		{name: "not a method", yaml: "methods:\n  GetUser:\n    public: true", wantErr: "invalid method"},
+		{name: "partial wildcard", yaml: "methods:\n  /user.v2.UserService/Get*:\n    public: true", wantErr: "only whole services"},
+		{name: "no access", yaml: "methods:\n  /user.v2.UserService/GetUser: {}", wantErr: "must be public, authenticated or require roles or scopes"},
+		{name: "public with roles", yaml: "methods:\n  /user.v2.UserService/GetUser:\n    public: true\n    roles: [admin]", wantErr: "public policies cannot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := authz.Parse([]byte(tt.yaml))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMatrix_PolicyOf(t *testing.T) {
+	matrix, err := authz.Parse([]byte(matrixYAML))
+	require.NoError(t, err)
+
+	policy, rule, ok := matrix.PolicyOf("/user.v2.UserService/DeleteUser")
+	require.True(t, ok)
+	assert.Equal(t, "/user.v2.UserService/DeleteUser", rule, "full method rules take precedence")
+	assert.Equal(t, []string{"admin"}, policy.Roles)
+
+	_, rule, ok = matrix.PolicyOf("/user.v2.UserService/GetUser")
+	require.True(t, ok)
+	assert.Equal(t, "/user.v2.UserService/*", rule)
+
+	_, _, ok = matrix.PolicyOf("/user.UserService/GetUser")
+	assert.False(t, ok)
+
+	assert.NoError(t, matrix.Validate(servedMethods))
+	assert.EqualError(t, matrix.Validate(append(servedMethods, "/user.UserService/GetUser", "/admin.DeadLetterQueueService/GetStats")),
+		"no authorization policy for /admin.DeadLetterQueueService/GetStats, /user.UserService/GetUser")
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	policy := authz.Policy{Roles: []string{"admin", "support"}, Scopes: []string{"users:delete"}}
+	assert.True(t, policy.Allows([]string{"support"}, []string{"users:read", "users:delete"}))
+	assert.False(t, policy.Allows([]string{"support"}, []string{"users:read"}), "every scope is required")
+	assert.False(t, policy.Allows([]string{"user"}, []string{"users:delete"}), "any of the roles is required")
+	assert.True(t, authz.Policy{Authenticated: true}.Allows(nil, nil))
+	assert.True(t, authz.AdminPolicy.Allows([]string{"user", authz.AdminRole}, nil))
+	assert.False(t, authz.AdminPolicy.Allows([]string{"user"}, nil), "users have no admin rights")
+}
+
+// writeMatrix writes an authorization matrix file in a temporary directory
+func writeMatrix(t *testing.T, path, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+type capturingLogger struct {
+	infos, errors []string
+}
+
+func (l *capturingLogger) Info(format string, v ...interface{}) {
+	l.infos = append(l.infos, fmt.Sprintf(format, v...))
+}
+
+func (l *capturingLogger) Error(format string, v ...interface{}) {
+	l.errors = append(l.errors, fmt.Sprintf(format, v...))
+}
+
+func TestAuthorizer_Authorize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authorization.yaml")
+	writeMatrix(t, path, matrixYAML)
+	authorizer, err := authz.NewAuthorizer(path, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, authorizer.Bind(servedMethods))
+
+	assert.NoError(t, authorizer.Authorize("/user.v2.UserService/GetUser", []string{"user"}, nil))
+	assert.ErrorIs(t, authorizer.Authorize("/user.v2.UserService/DeleteUser", []string{"user"}, nil), authz.ErrDenied)
+	assert.NoError(t, authorizer.Authorize("/user.v2.UserService/DeleteUser", []string{"admin"}, []string{"users:delete"}))
+	assert.ErrorIs(t, authorizer.Authorize("/user.UserService/GetUser", []string{"admin"}, nil), authz.ErrNoPolicy)
+
+	assert.ErrorContains(t, authorizer.Bind(append(servedMethods, "/user.UserService/GetUser")), "/user.UserService/GetUser")
+}
+
+func TestAuthorizer_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authorization.yaml")
+	writeMatrix(t, path, matrixYAML)
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	logger := &capturingLogger{}
+	authorizer, err := authz.NewAuthorizer(path, fake, logger)
+	require.NoError(t, err)
+	require.NoError(t, authorizer.Bind(servedMethods))
+
+	require.NoError(t, authorizer.Reload())
+	assert.Empty(t, logger.infos, "unchanged files are not reloaded")
+
+	writeMatrix(t, path, "methods:\n  /user.v2.UserService/*:\n    roles: [admin]\n")
+	assert.ErrorContains(t, authorizer.Reload(), "no authorization policy for /auth.AuthService/Login, /auth.AuthService/RefreshToken")
+	assert.NoError(t, authorizer.Authorize("/auth.AuthService/Login", nil, nil), "the current matrix is kept")
+
+	writeMatrix(t, path, "methods: [")
+	assert.Error(t, authorizer.Reload())
+
+	fake.Advance(time.Minute)
+	writeMatrix(t, path, matrixYAML+"  /user.v2.UserService/GetUser:\n    roles: [admin]\n")
+	require.NoError(t, authorizer.Reload())
+	assert.ErrorIs(t, authorizer.Authorize("/user.v2.UserService/GetUser", []string{"user"}, nil), authz.ErrDenied)
+	assert.Len(t, logger.infos, 1)
+
+	snapshot := authorizer.Snapshot()
+	assert.Equal(t, path, snapshot.Source)
+	assert.Equal(t, fake.Now(), snapshot.LoadedAt)
+	require.Len(t, snapshot.Methods, len(servedMethods))
+	assert.Equal(t, authz.MethodPolicy{Method: "/auth.AuthService/Login", Rule: "/auth.AuthService/Login", Policy: authz.Policy{Public: true}}, snapshot.Methods[0])
+	assert.Equal(t, authz.MethodPolicy{Method: "/user.v2.UserService/GetUser", Rule: "/user.v2.UserService/GetUser", Policy: authz.Policy{Roles: []string{"admin"}}}, snapshot.Methods[3])
+}
+
+func TestAuthorizer_RunReloadsEveryInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authorization.yaml")
+	writeMatrix(t, path, matrixYAML)
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	logger := &capturingLogger{}
+	authorizer, err := authz.NewAuthorizer(path, fake, logger)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- authorizer.Run(ctx, time.Minute) }()
+
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond)
+	writeMatrix(t, path, "methods: [")
+	fake.Advance(time.Minute)
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Len(t, logger.errors, 1)
+	assert.Contains(t, logger.errors[0], "Kept the current authorization matrix")
+}
+
+func TestNewAuthorizer_MissingFile(t *testing.T) {
+	_, err := authz.NewAuthorizer(filepath.Join(t.TempDir(), "missing.yaml"), nil, nil)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestExampleMatrix(t *testing.T) {
+	matrix, err := authz.LoadFile("../../docs/authorization.example.yaml")
+	require.NoError(t, err)
+	assert.NoError(t, matrix.Validate([]string{
+		"/auth.AuthService/Login",
+		"/auth.AuthService/RevokeRefreshToken",
+		"/auth.AuthService/ChangePassword",
+		"/user.UserService/ListUsers",
+		"/user.v2.UserService/UpdateUserPreferences",
+		"/admin.DeadLetterQueueService/RetryFailedEvent",
+		"/grpc.health.v1.Health/Check",
+		"/grpc.health.v1.Health/Watch",
+		"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+		"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+	}))
+}
@@ -0,0 +1,144 @@
+package authz
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is what a caller needs to call a gRPC method. Public methods are served to anonymous
+// callers; the others require a valid token carrying any of Roles and all of Scopes.
+type Policy struct {
+	Public        bool     `yaml:"public" json:"public"`
+	Authenticated bool     `yaml:"authenticated" json:"authenticated"` // Any valid token, when neither roles nor scopes are required
+	Roles         []string `yaml:"roles" json:"roles,omitempty"`
+	Scopes        []string `yaml:"scopes" json:"scopes,omitempty"`
+}
+
+// validate checks the policy declares exactly one kind of access
+func (p Policy) validate() error {
+	restricted := p.Authenticated || len(p.Roles) > 0 || len(p.Scopes) > 0
+	switch {
+	case p.Public && restricted:
+		return fmt.Errorf("public policies cannot require authentication, roles or scopes")
+	case !p.Public && !restricted:
+		return fmt.Errorf("policy must be public, authenticated or require roles or scopes")
+	}
+	return nil
+}
+
+// AdminRole is the role of the admins of the service
+const AdminRole = "admin"
+
+// AdminPolicy grants admin rights, e.g. to act on the resources of any user rather than only on
+// the caller's own
+var AdminPolicy = Policy{Roles: []string{AdminRole}}
+
+// Allows reports whether a caller with roles and scopes satisfies the policy
+func (p Policy) Allows(roles, scopes []string) bool {
+	if p.Public {
+		return true
+	}
+	if len(p.Roles) > 0 && !slices.ContainsFunc(p.Roles, func(role string) bool { return slices.Contains(roles, role) }) {
+		return false
+	}
+	for _, scope := range p.Scopes {
+		if !slices.Contains(scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matrix maps gRPC methods to their policy. Rules name a full method, e.g.
+// /user.v2.UserService/DeleteUser, or every method of a service, e.g. /user.v2.UserService/*;
+// full method rules take precedence.
+type Matrix struct {
+	rules map[string]Policy
+}
+
+// Parse parses a YAML authorization matrix:
+//
+//	methods:
+//	  /auth.AuthService/Login:
+//	    public: true
+//	  /user.v2.UserService/*:
+//	    roles: [user, admin]
+//	  /user.v2.UserService/DeleteUser:
+//	    roles: [admin]
+//	    scopes: [users:delete]
+func Parse(data []byte) (*Matrix, error) {
+	var document struct {
+		Methods map[string]Policy `yaml:"methods"`
+	}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse authorization matrix: %w", err)
+	}
+	if len(document.Methods) == 0 {
+		return nil, fmt.Errorf("authorization matrix declares no methods")
+	}
+
+	for rule, policy := range document.Methods {
+		if err := validateRule(rule); err != nil {
+			return nil, err
+		}
+		if err := policy.validate(); err != nil {
+			return nil, fmt.Errorf("policy of %s: %w", rule, err)
+		}
+	}
+	return &Matrix{rules: document.Methods}, nil
+}
+
+// validateRule checks a rule names a full method or every method of a service
+func validateRule(rule string) error {
+	service, method, ok := strings.Cut(strings.TrimPrefix(rule, "/"), "/")
+	if !strings.HasPrefix(rule, "/") || !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return fmt.Errorf("invalid method %q, expected /package.Service/Method or /package.Service/*", rule)
+	}
+	if strings.Contains(method, "*") && method != "*" {
+		return fmt.Errorf("invalid method %q, only whole services can be matched with *", rule)
+	}
+	return nil
+}
+
+// LoadFile loads the authorization matrix of a YAML file
+func LoadFile(path string) (*Matrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authorization matrix: %w", err)
+	}
+	return Parse(data)
+}
+
+// PolicyOf returns the policy of a full gRPC method and the rule it comes from
+func (m *Matrix) PolicyOf(method string) (Policy, string, bool) {
+	if policy, ok := m.rules[method]; ok {
+		return policy, method, true
+	}
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		rule := method[:i] + "/*"
+		if policy, ok := m.rules[rule]; ok {
+			return policy, rule, true
+		}
+	}
+	return Policy{}, "", false
+}
+
+// Validate fails when one of methods has no policy, listing every such method
+func (m *Matrix) Validate(methods []string) error {
+	var missing []string
+	for _, method := range methods {
+		if _, _, ok := m.PolicyOf(method); !ok {
+			missing = append(missing, method)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("no authorization policy for %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
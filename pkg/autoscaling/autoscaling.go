@@ -0,0 +1,202 @@
+package autoscaling
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// LagSource provides the consumer backlog used for scaling decisions
+type LagSource interface {
+	// ConsumerLag returns the outstanding message count per topic
+	ConsumerLag() map[string]int64
+	// QueueDepth returns the number of messages buffered in-process
+	QueueDepth() int
+}
+
+// Policy holds the thresholds used to compute replica hints
+type Policy struct {
+	LagPerReplica        int64         `json:"lag_per_replica"`         // Acceptable lag handled by a single replica
+	QueueDepthPerReplica int           `json:"queue_depth_per_replica"` // Acceptable in-process backlog per replica
+	MinReplicas          int           `json:"min_replicas"`
+	MaxReplicas          int           `json:"max_replicas"`
+	RefreshInterval      time.Duration `json:"refresh_interval"`
+}
+
+// DefaultPolicy returns default scaling policy
+func DefaultPolicy() Policy {
+	return Policy{
+		LagPerReplica:        1000,
+		QueueDepthPerReplica: 100,
+		MinReplicas:          1,
+		MaxReplicas:          10,
+		RefreshInterval:      15 * time.Second,
+	}
+}
+
+// Hint is the replica recommendation returned to autoscalers
+type Hint struct {
+	ConsumerGroup   string           `json:"consumer_group"`
+	TotalLag        int64            `json:"total_lag"`
+	TopicLag        map[string]int64 `json:"topic_lag"`
+	QueueDepth      int              `json:"queue_depth"`
+	DesiredReplicas int              `json:"desired_replicas"`
+	MinReplicas     int              `json:"min_replicas"`
+	MaxReplicas     int              `json:"max_replicas"`
+	Timestamp       time.Time        `json:"timestamp"`
+}
+
+// Exporter publishes lag and queue depth as Prometheus gauges.
+// Metric names follow the Prometheus adapter convention so they can be
+// consumed as external metrics by KEDA or the HPA without relabeling.
+type Exporter struct {
+	mu            sync.RWMutex
+	source        LagSource
+	consumerGroup string
+	policy        Policy
+	last          Hint
+
+	lag             *prometheus.GaugeVec
+	queueDepth      *prometheus.GaugeVec
+	desiredReplicas *prometheus.GaugeVec
+}
+
+// NewExporter creates a new autoscaling exporter.
+// A nil registerer registers the metrics with the default Prometheus registry.
+func NewExporter(source LagSource, consumerGroup string, policy Policy, registerer prometheus.Registerer) *Exporter {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	factory := promauto.With(registerer)
+
+	return &Exporter{
+		source:        source,
+		consumerGroup: consumerGroup,
+		policy:        policy,
+		lag: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "consumer_group_lag_messages",
+				Help: "Number of messages not yet consumed by the consumer group",
+			},
+			[]string{"consumer_group", "topic"},
+		),
+		queueDepth: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "consumer_queue_depth_messages",
+				Help: "Number of messages buffered in the consumer worker queue",
+			},
+			[]string{"consumer_group"},
+		),
+		desiredReplicas: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "consumer_desired_replicas",
+				Help: "Replica count recommended for the consumer group based on lag thresholds",
+			},
+			[]string{"consumer_group"},
+		),
+	}
+}
+
+// Refresh samples the lag source and updates gauges and the cached hint
+func (e *Exporter) Refresh() Hint {
+	topicLag := e.source.ConsumerLag()
+	queueDepth := e.source.QueueDepth()
+
+	var totalLag int64
+	for topic, lag := range topicLag {
+		totalLag += lag
+		e.lag.WithLabelValues(e.consumerGroup, topic).Set(float64(lag))
+	}
+
+	desired := DesiredReplicas(totalLag, queueDepth, e.policy)
+	e.queueDepth.WithLabelValues(e.consumerGroup).Set(float64(queueDepth))
+	e.desiredReplicas.WithLabelValues(e.consumerGroup).Set(float64(desired))
+
+	hint := Hint{
+		ConsumerGroup:   e.consumerGroup,
+		TotalLag:        totalLag,
+		TopicLag:        topicLag,
+		QueueDepth:      queueDepth,
+		DesiredReplicas: desired,
+		MinReplicas:     e.policy.MinReplicas,
+		MaxReplicas:     e.policy.MaxReplicas,
+		Timestamp:       time.Now(),
+	}
+
+	e.mu.Lock()
+	e.last = hint
+	e.mu.Unlock()
+
+	return hint
+}
+
+// Run refreshes the exporter periodically until the context is cancelled
+func (e *Exporter) Run(ctx context.Context) {
+	interval := e.policy.RefreshInterval
+	if interval <= 0 {
+		interval = DefaultPolicy().RefreshInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	e.Refresh()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Refresh()
+		}
+	}
+}
+
+// LastHint returns the most recently computed hint
+func (e *Exporter) LastHint() Hint {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.last
+}
+
+// HTTPHandler returns an HTTP handler serving the current replica hint
+func (e *Exporter) HTTPHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hint := e.Refresh()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(hint)
+	}
+}
+
+// DesiredReplicas computes the replica count needed to keep lag and queue
+// depth under the policy thresholds, clamped to the min/max bounds
+func DesiredReplicas(totalLag int64, queueDepth int, policy Policy) int {
+	desired := 0
+
+	if policy.LagPerReplica > 0 {
+		desired = int(math.Ceil(float64(totalLag) / float64(policy.LagPerReplica)))
+	}
+
+	if policy.QueueDepthPerReplica > 0 {
+		byQueue := int(math.Ceil(float64(queueDepth) / float64(policy.QueueDepthPerReplica)))
+		if byQueue > desired {
+			desired = byQueue
+		}
+	}
+
+	if desired < policy.MinReplicas {
+		desired = policy.MinReplicas
+	}
+	if policy.MaxReplicas > 0 && desired > policy.MaxReplicas {
+		desired = policy.MaxReplicas
+	}
+
+	return desired
+}
@@ -0,0 +1,69 @@
+package autoscaling_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/autoscaling"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticLagSource struct {
+	lag   map[string]int64
+	depth int
+}
+
+func (s *staticLagSource) ConsumerLag() map[string]int64 { return s.lag }
+func (s *staticLagSource) QueueDepth() int               { return s.depth }
+
+func TestDesiredReplicas(t *testing.T) {
+	policy := autoscaling.Policy{
+		LagPerReplica:        100,
+		QueueDepthPerReplica: 10,
+		MinReplicas:          1,
+		MaxReplicas:          5,
+	}
+
+	tests := []struct {
+		name       string
+		lag        int64
+		queueDepth int
+		expected   int
+	}{
+		{name: "idle uses min replicas", lag: 0, queueDepth: 0, expected: 1},
+		{name: "lag drives scale", lag: 250, queueDepth: 0, expected: 3},
+		{name: "queue depth drives scale", lag: 50, queueDepth: 35, expected: 4},
+		{name: "clamped to max replicas", lag: 10000, queueDepth: 0, expected: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, autoscaling.DesiredReplicas(tt.lag, tt.queueDepth, policy))
+		})
+	}
+}
+
+func TestExporter_HTTPHandler(t *testing.T) {
+	source := &staticLagSource{
+		lag:   map[string]int64{"user-events": 1500, "product-events": 600},
+		depth: 20,
+	}
+	policy := autoscaling.DefaultPolicy()
+	exporter := autoscaling.NewExporter(source, "user-service", policy, prometheus.NewRegistry())
+
+	rec := httptest.NewRecorder()
+	exporter.HTTPHandler()(rec, httptest.NewRequest(http.MethodGet, "/autoscaling/replicas", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var hint autoscaling.Hint
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &hint))
+	assert.Equal(t, "user-service", hint.ConsumerGroup)
+	assert.Equal(t, int64(2100), hint.TotalLag)
+	assert.Equal(t, 3, hint.DesiredReplicas)
+	assert.Equal(t, hint.DesiredReplicas, exporter.LastHint().DesiredReplicas)
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// BloomFilter is a set answering "definitely absent" or "maybe present" in constant memory:
+// keys added are always reported present, keys never added are reported present with the
+// false positive rate the filter was sized for. Keys cannot be removed.
+type BloomFilter struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	size   uint64 // Number of bits
+	hashes uint64 // Number of bits set per key
+	count  int
+}
+
+// NewBloomFilter creates a filter sized for expectedKeys keys at falsePositiveRate. The rate
+// rises above falsePositiveRate once more keys are added.
+func NewBloomFilter(expectedKeys int, falsePositiveRate float64) *BloomFilter {
+	if expectedKeys < 1 {
+		expectedKeys = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	n := float64(expectedKeys)
+	size := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	size = max(size, 64)
+	hashes := uint64(math.Round(float64(size) / n * math.Ln2))
+	hashes = max(hashes, 1)
+
+	return &BloomFilter{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+	}
+}
+
+// Add adds key to the filter
+func (f *BloomFilter) Add(key string) {
+	h1, h2 := bloomHashes(key)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.size
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.count++
+}
+
+// MayContain returns false when key was never added, true when it may have been
+func (f *BloomFilter) MayContain(key string) bool {
+	h1, h2 := bloomHashes(key)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.size
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of keys added, counting keys added twice twice
+func (f *BloomFilter) Count() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.count
+}
+
+// bloomHashes returns the two hashes of key the bits of the key are derived from. The second is
+// odd so it is never zero.
+func bloomHashes(key string) (uint64, uint64) {
+	a := fnv.New64a()
+	_, _ = a.Write([]byte(key))
+	b := fnv.New64()
+	_, _ = b.Write([]byte(key))
+	return a.Sum64(), b.Sum64() | 1
+}
@@ -0,0 +1,29 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/pkg/cache"
+)
+
+func TestBloomFilter(t *testing.T) {
+	filter := cache.NewBloomFilter(1000, 0.01)
+	for i := range 1000 {
+		filter.Add(fmt.Sprintf("user%d@example.com", i))
+	}
+
+	for i := range 1000 {
+		assert.True(t, filter.MayContain(fmt.Sprintf("user%d@example.com", i)), "keys added are never ruled out")
+	}
+	falsePositives := 0
+	for i := range 10000 {
+		if filter.MayContain(fmt.Sprintf("missing%d@example.com", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 300, "about one percent of the keys never added are reported present")
+	assert.Equal(t, 1000, filter.Count())
+}
@@ -0,0 +1,161 @@
+package cache
+
+import "sync"
+
+// Indexer maintains the entries of an index as the indexed records change
+type Indexer interface {
+	Put(id, key string)
+	Remove(id string)
+}
+
+// indexChange is a change made to an index while it is rebuilt, replayed on the rebuilt index
+type indexChange struct {
+	id, key string
+	removed bool
+}
+
+// KeyIndex maps lookup keys (e.g. emails) to the ids of the records holding them, so records can
+// be read by id, and keeps a bloom filter of every key indexed so lookups of keys no record holds
+// are answered without reading the records at all. Until it is first rebuilt from the records
+// the index does not know every key: MayExist answers true for every key.
+type KeyIndex struct {
+	mu                sync.RWMutex
+	ids               map[string]string // key -> id
+	keys              map[string]string // id -> key
+	filter            *BloomFilter
+	expectedKeys      int
+	falsePositiveRate float64
+	maxEntries        int
+	warm              bool
+	changes           []indexChange // Non nil while the index is rebuilt
+}
+
+// NewKeyIndex creates an empty index whose bloom filter is sized for expectedKeys keys at
+// falsePositiveRate, mapping at most maxEntries keys to ids (unbounded when zero). Keys past
+// maxEntries are still added to the bloom filter.
+func NewKeyIndex(expectedKeys int, falsePositiveRate float64, maxEntries int) *KeyIndex {
+	return &KeyIndex{
+		ids:               make(map[string]string),
+		keys:              make(map[string]string),
+		filter:            NewBloomFilter(expectedKeys, falsePositiveRate),
+		expectedKeys:      expectedKeys,
+		falsePositiveRate: falsePositiveRate,
+		maxEntries:        maxEntries,
+	}
+}
+
+// Put indexes key as the key of the record id, replacing the previous key of the record
+func (x *KeyIndex) Put(id, key string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.put(id, key)
+	if x.changes != nil {
+		x.changes = append(x.changes, indexChange{id: id, key: key})
+	}
+}
+
+// Remove removes the key of the record id. The bloom filter keeps the key, so its lookups go
+// on reading the records until the index is rebuilt.
+func (x *KeyIndex) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+	if x.changes != nil {
+		x.changes = append(x.changes, indexChange{id: id, removed: true})
+	}
+}
+
+// Lookup returns the id of the record holding key
+func (x *KeyIndex) Lookup(key string) (string, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	id, ok := x.ids[key]
+	return id, ok
+}
+
+// MayExist returns false when no record holds key, true when one may
+func (x *KeyIndex) MayExist(key string) bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return !x.warm || x.filter.MayContain(key)
+}
+
+// Warm returns whether the index was rebuilt from the records, i.e. whether MayExist rules
+// keys out
+func (x *KeyIndex) Warm() bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.warm
+}
+
+// Size returns the number of keys mapped to ids
+func (x *KeyIndex) Size() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.ids)
+}
+
+// Rebuild replaces the index with the keys load puts, dropping the keys of removed records from
+// the bloom filter. Changes made to the index while load runs are kept. Rebuilds must not run
+// concurrently.
+func (x *KeyIndex) Rebuild(load func(put func(id, key string)) error) error {
+	x.mu.Lock()
+	x.changes = []indexChange{}
+	x.mu.Unlock()
+
+	rebuilt := NewKeyIndex(x.expectedKeys, x.falsePositiveRate, x.maxEntries)
+	err := load(rebuilt.put)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	changes := x.changes
+	x.changes = nil
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if change.removed {
+			rebuilt.remove(change.id)
+		} else {
+			rebuilt.put(change.id, change.key)
+		}
+	}
+	x.ids, x.keys, x.filter = rebuilt.ids, rebuilt.keys, rebuilt.filter
+	x.warm = true
+	return nil
+}
+
+// put indexes key without locking the index
+func (x *KeyIndex) put(id, key string) {
+	x.filter.Add(key)
+	if previous, ok := x.keys[id]; ok {
+		if previous == key {
+			return
+		}
+		delete(x.ids, previous)
+		delete(x.keys, id)
+	}
+	if holder, ok := x.ids[key]; ok {
+		// The key moved to another record, e.g. the email of a deleted user was registered again
+		delete(x.keys, holder)
+		delete(x.ids, key)
+	}
+	if x.maxEntries > 0 && len(x.ids) >= x.maxEntries {
+		return
+	}
+	x.ids[key] = id
+	x.keys[id] = key
+}
+
+// remove removes the key of a record without locking the index
+func (x *KeyIndex) remove(id string) {
+	if key, ok := x.keys[id]; ok {
+		delete(x.ids, key)
+		delete(x.keys, id)
+	}
+}
@@ -0,0 +1,96 @@
+package cache_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/cache"
+)
+
+func TestKeyIndex_MayExistOnceWarm(t *testing.T) {
+	index := cache.NewKeyIndex(100, 0.01, 0)
+	assert.True(t, index.MayExist("nobody@example.com"), "a cold index rules no key out")
+
+	require.NoError(t, index.Rebuild(func(put func(id, key string)) error {
+		put("user-1", "alice@example.com")
+		return nil
+	}))
+	assert.True(t, index.Warm())
+	assert.True(t, index.MayExist("alice@example.com"))
+	assert.False(t, index.MayExist("nobody@example.com"))
+
+	index.Put("user-2", "bob@example.com")
+	assert.True(t, index.MayExist("bob@example.com"))
+	id, ok := index.Lookup("bob@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "user-2", id)
+}
+
+func TestKeyIndex_PutAndRemove(t *testing.T) {
+	index := cache.NewKeyIndex(100, 0.01, 0)
+	index.Put("user-1", "alice@example.com")
+	index.Put("user-1", "alice@example.org")
+
+	_, ok := index.Lookup("alice@example.com")
+	assert.False(t, ok, "the previous key of a record is unmapped")
+	id, _ := index.Lookup("alice@example.org")
+	assert.Equal(t, "user-1", id)
+
+	index.Remove("user-1")
+	_, ok = index.Lookup("alice@example.org")
+	assert.False(t, ok)
+
+	index.Put("user-2", "alice@example.org")
+	index.Remove("user-1")
+	id, _ = index.Lookup("alice@example.org")
+	assert.Equal(t, "user-2", id, "removing the former holder of a key keeps its new holder")
+}
+
+func TestKeyIndex_MaxEntries(t *testing.T) {
+	index := cache.NewKeyIndex(100, 0.01, 1)
+	require.NoError(t, index.Rebuild(func(put func(id, key string)) error {
+		put("user-1", "alice@example.com")
+		put("user-2", "bob@example.com")
+		return nil
+	}))
+
+	assert.Equal(t, 1, index.Size())
+	_, ok := index.Lookup("bob@example.com")
+	assert.False(t, ok)
+	assert.True(t, index.MayExist("bob@example.com"), "keys past the limit are still in the bloom filter")
+}
+
+func TestKeyIndex_RebuildKeepsConcurrentChanges(t *testing.T) {
+	index := cache.NewKeyIndex(100, 0.01, 0)
+	index.Put("user-1", "alice@example.com")
+
+	require.NoError(t, index.Rebuild(func(put func(id, key string)) error {
+		put("user-1", "alice@example.com")
+		// Changes consumed while the records are listed
+		index.Put("user-2", "bob@example.com")
+		index.Remove("user-1")
+		return nil
+	}))
+
+	_, ok := index.Lookup("alice@example.com")
+	assert.False(t, ok)
+	id, _ := index.Lookup("bob@example.com")
+	assert.Equal(t, "user-2", id)
+	assert.True(t, index.MayExist("bob@example.com"))
+}
+
+func TestKeyIndex_FailedRebuildKeepsIndex(t *testing.T) {
+	index := cache.NewKeyIndex(100, 0.01, 0)
+	index.Put("user-1", "alice@example.com")
+
+	err := index.Rebuild(func(put func(id, key string)) error {
+		return errors.New("database unavailable")
+	})
+	assert.Error(t, err)
+	assert.False(t, index.Warm())
+	id, _ := index.Lookup("alice@example.com")
+	assert.Equal(t, "user-1", id)
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Invalidator drops cached entries by tag
+type Invalidator interface {
+	InvalidateTag(tag string) int
+}
+
+// taggedEntry is a cached value with its expiration and tags
+type taggedEntry struct {
+	value      interface{}
+	expiration time.Time
+	tags       []string
+}
+
+// TaggedCache is an in-memory cache whose entries carry tags, so all entries derived from
+// the same data (e.g. every cached user read) can be invalidated together when it changes
+type TaggedCache struct {
+	mu         sync.Mutex
+	entries    map[string]*taggedEntry
+	tags       map[string]map[string]struct{} // tag -> keys
+	maxEntries int
+	clock      clock.Clock
+}
+
+// NewTaggedCache creates a tagged cache holding at most maxEntries entries (unbounded when zero).
+// A nil clock uses the system clock.
+func NewTaggedCache(maxEntries int, clk clock.Clock) *TaggedCache {
+	return &TaggedCache{
+		entries:    make(map[string]*taggedEntry),
+		tags:       make(map[string]map[string]struct{}),
+		maxEntries: maxEntries,
+		clock:      clock.OrDefault(clk),
+	}
+}
+
+// Get returns the value stored under key if it has not expired
+func (c *TaggedCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.clock.Now().Before(entry.expiration) {
+		c.remove(key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key for ttl with the given tags
+func (c *TaggedCache) Set(key string, value interface{}, ttl time.Duration, tags ...string) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	c.remove(key)
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+
+	c.entries[key] = &taggedEntry{value: value, expiration: now.Add(ttl), tags: tags}
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// Delete removes key from the cache
+func (c *TaggedCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(key)
+}
+
+// InvalidateTag removes all entries carrying tag and returns how many were removed
+func (c *TaggedCache) InvalidateTag(tag string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.tags[tag]
+	removed := len(keys)
+	for key := range keys {
+		c.remove(key)
+	}
+	return removed
+}
+
+// Clear removes all entries and returns how many were removed
+func (c *TaggedCache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := len(c.entries)
+	c.entries = make(map[string]*taggedEntry)
+	c.tags = make(map[string]map[string]struct{})
+	return removed
+}
+
+// Size returns the number of cached entries, including expired ones not yet removed
+func (c *TaggedCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// remove deletes key and its tag references
+func (c *TaggedCache) remove(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+	for _, tag := range entry.tags {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// evict removes expired entries, or an arbitrary entry when none have expired
+func (c *TaggedCache) evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiration) {
+			c.remove(key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for key := range c.entries {
+		c.remove(key)
+		return
+	}
+}
@@ -0,0 +1,74 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+func TestTaggedCache_Expiration(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	c := cache.NewTaggedCache(0, fake)
+
+	c.Set("user:1", "alice", time.Minute, "users")
+	value, ok := c.Get("user:1")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", value)
+
+	fake.Advance(time.Minute)
+	_, ok = c.Get("user:1")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Size())
+}
+
+func TestTaggedCache_InvalidateTag(t *testing.T) {
+	c := cache.NewTaggedCache(0, nil)
+	c.Set("user:1", "alice", time.Minute, "users")
+	c.Set("users:list", []string{"alice"}, time.Minute, "users", "lists")
+	c.Set("product:1", "book", time.Minute, "products")
+
+	assert.Equal(t, 2, c.InvalidateTag("users"))
+	_, ok := c.Get("user:1")
+	assert.False(t, ok)
+	_, ok = c.Get("users:list")
+	assert.False(t, ok)
+	_, ok = c.Get("product:1")
+	assert.True(t, ok)
+
+	// The removed entry no longer belongs to its other tags
+	assert.Equal(t, 0, c.InvalidateTag("lists"))
+}
+
+func TestTaggedCache_Clear(t *testing.T) {
+	c := cache.NewTaggedCache(0, nil)
+	c.Set("user:1", "alice", time.Minute, "users")
+	c.Set("product:1", "book", time.Minute, "products")
+
+	assert.Equal(t, 2, c.Clear())
+	assert.Equal(t, 0, c.Size())
+	assert.Equal(t, 0, c.InvalidateTag("users"))
+}
+
+func TestTaggedCache_MaxEntries(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	c := cache.NewTaggedCache(2, fake)
+
+	c.Set("a", 1, time.Second)
+	c.Set("b", 2, time.Minute)
+	fake.Advance(time.Second)
+
+	// Expired entries are evicted first
+	c.Set("c", 3, time.Minute)
+	assert.Equal(t, 2, c.Size())
+	_, ok := c.Get("b")
+	assert.True(t, ok)
+
+	c.Set("d", 4, time.Minute)
+	assert.Equal(t, 2, c.Size())
+	_, ok = c.Get("d")
+	assert.True(t, ok)
+}
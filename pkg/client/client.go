@@ -0,0 +1,261 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Rate limit headers of the gateway, see pkg/middleware
+const (
+	rateLimitRemainingHeader = "X-RateLimit-Remaining" // Requests left in the current window
+	rateLimitResetHeader     = "X-RateLimit-Reset"     // Seconds until the window resets
+	retryAfterHeader         = "Retry-After"           // Seconds to wait before retrying
+)
+
+// Config configures a client
+type Config struct {
+	BaseURL         string       // Gateway URL, e.g. http://localhost:8080
+	Token           string       // Bearer token of the logged in user
+	ChangefeedToken string       // Bearer token of the user changefeed, defaults to Token
+	HTTPClient      *http.Client // nil uses http.DefaultClient
+	Retry           RetryPolicy  // Zero value uses DefaultRetryPolicy
+	Clock           clock.Clock  // nil uses the system clock
+}
+
+// RetryPolicy controls how requests failing with a transient error are retried
+type RetryPolicy struct {
+	MaxAttempts    int           // Attempts per request including the first, 1 disables retries
+	InitialBackoff time.Duration // Wait before the first retry, doubled after every retry
+	MaxBackoff     time.Duration // Longest wait between attempts, including waits asked for by the server
+}
+
+// DefaultRetryPolicy returns the retry policy of clients without one
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// retryableStatus are the statuses of transient errors worth retrying
+var retryableStatus = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// APIError is a non 2xx gateway response
+type APIError struct {
+	StatusCode int
+	Code       string        // Error code of the response body, e.g. NOT_FOUND
+	Message    string        // Error message of the response body
+	RetryAfter time.Duration // Wait asked for by the server before retrying, 0 when not set
+}
+
+// Error describes the response
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("gateway returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("gateway returned %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Retryable reports whether the error is transient
+func (e *APIError) Retryable() bool {
+	return retryableStatus[e.StatusCode]
+}
+
+// Client calls the HTTP gateway. Requests failing with a transient error are retried with
+// exponential backoff, and requests are held back while the rate limit of the client is
+// exhausted. A client is safe for concurrent use.
+type Client struct {
+	baseURL         string
+	token           string
+	changefeedToken string
+	http            *http.Client
+	retry           RetryPolicy
+	clock           clock.Clock
+
+	mu          sync.Mutex
+	resumeAfter time.Time // Requests wait until then once the rate limit is exhausted
+}
+
+// New creates a client
+func New(cfg Config) *Client {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	retry := cfg.Retry
+	if retry.MaxAttempts <= 0 {
+		retry = DefaultRetryPolicy()
+	}
+	changefeedToken := cfg.ChangefeedToken
+	if changefeedToken == "" {
+		changefeedToken = cfg.Token
+	}
+
+	return &Client{
+		baseURL:         strings.TrimRight(cfg.BaseURL, "/"),
+		token:           cfg.Token,
+		changefeedToken: changefeedToken,
+		http:            httpClient,
+		retry:           retry,
+		clock:           clock.OrDefault(cfg.Clock),
+	}
+}
+
+// get sends a GET request, retrying transient errors, and returns the response body
+func (c *Client) get(ctx context.Context, path string, query url.Values, token string) ([]byte, error) {
+	backoff := c.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		body, err := c.send(ctx, path, query, token)
+		if err == nil {
+			return body, nil
+		}
+		if attempt >= c.retry.MaxAttempts || !retryable(ctx, err) {
+			return nil, err
+		}
+
+		wait := backoff
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			wait = apiErr.RetryAfter
+		}
+		if err := c.sleep(ctx, min(wait, c.retry.MaxBackoff)); err != nil {
+			return nil, err
+		}
+		backoff = min(backoff*2, c.retry.MaxBackoff)
+	}
+}
+
+// send sends a GET request once the rate limit allows it
+func (c *Client) send(ctx context.Context, path string, query url.Values, token string) ([]byte, error) {
+	c.mu.Lock()
+	wait := c.resumeAfter.Sub(c.clock.Now())
+	c.mu.Unlock()
+	if wait > 0 {
+		if err := c.sleep(ctx, min(wait, c.retry.MaxBackoff)); err != nil {
+			return nil, err
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	c.observeRateLimit(resp.Header)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s failed: %w", path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newAPIError(resp, body)
+	}
+	return body, nil
+}
+
+// observeRateLimit holds requests back until the rate limit window resets once no request is
+// left in it
+func (c *Client) observeRateLimit(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get(rateLimitRemainingHeader))
+	if err != nil || remaining > 0 {
+		return
+	}
+	reset, ok := parseSeconds(header.Get(rateLimitResetHeader))
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if resumeAfter := c.clock.Now().Add(reset); resumeAfter.After(c.resumeAfter) {
+		c.resumeAfter = resumeAfter
+	}
+}
+
+// sleep waits for d or until ctx is done
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clock.After(d):
+		return nil
+	}
+}
+
+// retryable reports whether a failed request is worth retrying: transient statuses and
+// transport errors, unless the request was cancelled
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	return true
+}
+
+// newAPIError decodes the error of a non 2xx response. The gateway and the HTTP handlers both
+// answer with a code and a message, the gateway code being a number.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var payload struct {
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		apiErr.Message = payload.Message
+		apiErr.Code = strings.Trim(string(payload.Code), `"`)
+	}
+
+	if wait, ok := parseSeconds(resp.Header.Get(retryAfterHeader)); ok {
+		apiErr.RetryAfter = wait
+	} else if resp.StatusCode == http.StatusTooManyRequests {
+		apiErr.RetryAfter, _ = parseSeconds(resp.Header.Get(rateLimitResetHeader))
+	}
+	return apiErr
+}
+
+// parseSeconds parses a header value counting seconds
+func parseSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,211 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/client"
+	"go-clean-ddd-es-template/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetry retries without waiting noticeably
+var fastRetry = client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+// usersServer serves total users in pages as the gateway does
+func usersServer(t *testing.T, total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/users", r.URL.Path)
+		assert.Equal(t, "Bearer user-token", r.Header.Get("Authorization"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+		users := []map[string]string{}
+		for i := (page - 1) * pageSize; i < page*pageSize && i < total; i++ {
+			users = append(users, map[string]string{"id": fmt.Sprintf("user-%d", i), "email": fmt.Sprintf("user-%d@example.com", i)})
+		}
+		// The gateway encodes int64 as strings and uses camel case names
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"users":    users,
+			"total":    strconv.Itoa(total),
+			"page":     page,
+			"pageSize": pageSize,
+		})
+	}))
+}
+
+func TestClient_Users(t *testing.T) {
+	server := usersServer(t, 7)
+	defer server.Close()
+
+	c := client.New(client.Config{BaseURL: server.URL, Token: "user-token"})
+
+	var ids []string
+	for user, err := range c.Users(context.Background(), 3) {
+		require.NoError(t, err)
+		ids = append(ids, user.Id)
+	}
+	assert.Equal(t, []string{"user-0", "user-1", "user-2", "user-3", "user-4", "user-5", "user-6"}, ids)
+}
+
+func TestClient_Users_Break(t *testing.T) {
+	var requests atomic.Int32
+	server := usersServer(t, 10)
+	defer server.Close()
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer counting.Close()
+
+	c := client.New(client.Config{BaseURL: counting.URL, Token: "user-token"})
+
+	seen := 0
+	for _, err := range c.Users(context.Background(), 2) {
+		require.NoError(t, err)
+		if seen++; seen == 3 {
+			break
+		}
+	}
+	assert.Equal(t, int32(2), requests.Load(), "no page is fetched after breaking out of the loop")
+}
+
+func TestClient_RetriesTransientErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"changes": []map[string]interface{}{{"sequence": 1, "user_id": "user-1"}}})
+	}))
+	defer server.Close()
+
+	c := client.New(client.Config{BaseURL: server.URL, Retry: fastRetry})
+
+	var changes []client.UserChange
+	for change, err := range c.UserChanges(context.Background(), nil, 0) {
+		require.NoError(t, err)
+		changes = append(changes, change)
+	}
+	assert.Len(t, changes, 1)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestClient_GivesUpAfterMaxAttempts(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := client.New(client.Config{BaseURL: server.URL, Retry: fastRetry})
+
+	for _, err := range c.UserChanges(context.Background(), nil, 0) {
+		var apiErr *client.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	}
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"code": "UNAUTHORIZED", "message": "a valid changefeed token is required"})
+	}))
+	defer server.Close()
+
+	c := client.New(client.Config{BaseURL: server.URL, Retry: fastRetry})
+
+	var apiErr *client.APIError
+	for _, err := range c.UserChanges(context.Background(), nil, 0) {
+		require.ErrorAs(t, err, &apiErr)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, "UNAUTHORIZED", apiErr.Code)
+	assert.Equal(t, "a valid changefeed token is required", apiErr.Message)
+}
+
+func TestClient_HonorsRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "20")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"changes": []interface{}{}})
+	}))
+	defer server.Close()
+
+	clk := clock.NewFake(time.Unix(0, 0))
+	c := client.New(client.Config{
+		BaseURL: server.URL,
+		Retry:   client.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Second, MaxBackoff: time.Minute},
+		Clock:   clk,
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		for _, err := range c.UserChanges(context.Background(), nil, 0) {
+			done <- err
+			return
+		}
+		done <- nil
+	}()
+
+	require.Eventually(t, func() bool { return clk.Waiters() == 1 }, time.Second, time.Millisecond)
+	clk.Advance(19 * time.Second)
+	assert.Equal(t, int32(1), requests.Load(), "the retry waits for the rate limit window to reset")
+	clk.Advance(time.Second)
+
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestClient_UserChangesFollowsCursor(t *testing.T) {
+	pages := map[string]map[string]interface{}{
+		"": {
+			"changes":     []map[string]interface{}{{"sequence": 1, "user_id": "user-1", "operation": "created"}, {"sequence": 2, "user_id": "user-2", "operation": "created"}},
+			"next_cursor": "c2",
+			"has_more":    true,
+		},
+		"c2": {
+			"changes":     []map[string]interface{}{{"sequence": 3, "user_id": "user-1", "operation": "deleted"}},
+			"next_cursor": "c3",
+			"has_more":    false,
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users/changes", r.URL.Path)
+		assert.Equal(t, "Bearer changefeed-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+		json.NewEncoder(w).Encode(pages[r.URL.Query().Get("cursor")])
+	}))
+	defer server.Close()
+
+	c := client.New(client.Config{BaseURL: server.URL, Token: "user-token", ChangefeedToken: "changefeed-token"})
+
+	cursor := ""
+	var sequences []int64
+	for change, err := range c.UserChanges(context.Background(), &cursor, 2) {
+		require.NoError(t, err)
+		sequences = append(sequences, change.Sequence)
+	}
+	assert.Equal(t, []int64{1, 2, 3}, sequences)
+	assert.Equal(t, "c3", cursor, "the cursor is advanced to resume polling")
+}
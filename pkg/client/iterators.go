@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+
+	userv2 "go-clean-ddd-es-template/proto/user/v2"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// UserChange is an entry of the user changefeed
+type UserChange struct {
+	Sequence   int64  `json:"sequence"`
+	UserID     string `json:"user_id"`
+	Operation  string `json:"operation"` // created, updated or deleted
+	Email      string `json:"email,omitempty"`
+	Name       string `json:"name,omitempty"`
+	OccurredAt string `json:"occurred_at"`
+}
+
+// userChangesPage is a response of the user changefeed
+type userChangesPage struct {
+	Changes    []UserChange `json:"changes"`
+	NextCursor string       `json:"next_cursor"`
+	HasMore    bool         `json:"has_more"`
+}
+
+// Users iterates over all users, fetching pageSize users per request, or the server default
+// when pageSize is 0. Pages are numbered, so users created or deleted while iterating may be
+// skipped or seen twice. Iteration stops at the first error, yielded with a nil user.
+//
+//	for user, err := range c.Users(ctx, 100) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) Users(ctx context.Context, pageSize int) iter.Seq2[*userv2.User, error] {
+	return func(yield func(*userv2.User, error) bool) {
+		for page := 1; ; page++ {
+			query := url.Values{"page": {strconv.Itoa(page)}}
+			if pageSize > 0 {
+				query.Set("page_size", strconv.Itoa(pageSize))
+			}
+
+			body, err := c.get(ctx, "/api/v2/users", query, c.token)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			var resp userv2.ListUsersResponse
+			if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, &resp); err != nil {
+				yield(nil, fmt.Errorf("failed to decode users page %d: %w", page, err))
+				return
+			}
+
+			for _, user := range resp.Users {
+				if !yield(user, nil) {
+					return
+				}
+			}
+			if len(resp.Users) == 0 || int64(page)*int64(resp.PageSize) >= resp.Total {
+				return
+			}
+		}
+	}
+}
+
+// UserChanges iterates over the user changefeed from cursor, an empty cursor reading from the
+// first change, fetching up to limit changes per request, or the server default when limit is
+// 0. Iteration stops once the changes recorded so far are read; poll again later with the
+// cursor to read newer ones. Iteration stops at the first error, yielded with a zero change.
+//
+// When cursor is not nil it is advanced past every page whose changes were all yielded, so
+// breaking out of the loop and resuming from it reads the remaining changes of the page again.
+func (c *Client) UserChanges(ctx context.Context, cursor *string, limit int) iter.Seq2[UserChange, error] {
+	return func(yield func(UserChange, error) bool) {
+		var next string
+		if cursor != nil {
+			next = *cursor
+		}
+
+		for {
+			query := url.Values{}
+			if next != "" {
+				query.Set("cursor", next)
+			}
+			if limit > 0 {
+				query.Set("limit", strconv.Itoa(limit))
+			}
+
+			body, err := c.get(ctx, "/api/v1/users/changes", query, c.changefeedToken)
+			if err != nil {
+				yield(UserChange{}, err)
+				return
+			}
+			var page userChangesPage
+			if err := json.Unmarshal(body, &page); err != nil {
+				yield(UserChange{}, fmt.Errorf("failed to decode user changes: %w", err))
+				return
+			}
+
+			for _, change := range page.Changes {
+				if !yield(change, nil) {
+					return
+				}
+			}
+			next = page.NextCursor
+			if cursor != nil {
+				*cursor = next
+			}
+			if !page.HasMore {
+				return
+			}
+		}
+	}
+}
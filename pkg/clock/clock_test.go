@@ -0,0 +1,69 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+var start = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+func TestFake_NowAndAdvance(t *testing.T) {
+	fake := clock.NewFake(start)
+	assert.Equal(t, start, fake.Now())
+
+	fake.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), fake.Now())
+	assert.Equal(t, time.Minute, fake.Since(start))
+
+	fake.Set(start)
+	assert.Equal(t, start, fake.Now())
+}
+
+func TestFake_After(t *testing.T) {
+	fake := clock.NewFake(start)
+
+	short := fake.After(time.Second)
+	long := fake.After(time.Hour)
+	assert.Equal(t, 2, fake.Waiters())
+
+	fake.Advance(time.Second)
+	select {
+	case fired := <-short:
+		assert.Equal(t, start.Add(time.Second), fired)
+	default:
+		t.Fatal("expected short timer to fire")
+	}
+
+	select {
+	case <-long:
+		t.Fatal("long timer fired early")
+	default:
+	}
+	assert.Equal(t, 1, fake.Waiters())
+
+	fake.Advance(time.Hour)
+	<-long
+	assert.Equal(t, 0, fake.Waiters())
+}
+
+func TestFake_AfterNonPositive(t *testing.T) {
+	fake := clock.NewFake(start)
+
+	select {
+	case fired := <-fake.After(0):
+		assert.Equal(t, start, fired)
+	default:
+		t.Fatal("expected immediate fire")
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	fake := clock.NewFake(start)
+	assert.Same(t, fake, clock.OrDefault(fake))
+	assert.NotNil(t, clock.OrDefault(nil))
+	assert.WithinDuration(t, time.Now(), clock.OrDefault(nil).Now(), time.Second)
+}
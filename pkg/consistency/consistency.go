@@ -0,0 +1,78 @@
+package consistency
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Header carries a consistency token: commands return it, queries accept it
+const Header = "X-Consistency-Token"
+
+// MetadataKey is the gRPC metadata key of Header
+const MetadataKey = "x-consistency-token"
+
+// ErrInvalidToken is returned when a consistency token cannot be parsed
+var ErrInvalidToken = errors.New("invalid consistency token")
+
+// Token is the version of an aggregate written by a command. A query given the token reads
+// the aggregate at that version or later, so clients read their own writes.
+type Token struct {
+	AggregateID string
+	Version     int
+}
+
+// String encodes the token for clients, who must treat it as opaque
+func (t Token) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.AggregateID + ":" + strconv.Itoa(t.Version)))
+}
+
+// Parse decodes a token encoded by Token.String
+func Parse(value string) (Token, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Token{}, ErrInvalidToken
+	}
+	separator := strings.LastIndex(string(data), ":")
+	if separator <= 0 {
+		return Token{}, ErrInvalidToken
+	}
+	version, err := strconv.Atoi(string(data[separator+1:]))
+	if err != nil || version <= 0 {
+		return Token{}, ErrInvalidToken
+	}
+	return Token{AggregateID: string(data[:separator]), Version: version}, nil
+}
+
+// Wait polls reached every interval until it reports true, maxWait elapses or ctx is done.
+// It returns whether the version was reached; a nil clock uses the system clock.
+func Wait(ctx context.Context, clk clock.Clock, maxWait, interval time.Duration, reached func(ctx context.Context) (bool, error)) (bool, error) {
+	clk = clock.OrDefault(clk)
+	if interval <= 0 {
+		return false, fmt.Errorf("poll interval must be positive, got %s", interval)
+	}
+
+	deadline := clk.Now().Add(maxWait)
+	for {
+		ok, err := reached(ctx)
+		if err != nil || ok {
+			return ok, err
+		}
+
+		remaining := deadline.Sub(clk.Now())
+		if remaining <= 0 {
+			return false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-clk.After(min(interval, remaining)):
+		}
+	}
+}
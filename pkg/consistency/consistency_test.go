@@ -0,0 +1,50 @@
+package consistency_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/consistency"
+)
+
+func TestToken_RoundTrip(t *testing.T) {
+	token := consistency.Token{AggregateID: "user:42", Version: 3}
+
+	parsed, err := consistency.Parse(token.String())
+	require.NoError(t, err)
+	assert.Equal(t, token, parsed)
+
+	for _, invalid := range []string{"", "not base64!", "dXNlcg", "dXNlcjow"} {
+		_, err := consistency.Parse(invalid)
+		assert.ErrorIs(t, err, consistency.ErrInvalidToken, invalid)
+	}
+}
+
+func TestWait(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	reached, err := consistency.Wait(ctx, nil, time.Second, time.Millisecond, func(ctx context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	require.NoError(t, err)
+	assert.True(t, reached)
+	assert.Equal(t, 3, calls)
+
+	reached, err = consistency.Wait(ctx, nil, 5*time.Millisecond, time.Millisecond, func(ctx context.Context) (bool, error) {
+		return false, nil
+	})
+	require.NoError(t, err)
+	assert.False(t, reached)
+
+	_, err = consistency.Wait(ctx, nil, time.Second, time.Millisecond, func(ctx context.Context) (bool, error) {
+		return false, errors.New("read failed")
+	})
+	assert.EqualError(t, err, "read failed")
+}
@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Assignments are the partitions of each topic owned by a consumer instance
+type Assignments map[string][]int32
+
+// copyAssignments returns a copy of assignments with the partitions of each topic sorted
+func copyAssignments(assignments map[string][]int32) Assignments {
+	copied := make(Assignments, len(assignments))
+	for topic, partitions := range assignments {
+		copied[topic] = slices.Sorted(slices.Values(partitions))
+	}
+	return copied
+}
+
+// diffAssignments returns the partitions of next not in prev, and those of prev not in next
+func diffAssignments(prev, next Assignments) (added, revoked Assignments) {
+	return subtractAssignments(next, prev), subtractAssignments(prev, next)
+}
+
+// subtractAssignments returns the partitions of a not in b
+func subtractAssignments(a, b Assignments) Assignments {
+	result := make(Assignments)
+	for topic, partitions := range a {
+		for _, partition := range partitions {
+			if !slices.Contains(b[topic], partition) {
+				result[topic] = append(result[topic], partition)
+			}
+		}
+	}
+	return result
+}
+
+// String formats the assignments as "topic[0 1],other[2]", sorted by topic
+func (a Assignments) String() string {
+	topics := make([]string, 0, len(a))
+	for topic := range a {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	parts := make([]string, len(topics))
+	for i, topic := range topics {
+		parts[i] = fmt.Sprintf("%s%v", topic, a[topic])
+	}
+	return strings.Join(parts, ",")
+}
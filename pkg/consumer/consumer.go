@@ -6,6 +6,8 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"go-clean-ddd-es-template/pkg/kafka"
 )
 
 // Message represents a message from the broker
@@ -15,8 +17,28 @@ type Message struct {
 	Offset    int64
 	Key       []byte
 	Value     []byte
-	Headers   map[string][]byte
+	Headers   kafka.Headers
 	Timestamp time.Time
+
+	ack func(processed bool) // Set by the consumer that delivered the message
+}
+
+// Ack acknowledges the message as processed, letting its offset be committed once every earlier
+// message of its partition is acknowledged too. Handlers of consumers in manual commit mode
+// must acknowledge every message, possibly after returning.
+func (m *Message) Ack() {
+	if m.ack != nil {
+		m.ack(true)
+	}
+}
+
+// Nack reports the message could not be processed. Its offset is not committed, holding back
+// the commits of its partition, so it is consumed again once the consumer restarts or the
+// partition is reassigned. Acknowledging it later releases the commits again.
+func (m *Message) Nack() {
+	if m.ack != nil {
+		m.ack(false)
+	}
 }
 
 // MessageHandler defines how messages should be processed
@@ -37,6 +59,13 @@ type Consumer interface {
 	JoinGroup(groupID string) error
 	LeaveGroup() error
 
+	// Offset management. MarkMessage acknowledges a delivered message like Message.Ack, and
+	// CommitOffset commits the offsets of the acknowledged messages right away instead of
+	// waiting for the next auto commit. In manual commit mode offsets are only committed by
+	// CommitOffset and when the consumer stops or its partitions are reassigned.
+	MarkMessage(message *Message) error
+	CommitOffset(ctx context.Context) error
+
 	// Health and monitoring
 	Health(ctx context.Context) error
 	GetStats(ctx context.Context) (*ConsumerStats, error)
@@ -58,7 +87,7 @@ type GroupConfig struct {
 	Handler            MessageHandler
 	NumConsumers       int
 	WorkerPoolSize     int
-	AutoCommit         bool
+	AutoCommit         bool // Acknowledge messages once handled, false for the manual commit mode
 	AutoCommitInterval time.Duration
 	SessionTimeout     time.Duration
 	HeartbeatInterval  time.Duration
@@ -92,6 +121,12 @@ type ConsumerStats struct {
 	ConsumerLag      map[string]int64 // topic -> lag
 	ActiveConsumers  int
 	IsRunning        bool
+
+	// Partition ownership of consumer group members, empty between generations
+	MemberID     string
+	GenerationID int32
+	Assignments  Assignments
+	AssignedAt   time.Time
 }
 
 // ConsumerManager manages multiple consumers
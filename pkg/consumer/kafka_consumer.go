@@ -8,11 +8,17 @@ import (
 	"time"
 
 	"github.com/IBM/sarama"
+
+	"go-clean-ddd-es-template/pkg/kafka"
+	"go-clean-ddd-es-template/pkg/retry"
 )
 
-// KafkaConsumer implements Consumer interface for Kafka
+// KafkaConsumer implements Consumer interface for Kafka. It consumes every partition of its
+// topics, resuming from the offsets committed for its group.
 type KafkaConsumer struct {
+	client   sarama.Client
 	consumer sarama.Consumer
+	offsets  sarama.OffsetManager
 	groupID  string
 	topics   []string
 	handlers map[string]MessageHandler
@@ -22,6 +28,10 @@ type KafkaConsumer struct {
 	wg       sync.WaitGroup
 	stats    *ConsumerStats
 	config   *KafkaConsumerConfig
+
+	tracker          *offsetTracker
+	partitionsMu     sync.Mutex
+	partitionOffsets map[topicPartition]sarama.PartitionOffsetManager
 }
 
 // KafkaConsumerConfig holds Kafka consumer configuration
@@ -29,7 +39,7 @@ type KafkaConsumerConfig struct {
 	Brokers            []string
 	GroupID            string
 	Topics             []string
-	AutoCommit         bool
+	AutoCommit         bool // Acknowledge messages once handled, false for the manual commit mode
 	AutoCommitInterval time.Duration
 	SessionTimeout     time.Duration
 	HeartbeatInterval  time.Duration
@@ -37,6 +47,23 @@ type KafkaConsumerConfig struct {
 	MaxPollInterval    time.Duration
 	OffsetReset        string // "earliest", "latest"
 	WorkerPoolSize     int
+
+	RetryPolicy        retry.Policy            // Retries of failed handlers
+	TopicRetryPolicies map[string]retry.Policy // Retries of the handlers of a topic, replacing RetryPolicy
+
+	Security kafka.SecurityConfig // Client identity, SASL authentication and TLS encryption
+}
+
+// RetryPolicyOf returns the retry policy of the handler of topic, the default retry policy when
+// none is configured
+func (c *KafkaConsumerConfig) RetryPolicyOf(topic string) retry.Policy {
+	if policy, ok := c.TopicRetryPolicies[topic]; ok {
+		return policy
+	}
+	if c.RetryPolicy.MaxAttempts == 0 {
+		return retry.DefaultPolicy()
+	}
+	return c.RetryPolicy
 }
 
 // DefaultKafkaConsumerConfig returns default Kafka consumer configuration
@@ -52,6 +79,7 @@ func DefaultKafkaConsumerConfig() *KafkaConsumerConfig {
 		MaxPollInterval:    300 * time.Second,
 		OffsetReset:        "latest",
 		WorkerPoolSize:     10,
+		RetryPolicy:        retry.DefaultPolicy(),
 	}
 }
 
@@ -74,26 +102,48 @@ func NewKafkaConsumer(config *KafkaConsumerConfig) (*KafkaConsumer, error) {
 	saramaConfig.Consumer.Group.Heartbeat.Interval = config.HeartbeatInterval
 	saramaConfig.Consumer.MaxWaitTime = config.MaxPollInterval
 	saramaConfig.Consumer.Fetch.Max = int32(config.MaxPollRecords)
+	if err := config.Security.Apply(saramaConfig); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security configuration: %w", err)
+	}
 
-	// Create Sarama consumer
-	consumer, err := sarama.NewConsumer(config.Brokers, saramaConfig)
+	// Create Sarama client, consumer and offset manager of the group
+	client, err := sarama.NewClient(config.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
 	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
 	}
-
-	kafkaConsumer := &KafkaConsumer{
-		consumer: consumer,
-		groupID:  config.GroupID,
-		topics:   config.Topics,
-		handlers: make(map[string]MessageHandler),
-		stopChan: make(chan struct{}),
-		stats:    &ConsumerStats{ConsumerLag: make(map[string]int64)},
-		config:   config,
+	offsets, err := sarama.NewOffsetManagerFromClient(config.GroupID, client)
+	if err != nil {
+		consumer.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka offset manager: %w", err)
 	}
 
+	kafkaConsumer := newKafkaConsumer(consumer, offsets, config)
+	kafkaConsumer.client = client
 	return kafkaConsumer, nil
 }
 
+// newKafkaConsumer creates a Kafka consumer on a Sarama consumer and offset manager
+func newKafkaConsumer(consumer sarama.Consumer, offsets sarama.OffsetManager, config *KafkaConsumerConfig) *KafkaConsumer {
+	return &KafkaConsumer{
+		consumer:         consumer,
+		offsets:          offsets,
+		groupID:          config.GroupID,
+		topics:           config.Topics,
+		handlers:         make(map[string]MessageHandler),
+		stopChan:         make(chan struct{}),
+		stats:            &ConsumerStats{ConsumerLag: make(map[string]int64)},
+		config:           config,
+		tracker:          newOffsetTracker(),
+		partitionOffsets: make(map[topicPartition]sarama.PartitionOffsetManager),
+	}
+}
+
 // Start starts the Kafka consumer
 func (kc *KafkaConsumer) Start(ctx context.Context) error {
 	kc.mu.Lock()
@@ -119,7 +169,7 @@ func (kc *KafkaConsumer) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops the Kafka consumer
+// Stop stops the Kafka consumer, committing the offsets of the acknowledged messages
 func (kc *KafkaConsumer) Stop(ctx context.Context) error {
 	kc.mu.Lock()
 	defer kc.mu.Unlock()
@@ -134,10 +184,28 @@ func (kc *KafkaConsumer) Stop(ctx context.Context) error {
 	close(kc.stopChan)
 	kc.wg.Wait()
 
+	// Commit before releasing the partitions, the offset manager only flushes on close when
+	// auto commit is enabled
+	kc.offsets.Commit()
+	kc.partitionsMu.Lock()
+	for key, partitionOffsets := range kc.partitionOffsets {
+		partitionOffsets.AsyncClose()
+		delete(kc.partitionOffsets, key)
+	}
+	kc.partitionsMu.Unlock()
+	if err := kc.offsets.Close(); err != nil {
+		log.Printf("Error closing Kafka offset manager: %v", err)
+	}
+
 	// Close Sarama consumer
 	if err := kc.consumer.Close(); err != nil {
 		log.Printf("Error closing Kafka consumer: %v", err)
 	}
+	if kc.client != nil {
+		if err := kc.client.Close(); err != nil {
+			log.Printf("Error closing Kafka client: %v", err)
+		}
+	}
 
 	kc.stats.mu.Lock()
 	kc.stats.IsRunning = false
@@ -188,6 +256,20 @@ func (kc *KafkaConsumer) LeaveGroup() error {
 	return nil
 }
 
+// MarkMessage acknowledges a message delivered by the consumer
+func (kc *KafkaConsumer) MarkMessage(message *Message) error {
+	return markMessage(message)
+}
+
+// CommitOffset commits the offsets of the acknowledged messages
+func (kc *KafkaConsumer) CommitOffset(ctx context.Context) error {
+	if !kc.IsRunning() {
+		return fmt.Errorf("consumer is not running")
+	}
+	kc.offsets.Commit()
+	return nil
+}
+
 // Health checks the health of the consumer
 func (kc *KafkaConsumer) Health(ctx context.Context) error {
 	if !kc.IsRunning() {
@@ -228,7 +310,7 @@ func (kc *KafkaConsumer) GetStats(ctx context.Context) (*ConsumerStats, error) {
 	return stats, nil
 }
 
-// consumeTopic consumes messages from a specific topic
+// consumeTopic consumes messages from every partition of a topic
 func (kc *KafkaConsumer) consumeTopic(ctx context.Context, topic string) {
 	defer kc.wg.Done()
 
@@ -239,40 +321,63 @@ func (kc *KafkaConsumer) consumeTopic(ctx context.Context, topic string) {
 		return
 	}
 
-	// Create partition consumers
 	for _, partition := range partitions {
-		partitionConsumer, err := kc.consumer.ConsumePartition(topic, partition, sarama.OffsetNewest)
-		if err != nil {
-			log.Printf("[ERROR] Failed to create partition consumer for topic %s partition %d: %v", topic, partition, err)
-			continue
-		}
-		defer partitionConsumer.Close()
+		kc.wg.Add(1)
+		go kc.consumePartition(ctx, topic, partition)
+	}
+}
 
-		// Consume messages
-		for {
-			select {
-			case <-ctx.Done():
-				log.Printf("[INFO] Context cancelled, stopping consumer for topic %s partition %d", topic, partition)
-				return
-			case <-kc.stopChan:
-				log.Printf("[INFO] Stop signal received, stopping consumer for topic %s partition %d", topic, partition)
+// consumePartition consumes messages from a partition, starting after the offset committed for
+// the group, or at OffsetReset when the group has not committed any
+func (kc *KafkaConsumer) consumePartition(ctx context.Context, topic string, partition int32) {
+	defer kc.wg.Done()
+
+	partitionOffsets, err := kc.offsets.ManagePartition(topic, partition)
+	if err != nil {
+		log.Printf("[ERROR] Failed to manage offsets of topic %s partition %d: %v", topic, partition, err)
+		return
+	}
+	kc.partitionsMu.Lock()
+	kc.partitionOffsets[topicPartition{topic: topic, partition: partition}] = partitionOffsets
+	kc.partitionsMu.Unlock()
+
+	offset, _ := partitionOffsets.NextOffset()
+	partitionConsumer, err := kc.consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create partition consumer for topic %s partition %d: %v", topic, partition, err)
+		return
+	}
+	defer partitionConsumer.Close()
+
+	// Consume messages
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[INFO] Context cancelled, stopping consumer for topic %s partition %d", topic, partition)
+			return
+		case <-kc.stopChan:
+			log.Printf("[INFO] Stop signal received, stopping consumer for topic %s partition %d", topic, partition)
+			return
+		case msg, ok := <-partitionConsumer.Messages():
+			if !ok {
 				return
-			case msg := <-partitionConsumer.Messages():
-				if msg != nil {
-					kc.handleMessage(ctx, topic, partition, msg)
-				}
-			case err := <-partitionConsumer.Errors():
-				if err != nil {
-					log.Printf("[ERROR] Error consuming from topic %s partition %d: %v", topic, partition, err)
-					kc.incrementFailedMessages()
-				}
+			}
+			kc.handleMessage(ctx, partitionOffsets, msg)
+		case err := <-partitionConsumer.Errors():
+			if err != nil {
+				log.Printf("[ERROR] Error consuming from topic %s partition %d: %v", topic, partition, err)
+				kc.incrementFailedMessages()
 			}
 		}
 	}
 }
 
-// handleMessage handles a single message
-func (kc *KafkaConsumer) handleMessage(ctx context.Context, topic string, partition int32, msg *sarama.ConsumerMessage) {
+// handleMessage handles a single message. In auto commit mode the message is acknowledged
+// once handled, even when its handler failed; in manual commit mode the handler acknowledges
+// it and a failure nacks it.
+func (kc *KafkaConsumer) handleMessage(ctx context.Context, partitionOffsets sarama.PartitionOffsetManager, msg *sarama.ConsumerMessage) {
+	topic, partition := msg.Topic, msg.Partition
+
 	// Convert Sarama message to our Message type
 	message := &Message{
 		Topic:     msg.Topic,
@@ -280,14 +385,14 @@ func (kc *KafkaConsumer) handleMessage(ctx context.Context, topic string, partit
 		Offset:    msg.Offset,
 		Key:       msg.Key,
 		Value:     msg.Value,
-		Headers:   make(map[string][]byte),
+		Headers:   kafka.HeadersFromRecords(msg.Headers),
 		Timestamp: msg.Timestamp,
 	}
-
-	// Convert headers
-	for _, header := range msg.Headers {
-		message.Headers[string(header.Key)] = header.Value
-	}
+	kc.tracker.track(message, func(next int64) {
+		partitionOffsets.MarkOffset(next, "")
+	}, func() {
+		log.Printf("[WARN] Message from topic %s partition %d offset %d nacked, holding back its offset", topic, partition, msg.Offset)
+	})
 
 	// Get handler for topic
 	kc.mu.RLock()
@@ -296,43 +401,47 @@ func (kc *KafkaConsumer) handleMessage(ctx context.Context, topic string, partit
 
 	if !exists {
 		log.Printf("[WARN] No handler registered for topic: %s", topic)
+		message.Ack()
 		return
 	}
 
-	// Process message with retry logic
+	// Process message with retry logic, exposing its headers to the handler
+	ctx = kafka.ContextWithHeaders(ctx, message.Headers)
 	err := kc.processMessageWithRetry(ctx, handler, message)
 	if err != nil {
 		log.Printf("[ERROR] Failed to process message from topic %s partition %d offset %d: %v",
 			topic, partition, msg.Offset, err)
 		kc.incrementFailedMessages()
+		if !kc.config.AutoCommit {
+			message.Nack()
+		}
 	} else {
 		kc.incrementConsumedMessages()
 		log.Printf("[INFO] Successfully processed message from topic %s partition %d offset %d",
 			topic, partition, msg.Offset)
 	}
+	if kc.config.AutoCommit {
+		message.Ack()
+	}
 }
 
-// processMessageWithRetry processes a message with retry logic
+// processMessageWithRetry processes a message with the retry policy of its topic
 func (kc *KafkaConsumer) processMessageWithRetry(ctx context.Context, handler MessageHandler, message *Message) error {
-	maxRetries := 3
-	delay := time.Second
-
-	var lastErr error
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if err := handler(ctx, message); err == nil {
-			return nil
-		} else {
-			lastErr = err
-			if attempt < maxRetries {
-				kc.incrementRetriedMessages()
-				log.Printf("[WARN] Attempt %d failed, retrying in %v: %v", attempt, delay, err)
-				time.Sleep(delay)
-				delay *= 2 // Exponential backoff
-			}
-		}
-	}
+	return processWithRetry(ctx, kc.config.RetryPolicyOf(message.Topic), handler, message, kc.incrementRetriedMessages)
+}
 
-	return fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+// processWithRetry attempts to handle a message as often as policy allows, calling retried
+// before each retry
+func processWithRetry(ctx context.Context, policy retry.Policy, handler MessageHandler, message *Message, retried func()) error {
+	return retry.Retrier{
+		Policy: policy,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			retried()
+			log.Printf("[WARN] Attempt %d failed, retrying in %v: %v", attempt, delay, err)
+		},
+	}.Do(ctx, func(attempt int) error {
+		return handler(ctx, message)
+	})
 }
 
 // incrementConsumedMessages increments the consumed messages count
@@ -367,6 +476,10 @@ type KafkaConsumerGroup struct {
 	wg       sync.WaitGroup
 	stats    *ConsumerStats
 	config   *KafkaConsumerConfig
+
+	tracker *offsetTracker
+	session sarama.ConsumerGroupSession // Session of the current generation, nil between generations
+	owned   Assignments                 // Partitions owned in the last generation, guarded by stats.mu
 }
 
 // NewKafkaConsumerGroup creates a new Kafka consumer group
@@ -386,6 +499,9 @@ func NewKafkaConsumerGroup(config *KafkaConsumerConfig) (*KafkaConsumerGroup, er
 	saramaConfig.Consumer.Offsets.AutoCommit.Interval = config.AutoCommitInterval
 	saramaConfig.Consumer.Group.Session.Timeout = config.SessionTimeout
 	saramaConfig.Consumer.Group.Heartbeat.Interval = config.HeartbeatInterval
+	if err := config.Security.Apply(saramaConfig); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security configuration: %w", err)
+	}
 
 	// Create Sarama consumer group
 	group, err := sarama.NewConsumerGroup(config.Brokers, config.GroupID, saramaConfig)
@@ -399,6 +515,7 @@ func NewKafkaConsumerGroup(config *KafkaConsumerConfig) (*KafkaConsumerGroup, er
 		stopChan: make(chan struct{}),
 		stats:    &ConsumerStats{ConsumerLag: make(map[string]int64)},
 		config:   config,
+		tracker:  newOffsetTracker(),
 	}
 
 	return kafkaGroup, nil
@@ -508,6 +625,24 @@ func (kcg *KafkaConsumerGroup) LeaveGroup() error {
 	return nil
 }
 
+// MarkMessage acknowledges a message delivered by the consumer group
+func (kcg *KafkaConsumerGroup) MarkMessage(message *Message) error {
+	return markMessage(message)
+}
+
+// CommitOffset commits the offsets of the acknowledged messages of the current generation
+func (kcg *KafkaConsumerGroup) CommitOffset(ctx context.Context) error {
+	kcg.mu.RLock()
+	session := kcg.session
+	kcg.mu.RUnlock()
+
+	if session == nil {
+		return fmt.Errorf("consumer group has no active session")
+	}
+	session.Commit()
+	return nil
+}
+
 // Health checks the health of the consumer group
 func (kcg *KafkaConsumerGroup) Health(ctx context.Context) error {
 	if !kcg.IsRunning() {
@@ -544,6 +679,12 @@ func (kcg *KafkaConsumerGroup) GetStats(ctx context.Context) (*ConsumerStats, er
 		stats.ConsumerLag[topic] = lag
 	}
 
+	// Copy partition ownership
+	stats.MemberID = kcg.stats.MemberID
+	stats.GenerationID = kcg.stats.GenerationID
+	stats.Assignments = copyAssignments(kcg.stats.Assignments)
+	stats.AssignedAt = kcg.stats.AssignedAt
+
 	return stats, nil
 }
 
@@ -553,26 +694,68 @@ func (kcg *KafkaConsumerGroup) ConsumeClaim(session sarama.ConsumerGroupSession,
 		select {
 		case <-kcg.stopChan:
 			return nil
-		case msg := <-claim.Messages():
-			if msg != nil {
-				kcg.handleMessage(context.Background(), msg.Topic, msg.Partition, msg)
+		case <-session.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
 			}
+			kcg.handleMessage(session.Context(), session, msg)
 		}
 	}
 }
 
-// Setup implements sarama.ConsumerGroupHandler
-func (kcg *KafkaConsumerGroup) Setup(sarama.ConsumerGroupSession) error {
+// Setup implements sarama.ConsumerGroupHandler. It records the partitions assigned to the
+// instance by the new generation and logs how they changed since the previous one.
+func (kcg *KafkaConsumerGroup) Setup(session sarama.ConsumerGroupSession) error {
+	kcg.mu.Lock()
+	kcg.session = session
+	kcg.mu.Unlock()
+
+	assignments := copyAssignments(session.Claims())
+	kcg.stats.mu.Lock()
+	added, revoked := diffAssignments(kcg.owned, assignments)
+	kcg.owned = assignments
+	kcg.stats.MemberID = session.MemberID()
+	kcg.stats.GenerationID = session.GenerationID()
+	kcg.stats.Assignments = assignments
+	kcg.stats.AssignedAt = time.Now()
+	kcg.stats.mu.Unlock()
+
+	log.Printf("[INFO] Consumer group %s member %s generation %d owns %v (added %v, revoked %v)",
+		kcg.config.GroupID, session.MemberID(), session.GenerationID(), assignments, added, revoked)
 	return nil
 }
 
-// Cleanup implements sarama.ConsumerGroupHandler
-func (kcg *KafkaConsumerGroup) Cleanup(sarama.ConsumerGroupSession) error {
+// Cleanup implements sarama.ConsumerGroupHandler. It commits the offsets acknowledged during
+// the generation before its partitions are reassigned; the messages still in flight are
+// consumed again by the next owner of their partition.
+func (kcg *KafkaConsumerGroup) Cleanup(session sarama.ConsumerGroupSession) error {
+	kcg.mu.Lock()
+	kcg.session = nil
+	kcg.mu.Unlock()
+
+	session.Commit()
+	for topic, partitions := range session.Claims() {
+		for _, partition := range partitions {
+			kcg.tracker.forget(topic, partition)
+		}
+	}
+
+	kcg.stats.mu.Lock()
+	kcg.stats.GenerationID = 0
+	kcg.stats.Assignments = nil
+	kcg.stats.mu.Unlock()
+
+	log.Printf("[INFO] Consumer group %s member %s generation %d released %v",
+		kcg.config.GroupID, session.MemberID(), session.GenerationID(), copyAssignments(session.Claims()))
 	return nil
 }
 
 // handleMessage handles a single message (same as KafkaConsumer)
-func (kcg *KafkaConsumerGroup) handleMessage(ctx context.Context, topic string, partition int32, msg *sarama.ConsumerMessage) {
+func (kcg *KafkaConsumerGroup) handleMessage(ctx context.Context, session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) {
+	topic, partition := msg.Topic, msg.Partition
+
 	// Convert Sarama message to our Message type
 	message := &Message{
 		Topic:     msg.Topic,
@@ -580,14 +763,14 @@ func (kcg *KafkaConsumerGroup) handleMessage(ctx context.Context, topic string,
 		Offset:    msg.Offset,
 		Key:       msg.Key,
 		Value:     msg.Value,
-		Headers:   make(map[string][]byte),
+		Headers:   kafka.HeadersFromRecords(msg.Headers),
 		Timestamp: msg.Timestamp,
 	}
-
-	// Convert headers
-	for _, header := range msg.Headers {
-		message.Headers[string(header.Key)] = header.Value
-	}
+	kcg.tracker.track(message, func(next int64) {
+		session.MarkOffset(topic, partition, next, "")
+	}, func() {
+		log.Printf("[WARN] Message from topic %s partition %d offset %d nacked, holding back its offset", topic, partition, msg.Offset)
+	})
 
 	// Get handler for topic
 	kcg.mu.RLock()
@@ -596,43 +779,33 @@ func (kcg *KafkaConsumerGroup) handleMessage(ctx context.Context, topic string,
 
 	if !exists {
 		log.Printf("[WARN] No handler registered for topic: %s", topic)
+		message.Ack()
 		return
 	}
 
-	// Process message with retry logic
+	// Process message with retry logic, exposing its headers to the handler
+	ctx = kafka.ContextWithHeaders(ctx, message.Headers)
 	err := kcg.processMessageWithRetry(ctx, handler, message)
 	if err != nil {
 		log.Printf("[ERROR] Failed to process message from topic %s partition %d offset %d: %v",
 			topic, partition, msg.Offset, err)
 		kcg.incrementFailedMessages()
+		if !kcg.config.AutoCommit {
+			message.Nack()
+		}
 	} else {
 		kcg.incrementConsumedMessages()
 		log.Printf("[INFO] Successfully processed message from topic %s partition %d offset %d",
 			topic, partition, msg.Offset)
 	}
+	if kcg.config.AutoCommit {
+		message.Ack()
+	}
 }
 
-// processMessageWithRetry processes a message with retry logic (same as KafkaConsumer)
+// processMessageWithRetry processes a message with the retry policy of its topic (same as KafkaConsumer)
 func (kcg *KafkaConsumerGroup) processMessageWithRetry(ctx context.Context, handler MessageHandler, message *Message) error {
-	maxRetries := 3
-	delay := time.Second
-
-	var lastErr error
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if err := handler(ctx, message); err == nil {
-			return nil
-		} else {
-			lastErr = err
-			if attempt < maxRetries {
-				kcg.incrementRetriedMessages()
-				log.Printf("[WARN] Attempt %d failed, retrying in %v: %v", attempt, delay, err)
-				time.Sleep(delay)
-				delay *= 2 // Exponential backoff
-			}
-		}
-	}
-
-	return fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+	return processWithRetry(ctx, kcg.config.RetryPolicyOf(message.Topic), handler, message, kcg.incrementRetriedMessages)
 }
 
 // incrementConsumedMessages increments the consumed messages count
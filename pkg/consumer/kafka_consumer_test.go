@@ -0,0 +1,235 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOffsetManager records the offsets marked and committed instead of storing them in Kafka
+type fakeOffsetManager struct {
+	mu        sync.Mutex
+	committed map[topicPartition]int64 // Offsets committed by Commit
+	marked    map[topicPartition]int64 // Offsets marked since the group started
+	initial   int64                    // Offset committed before the consumer started
+}
+
+func newFakeOffsetManager(initial int64) *fakeOffsetManager {
+	return &fakeOffsetManager{
+		committed: make(map[topicPartition]int64),
+		marked:    make(map[topicPartition]int64),
+		initial:   initial,
+	}
+}
+
+func (m *fakeOffsetManager) ManagePartition(topic string, partition int32) (sarama.PartitionOffsetManager, error) {
+	return &fakePartitionOffsetManager{manager: m, key: topicPartition{topic: topic, partition: partition}}, nil
+}
+
+func (m *fakeOffsetManager) Close() error { return nil }
+
+func (m *fakeOffsetManager) Commit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, offset := range m.marked {
+		m.committed[key] = offset
+	}
+}
+
+func (m *fakeOffsetManager) committedOffset(topic string, partition int32) (int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	offset, ok := m.committed[topicPartition{topic: topic, partition: partition}]
+	return offset, ok
+}
+
+type fakePartitionOffsetManager struct {
+	manager *fakeOffsetManager
+	key     topicPartition
+}
+
+func (p *fakePartitionOffsetManager) NextOffset() (int64, string) { return p.manager.initial, "" }
+
+func (p *fakePartitionOffsetManager) MarkOffset(offset int64, metadata string) {
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+	if offset > p.manager.marked[p.key] {
+		p.manager.marked[p.key] = offset
+	}
+}
+
+func (p *fakePartitionOffsetManager) ResetOffset(offset int64, metadata string) {}
+func (p *fakePartitionOffsetManager) Errors() <-chan *sarama.ConsumerError      { return nil }
+func (p *fakePartitionOffsetManager) AsyncClose()                               {}
+func (p *fakePartitionOffsetManager) Close() error                              { return nil }
+
+func TestOffsetTracker_MarksContiguousAcknowledgedOffsets(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	var marked []int64
+	mark := func(next int64) { marked = append(marked, next) }
+	nacked := 0
+	messages := make([]*Message, 3)
+	for i := range messages {
+		messages[i] = &Message{Topic: "events", Partition: 0, Offset: int64(10 + i)}
+		tracker.track(messages[i], mark, func() { nacked++ })
+	}
+
+	messages[1].Ack()
+	assert.Empty(t, marked, "an offset is not marked past an unacknowledged message")
+
+	messages[0].Nack()
+	assert.Equal(t, 1, nacked)
+	assert.Empty(t, marked, "a nacked message holds back the offsets after it")
+
+	messages[0].Ack()
+	assert.Equal(t, []int64{12}, marked)
+
+	messages[2].Ack()
+	messages[2].Ack()
+	assert.Equal(t, []int64{12, 13}, marked, "acknowledging twice is ignored")
+}
+
+func TestOffsetTracker_ForgetsReassignedPartitions(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	var marked []int64
+	message := &Message{Topic: "events", Partition: 1, Offset: 3}
+	tracker.track(message, func(next int64) { marked = append(marked, next) }, nil)
+	tracker.forget("events", 1)
+
+	message.Ack()
+	assert.Empty(t, marked, "messages of a partition no longer consumed are not marked")
+}
+
+func TestKafkaConsumer_ManualCommit(t *testing.T) {
+	saramaConsumer := mocks.NewConsumer(t, nil)
+	saramaConsumer.SetTopicMetadata(map[string][]int32{"events": {0}})
+	partitionConsumer := saramaConsumer.ExpectConsumePartition("events", 0, 5)
+
+	offsets := newFakeOffsetManager(5)
+	var _ Consumer = (*KafkaConsumer)(nil)
+	var _ Consumer = (*KafkaConsumerGroup)(nil)
+	kc := newKafkaConsumer(saramaConsumer, offsets, &KafkaConsumerConfig{GroupID: "group", Topics: []string{"events"}})
+
+	delivered := make(chan *Message, 3)
+	require.NoError(t, kc.Subscribe("events", func(ctx context.Context, message *Message) error {
+		delivered <- message
+		return nil
+	}))
+	require.NoError(t, kc.Start(context.Background()))
+
+	for i := 0; i < 3; i++ {
+		partitionConsumer.YieldMessage(&sarama.ConsumerMessage{Value: []byte("event")})
+	}
+	messages := make([]*Message, 3)
+	for i := range messages {
+		select {
+		case messages[i] = <-delivered:
+		case <-time.After(time.Second):
+			t.Fatal("message not delivered")
+		}
+	}
+	assert.Equal(t, int64(5), messages[0].Offset, "consumption resumes from the committed offset")
+
+	require.NoError(t, kc.MarkMessage(messages[1]))
+	require.NoError(t, kc.CommitOffset(context.Background()))
+	_, committed := offsets.committedOffset("events", 0)
+	assert.False(t, committed, "handled messages are not committed until acknowledged")
+
+	messages[0].Ack()
+	require.NoError(t, kc.CommitOffset(context.Background()))
+	offset, _ := offsets.committedOffset("events", 0)
+	assert.Equal(t, int64(7), offset)
+
+	messages[2].Ack()
+	require.NoError(t, kc.Stop(context.Background()))
+	offset, _ = offsets.committedOffset("events", 0)
+	assert.Equal(t, int64(8), offset, "stopping commits the acknowledged offsets")
+
+	assert.Error(t, kc.MarkMessage(&Message{Topic: "events"}), "messages not delivered by a consumer cannot be marked")
+}
+
+func TestKafkaConsumer_AutoCommit(t *testing.T) {
+	saramaConsumer := mocks.NewConsumer(t, nil)
+	saramaConsumer.SetTopicMetadata(map[string][]int32{"events": {0}})
+	partitionConsumer := saramaConsumer.ExpectConsumePartition("events", 0, sarama.OffsetOldest)
+
+	offsets := newFakeOffsetManager(sarama.OffsetOldest)
+	kc := newKafkaConsumer(saramaConsumer, offsets, &KafkaConsumerConfig{GroupID: "group", Topics: []string{"events"}, AutoCommit: true})
+
+	handled := make(chan struct{}, 2)
+	require.NoError(t, kc.Subscribe("events", func(ctx context.Context, message *Message) error {
+		handled <- struct{}{}
+		return nil
+	}))
+	require.NoError(t, kc.Start(context.Background()))
+
+	partitionConsumer.YieldMessage(&sarama.ConsumerMessage{})
+	partitionConsumer.YieldMessage(&sarama.ConsumerMessage{})
+	for i := 0; i < 2; i++ {
+		select {
+		case <-handled:
+		case <-time.After(time.Second):
+			t.Fatal("message not delivered")
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, kc.CommitOffset(context.Background()))
+		offset, _ := offsets.committedOffset("events", 0)
+		return offset == 2
+	}, time.Second, 10*time.Millisecond, "handled messages are acknowledged without the handler")
+	require.NoError(t, kc.Stop(context.Background()))
+}
+
+// fakeSession is a consumer group session owning fixed partitions
+type fakeSession struct {
+	claims     map[string][]int32
+	generation int32
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return s.claims }
+func (s *fakeSession) MemberID() string           { return "member-1" }
+func (s *fakeSession) GenerationID() int32        { return s.generation }
+func (s *fakeSession) Commit()                    {}
+func (s *fakeSession) Context() context.Context   { return context.Background() }
+
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string)  {}
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {}
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string)                 {}
+
+func TestKafkaConsumerGroup_TracksAssignments(t *testing.T) {
+	group := &KafkaConsumerGroup{
+		stats:   &ConsumerStats{ConsumerLag: make(map[string]int64)},
+		config:  &KafkaConsumerConfig{GroupID: "projections"},
+		tracker: newOffsetTracker(),
+	}
+	ctx := context.Background()
+
+	first := &fakeSession{claims: map[string][]int32{"user-events": {2, 0}}, generation: 1}
+	require.NoError(t, group.Setup(first))
+	stats, err := group.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "member-1", stats.MemberID)
+	assert.Equal(t, int32(1), stats.GenerationID)
+	assert.Equal(t, Assignments{"user-events": {0, 2}}, stats.Assignments)
+
+	require.NoError(t, group.Cleanup(first))
+	stats, err = group.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stats.Assignments, "partitions are not owned between generations")
+
+	second := &fakeSession{claims: map[string][]int32{"user-events": {0, 1}}, generation: 2}
+	require.NoError(t, group.Setup(second))
+	added, revoked := diffAssignments(Assignments{"user-events": {0, 2}}, group.owned)
+	assert.Equal(t, Assignments{"user-events": {1}}, added)
+	assert.Equal(t, Assignments{"user-events": {2}}, revoked)
+	assert.Equal(t, "user-events[1]", added.String())
+}
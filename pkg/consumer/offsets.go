@@ -0,0 +1,106 @@
+package consumer
+
+import (
+	"fmt"
+	"sync"
+)
+
+// topicPartition identifies a partition of a topic
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// offsetTracker tracks the messages in flight of each partition, so that an offset is only
+// committed once its message and every earlier message of the partition are acknowledged.
+// Kafka commits a single position per partition, so committing the offset of an acknowledged
+// message past an unacknowledged one would lose the latter.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+}
+
+// partitionOffsets are the messages in flight of a partition
+type partitionOffsets struct {
+	order   []int64        // Offsets in flight in delivery order
+	pending map[int64]bool // Offset in flight -> acknowledged
+}
+
+// newOffsetTracker creates a new offset tracker
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition]*partitionOffsets)}
+}
+
+// track registers a delivered message and makes it acknowledgeable. mark is called with the
+// offset to commit, the offset after the last message acknowledged with all earlier ones,
+// whenever an acknowledgement moves it forward; nack is called when the message is nacked.
+func (t *offsetTracker) track(message *Message, mark func(next int64), nack func()) {
+	key := topicPartition{topic: message.Topic, partition: message.Partition}
+	offset := message.Offset
+
+	t.mu.Lock()
+	offsets, ok := t.partitions[key]
+	if !ok {
+		offsets = &partitionOffsets{pending: make(map[int64]bool)}
+		t.partitions[key] = offsets
+	}
+	if _, exists := offsets.pending[offset]; !exists {
+		offsets.order = append(offsets.order, offset)
+		offsets.pending[offset] = false
+	}
+	t.mu.Unlock()
+
+	message.ack = func(processed bool) {
+		if !processed {
+			if nack != nil {
+				nack()
+			}
+			return
+		}
+		t.ack(key, offset, mark)
+	}
+}
+
+// ack acknowledges an offset in flight, calling mark when the offset to commit moves forward.
+// Offsets not in flight, e.g. acknowledged twice or forgotten, are ignored.
+func (t *offsetTracker) ack(key topicPartition, offset int64, mark func(next int64)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	offsets, ok := t.partitions[key]
+	if !ok {
+		return
+	}
+	if _, inFlight := offsets.pending[offset]; !inFlight {
+		return
+	}
+	offsets.pending[offset] = true
+
+	next := int64(-1)
+	for len(offsets.order) > 0 && offsets.pending[offsets.order[0]] {
+		next = offsets.order[0] + 1
+		delete(offsets.pending, offsets.order[0])
+		offsets.order = offsets.order[1:]
+	}
+	// Marked under the lock, so that concurrent acknowledgements mark offsets in order
+	if next >= 0 && mark != nil {
+		mark(next)
+	}
+}
+
+// forget drops the messages in flight of a partition no longer consumed, e.g. after a rebalance
+func (t *offsetTracker) forget(topic string, partition int32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.partitions, topicPartition{topic: topic, partition: partition})
+}
+
+// markMessage acknowledges a message delivered by a consumer
+func markMessage(message *Message) error {
+	if message == nil || message.ack == nil {
+		return fmt.Errorf("message was not delivered by a consumer")
+	}
+	message.Ack()
+	return nil
+}
@@ -0,0 +1,74 @@
+package control
+
+import (
+	"sync"
+	"time"
+)
+
+// Outcomes of received commands
+const (
+	OutcomeApplied  = "applied"
+	OutcomeFailed   = "failed"
+	OutcomeRejected = "rejected"
+)
+
+// AuditEntry records a command received by this instance and what became of it
+type AuditEntry struct {
+	CommandID  string            `json:"command_id"`
+	Type       string            `json:"type"`
+	Args       map[string]string `json:"args,omitempty"`
+	Issuer     string            `json:"issuer"`
+	IssuedAt   time.Time         `json:"issued_at"`
+	ReceivedAt time.Time         `json:"received_at"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// Logger writes audit entries to the application log
+type Logger interface {
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+}
+
+// AuditLog keeps the most recent audit entries in memory and writes every entry to the log
+type AuditLog struct {
+	mu       sync.RWMutex
+	entries  []AuditEntry
+	capacity int
+	logger   Logger
+}
+
+// NewAuditLog creates an audit log keeping the last capacity entries. A nil logger only keeps entries in memory.
+func NewAuditLog(capacity int, logger Logger) *AuditLog {
+	return &AuditLog{
+		capacity: capacity,
+		logger:   logger,
+	}
+}
+
+// Record adds an entry, dropping the oldest one when the log is full
+func (a *AuditLog) Record(entry AuditEntry) {
+	if a.logger != nil {
+		if entry.Outcome == OutcomeApplied {
+			a.logger.Info("Control command %s %s from %s applied (args: %v)", entry.CommandID, entry.Type, entry.Issuer, entry.Args)
+		} else {
+			a.logger.Warn("Control command %s %s from %s %s: %s", entry.CommandID, entry.Type, entry.Issuer, entry.Outcome, entry.Error)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.entries = append(a.entries, entry)
+	if a.capacity > 0 && len(a.entries) > a.capacity {
+		a.entries = append([]AuditEntry(nil), a.entries[len(a.entries)-a.capacity:]...)
+	}
+}
+
+// Entries returns the recorded entries, oldest first
+func (a *AuditLog) Entries() []AuditEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return append([]AuditEntry(nil), a.entries...)
+}
@@ -0,0 +1,278 @@
+package control
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
+)
+
+// Command types understood by consumer instances
+const (
+	CommandPauseTopic     = "pause_topic"     // args: topic
+	CommandResumeTopic    = "resume_topic"    // args: topic
+	CommandFlushCaches    = "flush_caches"    // args: tag (optional, all entries when empty)
+	CommandReloadHandlers = "reload_handlers" // args: feature flags to set on top of the configured defaults
+	CommandPromote        = "promote"         // args: instance
+	CommandDemote         = "demote"          // args: instance
+)
+
+// Errors of rejected commands
+var (
+	ErrInvalidSignature = errors.New("invalid command signature")
+	ErrExpired          = errors.New("command is expired or issued in the future")
+	ErrReplayed         = errors.New("command was already handled")
+	ErrUnknownCommand   = errors.New("unknown command type")
+)
+
+// Command is a runtime instruction broadcast to every consumer instance
+type Command struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Args      map[string]string `json:"args,omitempty"`
+	Issuer    string            `json:"issuer"`
+	IssuedAt  time.Time         `json:"issued_at"`
+	Signature string            `json:"signature,omitempty"`
+}
+
+// Validate checks the command type and its required arguments
+func (c Command) Validate() error {
+	switch c.Type {
+	case CommandPauseTopic, CommandResumeTopic:
+		if c.Args["topic"] == "" {
+			return fmt.Errorf("%s requires a topic argument", c.Type)
+		}
+	case CommandPromote, CommandDemote:
+		if c.Args["instance"] == "" {
+			return fmt.Errorf("%s requires an instance argument", c.Type)
+		}
+	case CommandFlushCaches, CommandReloadHandlers:
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, c.Type)
+	}
+	if c.Issuer == "" {
+		return errors.New("command issuer is required")
+	}
+	return nil
+}
+
+// Signer signs and verifies commands with a key shared by authorized producers and the consumers
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a command signer
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// Sign sets the signature of a command
+func (s *Signer) Sign(command *Command) error {
+	signature, err := s.signature(*command)
+	if err != nil {
+		return err
+	}
+	command.Signature = signature
+	return nil
+}
+
+// Verify checks the signature of a command
+func (s *Signer) Verify(command Command) error {
+	expected, err := s.signature(command)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(command.Signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// signature computes the HMAC-SHA256 of the command without its signature
+func (s *Signer) signature(command Command) (string, error) {
+	if len(s.key) == 0 {
+		return "", errors.New("command signing key is not configured")
+	}
+
+	command.Signature = ""
+	payload, err := json.Marshal(command)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Publisher publishes raw messages to a topic
+type Publisher interface {
+	Publish(topic string, message []byte) error
+}
+
+// Producer issues signed commands on the control topic
+type Producer struct {
+	publisher Publisher
+	topic     string
+	signer    *Signer
+	ids       *id.Generator
+	clock     clock.Clock
+}
+
+// NewProducer creates a command producer. A nil clock uses the system clock.
+func NewProducer(publisher Publisher, topic string, signer *Signer, clk clock.Clock) *Producer {
+	clk = clock.OrDefault(clk)
+	return &Producer{
+		publisher: publisher,
+		topic:     topic,
+		signer:    signer,
+		ids:       id.NewGenerator(clk),
+		clock:     clk,
+	}
+}
+
+// Send signs and broadcasts a command on behalf of issuer
+func (p *Producer) Send(commandType string, args map[string]string, issuer string) (Command, error) {
+	command := Command{
+		ID:       p.ids.ULID(),
+		Type:     commandType,
+		Args:     args,
+		Issuer:   issuer,
+		IssuedAt: p.clock.Now().UTC(),
+	}
+	if err := command.Validate(); err != nil {
+		return Command{}, err
+	}
+	if err := p.signer.Sign(&command); err != nil {
+		return Command{}, err
+	}
+
+	message, err := json.Marshal(command)
+	if err != nil {
+		return Command{}, err
+	}
+	if err := p.publisher.Publish(p.topic, message); err != nil {
+		return Command{}, fmt.Errorf("failed to publish command: %w", err)
+	}
+	return command, nil
+}
+
+// Handler applies a command on this instance
+type Handler func(ctx context.Context, command Command) error
+
+// Channel verifies commands received on the control topic, dispatches them to their handlers
+// and records every received command in the audit log
+type Channel struct {
+	signer   *Signer
+	maxAge   time.Duration
+	audit    *AuditLog
+	clock    clock.Clock
+	mu       sync.Mutex
+	handlers map[string]Handler
+	seen     map[string]time.Time // command ID -> issue time, for replay protection
+}
+
+// NewChannel creates a control channel accepting commands issued at most maxAge ago.
+// A nil clock uses the system clock.
+func NewChannel(signer *Signer, maxAge time.Duration, audit *AuditLog, clk clock.Clock) *Channel {
+	return &Channel{
+		signer:   signer,
+		maxAge:   maxAge,
+		audit:    audit,
+		clock:    clock.OrDefault(clk),
+		handlers: make(map[string]Handler),
+		seen:     make(map[string]time.Time),
+	}
+}
+
+// Register registers the handler of a command type
+func (c *Channel) Register(commandType string, handler Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.handlers[commandType] = handler
+}
+
+// Handle verifies and applies a command message
+func (c *Channel) Handle(ctx context.Context, message []byte) error {
+	var command Command
+	if err := json.Unmarshal(message, &command); err != nil {
+		err = fmt.Errorf("invalid command: %w", err)
+		c.record(command, OutcomeRejected, err)
+		return err
+	}
+
+	handler, err := c.accept(command)
+	if err != nil {
+		c.record(command, OutcomeRejected, err)
+		return err
+	}
+
+	if err := handler(ctx, command); err != nil {
+		c.record(command, OutcomeFailed, err)
+		return err
+	}
+	c.record(command, OutcomeApplied, nil)
+	return nil
+}
+
+// accept checks signature, age and uniqueness of a command and returns its handler
+func (c *Channel) accept(command Command) (Handler, error) {
+	if err := c.signer.Verify(command); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	if now.Sub(command.IssuedAt) > c.maxAge || command.IssuedAt.Sub(now) > c.maxAge {
+		return nil, ErrExpired
+	}
+
+	// Commands older than maxAge are rejected as expired, so their IDs need not be remembered
+	for commandID, issuedAt := range c.seen {
+		if now.Sub(issuedAt) > c.maxAge {
+			delete(c.seen, commandID)
+		}
+	}
+	if _, ok := c.seen[command.ID]; ok {
+		return nil, ErrReplayed
+	}
+
+	handler, ok := c.handlers[command.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCommand, command.Type)
+	}
+	c.seen[command.ID] = command.IssuedAt
+	return handler, nil
+}
+
+// record adds a received command to the audit log
+func (c *Channel) record(command Command, outcome string, err error) {
+	if c.audit == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		CommandID:  command.ID,
+		Type:       command.Type,
+		Args:       command.Args,
+		Issuer:     command.Issuer,
+		IssuedAt:   command.IssuedAt,
+		ReceivedAt: c.clock.Now(),
+		Outcome:    outcome,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.audit.Record(entry)
+}
@@ -0,0 +1,197 @@
+package control_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/control"
+)
+
+// capturePublisher keeps published messages
+type capturePublisher struct {
+	topic    string
+	messages [][]byte
+}
+
+func (p *capturePublisher) Publish(topic string, message []byte) error {
+	p.topic = topic
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func newTestChannel(fake *clock.Fake, key string) (*control.Channel, *control.AuditLog, *[]control.Command) {
+	audit := control.NewAuditLog(10, nil)
+	channel := control.NewChannel(control.NewSigner([]byte(key)), time.Minute, audit, fake)
+	var applied []control.Command
+	channel.Register(control.CommandPauseTopic, func(ctx context.Context, command control.Command) error {
+		applied = append(applied, command)
+		return nil
+	})
+	channel.Register(control.CommandFlushCaches, func(ctx context.Context, command control.Command) error {
+		return errors.New("cache unavailable")
+	})
+	return channel, audit, &applied
+}
+
+func TestChannel_AppliesSignedCommands(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	publisher := &capturePublisher{}
+	producer := control.NewProducer(publisher, "consumer-control", control.NewSigner([]byte("secret")), fake)
+	channel, audit, applied := newTestChannel(fake, "secret")
+
+	command, err := producer.Send(control.CommandPauseTopic, map[string]string{"topic": "user-events"}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "consumer-control", publisher.topic)
+	require.Len(t, publisher.messages, 1)
+
+	require.NoError(t, channel.Handle(context.Background(), publisher.messages[0]))
+	require.Len(t, *applied, 1)
+	assert.Equal(t, "user-events", (*applied)[0].Args["topic"])
+
+	entries := audit.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, command.ID, entries[0].CommandID)
+	assert.Equal(t, "alice", entries[0].Issuer)
+	assert.Equal(t, control.OutcomeApplied, entries[0].Outcome)
+}
+
+func TestChannel_RejectsCommands(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	signer := control.NewSigner([]byte("secret"))
+	signed := func(command control.Command) []byte {
+		require.NoError(t, signer.Sign(&command))
+		message, err := json.Marshal(command)
+		require.NoError(t, err)
+		return message
+	}
+	pause := control.Command{ID: "1", Type: control.CommandPauseTopic, Args: map[string]string{"topic": "a"}, Issuer: "alice", IssuedAt: fake.Now()}
+
+	tests := []struct {
+		name    string
+		message func() []byte
+		wantErr error
+	}{
+		{
+			name: "wrong key",
+			message: func() []byte {
+				command := pause
+				require.NoError(t, control.NewSigner([]byte("other")).Sign(&command))
+				message, _ := json.Marshal(command)
+				return message
+			},
+			wantErr: control.ErrInvalidSignature,
+		},
+		{
+			name: "tampered args",
+			message: func() []byte {
+				var command control.Command
+				require.NoError(t, json.Unmarshal(signed(pause), &command))
+				command.Args = map[string]string{"topic": "b"}
+				message, _ := json.Marshal(command)
+				return message
+			},
+			wantErr: control.ErrInvalidSignature,
+		},
+		{
+			name: "expired",
+			message: func() []byte {
+				command := pause
+				command.IssuedAt = fake.Now().Add(-2 * time.Minute)
+				return signed(command)
+			},
+			wantErr: control.ErrExpired,
+		},
+		{
+			name: "unknown type",
+			message: func() []byte {
+				command := pause
+				command.Type = "shutdown"
+				return signed(command)
+			},
+			wantErr: control.ErrUnknownCommand,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel, audit, applied := newTestChannel(fake, "secret")
+
+			err := channel.Handle(context.Background(), tt.message())
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Empty(t, *applied)
+			require.Len(t, audit.Entries(), 1)
+			assert.Equal(t, control.OutcomeRejected, audit.Entries()[0].Outcome)
+		})
+	}
+}
+
+func TestChannel_RejectsReplays(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	publisher := &capturePublisher{}
+	producer := control.NewProducer(publisher, "consumer-control", control.NewSigner([]byte("secret")), fake)
+	channel, _, applied := newTestChannel(fake, "secret")
+
+	_, err := producer.Send(control.CommandPauseTopic, map[string]string{"topic": "a"}, "alice")
+	require.NoError(t, err)
+
+	require.NoError(t, channel.Handle(context.Background(), publisher.messages[0]))
+	assert.ErrorIs(t, channel.Handle(context.Background(), publisher.messages[0]), control.ErrReplayed)
+
+	// Once forgotten, the command is expired
+	fake.Advance(2 * time.Minute)
+	assert.ErrorIs(t, channel.Handle(context.Background(), publisher.messages[0]), control.ErrExpired)
+	assert.Len(t, *applied, 1)
+}
+
+func TestChannel_RecordsFailedCommands(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	publisher := &capturePublisher{}
+	producer := control.NewProducer(publisher, "consumer-control", control.NewSigner([]byte("secret")), fake)
+	channel, audit, _ := newTestChannel(fake, "secret")
+
+	_, err := producer.Send(control.CommandFlushCaches, nil, "alice")
+	require.NoError(t, err)
+
+	assert.Error(t, channel.Handle(context.Background(), publisher.messages[0]))
+	entries := audit.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, control.OutcomeFailed, entries[0].Outcome)
+	assert.Equal(t, "cache unavailable", entries[0].Error)
+}
+
+func TestProducer_ValidatesCommands(t *testing.T) {
+	publisher := &capturePublisher{}
+	producer := control.NewProducer(publisher, "consumer-control", control.NewSigner([]byte("secret")), nil)
+
+	_, err := producer.Send("shutdown", nil, "alice")
+	assert.ErrorIs(t, err, control.ErrUnknownCommand)
+	_, err = producer.Send(control.CommandPauseTopic, nil, "alice")
+	assert.Error(t, err)
+	_, err = producer.Send(control.CommandPromote, nil, "alice")
+	assert.Error(t, err, "promotions name the instance promoted")
+	_, err = producer.Send(control.CommandFlushCaches, nil, "")
+	assert.Error(t, err)
+
+	_, err = control.NewProducer(publisher, "consumer-control", control.NewSigner(nil), nil).Send(control.CommandFlushCaches, nil, "alice")
+	assert.Error(t, err)
+	assert.Empty(t, publisher.messages)
+}
+
+func TestAuditLog_KeepsMostRecentEntries(t *testing.T) {
+	audit := control.NewAuditLog(2, nil)
+	for _, id := range []string{"1", "2", "3"} {
+		audit.Record(control.AuditEntry{CommandID: id, Outcome: control.OutcomeApplied})
+	}
+
+	entries := audit.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "2", entries[0].CommandID)
+	assert.Equal(t, "3", entries[1].CommandID)
+}
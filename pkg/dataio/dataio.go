@@ -0,0 +1,357 @@
+package dataio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a record file format
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// ParseFormat parses a format name, falling back to the file extension when empty
+func ParseFormat(name, path string) (Format, error) {
+	if name == "" {
+		name = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	switch strings.ToLower(name) {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unsupported format: %q (expected csv or jsonl)", name)
+	}
+}
+
+// Record is a single row of string fields
+type Record map[string]string
+
+// FieldMapping renames record fields, e.g. "mail=email,full_name=name"
+type FieldMapping map[string]string
+
+// ParseFieldMapping parses a comma separated list of source=target pairs
+func ParseFieldMapping(spec string) (FieldMapping, error) {
+	mapping := make(FieldMapping)
+	if strings.TrimSpace(spec) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid field mapping %q (expected source=target)", pair)
+		}
+		mapping[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return mapping, nil
+}
+
+// Apply returns a copy of the record with mapped field names
+func (m FieldMapping) Apply(record Record) Record {
+	mapped := make(Record, len(record))
+	for field, value := range record {
+		if target, ok := m[field]; ok {
+			field = target
+		}
+		mapped[field] = value
+	}
+	return mapped
+}
+
+// Reader reads records one at a time
+type Reader interface {
+	// Read returns the next record or io.EOF
+	Read() (Record, error)
+	// Line returns the line number of the last record read
+	Line() int
+}
+
+// NewReader creates a record reader for the given format
+func NewReader(r io.Reader, format Format) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		return &csvReader{reader: reader, header: header, line: 1}, nil
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		return &jsonlReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	header []string
+	line   int
+}
+
+func (r *csvReader) Read() (Record, error) {
+	row, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.line++
+
+	record := make(Record, len(r.header))
+	for i, field := range r.header {
+		if i < len(row) {
+			record[field] = row[i]
+		}
+	}
+	return record, nil
+}
+
+func (r *csvReader) Line() int {
+	return r.line
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			return nil, fmt.Errorf("line %d: invalid JSON: %w", r.line, err)
+		}
+
+		record := make(Record, len(raw))
+		for field, value := range raw {
+			switch v := value.(type) {
+			case string:
+				record[field] = v
+			case nil:
+				record[field] = ""
+			default:
+				record[field] = fmt.Sprintf("%v", v)
+			}
+		}
+		return record, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (r *jsonlReader) Line() int {
+	return r.line
+}
+
+// Writer writes records one at a time
+type Writer interface {
+	Write(record Record) error
+	Flush() error
+}
+
+// NewWriter creates a record writer for the given format.
+// Fields define the column order; a CSV header is written unless skipHeader is set.
+func NewWriter(w io.Writer, format Format, fields []string, skipHeader bool) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if !skipHeader {
+			if err := writer.Write(fields); err != nil {
+				return nil, fmt.Errorf("failed to write CSV header: %w", err)
+			}
+		}
+		return &csvWriter{writer: writer, fields: fields}, nil
+	case FormatJSONL:
+		buffered := bufio.NewWriter(w)
+		return &jsonlWriter{writer: buffered, encoder: json.NewEncoder(buffered), fields: fields}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	fields []string
+}
+
+func (w *csvWriter) Write(record Record) error {
+	row := make([]string, len(w.fields))
+	for i, field := range w.fields {
+		row[i] = record[field]
+	}
+	return w.writer.Write(row)
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonlWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	fields  []string
+}
+
+func (w *jsonlWriter) Write(record Record) error {
+	row := make(map[string]string, len(w.fields))
+	for _, field := range w.fields {
+		row[field] = record[field]
+	}
+	return w.encoder.Encode(row)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+// Progress tracks how many records were processed so a run can be resumed
+type Progress struct {
+	path      string
+	Processed int64 `json:"processed"`
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+}
+
+// LoadProgress loads progress from a checkpoint file.
+// An empty path disables checkpointing; a missing file starts from zero.
+func LoadProgress(path string) (*Progress, error) {
+	progress := &Progress{path: path}
+	if path == "" {
+		return progress, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read progress file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("failed to parse progress file: %w", err)
+	}
+	return progress, nil
+}
+
+// Save writes the progress to its checkpoint file
+func (p *Progress) Save() error {
+	if p.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so an interrupted run never leaves a corrupt checkpoint
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write progress file: %w", err)
+	}
+	return os.Rename(tmp, p.path)
+}
+
+// Clear removes the checkpoint file after a completed run
+func (p *Progress) Clear() error {
+	if p.path == "" {
+		return nil
+	}
+	if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ErrorReport writes failed records with their errors as JSON lines
+type ErrorReport struct {
+	writer *bufio.Writer
+	file   *os.File
+	count  int
+}
+
+// ReportEntry is a failed record in the error report
+type ReportEntry struct {
+	Line   int    `json:"line"`
+	Record Record `json:"record"`
+	Error  string `json:"error"`
+}
+
+// NewErrorReport creates an error report; an empty path discards entries
+func NewErrorReport(path string, appendMode bool) (*ErrorReport, error) {
+	if path == "" {
+		return &ErrorReport{}, nil
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open error report: %w", err)
+	}
+
+	return &ErrorReport{writer: bufio.NewWriter(file), file: file}, nil
+}
+
+// Add records a failed record
+func (r *ErrorReport) Add(line int, record Record, err error) error {
+	r.count++
+	if r.writer == nil {
+		return nil
+	}
+
+	data, marshalErr := json.Marshal(ReportEntry{Line: line, Record: record, Error: err.Error()})
+	if marshalErr != nil {
+		return marshalErr
+	}
+	if _, writeErr := r.writer.Write(append(data, '\n')); writeErr != nil {
+		return writeErr
+	}
+	return nil
+}
+
+// Count returns the number of failed records added
+func (r *ErrorReport) Count() int {
+	return r.count
+}
+
+// Close flushes and closes the report file
+func (r *ErrorReport) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	if err := r.writer.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,96 @@
+package dataio_test
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/dataio"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := dataio.ParseFormat("", "users.CSV")
+	require.NoError(t, err)
+	assert.Equal(t, dataio.FormatCSV, format)
+
+	format, err = dataio.ParseFormat("ndjson", "users.txt")
+	require.NoError(t, err)
+	assert.Equal(t, dataio.FormatJSONL, format)
+
+	_, err = dataio.ParseFormat("", "users.xml")
+	assert.Error(t, err)
+}
+
+func TestParseFieldMapping(t *testing.T) {
+	mapping, err := dataio.ParseFieldMapping("mail=email, full_name = name")
+	require.NoError(t, err)
+
+	record := mapping.Apply(dataio.Record{"mail": "a@example.com", "full_name": "Alice", "extra": "x"})
+	assert.Equal(t, dataio.Record{"email": "a@example.com", "name": "Alice", "extra": "x"}, record)
+
+	_, err = dataio.ParseFieldMapping("mail")
+	assert.Error(t, err)
+}
+
+func TestReader_CSVAndJSONL(t *testing.T) {
+	csvReader, err := dataio.NewReader(strings.NewReader("email,name\na@example.com,Alice\nb@example.com,Bob\n"), dataio.FormatCSV)
+	require.NoError(t, err)
+
+	record, err := csvReader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", record["name"])
+	assert.Equal(t, 2, csvReader.Line())
+
+	jsonlReader, err := dataio.NewReader(strings.NewReader("{\"email\":\"a@example.com\",\"age\":30}\n\n{\"email\":\"b@example.com\"}\n"), dataio.FormatJSONL)
+	require.NoError(t, err)
+
+	record, err = jsonlReader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "30", record["age"])
+
+	record, err = jsonlReader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "b@example.com", record["email"])
+	assert.Equal(t, 3, jsonlReader.Line())
+
+	_, err = jsonlReader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestWriter_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := dataio.NewWriter(&buf, dataio.FormatCSV, []string{"email", "name"}, false)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Write(dataio.Record{"email": "a@example.com", "name": "Alice", "ignored": "x"}))
+	require.NoError(t, writer.Flush())
+
+	assert.Equal(t, "email,name\na@example.com,Alice\n", buf.String())
+}
+
+func TestProgress_SaveLoadClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+
+	progress, err := dataio.LoadProgress(path)
+	require.NoError(t, err)
+	assert.Zero(t, progress.Processed)
+
+	progress.Processed = 42
+	progress.Failed = 2
+	require.NoError(t, progress.Save())
+
+	loaded, err := dataio.LoadProgress(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), loaded.Processed)
+	assert.Equal(t, int64(2), loaded.Failed)
+
+	require.NoError(t, loaded.Clear())
+	cleared, err := dataio.LoadProgress(path)
+	require.NoError(t, err)
+	assert.Zero(t, cleared.Processed)
+}
@@ -0,0 +1,240 @@
+package debugconsole
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSocketPath is the default unix socket used by the debug console
+const DefaultSocketPath = "/tmp/go-clean-ddd-es-template.sock"
+
+// endOfResponse terminates every response written by the server
+const endOfResponse = "\x00"
+
+// CommandFunc executes a console command and returns its output
+type CommandFunc func(ctx context.Context, args []string) (interface{}, error)
+
+// Command describes a console command
+type Command struct {
+	Name  string
+	Usage string
+	Run   CommandFunc
+}
+
+// Logger interface for logging
+type Logger interface {
+	Info(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Server serves debug console commands over a unix socket
+type Server struct {
+	mu         sync.RWMutex
+	socketPath string
+	commands   map[string]Command
+	listener   net.Listener
+	logger     Logger
+	wg         sync.WaitGroup
+}
+
+// NewServer creates a new debug console server
+func NewServer(socketPath string, logger Logger) *Server {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+
+	server := &Server{
+		socketPath: socketPath,
+		commands:   make(map[string]Command),
+		logger:     logger,
+	}
+
+	server.Register("help", "help - list available commands", server.help)
+
+	return server
+}
+
+// Register registers a console command
+func (s *Server) Register(name, usage string, run CommandFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands[name] = Command{Name: name, Usage: usage, Run: run}
+}
+
+// Start starts listening on the unix socket
+func (s *Server) Start(ctx context.Context) error {
+	// Remove a stale socket left by a previous process
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket %s: %w", s.socketPath, err)
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.socketPath, err)
+	}
+
+	// Restrict access to the owner of the process
+	if err := os.Chmod(s.socketPath, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	s.listener = listener
+	s.logger.Info("Debug console listening on %s", s.socketPath)
+
+	s.wg.Add(1)
+	go s.acceptLoop(ctx)
+
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+
+	return nil
+}
+
+// Stop stops the server and removes the socket
+func (s *Server) Stop() {
+	if s.listener == nil {
+		return
+	}
+	s.listener.Close()
+	s.wg.Wait()
+	os.Remove(s.socketPath)
+}
+
+// acceptLoop accepts client connections until the listener is closed
+func (s *Server) acceptLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(ctx, conn)
+	}
+}
+
+// serve handles commands from a single connection, one per line
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		output := s.Execute(ctx, line)
+		if _, err := io.WriteString(conn, output+"\n"+endOfResponse+"\n"); err != nil {
+			s.logger.Error("Debug console write failed: %v", err)
+			return
+		}
+	}
+}
+
+// Execute runs a command line and returns the formatted output
+func (s *Server) Execute(ctx context.Context, line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	s.mu.RLock()
+	command, exists := s.commands[fields[0]]
+	s.mu.RUnlock()
+
+	if !exists {
+		return fmt.Sprintf("unknown command: %s (try 'help')", fields[0])
+	}
+
+	result, err := command.Run(ctx, fields[1:])
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	switch v := result.(type) {
+	case string:
+		return v
+	default:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return string(data)
+	}
+}
+
+// help lists the registered commands
+func (s *Server) help(ctx context.Context, args []string) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.commands))
+	for name := range s.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(s.commands[name].Usage)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// Client connects to a running debug console server
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Dial connects to the debug console socket
+func Dial(socketPath string, timeout time.Duration) (*Client, error) {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to debug console at %s: %w", socketPath, err)
+	}
+
+	return &Client{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// Send sends a command line and returns the server response
+func (c *Client) Send(line string) (string, error) {
+	if _, err := io.WriteString(c.conn, strings.TrimSpace(line)+"\n"); err != nil {
+		return "", fmt.Errorf("failed to send command: %w", err)
+	}
+
+	var b strings.Builder
+	for {
+		response, err := c.reader.ReadString('\n')
+		if err != nil {
+			return b.String(), fmt.Errorf("failed to read response: %w", err)
+		}
+		if response == endOfResponse+"\n" {
+			return strings.TrimRight(b.String(), "\n"), nil
+		}
+		b.WriteString(response)
+	}
+}
+
+// Close closes the client connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,56 @@
+package debugconsole_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/debugconsole"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Info(format string, v ...interface{})  {}
+func (noopLogger) Error(format string, v ...interface{}) {}
+
+func TestServer_ClientRoundTrip(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "debug.sock")
+	server := debugconsole.NewServer(socketPath, noopLogger{})
+	server.Register("echo", "echo <args> - echo arguments", func(ctx context.Context, args []string) (interface{}, error) {
+		return map[string]interface{}{"args": args}, nil
+	})
+	server.Register("fail", "fail - always fails", func(ctx context.Context, args []string) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+
+	client, err := debugconsole.Dial(socketPath, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	output, err := client.Send("echo a b")
+	require.NoError(t, err)
+	assert.Contains(t, output, `"a"`)
+	assert.Contains(t, output, `"b"`)
+
+	output, err = client.Send("fail")
+	require.NoError(t, err)
+	assert.Equal(t, "error: boom", output)
+
+	output, err = client.Send("help")
+	require.NoError(t, err)
+	assert.Contains(t, output, "echo <args>")
+
+	output, err = client.Send("missing")
+	require.NoError(t, err)
+	assert.Contains(t, output, "unknown command")
+}
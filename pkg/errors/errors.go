@@ -1,9 +1,11 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // ErrorCode represents a unique error code
@@ -23,14 +25,22 @@ const (
 	ErrValidationFailed ErrorCode = "VALIDATION_FAILED"
 	ErrCommandFailed    ErrorCode = "COMMAND_FAILED"
 	ErrQueryFailed      ErrorCode = "QUERY_FAILED"
+	ErrPolicyDenied     ErrorCode = "POLICY_DENIED"
+	ErrApprovalRequired ErrorCode = "APPROVAL_REQUIRED"
+	ErrDuplicateCommand ErrorCode = "DUPLICATE_COMMAND"
+	ErrAccountLocked    ErrorCode = "ACCOUNT_LOCKED"
+	ErrLoginThrottled   ErrorCode = "LOGIN_THROTTLED"
 
 	// Infrastructure errors
 	ErrDatabaseConnection  ErrorCode = "DATABASE_CONNECTION"
 	ErrDatabaseQuery       ErrorCode = "DATABASE_QUERY"
 	ErrDatabaseTransaction ErrorCode = "DATABASE_TRANSACTION"
 	ErrEventStoreFailed    ErrorCode = "EVENT_STORE_FAILED"
+	ErrConcurrencyConflict ErrorCode = "CONCURRENCY_CONFLICT"
 	ErrEventPublishFailed  ErrorCode = "EVENT_PUBLISH_FAILED"
 	ErrMessageBrokerFailed ErrorCode = "MESSAGE_BROKER_FAILED"
+	ErrEventHandlingFailed ErrorCode = "EVENT_HANDLING_FAILED"
+	ErrInvalidEventPayload ErrorCode = "INVALID_EVENT_PAYLOAD"
 
 	// System errors
 	ErrInternalServer     ErrorCode = "INTERNAL_SERVER_ERROR"
@@ -40,6 +50,7 @@ const (
 	ErrForbidden          ErrorCode = "FORBIDDEN"
 	ErrNotFound           ErrorCode = "NOT_FOUND"
 	ErrBadRequest         ErrorCode = "BAD_REQUEST"
+	ErrRateLimited        ErrorCode = "RATE_LIMITED"
 )
 
 // AppError represents an application error with i18n support
@@ -147,6 +158,15 @@ func Wrapf(err error, code ErrorCode, format string, args ...interface{}) *AppEr
 	}
 }
 
+// CodeOf returns the code of the first AppError in the chain of err, or fallback when there is none
+func CodeOf(err error, fallback ErrorCode) ErrorCode {
+	var appErr *AppError
+	if stderrors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return fallback
+}
+
 // IsAppError checks if an error is an AppError
 func IsAppError(err error) bool {
 	_, ok := err.(*AppError)
@@ -210,16 +230,20 @@ func getHTTPStatus(code ErrorCode) int {
 		return 400
 	case ErrUnauthorized:
 		return 401
-	case ErrForbidden:
+	case ErrForbidden, ErrPolicyDenied, ErrApprovalRequired:
 		return 403
 	case ErrNotFound, ErrUserNotFound:
 		return 404
-	case ErrUserAlreadyExists:
+	case ErrUserAlreadyExists, ErrConcurrencyConflict, ErrDuplicateCommand:
 		return 409
 	case ErrUserDeleted:
 		return 410
 	case ErrTimeout:
 		return 408
+	case ErrAccountLocked:
+		return 423
+	case ErrRateLimited, ErrLoginThrottled:
+		return 429
 	case ErrServiceUnavailable:
 		return 503
 	case ErrInternalServer, ErrDatabaseConnection, ErrDatabaseQuery, ErrDatabaseTransaction,
@@ -267,6 +291,24 @@ func EventStoreError(operation string, err error) *AppError {
 	return Wrap(err, ErrEventStoreFailed, fmt.Sprintf("Event store %s failed", operation))
 }
 
+func ConcurrencyConflict(aggregateID string, err error) *AppError {
+	return Wrap(err, ErrConcurrencyConflict, fmt.Sprintf("Aggregate %s was modified concurrently", aggregateID))
+}
+
+func DuplicateCommand(commandID string) *AppError {
+	return New(ErrDuplicateCommand, fmt.Sprintf("Command %s was already handled", commandID))
+}
+
+func AccountLocked(until time.Time) *AppError {
+	return New(ErrAccountLocked, "Account is locked after too many failed logins").
+		WithDetails(map[string]interface{}{"locked_until": until.UTC().Format(time.RFC3339)})
+}
+
+func LoginThrottled(until time.Time) *AppError {
+	return New(ErrLoginThrottled, "Too many failed logins, try again later").
+		WithDetails(map[string]interface{}{"locked_until": until.UTC().Format(time.RFC3339)})
+}
+
 func EventPublishError(err error) *AppError {
 	return Wrap(err, ErrEventPublishFailed, "Failed to publish event")
 }
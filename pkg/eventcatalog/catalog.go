@@ -0,0 +1,155 @@
+package eventcatalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Event documents an event type: what it means, the shape of its payload, the topic it is
+// published to and the components producing and consuming it
+type Event struct {
+	Type        string          `json:"type"`
+	Version     int             `json:"version"` // Current payload version, 0 for unversioned events
+	Description string          `json:"description"`
+	Topic       string          `json:"topic"`
+	Schema      json.RawMessage `json:"schema,omitempty"` // JSON schema of the payload
+	Producers   []string        `json:"producers"`
+	Consumers   []string        `json:"consumers"`
+}
+
+// Validate checks that the event is fully documented
+func (e Event) Validate() error {
+	var errs []error
+	if e.Type == "" {
+		errs = append(errs, fmt.Errorf("event type is required"))
+	}
+	if e.Description == "" {
+		errs = append(errs, fmt.Errorf("event %s: description is required", e.Type))
+	}
+	if e.Topic == "" {
+		errs = append(errs, fmt.Errorf("event %s: topic is required", e.Type))
+	}
+	if len(e.Producers) == 0 {
+		errs = append(errs, fmt.Errorf("event %s: at least one producer is required", e.Type))
+	}
+	if len(e.Schema) > 0 && !json.Valid(e.Schema) {
+		errs = append(errs, fmt.Errorf("event %s: schema is not valid JSON", e.Type))
+	}
+	return errors.Join(errs...)
+}
+
+// Catalog holds the documentation of event types
+type Catalog struct {
+	mu     sync.RWMutex
+	events map[string]Event
+}
+
+// NewCatalog creates a new empty catalog
+func NewCatalog() *Catalog {
+	return &Catalog{
+		events: make(map[string]Event),
+	}
+}
+
+// Register documents event types; an event replaces an earlier one with the same type
+func (c *Catalog) Register(events ...Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, event := range events {
+		c.events[event.Type] = event
+	}
+}
+
+// Get returns the documentation of an event type
+func (c *Catalog) Get(eventType string) (Event, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	event, ok := c.events[eventType]
+	return event, ok
+}
+
+// Events returns the documented events sorted by type
+func (c *Catalog) Events() []Event {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	events := make([]Event, 0, len(c.events))
+	for _, event := range c.events {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Type < events[j].Type })
+	return events
+}
+
+// Validate checks all documented events
+func (c *Catalog) Validate() error {
+	var errs []error
+	for _, event := range c.Events() {
+		if err := event.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Markdown renders the catalog as a markdown document: a summary table of the events followed
+// by a section per event with its producers, consumers and payload schema
+func (c *Catalog) Markdown() []byte {
+	events := c.Events()
+
+	var b bytes.Buffer
+	b.WriteString("# Event catalog\n\n")
+	b.WriteString("<!-- Generated by \"events docs\", do not edit. -->\n\n")
+	b.WriteString("| Event | Version | Topic | Description |\n")
+	b.WriteString("|-------|---------|-------|-------------|\n")
+	for _, event := range events {
+		fmt.Fprintf(&b, "| [`%s`](#%s) | %d | `%s` | %s |\n", event.Type, anchor(event.Type), event.Version, event.Topic, event.Description)
+	}
+
+	for _, event := range events {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\n", event.Type, event.Description)
+		fmt.Fprintf(&b, "- Version: %d\n", event.Version)
+		fmt.Fprintf(&b, "- Topic: `%s`\n", event.Topic)
+		fmt.Fprintf(&b, "- Producers: %s\n", listComponents(event.Producers))
+		fmt.Fprintf(&b, "- Consumers: %s\n", listComponents(event.Consumers))
+
+		if len(event.Schema) > 0 {
+			fmt.Fprintf(&b, "\n```json\n%s\n```\n", indentJSON(event.Schema))
+		}
+	}
+	return b.Bytes()
+}
+
+// indentJSON reformats a JSON document with two space indentation, whatever its original layout
+func indentJSON(document []byte) []byte {
+	var compact, indented bytes.Buffer
+	if err := json.Compact(&compact, document); err != nil {
+		return document
+	}
+	if err := json.Indent(&indented, compact.Bytes(), "", "  "); err != nil {
+		return document
+	}
+	return indented.Bytes()
+}
+
+// listComponents formats components as a comma separated list of code spans
+func listComponents(components []string) string {
+	if len(components) == 0 {
+		return "none"
+	}
+	quoted := make([]string, len(components))
+	for i, component := range components {
+		quoted[i] = "`" + component + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// anchor returns the markdown heading anchor of an event type
+func anchor(eventType string) string {
+	return strings.ReplaceAll(strings.ToLower(eventType), ".", "")
+}
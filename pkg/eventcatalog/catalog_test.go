@@ -0,0 +1,64 @@
+package eventcatalog_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/eventcatalog"
+)
+
+func TestEvent_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   eventcatalog.Event
+		wantErr bool
+	}{
+		{name: "documented", event: eventcatalog.Event{Type: "order.created", Description: "An order was placed", Topic: "order-events", Producers: []string{"orders"}}},
+		{name: "no description", event: eventcatalog.Event{Type: "order.created", Topic: "order-events", Producers: []string{"orders"}}, wantErr: true},
+		{name: "no topic", event: eventcatalog.Event{Type: "order.created", Description: "An order was placed", Producers: []string{"orders"}}, wantErr: true},
+		{name: "no producer", event: eventcatalog.Event{Type: "order.created", Description: "An order was placed", Topic: "order-events"}, wantErr: true},
+		{name: "invalid schema", event: eventcatalog.Event{Type: "order.created", Description: "An order was placed", Topic: "order-events", Producers: []string{"orders"}, Schema: json.RawMessage(`{"type":`)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.event.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCatalog_Markdown(t *testing.T) {
+	catalog := eventcatalog.NewCatalog()
+	catalog.Register(
+		eventcatalog.Event{Type: "order.shipped", Version: 2, Description: "An order left the warehouse", Topic: "order-events", Producers: []string{"shipping"}},
+		eventcatalog.Event{Type: "order.created", Version: 1, Description: "An order was placed", Topic: "order-events",
+			Schema: json.RawMessage(`{"type": "object",  "required": ["order_id"]}`), Producers: []string{"orders"}, Consumers: []string{"billing", "shipping"}},
+	)
+	require.NoError(t, catalog.Validate())
+
+	event, ok := catalog.Get("order.created")
+	require.True(t, ok)
+	assert.Equal(t, []string{"billing", "shipping"}, event.Consumers)
+	_, ok = catalog.Get("order.cancelled")
+	assert.False(t, ok)
+
+	events := catalog.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "order.created", events[0].Type, "events are sorted by type")
+
+	markdown := string(catalog.Markdown())
+	assert.Contains(t, markdown, "| [`order.created`](#ordercreated) | 1 | `order-events` | An order was placed |")
+	assert.Contains(t, markdown, "- Consumers: `billing`, `shipping`")
+	assert.Contains(t, markdown, "- Consumers: none")
+	assert.Contains(t, markdown, "```json\n{\n  \"type\": \"object\",\n  \"required\": [\n    \"order_id\"\n  ]\n}\n```")
+	assert.Less(t, strings.Index(markdown, "## order.created"), strings.Index(markdown, "## order.shipped"))
+}
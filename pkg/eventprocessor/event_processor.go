@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"go-clean-ddd-es-template/pkg/id"
+	"go-clean-ddd-es-template/pkg/retry"
 )
 
 // Event represents a generic event
@@ -155,32 +158,26 @@ func (ep *EventProcessor) ProcessRawEvent(ctx context.Context, rawEvent []byte,
 	return ep.ProcessEvent(ctx, event)
 }
 
-// executeWithRetry executes a function with retry logic
+// executeWithRetry executes a function with retry logic, backing off exponentially until ctx is done
 func (ep *EventProcessor) executeWithRetry(ctx context.Context, fn func() error, event Event) error {
-	maxAttempts := 3
-	delay := time.Second
-
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if err := fn(); err == nil {
-			// Success - update metrics
-			ep.updateMetrics(event.GetType(), true)
-			return nil
-		} else {
-			lastErr = err
-			if attempt < maxAttempts {
-				ep.logger.Warn("Attempt %d failed for event %s, retrying in %v: %v",
-					attempt, event.GetType(), delay, err)
-				time.Sleep(delay)
-				delay *= 2 // Exponential backoff
-			}
-		}
+	err := retry.Retrier{
+		Policy: retry.DefaultPolicy(),
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			ep.logger.Warn("Attempt %d failed for event %s, retrying in %v: %v",
+				attempt, event.GetType(), delay, err)
+		},
+	}.Do(ctx, func(attempt int) error {
+		return fn()
+	})
+	if err != nil {
+		// All attempts failed - update metrics
+		ep.updateMetrics(event.GetType(), false)
+		return fmt.Errorf("failed to process event %s: %w", event.GetType(), err)
 	}
 
-	// All attempts failed - update metrics
-	ep.updateMetrics(event.GetType(), false)
-	return fmt.Errorf("failed to process event %s after %d attempts: %w",
-		event.GetType(), maxAttempts, lastErr)
+	// Success - update metrics
+	ep.updateMetrics(event.GetType(), true)
+	return nil
 }
 
 // updateMetrics updates event processing metrics
@@ -295,5 +292,5 @@ func (e *GenericEvent) GetID() string {
 
 // generateEventID generates a unique event ID
 func generateEventID() string {
-	return fmt.Sprintf("event_%d", time.Now().UnixNano())
+	return "event_" + id.NewULID()
 }
@@ -0,0 +1,196 @@
+// Package eventstream fans events out to the subscribers of event type patterns, e.g. the
+// WebSocket clients of the event gateway
+package eventstream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSlowSubscriber ends the subscriptions whose buffer filled up, instead of blocking publication
+var ErrSlowSubscriber = errors.New("subscriber is too slow, events were dropped")
+
+// Event is an event pushed to subscribers
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	TenantID      string          `json:"tenant_id,omitempty"`
+	UserID        string          `json:"user_id,omitempty"` // User the event is about, if any
+	Data          json.RawMessage `json:"data"`
+	Timestamp     time.Time       `json:"timestamp"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+}
+
+// ValidatePattern checks an event type pattern: an event type, "user.*" matching the types
+// starting with "user.", or "*" matching every type
+func ValidatePattern(pattern string) error {
+	prefix, wildcard := strings.CutSuffix(pattern, "*")
+	switch {
+	case pattern == "":
+		return errors.New("empty event type pattern")
+	case strings.Contains(prefix, "*"):
+		return fmt.Errorf("invalid event type pattern %q: * is only allowed at the end", pattern)
+	case wildcard && prefix != "" && !strings.HasSuffix(prefix, "."):
+		return fmt.Errorf("invalid event type pattern %q: * must follow a dot", pattern)
+	}
+	return nil
+}
+
+// Match reports whether an event type matches a pattern
+func Match(pattern, eventType string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return pattern == eventType
+}
+
+// Covers reports whether every event type matched by other is matched by pattern
+func Covers(pattern, other string) bool {
+	if prefix, ok := strings.CutSuffix(other, "*"); ok {
+		allowed, wildcard := strings.CutSuffix(pattern, "*")
+		return wildcard && strings.HasPrefix(prefix, allowed)
+	}
+	return Match(pattern, other)
+}
+
+// MatchAny reports whether an event type matches one of patterns
+func MatchAny(patterns []string, eventType string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hub delivers published events to the subscriptions of matching patterns
+type Hub struct {
+	mu            sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+	buffer        int
+}
+
+// NewHub creates a hub buffering up to buffer events per subscription
+func NewHub(buffer int) *Hub {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	return &Hub{
+		subscriptions: make(map[*Subscription]struct{}),
+		buffer:        buffer,
+	}
+}
+
+// Subscribe subscribes to the events matching patterns and accepted by filter, nil accepting
+// every event. The subscription must be closed once done.
+func (h *Hub) Subscribe(patterns []string, filter func(Event) bool) (*Subscription, error) {
+	if len(patterns) == 0 {
+		return nil, errors.New("at least one event type pattern is required")
+	}
+	for _, pattern := range patterns {
+		if err := ValidatePattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	subscription := &Subscription{
+		hub:      h,
+		patterns: patterns,
+		filter:   filter,
+		events:   make(chan Event, h.buffer),
+		done:     make(chan struct{}),
+	}
+	h.mu.Lock()
+	h.subscriptions[subscription] = struct{}{}
+	h.mu.Unlock()
+	return subscription, nil
+}
+
+// Publish delivers an event to the matching subscriptions, returning how many it was delivered
+// to. Subscriptions with a full buffer are ended with ErrSlowSubscriber.
+func (h *Hub) Publish(event Event) int {
+	delivered := 0
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for subscription := range h.subscriptions {
+		if !subscription.accepts(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+			delivered++
+		default:
+			slow = append(slow, subscription)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, subscription := range slow {
+		subscription.end(ErrSlowSubscriber)
+	}
+	return delivered
+}
+
+// Len returns the number of subscriptions
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscriptions)
+}
+
+// Subscription receives the events of a hub matching its patterns
+type Subscription struct {
+	hub      *Hub
+	patterns []string
+	filter   func(Event) bool
+	events   chan Event
+	done     chan struct{}
+	once     sync.Once
+	err      error
+}
+
+// Events returns the delivered events
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed once the subscription ended, by Close or by the hub; see Err
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the hub ended the subscription, nil while running or when closed
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.end(nil)
+}
+
+// accepts reports whether an event is delivered to the subscription
+func (s *Subscription) accepts(event Event) bool {
+	return MatchAny(s.patterns, event.Type) && (s.filter == nil || s.filter(event))
+}
+
+// end removes the subscription from its hub, recording err
+func (s *Subscription) end(err error) {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subscriptions, s)
+		s.hub.mu.Unlock()
+		s.err = err
+		close(s.done)
+	})
+}
@@ -0,0 +1,94 @@
+package eventstream_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/eventstream"
+)
+
+func TestMatch(t *testing.T) {
+	assert.True(t, eventstream.Match("*", "user.created"))
+	assert.True(t, eventstream.Match("user.*", "user.created"))
+	assert.False(t, eventstream.Match("user.*", "username.created"))
+	assert.True(t, eventstream.Match("user.created", "user.created"))
+	assert.False(t, eventstream.Match("user.created", "user.updated"))
+}
+
+func TestCovers(t *testing.T) {
+	assert.True(t, eventstream.Covers("*", "user.*"))
+	assert.True(t, eventstream.Covers("user.*", "user.*"))
+	assert.True(t, eventstream.Covers("user.*", "user.created"))
+	assert.True(t, eventstream.Covers("user.*", "user.preferences.*"))
+	assert.False(t, eventstream.Covers("user.*", "*"))
+	assert.False(t, eventstream.Covers("user.created", "user.*"))
+	assert.False(t, eventstream.Covers("product.*", "user.created"))
+}
+
+func TestValidatePattern(t *testing.T) {
+	for _, pattern := range []string{"*", "user.*", "user.created"} {
+		assert.NoError(t, eventstream.ValidatePattern(pattern), pattern)
+	}
+	for _, pattern := range []string{"", "user*", "*.created", "user.*.x"} {
+		assert.Error(t, eventstream.ValidatePattern(pattern), pattern)
+	}
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := eventstream.NewHub(4)
+
+	users, err := hub.Subscribe([]string{"user.*"}, nil)
+	require.NoError(t, err)
+	defer users.Close()
+	alice, err := hub.Subscribe([]string{"user.*", "product.*"}, func(event eventstream.Event) bool {
+		return event.UserID == "" || event.UserID == "alice"
+	})
+	require.NoError(t, err)
+	defer alice.Close()
+	assert.Equal(t, 2, hub.Len())
+
+	assert.Equal(t, 2, hub.Publish(eventstream.Event{Type: "user.updated", UserID: "alice"}))
+	assert.Equal(t, 1, hub.Publish(eventstream.Event{Type: "user.updated", UserID: "bob"}))
+	assert.Equal(t, 1, hub.Publish(eventstream.Event{Type: "product.created"}))
+	assert.Equal(t, 0, hub.Publish(eventstream.Event{Type: "order.created"}))
+
+	assert.Equal(t, "alice", (<-users.Events()).UserID)
+	assert.Equal(t, "bob", (<-users.Events()).UserID)
+	assert.Equal(t, "user.updated", (<-alice.Events()).Type)
+	assert.Equal(t, "product.created", (<-alice.Events()).Type)
+
+	_, err = hub.Subscribe([]string{"user*"}, nil)
+	assert.Error(t, err)
+	_, err = hub.Subscribe(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestHub_PublishEndsSlowSubscriptions(t *testing.T) {
+	hub := eventstream.NewHub(1)
+	slow, err := hub.Subscribe([]string{"*"}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, hub.Publish(eventstream.Event{Type: "user.created"}))
+	assert.NoError(t, slow.Err())
+	assert.Equal(t, 0, hub.Publish(eventstream.Event{Type: "user.updated"}))
+
+	<-slow.Done()
+	assert.ErrorIs(t, slow.Err(), eventstream.ErrSlowSubscriber)
+	assert.Equal(t, 0, hub.Len())
+	assert.Equal(t, "user.created", (<-slow.Events()).Type)
+}
+
+func TestSubscription_Close(t *testing.T) {
+	hub := eventstream.NewHub(1)
+	subscription, err := hub.Subscribe([]string{"*"}, nil)
+	require.NoError(t, err)
+
+	subscription.Close()
+	subscription.Close()
+	<-subscription.Done()
+	assert.NoError(t, subscription.Err())
+	assert.Equal(t, 0, hub.Len())
+	assert.Equal(t, 0, hub.Publish(eventstream.Event{Type: "user.created"}))
+}
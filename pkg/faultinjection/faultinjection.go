@@ -0,0 +1,125 @@
+package faultinjection
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInjected is wrapped by the errors of injected faults
+var ErrInjected = errors.New("injected failure")
+
+// Fault makes calls to a target fail, e.g. the handler of an event type
+type Fault struct {
+	Rate  float64 `json:"rate"`            // Fraction of the calls failing, from 0 to 1
+	Error string  `json:"error,omitempty"` // Message of the injected error
+}
+
+// Validate checks the rate of the fault
+func (f Fault) Validate() error {
+	if f.Rate < 0 || f.Rate > 1 {
+		return fmt.Errorf("fault rate must be between 0 and 1, got %v", f.Rate)
+	}
+	return nil
+}
+
+// String formats the fault like ParseFault parses it
+func (f Fault) String() string {
+	rate := strconv.FormatFloat(f.Rate, 'f', -1, 64)
+	if f.Error == "" {
+		return rate
+	}
+	return rate + ":" + f.Error
+}
+
+// ParseFault parses a "rate" or "rate:message" fault, e.g. "0.2" or "1:read model unavailable"
+func ParseFault(spec string) (Fault, error) {
+	rate, message, _ := strings.Cut(spec, ":")
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil {
+		return Fault{}, fmt.Errorf("invalid fault rate %q", rate)
+	}
+	fault := Fault{Rate: parsed, Error: strings.TrimSpace(message)}
+	return fault, fault.Validate()
+}
+
+// Injector injects the faults configured for targets
+type Injector struct {
+	mu     sync.RWMutex
+	faults map[string]Fault
+	random func() float64 // Returns a number in [0, 1)
+}
+
+// New creates an injector with the given faults by target
+func New(faults map[string]Fault) *Injector {
+	injector := &Injector{
+		faults: make(map[string]Fault, len(faults)),
+		random: rand.Float64,
+	}
+	for target, fault := range faults {
+		injector.faults[target] = fault
+	}
+	return injector
+}
+
+// Set configures the fault of a target, replacing any previous one
+func (i *Injector) Set(target string, fault Fault) error {
+	if err := fault.Validate(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults[target] = fault
+	return nil
+}
+
+// Clear removes the fault of a target
+func (i *Injector) Clear(target string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.faults, target)
+}
+
+// Faults returns a copy of the configured faults by target
+func (i *Injector) Faults() map[string]Fault {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	faults := make(map[string]Fault, len(i.faults))
+	for target, fault := range i.faults {
+		faults[target] = fault
+	}
+	return faults
+}
+
+// Inject returns an error wrapping ErrInjected when the call to target is picked to fail, nil
+// otherwise
+func (i *Injector) Inject(target string) error {
+	i.mu.RLock()
+	fault, ok := i.faults[target]
+	i.mu.RUnlock()
+	if !ok || fault.Rate <= 0 || i.random() >= fault.Rate {
+		return nil
+	}
+
+	if fault.Error != "" {
+		return fmt.Errorf("%w in %s: %s", ErrInjected, target, fault.Error)
+	}
+	return fmt.Errorf("%w in %s", ErrInjected, target)
+}
+
+// Global injector, without faults until set
+var globalInjector = New(nil)
+
+// Global returns the global injector
+func Global() *Injector {
+	return globalInjector
+}
+
+// SetGlobal sets the global injector
+func SetGlobal(injector *Injector) {
+	globalInjector = injector
+}
@@ -0,0 +1,45 @@
+package faultinjection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFault(t *testing.T) {
+	fault, err := ParseFault("0.25")
+	require.NoError(t, err)
+	assert.Equal(t, Fault{Rate: 0.25}, fault)
+
+	fault, err = ParseFault("1: read model unavailable")
+	require.NoError(t, err)
+	assert.Equal(t, Fault{Rate: 1, Error: "read model unavailable"}, fault)
+	assert.Equal(t, "1:read model unavailable", fault.String())
+
+	_, err = ParseFault("often")
+	assert.Error(t, err)
+	_, err = ParseFault("1.5")
+	assert.Error(t, err)
+}
+
+func TestInjector_Inject(t *testing.T) {
+	injector := New(map[string]Fault{"user.created": {Rate: 0.5, Error: "boom"}})
+	roll := 0.0
+	injector.random = func() float64 { return roll }
+
+	err := injector.Inject("user.created")
+	assert.ErrorIs(t, err, ErrInjected)
+	assert.ErrorContains(t, err, "user.created: boom")
+
+	roll = 0.5
+	assert.NoError(t, injector.Inject("user.created"), "calls past the rate succeed")
+	assert.NoError(t, injector.Inject("user.deleted"), "targets without a fault succeed")
+
+	require.NoError(t, injector.Set("user.deleted", Fault{Rate: 1}))
+	assert.ErrorIs(t, injector.Inject("user.deleted"), ErrInjected)
+	assert.Error(t, injector.Set("user.deleted", Fault{Rate: -1}))
+
+	injector.Clear("user.created")
+	assert.Equal(t, map[string]Fault{"user.deleted": {Rate: 1}}, injector.Faults())
+}
@@ -0,0 +1,86 @@
+package featureflags
+
+import (
+	"sort"
+	"sync"
+)
+
+// Flags holds runtime-toggleable feature flags
+type Flags struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// New creates a new flag set with the given defaults
+func New(defaults map[string]bool) *Flags {
+	flags := make(map[string]bool, len(defaults))
+	for name, enabled := range defaults {
+		flags[name] = enabled
+	}
+	return &Flags{flags: flags}
+}
+
+// Enabled reports whether a flag is enabled. Unknown flags are disabled.
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[name]
+}
+
+// Set enables or disables a flag
+func (f *Flags) Set(name string, enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flags[name] = enabled
+}
+
+// Toggle flips a flag and returns its new value
+func (f *Flags) Toggle(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flags[name] = !f.flags[name]
+	return f.flags[name]
+}
+
+// All returns a copy of all flags
+func (f *Flags) All() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flags := make(map[string]bool, len(f.flags))
+	for name, enabled := range f.flags {
+		flags[name] = enabled
+	}
+	return flags
+}
+
+// Names returns the sorted flag names
+func (f *Flags) Names() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	names := make([]string, 0, len(f.flags))
+	for name := range f.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Global flag set
+var globalFlags = New(nil)
+
+// Global returns the global flag set
+func Global() *Flags {
+	return globalFlags
+}
+
+// SetGlobal sets the global flag set
+func SetGlobal(flags *Flags) {
+	globalFlags = flags
+}
+
+// Enabled reports whether a flag is enabled in the global flag set
+func Enabled(name string) bool {
+	return globalFlags.Enabled(name)
+}
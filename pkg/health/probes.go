@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Services of the gRPC health checking protocol the probes report, besides the overall "" service
+// reporting readiness
+const (
+	LivenessService  = "liveness"
+	ReadinessService = "readiness"
+)
+
+// Probes serves the liveness and readiness of the service to Kubernetes, at /healthz and /readyz
+// and over the gRPC health checking protocol. Liveness should only fail when the process must be
+// restarted; readiness fails while a dependency requests need is down, and for good once the
+// service drains on shutdown.
+type Probes struct {
+	liveness  *HealthService
+	readiness *HealthService
+	draining  atomic.Bool
+	grpc      *grpchealth.Server
+}
+
+// NewProbes creates the probes of the liveness and readiness checks, adding the check failing
+// readiness once the service drains to readiness
+func NewProbes(liveness, readiness *HealthService) *Probes {
+	p := &Probes{
+		liveness:  liveness,
+		readiness: readiness,
+		grpc:      grpchealth.NewServer(),
+	}
+	readiness.AddCheck(p.drainCheck)
+	return p
+}
+
+// drainCheck fails once the service drains
+func (p *Probes) drainCheck(ctx context.Context) Check {
+	if p.draining.Load() {
+		return Check{Name: "shutdown", Status: StatusUnhealthy, Message: "Service is shutting down"}
+	}
+	return Check{Name: "shutdown", Status: StatusHealthy, Message: "Service is accepting requests"}
+}
+
+// LivenessHandler serves the liveness checks, answering 503 when one is unhealthy
+func (p *Probes) LivenessHandler() http.HandlerFunc {
+	return p.liveness.HTTPHandler()
+}
+
+// ReadinessHandler serves the readiness checks, answering 503 when one is unhealthy
+func (p *Probes) ReadinessHandler() http.HandlerFunc {
+	return p.readiness.HTTPHandler()
+}
+
+// GRPCServer returns the server of the gRPC health checking protocol, to register on the gRPC
+// server. Its statuses are refreshed by Run.
+func (p *Probes) GRPCServer() healthpb.HealthServer {
+	return p.grpc
+}
+
+// Run refreshes the gRPC health statuses from the checks every interval until ctx is done
+func (p *Probes) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh runs the checks and updates the gRPC health statuses
+func (p *Probes) Refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	p.grpc.SetServingStatus(LivenessService, servingStatus(p.liveness.OverallStatus(p.liveness.Check(ctx))))
+	readiness := servingStatus(p.readiness.OverallStatus(p.readiness.Check(ctx)))
+	if p.draining.Load() {
+		// Drained while the checks ran
+		readiness = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	p.grpc.SetServingStatus(ReadinessService, readiness)
+	p.grpc.SetServingStatus("", readiness)
+}
+
+// Drain makes readiness fail for good, so the service is taken out of rotation before it stops
+func (p *Probes) Drain() {
+	p.draining.Store(true)
+	p.grpc.SetServingStatus(ReadinessService, healthpb.HealthCheckResponse_NOT_SERVING)
+	p.grpc.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// servingStatus returns the gRPC health status of an overall status; degraded services still serve
+func servingStatus(status Status) healthpb.HealthCheckResponse_ServingStatus {
+	if status == StatusUnhealthy {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
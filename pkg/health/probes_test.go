@@ -0,0 +1,74 @@
+package health_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func serve(handler http.HandlerFunc) int {
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	return recorder.Code
+}
+
+func grpcStatus(t *testing.T, probes *health.Probes, service string) healthpb.HealthCheckResponse_ServingStatus {
+	response, err := probes.GRPCServer().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return response.Status
+}
+
+func TestProbes(t *testing.T) {
+	var brokerDown atomic.Bool
+	liveness := health.NewHealthService()
+	liveness.AddCheck(health.SystemCheck())
+	readiness := health.NewHealthService()
+	readiness.AddCheck(func(ctx context.Context) health.Check {
+		if brokerDown.Load() {
+			return health.Check{Name: "broker", Status: health.StatusUnhealthy}
+		}
+		return health.Check{Name: "broker", Status: health.StatusHealthy}
+	})
+	probes := health.NewProbes(liveness, readiness)
+
+	probes.Refresh(context.Background())
+	assert.Equal(t, http.StatusOK, serve(probes.LivenessHandler()))
+	assert.Equal(t, http.StatusOK, serve(probes.ReadinessHandler()))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, probes, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, probes, health.ReadinessService))
+
+	// Dependencies only fail readiness
+	brokerDown.Store(true)
+	probes.Refresh(context.Background())
+	assert.Equal(t, http.StatusOK, serve(probes.LivenessHandler()))
+	assert.Equal(t, http.StatusServiceUnavailable, serve(probes.ReadinessHandler()))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, probes, health.LivenessService))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, probes, ""))
+
+	brokerDown.Store(false)
+	probes.Refresh(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, probes, health.ReadinessService))
+}
+
+func TestProbes_Drain(t *testing.T) {
+	probes := health.NewProbes(health.NewHealthService(), health.NewHealthService())
+	probes.Refresh(context.Background())
+
+	probes.Drain()
+	assert.Equal(t, http.StatusServiceUnavailable, serve(probes.ReadinessHandler()))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, probes, ""))
+
+	// Draining is for good and leaves liveness alone
+	probes.Refresh(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, probes, health.ReadinessService))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, probes, health.LivenessService))
+	assert.Equal(t, http.StatusOK, serve(probes.LivenessHandler()))
+}
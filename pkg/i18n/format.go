@@ -0,0 +1,170 @@
+package i18n
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// fallbackFormatLocale is used for locales without a registered format
+const fallbackFormatLocale = "en"
+
+// LocaleFormat holds the display conventions of a locale
+type LocaleFormat struct {
+	DateTimeLayout   string // Go layout of timestamps, e.g. "Jan 2, 2006 3:04 PM"
+	DateLayout       string // Go layout of dates
+	DecimalSeparator string
+	GroupSeparator   string // Thousands separator
+}
+
+var (
+	formatsMu     sync.RWMutex
+	localeFormats = map[string]LocaleFormat{
+		"en": {DateTimeLayout: "Jan 2, 2006 3:04 PM", DateLayout: "Jan 2, 2006", DecimalSeparator: ".", GroupSeparator: ","},
+		"vi": {DateTimeLayout: "15:04 02/01/2006", DateLayout: "02/01/2006", DecimalSeparator: ",", GroupSeparator: "."},
+	}
+)
+
+// RegisterLocaleFormat adds or replaces the display conventions of a locale
+func RegisterLocaleFormat(locale string, format LocaleFormat) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	localeFormats[locale] = format
+}
+
+// MatchLocale returns the best locale with a registered format for an Accept-Language
+// header, or an empty string when none matches
+func MatchLocale(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return ""
+	}
+
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, tag := range tags {
+		if _, ok := localeFormats[tag.String()]; ok {
+			return tag.String()
+		}
+		base, _ := tag.Base()
+		if _, ok := localeFormats[base.String()]; ok {
+			return base.String()
+		}
+	}
+	return ""
+}
+
+// Formatter formats timestamps and numbers for display in a locale and time zone
+type Formatter struct {
+	locale   string
+	format   LocaleFormat
+	location *time.Location
+}
+
+// NewFormatter creates a formatter for locale, falling back to English conventions for
+// unknown locales. A nil location displays timestamps in UTC.
+func NewFormatter(locale string, location *time.Location) *Formatter {
+	formatsMu.RLock()
+	format, ok := localeFormats[locale]
+	if !ok {
+		locale = fallbackFormatLocale
+		format = localeFormats[fallbackFormatLocale]
+	}
+	formatsMu.RUnlock()
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &Formatter{
+		locale:   locale,
+		format:   format,
+		location: location,
+	}
+}
+
+// Locale returns the locale whose conventions the formatter uses
+func (f *Formatter) Locale() string {
+	return f.locale
+}
+
+// FormatDateTime formats a timestamp
+func (f *Formatter) FormatDateTime(t time.Time) string {
+	return t.In(f.location).Format(f.format.DateTimeLayout)
+}
+
+// FormatDate formats the date part of a timestamp
+func (f *Formatter) FormatDate(t time.Time) string {
+	return t.In(f.location).Format(f.format.DateLayout)
+}
+
+// FormatInteger formats an integer with grouped thousands
+func (f *Formatter) FormatInteger(value int64) string {
+	digits := strconv.FormatInt(value, 10)
+	sign := ""
+	if value < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	return sign + f.group(digits)
+}
+
+// FormatNumber formats a number rounded to decimals fraction digits with grouped thousands
+func (f *Formatter) FormatNumber(value float64, decimals int) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	formatted := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(formatted, ".")
+
+	result := f.group(integer)
+	if fraction != "" {
+		result += f.format.DecimalSeparator + fraction
+	}
+	if value < 0 && strings.Trim(formatted, "0.") != "" {
+		result = "-" + result
+	}
+	return result
+}
+
+// group inserts the group separator every three digits
+func (f *Formatter) group(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(f.format.GroupSeparator)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// formatterKey is the context key of the display formatter
+type formatterKey struct{}
+
+// WithFormatter returns a context carrying the display formatter of the request
+func WithFormatter(ctx context.Context, formatter *Formatter) context.Context {
+	return context.WithValue(ctx, formatterKey{}, formatter)
+}
+
+// FormatterFromContext returns the display formatter of the request, or nil when the
+// caller did not ask for localized display values
+func FormatterFromContext(ctx context.Context) *Formatter {
+	formatter, _ := ctx.Value(formatterKey{}).(*Formatter)
+	return formatter
+}
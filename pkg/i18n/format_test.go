@@ -0,0 +1,53 @@
+package i18n_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/pkg/i18n"
+)
+
+func TestFormatter_FormatDateTime(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 14, 7, 0, 0, time.UTC)
+	saigon := time.FixedZone("ICT", 7*60*60)
+
+	assert.Equal(t, "Mar 5, 2024 2:07 PM", i18n.NewFormatter("en", nil).FormatDateTime(ts))
+	assert.Equal(t, "21:07 05/03/2024", i18n.NewFormatter("vi", saigon).FormatDateTime(ts))
+	assert.Equal(t, "05/03/2024", i18n.NewFormatter("vi", nil).FormatDate(ts))
+
+	// Unknown locales use English conventions
+	formatter := i18n.NewFormatter("xx", nil)
+	assert.Equal(t, "en", formatter.Locale())
+	assert.Equal(t, "Mar 5, 2024", formatter.FormatDate(ts))
+}
+
+func TestFormatter_FormatNumber(t *testing.T) {
+	en := i18n.NewFormatter("en", nil)
+	vi := i18n.NewFormatter("vi", nil)
+
+	assert.Equal(t, "1,234,567", en.FormatInteger(1234567))
+	assert.Equal(t, "-1.234.567", vi.FormatInteger(-1234567))
+	assert.Equal(t, "999", en.FormatInteger(999))
+	assert.Equal(t, "1,234.57", en.FormatNumber(1234.567, 2))
+	assert.Equal(t, "1.234,57", vi.FormatNumber(1234.567, 2))
+	assert.Equal(t, "-0.5", en.FormatNumber(-0.5, 1))
+	assert.Equal(t, "0", en.FormatNumber(-0.0001, 0))
+}
+
+func TestMatchLocale(t *testing.T) {
+	assert.Equal(t, "vi", i18n.MatchLocale("vi-VN,vi;q=0.9,en;q=0.8"))
+	assert.Equal(t, "en", i18n.MatchLocale("fr-FR,en-US;q=0.8"))
+	assert.Equal(t, "", i18n.MatchLocale("fr-FR"))
+	assert.Equal(t, "", i18n.MatchLocale(""))
+}
+
+func TestFormatterFromContext(t *testing.T) {
+	assert.Nil(t, i18n.FormatterFromContext(context.Background()))
+
+	formatter := i18n.NewFormatter("vi", nil)
+	ctx := i18n.WithFormatter(context.Background(), formatter)
+	assert.Same(t, formatter, i18n.FormatterFromContext(ctx))
+}
@@ -92,6 +92,11 @@ func (t *Translator) Translate(key string, locale string, args ...interface{}) s
 	return translation
 }
 
+// DefaultLocale returns the locale used for keys missing in the requested locale
+func (t *Translator) DefaultLocale() string {
+	return t.defaultLocale
+}
+
 // getTranslation gets a translation for a specific locale
 func (t *Translator) getTranslation(key string, locale string) (string, bool) {
 	localeTranslations, exists := t.translations[locale]
@@ -0,0 +1,214 @@
+package id
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// maxTimestamp is the largest millisecond timestamp that fits in 48 bits
+const maxTimestamp = 1<<48 - 1
+
+// Generator creates time ordered identifiers. IDs created by the same generator are
+// strictly increasing, even when several are created within one millisecond or the
+// clock moves backwards.
+type Generator struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	entropy io.Reader
+
+	ulidMs   uint64
+	ulidRand [10]byte
+
+	uuidMs  uint64
+	uuidSeq uint16
+}
+
+// NewGenerator creates a generator that reads time from c and randomness from crypto/rand.
+// A nil clock uses the system clock.
+func NewGenerator(c clock.Clock) *Generator {
+	return NewGeneratorWithEntropy(c, rand.Reader)
+}
+
+// NewGeneratorWithEntropy creates a generator with a custom randomness source
+func NewGeneratorWithEntropy(c clock.Clock, entropy io.Reader) *Generator {
+	return &Generator{clock: clock.OrDefault(c), entropy: entropy}
+}
+
+var defaultGenerator = NewGenerator(nil)
+
+// NewULID returns a new ULID from the default generator
+func NewULID() string {
+	return defaultGenerator.ULID()
+}
+
+// NewUUIDv7 returns a new UUIDv7 from the default generator
+func NewUUIDv7() string {
+	return defaultGenerator.UUIDv7()
+}
+
+// ULID returns a new 26 character ULID: a 48 bit millisecond timestamp followed by
+// 80 bits of randomness. Within one millisecond the random part is incremented.
+func (g *Generator) ULID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.timestamp()
+	if ms <= g.ulidMs {
+		ms = g.ulidMs
+		if !increment(g.ulidRand[:]) {
+			ms++
+			g.fill(g.ulidRand[:])
+		}
+	} else {
+		g.fill(g.ulidRand[:])
+	}
+	g.ulidMs = ms
+
+	var b [16]byte
+	putUint48(b[:6], ms)
+	copy(b[6:], g.ulidRand[:])
+	return encodeULID(b)
+}
+
+// UUIDv7 returns a new RFC 9562 version 7 UUID. The 12 bit rand_a field is used as a
+// counter so UUIDs created within one millisecond keep their creation order.
+func (g *Generator) UUIDv7() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.timestamp()
+	if ms <= g.uuidMs {
+		ms = g.uuidMs
+		g.uuidSeq++
+		if g.uuidSeq > 0x0fff {
+			ms++
+			g.uuidSeq = 0
+		}
+	} else {
+		var seq [2]byte
+		g.fill(seq[:])
+		// Start in the lower half of the range to leave room for increments
+		g.uuidSeq = binary.BigEndian.Uint16(seq[:]) & 0x07ff
+	}
+	g.uuidMs = ms
+
+	var b [16]byte
+	putUint48(b[:6], ms)
+	binary.BigEndian.PutUint16(b[6:8], 0x7000|g.uuidSeq)
+	g.fill(b[8:])
+	b[8] = b[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// ULIDTime returns the creation time encoded in a ULID
+func ULIDTime(ulid string) (time.Time, error) {
+	if len(ulid) != 26 {
+		return time.Time{}, fmt.Errorf("invalid ULID length: %d", len(ulid))
+	}
+	if ulid[0] > '7' {
+		return time.Time{}, fmt.Errorf("invalid ULID: timestamp overflow")
+	}
+
+	var ms uint64
+	for i := 0; i < 10; i++ {
+		v := decodeChar(ulid[i])
+		if v < 0 {
+			return time.Time{}, fmt.Errorf("invalid ULID character: %q", ulid[i])
+		}
+		ms = ms<<5 | uint64(v)
+	}
+	for i := 10; i < 26; i++ {
+		if decodeChar(ulid[i]) < 0 {
+			return time.Time{}, fmt.Errorf("invalid ULID character: %q", ulid[i])
+		}
+	}
+	return time.UnixMilli(int64(ms)).UTC(), nil
+}
+
+// timestamp returns the current clock time in milliseconds since the Unix epoch
+func (g *Generator) timestamp() uint64 {
+	ms := g.clock.Now().UnixMilli()
+	if ms < 0 {
+		return 0
+	}
+	if ms > maxTimestamp {
+		return maxTimestamp
+	}
+	return uint64(ms)
+}
+
+// fill reads random bytes into b
+func (g *Generator) fill(b []byte) {
+	if _, err := io.ReadFull(g.entropy, b); err != nil {
+		panic(fmt.Sprintf("id: failed to read entropy: %v", err))
+	}
+}
+
+// increment adds one to the big endian number in b and reports false on overflow
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// putUint48 writes the low 48 bits of v to b in big endian order
+func putUint48(b []byte, v uint64) {
+	b[0] = byte(v >> 40)
+	b[1] = byte(v >> 32)
+	b[2] = byte(v >> 24)
+	b[3] = byte(v >> 16)
+	b[4] = byte(v >> 8)
+	b[5] = byte(v)
+}
+
+// encodeULID encodes 128 bits as 26 Crockford base32 characters
+func encodeULID(b [16]byte) string {
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// decodeChar returns the value of a Crockford base32 character, or -1 if invalid
+func decodeChar(c byte) int {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	for i := 0; i < len(crockford); i++ {
+		if crockford[i] == c {
+			return i
+		}
+	}
+	return -1
+}
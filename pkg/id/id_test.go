@@ -0,0 +1,106 @@
+package id_test
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
+)
+
+var start = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+func TestGenerator_ULIDMonotonic(t *testing.T) {
+	fake := clock.NewFake(start)
+	gen := id.NewGenerator(fake)
+
+	ids := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ids = append(ids, gen.ULID())
+	}
+
+	assert.True(t, sort.StringsAreSorted(ids))
+	assertUnique(t, ids)
+	for _, ulid := range ids {
+		assert.Len(t, ulid, 26)
+	}
+
+	created, err := id.ULIDTime(ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, start, created)
+}
+
+func TestGenerator_ULIDClockBackwards(t *testing.T) {
+	fake := clock.NewFake(start)
+	gen := id.NewGenerator(fake)
+
+	first := gen.ULID()
+	fake.Set(start.Add(-time.Hour))
+	second := gen.ULID()
+
+	assert.Less(t, first, second)
+}
+
+func TestGenerator_ULIDOverflow(t *testing.T) {
+	fake := clock.NewFake(start)
+	gen := id.NewGeneratorWithEntropy(fake, bytes.NewReader(bytes.Repeat([]byte{0xff}, 64)))
+
+	first := gen.ULID()
+	second := gen.ULID()
+	assert.Less(t, first, second)
+
+	created, err := id.ULIDTime(second)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(time.Millisecond), created)
+}
+
+func TestGenerator_UUIDv7(t *testing.T) {
+	fake := clock.NewFake(start)
+	gen := id.NewGenerator(fake)
+
+	ids := make([]string, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		ids = append(ids, gen.UUIDv7())
+	}
+
+	assert.True(t, sort.StringsAreSorted(ids))
+	assertUnique(t, ids)
+
+	parsed, err := uuid.Parse(ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+	assert.Equal(t, uuid.RFC4122, parsed.Variant())
+
+	sec, nsec := parsed.Time().UnixTime()
+	assert.Equal(t, start, time.Unix(sec, nsec).UTC())
+}
+
+func TestNewULIDAndUUIDv7(t *testing.T) {
+	assert.NotEqual(t, id.NewULID(), id.NewULID())
+
+	_, err := uuid.Parse(id.NewUUIDv7())
+	assert.NoError(t, err)
+}
+
+func TestULIDTime_Invalid(t *testing.T) {
+	for _, value := range []string{"", "short", "8ZZZZZZZZZZZZZZZZZZZZZZZZZ", "01HKQ8Z0U0000000000000000!"} {
+		_, err := id.ULIDTime(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func assertUnique(t *testing.T, ids []string) {
+	t.Helper()
+	seen := make(map[string]struct{}, len(ids))
+	for _, value := range ids {
+		_, dup := seen[value]
+		assert.False(t, dup, "duplicate id %s", value)
+		seen[value] = struct{}{}
+	}
+}
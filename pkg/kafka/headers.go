@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Standard message header keys
+const (
+	HeaderTraceParent   = "traceparent"     // W3C trace context of the request that produced the message
+	HeaderTenantID      = "tenant-id"       // Tenant of the event, absent for single tenant events
+	HeaderSchema        = "schema"          // Payload schema, the event type for domain events
+	HeaderContentType   = "content-type"    // Payload encoding
+	HeaderRetryCount    = "retry-count"     // Times the message was redelivered through a retry topic
+	HeaderOriginalTopic = "original-topic"  // Topic the message was first consumed from
+	HeaderFirstFailure  = "first-failure"   // RFC 3339 time the message first failed processing
+	HeaderLastError     = "last-error"      // Error code of the latest failed processing
+	HeaderConsumerGroup = "consumer-group"  // Consumer group whose processing failed
+	HeaderIdempotency   = "idempotency-key" // Key identifying the message across redeliveries, the event ID for domain events
+	HeaderCorrelationID = "correlation-id"  // Request or workflow the message is part of, shared by the messages it causes
+	HeaderCausationID   = "causation-id"    // ID of the request or message that caused the message
+	HeaderCommandID     = "command-id"      // ID of the command the message delivers, shared by its redeliveries and resubmissions
+)
+
+// ContentTypeJSON is the content type of JSON encoded events
+const ContentTypeJSON = "application/json"
+
+// Headers holds message headers by key. It implements the OpenTelemetry TextMapCarrier,
+// so trace context can be injected into and extracted from it directly.
+type Headers map[string][]byte
+
+// HeadersFromRecords converts consumed Kafka record headers
+func HeadersFromRecords(records []*sarama.RecordHeader) Headers {
+	headers := make(Headers, len(records))
+	for _, record := range records {
+		if record != nil {
+			headers[string(record.Key)] = record.Value
+		}
+	}
+	return headers
+}
+
+// Records converts headers to Kafka record headers sorted by key
+func (h Headers) Records() []sarama.RecordHeader {
+	keys := h.Keys()
+	sort.Strings(keys)
+
+	records := make([]sarama.RecordHeader, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, sarama.RecordHeader{Key: []byte(key), Value: h[key]})
+	}
+	return records
+}
+
+// Get returns the value of a header, or an empty string when it is missing
+func (h Headers) Get(key string) string {
+	return string(h[key])
+}
+
+// Set sets a header; empty values remove it
+func (h Headers) Set(key, value string) {
+	if value == "" {
+		delete(h, key)
+		return
+	}
+	h[key] = []byte(value)
+}
+
+// Keys returns the header keys
+func (h Headers) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Clone returns a copy of the headers
+func (h Headers) Clone() Headers {
+	clone := make(Headers, len(h))
+	for key, value := range h {
+		clone[key] = value
+	}
+	return clone
+}
+
+// MarshalJSON encodes the headers as an object of string values
+func (h Headers) MarshalJSON() ([]byte, error) {
+	values := make(map[string]string, len(h))
+	for key := range h {
+		values[key] = h.Get(key)
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON decodes headers encoded by MarshalJSON
+func (h *Headers) UnmarshalJSON(data []byte) error {
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*h = make(Headers, len(values))
+	for key, value := range values {
+		h.Set(key, value)
+	}
+	return nil
+}
+
+// TraceParent returns the W3C trace context header
+func (h Headers) TraceParent() string {
+	return h.Get(HeaderTraceParent)
+}
+
+// CorrelationID returns the request or workflow the message is part of
+func (h Headers) CorrelationID() string {
+	return h.Get(HeaderCorrelationID)
+}
+
+// CausationID returns the ID of the request or message that caused the message
+func (h Headers) CausationID() string {
+	return h.Get(HeaderCausationID)
+}
+
+// CommandID returns the ID of the command the message delivers
+func (h Headers) CommandID() string {
+	return h.Get(HeaderCommandID)
+}
+
+// TenantID returns the tenant of the message
+func (h Headers) TenantID() string {
+	return h.Get(HeaderTenantID)
+}
+
+// Schema returns the payload schema
+func (h Headers) Schema() string {
+	return h.Get(HeaderSchema)
+}
+
+// IdempotencyKey returns the key identifying the message across redeliveries
+func (h Headers) IdempotencyKey() string {
+	return h.Get(HeaderIdempotency)
+}
+
+// ContentType returns the payload encoding
+func (h Headers) ContentType() string {
+	return h.Get(HeaderContentType)
+}
+
+// OriginalTopic returns the topic the message was first consumed from
+func (h Headers) OriginalTopic() string {
+	return h.Get(HeaderOriginalTopic)
+}
+
+// RetryCount returns how often the message was redelivered; missing or invalid values count as zero
+func (h Headers) RetryCount() int {
+	count, err := strconv.Atoi(h.Get(HeaderRetryCount))
+	if err != nil || count < 0 {
+		return 0
+	}
+	return count
+}
+
+// SetRetryCount sets how often the message was redelivered
+func (h Headers) SetRetryCount(count int) {
+	h.Set(HeaderRetryCount, strconv.Itoa(count))
+}
+
+// FirstFailure returns when the message first failed processing, or the zero time
+func (h Headers) FirstFailure() time.Time {
+	firstFailure, err := time.Parse(time.RFC3339Nano, h.Get(HeaderFirstFailure))
+	if err != nil {
+		return time.Time{}
+	}
+	return firstFailure
+}
+
+// LastError returns the error code of the latest failed processing
+func (h Headers) LastError() string {
+	return h.Get(HeaderLastError)
+}
+
+// ConsumerGroup returns the consumer group whose processing failed
+func (h Headers) ConsumerGroup() string {
+	return h.Get(HeaderConsumerGroup)
+}
+
+// RecordFailure stamps a failed processing attempt by consumerGroup at time at. The first
+// failure time is kept from earlier attempts.
+func (h Headers) RecordFailure(errorCode, consumerGroup string, at time.Time) {
+	if h.FirstFailure().IsZero() {
+		h.Set(HeaderFirstFailure, at.UTC().Format(time.RFC3339Nano))
+	}
+	h.Set(HeaderLastError, errorCode)
+	h.Set(HeaderConsumerGroup, consumerGroup)
+}
+
+// headersKey is the context key of the headers of the message being handled
+type headersKey struct{}
+
+// ContextWithHeaders returns a context carrying the headers of the message being handled
+func ContextWithHeaders(ctx context.Context, headers Headers) context.Context {
+	return context.WithValue(ctx, headersKey{}, headers)
+}
+
+// HeadersFromContext returns the headers of the message being handled. Outside of message
+// handling it returns nil headers, whose accessors return zero values.
+func HeadersFromContext(ctx context.Context) Headers {
+	headers, _ := ctx.Value(headersKey{}).(Headers)
+	return headers
+}
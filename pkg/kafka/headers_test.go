@@ -0,0 +1,101 @@
+package kafka_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+
+	"go-clean-ddd-es-template/pkg/kafka"
+)
+
+func TestHeaders_Accessors(t *testing.T) {
+	headers := kafka.HeadersFromRecords([]*sarama.RecordHeader{
+		{Key: []byte(kafka.HeaderTenantID), Value: []byte("acme")},
+		{Key: []byte(kafka.HeaderSchema), Value: []byte("user.created")},
+		{Key: []byte(kafka.HeaderContentType), Value: []byte(kafka.ContentTypeJSON)},
+		{Key: []byte(kafka.HeaderRetryCount), Value: []byte("2")},
+		nil,
+	})
+
+	assert.Equal(t, "acme", headers.TenantID())
+	assert.Equal(t, "user.created", headers.Schema())
+	assert.Equal(t, kafka.ContentTypeJSON, headers.ContentType())
+	assert.Equal(t, 2, headers.RetryCount())
+	assert.Equal(t, "", headers.TraceParent())
+	assert.Equal(t, "", headers.CommandID())
+
+	headers.SetRetryCount(3)
+	assert.Equal(t, 3, headers.RetryCount())
+	headers.Set(kafka.HeaderRetryCount, "invalid")
+	assert.Equal(t, 0, headers.RetryCount())
+
+	headers.Set(kafka.HeaderTenantID, "")
+	_, ok := headers[kafka.HeaderTenantID]
+	assert.False(t, ok)
+
+	records := headers.Records()
+	assert.Len(t, records, 3)
+	assert.Equal(t, kafka.HeaderContentType, string(records[0].Key))
+}
+
+func TestHeaders_TraceContext(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	propagator := propagation.TraceContext{}
+
+	consumed := kafka.Headers{}
+	consumed.Set(kafka.HeaderTraceParent, traceParent)
+	ctx := propagator.Extract(context.Background(), consumed)
+
+	published := kafka.Headers{}
+	propagator.Inject(ctx, published)
+	assert.Equal(t, traceParent, published.TraceParent())
+}
+
+func TestHeadersFromContext(t *testing.T) {
+	// Accessors of missing headers return zero values
+	missing := kafka.HeadersFromContext(context.Background())
+	assert.Nil(t, missing)
+	assert.Equal(t, 0, missing.RetryCount())
+	assert.Equal(t, "", missing.TenantID())
+
+	headers := kafka.Headers{kafka.HeaderTenantID: []byte("acme")}
+	ctx := kafka.ContextWithHeaders(context.Background(), headers)
+	assert.Equal(t, "acme", kafka.HeadersFromContext(ctx).TenantID())
+}
+
+func TestHeaders_RecordFailure(t *testing.T) {
+	first := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	headers := kafka.Headers{}
+	assert.True(t, headers.FirstFailure().IsZero())
+
+	headers.RecordFailure("DATABASE_ERROR", "projections", first)
+	assert.Equal(t, first, headers.FirstFailure())
+	assert.Equal(t, "DATABASE_ERROR", headers.LastError())
+	assert.Equal(t, "projections", headers.ConsumerGroup())
+
+	// Later failures keep the first failure time
+	headers.RecordFailure("TIMEOUT", "audit", first.Add(time.Minute))
+	assert.Equal(t, first, headers.FirstFailure())
+	assert.Equal(t, "TIMEOUT", headers.LastError())
+	assert.Equal(t, "audit", headers.ConsumerGroup())
+}
+
+func TestHeaders_JSON(t *testing.T) {
+	headers := kafka.Headers{}
+	headers.Set(kafka.HeaderTenantID, "acme")
+	headers.SetRetryCount(2)
+
+	encoded, err := json.Marshal(headers)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tenant-id":"acme","retry-count":"2"}`, string(encoded))
+
+	var decoded kafka.Headers
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, headers, decoded)
+}
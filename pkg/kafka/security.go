@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// SASL mechanisms supported by SecurityConfig
+const (
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	SASLMechanismSCRAMSHA512 = "SCRAM-SHA-512"
+)
+
+// SecurityConfig holds the identity, authentication and encryption of the connections of a Kafka
+// client. The zero value connects anonymously in plaintext, like sarama's defaults.
+type SecurityConfig struct {
+	ClientID string // Client identity reported to the brokers, sarama's default when empty
+
+	SASLMechanism string // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, empty disables SASL
+	SASLUsername  string
+	SASLPassword  string
+
+	TLSEnabled            bool
+	TLSCAFile             string // PEM CA certificates the brokers are verified against, the system pool when empty
+	TLSCertFile           string // PEM client certificate for mutual TLS, together with TLSKeyFile
+	TLSKeyFile            string
+	TLSServerName         string // Name the broker certificates are verified against, the broker host when empty
+	TLSInsecureSkipVerify bool   // Skips the verification of broker certificates, for development only
+}
+
+// Validate checks the configuration is complete, without reading the certificate files
+func (c SecurityConfig) Validate() error {
+	switch c.SASLMechanism {
+	case "":
+	case SASLMechanismPlain, SASLMechanismSCRAMSHA256, SASLMechanismSCRAMSHA512:
+		if c.SASLUsername == "" || c.SASLPassword == "" {
+			return fmt.Errorf("SASL mechanism %s requires a username and a password", c.SASLMechanism)
+		}
+	default:
+		return fmt.Errorf("unsupported SASL mechanism %q, expected %s, %s or %s", c.SASLMechanism, SASLMechanismPlain, SASLMechanismSCRAMSHA256, SASLMechanismSCRAMSHA512)
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS client certificate and key must be set together")
+	}
+	if !c.TLSEnabled && (c.TLSCAFile != "" || c.TLSCertFile != "" || c.TLSServerName != "" || c.TLSInsecureSkipVerify) {
+		return fmt.Errorf("TLS settings require TLS to be enabled")
+	}
+	return nil
+}
+
+// Apply sets the client identity, SASL and TLS settings of saramaConfig, loading the certificate
+// files
+func (c SecurityConfig) Apply(saramaConfig *sarama.Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if c.ClientID != "" {
+		saramaConfig.ClientID = c.ClientID
+	}
+
+	if c.SASLMechanism != "" {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.Handshake = true
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLMechanism(c.SASLMechanism)
+		saramaConfig.Net.SASL.User = c.SASLUsername
+		saramaConfig.Net.SASL.Password = c.SASLPassword
+		switch c.SASLMechanism {
+		case SASLMechanismSCRAMSHA256:
+			saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hashGenerator: scram.SHA256}
+			}
+		case SASLMechanismSCRAMSHA512:
+			saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hashGenerator: scram.SHA512}
+			}
+		}
+	}
+
+	if c.TLSEnabled {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return err
+		}
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+	return nil
+}
+
+// tlsConfig builds the TLS configuration of the connections to the brokers
+func (c SecurityConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.TLSServerName,
+		InsecureSkipVerify: c.TLSInsecureSkipVerify,
+	}
+	if c.TLSCAFile != "" {
+		ca, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no PEM certificate found in Kafka CA file %s", c.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// scramClient runs the SCRAM exchange of a SASL authentication
+type scramClient struct {
+	hashGenerator scram.HashGeneratorFcn
+	conversation  *scram.ClientConversation
+}
+
+// Begin starts the exchange for userName
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGenerator.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+// Step answers a challenge of the broker
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+// Done reports whether the exchange completed
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
@@ -0,0 +1,106 @@
+package kafka_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/kafka"
+)
+
+func TestSecurityConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  kafka.SecurityConfig
+		wantErr string
+	}{
+		{name: "plaintext", config: kafka.SecurityConfig{}},
+		{name: "scram", config: kafka.SecurityConfig{SASLMechanism: kafka.SASLMechanismSCRAMSHA512, SASLUsername: "svc", SASLPassword: "secret"}},
+		{name: "unsupported mechanism", config: kafka.SecurityConfig{SASLMechanism: "GSSAPI", SASLUsername: "svc", SASLPassword: "secret"}, wantErr: "unsupported SASL mechanism"},
+		{name: "missing password", config: kafka.SecurityConfig{SASLMechanism: kafka.SASLMechanismPlain, SASLUsername: "svc"}, wantErr: "requires a username and a password"},
+		{name: "certificate without key", config: kafka.SecurityConfig{TLSEnabled: true, TLSCertFile: "client.pem"}, wantErr: "set together"},
+		{name: "TLS settings without TLS", config: kafka.SecurityConfig{TLSCAFile: "ca.pem"}, wantErr: "require TLS to be enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSecurityConfig_ApplySASL(t *testing.T) {
+	saramaConfig := sarama.NewConfig()
+	err := kafka.SecurityConfig{
+		ClientID:      "user-service",
+		SASLMechanism: kafka.SASLMechanismSCRAMSHA256,
+		SASLUsername:  "svc",
+		SASLPassword:  "secret",
+	}.Apply(saramaConfig)
+	require.NoError(t, err)
+
+	assert.Equal(t, "user-service", saramaConfig.ClientID)
+	assert.True(t, saramaConfig.Net.SASL.Enable)
+	assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA256), saramaConfig.Net.SASL.Mechanism)
+	assert.Equal(t, "svc", saramaConfig.Net.SASL.User)
+	assert.False(t, saramaConfig.Net.TLS.Enable)
+	require.NoError(t, saramaConfig.Validate())
+
+	client := saramaConfig.Net.SASL.SCRAMClientGeneratorFunc()
+	require.NoError(t, client.Begin("svc", "secret", ""))
+	first, err := client.Step("")
+	require.NoError(t, err)
+	assert.Contains(t, first, "n=svc,r=", "the exchange starts with the client first message")
+	assert.False(t, client.Done())
+}
+
+func TestSecurityConfig_ApplyTLS(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, selfSignedCertificate(t), 0o600))
+
+	saramaConfig := sarama.NewConfig()
+	err := kafka.SecurityConfig{TLSEnabled: true, TLSCAFile: caFile, TLSServerName: "kafka.internal"}.Apply(saramaConfig)
+	require.NoError(t, err)
+	assert.True(t, saramaConfig.Net.TLS.Enable)
+	require.NotNil(t, saramaConfig.Net.TLS.Config)
+	assert.NotNil(t, saramaConfig.Net.TLS.Config.RootCAs)
+	assert.Equal(t, "kafka.internal", saramaConfig.Net.TLS.Config.ServerName)
+
+	err = kafka.SecurityConfig{TLSEnabled: true, TLSCAFile: filepath.Join(dir, "missing.pem")}.Apply(sarama.NewConfig())
+	assert.ErrorContains(t, err, "failed to read Kafka CA file")
+}
+
+// selfSignedCertificate returns a PEM encoded self-signed CA certificate
+func selfSignedCertificate(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -25,7 +25,7 @@ func (w *ProducerWrapper) SendMessage(msg *sarama.ProducerMessage) (int32, int64
 	partition, offset, err := w.producer.SendMessage(msg)
 
 	if err != nil {
-		w.metrics.RecordKafkaProducerError(err.Error())
+		w.metrics.RecordKafkaProducerError(err)
 	} else {
 		// Extract event type from message headers or key
 		eventType := "unknown"
@@ -45,7 +45,7 @@ func (w *ProducerWrapper) SendMessages(msgs []*sarama.ProducerMessage) error {
 	err := w.producer.SendMessages(msgs)
 
 	if err != nil {
-		w.metrics.RecordKafkaProducerError(err.Error())
+		w.metrics.RecordKafkaProducerError(err)
 	} else {
 		// Record each message
 		for _, msg := range msgs {
@@ -85,7 +85,7 @@ func NewConsumerWrapper(consumer sarama.Consumer, m *metrics.Metrics) *ConsumerW
 func (w *ConsumerWrapper) ConsumePartition(topic string, partition int32, offset int64) (sarama.PartitionConsumer, error) {
 	pc, err := w.consumer.ConsumePartition(topic, partition, offset)
 	if err != nil {
-		w.metrics.RecordKafkaProducerError(err.Error())
+		w.metrics.RecordKafkaProducerError(err)
 	}
 	return pc, err
 }
@@ -0,0 +1,113 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Lock is a lock held by at most one instance at a time, e.g. a database advisory lock
+type Lock interface {
+	// TryLock acquires the lock unless another instance holds it. When this instance already
+	// holds it, it checks that it still does.
+	TryLock(ctx context.Context) (bool, error)
+	// Unlock releases the lock if this instance holds it
+	Unlock(ctx context.Context) error
+}
+
+// Logger logs leadership changes
+type Logger interface {
+	Info(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Elector elects a leader among instances competing for a lock, trying to acquire it every
+// interval. It calls onElected when this instance becomes the leader and onDemoted when it
+// stops being the leader: it lost the lock, the lock could not be checked, or it stopped.
+type Elector struct {
+	lock      Lock
+	interval  time.Duration
+	clock     clock.Clock
+	logger    Logger
+	onElected func()
+	onDemoted func()
+
+	mu     sync.Mutex
+	leader bool
+}
+
+// NewElector creates an elector. A nil clock uses the system clock.
+func NewElector(lock Lock, interval time.Duration, clk clock.Clock, logger Logger, onElected, onDemoted func()) *Elector {
+	return &Elector{
+		lock:      lock,
+		interval:  interval,
+		clock:     clock.OrDefault(clk),
+		logger:    logger,
+		onElected: onElected,
+		onDemoted: onDemoted,
+	}
+}
+
+// Run competes for leadership until ctx is done, then releases the lock if held
+func (e *Elector) Run(ctx context.Context) error {
+	for {
+		e.Campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return nil
+		case <-e.clock.After(e.interval):
+		}
+	}
+}
+
+// Campaign tries to acquire or keep the lock once, updating leadership
+func (e *Elector) Campaign(ctx context.Context) {
+	held, err := e.lock.TryLock(ctx)
+	if err != nil && ctx.Err() == nil {
+		e.logger.Error("Failed to acquire leader lock: %v", err)
+	}
+	e.setLeader(held && err == nil)
+}
+
+// IsLeader reports whether this instance is the leader
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// resign releases the lock and steps down
+func (e *Elector) resign() {
+	if !e.IsLeader() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+	if err := e.lock.Unlock(ctx); err != nil {
+		e.logger.Error("Failed to release leader lock: %v", err)
+	}
+	e.setLeader(false)
+}
+
+// setLeader records leadership, calling onElected or onDemoted when it changed
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	changed := e.leader != leader
+	e.leader = leader
+	e.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if leader {
+		e.logger.Info("Elected leader")
+		e.onElected()
+	} else {
+		e.logger.Info("Stepped down as leader")
+		e.onDemoted()
+	}
+}
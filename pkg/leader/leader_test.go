@@ -0,0 +1,84 @@
+package leader_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/leader"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLock is a lock whose next TryLock outcome is set by the test
+type fakeLock struct {
+	held     bool
+	err      error
+	unlocked int
+}
+
+func (l *fakeLock) TryLock(ctx context.Context) (bool, error) { return l.held, l.err }
+
+func (l *fakeLock) Unlock(ctx context.Context) error {
+	l.unlocked++
+	l.held = false
+	return nil
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(format string, v ...interface{})  {}
+func (nopLogger) Error(format string, v ...interface{}) {}
+
+func TestElector_Campaign(t *testing.T) {
+	ctx := context.Background()
+	lock := &fakeLock{}
+	var changes []string
+	elector := leader.NewElector(lock, time.Second, nil, nopLogger{},
+		func() { changes = append(changes, "elected") },
+		func() { changes = append(changes, "demoted") },
+	)
+
+	elector.Campaign(ctx)
+	assert.False(t, elector.IsLeader())
+	assert.Empty(t, changes, "followers are not demoted")
+
+	lock.held = true
+	elector.Campaign(ctx)
+	elector.Campaign(ctx)
+	assert.True(t, elector.IsLeader())
+	assert.Equal(t, []string{"elected"}, changes, "leaders are elected once")
+
+	lock.err = errors.New("connection lost")
+	elector.Campaign(ctx)
+	assert.False(t, elector.IsLeader(), "leaders step down when the lock cannot be checked")
+	assert.Equal(t, []string{"elected", "demoted"}, changes)
+
+	lock.err = nil
+	elector.Campaign(ctx)
+	lock.held = false
+	elector.Campaign(ctx)
+	assert.Equal(t, []string{"elected", "demoted", "elected", "demoted"}, changes)
+}
+
+func TestElector_RunReleasesLock(t *testing.T) {
+	lock := &fakeLock{held: true}
+	elected := make(chan struct{})
+	demoted := false
+	elector := leader.NewElector(lock, time.Hour, nil, nopLogger{},
+		func() { close(elected) },
+		func() { demoted = true },
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- elector.Run(ctx) }()
+
+	<-elected
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Equal(t, 1, lock.unlocked)
+	assert.True(t, demoted)
+	assert.False(t, elector.IsLeader())
+}
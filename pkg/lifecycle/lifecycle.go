@@ -0,0 +1,189 @@
+// Package lifecycle stops the components of the service in dependency order on shutdown, e.g.
+// the HTTP server before the event consumer and the database pools last
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Component is a part of the service stopped on shutdown
+type Component struct {
+	Name string
+	// Stop stops the component, giving up once ctx is done; it is called once
+	Stop func(ctx context.Context) error
+	// DependsOn names the components this one uses; they are stopped after it
+	DependsOn []string
+}
+
+// Logger logs the stopped components
+type Logger interface {
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+}
+
+// Manager stops registered components in dependency order. Components are stopped once every
+// component depending on them stopped; components whose dependents stopped are stopped
+// concurrently.
+type Manager struct {
+	logger Logger
+
+	mu         sync.Mutex
+	components []Component
+	names      map[string]bool
+	shutdown   bool
+}
+
+// NewManager creates an empty manager; logger may be nil
+func NewManager(logger Logger) *Manager {
+	return &Manager{
+		logger: logger,
+		names:  make(map[string]bool),
+	}
+}
+
+// Register adds a component. Its dependencies must be registered first, so components can't
+// depend on each other in a cycle.
+func (m *Manager) Register(component Component) error {
+	if component.Name == "" {
+		return errors.New("component name is required")
+	}
+	if component.Stop == nil {
+		return fmt.Errorf("component %s has no stop function", component.Name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shutdown {
+		return fmt.Errorf("component %s registered after shutdown", component.Name)
+	}
+	if m.names[component.Name] {
+		return fmt.Errorf("component %s is already registered", component.Name)
+	}
+	for _, dependency := range component.DependsOn {
+		if !m.names[dependency] {
+			return fmt.Errorf("component %s depends on unregistered component %s", component.Name, dependency)
+		}
+	}
+	m.names[component.Name] = true
+	m.components = append(m.components, component)
+	return nil
+}
+
+// Stages returns the names of the components in the order they are stopped, components of a
+// stage being stopped concurrently
+func (m *Manager) Stages() [][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stages := make([][]string, 0)
+	for _, stage := range m.stages() {
+		names := make([]string, len(stage))
+		for i, component := range stage {
+			names[i] = component.Name
+		}
+		stages = append(stages, names)
+	}
+	return stages
+}
+
+// stages groups the components by the order they are stopped in
+func (m *Manager) stages() [][]Component {
+	// A component is stopped in the stage following its latest dependent, dependents being
+	// registered after their dependencies
+	level := make(map[string]int, len(m.components))
+	depth := 0
+	for i := len(m.components) - 1; i >= 0; i-- {
+		component := m.components[i]
+		for _, dependency := range component.DependsOn {
+			level[dependency] = max(level[dependency], level[component.Name]+1)
+			depth = max(depth, level[dependency])
+		}
+	}
+
+	if len(m.components) == 0 {
+		return nil
+	}
+	stages := make([][]Component, depth+1)
+	for _, component := range m.components {
+		stages[level[component.Name]] = append(stages[level[component.Name]], component)
+	}
+	for _, stage := range stages {
+		sort.Slice(stage, func(i, j int) bool { return stage[i].Name < stage[j].Name })
+	}
+	return stages
+}
+
+// Shutdown stops every component in dependency order, waiting for them until ctx is done. Once
+// ctx is done, the components left are still stopped, with ctx, without waiting for them. It
+// returns the errors of the components that failed or did not stop in time.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.shutdown = true
+	stages := m.stages()
+	m.mu.Unlock()
+
+	var errs []error
+	for _, stage := range stages {
+		errs = append(errs, m.stopStage(ctx, stage)...)
+	}
+	return errors.Join(errs...)
+}
+
+// stopStage stops the components of a stage concurrently, waiting for them until ctx is done
+func (m *Manager) stopStage(ctx context.Context, stage []Component) []error {
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result, len(stage))
+	for _, component := range stage {
+		m.logInfo("Stopping %s", component.Name)
+		go func() {
+			results <- result{name: component.Name, err: component.Stop(ctx)}
+		}()
+	}
+
+	var errs []error
+	pending := make(map[string]bool, len(stage))
+	for _, component := range stage {
+		pending[component.Name] = true
+	}
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.name)
+			if r.err != nil {
+				m.logWarn("Failed to stop %s: %v", r.name, r.err)
+				errs = append(errs, fmt.Errorf("failed to stop %s: %w", r.name, r.err))
+				continue
+			}
+			m.logInfo("Stopped %s", r.name)
+		case <-ctx.Done():
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			m.logWarn("Gave up waiting for %s to stop: %v", strings.Join(names, ", "), ctx.Err())
+			return append(errs, fmt.Errorf("%s did not stop in time: %w", strings.Join(names, ", "), ctx.Err()))
+		}
+	}
+	return errs
+}
+
+func (m *Manager) logInfo(format string, v ...interface{}) {
+	if m.logger != nil {
+		m.logger.Info(format, v...)
+	}
+}
+
+func (m *Manager) logWarn(format string, v ...interface{}) {
+	if m.logger != nil {
+		m.logger.Warn(format, v...)
+	}
+}
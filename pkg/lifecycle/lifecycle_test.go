@@ -0,0 +1,109 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/lifecycle"
+)
+
+// recorder records the order components are stopped in
+type recorder struct {
+	mu      sync.Mutex
+	stopped []string
+}
+
+func (r *recorder) stop(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.stopped = append(r.stopped, name)
+		return nil
+	}
+}
+
+func (r *recorder) order() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.stopped...)
+}
+
+func TestManager_Register(t *testing.T) {
+	manager := lifecycle.NewManager(nil)
+	noop := func(ctx context.Context) error { return nil }
+
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "databases", Stop: noop}))
+	assert.Error(t, manager.Register(lifecycle.Component{Name: "databases", Stop: noop}))
+	assert.Error(t, manager.Register(lifecycle.Component{Name: "", Stop: noop}))
+	assert.Error(t, manager.Register(lifecycle.Component{Name: "consumer"}))
+	assert.Error(t, manager.Register(lifecycle.Component{Name: "consumer", Stop: noop, DependsOn: []string{"server"}}))
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "consumer", Stop: noop, DependsOn: []string{"databases"}}))
+
+	require.NoError(t, manager.Shutdown(context.Background()))
+	assert.Error(t, manager.Register(lifecycle.Component{Name: "server", Stop: noop}))
+}
+
+func TestManager_ShutdownStopsDependentsFirst(t *testing.T) {
+	stops := &recorder{}
+	manager := lifecycle.NewManager(nil)
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "databases", Stop: stops.stop("databases")}))
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "worker-pools", Stop: stops.stop("worker-pools")}))
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "jobs", Stop: stops.stop("jobs"), DependsOn: []string{"databases"}}))
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "consumer", Stop: stops.stop("consumer"), DependsOn: []string{"worker-pools", "databases"}}))
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "server", Stop: stops.stop("server"), DependsOn: []string{"consumer", "jobs"}}))
+
+	assert.Equal(t, [][]string{{"server"}, {"consumer", "jobs"}, {"databases", "worker-pools"}}, manager.Stages())
+	require.NoError(t, manager.Shutdown(context.Background()))
+
+	order := stops.order()
+	require.Len(t, order, 5)
+	assert.Equal(t, "server", order[0])
+	assert.ElementsMatch(t, []string{"consumer", "jobs"}, order[1:3])
+	assert.ElementsMatch(t, []string{"worker-pools", "databases"}, order[3:])
+}
+
+func TestManager_ShutdownJoinsErrors(t *testing.T) {
+	stops := &recorder{}
+	manager := lifecycle.NewManager(nil)
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "databases", Stop: stops.stop("databases")}))
+	require.NoError(t, manager.Register(lifecycle.Component{
+		Name:      "consumer",
+		Stop:      func(ctx context.Context) error { return errors.New("offsets not committed") },
+		DependsOn: []string{"databases"},
+	}))
+
+	err := manager.Shutdown(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to stop consumer: offsets not committed")
+	assert.Equal(t, []string{"databases"}, stops.order())
+}
+
+func TestManager_ShutdownGivesUpAfterDeadline(t *testing.T) {
+	stops := &recorder{}
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	manager := lifecycle.NewManager(nil)
+	require.NoError(t, manager.Register(lifecycle.Component{Name: "databases", Stop: stops.stop("databases")}))
+	require.NoError(t, manager.Register(lifecycle.Component{
+		Name:      "consumer",
+		Stop:      func(ctx context.Context) error { <-stuck; return nil },
+		DependsOn: []string{"databases"},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	err := manager.Shutdown(ctx)
+
+	assert.Less(t, time.Since(started), time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "consumer did not stop in time")
+	assert.Eventually(t, func() bool { return len(stops.order()) == 1 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ErrorClass is a bounded label value classifying an error, see ClassifyError
+type ErrorClass string
+
+// Error classes recorded as "error" labels
+const (
+	ErrorClassTimeout      ErrorClass = "timeout"
+	ErrorClassCanceled     ErrorClass = "canceled"
+	ErrorClassConnection   ErrorClass = "connection"
+	ErrorClassUnavailable  ErrorClass = "unavailable"
+	ErrorClassTooLarge     ErrorClass = "too_large"
+	ErrorClassUnknownTopic ErrorClass = "unknown_topic"
+	ErrorClassAuth         ErrorClass = "auth"
+	ErrorClassInvalid      ErrorClass = "invalid"
+	ErrorClassOther        ErrorClass = "other"
+)
+
+// ErrorClasses are all error classes, the allowed values of "error" labels
+var ErrorClasses = []ErrorClass{
+	ErrorClassTimeout, ErrorClassCanceled, ErrorClassConnection, ErrorClassUnavailable, ErrorClassTooLarge,
+	ErrorClassUnknownTopic, ErrorClassAuth, ErrorClassInvalid, ErrorClassOther,
+}
+
+// errorClassPatterns classify errors by message when they carry no typed cause, e.g. broker errors
+var errorClassPatterns = []struct {
+	substring string
+	class     ErrorClass
+}{
+	{"timed out", ErrorClassTimeout},
+	{"timeout", ErrorClassTimeout},
+	{"connection refused", ErrorClassConnection},
+	{"connection reset", ErrorClassConnection},
+	{"broken pipe", ErrorClassConnection},
+	{"out of available brokers", ErrorClassUnavailable},
+	{"not leader", ErrorClassUnavailable},
+	{"leader not available", ErrorClassUnavailable},
+	{"unavailable", ErrorClassUnavailable},
+	{"too large", ErrorClassTooLarge},
+	{"unknown topic", ErrorClassUnknownTopic},
+	{"sasl", ErrorClassAuth},
+	{"authentication", ErrorClassAuth},
+	{"authorization", ErrorClassAuth},
+	{"unauthorized", ErrorClassAuth},
+	{"invalid", ErrorClassInvalid},
+}
+
+// ClassifyError maps an error to one of ErrorClasses, so error labels keep a bounded cardinality
+// instead of one series per error message
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassOther
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ErrorClassConnection
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, pattern := range errorClassPatterns {
+		if strings.Contains(message, pattern.substring) {
+			return pattern.class
+		}
+	}
+	if errors.As(err, &netErr) {
+		return ErrorClassConnection
+	}
+	return ErrorClassOther
+}
+
+// OverflowLabelValue replaces label values rejected by a LabelGuard
+const OverflowLabelValue = "other"
+
+// Logger logs label violations
+type Logger interface {
+	Warn(format string, v ...interface{})
+}
+
+// LabelGuard protects metrics from unbounded label cardinality. Labels with an allow list only
+// take listed values; other labels take at most maxValues distinct values. Rejected values are
+// recorded as OverflowLabelValue, counted in metrics_label_violations_total and logged once per
+// metric label.
+type LabelGuard struct {
+	maxValues  int
+	violations func(metric, label string)
+
+	mu      sync.Mutex
+	logger  Logger
+	allowed map[string]map[string]bool // label -> allowed values
+	seen    map[string]map[string]bool // metric/label -> values seen
+	logged  map[string]bool            // metric/label violations already logged
+}
+
+// NewLabelGuard creates a guard allowing maxValues distinct values per label without an allow
+// list, 0 for no limit. violations, when not nil, is called for every rejected value.
+func NewLabelGuard(maxValues int, violations func(metric, label string)) *LabelGuard {
+	return &LabelGuard{
+		maxValues:  maxValues,
+		violations: violations,
+		allowed:    make(map[string]map[string]bool),
+		seen:       make(map[string]map[string]bool),
+		logged:     make(map[string]bool),
+	}
+}
+
+// SetLogger logs the first violation of each metric label; a nil logger only counts violations
+func (g *LabelGuard) SetLogger(logger Logger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.logger = logger
+}
+
+// Allow restricts a label to values
+func (g *LabelGuard) Allow(label string, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.allowed[label] == nil {
+		g.allowed[label] = make(map[string]bool)
+	}
+	for _, value := range values {
+		g.allowed[label][value] = true
+	}
+}
+
+// Value returns value when the label of metric may take it, or OverflowLabelValue
+func (g *LabelGuard) Value(metric, label, value string) string {
+	g.mu.Lock()
+	key := metric + "/" + label
+	if g.admit(key, label, value) {
+		g.mu.Unlock()
+		return value
+	}
+
+	logger := g.logger
+	first := !g.logged[key]
+	g.logged[key] = true
+	g.mu.Unlock()
+
+	if g.violations != nil {
+		g.violations(metric, label)
+	}
+	if first && logger != nil {
+		logger.Warn("Metric %s label %s rejected value %q, recording %q; further violations are only counted", metric, label, value, OverflowLabelValue)
+	}
+	return OverflowLabelValue
+}
+
+// admit reports whether a label takes value, remembering new values. Requires g.mu.
+func (g *LabelGuard) admit(key, label, value string) bool {
+	if allowed, ok := g.allowed[label]; ok {
+		return allowed[value]
+	}
+
+	seen := g.seen[key]
+	if seen == nil {
+		seen = make(map[string]bool)
+		g.seen[key] = seen
+	}
+	if seen[value] {
+		return true
+	}
+	if g.maxValues > 0 && len(seen) >= g.maxValues {
+		return false
+	}
+	seen[value] = true
+	return true
+}
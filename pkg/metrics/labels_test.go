@@ -0,0 +1,61 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/pkg/metrics"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected metrics.ErrorClass
+	}{
+		{fmt.Errorf("send: %w", context.DeadlineExceeded), metrics.ErrorClassTimeout},
+		{context.Canceled, metrics.ErrorClassCanceled},
+		{fmt.Errorf("dial: %w", syscall.ECONNREFUSED), metrics.ErrorClassConnection},
+		{errors.New("kafka: client has run out of available brokers to talk to"), metrics.ErrorClassUnavailable},
+		{errors.New("kafka server: Message was too large, server rejected it"), metrics.ErrorClassTooLarge},
+		{errors.New("kafka server: Request was for a topic or partition that does not exist on this broker (unknown topic)"), metrics.ErrorClassUnknownTopic},
+		{errors.New("user 42 exploded at 10:31"), metrics.ErrorClassOther},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, metrics.ClassifyError(tt.err), tt.err.Error())
+	}
+}
+
+type recordingLogger struct {
+	warnings []string
+}
+
+func (l *recordingLogger) Warn(format string, v ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, v...))
+}
+
+func TestLabelGuard(t *testing.T) {
+	violations := 0
+	guard := metrics.NewLabelGuard(2, func(metric, label string) { violations++ })
+	logger := &recordingLogger{}
+	guard.SetLogger(logger)
+	guard.Allow("error", "timeout", "other")
+
+	assert.Equal(t, "timeout", guard.Value("kafka_events_failed_total", "error", "timeout"))
+	assert.Equal(t, metrics.OverflowLabelValue, guard.Value("kafka_events_failed_total", "error", "dial tcp 10.0.0.1:9092: i/o timeout"))
+
+	// Labels without an allow list take a bounded number of values per metric
+	assert.Equal(t, "user-events", guard.Value("kafka_events_published_total", "topic", "user-events"))
+	assert.Equal(t, "order-events", guard.Value("kafka_events_published_total", "topic", "order-events"))
+	assert.Equal(t, "user-events", guard.Value("kafka_events_published_total", "topic", "user-events"))
+	assert.Equal(t, metrics.OverflowLabelValue, guard.Value("kafka_events_published_total", "topic", "tenant-1-events"))
+	assert.Equal(t, metrics.OverflowLabelValue, guard.Value("kafka_events_published_total", "topic", "tenant-2-events"))
+
+	assert.Equal(t, 3, violations)
+	assert.Len(t, logger.warnings, 2, "violations are logged once per metric label")
+}
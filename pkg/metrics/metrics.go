@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"runtime"
+	"strconv"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,26 +16,54 @@ type Metrics struct {
 	HTTPRequestDuration  *prometheus.HistogramVec
 	HTTPRequestsInFlight *prometheus.GaugeVec
 
+	// gRPC metrics
+	GRPCRequestsTotal   *prometheus.CounterVec
+	GRPCRequestDuration *prometheus.HistogramVec
+
 	// Database metrics
-	DBConnectionsActive *prometheus.GaugeVec
-	DBQueryDuration     *prometheus.HistogramVec
-	DBQueriesTotal      *prometheus.CounterVec
+	DBConnectionsActive    *prometheus.GaugeVec
+	DBQueryDuration        *prometheus.HistogramVec
+	DBQueriesTotal         *prometheus.CounterVec
+	DBTransactions         *prometheus.CounterVec
+	DBTransactionConflicts *prometheus.CounterVec
 
 	// Kafka metrics
 	KafkaEventsPublished *prometheus.CounterVec
 	KafkaEventsFailed    *prometheus.CounterVec
 	KafkaProducerErrors  *prometheus.CounterVec
 
+	// Versioned topic metrics
+	TopicVersionConsumed *prometheus.CounterVec
+
 	// Business metrics
 	UsersTotal      *prometheus.GaugeVec
 	EventsStored    *prometheus.CounterVec
 	EventsPublished *prometheus.CounterVec
 
+	// Read model metrics
+	ReadModelMigrations *prometheus.CounterVec
+
+	// Supervised component metrics
+	ComponentRestarts *prometheus.CounterVec
+	ComponentUp       *prometheus.GaugeVec
+
+	// Circuit breaker metrics
+	CircuitBreakerState       *prometheus.GaugeVec
+	CircuitBreakerTransitions *prometheus.CounterVec
+	ConcurrencyLimit          *prometheus.GaugeVec
+
 	// System metrics
 	MemoryAlloc *prometheus.GaugeVec
 	MemoryHeap  *prometheus.GaugeVec
+
+	// Cardinality protection of labels taking values from outside the code
+	LabelViolations *prometheus.CounterVec
+	labels          *LabelGuard
 }
 
+// maxLabelValues bounds the distinct values of labels without an allow list, per metric
+const maxLabelValues = 100
+
 var (
 	metricsInstance *Metrics
 	metricsOnce     sync.Once
@@ -68,6 +97,23 @@ func NewMetrics() *Metrics {
 				[]string{"method", "endpoint"},
 			),
 
+			// gRPC metrics
+			GRPCRequestsTotal: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "grpc_server_handled_total",
+					Help: "Total number of gRPC requests completed on the server",
+				},
+				[]string{"grpc_method", "grpc_code"},
+			),
+			GRPCRequestDuration: promauto.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    "grpc_server_handling_seconds",
+					Help:    "gRPC request duration in seconds",
+					Buckets: prometheus.DefBuckets,
+				},
+				[]string{"grpc_method"},
+			),
+
 			// Database metrics
 			DBConnectionsActive: promauto.NewGaugeVec(
 				prometheus.GaugeOpts{
@@ -91,6 +137,20 @@ func NewMetrics() *Metrics {
 				},
 				[]string{"operation", "table", "status"},
 			),
+			DBTransactions: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "db_transactions_total",
+					Help: "Total number of write database transactions of operations, by outcome: committed or failed",
+				},
+				[]string{"operation", "status"},
+			),
+			DBTransactionConflicts: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "db_transaction_conflicts_total",
+					Help: "Total number of write database transactions aborted by a serialization failure or deadlock, by whether they were retried",
+				},
+				[]string{"operation", "reason", "outcome"},
+			),
 
 			// Kafka metrics
 			KafkaEventsPublished: promauto.NewCounterVec(
@@ -138,6 +198,62 @@ func NewMetrics() *Metrics {
 				[]string{"event_type", "aggregate_type"},
 			),
 
+			// Versioned topic metrics
+			TopicVersionConsumed: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "topic_version_consumed_total",
+					Help: "Total number of messages consumed per topic schema version; deprecated versions have outdated=true",
+				},
+				[]string{"topic", "version", "outdated"},
+			),
+
+			// Read model metrics
+			ReadModelMigrations: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "read_model_migrations_total",
+					Help: "Total number of read model documents migrated to a newer schema version",
+				},
+				[]string{"model", "from_version", "to_version", "trigger", "status"},
+			),
+
+			// Supervised component metrics
+			ComponentRestarts: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "component_restarts_total",
+					Help: "Total number of restarts of crashed components",
+				},
+				[]string{"component"},
+			),
+			ComponentUp: promauto.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Name: "component_up",
+					Help: "Whether a supervised component is running (1) or crashed (0)",
+				},
+				[]string{"component"},
+			),
+
+			CircuitBreakerState: promauto.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Name: "circuit_breaker_state",
+					Help: "State of a circuit breaker: closed (0), open (1) or half-open (2)",
+				},
+				[]string{"breaker"},
+			),
+			CircuitBreakerTransitions: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "circuit_breaker_transitions_total",
+					Help: "Total number of state changes of circuit breakers",
+				},
+				[]string{"breaker", "from", "to"},
+			),
+			ConcurrencyLimit: promauto.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Name: "concurrency_limit",
+					Help: "Adaptive limit of calls in flight to a dependency",
+				},
+				[]string{"dependency"},
+			),
+
 			MemoryAlloc: promauto.NewGaugeVec(
 				prometheus.GaugeOpts{
 					Name: "go_memory_alloc_bytes",
@@ -152,11 +268,34 @@ func NewMetrics() *Metrics {
 				},
 				[]string{},
 			),
+
+			LabelViolations: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "metrics_label_violations_total",
+					Help: "Total number of label values rejected to protect metrics cardinality",
+				},
+				[]string{"metric", "label"},
+			),
 		}
+
+		metricsInstance.labels = NewLabelGuard(maxLabelValues, func(metric, label string) {
+			metricsInstance.LabelViolations.WithLabelValues(metric, label).Inc()
+		})
+		errorClasses := make([]string, len(ErrorClasses))
+		for i, class := range ErrorClasses {
+			errorClasses[i] = string(class)
+		}
+		metricsInstance.labels.Allow("error", errorClasses...)
 	})
 	return metricsInstance
 }
 
+// LabelGuard returns the guard of labels taking values from outside the code, e.g. topics,
+// event types and errors, to restrict them further or log violations
+func (m *Metrics) LabelGuard() *LabelGuard {
+	return m.labels
+}
+
 // RecordHTTPRequest records HTTP request metrics
 func (m *Metrics) RecordHTTPRequest(method, endpoint, status string, duration float64) {
 	m.HTTPRequestsTotal.WithLabelValues(method, endpoint, status).Inc()
@@ -168,6 +307,12 @@ func (m *Metrics) RecordHTTPRequestInFlight(method, endpoint string, count float
 	m.HTTPRequestsInFlight.WithLabelValues(method, endpoint).Set(count)
 }
 
+// RecordGRPCRequest records gRPC request metrics
+func (m *Metrics) RecordGRPCRequest(method, code string, duration float64) {
+	m.GRPCRequestsTotal.WithLabelValues(method, code).Inc()
+	m.GRPCRequestDuration.WithLabelValues(method).Observe(duration)
+}
+
 // RecordDBQuery records database query metrics
 func (m *Metrics) RecordDBQuery(operation, table, status string, duration float64) {
 	m.DBQueriesTotal.WithLabelValues(operation, table, status).Inc()
@@ -179,19 +324,42 @@ func (m *Metrics) RecordDBConnections(database string, count float64) {
 	m.DBConnectionsActive.WithLabelValues(database).Set(count)
 }
 
+// RecordTransaction records the outcome of a write database transaction of an operation,
+// "committed" or "failed", once it is not retried anymore
+func (m *Metrics) RecordTransaction(operation, status string) {
+	m.DBTransactions.WithLabelValues(operation, status).Inc()
+}
+
+// RecordTransactionConflict records a transaction of an operation aborted by a conflict, the
+// outcome being "retried" or "exhausted" once it is not retried anymore
+func (m *Metrics) RecordTransactionConflict(operation, reason, outcome string) {
+	m.DBTransactionConflicts.WithLabelValues(operation, reason, outcome).Inc()
+}
+
 // RecordKafkaEventPublished records Kafka event published
 func (m *Metrics) RecordKafkaEventPublished(topic, eventType string) {
-	m.KafkaEventsPublished.WithLabelValues(topic, eventType).Inc()
+	const metric = "kafka_events_published_total"
+	m.KafkaEventsPublished.WithLabelValues(m.labels.Value(metric, "topic", topic), m.labels.Value(metric, "event_type", eventType)).Inc()
+}
+
+// RecordKafkaEventFailed records Kafka event failure, labelled with the class of err
+func (m *Metrics) RecordKafkaEventFailed(topic, eventType string, err error) {
+	const metric = "kafka_events_failed_total"
+	m.KafkaEventsFailed.WithLabelValues(
+		m.labels.Value(metric, "topic", topic),
+		m.labels.Value(metric, "event_type", eventType),
+		m.labels.Value(metric, "error", string(ClassifyError(err))),
+	).Inc()
 }
 
-// RecordKafkaEventFailed records Kafka event failure
-func (m *Metrics) RecordKafkaEventFailed(topic, eventType, error string) {
-	m.KafkaEventsFailed.WithLabelValues(topic, eventType, error).Inc()
+// RecordKafkaProducerError records Kafka producer error, labelled with the class of err
+func (m *Metrics) RecordKafkaProducerError(err error) {
+	m.KafkaProducerErrors.WithLabelValues(m.labels.Value("kafka_producer_errors_total", "error", string(ClassifyError(err)))).Inc()
 }
 
-// RecordKafkaProducerError records Kafka producer error
-func (m *Metrics) RecordKafkaProducerError(error string) {
-	m.KafkaProducerErrors.WithLabelValues(error).Inc()
+// RecordTopicVersionConsumed records a message consumed from a version of a versioned topic
+func (m *Metrics) RecordTopicVersionConsumed(topic string, version int, outdated bool) {
+	m.TopicVersionConsumed.WithLabelValues(topic, strconv.Itoa(version), strconv.FormatBool(outdated)).Inc()
 }
 
 // RecordUsersTotal records total users count
@@ -201,12 +369,43 @@ func (m *Metrics) RecordUsersTotal(count float64) {
 
 // RecordEventStored records event stored in event store
 func (m *Metrics) RecordEventStored(eventType, aggregateType string) {
-	m.EventsStored.WithLabelValues(eventType, aggregateType).Inc()
+	m.EventsStored.WithLabelValues(m.labels.Value("events_stored_total", "event_type", eventType), aggregateType).Inc()
 }
 
 // RecordEventPublished records event published
 func (m *Metrics) RecordEventPublished(eventType, aggregateType string) {
-	m.EventsPublished.WithLabelValues(eventType, aggregateType).Inc()
+	m.EventsPublished.WithLabelValues(m.labels.Value("events_published_total", "event_type", eventType), aggregateType).Inc()
+}
+
+// RecordReadModelMigration records a read model document migration
+func (m *Metrics) RecordReadModelMigration(model string, fromVersion, toVersion int, trigger, status string) {
+	m.ReadModelMigrations.WithLabelValues(model, strconv.Itoa(fromVersion), strconv.Itoa(toVersion), trigger, status).Inc()
+}
+
+// RecordComponentRestart records the restart of a crashed component
+func (m *Metrics) RecordComponentRestart(component string) {
+	m.ComponentRestarts.WithLabelValues(component).Inc()
+}
+
+// RecordComponentUp records whether a component is running
+func (m *Metrics) RecordComponentUp(component string, up bool) {
+	value := 0.0
+	if up {
+		value = 1
+	}
+	m.ComponentUp.WithLabelValues(component).Set(value)
+}
+
+// RecordCircuitBreakerStateChange records a state change of a circuit breaker, states being
+// numbered closed (0), open (1) and half-open (2)
+func (m *Metrics) RecordCircuitBreakerStateChange(breaker, from, to string, state int) {
+	m.CircuitBreakerTransitions.WithLabelValues(breaker, from, to).Inc()
+	m.CircuitBreakerState.WithLabelValues(breaker).Set(float64(state))
+}
+
+// RecordConcurrencyLimit records the adaptive limit of calls in flight to a dependency
+func (m *Metrics) RecordConcurrencyLimit(dependency string, limit int) {
+	m.ConcurrencyLimit.WithLabelValues(dependency).Set(float64(limit))
 }
 
 // UpdateSystemMetrics updates system metrics
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// instrumentationName names the meter of the OpenTelemetry instruments
+const instrumentationName = "go-clean-ddd-es-template"
+
+// OTLPConfig configures the export of OpenTelemetry metrics to an OTLP collector
+type OTLPConfig struct {
+	ServiceName    string
+	ServiceVersion string
+	Endpoint       string        // OTLP HTTP endpoint, host:port
+	Interval       time.Duration // Interval between exports, the OpenTelemetry default when 0
+	Timeout        time.Duration // Timeout of an export request, the OpenTelemetry default when 0
+}
+
+// NewMeterProvider creates a meter provider exporting metrics to an OTLP HTTP collector every
+// interval, and installs it as the global meter provider so the instruments of Meter are exported
+func NewMeterProvider(cfg OTLPConfig) (*sdkmetric.MeterProvider, error) {
+	exporterOpts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(cfg.Endpoint),
+		otlpmetrichttp.WithURLPath("/v1/metrics"),
+		otlpmetrichttp.WithInsecure(),
+	}
+	if cfg.Timeout > 0 {
+		exporterOpts = append(exporterOpts, otlpmetrichttp.WithTimeout(cfg.Timeout))
+	}
+	exporter, err := otlpmetrichttp.New(context.Background(), exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	res, err := resource.New(
+		context.Background(),
+		resource.WithAttributes(
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(cfg.ServiceVersion),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	var readerOpts []sdkmetric.PeriodicReaderOption
+	if cfg.Interval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(cfg.Interval))
+	}
+	if cfg.Timeout > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithTimeout(cfg.Timeout))
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetMeterProvider(mp)
+	return mp, nil
+}
+
+// Meter returns the meter of the OpenTelemetry instruments on the global meter provider.
+// Measurements are only exported once NewMeterProvider installed its provider, so layers may
+// create their instruments before.
+func Meter() metric.Meter {
+	return otel.Meter(instrumentationName)
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Headers of API versioning
+const (
+	APIVersionHeader  = "X-API-Version" // Version of an unversioned /api/ request, e.g. "v2" or "2"
+	DeprecationHeader = "Deprecation"   // "true" on responses of deprecated methods
+	SunsetHeader      = "Sunset"        // HTTP date a deprecated method is removed
+	LinkHeader        = "Link"          // Successor of a deprecated method
+)
+
+// Deprecation describes a deprecated API method
+type Deprecation struct {
+	Sunset    time.Time // When the method is removed, zero when not scheduled
+	Successor string    // Path of the method replacing it, empty when there is none
+}
+
+// Metadata returns the deprecation headers as lower case gRPC metadata pairs
+func (d Deprecation) Metadata() metadata.MD {
+	md := metadata.Pairs(strings.ToLower(DeprecationHeader), "true")
+	if !d.Sunset.IsZero() {
+		md.Set(strings.ToLower(SunsetHeader), d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Successor != "" {
+		md.Set(strings.ToLower(LinkHeader), fmt.Sprintf(`<%s>; rel="successor-version"`, d.Successor))
+	}
+	return md
+}
+
+// GRPCDeprecationInterceptor creates a gRPC interceptor returning deprecation metadata in the
+// response headers of deprecated methods, keyed by full method
+func GRPCDeprecationInterceptor(deprecations map[string]Deprecation) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if deprecation, ok := deprecations[info.FullMethod]; ok {
+			// Best effort: the headers may already be sent, e.g. by an earlier interceptor
+			_ = grpc.SetHeader(ctx, deprecation.Metadata())
+		}
+		return handler(ctx, req)
+	}
+}
+
+// IsDeprecationMetadata reports whether a gRPC metadata key carries deprecation headers, so the
+// gateway can forward it to HTTP clients as is
+func IsDeprecationMetadata(key string) bool {
+	switch http.CanonicalHeaderKey(key) {
+	case DeprecationHeader, SunsetHeader, LinkHeader:
+		return true
+	}
+	return false
+}
+
+// versionSegment matches an API version path segment such as "v2"
+var versionSegment = regexp.MustCompile(`^v\d+$`)
+
+// APIVersionRouter routes unversioned /api/ requests to a version. Clients pick the version with
+// the X-API-Version header, "/api/users" with "X-API-Version: v1" is served as "/api/v1/users";
+// without the header the default version is served. Versioned paths are served as is.
+type APIVersionRouter struct {
+	defaultVersion string
+	supported      []string
+}
+
+// NewAPIVersionRouter creates a router serving unversioned requests with defaultVersion
+func NewAPIVersionRouter(defaultVersion string, supported []string) *APIVersionRouter {
+	return &APIVersionRouter{defaultVersion: defaultVersion, supported: supported}
+}
+
+// Wrap rewrites the path of unversioned /api/ requests before passing them to next. Requests for
+// an unsupported version are rejected with 400 Bad Request.
+func (v *APIVersionRouter) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/api/")
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if segment, _, _ := strings.Cut(rest, "/"); versionSegment.MatchString(segment) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version := v.defaultVersion
+		if requested := strings.TrimSpace(r.Header.Get(APIVersionHeader)); requested != "" {
+			version = strings.ToLower(requested)
+			if !strings.HasPrefix(version, "v") {
+				version = "v" + version
+			}
+		}
+		if !slices.Contains(v.supported, version) {
+			http.Error(w, fmt.Sprintf("unsupported API version %q", version), http.StatusBadRequest)
+			return
+		}
+
+		routed := r.Clone(r.Context())
+		routed.URL.Path = "/api/" + version + "/" + rest
+		routed.URL.RawPath = ""
+		next.ServeHTTP(w, routed)
+	})
+}
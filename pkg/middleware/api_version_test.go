@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// headerStream records the headers set by interceptors
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestGRPCDeprecationInterceptor(t *testing.T) {
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	interceptor := GRPCDeprecationInterceptor(map[string]Deprecation{
+		"/user.UserService/GetUser": {Sunset: sunset, Successor: "/api/v2/users/{id}"},
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	call := func(method string) metadata.MD {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return stream.header
+	}
+
+	header := call("/user.UserService/GetUser")
+	if got := header.Get("deprecation"); len(got) != 1 || got[0] != "true" {
+		t.Errorf("expected deprecation header, got %v", got)
+	}
+	if got := header.Get("sunset"); len(got) != 1 || got[0] != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("unexpected sunset header %v", got)
+	}
+	if got := header.Get("link"); len(got) != 1 || got[0] != `</api/v2/users/{id}>; rel="successor-version"` {
+		t.Errorf("unexpected link header %v", got)
+	}
+
+	if header := call("/user.v2.UserService/GetUser"); len(header) != 0 {
+		t.Errorf("expected no headers for a current method, got %v", header)
+	}
+
+	if md := (Deprecation{}).Metadata(); len(md) != 1 {
+		t.Errorf("expected only the deprecation header without sunset and successor, got %v", md)
+	}
+}
+
+func TestIsDeprecationMetadata(t *testing.T) {
+	for _, key := range []string{"deprecation", "sunset", "link"} {
+		if !IsDeprecationMetadata(key) {
+			t.Errorf("expected %s to be deprecation metadata", key)
+		}
+	}
+	if IsDeprecationMetadata("content-type") {
+		t.Error("content-type is not deprecation metadata")
+	}
+}
+
+func TestAPIVersionRouter(t *testing.T) {
+	var served string
+	router := NewAPIVersionRouter("v2", []string{"v1", "v2"}).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = r.URL.Path
+	}))
+
+	tests := []struct {
+		path       string
+		version    string
+		wantPath   string
+		wantStatus int
+	}{
+		{path: "/api/users", wantPath: "/api/v2/users", wantStatus: http.StatusOK},
+		{path: "/api/users/123", version: "v1", wantPath: "/api/v1/users/123", wantStatus: http.StatusOK},
+		{path: "/api/users", version: "1", wantPath: "/api/v1/users", wantStatus: http.StatusOK},
+		{path: "/api/v1/users", version: "v2", wantPath: "/api/v1/users", wantStatus: http.StatusOK},
+		{path: "/metrics", version: "v9", wantPath: "/metrics", wantStatus: http.StatusOK},
+		{path: "/api/users", version: "v9", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		served = ""
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.version != "" {
+			req.Header.Set(APIVersionHeader, tt.version)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s %s: expected status %d, got %d", tt.path, tt.version, tt.wantStatus, rec.Code)
+		}
+		if served != tt.wantPath {
+			t.Errorf("%s %s: expected %q to be served, got %q", tt.path, tt.version, tt.wantPath, served)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientIP returns the address of the client of a request received from peerAddress, with or
+// without port. Requests from a loopback peer, e.g. the HTTP gateway, or from a trusted proxy are
+// given the last forwarded address not of a trusted proxy, as the addresses before it are set by
+// the client; other requests are given the peer address, whatever they forward.
+func ClientIP(peerAddress string, forwardedFor []string, trustedProxies []*net.IPNet) string {
+	address := peerAddress
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	ip := net.ParseIP(address)
+	if ip == nil || !(ip.IsLoopback() || trustedProxy(ip, trustedProxies)) {
+		return address
+	}
+
+	var forwarded []string
+	for _, value := range forwardedFor {
+		forwarded = append(forwarded, strings.Split(value, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		address = hop
+		if ip := net.ParseIP(hop); ip == nil || !trustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+	return address
+}
+
+// GRPCClientIP returns the address of the client of a gRPC call from its peer and x-forwarded-for,
+// or x-real-ip when not forwarded, see ClientIP
+func GRPCClientIP(ctx context.Context, trustedProxies []*net.IPNet) string {
+	var address string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		address = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	forwarded := md.Get("x-forwarded-for")
+	if len(forwarded) == 0 {
+		forwarded = md.Get("x-real-ip")
+	}
+	return ClientIP(address, forwarded, trustedProxies)
+}
+
+// trustedProxy reports whether ip is the address of one of the trusted proxies
+func trustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"go-clean-ddd-es-template/pkg/logger"
+)
+
+// callFrom returns the context of a call from the peer at address, with x-forwarded-for when set
+func callFrom(address, forwardedFor string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(address), Port: 50000}})
+	if forwardedFor != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", forwardedFor))
+	}
+	return ctx
+}
+
+// proxyNetworks parses the networks of trusted proxies
+func proxyNetworks(t *testing.T, cidrs ...string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("invalid network %s: %v", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func TestGRPCClientIP_TrustedPeers(t *testing.T) {
+	trusted := proxyNetworks(t, "10.0.0.0/8")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		proxies  []*net.IPNet
+		expected string
+	}{
+		// The gateway forwards the address it received the request from, after those set by the client
+		{"gateway", callFrom("127.0.0.1", "198.51.100.1, 203.0.113.7"), nil, "203.0.113.7"},
+		{"trusted proxy", callFrom("10.1.2.3", "198.51.100.1, 203.0.113.7"), trusted, "203.0.113.7"},
+		{"addresses of trusted proxies are skipped", callFrom("127.0.0.1", "203.0.113.7, 10.1.2.3"), trusted, "203.0.113.7"},
+		{"not forwarded", callFrom("127.0.0.1", ""), nil, "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ip := GRPCClientIP(tt.ctx, tt.proxies); ip != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestGRPCClientIP_UntrustedPeers(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		proxies  []*net.IPNet
+		expected string
+	}{
+		// Callers cannot choose their address, e.g. to escape login throttling or rate limits
+		{"no trusted proxies", callFrom("192.0.2.10", "203.0.113.7"), nil, "192.0.2.10"},
+		{"peer not a trusted proxy", callFrom("192.0.2.10", "203.0.113.7"), proxyNetworks(t, "10.0.0.0/8"), "192.0.2.10"},
+		{"no peer", metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-forwarded-for", "203.0.113.7")), nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ip := GRPCClientIP(tt.ctx, tt.proxies); ip != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestGRPCRateLimitInterceptor_IgnoresForwardedForOfUntrustedPeers(t *testing.T) {
+	testLogger, _ := logger.NewLoggerFromConfig("info", "text")
+
+	config := DefaultValidationConfig()
+	config.RateLimitRequests = 1
+	vm := NewValidationMiddleware(config, testLogger)
+	interceptor := GRPCRateLimitInterceptor(vm)
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.UserService/GetUser"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "success", nil
+	}
+
+	if _, err := interceptor(callFrom("192.0.2.10", "203.0.113.1"), "req", info, handler); err != nil {
+		t.Fatalf("first call should succeed, got %v", err)
+	}
+	// A fresh x-forwarded-for does not give the client a fresh bucket
+	_, err := interceptor(callFrom("192.0.2.10", "203.0.113.2"), "req", info, handler)
+	if st, ok := status.FromError(err); !ok || st.Code() != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted, got %v", err)
+	}
+
+	// Behind a trusted proxy, the forwarded address is the client
+	vm.SetTrustedProxies(proxyNetworks(t, "10.0.0.0/8"))
+	if _, err := interceptor(callFrom("10.1.2.3", "203.0.113.3"), "req", info, handler); err != nil {
+		t.Errorf("call of another client behind a trusted proxy should succeed, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/i18n"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Headers with which clients request localized display values
+const (
+	DisplayFormatHeader    = "X-Display-Format" // "localized" requests display values
+	TimezoneHeader         = "X-Timezone"       // IANA time zone of displayed timestamps, UTC by default
+	DisplayFormatLocalized = "localized"
+)
+
+// DisplayFormatEndpoints lists the gRPC full methods and HTTP route patterns whose responses
+// may carry localized display values
+type DisplayFormatEndpoints map[string]bool
+
+// Wrap installs the display formatter for requests to listed routes that ask for display values.
+// Routes are matched by their ServeMux pattern.
+func (e DisplayFormatEndpoints) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e[r.Pattern] {
+			if formatter := displayFormatter(r.Header.Get(DisplayFormatHeader), r.Header.Get("Accept-Language"), r.Header.Get(TimezoneHeader)); formatter != nil {
+				r = r.WithContext(i18n.WithFormatter(r.Context(), formatter))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GRPCDisplayFormatInterceptor creates a gRPC interceptor installing the display formatter for
+// listed methods when the caller asks for display values
+func GRPCDisplayFormatInterceptor(endpoints DisplayFormatEndpoints) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if endpoints[info.FullMethod] {
+			md, _ := metadata.FromIncomingContext(ctx)
+			acceptLanguage := getMetadataValue(md, "accept-language")
+			if acceptLanguage == "" {
+				acceptLanguage = getMetadataValue(md, "grpcgateway-accept-language")
+			}
+			formatter := displayFormatter(getMetadataValue(md, "x-display-format"), acceptLanguage, getMetadataValue(md, "x-timezone"))
+			if formatter != nil {
+				ctx = i18n.WithFormatter(ctx, formatter)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// displayFormatter returns the formatter requested by the display format headers, or nil
+func displayFormatter(format, acceptLanguage, timezone string) *i18n.Formatter {
+	if !strings.EqualFold(strings.TrimSpace(format), DisplayFormatLocalized) {
+		return nil
+	}
+
+	location := time.UTC
+	if timezone != "" {
+		if loaded, err := time.LoadLocation(timezone); err == nil {
+			location = loaded
+		}
+	}
+
+	return i18n.NewFormatter(i18n.MatchLocale(acceptLanguage), location)
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"go-clean-ddd-es-template/pkg/i18n"
+)
+
+func TestGRPCDisplayFormatInterceptor(t *testing.T) {
+	interceptor := GRPCDisplayFormatInterceptor(DisplayFormatEndpoints{"/user.UserService/GetUser": true})
+
+	locale := func(method string, md metadata.MD) string {
+		var formatter *i18n.Formatter
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			formatter = i18n.FormatterFromContext(ctx)
+			return nil, nil
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if formatter == nil {
+			return ""
+		}
+		return formatter.Locale()
+	}
+
+	localized := metadata.Pairs("x-display-format", "localized", "grpcgateway-accept-language", "vi-VN")
+	if got := locale("/user.UserService/GetUser", localized); got != "vi" {
+		t.Errorf("expected vi formatter, got %q", got)
+	}
+	if got := locale("/user.UserService/GetUser", metadata.Pairs("x-display-format", "localized")); got != "en" {
+		t.Errorf("expected fallback en formatter, got %q", got)
+	}
+	if got := locale("/user.UserService/GetUser", metadata.Pairs("accept-language", "vi")); got != "" {
+		t.Errorf("expected no formatter without display format, got %q", got)
+	}
+	if got := locale("/user.UserService/ListUsers", localized); got != "" {
+		t.Errorf("expected no formatter for unlisted method, got %q", got)
+	}
+}
+
+func TestDisplayFormatEndpoints_Wrap(t *testing.T) {
+	var formatter *i18n.Formatter
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", DisplayFormatEndpoints{"GET /users/{id}": true}.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		formatter = i18n.FormatterFromContext(r.Context())
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(DisplayFormatHeader, "Localized")
+	req.Header.Set("Accept-Language", "vi")
+	req.Header.Set(TimezoneHeader, "Invalid/Zone")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if formatter == nil || formatter.Locale() != "vi" {
+		t.Fatalf("expected vi formatter, got %+v", formatter)
+	}
+}
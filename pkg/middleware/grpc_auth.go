@@ -10,12 +10,16 @@ import (
 	"google.golang.org/grpc/status"
 
 	"go-clean-ddd-es-template/internal/application/services"
+	"go-clean-ddd-es-template/pkg/authz"
 	"go-clean-ddd-es-template/pkg/logger"
 )
 
-// AuthInterceptor provides gRPC authentication middleware
+// AuthInterceptor provides gRPC authentication middleware. With an authorizer, calls are also
+// authorized against its matrix: public methods skip authentication, methods without a policy
+// are denied, and the others require the roles and scopes of their policy.
 type AuthInterceptor struct {
 	authService *services.AuthService
+	authorizer  *authz.Authorizer
 	logger      logger.Logger
 }
 
@@ -27,32 +31,18 @@ func NewAuthInterceptor(authService *services.AuthService, logger logger.Logger)
 	}
 }
 
+// SetAuthorizer authorizes calls against the matrix of authorizer, nil to authenticate them only
+func (a *AuthInterceptor) SetAuthorizer(authorizer *authz.Authorizer) {
+	a.authorizer = authorizer
+}
+
 // UnaryAuthInterceptor returns a unary interceptor for authentication
 func (a *AuthInterceptor) UnaryAuthInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// Skip auth for certain methods
-		if a.shouldSkipAuth(info.FullMethod) {
-			return handler(ctx, req)
-		}
-
-		// Extract token from metadata
-		token, err := a.extractToken(ctx)
+		ctx, err := a.authenticate(ctx, info.FullMethod)
 		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "missing or invalid authorization header: %v", err)
+			return nil, err
 		}
-
-		// Validate token
-		claims, err := a.authService.ValidateToken(ctx, token)
-		if err != nil {
-			a.logger.Error("Token validation failed: %v", err)
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
-		}
-
-		// Add user info to context
-		ctx = context.WithValue(ctx, "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "user_email", claims.Email)
-		ctx = context.WithValue(ctx, "user_roles", claims.Roles)
-
 		return handler(ctx, req)
 	}
 }
@@ -60,29 +50,11 @@ func (a *AuthInterceptor) UnaryAuthInterceptor() grpc.UnaryServerInterceptor {
 // StreamAuthInterceptor returns a stream interceptor for authentication
 func (a *AuthInterceptor) StreamAuthInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		// Skip auth for certain methods
-		if a.shouldSkipAuth(info.FullMethod) {
-			return handler(srv, stream)
-		}
-
-		// Extract token from metadata
-		token, err := a.extractToken(stream.Context())
-		if err != nil {
-			return status.Errorf(codes.Unauthenticated, "missing or invalid authorization header: %v", err)
-		}
-
-		// Validate token
-		claims, err := a.authService.ValidateToken(stream.Context(), token)
+		ctx, err := a.authenticate(stream.Context(), info.FullMethod)
 		if err != nil {
-			a.logger.Error("Token validation failed: %v", err)
-			return status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+			return err
 		}
 
-		// Create new context with user info
-		ctx := context.WithValue(stream.Context(), "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "user_email", claims.Email)
-		ctx = context.WithValue(ctx, "user_roles", claims.Roles)
-
 		// Create wrapped stream
 		wrappedStream := &wrappedServerStream{
 			ServerStream: stream,
@@ -93,6 +65,52 @@ func (a *AuthInterceptor) StreamAuthInterceptor() grpc.StreamServerInterceptor {
 	}
 }
 
+// authenticate validates the token of a call of method and authorizes it, returning the context
+// of the call with the user info of the token
+func (a *AuthInterceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if a.authorizer != nil {
+		policy, ok := a.authorizer.Policy(method)
+		if !ok {
+			a.logger.Error("Denied call of %s: no authorization policy", method)
+			return nil, status.Errorf(codes.PermissionDenied, "insufficient permissions")
+		}
+		if policy.Public {
+			return ctx, nil
+		}
+	} else if a.shouldSkipAuth(method) {
+		// Skip auth for certain methods
+		return ctx, nil
+	}
+
+	// Extract token from metadata
+	token, err := a.extractToken(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "missing or invalid authorization header: %v", err)
+	}
+
+	// Validate token
+	claims, err := a.authService.ValidateToken(ctx, token)
+	if err != nil {
+		a.logger.Error("Token validation failed: %v", err)
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+
+	// Check the roles and scopes the method requires
+	if a.authorizer != nil {
+		if err := a.authorizer.Authorize(method, claims.Roles, claims.Scopes); err != nil {
+			a.logger.Error("Denied call of %s - user_id: %s, user_roles: %v, user_scopes: %v: %v", method, claims.UserID, claims.Roles, claims.Scopes, err)
+			return nil, status.Errorf(codes.PermissionDenied, "insufficient permissions")
+		}
+	}
+
+	// Add user info to context
+	ctx = context.WithValue(ctx, "user_id", claims.UserID)
+	ctx = context.WithValue(ctx, "user_email", claims.Email)
+	ctx = context.WithValue(ctx, "user_roles", claims.Roles)
+	ctx = context.WithValue(ctx, "user_scopes", claims.Scopes)
+	return ctx, nil
+}
+
 // RoleAuthInterceptor returns a unary interceptor for role-based authorization
 func (a *AuthInterceptor) RoleAuthInterceptor(requiredRoles ...string) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
@@ -125,30 +143,37 @@ func (a *AuthInterceptor) RoleAuthInterceptor(requiredRoles ...string) grpc.Unar
 	}
 }
 
+// unauthenticatedMethods are the methods called without a user token when calls are only
+// authenticated. Methods of services added later are authenticated until listed here.
+var unauthenticatedMethods = map[string]bool{
+	"/auth.AuthService/Register":                 true,
+	"/auth.AuthService/Login":                    true,
+	"/auth.AuthService/RequestMagicLink":         true,
+	"/auth.AuthService/ConsumeMagicLink":         true,
+	"/auth.AuthService/ForgotPassword":           true,
+	"/auth.AuthService/ResetPassword":            true,
+	"/auth.AuthService/RequestEmailVerification": true,
+	"/auth.AuthService/VerifyEmail":              true,
+	"/auth.AuthService/RefreshToken":             true,
+	"/auth.AuthService/RevokeRefreshToken":       true,
+
+	"/grpc.health.v1.Health/Check":                                   true,
+	"/grpc.health.v1.Health/Watch":                                   true,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      true,
+
+	// The dead letter queue admin service authenticates every call with the admin token itself
+	"/admin.DeadLetterQueueService/ListFailedEvents":  true,
+	"/admin.DeadLetterQueueService/GetFailedEvent":    true,
+	"/admin.DeadLetterQueueService/RetryFailedEvent":  true,
+	"/admin.DeadLetterQueueService/DeleteFailedEvent": true,
+	"/admin.DeadLetterQueueService/GetStats":          true,
+	"/admin.DeadLetterQueueService/ClearFailedEvents": true,
+}
+
 // shouldSkipAuth checks if authentication should be skipped for a method
 func (a *AuthInterceptor) shouldSkipAuth(method string) bool {
-	// Skip auth for these methods
-	skipMethods := []string{
-		"/auth.AuthService/Register",
-		"/auth.AuthService/Login",
-		"/grpc.health.v1.Health/Check",
-		"/grpc.health.v1.Health/Watch",
-		"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
-		"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
-	}
-
-	for _, skipMethod := range skipMethods {
-		if method == skipMethod {
-			return true
-		}
-	}
-
-	// Skip reflection methods
-	if strings.Contains(method, "grpc.reflection") {
-		return true
-	}
-
-	return false
+	return unauthenticatedMethods[method]
 }
 
 // extractToken extracts JWT token from gRPC metadata
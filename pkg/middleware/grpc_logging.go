@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/redact"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// SensitiveRequestFields are request fields never logged. Generated protobuf messages cannot carry
+// `sensitive` tags, so their sensitive fields are stripped by name.
+var SensitiveRequestFields = []string{"password", "current_password", "new_password", "token", "refresh_token"}
+
+// RequestLogger logs requests
+type RequestLogger interface {
+	Info(format string, v ...interface{})
+}
+
+// GRPCRequestLoggingInterceptor creates a gRPC interceptor logging every call with its status,
+// duration and request payload. Payloads are stripped of fields tagged `sensitive` and of
+// SensitiveRequestFields.
+func GRPCRequestLoggingInterceptor(log RequestLogger) grpc.UnaryServerInterceptor {
+	redactor := redact.NewRedactor(redact.ForLogs, SensitiveRequestFields...)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		payload, marshalErr := redactor.Marshal(req)
+		if marshalErr != nil {
+			payload = []byte(`"<unserializable>"`)
+		}
+		log.Info("gRPC %s %s in %s request=%s", info.FullMethod, status.Code(err), time.Since(start).Round(time.Microsecond), payload)
+		return resp, err
+	}
+}
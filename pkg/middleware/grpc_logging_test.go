@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+type capturingLogger struct {
+	lines []string
+}
+
+func (l *capturingLogger) Info(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+type loginRequest struct {
+	Email    string `json:"email,omitempty"`
+	Password string `json:"password,omitempty"`
+	Otp      string `json:"otp,omitempty" sensitive:"true"`
+}
+
+func TestGRPCRequestLoggingInterceptorStripsSensitiveFields(t *testing.T) {
+	log := &capturingLogger{}
+	interceptor := GRPCRequestLoggingInterceptor(log)
+
+	req := &loginRequest{Email: "test@example.com", Password: "secret-password", Otp: "123456"}
+	_, err := interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/Login"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(log.lines) != 1 {
+		t.Fatalf("expected one log line, got %d", len(log.lines))
+	}
+	line := log.lines[0]
+	if !strings.Contains(line, "/auth.AuthService/Login OK") || !strings.Contains(line, "test@example.com") {
+		t.Errorf("log line misses the call: %s", line)
+	}
+	if strings.Contains(line, "secret-password") || strings.Contains(line, "123456") {
+		t.Errorf("log line leaks a sensitive field: %s", line)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/consistency"
+	"go-clean-ddd-es-template/pkg/requestcost"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// ReadCachePolicy declares how responses of an idempotent gRPC method are cached
+type ReadCachePolicy struct {
+	TTL  time.Duration
+	Tags []string // Invalidation tags, e.g. "users" for every read of the user read model
+}
+
+// GRPCReadCacheInterceptor creates a gRPC interceptor caching responses of the methods in policies,
+// keyed by method, request, caller credentials and display format. Callers can skip the cache by sending
+// "cache-control: no-cache" or "no-store" metadata, which the gateway forwards from the HTTP header.
+// Reads carrying a consistency token skip the cache too, which may be older than the caller's write.
+func GRPCReadCacheInterceptor(store *cache.TaggedCache, policies map[string]ReadCachePolicy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		policy, ok := policies[info.FullMethod]
+		if !ok || policy.TTL <= 0 {
+			return handler(ctx, req)
+		}
+
+		message, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		key, err := readCacheKey(info.FullMethod, message, md)
+		if err != nil {
+			return handler(ctx, req)
+		}
+
+		noCache, noStore := readCacheDirectives(md)
+		if noStore {
+			return handler(ctx, req)
+		}
+		if !noCache {
+			if cached, ok := store.Get(key); ok {
+				requestcost.RecordCacheHit(ctx)
+				return proto.Clone(cached.(proto.Message)), nil
+			}
+			requestcost.RecordCacheMiss(ctx)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		if response, ok := resp.(proto.Message); ok {
+			store.Set(key, proto.Clone(response), policy.TTL, policy.Tags...)
+		}
+		return resp, nil
+	}
+}
+
+// readCacheVaryMetadata are the metadata keys that change the response to the same request
+var readCacheVaryMetadata = []string{"authorization", "accept-language", "grpcgateway-accept-language", "x-display-format", "x-timezone"}
+
+// readCacheKey hashes the method, the deterministic request encoding, the caller credentials
+// and the requested display format
+func readCacheKey(method string, req proto.Message, md metadata.MD) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write(data)
+	for _, key := range readCacheVaryMetadata {
+		hash.Write([]byte{0})
+		hash.Write([]byte(strings.Join(md.Get(key), ",")))
+	}
+	return "grpc:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readCacheDirectives reports whether the caller asked for a fresh response and whether it may be stored
+func readCacheDirectives(md metadata.MD) (noCache, noStore bool) {
+	if len(md.Get(consistency.MetadataKey)) > 0 {
+		noCache = true
+	}
+
+	values := append(md.Get("cache-control"), md.Get("grpcgateway-cache-control")...)
+	for _, value := range values {
+		directives := parseCacheControl(value)
+		if _, ok := directives["no-cache"]; ok {
+			noCache = true
+		}
+		if _, ok := directives["no-store"]; ok {
+			noStore = true
+		}
+	}
+	return noCache, noStore
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"go-clean-ddd-es-template/pkg/cache"
+)
+
+func TestGRPCReadCacheInterceptor(t *testing.T) {
+	store := cache.NewTaggedCache(0, nil)
+	interceptor := GRPCReadCacheInterceptor(store, map[string]ReadCachePolicy{
+		"/user.UserService/GetUser": {TTL: time.Minute, Tags: []string{"users"}},
+	})
+
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return wrapperspb.String("user " + req.(*wrapperspb.StringValue).GetValue()), nil
+	}
+
+	call := func(ctx context.Context, method, id string) string {
+		resp, err := interceptor(ctx, wrapperspb.String(id), &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.(*wrapperspb.StringValue).GetValue()
+	}
+
+	ctx := context.Background()
+	call(ctx, "/user.UserService/GetUser", "1")
+	if got := call(ctx, "/user.UserService/GetUser", "1"); got != "user 1" || calls != 1 {
+		t.Errorf("expected cached response, got %q after %d calls", got, calls)
+	}
+
+	// Different requests, callers and uncached methods reach the handler
+	call(ctx, "/user.UserService/GetUser", "2")
+	call(metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer other")), "/user.UserService/GetUser", "1")
+	call(ctx, "/user.UserService/DeleteUser", "1")
+	call(ctx, "/user.UserService/DeleteUser", "1")
+	if calls != 5 {
+		t.Errorf("expected 5 handler calls, got %d", calls)
+	}
+
+	// no-cache skips the lookup, invalidation drops the entries
+	call(metadata.NewIncomingContext(ctx, metadata.Pairs("grpcgateway-cache-control", "no-cache")), "/user.UserService/GetUser", "1")
+	store.InvalidateTag("users")
+	call(ctx, "/user.UserService/GetUser", "1")
+	if calls != 7 {
+		t.Errorf("expected 7 handler calls, got %d", calls)
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/requestcost"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// GRPCRequestCostInterceptor creates a gRPC interceptor accounting the database queries, broker
+// publishes and cache lookups of every call. With headers, the cost is returned in server-timing
+// metadata, forwarded by the gateway as the Server-Timing header, or in the trailer when the
+// handler already sent the header. A non-nil aggregator aggregates the cost per method.
+func GRPCRequestCostInterceptor(aggregator *requestcost.Aggregator, headers bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, cost := requestcost.WithCost(ctx)
+
+		resp, err := handler(ctx, req)
+
+		elapsed := time.Since(start)
+		snapshot := cost.Snapshot()
+		if headers {
+			md := metadata.Pairs(requestcost.MetadataKey, snapshot.ServerTiming(elapsed))
+			if grpc.SetHeader(ctx, md) != nil {
+				_ = grpc.SetTrailer(ctx, md)
+			}
+		}
+		if aggregator != nil {
+			aggregator.Record(info.FullMethod, snapshot, elapsed)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/requestcost"
+
+	"google.golang.org/grpc"
+)
+
+func TestGRPCRequestCostInterceptor(t *testing.T) {
+	interceptor := GRPCRequestCostInterceptor(nil, true)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		for range 3 {
+			requestcost.RecordQuery(ctx, time.Millisecond)
+		}
+		requestcost.RecordPublish(ctx, time.Millisecond)
+		requestcost.RecordCacheHit(ctx)
+		return "ok", nil
+	}
+
+	stream := &headerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.v2.UserService/ListUsers"}, handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	timing := stream.header.Get(requestcost.MetadataKey)
+	if len(timing) != 1 {
+		t.Fatalf("expected one server-timing header, got %v", timing)
+	}
+	for _, metric := range []string{`db;dur=3.0;desc="3 queries"`, `broker;dur=1.0;desc="1 publishes"`, `cache;desc="1 hits, 0 misses"`, "total;dur="} {
+		if !strings.Contains(timing[0], metric) {
+			t.Errorf("server-timing %q misses %q", timing[0], metric)
+		}
+	}
+
+	stream = &headerStream{}
+	ctx = grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	if _, err := GRPCRequestCostInterceptor(nil, false)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.v2.UserService/ListUsers"}, handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stream.header) != 0 {
+		t.Errorf("expected no headers when they are disabled, got %v", stream.header)
+	}
+}
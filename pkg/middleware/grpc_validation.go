@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
 
 	"google.golang.org/grpc"
@@ -24,7 +23,8 @@ func GRPCValidationInterceptor(validationMiddleware *ValidationMiddleware) grpc.
 		}
 
 		// Validate request payload
-		if err := validateGRPCRequest(req, validationMiddleware); err != nil {
+		profile := validationMiddleware.routeProfile("", info.FullMethod)
+		if err := validateGRPCRequestWithProfile(req, validationMiddleware, profile); err != nil {
 			return nil, status.Errorf(codes.InvalidArgument, "request validation failed: %v", err)
 		}
 
@@ -45,6 +45,7 @@ func GRPCStreamValidationInterceptor(validationMiddleware *ValidationMiddleware)
 		wrappedStream := &validatedServerStream{
 			ServerStream:         stream,
 			validationMiddleware: validationMiddleware,
+			profile:              validationMiddleware.routeProfile("", info.FullMethod),
 		}
 
 		// Continue to handler
@@ -56,6 +57,7 @@ func GRPCStreamValidationInterceptor(validationMiddleware *ValidationMiddleware)
 type validatedServerStream struct {
 	grpc.ServerStream
 	validationMiddleware *ValidationMiddleware
+	profile              RouteProfile
 }
 
 // RecvMsg validates incoming messages
@@ -65,7 +67,7 @@ func (s *validatedServerStream) RecvMsg(m interface{}) error {
 	}
 
 	// Validate message
-	if err := validateGRPCRequest(m, s.validationMiddleware); err != nil {
+	if err := validateGRPCRequestWithProfile(m, s.validationMiddleware, s.profile); err != nil {
 		return status.Errorf(codes.InvalidArgument, "message validation failed: %v", err)
 	}
 
@@ -110,8 +112,13 @@ func validateGRPCMetadata(ctx context.Context, vm *ValidationMiddleware) error {
 	return nil
 }
 
-// validateGRPCRequest validates gRPC request payload
+// validateGRPCRequest validates gRPC request payload using the default profile
 func validateGRPCRequest(req interface{}, vm *ValidationMiddleware) error {
+	return validateGRPCRequestWithProfile(req, vm, vm.routeProfile("", ""))
+}
+
+// validateGRPCRequestWithProfile validates gRPC request payload against a route profile
+func validateGRPCRequestWithProfile(req interface{}, vm *ValidationMiddleware, profile RouteProfile) error {
 	if req == nil {
 		return nil
 	}
@@ -123,7 +130,7 @@ func validateGRPCRequest(req interface{}, vm *ValidationMiddleware) error {
 	}
 
 	// Check for blocked patterns
-	if vm.containsBlockedPatterns(reqStr) {
+	if !profile.SkipPatternCheck && vm.containsBlockedPatterns(reqStr) {
 		return errors.New(errors.ErrBadRequest, "Request contains blocked patterns")
 	}
 
@@ -133,7 +140,7 @@ func validateGRPCRequest(req interface{}, vm *ValidationMiddleware) error {
 	}
 
 	// Check request size (approximate)
-	if int64(len(reqStr)) > vm.config.MaxRequestSize {
+	if int64(len(reqStr)) > profile.MaxRequestSize {
 		return errors.New(errors.ErrBadRequest, "Request too large")
 	}
 
@@ -170,24 +177,13 @@ func requestToString(req interface{}) string {
 // GRPCRateLimitInterceptor creates a gRPC interceptor for rate limiting
 func GRPCRateLimitInterceptor(validationMiddleware *ValidationMiddleware) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// Create a mock HTTP request for rate limiting
-		mockReq := &http.Request{
-			Method: "POST", // gRPC requests are typically POST
-			Header: make(http.Header),
-		}
-
-		// Extract client IP from gRPC metadata
-		if md, ok := metadata.FromIncomingContext(ctx); ok {
-			if forwardedFor := md.Get("x-forwarded-for"); len(forwardedFor) > 0 {
-				mockReq.Header.Set("X-Forwarded-For", forwardedFor[0])
-			}
-			if realIP := md.Get("x-real-ip"); len(realIP) > 0 {
-				mockReq.Header.Set("X-Real-IP", realIP[0])
-			}
-		}
-
-		// Check rate limit
-		if err := validationMiddleware.checkRateLimit(mockReq); err != nil {
+		// Check rate limit of the client, returning its status as header metadata so clients see
+		// warnings early
+		profile := validationMiddleware.routeProfile("", info.FullMethod)
+		clientIP := GRPCClientIP(ctx, validationMiddleware.trustedProxies)
+		rateLimit, err := validationMiddleware.checkRateLimitFor(clientIP, profile)
+		_ = grpc.SetHeader(ctx, rateLimit.metadata())
+		if err != nil {
 			return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded: %v", err)
 		}
 
@@ -199,24 +195,13 @@ func GRPCRateLimitInterceptor(validationMiddleware *ValidationMiddleware) grpc.U
 // GRPCStreamRateLimitInterceptor creates a gRPC stream interceptor for rate limiting
 func GRPCStreamRateLimitInterceptor(validationMiddleware *ValidationMiddleware) grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		// Create a mock HTTP request for rate limiting
-		mockReq := &http.Request{
-			Method: "POST", // gRPC requests are typically POST
-			Header: make(http.Header),
-		}
-
-		// Extract client IP from gRPC metadata
-		if md, ok := metadata.FromIncomingContext(stream.Context()); ok {
-			if forwardedFor := md.Get("x-forwarded-for"); len(forwardedFor) > 0 {
-				mockReq.Header.Set("X-Forwarded-For", forwardedFor[0])
-			}
-			if realIP := md.Get("x-real-ip"); len(realIP) > 0 {
-				mockReq.Header.Set("X-Real-IP", realIP[0])
-			}
-		}
-
-		// Check rate limit
-		if err := validationMiddleware.checkRateLimit(mockReq); err != nil {
+		// Check rate limit of the client, returning its status as header metadata so clients see
+		// warnings early
+		profile := validationMiddleware.routeProfile("", info.FullMethod)
+		clientIP := GRPCClientIP(stream.Context(), validationMiddleware.trustedProxies)
+		rateLimit, err := validationMiddleware.checkRateLimitFor(clientIP, profile)
+		_ = stream.SetHeader(rateLimit.metadata())
+		if err != nil {
 			return status.Errorf(codes.ResourceExhausted, "rate limit exceeded: %v", err)
 		}
 
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"bufio"
+	"errors"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -31,9 +34,15 @@ func (m *MetricsMiddleware) Wrap(next http.Handler) http.Handler {
 			statusCode:     http.StatusOK,
 		}
 
+		// Label by the matched route so path parameters do not create new series
+		endpoint := r.URL.Path
+		if r.Pattern != "" {
+			endpoint = r.Pattern
+		}
+
 		// Record in-flight request
-		m.metrics.RecordHTTPRequestInFlight(r.Method, r.URL.Path, 1)
-		defer m.metrics.RecordHTTPRequestInFlight(r.Method, r.URL.Path, 0)
+		m.metrics.RecordHTTPRequestInFlight(r.Method, endpoint, 1)
+		defer m.metrics.RecordHTTPRequestInFlight(r.Method, endpoint, 0)
 
 		// Call the next handler
 		next.ServeHTTP(wrappedWriter, r)
@@ -41,7 +50,7 @@ func (m *MetricsMiddleware) Wrap(next http.Handler) http.Handler {
 		// Record metrics
 		duration := time.Since(start).Seconds()
 		status := strconv.Itoa(wrappedWriter.statusCode)
-		m.metrics.RecordHTTPRequest(r.Method, r.URL.Path, status, duration)
+		m.metrics.RecordHTTPRequest(r.Method, endpoint, status, duration)
 	})
 }
 
@@ -59,3 +68,22 @@ func (rw *responseWriter) WriteHeader(code int) {
 func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
+
+// Hijack hands the connection over to the handler, e.g. to serve WebSocket, recording the
+// request as switching protocols
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err == nil {
+		rw.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Rate limit headers returned with every rate limited request, and as gRPC header metadata
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"     // Requests allowed per window
+	RateLimitRemainingHeader = "X-RateLimit-Remaining" // Requests left in the current window
+	RateLimitResetHeader     = "X-RateLimit-Reset"     // Seconds until the window resets
+	RateLimitWarningHeader   = "X-RateLimit-Warning"   // Set once the soft limit is reached
+)
+
+// RateLimitApproachingLimit is the warning of clients past the soft limit, which are still served
+// but will be rejected once they reach the hard limit
+const RateLimitApproachingLimit = "approaching-limit"
+
+// RateLimitStatus is the state of a client's rate limit window after a request
+type RateLimitStatus struct {
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+	Warning   bool // Whether the soft limit is reached
+}
+
+// setHeaders writes the status as HTTP response headers
+func (s RateLimitStatus) setHeaders(header http.Header) {
+	header.Set(RateLimitLimitHeader, strconv.Itoa(s.Limit))
+	header.Set(RateLimitRemainingHeader, strconv.Itoa(s.Remaining))
+	header.Set(RateLimitResetHeader, strconv.Itoa(int(s.Reset.Round(time.Second).Seconds())))
+	if s.Warning {
+		header.Set(RateLimitWarningHeader, RateLimitApproachingLimit)
+	}
+}
+
+// metadata returns the status as gRPC header metadata
+func (s RateLimitStatus) metadata() metadata.MD {
+	header := make(http.Header)
+	s.setHeaders(header)
+
+	md := metadata.MD{}
+	for key, values := range header {
+		md.Set(key, values...)
+	}
+	return md
+}
+
+// IsRateLimitMetadata reports whether a gRPC metadata key carries rate limit headers, so the
+// gateway can forward it to HTTP clients as is
+func IsRateLimitMetadata(key string) bool {
+	switch http.CanonicalHeaderKey(key) {
+	case RateLimitLimitHeader, RateLimitRemainingHeader, RateLimitResetHeader, RateLimitWarningHeader:
+		return true
+	}
+	return false
+}
+
+// RateLimitStatusFromMetadata reads the rate limit status returned by a server in header metadata
+func RateLimitStatusFromMetadata(md metadata.MD) (RateLimitStatus, bool) {
+	limit, err := strconv.Atoi(firstMetadata(md, RateLimitLimitHeader))
+	if err != nil {
+		return RateLimitStatus{}, false
+	}
+	remaining, _ := strconv.Atoi(firstMetadata(md, RateLimitRemainingHeader))
+	reset, _ := strconv.Atoi(firstMetadata(md, RateLimitResetHeader))
+	return RateLimitStatus{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Duration(reset) * time.Second,
+		Warning:   firstMetadata(md, RateLimitWarningHeader) == RateLimitApproachingLimit,
+	}, true
+}
+
+// GRPCRateLimitWarningClientInterceptor creates a gRPC client interceptor surfacing rate limit
+// warnings: onWarning is called with the status of every call made past the server's soft limit,
+// e.g. to slow down or log before calls are rejected.
+func GRPCRateLimitWarningClientInterceptor(onWarning func(ctx context.Context, method string, status RateLimitStatus)) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var header metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		if status, ok := RateLimitStatusFromMetadata(header); ok && status.Warning {
+			onWarning(ctx, method, status)
+		}
+		return err
+	}
+}
+
+// firstMetadata returns the first value of a metadata key
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
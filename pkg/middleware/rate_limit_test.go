@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"go-clean-ddd-es-template/pkg/logger"
+)
+
+func TestValidationMiddleware_SoftRateLimit(t *testing.T) {
+	testLogger, _ := logger.NewLoggerFromConfig("info", "text")
+
+	config := DefaultValidationConfig()
+	config.RateLimitRequests = 4
+	config.RateLimitWindow = time.Minute
+	config.RateLimitSoftPct = 50
+	vm := NewValidationMiddleware(config, testLogger)
+
+	handler := vm.ValidateRequest()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		code      int
+		remaining string
+		warning   string
+	}{
+		{http.StatusOK, "3", ""},
+		{http.StatusOK, "2", RateLimitApproachingLimit},
+		{http.StatusOK, "1", RateLimitApproachingLimit},
+		{http.StatusOK, "0", RateLimitApproachingLimit},
+		{http.StatusTooManyRequests, "0", RateLimitApproachingLimit},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/users", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("request %d: expected status %d, got %d", i+1, tt.code, w.Code)
+		}
+		if got := w.Header().Get(RateLimitLimitHeader); got != "4" {
+			t.Errorf("request %d: expected limit 4, got %q", i+1, got)
+		}
+		if got := w.Header().Get(RateLimitRemainingHeader); got != tt.remaining {
+			t.Errorf("request %d: expected %s remaining, got %q", i+1, tt.remaining, got)
+		}
+		if got := w.Header().Get(RateLimitWarningHeader); got != tt.warning {
+			t.Errorf("request %d: expected warning %q, got %q", i+1, tt.warning, got)
+		}
+	}
+}
+
+func TestGRPCRateLimitWarningClientInterceptor(t *testing.T) {
+	var warnings []RateLimitStatus
+	interceptor := GRPCRateLimitWarningClientInterceptor(func(ctx context.Context, method string, status RateLimitStatus) {
+		warnings = append(warnings, status)
+	})
+
+	invoke := func(status RateLimitStatus) {
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			for _, opt := range opts {
+				if header, ok := opt.(grpc.HeaderCallOption); ok {
+					*header.HeaderAddr = status.metadata()
+				}
+			}
+			return nil
+		}
+		if err := interceptor(context.Background(), "/user.v2.UserService/GetUser", nil, nil, nil, invoker); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	invoke(RateLimitStatus{Limit: 10, Remaining: 5, Reset: time.Minute})
+	invoke(RateLimitStatus{Limit: 10, Remaining: 1, Reset: time.Minute, Warning: true})
+
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(warnings))
+	}
+	if warnings[0].Remaining != 1 || warnings[0].Limit != 10 || warnings[0].Reset != time.Minute {
+		t.Errorf("unexpected warning status: %+v", warnings[0])
+	}
+
+	if _, ok := RateLimitStatusFromMetadata(metadata.MD{}); ok {
+		t.Error("expected no status without rate limit metadata")
+	}
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/requestcost"
+)
+
+// Cache status header values
+const (
+	CacheStatusHeader = "X-Cache"
+	CacheHit          = "HIT"
+	CacheMiss         = "MISS"
+	CacheBypass       = "BYPASS"
+)
+
+// ResponseCacheConfig holds HTTP response cache configuration
+type ResponseCacheConfig struct {
+	DefaultTTL  time.Duration                  // TTL of cacheable responses without max-age; zero only caches responses with max-age
+	MaxBodySize int64                          // Larger responses are not cached
+	Tags        func(r *http.Request) []string // Invalidation tags of a cached response
+	Clock       clock.Clock                    // Clock used for the Age header (nil uses the system clock)
+}
+
+// ResponseCache caches successful GET and HEAD responses honoring Cache-Control.
+// Requests with different credentials, languages or display formats never share entries, and responses to
+// authenticated requests are only stored when marked public, s-maxage or must-revalidate.
+type ResponseCache struct {
+	store  *cache.TaggedCache
+	config ResponseCacheConfig
+	clock  clock.Clock
+}
+
+// cachedResponse is a stored HTTP response
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+	stored time.Time
+}
+
+// NewResponseCache creates a new HTTP response cache middleware
+func NewResponseCache(store *cache.TaggedCache, config ResponseCacheConfig) *ResponseCache {
+	return &ResponseCache{
+		store:  store,
+		config: config,
+		clock:  clock.OrDefault(config.Clock),
+	}
+}
+
+// Wrap wraps an HTTP handler with response caching
+func (c *ResponseCache) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestDirectives := parseCacheControl(r.Header.Get("Cache-Control"))
+		if _, ok := requestDirectives["no-store"]; ok {
+			w.Header().Set(CacheStatusHeader, CacheBypass)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := responseCacheKey(r)
+		if _, noCache := requestDirectives["no-cache"]; !noCache {
+			if value, ok := c.store.Get(key); ok {
+				c.writeCached(w, r, value.(*cachedResponse))
+				return
+			}
+		}
+
+		recorder := &cacheRecorder{ResponseWriter: w, statusCode: http.StatusOK, maxBody: c.config.MaxBodySize}
+		w.Header().Set(CacheStatusHeader, CacheMiss)
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode != http.StatusOK || recorder.overflow {
+			return
+		}
+		cacheControl := w.Header().Get("Cache-Control")
+		if r.Header.Get("Authorization") != "" && !storableWithAuthorization(cacheControl) {
+			return
+		}
+		ttl, ok := responseTTL(cacheControl, c.config.DefaultTTL)
+		if !ok {
+			return
+		}
+
+		var tags []string
+		if c.config.Tags != nil {
+			tags = c.config.Tags(r)
+		}
+		header := w.Header().Clone()
+		header.Del(CacheStatusHeader)
+		// The cost of the request storing the response is not the cost of the hits replaying it
+		header.Del(requestcost.Header)
+		c.store.Set(key, &cachedResponse{
+			status: recorder.statusCode,
+			header: header,
+			body:   recorder.body.Bytes(),
+			stored: c.clock.Now(),
+		}, ttl, tags...)
+	})
+}
+
+// writeCached replays a cached response
+func (c *ResponseCache) writeCached(w http.ResponseWriter, r *http.Request, response *cachedResponse) {
+	for name, values := range response.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Age", strconv.Itoa(int(c.clock.Since(response.stored).Seconds())))
+	w.Header().Set(CacheStatusHeader, CacheHit)
+	w.WriteHeader(response.status)
+	if r.Method != http.MethodHead {
+		w.Write(response.body)
+	}
+}
+
+// responseCacheKey identifies a response by request target, credentials, language and display format
+func responseCacheKey(r *http.Request) string {
+	hash := sha256.New()
+	for _, part := range []string{
+		r.Method, r.URL.RequestURI(), r.Header.Get("Authorization"), r.Header.Get("Accept-Language"), r.Header.Get("Accept"),
+		r.Header.Get(DisplayFormatHeader), r.Header.Get(TimezoneHeader),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return "http:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseTTL returns how long a response may be cached according to its Cache-Control header
+func responseTTL(header string, defaultTTL time.Duration) (time.Duration, bool) {
+	directives := parseCacheControl(header)
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[directive]; ok {
+			return 0, false
+		}
+	}
+
+	// s-maxage applies to shared caches and takes precedence over max-age
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		if value, ok := directives[directive]; ok {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	return defaultTTL, defaultTTL > 0
+}
+
+// storableWithAuthorization reports whether a response to an authenticated request may be stored
+func storableWithAuthorization(header string) bool {
+	directives := parseCacheControl(header)
+	for _, directive := range []string{"public", "s-maxage", "must-revalidate"} {
+		if _, ok := directives[directive]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCacheControl parses Cache-Control directives into lower-case names and their values
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+	return directives
+}
+
+// cacheRecorder passes a response through while keeping a copy of its body
+type cacheRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+	maxBody    int64
+	overflow   bool
+}
+
+func (rw *cacheRecorder) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *cacheRecorder) Write(b []byte) (int, error) {
+	if !rw.overflow {
+		if rw.maxBody > 0 && int64(rw.body.Len()+len(b)) > rw.maxBody {
+			rw.overflow = true
+			rw.body.Reset()
+		} else {
+			rw.body.Write(b)
+		}
+	}
+	return rw.ResponseWriter.Write(b)
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/cache"
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+func TestResponseCache(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		requestHeader http.Header
+		cacheControl  string
+		status        int
+		expectedCalls int
+	}{
+		{name: "cached with default ttl", method: http.MethodGet, status: http.StatusOK, expectedCalls: 1},
+		{name: "cached with max-age", method: http.MethodGet, cacheControl: "public, max-age=60", status: http.StatusOK, expectedCalls: 1},
+		{name: "response no-store", method: http.MethodGet, cacheControl: "no-store", status: http.StatusOK, expectedCalls: 2},
+		{name: "response private", method: http.MethodGet, cacheControl: "private, max-age=60", status: http.StatusOK, expectedCalls: 2},
+		{name: "request no-cache", method: http.MethodGet, requestHeader: http.Header{"Cache-Control": {"no-cache"}}, status: http.StatusOK, expectedCalls: 2},
+		{name: "non idempotent method", method: http.MethodPost, status: http.StatusOK, expectedCalls: 2},
+		{name: "error status", method: http.MethodGet, status: http.StatusNotFound, expectedCalls: 2},
+		{name: "authorized without public", method: http.MethodGet, requestHeader: http.Header{"Authorization": {"Bearer token"}}, status: http.StatusOK, expectedCalls: 2},
+		{name: "authorized and public", method: http.MethodGet, requestHeader: http.Header{"Authorization": {"Bearer token"}}, cacheControl: "public", status: http.StatusOK, expectedCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte("users"))
+			})
+
+			rc := NewResponseCache(cache.NewTaggedCache(0, nil), ResponseCacheConfig{DefaultTTL: time.Minute})
+			wrapped := rc.Wrap(handler)
+
+			var last *httptest.ResponseRecorder
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(tt.method, "/api/v1/users", nil)
+				for name, values := range tt.requestHeader {
+					req.Header[name] = values
+				}
+				last = httptest.NewRecorder()
+				wrapped.ServeHTTP(last, req)
+			}
+
+			if calls != tt.expectedCalls {
+				t.Errorf("expected %d handler calls, got %d", tt.expectedCalls, calls)
+			}
+			if last.Body.String() != "users" {
+				t.Errorf("expected body %q, got %q", "users", last.Body.String())
+			}
+			if tt.expectedCalls == 1 && last.Header().Get(CacheStatusHeader) != CacheHit {
+				t.Errorf("expected cache hit, got %q", last.Header().Get(CacheStatusHeader))
+			}
+		})
+	}
+}
+
+func TestResponseCache_AgeAndInvalidation(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := cache.NewTaggedCache(0, fake)
+	calls := 0
+	rc := NewResponseCache(store, ResponseCacheConfig{
+		DefaultTTL: time.Minute,
+		Tags:       func(r *http.Request) []string { return []string{"users"} },
+		Clock:      fake,
+	})
+	wrapped := rc.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("users"))
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		wrapped.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+		return rec
+	}
+
+	serve()
+	fake.Advance(10 * time.Second)
+	if age := serve().Header().Get("Age"); age != "10" {
+		t.Errorf("expected Age 10, got %q", age)
+	}
+
+	store.InvalidateTag("users")
+	if status := serve().Header().Get(CacheStatusHeader); status != CacheMiss {
+		t.Errorf("expected cache miss after invalidation, got %q", status)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 handler calls, got %d", calls)
+	}
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProfileName is the profile used for routes without a registered profile
+const DefaultProfileName = "default"
+
+// RouteProfile overrides validation and rate limiting for matching routes.
+// Zero values fall back to the global ValidationConfig.
+type RouteProfile struct {
+	Name                  string
+	MaxRequestSize        int64         // Maximum request body size in bytes
+	RateLimitRequests     int           // Number of requests per window
+	RateLimitSoftRequests int           // Requests per window after which responses warn of the limit
+	RateLimitWindow       time.Duration // Time window for rate limiting
+	SkipPatternCheck      bool          // Skip blocked pattern checks (e.g. binary uploads)
+}
+
+// routeEntry is a registered route pattern
+type routeEntry struct {
+	method  string
+	pattern string
+	prefix  bool
+	profile RouteProfile
+}
+
+// RouteRegistry maps HTTP routes and gRPC methods to route profiles
+type RouteRegistry struct {
+	mu     sync.RWMutex
+	routes []routeEntry
+}
+
+// NewRouteRegistry creates a new route registry
+func NewRouteRegistry() *RouteRegistry {
+	return &RouteRegistry{}
+}
+
+// Register declares a profile for a route.
+// Method is an HTTP method or empty for any method (gRPC methods use empty).
+// Pattern is an exact path such as "/v1/auth/login", a gRPC full method such as
+// "/auth.AuthService/Login", or a prefix ending in "*" such as "/v1/uploads/*".
+func (r *RouteRegistry) Register(method, pattern string, profile RouteProfile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if profile.Name == "" {
+		profile.Name = strings.ToUpper(method) + " " + pattern
+	}
+
+	entry := routeEntry{
+		method:  strings.ToUpper(method),
+		pattern: strings.TrimSuffix(pattern, "*"),
+		prefix:  strings.HasSuffix(pattern, "*"),
+		profile: profile,
+	}
+	r.routes = append(r.routes, entry)
+
+	// Exact routes first, then longer prefixes, so the most specific route wins
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		if r.routes[i].prefix != r.routes[j].prefix {
+			return !r.routes[i].prefix
+		}
+		return len(r.routes[i].pattern) > len(r.routes[j].pattern)
+	})
+}
+
+// Match returns the profile registered for a method and path
+func (r *RouteRegistry) Match(method, path string) (RouteProfile, bool) {
+	if r == nil {
+		return RouteProfile{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	method = strings.ToUpper(method)
+	for _, route := range r.routes {
+		if route.method != "" && route.method != method {
+			continue
+		}
+		if route.prefix && strings.HasPrefix(path, route.pattern) {
+			return route.profile, true
+		}
+		if !route.prefix && path == route.pattern {
+			return route.profile, true
+		}
+	}
+
+	return RouteProfile{}, false
+}
+
+// DefaultRouteRegistry returns the route profiles used by the template
+func DefaultRouteRegistry() *RouteRegistry {
+	registry := NewRouteRegistry()
+
+	// Credential endpoints: small bodies, strict rate limits against brute force
+	loginProfile := RouteProfile{
+		Name:              "auth_login",
+		MaxRequestSize:    4 * 1024,
+		RateLimitRequests: 10,
+		RateLimitWindow:   time.Minute,
+	}
+	registry.Register("POST", "/v1/auth/login", loginProfile)
+	registry.Register("", "/auth.AuthService/Login", loginProfile)
+
+	registerProfile := RouteProfile{
+		Name:              "auth_register",
+		MaxRequestSize:    4 * 1024,
+		RateLimitRequests: 5,
+		RateLimitWindow:   time.Minute,
+	}
+	registry.Register("POST", "/v1/auth/register", registerProfile)
+	registry.Register("", "/auth.AuthService/Register", registerProfile)
+
+	// Magic links are also limited per email by the auth service
+	magicLinkProfile := RouteProfile{
+		Name:              "auth_magic_link",
+		MaxRequestSize:    4 * 1024,
+		RateLimitRequests: 5,
+		RateLimitWindow:   time.Minute,
+	}
+	registry.Register("POST", "/v1/auth/magic-link", magicLinkProfile)
+	registry.Register("", "/auth.AuthService/RequestMagicLink", magicLinkProfile)
+	registry.Register("POST", "/v1/auth/magic-link/consume", loginProfile)
+	registry.Register("", "/auth.AuthService/ConsumeMagicLink", loginProfile)
+
+	// Password reset links are also limited per email by the auth service
+	passwordResetProfile := RouteProfile{
+		Name:              "auth_password_reset",
+		MaxRequestSize:    4 * 1024,
+		RateLimitRequests: 5,
+		RateLimitWindow:   time.Minute,
+	}
+	registry.Register("POST", "/v1/auth/password/forgot", passwordResetProfile)
+	registry.Register("", "/auth.AuthService/ForgotPassword", passwordResetProfile)
+	registry.Register("POST", "/v1/auth/password/reset", loginProfile)
+	registry.Register("", "/auth.AuthService/ResetPassword", loginProfile)
+
+	// Email verification links are also limited per email by the auth service
+	emailVerificationProfile := RouteProfile{
+		Name:              "auth_email_verification",
+		MaxRequestSize:    4 * 1024,
+		RateLimitRequests: 5,
+		RateLimitWindow:   time.Minute,
+	}
+	registry.Register("POST", "/v1/auth/email/verification", emailVerificationProfile)
+	registry.Register("", "/auth.AuthService/RequestEmailVerification", emailVerificationProfile)
+	registry.Register("POST", "/v1/auth/email/verify", loginProfile)
+	registry.Register("", "/auth.AuthService/VerifyEmail", loginProfile)
+
+	// Uploads: large binary bodies that cannot be pattern checked
+	registry.Register("", "/v1/uploads/*", RouteProfile{
+		Name:             "uploads",
+		MaxRequestSize:   50 * 1024 * 1024,
+		SkipPatternCheck: true,
+	})
+
+	return registry
+}
+
+// routeProfile resolves the effective profile for a route using config defaults
+func (vm *ValidationMiddleware) routeProfile(method, path string) RouteProfile {
+	profile, ok := vm.config.Routes.Match(method, path)
+	if !ok {
+		profile = RouteProfile{Name: DefaultProfileName}
+	}
+
+	if profile.MaxRequestSize <= 0 {
+		profile.MaxRequestSize = vm.config.MaxRequestSize
+	}
+	if profile.RateLimitRequests <= 0 {
+		profile.RateLimitRequests = vm.config.RateLimitRequests
+	}
+	if profile.RateLimitWindow <= 0 {
+		profile.RateLimitWindow = vm.config.RateLimitWindow
+	}
+	if profile.RateLimitSoftRequests <= 0 && vm.config.RateLimitSoftPct > 0 {
+		profile.RateLimitSoftRequests = max(profile.RateLimitRequests*vm.config.RateLimitSoftPct/100, 1)
+	}
+
+	return profile
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"go-clean-ddd-es-template/pkg/logger"
+)
+
+func TestRouteRegistry_Match(t *testing.T) {
+	registry := NewRouteRegistry()
+	registry.Register("", "/v1/uploads/*", RouteProfile{Name: "uploads"})
+	registry.Register("", "/v1/uploads/avatars/*", RouteProfile{Name: "avatars"})
+	registry.Register("POST", "/v1/auth/login", RouteProfile{Name: "login"})
+
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+		found    bool
+	}{
+		{"POST", "/v1/auth/login", "login", true},
+		{"GET", "/v1/auth/login", "", false},
+		{"PUT", "/v1/uploads/file", "uploads", true},
+		{"PUT", "/v1/uploads/avatars/1", "avatars", true},
+		{"GET", "/api/v1/users", "", false},
+	}
+
+	for _, tt := range tests {
+		profile, found := registry.Match(tt.method, tt.path)
+		if found != tt.found || profile.Name != tt.expected {
+			t.Errorf("Match(%s, %s) = %q, %v; expected %q, %v", tt.method, tt.path, profile.Name, found, tt.expected, tt.found)
+		}
+	}
+}
+
+func TestValidationMiddleware_RouteProfiles(t *testing.T) {
+	testLogger, _ := logger.NewLoggerFromConfig("info", "text")
+
+	config := DefaultValidationConfig()
+	config.Routes = NewRouteRegistry()
+	config.Routes.Register("POST", "/v1/auth/login", RouteProfile{
+		Name:              "login",
+		MaxRequestSize:    16,
+		RateLimitRequests: 1,
+		RateLimitWindow:   time.Minute,
+	})
+	vm := NewValidationMiddleware(config, testLogger)
+
+	handler := vm.ValidateRequest()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(path, body string) int {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send("/v1/auth/login", strings.Repeat("a", 32)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected oversized login body to be rejected, got %d", code)
+	}
+	if code := send("/v1/auth/login", "{}"); code != http.StatusOK {
+		t.Errorf("expected first login to succeed, got %d", code)
+	}
+	if code := send("/v1/auth/login", "{}"); code != http.StatusTooManyRequests {
+		t.Errorf("expected second login to be rate limited, got %d", code)
+	}
+
+	// Other routes keep the global limits
+	if code := send("/api/v1/users", strings.Repeat("a", 32)); code != http.StatusOK {
+		t.Errorf("expected default profile to allow request, got %d", code)
+	}
+}
+
+func TestGRPCRateLimitInterceptor_RouteProfiles(t *testing.T) {
+	testLogger, _ := logger.NewLoggerFromConfig("info", "text")
+
+	config := DefaultValidationConfig()
+	config.Routes = NewRouteRegistry()
+	config.Routes.Register("", "/auth.AuthService/Login", RouteProfile{Name: "login", RateLimitRequests: 1})
+	vm := NewValidationMiddleware(config, testLogger)
+
+	interceptor := GRPCRateLimitInterceptor(vm)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.New(map[string]string{
+		"x-forwarded-for": "192.168.1.3",
+	}))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "success", nil
+	}
+
+	login := &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/Login"}
+	if _, err := interceptor(ctx, "req", login, handler); err != nil {
+		t.Fatalf("first login should succeed, got %v", err)
+	}
+
+	_, err := interceptor(ctx, "req", login, handler)
+	if st, ok := status.FromError(err); !ok || st.Code() != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted, got %v", err)
+	}
+
+	// Other methods use the default profile
+	if _, err := interceptor(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/user.UserService/GetUser"}, handler); err != nil {
+		t.Errorf("expected other method to succeed, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"go-clean-ddd-es-template/pkg/id"
 	"go-clean-ddd-es-template/pkg/tracing"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -80,17 +81,7 @@ func (rw *tracingResponseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
-// generateRequestID generates a simple request ID
+// generateRequestID generates a unique, time ordered request ID
 func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
-}
-
-// randomString generates a random string of given length
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
+	return id.NewULID()
 }
@@ -3,22 +3,27 @@ package middleware
 import (
 	"bytes"
 	"io"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"go-clean-ddd-es-template/pkg/errors"
 	"go-clean-ddd-es-template/pkg/logger"
+	"go-clean-ddd-es-template/pkg/security"
 )
 
 // ValidationConfig holds validation configuration
 type ValidationConfig struct {
-	MaxRequestSize    int64         // Maximum request body size in bytes
-	MaxHeaderSize     int64         // Maximum header size in bytes
-	RateLimitRequests int           // Number of requests per window
-	RateLimitWindow   time.Duration // Time window for rate limiting
-	AllowedMethods    []string      // Allowed HTTP methods
-	BlockedPatterns   []string      // Patterns to block in requests
+	MaxRequestSize    int64          // Maximum request body size in bytes
+	MaxHeaderSize     int64          // Maximum header size in bytes
+	RateLimitRequests int            // Number of requests per window
+	RateLimitWindow   time.Duration  // Time window for rate limiting
+	RateLimitSoftPct  int            // Share of the rate limit in percent after which responses warn of the limit, 0 to never warn
+	AllowedMethods    []string       // Allowed HTTP methods
+	BlockedPatterns   []string       // Patterns to block in requests
+	Routes            *RouteRegistry // Per-route profiles overriding the limits above
 }
 
 // DefaultValidationConfig returns default validation configuration
@@ -28,14 +33,9 @@ func DefaultValidationConfig() *ValidationConfig {
 		MaxHeaderSize:     1 * 1024 * 1024,  // 1MB
 		RateLimitRequests: 100,
 		RateLimitWindow:   time.Minute,
+		RateLimitSoftPct:  80,
 		AllowedMethods:    []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		BlockedPatterns: []string{
-			"<script", "javascript:", "vbscript:", "onload=", "onerror=",
-			"<iframe", "<object", "<embed", "data:text/html",
-			"../../", "..\\", "file://", "ftp://", "gopher://",
-			"SELECT", "INSERT", "UPDATE", "DELETE", "DROP", "CREATE",
-			"UNION", "OR", "AND", "WHERE", "FROM", "JOIN",
-		},
+		BlockedPatterns:   security.DefaultBlockedPatterns(),
 	}
 }
 
@@ -44,8 +44,16 @@ type ValidationMiddleware struct {
 	config *ValidationConfig
 	logger logger.Logger
 	// Rate limiting storage (in production, use Redis or similar)
-	requestCounts map[string]int
-	lastReset     time.Time
+	mu          sync.Mutex
+	rateWindows map[string]*rateWindow
+	// Proxies, besides loopback peers, whose forwarded address gives the client of a request
+	trustedProxies []*net.IPNet
+}
+
+// rateWindow counts requests of a client for a route profile
+type rateWindow struct {
+	count int
+	start time.Time
 }
 
 // NewValidationMiddleware creates a new validation middleware
@@ -54,17 +62,24 @@ func NewValidationMiddleware(config *ValidationConfig, logger logger.Logger) *Va
 		config = DefaultValidationConfig()
 	}
 	return &ValidationMiddleware{
-		config:        config,
-		logger:        logger,
-		requestCounts: make(map[string]int),
-		lastReset:     time.Now(),
+		config:      config,
+		logger:      logger,
+		rateWindows: make(map[string]*rateWindow),
 	}
 }
 
+// SetTrustedProxies sets the proxies, besides loopback peers such as the HTTP gateway, whose
+// forwarded address gives the client rate limits are counted for
+func (vm *ValidationMiddleware) SetTrustedProxies(proxies []*net.IPNet) {
+	vm.trustedProxies = proxies
+}
+
 // ValidateRequest validates incoming HTTP requests
 func (vm *ValidationMiddleware) ValidateRequest() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			profile := vm.routeProfile(r.Method, r.URL.Path)
+
 			// Check HTTP method
 			if !vm.isMethodAllowed(r.Method) {
 				vm.logger.Warn("Blocked request with disallowed method: %s", r.Method)
@@ -73,7 +88,7 @@ func (vm *ValidationMiddleware) ValidateRequest() func(http.Handler) http.Handle
 			}
 
 			// Check request size
-			if r.ContentLength > vm.config.MaxRequestSize {
+			if r.ContentLength > profile.MaxRequestSize {
 				vm.logger.Warn("Request too large: %d bytes", r.ContentLength)
 				http.Error(w, "Request too large", http.StatusRequestEntityTooLarge)
 				return
@@ -86,15 +101,17 @@ func (vm *ValidationMiddleware) ValidateRequest() func(http.Handler) http.Handle
 				return
 			}
 
-			// Rate limiting
-			if err := vm.checkRateLimit(r); err != nil {
+			// Rate limiting, warning clients past the soft limit before rejecting them
+			rateLimit, err := vm.checkRateLimitFor(vm.getClientIP(r), profile)
+			rateLimit.setHeaders(w.Header())
+			if err != nil {
 				vm.logger.Warn("Rate limit exceeded: %v", err)
 				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 				return
 			}
 
 			// Validate request body
-			if err := vm.validateRequestBody(r); err != nil {
+			if err := vm.validateRequestBody(r, profile); err != nil {
 				vm.logger.Warn("Invalid request body: %v", err)
 				http.Error(w, "Invalid request body", http.StatusBadRequest)
 				return
@@ -146,44 +163,62 @@ func (vm *ValidationMiddleware) validateHeaders(r *http.Request) error {
 	return nil
 }
 
-// checkRateLimit implements basic rate limiting
-func (vm *ValidationMiddleware) checkRateLimit(r *http.Request) error {
-	// Reset counter if window has passed
-	if time.Since(vm.lastReset) > vm.config.RateLimitWindow {
-		vm.requestCounts = make(map[string]int)
-		vm.lastReset = time.Now()
+// checkRateLimitFor applies the rate limit of a route profile to a client, returning the status
+// of the client's window whether or not the request is allowed
+func (vm *ValidationMiddleware) checkRateLimitFor(clientIP string, profile RouteProfile) (RateLimitStatus, error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	// Each profile has its own window per client
+	key := profile.Name + "|" + clientIP
+	window, ok := vm.rateWindows[key]
+	if !ok || time.Since(window.start) > profile.RateLimitWindow {
+		window = &rateWindow{start: time.Now()}
+		vm.rateWindows[key] = window
 	}
 
-	// Get client identifier (IP address)
-	clientIP := vm.getClientIP(r)
+	status := RateLimitStatus{
+		Limit: profile.RateLimitRequests,
+		Reset: max(profile.RateLimitWindow-time.Since(window.start), 0),
+	}
 
 	// Check rate limit
-	if vm.requestCounts[clientIP] >= vm.config.RateLimitRequests {
-		return errors.New(errors.ErrBadRequest, "Rate limit exceeded")
+	if window.count >= profile.RateLimitRequests {
+		status.Warning = true
+		return status, errors.New(errors.ErrBadRequest, "Rate limit exceeded")
 	}
 
 	// Increment counter
-	vm.requestCounts[clientIP]++
+	window.count++
 
-	return nil
+	status.Remaining = profile.RateLimitRequests - window.count
+	status.Warning = profile.RateLimitSoftRequests > 0 && window.count >= profile.RateLimitSoftRequests
+	return status, nil
 }
 
 // validateRequestBody validates request body
-func (vm *ValidationMiddleware) validateRequestBody(r *http.Request) error {
+func (vm *ValidationMiddleware) validateRequestBody(r *http.Request, profile RouteProfile) error {
 	// Only validate for methods that typically have bodies
 	if r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS" {
 		return nil
 	}
 
-	// Read body for validation
-	body, err := io.ReadAll(r.Body)
+	// Read body for validation, bounded in case Content-Length was not set
+	body, err := io.ReadAll(io.LimitReader(r.Body, profile.MaxRequestSize+1))
 	if err != nil {
 		return errors.Wrap(err, errors.ErrBadRequest, "Failed to read request body")
 	}
+	if int64(len(body)) > profile.MaxRequestSize {
+		return errors.New(errors.ErrBadRequest, "Request too large")
+	}
 
 	// Restore body for next handlers
 	r.Body = io.NopCloser(bytes.NewBuffer(body))
 
+	if profile.SkipPatternCheck {
+		return nil
+	}
+
 	// Check for blocked patterns
 	if vm.containsBlockedPatterns(string(body)) {
 		return errors.New(errors.ErrBadRequest, "Request contains blocked patterns")
@@ -199,75 +234,19 @@ func (vm *ValidationMiddleware) validateRequestBody(r *http.Request) error {
 
 // containsBlockedPatterns checks if content contains blocked patterns
 func (vm *ValidationMiddleware) containsBlockedPatterns(content string) bool {
-	contentLower := strings.ToLower(content)
-	for _, pattern := range vm.config.BlockedPatterns {
-		patternLower := strings.ToLower(pattern)
-
-		// For SQL keywords, check for word boundaries
-		if isSQLKeyword(pattern) {
-			if hasWordBoundary(contentLower, patternLower) {
-				return true
-			}
-		} else {
-			// For other patterns, use simple contains
-			if strings.Contains(contentLower, patternLower) {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-// isSQLKeyword checks if a pattern is a SQL keyword
-func isSQLKeyword(pattern string) bool {
-	sqlKeywords := []string{"SELECT", "INSERT", "UPDATE", "DELETE", "DROP", "CREATE", "UNION", "OR", "AND", "WHERE", "FROM", "JOIN"}
-	patternUpper := strings.ToUpper(pattern)
-	for _, keyword := range sqlKeywords {
-		if patternUpper == keyword {
-			return true
-		}
-	}
-	return false
+	return security.ContainsBlockedPattern(content, vm.config.BlockedPatterns)
 }
 
-// hasWordBoundary checks if a word appears with proper boundaries
-func hasWordBoundary(content, word string) bool {
-	// Split content into words
-	words := strings.Fields(content)
-	for _, w := range words {
-		// Remove common punctuation
-		w = strings.Trim(w, ".,;:!?\"'()[]{}")
-		if strings.EqualFold(w, word) {
-			return true
-		}
-	}
-	return false
-}
-
-// getClientIP extracts the real client IP address
+// getClientIP extracts the client IP address, trusting X-Forwarded-For, or X-Real-IP when not
+// forwarded, only from loopback peers and the trusted proxies, see ClientIP
 func (vm *ValidationMiddleware) getClientIP(r *http.Request) string {
-	// Check for forwarded headers
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		if commaIndex := strings.Index(ip, ","); commaIndex != -1 {
-			return strings.TrimSpace(ip[:commaIndex])
-		}
-		return strings.TrimSpace(ip)
-	}
-
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return strings.TrimSpace(ip)
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 {
+		forwarded = r.Header.Values("X-Real-IP")
 	}
-
-	// Fallback to remote address
-	if r.RemoteAddr != "" {
-		// Remove port if present
-		if colonIndex := strings.LastIndex(r.RemoteAddr, ":"); colonIndex != -1 {
-			return r.RemoteAddr[:colonIndex]
-		}
-		return r.RemoteAddr
+	if ip := ClientIP(r.RemoteAddr, forwarded, vm.trustedProxies); ip != "" {
+		return ip
 	}
-
 	return "unknown"
 }
 
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -210,6 +211,8 @@ func TestValidationMiddleware_GetClientIP(t *testing.T) {
 
 	// Create validation middleware
 	vm := NewValidationMiddleware(DefaultValidationConfig(), testLogger)
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	vm.SetTrustedProxies([]*net.IPNet{proxies})
 
 	tests := []struct {
 		headers    map[string]string
@@ -218,19 +221,28 @@ func TestValidationMiddleware_GetClientIP(t *testing.T) {
 		name       string
 	}{
 		{
-			headers:  map[string]string{"X-Forwarded-For": "192.168.1.1"},
-			expected: "192.168.1.1",
-			name:     "X-Forwarded-For header",
+			headers:    map[string]string{"X-Forwarded-For": "192.168.1.1"},
+			remoteAddr: "127.0.0.1:12345",
+			expected:   "192.168.1.1",
+			name:       "X-Forwarded-For header from a loopback peer",
 		},
 		{
-			headers:  map[string]string{"X-Real-IP": "192.168.1.2"},
-			expected: "192.168.1.2",
-			name:     "X-Real-IP header",
+			headers:    map[string]string{"X-Real-IP": "192.168.1.2"},
+			remoteAddr: "127.0.0.1:12345",
+			expected:   "192.168.1.2",
+			name:       "X-Real-IP header from a loopback peer",
 		},
 		{
-			headers:  map[string]string{"X-Forwarded-For": "192.168.1.1, 10.0.0.1"},
-			expected: "192.168.1.1",
-			name:     "X-Forwarded-For with multiple IPs",
+			headers:    map[string]string{"X-Forwarded-For": "192.168.1.1, 10.0.0.1"},
+			remoteAddr: "10.0.0.2:12345",
+			expected:   "192.168.1.1",
+			name:       "X-Forwarded-For with multiple IPs from a trusted proxy",
+		},
+		{
+			headers:    map[string]string{"X-Forwarded-For": "192.168.1.1", "X-Real-IP": "192.168.1.2"},
+			remoteAddr: "192.0.2.10:12345",
+			expected:   "192.0.2.10",
+			name:       "forwarded headers from an untrusted peer",
 		},
 		{
 			remoteAddr: "192.168.1.3:12345",
@@ -0,0 +1,135 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ChecksumTable records the checksums of applied migration files
+const ChecksumTable = "schema_migration_checksums"
+
+// ChecksumStore records the checksums of applied migrations
+type ChecksumStore interface {
+	// Checksums returns the recorded checksums by version
+	Checksums(ctx context.Context) (map[uint]string, error)
+
+	// RecordChecksum records the checksum of an applied migration
+	RecordChecksum(ctx context.Context, version uint, name, checksum string) error
+}
+
+// ChecksumMismatch is an applied migration file edited since it was applied
+type ChecksumMismatch struct {
+	File     string
+	Recorded string
+	Actual   string
+}
+
+// String describes the mismatch
+func (m ChecksumMismatch) String() string {
+	return fmt.Sprintf("%s: changed since it was applied (checksum %s, recorded %s)", m.File, m.Actual, m.Recorded)
+}
+
+// FileChecksum returns the SHA-256 checksum of a migration file
+func FileChecksum(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration %s: %w", path, err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// VerifyChecksums compares the up migrations of a directory applied up to version with their
+// recorded checksums. Applied migrations without a checksum, e.g. applied before checksums were
+// recorded, have their current checksum recorded.
+func VerifyChecksums(ctx context.Context, store ChecksumStore, dir string, version uint) ([]ChecksumMismatch, error) {
+	files, err := ListMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := store.Checksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []ChecksumMismatch
+	for _, file := range files {
+		if file.Version > version {
+			break
+		}
+		checksum, err := FileChecksum(file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		previous, ok := recorded[file.Version]
+		switch {
+		case !ok:
+			if err := store.RecordChecksum(ctx, file.Version, file.Name, checksum); err != nil {
+				return nil, err
+			}
+		case previous != checksum:
+			mismatches = append(mismatches, ChecksumMismatch{File: file.Name, Recorded: previous, Actual: checksum})
+		}
+	}
+	return mismatches, nil
+}
+
+// PostgresChecksumStore records checksums in the ChecksumTable of a PostgreSQL database
+type PostgresChecksumStore struct {
+	db *sql.DB
+}
+
+// NewPostgresChecksumStore creates a checksum store
+func NewPostgresChecksumStore(db *sql.DB) *PostgresChecksumStore {
+	return &PostgresChecksumStore{db: db}
+}
+
+// Initialize creates the checksum table if it doesn't exist
+func (s *PostgresChecksumStore) Initialize(ctx context.Context) error {
+	query := `CREATE TABLE IF NOT EXISTS ` + ChecksumTable + ` (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		recorded_at TIMESTAMP NOT NULL
+	)`
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create migration checksum table: %w", err)
+	}
+	return nil
+}
+
+// Checksums returns the recorded checksums by version
+func (s *PostgresChecksumStore) Checksums(ctx context.Context) (map[uint]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT version, checksum FROM `+ChecksumTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration checksums: %w", err)
+	}
+	defer rows.Close()
+
+	checksums := make(map[uint]string)
+	for rows.Next() {
+		var version int64
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to read migration checksum: %w", err)
+		}
+		checksums[uint(version)] = checksum
+	}
+	return checksums, rows.Err()
+}
+
+// RecordChecksum records the checksum of an applied migration
+func (s *PostgresChecksumStore) RecordChecksum(ctx context.Context, version uint, name, checksum string) error {
+	query := `INSERT INTO ` + ChecksumTable + ` (version, name, checksum, recorded_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (version) DO UPDATE SET name = EXCLUDED.name, checksum = EXCLUDED.checksum, recorded_at = EXCLUDED.recorded_at`
+	if _, err := s.db.ExecContext(ctx, query, int64(version), name, checksum, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record migration checksum: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package migrations_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryChecksumStore map[uint]string
+
+func (s memoryChecksumStore) Checksums(ctx context.Context) (map[uint]string, error) {
+	checksums := make(map[uint]string, len(s))
+	for version, checksum := range s {
+		checksums[version] = checksum
+	}
+	return checksums, nil
+}
+
+func (s memoryChecksumStore) RecordChecksum(ctx context.Context, version uint, name, checksum string) error {
+	s[version] = checksum
+	return nil
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("000001_create_users.up.sql", createUsers)
+	write("000002_add_index.up.sql", "CREATE INDEX idx_users_email ON users(email);")
+	write("000003_add_column.up.sql", "ALTER TABLE users ADD COLUMN nickname TEXT;")
+	store := memoryChecksumStore{}
+
+	// Checksums of applied migrations are recorded on first verification
+	mismatches, err := migrations.VerifyChecksums(ctx, store, dir, 2)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+	assert.Len(t, store, 2)
+
+	// Editing an applied migration is detected, editing a pending one is not
+	write("000002_add_index.up.sql", "CREATE INDEX idx_users_name ON users(name);")
+	write("000003_add_column.up.sql", "ALTER TABLE users ADD COLUMN nickname VARCHAR(64);")
+	mismatches, err = migrations.VerifyChecksums(ctx, store, dir, 2)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, "000002_add_index.up.sql", mismatches[0].File)
+	assert.NotEqual(t, mismatches[0].Recorded, mismatches[0].Actual)
+}
@@ -3,9 +3,12 @@ package migrations
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/golang-migrate/migrate/v4"
 )
 
 // MigrationInterface defines the interface for database migrations
@@ -37,9 +40,19 @@ type MigrationManager struct {
 	WriteDBMigrator MigrationInterface
 	EventDBMigrator MigrationInterface
 	ReadDBMigrator  MigrationInterface
+
+	writeMigrationsPath string
+	eventMigrationsPath string
+	writeChecksums      *PostgresChecksumStore
+	eventChecksums      *PostgresChecksumStore
 }
 
-// NewMigrationManager creates a new migration manager
+// EventMigrationsTable records the applied event migrations of a write database that is also the
+// event database, apart from its write migrations
+const EventMigrationsTable = "event_schema_migrations"
+
+// NewMigrationManager creates a new migration manager. Passing the same connection as writeDB
+// and eventDB runs both sets of migrations on a database that is the write and event database.
 func NewMigrationManager(
 	writeDB *sql.DB,
 	eventDB *sql.DB,
@@ -47,12 +60,16 @@ func NewMigrationManager(
 	eventMigrationsPath string,
 ) (*MigrationManager, error) {
 	// Create PostgreSQL migrators for write and event databases
-	writeMigrator, err := NewPostgresMigrator(writeDB, writeMigrationsPath)
+	writeMigrator, err := NewPostgresMigrator(writeDB, writeMigrationsPath, "")
 	if err != nil {
 		return nil, err
 	}
 
-	eventMigrator, err := NewPostgresMigrator(eventDB, eventMigrationsPath)
+	eventMigrationsTable := ""
+	if eventDB == writeDB {
+		eventMigrationsTable = EventMigrationsTable
+	}
+	eventMigrator, err := NewPostgresMigrator(eventDB, eventMigrationsPath, eventMigrationsTable)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +78,11 @@ func NewMigrationManager(
 		WriteDBMigrator: writeMigrator,
 		EventDBMigrator: eventMigrator,
 		ReadDBMigrator:  nil, // MongoDB doesn't need SQL migrations
+
+		writeMigrationsPath: writeMigrationsPath,
+		eventMigrationsPath: eventMigrationsPath,
+		writeChecksums:      NewPostgresChecksumStore(writeDB),
+		eventChecksums:      NewPostgresChecksumStore(eventDB),
 	}, nil
 }
 
@@ -76,9 +98,76 @@ func (m *MigrationManager) Initialize(ctx context.Context) error {
 		return err
 	}
 
+	// Initialize checksums of applied migrations
+	if err := m.writeChecksums.Initialize(ctx); err != nil {
+		return err
+	}
+	if err := m.eventChecksums.Initialize(ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// Preflight lints the pending migrations of the write and event databases for destructive statements
+func (m *MigrationManager) Preflight(ctx context.Context) ([]Finding, error) {
+	var findings []Finding
+	for _, db := range m.databases() {
+		version, err := appliedVersion(ctx, db.migrator)
+		if err != nil {
+			return nil, err
+		}
+		dbFindings, err := LintDir(db.path, version)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, dbFindings...)
+	}
+	return findings, nil
+}
+
+// VerifyChecksums compares the applied migrations of the write and event databases with their
+// recorded checksums, recording the checksums of migrations applied since the last verification
+func (m *MigrationManager) VerifyChecksums(ctx context.Context) ([]ChecksumMismatch, error) {
+	var mismatches []ChecksumMismatch
+	for _, db := range m.databases() {
+		version, err := appliedVersion(ctx, db.migrator)
+		if err != nil {
+			return nil, err
+		}
+		dbMismatches, err := VerifyChecksums(ctx, db.checksums, db.path, version)
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, dbMismatches...)
+	}
+	return mismatches, nil
+}
+
+// managedDatabase is a database whose migrations the manager runs
+type managedDatabase struct {
+	migrator  MigrationInterface
+	path      string
+	checksums ChecksumStore
+}
+
+// databases returns the write and event databases
+func (m *MigrationManager) databases() []managedDatabase {
+	return []managedDatabase{
+		{migrator: m.WriteDBMigrator, path: m.writeMigrationsPath, checksums: m.writeChecksums},
+		{migrator: m.EventDBMigrator, path: m.eventMigrationsPath, checksums: m.eventChecksums},
+	}
+}
+
+// appliedVersion returns the version a database is migrated to, 0 when no migration was applied
+func appliedVersion(ctx context.Context, migrator MigrationInterface) (uint, error) {
+	version, _, err := migrator.Version(ctx)
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, nil
+	}
+	return version, err
+}
+
 // RunWriteDBMigrations runs migrations for write database
 func (m *MigrationManager) RunWriteDBMigrations(ctx context.Context) error {
 	return m.WriteDBMigrator.Up(ctx)
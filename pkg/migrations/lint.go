@@ -0,0 +1,459 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lint rules of destructive statements
+const (
+	RuleDropTable     = "drop_table"     // DROP TABLE loses the table's rows
+	RuleDropColumn    = "drop_column"    // ALTER TABLE ... DROP COLUMN loses the column's values
+	RuleDropSchema    = "drop_schema"    // DROP SCHEMA or DROP DATABASE loses everything it holds
+	RuleTruncate      = "truncate"       // TRUNCATE deletes every row
+	RuleDeleteAll     = "delete_all"     // DELETE without WHERE deletes every row
+	RuleTypeNarrowing = "type_narrowing" // ALTER COLUMN ... TYPE to a type not holding every previous value
+	RuleUnknownType   = "unknown_type"   // ALTER COLUMN ... TYPE of a column whose previous type is unknown
+)
+
+// Finding is a destructive statement found in a migration
+type Finding struct {
+	File      string // Migration file name
+	Line      int    // Line the statement starts on
+	Rule      string // One of the Rule* constants
+	Message   string
+	Statement string
+}
+
+// String describes the finding
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", f.File, f.Line, f.Rule, f.Message)
+}
+
+// MigrationFile is an up migration file
+type MigrationFile struct {
+	Version uint
+	Name    string
+	Path    string
+}
+
+// ListMigrations lists the up migration files of a directory by version
+func ListMigrations(dir string) ([]MigrationFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var files []MigrationFile
+	for _, entry := range entries {
+		match := upMigrationPattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+		}
+		files = append(files, MigrationFile{Version: uint(version), Name: entry.Name(), Path: filepath.Join(dir, entry.Name())})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+	return files, nil
+}
+
+// LintDir lints the up migrations of a directory newer than version, 0 for all of them. Older
+// migrations are only read to learn the column types narrowing is checked against.
+func LintDir(dir string, version uint) ([]Finding, error) {
+	files, err := ListMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer := NewAnalyzer()
+	var findings []Finding
+	for _, file := range files {
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file.Name, err)
+		}
+		fileFindings := analyzer.Analyze(file.Name, string(content))
+		if file.Version > version {
+			findings = append(findings, fileFindings...)
+		}
+	}
+	return findings, nil
+}
+
+// Analyzer detects destructive statements of PostgreSQL migrations. It remembers the column
+// types created by the migrations it analyzed, so migrations must be analyzed in order.
+type Analyzer struct {
+	columns map[string]string // table.column -> normalized type
+}
+
+// NewAnalyzer creates an analyzer knowing no schema
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{columns: make(map[string]string)}
+}
+
+var (
+	upMigrationPattern = regexp.MustCompile(`^(\d+)_(.+)\.up\.sql$`)
+	dollarQuotePattern = regexp.MustCompile(`^\$[A-Za-z_0-9]*\$`)
+	createTablePattern = regexp.MustCompile(`(?is)^create\s+(?:(?:temp|temporary|unlogged)\s+)?table\s+(?:if\s+not\s+exists\s+)?(\S+)\s*\((.*)\)`)
+	alterTablePattern  = regexp.MustCompile(`(?is)^alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?(\S+)\s+(.*)$`)
+	dropTablePattern   = regexp.MustCompile(`(?is)^drop\s+table\s+(?:if\s+exists\s+)?(.+?)(?:\s+(?:cascade|restrict))?$`)
+	dropSchemaPattern  = regexp.MustCompile(`(?is)^drop\s+(schema|database)\s+(?:if\s+exists\s+)?(\S+)`)
+	truncatePattern    = regexp.MustCompile(`(?is)^truncate\s+(?:table\s+)?(?:only\s+)?(\S+)`)
+	deletePattern      = regexp.MustCompile(`(?is)^delete\s+from\s+(?:only\s+)?(\S+)(.*)$`)
+	addColumnPattern   = regexp.MustCompile(`(?is)^add\s+(?:column\s+)?(?:if\s+not\s+exists\s+)?(\S+)\s+(.+)$`)
+	dropColumnPattern  = regexp.MustCompile(`(?is)^drop\s+(?:column\s+)?(?:if\s+exists\s+)?(\S+)`)
+	alterTypePattern   = regexp.MustCompile(`(?is)^alter\s+(?:column\s+)?(\S+)\s+(?:set\s+data\s+)?type\s+(.+?)(?:\s+(?:using|collate)\s+.*)?$`)
+	whereClausePattern = regexp.MustCompile(`(?is)\bwhere\b`)
+)
+
+// Analyze returns the destructive statements of a migration
+func (a *Analyzer) Analyze(file, sql string) []Finding {
+	var findings []Finding
+	for _, stmt := range splitStatements(sql) {
+		finding := func(rule, format string, args ...interface{}) {
+			findings = append(findings, Finding{File: file, Line: stmt.line, Rule: rule, Message: fmt.Sprintf(format, args...), Statement: stmt.text})
+		}
+
+		text := stmt.text
+		switch {
+		case createTablePattern.MatchString(text):
+			match := createTablePattern.FindStringSubmatch(text)
+			table := identifier(match[1])
+			for _, definition := range splitTopLevel(match[2]) {
+				if column, columnType, ok := columnDefinition(definition); ok {
+					a.columns[table+"."+column] = columnType
+				}
+			}
+		case dropTablePattern.MatchString(text):
+			for _, table := range strings.Split(dropTablePattern.FindStringSubmatch(text)[1], ",") {
+				finding(RuleDropTable, "drops table %s and its rows", identifier(table))
+				a.forgetTable(identifier(table))
+			}
+		case dropSchemaPattern.MatchString(text):
+			match := dropSchemaPattern.FindStringSubmatch(text)
+			finding(RuleDropSchema, "drops %s %s", strings.ToLower(match[1]), identifier(match[2]))
+		case truncatePattern.MatchString(text):
+			finding(RuleTruncate, "deletes every row of %s", identifier(truncatePattern.FindStringSubmatch(text)[1]))
+		case deletePattern.MatchString(text):
+			match := deletePattern.FindStringSubmatch(text)
+			if !whereClausePattern.MatchString(match[2]) {
+				finding(RuleDeleteAll, "deletes every row of %s", identifier(match[1]))
+			}
+		case alterTablePattern.MatchString(text):
+			match := alterTablePattern.FindStringSubmatch(text)
+			table := identifier(match[1])
+			for _, action := range splitTopLevel(match[2]) {
+				a.alterTable(table, action, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// alterTable analyzes an action of ALTER TABLE
+func (a *Analyzer) alterTable(table, action string, finding func(rule, format string, args ...interface{})) {
+	lower := strings.ToLower(action)
+	switch {
+	case strings.HasPrefix(lower, "drop constraint"), strings.HasPrefix(lower, "alter column") && !alterTypePattern.MatchString(action):
+		// Constraints and defaults hold no data
+	case addColumnPattern.MatchString(action) && !strings.HasPrefix(lower, "add constraint"):
+		match := addColumnPattern.FindStringSubmatch(action)
+		if column, columnType, ok := columnDefinition(match[1] + " " + match[2]); ok {
+			a.columns[table+"."+column] = columnType
+		}
+	case dropColumnPattern.MatchString(action):
+		column := identifier(dropColumnPattern.FindStringSubmatch(action)[1])
+		finding(RuleDropColumn, "drops column %s.%s and its values", table, column)
+		delete(a.columns, table+"."+column)
+	case alterTypePattern.MatchString(action):
+		match := alterTypePattern.FindStringSubmatch(action)
+		column := identifier(match[1])
+		newType := normalizeType(match[2])
+		key := table + "." + column
+		previous, known := a.columns[key]
+		a.columns[key] = newType
+		switch {
+		case !known:
+			finding(RuleUnknownType, "changes column %s.%s to %s, its previous type is unknown", table, column, newType)
+		case narrows(previous, newType):
+			finding(RuleTypeNarrowing, "narrows column %s.%s from %s to %s", table, column, previous, newType)
+		}
+	}
+}
+
+// forgetTable forgets the columns of a dropped table
+func (a *Analyzer) forgetTable(table string) {
+	for key := range a.columns {
+		if strings.HasPrefix(key, table+".") {
+			delete(a.columns, key)
+		}
+	}
+}
+
+// tableConstraintKeywords start table constraints rather than column definitions
+var tableConstraintKeywords = map[string]bool{
+	"constraint": true, "primary": true, "unique": true, "foreign": true, "check": true, "exclude": true, "like": true,
+}
+
+// columnConstraintKeywords end the type of a column definition
+var columnConstraintKeywords = map[string]bool{
+	"not": true, "null": true, "default": true, "primary": true, "unique": true, "references": true,
+	"check": true, "constraint": true, "generated": true, "collate": true,
+}
+
+// columnDefinition returns the column and normalized type of a column definition
+func columnDefinition(definition string) (string, string, bool) {
+	fields := strings.Fields(definition)
+	if len(fields) < 2 || tableConstraintKeywords[strings.ToLower(fields[0])] {
+		return "", "", false
+	}
+
+	var typeFields []string
+	for _, field := range fields[1:] {
+		if columnConstraintKeywords[strings.ToLower(field)] {
+			break
+		}
+		typeFields = append(typeFields, field)
+	}
+	if len(typeFields) == 0 {
+		return "", "", false
+	}
+	return identifier(fields[0]), normalizeType(strings.Join(typeFields, " ")), true
+}
+
+// typeAliases map PostgreSQL type names to a canonical name
+var typeAliases = map[string]string{
+	"int": "integer", "int4": "integer", "serial": "integer", "serial4": "integer",
+	"int8": "bigint", "bigserial": "bigint", "serial8": "bigint",
+	"int2": "smallint", "smallserial": "smallint", "serial2": "smallint",
+	"character varying": "varchar", "character": "char", "bpchar": "char",
+	"decimal": "numeric", "float8": "double precision", "float": "double precision", "float4": "real",
+	"bool": "boolean", "timestamp without time zone": "timestamp", "timestamp with time zone": "timestamptz",
+}
+
+var typePattern = regexp.MustCompile(`^([a-z ]+?)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?(\[\])?$`)
+
+// normalizeType lowercases a type and resolves aliases, e.g. "CHARACTER VARYING(64)" to "varchar(64)"
+func normalizeType(columnType string) string {
+	columnType = strings.Join(strings.Fields(strings.ToLower(strings.TrimSpace(columnType))), " ")
+	match := typePattern.FindStringSubmatch(columnType)
+	if match == nil {
+		return columnType
+	}
+	name := match[1]
+	if alias, ok := typeAliases[name]; ok {
+		name = alias
+	}
+	if match[2] != "" {
+		name += "(" + match[2]
+		if match[3] != "" {
+			name += "," + match[3]
+		}
+		name += ")"
+	}
+	return name + match[4]
+}
+
+// typeRanks order types of a family by the values they hold
+var typeRanks = map[string]struct {
+	family string
+	rank   int
+}{
+	"smallint": {"integer", 2}, "integer": {"integer", 4}, "bigint": {"integer", 8},
+	"real": {"float", 4}, "double precision": {"float", 8},
+	"char": {"text", 1}, "varchar": {"text", 2}, "text": {"text", 3},
+	"date": {"time", 1}, "timestamp": {"time", 2}, "timestamptz": {"time", 3},
+}
+
+// narrows reports whether changing a column from one normalized type to another may lose values.
+// Changes between type families are considered narrowing, except to text.
+func narrows(from, to string) bool {
+	if from == to {
+		return false
+	}
+	fromName, fromArgs := splitTypeArgs(from)
+	toName, toArgs := splitTypeArgs(to)
+	if toName == "text" {
+		return false
+	}
+
+	if fromName == "numeric" || toName == "numeric" {
+		switch {
+		case fromName == "numeric" && toName == "numeric":
+			return narrowerArgs(fromArgs, toArgs)
+		case toName == "numeric":
+			// Integers fit numeric unless its precision is limited
+			rank, known := typeRanks[fromName]
+			return !known || rank.family != "integer" || len(toArgs) > 0
+		default:
+			return true
+		}
+	}
+
+	fromRank, fromKnown := typeRanks[fromName]
+	toRank, toKnown := typeRanks[toName]
+	switch {
+	case !fromKnown || !toKnown:
+		return fromName != toName || narrowerArgs(fromArgs, toArgs)
+	case fromRank.family == "integer" && toRank.family == "float":
+		// Floats lose precision of large integers
+		return true
+	case fromRank.family != toRank.family:
+		return true
+	case toRank.rank < fromRank.rank:
+		return true
+	case toRank.rank > fromRank.rank:
+		// A wider type is narrowing only if its length is limited below the previous length
+		return len(toArgs) > 0 && (len(fromArgs) == 0 || toArgs[0] < fromArgs[0])
+	default:
+		return narrowerArgs(fromArgs, toArgs)
+	}
+}
+
+// splitTypeArgs splits a normalized type into its name and numeric arguments, e.g. varchar and [64]
+func splitTypeArgs(columnType string) (string, []int) {
+	open := strings.Index(columnType, "(")
+	if open < 0 || !strings.HasSuffix(columnType, ")") {
+		return columnType, nil
+	}
+	var args []int
+	for _, arg := range strings.Split(columnType[open+1:len(columnType)-1], ",") {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			return columnType, nil
+		}
+		args = append(args, value)
+	}
+	return columnType[:open], args
+}
+
+// narrowerArgs reports whether type arguments limit values more than previous arguments did.
+// No arguments means no limit.
+func narrowerArgs(from, to []int) bool {
+	if len(to) == 0 {
+		return false
+	}
+	if len(from) == 0 {
+		return true
+	}
+	for i := range to {
+		if i < len(from) && to[i] < from[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// identifier unquotes and lowercases an SQL identifier, keeping its schema
+func identifier(name string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(name), `"`))
+}
+
+// statement is an SQL statement without comments
+type statement struct {
+	text string
+	line int // Line the statement starts on
+}
+
+// splitStatements splits SQL into statements, dropping comments. Semicolons in quoted strings,
+// quoted identifiers and dollar quoted bodies do not end statements.
+func splitStatements(sql string) []statement {
+	var statements []statement
+	var current strings.Builder
+	line, start := 1, 0
+
+	flush := func() {
+		if text := strings.TrimSpace(current.String()); text != "" {
+			statements = append(statements, statement{text: text, line: start})
+		}
+		current.Reset()
+		start = 0
+	}
+	write := func(s string) {
+		if start == 0 && strings.TrimSpace(s) != "" {
+			start = line
+		}
+		current.WriteString(s)
+		line += strings.Count(s, "\n")
+	}
+
+	for i := 0; i < len(sql); {
+		rest := sql[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest) - 4
+			}
+			comment := rest[:end+4]
+			line += strings.Count(comment, "\n")
+			current.WriteByte(' ')
+			i += len(comment)
+		case rest[0] == '\'' || rest[0] == '"':
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				end = len(rest) - 2
+			}
+			write(rest[:end+2])
+			i += end + 2
+		case rest[0] == '$':
+			tag := dollarQuotePattern.FindString(rest)
+			if tag == "" {
+				write("$")
+				i++
+				break
+			}
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				end = len(rest) - 2*len(tag)
+			}
+			write(rest[:end+2*len(tag)])
+			i += end + 2*len(tag)
+		case rest[0] == ';':
+			flush()
+			i++
+		default:
+			write(rest[:1])
+			i++
+		}
+	}
+	flush()
+	return statements
+}
+
+// splitTopLevel splits a list on the commas outside parentheses
+func splitTopLevel(list string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(list[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
@@ -0,0 +1,123 @@
+package migrations_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const createUsers = `-- Migration: 000001_create_users
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    logins INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION purge() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM users;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+`
+
+func rules(findings []migrations.Finding) []string {
+	var rules []string
+	for _, finding := range findings {
+		rules = append(rules, finding.Rule)
+	}
+	return rules
+}
+
+func TestAnalyzer_CreatesAreNotDestructive(t *testing.T) {
+	analyzer := migrations.NewAnalyzer()
+
+	assert.Empty(t, analyzer.Analyze("000001_create_users.up.sql", createUsers))
+}
+
+func TestAnalyzer_DetectsDestructiveStatements(t *testing.T) {
+	analyzer := migrations.NewAnalyzer()
+	analyzer.Analyze("000001_create_users.up.sql", createUsers)
+
+	findings := analyzer.Analyze("000002_cleanup.up.sql", `
+-- DROP TABLE commented_out;
+DROP TABLE IF EXISTS sessions, tokens CASCADE;
+ALTER TABLE users DROP COLUMN logins, ADD COLUMN nickname VARCHAR(64);
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+TRUNCATE TABLE audit;
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+DELETE FROM outbox;
+DROP SCHEMA legacy;
+UPDATE users SET name = 'a;b';
+`)
+
+	assert.Equal(t, []string{
+		migrations.RuleDropTable, migrations.RuleDropTable, migrations.RuleDropColumn,
+		migrations.RuleTruncate, migrations.RuleDeleteAll, migrations.RuleDropSchema,
+	}, rules(findings))
+	assert.Equal(t, 3, findings[0].Line)
+	assert.Equal(t, "drops column users.logins and its values", findings[2].Message)
+}
+
+func TestAnalyzer_DetectsTypeNarrowing(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		rule      string
+	}{
+		{"shorter varchar", "ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(100)", migrations.RuleTypeNarrowing},
+		{"longer varchar", "ALTER TABLE users ALTER COLUMN email TYPE character varying(512)", ""},
+		{"varchar to text", "ALTER TABLE users ALTER COLUMN email TYPE TEXT", ""},
+		{"text to varchar", "ALTER TABLE users ALTER COLUMN name TYPE VARCHAR(255)", migrations.RuleTypeNarrowing},
+		{"integer to bigint", "ALTER TABLE users ALTER COLUMN logins TYPE BIGINT", ""},
+		{"integer to smallint", "ALTER TABLE users ALTER COLUMN logins TYPE int2", migrations.RuleTypeNarrowing},
+		{"integer to numeric", "ALTER TABLE users ALTER COLUMN logins SET DATA TYPE NUMERIC", ""},
+		{"timestamp to date", "ALTER TABLE users ALTER created_at TYPE DATE USING created_at::date", migrations.RuleTypeNarrowing},
+		{"uuid to varchar", "ALTER TABLE users ALTER COLUMN id TYPE VARCHAR(36)", migrations.RuleTypeNarrowing},
+		{"unknown column", "ALTER TABLE users ALTER COLUMN missing TYPE TEXT", migrations.RuleUnknownType},
+		{"set default", "ALTER TABLE users ALTER COLUMN logins SET DEFAULT 1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := migrations.NewAnalyzer()
+			analyzer.Analyze("000001_create_users.up.sql", createUsers)
+
+			findings := analyzer.Analyze("000002_alter.up.sql", tt.statement+";")
+
+			if tt.rule == "" {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.rule, findings[0].Rule)
+		})
+	}
+}
+
+func TestLintDir_LintsPendingMigrations(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("000001_create_users.up.sql", createUsers)
+	write("000001_create_users.down.sql", "DROP TABLE users;")
+	write("000002_drop_logins.up.sql", "ALTER TABLE users DROP COLUMN logins;")
+	write("000003_narrow_email.up.sql", "ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(64);")
+
+	findings, err := migrations.LintDir(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{migrations.RuleDropColumn, migrations.RuleTypeNarrowing}, rules(findings))
+
+	findings, err = migrations.LintDir(dir, 2)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "000003_narrow_email.up.sql", findings[0].File)
+}
@@ -15,10 +15,11 @@ type PostgresMigrator struct {
 	migrate *migrate.Migrate
 }
 
-// NewPostgresMigrator creates a new PostgreSQL migrator
-func NewPostgresMigrator(db *sql.DB, migrationsPath string) (*PostgresMigrator, error) {
+// NewPostgresMigrator creates a new PostgreSQL migrator recording the applied version in
+// migrationsTable, schema_migrations when empty
+func NewPostgresMigrator(db *sql.DB, migrationsPath, migrationsTable string) (*PostgresMigrator, error) {
 	// Create postgres driver
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	driver, err := postgres.WithInstance(db, &postgres.Config{MigrationsTable: migrationsTable})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create postgres driver: %w", err)
 	}
@@ -0,0 +1,315 @@
+package mongoindex
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultIndexName is the index MongoDB creates for every collection
+const defaultIndexName = "_id_"
+
+// Definition declares an index that should exist on a collection
+type Definition struct {
+	Name               string // Defaults to the MongoDB generated name, e.g. "email_1"
+	Keys               bson.D
+	Unique             bool
+	Sparse             bool
+	ExpireAfterSeconds *int32
+	PartialFilter      bson.M
+}
+
+// IndexName returns the explicit name or the name MongoDB would generate for the keys
+func (d Definition) IndexName() string {
+	if d.Name != "" {
+		return d.Name
+	}
+
+	parts := make([]string, 0, len(d.Keys)*2)
+	for _, key := range d.Keys {
+		parts = append(parts, key.Key, fmt.Sprintf("%v", key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// Model converts the definition into a driver index model
+func (d Definition) Model() mongo.IndexModel {
+	opts := options.Index().SetName(d.IndexName())
+	if d.Unique {
+		opts.SetUnique(true)
+	}
+	if d.Sparse {
+		opts.SetSparse(true)
+	}
+	if d.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*d.ExpireAfterSeconds)
+	}
+	if d.PartialFilter != nil {
+		opts.SetPartialFilterExpression(d.PartialFilter)
+	}
+
+	return mongo.IndexModel{Keys: d.Keys, Options: opts}
+}
+
+// Registry holds declarative index definitions per collection
+type Registry struct {
+	mu          sync.RWMutex
+	definitions map[string][]Definition
+}
+
+// NewRegistry creates a new index registry
+func NewRegistry() *Registry {
+	return &Registry{
+		definitions: make(map[string][]Definition),
+	}
+}
+
+// Register declares indexes for a collection
+func (r *Registry) Register(collection string, definitions ...Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.definitions[collection] = append(r.definitions[collection], definitions...)
+}
+
+// Collections returns the sorted names of collections with declared indexes
+func (r *Registry) Collections() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	collections := make([]string, 0, len(r.definitions))
+	for collection := range r.definitions {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+	return collections
+}
+
+// Definitions returns the indexes declared for a collection
+func (r *Registry) Definitions(collection string) []Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	definitions := make([]Definition, len(r.definitions[collection]))
+	copy(definitions, r.definitions[collection])
+	return definitions
+}
+
+// IndexManager lists and creates indexes on collections
+type IndexManager interface {
+	ListIndexes(ctx context.Context, collection string) ([]string, error)
+	CreateIndex(ctx context.Context, collection string, definition Definition) error
+}
+
+// MongoIndexManager manages indexes of a MongoDB database
+type MongoIndexManager struct {
+	database *mongo.Database
+}
+
+// NewMongoIndexManager creates a new MongoDB index manager
+func NewMongoIndexManager(database *mongo.Database) *MongoIndexManager {
+	return &MongoIndexManager{database: database}
+}
+
+// ListIndexes returns the names of the indexes that exist on a collection
+func (m *MongoIndexManager) ListIndexes(ctx context.Context, collection string) ([]string, error) {
+	specs, err := m.database.Collection(collection).Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes of %s: %w", collection, err)
+	}
+
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	return names, nil
+}
+
+// CreateIndex builds an index on a collection
+func (m *MongoIndexManager) CreateIndex(ctx context.Context, collection string, definition Definition) error {
+	if _, err := m.database.Collection(collection).Indexes().CreateOne(ctx, definition.Model()); err != nil {
+		return fmt.Errorf("failed to create index %s on %s: %w", definition.IndexName(), collection, err)
+	}
+	return nil
+}
+
+// Logger interface for logging
+type Logger interface {
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+}
+
+// CollectionReport describes the reconciliation result of a collection
+type CollectionReport struct {
+	Collection string   `json:"collection"`
+	Existing   []string `json:"existing"`
+	Missing    []string `json:"missing"`
+	Created    []string `json:"created"`
+	Extra      []string `json:"extra"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// Report describes the reconciliation result of all collections
+type Report struct {
+	DryRun      bool               `json:"dry_run"`
+	Collections []CollectionReport `json:"collections"`
+}
+
+// HasErrors returns true if any index could not be listed or created
+func (r *Report) HasErrors() bool {
+	for _, collection := range r.Collections {
+		if len(collection.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Reconciler makes the indexes of a database match the registry
+type Reconciler struct {
+	registry *Registry
+	manager  IndexManager
+	logger   Logger
+
+	buildDuration *prometheus.HistogramVec
+	extraIndexes  *prometheus.GaugeVec
+	missing       *prometheus.GaugeVec
+}
+
+// NewReconciler creates a new index reconciler.
+// If registerer is nil the default prometheus registerer is used.
+func NewReconciler(registry *Registry, manager IndexManager, logger Logger, registerer prometheus.Registerer) *Reconciler {
+	if logger == nil {
+		logger = &stdLogger{}
+	}
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	factory := promauto.With(registerer)
+
+	return &Reconciler{
+		registry: registry,
+		manager:  manager,
+		logger:   logger,
+		buildDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "mongo_index_build_duration_seconds",
+				Help:    "Time taken to build MongoDB indexes",
+				Buckets: []float64{0.01, 0.1, 0.5, 1, 5, 15, 60, 300},
+			},
+			[]string{"collection", "index"},
+		),
+		extraIndexes: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mongo_index_extra",
+				Help: "Number of indexes present in MongoDB but not declared in the registry",
+			},
+			[]string{"collection"},
+		),
+		missing: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mongo_index_missing",
+				Help: "Number of declared indexes missing from MongoDB",
+			},
+			[]string{"collection"},
+		),
+	}
+}
+
+// Plan compares declared and existing indexes without changing anything
+func (r *Reconciler) Plan(ctx context.Context) *Report {
+	return r.reconcile(ctx, true)
+}
+
+// Sync creates missing indexes and warns about undeclared ones
+func (r *Reconciler) Sync(ctx context.Context) *Report {
+	return r.reconcile(ctx, false)
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, dryRun bool) *Report {
+	report := &Report{DryRun: dryRun}
+
+	for _, collection := range r.registry.Collections() {
+		report.Collections = append(report.Collections, r.reconcileCollection(ctx, collection, dryRun))
+	}
+
+	return report
+}
+
+func (r *Reconciler) reconcileCollection(ctx context.Context, collection string, dryRun bool) CollectionReport {
+	result := CollectionReport{Collection: collection}
+
+	existing, err := r.manager.ListIndexes(ctx, collection)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	existingSet := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		existingSet[name] = true
+	}
+
+	declared := make(map[string]bool)
+	for _, definition := range r.registry.Definitions(collection) {
+		name := definition.IndexName()
+		declared[name] = true
+
+		if existingSet[name] {
+			result.Existing = append(result.Existing, name)
+			continue
+		}
+
+		result.Missing = append(result.Missing, name)
+		if dryRun {
+			continue
+		}
+
+		start := time.Now()
+		if err := r.manager.CreateIndex(ctx, collection, definition); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			r.logger.Warn("Failed to create index %s on %s: %v", name, collection, err)
+			continue
+		}
+		elapsed := time.Since(start)
+
+		r.buildDuration.WithLabelValues(collection, name).Observe(elapsed.Seconds())
+		result.Created = append(result.Created, name)
+		r.logger.Info("Created index %s on %s in %v", name, collection, elapsed)
+	}
+
+	for _, name := range existing {
+		if name == defaultIndexName || declared[name] {
+			continue
+		}
+		result.Extra = append(result.Extra, name)
+		r.logger.Warn("Index %s on %s is not declared in the index registry", name, collection)
+	}
+
+	r.extraIndexes.WithLabelValues(collection).Set(float64(len(result.Extra)))
+	r.missing.WithLabelValues(collection).Set(float64(len(result.Missing) - len(result.Created)))
+
+	return result
+}
+
+// stdLogger logs using the standard library logger
+type stdLogger struct{}
+
+func (l *stdLogger) Info(format string, v ...interface{}) {
+	log.Printf("[INFO] "+format, v...)
+}
+
+func (l *stdLogger) Warn(format string, v ...interface{}) {
+	log.Printf("[WARN] "+format, v...)
+}
@@ -0,0 +1,85 @@
+package mongoindex_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"go-clean-ddd-es-template/pkg/mongoindex"
+)
+
+type fakeIndexManager struct {
+	indexes   map[string][]string
+	createErr error
+}
+
+func (m *fakeIndexManager) ListIndexes(ctx context.Context, collection string) ([]string, error) {
+	return m.indexes[collection], nil
+}
+
+func (m *fakeIndexManager) CreateIndex(ctx context.Context, collection string, definition mongoindex.Definition) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	m.indexes[collection] = append(m.indexes[collection], definition.IndexName())
+	return nil
+}
+
+func newRegistry() *mongoindex.Registry {
+	registry := mongoindex.NewRegistry()
+	registry.Register("users",
+		mongoindex.Definition{Keys: bson.D{{Key: "user_id", Value: 1}}, Unique: true},
+		mongoindex.Definition{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	)
+	return registry
+}
+
+func TestDefinition_IndexName(t *testing.T) {
+	assert.Equal(t, "user_id_1_timestamp_-1", mongoindex.Definition{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: -1}}}.IndexName())
+	assert.Equal(t, "custom", mongoindex.Definition{Name: "custom", Keys: bson.D{{Key: "a", Value: 1}}}.IndexName())
+}
+
+func TestReconciler_Sync(t *testing.T) {
+	manager := &fakeIndexManager{indexes: map[string][]string{
+		"users": {"_id_", "user_id_1", "legacy_name_1"},
+	}}
+	reconciler := mongoindex.NewReconciler(newRegistry(), manager, nil, prometheus.NewRegistry())
+
+	report := reconciler.Sync(context.Background())
+
+	require.Len(t, report.Collections, 1)
+	users := report.Collections[0]
+	assert.Equal(t, []string{"user_id_1"}, users.Existing)
+	assert.Equal(t, []string{"created_at_-1"}, users.Missing)
+	assert.Equal(t, []string{"created_at_-1"}, users.Created)
+	assert.Equal(t, []string{"legacy_name_1"}, users.Extra)
+	assert.False(t, report.HasErrors())
+	assert.Contains(t, manager.indexes["users"], "created_at_-1")
+}
+
+func TestReconciler_PlanDoesNotCreate(t *testing.T) {
+	manager := &fakeIndexManager{indexes: map[string][]string{"users": {"_id_"}}}
+	reconciler := mongoindex.NewReconciler(newRegistry(), manager, nil, prometheus.NewRegistry())
+
+	report := reconciler.Plan(context.Background())
+
+	assert.True(t, report.DryRun)
+	assert.Len(t, report.Collections[0].Missing, 2)
+	assert.Empty(t, report.Collections[0].Created)
+	assert.Equal(t, []string{"_id_"}, manager.indexes["users"])
+}
+
+func TestReconciler_SyncReportsErrors(t *testing.T) {
+	manager := &fakeIndexManager{indexes: map[string][]string{}, createErr: errors.New("boom")}
+	reconciler := mongoindex.NewReconciler(newRegistry(), manager, nil, prometheus.NewRegistry())
+
+	report := reconciler.Sync(context.Background())
+
+	assert.True(t, report.HasErrors())
+	assert.Empty(t, report.Collections[0].Created)
+}
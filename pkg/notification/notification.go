@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"context"
+)
+
+// Message is a notification sent to a user, rendered by the delivery channel from its template
+type Message struct {
+	Recipient string            `json:"recipient"` // E.g. an email address
+	Template  string            `json:"template"`  // E.g. "magic_link"
+	Data      map[string]string `json:"data"`      // Values of the template
+}
+
+// Sender delivers notifications, e.g. by email or through a notification service
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// SenderFunc adapts a function to a Sender
+type SenderFunc func(ctx context.Context, message Message) error
+
+// Send calls f
+func (f SenderFunc) Send(ctx context.Context, message Message) error {
+	return f(ctx, message)
+}
+
+// Logger logs notifications
+type Logger interface {
+	Info(format string, v ...interface{})
+}
+
+// LogSender logs notifications instead of delivering them, for development
+type LogSender struct {
+	logger Logger
+}
+
+// NewLogSender creates a sender logging notifications with their data
+func NewLogSender(logger Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+// Send logs message
+func (s *LogSender) Send(ctx context.Context, message Message) error {
+	s.logger.Info("Notification %s to %s: %v", message.Template, message.Recipient, message.Data)
+	return nil
+}
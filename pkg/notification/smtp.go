@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SMTPConfig configures the SMTP server notifications are mailed through
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // Empty to send without authentication
+	Password string
+	From     string // Address the mails are sent from
+}
+
+// SendMailFunc sends a mail, see smtp.SendMail
+type SendMailFunc func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPSender mails notifications through an SMTP server, as plain text listing the values of their
+// template
+type SMTPSender struct {
+	config   SMTPConfig
+	sendMail SendMailFunc
+}
+
+// NewSMTPSender creates an SMTP sender. A nil sendMail uses smtp.SendMail.
+func NewSMTPSender(config SMTPConfig, sendMail SendMailFunc) *SMTPSender {
+	if sendMail == nil {
+		sendMail = smtp.SendMail
+	}
+	return &SMTPSender{config: config, sendMail: sendMail}
+}
+
+// Send mails message to its recipient
+func (s *SMTPSender) Send(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if message.Recipient == "" || strings.ContainsAny(message.Recipient, "\r\n") {
+		return errors.New("invalid notification recipient")
+	}
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	if err := s.sendMail(addr, auth, s.config.From, []string{message.Recipient}, RenderMail(s.config.From, message)); err != nil {
+		return fmt.Errorf("failed to mail notification: %w", err)
+	}
+	return nil
+}
+
+// RenderMail renders message as a plain text mail from from: its subject is the template, e.g.
+// "Email verification" for "email_verification", and its body lists the values of the template
+func RenderMail(from string, message Message) []byte {
+	subject := strings.ReplaceAll(message.Template, "_", " ")
+	if subject != "" {
+		subject = strings.ToUpper(subject[:1]) + subject[1:]
+	}
+
+	keys := make([]string, 0, len(message.Data))
+	for key := range message.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var mail bytes.Buffer
+	fmt.Fprintf(&mail, "From: %s\r\n", from)
+	fmt.Fprintf(&mail, "To: %s\r\n", message.Recipient)
+	fmt.Fprintf(&mail, "Subject: %s\r\n", strings.NewReplacer("\r", "", "\n", "").Replace(subject))
+	mail.WriteString("MIME-Version: 1.0\r\n")
+	mail.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	for _, key := range keys {
+		fmt.Fprintf(&mail, "%s: %s\r\n", key, message.Data[key])
+	}
+	return mail.Bytes()
+}
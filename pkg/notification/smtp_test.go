@@ -0,0 +1,42 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPSender(t *testing.T) {
+	var addr, from string
+	var to []string
+	var mail []byte
+	sender := notification.NewSMTPSender(notification.SMTPConfig{Host: "smtp.example.com", Port: 587, From: "noreply@example.com"}, func(a string, auth smtp.Auth, f string, t []string, msg []byte) error {
+		addr, from, to, mail = a, f, t, msg
+		return nil
+	})
+
+	message := notification.Message{Recipient: "alice@example.com", Template: "email_verification", Data: map[string]string{"name": "Alice", "link": "https://example.com/verify"}}
+	require.NoError(t, sender.Send(context.Background(), message))
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.Equal(t, "noreply@example.com", from)
+	assert.Equal(t, []string{"alice@example.com"}, to)
+	assert.Equal(t, "From: noreply@example.com\r\nTo: alice@example.com\r\nSubject: Email verification\r\n"+
+		"MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n"+
+		"link: https://example.com/verify\r\nname: Alice\r\n", string(mail))
+
+	message.Recipient = "alice@example.com\r\nBcc: mallory@example.com"
+	assert.Error(t, sender.Send(context.Background(), message), "recipients cannot inject headers")
+}
+
+func TestSMTPSender_Error(t *testing.T) {
+	sender := notification.NewSMTPSender(notification.SMTPConfig{Host: "smtp.example.com", Port: 25}, func(string, smtp.Auth, string, []string, []byte) error {
+		return errors.New("connection refused")
+	})
+	assert.ErrorContains(t, sender.Send(context.Background(), notification.Message{Recipient: "alice@example.com"}), "connection refused")
+}
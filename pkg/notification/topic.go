@@ -0,0 +1,83 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go-clean-ddd-es-template/pkg/retry"
+)
+
+// Publisher publishes messages to a topic, e.g. of Kafka
+type Publisher interface {
+	Publish(topic string, message []byte) error
+}
+
+// TopicSender publishes notifications as JSON to a topic instead of delivering them, so a Worker
+// consuming the topic delivers them apart from the requests sending them
+type TopicSender struct {
+	publisher Publisher
+	topic     string
+}
+
+// NewTopicSender creates a sender publishing notifications to topic
+func NewTopicSender(publisher Publisher, topic string) *TopicSender {
+	return &TopicSender{publisher: publisher, topic: topic}
+}
+
+// Send publishes message to the topic
+func (s *TopicSender) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if err := s.publisher.Publish(s.topic, body); err != nil {
+		return fmt.Errorf("failed to publish notification to %s: %w", s.topic, err)
+	}
+	return nil
+}
+
+// WorkerLogger logs the notifications a worker delivers
+type WorkerLogger interface {
+	Info(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Worker delivers the notifications consumed from the topic of a TopicSender
+type Worker struct {
+	delivery Sender
+	policy   retry.Policy
+	logger   WorkerLogger
+}
+
+// NewWorker creates a worker delivering notifications with delivery, attempted as often as policy
+// allows
+func NewWorker(delivery Sender, policy retry.Policy, logger WorkerLogger) *Worker {
+	return &Worker{delivery: delivery, policy: policy, logger: logger}
+}
+
+// Handle delivers a notification consumed from the topic. Notifications that cannot be decoded or
+// still fail once the attempts are used up are logged and dropped, so they do not block the ones
+// after them.
+func (w *Worker) Handle(ctx context.Context, payload []byte) error {
+	var message Message
+	if err := json.Unmarshal(payload, &message); err != nil {
+		w.logger.Error("Dropped invalid notification: %v", err)
+		return fmt.Errorf("invalid notification: %w", err)
+	}
+	if message.Recipient == "" {
+		w.logger.Error("Dropped notification %s without recipient", message.Template)
+		return errors.New("notification without recipient")
+	}
+
+	err := retry.Do(ctx, w.policy, func(attempt int) error {
+		return w.delivery.Send(ctx, message)
+	})
+	if err != nil {
+		w.logger.Error("Failed to deliver notification %s to %s: %v", message.Template, message.Recipient, err)
+		return err
+	}
+	w.logger.Info("Delivered notification %s to %s", message.Template, message.Recipient)
+	return nil
+}
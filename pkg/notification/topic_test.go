@@ -0,0 +1,57 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/notification"
+	"go-clean-ddd-es-template/pkg/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publisherFunc adapts a function to a notification.Publisher
+type publisherFunc func(topic string, message []byte) error
+
+func (f publisherFunc) Publish(topic string, message []byte) error {
+	return f(topic, message)
+}
+
+type testLogger struct{ errors int }
+
+func (l *testLogger) Info(format string, v ...interface{}) {}
+
+func (l *testLogger) Error(format string, v ...interface{}) { l.errors++ }
+
+func TestTopicSenderAndWorker(t *testing.T) {
+	var published [][]byte
+	sender := notification.NewTopicSender(publisherFunc(func(topic string, message []byte) error {
+		assert.Equal(t, "notifications", topic)
+		published = append(published, message)
+		return nil
+	}), "notifications")
+
+	message := notification.Message{Recipient: "alice@example.com", Template: "email_verification", Data: map[string]string{"link": "https://example.com/verify"}}
+	require.NoError(t, sender.Send(context.Background(), message))
+	require.Len(t, published, 1)
+
+	attempts := 0
+	var delivered []notification.Message
+	logger := &testLogger{}
+	worker := notification.NewWorker(notification.SenderFunc(func(ctx context.Context, m notification.Message) error {
+		if attempts++; attempts == 1 {
+			return errors.New("mail server unavailable")
+		}
+		delivered = append(delivered, m)
+		return nil
+	}), retry.Policy{MaxAttempts: 2}, logger)
+
+	require.NoError(t, worker.Handle(context.Background(), published[0]), "failed deliveries are retried")
+	assert.Equal(t, []notification.Message{message}, delivered)
+
+	assert.Error(t, worker.Handle(context.Background(), []byte("not json")))
+	assert.Error(t, worker.Handle(context.Background(), []byte(`{"template":"email_verification"}`)))
+	assert.Equal(t, 2, logger.errors, "invalid notifications are logged and dropped")
+}
@@ -0,0 +1,48 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookSender posts notifications as JSON to a URL, e.g. of the service delivering emails
+type WebhookSender struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSender creates a webhook sender. A nil client uses http.DefaultClient.
+func NewWebhookSender(url string, client *http.Client) *WebhookSender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookSender{url: url, client: client}
+}
+
+// Send posts message to the webhook
+func (s *WebhookSender) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %s", resp.Status)
+	}
+	return nil
+}
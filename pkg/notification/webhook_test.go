@@ -0,0 +1,33 @@
+package notification_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSender(t *testing.T) {
+	var received notification.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		if received.Recipient == "bounce@example.com" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	sender := notification.NewWebhookSender(server.URL, server.Client())
+	message := notification.Message{Recipient: "alice@example.com", Template: "magic_link", Data: map[string]string{"link": "https://example.com/signin"}}
+	require.NoError(t, sender.Send(context.Background(), message))
+	assert.Equal(t, message, received)
+
+	message.Recipient = "bounce@example.com"
+	assert.Error(t, sender.Send(context.Background(), message))
+}
@@ -0,0 +1,351 @@
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
+)
+
+// Reporter reports how much of an operation is done; total is 0 while unknown
+type Reporter func(done, total int64)
+
+// Task runs an operation until it is done or ctx is cancelled, returning its result, saved as JSON
+type Task func(ctx context.Context, report Reporter) (interface{}, error)
+
+// Starter validates the args of an operation and returns the task running it
+type Starter func(args map[string]string) (Task, error)
+
+// Logger logs the lifecycle of operations
+type Logger interface {
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+}
+
+// saveInterval bounds how often the progress of a running operation is saved
+const saveInterval = time.Second
+
+// staleHeartbeats is the number of missed heartbeats after which a running operation is abandoned
+const staleHeartbeats = 3
+
+// Manager starts long-running operations in the background and tracks them in a store, so they
+// can be polled and cancelled from any instance sharing the store. Instances refresh the
+// operations they run every heartbeat; operations whose instance stopped refreshing them, e.g.
+// after a crash, are marked failed.
+type Manager struct {
+	store     Store
+	retention time.Duration
+	logger    Logger
+	clock     clock.Clock
+	ids       *id.Generator
+
+	mu       sync.Mutex
+	starters map[string]Starter
+	running  map[string]*run
+}
+
+// run is an operation running on this instance
+type run struct {
+	op        Operation
+	cancel    context.CancelFunc
+	saved     time.Time // When the operation was last saved
+	cancelled bool
+}
+
+// NewManager creates a manager tracking operations in store, deleting finished operations after
+// retention, never when 0. A nil logger logs nothing, a nil clock uses the system clock.
+func NewManager(store Store, retention time.Duration, logger Logger, clk clock.Clock) *Manager {
+	clk = clock.OrDefault(clk)
+	return &Manager{
+		store:     store,
+		retention: retention,
+		logger:    logger,
+		clock:     clk,
+		ids:       id.NewGenerator(clk),
+		starters:  make(map[string]Starter),
+		running:   make(map[string]*run),
+	}
+}
+
+// Register makes operations of kind startable, running the task returned by starter
+func (m *Manager) Register(kind string, starter Starter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.starters[kind] = starter
+}
+
+// Kinds returns the kinds of operations that can be started, sorted
+func (m *Manager) Kinds() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kinds := make([]string, 0, len(m.starters))
+	for kind := range m.starters {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Start starts an operation of kind on behalf of requestedBy, returning it once saved as running.
+// The errors of starters rejecting args wrap ErrInvalidArgs.
+func (m *Manager) Start(ctx context.Context, kind string, args map[string]string, requestedBy string) (Operation, error) {
+	m.mu.Lock()
+	starter, ok := m.starters[kind]
+	m.mu.Unlock()
+	if !ok {
+		return Operation{}, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	task, err := starter(args)
+	if err != nil {
+		return Operation{}, fmt.Errorf("%w: %v", ErrInvalidArgs, err)
+	}
+
+	now := m.clock.Now().UTC()
+	op := Operation{
+		ID:          m.ids.ULID(),
+		Kind:        kind,
+		Args:        args,
+		Status:      StatusRunning,
+		RequestedBy: requestedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if _, err := m.store.Save(ctx, op); err != nil {
+		return Operation{}, fmt.Errorf("failed to save operation: %w", err)
+	}
+
+	// The operation outlives the request starting it
+	runCtx, cancel := context.WithCancel(context.Background())
+	r := &run{op: op, cancel: cancel, saved: now}
+	m.mu.Lock()
+	m.running[op.ID] = r
+	m.mu.Unlock()
+
+	m.info("Operation %s %s started by %s (args: %v)", op.ID, kind, requestedBy, args)
+	go m.execute(runCtx, r, task)
+	return op, nil
+}
+
+// execute runs the task of an operation and saves its outcome
+func (m *Manager) execute(ctx context.Context, r *run, task Task) {
+	defer r.cancel()
+	result, err := task(ctx, func(done, total int64) { m.report(r, done, total) })
+
+	m.mu.Lock()
+	op := r.op
+	switch {
+	case err != nil && r.cancelled:
+		op.Status = StatusCancelled
+	case err != nil:
+		op.Status, op.Error = StatusFailed, err.Error()
+	default:
+		op.Status = StatusSucceeded
+		if result != nil {
+			if op.Result, err = json.Marshal(result); err != nil {
+				op.Status, op.Error = StatusFailed, fmt.Sprintf("failed to marshal result: %v", err)
+			}
+		}
+	}
+	now := m.clock.Now().UTC()
+	op.UpdatedAt, op.FinishedAt = now, &now
+	r.op = op
+	m.mu.Unlock()
+
+	// The final state is saved before the operation leaves the running ones, so polls never see
+	// an outdated state
+	if _, err := m.store.Save(context.Background(), op); err != nil {
+		m.warn("Failed to save operation %s %s %s: %v", op.ID, op.Kind, op.Status, err)
+	}
+	m.mu.Lock()
+	delete(m.running, op.ID)
+	m.mu.Unlock()
+
+	if op.Status == StatusFailed {
+		m.warn("Operation %s %s failed: %s", op.ID, op.Kind, op.Error)
+	} else {
+		m.info("Operation %s %s %s", op.ID, op.Kind, op.Status)
+	}
+}
+
+// report records the progress of a running operation, saving it at most every saveInterval
+func (m *Manager) report(r *run, done, total int64) {
+	m.mu.Lock()
+	r.op.SetProgress(done, total)
+	now := m.clock.Now()
+	if now.Sub(r.saved) < saveInterval {
+		m.mu.Unlock()
+		return
+	}
+	r.op.UpdatedAt, r.saved = now.UTC(), now
+	op := r.op
+	m.mu.Unlock()
+
+	m.save(r, op)
+}
+
+// save saves the state of a running operation, cancelling it when a cancellation was requested
+// from another instance or the stored operation finished meanwhile, e.g. marked abandoned
+func (m *Manager) save(r *run, op Operation) {
+	stored, err := m.store.Save(context.Background(), op)
+	switch {
+	case errors.Is(err, ErrFinished):
+		m.warn("Operation %s %s finished elsewhere as %s, cancelling it", op.ID, op.Kind, stored.Status)
+		m.cancelRun(r)
+	case err != nil:
+		m.warn("Failed to save operation %s %s: %v", op.ID, op.Kind, err)
+	case stored.CancelRequested:
+		m.cancelRun(r)
+	}
+}
+
+// cancelRun cancels an operation running on this instance
+func (m *Manager) cancelRun(r *run) {
+	m.mu.Lock()
+	r.cancelled = true
+	r.op.CancelRequested = true
+	m.mu.Unlock()
+	r.cancel()
+}
+
+// Get returns an operation, the latest progress of the operations running on this instance
+func (m *Manager) Get(ctx context.Context, id string) (Operation, error) {
+	m.mu.Lock()
+	r, ok := m.running[id]
+	var op Operation
+	if ok {
+		op = r.op
+	}
+	m.mu.Unlock()
+	if ok {
+		return op, nil
+	}
+	return m.store.Get(ctx, id)
+}
+
+// List returns the operations matching filter, newest first
+func (m *Manager) List(ctx context.Context, filter Filter) ([]Operation, error) {
+	operations, err := m.store.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, op := range operations {
+		if r, ok := m.running[op.ID]; ok {
+			operations[i] = r.op
+		}
+	}
+	return operations, nil
+}
+
+// Cancel cancels a running operation. Operations running on another instance are cancelled at
+// its next heartbeat or progress save; they are returned with CancelRequested set until then.
+func (m *Manager) Cancel(ctx context.Context, id string) (Operation, error) {
+	m.mu.Lock()
+	r, ok := m.running[id]
+	m.mu.Unlock()
+	if ok {
+		m.info("Operation %s %s cancellation requested", id, r.op.Kind)
+		m.cancelRun(r)
+		return m.Get(ctx, id)
+	}
+
+	op, err := m.store.Get(ctx, id)
+	if err != nil {
+		return Operation{}, err
+	}
+	if op.Finished() {
+		return op, ErrFinished
+	}
+	m.info("Operation %s %s cancellation requested from another instance", id, op.Kind)
+	op.CancelRequested = true
+	return m.store.Save(ctx, op)
+}
+
+// Maintain saves a heartbeat of the operations running on this instance, marks failed the running
+// operations without a heartbeat for staleAfter, and deletes the operations past retention
+func (m *Manager) Maintain(ctx context.Context, staleAfter time.Duration) error {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	runs := make([]*run, 0, len(m.running))
+	for _, r := range m.running {
+		r.op.UpdatedAt, r.saved = now.UTC(), now
+		runs = append(runs, r)
+	}
+	m.mu.Unlock()
+	for _, r := range runs {
+		m.mu.Lock()
+		op := r.op
+		m.mu.Unlock()
+		m.save(r, op)
+	}
+
+	running, err := m.store.List(ctx, Filter{Status: StatusRunning})
+	if err != nil {
+		return fmt.Errorf("failed to list running operations: %w", err)
+	}
+	for _, op := range running {
+		m.mu.Lock()
+		_, local := m.running[op.ID]
+		m.mu.Unlock()
+		if local || now.Sub(op.UpdatedAt) < staleAfter {
+			continue
+		}
+
+		finishedAt := now.UTC()
+		op.Status = StatusFailed
+		op.Error = fmt.Sprintf("abandoned, no heartbeat since %s", op.UpdatedAt.Format(time.RFC3339))
+		op.UpdatedAt, op.FinishedAt = finishedAt, &finishedAt
+		if _, err := m.store.Save(ctx, op); err != nil && !errors.Is(err, ErrFinished) {
+			return fmt.Errorf("failed to save abandoned operation %s: %w", op.ID, err)
+		}
+		m.warn("Operation %s %s %s", op.ID, op.Kind, op.Error)
+	}
+
+	if m.retention > 0 {
+		if _, err := m.store.DeleteFinishedBefore(ctx, now.Add(-m.retention)); err != nil {
+			return fmt.Errorf("failed to delete finished operations: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run maintains the operations every interval until ctx is done, abandoning the operations
+// without a heartbeat for staleHeartbeats intervals
+func (m *Manager) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-m.clock.After(interval):
+		}
+
+		if err := m.Maintain(ctx, staleHeartbeats*interval); err != nil {
+			m.warn("Failed to maintain operations: %v", err)
+		}
+	}
+}
+
+// info logs an informational message when a logger is set
+func (m *Manager) info(format string, v ...interface{}) {
+	if m.logger != nil {
+		m.logger.Info(format, v...)
+	}
+}
+
+// warn logs a warning when a logger is set
+func (m *Manager) warn(format string, v ...interface{}) {
+	if m.logger != nil {
+		m.logger.Warn(format, v...)
+	}
+}
@@ -0,0 +1,159 @@
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Statuses of operations
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Errors of operation starts, lookups and cancellations
+var (
+	ErrNotFound    = errors.New("operation not found")
+	ErrFinished    = errors.New("operation already finished")
+	ErrUnknownKind = errors.New("unknown operation kind")
+	ErrInvalidArgs = errors.New("invalid operation args")
+)
+
+// Operation is the state of a long-running task, e.g. a projection rebuild, persisted so clients
+// can poll it from any instance and after restarts
+type Operation struct {
+	ID              string            `json:"id"`
+	Kind            string            `json:"kind"`
+	Args            map[string]string `json:"args,omitempty"`
+	Status          string            `json:"status"`
+	Done            int64             `json:"done"`
+	Total           int64             `json:"total"`    // 0 while unknown
+	Progress        float64           `json:"progress"` // Percent of Total done
+	Result          json.RawMessage   `json:"result,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	RequestedBy     string            `json:"requested_by,omitempty"`
+	CancelRequested bool              `json:"cancel_requested,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"` // Refreshed while running, stale once the instance running it stopped
+	FinishedAt      *time.Time        `json:"finished_at,omitempty"`
+}
+
+// SetProgress sets how much of the operation is done and the matching progress percentage
+func (o *Operation) SetProgress(done, total int64) {
+	o.Done, o.Total = done, total
+	switch {
+	case total <= 0:
+		o.Progress = 0
+	case done >= total:
+		o.Progress = 100
+	default:
+		o.Progress = float64(done) * 100 / float64(total)
+	}
+}
+
+// Finished reports whether the operation stopped running
+func (o Operation) Finished() bool {
+	return o.Status != StatusRunning
+}
+
+// Filter selects listed operations; empty fields match every operation
+type Filter struct {
+	Kind   string
+	Status string
+	Limit  int // 0 lists every operation
+}
+
+// matches reports whether an operation matches the filter
+func (f Filter) matches(o Operation) bool {
+	return (f.Kind == "" || o.Kind == f.Kind) && (f.Status == "" || o.Status == f.Status)
+}
+
+// Store persists operations. Save never clears CancelRequested, so a cancellation requested from
+// another instance survives the progress saved by the instance running the operation, and fails
+// with ErrFinished once the stored operation finished.
+type Store interface {
+	// Save creates or updates an operation, returning it as stored
+	Save(ctx context.Context, op Operation) (Operation, error)
+	Get(ctx context.Context, id string) (Operation, error)
+	// List returns the operations matching filter, newest first
+	List(ctx context.Context, filter Filter) ([]Operation, error)
+	// DeleteFinishedBefore deletes the operations finished before t, returning how many were
+	DeleteFinishedBefore(ctx context.Context, t time.Time) (int, error)
+}
+
+// MemoryStore keeps operations in memory, so they are local to an instance and lost on restart
+type MemoryStore struct {
+	mu         sync.Mutex
+	operations map[string]Operation
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{operations: make(map[string]Operation)}
+}
+
+// Save creates or updates an operation
+func (s *MemoryStore) Save(ctx context.Context, op Operation) (Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.operations[op.ID]; ok {
+		if stored.Finished() {
+			return stored, ErrFinished
+		}
+		op.CancelRequested = op.CancelRequested || stored.CancelRequested
+	}
+	s.operations[op.ID] = op
+	return op, nil
+}
+
+// Get returns an operation
+func (s *MemoryStore) Get(ctx context.Context, id string) (Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, ok := s.operations[id]
+	if !ok {
+		return Operation{}, ErrNotFound
+	}
+	return op, nil
+}
+
+// List returns the operations matching filter, newest first
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	operations := make([]Operation, 0, len(s.operations))
+	for _, op := range s.operations {
+		if filter.matches(op) {
+			operations = append(operations, op)
+		}
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].ID > operations[j].ID })
+	if filter.Limit > 0 && len(operations) > filter.Limit {
+		operations = operations[:filter.Limit]
+	}
+	return operations, nil
+}
+
+// DeleteFinishedBefore deletes the operations finished before t
+func (s *MemoryStore) DeleteFinishedBefore(ctx context.Context, t time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for id, op := range s.operations {
+		if op.FinishedAt != nil && op.FinishedAt.Before(t) {
+			delete(s.operations, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
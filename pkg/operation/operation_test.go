@@ -0,0 +1,188 @@
+package operation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/operation"
+)
+
+// newTestManager creates a manager with a "count" operation reporting progress until released,
+// and a "wait" operation running until cancelled
+func newTestManager(store operation.Store, fake *clock.Fake) (*operation.Manager, chan int64) {
+	manager := operation.NewManager(store, 24*time.Hour, nil, fake)
+	progress := make(chan int64)
+	manager.Register("count", func(args map[string]string) (operation.Task, error) {
+		if args["to"] == "" {
+			return nil, errors.New("to is required")
+		}
+		return func(ctx context.Context, report operation.Reporter) (interface{}, error) {
+			done := int64(0)
+			for n := range progress {
+				done = n
+				report(done, 4)
+			}
+			return map[string]int64{"counted": done}, nil
+		}, nil
+	})
+	manager.Register("wait", func(args map[string]string) (operation.Task, error) {
+		return func(ctx context.Context, report operation.Reporter) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}, nil
+	})
+	return manager, progress
+}
+
+// waitForStatus waits until the stored operation has status
+func waitForStatus(t *testing.T, store operation.Store, id, status string) operation.Operation {
+	var op operation.Operation
+	require.Eventually(t, func() bool {
+		op, _ = store.Get(context.Background(), id)
+		return op.Status == status
+	}, time.Second, time.Millisecond)
+	return op
+}
+
+func TestManager_Start(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := operation.NewMemoryStore()
+	manager, progress := newTestManager(store, fake)
+
+	_, err := manager.Start(ctx, "unknown", nil, "alice")
+	assert.ErrorIs(t, err, operation.ErrUnknownKind)
+	_, err = manager.Start(ctx, "count", nil, "alice")
+	assert.ErrorIs(t, err, operation.ErrInvalidArgs)
+	assert.Equal(t, []string{"count", "wait"}, manager.Kinds())
+
+	op, err := manager.Start(ctx, "count", map[string]string{"to": "4"}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, operation.StatusRunning, op.Status)
+
+	progress <- 1
+	current, err := manager.Get(ctx, op.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, current.Progress)
+	stored, err := store.Get(ctx, op.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.Done, "progress is saved at most every second")
+
+	fake.Advance(time.Second)
+	progress <- 2
+	require.Eventually(t, func() bool {
+		stored, _ = store.Get(ctx, op.ID)
+		return stored.Done == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 50.0, stored.Progress)
+
+	close(progress)
+	stored = waitForStatus(t, store, op.ID, operation.StatusSucceeded)
+	assert.JSONEq(t, `{"counted": 2}`, string(stored.Result))
+	require.NotNil(t, stored.FinishedAt)
+	assert.Equal(t, "alice", stored.RequestedBy)
+
+	listed, err := manager.List(ctx, operation.Filter{Kind: "count"})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, op.ID, listed[0].ID)
+}
+
+func TestManager_Cancel(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := operation.NewMemoryStore()
+	manager, _ := newTestManager(store, fake)
+
+	op, err := manager.Start(ctx, "wait", nil, "alice")
+	require.NoError(t, err)
+	cancelled, err := manager.Cancel(ctx, op.ID)
+	require.NoError(t, err)
+	assert.True(t, cancelled.CancelRequested)
+	waitForStatus(t, store, op.ID, operation.StatusCancelled)
+
+	_, err = manager.Cancel(ctx, op.ID)
+	assert.ErrorIs(t, err, operation.ErrFinished)
+	_, err = manager.Cancel(ctx, "missing")
+	assert.ErrorIs(t, err, operation.ErrNotFound)
+}
+
+func TestManager_CancelFromAnotherInstance(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := operation.NewMemoryStore()
+	running, _ := newTestManager(store, fake)
+	other, _ := newTestManager(store, fake)
+
+	op, err := running.Start(ctx, "wait", nil, "alice")
+	require.NoError(t, err)
+	requested, err := other.Cancel(ctx, op.ID)
+	require.NoError(t, err)
+	assert.True(t, requested.CancelRequested)
+	assert.Equal(t, operation.StatusRunning, requested.Status)
+
+	// The instance running the operation picks the cancellation up at its next heartbeat
+	require.NoError(t, running.Maintain(ctx, time.Minute))
+	waitForStatus(t, store, op.ID, operation.StatusCancelled)
+}
+
+func TestManager_Maintain(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := operation.NewMemoryStore()
+	manager, _ := newTestManager(store, fake)
+
+	// An operation of an instance that crashed, and one running on this instance
+	crashed := operation.Operation{ID: "01HMZ8Y6R7QK3W2V1T0S9N8M7L", Kind: "wait", Status: operation.StatusRunning, CreatedAt: fake.Now(), UpdatedAt: fake.Now()}
+	_, err := store.Save(ctx, crashed)
+	require.NoError(t, err)
+	local, err := manager.Start(ctx, "wait", nil, "alice")
+	require.NoError(t, err)
+
+	fake.Advance(time.Minute)
+	require.NoError(t, manager.Maintain(ctx, 30*time.Second))
+	abandoned, err := store.Get(ctx, crashed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, operation.StatusFailed, abandoned.Status)
+	assert.Contains(t, abandoned.Error, "abandoned")
+	heartbeat, err := store.Get(ctx, local.ID)
+	require.NoError(t, err)
+	assert.Equal(t, operation.StatusRunning, heartbeat.Status)
+	assert.Equal(t, fake.Now(), heartbeat.UpdatedAt, "operations running on this instance are refreshed")
+
+	fake.Advance(25 * time.Hour)
+	require.NoError(t, manager.Maintain(ctx, 30*time.Second))
+	_, err = store.Get(ctx, crashed.ID)
+	assert.ErrorIs(t, err, operation.ErrNotFound, "finished operations are deleted after the retention")
+	_, err = store.Get(ctx, local.ID)
+	assert.NoError(t, err, "running operations are kept")
+
+	_, err = manager.Cancel(ctx, local.ID)
+	require.NoError(t, err)
+	waitForStatus(t, store, local.ID, operation.StatusCancelled)
+}
+
+func TestMemoryStore_Save(t *testing.T) {
+	ctx := context.Background()
+	store := operation.NewMemoryStore()
+	op := operation.Operation{ID: "1", Kind: "wait", Status: operation.StatusRunning}
+	_, err := store.Save(ctx, operation.Operation{ID: "1", Kind: "wait", Status: operation.StatusRunning, CancelRequested: true})
+	require.NoError(t, err)
+
+	saved, err := store.Save(ctx, op)
+	require.NoError(t, err)
+	assert.True(t, saved.CancelRequested, "cancellation requests are kept")
+
+	op.Status = operation.StatusCancelled
+	_, err = store.Save(ctx, op)
+	require.NoError(t, err)
+	op.Status = operation.StatusRunning
+	_, err = store.Save(ctx, op)
+	assert.ErrorIs(t, err, operation.ErrFinished)
+}
@@ -0,0 +1,249 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Event is an event of the stream projections are applied to
+type Event struct {
+	ID          string
+	AggregateID string
+	Type        string
+	Data        []byte // JSON encoded payload
+	Version     int
+	Timestamp   time.Time
+	Position    string // Opaque position of the event in the stream, see Source
+}
+
+// Source reads the event stream in order
+type Source interface {
+	// Read returns up to limit events following position, from the start of the stream when
+	// position is empty
+	Read(ctx context.Context, position string, limit int) ([]Event, error)
+}
+
+// CheckpointStore keeps the position each projection reached in the event stream
+type CheckpointStore interface {
+	// Load returns the position of a projection, empty when it has not applied any event yet
+	Load(ctx context.Context, projection string) (string, error)
+	// Save records the position of a projection
+	Save(ctx context.Context, projection, position string) error
+}
+
+// Projection builds a read model from the events of the types it handles
+type Projection interface {
+	// Name identifies the projection and its checkpoint; renaming it rebuilds the read model
+	Name() string
+	// EventTypes returns the types of the events applied to the projection
+	EventTypes() []string
+	// Apply applies an event to the read model. Events are applied at least once: those applied
+	// since the last checkpoint are applied again after a restart.
+	Apply(ctx context.Context, event Event) error
+}
+
+// HandlerFunc applies an event of a type to a read model
+type HandlerFunc func(ctx context.Context, event Event) error
+
+// Handlers is a projection declared by its name and the handler of each event type it handles
+type Handlers struct {
+	name     string
+	handlers map[string]HandlerFunc
+}
+
+// Define declares a projection from the handlers of the event types it handles
+func Define(name string, handlers map[string]HandlerFunc) *Handlers {
+	return &Handlers{name: name, handlers: handlers}
+}
+
+// Name returns the name of the projection
+func (h *Handlers) Name() string {
+	return h.name
+}
+
+// EventTypes returns the event types with a handler, sorted
+func (h *Handlers) EventTypes() []string {
+	types := make([]string, 0, len(h.handlers))
+	for eventType := range h.handlers {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Apply applies an event with the handler of its type
+func (h *Handlers) Apply(ctx context.Context, event Event) error {
+	handler, ok := h.handlers[event.Type]
+	if !ok {
+		return fmt.Errorf("projection %s does not handle %s events", h.name, event.Type)
+	}
+	return handler(ctx, event)
+}
+
+// Logger logs the progress of projections
+type Logger interface {
+	Info(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Engine keeps projections up to date with the event stream, each from its checkpoint, so
+// projections added later catch up from the start of the stream and all of them resume where
+// they stopped after a restart
+type Engine struct {
+	source      Source
+	checkpoints CheckpointStore
+	batchSize   int
+	interval    time.Duration
+	clock       clock.Clock
+	logger      Logger
+
+	mu          sync.Mutex
+	projections []Projection
+}
+
+// NewEngine creates an engine reading batchSize events at a time and polling the stream every
+// interval once projections caught up. A nil clock uses the system clock.
+func NewEngine(source Source, checkpoints CheckpointStore, batchSize int, interval time.Duration, clk clock.Clock, logger Logger) *Engine {
+	return &Engine{
+		source:      source,
+		checkpoints: checkpoints,
+		batchSize:   batchSize,
+		interval:    interval,
+		clock:       clock.OrDefault(clk),
+		logger:      logger,
+	}
+}
+
+// Register adds projections to the engine. Names must be unique and every projection must
+// handle at least one event type.
+func (e *Engine) Register(projections ...Projection) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, projection := range projections {
+		if projection.Name() == "" {
+			return errors.New("projection name is required")
+		}
+		if len(projection.EventTypes()) == 0 {
+			return fmt.Errorf("projection %s handles no event types", projection.Name())
+		}
+		for _, registered := range e.projections {
+			if registered.Name() == projection.Name() {
+				return fmt.Errorf("projection %s is already registered", projection.Name())
+			}
+		}
+		e.projections = append(e.projections, projection)
+	}
+	return nil
+}
+
+// Projections returns the registered projections
+func (e *Engine) Projections() []Projection {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Projection(nil), e.projections...)
+}
+
+// Run catches every projection up with the stream, then polls it for new events, until ctx is
+// done. A failing projection is retried at the next poll without holding back the others.
+func (e *Engine) Run(ctx context.Context) error {
+	for {
+		for _, projection := range e.Projections() {
+			if _, err := e.CatchUp(ctx, projection); err != nil && ctx.Err() == nil {
+				e.logger.Error("Projection %s failed: %v", projection.Name(), err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-e.clock.After(e.interval):
+		}
+	}
+}
+
+// CatchUp applies the events following the checkpoint of a projection, saving the checkpoint
+// after each batch, and returns the number of events applied. At the first event failing to
+// apply, the checkpoint is saved right before it.
+func (e *Engine) CatchUp(ctx context.Context, projection Projection) (int, error) {
+	position, err := e.checkpoints.Load(ctx, projection.Name())
+	if err != nil {
+		return 0, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	handled := make(map[string]bool)
+	for _, eventType := range projection.EventTypes() {
+		handled[eventType] = true
+	}
+
+	applied := 0
+	for ctx.Err() == nil {
+		batch, err := e.source.Read(ctx, position, e.batchSize)
+		if err != nil {
+			return applied, fmt.Errorf("failed to read events: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		next := position
+		for _, event := range batch {
+			if handled[event.Type] {
+				if err := projection.Apply(ctx, event); err != nil {
+					if next != position {
+						if saveErr := e.checkpoints.Save(ctx, projection.Name(), next); saveErr != nil {
+							e.logger.Error("Failed to save checkpoint of projection %s: %v", projection.Name(), saveErr)
+						}
+					}
+					return applied, fmt.Errorf("failed to apply %s event %s: %w", event.Type, event.ID, err)
+				}
+				applied++
+			}
+			next = event.Position
+		}
+
+		if err := e.checkpoints.Save(ctx, projection.Name(), next); err != nil {
+			return applied, fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+		position = next
+		if len(batch) < e.batchSize {
+			break
+		}
+	}
+	if applied > 0 {
+		e.logger.Info("Projection %s applied %d events", projection.Name(), applied)
+	}
+	return applied, nil
+}
+
+// MemoryCheckpointStore keeps checkpoints in memory, for tests and projections rebuilt at every start
+type MemoryCheckpointStore struct {
+	mu        sync.Mutex
+	positions map[string]string
+}
+
+// NewMemoryCheckpointStore creates an empty in-memory checkpoint store
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{positions: make(map[string]string)}
+}
+
+// Load returns the position of a projection
+func (s *MemoryCheckpointStore) Load(ctx context.Context, projection string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.positions[projection], nil
+}
+
+// Save records the position of a projection
+func (s *MemoryCheckpointStore) Save(ctx context.Context, projection, position string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.positions[projection] = position
+	return nil
+}
@@ -0,0 +1,105 @@
+package projection_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/projection"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySource is an event stream whose positions are the indexes of its events
+type memorySource struct {
+	events []projection.Event
+}
+
+func (s *memorySource) append(eventType string) {
+	position := strconv.Itoa(len(s.events) + 1)
+	s.events = append(s.events, projection.Event{ID: "event-" + position, Type: eventType, Position: position})
+}
+
+func (s *memorySource) Read(ctx context.Context, position string, limit int) ([]projection.Event, error) {
+	start := 0
+	if position != "" {
+		start, _ = strconv.Atoi(position)
+	}
+	end := min(start+limit, len(s.events))
+	return s.events[start:end], nil
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(format string, v ...interface{})  {}
+func (nopLogger) Error(format string, v ...interface{}) {}
+
+func TestEngine_CatchUpResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	source := &memorySource{}
+	for _, eventType := range []string{"user.created", "user.updated", "user.created", "user.deleted", "user.created"} {
+		source.append(eventType)
+	}
+	checkpoints := projection.NewMemoryCheckpointStore()
+
+	var applied []string
+	counter := projection.Define("user_count", map[string]projection.HandlerFunc{
+		"user.created": func(ctx context.Context, event projection.Event) error {
+			applied = append(applied, event.ID)
+			return nil
+		},
+	})
+	engine := projection.NewEngine(source, checkpoints, 2, 0, nil, nopLogger{})
+	require.NoError(t, engine.Register(counter))
+
+	count, err := engine.CatchUp(ctx, counter)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []string{"event-1", "event-3", "event-5"}, applied)
+	position, _ := checkpoints.Load(ctx, "user_count")
+	assert.Equal(t, "5", position, "the checkpoint advances past unhandled events")
+
+	source.append("user.created")
+	count, err = engine.CatchUp(ctx, counter)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "events before the checkpoint are not applied again")
+	assert.Equal(t, []string{"event-1", "event-3", "event-5", "event-6"}, applied)
+}
+
+func TestEngine_CatchUpStopsAtFailedEvent(t *testing.T) {
+	ctx := context.Background()
+	source := &memorySource{}
+	for range 4 {
+		source.append("user.created")
+	}
+	checkpoints := projection.NewMemoryCheckpointStore()
+
+	failing := projection.Define("failing", map[string]projection.HandlerFunc{
+		"user.created": func(ctx context.Context, event projection.Event) error {
+			if event.ID == "event-3" {
+				return errors.New("read model unavailable")
+			}
+			return nil
+		},
+	})
+	engine := projection.NewEngine(source, checkpoints, 10, 0, nil, nopLogger{})
+
+	count, err := engine.CatchUp(ctx, failing)
+	assert.ErrorContains(t, err, "event-3")
+	assert.Equal(t, 2, count)
+	position, _ := checkpoints.Load(ctx, "failing")
+	assert.Equal(t, "2", position, "the failed event is applied again at the next catch up")
+}
+
+func TestEngine_Register(t *testing.T) {
+	engine := projection.NewEngine(&memorySource{}, projection.NewMemoryCheckpointStore(), 10, 0, nil, nopLogger{})
+	handler := func(ctx context.Context, event projection.Event) error { return nil }
+
+	require.NoError(t, engine.Register(projection.Define("users", map[string]projection.HandlerFunc{"user.created": handler})))
+	assert.Error(t, engine.Register(projection.Define("users", map[string]projection.HandlerFunc{"user.deleted": handler})), "names are unique")
+	assert.Error(t, engine.Register(projection.Define("empty", nil)), "projections handle at least one event type")
+	assert.Error(t, engine.Register(projection.Define("", map[string]projection.HandlerFunc{"user.created": handler})))
+	assert.Len(t, engine.Projections(), 1)
+}
@@ -0,0 +1,337 @@
+package queryplan
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// BackendPostgres identifies queries executed against PostgreSQL
+	BackendPostgres = "postgres"
+	// BackendMongo identifies queries executed against MongoDB
+	BackendMongo = "mongodb"
+)
+
+// Query describes a read query that can be explained
+type Query struct {
+	Backend   string
+	Operation string // Repository method that issued the query
+
+	// PostgreSQL specific
+	Statement string
+	Args      []interface{}
+
+	// MongoDB specific
+	Collection string
+	Filter     interface{}
+	Sort       interface{}
+	Skip       int64
+	Limit      int64
+}
+
+// Explainer returns the execution plan of a query
+type Explainer interface {
+	Explain(ctx context.Context, query Query) (string, error)
+}
+
+// Logger interface for logging
+type Logger interface {
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+}
+
+// PostgresExplainer runs EXPLAIN for PostgreSQL queries
+type PostgresExplainer struct {
+	db *sql.DB
+}
+
+// NewPostgresExplainer creates a new PostgreSQL explainer
+func NewPostgresExplainer(db *sql.DB) *PostgresExplainer {
+	return &PostgresExplainer{db: db}
+}
+
+// Explain runs EXPLAIN for a SELECT statement and returns the plan
+func (e *PostgresExplainer) Explain(ctx context.Context, query Query) (string, error) {
+	statement := strings.TrimSpace(query.Statement)
+	if !strings.HasPrefix(strings.ToUpper(statement), "SELECT") {
+		return "", fmt.Errorf("only SELECT statements can be explained")
+	}
+
+	rows, err := e.db.QueryContext(ctx, "EXPLAIN "+statement, query.Args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to explain query: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", fmt.Errorf("failed to read query plan: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to read query plan: %w", err)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// MongoExplainer runs the explain command for MongoDB find queries
+type MongoExplainer struct {
+	client   *mongo.Client
+	database string
+}
+
+// NewMongoExplainer creates a new MongoDB explainer
+func NewMongoExplainer(client *mongo.Client, database string) *MongoExplainer {
+	return &MongoExplainer{
+		client:   client,
+		database: database,
+	}
+}
+
+// Explain runs explain() for a find query and returns the query planner output
+func (e *MongoExplainer) Explain(ctx context.Context, query Query) (string, error) {
+	find := bson.D{{Key: "find", Value: query.Collection}, {Key: "filter", Value: query.Filter}}
+	if query.Sort != nil {
+		find = append(find, bson.E{Key: "sort", Value: query.Sort})
+	}
+	if query.Skip > 0 {
+		find = append(find, bson.E{Key: "skip", Value: query.Skip})
+	}
+	if query.Limit > 0 {
+		find = append(find, bson.E{Key: "limit", Value: query.Limit})
+	}
+
+	command := bson.D{{Key: "explain", Value: find}, {Key: "verbosity", Value: "queryPlanner"}}
+
+	var result bson.M
+	if err := e.client.Database(e.database).RunCommand(ctx, command).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to explain query: %w", err)
+	}
+
+	plan := interface{}(result)
+	if planner, ok := result["queryPlanner"]; ok {
+		plan = planner
+	}
+
+	output, err := bson.MarshalExtJSON(bson.M{"queryPlanner": plan}, false, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode query plan: %w", err)
+	}
+
+	return string(output), nil
+}
+
+var (
+	sqlStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlPlaceholder   = regexp.MustCompile(`\$\d+`)
+	sqlNumber        = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	whitespace       = regexp.MustCompile(`\s+`)
+)
+
+// Normalize returns the shape of a query with all literal values replaced by "?"
+func Normalize(query Query) string {
+	switch query.Backend {
+	case BackendMongo:
+		shape := map[string]interface{}{
+			"collection": query.Collection,
+			"filter":     shapeOf(query.Filter),
+		}
+		if query.Sort != nil {
+			shape["sort"] = sortShape(query.Sort)
+		}
+		encoded, err := json.Marshal(shape)
+		if err != nil {
+			return query.Collection
+		}
+		return string(encoded)
+	default:
+		normalized := sqlStringLiteral.ReplaceAllString(query.Statement, "?")
+		normalized = sqlPlaceholder.ReplaceAllString(normalized, "?")
+		normalized = sqlNumber.ReplaceAllString(normalized, "?")
+		normalized = whitespace.ReplaceAllString(normalized, " ")
+		return strings.ToLower(strings.TrimSpace(normalized))
+	}
+}
+
+// Fingerprint returns a stable identifier for queries sharing the same shape
+func Fingerprint(query Query) string {
+	sum := sha1.Sum([]byte(query.Backend + ":" + Normalize(query)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// shapeOf replaces literal values in a filter with "?" while keeping field names and operators
+func shapeOf(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case bson.M:
+		return shapeOfMap(v)
+	case map[string]interface{}:
+		return shapeOfMap(v)
+	case bson.D:
+		shape := make(map[string]interface{}, len(v))
+		for _, elem := range v {
+			shape[elem.Key] = shapeOf(elem.Value)
+		}
+		return shape
+	case bson.A:
+		return shapeOfSlice(v)
+	case []interface{}:
+		return shapeOfSlice(v)
+	case bool:
+		// Operators such as $exists are part of the shape, not a literal
+		return v
+	default:
+		return "?"
+	}
+}
+
+func shapeOfMap(m map[string]interface{}) map[string]interface{} {
+	shape := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		shape[key] = shapeOf(value)
+	}
+	return shape
+}
+
+func shapeOfSlice(values []interface{}) []interface{} {
+	if len(values) == 0 {
+		return values
+	}
+	// Only the shape of the first element matters, list length is a literal
+	return []interface{}{shapeOf(values[0])}
+}
+
+// sortShape keeps sort keys and directions since they affect index selection
+func sortShape(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		keys := make([]string, 0, len(v))
+		for _, elem := range v {
+			keys = append(keys, fmt.Sprintf("%s:%v", elem.Key, elem.Value))
+		}
+		return keys
+	case bson.M:
+		keys := make([]string, 0, len(v))
+		for key, direction := range v {
+			keys = append(keys, fmt.Sprintf("%s:%v", key, direction))
+		}
+		sort.Strings(keys)
+		return keys
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// Analyzer explains queries that exceed a slow threshold and logs their plans
+type Analyzer struct {
+	explainer   Explainer
+	threshold   time.Duration
+	cooldown    time.Duration
+	timeout     time.Duration
+	logger      Logger
+	mu          sync.Mutex
+	lastExplain map[string]time.Time
+}
+
+// NewAnalyzer creates a new slow query analyzer
+func NewAnalyzer(explainer Explainer, threshold time.Duration, logger Logger) *Analyzer {
+	if logger == nil {
+		logger = &stdLogger{}
+	}
+
+	return &Analyzer{
+		explainer:   explainer,
+		threshold:   threshold,
+		cooldown:    time.Minute,
+		timeout:     5 * time.Second,
+		logger:      logger,
+		lastExplain: make(map[string]time.Time),
+	}
+}
+
+// Threshold returns the duration above which a query is considered slow
+func (a *Analyzer) Threshold() time.Duration {
+	return a.threshold
+}
+
+// Observe records a query execution and explains it when it was slow.
+// Each fingerprint is explained at most once per cooldown period.
+func (a *Analyzer) Observe(query Query, elapsed time.Duration) {
+	if elapsed < a.threshold {
+		return
+	}
+
+	fingerprint := Fingerprint(query)
+	if !a.shouldExplain(fingerprint) {
+		return
+	}
+
+	go a.explain(query, fingerprint, elapsed)
+}
+
+// ExplainNow explains a query synchronously and logs the plan
+func (a *Analyzer) ExplainNow(ctx context.Context, query Query) (string, error) {
+	plan, err := a.explainer.Explain(ctx, query)
+	if err != nil {
+		return "", err
+	}
+
+	a.logger.Info("Query plan [%s] %s (fingerprint=%s):\n%s", query.Backend, query.Operation, Fingerprint(query), plan)
+	return plan, nil
+}
+
+func (a *Analyzer) shouldExplain(fingerprint string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := a.lastExplain[fingerprint]; ok && now.Sub(last) < a.cooldown {
+		return false
+	}
+	a.lastExplain[fingerprint] = now
+	return true
+}
+
+func (a *Analyzer) explain(query Query, fingerprint string, elapsed time.Duration) {
+	// The caller context may already be done, so explain with a detached timeout
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	plan, err := a.explainer.Explain(ctx, query)
+	if err != nil {
+		a.logger.Warn("Failed to explain slow query %s (fingerprint=%s): %v", query.Operation, fingerprint, err)
+		return
+	}
+
+	a.logger.Warn("Slow query [%s] %s took %v (threshold %v, fingerprint=%s, shape=%s)\n%s",
+		query.Backend, query.Operation, elapsed, a.threshold, fingerprint, Normalize(query), plan)
+}
+
+// stdLogger logs using the standard library logger
+type stdLogger struct{}
+
+func (l *stdLogger) Info(format string, v ...interface{}) {
+	log.Printf("[INFO] "+format, v...)
+}
+
+func (l *stdLogger) Warn(format string, v ...interface{}) {
+	log.Printf("[WARN] "+format, v...)
+}
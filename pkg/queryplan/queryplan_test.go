@@ -0,0 +1,100 @@
+package queryplan_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"go-clean-ddd-es-template/pkg/queryplan"
+)
+
+type fakeExplainer struct {
+	mu    sync.Mutex
+	calls int
+	done  chan struct{}
+}
+
+func (e *fakeExplainer) Explain(ctx context.Context, query queryplan.Query) (string, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	e.done <- struct{}{}
+	return "Index Scan using idx_user_read_models_email", nil
+}
+
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Info(format string, v ...interface{}) {
+	l.record(format, v...)
+}
+
+func (l *recordingLogger) Warn(format string, v ...interface{}) {
+	l.record(format, v...)
+}
+
+func (l *recordingLogger) record(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestFingerprint_SQLIgnoresLiterals(t *testing.T) {
+	a := queryplan.Query{Backend: queryplan.BackendPostgres, Statement: "SELECT * FROM users WHERE email = 'a@example.com' LIMIT 10"}
+	b := queryplan.Query{Backend: queryplan.BackendPostgres, Statement: "select *\n  from users where email = 'b@example.com' limit 20"}
+	c := queryplan.Query{Backend: queryplan.BackendPostgres, Statement: "SELECT * FROM users WHERE name = $1"}
+
+	assert.Equal(t, queryplan.Fingerprint(a), queryplan.Fingerprint(b))
+	assert.NotEqual(t, queryplan.Fingerprint(a), queryplan.Fingerprint(c))
+	assert.Equal(t, "select * from users where email = ? limit ?", queryplan.Normalize(a))
+}
+
+func TestFingerprint_MongoIgnoresValues(t *testing.T) {
+	a := queryplan.Query{Backend: queryplan.BackendMongo, Collection: "users", Filter: bson.M{"user_id": "1", "deleted_at": bson.M{"$exists": false}}}
+	b := queryplan.Query{Backend: queryplan.BackendMongo, Collection: "users", Filter: bson.M{"user_id": "2", "deleted_at": bson.M{"$exists": false}}}
+	c := queryplan.Query{Backend: queryplan.BackendMongo, Collection: "users", Filter: bson.M{"email": "x"}}
+
+	assert.Equal(t, queryplan.Fingerprint(a), queryplan.Fingerprint(b))
+	assert.NotEqual(t, queryplan.Fingerprint(a), queryplan.Fingerprint(c))
+	assert.Contains(t, queryplan.Normalize(a), `"user_id":"?"`)
+}
+
+func TestAnalyzer_ExplainsSlowQueriesOnce(t *testing.T) {
+	explainer := &fakeExplainer{done: make(chan struct{}, 4)}
+	logger := &recordingLogger{}
+	analyzer := queryplan.NewAnalyzer(explainer, 50*time.Millisecond, logger)
+
+	query := queryplan.Query{Backend: queryplan.BackendPostgres, Operation: "GetUserByEmail", Statement: "SELECT * FROM users WHERE email = $1"}
+
+	analyzer.Observe(query, 10*time.Millisecond)
+	analyzer.Observe(query, 80*time.Millisecond)
+	analyzer.Observe(query, 90*time.Millisecond)
+
+	select {
+	case <-explainer.done:
+	case <-time.After(time.Second):
+		t.Fatal("slow query was not explained")
+	}
+
+	assert.Eventually(t, func() bool {
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		return len(logger.messages) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	explainer.mu.Lock()
+	assert.Equal(t, 1, explainer.calls)
+	explainer.mu.Unlock()
+
+	logger.mu.Lock()
+	assert.Contains(t, logger.messages[0], "GetUserByEmail")
+	assert.Contains(t, logger.messages[0], queryplan.Fingerprint(query))
+	logger.mu.Unlock()
+}
@@ -0,0 +1,214 @@
+package redact
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Tag marks struct fields that must not leave the service as is, e.g. `sensitive:"true"`
+const Tag = "sensitive"
+
+// Tag values
+const (
+	TagAlways = "true" // Stripped from logs and responses, e.g. password hashes
+	TagLogs   = "log"  // Stripped from logs only, e.g. tokens a response exists to return
+)
+
+// Purpose is what a value is serialized for, deciding which sensitive fields are stripped
+type Purpose int
+
+// Purposes
+const (
+	ForLogs      Purpose = iota // Strips every sensitive field
+	ForResponses                // Strips fields tagged TagAlways
+)
+
+var (
+	jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// Redactor strips sensitive fields from values before they are serialized. Fields follow their
+// json tags, so the result serializes like the value would, minus the stripped fields.
+type Redactor struct {
+	purpose Purpose
+	names   map[string]bool
+}
+
+// NewRedactor creates a redactor for purpose. names are JSON field and map key names stripped
+// as well, for types that cannot be tagged such as generated protobuf messages.
+func NewRedactor(purpose Purpose, names ...string) *Redactor {
+	r := &Redactor{purpose: purpose, names: make(map[string]bool, len(names))}
+	for _, name := range names {
+		r.names[name] = true
+	}
+	return r
+}
+
+var (
+	logRedactor      = NewRedactor(ForLogs)
+	responseRedactor = NewRedactor(ForResponses)
+)
+
+// MarshalForLog serializes v to JSON without any sensitive field
+func MarshalForLog(v interface{}) ([]byte, error) {
+	return logRedactor.Marshal(v)
+}
+
+// MarshalForResponse serializes v to JSON without the fields that are never returned
+func MarshalForResponse(v interface{}) ([]byte, error) {
+	return responseRedactor.Marshal(v)
+}
+
+// Marshal serializes v to JSON without its sensitive fields
+func (r *Redactor) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(r.Strip(v))
+}
+
+// Strip returns v as JSON values (maps, slices and scalars) without its sensitive fields.
+// Values marshaling themselves, like times, are kept as is.
+func (r *Redactor) Strip(v interface{}) interface{} {
+	return r.strip(reflect.ValueOf(v))
+}
+
+func (r *Redactor) strip(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface && (v.Type().Implements(jsonMarshaler) || v.Type().Implements(textMarshaler)) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Pointer && (v.Type().Implements(jsonMarshaler) || v.Type().Implements(textMarshaler)) {
+			return v.Interface()
+		}
+		return r.strip(v.Elem())
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		r.stripFields(v, fields)
+		return fields
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		entries := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if !r.names[key] {
+				entries[key] = r.strip(iter.Value())
+			}
+		}
+		return entries
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface() // Bytes serialize as base64
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = r.strip(v.Index(i))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
+
+// stripFields adds the exported fields of a struct that are kept to fields, flattening embedded
+// structs like encoding/json
+func (r *Redactor) stripFields(v reflect.Value, fields map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		if r.sensitive(field.Tag.Get(Tag)) {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		value := v.Field(i)
+
+		if field.Anonymous && name == "" {
+			embedded := value
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.stripFields(embedded, fields)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if r.names[name] || omitted(value, options) {
+			continue
+		}
+		fields[name] = r.strip(value)
+	}
+}
+
+// sensitive reports whether a field with the sensitive tag value is stripped
+func (r *Redactor) sensitive(tag string) bool {
+	switch tag {
+	case TagAlways:
+		return true
+	case TagLogs:
+		return r.purpose == ForLogs
+	}
+	return false
+}
+
+// omitted reports whether the json tag options omit the value
+func omitted(v reflect.Value, options string) bool {
+	for _, option := range strings.Split(options, ",") {
+		switch option {
+		case "omitempty":
+			if isEmpty(v) {
+				return true
+			}
+		case "omitzero":
+			if zero, ok := v.Interface().(interface{ IsZero() bool }); ok {
+				if (v.Kind() != reflect.Pointer || !v.IsNil()) && zero.IsZero() {
+					return true
+				}
+			} else if v.IsZero() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isEmpty reports whether encoding/json considers a value empty
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package redact_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/redact"
+)
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password" sensitive:"true"`
+}
+
+type session struct {
+	credentials
+	Token     string            `json:"token" sensitive:"log"`
+	Roles     []string          `json:"roles,omitempty"`
+	Note      string            `json:"note,omitempty"`
+	Hidden    string            `json:"-"`
+	IssuedAt  time.Time         `json:"issued_at"`
+	Metadata  map[string]string `json:"metadata"`
+	Previous  *session          `json:"previous,omitempty"`
+	internal  string
+	Untagged  int
+	Embedded  *credentials `json:"embedded"`
+	Overrides []credentials
+}
+
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields
+}
+
+func TestMarshalForLog(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	value := session{
+		credentials: credentials{Email: "test@example.com", Password: "secret"},
+		Token:       "jwt",
+		Hidden:      "hidden",
+		IssuedAt:    issuedAt,
+		Metadata:    map[string]string{"password": "secret", "source": "web"},
+		internal:    "internal",
+		Untagged:    1,
+		Embedded:    &credentials{Email: "other@example.com", Password: "secret"},
+		Overrides:   []credentials{{Email: "third@example.com", Password: "secret"}},
+	}
+
+	data, err := redact.MarshalForLog(&value)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "jwt")
+
+	fields := decode(t, data)
+	assert.Equal(t, "test@example.com", fields["email"])
+	assert.Equal(t, "2024-01-01T00:00:00Z", fields["issued_at"])
+	assert.Equal(t, float64(1), fields["Untagged"])
+	assert.Equal(t, map[string]interface{}{"email": "other@example.com"}, fields["embedded"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"email": "third@example.com"}}, fields["Overrides"])
+	for _, key := range []string{"password", "token", "roles", "note", "Hidden", "previous", "internal"} {
+		assert.NotContains(t, fields, key)
+	}
+
+	// Map keys are only stripped by name
+	assert.Equal(t, map[string]interface{}{"password": "secret", "source": "web"}, fields["metadata"])
+	data, err = redact.NewRedactor(redact.ForLogs, "password").Marshal(value)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"source": "web"}, decode(t, data)["metadata"])
+}
+
+func TestMarshalForResponseKeepsLogOnlyFields(t *testing.T) {
+	data, err := redact.MarshalForResponse(session{credentials: credentials{Password: "secret"}, Token: "jwt"})
+	require.NoError(t, err)
+
+	fields := decode(t, data)
+	assert.Equal(t, "jwt", fields["token"])
+	assert.NotContains(t, fields, "password")
+}
+
+func TestStripScalarsAndNil(t *testing.T) {
+	r := redact.NewRedactor(redact.ForLogs)
+	assert.Nil(t, r.Strip(nil))
+	assert.Nil(t, r.Strip((*session)(nil)))
+	assert.Equal(t, "value", r.Strip("value"))
+	assert.Equal(t, []byte("raw"), r.Strip([]byte("raw")))
+}
@@ -0,0 +1,223 @@
+package replay
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/resilience"
+)
+
+// Lane identifies where a message was read from
+type Lane int
+
+const (
+	LaneLive   Lane = iota // Messages produced after the consumer started
+	LaneReplay             // Historical messages read during catch-up replay
+)
+
+// String returns the lane name
+func (l Lane) String() string {
+	if l == LaneReplay {
+		return "replay"
+	}
+	return "live"
+}
+
+// Item is a message queued in a lane
+type Item struct {
+	Lane  Lane
+	Key   string // Ordering key, e.g. the aggregate ID; items without a key are not ordered
+	Value interface{}
+}
+
+// Stats holds the queue depths and emitted item counts of a merger
+type Stats struct {
+	LiveQueued   int   `json:"live_queued"`
+	ReplayQueued int   `json:"replay_queued"`
+	Live         int64 `json:"live"`
+	Replayed     int64 `json:"replayed"`
+	Promoted     int64 `json:"promoted"` // Replay items emitted ahead of the rate limit for a waiting live item
+}
+
+// replayLimiterKey is the limiter bucket shared by all replay items
+const replayLimiterKey = "replay"
+
+// Merger merges a live and a replay lane into one stream. Live items are emitted first; replay
+// items are rate limited so catch-up replay cannot starve live traffic. Items of the same key are
+// emitted in the order they were pushed to their lane, and a live item is held back while replay
+// items of its key are queued. Those replay items are then emitted right away, ignoring the rate
+// limit, so live traffic of a key waits on its own history only.
+type Merger struct {
+	mu       sync.Mutex
+	live     []*Item
+	replay   []*Item
+	pending  map[string]int // key -> queued replay items
+	capacity int
+	limiter  *resilience.KeyedLimiter
+	clock    clock.Clock
+	reserved bool      // A replay token is reserved for the head replay item
+	readyAt  time.Time // When the reserved token may be used
+	changed  chan struct{}
+	stats    Stats
+}
+
+// NewMerger creates a merger queueing up to capacity items per lane and emitting at most
+// ratePerSecond replay items with bursts of burst items. A rate of zero does not throttle
+// replay. A nil clock uses the system clock.
+func NewMerger(capacity int, ratePerSecond float64, burst int, clk clock.Clock) *Merger {
+	if capacity < 1 {
+		capacity = 1
+	}
+	m := &Merger{
+		pending:  make(map[string]int),
+		capacity: capacity,
+		clock:    clock.OrDefault(clk),
+		changed:  make(chan struct{}),
+	}
+	if ratePerSecond > 0 {
+		m.limiter = resilience.NewKeyedLimiter(ratePerSecond, burst, m.clock)
+	}
+	return m
+}
+
+// Push queues a value in a lane, blocking while the lane is full or until ctx is done
+func (m *Merger) Push(ctx context.Context, lane Lane, key string, value interface{}) error {
+	for {
+		m.mu.Lock()
+		queue := &m.live
+		if lane == LaneReplay {
+			queue = &m.replay
+		}
+		if len(*queue) < m.capacity {
+			*queue = append(*queue, &Item{Lane: lane, Key: key, Value: value})
+			if lane == LaneReplay && key != "" {
+				m.pending[key]++
+			}
+			m.signal()
+			m.mu.Unlock()
+			return nil
+		}
+		changed := m.changed
+		m.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Next returns the next item to handle, blocking until one is available or ctx is done
+func (m *Merger) Next(ctx context.Context) (*Item, error) {
+	for {
+		m.mu.Lock()
+		item, wait := m.next()
+		changed := m.changed
+		m.mu.Unlock()
+
+		if item != nil {
+			return item, nil
+		}
+
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = m.clock.After(wait)
+		}
+		select {
+		case <-changed:
+		case <-timer:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Stats returns the current merger statistics
+func (m *Merger) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.LiveQueued = len(m.live)
+	stats.ReplayQueued = len(m.replay)
+	return stats
+}
+
+// next removes and returns the next item to emit, or how long to wait for the replay rate
+// limit when none is ready. It must be called with the lock held.
+func (m *Merger) next() (*Item, time.Duration) {
+	// Live items in arrival order, unless their key still has history queued. Later live items
+	// of a blocked key are blocked too, which keeps the order within the lane.
+	var blocked string
+	for i, item := range m.live {
+		if item.Key == "" || m.pending[item.Key] == 0 {
+			m.live = removeAt(m.live, i)
+			m.stats.Live++
+			m.signal()
+			return item, 0
+		}
+		if blocked == "" {
+			blocked = item.Key
+		}
+	}
+
+	// Promote the history of the oldest waiting live item
+	if blocked != "" {
+		for i, item := range m.replay {
+			if item.Key == blocked {
+				m.replay = removeAt(m.replay, i)
+				m.release(item)
+				m.stats.Promoted++
+				return item, 0
+			}
+		}
+	}
+
+	if len(m.replay) == 0 {
+		return nil, 0
+	}
+
+	if m.limiter != nil {
+		now := m.clock.Now()
+		if !m.reserved {
+			m.readyAt = now.Add(m.limiter.Reserve(replayLimiterKey))
+			m.reserved = true
+		}
+		if now.Before(m.readyAt) {
+			return nil, m.readyAt.Sub(now)
+		}
+		m.reserved = false
+	}
+
+	item := m.replay[0]
+	m.replay = removeAt(m.replay, 0)
+	m.release(item)
+	return item, 0
+}
+
+// release accounts for a replay item leaving the queue. It must be called with the lock held.
+func (m *Merger) release(item *Item) {
+	if item.Key != "" {
+		if m.pending[item.Key]--; m.pending[item.Key] <= 0 {
+			delete(m.pending, item.Key)
+		}
+	}
+	m.stats.Replayed++
+	m.signal()
+}
+
+// signal wakes up callers waiting for a queue change. It must be called with the lock held.
+func (m *Merger) signal() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// removeAt removes the item at index i, keeping the order of the others
+func removeAt(items []*Item, i int) []*Item {
+	copy(items[i:], items[i+1:])
+	items[len(items)-1] = nil
+	return items[:len(items)-1]
+}
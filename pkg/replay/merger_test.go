@@ -0,0 +1,125 @@
+package replay_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/replay"
+)
+
+func push(t *testing.T, m *replay.Merger, lane replay.Lane, key string, value string) {
+	t.Helper()
+	require.NoError(t, m.Push(context.Background(), lane, key, value))
+}
+
+func next(t *testing.T, m *replay.Merger) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	item, err := m.Next(ctx)
+	require.NoError(t, err)
+	return item.Value.(string)
+}
+
+func TestMerger_LivePriority(t *testing.T) {
+	m := replay.NewMerger(10, 0, 0, nil)
+	push(t, m, replay.LaneReplay, "a", "replay-a1")
+	push(t, m, replay.LaneReplay, "b", "replay-b1")
+	push(t, m, replay.LaneLive, "c", "live-c1")
+
+	assert.Equal(t, "live-c1", next(t, m))
+	assert.Equal(t, "replay-a1", next(t, m))
+	assert.Equal(t, "replay-b1", next(t, m))
+
+	stats := m.Stats()
+	assert.Equal(t, int64(1), stats.Live)
+	assert.Equal(t, int64(2), stats.Replayed)
+	assert.Zero(t, stats.ReplayQueued)
+}
+
+func TestMerger_PerKeyOrdering(t *testing.T) {
+	m := replay.NewMerger(10, 0, 0, nil)
+	push(t, m, replay.LaneReplay, "a", "replay-a1")
+	push(t, m, replay.LaneReplay, "b", "replay-b1")
+	push(t, m, replay.LaneReplay, "a", "replay-a2")
+	push(t, m, replay.LaneLive, "a", "live-a3")
+	push(t, m, replay.LaneLive, "", "live-unkeyed")
+	push(t, m, replay.LaneLive, "a", "live-a4")
+
+	// Unkeyed live items are never held; the history of a is promoted ahead of b
+	assert.Equal(t, "live-unkeyed", next(t, m))
+	assert.Equal(t, "replay-a1", next(t, m))
+	assert.Equal(t, "replay-a2", next(t, m))
+	assert.Equal(t, "live-a3", next(t, m))
+	assert.Equal(t, "live-a4", next(t, m))
+	assert.Equal(t, "replay-b1", next(t, m))
+	assert.Equal(t, int64(2), m.Stats().Promoted)
+}
+
+func TestMerger_ThrottlesReplay(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	m := replay.NewMerger(10, 1, 1, fake)
+	push(t, m, replay.LaneReplay, "a", "replay-a1")
+	push(t, m, replay.LaneReplay, "b", "replay-b1")
+
+	assert.Equal(t, "replay-a1", next(t, m))
+
+	result := make(chan string, 1)
+	go func() {
+		item, err := m.Next(context.Background())
+		if err == nil {
+			result <- item.Value.(string)
+		}
+	}()
+
+	// Live items are not held back by the replay rate limit
+	push(t, m, replay.LaneLive, "c", "live-c1")
+	assert.Equal(t, "live-c1", <-result)
+
+	go func() {
+		item, err := m.Next(context.Background())
+		if err == nil {
+			result <- item.Value.(string)
+		}
+	}()
+	select {
+	case value := <-result:
+		t.Fatalf("replay item %s emitted before the rate limit allowed it", value)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	fake.Advance(time.Second)
+	select {
+	case value := <-result:
+		assert.Equal(t, "replay-b1", value)
+	case <-time.After(time.Second):
+		t.Fatal("replay item not emitted after the rate limit allowed it")
+	}
+}
+
+func TestMerger_PushBlocksWhenFull(t *testing.T) {
+	m := replay.NewMerger(1, 0, 0, nil)
+	push(t, m, replay.LaneReplay, "a", "replay-a1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, m.Push(ctx, replay.LaneReplay, "a", "replay-a2"), context.DeadlineExceeded)
+
+	// The live lane has its own capacity
+	push(t, m, replay.LaneLive, "b", "live-b1")
+}
+
+func TestMerger_NextHonorsContext(t *testing.T) {
+	m := replay.NewMerger(1, 0, 0, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := m.Next(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,111 @@
+package requestcost
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Logger logs the aggregated cost of requests and the requests likely issuing N+1 queries
+type Logger interface {
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+}
+
+// methodCost is the cost of the requests of a method since the aggregates were last logged
+type methodCost struct {
+	requests    int
+	duration    time.Duration
+	queries     int
+	maxQueries  int
+	queryTime   time.Duration
+	publishes   int
+	cacheHits   int
+	cacheMisses int
+}
+
+// Aggregator aggregates the cost of requests per method and logs the aggregates every interval,
+// so handlers whose queries grow with their results stand out
+type Aggregator struct {
+	interval       time.Duration
+	queryThreshold int
+	clock          clock.Clock
+	logger         Logger
+
+	mu      sync.Mutex
+	methods map[string]*methodCost
+	since   time.Time
+}
+
+// NewAggregator creates an aggregator logging the cost of each method every interval, and each
+// request running at least queryThreshold database queries (never when zero). A nil clock uses
+// the system clock.
+func NewAggregator(interval time.Duration, queryThreshold int, clk clock.Clock, logger Logger) *Aggregator {
+	clk = clock.OrDefault(clk)
+	return &Aggregator{
+		interval:       interval,
+		queryThreshold: queryThreshold,
+		clock:          clk,
+		logger:         logger,
+		methods:        make(map[string]*methodCost),
+		since:          clk.Now(),
+	}
+}
+
+// Record adds the cost of a request of method that took duration, then logs the aggregates when
+// interval elapsed since they were last logged
+func (a *Aggregator) Record(method string, cost Snapshot, duration time.Duration) {
+	if a.queryThreshold > 0 && cost.Queries >= a.queryThreshold {
+		a.logger.Warn("%s ran %d database queries in %s, check its handler for N+1 queries", method, cost.Queries, duration.Round(time.Microsecond))
+	}
+
+	a.mu.Lock()
+	aggregate, ok := a.methods[method]
+	if !ok {
+		aggregate = &methodCost{}
+		a.methods[method] = aggregate
+	}
+	aggregate.requests++
+	aggregate.duration += duration
+	aggregate.queries += cost.Queries
+	aggregate.maxQueries = max(aggregate.maxQueries, cost.Queries)
+	aggregate.queryTime += cost.QueryTime
+	aggregate.publishes += cost.Publishes
+	aggregate.cacheHits += cost.CacheHits
+	aggregate.cacheMisses += cost.CacheMisses
+	due := a.interval > 0 && a.clock.Since(a.since) >= a.interval
+	a.mu.Unlock()
+
+	if due {
+		a.Flush()
+	}
+}
+
+// Flush logs the aggregates of every method with requests since they were last logged and
+// resets them
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	methods := a.methods
+	elapsed := a.clock.Since(a.since)
+	a.methods = make(map[string]*methodCost)
+	a.since = a.clock.Now()
+	a.mu.Unlock()
+
+	names := make([]string, 0, len(methods))
+	for method := range methods {
+		names = append(names, method)
+	}
+	sort.Strings(names)
+	for _, method := range names {
+		aggregate := methods[method]
+		requests := float64(aggregate.requests)
+		a.logger.Info("Cost of %s over %s: %d requests, %.1f queries (max %d) and %s in the database per request, %.1f publishes per request, %d cache hits, %d misses, %s per request",
+			method, elapsed.Round(time.Second), aggregate.requests,
+			float64(aggregate.queries)/requests, aggregate.maxQueries, (aggregate.queryTime / time.Duration(aggregate.requests)).Round(time.Microsecond),
+			float64(aggregate.publishes)/requests,
+			aggregate.cacheHits, aggregate.cacheMisses,
+			(aggregate.duration / time.Duration(aggregate.requests)).Round(time.Microsecond))
+	}
+}
@@ -0,0 +1,110 @@
+package requestcost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Header returns the cost of a request to clients, see https://www.w3.org/TR/server-timing/
+const Header = "Server-Timing"
+
+// MetadataKey is the gRPC metadata key of Header
+const MetadataKey = "server-timing"
+
+// Cost counts the work done on behalf of a request: database queries, broker publishes and cache
+// lookups. It is safe for concurrent use by the goroutines serving the request.
+type Cost struct {
+	queries     atomic.Int64
+	queryTime   atomic.Int64 // Nanoseconds
+	publishes   atomic.Int64
+	publishTime atomic.Int64 // Nanoseconds
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
+}
+
+// costKey is the context key of the cost of the request in progress
+type costKey struct{}
+
+// WithCost returns a context accounting the cost of a request in the returned Cost
+func WithCost(ctx context.Context) (context.Context, *Cost) {
+	cost := &Cost{}
+	return context.WithValue(ctx, costKey{}, cost), cost
+}
+
+// FromContext returns the cost of the request ctx serves, nil outside of accounted requests
+func FromContext(ctx context.Context) *Cost {
+	cost, _ := ctx.Value(costKey{}).(*Cost)
+	return cost
+}
+
+// RecordQuery records a database query of the request ctx serves that took d
+func RecordQuery(ctx context.Context, d time.Duration) {
+	if cost := FromContext(ctx); cost != nil {
+		cost.queries.Add(1)
+		cost.queryTime.Add(int64(d))
+	}
+}
+
+// RecordPublish records a message the request ctx serves published to the broker in d
+func RecordPublish(ctx context.Context, d time.Duration) {
+	if cost := FromContext(ctx); cost != nil {
+		cost.publishes.Add(1)
+		cost.publishTime.Add(int64(d))
+	}
+}
+
+// RecordCacheHit records a lookup of the request ctx serves answered by a cache
+func RecordCacheHit(ctx context.Context) {
+	if cost := FromContext(ctx); cost != nil {
+		cost.cacheHits.Add(1)
+	}
+}
+
+// RecordCacheMiss records a lookup of the request ctx serves a cache could not answer
+func RecordCacheMiss(ctx context.Context) {
+	if cost := FromContext(ctx); cost != nil {
+		cost.cacheMisses.Add(1)
+	}
+}
+
+// Snapshot is the cost of a request at a point in time
+type Snapshot struct {
+	Queries     int
+	QueryTime   time.Duration
+	Publishes   int
+	PublishTime time.Duration
+	CacheHits   int
+	CacheMisses int
+}
+
+// Snapshot returns the cost recorded so far
+func (c *Cost) Snapshot() Snapshot {
+	return Snapshot{
+		Queries:     int(c.queries.Load()),
+		QueryTime:   time.Duration(c.queryTime.Load()),
+		Publishes:   int(c.publishes.Load()),
+		PublishTime: time.Duration(c.publishTime.Load()),
+		CacheHits:   int(c.cacheHits.Load()),
+		CacheMisses: int(c.cacheMisses.Load()),
+	}
+}
+
+// ServerTiming formats the cost as a Server-Timing header value, with total the time the request
+// took, e.g. `db;dur=12.5;desc="4 queries", broker;dur=3.1;desc="1 publishes", cache;desc="2 hits, 1 misses", total;dur=20.4`
+func (s Snapshot) ServerTiming(total time.Duration) string {
+	metrics := []string{
+		fmt.Sprintf(`db;dur=%s;desc="%d queries"`, milliseconds(s.QueryTime), s.Queries),
+		fmt.Sprintf(`broker;dur=%s;desc="%d publishes"`, milliseconds(s.PublishTime), s.Publishes),
+		fmt.Sprintf(`cache;desc="%d hits, %d misses"`, s.CacheHits, s.CacheMisses),
+		fmt.Sprintf("total;dur=%s", milliseconds(total)),
+	}
+	return strings.Join(metrics, ", ")
+}
+
+// milliseconds formats d in milliseconds, the unit of Server-Timing durations
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,83 @@
+package requestcost_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/requestcost"
+)
+
+func TestCost_RecordsOnlyAccountedRequests(t *testing.T) {
+	requestcost.RecordQuery(context.Background(), time.Millisecond)
+	assert.Nil(t, requestcost.FromContext(context.Background()))
+
+	ctx, cost := requestcost.WithCost(context.Background())
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			requestcost.RecordQuery(ctx, 2*time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	requestcost.RecordPublish(ctx, time.Millisecond)
+	requestcost.RecordCacheHit(ctx)
+	requestcost.RecordCacheMiss(ctx)
+
+	assert.Equal(t, requestcost.Snapshot{
+		Queries:     4,
+		QueryTime:   8 * time.Millisecond,
+		Publishes:   1,
+		PublishTime: time.Millisecond,
+		CacheHits:   1,
+		CacheMisses: 1,
+	}, cost.Snapshot())
+}
+
+func TestSnapshot_ServerTiming(t *testing.T) {
+	snapshot := requestcost.Snapshot{Queries: 3, QueryTime: 12500 * time.Microsecond, Publishes: 1, PublishTime: 3 * time.Millisecond, CacheHits: 2}
+	assert.Equal(t,
+		`db;dur=12.5;desc="3 queries", broker;dur=3.0;desc="1 publishes", cache;desc="2 hits, 0 misses", total;dur=20.0`,
+		snapshot.ServerTiming(20*time.Millisecond))
+}
+
+type capturingLogger struct {
+	infos, warnings []string
+}
+
+func (l *capturingLogger) Info(format string, v ...interface{}) {
+	l.infos = append(l.infos, fmt.Sprintf(format, v...))
+}
+
+func (l *capturingLogger) Warn(format string, v ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, v...))
+}
+
+func TestAggregator_LogsEveryInterval(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	logger := &capturingLogger{}
+	aggregator := requestcost.NewAggregator(time.Minute, 10, fake, logger)
+
+	aggregator.Record("/user.v2.UserService/ListUsers", requestcost.Snapshot{Queries: 2, QueryTime: 2 * time.Millisecond}, 5*time.Millisecond)
+	aggregator.Record("/user.v2.UserService/ListUsers", requestcost.Snapshot{Queries: 12, QueryTime: 6 * time.Millisecond}, 15*time.Millisecond)
+	assert.Empty(t, logger.infos, "aggregates are logged once the interval elapsed")
+	require.Len(t, logger.warnings, 1)
+	assert.Contains(t, logger.warnings[0], "ran 12 database queries")
+
+	fake.Advance(time.Minute)
+	aggregator.Record("/user.v2.UserService/GetUser", requestcost.Snapshot{Queries: 1, CacheMisses: 1}, time.Millisecond)
+	require.Len(t, logger.infos, 2)
+	assert.Contains(t, logger.infos[0], "GetUser over 1m0s: 1 requests, 1.0 queries (max 1)")
+	assert.Contains(t, logger.infos[1], "ListUsers over 1m0s: 2 requests, 7.0 queries (max 12) and 4ms in the database per request")
+
+	aggregator.Flush()
+	assert.Len(t, logger.infos, 2, "aggregates are reset once logged")
+}
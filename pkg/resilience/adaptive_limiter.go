@@ -0,0 +1,263 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// LimitAlgorithm computes the concurrency limit of an AdaptiveLimiter from the completed calls.
+// Algorithms are called with the limiter locked, so they may keep state without locking.
+type LimitAlgorithm interface {
+	// Update returns the limit after a call took rtt with inFlight calls in flight, including
+	// itself. Dropped calls timed out or were slow enough to signal an overloaded dependency.
+	Update(limit float64, rtt time.Duration, inFlight int, dropped bool) float64
+}
+
+// AIMDLimit increases the limit by one on every call made while the limit is in use, and
+// multiplies it by the backoff ratio on every dropped call, like TCP congestion control
+type AIMDLimit struct {
+	backoffRatio float64
+}
+
+// NewAIMDLimit creates an AIMD algorithm; a backoff ratio outside (0, 1) defaults to 0.9
+func NewAIMDLimit(backoffRatio float64) *AIMDLimit {
+	if backoffRatio <= 0 || backoffRatio >= 1 {
+		backoffRatio = 0.9
+	}
+	return &AIMDLimit{backoffRatio: backoffRatio}
+}
+
+// Update implements LimitAlgorithm
+func (a *AIMDLimit) Update(limit float64, rtt time.Duration, inFlight int, dropped bool) float64 {
+	if dropped {
+		return limit * a.backoffRatio
+	}
+	// Only grow a limit the load gets close to, or an idle dependency would get an unbounded one
+	if float64(inFlight)*2 >= limit {
+		return limit + 1
+	}
+	return limit
+}
+
+// vegasProbeInterval is the number of calls after which Vegas forgets the lowest latency, so
+// that it follows a dependency getting durably slower, e.g. after a failover
+const vegasProbeInterval = 1000
+
+// VegasLimit estimates the calls queued in the dependency from how much slower calls are than
+// the lowest latency observed, the latency without load, like TCP Vegas. The limit grows while
+// few calls are queued and shrinks once many are, before calls start to time out.
+type VegasLimit struct {
+	smoothing float64
+	noLoadRTT time.Duration // Lowest latency observed, 0 until the first call
+	samples   int           // Calls since the lowest latency was reset
+}
+
+// NewVegasLimit creates a Vegas algorithm moving the limit by a fraction of each computed
+// change; a smoothing outside (0, 1] defaults to 1, applying changes in full
+func NewVegasLimit(smoothing float64) *VegasLimit {
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 1
+	}
+	return &VegasLimit{smoothing: smoothing}
+}
+
+// Update implements LimitAlgorithm
+func (v *VegasLimit) Update(limit float64, rtt time.Duration, inFlight int, dropped bool) float64 {
+	if v.samples++; v.samples > vegasProbeInterval {
+		v.noLoadRTT, v.samples = 0, 0
+	}
+	if rtt > 0 && (v.noLoadRTT == 0 || rtt < v.noLoadRTT) {
+		v.noLoadRTT = rtt
+	}
+
+	// Changes are logarithmic in the limit, so that large limits move faster
+	step := math.Max(1, math.Log10(limit))
+	var target float64
+	switch {
+	case dropped:
+		target = limit - step
+	case float64(inFlight)*2 < limit || rtt <= 0:
+		return limit // Not enough load to tell
+	default:
+		queued := limit * (1 - float64(v.noLoadRTT)/float64(rtt))
+		alpha, beta := 3*step, 6*step
+		switch {
+		case queued <= step:
+			target = limit + beta
+		case queued < alpha:
+			target = limit + step
+		case queued > beta:
+			target = limit - step
+		default:
+			return limit
+		}
+	}
+	return (1-v.smoothing)*limit + v.smoothing*target
+}
+
+// AdaptiveLimiter bounds the calls in flight to a dependency, e.g. a database, with a limit
+// adjusted from the latency of the calls: it grows while the dependency keeps up and shrinks
+// when it slows down, so that excess calls fail fast instead of queueing in an overloaded
+// dependency until they time out.
+type AdaptiveLimiter struct {
+	mu sync.Mutex
+
+	// Configuration
+	minLimit         float64
+	maxLimit         float64
+	algorithm        LimitAlgorithm
+	latencyThreshold time.Duration // Duration from which a call counts as dropped, 0 disables
+	clock            clock.Clock
+	onLimitChange    func(limit int)
+
+	// State
+	limit    float64
+	inFlight int
+
+	// Metrics
+	accepted int64
+	rejected int64
+	dropped  int64
+}
+
+// AdaptiveLimiterConfig holds configuration for adaptive limiter
+type AdaptiveLimiterConfig struct {
+	InitialLimit int            `json:"initial_limit"`
+	MinLimit     int            `json:"min_limit"`
+	MaxLimit     int            `json:"max_limit"`
+	Algorithm    LimitAlgorithm `json:"-"` // Defaults to AIMD
+
+	// Calls taking at least this long count as dropped, like calls whose context deadline
+	// exceeded; 0 only counts the latter
+	LatencyThreshold time.Duration `json:"latency_threshold,omitempty"`
+
+	Clock         clock.Clock     `json:"-"` // Defaults to the system clock
+	OnLimitChange func(limit int) `json:"-"` // Called with the limiter locked when the limit changes
+}
+
+// DefaultAdaptiveLimiterConfig returns default configuration
+func DefaultAdaptiveLimiterConfig() AdaptiveLimiterConfig {
+	return AdaptiveLimiterConfig{
+		InitialLimit: 20,
+		MinLimit:     1,
+		MaxLimit:     200,
+	}
+}
+
+// NewAdaptiveLimiter creates a new adaptive limiter
+func NewAdaptiveLimiter(config AdaptiveLimiterConfig) *AdaptiveLimiter {
+	minLimit := max(config.MinLimit, 1)
+	maxLimit := max(config.MaxLimit, minLimit)
+	algorithm := config.Algorithm
+	if algorithm == nil {
+		algorithm = NewAIMDLimit(0)
+	}
+
+	return &AdaptiveLimiter{
+		minLimit:         float64(minLimit),
+		maxLimit:         float64(maxLimit),
+		algorithm:        algorithm,
+		latencyThreshold: config.LatencyThreshold,
+		clock:            clock.OrDefault(config.Clock),
+		onLimitChange:    config.OnLimitChange,
+		limit:            float64(min(max(config.InitialLimit, minLimit), maxLimit)),
+	}
+}
+
+// Execute runs a function unless the limit of calls in flight is reached
+func (l *AdaptiveLimiter) Execute(ctx context.Context, fn func() error) error {
+	if err := l.acquire(); err != nil {
+		return err
+	}
+
+	start := l.clock.Now()
+	err := fn()
+	l.release(err, l.clock.Since(start))
+	return err
+}
+
+// ExecuteWithResult runs a function that returns a result unless the limit of calls in flight is reached
+func (l *AdaptiveLimiter) ExecuteWithResult(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+	if err := l.acquire(); err != nil {
+		return nil, err
+	}
+
+	start := l.clock.Now()
+	result, err := fn()
+	l.release(err, l.clock.Since(start))
+	return result, err
+}
+
+// acquire takes a slot for a call, failing when the limit of calls in flight is reached
+func (l *AdaptiveLimiter) acquire() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= int(l.limit) {
+		l.rejected++
+		return fmt.Errorf("%d calls in flight: %w", l.inFlight, ErrLimitExceeded)
+	}
+	l.inFlight++
+	l.accepted++
+	return nil
+}
+
+// release frees the slot of a completed call and updates the limit from its latency
+func (l *AdaptiveLimiter) release(err error, rtt time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dropped := errors.Is(err, context.DeadlineExceeded) || (l.latencyThreshold > 0 && rtt >= l.latencyThreshold)
+	if dropped {
+		l.dropped++
+	}
+
+	previous := int(l.limit)
+	l.limit = math.Min(l.maxLimit, math.Max(l.minLimit, l.algorithm.Update(l.limit, rtt, l.inFlight, dropped)))
+	l.inFlight--
+
+	if current := int(l.limit); current != previous && l.onLimitChange != nil {
+		l.onLimitChange(current)
+	}
+}
+
+// Limit returns the current limit of calls in flight
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// GetStats returns adaptive limiter statistics
+func (l *AdaptiveLimiter) GetStats() AdaptiveLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return AdaptiveLimiterStats{
+		Limit:    int(l.limit),
+		InFlight: l.inFlight,
+		Accepted: l.accepted,
+		Rejected: l.rejected,
+		Dropped:  l.dropped,
+	}
+}
+
+// AdaptiveLimiterStats holds statistics for adaptive limiter
+type AdaptiveLimiterStats struct {
+	Limit    int   `json:"limit"`
+	InFlight int   `json:"in_flight"`
+	Accepted int64 `json:"accepted"`
+	Rejected int64 `json:"rejected"` // Calls failed fast at the limit
+	Dropped  int64 `json:"dropped"`  // Calls timed out or slower than the latency threshold
+}
+
+// Errors
+var (
+	ErrLimitExceeded = fmt.Errorf("concurrency limit exceeded")
+)
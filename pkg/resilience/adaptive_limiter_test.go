@@ -0,0 +1,100 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+func TestAdaptiveLimiter_RejectsCallsPastTheLimit(t *testing.T) {
+	limiter := NewAdaptiveLimiter(AdaptiveLimiterConfig{InitialLimit: 2, MinLimit: 1, MaxLimit: 2})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- limiter.Execute(context.Background(), func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+	}
+	<-started
+	<-started
+
+	called := false
+	err := limiter.Execute(context.Background(), func() error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.False(t, called)
+
+	close(release)
+	require.NoError(t, <-done)
+	require.NoError(t, <-done)
+	assert.NoError(t, limiter.Execute(context.Background(), func() error { return nil }))
+
+	stats := limiter.GetStats()
+	assert.Equal(t, int64(3), stats.Accepted)
+	assert.Equal(t, int64(1), stats.Rejected)
+	assert.Equal(t, 0, stats.InFlight)
+}
+
+func TestAdaptiveLimiter_AIMD(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var changes []int
+	limiter := NewAdaptiveLimiter(AdaptiveLimiterConfig{
+		InitialLimit:     10,
+		MinLimit:         5,
+		MaxLimit:         20,
+		Algorithm:        NewAIMDLimit(0.5),
+		LatencyThreshold: time.Second,
+		Clock:            fake,
+		OnLimitChange:    func(limit int) { changes = append(changes, limit) },
+	})
+
+	// An idle dependency does not grow the limit
+	require.NoError(t, limiter.Execute(context.Background(), func() error { return nil }))
+	assert.Equal(t, 10, limiter.Limit())
+
+	// Slow calls and timeouts halve it, down to the minimum
+	limiter.Execute(context.Background(), func() error {
+		fake.Advance(time.Second)
+		return nil
+	})
+	assert.Equal(t, 5, limiter.Limit())
+	err := limiter.Execute(context.Background(), func() error { return context.DeadlineExceeded })
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 5, limiter.Limit())
+	assert.Equal(t, int64(2), limiter.GetStats().Dropped)
+	assert.Equal(t, []int{5}, changes)
+
+	// Fast calls using the limit grow it one at a time
+	assert.Equal(t, 6.0, NewAIMDLimit(0).Update(5, time.Millisecond, 3, false))
+}
+
+func TestVegasLimit(t *testing.T) {
+	vegas := NewVegasLimit(0)
+
+	// Latencies at the lowest seen leave no queue, the limit grows
+	limit := vegas.Update(100, 10*time.Millisecond, 60, false)
+	assert.Greater(t, limit, 100.0)
+
+	// Latencies doubling mean half the calls are queued, the limit shrinks
+	assert.Less(t, vegas.Update(100, 20*time.Millisecond, 60, false), 100.0)
+
+	// Without enough load the latency tells nothing
+	assert.Equal(t, 100.0, vegas.Update(100, 20*time.Millisecond, 10, false))
+
+	// Dropped calls shrink the limit
+	assert.Less(t, vegas.Update(100, 10*time.Millisecond, 60, true), 100.0)
+}
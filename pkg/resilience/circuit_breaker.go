@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
 )
 
 // CircuitState represents the state of a circuit breaker
@@ -29,14 +31,30 @@ func (s CircuitState) String() string {
 	}
 }
 
-// CircuitBreaker implements the circuit breaker pattern
+// StateChangeListener is notified of the state changes of a circuit breaker, e.g. to log them or
+// export them as metrics. Listeners are called with the breaker locked and must not call it.
+type StateChangeListener func(from, to CircuitState)
+
+// CircuitBreaker implements the circuit breaker pattern. By default it opens after a number of
+// consecutive failures; with a sliding window it opens instead when the rate of failed or slow
+// calls among the most recent ones reaches a threshold.
 type CircuitBreaker struct {
 	mu sync.RWMutex
 
 	// Configuration
-	failureThreshold int           // Number of failures before opening circuit
-	timeout          time.Duration // Time to wait before trying half-open
-	successThreshold int           // Number of successes to close circuit
+	failureThreshold      int           // Number of failures before opening circuit
+	timeout               time.Duration // Time to wait before trying half-open
+	successThreshold      int           // Number of successes to close circuit
+	minimumCalls          int           // Calls in the window before its rates are evaluated
+	failureRateThreshold  float64       // Percentage of failed calls opening the circuit, 0 disables
+	slowCallRateThreshold float64       // Percentage of slow calls opening the circuit, 0 disables
+	slowCallDuration      time.Duration // Duration from which a call is slow, 0 disables
+	maxHalfOpenCalls      int           // Trial calls allowed at once in half-open state, 0 for unlimited
+	clock                 clock.Clock
+	listeners             []StateChangeListener
+
+	// Outcomes of the most recent calls, nil when counting consecutive failures
+	window *slidingWindow
 
 	// State
 	state       CircuitState
@@ -45,6 +63,11 @@ type CircuitBreaker struct {
 	lastFailure time.Time
 	lastSuccess time.Time
 
+	// Half-open trial calls in flight, counted for the generation of the state admitting them.
+	// The generation changes with the state, so trials ending after it are not counted.
+	halfOpenCalls int
+	generation    uint64
+
 	// Metrics
 	totalRequests   int64
 	totalFailures   int64
@@ -57,6 +80,23 @@ type CircuitBreakerConfig struct {
 	FailureThreshold int           `json:"failure_threshold"`
 	Timeout          time.Duration `json:"timeout"`
 	SuccessThreshold int           `json:"success_threshold"`
+	Clock            clock.Clock   `json:"-"` // Defaults to the system clock
+
+	// Trial calls let through at once in half-open state, the others failing fast as if the
+	// circuit was open. 0 lets every call through.
+	MaxHalfOpenCalls int `json:"max_half_open_calls,omitempty"`
+
+	// Called on every state change, more listeners can be added with OnStateChange
+	OnStateChange StateChangeListener `json:"-"`
+
+	// Sliding window of the last WindowSize calls, replacing FailureThreshold when set. The
+	// circuit opens once the window holds MinimumCalls calls (defaults to WindowSize) and the
+	// percentage of failed or slow calls reaches its threshold; a zero threshold is ignored.
+	WindowSize            int           `json:"window_size,omitempty"`
+	MinimumCalls          int           `json:"minimum_calls,omitempty"`
+	FailureRateThreshold  float64       `json:"failure_rate_threshold,omitempty"`
+	SlowCallRateThreshold float64       `json:"slow_call_rate_threshold,omitempty"`
+	SlowCallDuration      time.Duration `json:"slow_call_duration,omitempty"` // Calls taking at least this long are slow
 }
 
 // DefaultCircuitBreakerConfig returns default configuration
@@ -70,39 +110,70 @@ func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
 
 // NewCircuitBreaker creates a new circuit breaker
 func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
-	return &CircuitBreaker{
-		failureThreshold: config.FailureThreshold,
-		timeout:          config.Timeout,
-		successThreshold: config.SuccessThreshold,
-		state:            StateClosed,
-		lastStateChange:  time.Now(),
+	clk := clock.OrDefault(config.Clock)
+	cb := &CircuitBreaker{
+		failureThreshold:      config.FailureThreshold,
+		timeout:               config.Timeout,
+		successThreshold:      config.SuccessThreshold,
+		failureRateThreshold:  config.FailureRateThreshold,
+		slowCallRateThreshold: config.SlowCallRateThreshold,
+		slowCallDuration:      config.SlowCallDuration,
+		maxHalfOpenCalls:      config.MaxHalfOpenCalls,
+		clock:                 clk,
+		state:                 StateClosed,
+		lastStateChange:       clk.Now(),
+	}
+
+	if config.WindowSize > 0 {
+		cb.window = newSlidingWindow(config.WindowSize)
+		cb.minimumCalls = config.MinimumCalls
+		if cb.minimumCalls <= 0 || cb.minimumCalls > config.WindowSize {
+			cb.minimumCalls = config.WindowSize
+		}
+	}
+	if config.OnStateChange != nil {
+		cb.listeners = append(cb.listeners, config.OnStateChange)
 	}
+
+	return cb
+}
+
+// OnStateChange adds a listener notified of the state changes of the circuit breaker
+func (cb *CircuitBreaker) OnStateChange(listener StateChangeListener) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.listeners = append(cb.listeners, listener)
 }
 
 // Execute runs a function with circuit breaker protection
 func (cb *CircuitBreaker) Execute(ctx context.Context, fn func() error) error {
-	if err := cb.beforeExecution(); err != nil {
+	probe, err := cb.beforeExecution()
+	if err != nil {
 		return err
 	}
 
-	err := fn()
-	cb.afterExecution(err)
+	start := cb.clock.Now()
+	err = fn()
+	cb.afterExecution(probe, err, cb.clock.Since(start))
 	return err
 }
 
 // ExecuteWithResult runs a function that returns a result with circuit breaker protection
 func (cb *CircuitBreaker) ExecuteWithResult(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
-	if err := cb.beforeExecution(); err != nil {
+	probe, err := cb.beforeExecution()
+	if err != nil {
 		return nil, err
 	}
 
+	start := cb.clock.Now()
 	result, err := fn()
-	cb.afterExecution(err)
+	cb.afterExecution(probe, err, cb.clock.Since(start))
 	return result, err
 }
 
-// beforeExecution checks if circuit breaker allows execution
-func (cb *CircuitBreaker) beforeExecution() error {
+// beforeExecution checks if circuit breaker allows execution. Calls let through as half-open
+// trials get the generation of the state admitting them as probe, other calls 0.
+func (cb *CircuitBreaker) beforeExecution() (probe uint64, err error) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
@@ -110,55 +181,113 @@ func (cb *CircuitBreaker) beforeExecution() error {
 
 	switch cb.state {
 	case StateClosed:
-		return nil // Allow execution
+		return 0, nil // Allow execution
 
 	case StateOpen:
-		if time.Since(cb.lastFailure) >= cb.timeout {
+		if cb.clock.Since(cb.lastFailure) >= cb.timeout {
 			// Timeout reached, try half-open
-			cb.state = StateHalfOpen
-			cb.lastStateChange = time.Now()
+			cb.setState(StateHalfOpen)
 			cb.successes = 0
-			return nil
+			return cb.admitProbe()
 		}
-		return fmt.Errorf("circuit breaker is OPEN: %w", ErrCircuitOpen)
+		return 0, fmt.Errorf("circuit breaker is OPEN: %w", ErrCircuitOpen)
 
 	case StateHalfOpen:
-		return nil // Allow execution to test if service is back
+		return cb.admitProbe() // Allow execution to test if service is back
 
 	default:
-		return fmt.Errorf("unknown circuit breaker state: %v", cb.state)
+		return 0, fmt.Errorf("unknown circuit breaker state: %v", cb.state)
 	}
 }
 
-// afterExecution updates circuit breaker state based on execution result
-func (cb *CircuitBreaker) afterExecution(err error) {
+// admitProbe lets a trial call through in half-open state unless the maximum of trial calls
+// is in flight
+func (cb *CircuitBreaker) admitProbe() (uint64, error) {
+	if cb.maxHalfOpenCalls > 0 && cb.halfOpenCalls >= cb.maxHalfOpenCalls {
+		return 0, fmt.Errorf("circuit breaker is HALF_OPEN with %d trial calls in flight: %w", cb.halfOpenCalls, ErrCircuitOpen)
+	}
+	cb.halfOpenCalls++
+	return cb.generation, nil
+}
+
+// setState changes the state of the circuit breaker and notifies the listeners
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	from := cb.state
+	cb.state = state
+	cb.lastStateChange = cb.clock.Now()
+	cb.halfOpenCalls = 0
+	cb.generation++
+
+	if from != state {
+		for _, listener := range cb.listeners {
+			listener(from, state)
+		}
+	}
+}
+
+// afterExecution updates circuit breaker state based on execution result and duration
+func (cb *CircuitBreaker) afterExecution(probe uint64, err error, duration time.Duration) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	if probe != 0 && probe == cb.generation {
+		cb.halfOpenCalls--
+	}
+
+	slow := cb.slowCallDuration > 0 && duration >= cb.slowCallDuration
+	if cb.window != nil && cb.state == StateClosed {
+		cb.window.record(callOutcome{failed: err != nil, slow: slow})
+	}
+
 	if err != nil {
 		cb.recordFailure()
+	} else if slow && cb.window != nil && cb.state == StateHalfOpen {
+		// A dependency still slow to answer is not back yet
+		cb.open()
 	} else {
 		cb.recordSuccess()
 	}
 }
 
+// open opens the circuit
+func (cb *CircuitBreaker) open() {
+	cb.setState(StateOpen)
+	if cb.window != nil {
+		// Rates restart from the calls made once the circuit closes again
+		cb.window.reset()
+	}
+}
+
+// windowTripped reports whether the rates of the sliding window reached their thresholds
+func (cb *CircuitBreaker) windowTripped() bool {
+	if cb.window.count < cb.minimumCalls {
+		return false
+	}
+	if cb.failureRateThreshold > 0 && cb.window.failureRate() >= cb.failureRateThreshold {
+		return true
+	}
+	return cb.slowCallRateThreshold > 0 && cb.window.slowCallRate() >= cb.slowCallRateThreshold
+}
+
 // recordFailure handles failure and updates circuit breaker state
 func (cb *CircuitBreaker) recordFailure() {
 	cb.totalFailures++
-	cb.lastFailure = time.Now()
+	cb.lastFailure = cb.clock.Now()
 
 	switch cb.state {
 	case StateClosed:
 		cb.failures++
-		if cb.failures >= cb.failureThreshold {
-			cb.state = StateOpen
-			cb.lastStateChange = time.Now()
+		if cb.window != nil {
+			if cb.windowTripped() {
+				cb.open()
+			}
+		} else if cb.failures >= cb.failureThreshold {
+			cb.open()
 		}
 
 	case StateHalfOpen:
 		// Any failure in half-open state opens the circuit
-		cb.state = StateOpen
-		cb.lastStateChange = time.Now()
+		cb.open()
 		cb.failures = cb.failureThreshold // Ensure it stays open
 	}
 }
@@ -166,19 +295,22 @@ func (cb *CircuitBreaker) recordFailure() {
 // recordSuccess handles success and updates circuit breaker state
 func (cb *CircuitBreaker) recordSuccess() {
 	cb.totalSuccesses++
-	cb.lastSuccess = time.Now()
+	cb.lastSuccess = cb.clock.Now()
 
 	switch cb.state {
 	case StateClosed:
 		// Reset failure count on success
 		cb.failures = 0
+		if cb.window != nil && cb.windowTripped() {
+			// Successful but slow calls open the circuit too
+			cb.open()
+		}
 
 	case StateHalfOpen:
 		cb.successes++
 		if cb.successes >= cb.successThreshold {
 			// Enough successes, close the circuit
-			cb.state = StateClosed
-			cb.lastStateChange = time.Now()
+			cb.setState(StateClosed)
 			cb.failures = 0
 			cb.successes = 0
 		}
@@ -197,7 +329,7 @@ func (cb *CircuitBreaker) GetStats() CircuitBreakerStats {
 	cb.mu.RLock()
 	defer cb.mu.RUnlock()
 
-	return CircuitBreakerStats{
+	stats := CircuitBreakerStats{
 		State:            cb.state,
 		Failures:         cb.failures,
 		Successes:        cb.successes,
@@ -210,7 +342,14 @@ func (cb *CircuitBreaker) GetStats() CircuitBreakerStats {
 		FailureThreshold: cb.failureThreshold,
 		SuccessThreshold: cb.successThreshold,
 		Timeout:          cb.timeout,
+		HalfOpenCalls:    cb.halfOpenCalls,
 	}
+	if cb.window != nil {
+		stats.WindowCalls = cb.window.count
+		stats.FailureRate = cb.window.failureRate()
+		stats.SlowCallRate = cb.window.slowCallRate()
+	}
+	return stats
 }
 
 // CircuitBreakerStats holds statistics for circuit breaker
@@ -227,24 +366,31 @@ type CircuitBreakerStats struct {
 	FailureThreshold int           `json:"failure_threshold"`
 	SuccessThreshold int           `json:"success_threshold"`
 	Timeout          time.Duration `json:"timeout"`
+	HalfOpenCalls    int           `json:"half_open_calls,omitempty"` // Trial calls in flight in half-open state
+
+	// Sliding window statistics, zero without a window
+	WindowCalls  int     `json:"window_calls,omitempty"`
+	FailureRate  float64 `json:"failure_rate,omitempty"`   // Percentage of failed calls in the window
+	SlowCallRate float64 `json:"slow_call_rate,omitempty"` // Percentage of slow calls in the window
 }
 
 // ForceOpen forces the circuit breaker to open state
 func (cb *CircuitBreaker) ForceOpen() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	cb.state = StateOpen
-	cb.lastStateChange = time.Now()
+	cb.setState(StateOpen)
 }
 
 // ForceClose forces the circuit breaker to closed state
 func (cb *CircuitBreaker) ForceClose() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	cb.state = StateClosed
-	cb.lastStateChange = time.Now()
+	cb.setState(StateClosed)
 	cb.failures = 0
 	cb.successes = 0
+	if cb.window != nil {
+		cb.window.reset()
+	}
 }
 
 // Reset resets all circuit breaker statistics
@@ -258,7 +404,10 @@ func (cb *CircuitBreaker) Reset() {
 	cb.totalSuccesses = 0
 	cb.lastFailure = time.Time{}
 	cb.lastSuccess = time.Time{}
-	cb.lastStateChange = time.Now()
+	cb.lastStateChange = cb.clock.Now()
+	if cb.window != nil {
+		cb.window.reset()
+	}
 }
 
 // Errors
@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/health"
 )
 
 func TestNewCircuitBreaker(t *testing.T) {
@@ -174,6 +177,34 @@ func TestCircuitBreaker_ForceOpen(t *testing.T) {
 	assert.Equal(t, StateOpen, cb.GetState())
 }
 
+func TestCircuitBreaker_Execute_HalfOpen_FakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		Timeout:          time.Minute,
+		SuccessThreshold: 1,
+		Clock:            fake,
+	})
+
+	// Open the circuit
+	cb.Execute(context.Background(), func() error {
+		return errors.New("test error")
+	})
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	// Still open before the timeout elapses
+	fake.Advance(59 * time.Second)
+	err := cb.Execute(context.Background(), func() error { return nil })
+	assert.Error(t, err)
+
+	// Half-open after the timeout, a success closes the circuit
+	fake.Advance(time.Second)
+	err = cb.Execute(context.Background(), func() error { return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, StateClosed, cb.GetState())
+	assert.Equal(t, fake.Now(), cb.GetStats().LastStateChange)
+}
+
 func TestCircuitBreaker_ForceClose(t *testing.T) {
 	cb := NewCircuitBreaker(CircuitBreakerConfig{
 		FailureThreshold: 1,
@@ -213,3 +244,174 @@ func TestCircuitState_String(t *testing.T) {
 	assert.Equal(t, "OPEN", StateOpen.String())
 	assert.Equal(t, "HALF_OPEN", StateHalfOpen.String())
 }
+
+func TestCircuitBreaker_FailureRate(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		Timeout:              time.Minute,
+		SuccessThreshold:     1,
+		WindowSize:           4,
+		FailureRateThreshold: 50,
+		Clock:                clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+	})
+	fail := func() error { return errors.New("test error") }
+	succeed := func() error { return nil }
+
+	cb.Execute(context.Background(), fail)
+	cb.Execute(context.Background(), fail)
+	cb.Execute(context.Background(), succeed)
+	assert.Equal(t, StateClosed, cb.GetState(), "rates are not evaluated before the window holds the minimum calls")
+	assert.InDelta(t, 66.7, cb.GetStats().FailureRate, 0.1)
+
+	cb.Execute(context.Background(), succeed)
+	assert.Equal(t, StateOpen, cb.GetState(), "every call evaluates the full window")
+
+	cb.ForceClose()
+	for _, fn := range []func() error{succeed, fail, succeed, succeed, fail, succeed, fail} {
+		cb.Execute(context.Background(), fn)
+		if cb.GetState() == StateOpen {
+			break
+		}
+	}
+	assert.Equal(t, StateOpen, cb.GetState(), "consecutive failures do not matter, the rate among the last calls does")
+	assert.Equal(t, 0, cb.GetStats().WindowCalls, "the window restarts when the circuit opens")
+}
+
+func TestCircuitBreaker_SlowCallRate(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold:      1,
+		Timeout:               time.Minute,
+		SuccessThreshold:      1,
+		WindowSize:            10,
+		MinimumCalls:          4,
+		SlowCallRateThreshold: 50,
+		SlowCallDuration:      time.Second,
+		Clock:                 fake,
+	})
+	fast := func() error { return nil }
+	slow := func() error {
+		fake.Advance(2 * time.Second)
+		return nil
+	}
+
+	cb.Execute(context.Background(), slow)
+	cb.Execute(context.Background(), fast)
+	cb.Execute(context.Background(), fast)
+	assert.Equal(t, StateClosed, cb.GetState())
+	assert.InDelta(t, 33.3, cb.GetStats().SlowCallRate, 0.1)
+
+	assert.NoError(t, cb.Execute(context.Background(), slow), "slow calls still return their result")
+	assert.Equal(t, StateOpen, cb.GetState(), "successful calls open the circuit when too many are slow")
+
+	// A slow call while half-open opens the circuit again
+	fake.Advance(time.Minute)
+	cb.Execute(context.Background(), slow)
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	fake.Advance(time.Minute)
+	cb.Execute(context.Background(), fast)
+	assert.Equal(t, StateClosed, cb.GetState())
+}
+
+func TestSlidingWindow_EvictsOldestCalls(t *testing.T) {
+	window := newSlidingWindow(2)
+
+	window.record(callOutcome{failed: true, slow: true})
+	window.record(callOutcome{failed: true})
+	assert.Equal(t, 100.0, window.failureRate())
+	assert.Equal(t, 50.0, window.slowCallRate())
+
+	window.record(callOutcome{})
+	assert.Equal(t, 2, window.count)
+	assert.Equal(t, 50.0, window.failureRate())
+	assert.Equal(t, 0.0, window.slowCallRate())
+}
+
+func TestCircuitBreaker_LimitsHalfOpenCalls(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		Timeout:          time.Minute,
+		SuccessThreshold: 2,
+		MaxHalfOpenCalls: 1,
+		Clock:            fake,
+	})
+
+	cb.Execute(context.Background(), func() error { return errors.New("test error") })
+	fake.Advance(time.Minute)
+
+	// The trial call in flight makes concurrent calls fail fast
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- cb.Execute(context.Background(), func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	assert.Equal(t, StateHalfOpen, cb.GetState())
+	assert.Equal(t, 1, cb.GetStats().HalfOpenCalls)
+
+	called := false
+	err := cb.Execute(context.Background(), func() error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.False(t, called)
+
+	// Once the trial ends another one is let through
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, 0, cb.GetStats().HalfOpenCalls)
+	assert.NoError(t, cb.Execute(context.Background(), func() error { return nil }))
+	assert.Equal(t, StateClosed, cb.GetState())
+}
+
+func TestCircuitBreaker_StateChangeListeners(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var transitions []string
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		Timeout:          time.Minute,
+		SuccessThreshold: 1,
+		Clock:            fake,
+		OnStateChange: func(from, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+
+	registry := NewCircuitBreakerRegistry()
+	var named []string
+	registry.OnStateChange(func(name string, from, to CircuitState) {
+		named = append(named, name+":"+to.String())
+	})
+	registry.Register("store", cb)
+
+	cb.Execute(context.Background(), func() error { return errors.New("test error") })
+	fake.Advance(time.Minute)
+	cb.Execute(context.Background(), func() error { return nil })
+	cb.ForceClose() // Already closed, not a state change
+
+	assert.Equal(t, []string{"CLOSED->OPEN", "OPEN->HALF_OPEN", "HALF_OPEN->CLOSED"}, transitions)
+	assert.Equal(t, []string{"store:OPEN", "store:HALF_OPEN", "store:CLOSED"}, named)
+}
+
+func TestCircuitBreakerRegistry_HealthCheck(t *testing.T) {
+	registry := NewCircuitBreakerRegistry()
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Timeout: time.Minute, SuccessThreshold: 1})
+	registry.Register("store", cb)
+	registry.Register("broker", NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Timeout: time.Minute, SuccessThreshold: 1}))
+
+	check := registry.HealthCheck()(context.Background())
+	assert.Equal(t, health.StatusHealthy, check.Status)
+	assert.Equal(t, map[string]interface{}{"store": "CLOSED", "broker": "CLOSED"}, check.Details)
+
+	cb.ForceOpen()
+	check = registry.HealthCheck()(context.Background())
+	assert.Equal(t, health.StatusDegraded, check.Status)
+	assert.Equal(t, "circuit breakers not closed: [store]", check.Message)
+}
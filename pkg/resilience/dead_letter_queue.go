@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/id"
 )
 
 // FailedEvent represents a failed event in the dead letter queue
@@ -27,14 +30,22 @@ type DeadLetterQueue struct {
 	mu sync.RWMutex
 
 	// Configuration
-	maxSize      int
-	maxAttempts  int
-	retryDelay   time.Duration
-	storage      DLQStorage
-	retryHandler RetryHandler
+	maxSize       int
+	maxAttempts   int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	scanInterval  time.Duration
+	storage       DLQStorage
+	retryHandler  RetryHandler
+	clock         clock.Clock
+	ids           *id.Generator
 
 	// In-memory storage (fallback)
 	events []*FailedEvent
+
+	// Background retries, nil when not started
+	stopScheduler context.CancelFunc
+	schedulerDone chan struct{}
 }
 
 // DLQStorage interface for persistent storage
@@ -55,64 +66,72 @@ type RetryHandler interface {
 type DeadLetterQueueConfig struct {
 	MaxSize     int           `json:"max_size"`
 	MaxAttempts int           `json:"max_attempts"`
-	RetryDelay  time.Duration `json:"retry_delay"`
+	RetryDelay  time.Duration `json:"retry_delay"` // Delay before the first background retry, doubled for every further one
+	Clock       clock.Clock   `json:"-"`           // Defaults to the system clock
+
+	MaxRetryDelay time.Duration `json:"max_retry_delay,omitempty"` // Longest delay between background retries, 0 for no limit
+	ScanInterval  time.Duration `json:"scan_interval,omitempty"`   // How often Start looks for events due for a retry
 }
 
 // DefaultDeadLetterQueueConfig returns default configuration
 func DefaultDeadLetterQueueConfig() DeadLetterQueueConfig {
 	return DeadLetterQueueConfig{
-		MaxSize:     1000,
-		MaxAttempts: 3,
-		RetryDelay:  5 * time.Minute,
+		MaxSize:       1000,
+		MaxAttempts:   3,
+		RetryDelay:    5 * time.Minute,
+		MaxRetryDelay: time.Hour,
+		ScanInterval:  time.Minute,
 	}
 }
 
 // NewDeadLetterQueue creates a new dead letter queue
 func NewDeadLetterQueue(config DeadLetterQueueConfig, storage DLQStorage, retryHandler RetryHandler) *DeadLetterQueue {
+	clk := clock.OrDefault(config.Clock)
 	return &DeadLetterQueue{
-		maxSize:      config.MaxSize,
-		maxAttempts:  config.MaxAttempts,
-		retryDelay:   config.RetryDelay,
-		storage:      storage,
-		retryHandler: retryHandler,
-		events:       make([]*FailedEvent, 0),
+		maxSize:       config.MaxSize,
+		maxAttempts:   config.MaxAttempts,
+		retryDelay:    config.RetryDelay,
+		maxRetryDelay: config.MaxRetryDelay,
+		scanInterval:  config.ScanInterval,
+		storage:       storage,
+		retryHandler:  retryHandler,
+		clock:         clk,
+		ids:           id.NewGenerator(clk),
+		events:        make([]*FailedEvent, 0),
 	}
 }
 
-// AddEvent adds a failed event to the dead letter queue
-func (dlq *DeadLetterQueue) AddEvent(ctx context.Context, eventType string, eventData map[string]interface{}, err error, metadata map[string]string) error {
+// SetRetryHandler sets the handler that retries events, replacing the one given at construction
+func (dlq *DeadLetterQueue) SetRetryHandler(retryHandler RetryHandler) {
 	dlq.mu.Lock()
 	defer dlq.mu.Unlock()
 
-	// Check if queue is full
-	if len(dlq.events) >= dlq.maxSize {
-		return fmt.Errorf("dead letter queue is full (max size: %d)", dlq.maxSize)
-	}
+	dlq.retryHandler = retryHandler
+}
+
+// SetStorage sets the persistent storage of events, replacing the one given at construction.
+// Events already kept in memory stay there.
+func (dlq *DeadLetterQueue) SetStorage(storage DLQStorage) {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	dlq.storage = storage
+}
 
+// AddEvent adds a failed event to the dead letter queue
+func (dlq *DeadLetterQueue) AddEvent(ctx context.Context, eventType string, eventData map[string]interface{}, err error, metadata map[string]string) error {
 	failedEvent := &FailedEvent{
-		ID:          generateEventID(),
+		ID:          dlq.generateEventID(),
 		EventType:   eventType,
 		EventData:   eventData,
 		Error:       err.Error(),
-		Timestamp:   time.Now(),
+		Timestamp:   dlq.clock.Now(),
 		Attempts:    0,
 		MaxAttempts: dlq.maxAttempts,
 		Metadata:    metadata,
 	}
 
-	// Try to store in persistent storage first
-	if dlq.storage != nil {
-		if storeErr := dlq.storage.Store(ctx, failedEvent); storeErr != nil {
-			// Fallback to in-memory storage
-			dlq.events = append(dlq.events, failedEvent)
-			return fmt.Errorf("failed to store in persistent storage: %w, stored in memory", storeErr)
-		}
-	} else {
-		// Use in-memory storage
-		dlq.events = append(dlq.events, failedEvent)
-	}
-
-	return nil
+	return dlq.add(ctx, failedEvent)
 }
 
 // AddKafkaEvent adds a failed Kafka event to the dead letter queue
@@ -123,11 +142,11 @@ func (dlq *DeadLetterQueue) AddKafkaEvent(ctx context.Context, eventType string,
 	}
 
 	failedEvent := &FailedEvent{
-		ID:          generateEventID(),
+		ID:          dlq.generateEventID(),
 		EventType:   eventType,
 		EventData:   eventData,
 		Error:       err.Error(),
-		Timestamp:   time.Now(),
+		Timestamp:   dlq.clock.Now(),
 		Attempts:    0,
 		MaxAttempts: dlq.maxAttempts,
 		Topic:       topic,
@@ -136,6 +155,11 @@ func (dlq *DeadLetterQueue) AddKafkaEvent(ctx context.Context, eventType string,
 		Metadata:    metadata,
 	}
 
+	return dlq.add(ctx, failedEvent)
+}
+
+// add stores a failed event, falling back to memory when persistent storage fails
+func (dlq *DeadLetterQueue) add(ctx context.Context, failedEvent *FailedEvent) error {
 	dlq.mu.Lock()
 	defer dlq.mu.Unlock()
 
@@ -194,7 +218,7 @@ func (dlq *DeadLetterQueue) RetryEvent(ctx context.Context, eventID string) erro
 		if retryErr := dlq.retryHandler.HandleRetry(ctx, event); retryErr != nil {
 			// Update error message
 			event.Error = retryErr.Error()
-			event.Timestamp = time.Now()
+			event.Timestamp = dlq.clock.Now()
 
 			// Update in storage
 			if dlq.storage != nil {
@@ -262,6 +286,32 @@ func (dlq *DeadLetterQueue) ListEvents(ctx context.Context, limit, offset int) (
 	return events, nil
 }
 
+// FindEvents returns a page of the failed events matching filter, and how many events match
+func (dlq *DeadLetterQueue) FindEvents(ctx context.Context, filter DLQFilter, limit, offset int) ([]*FailedEvent, int, error) {
+	matches := []*FailedEvent{}
+	total := 0
+	for page := 0; ; page += exportPageSize {
+		events, err := dlq.ListEvents(ctx, exportPageSize, page)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for _, event := range events {
+			if !filter.Matches(event) {
+				continue
+			}
+			if total >= offset && len(matches) < limit {
+				matches = append(matches, event)
+			}
+			total++
+		}
+
+		if len(events) < exportPageSize {
+			return matches, total, nil
+		}
+	}
+}
+
 // DeleteEvent removes a failed event from the queue
 func (dlq *DeadLetterQueue) DeleteEvent(ctx context.Context, eventID string) error {
 	dlq.mu.Lock()
@@ -349,17 +399,7 @@ func (dlq *DeadLetterQueue) removeEventByID(eventID string) bool {
 	return false
 }
 
-// generateEventID generates a unique event ID
-func generateEventID() string {
-	return fmt.Sprintf("dlq_%d_%s", time.Now().UnixNano(), generateRandomString(8))
-}
-
-// generateRandomString generates a random string of specified length
-func generateRandomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
+// generateEventID generates a unique, time ordered event ID
+func (dlq *DeadLetterQueue) generateEventID() string {
+	return "dlq_" + dlq.ids.ULID()
 }
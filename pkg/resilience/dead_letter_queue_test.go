@@ -0,0 +1,43 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+type failingRetryHandler struct{}
+
+func (failingRetryHandler) HandleRetry(ctx context.Context, event *FailedEvent) error {
+	return errors.New("still failing")
+}
+
+func TestDeadLetterQueue_UsesClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	config := DefaultDeadLetterQueueConfig()
+	config.Clock = fake
+	dlq := NewDeadLetterQueue(config, nil, failingRetryHandler{})
+
+	ctx := context.Background()
+	require.NoError(t, dlq.AddEvent(ctx, "user.created", nil, errors.New("boom"), nil))
+
+	events, err := dlq.ListEvents(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, fake.Now(), events[0].Timestamp)
+
+	// A failed retry records the time of the retry
+	fake.Advance(time.Minute)
+	assert.Error(t, dlq.RetryEvent(ctx, events[0].ID))
+
+	event, err := dlq.GetEvent(ctx, events[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, fake.Now(), event.Timestamp)
+	assert.Equal(t, 1, event.Attempts)
+}
@@ -0,0 +1,119 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DLQRetryResult summarizes a pass of background retries
+type DLQRetryResult struct {
+	Retried int `json:"retried"` // Events retried successfully and removed from the queue
+	Failed  int `json:"failed"`  // Events whose retry failed, retried again after a longer delay
+}
+
+// Start retries the events due for a retry every scan interval in the background, until Stop
+// is called or ctx is done. An event is due once the retry delay, doubled for each attempt made
+// and bounded by the max retry delay, elapsed since it failed; events that used up their
+// attempts are left for operators.
+func (dlq *DeadLetterQueue) Start(ctx context.Context) error {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	if dlq.scanInterval <= 0 {
+		return fmt.Errorf("dead letter queue scan interval must be positive")
+	}
+	if dlq.stopScheduler != nil {
+		return fmt.Errorf("dead letter queue retries already started")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	dlq.stopScheduler = cancel
+	dlq.schedulerDone = done
+
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-dlq.clock.After(dlq.scanInterval):
+				dlq.RetryDue(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the background retries and waits for a pass in progress to end
+func (dlq *DeadLetterQueue) Stop() {
+	dlq.mu.Lock()
+	stop, done := dlq.stopScheduler, dlq.schedulerDone
+	dlq.stopScheduler, dlq.schedulerDone = nil, nil
+	dlq.mu.Unlock()
+
+	if stop != nil {
+		stop()
+		<-done
+	}
+}
+
+// RetryDue retries the events due for a retry with the retry handler. Nothing is retried
+// without a retry handler, as the events would be dropped without being handled.
+func (dlq *DeadLetterQueue) RetryDue(ctx context.Context) DLQRetryResult {
+	var result DLQRetryResult
+
+	dlq.mu.RLock()
+	hasHandler := dlq.retryHandler != nil
+	dlq.mu.RUnlock()
+	if !hasHandler {
+		return result
+	}
+
+	// Collect the due events first, retried events leave the pages being listed
+	now := dlq.clock.Now()
+	var due []string
+	for offset := 0; ; offset += exportPageSize {
+		events, err := dlq.ListEvents(ctx, exportPageSize, offset)
+		if err != nil {
+			return result
+		}
+		for _, event := range events {
+			if event.Attempts < event.MaxAttempts && !now.Before(dlq.NextRetry(event)) {
+				due = append(due, event.ID)
+			}
+		}
+		if len(events) < exportPageSize {
+			break
+		}
+	}
+
+	for _, eventID := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := dlq.RetryEvent(ctx, eventID); err != nil {
+			result.Failed++
+		} else {
+			result.Retried++
+		}
+	}
+	return result
+}
+
+// NextRetry returns when an event is due for a background retry: the retry delay after it
+// failed, doubled for each attempt already made and bounded by the max retry delay
+func (dlq *DeadLetterQueue) NextRetry(event *FailedEvent) time.Time {
+	delay := dlq.retryDelay
+	for i := 0; i < event.Attempts; i++ {
+		if dlq.maxRetryDelay > 0 && delay >= dlq.maxRetryDelay {
+			break
+		}
+		delay *= 2
+	}
+	if dlq.maxRetryDelay > 0 && delay > dlq.maxRetryDelay {
+		delay = dlq.maxRetryDelay
+	}
+	return event.Timestamp.Add(delay)
+}
@@ -0,0 +1,117 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// flakyRetryHandler fails the retries of an event until it is told to succeed
+type flakyRetryHandler struct {
+	mu      sync.Mutex
+	fail    bool
+	retries int
+}
+
+func (h *flakyRetryHandler) HandleRetry(ctx context.Context, event *FailedEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retries++
+	if h.fail {
+		return errors.New("still failing")
+	}
+	return nil
+}
+
+func (h *flakyRetryHandler) setFail(fail bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fail = fail
+}
+
+func newSchedulerTestQueue(fake *clock.Fake, handler RetryHandler) *DeadLetterQueue {
+	return NewDeadLetterQueue(DeadLetterQueueConfig{
+		MaxSize:       10,
+		MaxAttempts:   4,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: 3 * time.Minute,
+		ScanInterval:  10 * time.Second,
+		Clock:         fake,
+	}, nil, handler)
+}
+
+func TestDeadLetterQueue_RetryDueBacksOff(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	handler := &flakyRetryHandler{fail: true}
+	dlq := newSchedulerTestQueue(fake, handler)
+
+	ctx := context.Background()
+	require.NoError(t, dlq.AddEvent(ctx, "user.created", nil, errors.New("boom"), nil))
+
+	assert.Equal(t, DLQRetryResult{}, dlq.RetryDue(ctx), "events are not retried before the retry delay")
+
+	// Delays double after each failed retry: 1m, 2m, then capped at 3m
+	for _, delay := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		fake.Advance(delay - time.Second)
+		assert.Equal(t, DLQRetryResult{}, dlq.RetryDue(ctx))
+		fake.Advance(time.Second)
+		assert.Equal(t, DLQRetryResult{Failed: 1}, dlq.RetryDue(ctx))
+	}
+
+	handler.setFail(false)
+	fake.Advance(3 * time.Minute)
+	assert.Equal(t, DLQRetryResult{Retried: 1}, dlq.RetryDue(ctx))
+	stats, err := dlq.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.TotalEvents)
+}
+
+func TestDeadLetterQueue_RetryDueSkipsExhaustedEvents(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	dlq := newSchedulerTestQueue(fake, failingRetryHandler{})
+
+	ctx := context.Background()
+	require.NoError(t, dlq.AddEvent(ctx, "user.created", nil, errors.New("boom"), nil))
+	for i := 0; i < 4; i++ {
+		fake.Advance(time.Hour)
+		dlq.RetryDue(ctx)
+	}
+
+	fake.Advance(time.Hour)
+	assert.Equal(t, DLQRetryResult{}, dlq.RetryDue(ctx), "events that used up their attempts are left to operators")
+
+	// Without a retry handler events are not dropped
+	withoutHandler := newSchedulerTestQueue(fake, nil)
+	require.NoError(t, withoutHandler.AddEvent(ctx, "user.created", nil, errors.New("boom"), nil))
+	fake.Advance(time.Hour)
+	assert.Equal(t, DLQRetryResult{}, withoutHandler.RetryDue(ctx))
+}
+
+func TestDeadLetterQueue_StartRetriesInBackground(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	handler := &flakyRetryHandler{}
+	dlq := newSchedulerTestQueue(fake, handler)
+
+	ctx := context.Background()
+	require.NoError(t, dlq.AddEvent(ctx, "user.created", nil, errors.New("boom"), nil))
+	require.NoError(t, dlq.Start(ctx))
+	assert.Error(t, dlq.Start(ctx), "retries start once")
+
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond)
+	fake.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		stats, _ := dlq.GetStats(ctx)
+		return stats.TotalEvents == 0
+	}, time.Second, time.Millisecond)
+
+	dlq.Stop()
+	dlq.Stop()
+	assert.Equal(t, 1, handler.retries)
+}
@@ -0,0 +1,222 @@
+package resilience
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/dataio"
+)
+
+// FailedEventFields are the columns written when exporting failed events
+var FailedEventFields = []string{
+	"id", "event_type", "event_data", "error", "timestamp", "attempts",
+	"max_attempts", "topic", "partition", "offset", "metadata",
+}
+
+// exportPageSize is the number of events read per page while exporting
+const exportPageSize = 100
+
+// DLQFilter selects failed events to export or triage. Zero fields match everything.
+type DLQFilter struct {
+	EventType string
+	Topic     string
+	Since     time.Time
+	Until     time.Time
+}
+
+// Matches reports whether the event passes the filter
+func (f DLQFilter) Matches(event *FailedEvent) bool {
+	if f.EventType != "" && event.EventType != f.EventType {
+		return false
+	}
+	if f.Topic != "" && event.Topic != f.Topic {
+		return false
+	}
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !event.Timestamp.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// DLQImportResult summarizes an import
+type DLQImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// Export streams the events matching filter to w page by page and returns how many were written
+func (dlq *DeadLetterQueue) Export(ctx context.Context, w dataio.Writer, filter DLQFilter) (int, error) {
+	written := 0
+	for offset := 0; ; offset += exportPageSize {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		events, err := dlq.ListEvents(ctx, exportPageSize, offset)
+		if err != nil {
+			return written, fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for _, event := range events {
+			if !filter.Matches(event) {
+				continue
+			}
+			record, err := FailedEventToRecord(event)
+			if err != nil {
+				return written, err
+			}
+			if err := w.Write(record); err != nil {
+				return written, fmt.Errorf("failed to write event %s: %w", event.ID, err)
+			}
+			written++
+		}
+
+		if len(events) < exportPageSize {
+			break
+		}
+	}
+
+	return written, w.Flush()
+}
+
+// Import adds previously exported events to the queue for reprocessing.
+// Attempts are reset so imported events can be retried; events whose ID is already queued are skipped.
+func (dlq *DeadLetterQueue) Import(ctx context.Context, r dataio.Reader) (DLQImportResult, error) {
+	var result DLQImportResult
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", r.Line(), err)
+		}
+
+		event, err := FailedEventFromRecord(record)
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", r.Line(), err)
+		}
+
+		if existing, _ := dlq.GetEvent(ctx, event.ID); existing != nil {
+			result.Skipped++
+			continue
+		}
+
+		event.Attempts = 0
+		if event.MaxAttempts <= 0 {
+			event.MaxAttempts = dlq.maxAttempts
+		}
+
+		if err := dlq.add(ctx, event); err != nil {
+			return result, fmt.Errorf("line %d: %w", r.Line(), err)
+		}
+		result.Imported++
+	}
+}
+
+// FailedEventToRecord converts a failed event to an export record; maps are encoded as JSON
+func FailedEventToRecord(event *FailedEvent) (dataio.Record, error) {
+	eventData, err := json.Marshal(event.EventData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode data of event %s: %w", event.ID, err)
+	}
+	metadata, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata of event %s: %w", event.ID, err)
+	}
+
+	return dataio.Record{
+		"id":           event.ID,
+		"event_type":   event.EventType,
+		"event_data":   string(eventData),
+		"error":        event.Error,
+		"timestamp":    event.Timestamp.UTC().Format(time.RFC3339Nano),
+		"attempts":     strconv.Itoa(event.Attempts),
+		"max_attempts": strconv.Itoa(event.MaxAttempts),
+		"topic":        event.Topic,
+		"partition":    strconv.FormatInt(int64(event.Partition), 10),
+		"offset":       strconv.FormatInt(event.Offset, 10),
+		"metadata":     string(metadata),
+	}, nil
+}
+
+// FailedEventFromRecord converts an export record back to a failed event
+func FailedEventFromRecord(record dataio.Record) (*FailedEvent, error) {
+	event := &FailedEvent{
+		ID:        record["id"],
+		EventType: record["event_type"],
+		Error:     record["error"],
+		Topic:     record["topic"],
+	}
+	if event.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if event.EventType == "" {
+		return nil, fmt.Errorf("event_type is required")
+	}
+
+	if err := unmarshalField(record, "event_data", &event.EventData); err != nil {
+		return nil, err
+	}
+	if err := unmarshalField(record, "metadata", &event.Metadata); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if value := record["timestamp"]; value != "" {
+		if event.Timestamp, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return nil, fmt.Errorf("invalid timestamp: %w", err)
+		}
+	}
+	if event.Attempts, err = atoiField(record, "attempts"); err != nil {
+		return nil, err
+	}
+	if event.MaxAttempts, err = atoiField(record, "max_attempts"); err != nil {
+		return nil, err
+	}
+	partition, err := atoiField(record, "partition")
+	if err != nil {
+		return nil, err
+	}
+	event.Partition = int32(partition)
+	if value := record["offset"]; value != "" {
+		if event.Offset, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid offset: %w", err)
+		}
+	}
+
+	return event, nil
+}
+
+// unmarshalField decodes a JSON encoded record field; empty fields are left untouched
+func unmarshalField(record dataio.Record, field string, target interface{}) error {
+	value := record[field]
+	if value == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), target); err != nil {
+		return fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return nil
+}
+
+// atoiField parses an integer record field; empty fields are zero
+func atoiField(record dataio.Record, field string) (int, error) {
+	value := record[field]
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,157 @@
+package resilience
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/dataio"
+)
+
+func newTransferTestQueue(t *testing.T, fake *clock.Fake) *DeadLetterQueue {
+	t.Helper()
+
+	config := DefaultDeadLetterQueueConfig()
+	config.Clock = fake
+	dlq := NewDeadLetterQueue(config, nil, nil)
+
+	ctx := context.Background()
+	require.NoError(t, dlq.AddEvent(ctx, "user.created", map[string]interface{}{"user_id": "1"}, errors.New("boom"), map[string]string{"source": "test"}))
+	fake.Advance(time.Hour)
+	require.NoError(t, dlq.AddKafkaEvent(ctx, "user.updated", map[string]interface{}{"user_id": "2"}, errors.New("timeout, retry later"), "user-events", 3, 42))
+	return dlq
+}
+
+func TestDeadLetterQueue_ExportImportRoundTrip(t *testing.T) {
+	for _, format := range []dataio.Format{dataio.FormatCSV, dataio.FormatJSONL} {
+		t.Run(string(format), func(t *testing.T) {
+			ctx := context.Background()
+			fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+			source := newTransferTestQueue(t, fake)
+
+			var buf bytes.Buffer
+			writer, err := dataio.NewWriter(&buf, format, FailedEventFields, false)
+			require.NoError(t, err)
+			exported, err := source.Export(ctx, writer, DLQFilter{})
+			require.NoError(t, err)
+			assert.Equal(t, 2, exported)
+
+			target := NewDeadLetterQueue(DefaultDeadLetterQueueConfig(), nil, nil)
+			reader, err := dataio.NewReader(bytes.NewReader(buf.Bytes()), format)
+			require.NoError(t, err)
+			result, err := target.Import(ctx, reader)
+			require.NoError(t, err)
+			assert.Equal(t, DLQImportResult{Imported: 2}, result)
+
+			want, err := source.ListEvents(ctx, 10, 0)
+			require.NoError(t, err)
+			got, err := target.ListEvents(ctx, 10, 0)
+			require.NoError(t, err)
+			require.Len(t, got, 2)
+			for i := range want {
+				assert.Equal(t, want[i].ID, got[i].ID)
+				assert.Equal(t, want[i].EventType, got[i].EventType)
+				assert.Equal(t, want[i].EventData, got[i].EventData)
+				assert.Equal(t, want[i].Error, got[i].Error)
+				assert.True(t, want[i].Timestamp.Equal(got[i].Timestamp))
+				assert.Equal(t, want[i].Topic, got[i].Topic)
+				assert.Equal(t, want[i].Partition, got[i].Partition)
+				assert.Equal(t, want[i].Offset, got[i].Offset)
+				assert.Equal(t, want[i].Metadata, got[i].Metadata)
+			}
+
+			// Importing the same file again skips queued events
+			reader, err = dataio.NewReader(bytes.NewReader(buf.Bytes()), format)
+			require.NoError(t, err)
+			result, err = target.Import(ctx, reader)
+			require.NoError(t, err)
+			assert.Equal(t, DLQImportResult{Skipped: 2}, result)
+		})
+	}
+}
+
+func TestDeadLetterQueue_ExportFilter(t *testing.T) {
+	start := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	dlq := newTransferTestQueue(t, clock.NewFake(start))
+
+	tests := []struct {
+		name     string
+		filter   DLQFilter
+		expected int
+	}{
+		{name: "all", filter: DLQFilter{}, expected: 2},
+		{name: "event type", filter: DLQFilter{EventType: "user.created"}, expected: 1},
+		{name: "topic", filter: DLQFilter{Topic: "user-events"}, expected: 1},
+		{name: "since", filter: DLQFilter{Since: start.Add(time.Minute)}, expected: 1},
+		{name: "until", filter: DLQFilter{Until: start.Add(time.Minute)}, expected: 1},
+		{name: "no match", filter: DLQFilter{EventType: "product.created"}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := dataio.NewWriter(&buf, dataio.FormatJSONL, FailedEventFields, false)
+			require.NoError(t, err)
+
+			exported, err := dlq.Export(context.Background(), writer, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, exported)
+			assert.Equal(t, tt.expected, strings.Count(buf.String(), "\n"))
+		})
+	}
+}
+
+func TestDeadLetterQueue_ImportResetsAttempts(t *testing.T) {
+	input := `{"id":"dlq_1","event_type":"user.created","event_data":"{\"user_id\":\"1\"}","attempts":"3","max_attempts":"3"}` + "\n"
+	reader, err := dataio.NewReader(strings.NewReader(input), dataio.FormatJSONL)
+	require.NoError(t, err)
+
+	dlq := NewDeadLetterQueue(DefaultDeadLetterQueueConfig(), nil, nil)
+	_, err = dlq.Import(context.Background(), reader)
+	require.NoError(t, err)
+
+	event, err := dlq.GetEvent(context.Background(), "dlq_1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, event.Attempts)
+	assert.Equal(t, 3, event.MaxAttempts)
+}
+
+func TestDeadLetterQueue_ImportInvalidRecord(t *testing.T) {
+	input := `{"id":"dlq_1","event_type":"user.created"}` + "\n" + `{"id":"dlq_2"}` + "\n"
+	reader, err := dataio.NewReader(strings.NewReader(input), dataio.FormatJSONL)
+	require.NoError(t, err)
+
+	dlq := NewDeadLetterQueue(DefaultDeadLetterQueueConfig(), nil, nil)
+	result, err := dlq.Import(context.Background(), reader)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+	assert.Equal(t, 1, result.Imported)
+}
+
+func TestDeadLetterQueue_FindEvents(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	dlq := newTransferTestQueue(t, fake)
+	require.NoError(t, dlq.AddEvent(ctx, "user.created", map[string]interface{}{"user_id": "3"}, errors.New("boom"), nil))
+
+	events, total, err := dlq.FindEvents(ctx, DLQFilter{EventType: "user.created"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, events, 2)
+	assert.Equal(t, "1", events[0].EventData["user_id"])
+	assert.Equal(t, "3", events[1].EventData["user_id"])
+
+	// Pages count every match
+	events, total, err = dlq.FindEvents(ctx, DLQFilter{}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, events, 1)
+	assert.Equal(t, "user.updated", events[0].EventType)
+}
@@ -0,0 +1,134 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// sweepInterval is the number of reservations between removals of idle buckets
+const sweepInterval = 1024
+
+// KeyedLimiter is a token bucket rate limiter with an independent bucket per key,
+// e.g. per tenant, so one busy key cannot use up the budget of the others.
+type KeyedLimiter struct {
+	mu           sync.Mutex
+	rate         float64 // Tokens added per second
+	burst        float64
+	clock        clock.Clock
+	buckets      map[string]*tokenBucket
+	reservations int
+}
+
+// tokenBucket holds the tokens of one key; tokens go negative while reservations are pending
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewKeyedLimiter creates a limiter allowing ratePerSecond events per key with bursts of burst events.
+// A nil clock uses the system clock.
+func NewKeyedLimiter(ratePerSecond float64, burst int, clk clock.Clock) *KeyedLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &KeyedLimiter{
+		rate:    ratePerSecond,
+		burst:   float64(burst),
+		clock:   clock.OrDefault(clk),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Reserve takes a token for key and returns how long the caller must wait before using it
+func (l *KeyedLimiter) Reserve(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	l.refill(bucket, now)
+
+	bucket.tokens--
+
+	l.reservations++
+	if l.reservations%sweepInterval == 0 {
+		l.sweep(now)
+	}
+
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.tokens / l.rate * float64(time.Second))
+}
+
+// Allow takes a token for key if one is available, without reserving one otherwise, e.g. to
+// reject requests over the rate instead of delaying them
+func (l *KeyedLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	l.refill(bucket, now)
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// Wait blocks until a token for key is available or ctx is done
+func (l *KeyedLimiter) Wait(ctx context.Context, key string) error {
+	delay := l.Reserve(key)
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-l.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Keys returns the number of keys currently tracked
+func (l *KeyedLimiter) Keys() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// refill adds the tokens earned since the last update
+func (l *KeyedLimiter) refill(bucket *tokenBucket, now time.Time) {
+	elapsed := now.Sub(bucket.updated).Seconds()
+	if elapsed > 0 {
+		bucket.tokens += elapsed * l.rate
+		if bucket.tokens > l.burst {
+			bucket.tokens = l.burst
+		}
+	}
+	bucket.updated = now
+}
+
+// sweep removes buckets that are full again; they behave the same as new buckets
+func (l *KeyedLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		l.refill(bucket, now)
+		if bucket.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
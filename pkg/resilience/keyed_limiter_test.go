@@ -0,0 +1,81 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+func TestKeyedLimiter_Reserve(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	limiter := NewKeyedLimiter(10, 2, fake)
+
+	// The burst is available immediately
+	assert.Zero(t, limiter.Reserve("acme"))
+	assert.Zero(t, limiter.Reserve("acme"))
+
+	// Further reservations are spaced at the rate
+	assert.Equal(t, 100*time.Millisecond, limiter.Reserve("acme"))
+	assert.Equal(t, 200*time.Millisecond, limiter.Reserve("acme"))
+
+	// Other keys have their own budget
+	assert.Zero(t, limiter.Reserve("globex"))
+
+	fake.Advance(time.Second)
+	assert.Zero(t, limiter.Reserve("acme"))
+}
+
+func TestKeyedLimiter_Allow(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	limiter := NewKeyedLimiter(1, 2, fake)
+
+	assert.True(t, limiter.Allow("acme"))
+	assert.True(t, limiter.Allow("acme"))
+	assert.False(t, limiter.Allow("acme"))
+	assert.False(t, limiter.Allow("acme"), "rejected requests do not take tokens")
+	assert.True(t, limiter.Allow("globex"))
+
+	fake.Advance(time.Second)
+	assert.True(t, limiter.Allow("acme"))
+	assert.False(t, limiter.Allow("acme"))
+}
+
+func TestKeyedLimiter_Wait(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	limiter := NewKeyedLimiter(1, 1, fake)
+
+	require.NoError(t, limiter.Wait(context.Background(), "acme"))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- limiter.Wait(context.Background(), "acme")
+	}()
+
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond)
+	fake.Advance(time.Second)
+	require.NoError(t, <-done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx, "acme"), context.Canceled)
+}
+
+func TestKeyedLimiter_SweepsIdleKeys(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	limiter := NewKeyedLimiter(100, 1, fake)
+
+	for i := 0; i < sweepInterval-1; i++ {
+		limiter.Reserve(string(rune('a' + i%26)))
+	}
+	assert.Equal(t, 26, limiter.Keys())
+
+	// Only the key reserved during the sweep is still tracked
+	fake.Advance(time.Minute)
+	limiter.Reserve("acme")
+	assert.Equal(t, 1, limiter.Keys())
+}
@@ -0,0 +1,118 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go-clean-ddd-es-template/pkg/health"
+)
+
+// NamedStateChangeListener is notified of the state changes of registered circuit breakers
+type NamedStateChangeListener func(name string, from, to CircuitState)
+
+// CircuitBreakerRegistry keeps named circuit breakers for inspection
+type CircuitBreakerRegistry struct {
+	mu       sync.RWMutex
+	breakers map[string]*CircuitBreaker
+
+	// Guarded apart from the breakers, which notify the listeners with their own lock held
+	listenersMu sync.RWMutex
+	listeners   []NamedStateChangeListener
+}
+
+// NewCircuitBreakerRegistry creates a new circuit breaker registry
+func NewCircuitBreakerRegistry() *CircuitBreakerRegistry {
+	return &CircuitBreakerRegistry{
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Register adds a circuit breaker under a name, replacing any previous one. The state changes
+// of the breaker are passed on to the listeners of the registry.
+func (r *CircuitBreakerRegistry) Register(name string, cb *CircuitBreaker) {
+	r.mu.Lock()
+	r.breakers[name] = cb
+	r.mu.Unlock()
+
+	cb.OnStateChange(func(from, to CircuitState) {
+		r.listenersMu.RLock()
+		listeners := r.listeners
+		r.listenersMu.RUnlock()
+		for _, listener := range listeners {
+			listener(name, from, to)
+		}
+	})
+}
+
+// OnStateChange adds a listener notified of the state changes of the circuit breakers
+// registered, before or after the listener
+func (r *CircuitBreakerRegistry) OnStateChange(listener NamedStateChangeListener) {
+	r.listenersMu.Lock()
+	defer r.listenersMu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// Get returns the circuit breaker registered under a name
+func (r *CircuitBreakerRegistry) Get(name string) (*CircuitBreaker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cb, exists := r.breakers[name]
+	return cb, exists
+}
+
+// Names returns the sorted names of registered circuit breakers
+func (r *CircuitBreakerRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.breakers))
+	for name := range r.breakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot returns the statistics of every registered circuit breaker
+func (r *CircuitBreakerRegistry) Snapshot() map[string]CircuitBreakerStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[string]CircuitBreakerStats, len(r.breakers))
+	for name, cb := range r.breakers {
+		stats[name] = cb.GetStats()
+	}
+	return stats
+}
+
+// HealthCheck reports the circuit breakers: degraded while one is open or half-open, healthy
+// otherwise. An open breaker fails calls fast rather than making the service unfit for requests.
+func (r *CircuitBreakerRegistry) HealthCheck() health.HealthChecker {
+	return func(ctx context.Context) health.Check {
+		check := health.Check{Name: "circuit_breakers", Status: health.StatusHealthy, Details: make(map[string]interface{})}
+		snapshot := r.Snapshot()
+		var open []string
+		for name, stats := range snapshot {
+			check.Details[name] = stats.State.String()
+			if stats.State != StateClosed {
+				open = append(open, name)
+			}
+		}
+		if len(open) > 0 {
+			sort.Strings(open)
+			check.Status = health.StatusDegraded
+			check.Message = fmt.Sprintf("circuit breakers not closed: %v", open)
+		}
+		return check
+	}
+}
+
+// Default registry used by the infrastructure circuit breaker decorators
+var defaultRegistry = NewCircuitBreakerRegistry()
+
+// DefaultRegistry returns the default circuit breaker registry
+func DefaultRegistry() *CircuitBreakerRegistry {
+	return defaultRegistry
+}
@@ -0,0 +1,68 @@
+package resilience
+
+// callOutcome is the outcome of a call recorded by a sliding window
+type callOutcome struct {
+	failed bool
+	slow   bool
+}
+
+// slidingWindow keeps the outcomes of the most recent calls, up to its size, and the failed
+// and slow calls among them
+type slidingWindow struct {
+	outcomes []callOutcome // Ring buffer of outcomes
+	next     int           // Index of the next outcome in the ring buffer
+	count    int           // Number of outcomes recorded, up to the size
+	failed   int
+	slow     int
+}
+
+// newSlidingWindow creates a sliding window over the last size calls
+func newSlidingWindow(size int) *slidingWindow {
+	return &slidingWindow{outcomes: make([]callOutcome, size)}
+}
+
+// record adds the outcome of a call, evicting the oldest one when the window is full
+func (w *slidingWindow) record(outcome callOutcome) {
+	if w.count == len(w.outcomes) {
+		evicted := w.outcomes[w.next]
+		if evicted.failed {
+			w.failed--
+		}
+		if evicted.slow {
+			w.slow--
+		}
+	} else {
+		w.count++
+	}
+
+	w.outcomes[w.next] = outcome
+	w.next = (w.next + 1) % len(w.outcomes)
+	if outcome.failed {
+		w.failed++
+	}
+	if outcome.slow {
+		w.slow++
+	}
+}
+
+// failureRate returns the percentage of failed calls, 0 when empty
+func (w *slidingWindow) failureRate() float64 {
+	return percentage(w.failed, w.count)
+}
+
+// slowCallRate returns the percentage of slow calls, 0 when empty
+func (w *slidingWindow) slowCallRate() float64 {
+	return percentage(w.slow, w.count)
+}
+
+// reset drops every recorded outcome
+func (w *slidingWindow) reset() {
+	w.next, w.count, w.failed, w.slow = 0, 0, 0, 0
+}
+
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
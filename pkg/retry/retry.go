@@ -0,0 +1,137 @@
+// Package retry retries failing operations with jittered exponential backoff. Waits between
+// attempts end as soon as the context is done, so retries never hold up a shutdown.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Policy is how often a failing operation, e.g. a message handler, is attempted and how long to
+// wait between attempts: the base delay, doubled after every failed attempt and bounded by the
+// max delay
+type Policy struct {
+	MaxAttempts int           // Attempts including the first
+	BaseDelay   time.Duration // Wait after the first failed attempt
+	MaxDelay    time.Duration // Longest wait between attempts, 0 for no limit
+	Jitter      float64       // Fraction of each wait that is random, from 0 to 1, so failing consumers do not retry in lockstep
+}
+
+// DefaultPolicy returns the policy of 3 attempts, 1s then 2s apart
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// Validate checks that the policy makes at least one attempt and its delays and jitter are in range
+func (p Policy) Validate() error {
+	switch {
+	case p.MaxAttempts < 1:
+		return errors.New("retry policy must make at least one attempt")
+	case p.BaseDelay < 0 || p.MaxDelay < 0:
+		return errors.New("retry policy delays must not be negative")
+	case p.MaxDelay > 0 && p.MaxDelay < p.BaseDelay:
+		return errors.New("retry policy max delay must not be shorter than its base delay")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return errors.New("retry policy jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Delay returns the wait after the failed attempt, counted from 1. With jitter, up to that
+// fraction of the wait is taken off at random.
+func (p Policy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		if p.MaxDelay > 0 && delay >= p.MaxDelay || delay > math.MaxInt64/2 {
+			break
+		}
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// Retrier attempts operations as often as its policy allows
+type Retrier struct {
+	Policy       Policy
+	FirstAttempt int                                               // Attempt of the first call, for operations attempted before; 0 for 1
+	Clock        clock.Clock                                       // Clock waited on between attempts, nil for the system clock
+	OnRetry      func(attempt int, delay time.Duration, err error) // Called after each failed attempt that is retried, before waiting
+}
+
+// Do calls fn with the attempt, counted from 1, until it succeeds or policy.MaxAttempts attempts
+// failed, waiting policy.Delay between attempts. fn is called at least once.
+func Do(ctx context.Context, policy Policy, fn func(attempt int) error) error {
+	return Retrier{Policy: policy}.Do(ctx, fn)
+}
+
+// Do calls fn with the attempt until it succeeds or the policy's attempts are used up. When ctx is
+// done while waiting for the next attempt, Do stops retrying and returns an error wrapping both
+// the error of ctx and the last error of fn.
+func (r Retrier) Do(ctx context.Context, fn func(attempt int) error) error {
+	attempt := max(r.FirstAttempt, 1)
+	for {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+		if attempt >= r.Policy.MaxAttempts {
+			return fmt.Errorf("failed after %d attempts: %w", attempt, err)
+		}
+
+		delay := r.Policy.Delay(attempt)
+		if r.OnRetry != nil {
+			r.OnRetry(attempt, delay, err)
+		}
+		if waitErr := Sleep(ctx, r.Clock, delay); waitErr != nil {
+			return fmt.Errorf("stopped retrying after %d attempts, %w: %w", attempt, waitErr, err)
+		}
+		attempt++
+	}
+}
+
+// Sleep waits for d on clk, nil for the system clock, returning the error of ctx as soon as it
+// is done
+func Sleep(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.OrDefault(clk).After(d):
+		return nil
+	}
+}
+
+// UntilClosed returns a context canceled once stop is closed, so workers stopped by closing a
+// channel can cut their waits short. Call cancel when the worker is done to release it.
+func UntilClosed(stop <-chan struct{}) (ctx context.Context, cancel context.CancelFunc) {
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
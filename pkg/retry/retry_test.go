@@ -0,0 +1,118 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	assert.Equal(t, time.Second, policy.Delay(1))
+	assert.Equal(t, 2*time.Second, policy.Delay(2))
+	assert.Equal(t, 4*time.Second, policy.Delay(3))
+	assert.Equal(t, 5*time.Second, policy.Delay(4), "delays are bounded by the max delay")
+	assert.Equal(t, 5*time.Second, policy.Delay(100))
+
+	policy.Jitter = 0.5
+	for range 100 {
+		delay := policy.Delay(2)
+		assert.GreaterOrEqual(t, delay, time.Second, "jitter takes off at most its fraction of the delay")
+		assert.LessOrEqual(t, delay, 2*time.Second)
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	assert.NoError(t, retry.DefaultPolicy().Validate())
+	assert.NoError(t, retry.Policy{MaxAttempts: 1}.Validate())
+
+	assert.Error(t, retry.Policy{}.Validate(), "at least one attempt")
+	assert.Error(t, retry.Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Second}.Validate())
+	assert.Error(t, retry.Policy{MaxAttempts: 3, BaseDelay: -time.Second}.Validate())
+	assert.Error(t, retry.Policy{MaxAttempts: 3, Jitter: 1.5}.Validate())
+}
+
+func TestDo(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 3}
+
+	var attempts []int
+	err := retry.Do(context.Background(), policy, func(attempt int) error {
+		attempts = append(attempts, attempt)
+		if attempt < 2 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, attempts)
+
+	attempts = nil
+	err = retry.Do(context.Background(), policy, func(attempt int) error {
+		attempts = append(attempts, attempt)
+		return errors.New("unavailable")
+	})
+	assert.EqualError(t, err, "failed after 3 attempts: unavailable")
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestRetrier_WaitsOnClockFromFirstAttempt(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var delays []time.Duration
+	retrier := retry.Retrier{
+		Policy:       retry.Policy{MaxAttempts: 4, BaseDelay: time.Minute},
+		FirstAttempt: 2,
+		Clock:        fake,
+		OnRetry:      func(attempt int, delay time.Duration, err error) { delays = append(delays, delay) },
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- retrier.Do(context.Background(), func(attempt int) error { return errors.New("unavailable") })
+	}()
+
+	// Each delay only elapses when the fake clock is advanced
+	for range 2 {
+		for fake.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		fake.Advance(time.Hour)
+	}
+
+	assert.EqualError(t, <-done, "failed after 4 attempts: unavailable")
+	assert.Equal(t, []time.Duration{2 * time.Minute, 4 * time.Minute}, delays, "attempts before the first are counted in the backoff")
+}
+
+func TestRetrier_StopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := retry.Retrier{
+		Policy:  retry.Policy{MaxAttempts: 3, BaseDelay: time.Hour},
+		OnRetry: func(attempt int, delay time.Duration, err error) { cancel() },
+	}.Do(ctx, func(attempt int) error {
+		calls++
+		return errors.New("unavailable")
+	})
+
+	assert.Equal(t, 1, calls, "no attempt is made once ctx is done")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "unavailable")
+}
+
+func TestUntilClosed(t *testing.T) {
+	stop := make(chan struct{})
+	ctx, cancel := retry.UntilClosed(stop)
+	defer cancel()
+
+	require.NoError(t, ctx.Err())
+	close(stop)
+	<-ctx.Done()
+	assert.ErrorIs(t, retry.Sleep(ctx, nil, time.Hour), context.Canceled)
+}
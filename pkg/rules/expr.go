@@ -0,0 +1,440 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Compile compiles a rule condition written in a small expression language:
+//
+//	email_domain == "example.com"
+//	email_domain in ["example.com", "example.org"] && !verified
+//	name matches "^(?i)test" || (attempts >= 3 && email endsWith ".ru")
+//
+// A comparison is a fact name, an operator and a literal. Operators are == != < <= > >= on
+// numbers, == != contains startsWith endsWith matches on strings and in on lists. A bare fact
+// name is true when the fact is true, a non-empty string or a non-zero number. Comparisons combine
+// with && || ! and parentheses. Missing facts only satisfy !=.
+func Compile(expression string) (Condition, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	condition, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].offset)
+	}
+	return condition, nil
+}
+
+// MustCompile compiles a rule condition and panics when it is invalid
+func MustCompile(expression string) Condition {
+	condition, err := Compile(expression)
+	if err != nil {
+		panic(fmt.Sprintf("rules: invalid condition %q: %v", expression, err))
+	}
+	return condition
+}
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+// symbols are the operators and punctuation, longest first
+var symbols = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		c := rune(expression[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(expression) && expression[end] != '"' {
+				if expression[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expression) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			text, err := strconv.Unquote(expression[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", i, err)
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i = end + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(expression) && unicode.IsDigit(rune(expression[i+1]))):
+			end := i + 1
+			for end < len(expression) && (unicode.IsDigit(rune(expression[end])) || expression[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{tokenNumber, expression[i:end], i})
+			i = end
+		case unicode.IsLetter(c) || c == '_':
+			end := i + 1
+			for end < len(expression) && (unicode.IsLetter(rune(expression[end])) || unicode.IsDigit(rune(expression[end])) || expression[end] == '_' || expression[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{tokenIdent, expression[i:end], i})
+			i = end
+		default:
+			matched := false
+			for _, symbol := range symbols {
+				if strings.HasPrefix(expression[i:], symbol) {
+					tokens = append(tokens, token{tokenSymbol, symbol, i})
+					i += len(symbol)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() *token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *exprParser) accept(kind tokenKind, text string) bool {
+	if t := p.peek(); t != nil && t.kind == kind && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (Condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenSymbol, "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = or(left, right)
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (Condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenSymbol, "&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = and(left, right)
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (Condition, error) {
+	if p.accept(tokenSymbol, "!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(facts map[string]interface{}) (bool, error) {
+			matched, err := operand(facts)
+			return !matched, err
+		}, nil
+	}
+	if p.accept(tokenSymbol, "(") {
+		condition, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(tokenSymbol, ")") {
+			return nil, p.expected(")")
+		}
+		return condition, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (Condition, error) {
+	fact := p.peek()
+	if fact == nil || fact.kind != tokenIdent {
+		return nil, p.expected("fact name")
+	}
+	p.pos++
+	name := fact.text
+
+	operator := p.peek()
+	if operator == nil || !isOperator(operator) {
+		return truthy(name), nil
+	}
+	p.pos++
+
+	if operator.text == "in" {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return in(name, values), nil
+	}
+
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	return compare(name, operator.text, value)
+}
+
+func (p *exprParser) parseList() ([]interface{}, error) {
+	if !p.accept(tokenSymbol, "[") {
+		return nil, p.expected("[")
+	}
+	var values []interface{}
+	for !p.accept(tokenSymbol, "]") {
+		if len(values) > 0 && !p.accept(tokenSymbol, ",") {
+			return nil, p.expected(",")
+		}
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (p *exprParser) parseLiteral() (interface{}, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, p.expected("literal")
+	}
+	switch {
+	case t.kind == tokenString:
+		p.pos++
+		return t.text, nil
+	case t.kind == tokenNumber:
+		p.pos++
+		number, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.offset)
+		}
+		return number, nil
+	case t.kind == tokenIdent && (t.text == "true" || t.text == "false"):
+		p.pos++
+		return t.text == "true", nil
+	}
+	return nil, p.expected("literal")
+}
+
+func (p *exprParser) expected(what string) error {
+	if t := p.peek(); t != nil {
+		return fmt.Errorf("expected %s at position %d, got %q", what, t.offset, t.text)
+	}
+	return fmt.Errorf("expected %s at end of expression", what)
+}
+
+// wordOperators are the operators spelled as words
+var wordOperators = map[string]bool{"contains": true, "startsWith": true, "endsWith": true, "matches": true, "in": true}
+
+func isOperator(t *token) bool {
+	switch t.kind {
+	case tokenIdent:
+		return wordOperators[t.text]
+	case tokenSymbol:
+		switch t.text {
+		case "==", "!=", "<", "<=", ">", ">=":
+			return true
+		}
+	}
+	return false
+}
+
+func or(left, right Condition) Condition {
+	return func(facts map[string]interface{}) (bool, error) {
+		matched, err := left(facts)
+		if err != nil || matched {
+			return matched, err
+		}
+		return right(facts)
+	}
+}
+
+func and(left, right Condition) Condition {
+	return func(facts map[string]interface{}) (bool, error) {
+		matched, err := left(facts)
+		if err != nil || !matched {
+			return false, err
+		}
+		return right(facts)
+	}
+}
+
+func truthy(name string) Condition {
+	return func(facts map[string]interface{}) (bool, error) {
+		switch value := facts[name].(type) {
+		case nil:
+			return false, nil
+		case bool:
+			return value, nil
+		case string:
+			return value != "", nil
+		default:
+			if number, ok := toNumber(value); ok {
+				return number != 0, nil
+			}
+			return true, nil
+		}
+	}
+}
+
+func in(name string, values []interface{}) Condition {
+	return func(facts map[string]interface{}) (bool, error) {
+		fact, ok := facts[name]
+		if !ok || fact == nil {
+			return false, nil
+		}
+		for _, value := range values {
+			if equal(fact, value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func compare(name, operator string, value interface{}) (Condition, error) {
+	switch operator {
+	case "==", "!=":
+		negate := operator == "!="
+		return func(facts map[string]interface{}) (bool, error) {
+			fact, ok := facts[name]
+			if !ok || fact == nil {
+				return negate, nil
+			}
+			return equal(fact, value) != negate, nil
+		}, nil
+
+	case "<", "<=", ">", ">=":
+		limit, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("operator %s of %s requires a number", operator, name)
+		}
+		return func(facts map[string]interface{}) (bool, error) {
+			fact, ok := facts[name]
+			if !ok || fact == nil {
+				return false, nil
+			}
+			number, ok := toNumber(fact)
+			if !ok {
+				return false, fmt.Errorf("fact %s is not a number", name)
+			}
+			switch operator {
+			case "<":
+				return number < limit, nil
+			case "<=":
+				return number <= limit, nil
+			case ">":
+				return number > limit, nil
+			default:
+				return number >= limit, nil
+			}
+		}, nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("operator %s of %s requires a string", operator, name)
+	}
+	var match func(string) bool
+	switch operator {
+	case "contains":
+		match = func(fact string) bool { return strings.Contains(fact, text) }
+	case "startsWith":
+		match = func(fact string) bool { return strings.HasPrefix(fact, text) }
+	case "endsWith":
+		match = func(fact string) bool { return strings.HasSuffix(fact, text) }
+	case "matches":
+		pattern, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of %s: %w", name, err)
+		}
+		match = pattern.MatchString
+	}
+	return func(facts map[string]interface{}) (bool, error) {
+		fact, ok := facts[name]
+		if !ok || fact == nil {
+			return false, nil
+		}
+		return match(fmt.Sprint(fact)), nil
+	}, nil
+}
+
+// equal compares a fact with a literal, numerically when both are numbers
+func equal(fact, value interface{}) bool {
+	if number, ok := value.(float64); ok {
+		factNumber, ok := toNumber(fact)
+		return ok && factNumber == number
+	}
+	if boolean, ok := value.(bool); ok {
+		factBool, ok := fact.(bool)
+		return ok && factBool == boolean
+	}
+	return fmt.Sprint(fact) == value
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case uint:
+		return float64(number), true
+	case uint32:
+		return float64(number), true
+	case uint64:
+		return float64(number), true
+	case float32:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
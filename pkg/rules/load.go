@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Definition is a rule declared in a rules file, with its condition as an expression
+type Definition struct {
+	Name     string   `yaml:"name"`
+	Commands []string `yaml:"commands"`
+	When     string   `yaml:"when"`
+	Effect   Effect   `yaml:"effect"`
+	Message  string   `yaml:"message"`
+}
+
+// Compile compiles the definition to a rule. The effect defaults to deny.
+func (d Definition) Compile() (Rule, error) {
+	if d.Name == "" {
+		return Rule{}, fmt.Errorf("rule name is required")
+	}
+	effect := d.Effect
+	switch effect {
+	case "":
+		effect = EffectDeny
+	case EffectDeny, EffectRequireApproval:
+	default:
+		return Rule{}, fmt.Errorf("rule %s has unknown effect %q", d.Name, d.Effect)
+	}
+	condition, err := Compile(d.When)
+	if err != nil {
+		return Rule{}, fmt.Errorf("rule %s has an invalid condition: %w", d.Name, err)
+	}
+
+	message := d.Message
+	if message == "" {
+		message = fmt.Sprintf("violates rule %s", d.Name)
+	}
+	return Rule{Name: d.Name, Commands: d.Commands, Effect: effect, Message: message, When: condition}, nil
+}
+
+// Parse parses a YAML rules document:
+//
+//	rules:
+//	  - name: partner-registrations
+//	    commands: [auth.register, user.create]
+//	    when: email_domain == "partner.example"
+//	    effect: require_approval
+//	    message: Registrations from partner.example require approval
+func Parse(data []byte) ([]Rule, error) {
+	var document struct {
+		Rules []Definition `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	rules := make([]Rule, 0, len(document.Rules))
+	for _, definition := range document.Rules {
+		rule, err := definition.Compile()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// LoadFile loads the rules of a YAML rules file
+func LoadFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	return Parse(data)
+}
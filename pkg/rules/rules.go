@@ -0,0 +1,113 @@
+package rules
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go-clean-ddd-es-template/pkg/errors"
+)
+
+// Effect is what a matching rule does to a command
+type Effect string
+
+const (
+	EffectDeny            Effect = "deny"             // The command is rejected
+	EffectRequireApproval Effect = "require_approval" // The command needs an approval before it runs
+)
+
+// Condition reports whether a rule matches the facts of a command
+type Condition func(facts map[string]interface{}) (bool, error)
+
+// Rule is a business rule evaluated before commands run
+type Rule struct {
+	Name     string
+	Commands []string // Commands the rule applies to, empty for all commands
+	Effect   Effect
+	Message  string
+	When     Condition
+}
+
+// AppliesTo reports whether the rule is evaluated for command
+func (r Rule) AppliesTo(command string) bool {
+	return len(r.Commands) == 0 || slices.Contains(r.Commands, command)
+}
+
+// Violation describes a rule that matched a command
+type Violation struct {
+	Rule    string `json:"rule"`
+	Effect  Effect `json:"effect"`
+	Message string `json:"message"`
+}
+
+// Engine evaluates business rules for commands
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine creates a new rule engine
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Add registers a rule
+func (e *Engine) Add(rule Rule) {
+	e.rules = append(e.rules, rule)
+}
+
+// Rules returns the registered rules
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Evaluate evaluates the rules of command against its facts. It returns nil when no rule matches,
+// or an AppError listing the violations in its details: POLICY_DENIED when a deny rule matches,
+// APPROVAL_REQUIRED when only approval rules match.
+func (e *Engine) Evaluate(ctx context.Context, command string, facts map[string]interface{}) error {
+	var violations []Violation
+	for _, rule := range e.rules {
+		if !rule.AppliesTo(command) {
+			continue
+		}
+		matched, err := rule.When(facts)
+		if err != nil {
+			return errors.Wrapf(err, errors.ErrCommandFailed, "failed to evaluate rule %s", rule.Name)
+		}
+		if matched {
+			violations = append(violations, Violation{Rule: rule.Name, Effect: rule.Effect, Message: rule.Message})
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return Denial(command, violations)
+}
+
+// Denial creates the structured error of a command violating rules
+func Denial(command string, violations []Violation) *errors.AppError {
+	code := errors.ErrApprovalRequired
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		if violation.Effect != EffectRequireApproval {
+			code = errors.ErrPolicyDenied
+		}
+		messages[i] = violation.Message
+	}
+
+	return errors.New(code, fmt.Sprintf("%s: %s", command, strings.Join(messages, "; "))).WithDetails(map[string]interface{}{
+		"command":    command,
+		"violations": violations,
+	})
+}
+
+// Violations returns the violations of a denial created by the engine, or nil for other errors
+func Violations(err error) []Violation {
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) {
+		return nil
+	}
+	violations, _ := appErr.Details["violations"].([]Violation)
+	return violations
+}
@@ -0,0 +1,124 @@
+package rules_test
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/errors"
+	"go-clean-ddd-es-template/pkg/rules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	facts := map[string]interface{}{
+		"email":        "jane@partner.example",
+		"email_domain": "partner.example",
+		"name":         "Test User",
+		"attempts":     3,
+		"verified":     false,
+	}
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{`email_domain == "partner.example"`, true},
+		{`email_domain != "partner.example"`, false},
+		{`email_domain in ["example.com", "partner.example"]`, true},
+		{`email endsWith ".example" && !verified`, true},
+		{`name matches "^(?i)test"`, true},
+		{`name startsWith "Jane" || (attempts >= 3 && email contains "@")`, true},
+		{`attempts < 3`, false},
+		{`attempts == 3`, true},
+		{`verified`, false},
+		{`missing == "x"`, false},
+		{`missing != "x"`, true},
+		{`missing`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			condition, err := rules.Compile(tt.expression)
+			require.NoError(t, err)
+			matched, err := condition(facts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`email ==`,
+		`email == "unterminated`,
+		`(email == "a"`,
+		`attempts > "three"`,
+		`name matches "("`,
+		`email == "a" extra`,
+		`email # "a"`,
+	} {
+		_, err := rules.Compile(expression)
+		assert.Error(t, err, expression)
+	}
+
+	// Numeric comparisons of non-numeric facts fail at evaluation
+	condition := rules.MustCompile(`name > 1`)
+	_, err := condition(map[string]interface{}{"name": "Jane"})
+	assert.Error(t, err)
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine := rules.NewEngine(
+		rules.Rule{
+			Name:     "partner-approval",
+			Commands: []string{"auth.register"},
+			Effect:   rules.EffectRequireApproval,
+			Message:  "partner registrations require approval",
+			When:     rules.MustCompile(`email_domain == "partner.example"`),
+		},
+	)
+	ctx := context.Background()
+
+	assert.NoError(t, engine.Evaluate(ctx, "auth.register", map[string]interface{}{"email_domain": "example.com"}))
+	assert.NoError(t, engine.Evaluate(ctx, "user.create", map[string]interface{}{"email_domain": "partner.example"}))
+
+	err := engine.Evaluate(ctx, "auth.register", map[string]interface{}{"email_domain": "partner.example"})
+	assert.Equal(t, errors.ErrApprovalRequired, errors.CodeOf(err, ""))
+	assert.Len(t, rules.Violations(err), 1)
+
+	// A matching deny rule takes precedence over approval
+	engine.Add(rules.Rule{
+		Name:   "no-partners",
+		Effect: rules.EffectDeny,
+		When:   rules.MustCompile(`email_domain endsWith "partner.example"`),
+	})
+	err = engine.Evaluate(ctx, "auth.register", map[string]interface{}{"email_domain": "partner.example"})
+	assert.Equal(t, errors.ErrPolicyDenied, errors.CodeOf(err, ""))
+	assert.Len(t, rules.Violations(err), 2)
+
+	assert.Nil(t, rules.Violations(assert.AnError))
+}
+
+func TestParse(t *testing.T) {
+	parsed, err := rules.Parse([]byte(`
+rules:
+  - name: partner-registrations
+    commands: [auth.register]
+    when: email_domain == "partner.example"
+    effect: require_approval
+  - name: blocked
+    when: email endsWith "@blocked.example"
+`))
+	require.NoError(t, err)
+	require.Len(t, parsed, 2)
+	assert.Equal(t, rules.EffectRequireApproval, parsed[0].Effect)
+	assert.Equal(t, rules.EffectDeny, parsed[1].Effect)
+	assert.Equal(t, "violates rule blocked", parsed[1].Message)
+
+	_, err = rules.Parse([]byte("rules:\n  - name: bad\n    when: email ==\n"))
+	assert.Error(t, err)
+	_, err = rules.Parse([]byte("rules:\n  - name: bad\n    when: verified\n    effect: warn\n"))
+	assert.Error(t, err)
+}
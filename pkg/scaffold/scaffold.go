@@ -0,0 +1,471 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Module is an optional part of a template, e.g. a message broker, set up in the services
+// selecting it and pruned from the others
+type Module struct {
+	Name     string
+	Env      map[string]string // Settings of the env file when selected, e.g. the broker type
+	Services []string          // Docker compose services of the template the module runs
+	Volumes  []string          // Docker compose volumes of those services
+	Compose  string            // Docker compose services added when selected, for modules the template does not run
+	Paths    []string          // Files and directories only the module uses
+	Notes    string            // Steps left to the developer once generated, e.g. the build tags the module needs
+}
+
+// Choice selects one of several modules, e.g. the message broker
+type Choice struct {
+	Name    string
+	Modules []Module // The first one is the default
+}
+
+// module returns the module of a name, the default one when name is empty
+func (c Choice) module(name string) (Module, error) {
+	if name == "" {
+		return c.Modules[0], nil
+	}
+	names := make([]string, 0, len(c.Modules))
+	for _, module := range c.Modules {
+		if module.Name == name {
+			return module, nil
+		}
+		names = append(names, module.Name)
+	}
+	return Module{}, fmt.Errorf("unknown %s %q, expected one of %s", c.Name, name, strings.Join(names, ", "))
+}
+
+// Template describes how services are generated from a copy of a template repository
+type Template struct {
+	Module      string   // Module path of the template, replaced with the one of the service
+	Skip        []string // Paths never copied, e.g. of the template tooling
+	Examples    []string // Paths of the examples, pruned unless kept
+	EnvFile     string   // Path of the env file settings of the modules are written to
+	ComposeFile string   // Path of the docker compose file running the modules
+	Choices     []Choice
+}
+
+// Options are the options of a generated service
+type Options struct {
+	Module       string            // Module path of the service, e.g. github.com/acme/orders
+	Modules      map[string]string // Module selected for each choice, the default one when missing
+	KeepExamples bool
+}
+
+// Result describes a generated service
+type Result struct {
+	Files   int               // Files written
+	Modules map[string]string // Module selected for each choice
+	Notes   []string          // Steps left to the developer
+}
+
+var (
+	// modulePathPattern matches the module paths accepted for services
+	modulePathPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._~-]*(/[A-Za-z0-9._~-]+)*$`)
+	// majorVersionPattern matches the major version suffix of module paths
+	majorVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// Generate copies the template at source into target, an empty or missing directory, renaming its
+// module and setting up the modules selected. Generation fails before writing anything when the
+// options or the template are invalid.
+func (t *Template) Generate(source, target string, options Options) (*Result, error) {
+	if !modulePathPattern.MatchString(options.Module) {
+		return nil, fmt.Errorf("invalid module path %q", options.Module)
+	}
+	if err := checkTarget(source, target); err != nil {
+		return nil, err
+	}
+
+	selected, pruned, err := t.resolve(options)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := t.configure(source, selected)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Modules: map[string]string{}}
+	for i, choice := range t.Choices {
+		result.Modules[choice.Name] = selected[i].Name
+		if selected[i].Notes != "" {
+			result.Notes = append(result.Notes, selected[i].Notes)
+		}
+	}
+
+	renamer := newRenamer(t.Module, options.Module)
+	err = filepath.WalkDir(source, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && isPruned(rel, pruned) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(target, filepath.FromSlash(rel)), 0o755)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		content, ok := overrides[rel]
+		if !ok {
+			if content, err = os.ReadFile(file); err != nil {
+				return err
+			}
+		}
+		if content, err = renamer.rename(rel, content); err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		result.Files++
+		return os.WriteFile(filepath.Join(target, filepath.FromSlash(rel)), content, info.Mode().Perm())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate service: %w", err)
+	}
+	return result, nil
+}
+
+// checkTarget checks that target is an empty or missing directory outside of source
+func checkTarget(source, target string) error {
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(absSource, absTarget); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("target %s is inside the template %s", target, source)
+	}
+
+	entries, err := os.ReadDir(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("target %s is not empty", target)
+	}
+	return nil
+}
+
+// resolve returns the module selected for each choice and the paths pruned
+func (t *Template) resolve(options Options) ([]Module, []string, error) {
+	for name := range options.Modules {
+		if !t.hasChoice(name) {
+			return nil, nil, fmt.Errorf("unknown module choice %q", name)
+		}
+	}
+
+	pruned := append([]string{}, t.Skip...)
+	if !options.KeepExamples {
+		pruned = append(pruned, t.Examples...)
+	}
+	selected := make([]Module, 0, len(t.Choices))
+	for _, choice := range t.Choices {
+		module, err := choice.module(options.Modules[choice.Name])
+		if err != nil {
+			return nil, nil, err
+		}
+		selected = append(selected, module)
+		for _, other := range choice.Modules {
+			if other.Name != module.Name {
+				pruned = append(pruned, other.Paths...)
+			}
+		}
+	}
+	return selected, pruned, nil
+}
+
+func (t *Template) hasChoice(name string) bool {
+	for _, choice := range t.Choices {
+		if choice.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// configure returns the env and docker compose files of the template set up for the modules
+// selected
+func (t *Template) configure(source string, selected []Module) (map[string][]byte, error) {
+	overrides := map[string][]byte{}
+
+	if t.EnvFile != "" {
+		content, err := os.ReadFile(filepath.Join(source, filepath.FromSlash(t.EnvFile)))
+		if err != nil {
+			return nil, err
+		}
+		env := map[string]string{}
+		for _, module := range selected {
+			for key, value := range module.Env {
+				env[key] = value
+			}
+		}
+		if content, err = setEnv(content, env); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.EnvFile, err)
+		}
+		overrides[t.EnvFile] = content
+	}
+
+	if t.ComposeFile != "" {
+		content, err := os.ReadFile(filepath.Join(source, filepath.FromSlash(t.ComposeFile)))
+		if err != nil {
+			return nil, err
+		}
+		for i, choice := range t.Choices {
+			for _, module := range choice.Modules {
+				if module.Name == selected[i].Name {
+					continue
+				}
+				if content, err = removeBlocks(content, "services", module.Services); err != nil {
+					return nil, fmt.Errorf("%s: %w", t.ComposeFile, err)
+				}
+				if content, err = removeBlocks(content, "volumes", module.Volumes); err != nil {
+					return nil, fmt.Errorf("%s: %w", t.ComposeFile, err)
+				}
+			}
+			if selected[i].Compose != "" {
+				if content, err = appendBlock(content, "services", selected[i].Compose); err != nil {
+					return nil, fmt.Errorf("%s: %w", t.ComposeFile, err)
+				}
+			}
+		}
+		overrides[t.ComposeFile] = content
+	}
+	return overrides, nil
+}
+
+// isPruned reports whether a path, or a directory containing it, is pruned. Pruned paths may be
+// patterns of path.Match.
+func isPruned(rel string, pruned []string) bool {
+	for _, pattern := range pruned {
+		if rel == pattern || strings.HasPrefix(rel, pattern+"/") {
+			return true
+		}
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// setEnv sets the value of settings of an env file, failing for settings missing from it
+func setEnv(content []byte, env map[string]string) ([]byte, error) {
+	lines := strings.Split(string(content), "\n")
+	set := map[string]bool{}
+	for i, line := range lines {
+		key, _, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		if value, ok := env[key]; ok {
+			lines[i] = key + "=" + value
+			set[key] = true
+		}
+	}
+
+	var missing []string
+	for key := range env {
+		if !set[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("no setting %s", strings.Join(missing, ", "))
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// removeBlocks removes entries of a top level section of a YAML file, with their trailing blank
+// lines, failing for entries missing from it
+func removeBlocks(content []byte, section string, names []string) ([]byte, error) {
+	if len(names) == 0 {
+		return content, nil
+	}
+	remove := map[string]bool{}
+	for _, name := range names {
+		remove[name] = true
+	}
+
+	text := strings.TrimSuffix(string(content), "\n")
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	inSection, removing := false, false
+	for _, line := range lines {
+		switch {
+		case line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#"):
+			inSection = strings.TrimRight(line, " ") == section+":"
+			removing = false
+		case inSection && strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "   "):
+			name, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+			removing = remove[name]
+			if removing {
+				delete(remove, name)
+			}
+		}
+		if !removing {
+			kept = append(kept, line)
+		}
+	}
+
+	if len(remove) > 0 {
+		missing := make([]string, 0, len(remove))
+		for name := range remove {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("no %s %s", section, strings.Join(missing, ", "))
+	}
+	return []byte(strings.Join(kept, "\n") + strings.TrimPrefix(string(content), text)), nil
+}
+
+// appendBlock appends entries, indented already, to a top level section of a YAML file
+func appendBlock(content []byte, section, block string) ([]byte, error) {
+	lines := strings.Split(string(content), "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.TrimRight(line, " ") == section+":" {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("no %s section", section)
+	}
+
+	// Entries end at the next top level key, before the blank lines preceding it
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if lines[i] != "" && !strings.HasPrefix(lines[i], " ") && !strings.HasPrefix(lines[i], "#") {
+			end = i
+			break
+		}
+	}
+	for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	added := append([]string{""}, strings.Split(strings.TrimRight(block, "\n"), "\n")...)
+	lines = append(lines[:end], append(added, lines[end:]...)...)
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// renamer replaces the module path of the template with the one of a service
+type renamer struct {
+	from, to string
+	name     string // Name of the service, replacing the bare name of the template
+	goMod    *regexp.Regexp
+}
+
+func newRenamer(from, to string) *renamer {
+	return &renamer{
+		from:  from,
+		to:    to,
+		name:  serviceName(to),
+		goMod: regexp.MustCompile(`(?m)^module[ \t]+` + regexp.QuoteMeta(from) + `[ \t]*$`),
+	}
+}
+
+// rename renames the module in a file, reformatting Go files as renamed imports may sort
+// differently. Binary files are left untouched.
+func (r *renamer) rename(rel string, content []byte) ([]byte, error) {
+	if !bytes.Contains(content, []byte(r.from)) || bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+		return content, nil
+	}
+
+	if path.Ext(rel) != ".go" {
+		return []byte(r.renameText(string(content))), nil
+	}
+
+	renamed, err := r.renameGo(rel, content)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(renamed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rel, err)
+	}
+	return formatted, nil
+}
+
+// renameText renames the module path, and the bare name of the template
+func (r *renamer) renameText(text string) string {
+	text = r.goMod.ReplaceAllString(text, "module "+r.to)
+	text = strings.ReplaceAll(text, r.from+"/", r.to+"/")
+	return strings.ReplaceAll(text, r.from, r.name)
+}
+
+// renameGo renames a Go file. Only the imports of generated files are renamed: the descriptors
+// embedded in generated protobuf code are length prefixed, and regenerated from the renamed
+// proto files instead.
+func (r *renamer) renameGo(rel string, content []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, content, parser.ParseComments|parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rel, err)
+	}
+	if !ast.IsGenerated(file) {
+		return []byte(r.renameText(string(content))), nil
+	}
+
+	// Imports are replaced from the last one so the offsets of the others remain valid
+	renamed := append([]byte{}, content...)
+	for i := len(file.Imports) - 1; i >= 0; i-- {
+		spec := file.Imports[i]
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || (importPath != r.from && !strings.HasPrefix(importPath, r.from+"/")) {
+			continue
+		}
+		start, end := fset.Position(spec.Path.Pos()).Offset, fset.Position(spec.Path.End()).Offset
+		quoted := strconv.Quote(r.to + strings.TrimPrefix(importPath, r.from))
+		renamed = append(renamed[:start], append([]byte(quoted), renamed[end:]...)...)
+	}
+	return renamed, nil
+}
+
+// serviceName returns the name of the service of a module, its last path element but for major
+// version suffixes
+func serviceName(module string) string {
+	elements := strings.Split(module, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && majorVersionPattern.MatchString(name) {
+		name = elements[len(elements)-2]
+	}
+	return name
+}
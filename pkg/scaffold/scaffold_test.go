@@ -0,0 +1,205 @@
+package scaffold_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/scaffold"
+)
+
+const testCompose = `version: '3.8'
+
+services:
+  postgres:
+    image: postgres:15-alpine
+
+  kafka:
+    image: confluentinc/cp-kafka:7.4.0
+    depends_on:
+      - postgres
+
+  jaeger:
+    image: jaegertracing/all-in-one:latest
+
+volumes:
+  postgres_data:
+  kafka_data:
+`
+
+const testGenerated = `// Code generated by protoc-gen-go. DO NOT EDIT.
+
+package proto
+
+import (
+	app "tmpl-template/pkg/app"
+)
+
+const rawDesc = "\x1ftmpl-template/proto;proto"
+
+var _ = app.Run
+`
+
+// writeTemplate writes a template repository
+func writeTemplate(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module tmpl-template\n\ngo 1.24.0\n",
+		"main.go": `package main
+
+import (
+	"tmpl-template/pkg/app"
+	"github.com/acme/lib"
+)
+
+const serviceName = "tmpl-template"
+
+func main() { app.Run(lib.Name, serviceName) }
+`,
+		"pkg/app/app.go":       "package app\n\nfunc Run(names ...string) {}\n",
+		"proto/app.pb.go":      testGenerated,
+		"pkg/kafka/kafka.go":   "package kafka\n",
+		"examples/demo.go":     "package examples\n",
+		"tools/generator.go":   "package tools\n",
+		"env.example":          "# Broker\nBROKER_TYPE=kafka\nBROKER_URL=localhost:9092\n",
+		"docker-compose.yml":   testCompose,
+		"assets/logo.bin":      "tmpl-template\x00",
+		"scripts/README.md":    "Run tmpl-template/scripts",
+		"scripts/unrelated.sh": "#!/bin/sh\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+	return dir
+}
+
+var testTemplate = scaffold.Template{
+	Module:      "tmpl-template",
+	Skip:        []string{"tools"},
+	Examples:    []string{"examples"},
+	EnvFile:     "env.example",
+	ComposeFile: "docker-compose.yml",
+	Choices: []scaffold.Choice{{
+		Name: "broker",
+		Modules: []scaffold.Module{
+			{Name: "kafka", Env: map[string]string{"BROKER_TYPE": "kafka"}, Services: []string{"kafka"}, Volumes: []string{"kafka_data"}, Paths: []string{"pkg/kafka"}},
+			{
+				Name:    "rabbitmq",
+				Env:     map[string]string{"BROKER_TYPE": "rabbitmq", "BROKER_URL": "amqp://localhost:5672"},
+				Compose: "  rabbitmq:\n    image: rabbitmq:3-management\n",
+				Notes:   "Build with -tags rabbitmq",
+			},
+		},
+	}},
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestTemplate_GenerateDefaults(t *testing.T) {
+	source := writeTemplate(t)
+	target := filepath.Join(t.TempDir(), "orders")
+
+	result, err := testTemplate.Generate(source, target, scaffold.Options{Module: "github.com/acme/orders/v2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"broker": "kafka"}, result.Modules)
+	assert.Empty(t, result.Notes)
+
+	assert.Equal(t, "module github.com/acme/orders/v2\n\ngo 1.24.0\n", readFile(t, target, "go.mod"))
+	assert.Equal(t, `package main
+
+import (
+	"github.com/acme/lib"
+	"github.com/acme/orders/v2/pkg/app"
+)
+
+const serviceName = "orders"
+
+func main() { app.Run(lib.Name, serviceName) }
+`, readFile(t, target, "main.go"), "imports are renamed and sorted again, the bare template name becomes the service name")
+	assert.Equal(t, strings.Replace(testGenerated, `"tmpl-template/pkg/app"`, `"github.com/acme/orders/v2/pkg/app"`, 1), readFile(t, target, "proto/app.pb.go"),
+		"only the imports of generated files are renamed")
+	assert.Equal(t, "Run github.com/acme/orders/v2/scripts", readFile(t, target, "scripts/README.md"))
+	assert.Equal(t, "tmpl-template\x00", readFile(t, target, "assets/logo.bin"), "binary files are copied as is")
+	assert.Equal(t, testCompose, readFile(t, target, "docker-compose.yml"))
+	assert.FileExists(t, filepath.Join(target, "pkg/kafka/kafka.go"))
+	assert.NoDirExists(t, filepath.Join(target, "examples"))
+	assert.NoDirExists(t, filepath.Join(target, "tools"))
+}
+
+func TestTemplate_GenerateSelectedModule(t *testing.T) {
+	source := writeTemplate(t)
+	target := t.TempDir()
+
+	result, err := testTemplate.Generate(source, target, scaffold.Options{
+		Module:       "orders",
+		Modules:      map[string]string{"broker": "rabbitmq"},
+		KeepExamples: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Build with -tags rabbitmq"}, result.Notes)
+
+	assert.Equal(t, "# Broker\nBROKER_TYPE=rabbitmq\nBROKER_URL=amqp://localhost:5672\n", readFile(t, target, "env.example"))
+	assert.Equal(t, `version: '3.8'
+
+services:
+  postgres:
+    image: postgres:15-alpine
+
+  jaeger:
+    image: jaegertracing/all-in-one:latest
+
+  rabbitmq:
+    image: rabbitmq:3-management
+
+volumes:
+  postgres_data:
+`, readFile(t, target, "docker-compose.yml"))
+	assert.NoDirExists(t, filepath.Join(target, "pkg/kafka"), "paths of the modules not selected are pruned")
+	assert.FileExists(t, filepath.Join(target, "examples/demo.go"))
+}
+
+func TestTemplate_GenerateInvalid(t *testing.T) {
+	source := writeTemplate(t)
+	nonEmpty := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(nonEmpty, "main.go"), []byte("package main\n"), 0o644))
+	drifted := testTemplate
+	drifted.Choices = []scaffold.Choice{{Name: "broker", Modules: []scaffold.Module{{Name: "nats", Env: map[string]string{"NATS_URL": "nats://localhost"}}}}}
+
+	tests := []struct {
+		name     string
+		template scaffold.Template
+		target   string
+		options  scaffold.Options
+	}{
+		{name: "invalid module path", template: testTemplate, options: scaffold.Options{Module: "github.com/acme/orders service"}},
+		{name: "unknown module", template: testTemplate, options: scaffold.Options{Module: "orders", Modules: map[string]string{"broker": "nats"}}},
+		{name: "unknown choice", template: testTemplate, options: scaffold.Options{Module: "orders", Modules: map[string]string{"cache": "redis"}}},
+		{name: "target not empty", template: testTemplate, target: nonEmpty, options: scaffold.Options{Module: "orders"}},
+		{name: "target inside the template", template: testTemplate, target: filepath.Join(source, "out"), options: scaffold.Options{Module: "orders"}},
+		{name: "setting missing from the env file", template: drifted, options: scaffold.Options{Module: "orders"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = filepath.Join(t.TempDir(), "orders")
+			}
+			_, err := tt.template.Generate(source, target, tt.options)
+			assert.Error(t, err)
+			if tt.target == "" {
+				assert.NoDirExists(t, target, "nothing is written")
+			}
+		})
+	}
+}
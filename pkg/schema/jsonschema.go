@@ -0,0 +1,269 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Violations are the rules a payload violates
+type Violations []string
+
+// Error joins the violations
+func (v Violations) Error() string {
+	return strings.Join(v, "; ")
+}
+
+// JSONSchema is a JSON schema. It supports the validation keywords describing event payloads:
+// type, properties, required, additionalProperties, items, enum, minLength, maxLength, pattern,
+// minimum, maximum and the date-time, email and uuid formats. Other keywords are ignored.
+type JSONSchema struct {
+	Type                 types                  `json:"type"`
+	Properties           map[string]*JSONSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *JSONSchema            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	Format               string                 `json:"format"`
+
+	pattern *regexp.Regexp
+}
+
+// types are the types a value may have, a single type or a list of types in JSON
+type types []string
+
+// UnmarshalJSON accepts a type or a list of types
+func (t *types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = list
+	return nil
+}
+
+// ParseJSONSchema parses a JSON schema document
+func ParseJSONSchema(document []byte) (*JSONSchema, error) {
+	var schema JSONSchema
+	if err := json.Unmarshal(document, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+	if err := schema.compile("$"); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// MustParseJSONSchema parses a JSON schema document, panicking when it is invalid. It is meant
+// for schemas defined in code.
+func MustParseJSONSchema(document string) *JSONSchema {
+	schema, err := ParseJSONSchema([]byte(document))
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// compile checks the schema and compiles its patterns
+func (s *JSONSchema) compile(path string) error {
+	for _, typ := range s.Type {
+		switch typ {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return fmt.Errorf("%s: unknown type %q", path, typ)
+		}
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", path, err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s.%s: schema must be an object", path, name)
+		}
+		if err := property.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// Validate validates a JSON payload, returning its Violations
+func (s *JSONSchema) Validate(payload []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return Violations{fmt.Sprintf("$: invalid JSON: %v", err)}
+	}
+
+	var violations Violations
+	s.validate(value, "$", &violations)
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+// validate appends the violations of value at path to violations
+func (s *JSONSchema) validate(value interface{}, path string, violations *Violations) {
+	violate := func(format string, v ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, v...))
+	}
+
+	if len(s.Type) > 0 && !s.hasTypeOf(value) {
+		violate("must be %s, got %s", strings.Join(s.Type, " or "), typeOf(value))
+		return
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		violate("must be one of %v", s.Enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				violate("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			switch {
+			case ok:
+				property.validate(v[name], path+"."+name, violations)
+			case s.AdditionalProperties != nil && !*s.AdditionalProperties:
+				violate("unexpected property %q", name)
+			}
+		}
+
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			violate("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			violate("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			violate("must match %s", s.Pattern)
+		}
+		if err := checkFormat(s.Format, v); err != nil {
+			violate("must be a valid %s: %v", s.Format, err)
+		}
+
+	case json.Number:
+		number, _ := v.Float64()
+		if s.Minimum != nil && number < *s.Minimum {
+			violate("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			violate("must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+// hasTypeOf reports whether value has one of the schema types
+func (s *JSONSchema) hasTypeOf(value interface{}) bool {
+	actual := typeOf(value)
+	for _, typ := range s.Type {
+		if typ == actual {
+			return true
+		}
+		if typ == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// inEnum reports whether value is one of the enum values
+func (s *JSONSchema) inEnum(value interface{}) bool {
+	if number, ok := value.(json.Number); ok {
+		value, _ = number.Float64()
+	}
+	for _, allowed := range s.Enum {
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON schema type of a decoded value
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if number, err := v.Float64(); err == nil && number == math.Trunc(number) && !strings.ContainsAny(v.String(), ".eE") {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// uuidPattern matches UUIDs in their canonical form
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// checkFormat checks a string has a format, ignoring unknown formats
+func checkFormat(format, value string) error {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err
+	case "uuid":
+		if !uuidPattern.MatchString(value) {
+			return fmt.Errorf("not a UUID")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package schema
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoSchema validates JSON payloads against a protobuf message: payloads must decode into the
+// message without unknown fields or values of the wrong type
+type ProtoSchema struct {
+	message proto.Message
+}
+
+// NewProtoSchema creates a schema of the message type of message
+func NewProtoSchema(message proto.Message) *ProtoSchema {
+	return &ProtoSchema{message: message}
+}
+
+// Validate decodes a JSON payload into a new message
+func (s *ProtoSchema) Validate(payload []byte) error {
+	message := s.message.ProtoReflect().New().Interface()
+	return protojson.Unmarshal(payload, message)
+}
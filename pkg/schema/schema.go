@@ -0,0 +1,160 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Validator validates a payload against a schema
+type Validator interface {
+	Validate(payload []byte) error
+}
+
+// ValidationError is a payload violating the schema of its event type and version
+type ValidationError struct {
+	EventType  string
+	Version    int
+	Violations []string // Violated rules, prefixed with the JSON path of the offending value
+}
+
+// Error describes the violations
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("payload of %s violates its schema: %s", Key(e.EventType, e.Version), strings.Join(e.Violations, "; "))
+}
+
+// MissingSchemaError is an event without a registered schema, returned by strict registries
+type MissingSchemaError struct {
+	EventType string
+	Version   int
+}
+
+// Error describes the missing schema
+func (e *MissingSchemaError) Error() string {
+	return fmt.Sprintf("no schema registered for %s", Key(e.EventType, e.Version))
+}
+
+// Key names the schema of an event type and version, e.g. user.created@v1, or user.created@* for
+// any version
+func Key(eventType string, version int) string {
+	if version == AnyVersion {
+		return eventType + "@*"
+	}
+	return eventType + "@v" + strconv.Itoa(version)
+}
+
+// AnyVersion registers a schema for the versions of an event type without a schema of their own
+const AnyVersion = -1
+
+// Registry holds the schemas of event payloads by event type and version
+type Registry struct {
+	strict bool
+
+	mu      sync.RWMutex
+	schemas map[string]Validator // Key -> schema
+}
+
+// NewRegistry creates a registry. Strict registries reject events without a schema; others
+// accept them unvalidated.
+func NewRegistry(strict bool) *Registry {
+	return &Registry{strict: strict, schemas: make(map[string]Validator)}
+}
+
+// Register registers the schema of an event type and version, replacing any previous one
+func (r *Registry) Register(eventType string, version int, schema Validator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schemas[Key(eventType, version)] = schema
+}
+
+// Lookup returns the schema of an event type and version, falling back to the schema registered
+// for any version of the type
+func (r *Registry) Lookup(eventType string, version int) (Validator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if schema, ok := r.schemas[Key(eventType, version)]; ok {
+		return schema, true
+	}
+	schema, ok := r.schemas[Key(eventType, AnyVersion)]
+	return schema, ok
+}
+
+// Keys returns the keys of the registered schemas, sorted
+func (r *Registry) Keys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]string, 0, len(r.schemas))
+	for key := range r.schemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate validates the payload of an event against the schema of its type and version. It
+// returns a *ValidationError when the payload violates the schema, and a *MissingSchemaError for
+// events without a schema when the registry is strict.
+func (r *Registry) Validate(eventType string, version int, payload []byte) error {
+	schema, ok := r.Lookup(eventType, version)
+	if !ok {
+		if r.strict {
+			return &MissingSchemaError{EventType: eventType, Version: version}
+		}
+		return nil
+	}
+
+	if err := schema.Validate(payload); err != nil {
+		violations := []string{err.Error()}
+		if list, ok := err.(Violations); ok {
+			violations = list
+		}
+		return &ValidationError{EventType: eventType, Version: version, Violations: violations}
+	}
+	return nil
+}
+
+// schemaFileName matches JSON schema files named <event type>[.v<version>].json
+var schemaFileName = regexp.MustCompile(`^(.+?)(?:\.v(\d+))?\.json$`)
+
+// LoadDir registers the JSON schemas of a directory. Files are named <event type>.v<version>.json,
+// or <event type>.json for a schema of any version, e.g. user.created.v1.json.
+func (r *Registry) LoadDir(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema directory %s: %w", dir, err)
+	}
+
+	loaded := 0
+	for _, entry := range entries {
+		match := schemaFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version := AnyVersion
+		if match[2] != "" {
+			version, _ = strconv.Atoi(match[2])
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return loaded, fmt.Errorf("failed to read schema %s: %w", entry.Name(), err)
+		}
+		schema, err := ParseJSONSchema(content)
+		if err != nil {
+			return loaded, fmt.Errorf("invalid schema %s: %w", entry.Name(), err)
+		}
+
+		r.Register(match[1], version, schema)
+		loaded++
+	}
+	return loaded, nil
+}
@@ -0,0 +1,107 @@
+package schema_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/schema"
+	userpb "go-clean-ddd-es-template/proto/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Validate(t *testing.T) {
+	registry := schema.NewRegistry(false)
+	registry.Register("user.created", 1, schema.MustParseJSONSchema(`{"type": "object", "required": ["user_id"]}`))
+	registry.Register("user.created", schema.AnyVersion, schema.MustParseJSONSchema(`{"type": "object", "required": ["id"]}`))
+
+	assert.NoError(t, registry.Validate("user.created", 1, []byte(`{"user_id": "u1"}`)))
+	assert.NoError(t, registry.Validate("user.created", 2, []byte(`{"id": "u1"}`)), "versions without a schema use the schema of any version")
+	assert.NoError(t, registry.Validate("product.created", 1, []byte(`not json`)), "events without a schema are not validated")
+
+	err := registry.Validate("user.created", 1, []byte(`{"id": "u1"}`))
+	var validationErr *schema.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "user.created", validationErr.EventType)
+	assert.Equal(t, []string{`$: missing required property "user_id"`}, validationErr.Violations)
+	assert.EqualError(t, err, `payload of user.created@v1 violates its schema: $: missing required property "user_id"`)
+
+	strict := schema.NewRegistry(true)
+	var missingErr *schema.MissingSchemaError
+	assert.True(t, errors.As(strict.Validate("product.created", 1, []byte(`{}`)), &missingErr))
+}
+
+func TestRegistry_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user.created.v2.json"), []byte(`{"type": "object"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user.deleted.json"), []byte(`{"type": "object"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(`schemas`), 0o644))
+
+	registry := schema.NewRegistry(false)
+	loaded, err := registry.LoadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loaded)
+	assert.Equal(t, []string{"user.created@v2", "user.deleted@*"}, registry.Keys())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user.updated.v1.json"), []byte(`{"type": "text"}`), 0o644))
+	_, err = registry.LoadDir(dir)
+	assert.ErrorContains(t, err, `invalid schema user.updated.v1.json: $: unknown type "text"`)
+}
+
+func TestJSONSchema_Validate(t *testing.T) {
+	s := schema.MustParseJSONSchema(`{
+		"type": "object",
+		"required": ["user_id", "email"],
+		"additionalProperties": false,
+		"properties": {
+			"user_id": {"type": "string", "format": "uuid"},
+			"email": {"type": "string", "format": "email"},
+			"name": {"type": "string", "minLength": 1, "maxLength": 5},
+			"status": {"enum": ["active", "deleted"]},
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}},
+			"created_at": {"type": "string", "format": "date-time"},
+			"note": {"type": ["string", "null"]}
+		}
+	}`)
+
+	valid := `{"user_id": "9b2f5a3e-8c1d-4e6f-a7b8-1c2d3e4f5a6b", "email": "a@example.com", "name": "Ann",
+		"status": "active", "age": 30, "tags": ["admin"], "created_at": "2024-01-02T15:04:05.123+07:00", "note": null}`
+	assert.NoError(t, s.Validate([]byte(valid)))
+
+	err := s.Validate([]byte(`{"user_id": "u1", "name": "", "status": "gone", "age": 1.5, "tags": ["Admin"], "created_at": "yesterday", "extra": true}`))
+	var violations schema.Violations
+	require.True(t, errors.As(err, &violations))
+	assert.Equal(t, schema.Violations{
+		`$: missing required property "email"`,
+		`$.age: must be integer, got number`,
+		`$.created_at: must be a valid date-time: parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`,
+		`$: unexpected property "extra"`,
+		`$.name: must be at least 1 characters long`,
+		`$.status: must be one of [active deleted]`,
+		`$.tags[0]: must match ^[a-z]+$`,
+		`$.user_id: must be a valid uuid: not a UUID`,
+	}, violations)
+
+	assert.EqualError(t, s.Validate([]byte(`[]`)), "$: must be object, got array")
+	assert.ErrorContains(t, s.Validate([]byte(`{`)), "$: invalid JSON")
+}
+
+func TestParseJSONSchema_Invalid(t *testing.T) {
+	_, err := schema.ParseJSONSchema([]byte(`{"properties": {"name": {"pattern": "("}}}`))
+	assert.ErrorContains(t, err, "$.name: invalid pattern")
+
+	_, err = schema.ParseJSONSchema([]byte(`{"type": 1}`))
+	assert.Error(t, err)
+}
+
+func TestProtoSchema_Validate(t *testing.T) {
+	s := schema.NewProtoSchema(&userpb.UserDeletedEvent{})
+
+	assert.NoError(t, s.Validate([]byte(`{"user_id": "u1", "deleted_at": "2024-01-02T15:04:05.123456Z"}`)))
+	assert.Error(t, s.Validate([]byte(`{"user_id": 1}`)), "fields must have their type")
+	assert.Error(t, s.Validate([]byte(`{"user_id": "u1", "reason": "spam"}`)), "unknown fields are rejected")
+}
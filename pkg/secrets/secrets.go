@@ -0,0 +1,118 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned for secrets that do not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Provider stores named secrets, e.g. a secrets manager or a mounted secrets volume. Names are
+// slash separated paths such as "tenants/acme/data-key".
+type Provider interface {
+	// Get returns the value of a secret, ErrNotFound when there is none
+	Get(ctx context.Context, name string) ([]byte, error)
+	// Put creates or replaces a secret
+	Put(ctx context.Context, name string, value []byte) error
+}
+
+// MemoryProvider keeps secrets in memory, for tests and demos
+type MemoryProvider struct {
+	mu      sync.RWMutex
+	secrets map[string][]byte
+}
+
+// NewMemoryProvider creates an empty in-memory secrets provider
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{secrets: make(map[string][]byte)}
+}
+
+// Get returns a copy of the value of a secret
+func (p *MemoryProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	value, ok := p.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Put creates or replaces a secret
+func (p *MemoryProvider) Put(ctx context.Context, name string, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.secrets[name] = append([]byte(nil), value...)
+	return nil
+}
+
+// FileProvider keeps each secret in a file under a directory, readable by the service only
+type FileProvider struct {
+	dir string
+}
+
+// NewFileProvider creates a secrets provider storing secrets under dir
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+// Get returns the value of a secret
+func (p *FileProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	path, err := p.path(name)
+	if err != nil {
+		return nil, err
+	}
+	value, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// Put creates or replaces a secret, writing it to a temporary file renamed over the secret so
+// readers never see a partial value
+func (p *FileProvider) Put(ctx context.Context, name string, value []byte) error {
+	path, err := p.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create secret directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".secret-*")
+	if err != nil {
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// path returns the file of a secret, refusing names escaping the directory
+func (p *FileProvider) path(name string) (string, error) {
+	if name == "" || strings.Contains(name, "..") || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	return filepath.Join(p.dir, filepath.FromSlash(name)), nil
+}
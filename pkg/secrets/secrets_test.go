@@ -0,0 +1,38 @@
+package secrets_test
+
+import (
+	"context"
+	"testing"
+
+	"go-clean-ddd-es-template/pkg/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviders(t *testing.T) {
+	providers := map[string]secrets.Provider{
+		"memory": secrets.NewMemoryProvider(),
+		"file":   secrets.NewFileProvider(t.TempDir()),
+	}
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := provider.Get(ctx, "tenants/acme/data-key")
+			assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+			require.NoError(t, provider.Put(ctx, "tenants/acme/data-key", []byte("v1")))
+			require.NoError(t, provider.Put(ctx, "tenants/acme/data-key", []byte("v2")))
+			value, err := provider.Get(ctx, "tenants/acme/data-key")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("v2"), value)
+		})
+	}
+}
+
+func TestFileProvider_RefusesNamesOutsideItsDirectory(t *testing.T) {
+	provider := secrets.NewFileProvider(t.TempDir())
+	assert.Error(t, provider.Put(context.Background(), "../escape", []byte("x")))
+	_, err := provider.Get(context.Background(), "/etc/passwd")
+	assert.Error(t, err)
+}
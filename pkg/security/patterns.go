@@ -0,0 +1,76 @@
+package security
+
+import "strings"
+
+// InjectionPatterns are markup, script and path traversal fragments that never
+// appear in legitimate user input such as names or email addresses
+var InjectionPatterns = []string{
+	"<script", "javascript:", "vbscript:", "onload=", "onerror=",
+	"<iframe", "<object", "<embed", "data:text/html",
+	"../../", "..\\", "file://", "ftp://", "gopher://",
+}
+
+// SQLKeywords are SQL keywords matched as whole words in request payloads
+var SQLKeywords = []string{
+	"SELECT", "INSERT", "UPDATE", "DELETE", "DROP", "CREATE",
+	"UNION", "OR", "AND", "WHERE", "FROM", "JOIN",
+}
+
+// DefaultBlockedPatterns returns the patterns blocked in request payloads
+func DefaultBlockedPatterns() []string {
+	patterns := make([]string, 0, len(InjectionPatterns)+len(SQLKeywords))
+	patterns = append(patterns, InjectionPatterns...)
+	return append(patterns, SQLKeywords...)
+}
+
+// ContainsInjectionPattern reports whether value contains an injection pattern (case insensitive)
+func ContainsInjectionPattern(value string) bool {
+	valueLower := strings.ToLower(value)
+	for _, pattern := range InjectionPatterns {
+		if strings.Contains(valueLower, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsBlockedPattern reports whether content contains one of patterns.
+// SQL keywords only match as whole words, other patterns match anywhere (case insensitive).
+func ContainsBlockedPattern(content string, patterns []string) bool {
+	contentLower := strings.ToLower(content)
+	for _, pattern := range patterns {
+		patternLower := strings.ToLower(pattern)
+
+		if IsSQLKeyword(pattern) {
+			if ContainsWord(contentLower, patternLower) {
+				return true
+			}
+		} else if strings.Contains(contentLower, patternLower) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSQLKeyword reports whether pattern is a SQL keyword
+func IsSQLKeyword(pattern string) bool {
+	patternUpper := strings.ToUpper(pattern)
+	for _, keyword := range SQLKeywords {
+		if patternUpper == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsWord reports whether word appears in content delimited by whitespace or punctuation
+func ContainsWord(content, word string) bool {
+	for _, w := range strings.Fields(content) {
+		// Remove common punctuation
+		w = strings.Trim(w, ".,;:!?\"'()[]{}")
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package security_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-clean-ddd-es-template/pkg/security"
+)
+
+func TestContainsInjectionPattern(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"John Doe", false},
+		{"user@example.com", false},
+		{"Select Committee", false},
+		{"<SCRIPT>alert(1)</SCRIPT>", true},
+		{"JavaScript:alert(1)", true},
+		{"../../etc/passwd", true},
+		{"file:///etc/passwd", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.expected, security.ContainsInjectionPattern(tt.value))
+		})
+	}
+}
+
+func TestContainsBlockedPattern(t *testing.T) {
+	patterns := security.DefaultBlockedPatterns()
+
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{`{"name": "Oregon"}`, false},
+		{`{"name": "Anderson"}`, false},
+		{`{"query": "1 OR 1=1"}`, true},
+		{`{"query": "x; drop table users"}`, true},
+		{`{"bio": "<iframe src=x>"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			assert.Equal(t, tt.expected, security.ContainsBlockedPattern(tt.content, patterns))
+		})
+	}
+}
+
+func TestDefaultBlockedPatterns_ReturnsCopy(t *testing.T) {
+	patterns := security.DefaultBlockedPatterns()
+	patterns[0] = "changed"
+
+	assert.Equal(t, "<script", security.DefaultBlockedPatterns()[0])
+	assert.Len(t, patterns, len(security.InjectionPatterns)+len(security.SQLKeywords))
+}
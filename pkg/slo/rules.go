@@ -0,0 +1,194 @@
+package slo
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Metrics recorded by pkg/metrics that the rules are built on
+const (
+	grpcHandledMetric  = "grpc_server_handled_total"
+	grpcDurationMetric = "grpc_server_handling_seconds_bucket"
+	httpRequestsMetric = "http_requests_total"
+	httpDurationMetric = "http_request_duration_seconds_bucket"
+)
+
+// grpcServerErrorCodes are the status codes that burn the error budget; client errors do not
+const grpcServerErrorCodes = "Unknown|DeadlineExceeded|Unimplemented|Internal|Unavailable|DataLoss"
+
+// Recorded series and alert names
+const (
+	LatencyRecord    = "slo:request_latency_seconds"
+	ErrorRatioRecord = "slo:request_error_ratio"
+	LatencyAlert     = "SLOLatencyBudgetExceeded"
+	ErrorBudgetAlert = "SLOErrorBudgetExceeded"
+)
+
+// DefaultRuleWindow is the rate window of the recording rules
+const DefaultRuleWindow = 5 * time.Minute
+
+// generatedHeader marks rule files as generated
+const generatedHeader = "# Code generated by \"observability gen\". DO NOT EDIT.\n# Budgets are declared in code; regenerate after changing them.\n"
+
+// RuleFile is a Prometheus rule file
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup is a named group of recording or alerting rules
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a Prometheus recording rule (Record set) or alerting rule (Alert set)
+type Rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// GenerateRules builds recording rules for the latency and error ratio of each budget over
+// window and alerting rules that fire when a budget is exceeded
+func GenerateRules(budgets []Budget, window time.Duration) (RuleFile, error) {
+	if window <= 0 {
+		window = DefaultRuleWindow
+	}
+
+	recording := RuleGroup{Name: "slo_recording_rules", Rules: []Rule{}}
+	alerting := RuleGroup{Name: "slo_alerts", Rules: []Rule{}}
+
+	for _, budget := range budgets {
+		if err := budget.Validate(); err != nil {
+			return RuleFile{}, err
+		}
+		budget = budget.withDefaults()
+		selector := budgetSelector(budget)
+
+		if budget.Latency > 0 {
+			quantile := formatFloat(budget.Percentile)
+			recording.Rules = append(recording.Rules, Rule{
+				Record: LatencyRecord,
+				Expr: fmt.Sprintf("histogram_quantile(%s, sum by (le) (rate(%s{%s}[%s])))",
+					quantile, durationMetric(budget), selector, formatDuration(window)),
+				Labels: map[string]string{"slo": budget.Name, "quantile": quantile},
+			})
+			alerting.Rules = append(alerting.Rules, Rule{
+				Alert:  LatencyAlert,
+				Expr:   fmt.Sprintf(`%s{slo=%q,quantile=%q} > %s`, LatencyRecord, budget.Name, quantile, formatFloat(budget.Latency.Seconds())),
+				For:    formatDuration(budget.For),
+				Labels: map[string]string{"slo": budget.Name, "severity": budget.Severity},
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("%s latency budget exceeded", budget.Target),
+					"description": fmt.Sprintf("p%s latency is {{ $value | humanizeDuration }}, budget is %s.", percentileLabel(budget.Percentile), budget.Latency),
+				},
+			})
+		}
+
+		if budget.ErrorRate > 0 {
+			total := fmt.Sprintf("sum(rate(%s{%s}[%s]))", requestsMetric(budget), selector, formatDuration(window))
+			failed := fmt.Sprintf("sum(rate(%s{%s,%s}[%s]))", requestsMetric(budget), selector, serverErrorMatcher(budget), formatDuration(window))
+			recording.Rules = append(recording.Rules, Rule{
+				Record: ErrorRatioRecord,
+				Expr:   failed + " / " + total,
+				Labels: map[string]string{"slo": budget.Name},
+			})
+			alerting.Rules = append(alerting.Rules, Rule{
+				Alert:  ErrorBudgetAlert,
+				Expr:   fmt.Sprintf(`%s{slo=%q} > %s`, ErrorRatioRecord, budget.Name, formatFloat(budget.ErrorRate)),
+				For:    formatDuration(budget.For),
+				Labels: map[string]string{"slo": budget.Name, "severity": budget.Severity},
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("%s error budget exceeded", budget.Target),
+					"description": fmt.Sprintf("Error ratio is {{ $value | humanizePercentage }}, budget is %s%%.", formatFloat(math.Round(budget.ErrorRate*1000000)/10000)),
+				},
+			})
+		}
+	}
+
+	return RuleFile{Groups: []RuleGroup{recording, alerting}}, nil
+}
+
+// YAML renders the rule file with a generated code header
+func (f RuleFile) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(generatedHeader)
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return nil, fmt.Errorf("failed to encode rules: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode rules: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// budgetSelector returns the label matchers selecting the series of a budget
+func budgetSelector(budget Budget) string {
+	if budget.Kind == KindRPC {
+		return fmt.Sprintf("grpc_method=%q", budget.Target)
+	}
+
+	// The HTTP metrics middleware labels requests with the matched ServeMux pattern
+	selector := fmt.Sprintf("endpoint=%q", budget.Target)
+	if method, _, ok := strings.Cut(budget.Target, " "); ok {
+		selector = fmt.Sprintf("method=%q,%s", method, selector)
+	}
+	return selector
+}
+
+// serverErrorMatcher returns the label matcher selecting failed requests
+func serverErrorMatcher(budget Budget) string {
+	if budget.Kind == KindRPC {
+		return fmt.Sprintf("grpc_code=~%q", grpcServerErrorCodes)
+	}
+	return `status=~"5.."`
+}
+
+func durationMetric(budget Budget) string {
+	if budget.Kind == KindRPC {
+		return grpcDurationMetric
+	}
+	return httpDurationMetric
+}
+
+func requestsMetric(budget Budget) string {
+	if budget.Kind == KindRPC {
+		return grpcHandledMetric
+	}
+	return httpRequestsMetric
+}
+
+// percentileLabel formats a percentile such as 0.99 as "99"
+func percentileLabel(percentile float64) string {
+	return formatFloat(math.Round(percentile*10000) / 100)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatDuration formats a duration in the Prometheus duration syntax, e.g. "5m"
+func formatDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	default:
+		return fmt.Sprintf("%dms", d/time.Millisecond)
+	}
+}
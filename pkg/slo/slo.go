@@ -0,0 +1,134 @@
+package slo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the kind of endpoint a budget applies to
+type Kind string
+
+// Endpoint kinds
+const (
+	KindRPC  Kind = "rpc"  // Unary gRPC method, measured by the gRPC metrics interceptor
+	KindHTTP Kind = "http" // Plain HTTP handler, measured by the HTTP metrics middleware
+)
+
+// Defaults applied to zero budget fields
+const (
+	DefaultPercentile = 0.99
+	DefaultFor        = 5 * time.Minute
+	DefaultSeverity   = "warning"
+)
+
+// namePattern restricts budget names to characters valid in rule labels and file names
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Budget declares the latency and error budget of an RPC or HTTP handler
+type Budget struct {
+	Name       string        // Unique snake_case name, e.g. "user_create"
+	Kind       Kind          // KindRPC or KindHTTP
+	Target     string        // gRPC full method ("/user.UserService/CreateUser") or ServeMux pattern ("POST /api/v1/users/{id}/avatar")
+	Latency    time.Duration // Latency objective at Percentile; zero disables the latency alert
+	Percentile float64       // Defaults to DefaultPercentile
+	ErrorRate  float64       // Maximum ratio of server errors, e.g. 0.01; zero disables the error alert
+	For        time.Duration // How long the budget must be exceeded before alerting; defaults to DefaultFor
+	Severity   string        // Alert severity label; defaults to DefaultSeverity
+}
+
+// Validate checks that the budget can be turned into rules
+func (b Budget) Validate() error {
+	if !namePattern.MatchString(b.Name) {
+		return fmt.Errorf("budget name %q must be snake_case", b.Name)
+	}
+	switch b.Kind {
+	case KindRPC:
+		if !strings.HasPrefix(b.Target, "/") || strings.Count(b.Target, "/") != 2 {
+			return fmt.Errorf("budget %s: target %q is not a gRPC full method", b.Name, b.Target)
+		}
+	case KindHTTP:
+		if b.Target == "" {
+			return fmt.Errorf("budget %s: target is required", b.Name)
+		}
+	default:
+		return fmt.Errorf("budget %s: unknown kind %q", b.Name, b.Kind)
+	}
+	if b.Latency < 0 || b.ErrorRate < 0 || b.ErrorRate >= 1 {
+		return fmt.Errorf("budget %s: latency must be positive and error rate between 0 and 1", b.Name)
+	}
+	if b.Latency == 0 && b.ErrorRate == 0 {
+		return fmt.Errorf("budget %s: declares neither a latency nor an error budget", b.Name)
+	}
+	if b.Percentile < 0 || b.Percentile >= 1 {
+		return fmt.Errorf("budget %s: percentile must be between 0 and 1", b.Name)
+	}
+	return nil
+}
+
+// withDefaults fills zero optional fields
+func (b Budget) withDefaults() Budget {
+	if b.Percentile == 0 {
+		b.Percentile = DefaultPercentile
+	}
+	if b.For == 0 {
+		b.For = DefaultFor
+	}
+	if b.Severity == "" {
+		b.Severity = DefaultSeverity
+	}
+	return b
+}
+
+// Registry holds the declared budgets
+type Registry struct {
+	mu      sync.RWMutex
+	budgets map[string]Budget
+}
+
+// NewRegistry creates a new budget registry
+func NewRegistry() *Registry {
+	return &Registry{
+		budgets: make(map[string]Budget),
+	}
+}
+
+// Register declares budgets; a budget replaces an earlier one with the same name
+func (r *Registry) Register(budgets ...Budget) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, budget := range budgets {
+		r.budgets[budget.Name] = budget
+	}
+}
+
+// Budgets returns the declared budgets sorted by name
+func (r *Registry) Budgets() []Budget {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	budgets := make([]Budget, 0, len(r.budgets))
+	for _, budget := range r.budgets {
+		budgets = append(budgets, budget)
+	}
+	sort.Slice(budgets, func(i, j int) bool { return budgets[i].Name < budgets[j].Name })
+	return budgets
+}
+
+// Validate checks all declared budgets
+func (r *Registry) Validate() error {
+	var errs []string
+	for _, budget := range r.Budgets() {
+		if err := budget.Validate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid budgets: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
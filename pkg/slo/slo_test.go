@@ -0,0 +1,111 @@
+package slo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"go-clean-ddd-es-template/pkg/slo"
+)
+
+func TestBudget_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  slo.Budget
+		wantErr bool
+	}{
+		{name: "rpc", budget: slo.Budget{Name: "user_get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser", Latency: time.Second}},
+		{name: "http error only", budget: slo.Budget{Name: "upload", Kind: slo.KindHTTP, Target: "POST /upload", ErrorRate: 0.01}},
+		{name: "invalid name", budget: slo.Budget{Name: "User Get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser", Latency: time.Second}, wantErr: true},
+		{name: "invalid method", budget: slo.Budget{Name: "user_get", Kind: slo.KindRPC, Target: "GetUser", Latency: time.Second}, wantErr: true},
+		{name: "unknown kind", budget: slo.Budget{Name: "user_get", Target: "/user.UserService/GetUser", Latency: time.Second}, wantErr: true},
+		{name: "no budget", budget: slo.Budget{Name: "user_get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser"}, wantErr: true},
+		{name: "error rate above one", budget: slo.Budget{Name: "user_get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser", ErrorRate: 2}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.budget.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRegistry_Budgets(t *testing.T) {
+	registry := slo.NewRegistry()
+	registry.Register(
+		slo.Budget{Name: "user_list", Kind: slo.KindRPC, Target: "/user.UserService/ListUsers", Latency: time.Second},
+		slo.Budget{Name: "user_get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser", Latency: time.Second},
+	)
+	registry.Register(slo.Budget{Name: "user_list", Kind: slo.KindRPC, Target: "/user.UserService/ListUsers", Latency: 2 * time.Second})
+
+	budgets := registry.Budgets()
+	require.Len(t, budgets, 2)
+	assert.Equal(t, "user_get", budgets[0].Name)
+	assert.Equal(t, 2*time.Second, budgets[1].Latency)
+	assert.NoError(t, registry.Validate())
+
+	registry.Register(slo.Budget{Name: "broken", Kind: slo.KindRPC})
+	assert.Error(t, registry.Validate())
+}
+
+func TestGenerateRules(t *testing.T) {
+	rules, err := slo.GenerateRules([]slo.Budget{
+		{Name: "user_get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser", Latency: 250 * time.Millisecond, ErrorRate: 0.01, Severity: "critical"},
+		{Name: "avatar_upload", Kind: slo.KindHTTP, Target: "POST /api/v1/users/{id}/avatar", ErrorRate: 0.05, For: 15 * time.Minute},
+	}, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, rules.Groups, 2)
+
+	recording, alerting := rules.Groups[0], rules.Groups[1]
+	require.Len(t, recording.Rules, 3)
+	require.Len(t, alerting.Rules, 3)
+
+	assert.Equal(t, slo.LatencyRecord, recording.Rules[0].Record)
+	assert.Equal(t, `histogram_quantile(0.99, sum by (le) (rate(grpc_server_handling_seconds_bucket{grpc_method="/user.UserService/GetUser"}[1m])))`, recording.Rules[0].Expr)
+	assert.Equal(t, map[string]string{"slo": "user_get", "quantile": "0.99"}, recording.Rules[0].Labels)
+	assert.Contains(t, recording.Rules[1].Expr, `grpc_code=~"Unknown|DeadlineExceeded|Unimplemented|Internal|Unavailable|DataLoss"`)
+	assert.Equal(t, `sum(rate(http_requests_total{method="POST",endpoint="POST /api/v1/users/{id}/avatar",status=~"5.."}[1m])) / sum(rate(http_requests_total{method="POST",endpoint="POST /api/v1/users/{id}/avatar"}[1m]))`, recording.Rules[2].Expr)
+
+	latency := alerting.Rules[0]
+	assert.Equal(t, slo.LatencyAlert, latency.Alert)
+	assert.Equal(t, `slo:request_latency_seconds{slo="user_get",quantile="0.99"} > 0.25`, latency.Expr)
+	assert.Equal(t, "5m", latency.For)
+	assert.Equal(t, "critical", latency.Labels["severity"])
+	assert.Contains(t, latency.Annotations["description"], "p99 latency")
+
+	errorBudget := alerting.Rules[2]
+	assert.Equal(t, slo.ErrorBudgetAlert, errorBudget.Alert)
+	assert.Equal(t, `slo:request_error_ratio{slo="avatar_upload"} > 0.05`, errorBudget.Expr)
+	assert.Equal(t, "15m", errorBudget.For)
+	assert.Equal(t, slo.DefaultSeverity, errorBudget.Labels["severity"])
+	assert.Contains(t, errorBudget.Annotations["description"], "budget is 5%")
+}
+
+func TestGenerateRules_InvalidBudget(t *testing.T) {
+	_, err := slo.GenerateRules([]slo.Budget{{Name: "user_get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser"}}, 0)
+	assert.Error(t, err)
+}
+
+func TestRuleFile_YAML(t *testing.T) {
+	rules, err := slo.GenerateRules([]slo.Budget{
+		{Name: "user_get", Kind: slo.KindRPC, Target: "/user.UserService/GetUser", Latency: time.Second},
+	}, 0)
+	require.NoError(t, err)
+
+	data, err := rules.YAML()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "DO NOT EDIT")
+
+	var decoded slo.RuleFile
+	require.NoError(t, yaml.Unmarshal(data, &decoded))
+	assert.Equal(t, rules, decoded)
+	assert.Contains(t, decoded.Groups[0].Rules[0].Expr, "[5m]")
+}
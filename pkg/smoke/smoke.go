@@ -0,0 +1,108 @@
+package smoke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// ErrSkipped is returned by a step that does not apply to the target, e.g. an admin check
+// without an admin token. Skipped steps do not fail the run.
+var ErrSkipped = errors.New("skipped")
+
+// Step is one action of a scenario. Steps run in order and share state through closures,
+// so a step failing skips the steps after it.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Step outcomes
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// StepResult is the outcome of a step
+type StepResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Report is the outcome of a scenario run
+type Report struct {
+	Target    string        `json:"target"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+	Passed    bool          `json:"passed"`
+	Steps     []StepResult  `json:"steps"`
+}
+
+// Run runs steps in order against target and reports the outcome of each. A nil clock uses
+// the system clock.
+func Run(ctx context.Context, clk clock.Clock, target string, steps []Step) Report {
+	clk = clock.OrDefault(clk)
+	report := Report{Target: target, StartedAt: clk.Now(), Passed: true}
+
+	for _, step := range steps {
+		result := StepResult{Name: step.Name}
+		switch {
+		case !report.Passed:
+			result.Status = StatusSkipped
+			result.Error = "previous step failed"
+		case ctx.Err() != nil:
+			report.Passed = false
+			result.Status = StatusFailed
+			result.Error = ctx.Err().Error()
+		default:
+			start := clk.Now()
+			err := step.Run(ctx)
+			result.Duration = clk.Now().Sub(start)
+			switch {
+			case err == nil:
+				result.Status = StatusPassed
+			case errors.Is(err, ErrSkipped):
+				result.Status = StatusSkipped
+				result.Error = err.Error()
+			default:
+				report.Passed = false
+				result.Status = StatusFailed
+				result.Error = err.Error()
+			}
+		}
+		report.Steps = append(report.Steps, result)
+	}
+
+	report.Duration = clk.Now().Sub(report.StartedAt)
+	return report
+}
+
+// WriteText writes the report as one line per step followed by the verdict
+func (r Report) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "Smoke test against %s\n", r.Target); err != nil {
+		return err
+	}
+	for _, step := range r.Steps {
+		line := fmt.Sprintf("  %-7s %-28s %s", step.Status, step.Name, step.Duration.Round(time.Millisecond))
+		if step.Error != "" {
+			line += "  " + step.Error
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	verdict := "PASS"
+	if !r.Passed {
+		verdict = "FAIL"
+	}
+	_, err := fmt.Fprintf(w, "%s in %s\n", verdict, r.Duration.Round(time.Millisecond))
+	return err
+}
@@ -0,0 +1,68 @@
+package smoke_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/smoke"
+)
+
+func TestRunSkipsStepsAfterFailure(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ran := 0
+	steps := []smoke.Step{
+		{Name: "register", Run: func(context.Context) error { ran++; clk.Advance(time.Second); return nil }},
+		{Name: "dlq", Run: func(context.Context) error { ran++; return fmt.Errorf("no token: %w", smoke.ErrSkipped) }},
+		{Name: "update", Run: func(context.Context) error { ran++; return errors.New("boom") }},
+		{Name: "delete", Run: func(context.Context) error { ran++; return nil }},
+	}
+
+	report := smoke.Run(context.Background(), clk, "http://localhost:8080", steps)
+
+	assert.False(t, report.Passed)
+	assert.Equal(t, 3, ran)
+	require.Len(t, report.Steps, 4)
+	assert.Equal(t, smoke.StatusPassed, report.Steps[0].Status)
+	assert.Equal(t, time.Second, report.Steps[0].Duration)
+	assert.Equal(t, smoke.StatusSkipped, report.Steps[1].Status)
+	assert.Equal(t, smoke.StatusFailed, report.Steps[2].Status)
+	assert.Equal(t, "boom", report.Steps[2].Error)
+	assert.Equal(t, smoke.StatusSkipped, report.Steps[3].Status)
+	assert.Equal(t, time.Second, report.Duration)
+}
+
+func TestRunPassesWithSkippedSteps(t *testing.T) {
+	steps := []smoke.Step{
+		{Name: "register", Run: func(context.Context) error { return nil }},
+		{Name: "dlq", Run: func(context.Context) error { return smoke.ErrSkipped }},
+	}
+
+	report := smoke.Run(context.Background(), nil, "target", steps)
+	assert.True(t, report.Passed)
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteText(&out))
+	assert.Contains(t, out.String(), "passed  register")
+	assert.Contains(t, out.String(), "skipped dlq")
+	assert.Contains(t, out.String(), "PASS in")
+}
+
+func TestRunFailsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := smoke.Run(ctx, nil, "target", []smoke.Step{
+		{Name: "register", Run: func(context.Context) error { t.Fatal("step ran"); return nil }},
+	})
+
+	assert.False(t, report.Passed)
+	assert.Equal(t, smoke.StatusFailed, report.Steps[0].Status)
+}
@@ -0,0 +1,189 @@
+package startup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/health"
+)
+
+// Probe is a single startup check
+type Probe struct {
+	Name     string
+	Critical bool // Critical probes make the report unhealthy when they fail
+	Check    health.HealthChecker
+}
+
+// Report is the machine-readable result of a startup self-check
+type Report struct {
+	Status     health.Status  `json:"status"`
+	Strict     bool           `json:"strict"`
+	Checks     []health.Check `json:"checks"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Duration   time.Duration  `json:"duration"`
+}
+
+// Failed returns the names of the checks that did not pass
+func (r Report) Failed() []string {
+	var failed []string
+	for _, check := range r.Checks {
+		if check.Status != health.StatusHealthy {
+			failed = append(failed, check.Name)
+		}
+	}
+	return failed
+}
+
+// JSON returns the report encoded as JSON
+func (r Report) JSON() string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Sprintf(`{"status":%q,"error":%q}`, r.Status, err.Error())
+	}
+	return string(data)
+}
+
+// SelfCheck runs startup probes and keeps the last report
+type SelfCheck struct {
+	mu      sync.RWMutex
+	probes  []Probe
+	strict  bool
+	timeout time.Duration
+	report  *Report
+}
+
+// NewSelfCheck creates a new startup self-check.
+// In strict mode any failing probe, critical or not, marks the report unhealthy.
+func NewSelfCheck(strict bool, timeout time.Duration) *SelfCheck {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &SelfCheck{
+		probes:  make([]Probe, 0),
+		strict:  strict,
+		timeout: timeout,
+	}
+}
+
+// AddProbe registers a startup probe
+func (s *SelfCheck) AddProbe(name string, critical bool, check health.HealthChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.probes = append(s.probes, Probe{Name: name, Critical: critical, Check: check})
+}
+
+// Run executes all probes sequentially and stores the resulting report
+func (s *SelfCheck) Run(ctx context.Context) Report {
+	s.mu.RLock()
+	probes := make([]Probe, len(s.probes))
+	copy(probes, s.probes)
+	s.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	report := Report{
+		Status:    health.StatusHealthy,
+		Strict:    s.strict,
+		Checks:    make([]health.Check, 0, len(probes)),
+		StartedAt: time.Now(),
+	}
+
+	for _, probe := range probes {
+		start := time.Now()
+		check := probe.Check(ctx)
+		if check.Name == "" {
+			check.Name = probe.Name
+		}
+		if check.Duration == 0 {
+			check.Duration = time.Since(start)
+		}
+		report.Checks = append(report.Checks, check)
+
+		if check.Status == health.StatusHealthy {
+			continue
+		}
+		if probe.Critical || s.strict {
+			report.Status = health.StatusUnhealthy
+		} else if report.Status == health.StatusHealthy {
+			report.Status = health.StatusDegraded
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	report.Duration = report.FinishedAt.Sub(report.StartedAt)
+
+	s.mu.Lock()
+	s.report = &report
+	s.mu.Unlock()
+
+	return report
+}
+
+// Report returns the last report, or nil if the self-check has not run yet
+func (s *SelfCheck) Report() *Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.report
+}
+
+// ShouldRefuseStart reports whether startup must be aborted
+func (s *SelfCheck) ShouldRefuseStart() bool {
+	report := s.Report()
+	return s.strict && report != nil && report.Status != health.StatusHealthy
+}
+
+// HTTPHandler returns an HTTP handler exposing the last report (for /startupz)
+func (s *SelfCheck) HTTPHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := s.Report()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if report == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status":  health.StatusUnhealthy,
+				"message": "startup self-check has not run yet",
+			})
+			return
+		}
+
+		if report.Status == health.StatusUnhealthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// ErrorCheck adapts a function returning an error into a health checker
+func ErrorCheck(name string, fn func(ctx context.Context) (string, error)) health.HealthChecker {
+	return func(ctx context.Context) health.Check {
+		start := time.Now()
+		message, err := fn(ctx)
+
+		check := health.Check{
+			Name:     name,
+			Duration: time.Since(start),
+		}
+
+		if err != nil {
+			check.Status = health.StatusUnhealthy
+			check.Message = err.Error()
+		} else {
+			check.Status = health.StatusHealthy
+			check.Message = message
+		}
+
+		return check
+	}
+}
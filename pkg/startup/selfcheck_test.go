@@ -0,0 +1,56 @@
+package startup_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/health"
+	"go-clean-ddd-es-template/pkg/startup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func passing(ctx context.Context) (string, error) { return "ok", nil }
+func failing(ctx context.Context) (string, error) { return "", errors.New("boom") }
+
+func TestSelfCheck_NonCriticalFailureDegrades(t *testing.T) {
+	selfCheck := startup.NewSelfCheck(false, time.Second)
+	selfCheck.AddProbe("config", true, startup.ErrorCheck("config", passing))
+	selfCheck.AddProbe("translations", false, startup.ErrorCheck("translations", failing))
+
+	report := selfCheck.Run(context.Background())
+
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	assert.Equal(t, []string{"translations"}, report.Failed())
+	assert.False(t, selfCheck.ShouldRefuseStart())
+}
+
+func TestSelfCheck_StrictRefusesStart(t *testing.T) {
+	selfCheck := startup.NewSelfCheck(true, time.Second)
+	selfCheck.AddProbe("translations", false, startup.ErrorCheck("translations", failing))
+
+	report := selfCheck.Run(context.Background())
+
+	assert.Equal(t, health.StatusUnhealthy, report.Status)
+	assert.True(t, selfCheck.ShouldRefuseStart())
+}
+
+func TestSelfCheck_HTTPHandler(t *testing.T) {
+	selfCheck := startup.NewSelfCheck(false, time.Second)
+	selfCheck.AddProbe("database", true, startup.ErrorCheck("database", failing))
+
+	rec := httptest.NewRecorder()
+	selfCheck.HTTPHandler()(rec, httptest.NewRequest(http.MethodGet, "/startupz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	selfCheck.Run(context.Background())
+
+	rec = httptest.NewRecorder()
+	selfCheck.HTTPHandler()(rec, httptest.NewRequest(http.MethodGet, "/startupz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"database"`)
+}
@@ -0,0 +1,195 @@
+// Package statshistory persists periodic snapshots of pipeline stats, e.g. consumer lag and
+// dead letter queue size, so their history survives restarts
+package statshistory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Snapshot is the value of every stat of an instance at a time. Stats are named by dotted paths,
+// e.g. consumer.lag.user-events.
+type Snapshot struct {
+	TakenAt  time.Time          `json:"taken_at"`
+	Instance string             `json:"instance"`
+	Stats    map[string]float64 `json:"stats"`
+}
+
+// Query selects snapshots; empty fields match every snapshot
+type Query struct {
+	Prefix   string // Stats named Prefix or starting with Prefix followed by a dot
+	Instance string
+	Since    time.Time
+	Until    time.Time
+	Limit    int // Most recent snapshots returned, 0 for all
+}
+
+// matches reports whether a snapshot taken by instance at t is selected
+func (q Query) matches(instance string, t time.Time) bool {
+	return (q.Instance == "" || instance == q.Instance) &&
+		(q.Since.IsZero() || !t.Before(q.Since)) &&
+		(q.Until.IsZero() || t.Before(q.Until))
+}
+
+// MatchesStat reports whether a stat is selected by the prefix of the query
+func (q Query) MatchesStat(name string) bool {
+	return q.Prefix == "" || name == q.Prefix || strings.HasPrefix(name, q.Prefix+".")
+}
+
+// Store persists snapshots
+type Store interface {
+	Save(ctx context.Context, snapshot Snapshot) error
+	// List returns the snapshots selected by query, oldest first, with the selected stats only;
+	// snapshots without any are skipped
+	List(ctx context.Context, query Query) ([]Snapshot, error)
+	// DeleteBefore deletes the snapshots taken before t, returning how many stats were deleted
+	DeleteBefore(ctx context.Context, t time.Time) (int, error)
+}
+
+// Source returns the current value of stats
+type Source func(ctx context.Context) map[string]float64
+
+// Logger logs failed snapshots
+type Logger interface {
+	Warn(format string, v ...interface{})
+}
+
+// Recorder takes snapshots of its sources every interval and deletes those past the retention
+type Recorder struct {
+	store     Store
+	instance  string
+	retention time.Duration
+	sources   []Source
+	logger    Logger
+	clock     clock.Clock
+}
+
+// NewRecorder creates a recorder of the stats of instance, keeping snapshots for retention, 0
+// keeping them forever. A nil logger logs nothing, a nil clock uses the system clock.
+func NewRecorder(store Store, instance string, retention time.Duration, logger Logger, clk clock.Clock) *Recorder {
+	return &Recorder{
+		store:     store,
+		instance:  instance,
+		retention: retention,
+		logger:    logger,
+		clock:     clock.OrDefault(clk),
+	}
+}
+
+// AddSource adds a source of stats to the snapshots; later sources override the stats of the same name
+func (r *Recorder) AddSource(source Source) {
+	r.sources = append(r.sources, source)
+}
+
+// Record takes a snapshot of the sources and deletes the snapshots past the retention
+func (r *Recorder) Record(ctx context.Context) error {
+	now := r.clock.Now().UTC()
+	snapshot := Snapshot{TakenAt: now, Instance: r.instance, Stats: make(map[string]float64)}
+	for _, source := range r.sources {
+		for name, value := range source(ctx) {
+			snapshot.Stats[name] = value
+		}
+	}
+	if len(snapshot.Stats) > 0 {
+		if err := r.store.Save(ctx, snapshot); err != nil {
+			return err
+		}
+	}
+
+	if r.retention > 0 {
+		if _, err := r.store.DeleteBefore(ctx, now.Add(-r.retention)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run records a snapshot every interval until ctx is done
+func (r *Recorder) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.clock.After(interval):
+		}
+
+		if err := r.Record(ctx); err != nil && r.logger != nil {
+			r.logger.Warn("Failed to record a stats snapshot: %v", err)
+		}
+	}
+}
+
+// MemoryStore keeps snapshots in memory, for tests and development
+type MemoryStore struct {
+	mu        sync.Mutex
+	snapshots []Snapshot
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Save adds a snapshot
+func (s *MemoryStore) Save(ctx context.Context, snapshot Snapshot) error {
+	stats := make(map[string]float64, len(snapshot.Stats))
+	for name, value := range snapshot.Stats {
+		stats[name] = value
+	}
+	snapshot.Stats = stats
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+	sort.SliceStable(s.snapshots, func(i, j int) bool { return s.snapshots[i].TakenAt.Before(s.snapshots[j].TakenAt) })
+	return nil
+}
+
+// List returns the snapshots selected by query, oldest first
+func (s *MemoryStore) List(ctx context.Context, query Query) ([]Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Snapshot
+	for _, snapshot := range s.snapshots {
+		if !query.matches(snapshot.Instance, snapshot.TakenAt) {
+			continue
+		}
+		stats := make(map[string]float64)
+		for name, value := range snapshot.Stats {
+			if query.MatchesStat(name) {
+				stats[name] = value
+			}
+		}
+		if len(stats) > 0 {
+			result = append(result, Snapshot{TakenAt: snapshot.TakenAt, Instance: snapshot.Instance, Stats: stats})
+		}
+	}
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[len(result)-query.Limit:]
+	}
+	return result, nil
+}
+
+// DeleteBefore deletes the snapshots taken before t
+func (s *MemoryStore) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	kept := s.snapshots[:0]
+	for _, snapshot := range s.snapshots {
+		if snapshot.TakenAt.Before(t) {
+			deleted += len(snapshot.Stats)
+			continue
+		}
+		kept = append(kept, snapshot)
+	}
+	s.snapshots = kept
+	return deleted, nil
+}
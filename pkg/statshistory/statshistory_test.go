@@ -0,0 +1,122 @@
+package statshistory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/statshistory"
+)
+
+func TestRecorder_Record(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	store := statshistory.NewMemoryStore()
+	recorder := statshistory.NewRecorder(store, "api-1", 2*time.Hour, nil, fake)
+
+	lag := 0.0
+	recorder.AddSource(func(ctx context.Context) map[string]float64 {
+		lag += 10
+		return map[string]float64{"consumer.lag.user-events": lag, "dlq.events": 1}
+	})
+	recorder.AddSource(func(ctx context.Context) map[string]float64 {
+		return map[string]float64{"dlq.events": 2}
+	})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, recorder.Record(ctx))
+		fake.Advance(time.Hour)
+	}
+
+	snapshots, err := store.List(ctx, statshistory.Query{})
+	require.NoError(t, err)
+	require.Len(t, snapshots, 3)
+	assert.Equal(t, "api-1", snapshots[0].Instance)
+	assert.Equal(t, fake.Now().Add(-3*time.Hour), snapshots[0].TakenAt)
+	assert.Equal(t, map[string]float64{"consumer.lag.user-events": 10, "dlq.events": 2}, snapshots[0].Stats)
+
+	// The snapshot of the next record is past the retention
+	require.NoError(t, recorder.Record(ctx))
+	snapshots, err = store.List(ctx, statshistory.Query{Prefix: "consumer.lag"})
+	require.NoError(t, err)
+	require.Len(t, snapshots, 3)
+	assert.Equal(t, map[string]float64{"consumer.lag.user-events": 20}, snapshots[0].Stats)
+	assert.Equal(t, map[string]float64{"consumer.lag.user-events": 40}, snapshots[2].Stats)
+}
+
+func TestRecorder_Run(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	recorded := make(chan struct{}, 1)
+	store := statshistory.NewMemoryStore()
+	recorder := statshistory.NewRecorder(store, "api-1", 0, nil, fake)
+	recorder.AddSource(func(ctx context.Context) map[string]float64 {
+		recorded <- struct{}{}
+		return map[string]float64{"dlq.events": 1}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- recorder.Run(ctx, time.Minute) }()
+
+	require.Eventually(t, func() bool { return fake.Waiters() > 0 }, time.Second, time.Millisecond)
+	fake.Advance(time.Minute)
+	<-recorded
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestMemoryStore_List(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	store := statshistory.NewMemoryStore()
+	for i, instance := range []string{"api-1", "api-2", "api-1", "api-1"} {
+		require.NoError(t, store.Save(ctx, statshistory.Snapshot{
+			TakenAt:  start.Add(time.Duration(i) * time.Minute),
+			Instance: instance,
+			Stats:    map[string]float64{"consumer.lag": float64(i), "consumer.lagging": 1, "dlq.events": float64(i)},
+		}))
+	}
+
+	snapshots, err := store.List(ctx, statshistory.Query{Prefix: "consumer.lag", Instance: "api-1", Since: start.Add(time.Minute), Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, start.Add(3*time.Minute), snapshots[0].TakenAt)
+	assert.Equal(t, map[string]float64{"consumer.lag": 3}, snapshots[0].Stats)
+
+	snapshots, err = store.List(ctx, statshistory.Query{Prefix: "publisher", Until: start.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	deleted, err := store.DeleteBefore(ctx, start.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 6, deleted)
+	snapshots, err = store.List(ctx, statshistory.Query{})
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+}
+
+func TestRecorder_RecordFailure(t *testing.T) {
+	recorder := statshistory.NewRecorder(failingStore{}, "api-1", time.Hour, nil, nil)
+	recorder.AddSource(func(ctx context.Context) map[string]float64 { return map[string]float64{"dlq.events": 1} })
+	assert.ErrorContains(t, recorder.Record(context.Background()), "unavailable")
+}
+
+// failingStore fails every call
+type failingStore struct{}
+
+func (failingStore) Save(ctx context.Context, snapshot statshistory.Snapshot) error {
+	return errors.New("unavailable")
+}
+
+func (failingStore) List(ctx context.Context, query statshistory.Query) ([]statshistory.Snapshot, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingStore) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	return 0, errors.New("unavailable")
+}
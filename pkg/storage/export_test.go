@@ -0,0 +1,8 @@
+package storage
+
+import "time"
+
+// SetS3Clock overrides the clock used to sign S3 requests
+func SetS3Clock(s *S3Storage, now func() time.Time) {
+	s.now = now
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStorage stores objects on the local disk
+type LocalStorage struct {
+	basePath   string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewLocalStorage creates a new local disk storage
+func NewLocalStorage(basePath, baseURL, signingKey string) (*LocalStorage, error) {
+	if basePath == "" {
+		return nil, fmt.Errorf("local storage requires a base path")
+	}
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalStorage{
+		basePath:   basePath,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		signingKey: []byte(signingKey),
+	}, nil
+}
+
+// Put stores an object on disk
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, opts PutOptions) (*Object, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	// Write to a temp file first so readers never see partial objects
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write object %s: %w", key, err)
+	}
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("object %s: expected %d bytes, got %d", key, size, written)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to store object %s: %w", key, err)
+	}
+
+	return s.Stat(ctx, key)
+}
+
+// Get opens an object on disk
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	object, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path, _ := s.path(key)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open object %s: %w", key, err)
+	}
+
+	return file, object, nil
+}
+
+// Stat returns metadata of an object on disk
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*Object, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %w", key, err)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  contentType,
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// Delete removes an object from disk
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	return nil
+}
+
+// SignedURL returns a URL served by Handler that is valid until expiry
+func (s *LocalStorage) SignedURL(ctx context.Context, key, method string, expiry time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(method, key, expires))
+
+	return fmt.Sprintf("%s/%s?%s", s.baseURL, escapeKey(key), query.Encode()), nil
+}
+
+// VerifySignedURL checks the signature and expiry of a signed request
+func (s *LocalStorage) VerifySignedURL(method, key string, query url.Values) error {
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expiry")
+	}
+	if time.Now().Unix() > unix {
+		return fmt.Errorf("signed URL expired")
+	}
+
+	expected := s.sign(method, key, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// Handler serves signed GET requests for objects; mount it at the base URL path
+func (s *LocalStorage) Handler(prefix string) http.Handler {
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if err := s.VerifySignedURL(http.MethodGet, key, r.URL.Query()); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		reader, object, err := s.Get(r.Context(), key)
+		if err == ErrNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to read object", http.StatusInternalServerError)
+			return
+		}
+		defer reader.Close()
+
+		w.Header().Set("Content-Type", object.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(object.Size, 10))
+		if r.Method == http.MethodHead {
+			return
+		}
+		io.Copy(w, reader)
+	}))
+}
+
+// sign computes the HMAC signature of a method, key and expiry
+func (s *LocalStorage) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path resolves an object key to a file path, rejecting keys escaping the base path
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if key == "" || cleaned == "/" {
+		return "", fmt.Errorf("invalid object key: %q", key)
+	}
+	return filepath.Join(s.basePath, filepath.FromSlash(cleaned)), nil
+}
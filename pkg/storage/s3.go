@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	amzDateFormat   = "20060102T150405Z"
+	amzShortFormat  = "20060102"
+	maxPresignedTTL = 7 * 24 * time.Hour
+)
+
+// S3Config holds configuration for S3 compatible object storage
+type S3Config struct {
+	Bucket       string
+	Region       string
+	Endpoint     string // Defaults to AWS S3 for the region
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool // Required for MinIO and GCS
+	HTTPClient   *http.Client
+}
+
+// S3Storage stores objects in S3 compatible storage (AWS S3, MinIO, GCS XML API)
+// using AWS Signature Version 4.
+type S3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Storage creates a new S3 compatible storage
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage requires a bucket")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("s3 storage requires access and secret keys")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %q", cfg.Endpoint)
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	return &S3Storage{
+		config:   cfg,
+		endpoint: endpoint,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// Put uploads an object
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, opts PutOptions) (*Object, error) {
+	// S3 requires a content length, spool unknown sized bodies to disk
+	if size < 0 {
+		file, spooled, cleanup, err := spoolToTempFile(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to buffer object %s: %w", key, err)
+		}
+		defer cleanup()
+		r, size = file, spooled
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), r)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if opts.ContentType != "" {
+		req.Header.Set("Content-Type", opts.ContentType)
+	}
+	if opts.CacheControl != "" {
+		req.Header.Set("Cache-Control", opts.CacheControl)
+	}
+
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, key); err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         size,
+		ContentType:  opts.ContentType,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		LastModified: s.now().UTC(),
+	}, nil
+}
+
+// Get downloads an object
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.do(req, emptyPayload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkResponse(resp, key); err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+
+	return resp.Body, objectFromHeaders(key, resp), nil
+}
+
+// Stat returns object metadata
+func (s *S3Storage) Stat(ctx context.Context, key string) (*Object, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, emptyPayload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, key); err != nil {
+		return nil, err
+	}
+
+	return objectFromHeaders(key, resp), nil
+}
+
+// Delete removes an object
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, emptyPayload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, key); err != nil && err != ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// SignedURL returns a presigned URL valid for expiry (at most 7 days)
+func (s *S3Storage) SignedURL(ctx context.Context, key, method string, expiry time.Duration) (string, error) {
+	if expiry <= 0 || expiry > maxPresignedTTL {
+		return "", fmt.Errorf("signed URL expiry must be between 1s and %v", maxPresignedTTL)
+	}
+
+	now := s.now().UTC()
+	target := s.objectURL(key)
+
+	query := target.Query()
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.config.AccessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		strings.ToUpper(method),
+		target.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + target.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, canonical))
+	target.RawQuery = canonicalQuery(query)
+
+	return target.String(), nil
+}
+
+// objectURL builds the URL of an object using path or virtual hosted style
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	escaped := escapeKey(key)
+
+	if s.config.UsePathStyle {
+		u.Path = "/" + s.config.Bucket + "/" + key
+		u.RawPath = "/" + s.config.Bucket + "/" + escaped
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = "/" + key
+		u.RawPath = "/" + escaped
+	}
+
+	return &u
+}
+
+// do signs a request with SigV4 headers and sends it
+func (s *S3Storage) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + now.Format(amzDateFormat) + "\n"
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.config.AccessKey, s.scope(now), strings.Join(signedHeaders, ";"), s.signature(now, canonical)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// scope returns the SigV4 credential scope
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format(amzShortFormat) + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request
+func (s *S3Storage) signature(now time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(amzDateFormat),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), now.Format(amzShortFormat))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by key as required by SigV4
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapeKey URI encodes each segment of an object key
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode encodes a string per RFC 3986 leaving only unreserved characters
+func uriEncode(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' {
+			builder.WriteByte(b)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
+
+// checkResponse converts error responses into errors
+func checkResponse(resp *http.Response, key string) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("storage request for %s failed with status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// objectFromHeaders builds object metadata from response headers
+func objectFromHeaders(key string, resp *http.Response) *Object {
+	object := &Object{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		object.LastModified = modified
+	}
+	return object
+}
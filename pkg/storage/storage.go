@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// ErrTooLarge is returned when an upload exceeds its size limit
+var ErrTooLarge = errors.New("object exceeds maximum size")
+
+// Object describes a stored object
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// PutOptions holds optional object attributes
+type PutOptions struct {
+	ContentType  string
+	CacheControl string
+}
+
+// Storage is an object storage backend
+type Storage interface {
+	// Put stores an object. Size may be -1 when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, opts PutOptions) (*Object, error)
+	// Get opens an object for reading; the caller must close the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Stat returns object metadata without reading its content
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a time limited URL granting method access to an object
+	SignedURL(ctx context.Context, key, method string, expiry time.Duration) (string, error)
+}
+
+// Config holds object storage configuration
+type Config struct {
+	Provider string // "local", "s3", "minio", "gcs"
+
+	// Local disk specific
+	BasePath   string // Directory objects are stored in
+	BaseURL    string // Public URL the local download handler is mounted at
+	SigningKey string // Secret used to sign local URLs
+
+	// S3 compatible specific (S3, MinIO, GCS interoperability API)
+	Bucket       string
+	Region       string
+	Endpoint     string
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool
+}
+
+// New creates a storage backend from configuration
+func New(cfg Config) (Storage, error) {
+	switch cfg.Provider {
+	case "local", "":
+		return NewLocalStorage(cfg.BasePath, cfg.BaseURL, cfg.SigningKey)
+	case "s3":
+		return NewS3Storage(S3Config{
+			Bucket:       cfg.Bucket,
+			Region:       cfg.Region,
+			Endpoint:     cfg.Endpoint,
+			AccessKey:    cfg.AccessKey,
+			SecretKey:    cfg.SecretKey,
+			UsePathStyle: cfg.UsePathStyle,
+		})
+	case "minio":
+		if cfg.Endpoint == "" {
+			return nil, fmt.Errorf("minio storage requires an endpoint")
+		}
+		return NewS3Storage(S3Config{
+			Bucket:       cfg.Bucket,
+			Region:       defaultString(cfg.Region, "us-east-1"),
+			Endpoint:     cfg.Endpoint,
+			AccessKey:    cfg.AccessKey,
+			SecretKey:    cfg.SecretKey,
+			UsePathStyle: true,
+		})
+	case "gcs":
+		// Uses the Cloud Storage XML API with HMAC interoperability keys
+		return NewS3Storage(S3Config{
+			Bucket:       cfg.Bucket,
+			Region:       defaultString(cfg.Region, "auto"),
+			Endpoint:     defaultString(cfg.Endpoint, "https://storage.googleapis.com"),
+			AccessKey:    cfg.AccessKey,
+			SecretKey:    cfg.SecretKey,
+			UsePathStyle: true,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage provider: %s", cfg.Provider)
+	}
+}
+
+// UploadLimited streams r into storage, failing with ErrTooLarge when more than maxSize bytes are read.
+// When contentType is empty it is detected from the first bytes of the content.
+func UploadLimited(ctx context.Context, s Storage, key string, r io.Reader, maxSize int64, contentType string) (*Object, error) {
+	// Sniff the content type from the first 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	head = head[:n]
+
+	if contentType == "" {
+		contentType = http.DetectContentType(head)
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), r)
+	limited := &limitedReader{reader: body, remaining: maxSize}
+
+	object, err := s.Put(ctx, key, limited, -1, PutOptions{ContentType: contentType})
+	if limited.exceeded {
+		// Do not keep a truncated object around
+		_ = s.Delete(ctx, key)
+		return nil, ErrTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// Download streams an object into w
+func Download(ctx context.Context, s Storage, key string, w io.Writer) (*Object, error) {
+	reader, object, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(w, reader); err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	return object, nil
+}
+
+// limitedReader fails once more than remaining bytes were read
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// spoolToTempFile copies r into a temporary file so its size is known.
+// The returned cleanup function closes and removes the file.
+func spoolToTempFile(r io.Reader) (*os.File, int64, func(), error) {
+	file, err := os.CreateTemp("", "storage-upload-*")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	size, err := io.Copy(file, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+
+	return file, size, cleanup, nil
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
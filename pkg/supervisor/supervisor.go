@@ -0,0 +1,283 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/health"
+)
+
+// Component states
+const (
+	StateRunning = "running" // The component runs
+	StateBackoff = "backoff" // The component crashed and waits to be restarted
+	StateFailed  = "failed"  // The component crashed more often than its policy allows and is not restarted
+	StateStopped = "stopped" // The component returned after its context was done
+)
+
+// RestartPolicy decides how crashed components are restarted. A component crashes when its run
+// function returns or panics before its context is done.
+type RestartPolicy struct {
+	InitialBackoff time.Duration // Wait before the first restart, doubled for every further restart in the window
+	MaxBackoff     time.Duration // Longest wait before a restart
+	MaxRestarts    int           // Restarts allowed within Window before the component fails, 0 for no limit
+	Window         time.Duration // Restarts older than this are forgotten, 0 to count every restart
+}
+
+// DefaultRestartPolicy returns the default restart policy
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		MaxRestarts:    5,
+		Window:         10 * time.Minute,
+	}
+}
+
+// backoff returns the wait before the restart following restarts recent restarts
+func (p RestartPolicy) backoff(restarts int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < restarts && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 {
+		backoff = min(backoff, p.MaxBackoff)
+	}
+	return backoff
+}
+
+// Component is a long running part of the service, e.g. the event consumer
+type Component struct {
+	Name   string
+	Run    func(ctx context.Context) error // Blocks until ctx is done
+	Policy RestartPolicy
+}
+
+// Status is the state of a supervised component
+type Status struct {
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	Restarts  int       `json:"restarts"` // Restarts since the component was started
+	LastError string    `json:"last_error,omitempty"`
+	LastCrash time.Time `json:"last_crash,omitzero"`
+}
+
+// Recorder records component restarts and states, e.g. as metrics
+type Recorder interface {
+	RecordComponentRestart(component string)
+	RecordComponentUp(component string, up bool)
+}
+
+// Logger logs crashes and restarts
+type Logger interface {
+	Warn(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Supervisor runs components, restarting them with backoff when they crash as their restart
+// policy allows, like supervisord. Components crashing too often fail: they are not restarted
+// and the supervisor's health check reports them unhealthy.
+type Supervisor struct {
+	clock    clock.Clock
+	logger   Logger
+	recorder Recorder
+
+	mu         sync.Mutex
+	components map[string]*Status
+	running    map[string]*running
+	wg         sync.WaitGroup
+}
+
+// running is a started component
+type running struct {
+	cancel context.CancelFunc
+	done   chan struct{} // Closed once the component is no longer run
+}
+
+// New creates a supervisor. A nil clock uses the system clock; logger and recorder may be nil.
+func New(clk clock.Clock, logger Logger, recorder Recorder) *Supervisor {
+	return &Supervisor{
+		clock:      clock.OrDefault(clk),
+		logger:     logger,
+		recorder:   recorder,
+		components: make(map[string]*Status),
+		running:    make(map[string]*running),
+	}
+}
+
+// Go runs a component in the background until ctx is done
+func (s *Supervisor) Go(ctx context.Context, component Component) {
+	ctx, cancel := context.WithCancel(ctx)
+	started := &running{cancel: cancel, done: make(chan struct{})}
+	s.mu.Lock()
+	s.components[component.Name] = &Status{Name: component.Name, State: StateRunning}
+	s.running[component.Name] = started
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(started.done)
+		defer cancel()
+		s.supervise(ctx, component)
+	}()
+}
+
+// Wait waits until every component stopped or failed
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// Stop stops the named components, every component when none is named, waiting until they
+// returned or ctx is done. Components never started are ignored.
+func (s *Supervisor) Stop(ctx context.Context, names ...string) error {
+	s.mu.Lock()
+	var stopping []*running
+	if len(names) == 0 {
+		for _, started := range s.running {
+			stopping = append(stopping, started)
+		}
+	}
+	for _, name := range names {
+		if started, ok := s.running[name]; ok {
+			stopping = append(stopping, started)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, started := range stopping {
+		started.cancel()
+	}
+	for _, started := range stopping {
+		select {
+		case <-started.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// supervise runs a component, restarting it as its policy allows
+func (s *Supervisor) supervise(ctx context.Context, component Component) {
+	var crashes []time.Time // Crashes within the policy window
+	for {
+		s.setState(component.Name, StateRunning, nil)
+		err := s.run(ctx, component)
+		if ctx.Err() != nil {
+			s.setState(component.Name, StateStopped, nil)
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("component returned")
+		}
+
+		now := s.clock.Now()
+		crashes = append(crashes, now)
+		if window := component.Policy.Window; window > 0 {
+			for len(crashes) > 0 && now.Sub(crashes[0]) > window {
+				crashes = crashes[1:]
+			}
+		}
+
+		if maxRestarts := component.Policy.MaxRestarts; maxRestarts > 0 && len(crashes) > maxRestarts {
+			s.setState(component.Name, StateFailed, err)
+			s.logError("Component %s crashed %d times within %s, giving up: %v", component.Name, len(crashes), component.Policy.Window, err)
+			return
+		}
+
+		backoff := component.Policy.backoff(len(crashes))
+		s.setState(component.Name, StateBackoff, err)
+		s.logWarn("Component %s crashed, restarting in %s: %v", component.Name, backoff, err)
+		select {
+		case <-ctx.Done():
+			s.setState(component.Name, StateStopped, nil)
+			return
+		case <-s.clock.After(backoff):
+		}
+
+		s.mu.Lock()
+		s.components[component.Name].Restarts++
+		s.mu.Unlock()
+		if s.recorder != nil {
+			s.recorder.RecordComponentRestart(component.Name)
+		}
+	}
+}
+
+// run runs a component, returning panics as errors and logging their stack
+func (s *Supervisor) run(ctx context.Context, component Component) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			s.logError("Component %s panicked: %v\n%s", component.Name, r, debug.Stack())
+		}
+	}()
+	return component.Run(ctx)
+}
+
+// setState sets the state of a component, and its last crash when err is not nil
+func (s *Supervisor) setState(name, state string, err error) {
+	s.mu.Lock()
+	status := s.components[name]
+	status.State = state
+	if err != nil {
+		status.LastError = err.Error()
+		status.LastCrash = s.clock.Now()
+	}
+	s.mu.Unlock()
+
+	if s.recorder != nil {
+		s.recorder.RecordComponentUp(name, state == StateRunning)
+	}
+}
+
+// Status returns the status of every component by name
+func (s *Supervisor) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.components))
+	for _, status := range s.components {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// HealthCheck reports components: unhealthy when one failed, degraded while one waits to be
+// restarted, healthy otherwise
+func (s *Supervisor) HealthCheck() health.HealthChecker {
+	return func(ctx context.Context) health.Check {
+		check := health.Check{Name: "components", Status: health.StatusHealthy, Details: make(map[string]interface{})}
+		for _, status := range s.Status() {
+			check.Details[status.Name] = status
+			switch {
+			case status.State == StateFailed:
+				check.Status = health.StatusUnhealthy
+				check.Message = fmt.Sprintf("component %s failed: %s", status.Name, status.LastError)
+			case status.State == StateBackoff && check.Status == health.StatusHealthy:
+				check.Status = health.StatusDegraded
+				check.Message = fmt.Sprintf("component %s is restarting: %s", status.Name, status.LastError)
+			}
+		}
+		return check
+	}
+}
+
+func (s *Supervisor) logWarn(format string, v ...interface{}) {
+	if s.logger != nil {
+		s.logger.Warn(format, v...)
+	}
+}
+
+func (s *Supervisor) logError(format string, v ...interface{}) {
+	if s.logger != nil {
+		s.logger.Error(format, v...)
+	}
+}
@@ -0,0 +1,186 @@
+package supervisor_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/health"
+	"go-clean-ddd-es-template/pkg/supervisor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	restarts map[string]int
+	up       map[string]bool
+}
+
+func newRecorder() *recorder {
+	return &recorder{restarts: make(map[string]int), up: make(map[string]bool)}
+}
+
+func (r *recorder) RecordComponentRestart(component string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.restarts[component]++
+}
+
+func (r *recorder) RecordComponentUp(component string, up bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.up[component] = up
+}
+
+// waitForWaiters waits until the supervisor waits on the clock
+func waitForWaiters(t *testing.T, clk *clock.Fake) {
+	t.Helper()
+	require.Eventually(t, func() bool { return clk.Waiters() > 0 }, time.Second, time.Millisecond)
+}
+
+func state(s *supervisor.Supervisor) string {
+	return s.Status()[0].State
+}
+
+func TestSupervisor_FailsAfterMaxRestarts(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rec := newRecorder()
+	s := supervisor.New(clk, nil, rec)
+
+	runs := 0
+	s.Go(context.Background(), supervisor.Component{
+		Name: "consumer",
+		Run: func(ctx context.Context) error {
+			runs++
+			return errors.New("broker unavailable")
+		},
+		Policy: supervisor.RestartPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute, MaxRestarts: 2, Window: time.Hour},
+	})
+
+	// Restarts back off exponentially
+	waitForWaiters(t, clk)
+	assert.Equal(t, supervisor.StateBackoff, state(s))
+	assert.Equal(t, health.StatusDegraded, s.HealthCheck()(context.Background()).Status)
+	clk.Advance(time.Second)
+	waitForWaiters(t, clk)
+	clk.Advance(time.Second)
+	assert.Equal(t, 1, clk.Waiters(), "second restart must wait 2s")
+	clk.Advance(time.Second)
+
+	s.Wait()
+	assert.Equal(t, 3, runs)
+	status := s.Status()[0]
+	assert.Equal(t, supervisor.StateFailed, status.State)
+	assert.Equal(t, 2, status.Restarts)
+	assert.Equal(t, "broker unavailable", status.LastError)
+	assert.Equal(t, 2, rec.restarts["consumer"])
+	assert.False(t, rec.up["consumer"])
+
+	check := s.HealthCheck()(context.Background())
+	assert.Equal(t, health.StatusUnhealthy, check.Status)
+	assert.Contains(t, check.Message, "consumer failed")
+}
+
+func TestSupervisor_RestartsPanickedComponent(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := supervisor.New(clk, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	runs := 0
+	s.Go(ctx, supervisor.Component{
+		Name: "scheduler",
+		Run: func(ctx context.Context) error {
+			runs++
+			if runs == 1 {
+				panic("nil map")
+			}
+			close(started)
+			<-ctx.Done()
+			return nil
+		},
+		Policy: supervisor.DefaultRestartPolicy(),
+	})
+
+	waitForWaiters(t, clk)
+	assert.Equal(t, "panic: nil map", s.Status()[0].LastError)
+	clk.Advance(time.Second)
+	<-started
+	assert.Equal(t, supervisor.StateRunning, state(s))
+	assert.Equal(t, health.StatusHealthy, s.HealthCheck()(ctx).Status)
+
+	cancel()
+	s.Wait()
+	assert.Equal(t, supervisor.StateStopped, state(s))
+}
+
+func TestSupervisor_ForgetsRestartsOutsideWindow(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := supervisor.New(clk, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.Go(ctx, supervisor.Component{
+		Name: "consumer",
+		Run: func(ctx context.Context) error {
+			// Crash after running for an hour
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-clk.After(time.Hour):
+				return errors.New("connection reset")
+			}
+		},
+		Policy: supervisor.RestartPolicy{InitialBackoff: time.Second, MaxRestarts: 1, Window: 10 * time.Minute},
+	})
+
+	for i := 0; i < 3; i++ {
+		waitForWaiters(t, clk)
+		clk.Advance(time.Hour)
+		waitForWaiters(t, clk)
+		clk.Advance(time.Second)
+	}
+	waitForWaiters(t, clk)
+	status := s.Status()[0]
+	assert.Equal(t, supervisor.StateRunning, status.State)
+	assert.Equal(t, 3, status.Restarts)
+}
+
+func TestSupervisor_StopsNamedComponents(t *testing.T) {
+	s := supervisor.New(nil, nil, nil)
+	run := func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}
+	s.Go(context.Background(), supervisor.Component{Name: "consumer", Run: run, Policy: supervisor.DefaultRestartPolicy()})
+	s.Go(context.Background(), supervisor.Component{Name: "scheduler", Run: run, Policy: supervisor.DefaultRestartPolicy()})
+
+	require.NoError(t, s.Stop(context.Background(), "consumer", "unknown"))
+	statuses := s.Status()
+	assert.Equal(t, supervisor.StateStopped, statuses[0].State)
+	assert.Equal(t, supervisor.StateRunning, statuses[1].State)
+
+	require.NoError(t, s.Stop(context.Background()))
+	s.Wait()
+	assert.Equal(t, supervisor.StateStopped, s.Status()[1].State)
+}
+
+func TestSupervisor_StopGivesUpWhenContextIsDone(t *testing.T) {
+	s := supervisor.New(nil, nil, nil)
+	stuck := make(chan struct{})
+	defer close(stuck)
+	s.Go(context.Background(), supervisor.Component{
+		Name:   "relay",
+		Run:    func(ctx context.Context) error { <-stuck; return nil },
+		Policy: supervisor.DefaultRestartPolicy(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx, "relay"), context.DeadlineExceeded)
+}
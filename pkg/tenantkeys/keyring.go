@@ -0,0 +1,233 @@
+package tenantkeys
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/secrets"
+)
+
+// keySize is the size of data keys, for AES-256
+const keySize = 32
+
+// CurrentRefresh is how long the current key version of a tenant is cached before it is read from
+// the provider again, picking up rotations made by other keyrings
+const CurrentRefresh = time.Minute
+
+// DataKey is a version of the key encrypting the data of a tenant
+type DataKey struct {
+	Version   int       `json:"version"`
+	Key       []byte    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Ciphertext is data encrypted with a version of the data key of a tenant
+type Ciphertext struct {
+	KeyVersion int
+	Data       []byte // Nonce followed by the sealed data
+}
+
+// Keyring manages a data key per tenant in a secrets provider. Keys are versioned: rotating the
+// key of a tenant adds a version that encrypts new data, while earlier versions still decrypt
+// data encrypted before, until it is re-encrypted. Keys are cached once read; the current
+// version of a tenant is read again after CurrentRefresh.
+type Keyring struct {
+	provider secrets.Provider
+	clock    clock.Clock
+
+	mu      sync.Mutex
+	keys    map[string]map[int]*DataKey // tenant -> version -> key
+	current map[string]currentVersion   // tenant -> current version
+}
+
+// currentVersion is the current key version of a tenant, as read at a time
+type currentVersion struct {
+	version int
+	readAt  time.Time
+}
+
+// NewKeyring creates a keyring keeping data keys in provider
+func NewKeyring(provider secrets.Provider, clk clock.Clock) *Keyring {
+	return &Keyring{
+		provider: provider,
+		clock:    clock.OrDefault(clk),
+		keys:     make(map[string]map[int]*DataKey),
+		current:  make(map[string]currentVersion),
+	}
+}
+
+// Current returns the current data key of a tenant, creating its first key if it has none
+func (k *Keyring) Current(ctx context.Context, tenant string) (*DataKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	current, ok := k.current[tenant]
+	if !ok || k.clock.Since(current.readAt) >= CurrentRefresh {
+		version, err := k.loadCurrentVersion(ctx, tenant)
+		if errors.Is(err, secrets.ErrNotFound) {
+			return k.createKey(ctx, tenant, 1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		current = currentVersion{version: version, readAt: k.clock.Now()}
+		k.current[tenant] = current
+	}
+	return k.key(ctx, tenant, current.version)
+}
+
+// Key returns a version of the data key of a tenant
+func (k *Keyring) Key(ctx context.Context, tenant string, version int) (*DataKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.key(ctx, tenant, version)
+}
+
+// Rotate adds a version of the data key of a tenant, encrypting data from then on
+func (k *Keyring) Rotate(ctx context.Context, tenant string) (*DataKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	version, err := k.loadCurrentVersion(ctx, tenant)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return nil, err
+	}
+	return k.createKey(ctx, tenant, version+1)
+}
+
+// Encrypt encrypts data with the current data key of a tenant. The tenant is authenticated with
+// the data, so ciphertexts do not decrypt for other tenants.
+func (k *Keyring) Encrypt(ctx context.Context, tenant string, plaintext []byte) (*Ciphertext, error) {
+	key, err := k.Current(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return &Ciphertext{
+		KeyVersion: key.Version,
+		Data:       aead.Seal(nonce, nonce, plaintext, []byte(tenant)),
+	}, nil
+}
+
+// Decrypt decrypts data encrypted with a version of the data key of a tenant
+func (k *Keyring) Decrypt(ctx context.Context, tenant string, ciphertext *Ciphertext) ([]byte, error) {
+	key, err := k.Key(ctx, tenant, ciphertext.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext.Data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext.Data[:aead.NonceSize()], ciphertext.Data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(tenant))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data of tenant %s: %w", tenant, err)
+	}
+	return plaintext, nil
+}
+
+// key returns a version of the data key of a tenant, reading it from the provider once
+func (k *Keyring) key(ctx context.Context, tenant string, version int) (*DataKey, error) {
+	if key, ok := k.keys[tenant][version]; ok {
+		return key, nil
+	}
+
+	value, err := k.provider.Get(ctx, keyName(tenant, version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data key %d of tenant %s: %w", version, tenant, err)
+	}
+	var key DataKey
+	if err := json.Unmarshal(value, &key); err != nil {
+		return nil, fmt.Errorf("failed to decode data key %d of tenant %s: %w", version, tenant, err)
+	}
+	k.cache(tenant, &key)
+	return &key, nil
+}
+
+// createKey generates a version of the data key of a tenant and makes it current
+func (k *Keyring) createKey(ctx context.Context, tenant string, version int) (*DataKey, error) {
+	key := &DataKey{
+		Version:   version,
+		Key:       make([]byte, keySize),
+		CreatedAt: k.clock.Now().UTC(),
+	}
+	if _, err := rand.Read(key.Key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	value, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.provider.Put(ctx, keyName(tenant, version), value); err != nil {
+		return nil, fmt.Errorf("failed to store data key %d of tenant %s: %w", version, tenant, err)
+	}
+	if err := k.provider.Put(ctx, currentName(tenant), []byte(strconv.Itoa(version))); err != nil {
+		return nil, fmt.Errorf("failed to make data key %d of tenant %s current: %w", version, tenant, err)
+	}
+	k.cache(tenant, key)
+	k.current[tenant] = currentVersion{version: version, readAt: k.clock.Now()}
+	return key, nil
+}
+
+// loadCurrentVersion reads the current version of the data key of a tenant from the provider
+func (k *Keyring) loadCurrentVersion(ctx context.Context, tenant string) (int, error) {
+	value, err := k.provider.Get(ctx, currentName(tenant))
+	if err != nil {
+		return 0, err
+	}
+	version, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid current data key version of tenant %s: %w", tenant, err)
+	}
+	return version, nil
+}
+
+func (k *Keyring) cache(tenant string, key *DataKey) {
+	if k.keys[tenant] == nil {
+		k.keys[tenant] = make(map[int]*DataKey)
+	}
+	k.keys[tenant][key.Version] = key
+}
+
+// newAEAD returns AES-GCM with a data key
+func newAEAD(key *DataKey) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key %d: %w", key.Version, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyName returns the secret holding a version of the data key of a tenant
+func keyName(tenant string, version int) string {
+	return fmt.Sprintf("tenants/%s/data-keys/v%d", tenant, version)
+}
+
+// currentName returns the secret holding the current version of the data key of a tenant
+func currentName(tenant string) string {
+	return fmt.Sprintf("tenants/%s/data-keys/current", tenant)
+}
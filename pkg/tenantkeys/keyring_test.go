@@ -0,0 +1,71 @@
+package tenantkeys_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/secrets"
+	"go-clean-ddd-es-template/pkg/tenantkeys"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	keyring := tenantkeys.NewKeyring(secrets.NewMemoryProvider(), nil)
+
+	ciphertext, err := keyring.Encrypt(ctx, "acme", []byte(`{"email":"jane@acme.io"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, ciphertext.KeyVersion)
+	assert.NotContains(t, string(ciphertext.Data), "jane@acme.io")
+
+	plaintext, err := keyring.Decrypt(ctx, "acme", ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, `{"email":"jane@acme.io"}`, string(plaintext))
+
+	_, err = keyring.Decrypt(ctx, "globex", ciphertext)
+	assert.Error(t, err, "ciphertexts do not decrypt for other tenants")
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	ctx := context.Background()
+	provider := secrets.NewMemoryProvider()
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	keyring := tenantkeys.NewKeyring(provider, clk)
+
+	before, err := keyring.Encrypt(ctx, "acme", []byte("before"))
+	require.NoError(t, err)
+
+	clk.Advance(24 * time.Hour)
+	rotated, err := keyring.Rotate(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rotated.Version)
+	assert.Equal(t, clk.Now(), rotated.CreatedAt)
+
+	after, err := keyring.Encrypt(ctx, "acme", []byte("after"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, after.KeyVersion)
+
+	// Another keyring on the same provider, e.g. of another replica, decrypts both versions and
+	// picks up further rotations once its current version is refreshed
+	other := tenantkeys.NewKeyring(provider, clk)
+	current, err := other.Current(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.Version)
+	plaintext, err := other.Decrypt(ctx, "acme", before)
+	require.NoError(t, err)
+	assert.Equal(t, "before", string(plaintext))
+
+	_, err = keyring.Rotate(ctx, "acme")
+	require.NoError(t, err)
+	current, err = other.Current(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.Version)
+	clk.Advance(tenantkeys.CurrentRefresh)
+	current, err = other.Current(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 3, current.Version)
+}
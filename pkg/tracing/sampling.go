@@ -0,0 +1,240 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+// Head sampling strategies
+const (
+	SamplerAlwaysOn    = "always_on"    // Sample every trace
+	SamplerAlwaysOff   = "always_off"   // Sample no trace, except those kept by tail sampling rules
+	SamplerRatio       = "ratio"        // Sample a ratio of traces by trace ID
+	SamplerRateLimited = "rate_limited" // Sample at most a number of traces per second
+)
+
+// SamplingConfig configures which traces are exported. Head sampling decides when a trace
+// starts; tail sampling rules then keep spans the head sampler dropped, e.g. failed or slow ones.
+type SamplingConfig struct {
+	Strategy      string        // One of the Sampler* strategies, always_on when empty
+	Ratio         float64       // Ratio of traces sampled by the ratio strategy
+	RatePerSecond float64       // Traces sampled per second by the rate limited strategy
+	ParentBased   bool          // Whether spans follow the decision of their parent, so traces are sampled whole
+	SampleErrors  bool          // Whether spans that failed are always exported
+	SlowThreshold time.Duration // Spans lasting at least this long are always exported, 0 to disable
+
+	// Rules are additional tail sampling hooks, see TailSamplingRule
+	Rules []TailSamplingRule
+}
+
+// tailRules returns the tail sampling rules of the configuration
+func (c SamplingConfig) tailRules() []TailSamplingRule {
+	rules := append([]TailSamplingRule(nil), c.Rules...)
+	if c.SampleErrors {
+		rules = append(rules, SampleErrors())
+	}
+	if c.SlowThreshold > 0 {
+		rules = append(rules, SampleSlowerThan(c.SlowThreshold))
+	}
+	return rules
+}
+
+// NewSampler creates the head sampler of a configuration. A nil clock uses the system clock.
+func NewSampler(cfg SamplingConfig, clk clock.Clock) (sdktrace.Sampler, error) {
+	var sampler sdktrace.Sampler
+	switch cfg.Strategy {
+	case "", SamplerAlwaysOn:
+		sampler = sdktrace.AlwaysSample()
+	case SamplerAlwaysOff:
+		sampler = sdktrace.NeverSample()
+	case SamplerRatio:
+		if cfg.Ratio < 0 || cfg.Ratio > 1 {
+			return nil, fmt.Errorf("sampling ratio must be between 0 and 1, got %v", cfg.Ratio)
+		}
+		sampler = sdktrace.TraceIDRatioBased(cfg.Ratio)
+	case SamplerRateLimited:
+		if cfg.RatePerSecond <= 0 {
+			return nil, fmt.Errorf("sampling rate must be positive, got %v", cfg.RatePerSecond)
+		}
+		sampler = NewRateLimitingSampler(cfg.RatePerSecond, clk)
+	default:
+		return nil, fmt.Errorf("unknown sampling strategy %q", cfg.Strategy)
+	}
+
+	if cfg.ParentBased {
+		sampler = sdktrace.ParentBased(sampler)
+	}
+	if len(cfg.tailRules()) > 0 {
+		// Spans dropped by the head sampler are still recorded so tail sampling rules can keep them
+		sampler = recordingSampler{sampler}
+	}
+	return sampler, nil
+}
+
+// RateLimitingSampler samples at most a number of traces per second, allowing bursts of up to
+// one second's worth of traces
+type RateLimitingSampler struct {
+	rate  float64
+	clock clock.Clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimitingSampler creates a sampler of rate traces per second. A nil clock uses the system clock.
+func NewRateLimitingSampler(rate float64, clk clock.Clock) *RateLimitingSampler {
+	clk = clock.OrDefault(clk)
+	return &RateLimitingSampler{
+		rate:   rate,
+		clock:  clk,
+		tokens: max(rate, 1),
+		last:   clk.Now(),
+	}
+}
+
+// ShouldSample samples the trace when the rate allows it
+func (s *RateLimitingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	decision := sdktrace.Drop
+	if s.take() {
+		decision = sdktrace.RecordAndSample
+	}
+	return sdktrace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+// take takes a token, refilled at the sampling rate
+func (s *RateLimitingSampler) take() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	s.tokens = min(s.tokens+now.Sub(s.last).Seconds()*s.rate, max(s.rate, 1))
+	s.last = now
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+// Description describes the sampler
+func (s *RateLimitingSampler) Description() string {
+	return fmt.Sprintf("RateLimitingSampler{%v}", s.rate)
+}
+
+// recordingSampler records the spans its sampler drops without sampling them
+type recordingSampler struct {
+	sampler sdktrace.Sampler
+}
+
+// ShouldSample records dropped spans
+func (s recordingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.sampler.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+// Description describes the sampler
+func (s recordingSampler) Description() string {
+	return "Recording{" + s.sampler.Description() + "}"
+}
+
+// TailSamplingRule decides once a span ended whether to export it although the head sampler
+// dropped it. Rules see single spans, so a kept span is exported without the rest of its trace.
+type TailSamplingRule func(span sdktrace.ReadOnlySpan) bool
+
+// SampleErrors keeps spans with an error status or a recorded error
+func SampleErrors() TailSamplingRule {
+	return func(span sdktrace.ReadOnlySpan) bool {
+		if span.Status().Code == codes.Error {
+			return true
+		}
+		for _, event := range span.Events() {
+			if event.Name == "exception" {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SampleSlowerThan keeps spans lasting at least threshold
+func SampleSlowerThan(threshold time.Duration) TailSamplingRule {
+	return func(span sdktrace.ReadOnlySpan) bool {
+		return span.EndTime().Sub(span.StartTime()) >= threshold
+	}
+}
+
+// SampleAttribute keeps spans with an attribute set to value, e.g. a tenant being debugged
+func SampleAttribute(key attribute.Key, value string) TailSamplingRule {
+	return func(span sdktrace.ReadOnlySpan) bool {
+		for _, attr := range span.Attributes() {
+			if attr.Key == key && attr.Value.Emit() == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// tailSamplingProcessor passes sampled spans to the next processor, and the unsampled spans kept
+// by a rule as if they were sampled
+type tailSamplingProcessor struct {
+	next  sdktrace.SpanProcessor
+	rules []TailSamplingRule
+}
+
+// newTailSamplingProcessor creates a tail sampling processor in front of next
+func newTailSamplingProcessor(next sdktrace.SpanProcessor, rules []TailSamplingRule) sdktrace.SpanProcessor {
+	return &tailSamplingProcessor{next: next, rules: rules}
+}
+
+func (p *tailSamplingProcessor) OnStart(parent context.Context, span sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, span)
+}
+
+func (p *tailSamplingProcessor) OnEnd(span sdktrace.ReadOnlySpan) {
+	if span.SpanContext().IsSampled() {
+		p.next.OnEnd(span)
+		return
+	}
+	for _, rule := range p.rules {
+		if rule(span) {
+			p.next.OnEnd(sampledSpan{span})
+			return
+		}
+	}
+}
+
+func (p *tailSamplingProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+func (p *tailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// sampledSpan marks a span kept by tail sampling as sampled, so it is exported
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+// SpanContext returns the span context with the sampled flag set
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-clean-ddd-es-template/pkg/clock"
+)
+
+func TestNewSampler_Invalid(t *testing.T) {
+	_, err := NewSampler(SamplingConfig{Strategy: SamplerRatio, Ratio: 2}, nil)
+	assert.Error(t, err)
+	_, err = NewSampler(SamplingConfig{Strategy: SamplerRateLimited}, nil)
+	assert.Error(t, err)
+	_, err = NewSampler(SamplingConfig{Strategy: "sometimes"}, nil)
+	assert.Error(t, err)
+}
+
+func TestRateLimitingSampler(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	sampler := NewRateLimitingSampler(2, fake)
+
+	sample := func() bool {
+		return sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background()}).Decision == sdktrace.RecordAndSample
+	}
+
+	assert.True(t, sample())
+	assert.True(t, sample())
+	assert.False(t, sample(), "the burst is one second of traces")
+
+	fake.Advance(500 * time.Millisecond)
+	assert.True(t, sample())
+	assert.False(t, sample())
+}
+
+func TestTailSampling(t *testing.T) {
+	cfg := SamplingConfig{
+		Strategy:      SamplerAlwaysOff,
+		ParentBased:   true,
+		SampleErrors:  true,
+		SlowThreshold: time.Hour,
+		Rules:         []TailSamplingRule{SampleAttribute("tenant", "debug")},
+	}
+	sampler, err := NewSampler(cfg, nil)
+	require.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(newTailSamplingProcessor(recorder, cfg.tailRules())),
+	)
+	tracer := provider.Tracer("test")
+	ctx := context.Background()
+
+	_, ok := tracer.Start(ctx, "ok")
+	ok.End()
+
+	_, failed := tracer.Start(ctx, "failed")
+	failed.SetStatus(codes.Error, "boom")
+	failed.End()
+
+	start := time.Now()
+	_, slow := tracer.Start(ctx, "slow", trace.WithTimestamp(start))
+	slow.End(trace.WithTimestamp(start.Add(2 * time.Hour)))
+
+	_, tenant := tracer.Start(ctx, "tenant")
+	tenant.SetAttributes(attribute.String("tenant", "debug"))
+	tenant.End()
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+		assert.True(t, span.SpanContext().IsSampled(), span.Name())
+	}
+	assert.Equal(t, []string{"failed", "slow", "tenant"}, names)
+}
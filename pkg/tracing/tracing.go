@@ -7,6 +7,7 @@ import (
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -15,20 +16,81 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// instrumentationName names the tracer of the spans started with Start
+const instrumentationName = "go-clean-ddd-es-template"
+
 // Tracer represents the tracing service
 type Tracer struct {
 	tracer trace.Tracer
 }
 
-// NewTracer creates a new tracer instance
+// Config configures a tracer
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+	Endpoint       string // OTLP HTTP endpoint, host:port
+	Sampling       SamplingConfig
+	Export         ExportConfig
+}
+
+// ExportConfig configures the OTLP exporter and the queue of spans waiting for export.
+// Zero values use the OpenTelemetry defaults.
+type ExportConfig struct {
+	Timeout              time.Duration // Timeout of an export request
+	RetryEnabled         bool          // Whether failed exports are retried with exponential backoff
+	RetryInitialInterval time.Duration // Wait before the first retry
+	RetryMaxInterval     time.Duration // Maximum wait between retries
+	RetryMaxElapsedTime  time.Duration // Time after which a batch is dropped
+	MaxQueueSize         int           // Spans queued for export; spans are dropped when it is full
+	MaxExportBatchSize   int           // Spans exported per request
+	BatchTimeout         time.Duration // Maximum delay before queued spans are exported
+}
+
+// DefaultExportConfig returns the OpenTelemetry defaults: exports retried for up to a minute
+func DefaultExportConfig() ExportConfig {
+	return ExportConfig{
+		RetryEnabled:         true,
+		RetryInitialInterval: 5 * time.Second,
+		RetryMaxInterval:     30 * time.Second,
+		RetryMaxElapsedTime:  time.Minute,
+	}
+}
+
+// NewTracer creates a new tracer instance sampling every trace
 func NewTracer(serviceName, serviceVersion, endpoint string) (*Tracer, error) {
+	return NewTracerFromConfig(Config{
+		ServiceName:    serviceName,
+		ServiceVersion: serviceVersion,
+		Endpoint:       endpoint,
+		Export:         DefaultExportConfig(),
+	})
+}
+
+// NewTracerFromConfig creates a new tracer instance
+func NewTracerFromConfig(cfg Config) (*Tracer, error) {
+	serviceName, serviceVersion := cfg.ServiceName, cfg.ServiceVersion
+
+	sampler, err := NewSampler(cfg.Sampling, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sampler: %w", err)
+	}
+
 	// Create OTLP exporter
-	exporter, err := otlptracehttp.New(
-		context.Background(),
-		otlptracehttp.WithEndpoint(endpoint),
+	exporterOpts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(cfg.Endpoint),
 		otlptracehttp.WithURLPath("/v1/traces"),
 		otlptracehttp.WithInsecure(),
-	)
+		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
+			Enabled:         cfg.Export.RetryEnabled,
+			InitialInterval: cfg.Export.RetryInitialInterval,
+			MaxInterval:     cfg.Export.RetryMaxInterval,
+			MaxElapsedTime:  cfg.Export.RetryMaxElapsedTime,
+		}),
+	}
+	if cfg.Export.Timeout > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithTimeout(cfg.Export.Timeout))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), exporterOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
 	}
@@ -45,9 +107,14 @@ func NewTracer(serviceName, serviceVersion, endpoint string) (*Tracer, error) {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
-	// Create trace provider
+	// Create trace provider, with tail sampling rules in front of the export queue
+	var processor sdktrace.SpanProcessor = sdktrace.NewBatchSpanProcessor(exporter, cfg.Export.batchOptions()...)
+	if rules := cfg.Sampling.tailRules(); len(rules) > 0 {
+		processor = newTailSamplingProcessor(processor, rules)
+	}
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(processor),
 		sdktrace.WithResource(res),
 	)
 
@@ -68,11 +135,52 @@ func NewTracer(serviceName, serviceVersion, endpoint string) (*Tracer, error) {
 	}, nil
 }
 
+// NewTracerFromProvider creates a tracer on a trace provider, e.g. one recording spans in tests
+func NewTracerFromProvider(tp trace.TracerProvider, serviceName string) *Tracer {
+	return &Tracer{
+		tracer: tp.Tracer(serviceName),
+	}
+}
+
+// batchOptions returns the export queue options of the configuration
+func (c ExportConfig) batchOptions() []sdktrace.BatchSpanProcessorOption {
+	var opts []sdktrace.BatchSpanProcessorOption
+	if c.MaxQueueSize > 0 {
+		opts = append(opts, sdktrace.WithMaxQueueSize(c.MaxQueueSize))
+	}
+	if c.MaxExportBatchSize > 0 {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(c.MaxExportBatchSize))
+	}
+	if c.BatchTimeout > 0 {
+		opts = append(opts, sdktrace.WithBatchTimeout(c.BatchTimeout))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, sdktrace.WithExportTimeout(c.Timeout))
+	}
+	return opts
+}
+
 // StartSpan starts a new span
 func (t *Tracer) StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
 	return t.tracer.Start(ctx, name, opts...)
 }
 
+// Start starts a span on the global trace provider, for layers without a Tracer of their own.
+// Spans are only recorded once NewTracerFromConfig installed its provider, but they always carry
+// the trace context of ctx on.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records err, when not nil, on a span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 // StartSpanWithAttributes starts a new span with attributes
 func (t *Tracer) StartSpanWithAttributes(ctx context.Context, name string, attrs map[string]interface{}, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
 	spanOpts := make([]trace.SpanStartOption, 0, len(opts)+len(attrs))
@@ -0,0 +1,160 @@
+// Package tracingtest records the spans of tests in memory and asserts how they relate, so that
+// tests can catch trace context getting lost between layers.
+package tracingtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-clean-ddd-es-template/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Recorder captures the spans ended during a test with an in-memory exporter
+type Recorder struct {
+	exporter *tracetest.InMemoryExporter
+	provider *sdktrace.TracerProvider
+}
+
+// NewRecorder installs a trace provider sampling every span into memory, along with the W3C
+// trace context propagator, as the global ones until the test ends. Tests using a recorder must
+// not run in parallel.
+func NewRecorder(t testing.TB) *Recorder {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSyncer(exporter),
+	)
+
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	t.Cleanup(func() {
+		// The provider stays installed but, once shut down, records nothing
+		provider.Shutdown(context.Background())
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return &Recorder{
+		exporter: exporter,
+		provider: provider,
+	}
+}
+
+// Tracer returns a tracer recording into the recorder, for components taking a *tracing.Tracer
+func (r *Recorder) Tracer() *tracing.Tracer {
+	return tracing.NewTracerFromProvider(r.provider, "tracingtest")
+}
+
+// Spans returns the spans ended so far in the order they ended
+func (r *Recorder) Spans() tracetest.SpanStubs {
+	return r.exporter.GetSpans()
+}
+
+// Reset drops the spans recorded so far
+func (r *Recorder) Reset() {
+	r.exporter.Reset()
+}
+
+// find returns the first ended span with a name
+func (r *Recorder) find(name string) (tracetest.SpanStub, bool) {
+	for _, span := range r.Spans() {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+// Span returns the first ended span with a name, failing the test when there is none
+func (r *Recorder) Span(t testing.TB, name string) tracetest.SpanStub {
+	t.Helper()
+
+	span, ok := r.find(name)
+	if !ok {
+		t.Fatalf("no span %q recorded, recorded spans: %s", name, r.names())
+	}
+	return span
+}
+
+// WaitForSpan waits up to timeout for a span with a name to end, e.g. one started by a consumer
+// in the background, and returns it
+func (r *Recorder) WaitForSpan(t testing.TB, name string, timeout time.Duration) tracetest.SpanStub {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if span, ok := r.find(name); ok {
+			return span
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("span %q not recorded within %v, recorded spans: %s", name, timeout, r.names())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// AssertChildOf asserts that the span named child is a direct child of the span named parent,
+// in the same trace
+func (r *Recorder) AssertChildOf(t testing.TB, child, parent string) bool {
+	t.Helper()
+
+	childSpan, parentSpan := r.Span(t, child), r.Span(t, parent)
+	if childSpan.SpanContext.TraceID() != parentSpan.SpanContext.TraceID() {
+		t.Errorf("span %q is in trace %s, not in trace %s of its expected parent %q",
+			child, childSpan.SpanContext.TraceID(), parentSpan.SpanContext.TraceID(), parent)
+		return false
+	}
+	if childSpan.Parent.SpanID() != parentSpan.SpanContext.SpanID() {
+		t.Errorf("span %q has parent %s, want %q (%s)", child, r.parentOf(childSpan), parent, parentSpan.SpanContext.SpanID())
+		return false
+	}
+	return true
+}
+
+// AssertChain asserts that each named span is a direct child of the span named before it, i.e.
+// that the trace context went through every step of a flow
+func (r *Recorder) AssertChain(t testing.TB, names ...string) bool {
+	t.Helper()
+
+	ok := true
+	for i := 1; i < len(names); i++ {
+		ok = r.AssertChildOf(t, names[i], names[i-1]) && ok
+	}
+	return ok
+}
+
+// parentOf describes the parent of a span for failure messages
+func (r *Recorder) parentOf(span tracetest.SpanStub) string {
+	if !span.Parent.IsValid() {
+		return "none, it is a root span"
+	}
+	for _, candidate := range r.Spans() {
+		if candidate.SpanContext.SpanID() == span.Parent.SpanID() {
+			return "\"" + candidate.Name + "\""
+		}
+	}
+	return span.Parent.SpanID().String() + " (not recorded)"
+}
+
+// names lists the names of the recorded spans for failure messages
+func (r *Recorder) names() string {
+	spans := r.Spans()
+	if len(spans) == 0 {
+		return "none"
+	}
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	return strings.Join(names, ", ")
+}
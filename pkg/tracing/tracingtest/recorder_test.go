@@ -0,0 +1,61 @@
+package tracingtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"go-clean-ddd-es-template/pkg/tracing"
+)
+
+// failureRecorder records assertion failures instead of failing the test
+type failureRecorder struct {
+	testing.TB
+	failures int
+}
+
+func (f *failureRecorder) Helper() {}
+
+func (f *failureRecorder) Errorf(format string, args ...interface{}) { f.failures++ }
+
+func TestRecorder_AssertChain(t *testing.T) {
+	recorder := NewRecorder(t)
+
+	ctx, root := recorder.Tracer().StartSpan(context.Background(), "root")
+	ctx, child := tracing.Start(ctx, "child")
+	_, grandchild := tracing.Start(ctx, "grandchild")
+	grandchild.End()
+	child.End()
+	root.End()
+	_, unrelated := tracing.Start(context.Background(), "unrelated")
+	unrelated.End()
+
+	assert.True(t, recorder.AssertChain(t, "root", "child", "grandchild"))
+
+	failures := &failureRecorder{TB: t}
+	assert.False(t, recorder.AssertChildOf(failures, "grandchild", "root"), "a grandchild is not a direct child")
+	assert.False(t, recorder.AssertChain(failures, "root", "unrelated"), "spans of other traces are not children")
+	assert.Equal(t, 2, failures.failures)
+}
+
+func TestRecorder_PropagatesAcrossCarriers(t *testing.T) {
+	recorder := NewRecorder(t)
+
+	ctx, producer := tracing.Start(context.Background(), "produce")
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	producer.End()
+
+	go func() {
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+		_, consumer := tracing.Start(ctx, "consume")
+		consumer.End()
+	}()
+
+	recorder.WaitForSpan(t, "consume", time.Second)
+	recorder.AssertChildOf(t, "consume", "produce")
+}
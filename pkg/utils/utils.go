@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
@@ -10,6 +11,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"go-clean-ddd-es-template/pkg/clock"
+	"go-clean-ddd-es-template/pkg/retry"
 )
 
 // GenerateUUID generates a new UUID
@@ -97,18 +101,18 @@ func FormatDuration(d time.Duration) string {
 
 // Retry executes function with retry logic
 func Retry(maxAttempts int, delay time.Duration, fn func() error) error {
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if err := fn(); err == nil {
-			return nil
-		} else {
-			lastErr = err
-			if attempt < maxAttempts {
-				time.Sleep(delay)
-			}
-		}
-	}
-	return fmt.Errorf("failed after %d attempts: %w", maxAttempts, lastErr)
+	return RetryWithClock(clock.New(), maxAttempts, delay, fn)
+}
+
+// RetryWithClock executes function with retry logic, waiting between attempts on c
+func RetryWithClock(c clock.Clock, maxAttempts int, delay time.Duration, fn func() error) error {
+	// A fixed delay is a backoff bounded by its base delay
+	return retry.Retrier{
+		Policy: retry.Policy{MaxAttempts: maxAttempts, BaseDelay: delay, MaxDelay: delay},
+		Clock:  c,
+	}.Do(context.Background(), func(attempt int) error {
+		return fn()
+	})
 }
 
 // Contains checks if slice contains element
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"go-clean-ddd-es-template/pkg/clock"
 	"go-clean-ddd-es-template/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
@@ -367,3 +368,30 @@ func TestRetry_WithDelay(t *testing.T) {
 	// But not more than 2 delays (since we succeed on attempt 2)
 	assert.Less(t, duration, 3*delay)
 }
+
+func TestRetryWithClock_WaitsOnClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	attemptCount := 0
+	fn := func() error {
+		attemptCount++
+		return fmt.Errorf("attempt %d failed", attemptCount)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- utils.RetryWithClock(fake, 3, time.Hour, fn)
+	}()
+
+	// Each delay only elapses when the fake clock is advanced
+	for i := 0; i < 2; i++ {
+		for fake.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		fake.Advance(time.Hour)
+	}
+
+	err := <-done
+	assert.Error(t, err)
+	assert.Equal(t, 3, attemptCount)
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"go-clean-ddd-es-template/pkg/retry"
 )
 
 // Job represents a generic job to be processed
@@ -18,12 +20,13 @@ type Job interface {
 
 // Worker represents a worker in the pool
 type Worker struct {
-	id       int
-	jobQueue <-chan Job
-	stopChan <-chan struct{}
-	wg       *sync.WaitGroup
-	metrics  *Metrics
-	handler  JobHandler
+	id         int
+	jobQueue   <-chan Job
+	stopChan   <-chan struct{}
+	wg         *sync.WaitGroup
+	metrics    *Metrics
+	handler    JobHandler
+	retryDelay time.Duration
 }
 
 // JobHandler defines how jobs should be processed
@@ -58,6 +61,7 @@ type WorkerPool struct {
 	handler    JobHandler
 	numWorkers int
 	bufferSize int
+	retryDelay time.Duration
 }
 
 // Config holds worker pool configuration
@@ -65,7 +69,7 @@ type Config struct {
 	NumWorkers int           // Number of workers in the pool
 	BufferSize int           // Buffer size for job queue
 	Handler    JobHandler    // Job handler implementation
-	RetryDelay time.Duration // Delay between retries
+	RetryDelay time.Duration // Delay after the first failed attempt, doubled after every other one
 	MaxRetries int           // Maximum number of retries per job
 }
 
@@ -98,6 +102,7 @@ func NewWorkerPool(config Config) *WorkerPool {
 		handler:    config.Handler,
 		numWorkers: config.NumWorkers,
 		bufferSize: config.BufferSize,
+		retryDelay: config.RetryDelay,
 	}
 
 	pool.createWorkers()
@@ -110,12 +115,13 @@ func (wp *WorkerPool) createWorkers() {
 
 	for i := 0; i < wp.numWorkers; i++ {
 		worker := &Worker{
-			id:       i + 1,
-			jobQueue: wp.jobQueue,
-			stopChan: wp.stopChan,
-			wg:       &wp.wg,
-			metrics:  wp.metrics,
-			handler:  wp.handler,
+			id:         i + 1,
+			jobQueue:   wp.jobQueue,
+			stopChan:   wp.stopChan,
+			wg:         &wp.wg,
+			metrics:    wp.metrics,
+			handler:    wp.handler,
+			retryDelay: wp.retryDelay,
 		}
 
 		wp.workers[i] = worker
@@ -139,6 +145,10 @@ func (w *Worker) start() {
 
 	log.Printf("Worker %d started", w.id)
 
+	// Retries stop waiting once the pool is stopped
+	stop, cancel := retry.UntilClosed(w.stopChan)
+	defer cancel()
+
 	for {
 		select {
 		case <-w.stopChan:
@@ -149,13 +159,13 @@ func (w *Worker) start() {
 				continue
 			}
 
-			w.processJob(job)
+			w.processJob(stop, job)
 		}
 	}
 }
 
-// processJob processes a job with retry logic
-func (w *Worker) processJob(job Job) {
+// processJob processes a job with retry logic, retrying until stop is done
+func (w *Worker) processJob(stop context.Context, job Job) {
 	startTime := time.Now()
 
 	// Update worker stats
@@ -165,37 +175,32 @@ func (w *Worker) processJob(job Job) {
 	stats.LastJobTime = startTime
 	w.metrics.mu.Unlock()
 
-	// Process job with retry logic
+	// Process job with retry logic, backing off exponentially
 	ctx := context.Background()
-	var lastErr error
-
-	for attempt := job.GetRetryCount(); attempt <= job.GetMaxRetries(); attempt++ {
-		if err := w.handler.ProcessJob(ctx, job); err == nil {
-			// Success
-			w.metrics.mu.Lock()
-			w.metrics.ProcessedJobs++
-			w.metrics.mu.Unlock()
-
-			log.Printf("Worker %d: Successfully processed job %s (attempt %d)",
-				w.id, job.GetID(), attempt)
-			return
-		} else {
-			lastErr = err
-			if attempt < job.GetMaxRetries() {
-				// Increment retry count
-				job.IncrementRetryCount()
-
-				// Exponential backoff
-				backoff := time.Duration(attempt) * time.Second
-				log.Printf("Worker %d: Failed to process job %s (attempt %d), retrying in %v: %v",
-					w.id, job.GetID(), attempt, backoff, err)
-				time.Sleep(backoff)
-			}
+	err := retry.Retrier{
+		Policy:       retry.Policy{MaxAttempts: job.GetMaxRetries(), BaseDelay: w.retryDelay, MaxDelay: 30 * time.Second},
+		FirstAttempt: job.GetRetryCount(),
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			job.IncrementRetryCount()
+			log.Printf("Worker %d: Failed to process job %s (attempt %d), retrying in %v: %v",
+				w.id, job.GetID(), attempt, delay, err)
+		},
+	}.Do(stop, func(attempt int) error {
+		if err := w.handler.ProcessJob(ctx, job); err != nil {
+			return err
 		}
-	}
+		w.metrics.mu.Lock()
+		w.metrics.ProcessedJobs++
+		w.metrics.mu.Unlock()
 
-	// All attempts failed
-	w.handleJobError(job, lastErr)
+		log.Printf("Worker %d: Successfully processed job %s (attempt %d)",
+			w.id, job.GetID(), attempt)
+		return nil
+	})
+	if err != nil {
+		// All attempts failed
+		w.handleJobError(job, err)
+	}
 }
 
 // handleJobError handles job processing errors